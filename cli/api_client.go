@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/websocket"
+)
+
+// apiClient calls the management API of a running framework
+type apiClient struct {
+	baseURL string
+	token   string
+	// user is name:password for basic auth, used when there is no token
+	user       string
+	tlsConfig  *tls.Config
+	httpClient *http.Client
+	// err is a problem with the connection flags, returned by every call
+	err error
+}
+
+// apiFlags holds the connection flags shared by commands that use the management API
+type apiFlags struct {
+	host  string
+	port  int
+	token string
+	user  string
+	// TLS connects over HTTPS, verified against caFile if set, presenting the
+	// certFile/keyFile client certificate to servers requiring mutual TLS
+	tls      bool
+	caFile   string
+	certFile string
+	keyFile  string
+}
+
+// register adds the connection flags to a command
+func (a *apiFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&a.host, "host", "localhost", "Framework host")
+	cmd.Flags().IntVar(&a.port, "port", 9090, "Framework port")
+	cmd.Flags().StringVar(&a.token, "token", os.Getenv("AGENT_API_TOKEN"), "API token or OIDC token")
+	cmd.Flags().StringVar(&a.user, "user", os.Getenv("AGENT_API_USER"), "Basic auth user as name:password")
+	cmd.Flags().BoolVar(&a.tls, "tls", false, "Connect over HTTPS")
+	cmd.Flags().StringVar(&a.caFile, "ca-file", "", "CA bundle to verify the framework's certificate (implies --tls)")
+	cmd.Flags().StringVar(&a.certFile, "cert-file", "", "Client certificate for mutual TLS (implies --tls)")
+	cmd.Flags().StringVar(&a.keyFile, "key-file", "", "Key of the client certificate")
+}
+
+// client creates an API client from the flags
+func (a *apiFlags) client() *apiClient {
+	client := &apiClient{
+		baseURL:    fmt.Sprintf("http://%s:%d", a.host, a.port),
+		token:      a.token,
+		user:       a.user,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	if a.tls || a.caFile != "" || a.certFile != "" {
+		client.baseURL = fmt.Sprintf("https://%s:%d", a.host, a.port)
+		client.tlsConfig, client.err = core.NewClientTLSConfig(map[string]interface{}{
+			"ca_file":   a.caFile,
+			"cert_file": a.certFile,
+			"key_file":  a.keyFile,
+		})
+		client.httpClient.Transport = &http.Transport{TLSClientConfig: client.tlsConfig}
+	}
+	return client
+}
+
+// do sends a request with an optional JSON body and decodes a JSON response into out
+func (c *apiClient) do(method, path string, body, out interface{}) error {
+	if c.err != nil {
+		return c.err
+	}
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if auth := c.authorization(); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", path, err)
+	}
+	return nil
+}
+
+// stream opens a WebSocket to a streaming endpoint of the management API
+func (c *apiClient) stream(path string) (*websocket.Conn, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	config, err := websocket.NewConfig("ws"+strings.TrimPrefix(c.baseURL, "http")+path, c.baseURL)
+	if err != nil {
+		return nil, err
+	}
+	config.TlsConfig = c.tlsConfig
+	if auth := c.authorization(); auth != "" {
+		config.Header.Set("Authorization", auth)
+	}
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stream %s: %w", path, err)
+	}
+	return conn, nil
+}
+
+// authorization is the Authorization header for the token, or else the basic auth user
+func (c *apiClient) authorization() string {
+	switch {
+	case c.token != "":
+		return "Bearer " + c.token
+	case c.user != "":
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(c.user))
+	default:
+		return ""
+	}
+}
@@ -3,21 +3,35 @@ package cli
 import (
 	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"runtime"
+	"sort"
 	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/habruzzo/agent/config"
+	"github.com/habruzzo/agent/controlplane"
 	"github.com/habruzzo/agent/core"
 	"github.com/spf13/cobra"
 )
 
+// version is the version of the agent
+const version = "0.2.0"
+
 // CLI represents the command-line interface
 type CLI struct {
 	rootCmd *cobra.Command
+	// output is the format selected with --output
+	output string
 }
 
 // NewCLI creates a new CLI instance
@@ -35,8 +49,12 @@ func (c *CLI) setupCommands() {
 		Long: `AI Agent Framework is a modular, extensible system for building AI-powered 
 observability agents. It follows modern software engineering principles including 
 dependency injection, interface-based design, and comprehensive error handling.`,
-		Version: "0.2.0",
+		Version: version,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return c.validateOutput()
+		},
 	}
+	c.rootCmd.PersistentFlags().StringVar(&c.output, "output", outputText, "Output format: text, json, or yaml")
 
 	// Add subcommands
 	c.rootCmd.AddCommand(c.createStartCommand())
@@ -45,24 +63,40 @@ dependency injection, interface-based design, and comprehensive error handling.`
 	c.rootCmd.AddCommand(c.createInteractiveCommand())
 	c.rootCmd.AddCommand(c.createHealthCommand())
 	c.rootCmd.AddCommand(c.createStatusCommand())
+	c.rootCmd.AddCommand(c.createTraceCommand())
+	c.rootCmd.AddCommand(c.createExplainCommand())
+	c.rootCmd.AddCommand(c.createSilenceCommand())
+	c.rootCmd.AddCommand(c.createDryRunCommand())
+	c.rootCmd.AddCommand(c.createRemediationCommand())
+	c.rootCmd.AddCommand(c.createWorkflowCommand())
+	c.rootCmd.AddCommand(c.createPluginCommand())
+	c.rootCmd.AddCommand(c.createIncidentCommand())
+	c.rootCmd.AddCommand(c.createSnapshotCommand())
+	c.rootCmd.AddCommand(c.createKnowledgeCommand())
+	c.rootCmd.AddCommand(c.createTailCommand())
+	c.rootCmd.AddCommand(c.createOpenAPICommand())
 }
 
 // createStartCommand creates the start command
 func (c *CLI) createStartCommand() *cobra.Command {
 	var configFile string
 	var useEnv bool
+	var readOnly bool
+	var dryRun bool
 
 	cmd := &cobra.Command{
 		Use:   "start",
 		Short: "Start the agent framework",
 		Long:  "Start the agent framework with the specified configuration",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return c.startFramework(configFile, useEnv)
+			return c.startFramework(configFile, useEnv, readOnly, dryRun)
 		},
 	}
 
 	cmd.Flags().StringVarP(&configFile, "config", "c", "framework.yaml", "Path to configuration file")
 	cmd.Flags().BoolVarP(&useEnv, "env", "e", false, "Use environment variables for configuration")
+	cmd.Flags().BoolVar(&readOnly, "read-only", false, "Observe only: disable responders with side effects and runbooks")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Simulate responders with side effects and collect a would-have-fired report")
 
 	return cmd
 }
@@ -84,6 +118,7 @@ func (c *CLI) createConfigCommand() *cobra.Command {
 			return createDefaultConfig(outputFile)
 		},
 	}
+	// --output names the file here and on migrate, shadowing the global format flag
 	createCmd.Flags().StringVarP(&outputFile, "output", "o", "framework.yaml", "Output file path")
 
 	validateCmd := &cobra.Command{
@@ -103,7 +138,25 @@ func (c *CLI) createConfigCommand() *cobra.Command {
 		},
 	}
 
-	cmd.AddCommand(createCmd, validateCmd, showCmd)
+	var migrateFile, migrateOutput string
+	var inPlace bool
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade a configuration file to the current schema",
+		Long:  "Rewrite deprecated keys of an older configuration file and report what changed",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if inPlace {
+				migrateOutput = migrateFile
+			}
+			return c.migrateConfig(migrateFile, migrateOutput)
+		},
+	}
+	migrateCmd.Flags().StringVarP(&migrateFile, "config", "c", "framework.yaml", "Configuration file to migrate")
+	migrateCmd.Flags().StringVarP(&migrateOutput, "output", "o", "", "Write the migrated configuration to this file instead of stdout")
+	migrateCmd.Flags().BoolVar(&inPlace, "in-place", false, "Overwrite the configuration file")
+	migrateCmd.MarkFlagsMutuallyExclusive("output", "in-place")
+
+	cmd.AddCommand(createCmd, validateCmd, showCmd, migrateCmd)
 	return cmd
 }
 
@@ -112,9 +165,13 @@ func (c *CLI) createVersionCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "version",
 		Short: "Show version information",
-		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("AI Agent Framework v0.2.0")
-			fmt.Println("Built with Go", "1.21+")
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := versionInfo{Version: version, GoVersion: runtime.Version()}
+			return c.render(info, func() error {
+				fmt.Printf("AI Agent Framework v%s\n", info.Version)
+				fmt.Println("Built with", info.GoVersion)
+				return nil
+			})
 		},
 	}
 }
@@ -146,7 +203,16 @@ func (c *CLI) createHealthCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "health",
 		Short: "Check framework health",
-		Long:  "Check the health status of a running framework instance",
+		Long: `Check the health status of a running framework instance.
+
+The exit status reports the result, for use by monitoring wrappers and cron checks:
+  0  healthy
+  1  degraded: some plugins are failing
+  2  unhealthy
+  3  unreachable
+
+With --output json the result is printed as a single compact JSON line.`,
+		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return c.checkHealth(host, port, timeout)
 		},
@@ -163,24 +229,83 @@ func (c *CLI) createHealthCommand() *cobra.Command {
 func (c *CLI) createStatusCommand() *cobra.Command {
 	var host string
 	var port int
+	var html bool
 
 	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show framework status",
-		Long:  "Show detailed status information about a running framework instance",
+		Long: `Show detailed status information about a running framework instance.
+
+With --html the output is a self-contained HTML status page, e.g.
+  agent status --html > status.html`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return c.showStatus(host, port)
+			if html && c.structured() {
+				return fmt.Errorf("--html cannot be combined with --output %s", c.output)
+			}
+			return c.showStatus(host, port, html)
 		},
 	}
 
 	cmd.Flags().StringVar(&host, "host", "localhost", "Framework host")
 	cmd.Flags().IntVar(&port, "port", 9090, "Framework port")
+	cmd.Flags().BoolVar(&html, "html", false, "Print a static HTML status page")
+
+	return cmd
+}
+
+// createTraceCommand creates the trace command
+func (c *CLI) createTraceCommand() *cobra.Command {
+	var logFile string
+
+	cmd := &cobra.Command{
+		Use:   "trace <trace-id>",
+		Short: "Show how a batch moved through the pipeline",
+		Long: `Reconstruct a single pass through the pipeline from the debug log
+(debug_log_path): the data batch, each analyzer's verdict, incident tracking, and
+every responder decision. Trace IDs appear in analysis details as trace_id.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.showTrace(logFile, args[0])
+		},
+	}
+
+	cmd.Flags().StringVarP(&logFile, "log", "l", "agent-debug.jsonl", "Path to the debug log")
+
+	return cmd
+}
+
+// createExplainCommand creates the explain command
+func (c *CLI) createExplainCommand() *cobra.Command {
+	var api apiFlags
+	var metric string
+	var at string
+
+	cmd := &cobra.Command{
+		Use:   "explain",
+		Short: "Explain why an alert did or didn't fire",
+		Long: `Show how each analyzer judged a metric at a point in time: the window it
+compared against, its mean and standard deviation, the threshold, and the verdict.
+
+  agent explain --metric cpu_usage_percent --at 14:03`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			when, err := parseExplainTime(at, time.Now())
+			if err != nil {
+				return err
+			}
+			return c.explain(api.client(), metric, when)
+		},
+	}
+
+	api.register(cmd)
+	cmd.Flags().StringVarP(&metric, "metric", "m", "", "Metric to explain")
+	cmd.Flags().StringVar(&at, "at", "", "Time to explain: HH:MM, \"YYYY-MM-DD HH:MM\", or RFC 3339 (default now)")
+	cmd.MarkFlagRequired("metric")
 
 	return cmd
 }
 
 // startFramework starts the framework
-func (c *CLI) startFramework(configFile string, useEnv bool) error {
+func (c *CLI) startFramework(configFile string, useEnv, readOnly, dryRun bool) error {
 	var frameworkConfig *core.FrameworkConfig
 	var err error
 
@@ -197,12 +322,18 @@ func (c *CLI) startFramework(configFile string, useEnv bool) error {
 			return fmt.Errorf("failed to load config from file: %w", err)
 		}
 	}
+	if readOnly {
+		frameworkConfig.ReadOnly = true
+	}
+	if dryRun {
+		frameworkConfig.DryRun = true
+	}
 
 	// Create framework
 	framework := core.NewFramework(frameworkConfig)
 
 	// Register plugin creators
-	registerPluginCreators(framework.GetFactory())
+	registerPluginCreators(framework.GetFactory(), frameworkConfig)
 
 	// Load plugins
 	if err := loadPluginsFromConfig(framework, frameworkConfig); err != nil {
@@ -222,10 +353,47 @@ func (c *CLI) startFramework(configFile string, useEnv bool) error {
 		cancel()
 	}()
 
+	// Forward to the fleet coordinator as a remote agent
+	var fleetAgent *controlplane.FleetAgent
+	if frameworkConfig.Fleet.Coordinator != "" {
+		fleetAgent, err = controlplane.NewFleetAgent(framework, frameworkConfig)
+		if err != nil {
+			return fmt.Errorf("failed to join fleet: %w", err)
+		}
+		framework.SetForwarder(fleetAgent)
+	}
+
 	// Start framework
-	if err := framework.Start(ctx); err != nil {
+	if err := startFramework(ctx, framework); err != nil {
 		return fmt.Errorf("failed to start framework: %w", err)
 	}
+	if fleetAgent != nil {
+		fleetAgent.Start(ctx)
+	}
+
+	// Apply changes to the configuration files without a restart
+	if !useEnv && frameworkConfig.ConfigWatchInterval > 0 {
+		manager := config.NewFileConfigurationManager(frameworkConfig.ConfigWatchInterval)
+		defer manager.Close()
+		framework.SetConfigurationManager(manager)
+		if err := framework.WatchConfig(ctx, configFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: configuration changes will not be reloaded: %v\n", err)
+		}
+	}
+
+	// Serve the gRPC control plane alongside the management API
+	if frameworkConfig.GRPCPort > 0 {
+		address := fmt.Sprintf("%s:%d", frameworkConfig.ServerHost, frameworkConfig.GRPCPort)
+		server, err := controlplane.NewServer(framework)
+		if err != nil {
+			return fmt.Errorf("failed to create control plane: %w", err)
+		}
+		go func() {
+			if err := server.Serve(ctx, address); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: control plane stopped: %v\n", err)
+			}
+		}()
+	}
 
 	// Wait for shutdown
 	<-ctx.Done()
@@ -234,11 +402,31 @@ func (c *CLI) startFramework(configFile string, useEnv bool) error {
 	if err := framework.Stop(); err != nil {
 		return fmt.Errorf("failed to stop framework: %w", err)
 	}
+	if fleetAgent != nil {
+		// Forward what the framework drained on shutdown
+		if err := fleetAgent.Stop(frameworkConfig.ShutdownTimeout); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to leave fleet: %v\n", err)
+		}
+	}
 
 	fmt.Println("Framework stopped successfully")
 	return nil
 }
 
+// startFramework starts the framework, reporting plugins that failed to start as warnings
+// since the framework keeps running without them
+func startFramework(ctx context.Context, framework *core.Framework) error {
+	err := framework.Start(ctx)
+	var startErr *core.PluginStartError
+	if !errors.As(err, &startErr) {
+		return err
+	}
+	for _, failure := range startErr.Failures {
+		fmt.Fprintf(os.Stderr, "Warning: plugin %s failed to start after %s: %v\n", failure.Plugin, failure.Duration.Round(time.Millisecond), failure.Err)
+	}
+	return nil
+}
+
 // startInteractive starts the framework in interactive mode
 func (c *CLI) startInteractive(configFile string) error {
 	// Load configuration
@@ -251,7 +439,7 @@ func (c *CLI) startInteractive(configFile string) error {
 	framework := core.NewFramework(frameworkConfig)
 
 	// Register plugin creators
-	registerPluginCreators(framework.GetFactory())
+	registerPluginCreators(framework.GetFactory(), frameworkConfig)
 
 	// Load plugins
 	if err := loadPluginsFromConfig(framework, frameworkConfig); err != nil {
@@ -262,7 +450,7 @@ func (c *CLI) startInteractive(configFile string) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	if err := framework.Start(ctx); err != nil {
+	if err := startFramework(ctx, framework); err != nil {
 		return fmt.Errorf("failed to start framework: %w", err)
 	}
 
@@ -343,38 +531,278 @@ func (c *CLI) showPluginStatus(framework *core.Framework) {
 	}
 }
 
+// processQuery prints the default agent's answer as it is generated
 func (c *CLI) processQuery(framework *core.Framework, query string) {
-	response, err := framework.QueryDefaultAgent(context.Background(), query)
+	agent := framework.DefaultAgent()
+	if agent == "" {
+		fmt.Println("Error: no default agent configured")
+		return
+	}
+
+	fmt.Print("Response: ")
+	response, err := framework.StreamAgentQuery(context.Background(), agent, query, func(chunk string) error {
+		fmt.Print(chunk)
+		return nil
+	})
+	fmt.Println()
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		return
 	}
 
-	fmt.Printf("Response: %s\n", response.Response)
 	if response.Confidence < 0.8 {
 		fmt.Printf("Confidence: %.2f (low confidence)\n", response.Confidence)
 	}
 }
 
-// checkHealth checks the health of a running framework
+// Exit codes of the health command, following the Nagios plugin convention
+const (
+	healthExitHealthy     = 0
+	healthExitDegraded    = 1
+	healthExitUnhealthy   = 2
+	healthExitUnreachable = 3
+)
+
+// checkHealth checks the health and readiness endpoints of a running framework. It prints
+// one line, a compact JSON object with --output json, and fails with the exit code of the
+// health status unless the framework is healthy.
 func (c *CLI) checkHealth(host string, port int, timeout time.Duration) error {
-	// Implementation would make HTTP request to health endpoint
-	fmt.Printf("Checking health at %s:%d (timeout: %v)\n", host, port, timeout)
-	// TODO: Implement actual health check
-	return nil
+	report := fetchHealth(fmt.Sprintf("http://%s:%d", host, port), timeout)
+
+	if c.output == outputJSON {
+		line, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(line))
+	} else {
+		err := c.render(report, func() error {
+			line := fmt.Sprintf("%s: %s", strings.ToUpper(report.Status), report.Endpoint)
+			if report.Status != "unreachable" && !report.Ready {
+				line += " (not ready)"
+			}
+			if report.Message != "" {
+				line += ": " + report.Message
+			}
+			if len(report.Failing) > 0 {
+				line += " [" + strings.Join(report.Failing, ", ") + "]"
+			}
+			fmt.Println(line)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if report.Code == healthExitHealthy {
+		return nil
+	}
+	return &ExitError{Code: report.Code, Err: fmt.Errorf("framework at %s is %s", report.Endpoint, report.Status)}
 }
 
-// showStatus shows the status of a running framework
-func (c *CLI) showStatus(host string, port int) error {
-	// Implementation would make HTTP request to status endpoint
-	fmt.Printf("Getting status from %s:%d\n", host, port)
-	// TODO: Implement actual status check
-	return nil
+// fetchHealth reads the health status and readiness of a framework
+func fetchHealth(endpoint string, timeout time.Duration) healthReport {
+	report := healthReport{Endpoint: endpoint, CheckedAt: time.Now().UTC()}
+	client := &http.Client{Timeout: timeout}
+
+	resp, err := client.Get(endpoint + "/health")
+	if err != nil {
+		report.Status = "unreachable"
+		report.Code = healthExitUnreachable
+		report.Message = err.Error()
+		return report
+	}
+	var health core.HealthStatus
+	decodeErr := json.NewDecoder(resp.Body).Decode(&health)
+	resp.Body.Close()
+
+	switch {
+	case decodeErr == nil && health.Status != "":
+		report.Status = health.Status
+		report.Message = health.Message
+		for name, check := range health.Checks {
+			if check.Status != "healthy" {
+				report.Failing = append(report.Failing, name)
+			}
+		}
+		sort.Strings(report.Failing)
+	case resp.StatusCode == http.StatusOK:
+		// Older frameworks answer with a plain OK
+		report.Status = "healthy"
+	default:
+		report.Status = "unhealthy"
+		report.Message = resp.Status
+	}
+
+	switch report.Status {
+	case "healthy":
+		report.Code = healthExitHealthy
+	case "degraded":
+		report.Code = healthExitDegraded
+	default:
+		report.Code = healthExitUnhealthy
+	}
+
+	if resp, err := client.Get(endpoint + "/ready"); err == nil {
+		resp.Body.Close()
+		report.Ready = resp.StatusCode == http.StatusOK
+	}
+	return report
+}
+
+// showStatus shows the status of a running framework, as JSON or as an HTML status page
+func (c *CLI) showStatus(host string, port int, html bool) error {
+	path := "/status"
+	if html {
+		path = "/status.html"
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://%s:%d%s", host, port, path))
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get status: %s", resp.Status)
+	}
+
+	if html {
+		_, err = io.Copy(os.Stdout, resp.Body)
+		return err
+	}
+
+	var status core.FrameworkStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return fmt.Errorf("invalid status response: %w", err)
+	}
+	return c.render(status, func() error {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "Running\t%t\n", status.Running)
+		fmt.Fprintf(w, "Uptime\t%s\n", status.Uptime)
+		fmt.Fprintf(w, "Read only\t%t\n", status.ReadOnly)
+		fmt.Fprintf(w, "Dry run\t%t\n", status.DryRun)
+		fmt.Fprintf(w, "Plugins\t%d (%d collectors, %d analyzers, %d responders, %d agents)\n",
+			status.TotalPlugins, status.Collectors, status.Analyzers, status.Responders, status.Agents)
+		names := make([]string, 0, len(status.Plugins))
+		for name := range status.Plugins {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if plugin := status.Plugins[name]; plugin.StartError != "" {
+				fmt.Fprintf(w, "Failed to start\t%s: %s\n", name, plugin.StartError)
+			}
+		}
+		return w.Flush()
+	})
+}
+
+// showTrace prints the debug log events of one trace
+func (c *CLI) showTrace(logFile, traceID string) error {
+	file, err := os.Open(logFile)
+	if err != nil {
+		return fmt.Errorf("failed to open debug log: %w", err)
+	}
+	defer file.Close()
+
+	events, err := core.ReadTrace(file, traceID)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return fmt.Errorf("trace %s not found in %s", traceID, logFile)
+	}
+
+	return c.render(events, func() error {
+		fmt.Printf("Trace %s\n", traceID)
+		for _, event := range events {
+			line := fmt.Sprintf("%s  %-9s  %s", event.Timestamp.Format("15:04:05.000"), event.Stage, event.Decision)
+			if event.Plugin != "" {
+				line += fmt.Sprintf(" (%s)", event.Plugin)
+			}
+			if event.Reason != "" {
+				line += ": " + event.Reason
+			}
+			if points, ok := event.Data["data_points"].([]interface{}); ok {
+				line += fmt.Sprintf(": %d data points", len(points))
+			}
+			fmt.Println(line)
+		}
+		return nil
+	})
+}
+
+// parseExplainTime parses the --at flag. A bare time of day refers to the most recent
+// occurrence of that time.
+func parseExplainTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return now, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", value, now.Location()); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		clock, err := time.ParseInLocation(layout, value, now.Location())
+		if err != nil {
+			continue
+		}
+		t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, now.Location())
+		if t.After(now) {
+			t = t.AddDate(0, 0, -1)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use HH:MM, \"YYYY-MM-DD HH:MM\", or RFC 3339", value)
+}
+
+// explain prints each analyzer's verdict for a metric at a point in time
+func (c *CLI) explain(client *apiClient, metric string, at time.Time) error {
+	query := url.Values{"metric": {metric}, "at": {at.Format(time.RFC3339)}}
+	var result struct {
+		Metric   string         `json:"metric"`
+		At       time.Time      `json:"at"`
+		Verdicts []core.Verdict `json:"verdicts"`
+	}
+	if err := client.do(http.MethodGet, "/api/v1/explain?"+query.Encode(), nil, &result); err != nil {
+		return err
+	}
+
+	return c.render(result, func() error {
+		if len(result.Verdicts) == 0 {
+			fmt.Printf("No analyzer has judged %s; it may not be collected, or the time is outside the retention window\n", metric)
+			return nil
+		}
+
+		for _, verdict := range result.Verdicts {
+			fmt.Printf("%s  %s  at %s\n", verdict.Analyzer, verdict.Series, verdict.Timestamp.Local().Format("2006-01-02 15:04:05"))
+			fmt.Printf("  value      %g\n", verdict.Value)
+			fmt.Printf("  window     %d samples, mean %.4g, stddev %.4g\n", verdict.WindowSize, verdict.Mean, verdict.StdDev)
+			fmt.Printf("  threshold  %.2fσ (deviation %.2fσ)\n", verdict.Threshold, verdict.Deviation)
+			fmt.Printf("  verdict    %s: %s\n\n", verdict.Verdict, verdict.Reason)
+		}
+		return nil
+	})
 }
 
 // validateConfig validates a configuration file
 func (c *CLI) validateConfig(configFile string) error {
 	_, err := config.LoadConfig(configFile)
+	if c.structured() {
+		result := configValidation{File: configFile, Valid: err == nil}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		if renderErr := writeStructured(os.Stdout, c.output, result); renderErr != nil {
+			return renderErr
+		}
+		return err
+	}
 	if err != nil {
 		fmt.Printf("Configuration validation failed: %v\n", err)
 		return err
@@ -383,6 +811,46 @@ func (c *CLI) validateConfig(configFile string) error {
 	return nil
 }
 
+// migrateConfig upgrades a configuration file, writing the result to output or stdout and
+// the report to stderr
+func (c *CLI) migrateConfig(configFile, output string) error {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	migrated, report, err := config.MigrateConfig(data)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range report.Renamed {
+		fmt.Fprintf(os.Stderr, "renamed   %s -> %s\n", change.From, change.To)
+	}
+	for _, change := range report.Conflicts {
+		fmt.Fprintf(os.Stderr, "dropped   %s (%s is already set)\n", change.From, change.To)
+	}
+	for _, key := range report.Unknown {
+		fmt.Fprintf(os.Stderr, "unknown   %s (kept, but ignored by the loader)\n", key)
+	}
+	if !report.Changed() {
+		fmt.Fprintln(os.Stderr, "Configuration is already up to date")
+	}
+
+	if output == "" {
+		_, err := os.Stdout.Write(migrated)
+		return err
+	}
+	if output == configFile && !report.Changed() {
+		return nil
+	}
+	if err := os.WriteFile(output, migrated, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	fmt.Fprintf(os.Stderr, "Migrated configuration written to %s\n", output)
+	return nil
+}
+
 // showConfig shows the current configuration
 func (c *CLI) showConfig() error {
 	frameworkConfig, err := config.LoadConfigFromEnv()
@@ -391,8 +859,10 @@ func (c *CLI) showConfig() error {
 	}
 
 	summary := config.GetConfigSummary(frameworkConfig)
-	fmt.Println("Current Configuration:")
-	// TODO: Pretty print the configuration summary
-	fmt.Printf("%+v\n", summary)
-	return nil
+	return c.render(summary, func() error {
+		fmt.Println("Current Configuration:")
+		// TODO: Pretty print the configuration summary
+		fmt.Printf("%+v\n", summary)
+		return nil
+	})
 }
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"text/tabwriter"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/spf13/cobra"
+)
+
+// createDryRunCommand creates the dry-run command, which prints the would-have-fired
+// report of a framework started with --dry-run
+func (c *CLI) createDryRunCommand() *cobra.Command {
+	var api apiFlags
+	var showEntries bool
+	var showPayloads bool
+
+	cmd := &cobra.Command{
+		Use:   "dry-run",
+		Short: "Show what responders would have done in dry-run mode",
+		Long: `Prints how often each responder would have acted on each analyzer's analyses
+since a framework started with --dry-run, for tuning before it is allowed to act.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var summary core.DryRunSummary
+			if err := api.client().do(http.MethodGet, "/api/v1/dry-run", nil, &summary); err != nil {
+				return err
+			}
+
+			return c.render(summary, func() error {
+				if summary.Total == 0 {
+					fmt.Printf("No responder would have fired since %s\n", summary.Since.Local().Format("2006-01-02 15:04"))
+					return nil
+				}
+
+				fmt.Printf("%d would-have-fired actions since %s\n\n", summary.Total, summary.Since.Local().Format("2006-01-02 15:04"))
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "RESPONDER\tANALYZER\tCOUNT")
+				for _, count := range summary.Counts {
+					fmt.Fprintf(w, "%s\t%s\t%d\n", count.Responder, count.Source, count.Count)
+				}
+				w.Flush()
+
+				if !showEntries && !showPayloads {
+					return nil
+				}
+				fmt.Println()
+				for _, entry := range summary.Entries {
+					fmt.Printf("%s  %s %s %s  [%s] %s (%s)\n", entry.Time.Local().Format("15:04:05"),
+						entry.Responder, entry.Action, entry.Target, entry.Severity, entry.Summary, entry.AnalysisID)
+					if showPayloads && entry.Payload != nil {
+						payload, err := json.MarshalIndent(entry.Payload, "    ", "  ")
+						if err != nil {
+							return err
+						}
+						fmt.Printf("    %s\n", payload)
+					}
+				}
+				return nil
+			})
+		},
+	}
+
+	api.register(cmd)
+	cmd.Flags().BoolVar(&showEntries, "entries", false, "List the most recent simulated actions")
+	cmd.Flags().BoolVar(&showPayloads, "payloads", false, "List the simulated actions with their payloads")
+	return cmd
+}
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"text/tabwriter"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/spf13/cobra"
+)
+
+// createIncidentCommand creates the incident command and its subcommands
+func (c *CLI) createIncidentCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "incident",
+		Short: "Inspect incidents tracked by a running framework",
+	}
+
+	cmd.AddCommand(c.createIncidentListCommand())
+	cmd.AddCommand(c.createIncidentShowCommand())
+	return cmd
+}
+
+// fetchIncidents lists incidents, optionally only those of one fingerprint
+func fetchIncidents(client *apiClient, fingerprint string) ([]core.Incident, error) {
+	path := "/api/v1/incidents"
+	if fingerprint != "" {
+		path += "?" + url.Values{"fingerprint": {fingerprint}}.Encode()
+	}
+	var incidents []core.Incident
+	if err := client.do(http.MethodGet, path, nil, &incidents); err != nil {
+		return nil, err
+	}
+	return incidents, nil
+}
+
+// createIncidentListCommand creates the incident list command
+func (c *CLI) createIncidentListCommand() *cobra.Command {
+	var api apiFlags
+	var fingerprint string
+	var openOnly bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List incidents",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			incidents, err := fetchIncidents(api.client(), fingerprint)
+			if err != nil {
+				return err
+			}
+			if openOnly {
+				unresolved := make([]core.Incident, 0, len(incidents))
+				for _, incident := range incidents {
+					if incident.Status != core.IncidentStatusResolved {
+						unresolved = append(unresolved, incident)
+					}
+				}
+				incidents = unresolved
+			}
+
+			return c.render(incidents, func() error {
+				if len(incidents) == 0 {
+					fmt.Println("No incidents")
+					return nil
+				}
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "ID\tSTATUS\tSEVERITY\tOCCURRENCES\tOPENED\tUPDATED\tSUMMARY")
+				for _, incident := range incidents {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+						incident.ID, incident.Status, incident.Severity, incident.Occurrences,
+						incident.OpenedAt.Local().Format("2006-01-02 15:04"), incident.UpdatedAt.Local().Format("2006-01-02 15:04"),
+						incident.Summary)
+				}
+				return w.Flush()
+			})
+		},
+	}
+
+	api.register(cmd)
+	cmd.Flags().StringVar(&fingerprint, "fingerprint", "", "Only list incidents of this fingerprint")
+	cmd.Flags().BoolVar(&openOnly, "open", false, "Only list incidents that are not resolved")
+	return cmd
+}
+
+// createIncidentShowCommand creates the incident show command
+func (c *CLI) createIncidentShowCommand() *cobra.Command {
+	var api apiFlags
+
+	cmd := &cobra.Command{
+		Use:   "show <incident-id>",
+		Short: "Show an incident and its timeline",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			incidents, err := fetchIncidents(api.client(), "")
+			if err != nil {
+				return err
+			}
+			for _, incident := range incidents {
+				if incident.ID != args[0] {
+					continue
+				}
+				return c.render(incident, func() error {
+					fmt.Printf("%s  %s  [%s] %s\n", incident.ID, incident.Status, incident.Severity, incident.Summary)
+					fmt.Printf("  fingerprint  %s\n", incident.Fingerprint)
+					fmt.Printf("  source       %s\n", incident.Source)
+					fmt.Printf("  occurrences  %d\n", incident.Occurrences)
+					if incident.AcknowledgedBy != "" {
+						fmt.Printf("  acked by     %s\n", incident.AcknowledgedBy)
+					}
+					fmt.Println()
+					for _, event := range incident.Timeline {
+						line := fmt.Sprintf("  %s  %-16s  %s", event.Timestamp.Local().Format("2006-01-02 15:04:05"), event.Type, event.Message)
+						if event.Actor != "" {
+							line += fmt.Sprintf(" (%s)", event.Actor)
+						}
+						fmt.Println(line)
+					}
+					return nil
+				})
+			}
+			return fmt.Errorf("incident %s not found", args[0])
+		},
+	}
+
+	api.register(cmd)
+	return cmd
+}
@@ -0,0 +1,337 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/spf13/cobra"
+)
+
+// kbPreviewLength is how much of a document's content listings show
+const kbPreviewLength = 60
+
+// createKnowledgeCommand creates the kb command and its subcommands
+func (c *CLI) createKnowledgeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "kb",
+		Short: "Curate the knowledge base of a RAG agent",
+		Long: `The knowledge base holds the documents a RAG agent answers from, such as
+runbooks and postmortems. Documents can be added, listed, searched, and deleted
+on a running agent. Each command uses the default agent unless --agent is given.`,
+	}
+
+	cmd.AddCommand(c.createKnowledgeAddCommand())
+	cmd.AddCommand(c.createKnowledgeListCommand())
+	cmd.AddCommand(c.createKnowledgeDeleteCommand())
+	cmd.AddCommand(c.createKnowledgeSearchCommand())
+	cmd.AddCommand(c.createKnowledgeStatsCommand())
+	return cmd
+}
+
+// createKnowledgeAddCommand creates the kb add command
+func (c *CLI) createKnowledgeAddCommand() *cobra.Command {
+	var api apiFlags
+	var agent string
+	var id string
+	var docType string
+	var source string
+	var metadata []string
+
+	cmd := &cobra.Command{
+		Use:   "add <file>...",
+		Short: "Add text files to the knowledge base",
+		Long: `Adds each file as one document, with the file's path as its ID unless --id is
+given. A document with the same ID is replaced. Use - to read a document from
+standard input, which needs --id. Long runbooks are better split into chunks by
+the agent's knowledge_base_path ingestion.`,
+		Example: `  agent kb add runbooks/disk-full.md --metadata service=storage
+  kubectl describe pod checkout-7d9 | agent kb add - --id checkout-pod --type note`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if id != "" && len(args) > 1 {
+				return fmt.Errorf("--id can only be given with one file")
+			}
+			extra := make(map[string]interface{}, len(metadata))
+			for _, entry := range metadata {
+				key, value, ok := strings.Cut(entry, "=")
+				if !ok || key == "" {
+					return fmt.Errorf("invalid metadata %q, expected key=value", entry)
+				}
+				extra[key] = value
+			}
+
+			docs := make([]core.KnowledgeDocument, 0, len(args))
+			for _, file := range args {
+				var content []byte
+				var err error
+				docID, docSource := id, source
+				if file == "-" {
+					if id == "" {
+						return fmt.Errorf("--id is required when reading standard input")
+					}
+					content, err = io.ReadAll(os.Stdin)
+				} else {
+					content, err = os.ReadFile(file)
+					if docID == "" {
+						docID = filepath.ToSlash(filepath.Clean(file))
+					}
+					if docSource == "" {
+						docSource = filepath.ToSlash(filepath.Clean(file))
+					}
+				}
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %w", file, err)
+				}
+				if strings.TrimSpace(string(content)) == "" {
+					return fmt.Errorf("%s is empty", file)
+				}
+
+				docMetadata := map[string]interface{}{"type": docType}
+				if docSource != "" {
+					docMetadata["source"] = docSource
+				}
+				for key, value := range extra {
+					docMetadata[key] = value
+				}
+				docs = append(docs, core.KnowledgeDocument{ID: docID, Content: string(content), Metadata: docMetadata})
+			}
+
+			request := map[string]interface{}{"agent": agent, "documents": docs}
+			var added []core.KnowledgeDocument
+			if err := api.client().do(http.MethodPost, "/api/v1/knowledge", request, &added); err != nil {
+				return err
+			}
+			return c.render(added, func() error {
+				for _, doc := range added {
+					fmt.Printf("Added %s\n", doc.ID)
+				}
+				return nil
+			})
+		},
+	}
+
+	api.register(cmd)
+	cmd.Flags().StringVar(&agent, "agent", "", "Agent whose knowledge base to change (default the configured default agent)")
+	cmd.Flags().StringVar(&id, "id", "", "Document ID (default the file's path)")
+	cmd.Flags().StringVar(&docType, "type", "runbook", "Document type, stored in its metadata")
+	cmd.Flags().StringVar(&source, "source", "", "Source cited in answers (default the file's path)")
+	cmd.Flags().StringArrayVar(&metadata, "metadata", nil, "Metadata as key=value (repeatable)")
+	return cmd
+}
+
+// createKnowledgeListCommand creates the kb list command
+func (c *CLI) createKnowledgeListCommand() *cobra.Command {
+	var api apiFlags
+	var agent string
+	var limit int
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the documents in the knowledge base, ordered by ID",
+		Example: `  agent kb list --limit 20
+  agent kb list --all --output json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := api.client()
+			var docs []core.KnowledgeDocument
+			offset := ""
+			for {
+				query := url.Values{"limit": {fmt.Sprint(limit)}}
+				if agent != "" {
+					query.Set("agent", agent)
+				}
+				if offset != "" {
+					query.Set("offset", offset)
+				}
+				var page core.KnowledgePage
+				if err := client.do(http.MethodGet, "/api/v1/knowledge?"+query.Encode(), nil, &page); err != nil {
+					return err
+				}
+				docs = append(docs, page.Documents...)
+				offset = page.Next
+				if !all || offset == "" {
+					break
+				}
+			}
+
+			result := core.KnowledgePage{Documents: docs, Next: offset}
+			if result.Documents == nil {
+				result.Documents = []core.KnowledgeDocument{}
+			}
+			return c.render(result, func() error {
+				if len(docs) == 0 {
+					fmt.Println("No documents")
+					return nil
+				}
+
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "ID\tTYPE\tSOURCE\tUPDATED\tCONTENT")
+				for _, doc := range docs {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", doc.ID, metadataString(doc, "type"), metadataString(doc, "source"),
+						doc.Timestamp.Local().Format("2006-01-02 15:04"), preview(doc.Content))
+				}
+				if err := w.Flush(); err != nil {
+					return err
+				}
+				if offset != "" {
+					fmt.Println("More documents follow; use --all to list them")
+				}
+				return nil
+			})
+		},
+	}
+
+	api.register(cmd)
+	cmd.Flags().StringVar(&agent, "agent", "", "Agent whose knowledge base to list (default the configured default agent)")
+	cmd.Flags().IntVar(&limit, "limit", 100, "Documents fetched per request")
+	cmd.Flags().BoolVar(&all, "all", false, "List every document rather than the first page")
+	return cmd
+}
+
+// createKnowledgeDeleteCommand creates the kb delete command
+func (c *CLI) createKnowledgeDeleteCommand() *cobra.Command {
+	var api apiFlags
+	var agent string
+
+	cmd := &cobra.Command{
+		Use:     "delete <id>...",
+		Short:   "Delete documents from the knowledge base",
+		Example: `  agent kb delete runbooks/disk-full.md checkout-pod`,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := api.client()
+			query := ""
+			if agent != "" {
+				query = "?" + url.Values{"agent": {agent}}.Encode()
+			}
+			results := make([]actionResult, 0, len(args))
+			for _, id := range args {
+				if err := client.do(http.MethodDelete, "/api/v1/knowledge/"+url.PathEscape(id)+query, nil, nil); err != nil {
+					return err
+				}
+				results = append(results, actionResult{Action: "deleted", Target: id})
+			}
+			return c.render(results, func() error {
+				for _, id := range args {
+					fmt.Printf("Deleted %s\n", id)
+				}
+				return nil
+			})
+		},
+	}
+
+	api.register(cmd)
+	cmd.Flags().StringVar(&agent, "agent", "", "Agent whose knowledge base to change (default the configured default agent)")
+	return cmd
+}
+
+// createKnowledgeSearchCommand creates the kb search command
+func (c *CLI) createKnowledgeSearchCommand() *cobra.Command {
+	var api apiFlags
+	var agent string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Show the documents the agent would answer a question from",
+		Long: `Retrieves documents the way the agent does for a question, most relevant
+first, to check that the knowledge base covers it.`,
+		Example: `  agent kb search "How do I free space on a full disk?"`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := url.Values{"q": {args[0]}, "limit": {fmt.Sprint(limit)}}
+			if agent != "" {
+				query.Set("agent", agent)
+			}
+			var docs []core.KnowledgeDocument
+			if err := api.client().do(http.MethodGet, "/api/v1/knowledge/search?"+query.Encode(), nil, &docs); err != nil {
+				return err
+			}
+
+			return c.render(docs, func() error {
+				if len(docs) == 0 {
+					fmt.Println("No relevant documents")
+					return nil
+				}
+				for i, doc := range docs {
+					fmt.Printf("%d. %s", i+1, doc.ID)
+					if source := metadataString(doc, "source"); source != "" && source != doc.ID {
+						fmt.Printf(" (%s)", source)
+					}
+					fmt.Printf("\n   %s\n", preview(doc.Content))
+				}
+				return nil
+			})
+		},
+	}
+
+	api.register(cmd)
+	cmd.Flags().StringVar(&agent, "agent", "", "Agent whose knowledge base to search (default the configured default agent)")
+	cmd.Flags().IntVar(&limit, "limit", 5, "Most documents shown")
+	return cmd
+}
+
+// createKnowledgeStatsCommand creates the kb stats command
+func (c *CLI) createKnowledgeStatsCommand() *cobra.Command {
+	var api apiFlags
+	var agent string
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show how many documents the knowledge base holds, per category",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "/api/v1/knowledge/stats"
+			if agent != "" {
+				path += "?" + url.Values{"agent": {agent}}.Encode()
+			}
+			var stats map[string]interface{}
+			if err := api.client().do(http.MethodGet, path, nil, &stats); err != nil {
+				return err
+			}
+
+			return c.render(stats, func() error {
+				fmt.Printf("%v documents, %v waiting to be embedded\n", stats["total_documents"], stats["pending_documents"])
+				categories, _ := stats["categories"].(map[string]interface{})
+				names := make([]string, 0, len(categories))
+				for name := range categories {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				for _, name := range names {
+					fmt.Fprintf(w, "  %s\t%v\n", name, categories[name])
+				}
+				return w.Flush()
+			})
+		},
+	}
+
+	api.register(cmd)
+	cmd.Flags().StringVar(&agent, "agent", "", "Agent whose knowledge base to describe (default the configured default agent)")
+	return cmd
+}
+
+// metadataString returns a metadata value of a document as text
+func metadataString(doc core.KnowledgeDocument, key string) string {
+	if value, ok := doc.Metadata[key]; ok && value != nil {
+		return fmt.Sprint(value)
+	}
+	return ""
+}
+
+// preview shortens content to one line for listings
+func preview(content string) string {
+	text := strings.Join(strings.Fields(content), " ")
+	if runes := []rune(text); len(runes) > kbPreviewLength {
+		return string(runes[:kbPreviewLength-3]) + "..."
+	}
+	return text
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/spf13/cobra"
+)
+
+// createOpenAPICommand creates the openapi command, which writes the OpenAPI document of
+// the management API for generating clients
+func (c *CLI) createOpenAPICommand() *cobra.Command {
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "openapi",
+		Short: "Write the OpenAPI document of the management API",
+		Long: `Writes the OpenAPI 3 document describing the management API, from which
+clients can be generated. A running framework serves the same document at
+/api/v1/openapi.json.
+
+  agent openapi --file agent-api.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			spec := core.OpenAPISpec()
+			if file == "" {
+				return c.render(spec, func() error {
+					return writeStructured(os.Stdout, outputJSON, spec)
+				})
+			}
+
+			encoded, err := json.MarshalIndent(spec, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode OpenAPI document: %w", err)
+			}
+			if err := os.WriteFile(file, append(encoded, '\n'), 0o644); err != nil {
+				return fmt.Errorf("failed to write OpenAPI document: %w", err)
+			}
+			fmt.Printf("OpenAPI document written to %s\n", file)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Write the document to a file instead of stdout")
+	return cmd
+}
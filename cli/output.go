@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats selected with the global --output flag
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// actionResult is the structured output of commands that change something and have
+// nothing else to report
+type actionResult struct {
+	Action string `json:"action"`
+	Target string `json:"target"`
+}
+
+// versionInfo is the structured output of the version command
+type versionInfo struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"go_version"`
+}
+
+// healthReport is the structured output of the health command
+type healthReport struct {
+	// Status is healthy, degraded, unhealthy, or unreachable
+	Status    string    `json:"status"`
+	Code      int       `json:"code"`
+	Endpoint  string    `json:"endpoint"`
+	Ready     bool      `json:"ready"`
+	Message   string    `json:"message,omitempty"`
+	Failing   []string  `json:"failing,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// configValidation is the structured output of the config validate command
+type configValidation struct {
+	File  string `json:"file"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// ExitError is returned by commands whose outcome is reported through the exit status
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the process exit status for an error returned by Execute
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return 1
+}
+
+// validateOutput checks the --output flag
+func (c *CLI) validateOutput() error {
+	switch c.output {
+	case outputText, outputJSON, outputYAML:
+		return nil
+	default:
+		return fmt.Errorf("invalid output format %q: use text, json, or yaml", c.output)
+	}
+}
+
+// structured reports whether a machine-readable output format was selected
+func (c *CLI) structured() bool {
+	return c.output == outputJSON || c.output == outputYAML
+}
+
+// render prints v in the selected structured format, or calls text for the
+// human-readable output
+func (c *CLI) render(v interface{}, text func() error) error {
+	if !c.structured() {
+		return text()
+	}
+	return writeStructured(os.Stdout, c.output, v)
+}
+
+// writeStructured writes v as indented JSON or as YAML. YAML is converted from the JSON
+// encoding, so both formats use the same field names and omit the same empty fields.
+func writeStructured(w io.Writer, format string, v interface{}) error {
+	encoded, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	if format == outputJSON {
+		_, err := fmt.Fprintf(w, "%s\n", encoded)
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	out, err := yaml.Marshal(yamlNumbers(generic))
+	if err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// yamlNumbers replaces JSON numbers with integers or floats, which YAML would otherwise
+// quote as strings
+func yamlNumbers(v interface{}) interface{} {
+	switch value := v.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		f, _ := value.Float64()
+		return f
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = yamlNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = yamlNumbers(item)
+		}
+	}
+	return v
+}
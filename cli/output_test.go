@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteStructured(t *testing.T) {
+	value := struct {
+		Name    string   `json:"name"`
+		Count   int      `json:"count"`
+		Ratio   float64  `json:"ratio"`
+		Tags    []string `json:"tags"`
+		Missing string   `json:"missing,omitempty"`
+	}{Name: "checkout", Count: 3, Ratio: 0.5, Tags: []string{"web"}}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{outputJSON, "{\n  \"name\": \"checkout\",\n  \"count\": 3,\n  \"ratio\": 0.5,\n  \"tags\": [\n    \"web\"\n  ]\n}\n"},
+		{outputYAML, "count: 3\nname: checkout\nratio: 0.5\ntags:\n    - web\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, writeStructured(&out, tt.format, value))
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestYAMLNumbers(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  interface{}
+	}{
+		{"integer", json.Number("42"), int64(42)},
+		{"float", json.Number("1.25"), 1.25},
+		{"nested", map[string]interface{}{"items": []interface{}{json.Number("1"), "a"}},
+			map[string]interface{}{"items": []interface{}{int64(1), "a"}}},
+		{"string", "7", "7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, yamlNumbers(tt.value))
+		})
+	}
+}
+
+func TestWorkflowStart_Output(t *testing.T) {
+	var request startWorkflowBody
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/workflows/restart-api/start", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		json.NewEncoder(w).Encode(core.Remediation{ID: "rem-1", Status: core.RemediationStatusExecuted, WorkflowID: "restart-api"})
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	host, port, err := net.SplitHostPort(serverURL.Host)
+	require.NoError(t, err)
+
+	tests := []struct {
+		output string
+		check  func(t *testing.T, out string)
+	}{
+		{outputText, func(t *testing.T, out string) {
+			assert.Equal(t, "rem-1 executed\n", out)
+		}},
+		{outputJSON, func(t *testing.T, out string) {
+			var remediation core.Remediation
+			require.NoError(t, json.Unmarshal([]byte(out), &remediation))
+			assert.Equal(t, "rem-1", remediation.ID)
+			assert.Equal(t, "restart-api", remediation.WorkflowID)
+		}},
+		{outputYAML, func(t *testing.T, out string) {
+			assert.Contains(t, out, "id: rem-1\n")
+			assert.Contains(t, out, "workflow_id: restart-api\n")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			out, err := runCLI(t, "workflow", "start", "restart-api", "--host", host, "--port", port,
+				"--author", "alice", "--input", "reason=deploy", "--output", tt.output)
+			require.NoError(t, err)
+			tt.check(t, out)
+			assert.Equal(t, startWorkflowBody{Actor: "alice", Input: map[string]string{"reason": "deploy"}}, request)
+		})
+	}
+}
+
+func TestOutput_Invalid(t *testing.T) {
+	_, err := runCLI(t, "version", "--output", "xml")
+	assert.ErrorContains(t, err, `invalid output format "xml"`)
+}
+
+// startWorkflowBody is the body the workflow start command sends
+type startWorkflowBody struct {
+	Actor   string            `json:"actor"`
+	Service string            `json:"service"`
+	Input   map[string]string `json:"input"`
+}
+
+// runCLI runs the CLI with args and returns what it printed to standard output
+func runCLI(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(reader)
+		output <- string(out)
+	}()
+
+	c := NewCLI()
+	c.rootCmd.SetArgs(args)
+	c.rootCmd.SilenceUsage = true
+	c.rootCmd.SilenceErrors = true
+	err = c.Execute()
+	writer.Close()
+	return <-output, err
+}
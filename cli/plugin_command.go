@@ -0,0 +1,249 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// createPluginCommand creates the plugin command and its subcommands
+func (c *CLI) createPluginCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plugin",
+		Short: "Manage the plugins of a running framework",
+	}
+
+	cmd.AddCommand(c.createPluginListCommand())
+	cmd.AddCommand(c.createPluginConfigCommand())
+	cmd.AddCommand(c.createPluginReconfigureCommand())
+	cmd.AddCommand(c.createPluginLoadCommand())
+	cmd.AddCommand(c.createPluginUnloadCommand())
+	return cmd
+}
+
+// createPluginListCommand creates the plugin list command
+func (c *CLI) createPluginListCommand() *cobra.Command {
+	var api apiFlags
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List loaded plugins with their status and health",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var plugins []core.PluginState
+			if err := api.client().do(http.MethodGet, "/api/v1/plugins", nil, &plugins); err != nil {
+				return err
+			}
+
+			return c.render(plugins, func() error {
+				if len(plugins) == 0 {
+					fmt.Println("No plugins")
+					return nil
+				}
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "NAME\tTYPE\tSTATUS\tHEALTH")
+				for _, plugin := range plugins {
+					health := "ok"
+					if !plugin.Healthy {
+						health = plugin.Error
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", plugin.Name, plugin.Type, plugin.Status, health)
+				}
+				return w.Flush()
+			})
+		},
+	}
+
+	api.register(cmd)
+	return cmd
+}
+
+// createPluginConfigCommand creates the plugin config command
+func (c *CLI) createPluginConfigCommand() *cobra.Command {
+	var api apiFlags
+
+	cmd := &cobra.Command{
+		Use:   "config <plugin>",
+		Short: "Show the settings a plugin is running with",
+		Long: `Shows the settings of a plugin loaded from configuration, including changes
+made since with reconfigure. Credentials such as API keys and tokens are
+redacted.
+
+  agent plugin config ai-agent`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var settings map[string]interface{}
+			path := fmt.Sprintf("/api/v1/plugins/%s/config", args[0])
+			if err := api.client().do(http.MethodGet, path, nil, &settings); err != nil {
+				return err
+			}
+
+			return c.render(settings, func() error {
+				if len(settings) == 0 {
+					fmt.Printf("No known settings for %s\n", args[0])
+					return nil
+				}
+				out, err := yaml.Marshal(settings)
+				if err != nil {
+					return err
+				}
+				fmt.Print(string(out))
+				return nil
+			})
+		},
+	}
+
+	api.register(cmd)
+	return cmd
+}
+
+// createPluginReconfigureCommand creates the plugin reconfigure command
+func (c *CLI) createPluginReconfigureCommand() *cobra.Command {
+	var api apiFlags
+	var settings []string
+
+	cmd := &cobra.Command{
+		Use:   "reconfigure <plugin>",
+		Short: "Change the settings of a running plugin",
+		Long: `Changes settings of a running plugin without restarting it, such as the model
+of an AI agent. Settings not given are kept, and requests already in flight
+finish with the previous settings.
+
+  agent plugin reconfigure ai-agent --set model=gpt-4o-mini`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(settings) == 0 {
+				return fmt.Errorf("at least one --set key=value is required")
+			}
+			config := make(map[string]interface{}, len(settings))
+			for _, setting := range settings {
+				key, value, ok := strings.Cut(setting, "=")
+				if !ok || key == "" {
+					return fmt.Errorf("invalid setting %q, expected key=value", setting)
+				}
+				config[key] = value
+			}
+
+			path := fmt.Sprintf("/api/v1/plugins/%s/config", args[0])
+			if err := api.client().do(http.MethodPut, path, config, nil); err != nil {
+				return err
+			}
+			return c.render(actionResult{Action: "reconfigured", Target: args[0]}, func() error {
+				fmt.Printf("%s reconfigured\n", args[0])
+				return nil
+			})
+		},
+	}
+
+	api.register(cmd)
+	cmd.Flags().StringArrayVar(&settings, "set", nil, "Setting to change as key=value (repeatable)")
+	return cmd
+}
+
+// createPluginLoadCommand creates the plugin load command
+func (c *CLI) createPluginLoadCommand() *cobra.Command {
+	var api apiFlags
+	var pluginType, file string
+	var settings []string
+
+	cmd := &cobra.Command{
+		Use:   "load [plugin]",
+		Short: "Load a new plugin into a running framework",
+		Long: `Creates a plugin on a running framework and starts it, collectors included.
+The plugin is given either as a YAML file holding one entry like those in
+plugins.yaml, or by name with --type and --set. Setting values are read as
+YAML, so numbers, booleans, and lists keep their type.
+
+  agent plugin load --file grpc-health.yaml
+  agent plugin load strict-anomaly --type anomaly --set threshold=3 --set min_samples=20`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var config core.PluginConfig
+			if file != "" {
+				data, err := os.ReadFile(file)
+				if err != nil {
+					return fmt.Errorf("failed to read plugin file: %w", err)
+				}
+				if err := yaml.Unmarshal(data, &config); err != nil {
+					return fmt.Errorf("failed to parse plugin file: %w", err)
+				}
+			}
+			if len(args) == 1 {
+				config.Name = args[0]
+			}
+			if pluginType != "" {
+				config.Type = pluginType
+			}
+			if config.Name == "" || config.Type == "" {
+				return fmt.Errorf("a plugin name and --type, or a --file giving them, are required")
+			}
+
+			if len(settings) > 0 {
+				values, _ := config.Config.(map[string]interface{})
+				if values == nil {
+					values = make(map[string]interface{}, len(settings))
+				}
+				for _, setting := range settings {
+					key, raw, ok := strings.Cut(setting, "=")
+					if !ok || key == "" {
+						return fmt.Errorf("invalid setting %q, expected key=value", setting)
+					}
+					var value interface{}
+					if err := yaml.Unmarshal([]byte(raw), &value); err != nil {
+						value = raw
+					}
+					values[key] = value
+				}
+				config.Config = values
+			}
+
+			var state core.PluginState
+			if err := api.client().do(http.MethodPost, "/api/v1/plugins", config, &state); err != nil {
+				return err
+			}
+			return c.render(state, func() error {
+				fmt.Printf("%s loaded (%s, %s)\n", state.Name, state.Type, state.Status)
+				return nil
+			})
+		},
+	}
+
+	api.register(cmd)
+	cmd.Flags().StringVar(&pluginType, "type", "", "Plugin type as registered with the factory, e.g. prometheus or anomaly")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "YAML file with the plugin's name, type, and config")
+	cmd.Flags().StringArrayVar(&settings, "set", nil, "Setting as key=value (repeatable)")
+	return cmd
+}
+
+// createPluginUnloadCommand creates the plugin unload command
+func (c *CLI) createPluginUnloadCommand() *cobra.Command {
+	var api apiFlags
+
+	cmd := &cobra.Command{
+		Use:   "unload <plugin>",
+		Short: "Stop a plugin and remove it from a running framework",
+		Long: `Stops a plugin, along with its collection or evaluation worker, and removes it
+from the running framework. The configuration files are not changed, so the
+plugin comes back on the next restart.
+
+  agent plugin unload noisy-analyzer`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := api.client().do(http.MethodDelete, "/api/v1/plugins/"+args[0], nil, nil); err != nil {
+				return err
+			}
+			return c.render(actionResult{Action: "unloaded", Target: args[0]}, func() error {
+				fmt.Printf("%s unloaded\n", args[0])
+				return nil
+			})
+		},
+	}
+
+	api.register(cmd)
+	return cmd
+}
@@ -6,11 +6,12 @@ import (
 	"github.com/habruzzo/agent/plugins/agents"
 	"github.com/habruzzo/agent/plugins/analyzers"
 	"github.com/habruzzo/agent/plugins/collectors"
+	"github.com/habruzzo/agent/plugins/external"
 	"github.com/habruzzo/agent/plugins/responders"
 )
 
 // registerPluginCreators registers all available plugin creators with the factory
-func registerPluginCreators(factory core.PluginFactory) {
+func registerPluginCreators(factory core.PluginFactory, frameworkConfig *core.FrameworkConfig) {
 	// Register Prometheus collector
 	factory.RegisterPluginCreator("prometheus", func(config core.PluginConfig) (core.Plugin, error) {
 		plugin := collectors.NewPrometheusCollector(config.Name)
@@ -22,6 +23,28 @@ func registerPluginCreators(factory core.PluginFactory) {
 		return plugin, nil
 	})
 
+	// Register multi-cluster Prometheus collector
+	factory.RegisterPluginCreator("prometheus_federation", func(config core.PluginConfig) (core.Plugin, error) {
+		plugin := collectors.NewFederatedPrometheusCollector(config.Name)
+		if configMap, ok := config.Config.(map[string]interface{}); ok {
+			if err := plugin.Configure(configMap); err != nil {
+				return nil, err
+			}
+		}
+		return plugin, nil
+	})
+
+	// Register gRPC health-check collector
+	factory.RegisterPluginCreator("grpc_health", func(config core.PluginConfig) (core.Plugin, error) {
+		plugin := collectors.NewGRPCHealthCollector(config.Name)
+		if configMap, ok := config.Config.(map[string]interface{}); ok {
+			if err := plugin.Configure(configMap); err != nil {
+				return nil, err
+			}
+		}
+		return plugin, nil
+	})
+
 	// Register anomaly analyzer
 	factory.RegisterPluginCreator("anomaly", func(config core.PluginConfig) (core.Plugin, error) {
 		plugin := analyzers.NewAnomalyAnalyzer(config.Name)
@@ -33,6 +56,72 @@ func registerPluginCreators(factory core.PluginFactory) {
 		return plugin, nil
 	})
 
+	// Register seasonal analyzer
+	factory.RegisterPluginCreator("seasonal", func(config core.PluginConfig) (core.Plugin, error) {
+		plugin := analyzers.NewSeasonalAnalyzer(config.Name)
+		if configMap, ok := config.Config.(map[string]interface{}); ok {
+			if err := plugin.Configure(configMap); err != nil {
+				return nil, err
+			}
+		}
+		return plugin, nil
+	})
+
+	// Register week-over-week analyzer
+	factory.RegisterPluginCreator("week_over_week", func(config core.PluginConfig) (core.Plugin, error) {
+		plugin := analyzers.NewWeekOverWeekAnalyzer(config.Name)
+		if configMap, ok := config.Config.(map[string]interface{}); ok {
+			if err := plugin.Configure(configMap); err != nil {
+				return nil, err
+			}
+		}
+		return plugin, nil
+	})
+
+	// Register missing-data analyzer
+	factory.RegisterPluginCreator("staleness", func(config core.PluginConfig) (core.Plugin, error) {
+		plugin := analyzers.NewStalenessAnalyzer(config.Name)
+		if configMap, ok := config.Config.(map[string]interface{}); ok {
+			if err := plugin.Configure(configMap); err != nil {
+				return nil, err
+			}
+		}
+		return plugin, nil
+	})
+
+	// Register forecasting analyzer
+	factory.RegisterPluginCreator("forecast", func(config core.PluginConfig) (core.Plugin, error) {
+		plugin := analyzers.NewForecastAnalyzer(config.Name)
+		if configMap, ok := config.Config.(map[string]interface{}); ok {
+			if err := plugin.Configure(configMap); err != nil {
+				return nil, err
+			}
+		}
+		return plugin, nil
+	})
+
+	// Register multivariate analyzer
+	factory.RegisterPluginCreator("multivariate", func(config core.PluginConfig) (core.Plugin, error) {
+		plugin := analyzers.NewMultivariateAnalyzer(config.Name)
+		if configMap, ok := config.Config.(map[string]interface{}); ok {
+			if err := plugin.Configure(configMap); err != nil {
+				return nil, err
+			}
+		}
+		return plugin, nil
+	})
+
+	// Register static threshold rule analyzer
+	factory.RegisterPluginCreator("threshold", func(config core.PluginConfig) (core.Plugin, error) {
+		plugin := analyzers.NewThresholdAnalyzer(config.Name)
+		if configMap, ok := config.Config.(map[string]interface{}); ok {
+			if err := plugin.Configure(configMap); err != nil {
+				return nil, err
+			}
+		}
+		return plugin, nil
+	})
+
 	// Register logger responder
 	factory.RegisterPluginCreator("log", func(config core.PluginConfig) (core.Plugin, error) {
 		plugin := responders.NewLoggerResponder(config.Name)
@@ -44,6 +133,94 @@ func registerPluginCreators(factory core.PluginFactory) {
 		return plugin, nil
 	})
 
+	// Register Slack responder
+	factory.RegisterPluginCreator("slack", func(config core.PluginConfig) (core.Plugin, error) {
+		plugin := responders.NewSlackResponder(config.Name)
+		if configMap, ok := config.Config.(map[string]interface{}); ok {
+			if err := plugin.Configure(configMap); err != nil {
+				return nil, err
+			}
+		}
+		return plugin, nil
+	})
+
+	// Register PagerDuty responder
+	factory.RegisterPluginCreator("pagerduty", func(config core.PluginConfig) (core.Plugin, error) {
+		plugin := responders.NewPagerDutyResponder(config.Name)
+		if configMap, ok := config.Config.(map[string]interface{}); ok {
+			if err := plugin.Configure(configMap); err != nil {
+				return nil, err
+			}
+		}
+		return plugin, nil
+	})
+
+	// Register Opsgenie responder
+	factory.RegisterPluginCreator("opsgenie", func(config core.PluginConfig) (core.Plugin, error) {
+		plugin := responders.NewOpsgenieResponder(config.Name)
+		if configMap, ok := config.Config.(map[string]interface{}); ok {
+			if err := plugin.Configure(configMap); err != nil {
+				return nil, err
+			}
+		}
+		return plugin, nil
+	})
+
+	// Register Jira responder
+	factory.RegisterPluginCreator("jira", func(config core.PluginConfig) (core.Plugin, error) {
+		plugin := responders.NewJiraResponder(config.Name)
+		if configMap, ok := config.Config.(map[string]interface{}); ok {
+			if err := plugin.Configure(configMap); err != nil {
+				return nil, err
+			}
+		}
+		return plugin, nil
+	})
+
+	// Register analysis metrics responder
+	factory.RegisterPluginCreator("metrics", func(config core.PluginConfig) (core.Plugin, error) {
+		plugin := responders.NewMetricsResponder(config.Name)
+		if configMap, ok := config.Config.(map[string]interface{}); ok {
+			if err := plugin.Configure(configMap); err != nil {
+				return nil, err
+			}
+		}
+		return plugin, nil
+	})
+
+	// Register analysis archive responder
+	factory.RegisterPluginCreator("archive", func(config core.PluginConfig) (core.Plugin, error) {
+		plugin := responders.NewArchiveResponder(config.Name)
+		if configMap, ok := config.Config.(map[string]interface{}); ok {
+			if err := plugin.Configure(configMap); err != nil {
+				return nil, err
+			}
+		}
+		return plugin, nil
+	})
+
+	// Register static status page responder
+	factory.RegisterPluginCreator("status_page", func(config core.PluginConfig) (core.Plugin, error) {
+		plugin := responders.NewStatusPageResponder(config.Name)
+		if configMap, ok := config.Config.(map[string]interface{}); ok {
+			if err := plugin.Configure(configMap); err != nil {
+				return nil, err
+			}
+		}
+		return plugin, nil
+	})
+
+	// Register incident summary responder
+	factory.RegisterPluginCreator("incident_summary", func(config core.PluginConfig) (core.Plugin, error) {
+		plugin := responders.NewIncidentSummaryResponder(config.Name)
+		if configMap, ok := config.Config.(map[string]interface{}); ok {
+			if err := plugin.Configure(configMap); err != nil {
+				return nil, err
+			}
+		}
+		return plugin, nil
+	})
+
 	// Register AI agent
 	factory.RegisterPluginCreator("ai", func(config core.PluginConfig) (core.Plugin, error) {
 		plugin := agents.NewAIAgent(config.Name)
@@ -66,7 +243,26 @@ func registerPluginCreators(factory core.PluginFactory) {
 		return plugin, nil
 	})
 
-	// Note: Orchestrator agent registration removed as it doesn't implement Plugin interface correctly
+	// Register out-of-process plugins; the binary decides whether it is a collector,
+	// analyzer, or responder, and must be inside external_plugins_dir
+	factory.RegisterPluginCreator("external", func(config core.PluginConfig) (core.Plugin, error) {
+		configMap, _ := config.Config.(map[string]interface{})
+		if configMap == nil {
+			configMap = make(map[string]interface{})
+		}
+		return external.Load(config.Name, frameworkConfig.ExternalPluginsDir, configMap)
+	})
+
+	// Register agent orchestrator, which runs workflows across the other agents
+	factory.RegisterPluginCreator("orchestrator", func(config core.PluginConfig) (core.Plugin, error) {
+		plugin := agents.NewAgentOrchestrator(config.Name)
+		if configMap, ok := config.Config.(map[string]interface{}); ok {
+			if err := plugin.Configure(configMap); err != nil {
+				return nil, err
+			}
+		}
+		return plugin, nil
+	})
 }
 
 // loadPluginsFromConfig loads plugins from the framework configuration
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"text/tabwriter"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/spf13/cobra"
+)
+
+// createRemediationCommand creates the remediation command and its subcommands
+func (c *CLI) createRemediationCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remediation",
+		Short: "Review remediation actions",
+		Long: `Remediation actions beyond the per-service hourly cap, and actions agents propose,
+are held until someone approves or rejects them, or they expire. Executed actions are
+verified by re-checking the metric that triggered them; actions that did not help are
+escalated. Every step is recorded in the audit trail.`,
+	}
+
+	cmd.AddCommand(c.createRemediationListCommand())
+	cmd.AddCommand(c.createRemediationDecisionCommand("approve", "Run a held remediation"))
+	cmd.AddCommand(c.createRemediationDecisionCommand("reject", "Discard a held remediation"))
+	cmd.AddCommand(c.createRemediationAuditCommand())
+	return cmd
+}
+
+// createRemediationAuditCommand creates the remediation audit command
+func (c *CLI) createRemediationAuditCommand() *cobra.Command {
+	var api apiFlags
+
+	cmd := &cobra.Command{
+		Use:   "audit [remediation-id]",
+		Short: "Show the approval audit trail, of one remediation if given",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "/api/v1/remediations/audit"
+			if len(args) == 1 {
+				path += "?remediation=" + url.QueryEscape(args[0])
+			}
+			var entries []core.ApprovalAuditEntry
+			if err := api.client().do(http.MethodGet, path, nil, &entries); err != nil {
+				return err
+			}
+
+			return c.render(entries, func() error {
+				if len(entries) == 0 {
+					fmt.Println("No audit entries")
+					return nil
+				}
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "TIME\tREMEDIATION\tEVENT\tACTOR\tSERVICE\tACTION\tDETAIL")
+				for _, entry := range entries {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+						entry.Time.Local().Format("2006-01-02 15:04:05"), entry.RemediationID, entry.Event,
+						entry.Actor, entry.Service, entry.Action, entry.Detail)
+				}
+				return w.Flush()
+			})
+		},
+	}
+
+	api.register(cmd)
+	return cmd
+}
+
+// createRemediationListCommand creates the remediation list command
+func (c *CLI) createRemediationListCommand() *cobra.Command {
+	var api apiFlags
+	var pendingOnly bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List remediation actions",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var remediations []core.Remediation
+			if err := api.client().do(http.MethodGet, "/api/v1/remediations", nil, &remediations); err != nil {
+				return err
+			}
+
+			if pendingOnly {
+				pending := make([]core.Remediation, 0, len(remediations))
+				for _, remediation := range remediations {
+					if remediation.Status == core.RemediationStatusPending {
+						pending = append(pending, remediation)
+					}
+				}
+				remediations = pending
+			}
+
+			return c.render(remediations, func() error {
+				if len(remediations) == 0 {
+					fmt.Println("No remediations")
+					return nil
+				}
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "ID\tSTATUS\tVERIFIED\tSERVICE\tACTION\tWORKFLOW\tREQUESTED\tBY\tREASON")
+				for _, remediation := range remediations {
+					verified := "-"
+					if remediation.Verification != nil && remediation.Verification.Status != "" {
+						verified = string(remediation.Verification.Status)
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+						remediation.ID, remediation.Status, verified, remediation.Service, remediation.Action, remediation.WorkflowID,
+						remediation.RequestedAt.Local().Format("2006-01-02 15:04"), remediation.RequestedBy, remediation.Reason)
+				}
+				return w.Flush()
+			})
+		},
+	}
+
+	api.register(cmd)
+	cmd.Flags().BoolVar(&pendingOnly, "pending", false, "Only list remediations waiting for approval")
+	return cmd
+}
+
+// createRemediationDecisionCommand creates the remediation approve or reject command
+func (c *CLI) createRemediationDecisionCommand(decision, short string) *cobra.Command {
+	var api apiFlags
+	var actor string
+
+	cmd := &cobra.Command{
+		Use:   decision + " <remediation-id>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if actor == "" {
+				actor = os.Getenv("USER")
+			}
+
+			var remediation core.Remediation
+			path := fmt.Sprintf("/api/v1/remediations/%s/%s", args[0], decision)
+			if err := api.client().do(http.MethodPost, path, map[string]string{"actor": actor}, &remediation); err != nil {
+				return err
+			}
+			return c.render(remediation, func() error {
+				fmt.Printf("%s %s", remediation.ID, remediation.Status)
+				if remediation.Reason != "" {
+					fmt.Printf(": %s", remediation.Reason)
+				}
+				fmt.Println()
+				return nil
+			})
+		},
+	}
+
+	api.register(cmd)
+	cmd.Flags().StringVar(&actor, "author", "", "Who made the decision (default $USER)")
+	return cmd
+}
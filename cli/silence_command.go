@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/spf13/cobra"
+)
+
+// createSilenceCommand creates the silence command and its subcommands
+func (c *CLI) createSilenceCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "silence",
+		Short: "Manage silences",
+		Long: `Silences mute analyses whose series all match label selectors and an optional
+metric regex, like Alertmanager silences. They expire automatically. Maintenance
+windows are silences declared in the configuration file, optionally recurring.`,
+	}
+
+	cmd.AddCommand(c.createSilenceAddCommand())
+	cmd.AddCommand(c.createSilenceListCommand())
+	cmd.AddCommand(c.createSilenceExpireCommand())
+	cmd.AddCommand(c.createSilenceWindowsCommand())
+	return cmd
+}
+
+// createSilenceAddCommand creates the silence add command
+func (c *CLI) createSilenceAddCommand() *cobra.Command {
+	var api apiFlags
+	var metric string
+	var duration time.Duration
+	var comment string
+	var author string
+
+	cmd := &cobra.Command{
+		Use:   "add [selector]",
+		Short: "Create a silence",
+		Example: `  agent silence add 'service="checkout",env=~"prod|staging"' --duration 2h --comment "deploy"
+  agent silence add --metric 'node_disk_.*' --duration 30m`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			selector := ""
+			if len(args) == 1 {
+				selector = args[0]
+			}
+			if author == "" {
+				author = os.Getenv("USER")
+			}
+
+			request := map[string]interface{}{
+				"matchers":   selector,
+				"metric":     metric,
+				"duration":   duration.String(),
+				"comment":    comment,
+				"created_by": author,
+			}
+			var silence core.Silence
+			if err := api.client().do(http.MethodPost, "/api/v1/silences", request, &silence); err != nil {
+				return err
+			}
+			return c.render(silence, func() error {
+				fmt.Printf("Created %s until %s\n", silence.ID, silence.EndsAt.Local().Format("2006-01-02 15:04"))
+				return nil
+			})
+		},
+	}
+
+	api.register(cmd)
+	cmd.Flags().StringVar(&metric, "metric", "", "Regex that metric names must match")
+	cmd.Flags().DurationVarP(&duration, "duration", "d", time.Hour, "How long the silence lasts")
+	cmd.Flags().StringVar(&comment, "comment", "", "Why the silence was created")
+	cmd.Flags().StringVar(&author, "author", "", "Who created the silence (default $USER)")
+	return cmd
+}
+
+// createSilenceListCommand creates the silence list command
+func (c *CLI) createSilenceListCommand() *cobra.Command {
+	var api apiFlags
+	var showSuppressed bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List silences and what they suppressed",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var silences []core.SilenceStatus
+			if err := api.client().do(http.MethodGet, "/api/v1/silences", nil, &silences); err != nil {
+				return err
+			}
+
+			return c.render(silences, func() error {
+				if len(silences) == 0 {
+					fmt.Println("No silences")
+					return nil
+				}
+
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "ID\tSTATE\tMATCHERS\tENDS\tSUPPRESSED\tCREATED BY\tCOMMENT")
+				for _, silence := range silences {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+						silence.ID, silence.State, formatSilenceMatchers(silence.Silence),
+						silence.EndsAt.Local().Format("2006-01-02 15:04"), silence.SuppressedCount,
+						silence.CreatedBy, silence.Comment)
+				}
+				w.Flush()
+
+				if showSuppressed {
+					for _, silence := range silences {
+						if len(silence.Suppressed) == 0 {
+							continue
+						}
+						fmt.Printf("\n%s suppressed:\n", silence.ID)
+						for _, suppressed := range silence.Suppressed {
+							fmt.Printf("  %s  [%s] %s (%s)\n", suppressed.Timestamp.Local().Format("15:04:05"),
+								suppressed.Severity, suppressed.Summary, suppressed.AnalysisID)
+						}
+					}
+				}
+				return nil
+			})
+		},
+	}
+
+	api.register(cmd)
+	cmd.Flags().BoolVar(&showSuppressed, "suppressed", false, "Show the analyses each silence suppressed")
+	return cmd
+}
+
+// createSilenceExpireCommand creates the silence expire command
+func (c *CLI) createSilenceExpireCommand() *cobra.Command {
+	var api apiFlags
+
+	cmd := &cobra.Command{
+		Use:   "expire <silence-id>",
+		Short: "Expire a silence now",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := api.client().do(http.MethodDelete, "/api/v1/silences/"+args[0], nil, nil); err != nil {
+				return err
+			}
+			return c.render(actionResult{Action: "expired", Target: args[0]}, func() error {
+				fmt.Printf("Expired %s\n", args[0])
+				return nil
+			})
+		},
+	}
+
+	api.register(cmd)
+	return cmd
+}
+
+// createSilenceWindowsCommand creates the silence windows command
+func (c *CLI) createSilenceWindowsCommand() *cobra.Command {
+	var api apiFlags
+
+	cmd := &cobra.Command{
+		Use:   "windows",
+		Short: "List maintenance windows declared in the configuration",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var windows []core.MaintenanceWindowStatus
+			if err := api.client().do(http.MethodGet, "/api/v1/maintenance-windows", nil, &windows); err != nil {
+				return err
+			}
+
+			return c.render(windows, func() error {
+				if len(windows) == 0 {
+					fmt.Println("No maintenance windows")
+					return nil
+				}
+
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "NAME\tSTATE\tMATCHERS\tSCHEDULE\tNEXT START\tSUPPRESSED\tCOMMENT")
+				for _, window := range windows {
+					next := "-"
+					if !window.NextStart.IsZero() {
+						next = window.NextStart.Local().Format("2006-01-02 15:04")
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
+						window.Name, window.State,
+						formatMatchers(window.Matchers, window.MetricPattern),
+						formatWindowSchedule(window.MaintenanceWindow), next, window.SuppressedCount, window.Comment)
+				}
+				return w.Flush()
+			})
+		},
+	}
+
+	api.register(cmd)
+	return cmd
+}
+
+// formatWindowSchedule describes when a maintenance window applies
+func formatWindowSchedule(window core.MaintenanceWindow) string {
+	if window.Duration == 0 {
+		return fmt.Sprintf("%s to %s", window.StartsAt.Local().Format("2006-01-02 15:04"), window.EndsAt.Local().Format("2006-01-02 15:04"))
+	}
+	days := "daily"
+	if len(window.Days) > 0 {
+		names := make([]string, len(window.Days))
+		for i, day := range window.Days {
+			names[i] = day.String()[:3]
+		}
+		days = strings.Join(names, ",")
+	}
+	timezone := window.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	return fmt.Sprintf("%s %s %s for %s", days, window.Start, timezone, window.Duration)
+}
+
+// formatSilenceMatchers renders a silence's selectors in selector syntax
+func formatSilenceMatchers(silence core.Silence) string {
+	return formatMatchers(silence.Matchers, silence.MetricPattern)
+}
+
+// formatMatchers renders label matchers and a metric regex in selector syntax
+func formatMatchers(matchers []core.LabelMatcher, metricPattern string) string {
+	parts := make([]string, 0, len(matchers)+1)
+	if metricPattern != "" {
+		parts = append(parts, fmt.Sprintf("metric=~%q", metricPattern))
+	}
+	for _, matcher := range matchers {
+		parts = append(parts, matcher.String())
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/spf13/cobra"
+)
+
+// createSnapshotCommand creates the snapshot command and its subcommands
+func (c *CLI) createSnapshotCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Capture context snapshots and query agents against them",
+		Long: `A snapshot saves the data agents currently see together with recent analyses.
+Queries run against a snapshot see that data rather than live data, so a
+postmortem can ask the same questions later and get answers about the moment
+the snapshot was taken.`,
+	}
+
+	cmd.AddCommand(c.createSnapshotCaptureCommand())
+	cmd.AddCommand(c.createSnapshotListCommand())
+	cmd.AddCommand(c.createSnapshotShowCommand())
+	cmd.AddCommand(c.createSnapshotQueryCommand())
+	cmd.AddCommand(c.createSnapshotDeleteCommand())
+	return cmd
+}
+
+// createSnapshotCaptureCommand creates the snapshot capture command
+func (c *CLI) createSnapshotCaptureCommand() *cobra.Command {
+	var api apiFlags
+	var comment string
+	var author string
+	var window time.Duration
+
+	cmd := &cobra.Command{
+		Use:     "capture <name>",
+		Short:   "Save the current agent context and recent analyses",
+		Example: `  agent snapshot capture checkout-outage --comment "INC-231 at peak" --window 2h`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if author == "" {
+				author = os.Getenv("USER")
+			}
+			request := map[string]interface{}{
+				"name":       args[0],
+				"comment":    comment,
+				"created_by": author,
+				"window":     window.String(),
+			}
+			var summary core.ContextSnapshotSummary
+			if err := api.client().do(http.MethodPost, "/api/v1/snapshots", request, &summary); err != nil {
+				return err
+			}
+			return c.render(summary, func() error {
+				fmt.Printf("Captured %s: %d data points, %d analyses\n", summary.Name, summary.DataPoints, summary.Analyses)
+				return nil
+			})
+		},
+	}
+
+	api.register(cmd)
+	cmd.Flags().StringVar(&comment, "comment", "", "Why the snapshot was taken")
+	cmd.Flags().StringVar(&author, "author", "", "Who took the snapshot (default $USER)")
+	cmd.Flags().DurationVar(&window, "window", time.Hour, "How far back analyses are captured")
+	return cmd
+}
+
+// createSnapshotListCommand creates the snapshot list command
+func (c *CLI) createSnapshotListCommand() *cobra.Command {
+	var api apiFlags
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List saved snapshots",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var summaries []core.ContextSnapshotSummary
+			if err := api.client().do(http.MethodGet, "/api/v1/snapshots", nil, &summaries); err != nil {
+				return err
+			}
+
+			return c.render(summaries, func() error {
+				if len(summaries) == 0 {
+					fmt.Println("No snapshots")
+					return nil
+				}
+
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "NAME\tCREATED\tDATA POINTS\tANALYSES\tCREATED BY\tCOMMENT")
+				for _, summary := range summaries {
+					fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\n", summary.Name,
+						summary.CreatedAt.Local().Format("2006-01-02 15:04"), summary.DataPoints, summary.Analyses,
+						summary.CreatedBy, summary.Comment)
+				}
+				return w.Flush()
+			})
+		},
+	}
+
+	api.register(cmd)
+	return cmd
+}
+
+// createSnapshotShowCommand creates the snapshot show command
+func (c *CLI) createSnapshotShowCommand() *cobra.Command {
+	var api apiFlags
+
+	cmd := &cobra.Command{
+		Use:   "show <name>",
+		Short: "Show the analyses saved in a snapshot",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var snapshot core.ContextSnapshot
+			if err := api.client().do(http.MethodGet, "/api/v1/snapshots/"+args[0], nil, &snapshot); err != nil {
+				return err
+			}
+
+			return c.render(snapshot, func() error {
+				fmt.Printf("Snapshot %s taken %s", snapshot.Name, snapshot.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+				if snapshot.CreatedBy != "" {
+					fmt.Printf(" by %s", snapshot.CreatedBy)
+				}
+				fmt.Println()
+				if snapshot.Comment != "" {
+					fmt.Printf("  %s\n", snapshot.Comment)
+				}
+				fmt.Printf("%d data points, %d analyses\n", len(snapshot.Data), len(snapshot.Analyses))
+				for _, analysis := range snapshot.Analyses {
+					fmt.Printf("  %s  [%s] %s (%s)\n", analysis.Timestamp.Local().Format("15:04:05"),
+						analysis.Severity, analysis.Summary, analysis.Source)
+				}
+				return nil
+			})
+		},
+	}
+
+	api.register(cmd)
+	return cmd
+}
+
+// createSnapshotQueryCommand creates the snapshot query command
+func (c *CLI) createSnapshotQueryCommand() *cobra.Command {
+	var api apiFlags
+	var agent string
+
+	cmd := &cobra.Command{
+		Use:     "query <name> <query>",
+		Short:   "Ask an agent a question as of a snapshot",
+		Example: `  agent snapshot query checkout-outage "What was causing the high CPU usage?"`,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			request := map[string]interface{}{"agent": agent, "query": args[1]}
+			var response core.AgentResponse
+			if err := api.client().do(http.MethodPost, "/api/v1/snapshots/"+args[0]+"/query", request, &response); err != nil {
+				return err
+			}
+			return c.render(response, func() error {
+				fmt.Println(response.Response)
+				return nil
+			})
+		},
+	}
+
+	api.register(cmd)
+	cmd.Flags().StringVar(&agent, "agent", "", "Agent to ask (default the configured default agent)")
+	return cmd
+}
+
+// createSnapshotDeleteCommand creates the snapshot delete command
+func (c *CLI) createSnapshotDeleteCommand() *cobra.Command {
+	var api apiFlags
+
+	cmd := &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete a snapshot",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := api.client().do(http.MethodDelete, "/api/v1/snapshots/"+args[0], nil, nil); err != nil {
+				return err
+			}
+			return c.render(actionResult{Action: "deleted", Target: args[0]}, func() error {
+				fmt.Printf("Deleted %s\n", args[0])
+				return nil
+			})
+		},
+	}
+
+	api.register(cmd)
+	return cmd
+}
@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/websocket"
+)
+
+// ANSI colors for severities in the tail output
+var severityColors = map[string]string{
+	"critical": "\033[1;31m",
+	"high":     "\033[31m",
+	"medium":   "\033[33m",
+	"low":      "\033[36m",
+}
+
+const colorReset = "\033[0m"
+
+// createTailCommand creates the tail command
+func (c *CLI) createTailCommand() *cobra.Command {
+	var api apiFlags
+	var severity string
+	var labels string
+	var since string
+	var rate float64
+	var events string
+	var noColor bool
+
+	cmd := &cobra.Command{
+		Use:   "tail",
+		Short: "Stream analyses from a running framework as they happen",
+		Long: `Tail follows the analyses of a running framework like tail -f. Analyses can
+be filtered by minimum severity and by a label selector over the labels all of
+their data points share. The server sends at most --rate analyses per second
+and reports how many it skipped, so an alert storm cannot flood the terminal.
+With --events, framework events such as plugins being loaded or the data
+channel degrading are shown as well, optionally only the types matching globs.`,
+		Example: `  agent tail --severity high --labels '{service="checkout"}' --since 15m
+  agent tail --events 'plugin_*,data_channel_*'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := url.Values{}
+			if severity != "" {
+				query.Set("severity", severity)
+			}
+			if labels != "" {
+				query.Set("labels", labels)
+			}
+			if since != "" {
+				query.Set("since", since)
+			}
+			if rate > 0 {
+				query.Set("rate", strconv.FormatFloat(rate, 'f', -1, 64))
+			}
+
+			path := "/api/v1/analyses/stream?"
+			if cmd.Flags().Changed("events") {
+				path = "/api/v1/stream?"
+				query.Set("events", events)
+			}
+			conn, err := api.client().stream(path + query.Encode())
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			color := !noColor && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)
+			return c.tail(conn, color)
+		},
+	}
+
+	api.register(cmd)
+	cmd.Flags().StringVar(&severity, "severity", "", "Minimum severity (low, medium, high, critical)")
+	cmd.Flags().StringVar(&labels, "labels", "", `Label selector, e.g. '{service="api",env=~"prod|staging"}'`)
+	cmd.Flags().StringVar(&since, "since", "", "Also show analyses since a time (RFC 3339) or for a duration such as 1h")
+	cmd.Flags().Float64Var(&rate, "rate", 0, "Maximum analyses per second (default 10)")
+	cmd.Flags().StringVar(&events, "events", "", "Also show framework events, optionally only types matching comma-separated globs")
+	cmd.Flags().Lookup("events").NoOptDefVal = "*"
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "Disable severity colors")
+	return cmd
+}
+
+// tail prints stream messages until the server closes the stream
+func (c *CLI) tail(conn *websocket.Conn, color bool) error {
+	encoder := json.NewEncoder(os.Stdout)
+	for {
+		var message core.StreamMessage
+		if err := websocket.JSON.Receive(conn, &message); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("analysis stream failed: %w", err)
+		}
+
+		// Structured output is one JSON object per line so it can be piped to jq
+		if c.structured() {
+			if err := encoder.Encode(message); err != nil {
+				return err
+			}
+			continue
+		}
+		if message.Dropped > 0 {
+			fmt.Fprintf(os.Stderr, "... %d messages skipped\n", message.Dropped)
+		}
+		if message.Analysis != nil {
+			fmt.Println(formatTailLine(message.Analysis, color))
+		}
+		if message.Event != nil {
+			fmt.Println(formatEventLine(message.Event))
+		}
+	}
+}
+
+// formatTailLine renders an analysis as one line: time, severity, source, summary, labels
+func formatTailLine(analysis *core.Analysis, color bool) string {
+	severity := fmt.Sprintf("%-8s", strings.ToUpper(analysis.Severity))
+	if code, ok := severityColors[analysis.Severity]; ok && color {
+		severity = code + severity + colorReset
+	}
+	line := fmt.Sprintf("%s  %s  %-20s  %s", analysis.Timestamp.Local().Format("15:04:05"), severity, analysis.Source, analysis.Summary)
+	if labels := sharedLabels(analysis); labels != "" {
+		line += "  " + labels
+	}
+	return line
+}
+
+// formatEventLine renders a framework event as one line: time, type, and its data
+func formatEventLine(event *core.Event) string {
+	pairs := make([]string, 0, len(event.Data))
+	for name, value := range event.Data {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", name, value))
+	}
+	sort.Strings(pairs)
+	return strings.TrimSpace(fmt.Sprintf("%s  %-8s  %-20s  %s", event.Timestamp.Local().Format("15:04:05"), "EVENT", event.Type, strings.Join(pairs, " ")))
+}
+
+// sharedLabels formats the labels every data point of an analysis shares
+func sharedLabels(analysis *core.Analysis) string {
+	if len(analysis.DataPoints) == 0 {
+		return ""
+	}
+	var pairs []string
+	for name, value := range analysis.DataPoints[0].Labels {
+		shared := true
+		for _, point := range analysis.DataPoints[1:] {
+			if point.Labels[name] != value {
+				shared = false
+				break
+			}
+		}
+		if shared {
+			pairs = append(pairs, fmt.Sprintf("%s=%q", name, value))
+		}
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// isTerminal reports whether the file is a terminal rather than a pipe or file
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
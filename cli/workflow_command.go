@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/spf13/cobra"
+)
+
+// createWorkflowCommand creates the workflow command and its subcommands
+func (c *CLI) createWorkflowCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "workflow",
+		Aliases: []string{"workflows"},
+		Short:   "Run workflows",
+		Long: `Workflows are declared in the orchestrator plugin's configuration. Starting one
+by hand runs it as a remediation: it counts against the remediation cap, is
+simulated in dry-run mode, and is recorded in the audit trail.`,
+	}
+
+	cmd.AddCommand(c.createWorkflowStartCommand())
+	return cmd
+}
+
+// createWorkflowStartCommand creates the workflow start command
+func (c *CLI) createWorkflowStartCommand() *cobra.Command {
+	var api apiFlags
+	var actor string
+	var service string
+	var input []string
+
+	cmd := &cobra.Command{
+		Use:   "start <workflow-id>",
+		Short: "Run a workflow and wait for it to finish",
+		Example: `  agent workflow start restart-api --service checkout
+  agent workflow start diagnose --input question="Why is checkout slow?" --output json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if actor == "" {
+				actor = os.Getenv("USER")
+			}
+			values := make(map[string]interface{}, len(input))
+			for _, entry := range input {
+				key, value, ok := strings.Cut(entry, "=")
+				if !ok || key == "" {
+					return fmt.Errorf("invalid input %q, expected key=value", entry)
+				}
+				values[key] = value
+			}
+
+			request := map[string]interface{}{"actor": actor, "service": service, "input": values}
+			var remediation core.Remediation
+			path := fmt.Sprintf("/api/v1/workflows/%s/start", url.PathEscape(args[0]))
+			if err := api.client().do(http.MethodPost, path, request, &remediation); err != nil {
+				return err
+			}
+			return c.render(remediation, func() error {
+				fmt.Printf("%s %s", remediation.ID, remediation.Status)
+				if remediation.Reason != "" {
+					fmt.Printf(": %s", remediation.Reason)
+				}
+				fmt.Println()
+				return nil
+			})
+		},
+	}
+
+	api.register(cmd)
+	cmd.Flags().StringVar(&actor, "author", "", "Who started the workflow (default $USER)")
+	cmd.Flags().StringVar(&service, "service", "", "Service the workflow acts on")
+	cmd.Flags().StringArrayVar(&input, "input", nil, "Workflow input as key=value (repeatable)")
+	return cmd
+}
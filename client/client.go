@@ -0,0 +1,284 @@
+// Package client is a Go client for the management API of a running agent framework.
+//
+//	c, err := client.New(client.Config{BaseURL: "http://agent:9090", Token: os.Getenv("AGENT_API_TOKEN")})
+//	if err != nil {
+//		return err
+//	}
+//	analyses, err := c.ListAnalyses(ctx, time.Now().Add(-time.Hour))
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// DefaultTimeout bounds each request when no timeout is configured
+const DefaultTimeout = 10 * time.Second
+
+// DefaultRetryPolicy retries failed requests three times with exponential backoff
+var DefaultRetryPolicy = core.RetryPolicy{
+	MaxAttempts:  3,
+	InitialDelay: 200 * time.Millisecond,
+	MaxDelay:     2 * time.Second,
+	Multiplier:   2,
+	Jitter:       true,
+}
+
+// Config configures a Client
+type Config struct {
+	// BaseURL of the framework's HTTP server, such as http://localhost:9090
+	BaseURL string
+	// Token is a management API key, sent as a bearer token
+	Token string
+	// Timeout bounds each attempt of a request; zero means DefaultTimeout
+	Timeout time.Duration
+	// Retry controls how failed requests are retried; zero means DefaultRetryPolicy and
+	// MaxAttempts of 1 disables retries
+	Retry core.RetryPolicy
+	// HTTPClient sends the requests; nil means a client with Timeout
+	HTTPClient *http.Client
+}
+
+// Client calls the management API of a running framework. Requests are retried on
+// connection errors and on 429, 502, 503, and 504 responses, except that a request that
+// starts a workflow is only retried when the server rejected it without running it.
+type Client struct {
+	baseURL    *url.URL
+	token      string
+	retry      core.RetryPolicy
+	httpClient *http.Client
+}
+
+// APIError is returned for responses with a non-2xx status
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+	// RetryAfter is the delay the server asked for before retrying, if any
+	RetryAfter time.Duration
+}
+
+// Error describes the failed request and the server's message
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s: %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// New creates a client from the config
+func New(config Config) (*Client, error) {
+	if config.BaseURL == "" {
+		return nil, core.NewValidationError("client", "new", "base URL is required")
+	}
+	baseURL, err := url.Parse(strings.TrimSuffix(config.BaseURL, "/"))
+	if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, core.NewValidationError("client", "new", fmt.Sprintf("invalid base URL %q", config.BaseURL))
+	}
+
+	retry := config.Retry
+	if retry.MaxAttempts <= 0 {
+		retry = DefaultRetryPolicy
+	}
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		timeout := config.Timeout
+		if timeout <= 0 {
+			timeout = DefaultTimeout
+		}
+		httpClient = &http.Client{Timeout: timeout}
+	}
+
+	return &Client{
+		baseURL:    baseURL,
+		token:      config.Token,
+		retry:      retry,
+		httpClient: httpClient,
+	}, nil
+}
+
+// GetStatus returns the framework's status
+func (c *Client) GetStatus(ctx context.Context) (*core.FrameworkStatus, error) {
+	var status core.FrameworkStatus
+	if err := c.do(ctx, http.MethodGet, "/status", nil, nil, true, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// ListAnalyses returns the analyses recorded since a time; the zero time returns every
+// analysis still in the history
+func (c *Client) ListAnalyses(ctx context.Context, since time.Time) ([]core.Analysis, error) {
+	query := url.Values{}
+	if !since.IsZero() {
+		query.Set("since", since.Format(time.RFC3339))
+	}
+	var analyses []core.Analysis
+	if err := c.do(ctx, http.MethodGet, "/api/v1/analyses", query, nil, true, &analyses); err != nil {
+		return nil, err
+	}
+	return analyses, nil
+}
+
+// Query sends a query to an agent; an empty agent uses the framework's default agent
+func (c *Client) Query(ctx context.Context, agent, query string) (*core.AgentResponse, error) {
+	body := map[string]string{"query": query}
+	if agent != "" {
+		body["agent"] = agent
+	}
+	var response core.AgentResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/query", nil, body, true, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// StartWorkflowRequest describes who starts a workflow and with what input
+type StartWorkflowRequest struct {
+	// Actor is recorded as the person who started and approved the workflow
+	Actor string `json:"actor"`
+	// Service the workflow acts on, counted against the remediation cap
+	Service string                 `json:"service,omitempty"`
+	Input   map[string]interface{} `json:"input,omitempty"`
+}
+
+// StartWorkflow runs a workflow as a remediation. The returned remediation reports
+// whether the workflow ran, failed, or was held by the remediation cap.
+func (c *Client) StartWorkflow(ctx context.Context, workflowID string, req StartWorkflowRequest) (*core.Remediation, error) {
+	if workflowID == "" || req.Actor == "" {
+		return nil, core.NewValidationError("client", "start_workflow", "workflow ID and actor are required")
+	}
+	var remediation core.Remediation
+	path := "/api/v1/workflows/" + workflowID + "/start"
+	if err := c.do(ctx, http.MethodPost, path, nil, req, false, &remediation); err != nil {
+		return nil, err
+	}
+	return &remediation, nil
+}
+
+// do sends a request, retrying it per the client's policy, and decodes a JSON response
+// into out. Requests that are not idempotent are only retried when the server cannot have
+// acted on them.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body interface{}, idempotent bool, out interface{}) error {
+	var encoded []byte
+	if body != nil {
+		var err error
+		if encoded, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	delay := c.retry.InitialDelay
+	for attempt := 1; ; attempt++ {
+		err := c.send(ctx, method, path, query, encoded, out)
+		if err == nil || attempt >= c.retry.MaxAttempts || !retryable(err, idempotent) || ctx.Err() != nil {
+			return err
+		}
+
+		wait := delay
+		if c.retry.Jitter && wait > 0 {
+			wait = wait/2 + time.Duration(rand.Int64N(int64(wait/2)+1))
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > wait {
+			wait = apiErr.RetryAfter
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		if c.retry.Multiplier > 1 {
+			delay = time.Duration(float64(delay) * c.retry.Multiplier)
+		}
+		if c.retry.MaxDelay > 0 && delay > c.retry.MaxDelay {
+			delay = c.retry.MaxDelay
+		}
+	}
+}
+
+// send makes one attempt at a request
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body []byte, out interface{}) error {
+	endpoint := *c.baseURL
+	endpoint.Path += path
+	endpoint.RawQuery = query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		apiErr := &APIError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(string(message)),
+		}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			apiErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: invalid response: %w", method, path, err)
+	}
+	return nil
+}
+
+// retryable reports whether a failed attempt may be retried. Rate limiting and
+// unavailability mean the server did not act on the request, so those are always retried;
+// connection errors and gateway errors only for idempotent requests.
+func retryable(err error, idempotent bool) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return idempotent
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	default:
+		return false
+	}
+}
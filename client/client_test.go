@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type echoAgent struct {
+	name string
+}
+
+func (a *echoAgent) Name() string                                  { return a.name }
+func (a *echoAgent) Type() core.PluginType                         { return core.PluginTypeAgent }
+func (a *echoAgent) Version() string                               { return "1.0.0" }
+func (a *echoAgent) Configure(config map[string]interface{}) error { return nil }
+func (a *echoAgent) Start(ctx context.Context) error               { return nil }
+func (a *echoAgent) Stop() error                                   { return nil }
+func (a *echoAgent) Status() core.PluginStatus                     { return core.PluginStatusRunning }
+func (a *echoAgent) Health(ctx context.Context) error              { return nil }
+func (a *echoAgent) GetCapabilities() []string                     { return nil }
+func (a *echoAgent) SetContext(data []core.DataPoint)              {}
+func (a *echoAgent) GetAvailableQueries() []string                 { return nil }
+
+func (a *echoAgent) ProcessQuery(ctx context.Context, query string) (*core.AgentResponse, error) {
+	return &core.AgentResponse{Query: query, Response: "echo: " + query}, nil
+}
+
+type completingEngine struct{}
+
+func (completingEngine) CreateWorkflow(workflow *core.Workflow) error { return nil }
+func (completingEngine) GetWorkflowStatus(workflowID string) (*core.WorkflowStatus, error) {
+	return nil, nil
+}
+func (completingEngine) CancelWorkflow(workflowID string) error { return nil }
+
+func (completingEngine) ExecuteWorkflow(ctx context.Context, workflowID string, input map[string]interface{}) (*core.WorkflowResult, error) {
+	return &core.WorkflowResult{WorkflowID: workflowID, Status: "completed", Output: input}, nil
+}
+
+func TestClient_Framework(t *testing.T) {
+	framework := core.NewFramework(&core.FrameworkConfig{
+		LogLevel:     "info",
+		LogFormat:    "text",
+		LogOutput:    "stdout",
+		DefaultAgent: "echo",
+		APIKeys:      []core.APIKeyConfig{{Name: "ops", Token: "secret", Scopes: []string{"admin"}}},
+	})
+	framework.SetWorkflowEngine(completingEngine{})
+	require.NoError(t, framework.LoadPlugin(&echoAgent{name: "echo"}))
+	ctx := context.Background()
+	require.NoError(t, framework.GetAnalysisHistory().Record(ctx, &core.Analysis{
+		ID: "a-1", Source: "anomaly", Severity: "high", Summary: "CPU spike", Timestamp: time.Now(),
+	}))
+
+	server := httptest.NewServer(framework.Handler())
+	defer server.Close()
+	c, err := New(Config{BaseURL: server.URL, Token: "secret"})
+	require.NoError(t, err)
+
+	status, err := c.GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Agents)
+	assert.Equal(t, core.PluginTypeAgent, status.Plugins["echo"].Type)
+
+	analyses, err := c.ListAnalyses(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, analyses, 1)
+	assert.Equal(t, "CPU spike", analyses[0].Summary)
+
+	response, err := c.Query(ctx, "", "why is CPU high?")
+	require.NoError(t, err)
+	assert.Equal(t, "echo: why is CPU high?", response.Response)
+
+	remediation, err := c.StartWorkflow(ctx, "restart-api", StartWorkflowRequest{
+		Actor: "alice", Service: "api", Input: map[string]interface{}{"replicas": 3.0},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, core.RemediationStatusExecuted, remediation.Status)
+	assert.Equal(t, "restart-api", remediation.WorkflowID)
+	assert.Equal(t, "alice", remediation.ApprovedBy)
+
+	unauthorized, err := New(Config{BaseURL: server.URL, Token: "wrong"})
+	require.NoError(t, err)
+	_, err = unauthorized.GetStatus(ctx)
+	require.NoError(t, err, "Expected /status to be open like the other health endpoints")
+	_, err = unauthorized.ListAnalyses(ctx, time.Time{})
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+}
+
+func TestClient_Retries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch {
+		case r.URL.Path == "/status" && calls.Add(1) < 3:
+			http.Error(w, "starting up", http.StatusServiceUnavailable)
+		case r.URL.Path == "/status":
+			w.Write([]byte(`{"running": true}`))
+		default:
+			calls.Add(1)
+			http.Error(w, "upstream failed", http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	c, err := New(Config{
+		BaseURL: server.URL,
+		Token:   "secret",
+		Retry:   core.RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	status, err := c.GetStatus(ctx)
+	require.NoError(t, err)
+	assert.True(t, status.Running)
+	assert.Equal(t, int32(3), calls.Load())
+
+	calls.Store(0)
+	_, err = c.StartWorkflow(ctx, "restart-api", StartWorkflowRequest{Actor: "alice"})
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	assert.Equal(t, int32(1), calls.Load(), "Expected a workflow the server may have started not to be retried")
+
+	calls.Store(0)
+	_, err = c.Query(ctx, "", "status?")
+	require.Error(t, err)
+	assert.Equal(t, int32(3), calls.Load(), "Expected queries to be retried on gateway errors")
+}
+
+func TestNew_InvalidBaseURL(t *testing.T) {
+	_, err := New(Config{})
+	assert.Error(t, err)
+	_, err = New(Config{BaseURL: "localhost:9090"})
+	assert.Error(t, err)
+}
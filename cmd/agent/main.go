@@ -9,6 +9,6 @@ import (
 func main() {
 	app := cli.NewCLI()
 	if err := app.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(cli.ExitCode(err))
 	}
 }
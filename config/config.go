@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -23,9 +24,16 @@ func LoadConfig(filename string) (*core.FrameworkConfig, error) {
 			return nil, core.NewConfigurationError("config", "load", fmt.Sprintf("failed to read config file: %v", err))
 		}
 
-		// Parse YAML into the config struct
-		if err := yaml.Unmarshal(data, config); err != nil {
-			return nil, core.NewConfigurationError("config", "parse", fmt.Sprintf("failed to parse config file: %v", err))
+		// Files written for older schemas are upgraded in memory so their settings still apply
+		migrated, report, err := MigrateConfig(data)
+		if err != nil {
+			return nil, err
+		}
+		report.warnDeprecated(filename)
+
+		// Parse YAML into the config struct, interpolating environment references in plugins
+		if err := decodeConfig(migrated, config); err != nil {
+			return nil, err
 		}
 	}
 
@@ -87,22 +95,67 @@ func SaveConfig(config *core.FrameworkConfig, filename string) error {
 // DefaultConfig returns a default configuration
 func DefaultConfig() *core.FrameworkConfig {
 	config := &core.FrameworkConfig{
-		LogLevel:           "info",
-		LogFormat:          "text",
-		LogOutput:          "stdout",
-		ServerHost:         "0.0.0.0",
-		ServerPort:         9090,
-		DefaultAgent:       "",
-		AIAPIKey:           "",
-		AIAPIURL:           "https://api.openai.com/v1",
-		PrometheusEnabled:  true,
-		PrometheusURL:      "http://localhost:9090",
-		PluginConfigFile:   "plugins.yaml",
-		HealthCheckTimeout: 5 * time.Second,
-		DataChannelSize:    100,
-		WorkerPoolSize:     4,
-		ShutdownTimeout:    30 * time.Second,
-		Plugins:            getDefaultPluginConfigs(),
+		LogLevel:            "info",
+		LogFormat:           "text",
+		LogOutput:           "stdout",
+		ServerHost:          "0.0.0.0",
+		ServerPort:          9090,
+		DefaultAgent:        "",
+		AIAPIKey:            "",
+		AIAPIURL:            "https://api.openai.com/v1",
+		PrometheusEnabled:   true,
+		PrometheusURL:       "http://localhost:9090",
+		PluginConfigFile:    "plugins.yaml",
+		HealthCheckTimeout:  5 * time.Second,
+		DataChannelSize:     100,
+		WorkerPoolSize:      4,
+		ShutdownTimeout:     30 * time.Second,
+		EventBufferSize:     100,
+		PluginStartTimeout:  30 * time.Second,
+		AnalyzerTimeout:     30 * time.Second,
+		ConfigWatchInterval: time.Second,
+		ExplainRetention:    6 * time.Hour,
+		AnalysisRetention:   24 * time.Hour,
+		DataPointRetention:  time.Hour,
+		Store:               core.StoreConfig{Driver: core.StoreDriverMemory},
+		Backpressure: core.BackpressureConfig{
+			Overflow:      core.OverflowBlock,
+			DegradedAfter: 30 * time.Second,
+		},
+		WAL: core.WALConfig{
+			Dir:          "data/wal",
+			SegmentBytes: 16 << 20,
+			MaxBytes:     1 << 30,
+			MaxAge:       24 * time.Hour,
+		},
+		Dedup: core.DedupConfig{
+			RepeatInterval: time.Hour,
+			ResolveTimeout: 5 * time.Minute,
+			FlapWindow:     30 * time.Minute,
+			FlapThreshold:  4,
+		},
+		Remediation: core.RemediationConfig{
+			MaxActionsPerHour: 3,
+			ServiceLabel:      "service",
+			VerifyAfter:       5 * time.Minute,
+			MinImprovement:    0.1,
+			ApprovalTTL:       4 * time.Hour,
+		},
+		AgentQueries: core.AgentQueryConfig{Concurrency: 4},
+		NoiseReport:  core.NoiseReportConfig{Window: 7 * 24 * time.Hour, Top: 10},
+		Delivery: core.DeliveryConfig{
+			Timeout:             10 * time.Second,
+			MaxAttempts:         3,
+			RetryBackoff:        500 * time.Millisecond,
+			MaxRetryBackoff:     5 * time.Second,
+			BreakerThreshold:    5,
+			BreakerResetTimeout: time.Minute,
+			QueueSize:           1000,
+			RetryInterval:       30 * time.Second,
+			MaxAge:              24 * time.Hour,
+			FailoverAfter:       3,
+		},
+		Plugins: getDefaultPluginConfigs(),
 	}
 
 	return config
@@ -289,22 +342,63 @@ func GetConfigSummary(config *core.FrameworkConfig) map[string]interface{} {
 			"output": config.LogOutput,
 		},
 		"server": map[string]interface{}{
-			"host": config.ServerHost,
-			"port": config.ServerPort,
+			"host":      config.ServerHost,
+			"port":      config.ServerPort,
+			"grpc_port": config.GRPCPort,
+			"tls":       config.TLS.Enabled(),
+			"mtls":      config.TLS.Enabled() && config.TLS.ClientCAFile != "",
+		},
+		"auth": map[string]interface{}{
+			"api_keys":       len(config.APIKeys),
+			"basic_users":    len(config.Auth.BasicUsers),
+			"oidc":           config.Auth.OIDC != nil,
+			"protect_status": config.Auth.ProtectStatus,
+		},
+		"secrets": map[string]interface{}{
+			"vault":     config.Secrets.Vault != nil,
+			"aws":       config.Secrets.AWS != nil,
+			"cache_ttl": config.Secrets.CacheTTL.String(),
+		},
+		"discovery": map[string]interface{}{
+			"consul":           config.Discovery.Consul != nil,
+			"kubernetes":       config.Discovery.Kubernetes != nil,
+			"refresh_interval": config.Discovery.RefreshInterval.String(),
+		},
+		"tracing": map[string]interface{}{
+			"enabled":      config.Tracing.Endpoint != "",
+			"endpoint":     redactURL(config.Tracing.Endpoint),
+			"service_name": config.Tracing.ServiceName,
+			"sample_ratio": config.Tracing.SampleRatio,
+		},
+		"tenants": tenantNames(config.Tenants),
+		"fleet": map[string]interface{}{
+			"coordinator":        config.Fleet.Coordinator,
+			"agent_id":           config.Fleet.AgentID,
+			"heartbeat_interval": config.Fleet.HeartbeatInterval.String(),
+			"agents":             len(config.Fleet.Agents),
 		},
 		"agent": map[string]interface{}{
 			"default_agent": config.DefaultAgent,
-			"ai_api_url":    config.AIAPIURL,
+			"ai_api_url":    redactURL(config.AIAPIURL),
 			"has_api_key":   config.AIAPIKey != "",
 		},
 		"prometheus": map[string]interface{}{
 			"enabled": config.PrometheusEnabled,
-			"url":     config.PrometheusURL,
+			"url":     redactURL(config.PrometheusURL),
 		},
 		"processing": map[string]interface{}{
-			"data_channel_size": config.DataChannelSize,
-			"worker_pool_size":  config.WorkerPoolSize,
-			"shutdown_timeout":  config.ShutdownTimeout.String(),
+			"data_channel_size":     config.DataChannelSize,
+			"processors":            len(config.Processors),
+			"recording_rules":       len(config.RecordingRules),
+			"worker_pool_size":      config.WorkerPoolSize,
+			"overflow_policy":       config.Backpressure.Overflow,
+			"shutdown_timeout":      config.ShutdownTimeout.String(),
+			"event_buffer_size":     config.EventBufferSize,
+			"plugin_start_timeout":  config.PluginStartTimeout.String(),
+			"supervisor_interval":   config.Supervisor.CheckInterval.String(),
+			"max_plugin_restarts":   config.Supervisor.MaxRestarts,
+			"analyzer_timeout":      config.AnalyzerTimeout.String(),
+			"config_watch_interval": config.ConfigWatchInterval.String(),
 		},
 		"plugins": map[string]interface{}{
 			"count": len(config.Plugins),
@@ -313,6 +407,24 @@ func GetConfigSummary(config *core.FrameworkConfig) map[string]interface{} {
 	}
 }
 
+// tenantNames lists the configured tenants by name
+func tenantNames(tenants []core.TenantConfig) []string {
+	names := make([]string, len(tenants))
+	for i, tenant := range tenants {
+		names[i] = tenant.Name
+	}
+	return names
+}
+
+// redactURL hides a password in a URL's user info, so summaries can be shared
+func redactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return parsed.Redacted()
+}
+
 // getPluginTypeCounts returns a count of plugins by type
 func getPluginTypeCounts(plugins []core.PluginConfig) map[string]int {
 	counts := make(map[string]int)
@@ -331,10 +443,5 @@ func LoadPluginConfigsFromFile(filename string) ([]core.PluginConfig, error) {
 		return nil, core.NewConfigurationError("config", "load-plugins", fmt.Sprintf("failed to read plugin config file: %v", err))
 	}
 
-	var plugins []core.PluginConfig
-	if err := yaml.Unmarshal(data, &plugins); err != nil {
-		return nil, core.NewConfigurationError("config", "parse-plugins", fmt.Sprintf("failed to parse plugin config file: %v", err))
-	}
-
-	return plugins, nil
+	return decodePluginConfigs(data)
 }
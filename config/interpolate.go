@@ -0,0 +1,196 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/habruzzo/agent/core"
+	"gopkg.in/yaml.v3"
+)
+
+// isExtensionKey reports whether a top-level key only holds YAML anchors for the rest of
+// the file, such as x-prometheus; the loader ignores these keys
+func isExtensionKey(key string) bool {
+	return strings.HasPrefix(key, "x-")
+}
+
+// interpolateEnv replaces ${VAR} and ${VAR:-default} references in the scalar values under
+// node with environment variables; $${ stands for a literal ${. Secret references such as
+// ${vault:...} are left for the framework to resolve. A plain scalar is retyped
+// after substitution, so port: ${PORT} decodes as a number. Aliases are not followed: the
+// anchored value is interpolated where it is defined, and every alias shares it.
+func interpolateEnv(node *yaml.Node, lookup func(string) (string, bool)) error {
+	missing := make(map[string]bool)
+	if err := interpolateNode(node, lookup, missing); err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return core.NewConfigurationError("config", "interpolate",
+			fmt.Sprintf("environment variables referenced by the config are not set: %s", strings.Join(names, ", ")))
+	}
+	return nil
+}
+
+// interpolateNode interpolates node and its children, collecting unset variables in missing
+func interpolateNode(node *yaml.Node, lookup func(string) (string, bool), missing map[string]bool) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "${") {
+			return nil
+		}
+		value, err := expandEnv(node.Value, lookup, missing)
+		if err != nil {
+			return core.NewConfigurationError("config", "interpolate", fmt.Sprintf("line %d: %v", node.Line, err))
+		}
+		node.Value = value
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+	case yaml.DocumentNode, yaml.SequenceNode, yaml.MappingNode:
+		for i, child := range node.Content {
+			// Mapping keys are names, not values
+			if node.Kind == yaml.MappingNode && i%2 == 0 {
+				continue
+			}
+			if err := interpolateNode(child, lookup, missing); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expandEnv replaces the references in one value
+func expandEnv(value string, lookup func(string) (string, bool), missing map[string]bool) (string, error) {
+	var out strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			out.WriteString(value)
+			return out.String(), nil
+		}
+		end := strings.IndexByte(value[start:], '}')
+		if end >= 0 && core.IsSecretScheme(value[start+2:start+end]) {
+			// Secret references, escaped or not, are resolved when the plugin loads
+			out.WriteString(value[:start+end+1])
+			value = value[start+end+1:]
+			continue
+		}
+		if start > 0 && value[start-1] == '$' {
+			out.WriteString(value[:start-1] + "${")
+			value = value[start+2:]
+			continue
+		}
+		if end < 0 {
+			return "", fmt.Errorf("unterminated reference in %q", value)
+		}
+		out.WriteString(value[:start])
+
+		reference := value[start+2 : start+end]
+		name, fallback, hasDefault := strings.Cut(reference, ":-")
+		if !validEnvName(name) {
+			return "", fmt.Errorf("invalid environment variable reference ${%s}", reference)
+		}
+		if resolved, ok := lookup(name); ok && (resolved != "" || !hasDefault) {
+			out.WriteString(resolved)
+		} else if hasDefault {
+			out.WriteString(fallback)
+		} else {
+			missing[name] = true
+		}
+		value = value[start+end+1:]
+	}
+}
+
+// validEnvName reports whether name can be an environment variable name
+func validEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// decodeConfig decodes a framework config file. Environment references are interpolated in
+// the plugins section and in the x- keys that hold its anchors; other settings are taken
+// literally, since they have environment variable overrides of their own.
+func decodeConfig(data []byte, config *core.FrameworkConfig) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return core.NewConfigurationError("config", "parse", fmt.Sprintf("failed to parse config file: %v", err))
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			key := root.Content[i].Value
+			if key != "plugins" && !isExtensionKey(key) {
+				continue
+			}
+			if err := interpolateEnv(root.Content[i+1], os.LookupEnv); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := doc.Decode(config); err != nil {
+		return core.NewConfigurationError("config", "parse", fmt.Sprintf("failed to parse config file: %v", err))
+	}
+	return nil
+}
+
+// decodePluginConfigs decodes a plugin config file: either a list of plugins, or a mapping
+// with the list under plugins and x- keys holding anchors the plugins refer to
+func decodePluginConfigs(data []byte) ([]core.PluginConfig, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, core.NewConfigurationError("config", "parse-plugins", fmt.Sprintf("failed to parse plugin config file: %v", err))
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	if err := interpolateEnv(&doc, os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	list := doc.Content[0]
+	if list.Kind == yaml.MappingNode {
+		list = nil
+		root := doc.Content[0]
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			switch key := root.Content[i].Value; {
+			case key == "plugins":
+				list = root.Content[i+1]
+			case !isExtensionKey(key):
+				return nil, core.NewConfigurationError("config", "parse-plugins",
+					fmt.Sprintf("unknown key %q in plugin config file; only plugins and x- keys are allowed", key))
+			}
+		}
+		if list == nil {
+			return nil, core.NewConfigurationError("config", "parse-plugins", "plugin config file has no plugins key")
+		}
+	}
+
+	var plugins []core.PluginConfig
+	if err := list.Decode(&plugins); err != nil {
+		return nil, core.NewConfigurationError("config", "parse-plugins", fmt.Sprintf("failed to parse plugin config file: %v", err))
+	}
+	return plugins, nil
+}
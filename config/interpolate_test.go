@@ -0,0 +1,156 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestExpandEnv(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		value, ok := map[string]string{"HOST": "prom", "PORT": "9090", "EMPTY": ""}[name]
+		return value, ok
+	}
+
+	tests := []struct {
+		value    string
+		expected string
+		missing  []string
+		wantErr  bool
+	}{
+		{value: "http://${HOST}:${PORT}", expected: "http://prom:9090"},
+		{value: "${UNSET:-fallback}", expected: "fallback"},
+		{value: "${EMPTY:-fallback}", expected: "fallback"},
+		{value: "${EMPTY}", expected: ""},
+		{value: "$${HOST} costs $5", expected: "${HOST} costs $5"},
+		{value: "${UNSET}/${ALSO_UNSET}", missing: []string{"ALSO_UNSET", "UNSET"}},
+		{value: "${vault:secret/data/agent#token}", expected: "${vault:secret/data/agent#token}"},
+		{value: "$${file:/etc/key} for ${HOST}", expected: "$${file:/etc/key} for prom"},
+		{value: "${UNSET:-http://fallback}", expected: "http://fallback"},
+		{value: "${HOST", wantErr: true},
+		{value: "${1BAD}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			missing := make(map[string]bool)
+			result, err := expandEnv(tt.value, lookup, missing)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tt.missing != nil {
+				for _, name := range tt.missing {
+					assert.True(t, missing[name], "Expected %s to be reported missing", name)
+				}
+				return
+			}
+			assert.Equal(t, tt.expected, result)
+			assert.Empty(t, missing)
+		})
+	}
+}
+
+func TestInterpolateEnv_RetypesPlainScalars(t *testing.T) {
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("port: ${PORT}\nquoted: \"${PORT}\"\n${PORT}: key\n"), &doc))
+	lookup := func(string) (string, bool) { return "8080", true }
+	require.NoError(t, interpolateEnv(&doc, lookup))
+
+	var decoded map[string]interface{}
+	require.NoError(t, doc.Decode(&decoded))
+	assert.Equal(t, 8080, decoded["port"], "Expected an unquoted reference to decode as a number")
+	assert.Equal(t, "8080", decoded["quoted"], "Expected a quoted reference to stay a string")
+	assert.Equal(t, "key", decoded["${PORT}"], "Expected keys to be left as written")
+}
+
+func TestLoadPluginConfigsFromFile_AnchorsAndEnv(t *testing.T) {
+	t.Setenv("TEST_PROMETHEUS_URL", "http://prometheus.monitoring:9090")
+	t.Setenv("TEST_SLACK_WEBHOOK", "https://hooks.slack.com/services/T000/B000/XXX")
+
+	filename := filepath.Join(t.TempDir(), "plugins.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte(`
+x-prometheus: &prometheus
+  url: ${TEST_PROMETHEUS_URL}
+  interval: ${TEST_INTERVAL:-30s}
+
+plugins:
+  - name: api-metrics
+    type: prometheus
+    enabled: true
+    config:
+      <<: *prometheus
+      queries: ["up{job=\"api\"}"]
+  - name: db-metrics
+    type: prometheus
+    enabled: true
+    config:
+      <<: *prometheus
+      interval: 1m
+  - name: slack
+    type: slack
+    enabled: true
+    config:
+      webhook_url: ${TEST_SLACK_WEBHOOK}
+`), 0o600))
+
+	plugins, err := LoadPluginConfigsFromFile(filename)
+	require.NoError(t, err)
+	require.Len(t, plugins, 3)
+
+	api := plugins[0].Config.(map[string]interface{})
+	assert.Equal(t, "http://prometheus.monitoring:9090", api["url"])
+	assert.Equal(t, "30s", api["interval"])
+	assert.Equal(t, []interface{}{`up{job="api"}`}, api["queries"])
+
+	db := plugins[1].Config.(map[string]interface{})
+	assert.Equal(t, "http://prometheus.monitoring:9090", db["url"])
+	assert.Equal(t, "1m", db["interval"], "Expected keys set beside a merge to override the anchor")
+
+	slack := plugins[2].Config.(map[string]interface{})
+	assert.Equal(t, "https://hooks.slack.com/services/T000/B000/XXX", slack["webhook_url"])
+}
+
+func TestLoadPluginConfigsFromFile_Errors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		filename := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+		return filename
+	}
+
+	_, err := LoadPluginConfigsFromFile(write("missing.yaml", "- name: a\n  config:\n    token: ${TEST_UNSET_TOKEN}\n"))
+	assert.ErrorContains(t, err, "TEST_UNSET_TOKEN")
+
+	_, err = LoadPluginConfigsFromFile(write("unknown.yaml", "defaults: {}\nplugins: []\n"))
+	assert.ErrorContains(t, err, `unknown key "defaults"`)
+
+	_, err = LoadPluginConfigsFromFile(write("empty.yaml", "x-defaults: {}\n"))
+	assert.ErrorContains(t, err, "no plugins key")
+}
+
+func TestLoadConfig_InterpolatesPlugins(t *testing.T) {
+	t.Setenv("TEST_PROMETHEUS_URL", "http://prometheus:9090")
+
+	filename := filepath.Join(t.TempDir(), "framework.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte(`
+log_level: info
+x-prometheus: &prometheus
+  url: ${TEST_PROMETHEUS_URL}
+plugins:
+  - name: prometheus
+    type: collector
+    enabled: true
+    config: *prometheus
+`), 0o600))
+
+	cfg, err := LoadConfig(filename)
+	require.NoError(t, err)
+	require.Len(t, cfg.Plugins, 1)
+	assert.Equal(t, "http://prometheus:9090", cfg.Plugins[0].Config.(map[string]interface{})["url"])
+}
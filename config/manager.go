@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/habruzzo/agent/core"
+)
+
+// FileConfigurationManager loads configuration from YAML files and watches them for
+// changes through OS file notifications. The directory holding each file is watched
+// rather than the file itself, so saves that replace the file, as editors and Kubernetes
+// ConfigMap updates do, are noticed too.
+type FileConfigurationManager struct {
+	settle time.Duration
+	stop   chan struct{}
+	once   sync.Once
+}
+
+// NewFileConfigurationManager creates a manager that reloads a watched file once it has
+// seen no further change for the settle delay, so a burst of writes reloads it once
+func NewFileConfigurationManager(settle time.Duration) *FileConfigurationManager {
+	return &FileConfigurationManager{
+		settle: settle,
+		stop:   make(chan struct{}),
+	}
+}
+
+// LoadConfig loads configuration from a YAML file with environment variable overrides
+func (m *FileConfigurationManager) LoadConfig(filename string) (*core.FrameworkConfig, error) {
+	return LoadConfig(filename)
+}
+
+// ValidateConfig validates a configuration
+func (m *FileConfigurationManager) ValidateConfig(config *core.FrameworkConfig) error {
+	return core.ValidateFrameworkConfig(config)
+}
+
+// SaveConfig saves configuration to a YAML file
+func (m *FileConfigurationManager) SaveConfig(config *core.FrameworkConfig, filename string) error {
+	return SaveConfig(config, filename)
+}
+
+// WatchConfig sends the configuration loaded from filename every time it or its plugin
+// configuration file changes. Changes that fail to load or validate are logged and
+// skipped, so the running configuration stays in effect until the files are fixed.
+func (m *FileConfigurationManager) WatchConfig(filename string) (<-chan *core.FrameworkConfig, error) {
+	config, err := m.LoadConfig(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{filename}
+	if config.PluginConfigFile != "" && config.PluginConfigFile != filename {
+		files = append(files, config.PluginConfigFile)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, core.WrapError(err, core.ErrorTypeConfiguration, "config", "watch", "failed to create file watcher")
+	}
+	// targets maps each watched file to the file its symlinks resolve to
+	targets := make(map[string]string, len(files))
+	dirs := make(map[string]bool, len(files))
+	for _, file := range files {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			watcher.Close()
+			return nil, core.WrapError(err, core.ErrorTypeConfiguration, "config", "watch", fmt.Sprintf("failed to resolve %s", file))
+		}
+		targets[path] = resolveFile(path)
+		if dir := filepath.Dir(path); !dirs[dir] {
+			if err := watcher.Add(dir); err != nil {
+				watcher.Close()
+				return nil, core.WrapError(err, core.ErrorTypeConfiguration, "config", "watch", fmt.Sprintf("failed to watch %s", dir))
+			}
+			dirs[dir] = true
+		}
+	}
+
+	updates := make(chan *core.FrameworkConfig, 1)
+	go func() {
+		defer close(updates)
+		defer watcher.Close()
+
+		var settled <-chan time.Time
+		for {
+			select {
+			case <-m.stop:
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op != fsnotify.Chmod && changesTarget(event, targets) {
+					settled = time.After(m.settle)
+				}
+				continue
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Warn("Configuration file watcher error", "file", filename, "error", err)
+				continue
+			case <-settled:
+				settled = nil
+			}
+
+			config, err := m.LoadConfig(filename)
+			if err != nil {
+				slog.Error("Ignoring invalid configuration change", "file", filename, "error", err)
+				continue
+			}
+			slog.Info("Configuration file changed", "file", filename)
+			select {
+			case updates <- config:
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+
+	return updates, nil
+}
+
+// Close stops every watch the manager started
+func (m *FileConfigurationManager) Close() error {
+	m.once.Do(func() { close(m.stop) })
+	return nil
+}
+
+// changesTarget reports whether an event in a watched directory changes a watched file:
+// either the event names the file, or it replaced what a symlinked file resolves to, as a
+// ConfigMap update swaps its data directory
+func changesTarget(event fsnotify.Event, targets map[string]string) bool {
+	if _, ok := targets[filepath.Clean(event.Name)]; ok {
+		return true
+	}
+	changed := false
+	for path, resolved := range targets {
+		if current := resolveFile(path); current != resolved {
+			targets[path] = current
+			changed = true
+		}
+	}
+	return changed
+}
+
+// resolveFile returns the file a path's symlinks lead to, or an empty string when it
+// cannot be resolved, as for a file that does not exist
+func resolveFile(path string) string {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return ""
+	}
+	return resolved
+}
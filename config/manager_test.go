@@ -0,0 +1,111 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileConfigurationManager_WatchConfig(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "framework.yaml")
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+	}
+	write("log_level: info\nplugin_config_file: " + filepath.Join(dir, "plugins.yaml") + "\n")
+
+	manager := NewFileConfigurationManager(10 * time.Millisecond)
+	defer manager.Close()
+	updates, err := manager.WatchConfig(filename)
+	require.NoError(t, err)
+
+	// An invalid change is skipped rather than sent
+	write("log_level: loud\nplugin_config_file: " + filepath.Join(dir, "plugins.yaml") + "\n")
+	select {
+	case config := <-updates:
+		t.Fatalf("Expected an invalid configuration to be skipped, got log level %s", config.LogLevel)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	write("log_level: debug\nplugin_config_file: " + filepath.Join(dir, "plugins.yaml") + "\n")
+	select {
+	case config := <-updates:
+		assert.Equal(t, "debug", config.LogLevel)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the changed configuration to be sent")
+	}
+
+	manager.Close()
+	assert.Eventually(t, func() bool {
+		_, ok := <-updates
+		return !ok
+	}, time.Second, 10*time.Millisecond, "Expected Close to end the watch")
+}
+
+// expectLogLevel waits for a configuration update with the given log level
+func expectLogLevel(t *testing.T, updates <-chan *core.FrameworkConfig, level, why string) {
+	t.Helper()
+	select {
+	case config := <-updates:
+		assert.Equal(t, level, config.LogLevel, why)
+	case <-time.After(2 * time.Second):
+		t.Fatal(why)
+	}
+}
+
+func TestFileConfigurationManager_WatchEdits(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "framework.yaml")
+	content := func(level string) []byte {
+		return []byte("log_level: " + level + "\nplugin_config_file: " + filepath.Join(dir, "plugins.yaml") + "\n")
+	}
+	require.NoError(t, os.WriteFile(filename, content("info"), 0644))
+
+	manager := NewFileConfigurationManager(10 * time.Millisecond)
+	defer manager.Close()
+	updates, err := manager.WatchConfig(filename)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filename, content("debug"), 0644))
+	expectLogLevel(t, updates, "debug", "Expected the edited file to be reloaded")
+
+	// Same size, and usually within the file system's modification time granularity
+	require.NoError(t, os.WriteFile(filename, content("error"), 0644))
+	expectLogLevel(t, updates, "error", "Expected an edit keeping the size to be reloaded")
+
+	// Editors write a temporary file and rename it over the original
+	temp := filepath.Join(dir, ".framework.yaml.swp")
+	require.NoError(t, os.WriteFile(temp, content("warn"), 0644))
+	require.NoError(t, os.Rename(temp, filename))
+	expectLogLevel(t, updates, "warn", "Expected a file renamed over the original to be reloaded")
+}
+
+func TestFileConfigurationManager_WatchConfigMap(t *testing.T) {
+	// Kubernetes mounts a ConfigMap key as a symlink through ..data, which updates point
+	// to a new directory
+	dir := t.TempDir()
+	write := func(version, level string) {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, version), 0755))
+		content := "log_level: " + level + "\nplugin_config_file: " + filepath.Join(dir, "plugins.yaml") + "\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, version, "framework.yaml"), []byte(content), 0644))
+	}
+	write("v1", "info")
+	require.NoError(t, os.Symlink("v1", filepath.Join(dir, "..data")))
+	filename := filepath.Join(dir, "framework.yaml")
+	require.NoError(t, os.Symlink(filepath.Join("..data", "framework.yaml"), filename))
+
+	manager := NewFileConfigurationManager(10 * time.Millisecond)
+	defer manager.Close()
+	updates, err := manager.WatchConfig(filename)
+	require.NoError(t, err)
+
+	write("v2", "debug")
+	require.NoError(t, os.Symlink("v2", filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+	expectLogLevel(t, updates, "debug", "Expected the swapped ConfigMap data to be reloaded")
+}
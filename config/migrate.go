@@ -0,0 +1,256 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+
+	"github.com/habruzzo/agent/core"
+	"gopkg.in/yaml.v3"
+)
+
+// legacySections maps the nested sections of older configuration files to the flat keys
+// that replaced them
+var legacySections = map[string]map[string]string{
+	"logging": {
+		"level":  "log_level",
+		"format": "log_format",
+		"output": "log_output",
+	},
+	"server": {
+		"host": "server_host",
+		"port": "server_port",
+	},
+	"agent": {
+		"default_agent": "default_agent",
+		"ai_api_key":    "ai_api_key",
+		"ai_api_url":    "ai_api_url",
+	},
+	"prometheus": {
+		"enabled": "prometheus_enabled",
+		"url":     "prometheus_url",
+	},
+}
+
+// legacyPluginTypes maps plugin types that were renamed to their current names
+var legacyPluginTypes = map[string]string{
+	"logger": "log",
+}
+
+// legacyPluginKeys maps renamed plugin config keys, per plugin type
+var legacyPluginKeys = map[string]map[string]string{
+	"prometheus": {
+		"endpoint":        "url",
+		"scrape_interval": "interval",
+	},
+}
+
+// KeyChange records a deprecated key and what replaced it
+type KeyChange struct {
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to" yaml:"to"`
+}
+
+// MigrationReport describes what a migration changed and what it could not carry over
+type MigrationReport struct {
+	// Renamed keys and values that were moved to their current names
+	Renamed []KeyChange `json:"renamed,omitempty" yaml:"renamed,omitempty"`
+	// Deprecated keys dropped because the current key was also set and takes precedence
+	Conflicts []KeyChange `json:"conflicts,omitempty" yaml:"conflicts,omitempty"`
+	// Keys the loader does not know; they are kept in the file but have no effect
+	Unknown []string `json:"unknown,omitempty" yaml:"unknown,omitempty"`
+}
+
+// Changed reports whether the migration modified the configuration
+func (r *MigrationReport) Changed() bool {
+	return len(r.Renamed) > 0 || len(r.Conflicts) > 0
+}
+
+// warnDeprecated logs a deprecation warning for every key that had to be migrated
+func (r *MigrationReport) warnDeprecated(filename string) {
+	for _, change := range r.Renamed {
+		slog.Warn("Deprecated config key, run 'agent config migrate' to update the file",
+			"file", filename, "key", change.From, "replacement", change.To)
+	}
+	for _, change := range r.Conflicts {
+		slog.Warn("Deprecated config key ignored because its replacement is also set",
+			"file", filename, "key", change.From, "replacement", change.To)
+	}
+}
+
+// MigrateConfig upgrades a framework configuration file to the current schema. Key order
+// and comments are preserved; deprecated keys are reported rather than silently dropped.
+func MigrateConfig(data []byte) ([]byte, *MigrationReport, error) {
+	report := &MigrationReport{}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, core.NewConfigurationError("config", "migrate", fmt.Sprintf("failed to parse config file: %v", err))
+	}
+	if len(doc.Content) == 0 {
+		return data, report, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, core.NewConfigurationError("config", "migrate", "config file must be a YAML mapping")
+	}
+
+	migrateRoot(root, report)
+	if !report.Changed() {
+		return data, report, nil
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, nil, core.NewConfigurationError("config", "migrate", fmt.Sprintf("failed to encode config: %v", err))
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, nil, core.NewConfigurationError("config", "migrate", fmt.Sprintf("failed to encode config: %v", err))
+	}
+	return buf.Bytes(), report, nil
+}
+
+// migrateRoot flattens legacy sections in place and migrates plugin entries
+func migrateRoot(root *yaml.Node, report *MigrationReport) {
+	known := frameworkConfigKeys()
+
+	present := make(map[string]bool)
+	for i := 0; i < len(root.Content); i += 2 {
+		present[root.Content[i].Value] = true
+	}
+
+	content := make([]*yaml.Node, 0, len(root.Content))
+	for i := 0; i < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+
+		mapping, legacy := legacySections[key.Value]
+		if legacy && value.Kind == yaml.MappingNode && !known[key.Value] {
+			flattened := flattenSection(key.Value, value, mapping, present, report)
+			if len(flattened) > 0 && key.HeadComment != "" {
+				flattened[0].HeadComment = key.HeadComment
+			}
+			content = append(content, flattened...)
+			continue
+		}
+
+		switch {
+		case key.Value == "plugins" && value.Kind == yaml.SequenceNode:
+			migratePlugins(value, report)
+		case !known[key.Value] && !isExtensionKey(key.Value):
+			report.Unknown = append(report.Unknown, key.Value)
+		}
+		content = append(content, key, value)
+	}
+	root.Content = content
+}
+
+// flattenSection turns the entries of a legacy section into top-level pairs
+func flattenSection(section string, value *yaml.Node, mapping map[string]string, present map[string]bool, report *MigrationReport) []*yaml.Node {
+	var flattened []*yaml.Node
+	for j := 0; j < len(value.Content); j += 2 {
+		key, val := value.Content[j], value.Content[j+1]
+		from := section + "." + key.Value
+
+		to, ok := mapping[key.Value]
+		if !ok {
+			report.Unknown = append(report.Unknown, from)
+			continue
+		}
+		if present[to] {
+			report.Conflicts = append(report.Conflicts, KeyChange{From: from, To: to})
+			continue
+		}
+
+		present[to] = true
+		key.Value = to
+		flattened = append(flattened, key, val)
+		report.Renamed = append(report.Renamed, KeyChange{From: from, To: to})
+	}
+	return flattened
+}
+
+// migratePlugins renames legacy plugin types and config keys
+func migratePlugins(plugins *yaml.Node, report *MigrationReport) {
+	for i, plugin := range plugins.Content {
+		if plugin.Kind != yaml.MappingNode {
+			continue
+		}
+
+		prefix := fmt.Sprintf("plugins[%d]", i)
+		if name := mappingValue(plugin, "name"); name != nil && name.Value != "" {
+			prefix = fmt.Sprintf("plugins[%s]", name.Value)
+		}
+
+		pluginType := mappingValue(plugin, "type")
+		if pluginType == nil {
+			continue
+		}
+		if current, ok := legacyPluginTypes[pluginType.Value]; ok {
+			report.Renamed = append(report.Renamed, KeyChange{
+				From: fmt.Sprintf("%s.type=%s", prefix, pluginType.Value),
+				To:   fmt.Sprintf("%s.type=%s", prefix, current),
+			})
+			pluginType.Value = current
+		}
+
+		renames, ok := legacyPluginKeys[pluginType.Value]
+		if !ok {
+			continue
+		}
+		settings := mappingValue(plugin, "config")
+		if settings == nil || settings.Kind != yaml.MappingNode {
+			continue
+		}
+		renameKeys(settings, renames, prefix+".config", report)
+	}
+}
+
+// renameKeys renames keys of a mapping, dropping the old key when the new one is also set
+func renameKeys(mapping *yaml.Node, renames map[string]string, prefix string, report *MigrationReport) {
+	content := make([]*yaml.Node, 0, len(mapping.Content))
+	for i := 0; i < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		to, ok := renames[key.Value]
+		if !ok {
+			content = append(content, key, value)
+			continue
+		}
+
+		change := KeyChange{From: prefix + "." + key.Value, To: prefix + "." + to}
+		if mappingValue(mapping, to) != nil {
+			report.Conflicts = append(report.Conflicts, change)
+			continue
+		}
+		key.Value = to
+		content = append(content, key, value)
+		report.Renamed = append(report.Renamed, change)
+	}
+	mapping.Content = content
+}
+
+// mappingValue returns the value of a key in a mapping node, or nil
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// frameworkConfigKeys returns the top-level YAML keys the loader understands
+func frameworkConfigKeys() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(core.FrameworkConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
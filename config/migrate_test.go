@@ -0,0 +1,129 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMigrateConfig_LegacySections(t *testing.T) {
+	legacy := `# Agent configuration
+logging:
+  level: debug
+  format: json
+  output: stdout
+
+server:
+  host: 127.0.0.1
+  port: 8080
+
+agent:
+  default_agent: ai-agent
+
+worker_pool_size: 8
+`
+	migrated, report, err := MigrateConfig([]byte(legacy))
+	require.NoError(t, err)
+	assert.True(t, report.Changed())
+	assert.Empty(t, report.Unknown)
+	assert.Contains(t, report.Renamed, KeyChange{From: "logging.level", To: "log_level"})
+	assert.Contains(t, report.Renamed, KeyChange{From: "server.port", To: "server_port"})
+	assert.Contains(t, report.Renamed, KeyChange{From: "agent.default_agent", To: "default_agent"})
+	assert.Contains(t, string(migrated), "# Agent configuration")
+
+	var raw map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(migrated, &raw))
+	assert.NotContains(t, raw, "logging")
+	assert.NotContains(t, raw, "server")
+
+	// The migrated file must decode into the current schema with the settings intact
+	var config core.FrameworkConfig
+	require.NoError(t, yaml.Unmarshal(migrated, &config))
+	assert.Equal(t, "debug", config.LogLevel)
+	assert.Equal(t, "json", config.LogFormat)
+	assert.Equal(t, "127.0.0.1", config.ServerHost)
+	assert.Equal(t, 8080, config.ServerPort)
+	assert.Equal(t, "ai-agent", config.DefaultAgent)
+	assert.Equal(t, 8, config.WorkerPoolSize)
+}
+
+func TestMigrateConfig_Plugins(t *testing.T) {
+	legacy := `plugins:
+  - name: prom
+    type: prometheus
+    config:
+      endpoint: http://prometheus:9090
+      scrape_interval: 15s
+  - name: logger
+    type: logger
+    config:
+      level: info
+`
+	migrated, report, err := MigrateConfig([]byte(legacy))
+	require.NoError(t, err)
+	assert.Contains(t, report.Renamed, KeyChange{From: "plugins[prom].config.endpoint", To: "plugins[prom].config.url"})
+	assert.Contains(t, report.Renamed, KeyChange{From: "plugins[logger].type=logger", To: "plugins[logger].type=log"})
+
+	var raw struct {
+		Plugins []struct {
+			Type   string                 `yaml:"type"`
+			Config map[string]interface{} `yaml:"config"`
+		} `yaml:"plugins"`
+	}
+	require.NoError(t, yaml.Unmarshal(migrated, &raw))
+	require.Len(t, raw.Plugins, 2)
+	assert.Equal(t, "http://prometheus:9090", raw.Plugins[0].Config["url"])
+	assert.Equal(t, "15s", raw.Plugins[0].Config["interval"])
+	assert.NotContains(t, raw.Plugins[0].Config, "endpoint")
+	assert.Equal(t, "log", raw.Plugins[1].Type)
+}
+
+func TestMigrateConfig_ConflictsAndUnknownKeys(t *testing.T) {
+	legacy := `log_level: warn
+logging:
+  level: debug
+  rotate: true
+tracing_enabled: true
+`
+	migrated, report, err := MigrateConfig([]byte(legacy))
+	require.NoError(t, err)
+	assert.Equal(t, []KeyChange{{From: "logging.level", To: "log_level"}}, report.Conflicts)
+	assert.ElementsMatch(t, []string{"logging.rotate", "tracing_enabled"}, report.Unknown)
+
+	var raw map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(migrated, &raw))
+	assert.Equal(t, "warn", raw["log_level"])
+	assert.Equal(t, true, raw["tracing_enabled"])
+}
+
+func TestMigrateConfig_CurrentConfigUnchanged(t *testing.T) {
+	current := "log_level: info\nserver_port: 9090\n"
+	migrated, report, err := MigrateConfig([]byte(current))
+	require.NoError(t, err)
+	assert.False(t, report.Changed())
+	assert.Equal(t, current, string(migrated))
+}
+
+func TestLoadConfig_LegacySchema(t *testing.T) {
+	legacy := `logging:
+  level: debug
+  format: json
+log_output: stderr
+agent:
+  default_agent: ai-agent
+`
+	path := filepath.Join(t.TempDir(), "framework.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(legacy), 0644))
+
+	config, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "debug", config.LogLevel)
+	assert.Equal(t, "json", config.LogFormat)
+	assert.Equal(t, "stderr", config.LogOutput, "Expected current keys to be kept alongside migrated ones")
+	assert.Equal(t, "ai-agent", config.DefaultAgent)
+}
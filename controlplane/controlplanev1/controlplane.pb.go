@@ -0,0 +1,1415 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: controlplane/controlplanev1/controlplane.proto
+
+package controlplanev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusRequest) Reset() {
+	*x = GetStatusRequest{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusRequest) ProtoMessage() {}
+
+func (x *GetStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetStatusRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{0}
+}
+
+type WatchStatusRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// How often the status is sent; 5s when unset, at least 1s
+	Interval      *durationpb.Duration `protobuf:"bytes,1,opt,name=interval,proto3" json:"interval,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchStatusRequest) Reset() {
+	*x = WatchStatusRequest{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchStatusRequest) ProtoMessage() {}
+
+func (x *WatchStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchStatusRequest.ProtoReflect.Descriptor instead.
+func (*WatchStatusRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *WatchStatusRequest) GetInterval() *durationpb.Duration {
+	if x != nil {
+		return x.Interval
+	}
+	return nil
+}
+
+// Status is the framework status with the state of every plugin and health check
+type Status struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Running  bool                   `protobuf:"varint,1,opt,name=running,proto3" json:"running,omitempty"`
+	ReadOnly bool                   `protobuf:"varint,2,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	DryRun   bool                   `protobuf:"varint,3,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	// healthy, degraded, or unhealthy
+	Health        string                  `protobuf:"bytes,4,opt,name=health,proto3" json:"health,omitempty"`
+	HealthMessage string                  `protobuf:"bytes,5,opt,name=health_message,json=healthMessage,proto3" json:"health_message,omitempty"`
+	Uptime        *durationpb.Duration    `protobuf:"bytes,6,opt,name=uptime,proto3" json:"uptime,omitempty"`
+	Plugins       []*Plugin               `protobuf:"bytes,7,rep,name=plugins,proto3" json:"plugins,omitempty"`
+	Checks        map[string]*HealthCheck `protobuf:"bytes,8,rep,name=checks,proto3" json:"checks,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Timestamp     *timestamppb.Timestamp  `protobuf:"bytes,9,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Status) Reset() {
+	*x = Status{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Status) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Status) ProtoMessage() {}
+
+func (x *Status) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Status.ProtoReflect.Descriptor instead.
+func (*Status) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Status) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *Status) GetReadOnly() bool {
+	if x != nil {
+		return x.ReadOnly
+	}
+	return false
+}
+
+func (x *Status) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *Status) GetHealth() string {
+	if x != nil {
+		return x.Health
+	}
+	return ""
+}
+
+func (x *Status) GetHealthMessage() string {
+	if x != nil {
+		return x.HealthMessage
+	}
+	return ""
+}
+
+func (x *Status) GetUptime() *durationpb.Duration {
+	if x != nil {
+		return x.Uptime
+	}
+	return nil
+}
+
+func (x *Status) GetPlugins() []*Plugin {
+	if x != nil {
+		return x.Plugins
+	}
+	return nil
+}
+
+func (x *Status) GetChecks() map[string]*HealthCheck {
+	if x != nil {
+		return x.Checks
+	}
+	return nil
+}
+
+func (x *Status) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+// HealthCheck is the result of one health check
+type HealthCheck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthCheck) Reset() {
+	*x = HealthCheck{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthCheck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthCheck) ProtoMessage() {}
+
+func (x *HealthCheck) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthCheck.ProtoReflect.Descriptor instead.
+func (*HealthCheck) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *HealthCheck) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *HealthCheck) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *HealthCheck) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Plugin is the status and health of a loaded plugin
+type Plugin struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// collector, analyzer, responder, or agent
+	Type          string               `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Status        string               `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Healthy       bool                 `protobuf:"varint,4,opt,name=healthy,proto3" json:"healthy,omitempty"`
+	Error         string               `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	StartDuration *durationpb.Duration `protobuf:"bytes,6,opt,name=start_duration,json=startDuration,proto3" json:"start_duration,omitempty"`
+	StartError    string               `protobuf:"bytes,7,opt,name=start_error,json=startError,proto3" json:"start_error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Plugin) Reset() {
+	*x = Plugin{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Plugin) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Plugin) ProtoMessage() {}
+
+func (x *Plugin) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Plugin.ProtoReflect.Descriptor instead.
+func (*Plugin) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Plugin) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Plugin) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Plugin) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Plugin) GetHealthy() bool {
+	if x != nil {
+		return x.Healthy
+	}
+	return false
+}
+
+func (x *Plugin) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Plugin) GetStartDuration() *durationpb.Duration {
+	if x != nil {
+		return x.StartDuration
+	}
+	return nil
+}
+
+func (x *Plugin) GetStartError() string {
+	if x != nil {
+		return x.StartError
+	}
+	return ""
+}
+
+type ListPluginsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPluginsRequest) Reset() {
+	*x = ListPluginsRequest{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPluginsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPluginsRequest) ProtoMessage() {}
+
+func (x *ListPluginsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPluginsRequest.ProtoReflect.Descriptor instead.
+func (*ListPluginsRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{5}
+}
+
+type ListPluginsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Plugins       []*Plugin              `protobuf:"bytes,1,rep,name=plugins,proto3" json:"plugins,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPluginsResponse) Reset() {
+	*x = ListPluginsResponse{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPluginsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPluginsResponse) ProtoMessage() {}
+
+func (x *ListPluginsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPluginsResponse.ProtoReflect.Descriptor instead.
+func (*ListPluginsResponse) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListPluginsResponse) GetPlugins() []*Plugin {
+	if x != nil {
+		return x.Plugins
+	}
+	return nil
+}
+
+type GetPluginRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPluginRequest) Reset() {
+	*x = GetPluginRequest{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPluginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPluginRequest) ProtoMessage() {}
+
+func (x *GetPluginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPluginRequest.ProtoReflect.Descriptor instead.
+func (*GetPluginRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetPluginRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type LoadPluginRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// A registered plugin type, such as prometheus or external
+	Type          string           `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Config        *structpb.Struct `protobuf:"bytes,3,opt,name=config,proto3" json:"config,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoadPluginRequest) Reset() {
+	*x = LoadPluginRequest{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoadPluginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoadPluginRequest) ProtoMessage() {}
+
+func (x *LoadPluginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoadPluginRequest.ProtoReflect.Descriptor instead.
+func (*LoadPluginRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *LoadPluginRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *LoadPluginRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *LoadPluginRequest) GetConfig() *structpb.Struct {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+type UnloadPluginRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnloadPluginRequest) Reset() {
+	*x = UnloadPluginRequest{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnloadPluginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnloadPluginRequest) ProtoMessage() {}
+
+func (x *UnloadPluginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnloadPluginRequest.ProtoReflect.Descriptor instead.
+func (*UnloadPluginRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UnloadPluginRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type UnloadPluginResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnloadPluginResponse) Reset() {
+	*x = UnloadPluginResponse{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnloadPluginResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnloadPluginResponse) ProtoMessage() {}
+
+func (x *UnloadPluginResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnloadPluginResponse.ProtoReflect.Descriptor instead.
+func (*UnloadPluginResponse) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{10}
+}
+
+type GetPluginConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPluginConfigRequest) Reset() {
+	*x = GetPluginConfigRequest{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPluginConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPluginConfigRequest) ProtoMessage() {}
+
+func (x *GetPluginConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPluginConfigRequest.ProtoReflect.Descriptor instead.
+func (*GetPluginConfigRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetPluginConfigRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// PluginConfig is the settings a plugin runs with
+type PluginConfig struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Settings      *structpb.Struct       `protobuf:"bytes,2,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PluginConfig) Reset() {
+	*x = PluginConfig{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PluginConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PluginConfig) ProtoMessage() {}
+
+func (x *PluginConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PluginConfig.ProtoReflect.Descriptor instead.
+func (*PluginConfig) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *PluginConfig) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PluginConfig) GetSettings() *structpb.Struct {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type ReconfigurePluginRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Settings to change; those not given are kept
+	Settings      *structpb.Struct `protobuf:"bytes,2,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReconfigurePluginRequest) Reset() {
+	*x = ReconfigurePluginRequest{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReconfigurePluginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReconfigurePluginRequest) ProtoMessage() {}
+
+func (x *ReconfigurePluginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReconfigurePluginRequest.ProtoReflect.Descriptor instead.
+func (*ReconfigurePluginRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ReconfigurePluginRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ReconfigurePluginRequest) GetSettings() *structpb.Struct {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type ReconfigurePluginResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReconfigurePluginResponse) Reset() {
+	*x = ReconfigurePluginResponse{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReconfigurePluginResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReconfigurePluginResponse) ProtoMessage() {}
+
+func (x *ReconfigurePluginResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReconfigurePluginResponse.ProtoReflect.Descriptor instead.
+func (*ReconfigurePluginResponse) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{14}
+}
+
+type QueryAgentRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The default agent when empty
+	Agent         string `protobuf:"bytes,1,opt,name=agent,proto3" json:"agent,omitempty"`
+	Query         string `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryAgentRequest) Reset() {
+	*x = QueryAgentRequest{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryAgentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryAgentRequest) ProtoMessage() {}
+
+func (x *QueryAgentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryAgentRequest.ProtoReflect.Descriptor instead.
+func (*QueryAgentRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *QueryAgentRequest) GetAgent() string {
+	if x != nil {
+		return x.Agent
+	}
+	return ""
+}
+
+func (x *QueryAgentRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+// AgentResponse is an agent's answer to a query
+type AgentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Response      string                 `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`
+	Confidence    float64                `protobuf:"fixed64,3,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	Actions       []*AgentAction         `protobuf:"bytes,4,rep,name=actions,proto3" json:"actions,omitempty"`
+	Metadata      *structpb.Struct       `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentResponse) Reset() {
+	*x = AgentResponse{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentResponse) ProtoMessage() {}
+
+func (x *AgentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentResponse.ProtoReflect.Descriptor instead.
+func (*AgentResponse) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *AgentResponse) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *AgentResponse) GetResponse() string {
+	if x != nil {
+		return x.Response
+	}
+	return ""
+}
+
+func (x *AgentResponse) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *AgentResponse) GetActions() []*AgentAction {
+	if x != nil {
+		return x.Actions
+	}
+	return nil
+}
+
+func (x *AgentResponse) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *AgentResponse) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+// AgentAction is an action an agent suggests
+type AgentAction struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Type        string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Description string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Parameters  *structpb.Struct       `protobuf:"bytes,3,opt,name=parameters,proto3" json:"parameters,omitempty"`
+	// Service or resource the action acts on
+	Target string `protobuf:"bytes,4,opt,name=target,proto3" json:"target,omitempty"`
+	// low, medium, or high
+	Risk          string `protobuf:"bytes,5,opt,name=risk,proto3" json:"risk,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentAction) Reset() {
+	*x = AgentAction{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentAction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentAction) ProtoMessage() {}
+
+func (x *AgentAction) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentAction.ProtoReflect.Descriptor instead.
+func (*AgentAction) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *AgentAction) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *AgentAction) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *AgentAction) GetParameters() *structpb.Struct {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *AgentAction) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *AgentAction) GetRisk() string {
+	if x != nil {
+		return x.Risk
+	}
+	return ""
+}
+
+type QueryAgentBatchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The default agent when empty
+	Agent         string   `protobuf:"bytes,1,opt,name=agent,proto3" json:"agent,omitempty"`
+	Queries       []string `protobuf:"bytes,2,rep,name=queries,proto3" json:"queries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryAgentBatchRequest) Reset() {
+	*x = QueryAgentBatchRequest{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryAgentBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryAgentBatchRequest) ProtoMessage() {}
+
+func (x *QueryAgentBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryAgentBatchRequest.ProtoReflect.Descriptor instead.
+func (*QueryAgentBatchRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *QueryAgentBatchRequest) GetAgent() string {
+	if x != nil {
+		return x.Agent
+	}
+	return ""
+}
+
+func (x *QueryAgentBatchRequest) GetQueries() []string {
+	if x != nil {
+		return x.Queries
+	}
+	return nil
+}
+
+type QueryAgentBatchResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// In query order
+	Results       []*AgentBatchResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryAgentBatchResponse) Reset() {
+	*x = QueryAgentBatchResponse{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryAgentBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryAgentBatchResponse) ProtoMessage() {}
+
+func (x *QueryAgentBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryAgentBatchResponse.ProtoReflect.Descriptor instead.
+func (*QueryAgentBatchResponse) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *QueryAgentBatchResponse) GetResults() []*AgentBatchResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// AgentBatchResult is the answer to one query of a batch, or why it failed
+type AgentBatchResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Response      *AgentResponse         `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	Duration      *durationpb.Duration   `protobuf:"bytes,4,opt,name=duration,proto3" json:"duration,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentBatchResult) Reset() {
+	*x = AgentBatchResult{}
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentBatchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentBatchResult) ProtoMessage() {}
+
+func (x *AgentBatchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_controlplanev1_controlplane_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentBatchResult.ProtoReflect.Descriptor instead.
+func (*AgentBatchResult) Descriptor() ([]byte, []int) {
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *AgentBatchResult) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *AgentBatchResult) GetResponse() *AgentResponse {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *AgentBatchResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *AgentBatchResult) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+var File_controlplane_controlplanev1_controlplane_proto protoreflect.FileDescriptor
+
+const file_controlplane_controlplanev1_controlplane_proto_rawDesc = "" +
+	"\n" +
+	".controlplane/controlplanev1/controlplane.proto\x12\x15agent.controlplane.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x12\n" +
+	"\x10GetStatusRequest\"K\n" +
+	"\x12WatchStatusRequest\x125\n" +
+	"\binterval\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\binterval\"\xdf\x03\n" +
+	"\x06Status\x12\x18\n" +
+	"\arunning\x18\x01 \x01(\bR\arunning\x12\x1b\n" +
+	"\tread_only\x18\x02 \x01(\bR\breadOnly\x12\x17\n" +
+	"\adry_run\x18\x03 \x01(\bR\x06dryRun\x12\x16\n" +
+	"\x06health\x18\x04 \x01(\tR\x06health\x12%\n" +
+	"\x0ehealth_message\x18\x05 \x01(\tR\rhealthMessage\x121\n" +
+	"\x06uptime\x18\x06 \x01(\v2\x19.google.protobuf.DurationR\x06uptime\x127\n" +
+	"\aplugins\x18\a \x03(\v2\x1d.agent.controlplane.v1.PluginR\aplugins\x12A\n" +
+	"\x06checks\x18\b \x03(\v2).agent.controlplane.v1.Status.ChecksEntryR\x06checks\x128\n" +
+	"\ttimestamp\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x1a]\n" +
+	"\vChecksEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x128\n" +
+	"\x05value\x18\x02 \x01(\v2\".agent.controlplane.v1.HealthCheckR\x05value:\x028\x01\"U\n" +
+	"\vHealthCheck\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\xdb\x01\n" +
+	"\x06Plugin\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x18\n" +
+	"\ahealthy\x18\x04 \x01(\bR\ahealthy\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12@\n" +
+	"\x0estart_duration\x18\x06 \x01(\v2\x19.google.protobuf.DurationR\rstartDuration\x12\x1f\n" +
+	"\vstart_error\x18\a \x01(\tR\n" +
+	"startError\"\x14\n" +
+	"\x12ListPluginsRequest\"N\n" +
+	"\x13ListPluginsResponse\x127\n" +
+	"\aplugins\x18\x01 \x03(\v2\x1d.agent.controlplane.v1.PluginR\aplugins\"&\n" +
+	"\x10GetPluginRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"l\n" +
+	"\x11LoadPluginRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12/\n" +
+	"\x06config\x18\x03 \x01(\v2\x17.google.protobuf.StructR\x06config\")\n" +
+	"\x13UnloadPluginRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\x16\n" +
+	"\x14UnloadPluginResponse\",\n" +
+	"\x16GetPluginConfigRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"W\n" +
+	"\fPluginConfig\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x123\n" +
+	"\bsettings\x18\x02 \x01(\v2\x17.google.protobuf.StructR\bsettings\"c\n" +
+	"\x18ReconfigurePluginRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x123\n" +
+	"\bsettings\x18\x02 \x01(\v2\x17.google.protobuf.StructR\bsettings\"\x1b\n" +
+	"\x19ReconfigurePluginResponse\"?\n" +
+	"\x11QueryAgentRequest\x12\x14\n" +
+	"\x05agent\x18\x01 \x01(\tR\x05agent\x12\x14\n" +
+	"\x05query\x18\x02 \x01(\tR\x05query\"\x8e\x02\n" +
+	"\rAgentResponse\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x1a\n" +
+	"\bresponse\x18\x02 \x01(\tR\bresponse\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x03 \x01(\x01R\n" +
+	"confidence\x12<\n" +
+	"\aactions\x18\x04 \x03(\v2\".agent.controlplane.v1.AgentActionR\aactions\x123\n" +
+	"\bmetadata\x18\x05 \x01(\v2\x17.google.protobuf.StructR\bmetadata\x128\n" +
+	"\ttimestamp\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"\xa8\x01\n" +
+	"\vAgentAction\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x127\n" +
+	"\n" +
+	"parameters\x18\x03 \x01(\v2\x17.google.protobuf.StructR\n" +
+	"parameters\x12\x16\n" +
+	"\x06target\x18\x04 \x01(\tR\x06target\x12\x12\n" +
+	"\x04risk\x18\x05 \x01(\tR\x04risk\"H\n" +
+	"\x16QueryAgentBatchRequest\x12\x14\n" +
+	"\x05agent\x18\x01 \x01(\tR\x05agent\x12\x18\n" +
+	"\aqueries\x18\x02 \x03(\tR\aqueries\"\\\n" +
+	"\x17QueryAgentBatchResponse\x12A\n" +
+	"\aresults\x18\x01 \x03(\v2'.agent.controlplane.v1.AgentBatchResultR\aresults\"\xb7\x01\n" +
+	"\x10AgentBatchResult\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12@\n" +
+	"\bresponse\x18\x02 \x01(\v2$.agent.controlplane.v1.AgentResponseR\bresponse\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x125\n" +
+	"\bduration\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\bduration2\xe8\a\n" +
+	"\fControlPlane\x12S\n" +
+	"\tGetStatus\x12'.agent.controlplane.v1.GetStatusRequest\x1a\x1d.agent.controlplane.v1.Status\x12Y\n" +
+	"\vWatchStatus\x12).agent.controlplane.v1.WatchStatusRequest\x1a\x1d.agent.controlplane.v1.Status0\x01\x12d\n" +
+	"\vListPlugins\x12).agent.controlplane.v1.ListPluginsRequest\x1a*.agent.controlplane.v1.ListPluginsResponse\x12S\n" +
+	"\tGetPlugin\x12'.agent.controlplane.v1.GetPluginRequest\x1a\x1d.agent.controlplane.v1.Plugin\x12U\n" +
+	"\n" +
+	"LoadPlugin\x12(.agent.controlplane.v1.LoadPluginRequest\x1a\x1d.agent.controlplane.v1.Plugin\x12g\n" +
+	"\fUnloadPlugin\x12*.agent.controlplane.v1.UnloadPluginRequest\x1a+.agent.controlplane.v1.UnloadPluginResponse\x12e\n" +
+	"\x0fGetPluginConfig\x12-.agent.controlplane.v1.GetPluginConfigRequest\x1a#.agent.controlplane.v1.PluginConfig\x12v\n" +
+	"\x11ReconfigurePlugin\x12/.agent.controlplane.v1.ReconfigurePluginRequest\x1a0.agent.controlplane.v1.ReconfigurePluginResponse\x12\\\n" +
+	"\n" +
+	"QueryAgent\x12(.agent.controlplane.v1.QueryAgentRequest\x1a$.agent.controlplane.v1.AgentResponse\x12p\n" +
+	"\x0fQueryAgentBatch\x12-.agent.controlplane.v1.QueryAgentBatchRequest\x1a..agent.controlplane.v1.QueryAgentBatchResponseBFZDgithub.com/habruzzo/agent/controlplane/controlplanev1;controlplanev1b\x06proto3"
+
+var (
+	file_controlplane_controlplanev1_controlplane_proto_rawDescOnce sync.Once
+	file_controlplane_controlplanev1_controlplane_proto_rawDescData []byte
+)
+
+func file_controlplane_controlplanev1_controlplane_proto_rawDescGZIP() []byte {
+	file_controlplane_controlplanev1_controlplane_proto_rawDescOnce.Do(func() {
+		file_controlplane_controlplanev1_controlplane_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_controlplane_controlplanev1_controlplane_proto_rawDesc), len(file_controlplane_controlplanev1_controlplane_proto_rawDesc)))
+	})
+	return file_controlplane_controlplanev1_controlplane_proto_rawDescData
+}
+
+var file_controlplane_controlplanev1_controlplane_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
+var file_controlplane_controlplanev1_controlplane_proto_goTypes = []any{
+	(*GetStatusRequest)(nil),          // 0: agent.controlplane.v1.GetStatusRequest
+	(*WatchStatusRequest)(nil),        // 1: agent.controlplane.v1.WatchStatusRequest
+	(*Status)(nil),                    // 2: agent.controlplane.v1.Status
+	(*HealthCheck)(nil),               // 3: agent.controlplane.v1.HealthCheck
+	(*Plugin)(nil),                    // 4: agent.controlplane.v1.Plugin
+	(*ListPluginsRequest)(nil),        // 5: agent.controlplane.v1.ListPluginsRequest
+	(*ListPluginsResponse)(nil),       // 6: agent.controlplane.v1.ListPluginsResponse
+	(*GetPluginRequest)(nil),          // 7: agent.controlplane.v1.GetPluginRequest
+	(*LoadPluginRequest)(nil),         // 8: agent.controlplane.v1.LoadPluginRequest
+	(*UnloadPluginRequest)(nil),       // 9: agent.controlplane.v1.UnloadPluginRequest
+	(*UnloadPluginResponse)(nil),      // 10: agent.controlplane.v1.UnloadPluginResponse
+	(*GetPluginConfigRequest)(nil),    // 11: agent.controlplane.v1.GetPluginConfigRequest
+	(*PluginConfig)(nil),              // 12: agent.controlplane.v1.PluginConfig
+	(*ReconfigurePluginRequest)(nil),  // 13: agent.controlplane.v1.ReconfigurePluginRequest
+	(*ReconfigurePluginResponse)(nil), // 14: agent.controlplane.v1.ReconfigurePluginResponse
+	(*QueryAgentRequest)(nil),         // 15: agent.controlplane.v1.QueryAgentRequest
+	(*AgentResponse)(nil),             // 16: agent.controlplane.v1.AgentResponse
+	(*AgentAction)(nil),               // 17: agent.controlplane.v1.AgentAction
+	(*QueryAgentBatchRequest)(nil),    // 18: agent.controlplane.v1.QueryAgentBatchRequest
+	(*QueryAgentBatchResponse)(nil),   // 19: agent.controlplane.v1.QueryAgentBatchResponse
+	(*AgentBatchResult)(nil),          // 20: agent.controlplane.v1.AgentBatchResult
+	nil,                               // 21: agent.controlplane.v1.Status.ChecksEntry
+	(*durationpb.Duration)(nil),       // 22: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil),     // 23: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),           // 24: google.protobuf.Struct
+}
+var file_controlplane_controlplanev1_controlplane_proto_depIdxs = []int32{
+	22, // 0: agent.controlplane.v1.WatchStatusRequest.interval:type_name -> google.protobuf.Duration
+	22, // 1: agent.controlplane.v1.Status.uptime:type_name -> google.protobuf.Duration
+	4,  // 2: agent.controlplane.v1.Status.plugins:type_name -> agent.controlplane.v1.Plugin
+	21, // 3: agent.controlplane.v1.Status.checks:type_name -> agent.controlplane.v1.Status.ChecksEntry
+	23, // 4: agent.controlplane.v1.Status.timestamp:type_name -> google.protobuf.Timestamp
+	22, // 5: agent.controlplane.v1.Plugin.start_duration:type_name -> google.protobuf.Duration
+	4,  // 6: agent.controlplane.v1.ListPluginsResponse.plugins:type_name -> agent.controlplane.v1.Plugin
+	24, // 7: agent.controlplane.v1.LoadPluginRequest.config:type_name -> google.protobuf.Struct
+	24, // 8: agent.controlplane.v1.PluginConfig.settings:type_name -> google.protobuf.Struct
+	24, // 9: agent.controlplane.v1.ReconfigurePluginRequest.settings:type_name -> google.protobuf.Struct
+	17, // 10: agent.controlplane.v1.AgentResponse.actions:type_name -> agent.controlplane.v1.AgentAction
+	24, // 11: agent.controlplane.v1.AgentResponse.metadata:type_name -> google.protobuf.Struct
+	23, // 12: agent.controlplane.v1.AgentResponse.timestamp:type_name -> google.protobuf.Timestamp
+	24, // 13: agent.controlplane.v1.AgentAction.parameters:type_name -> google.protobuf.Struct
+	20, // 14: agent.controlplane.v1.QueryAgentBatchResponse.results:type_name -> agent.controlplane.v1.AgentBatchResult
+	16, // 15: agent.controlplane.v1.AgentBatchResult.response:type_name -> agent.controlplane.v1.AgentResponse
+	22, // 16: agent.controlplane.v1.AgentBatchResult.duration:type_name -> google.protobuf.Duration
+	3,  // 17: agent.controlplane.v1.Status.ChecksEntry.value:type_name -> agent.controlplane.v1.HealthCheck
+	0,  // 18: agent.controlplane.v1.ControlPlane.GetStatus:input_type -> agent.controlplane.v1.GetStatusRequest
+	1,  // 19: agent.controlplane.v1.ControlPlane.WatchStatus:input_type -> agent.controlplane.v1.WatchStatusRequest
+	5,  // 20: agent.controlplane.v1.ControlPlane.ListPlugins:input_type -> agent.controlplane.v1.ListPluginsRequest
+	7,  // 21: agent.controlplane.v1.ControlPlane.GetPlugin:input_type -> agent.controlplane.v1.GetPluginRequest
+	8,  // 22: agent.controlplane.v1.ControlPlane.LoadPlugin:input_type -> agent.controlplane.v1.LoadPluginRequest
+	9,  // 23: agent.controlplane.v1.ControlPlane.UnloadPlugin:input_type -> agent.controlplane.v1.UnloadPluginRequest
+	11, // 24: agent.controlplane.v1.ControlPlane.GetPluginConfig:input_type -> agent.controlplane.v1.GetPluginConfigRequest
+	13, // 25: agent.controlplane.v1.ControlPlane.ReconfigurePlugin:input_type -> agent.controlplane.v1.ReconfigurePluginRequest
+	15, // 26: agent.controlplane.v1.ControlPlane.QueryAgent:input_type -> agent.controlplane.v1.QueryAgentRequest
+	18, // 27: agent.controlplane.v1.ControlPlane.QueryAgentBatch:input_type -> agent.controlplane.v1.QueryAgentBatchRequest
+	2,  // 28: agent.controlplane.v1.ControlPlane.GetStatus:output_type -> agent.controlplane.v1.Status
+	2,  // 29: agent.controlplane.v1.ControlPlane.WatchStatus:output_type -> agent.controlplane.v1.Status
+	6,  // 30: agent.controlplane.v1.ControlPlane.ListPlugins:output_type -> agent.controlplane.v1.ListPluginsResponse
+	4,  // 31: agent.controlplane.v1.ControlPlane.GetPlugin:output_type -> agent.controlplane.v1.Plugin
+	4,  // 32: agent.controlplane.v1.ControlPlane.LoadPlugin:output_type -> agent.controlplane.v1.Plugin
+	10, // 33: agent.controlplane.v1.ControlPlane.UnloadPlugin:output_type -> agent.controlplane.v1.UnloadPluginResponse
+	12, // 34: agent.controlplane.v1.ControlPlane.GetPluginConfig:output_type -> agent.controlplane.v1.PluginConfig
+	14, // 35: agent.controlplane.v1.ControlPlane.ReconfigurePlugin:output_type -> agent.controlplane.v1.ReconfigurePluginResponse
+	16, // 36: agent.controlplane.v1.ControlPlane.QueryAgent:output_type -> agent.controlplane.v1.AgentResponse
+	19, // 37: agent.controlplane.v1.ControlPlane.QueryAgentBatch:output_type -> agent.controlplane.v1.QueryAgentBatchResponse
+	28, // [28:38] is the sub-list for method output_type
+	18, // [18:28] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
+}
+
+func init() { file_controlplane_controlplanev1_controlplane_proto_init() }
+func file_controlplane_controlplanev1_controlplane_proto_init() {
+	if File_controlplane_controlplanev1_controlplane_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_controlplane_controlplanev1_controlplane_proto_rawDesc), len(file_controlplane_controlplanev1_controlplane_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   22,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_controlplane_controlplanev1_controlplane_proto_goTypes,
+		DependencyIndexes: file_controlplane_controlplanev1_controlplane_proto_depIdxs,
+		MessageInfos:      file_controlplane_controlplanev1_controlplane_proto_msgTypes,
+	}.Build()
+	File_controlplane_controlplanev1_controlplane_proto = out.File
+	file_controlplane_controlplanev1_controlplane_proto_goTypes = nil
+	file_controlplane_controlplanev1_controlplane_proto_depIdxs = nil
+}
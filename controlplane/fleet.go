@@ -0,0 +1,218 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/habruzzo/agent/controlplane/controlplanev1"
+	"github.com/habruzzo/agent/core"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fleetServer implements the fleet service, making the framework a coordinator of remote
+// agents
+type fleetServer struct {
+	controlplanev1.UnimplementedFleetServer
+	framework *core.Framework
+}
+
+// Register announces a remote agent and returns the configuration pushed to it
+func (s *fleetServer) Register(_ context.Context, req *controlplanev1.RegisterRequest) (*controlplanev1.RegisterResponse, error) {
+	config, err := s.framework.RegisterRemoteAgent(core.RemoteAgent{
+		ID:       req.GetAgentId(),
+		Hostname: req.GetHostname(),
+		Version:  req.GetVersion(),
+		Labels:   req.GetLabels(),
+	})
+	if err != nil {
+		return nil, fleetError(err)
+	}
+	message, err := agentConfigMessage(config)
+	if err != nil {
+		return nil, err
+	}
+	return &controlplanev1.RegisterResponse{
+		HeartbeatInterval: durationpb.New(config.HeartbeatInterval),
+		Config:            message,
+	}, nil
+}
+
+// Heartbeat records that a remote agent is alive and returns its configuration when it
+// changed
+func (s *fleetServer) Heartbeat(_ context.Context, req *controlplanev1.HeartbeatRequest) (*controlplanev1.HeartbeatResponse, error) {
+	config, changed, err := s.framework.RemoteAgentHeartbeat(req.GetAgentId(), req.GetConfigVersion(), req.GetConfigError())
+	if err != nil {
+		return nil, fleetError(err)
+	}
+	if !changed {
+		return &controlplanev1.HeartbeatResponse{}, nil
+	}
+	message, err := agentConfigMessage(config)
+	if err != nil {
+		return nil, err
+	}
+	return &controlplanev1.HeartbeatResponse{Config: message}, nil
+}
+
+// Forward hands a remote agent's data points and analyses to the pipeline
+func (s *fleetServer) Forward(ctx context.Context, req *controlplanev1.ForwardRequest) (*controlplanev1.ForwardResponse, error) {
+	data := make([]core.DataPoint, 0, len(req.GetDataPoints()))
+	for _, point := range req.GetDataPoints() {
+		data = append(data, fromDataPointMessage(point))
+	}
+	analyses := make([]core.ForwardedAnalysis, 0, len(req.GetAnalyses()))
+	for _, forwarded := range req.GetAnalyses() {
+		analysis, err := fromAnalysisStruct(forwarded.GetAnalysis())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid analysis from %s: %v", forwarded.GetAnalyzer(), err)
+		}
+		analyses = append(analyses, core.ForwardedAnalysis{Analyzer: forwarded.GetAnalyzer(), Analysis: analysis})
+	}
+
+	// Responders get the analyses even if the remote agent gives up waiting
+	if err := s.framework.ForwardFromRemoteAgent(context.WithoutCancel(ctx), req.GetAgentId(), data, analyses); err != nil {
+		return nil, fleetError(err)
+	}
+	return &controlplanev1.ForwardResponse{DataPoints: int32(len(data)), Analyses: int32(len(analyses))}, nil
+}
+
+// ListAgents returns the registered remote agents sorted by ID
+func (s *fleetServer) ListAgents(context.Context, *controlplanev1.ListAgentsRequest) (*controlplanev1.ListAgentsResponse, error) {
+	agents := s.framework.RemoteAgents()
+	response := &controlplanev1.ListAgentsResponse{Agents: make([]*controlplanev1.RemoteAgent, 0, len(agents))}
+	for _, agent := range agents {
+		response.Agents = append(response.Agents, &controlplanev1.RemoteAgent{
+			AgentId:       agent.ID,
+			Hostname:      agent.Hostname,
+			Version:       agent.Version,
+			Labels:        agent.Labels,
+			Online:        agent.Online,
+			RegisteredAt:  timestamppb.New(agent.RegisteredAt),
+			LastHeartbeat: timestamppb.New(agent.LastHeartbeat),
+			ConfigVersion: agent.ConfigVersion,
+			ConfigError:   agent.ConfigError,
+			DataPoints:    agent.DataPoints,
+			Analyses:      agent.Analyses,
+		})
+	}
+	return response, nil
+}
+
+// SetAgentConfig changes the plugins pushed to a remote agent
+func (s *fleetServer) SetAgentConfig(_ context.Context, req *controlplanev1.SetAgentConfigRequest) (*controlplanev1.AgentConfig, error) {
+	plugins := make([]core.PluginConfig, 0, len(req.GetPlugins()))
+	for _, spec := range req.GetPlugins() {
+		plugins = append(plugins, fromPluginSpec(spec))
+	}
+	config, err := s.framework.SetRemoteAgentPlugins(req.GetAgentId(), plugins)
+	if err != nil {
+		return nil, fleetError(err)
+	}
+	return agentConfigMessage(config)
+}
+
+// fleetError maps fleet errors to gRPC status codes
+func fleetError(err error) error {
+	switch {
+	case errors.Is(err, core.ErrUnknownRemoteAgent):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, core.ErrShuttingDown), errors.Is(err, core.ErrDataChannelFull):
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return statusError(err)
+}
+
+// agentConfigMessage converts the configuration pushed to a remote agent
+func agentConfigMessage(config core.RemoteAgentConfig) (*controlplanev1.AgentConfig, error) {
+	message := &controlplanev1.AgentConfig{Version: config.Version}
+	for _, plugin := range config.Plugins {
+		spec := &controlplanev1.PluginSpec{Name: plugin.Name, Type: plugin.Type, Enabled: plugin.Enabled}
+		if settings, ok := plugin.Config.(map[string]interface{}); ok {
+			converted, err := toStruct(settings)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "invalid settings of plugin %s: %v", plugin.Name, err)
+			}
+			spec.Config = converted
+		}
+		message.Plugins = append(message.Plugins, spec)
+	}
+	return message, nil
+}
+
+// fromPluginSpec converts a pushed plugin to its configuration
+func fromPluginSpec(spec *controlplanev1.PluginSpec) core.PluginConfig {
+	config := core.PluginConfig{Name: spec.GetName(), Type: spec.GetType(), Enabled: spec.GetEnabled()}
+	if spec.GetConfig() != nil {
+		config.Config = spec.GetConfig().AsMap()
+	}
+	return config
+}
+
+// dataPointMessage converts a data point for forwarding
+func dataPointMessage(point core.DataPoint) (*controlplanev1.DataPoint, error) {
+	message := &controlplanev1.DataPoint{
+		Timestamp: timestamppb.New(point.Timestamp),
+		Source:    point.Source,
+		Metric:    point.Metric,
+		Value:     point.Value,
+		Labels:    point.Labels,
+	}
+	if len(point.Metadata) > 0 {
+		metadata, err := toStruct(point.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metadata of %s: %w", point.Metric, err)
+		}
+		message.Metadata = metadata
+	}
+	return message, nil
+}
+
+// fromDataPointMessage converts a forwarded data point
+func fromDataPointMessage(message *controlplanev1.DataPoint) core.DataPoint {
+	point := core.DataPoint{
+		Source: message.GetSource(),
+		Metric: message.GetMetric(),
+		Value:  message.GetValue(),
+		Labels: message.GetLabels(),
+	}
+	if message.GetTimestamp() != nil {
+		point.Timestamp = message.GetTimestamp().AsTime()
+	}
+	if message.GetMetadata() != nil {
+		point.Metadata = message.GetMetadata().AsMap()
+	}
+	return point
+}
+
+// analysisStruct converts an analysis for forwarding, encoding it as the HTTP API does
+func analysisStruct(analysis *core.Analysis) (*structpb.Struct, error) {
+	encoded, err := json.Marshal(analysis)
+	if err != nil {
+		return nil, err
+	}
+	result := &structpb.Struct{}
+	if err := protojson.Unmarshal(encoded, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// fromAnalysisStruct converts a forwarded analysis
+func fromAnalysisStruct(message *structpb.Struct) (*core.Analysis, error) {
+	encoded, err := protojson.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	var analysis core.Analysis
+	if err := json.Unmarshal(encoded, &analysis); err != nil {
+		return nil, err
+	}
+	return &analysis, nil
+}
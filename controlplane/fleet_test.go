@@ -0,0 +1,99 @@
+package controlplane
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/controlplane/controlplanev1"
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFleet_RemoteAgent(t *testing.T) {
+	coordinator := newFramework(t, &core.FrameworkConfig{
+		DataChannelSize: 10,
+		Fleet:           core.FleetConfig{HeartbeatInterval: 20 * time.Millisecond},
+	})
+	conn := connect(t, coordinator)
+	fleet := controlplanev1.NewFleetClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := fleet.Heartbeat(ctx, &controlplanev1.HeartbeatRequest{AgentId: "edge-1"})
+	assert.Equal(t, codes.NotFound, status.Code(err), "Expected heartbeats of unregistered agents to be rejected")
+
+	// The remote agent runs the plugins of its own configuration until others are pushed
+	config := &core.FrameworkConfig{
+		ServerHost:         "0.0.0.0",
+		ServerPort:         9090,
+		HealthCheckTimeout: 5 * time.Second,
+		DataChannelSize:    10,
+		WorkerPoolSize:     1,
+		ShutdownTimeout:    5 * time.Second,
+		Fleet:              core.FleetConfig{Coordinator: "passthrough:///bufnet", AgentID: "edge-1", Labels: map[string]string{"zone": "a"}},
+	}
+	remote := newFramework(t, config)
+	require.NoError(t, remote.GetFactory().RegisterPluginCreator("agent", func(config core.PluginConfig) (core.Plugin, error) {
+		return &echoAgent{name: config.Name}, nil
+	}))
+	require.NoError(t, remote.Start(ctx))
+	defer remote.Stop()
+	agent, err := NewFleetAgent(remote, config)
+	require.NoError(t, err)
+	require.NoError(t, agent.conn.Close())
+	agent.conn, agent.client = conn, fleet
+	remote.SetForwarder(agent)
+	agent.Start(ctx)
+
+	listed := func() *controlplanev1.RemoteAgent {
+		response, err := fleet.ListAgents(ctx, &controlplanev1.ListAgentsRequest{})
+		require.NoError(t, err)
+		if len(response.GetAgents()) == 0 {
+			return nil
+		}
+		return response.GetAgents()[0]
+	}
+	require.Eventually(t, func() bool { return listed() != nil }, 5*time.Second, 10*time.Millisecond)
+	registered := listed()
+	assert.Equal(t, "edge-1", registered.GetAgentId())
+	assert.Equal(t, map[string]string{"zone": "a"}, registered.GetLabels())
+	assert.True(t, registered.GetOnline())
+
+	// Pushed plugins reach the agent with its next heartbeat
+	pushed, err := fleet.SetAgentConfig(ctx, &controlplanev1.SetAgentConfigRequest{
+		AgentId: "edge-1",
+		Plugins: []*controlplanev1.PluginSpec{{Name: "ask", Type: "agent", Enabled: true}},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, pushed.GetVersion())
+	require.Eventually(t, func() bool { return listed().GetConfigVersion() == pushed.GetVersion() }, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, listed().GetConfigError())
+	_, ok := remote.Status().Plugins["ask"]
+	assert.True(t, ok, "Expected the remote agent to run the pushed plugin")
+
+	// Forwarded data points and analyses reach the coordinator's pipeline
+	agent.ForwardData([]core.DataPoint{{Timestamp: time.Now(), Source: "cpu", Metric: "cpu_usage", Value: 97}})
+	agent.ForwardAnalysis("threshold", &core.Analysis{ID: "a1", Severity: "high", Summary: "CPU high"})
+	require.Eventually(t, func() bool {
+		forwarded := listed()
+		return forwarded.GetDataPoints() == 1 && forwarded.GetAnalyses() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, agent.Stop(time.Second))
+}
+
+func TestFleet_SetAgentConfigValidates(t *testing.T) {
+	fleet := controlplanev1.NewFleetClient(connect(t, newFramework(t, &core.FrameworkConfig{})))
+	_, err := fleet.SetAgentConfig(context.Background(), &controlplanev1.SetAgentConfigRequest{
+		AgentId: "edge-1",
+		Plugins: []*controlplanev1.PluginSpec{{Name: "ask"}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = fleet.Forward(context.Background(), &controlplanev1.ForwardRequest{AgentId: "edge-1"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
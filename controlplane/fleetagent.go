@@ -0,0 +1,418 @@
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/controlplane/controlplanev1"
+	"github.com/habruzzo/agent/core"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Remote agent defaults
+const (
+	defaultFleetQueueSize = 1000
+	// maxForwardBatches is how many queued batches and analyses one Forward call carries
+	maxForwardBatches = 100
+	// Backoff between attempts to reach the coordinator
+	minFleetRetry = time.Second
+	maxFleetRetry = 30 * time.Second
+)
+
+// AgentVersion is the version remote agents register with
+var AgentVersion = "dev"
+
+// FleetAgent makes a framework a remote agent of a fleet coordinator. It registers with the
+// coordinator, sends heartbeats, runs the plugins the coordinator pushes, and forwards the
+// data points and analyses the framework produces. Set it as the framework's forwarder
+// before the framework starts.
+type FleetAgent struct {
+	framework *core.Framework
+	config    core.FrameworkConfig
+	id        string
+	conn      *grpc.ClientConn
+	client    controlplanev1.FleetClient
+
+	queue   chan *controlplanev1.ForwardRequest
+	sending sync.WaitGroup
+	cancel  context.CancelFunc
+
+	// registering lets one call register at a time
+	registering sync.Mutex
+
+	mu            sync.Mutex
+	registered    bool
+	heartbeat     time.Duration
+	configVersion string
+	configError   string
+	dropped       int
+	stopped       bool
+}
+
+// NewFleetAgent connects to the coordinator named in the framework configuration's fleet
+// settings. The configuration's own plugins are run until the coordinator pushes others.
+func NewFleetAgent(framework *core.Framework, config *core.FrameworkConfig) (*FleetAgent, error) {
+	fleet := config.Fleet
+	if fleet.Coordinator == "" {
+		return nil, core.NewConfigurationError("fleet", "connect", "no coordinator configured")
+	}
+
+	id := fleet.AgentID
+	if id == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, core.WrapError(err, core.ErrorTypeConfiguration, "fleet", "connect", "agent_id is not set and the hostname is unknown")
+		}
+		id = hostname
+	}
+
+	transport := insecure.NewCredentials()
+	if fleet.TLS != nil {
+		tlsConfig, err := core.NewClientTLSConfig(fleet.TLS)
+		if err != nil {
+			return nil, core.WrapError(err, core.ErrorTypeConfiguration, "fleet", "connect", "invalid coordinator TLS settings")
+		}
+		transport = credentials.NewTLS(tlsConfig)
+	}
+	conn, err := grpc.NewClient(fleet.Coordinator, grpc.WithTransportCredentials(transport))
+	if err != nil {
+		return nil, core.WrapError(err, core.ErrorTypeConfiguration, "fleet", "connect", "invalid coordinator address")
+	}
+
+	queueSize := fleet.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultFleetQueueSize
+	}
+	return &FleetAgent{
+		framework: framework,
+		config:    *config,
+		id:        id,
+		conn:      conn,
+		client:    controlplanev1.NewFleetClient(conn),
+		queue:     make(chan *controlplanev1.ForwardRequest, queueSize),
+		heartbeat: fleet.HeartbeatInterval,
+	}, nil
+}
+
+// ID returns the ID the agent registers under
+func (a *FleetAgent) ID() string {
+	return a.id
+}
+
+// Start registers with the coordinator and keeps sending heartbeats until the context is
+// done. Forwarding continues until Stop, so data the framework drains on shutdown still
+// reaches the coordinator.
+func (a *FleetAgent) Start(ctx context.Context) {
+	forwardCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	a.cancel = cancel
+	a.sending.Add(1)
+	go a.forward(forwardCtx)
+	go a.heartbeats(ctx)
+}
+
+// Stop waits up to timeout for queued batches and analyses to be forwarded, then stops
+// forwarding and closes the connection to the coordinator
+func (a *FleetAgent) Stop(timeout time.Duration) error {
+	a.mu.Lock()
+	a.stopped = true
+	close(a.queue)
+	a.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		a.sending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		slog.Warn("Timeout forwarding to the fleet coordinator", "batches_left", len(a.queue))
+	}
+	if a.cancel != nil {
+		a.cancel()
+	}
+	return a.conn.Close()
+}
+
+// ForwardData queues data points for the coordinator
+func (a *FleetAgent) ForwardData(data []core.DataPoint) {
+	request := &controlplanev1.ForwardRequest{DataPoints: make([]*controlplanev1.DataPoint, 0, len(data))}
+	for _, point := range data {
+		message, err := dataPointMessage(point)
+		if err != nil {
+			slog.Warn("Not forwarding data point", "metric", point.Metric, "error", err)
+			continue
+		}
+		request.DataPoints = append(request.DataPoints, message)
+	}
+	a.enqueue(request)
+}
+
+// ForwardAnalysis queues an analysis for the coordinator
+func (a *FleetAgent) ForwardAnalysis(analyzer string, analysis *core.Analysis) {
+	encoded, err := analysisStruct(analysis)
+	if err != nil {
+		slog.Warn("Not forwarding analysis", "analyzer", analyzer, "analysis", analysis.ID, "error", err)
+		return
+	}
+	a.enqueue(&controlplanev1.ForwardRequest{
+		Analyses: []*controlplanev1.ForwardedAnalysis{{Analyzer: analyzer, Analysis: encoded}},
+	})
+}
+
+// enqueue queues a request without blocking the pipeline, dropping it when the queue is full
+func (a *FleetAgent) enqueue(request *controlplanev1.ForwardRequest) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.stopped {
+		return
+	}
+	select {
+	case a.queue <- request:
+	default:
+		a.dropped++
+		if a.dropped == 1 {
+			slog.Warn("Fleet coordinator queue full, dropping data", "queue_size", cap(a.queue))
+		}
+	}
+}
+
+// forward sends queued requests to the coordinator, several at a time, retrying each until
+// it is accepted or forwarding stops
+func (a *FleetAgent) forward(ctx context.Context) {
+	defer a.sending.Done()
+	for request := range a.queue {
+		batch := &controlplanev1.ForwardRequest{
+			DataPoints: request.DataPoints,
+			Analyses:   request.Analyses,
+		}
+	more:
+		for i := 1; i < maxForwardBatches; i++ {
+			select {
+			case next, ok := <-a.queue:
+				if !ok {
+					break more
+				}
+				batch.DataPoints = append(batch.DataPoints, next.DataPoints...)
+				batch.Analyses = append(batch.Analyses, next.Analyses...)
+			default:
+				break more
+			}
+		}
+
+		if err := a.send(ctx, batch); err != nil {
+			slog.Warn("Dropping data not forwarded to the fleet coordinator", "data_points", len(batch.DataPoints),
+				"analyses", len(batch.Analyses), "error", err)
+		}
+	}
+}
+
+// send forwards a batch, registering again if the coordinator does not know the agent
+func (a *FleetAgent) send(ctx context.Context, batch *controlplanev1.ForwardRequest) error {
+	batch.AgentId = a.id
+	return a.retry(ctx, "forward", func(ctx context.Context) error {
+		if err := a.ensureRegistered(ctx); err != nil {
+			return err
+		}
+		_, err := a.client.Forward(a.outgoing(ctx), batch)
+		if status.Code(err) == codes.NotFound {
+			a.unregistered()
+		}
+		if err == nil {
+			a.mu.Lock()
+			if a.dropped > 0 {
+				slog.Info("Forwarding to the fleet coordinator again", "dropped", a.dropped)
+				a.dropped = 0
+			}
+			a.mu.Unlock()
+		}
+		return err
+	})
+}
+
+// heartbeats registers with the coordinator and sends heartbeats until the context is done
+func (a *FleetAgent) heartbeats(ctx context.Context) {
+	if err := a.retry(ctx, "register", a.ensureRegistered); err != nil {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(a.heartbeatInterval()):
+		}
+		if err := a.sendHeartbeat(ctx); err != nil {
+			slog.Warn("Failed to send heartbeat to the fleet coordinator", "coordinator", a.config.Fleet.Coordinator, "error", err)
+		}
+	}
+}
+
+// sendHeartbeat reports the agent is alive and applies the configuration it gets back
+func (a *FleetAgent) sendHeartbeat(ctx context.Context) error {
+	if err := a.ensureRegistered(ctx); err != nil {
+		return err
+	}
+	state := a.framework.Status()
+	a.mu.Lock()
+	request := &controlplanev1.HeartbeatRequest{
+		AgentId:       a.id,
+		ConfigVersion: a.configVersion,
+		ConfigError:   a.configError,
+		Running:       state.Running,
+		Plugins:       int32(state.TotalPlugins),
+	}
+	a.mu.Unlock()
+
+	response, err := a.client.Heartbeat(a.outgoing(ctx), request)
+	if status.Code(err) == codes.NotFound {
+		// The coordinator restarted; register again, which sends the configuration
+		a.unregistered()
+		return a.ensureRegistered(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	if response.GetConfig() != nil {
+		a.apply(ctx, response.GetConfig())
+	}
+	return nil
+}
+
+// ensureRegistered registers with the coordinator unless the agent is registered
+func (a *FleetAgent) ensureRegistered(ctx context.Context) error {
+	a.registering.Lock()
+	defer a.registering.Unlock()
+	a.mu.Lock()
+	registered := a.registered
+	a.mu.Unlock()
+	if registered {
+		return nil
+	}
+
+	response, err := a.client.Register(a.outgoing(ctx), &controlplanev1.RegisterRequest{
+		AgentId:  a.id,
+		Hostname: hostname(),
+		Version:  AgentVersion,
+		Labels:   a.config.Fleet.Labels,
+	})
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.registered = true
+	if interval := response.GetHeartbeatInterval().AsDuration(); interval > 0 {
+		a.heartbeat = interval
+	}
+	a.mu.Unlock()
+	slog.Info("Registered with the fleet coordinator", "coordinator", a.config.Fleet.Coordinator, "agent", a.id)
+
+	a.apply(ctx, response.GetConfig())
+	return nil
+}
+
+// unregistered notes the coordinator no longer knows the agent
+func (a *FleetAgent) unregistered() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.registered = false
+}
+
+// apply switches the framework to the plugins the coordinator pushed; a configuration
+// without a version returns the agent to the plugins of its own configuration
+func (a *FleetAgent) apply(ctx context.Context, pushed *controlplanev1.AgentConfig) {
+	a.mu.Lock()
+	current := a.configVersion
+	a.mu.Unlock()
+	if pushed.GetVersion() == current {
+		return
+	}
+
+	config := a.config
+	if pushed.GetVersion() != "" {
+		config.Plugins = make([]core.PluginConfig, 0, len(pushed.GetPlugins()))
+		for _, spec := range pushed.GetPlugins() {
+			config.Plugins = append(config.Plugins, fromPluginSpec(spec))
+		}
+	}
+
+	var failure string
+	reload, err := a.framework.ApplyConfig(context.WithoutCancel(ctx), &config)
+	switch {
+	case err != nil:
+		failure = err.Error()
+	case len(reload.Failed) > 0:
+		names := make([]string, 0, len(reload.Failed))
+		for name := range reload.Failed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		failures := make([]string, len(names))
+		for i, name := range names {
+			failures[i] = fmt.Sprintf("%s: %s", name, reload.Failed[name])
+		}
+		failure = strings.Join(failures, "; ")
+	}
+	if failure != "" {
+		slog.Error("Failed to apply the configuration pushed by the fleet coordinator", "config_version", pushed.GetVersion(), "error", failure)
+	} else {
+		slog.Info("Applied the configuration pushed by the fleet coordinator", "config_version", pushed.GetVersion(), "plugins", len(config.Plugins))
+	}
+
+	a.mu.Lock()
+	a.configVersion = pushed.GetVersion()
+	a.configError = failure
+	a.mu.Unlock()
+}
+
+// retry runs a call to the coordinator with backoff until it succeeds or the context is done
+func (a *FleetAgent) retry(ctx context.Context, operation string, call func(context.Context) error) error {
+	wait := minFleetRetry
+	for {
+		err := call(ctx)
+		if err == nil {
+			return nil
+		}
+		slog.Warn("Fleet coordinator call failed, retrying", "operation", operation, "coordinator", a.config.Fleet.Coordinator,
+			"retry_in", wait, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait = min(2*wait, maxFleetRetry)
+	}
+}
+
+// heartbeatInterval returns how often heartbeats are sent, as the coordinator asked
+func (a *FleetAgent) heartbeatInterval() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.heartbeat <= 0 {
+		return 10 * time.Second
+	}
+	return a.heartbeat
+}
+
+// outgoing adds the agent's API key to a call
+func (a *FleetAgent) outgoing(ctx context.Context) context.Context {
+	if a.config.Fleet.Token == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+a.config.Fleet.Token)
+}
+
+// hostname returns the host's name, or nothing when it is unknown
+func hostname() string {
+	name, _ := os.Hostname()
+	return name
+}
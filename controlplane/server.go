@@ -0,0 +1,393 @@
+// Package controlplane serves the agent's gRPC control plane, which mirrors the management
+// API for fleet controllers: plugin lifecycle, status streaming, and agent queries. The
+// service is defined in controlplanev1/controlplane.proto. Alongside it the fleet service,
+// defined in controlplanev1/fleet.proto, makes the agent a coordinator of remote agents,
+// and FleetAgent makes an agent a remote agent of one.
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/habruzzo/agent/controlplane/controlplanev1"
+	"github.com/habruzzo/agent/core"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Status streaming intervals
+const (
+	defaultWatchInterval = 5 * time.Second
+	minWatchInterval     = time.Second
+)
+
+// methodScopes is the API key scope each method requires
+var methodScopes = map[string]core.APIScope{
+	controlplanev1.ControlPlane_GetStatus_FullMethodName:         core.APIScopeQuery,
+	controlplanev1.ControlPlane_WatchStatus_FullMethodName:       core.APIScopeQuery,
+	controlplanev1.ControlPlane_ListPlugins_FullMethodName:       core.APIScopeQuery,
+	controlplanev1.ControlPlane_GetPlugin_FullMethodName:         core.APIScopeQuery,
+	controlplanev1.ControlPlane_LoadPlugin_FullMethodName:        core.APIScopeAdmin,
+	controlplanev1.ControlPlane_UnloadPlugin_FullMethodName:      core.APIScopeAdmin,
+	controlplanev1.ControlPlane_GetPluginConfig_FullMethodName:   core.APIScopeAdmin,
+	controlplanev1.ControlPlane_ReconfigurePlugin_FullMethodName: core.APIScopeAdmin,
+	controlplanev1.ControlPlane_QueryAgent_FullMethodName:        core.APIScopeQuery,
+	controlplanev1.ControlPlane_QueryAgentBatch_FullMethodName:   core.APIScopeQuery,
+	controlplanev1.Fleet_Register_FullMethodName:                 core.APIScopeIngest,
+	controlplanev1.Fleet_Heartbeat_FullMethodName:                core.APIScopeIngest,
+	controlplanev1.Fleet_Forward_FullMethodName:                  core.APIScopeIngest,
+	controlplanev1.Fleet_ListAgents_FullMethodName:               core.APIScopeQuery,
+	controlplanev1.Fleet_SetAgentConfig_FullMethodName:           core.APIScopeAdmin,
+}
+
+// Server implements the control plane service for a framework
+type Server struct {
+	controlplanev1.UnimplementedControlPlaneServer
+	framework *core.Framework
+	grpc      *grpc.Server
+}
+
+// NewServer creates a control plane server for the framework. Calls are checked against the
+// framework's API keys like the management API, and served over the framework's TLS
+// configuration when it has one.
+func NewServer(framework *core.Framework) (*Server, error) {
+	tlsConfig, err := framework.ServerTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{framework: framework}
+	options := []grpc.ServerOption{
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := s.authorize(ctx, info.FullMethod); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := s.authorize(stream.Context(), info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, stream)
+		}),
+	}
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	s.grpc = grpc.NewServer(options...)
+	controlplanev1.RegisterControlPlaneServer(s.grpc, s)
+	controlplanev1.RegisterFleetServer(s.grpc, &fleetServer{framework: framework})
+	return s, nil
+}
+
+// Serve listens on the address and serves the control plane until the context is done
+func (s *Server) Serve(ctx context.Context, address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+	go func() {
+		<-ctx.Done()
+		s.grpc.GracefulStop()
+	}()
+
+	slog.Info("Control plane listening", "address", listener.Addr().String())
+	return s.grpc.Serve(listener)
+}
+
+// authorize checks the call's API key against the scope of the method
+func (s *Server) authorize(ctx context.Context, method string) error {
+	scope, ok := methodScopes[method]
+	if !ok {
+		scope = core.APIScopeAdmin
+	}
+
+	var authorization, apiKey string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			authorization = values[0]
+		}
+		if values := md.Get("x-api-key"); len(values) > 0 {
+			apiKey = values[0]
+		}
+	}
+
+	err := s.framework.GetAPIKeys().Authorize(ctx, core.CredentialsFromHeaders(authorization, apiKey), scope)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, core.ErrAPIKeyForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, core.ErrAPIKeyRateLimited):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	default:
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+}
+
+// GetStatus returns the framework status
+func (s *Server) GetStatus(ctx context.Context, _ *controlplanev1.GetStatusRequest) (*controlplanev1.Status, error) {
+	return s.status(ctx), nil
+}
+
+// WatchStatus sends the status at once and then every interval until the call ends
+func (s *Server) WatchStatus(req *controlplanev1.WatchStatusRequest, stream grpc.ServerStreamingServer[controlplanev1.Status]) error {
+	interval := defaultWatchInterval
+	if req.GetInterval() != nil {
+		interval = max(req.GetInterval().AsDuration(), minWatchInterval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := stream.Send(s.status(stream.Context())); err != nil {
+			return err
+		}
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// status builds the status message from the framework's status, plugin states, and health
+func (s *Server) status(ctx context.Context) *controlplanev1.Status {
+	summary := s.framework.Status()
+	health := s.framework.GetHealthStatus(ctx)
+
+	result := &controlplanev1.Status{
+		Running:       summary.Running,
+		ReadOnly:      summary.ReadOnly,
+		DryRun:        summary.DryRun,
+		Health:        health.Status,
+		HealthMessage: health.Message,
+		Checks:        make(map[string]*controlplanev1.HealthCheck, len(health.Checks)),
+		Timestamp:     timestamppb.New(health.Timestamp),
+	}
+	if uptime, err := time.ParseDuration(summary.Uptime); err == nil {
+		result.Uptime = durationpb.New(uptime)
+	}
+	for _, state := range s.framework.PluginStates(ctx) {
+		plugin := pluginMessage(state)
+		if start, ok := summary.Plugins[state.Name]; ok {
+			if duration, err := time.ParseDuration(start.StartDuration); err == nil {
+				plugin.StartDuration = durationpb.New(duration)
+			}
+			plugin.StartError = start.StartError
+		}
+		result.Plugins = append(result.Plugins, plugin)
+	}
+	for name, check := range health.Checks {
+		result.Checks[name] = &controlplanev1.HealthCheck{Status: check.Status, Message: check.Message, Error: check.Error}
+	}
+	return result
+}
+
+// ListPlugins returns the loaded plugins sorted by name
+func (s *Server) ListPlugins(ctx context.Context, _ *controlplanev1.ListPluginsRequest) (*controlplanev1.ListPluginsResponse, error) {
+	states := s.framework.PluginStates(ctx)
+	response := &controlplanev1.ListPluginsResponse{Plugins: make([]*controlplanev1.Plugin, 0, len(states))}
+	for _, state := range states {
+		response.Plugins = append(response.Plugins, pluginMessage(state))
+	}
+	return response, nil
+}
+
+// GetPlugin returns one plugin
+func (s *Server) GetPlugin(ctx context.Context, req *controlplanev1.GetPluginRequest) (*controlplanev1.Plugin, error) {
+	state, err := s.framework.PluginState(ctx, req.GetName())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return pluginMessage(state), nil
+}
+
+// LoadPlugin creates a plugin from configuration and starts it on a running framework
+func (s *Server) LoadPlugin(ctx context.Context, req *controlplanev1.LoadPluginRequest) (*controlplanev1.Plugin, error) {
+	if !s.framework.AllowsRuntimePlugins() {
+		return nil, status.Error(codes.PermissionDenied, core.ErrRuntimePluginsRefused.Error())
+	}
+	if req.GetName() == "" || req.GetType() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name and type are required")
+	}
+	if _, err := s.framework.PluginState(ctx, req.GetName()); err == nil {
+		return nil, status.Errorf(codes.AlreadyExists, "plugin %s is already loaded", req.GetName())
+	}
+
+	config := core.PluginConfig{Name: req.GetName(), Type: req.GetType(), Enabled: true}
+	if req.GetConfig() != nil {
+		config.Config = req.GetConfig().AsMap()
+	}
+	if err := s.framework.LoadPluginFromConfig(config); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return s.GetPlugin(ctx, &controlplanev1.GetPluginRequest{Name: req.GetName()})
+}
+
+// UnloadPlugin stops a plugin and removes it
+func (s *Server) UnloadPlugin(_ context.Context, req *controlplanev1.UnloadPluginRequest) (*controlplanev1.UnloadPluginResponse, error) {
+	if err := s.framework.UnloadPlugin(req.GetName()); err != nil {
+		return nil, statusError(err)
+	}
+	return &controlplanev1.UnloadPluginResponse{}, nil
+}
+
+// GetPluginConfig returns the settings a plugin runs with, credentials redacted
+func (s *Server) GetPluginConfig(_ context.Context, req *controlplanev1.GetPluginConfigRequest) (*controlplanev1.PluginConfig, error) {
+	settings, err := s.framework.PluginSettings(req.GetName())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	encoded, err := toStruct(settings)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &controlplanev1.PluginConfig{Name: req.GetName(), Settings: encoded}, nil
+}
+
+// ReconfigurePlugin changes settings of a running plugin without restarting it
+func (s *Server) ReconfigurePlugin(ctx context.Context, req *controlplanev1.ReconfigurePluginRequest) (*controlplanev1.ReconfigurePluginResponse, error) {
+	if !s.framework.AllowsRuntimePlugins() {
+		return nil, status.Error(codes.PermissionDenied, core.ErrRuntimePluginsRefused.Error())
+	}
+	if len(req.GetSettings().GetFields()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "settings are required")
+	}
+	// The new settings are checked against the plugin's backend even if the caller goes away
+	if err := s.framework.ReconfigurePlugin(context.WithoutCancel(ctx), req.GetName(), req.GetSettings().AsMap()); err != nil {
+		return nil, statusError(err)
+	}
+	return &controlplanev1.ReconfigurePluginResponse{}, nil
+}
+
+// QueryAgent sends a query to an agent, or the default agent
+func (s *Server) QueryAgent(ctx context.Context, req *controlplanev1.QueryAgentRequest) (*controlplanev1.AgentResponse, error) {
+	if req.GetQuery() == "" {
+		return nil, status.Error(codes.InvalidArgument, "query is required")
+	}
+
+	var response *core.AgentResponse
+	var err error
+	if req.GetAgent() != "" {
+		response, err = s.framework.QueryAgent(ctx, req.GetAgent(), req.GetQuery())
+	} else {
+		response, err = s.framework.QueryDefaultAgent(ctx, req.GetQuery())
+	}
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return agentResponseMessage(response)
+}
+
+// QueryAgentBatch sends several queries to an agent at once
+func (s *Server) QueryAgentBatch(ctx context.Context, req *controlplanev1.QueryAgentBatchRequest) (*controlplanev1.QueryAgentBatchResponse, error) {
+	if len(req.GetQueries()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "queries are required")
+	}
+	agent := req.GetAgent()
+	if agent == "" {
+		agent = s.framework.DefaultAgent()
+	}
+	if agent == "" {
+		return nil, status.Error(codes.InvalidArgument, "no agent given and no default agent configured")
+	}
+
+	results, err := s.framework.QueryAgentBatch(ctx, agent, req.GetQueries())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	response := &controlplanev1.QueryAgentBatchResponse{Results: make([]*controlplanev1.AgentBatchResult, 0, len(results))}
+	for _, result := range results {
+		message := &controlplanev1.AgentBatchResult{
+			Query:    result.Query,
+			Error:    result.Error,
+			Duration: durationpb.New(time.Duration(result.DurationMS) * time.Millisecond),
+		}
+		if result.Response != nil {
+			if message.Response, err = agentResponseMessage(result.Response); err != nil {
+				return nil, err
+			}
+		}
+		response.Results = append(response.Results, message)
+	}
+	return response, nil
+}
+
+// pluginMessage converts a plugin state
+func pluginMessage(state core.PluginState) *controlplanev1.Plugin {
+	return &controlplanev1.Plugin{
+		Name:    state.Name,
+		Type:    string(state.Type),
+		Status:  string(state.Status),
+		Healthy: state.Healthy,
+		Error:   state.Error,
+	}
+}
+
+// agentResponseMessage converts an agent response
+func agentResponseMessage(response *core.AgentResponse) (*controlplanev1.AgentResponse, error) {
+	message := &controlplanev1.AgentResponse{
+		Query:      response.Query,
+		Response:   response.Response,
+		Confidence: response.Confidence,
+		Timestamp:  timestamppb.New(response.Timestamp),
+	}
+	var err error
+	if response.Metadata != nil {
+		if message.Metadata, err = toStruct(response.Metadata); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	for _, action := range response.Actions {
+		converted := &controlplanev1.AgentAction{Type: action.Type, Description: action.Description,
+			Target: action.Target, Risk: string(action.Risk)}
+		if action.Parameters != nil {
+			if converted.Parameters, err = toStruct(action.Parameters); err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+		}
+		message.Actions = append(message.Actions, converted)
+	}
+	return message, nil
+}
+
+// toStruct converts settings to a Struct by way of JSON, so values such as durations and
+// typed slices are encoded as the HTTP API would encode them
+func toStruct(values map[string]interface{}) (*structpb.Struct, error) {
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	result := &structpb.Struct{}
+	if err := protojson.Unmarshal(encoded, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// statusError maps framework errors to gRPC status codes
+func statusError(err error) error {
+	code := codes.Internal
+	switch core.GetErrorType(err) {
+	case core.ErrorTypePlugin:
+		code = codes.NotFound
+	case core.ErrorTypeValidation, core.ErrorTypeConfiguration:
+		code = codes.InvalidArgument
+	case core.ErrorTypeTimeout:
+		code = codes.DeadlineExceeded
+	}
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,185 @@
+package controlplane
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/controlplane/controlplanev1"
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type echoAgent struct {
+	name     string
+	settings map[string]interface{}
+}
+
+func (a *echoAgent) Name() string                     { return a.name }
+func (a *echoAgent) Type() core.PluginType            { return core.PluginTypeAgent }
+func (a *echoAgent) Version() string                  { return "1.0.0" }
+func (a *echoAgent) Start(context.Context) error      { return nil }
+func (a *echoAgent) Stop() error                      { return nil }
+func (a *echoAgent) Status() core.PluginStatus        { return core.PluginStatusRunning }
+func (a *echoAgent) Health(context.Context) error     { return nil }
+func (a *echoAgent) GetCapabilities() []string        { return nil }
+func (a *echoAgent) SetContext(data []core.DataPoint) {}
+func (a *echoAgent) GetAvailableQueries() []string    { return nil }
+
+func (a *echoAgent) Configure(config map[string]interface{}) error {
+	a.settings = config
+	return nil
+}
+
+func (a *echoAgent) ProcessQuery(ctx context.Context, query string) (*core.AgentResponse, error) {
+	return &core.AgentResponse{Query: query, Response: "echo: " + query, Metadata: map[string]interface{}{"agent": a.name}}, nil
+}
+
+// dial serves the control plane for the framework in memory and returns a client
+func dial(t *testing.T, framework *core.Framework) controlplanev1.ControlPlaneClient {
+	return controlplanev1.NewControlPlaneClient(connect(t, framework))
+}
+
+// connect serves the control plane for the framework in memory and returns a connection
+func connect(t *testing.T, framework *core.Framework) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	server, err := NewServer(framework)
+	require.NoError(t, err)
+	go server.grpc.Serve(listener)
+	t.Cleanup(server.grpc.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func newFramework(t *testing.T, config *core.FrameworkConfig) *core.Framework {
+	config.LogLevel, config.LogFormat, config.LogOutput = "info", "text", "stdout"
+	framework := core.NewFramework(config)
+	require.NoError(t, framework.GetFactory().RegisterPluginCreator("echo", func(config core.PluginConfig) (core.Plugin, error) {
+		agent := &echoAgent{name: config.Name}
+		if settings, ok := config.Config.(map[string]interface{}); ok {
+			return agent, agent.Configure(settings)
+		}
+		return agent, nil
+	}))
+	return framework
+}
+
+func TestServer_PluginLifecycle(t *testing.T) {
+	framework := newFramework(t, &core.FrameworkConfig{AllowRuntimePlugins: true})
+	client := dial(t, framework)
+	ctx := context.Background()
+
+	config, err := structpb.NewStruct(map[string]interface{}{"model": "small", "api_key": "secret"})
+	require.NoError(t, err)
+	plugin, err := client.LoadPlugin(ctx, &controlplanev1.LoadPluginRequest{Name: "echo", Type: "echo", Config: config})
+	require.NoError(t, err)
+	assert.Equal(t, "echo", plugin.GetName())
+	assert.Equal(t, string(core.PluginTypeAgent), plugin.GetType())
+
+	_, err = client.LoadPlugin(ctx, &controlplanev1.LoadPluginRequest{Name: "echo", Type: "echo"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = client.LoadPlugin(ctx, &controlplanev1.LoadPluginRequest{Name: "other", Type: "unknown"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	plugins, err := client.ListPlugins(ctx, &controlplanev1.ListPluginsRequest{})
+	require.NoError(t, err)
+	require.Len(t, plugins.GetPlugins(), 1)
+
+	settings, err := client.GetPluginConfig(ctx, &controlplanev1.GetPluginConfigRequest{Name: "echo"})
+	require.NoError(t, err)
+	assert.Equal(t, "small", settings.GetSettings().GetFields()["model"].GetStringValue())
+	assert.Equal(t, "********", settings.GetSettings().GetFields()["api_key"].GetStringValue())
+
+	answer, err := client.QueryAgent(ctx, &controlplanev1.QueryAgentRequest{Agent: "echo", Query: "status"})
+	require.NoError(t, err)
+	assert.Equal(t, "echo: status", answer.GetResponse())
+	assert.Equal(t, "echo", answer.GetMetadata().GetFields()["agent"].GetStringValue())
+
+	batch, err := client.QueryAgentBatch(ctx, &controlplanev1.QueryAgentBatchRequest{Agent: "echo", Queries: []string{"a", "b"}})
+	require.NoError(t, err)
+	require.Len(t, batch.GetResults(), 2)
+	assert.Equal(t, "echo: b", batch.GetResults()[1].GetResponse().GetResponse())
+
+	_, err = client.UnloadPlugin(ctx, &controlplanev1.UnloadPluginRequest{Name: "echo"})
+	require.NoError(t, err)
+	_, err = client.GetPlugin(ctx, &controlplanev1.GetPluginRequest{Name: "echo"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestServer_Authorization(t *testing.T) {
+	framework := newFramework(t, &core.FrameworkConfig{APIKeys: []core.APIKeyConfig{
+		{Name: "reader", Token: "read-token", Scopes: []string{"query"}},
+		{Name: "ops", Token: "admin-token", Scopes: []string{"admin"}},
+	}})
+	client := dial(t, framework)
+	withToken := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+
+	_, err := client.GetStatus(context.Background(), &controlplanev1.GetStatusRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.GetStatus(withToken("wrong"), &controlplanev1.GetStatusRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.GetStatus(withToken("read-token"), &controlplanev1.GetStatusRequest{})
+	assert.NoError(t, err)
+	_, err = client.LoadPlugin(withToken("read-token"), &controlplanev1.LoadPluginRequest{Name: "echo", Type: "echo"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "admin-token")
+	_, err = client.LoadPlugin(ctx, &controlplanev1.LoadPluginRequest{Name: "echo", Type: "echo"})
+	assert.NoError(t, err)
+
+	stream, err := client.WatchStatus(context.Background(), &controlplanev1.WatchStatusRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestServer_RuntimePluginsNeedAuthentication(t *testing.T) {
+	framework := newFramework(t, &core.FrameworkConfig{})
+	client := dial(t, framework)
+	ctx := context.Background()
+
+	_, err := client.LoadPlugin(ctx, &controlplanev1.LoadPluginRequest{Name: "echo", Type: "echo"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	settings, err := structpb.NewStruct(map[string]interface{}{"model": "small"})
+	require.NoError(t, err)
+	_, err = client.ReconfigurePlugin(ctx, &controlplanev1.ReconfigurePluginRequest{Name: "echo", Settings: settings})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestServer_WatchStatus(t *testing.T) {
+	framework := newFramework(t, &core.FrameworkConfig{AllowRuntimePlugins: true})
+	client := dial(t, framework)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := client.LoadPlugin(ctx, &controlplanev1.LoadPluginRequest{Name: "echo", Type: "echo"})
+	require.NoError(t, err)
+
+	stream, err := client.WatchStatus(ctx, &controlplanev1.WatchStatusRequest{Interval: durationpb.New(time.Second)})
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		update, err := stream.Recv()
+		require.NoError(t, err)
+		require.Len(t, update.GetPlugins(), 1)
+		assert.Equal(t, "echo", update.GetPlugins()[0].GetName())
+		assert.NotNil(t, update.GetTimestamp())
+	}
+}
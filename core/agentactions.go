@@ -0,0 +1,231 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Action types an agent may propose
+const (
+	// AgentActionRestart restarts the target service
+	AgentActionRestart = "restart"
+	// AgentActionScale scales the target service to parameters.replicas instances
+	AgentActionScale = "scale"
+	// AgentActionRollback rolls the target service back, to parameters.revision if given
+	AgentActionRollback = "rollback"
+	// AgentActionRunWorkflow runs the workflow parameters.workflow_id with parameters.input
+	AgentActionRunWorkflow = "run_workflow"
+)
+
+// ActionRisk is how much harm an action could do if it turns out to be wrong
+type ActionRisk string
+
+const (
+	ActionRiskLow    ActionRisk = "low"
+	ActionRiskMedium ActionRisk = "medium"
+	ActionRiskHigh   ActionRisk = "high"
+)
+
+// actionParameter is a parameter of an action type
+type actionParameter struct {
+	// kind is the JSON schema type: string, integer, or object
+	kind        string
+	description string
+	required    bool
+}
+
+// actionType describes what an action type does and the parameters it takes
+type actionType struct {
+	description string
+	parameters  map[string]actionParameter
+}
+
+// agentActionTypes are the action types agents may propose, by name
+var agentActionTypes = map[string]actionType{
+	AgentActionRestart: {description: "Restart the target service"},
+	AgentActionScale: {
+		description: "Scale the target service to a number of instances",
+		parameters: map[string]actionParameter{
+			"replicas": {kind: "integer", description: "Number of instances, at least 1", required: true},
+		},
+	},
+	AgentActionRollback: {
+		description: "Roll the target service back to an earlier release",
+		parameters: map[string]actionParameter{
+			"revision": {kind: "string", description: "Release to roll back to; the previous one if not given"},
+		},
+	},
+	AgentActionRunWorkflow: {
+		description: "Run a configured workflow against the target",
+		parameters: map[string]actionParameter{
+			"workflow_id": {kind: "string", description: "ID of the workflow to run", required: true},
+			"input":       {kind: "object", description: "Input passed to the workflow"},
+		},
+	},
+}
+
+// AgentActionTypes lists the action types agents may propose
+func AgentActionTypes() []string {
+	types := make([]string, 0, len(agentActionTypes))
+	for name := range agentActionTypes {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Validate checks an action against the schema of its type: a known type, a target, a
+// known risk level, and the parameters the type takes
+func (a AgentAction) Validate() error {
+	schema, ok := agentActionTypes[a.Type]
+	if !ok {
+		return NewValidationError("agent-action", "validate",
+			fmt.Sprintf("unknown action type %q; expected one of %s", a.Type, strings.Join(AgentActionTypes(), ", ")))
+	}
+	if strings.TrimSpace(a.Target) == "" {
+		return NewValidationError("agent-action", "validate", fmt.Sprintf("%s action needs a target", a.Type))
+	}
+	switch a.Risk {
+	case ActionRiskLow, ActionRiskMedium, ActionRiskHigh:
+	default:
+		return NewValidationError("agent-action", "validate", fmt.Sprintf("risk must be low, medium, or high, got %q", a.Risk))
+	}
+
+	for name, parameter := range schema.parameters {
+		if _, ok := a.Parameters[name]; parameter.required && !ok {
+			return NewValidationError("agent-action", "validate", fmt.Sprintf("%s action needs parameter %s", a.Type, name))
+		}
+	}
+	for name, value := range a.Parameters {
+		parameter, ok := schema.parameters[name]
+		if !ok {
+			return NewValidationError("agent-action", "validate", fmt.Sprintf("%s action does not take parameter %s", a.Type, name))
+		}
+		if err := parameter.check(value); err != nil {
+			return NewValidationError("agent-action", "validate", fmt.Sprintf("parameter %s %v", name, err))
+		}
+	}
+	return nil
+}
+
+// check reports whether a decoded JSON value fits the parameter
+func (p actionParameter) check(value interface{}) error {
+	switch p.kind {
+	case "integer":
+		var number float64
+		switch v := value.(type) {
+		case int:
+			number = float64(v)
+		case float64:
+			number = v
+		default:
+			return fmt.Errorf("must be an integer")
+		}
+		if number != float64(int64(number)) || number < 1 {
+			return fmt.Errorf("must be a positive integer")
+		}
+	case "string":
+		if text, ok := value.(string); !ok || text == "" {
+			return fmt.Errorf("must be a non-empty string")
+		}
+	case "object":
+		if _, ok := value.(map[string]interface{}); !ok {
+			return fmt.Errorf("must be an object")
+		}
+	}
+	return nil
+}
+
+// AgentActionSchema returns the JSON schema of a list of actions, for asking a model to
+// propose actions as structured output
+func AgentActionSchema() map[string]interface{} {
+	variants := make([]interface{}, 0, len(agentActionTypes))
+	for _, name := range AgentActionTypes() {
+		schema := agentActionTypes[name]
+		properties := make(map[string]interface{}, len(schema.parameters))
+		var required []string
+		for parameter, spec := range schema.parameters {
+			properties[parameter] = map[string]interface{}{"type": spec.kind, "description": spec.description}
+			if spec.required {
+				required = append(required, parameter)
+			}
+		}
+		sort.Strings(required)
+		parameters := map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}
+		if len(required) > 0 {
+			parameters["required"] = required
+		}
+		variants = append(variants, map[string]interface{}{
+			"type":        "object",
+			"description": schema.description,
+			"properties": map[string]interface{}{
+				"type":        map[string]interface{}{"const": name},
+				"description": map[string]interface{}{"type": "string", "description": "Why the action would help, in one sentence"},
+				"target":      map[string]interface{}{"type": "string", "description": "Service or resource the action acts on"},
+				"parameters":  parameters,
+				"risk":        map[string]interface{}{"enum": []string{string(ActionRiskLow), string(ActionRiskMedium), string(ActionRiskHigh)}},
+			},
+			"required": []string{"type", "description", "target", "risk"},
+		})
+	}
+	return map[string]interface{}{"type": "array", "items": map[string]interface{}{"oneOf": variants}}
+}
+
+// ProposeAgentAction turns an action an agent proposed into a remediation held for
+// approval. Once approved it runs the workflow configured for its type in
+// remediation.action_workflows, or the workflow a run_workflow action names, with the
+// action's parameters and target as input. Types without a workflow are carried out by
+// the responder configured in remediation.action_responders.
+func (f *Framework) ProposeAgentAction(ctx context.Context, action AgentAction, requestedBy, incidentID string) (Remediation, error) {
+	if err := action.Validate(); err != nil {
+		return Remediation{}, err
+	}
+
+	input := make(map[string]interface{}, len(action.Parameters)+1)
+	for key, value := range action.Parameters {
+		input[key] = value
+	}
+	workflowID := f.config.Remediation.ActionWorkflows[action.Type]
+	if action.Type == AgentActionRunWorkflow {
+		workflowID, _ = action.Parameters["workflow_id"].(string)
+		delete(input, "workflow_id")
+		if workflowInput, ok := action.Parameters["input"].(map[string]interface{}); ok {
+			delete(input, "input")
+			for key, value := range workflowInput {
+				input[key] = value
+			}
+		}
+	}
+	responder := ""
+	if workflowID == "" && action.Type != AgentActionRunWorkflow {
+		responder = f.config.Remediation.ActionResponders[action.Type]
+	}
+	if workflowID == "" && responder == "" {
+		return Remediation{}, NewConfigurationError("agent-action", "propose",
+			fmt.Sprintf("no workflow or responder configured for %s actions in remediation.action_workflows or action_responders", action.Type))
+	}
+	input["target"] = action.Target
+
+	reason := fmt.Sprintf("proposed by %s, %s risk", requestedBy, action.Risk)
+	if action.Description != "" {
+		reason += ": " + action.Description
+	}
+	held := f.remediations.hold(Remediation{
+		Service:     action.Target,
+		Action:      action.Type,
+		WorkflowID:  workflowID,
+		Responder:   responder,
+		Input:       input,
+		IncidentID:  incidentID,
+		RequestedBy: requestedBy,
+	}, reason, time.Now())
+	if incidentID != "" {
+		f.incidents.Annotate(incidentID, "action_proposed", requestedBy,
+			fmt.Sprintf("%s %s proposed as %s, waiting for approval", action.Type, action.Target, held.ID))
+	}
+	f.requestApproval(ctx, held)
+	return held, nil
+}
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentAction_Validate(t *testing.T) {
+	valid := []AgentAction{
+		{Type: AgentActionRestart, Target: "api", Risk: ActionRiskLow},
+		{Type: AgentActionScale, Target: "api", Risk: ActionRiskMedium, Parameters: map[string]interface{}{"replicas": 4.0}},
+		{Type: AgentActionRollback, Target: "api", Risk: ActionRiskHigh, Parameters: map[string]interface{}{"revision": "v41"}},
+		{Type: AgentActionRunWorkflow, Target: "api", Risk: ActionRiskLow,
+			Parameters: map[string]interface{}{"workflow_id": "drain", "input": map[string]interface{}{"zone": "b"}}},
+	}
+	for _, action := range valid {
+		assert.NoError(t, action.Validate(), action.Type)
+	}
+
+	invalid := map[string]AgentAction{
+		"unknown type":      {Type: "delete", Target: "api", Risk: ActionRiskLow},
+		"no target":         {Type: AgentActionRestart, Risk: ActionRiskLow},
+		"unknown risk":      {Type: AgentActionRestart, Target: "api", Risk: "none"},
+		"missing parameter": {Type: AgentActionScale, Target: "api", Risk: ActionRiskLow},
+		"fractional":        {Type: AgentActionScale, Target: "api", Risk: ActionRiskLow, Parameters: map[string]interface{}{"replicas": 2.5}},
+		"zero replicas":     {Type: AgentActionScale, Target: "api", Risk: ActionRiskLow, Parameters: map[string]interface{}{"replicas": 0.0}},
+		"extra parameter":   {Type: AgentActionRestart, Target: "api", Risk: ActionRiskLow, Parameters: map[string]interface{}{"force": true}},
+		"input not object":  {Type: AgentActionRunWorkflow, Target: "api", Risk: ActionRiskLow, Parameters: map[string]interface{}{"workflow_id": "drain", "input": "b"}},
+	}
+	for name, action := range invalid {
+		err := action.Validate()
+		assert.Error(t, err, name)
+		assert.Equal(t, ErrorTypeValidation, GetErrorType(err), name)
+	}
+
+	schema := AgentActionSchema()
+	assert.Equal(t, "array", schema["type"])
+	assert.Len(t, schema["items"].(map[string]interface{})["oneOf"], len(AgentActionTypes()), "Expected one schema per action type")
+}
+
+func TestFramework_ProposeAgentAction(t *testing.T) {
+	framework, engine := newRemediationFramework(&FrameworkConfig{Remediation: RemediationConfig{
+		MaxActionsPerHour: 10, ActionWorkflows: map[string]string{AgentActionScale: "scale-service"},
+	}})
+	ctx := context.Background()
+	incident, _ := framework.GetIncidentManager().Track(&Analysis{Summary: "checkout saturated", Source: "anomaly"})
+
+	held, err := framework.ProposeAgentAction(ctx, AgentAction{
+		Type: AgentActionScale, Description: "CPU is pinned", Target: "checkout", Risk: ActionRiskMedium,
+		Parameters: map[string]interface{}{"replicas": 6.0},
+	}, "ai-agent", incident.ID)
+	require.NoError(t, err)
+	assert.Equal(t, RemediationStatusPending, held.Status, "Expected a proposed action to wait for approval even under the cap")
+	assert.Equal(t, "scale-service", held.WorkflowID)
+	assert.Equal(t, "proposed by ai-agent, medium risk: CPU is pinned", held.Reason)
+	assert.Empty(t, engine.executed)
+
+	approved, err := framework.ApproveRemediation(ctx, held.ID, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, RemediationStatusExecuted, approved.Status)
+	require.Len(t, engine.inputs, 1)
+	assert.Equal(t, map[string]interface{}{"replicas": 6.0, "target": "checkout"}, engine.inputs[0])
+
+	incident, err = framework.GetIncidentManager().Get(incident.ID)
+	require.NoError(t, err)
+	var events []string
+	for _, event := range incident.Timeline {
+		events = append(events, event.Type)
+	}
+	assert.Contains(t, events, "action_proposed")
+
+	// run_workflow names its own workflow and input
+	held, err = framework.ProposeAgentAction(ctx, AgentAction{
+		Type: AgentActionRunWorkflow, Target: "checkout", Risk: ActionRiskLow,
+		Parameters: map[string]interface{}{"workflow_id": "drain", "input": map[string]interface{}{"zone": "b"}},
+	}, "ai-agent", "")
+	require.NoError(t, err)
+	assert.Equal(t, "drain", held.WorkflowID)
+	assert.Equal(t, map[string]interface{}{"zone": "b", "target": "checkout"}, held.Input)
+
+	_, err = framework.ProposeAgentAction(ctx, AgentAction{Type: AgentActionRestart, Target: "checkout", Risk: ActionRiskLow}, "ai-agent", "")
+	assert.Equal(t, ErrorTypeConfiguration, GetErrorType(err), "Expected an error without a workflow for restarts")
+}
+
+func TestFramework_ProposeActionAPI(t *testing.T) {
+	framework, _ := newRemediationFramework(&FrameworkConfig{Remediation: RemediationConfig{
+		ActionWorkflows: map[string]string{AgentActionRestart: "restart-service"},
+	}})
+	mux := http.NewServeMux()
+	framework.registerAPIRoutes(mux)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/actions", strings.NewReader(body)))
+		return recorder
+	}
+	recorder := post(`{"actor": "alice", "action": {"type": "restart", "target": "api", "risk": "low"}}`)
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"status":"pending_approval"`)
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"actor": "alice", "action": {"type": "restart", "target": "api"}}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"action": {"type": "restart", "target": "api", "risk": "low"}}`).Code)
+	assert.Equal(t, http.StatusConflict, post(`{"actor": "alice", "action": {"type": "scale", "target": "api", "risk": "low", "parameters": {"replicas": 2}}}`).Code)
+}
+
+func TestValidateFrameworkConfig_ActionWorkflows(t *testing.T) {
+	config := reloadConfig()
+	config.Remediation.ActionWorkflows = map[string]string{AgentActionRestart: "restart-service"}
+	assert.NoError(t, ValidateFrameworkConfig(config))
+	config.Remediation.ActionWorkflows = map[string]string{"delete": "delete-service"}
+	assert.ErrorContains(t, ValidateFrameworkConfig(config), "unknown action type")
+	config.Remediation.ActionWorkflows = map[string]string{AgentActionRunWorkflow: "drain"}
+	assert.Error(t, ValidateFrameworkConfig(config), "Expected run_workflow actions to name their own workflow")
+	config.Remediation.ActionWorkflows = map[string]string{AgentActionScale: ""}
+	assert.Error(t, ValidateFrameworkConfig(config))
+}
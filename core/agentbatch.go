@@ -0,0 +1,163 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// defaultAgentQueryConcurrency is how many queries of a batch run at once by default
+const defaultAgentQueryConcurrency = 4
+
+// AgentBatchResult is the answer to one query of a batch
+type AgentBatchResult struct {
+	Query      string         `json:"query"`
+	Response   *AgentResponse `json:"response,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	DurationMS int64          `json:"duration_ms"`
+}
+
+// agentContext holds the data most recently given to agents
+type agentContext struct {
+	data []DataPoint
+	mu   sync.RWMutex
+}
+
+// set replaces the context data
+func (c *agentContext) set(data []DataPoint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data = data
+}
+
+// snapshot returns the context data. Batches are never modified, so it is shared.
+func (c *agentContext) snapshot() []DataPoint {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.data
+}
+
+// newAgentQueryLimiter creates the rate limiter shared by all batched queries, or nil when
+// they are unlimited
+func newAgentQueryLimiter(config AgentQueryConfig) RateLimiter {
+	return NewRateLimiter(RateLimiterConfig{Rate: config.RateLimit, Burst: config.Burst})
+}
+
+// QueryAgentBatch answers several queries with one agent. The queries run in parallel up
+// to the configured concurrency and rate limit, and agents implementing SnapshotAgent
+// answer all of them against the same context snapshot. Results are in query order; a
+// failed query is reported in its result rather than failing the batch.
+func (f *Framework) QueryAgentBatch(ctx context.Context, agentName string, queries []string) ([]AgentBatchResult, error) {
+	f.mu.RLock()
+	plugin, err := f.registry.GetPlugin(agentName)
+	f.mu.RUnlock()
+	if err != nil {
+		return nil, NewPluginError("framework", "query-batch", fmt.Sprintf("agent %s not found", agentName))
+	}
+
+	agentPlugin, ok := plugin.(AgentPlugin)
+	if !ok {
+		return nil, NewPluginError("framework", "query-batch", fmt.Sprintf("plugin %s is not an agent", agentName))
+	}
+
+	process := agentPlugin.ProcessQuery
+	if snapshotAgent, ok := agentPlugin.(SnapshotAgent); ok {
+		data := f.agentContext.snapshot()
+		process = func(ctx context.Context, query string) (*AgentResponse, error) {
+			return snapshotAgent.ProcessQueryWithContext(ctx, query, data)
+		}
+	}
+
+	concurrency := f.config.AgentQueries.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultAgentQueryConcurrency
+	}
+
+	results := make([]AgentBatchResult, len(queries))
+	for i, query := range queries {
+		results[i].Query = query
+	}
+
+	slots := make(chan struct{}, concurrency)
+	acquire := func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		// A token is only taken once a slot is free, so waiting for a slot does not use up the rate
+		if f.agentLimiter != nil {
+			if err := f.agentLimiter.Wait(ctx); err != nil {
+				<-slots
+				return err
+			}
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for i := range results {
+		if err := acquire(); err != nil {
+			// The remaining queries are reported as not run
+			for j := i; j < len(results); j++ {
+				results[j].Error = err.Error()
+			}
+			break
+		}
+
+		wg.Add(1)
+		go func(result *AgentBatchResult) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			ctx, span := f.startSpan(ctx, "agent.query", attribute.String("agent", agentName))
+			started := time.Now()
+			var response *AgentResponse
+			var err error
+			f.sandbox.run(agentName, func() error { response, err = process(ctx, result.Query); return err })
+			result.DurationMS = time.Since(started).Milliseconds()
+			result.Response = response
+			if err != nil {
+				result.Error = err.Error()
+			}
+			f.recordAgentQuery(TraceIDFromContext(ctx), agentName, result.Query, response, err)
+			endSpan(span, err)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+	slog.Info("Agent batch query finished", "agent", agentName, "queries", len(queries), "failed", failed)
+	return results, nil
+}
+
+// recordAgentQuery writes an agent query and its outcome to the debug log
+func (f *Framework) recordAgentQuery(traceID, agentName, query string, response *AgentResponse, err error) {
+	event := DebugEvent{
+		TraceID:  traceID,
+		Stage:    DebugStageAgent,
+		Plugin:   agentName,
+		Decision: "answered",
+		Data:     map[string]interface{}{"query": query},
+	}
+	if err != nil {
+		event.Decision = "failed"
+		event.Reason = err.Error()
+	} else if response != nil {
+		event.Data["response"] = response.Response
+		event.Data["confidence"] = response.Confidence
+	}
+	f.debugLog.Load().Record(event)
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchAgent answers with the number of context data points it was given and tracks how
+// many queries ran at once
+type batchAgent struct {
+	MockPlugin
+	onQuery  func(query string)
+	running  int
+	peak     int
+	contexts []int
+	mu       sync.Mutex
+}
+
+func (a *batchAgent) ProcessQuery(ctx context.Context, query string) (*AgentResponse, error) {
+	return a.ProcessQueryWithContext(ctx, query, nil)
+}
+
+func (a *batchAgent) ProcessQueryWithContext(ctx context.Context, query string, data []DataPoint) (*AgentResponse, error) {
+	a.mu.Lock()
+	a.running++
+	if a.running > a.peak {
+		a.peak = a.running
+	}
+	a.contexts = append(a.contexts, len(data))
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		a.running--
+		a.mu.Unlock()
+	}()
+
+	if a.onQuery != nil {
+		a.onQuery(query)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if query == "fail" {
+		return nil, fmt.Errorf("model unavailable")
+	}
+	return &AgentResponse{Query: query, Response: fmt.Sprintf("%s (%d points)", query, len(data))}, nil
+}
+
+func (a *batchAgent) SetContext(data []DataPoint) {}
+
+func (a *batchAgent) GetAvailableQueries() []string { return nil }
+
+func TestFramework_QueryAgentBatch(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout",
+		AgentQueries: AgentQueryConfig{Concurrency: 2}})
+	agent := &batchAgent{MockPlugin: MockPlugin{name: "ai", pluginType: PluginTypeAgent}}
+	require.NoError(t, framework.LoadPlugin(agent))
+
+	framework.agentContext.set([]DataPoint{{Metric: "cpu"}, {Metric: "memory"}})
+	// New data arriving mid-batch must not change what the remaining queries see
+	var once sync.Once
+	agent.onQuery = func(string) {
+		once.Do(func() { framework.agentContext.set([]DataPoint{{Metric: "cpu"}}) })
+	}
+
+	queries := []string{"summary", "fail", "top errors", "capacity", "latency"}
+	results, err := framework.QueryAgentBatch(context.Background(), "ai", queries)
+	require.NoError(t, err)
+	require.Len(t, results, len(queries))
+
+	for i, result := range results {
+		assert.Equal(t, queries[i], result.Query, "Expected results in query order")
+	}
+	assert.Equal(t, "summary (2 points)", results[0].Response.Response)
+	assert.Equal(t, "model unavailable", results[1].Error)
+	assert.Nil(t, results[1].Response)
+	assert.Equal(t, "latency (2 points)", results[4].Response.Response)
+
+	assert.Equal(t, []int{2, 2, 2, 2, 2}, agent.contexts, "Expected every query to use the snapshot taken at the start")
+	assert.LessOrEqual(t, agent.peak, 2, "Expected at most the configured number of queries at once")
+	assert.Equal(t, 2, agent.peak, "Expected queries to run in parallel")
+
+	_, err = framework.QueryAgentBatch(context.Background(), "missing", queries)
+	assert.Equal(t, ErrorTypePlugin, GetErrorType(err))
+}
+
+func TestFramework_QueryAgentBatchRateLimit(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout",
+		AgentQueries: AgentQueryConfig{Concurrency: 5, RateLimit: 20, Burst: 1}})
+	agent := &batchAgent{MockPlugin: MockPlugin{name: "ai", pluginType: PluginTypeAgent}}
+	require.NoError(t, framework.LoadPlugin(agent))
+
+	started := time.Now()
+	results, err := framework.QueryAgentBatch(context.Background(), "ai", []string{"a", "b", "c", "d", "e"})
+	require.NoError(t, err)
+	assert.Len(t, results, 5)
+	// One query at once, then one every 50ms
+	assert.GreaterOrEqual(t, time.Since(started), 190*time.Millisecond, "Expected the rate limit to space out queries")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err = framework.QueryAgentBatch(ctx, "ai", []string{"a", "b"})
+	require.NoError(t, err)
+	for _, result := range results {
+		assert.Equal(t, context.Canceled.Error(), result.Error, "Expected queries not run to report why")
+	}
+}
+
+func TestFramework_QueryBatchAPI(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout", DefaultAgent: "ai"})
+	require.NoError(t, framework.LoadPlugin(&batchAgent{MockPlugin: MockPlugin{name: "ai", pluginType: PluginTypeAgent}}))
+
+	mux := http.NewServeMux()
+	framework.registerAPIRoutes(mux)
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/query/batch", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := post(`{"queries": ["summary", "capacity"]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var results []AgentBatchResult
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&results))
+	require.Len(t, results, 2)
+	assert.Equal(t, "capacity (0 points)", results[1].Response.Response)
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"queries": []}`).Code)
+	assert.Equal(t, http.StatusNotFound, post(`{"agent": "missing", "queries": ["summary"]}`).Code)
+}
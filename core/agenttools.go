@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// AgentToolProvider gives agents read access to what the framework knows, so a model can
+// look up more than the context it was given while it answers
+type AgentToolProvider interface {
+	// QueryDataPoints returns recorded data points matching the query
+	QueryDataPoints(ctx context.Context, query HistoryQuery) ([]DataPoint, error)
+	// QueryAnalyses returns recorded analyses matching the query
+	QueryAnalyses(ctx context.Context, query HistoryQuery) ([]Analysis, error)
+	// QueryCollector runs an ad-hoc query through a collector; an empty name picks the
+	// first loaded collector that supports queries
+	QueryCollector(ctx context.Context, collector, query string) ([]DataPoint, error)
+	// PluginStates returns the status and health of every loaded plugin
+	PluginStates(ctx context.Context) []PluginState
+}
+
+// AgentToolsAware is implemented by agents that let their model call framework tools. The
+// framework provides itself as the tools when the plugin is loaded.
+type AgentToolsAware interface {
+	SetAgentTools(tools AgentToolProvider)
+}
+
+// QueryableCollector is implemented by collectors that can run ad-hoc queries, such as
+// PromQL, besides their configured ones
+type QueryableCollector interface {
+	DataCollector
+
+	// Query runs a query in the collector's language and returns its result as data points
+	Query(ctx context.Context, query string) ([]DataPoint, error)
+}
+
+// AgentToolCall records a tool an agent called while answering. Agents list the calls in
+// order under the "tool_calls" key of AgentResponse.Metadata.
+type AgentToolCall struct {
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Result    interface{}            `json:"result,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+// QueryDataPoints returns the data points in the history matching the query
+func (f *Framework) QueryDataPoints(ctx context.Context, query HistoryQuery) ([]DataPoint, error) {
+	return f.dataHistory.Query(ctx, query)
+}
+
+// QueryAnalyses returns the analyses in the history matching the query
+func (f *Framework) QueryAnalyses(ctx context.Context, query HistoryQuery) ([]Analysis, error) {
+	return f.history.Query(ctx, query)
+}
+
+// QueryCollector runs an ad-hoc query through the named collector, or through the first
+// loaded collector by name that supports queries when no name is given
+func (f *Framework) QueryCollector(ctx context.Context, name, query string) ([]DataPoint, error) {
+	if name != "" {
+		plugin, err := f.registry.GetPlugin(name)
+		if err != nil {
+			return nil, WrapError(err, ErrorTypePlugin, "framework", "query-collector", fmt.Sprintf("collector %s not found", name))
+		}
+		collector, ok := plugin.(QueryableCollector)
+		if !ok {
+			return nil, NewPluginError("framework", "query-collector", fmt.Sprintf("plugin %s does not support queries", name))
+		}
+		return collector.Query(ctx, query)
+	}
+
+	plugins := f.registry.ListPluginsByType(PluginTypeCollector)
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name() < plugins[j].Name() })
+	for _, plugin := range plugins {
+		if collector, ok := plugin.(QueryableCollector); ok {
+			return collector.Query(ctx, query)
+		}
+	}
+	return nil, NewPluginError("framework", "query-collector", "no loaded collector supports queries")
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// queryableCollector answers every query with one point named after it
+type queryableCollector struct {
+	MockCollector
+}
+
+func (c *queryableCollector) Query(ctx context.Context, query string) ([]DataPoint, error) {
+	return []DataPoint{{Timestamp: time.Now(), Source: c.name, Metric: query, Value: 1}}, nil
+}
+
+// toolsAgent keeps the tools the framework provides
+type toolsAgent struct {
+	batchAgent
+	tools AgentToolProvider
+}
+
+func (a *toolsAgent) SetAgentTools(tools AgentToolProvider) { a.tools = tools }
+
+func TestFramework_AgentTools(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout"})
+	agent := &toolsAgent{batchAgent: batchAgent{MockPlugin: MockPlugin{name: "ai", pluginType: PluginTypeAgent}}}
+	require.NoError(t, framework.LoadPlugin(agent))
+	require.Same(t, framework, agent.tools, "Expected the framework to provide itself as the tools")
+
+	ctx := context.Background()
+	_, err := framework.QueryCollector(ctx, "", "up")
+	assert.Error(t, err, "Expected no collector to support queries")
+
+	require.NoError(t, framework.LoadPlugin(&MockCollector{MockPlugin: MockPlugin{name: "a-plain", pluginType: PluginTypeCollector}}))
+	require.NoError(t, framework.LoadPlugin(&queryableCollector{MockCollector{MockPlugin: MockPlugin{name: "prometheus", pluginType: PluginTypeCollector}}}))
+
+	points, err := framework.QueryCollector(ctx, "", "up")
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+	assert.Equal(t, "prometheus", points[0].Source, "Expected the collector that supports queries to be picked")
+	assert.Equal(t, "up", points[0].Metric)
+
+	_, err = framework.QueryCollector(ctx, "a-plain", "up")
+	assert.ErrorContains(t, err, "does not support queries")
+	_, err = framework.QueryCollector(ctx, "missing", "up")
+	assert.Equal(t, ErrorTypePlugin, GetErrorType(err))
+
+	require.NoError(t, framework.dataHistory.Record(ctx, []DataPoint{
+		{Timestamp: time.Now(), Source: "prometheus", Metric: "cpu_usage", Value: 0.9},
+		{Timestamp: time.Now(), Source: "prometheus", Metric: "memory_usage", Value: 0.4},
+	}))
+	points, err = framework.QueryDataPoints(ctx, HistoryQuery{Metric: "cpu_*"})
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+	assert.Equal(t, 0.9, points[0].Value)
+}
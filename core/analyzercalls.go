@@ -0,0 +1,79 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultAnalyzerTimeout bounds an analyzer call when no timeout is configured
+const defaultAnalyzerTimeout = 30 * time.Second
+
+// analyzerCall is one analyzer call in flight
+type analyzerCall struct {
+	analysis  *Analysis
+	err       error
+	done      chan struct{}
+	abandoned bool
+}
+
+// analyzerCalls runs analyzer calls under a timeout and tracks the calls that timed out but
+// have not returned. An analyzer with such a call is skipped until it returns, so a hung
+// analyzer holds one goroutine rather than one per batch.
+type analyzerCalls struct {
+	mu        sync.Mutex
+	abandoned map[string]int
+}
+
+// stuck reports whether the analyzer has a call that timed out and has not returned
+func (c *analyzerCalls) stuck(analyzer string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.abandoned[analyzer] > 0
+}
+
+// run calls the analyzer and waits up to timeout for it, reporting whether it timed out. A
+// call that times out keeps running in the background and its analysis is dropped.
+func (c *analyzerCalls) run(analyzer string, timeout time.Duration, call func() (*Analysis, error)) (*Analysis, bool, error) {
+	if timeout <= 0 {
+		timeout = defaultAnalyzerTimeout
+	}
+
+	inflight := &analyzerCall{done: make(chan struct{})}
+	go func() {
+		analysis, err := call()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if inflight.abandoned {
+			if c.abandoned[analyzer]--; c.abandoned[analyzer] <= 0 {
+				delete(c.abandoned, analyzer)
+			}
+			return
+		}
+		inflight.analysis, inflight.err = analysis, err
+		close(inflight.done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-inflight.done:
+		return inflight.analysis, false, inflight.err
+	case <-timer.C:
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-inflight.done:
+		// The call returned while the timer fired
+		return inflight.analysis, false, inflight.err
+	default:
+	}
+	inflight.abandoned = true
+	if c.abandoned == nil {
+		c.abandoned = make(map[string]int)
+	}
+	c.abandoned[analyzer]++
+	return nil, true, NewTimeoutError("framework", "analyze", fmt.Sprintf("analyzer %s did not return within %s", analyzer, timeout))
+}
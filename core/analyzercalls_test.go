@@ -0,0 +1,109 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingAnalyzer reports once release is closed, after signalling that it started
+type blockingAnalyzer struct {
+	reportingAnalyzer
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingAnalyzer) Analyze(data []DataPoint) (*Analysis, error) {
+	b.started <- struct{}{}
+	<-b.release
+	return b.reportingAnalyzer.Analyze(data)
+}
+
+func newBlockingAnalyzer(name string) *blockingAnalyzer {
+	return &blockingAnalyzer{
+		reportingAnalyzer: reportingAnalyzer{MockAnalyzer{MockPlugin{name: name, pluginType: PluginTypeAnalyzer}}},
+		started:           make(chan struct{}, 10),
+		release:           make(chan struct{}),
+	}
+}
+
+func TestAnalyzerCalls_Timeout(t *testing.T) {
+	var calls analyzerCalls
+	release := make(chan struct{})
+
+	analysis, timedOut, err := calls.run("slow", 20*time.Millisecond, func() (*Analysis, error) {
+		<-release
+		return &Analysis{}, nil
+	})
+	assert.Nil(t, analysis)
+	assert.True(t, timedOut)
+	assert.Equal(t, ErrorTypeTimeout, GetErrorType(err))
+	assert.True(t, calls.stuck("slow"), "Expected an analyzer with a call that timed out to be stuck")
+	assert.False(t, calls.stuck("fast"))
+
+	close(release)
+	assert.Eventually(t, func() bool { return !calls.stuck("slow") }, time.Second, 5*time.Millisecond,
+		"Expected the analyzer to be released once its call returns")
+
+	analysis, timedOut, err = calls.run("slow", time.Second, func() (*Analysis, error) { return &Analysis{Summary: "done"}, nil })
+	require.NoError(t, err)
+	assert.False(t, timedOut)
+	assert.Equal(t, "done", analysis.Summary)
+}
+
+func TestFramework_RunsAnalyzersConcurrently(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout", AnalyzerTimeout: 5 * time.Second})
+	first := newBlockingAnalyzer("first")
+	second := newBlockingAnalyzer("second")
+	responder := &severityResponder{MockPlugin: MockPlugin{name: "responder", pluginType: PluginTypeResponder}, severity: "low"}
+	for _, plugin := range []Plugin{first, second, responder} {
+		require.NoError(t, framework.LoadPlugin(plugin))
+	}
+
+	// Each analyzer is released only once both have started
+	go func() {
+		<-first.started
+		<-second.started
+		close(first.release)
+		close(second.release)
+	}()
+
+	framework.processData(context.Background(), []DataPoint{{Source: "api", Metric: "latency", Value: 900, Timestamp: time.Now()}})
+
+	require.Len(t, responder.handled, 2)
+	assert.Equal(t, "first", responder.handled[0].Source, "Expected analyses to be handled in analyzer order")
+	assert.Equal(t, "second", responder.handled[1].Source)
+}
+
+func TestFramework_AnalyzerTimeout(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout", AnalyzerTimeout: 50 * time.Millisecond})
+	slow := newBlockingAnalyzer("slow")
+	fast := newCapturingAnalyzer("fast")
+	responder := &severityResponder{MockPlugin: MockPlugin{name: "responder", pluginType: PluginTypeResponder}, severity: "low"}
+	for _, plugin := range []Plugin{slow, fast, responder} {
+		require.NoError(t, framework.LoadPlugin(plugin))
+	}
+	data := []DataPoint{{Source: "api", Metric: "latency", Value: 900, Timestamp: time.Now()}}
+
+	start := time.Now()
+	framework.processData(context.Background(), data)
+	assert.Less(t, time.Since(start), time.Second, "Expected a hung analyzer not to hold up the batch")
+	require.Len(t, responder.handled, 1, "Expected the other analyzers' analyses to be handled")
+	assert.Equal(t, "fast", responder.handled[0].Source)
+
+	// A hung analyzer is skipped rather than called again
+	framework.processData(context.Background(), data)
+	assert.Len(t, slow.started, 1)
+	assert.Len(t, fast.batches, 2)
+
+	var buf bytes.Buffer
+	require.NoError(t, framework.WriteMetrics(&buf))
+	assert.Contains(t, buf.String(), `framework_analyzer_timeouts_total{analyzer="slow"} 1`)
+
+	close(slow.release)
+	assert.Eventually(t, func() bool { return !framework.analyzerCalls.stuck("slow") }, time.Second, 5*time.Millisecond)
+}
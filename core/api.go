@@ -0,0 +1,899 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// queryRequest is the body accepted by the query endpoint
+type queryRequest struct {
+	Agent string `json:"agent,omitempty"`
+	Query string `json:"query"`
+}
+
+// queryBatchRequest is the body accepted by the batch query endpoint
+type queryBatchRequest struct {
+	Agent   string   `json:"agent,omitempty"`
+	Queries []string `json:"queries"`
+}
+
+// registerAPIRoutes registers the management API endpoints on the mux
+func (f *Framework) registerAPIRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/v1/openapi.json", f.handleOpenAPI)
+	mux.HandleFunc("/api/v1/ingest", f.apiKeys.Require(APIScopeIngest, f.handleIngest))
+	mux.HandleFunc("/api/v1/query", f.apiKeys.Require(APIScopeQuery, f.handleQuery))
+	mux.HandleFunc("/api/v1/query/batch", f.apiKeys.Require(APIScopeQuery, f.handleQueryBatch))
+	mux.HandleFunc("/api/v1/query/stream", f.apiKeys.Require(APIScopeQuery, f.handleQueryStream))
+	mux.HandleFunc("/api/v1/keys", f.apiKeys.Require(APIScopeAdmin, f.handleKeys))
+	mux.HandleFunc("/api/v1/incidents", f.apiKeys.Require(APIScopeQuery, f.handleIncidents))
+	mux.HandleFunc("/api/v1/analyses", f.apiKeys.Require(APIScopeQuery, f.handleAnalyses))
+	mux.HandleFunc("/api/v1/datapoints", f.apiKeys.Require(APIScopeQuery, f.handleDataPoints))
+	mux.HandleFunc("/api/v1/analyses/stream", f.apiKeys.Require(APIScopeQuery, f.handleAnalysisStream))
+	mux.HandleFunc("/api/v1/stream", f.apiKeys.Require(APIScopeQuery, f.handleStream))
+	mux.HandleFunc("/api/v1/reports/noise", f.apiKeys.Require(APIScopeQuery, f.handleNoiseReport))
+	mux.HandleFunc("/api/v1/metadata", f.apiKeys.Require(APIScopeQuery, f.handleMetricMetadata))
+	mux.HandleFunc("/api/v1/explain", f.apiKeys.Require(APIScopeQuery, f.handleExplain))
+	mux.HandleFunc("/api/v1/dry-run", f.apiKeys.Require(APIScopeQuery, f.handleDryRun))
+	mux.HandleFunc("/api/v1/deliveries", f.apiKeys.Require(APIScopeQuery, f.handleDeliveries))
+	mux.HandleFunc("/api/v1/remediations", f.apiKeys.Require(APIScopeQuery, f.handleRemediations))
+	mux.HandleFunc("/api/v1/remediations/", f.apiKeys.Require(APIScopeOperate, f.handleRemediationDecision))
+	mux.HandleFunc("/api/v1/remediations/audit", f.apiKeys.Require(APIScopeQuery, f.handleApprovalAudit))
+	mux.HandleFunc("/api/v1/workflows/", f.apiKeys.Require(APIScopeOperate, f.handleStartWorkflow))
+	mux.HandleFunc("/api/v1/actions", f.apiKeys.Require(APIScopeOperate, f.handleProposeAction))
+	mux.HandleFunc("/api/v1/plugins", f.handlePlugins)
+	mux.HandleFunc("/api/v1/plugins/", f.handlePlugin)
+	mux.HandleFunc("/api/v1/maintenance-windows", f.apiKeys.Require(APIScopeQuery, f.handleMaintenanceWindows))
+	mux.HandleFunc("/api/v1/silences", f.handleSilences)
+	mux.HandleFunc("/api/v1/snapshots", f.handleSnapshots)
+	mux.HandleFunc("/api/v1/snapshots/", f.handleSnapshot)
+	mux.HandleFunc("/api/v1/silences/", f.apiKeys.Require(APIScopeOperate, f.handleExpireSilence))
+	mux.HandleFunc("/api/v1/knowledge", f.handleKnowledge)
+	mux.HandleFunc("/api/v1/knowledge/", f.handleKnowledgeItem)
+
+	// Slack authenticates interaction callbacks with its signing secret instead of an API key
+	mux.HandleFunc("/api/v1/interactions/slack", f.handleSlackInteraction)
+}
+
+// handleIngest accepts a batch of data points and feeds it into the pipeline
+func (f *Framework) handleIngest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var data []DataPoint
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, fmt.Sprintf("invalid data points: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(data) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Data the pipeline cannot take now is rejected, so the sender can retry it
+	if err := f.Ingest(r.Context(), data); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleQuery sends a query to the requested or default agent
+func (f *Framework) handleQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req queryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Query == "" {
+		http.Error(w, "request must include a query", http.StatusBadRequest)
+		return
+	}
+
+	var response *AgentResponse
+	var err error
+	if req.Agent != "" {
+		response, err = f.QueryAgent(r.Context(), req.Agent, req.Query)
+	} else {
+		response, err = f.QueryDefaultAgent(r.Context(), req.Query)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleQueryBatch sends several queries to the requested or default agent at once
+func (f *Framework) handleQueryBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req queryBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Queries) == 0 {
+		http.Error(w, "request must include queries", http.StatusBadRequest)
+		return
+	}
+
+	agent := req.Agent
+	if agent == "" {
+		agent = f.config.DefaultAgent
+	}
+	if agent == "" {
+		http.Error(w, "no agent given and no default agent configured", http.StatusBadRequest)
+		return
+	}
+
+	results, err := f.QueryAgentBatch(r.Context(), agent, req.Queries)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+// handleKeys reports usage of the configured API keys
+func (f *Framework) handleKeys(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, f.apiKeys.Usage())
+}
+
+// handleIncidents lists tracked incidents, optionally only those with a given fingerprint
+func (f *Framework) handleIncidents(w http.ResponseWriter, r *http.Request) {
+	incidents := f.incidents.List()
+	if fingerprint := r.URL.Query().Get("fingerprint"); fingerprint != "" {
+		matching := make([]Incident, 0, 1)
+		for _, incident := range incidents {
+			if incident.Fingerprint == fingerprint {
+				matching = append(matching, incident)
+			}
+		}
+		incidents = matching
+	}
+	writeJSON(w, http.StatusOK, incidents)
+}
+
+// handleAnalyses lists the analyses in the history matching the query parameters
+func (f *Framework) handleAnalyses(w http.ResponseWriter, r *http.Request) {
+	query, err := parseHistoryQuery(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	analyses, err := f.history.Query(r.Context(), query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if analyses == nil {
+		analyses = []Analysis{}
+	}
+	writeJSON(w, http.StatusOK, analyses)
+}
+
+// handleDataPoints lists the data points in the history matching the query parameters
+func (f *Framework) handleDataPoints(w http.ResponseWriter, r *http.Request) {
+	query, err := parseHistoryQuery(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.MinSeverity != "" {
+		http.Error(w, "severity only applies to analyses", http.StatusBadRequest)
+		return
+	}
+
+	points, err := f.dataHistory.Query(r.Context(), query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if points == nil {
+		points = []DataPoint{}
+	}
+	writeJSON(w, http.StatusOK, points)
+}
+
+// handleNoiseReport ranks the noisiest alerting metrics over the window query parameter,
+// or the configured window
+func (f *Framework) handleNoiseReport(w http.ResponseWriter, r *http.Request) {
+	var window time.Duration
+	if raw := r.URL.Query().Get("window"); raw != "" {
+		var err error
+		if window, err = time.ParseDuration(raw); err != nil || window <= 0 {
+			http.Error(w, "window must be a positive duration", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := f.NoiseReport(r.Context(), window)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleMetricMetadata lists known metric metadata
+func (f *Framework) handleMetricMetadata(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, f.metadata.List())
+}
+
+// handleDryRun returns the would-have-fired report of dry-run mode
+func (f *Framework) handleDryRun(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, f.dryRun.Summary())
+}
+
+// handlePlugins lists the loaded plugins with their status and health, or loads a new one
+func (f *Framework) handlePlugins(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		f.apiKeys.Require(APIScopeQuery, func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, f.PluginStates(r.Context()))
+		})(w, r)
+	case http.MethodPost:
+		f.apiKeys.Require(APIScopeAdmin, f.handleLoadPlugin)(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleLoadPlugin creates a plugin from a configuration like those in plugins.yaml, e.g.
+// POST /api/v1/plugins with {"name": "grpc", "type": "grpc_health", "config": {...}}. On a
+// running framework the plugin starts at once, collectors included.
+func (f *Framework) handleLoadPlugin(w http.ResponseWriter, r *http.Request) {
+	if !f.AllowsRuntimePlugins() {
+		http.Error(w, ErrRuntimePluginsRefused.Error(), http.StatusForbidden)
+		return
+	}
+	var config PluginConfig
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		http.Error(w, fmt.Sprintf("invalid plugin config: %v", err), http.StatusBadRequest)
+		return
+	}
+	if config.Name == "" || config.Type == "" {
+		http.Error(w, "name and type are required", http.StatusBadRequest)
+		return
+	}
+	config.Enabled = true
+
+	if _, err := f.registry.GetPlugin(config.Name); err == nil {
+		http.Error(w, fmt.Sprintf("plugin %s is already loaded", config.Name), http.StatusConflict)
+		return
+	}
+	if err := f.LoadPluginFromConfig(config); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	plugin, err := f.registry.GetPlugin(config.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusCreated, pluginState(r.Context(), plugin))
+}
+
+// handlePlugin serves a single plugin: GET /api/v1/plugins/<name> returns its state (scope
+// query), DELETE unloads it, and GET or PUT /api/v1/plugins/<name>/config reads or changes
+// its settings (scope admin)
+func (f *Framework) handlePlugin(w http.ResponseWriter, r *http.Request) {
+	name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/plugins/"), "/")
+	switch {
+	case name == "" || (action != "" && action != "config"):
+		http.Error(w, "expected /api/v1/plugins/<name> or /api/v1/plugins/<name>/config", http.StatusNotFound)
+	case action == "" && r.Method == http.MethodGet:
+		f.apiKeys.Require(APIScopeQuery, func(w http.ResponseWriter, r *http.Request) {
+			state, err := f.PluginState(r.Context(), name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, state)
+		})(w, r)
+	case action == "" && r.Method == http.MethodDelete:
+		f.apiKeys.Require(APIScopeAdmin, f.handleUnloadPlugin)(w, r)
+	case action == "config" && r.Method == http.MethodGet:
+		f.apiKeys.Require(APIScopeAdmin, func(w http.ResponseWriter, r *http.Request) {
+			settings, err := f.PluginSettings(name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, settings)
+		})(w, r)
+	case action == "config" && r.Method == http.MethodPut:
+		f.apiKeys.Require(APIScopeAdmin, f.handleReconfigurePlugin)(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// pluginActionResponse is the body returned when a plugin is unloaded or reconfigured
+type pluginActionResponse struct {
+	Plugin string `json:"plugin"`
+	Status string `json:"status"`
+}
+
+// handleUnloadPlugin stops a plugin, along with its collector or evaluation worker, and
+// removes it
+func (f *Framework) handleUnloadPlugin(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/v1/plugins/")
+	if name == "" || strings.Contains(name, "/") {
+		http.Error(w, "expected /api/v1/plugins/<name>", http.StatusNotFound)
+		return
+	}
+
+	if err := f.UnloadPlugin(name); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, pluginActionResponse{Plugin: name, Status: "unloaded"})
+}
+
+// handleDeliveries reports queued deliveries and destination health per responder
+func (f *Framework) handleDeliveries(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, f.DeliveryStatus())
+}
+
+// explainResponse is the body returned by the explain endpoint
+type explainResponse struct {
+	Metric   string    `json:"metric"`
+	At       time.Time `json:"at"`
+	Verdicts []Verdict `json:"verdicts"`
+}
+
+// handleExplain reports how analyzers judged a metric around a point in time
+func (f *Framework) handleExplain(w http.ResponseWriter, r *http.Request) {
+	metric := r.URL.Query().Get("metric")
+	if metric == "" {
+		http.Error(w, "metric is required", http.StatusBadRequest)
+		return
+	}
+
+	at := time.Now()
+	if raw := r.URL.Query().Get("at"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid at: %v", err), http.StatusBadRequest)
+			return
+		}
+		at = parsed
+	}
+
+	verdicts := f.Explain(metric, at)
+	if verdicts == nil {
+		verdicts = []Verdict{}
+	}
+	writeJSON(w, http.StatusOK, explainResponse{Metric: metric, At: at, Verdicts: verdicts})
+}
+
+// silenceRequest is the body accepted when creating a silence
+type silenceRequest struct {
+	Matchers  string    `json:"matchers"`
+	Metric    string    `json:"metric,omitempty"`
+	Duration  string    `json:"duration,omitempty"`
+	StartsAt  time.Time `json:"starts_at,omitempty"`
+	EndsAt    time.Time `json:"ends_at,omitempty"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedBy string    `json:"created_by,omitempty"`
+}
+
+// handleSilences lists silences, or creates one; creating requires the operate scope
+func (f *Framework) handleSilences(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		f.apiKeys.Require(APIScopeQuery, func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, f.silences.List())
+		})(w, r)
+	case http.MethodPost:
+		f.apiKeys.Require(APIScopeOperate, f.handleCreateSilence)(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleCreateSilence creates a silence from a selector and a duration or end time
+func (f *Framework) handleCreateSilence(w http.ResponseWriter, r *http.Request) {
+	var req silenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid silence: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	matchers, err := ParseMatchers(req.Matchers)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	silence := Silence{
+		Matchers:      matchers,
+		MetricPattern: req.Metric,
+		Comment:       req.Comment,
+		CreatedBy:     req.CreatedBy,
+		StartsAt:      req.StartsAt,
+		EndsAt:        req.EndsAt,
+	}
+	if req.Duration != "" {
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 {
+			http.Error(w, fmt.Sprintf("invalid duration %q", req.Duration), http.StatusBadRequest)
+			return
+		}
+		start := req.StartsAt
+		if start.IsZero() {
+			start = time.Now()
+		}
+		silence.StartsAt = start
+		silence.EndsAt = start.Add(duration)
+	}
+
+	created, err := f.silences.Create(silence)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// handleMaintenanceWindows lists the maintenance windows declared in config
+func (f *Framework) handleMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, f.silences.MaintenanceWindows())
+}
+
+// handleExpireSilence expires the silence named in the path, e.g. DELETE /api/v1/silences/SIL-1
+func (f *Framework) handleExpireSilence(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	silence, err := f.silences.Expire(strings.TrimPrefix(r.URL.Path, "/api/v1/silences/"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, silence)
+}
+
+// snapshotRequest is the body accepted when capturing a context snapshot
+type snapshotRequest struct {
+	Name      string `json:"name"`
+	Comment   string `json:"comment,omitempty"`
+	CreatedBy string `json:"created_by,omitempty"`
+	// How far back analyses are captured, such as 2h; one hour by default
+	Window string `json:"window,omitempty"`
+}
+
+// handleSnapshots lists context snapshots (scope query) or captures one (scope operate)
+func (f *Framework) handleSnapshots(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		f.apiKeys.Require(APIScopeQuery, func(w http.ResponseWriter, r *http.Request) {
+			summaries, err := f.ListSnapshots(r.Context())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, summaries)
+		})(w, r)
+	case http.MethodPost:
+		f.apiKeys.Require(APIScopeOperate, f.handleCaptureSnapshot)(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleCaptureSnapshot saves the current agent context and recent analyses under a name
+func (f *Framework) handleCaptureSnapshot(w http.ResponseWriter, r *http.Request) {
+	var req snapshotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid snapshot: %v", err), http.StatusBadRequest)
+		return
+	}
+	var window time.Duration
+	if req.Window != "" {
+		var err error
+		if window, err = time.ParseDuration(req.Window); err != nil {
+			http.Error(w, fmt.Sprintf("invalid window: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	snapshot, err := f.CaptureSnapshot(r.Context(), req.Name, req.Comment, req.CreatedBy, window)
+	if err != nil {
+		http.Error(w, err.Error(), snapshotErrorStatus(err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, snapshot.Summary())
+}
+
+// handleSnapshot returns (scope query) or deletes (scope operate) a snapshot, or answers a
+// query against it with POST /api/v1/snapshots/<name>/query (scope query)
+func (f *Framework) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/snapshots/"), "/")
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		f.apiKeys.Require(APIScopeQuery, func(w http.ResponseWriter, r *http.Request) {
+			snapshot, err := f.GetSnapshot(r.Context(), name)
+			if err != nil {
+				http.Error(w, err.Error(), snapshotErrorStatus(err))
+				return
+			}
+			writeJSON(w, http.StatusOK, snapshot)
+		})(w, r)
+	case action == "" && r.Method == http.MethodDelete:
+		f.apiKeys.Require(APIScopeOperate, func(w http.ResponseWriter, r *http.Request) {
+			if err := f.DeleteSnapshot(r.Context(), name); err != nil {
+				http.Error(w, err.Error(), snapshotErrorStatus(err))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})(w, r)
+	case action == "query" && r.Method == http.MethodPost:
+		f.apiKeys.Require(APIScopeQuery, func(w http.ResponseWriter, r *http.Request) {
+			var req queryRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Query == "" {
+				http.Error(w, "request must include a query", http.StatusBadRequest)
+				return
+			}
+			agent := req.Agent
+			if agent == "" {
+				agent = f.config.DefaultAgent
+			}
+			response, err := f.QueryAgentAsOf(r.Context(), agent, name, req.Query)
+			if err != nil {
+				status := snapshotErrorStatus(err)
+				if status == http.StatusInternalServerError {
+					status = http.StatusBadGateway
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+			writeJSON(w, http.StatusOK, response)
+		})(w, r)
+	case action == "" || action == "query":
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.Error(w, "expected /api/v1/snapshots/<name> or /api/v1/snapshots/<name>/query", http.StatusNotFound)
+	}
+}
+
+// snapshotErrorStatus maps snapshot errors to HTTP status codes
+func snapshotErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrSnapshotNotFound), GetErrorType(err) == ErrorTypePlugin:
+		return http.StatusNotFound
+	case errors.Is(err, ErrSnapshotExists):
+		return http.StatusConflict
+	case GetErrorType(err) == ErrorTypeValidation:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// knowledgeRequest is the body accepted when adding documents to a knowledge base
+type knowledgeRequest struct {
+	Agent     string              `json:"agent,omitempty"`
+	Documents []KnowledgeDocument `json:"documents"`
+}
+
+// handleKnowledge lists the documents of an agent's knowledge base (scope query) or adds
+// documents to it (scope operate). The agent is given by the agent parameter, or is the
+// default agent.
+func (f *Framework) handleKnowledge(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		f.apiKeys.Require(APIScopeQuery, func(w http.ResponseWriter, r *http.Request) {
+			limit, err := parseKnowledgeLimit(r.URL.Query().Get("limit"), maxKnowledgePage)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			page, err := f.ListKnowledge(r.Context(), r.URL.Query().Get("agent"), r.URL.Query().Get("offset"), limit)
+			if err != nil {
+				http.Error(w, err.Error(), knowledgeErrorStatus(err))
+				return
+			}
+			writeJSON(w, http.StatusOK, page)
+		})(w, r)
+	case http.MethodPost:
+		f.apiKeys.Require(APIScopeOperate, func(w http.ResponseWriter, r *http.Request) {
+			var req knowledgeRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, fmt.Sprintf("invalid documents: %v", err), http.StatusBadRequest)
+				return
+			}
+			if err := f.AddKnowledge(r.Context(), req.Agent, req.Documents); err != nil {
+				http.Error(w, err.Error(), knowledgeErrorStatus(err))
+				return
+			}
+			writeJSON(w, http.StatusCreated, req.Documents)
+		})(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleKnowledgeItem searches a knowledge base with GET /api/v1/knowledge/search?q=...,
+// describes it with GET /api/v1/knowledge/stats (both scope query), or deletes a document
+// with DELETE /api/v1/knowledge/<id> (scope operate)
+func (f *Framework) handleKnowledgeItem(w http.ResponseWriter, r *http.Request) {
+	item := strings.TrimPrefix(r.URL.Path, "/api/v1/knowledge/")
+	switch {
+	case item == "search" && r.Method == http.MethodGet:
+		f.apiKeys.Require(APIScopeQuery, func(w http.ResponseWriter, r *http.Request) {
+			limit, err := parseKnowledgeLimit(r.URL.Query().Get("limit"), maxKnowledgeResults)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			docs, err := f.SearchKnowledge(r.Context(), r.URL.Query().Get("agent"), r.URL.Query().Get("q"), limit)
+			if err != nil {
+				http.Error(w, err.Error(), knowledgeErrorStatus(err))
+				return
+			}
+			writeJSON(w, http.StatusOK, docs)
+		})(w, r)
+	case item == "stats" && r.Method == http.MethodGet:
+		f.apiKeys.Require(APIScopeQuery, func(w http.ResponseWriter, r *http.Request) {
+			stats, err := f.KnowledgeStats(r.Context(), r.URL.Query().Get("agent"))
+			if err != nil {
+				http.Error(w, err.Error(), knowledgeErrorStatus(err))
+				return
+			}
+			writeJSON(w, http.StatusOK, stats)
+		})(w, r)
+	case item != "" && r.Method == http.MethodDelete:
+		f.apiKeys.Require(APIScopeOperate, func(w http.ResponseWriter, r *http.Request) {
+			if err := f.DeleteKnowledge(r.Context(), r.URL.Query().Get("agent"), []string{item}); err != nil {
+				http.Error(w, err.Error(), knowledgeErrorStatus(err))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// knowledgeErrorStatus maps an error from a knowledge base call to an HTTP status. Other
+// failures are the agent's, such as its embeddings API being down.
+func knowledgeErrorStatus(err error) int {
+	switch GetErrorType(err) {
+	case ErrorTypePlugin:
+		return http.StatusNotFound
+	case ErrorTypeValidation:
+		return http.StatusBadRequest
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// handleRemediations lists remediation actions, newest first
+func (f *Framework) handleRemediations(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, f.remediations.List())
+}
+
+// handleApprovalAudit returns the approval audit trail, oldest first, optionally of one
+// remediation, e.g. GET /api/v1/remediations/audit?remediation=REM-1
+func (f *Framework) handleApprovalAudit(w http.ResponseWriter, r *http.Request) {
+	entries, err := f.ApprovalAudit(r.Context(), r.URL.Query().Get("remediation"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []ApprovalAuditEntry{}
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// remediationDecision is the body accepted when approving or rejecting a remediation
+type remediationDecision struct {
+	Actor string `json:"actor"`
+}
+
+// handleRemediationDecision approves or rejects a held remediation, e.g.
+// POST /api/v1/remediations/REM-1/approve
+func (f *Framework) handleRemediationDecision(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, decision, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/remediations/"), "/")
+	if !ok || (decision != "approve" && decision != "reject") {
+		http.Error(w, "expected /api/v1/remediations/<id>/approve or /reject", http.StatusNotFound)
+		return
+	}
+
+	var body remediationDecision
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, fmt.Sprintf("invalid body: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if body.Actor == "" {
+		http.Error(w, "actor is required", http.StatusBadRequest)
+		return
+	}
+
+	var remediation Remediation
+	var err error
+	if decision == "approve" {
+		// The workflow keeps running if the client goes away
+		remediation, err = f.ApproveRemediation(context.WithoutCancel(r.Context()), id, body.Actor)
+	} else {
+		remediation, err = f.RejectRemediation(id, body.Actor)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeJSON(w, http.StatusOK, remediation)
+}
+
+// startWorkflowRequest is the body accepted when starting a workflow
+type startWorkflowRequest struct {
+	Actor   string                 `json:"actor"`
+	Service string                 `json:"service,omitempty"`
+	Input   map[string]interface{} `json:"input,omitempty"`
+}
+
+// handleStartWorkflow runs a workflow as a remediation started by a person, e.g.
+// POST /api/v1/workflows/restart-api/start. It counts against the remediation cap and is
+// simulated in dry-run mode.
+func (f *Framework) handleStartWorkflow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, action, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/workflows/"), "/")
+	if !ok || id == "" || action != "start" {
+		http.Error(w, "expected /api/v1/workflows/<id>/start", http.StatusNotFound)
+		return
+	}
+
+	var req startWorkflowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Actor == "" {
+		http.Error(w, "actor is required", http.StatusBadRequest)
+		return
+	}
+
+	// The workflow keeps running if the client goes away
+	remediation, err := f.ExecuteRemediation(context.WithoutCancel(r.Context()), Remediation{
+		Service:     req.Service,
+		Action:      "workflow",
+		WorkflowID:  id,
+		Input:       req.Input,
+		RequestedBy: req.Actor,
+		ApprovedBy:  req.Actor,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeJSON(w, http.StatusOK, remediation)
+}
+
+// proposeActionRequest is the body accepted when proposing an action an agent suggested
+type proposeActionRequest struct {
+	Actor      string      `json:"actor"`
+	IncidentID string      `json:"incident_id,omitempty"`
+	Action     AgentAction `json:"action"`
+}
+
+// handleProposeAction holds an action an agent proposed as a remediation waiting for
+// approval, e.g. POST /api/v1/actions with a scale action from an agent's response
+func (f *Framework) handleProposeAction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req proposeActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Actor == "" {
+		http.Error(w, "actor is required", http.StatusBadRequest)
+		return
+	}
+
+	remediation, err := f.ProposeAgentAction(r.Context(), req.Action, req.Actor, req.IncidentID)
+	if err != nil {
+		status := http.StatusConflict
+		if GetErrorType(err) == ErrorTypeValidation {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, remediation)
+}
+
+// handleReconfigurePlugin changes the settings of a running plugin, e.g.
+// PUT /api/v1/plugins/ai-agent/config with {"model": "gpt-4o-mini"}
+func (f *Framework) handleReconfigurePlugin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/plugins/"), "/config")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		http.Error(w, "expected /api/v1/plugins/<name>/config", http.StatusNotFound)
+		return
+	}
+	if !f.AllowsRuntimePlugins() {
+		http.Error(w, ErrRuntimePluginsRefused.Error(), http.StatusForbidden)
+		return
+	}
+
+	var config map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil || len(config) == 0 {
+		http.Error(w, "body must be a JSON object of settings", http.StatusBadRequest)
+		return
+	}
+
+	// The new settings are checked against the plugin's backend even if the client goes away
+	if err := f.ReconfigurePlugin(context.WithoutCancel(r.Context()), name, config); err != nil {
+		status := http.StatusBadRequest
+		if GetErrorType(err) == ErrorTypePlugin {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, http.StatusOK, pluginActionResponse{Plugin: name, Status: "reconfigured"})
+}
+
+// Descriptors of the per-key usage counters
+var (
+	apiUnauthorizedDesc = prometheus.NewDesc("framework_api_unauthorized_total",
+		"API requests without a valid key", nil, nil)
+	apiRequestsDesc = prometheus.NewDesc("framework_api_requests_total",
+		"API requests per key and result", []string{"key", "result"}, nil)
+)
+
+// collectAPIKeyMetrics exports per-key usage counters
+func (f *Framework) collectAPIKeyMetrics(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(apiUnauthorizedDesc, prometheus.CounterValue, float64(f.apiKeys.UnauthorizedCount()))
+	for _, usage := range f.apiKeys.Usage() {
+		ch <- prometheus.MustNewConstMetric(apiRequestsDesc, prometheus.CounterValue, float64(usage.Allowed), usage.Name, "allowed")
+		ch <- prometheus.MustNewConstMetric(apiRequestsDesc, prometheus.CounterValue, float64(usage.Throttled), usage.Name, "throttled")
+		ch <- prometheus.MustNewConstMetric(apiRequestsDesc, prometheus.CounterValue, float64(usage.Forbidden), usage.Name, "forbidden")
+	}
+}
+
+// writeJSON encodes a value as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to encode JSON response", "error", err)
+	}
+}
@@ -0,0 +1,339 @@
+package core
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// APIScope represents a permission granted to a management API key. Query is read-only,
+// operate adds day-to-day actions such as silencing, approving remediations, and starting
+// workflows, and admin adds changing plugins and reading their settings.
+type APIScope string
+
+const (
+	APIScopeIngest  APIScope = "ingest"
+	APIScopeQuery   APIScope = "query"
+	APIScopeOperate APIScope = "operate"
+	APIScopeAdmin   APIScope = "admin"
+)
+
+// oidcUsageName is the usage entry counting requests made with OIDC tokens
+const oidcUsageName = "oidc"
+
+// APIKey is a configured management API token, or basic auth user, with its quota and
+// usage counters
+type APIKey struct {
+	Name  string
+	token string
+	// bcrypt hash of a basic auth user's password; empty for tokens
+	passwordHash []byte
+	scopes       map[APIScope]bool
+	// Tenants a tenant-limited key may use; empty for keys that may use every API
+	tenants map[string]bool
+	limiter RateLimiter
+
+	allowed   atomic.Int64
+	throttled atomic.Int64
+	forbidden atomic.Int64
+}
+
+// HasScope reports whether the key grants the given scope
+func (k *APIKey) HasScope(scope APIScope) bool {
+	return grantsScope(k.scopes, scope)
+}
+
+// grantsScope reports whether scopes grant a scope; admin grants everything, and operate
+// grants query
+func grantsScope(scopes map[APIScope]bool, scope APIScope) bool {
+	return scopes[APIScopeAdmin] || scopes[scope] || (scope == APIScopeQuery && scopes[APIScopeOperate])
+}
+
+// Scopes returns the scopes granted to the key in sorted order
+func (k *APIKey) Scopes() []string {
+	scopes := make([]string, 0, len(k.scopes))
+	for scope := range k.scopes {
+		scopes = append(scopes, string(scope))
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// Tenants returns the tenants a tenant-limited key may use in sorted order
+func (k *APIKey) Tenants() []string {
+	tenants := make([]string, 0, len(k.tenants))
+	for tenant := range k.tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// allowsTenant reports whether the key may use the API of a tenant, or the framework's
+// for the empty tenant
+func (k *APIKey) allowsTenant(tenant string) bool {
+	return len(k.tenants) == 0 || k.tenants[tenant]
+}
+
+// APIKeyUsage is a snapshot of the usage counters of a key
+type APIKeyUsage struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	Tenants   []string `json:"tenants,omitempty"`
+	Allowed   int64    `json:"allowed"`
+	Throttled int64    `json:"throttled"`
+	Forbidden int64    `json:"forbidden"`
+}
+
+// Credentials are what a request presented: a bearer token, which is an API key or an OIDC
+// token, or a basic auth user name and password
+type Credentials struct {
+	Token    string
+	Username string
+	Password string
+}
+
+// CredentialsFromHeaders reads credentials from the values of the Authorization and
+// X-API-Key headers, or of the same gRPC metadata
+func CredentialsFromHeaders(authorization, apiKey string) Credentials {
+	scheme, value, _ := strings.Cut(authorization, " ")
+	switch {
+	case strings.EqualFold(scheme, "Bearer"):
+		return Credentials{Token: strings.TrimSpace(value)}
+	case strings.EqualFold(scheme, "Basic"):
+		if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value)); err == nil {
+			if username, password, ok := strings.Cut(string(decoded), ":"); ok {
+				return Credentials{Username: username, Password: password}
+			}
+		}
+	}
+	return Credentials{Token: apiKey}
+}
+
+// APIKeyManager authenticates management API requests against configured keys, basic auth
+// users, and OIDC tokens
+type APIKeyManager struct {
+	keys  []*APIKey
+	users []*APIKey
+	oidc  *oidcValidator
+	// Counts requests made with OIDC tokens, whose callers are not configured one by one
+	oidcUsage    *APIKey
+	unauthorized atomic.Int64
+	// Tenant whose API the manager guards; empty for the framework's
+	tenant string
+	mu     sync.RWMutex
+}
+
+// NewAPIKeyManager creates a key manager from the configured keys and auth settings
+func NewAPIKeyManager(configs []APIKeyConfig, auth AuthConfig) *APIKeyManager {
+	manager := &APIKeyManager{}
+	for _, cfg := range configs {
+		manager.keys = append(manager.keys, newAPIKey(cfg.Name, cfg.Scopes, cfg.RateLimit, cfg.Burst))
+		key := manager.keys[len(manager.keys)-1]
+		key.token = cfg.Token
+		if len(cfg.Tenants) > 0 {
+			key.tenants = make(map[string]bool, len(cfg.Tenants))
+			for _, tenant := range cfg.Tenants {
+				key.tenants[tenant] = true
+			}
+		}
+	}
+	for _, cfg := range auth.BasicUsers {
+		manager.users = append(manager.users, newAPIKey(cfg.Username, cfg.Scopes, cfg.RateLimit, cfg.Burst))
+		manager.users[len(manager.users)-1].passwordHash = []byte(cfg.PasswordHash)
+	}
+	if auth.OIDC != nil {
+		manager.oidc = newOIDCValidator(*auth.OIDC)
+		manager.oidcUsage = &APIKey{Name: oidcUsageName, scopes: make(map[APIScope]bool)}
+	}
+	return manager
+}
+
+// newAPIKey creates a key or user with its scopes and quota
+func newAPIKey(name string, scopes []string, rateLimit float64, burst int) *APIKey {
+	key := &APIKey{Name: name, scopes: make(map[APIScope]bool)}
+	for _, scope := range scopes {
+		key.scopes[APIScope(scope)] = true
+	}
+	if rateLimit > 0 {
+		key.limiter = NewTokenBucketRateLimiter(rateLimit, burst)
+	}
+	return key
+}
+
+// forTenant returns a manager guarding a tenant's API with the same keys, users, and OIDC
+// provider, sharing their quotas and usage counters
+func (m *APIKeyManager) forTenant(tenant string) *APIKeyManager {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return &APIKeyManager{keys: m.keys, users: m.users, oidc: m.oidc, oidcUsage: m.oidcUsage, tenant: tenant}
+}
+
+// Enabled reports whether any keys, users, or OIDC provider are configured; without them
+// the API is open
+func (m *APIKeyManager) Enabled() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.keys) > 0 || len(m.users) > 0 || m.oidc != nil
+}
+
+// Authenticate finds the key matching the given token
+func (m *APIKeyManager) Authenticate(token string) (*APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if token == "" {
+		return nil, NewValidationError("api-keys", "authenticate", "missing API key")
+	}
+	for _, key := range m.keys {
+		if subtle.ConstantTimeCompare([]byte(key.token), []byte(token)) == 1 {
+			return key, nil
+		}
+	}
+	return nil, NewValidationError("api-keys", "authenticate", "invalid API key")
+}
+
+// Errors returned by Authorize when a request may not go ahead
+var (
+	ErrAPIKeyInvalid     = errors.New("unauthorized")
+	ErrAPIKeyForbidden   = errors.New("forbidden")
+	ErrAPIKeyRateLimited = errors.New("rate limited")
+)
+
+// authenticateUser finds the basic auth user with the given name and password
+func (m *APIKeyManager) authenticateUser(username, password string) (*APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, user := range m.users {
+		if user.Name == username && bcrypt.CompareHashAndPassword(user.passwordHash, []byte(password)) == nil {
+			return user, nil
+		}
+	}
+	return nil, NewValidationError("api-keys", "authenticate", "invalid user name or password")
+}
+
+// Authorize checks that credentials name a key, user, or OIDC caller granting the scope and
+// within its quota, and counts the outcome. Without keys, users, or OIDC configured every
+// request is authorized.
+func (m *APIKeyManager) Authorize(ctx context.Context, credentials Credentials, scope APIScope) error {
+	if !m.Enabled() {
+		return nil
+	}
+
+	var key *APIKey
+	var caller string
+	var scopes map[APIScope]bool
+	var err error
+	switch {
+	case credentials.Username != "":
+		if key, err = m.authenticateUser(credentials.Username, credentials.Password); err == nil {
+			caller, scopes = "user "+key.Name, key.scopes
+		}
+	case m.oidc != nil && looksLikeJWT(credentials.Token):
+		// Callers with OIDC tokens share one usage entry, without a quota
+		var subject string
+		if subject, scopes, err = m.oidc.Validate(ctx, credentials.Token); err == nil {
+			key, caller = m.oidcUsage, "OIDC subject "+subject
+		}
+	default:
+		if key, err = m.Authenticate(credentials.Token); err == nil {
+			caller, scopes = "API key "+key.Name, key.scopes
+		}
+	}
+	if err != nil {
+		m.unauthorized.Add(1)
+		return fmt.Errorf("%w: %s", ErrAPIKeyInvalid, err.Error())
+	}
+
+	if !key.allowsTenant(m.tenant) {
+		key.forbidden.Add(1)
+		if m.tenant == "" {
+			return fmt.Errorf("%w: %s is limited to tenants %s", ErrAPIKeyForbidden, caller, strings.Join(key.Tenants(), ", "))
+		}
+		return fmt.Errorf("%w: %s may not use tenant %s", ErrAPIKeyForbidden, caller, m.tenant)
+	}
+	if !grantsScope(scopes, scope) {
+		key.forbidden.Add(1)
+		return fmt.Errorf("%w: %s lacks scope %s", ErrAPIKeyForbidden, caller, scope)
+	}
+	if key.limiter != nil && !key.limiter.Allow() {
+		key.throttled.Add(1)
+		return fmt.Errorf("%w: rate limit exceeded for %s", ErrAPIKeyRateLimited, caller)
+	}
+	key.allowed.Add(1)
+	return nil
+}
+
+// Require wraps a handler so it only runs for callers granted the scope and within their
+// quota
+func (m *APIKeyManager) Require(scope APIScope, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := m.Authorize(r.Context(), credentialsFromRequest(r), scope)
+		switch {
+		case err == nil:
+			next(w, r)
+		case errors.Is(err, ErrAPIKeyForbidden):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, ErrAPIKeyRateLimited):
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+		default:
+			w.Header().Set("WWW-Authenticate", m.challenge())
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		}
+	}
+}
+
+// challenge is the WWW-Authenticate header of a 401; browsers prompt for a password when
+// basic auth users are configured
+func (m *APIKeyManager) challenge() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.users) > 0 {
+		return `Basic realm="agent", charset="UTF-8"`
+	}
+	return `Bearer realm="agent"`
+}
+
+// Usage returns the usage counters of every configured key
+func (m *APIKeyManager) Usage() []APIKeyUsage {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := append(append([]*APIKey(nil), m.keys...), m.users...)
+	if m.oidcUsage != nil {
+		keys = append(keys, m.oidcUsage)
+	}
+	usage := make([]APIKeyUsage, 0, len(keys))
+	for _, key := range keys {
+		usage = append(usage, APIKeyUsage{
+			Name:      key.Name,
+			Scopes:    key.Scopes(),
+			Tenants:   key.Tenants(),
+			Allowed:   key.allowed.Load(),
+			Throttled: key.throttled.Load(),
+			Forbidden: key.forbidden.Load(),
+		})
+	}
+	return usage
+}
+
+// UnauthorizedCount returns how many requests presented no valid credentials
+func (m *APIKeyManager) UnauthorizedCount() int64 {
+	return m.unauthorized.Load()
+}
+
+// credentialsFromRequest extracts the credentials from the Authorization or X-API-Key headers
+func credentialsFromRequest(r *http.Request) Credentials {
+	return CredentialsFromHeaders(r.Header.Get("Authorization"), r.Header.Get("X-API-Key"))
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAPIKeyManager_Require(t *testing.T) {
+	manager := NewAPIKeyManager([]APIKeyConfig{
+		{Name: "ingest-team", Token: "ingest-token", Scopes: []string{"ingest"}},
+		{Name: "ops", Token: "admin-token", Scopes: []string{"admin"}},
+		{Name: "limited", Token: "limited-token", Scopes: []string{"query"}, RateLimit: 0.001, Burst: 1},
+	}, AuthConfig{})
+
+	handler := manager.Require(APIScopeQuery, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{name: "missing key", token: "", status: http.StatusUnauthorized},
+		{name: "unknown key", token: "nope", status: http.StatusUnauthorized},
+		{name: "wrong scope", token: "ingest-token", status: http.StatusForbidden},
+		{name: "admin implies query", token: "admin-token", status: http.StatusOK},
+		{name: "within quota", token: "limited-token", status: http.StatusOK},
+		{name: "over quota", token: "limited-token", status: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/query", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+
+	usage := manager.Usage()
+	require.Len(t, usage, 3)
+	assert.Equal(t, int64(1), usage[0].Forbidden, "Expected ingest key to record a forbidden request")
+	assert.Equal(t, int64(1), usage[1].Allowed, "Expected admin key to record an allowed request")
+	assert.Equal(t, int64(1), usage[2].Throttled, "Expected limited key to record a throttled request")
+	assert.Equal(t, int64(2), manager.UnauthorizedCount())
+}
+
+func TestAPIKeyManager_DisabledWithoutKeys(t *testing.T) {
+	manager := NewAPIKeyManager(nil, AuthConfig{})
+	assert.False(t, manager.Enabled())
+
+	called := false
+	handler := manager.Require(APIScopeAdmin, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/keys", nil))
+	assert.True(t, called, "Expected handler to run when no keys are configured")
+}
+
+func TestTokenBucketRateLimiter_Allow(t *testing.T) {
+	limiter := NewTokenBucketRateLimiter(1, 2)
+
+	assert.True(t, limiter.Allow())
+	assert.True(t, limiter.Allow())
+	assert.False(t, limiter.Allow(), "Expected burst to be exhausted")
+}
+
+func TestAPIKeyManager_Roles(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	require.NoError(t, err)
+	manager := NewAPIKeyManager([]APIKeyConfig{
+		{Name: "viewer", Token: "viewer-token", Scopes: []string{"query"}},
+		{Name: "oncall", Token: "oncall-token", Scopes: []string{"operate"}},
+	}, AuthConfig{BasicUsers: []BasicUserConfig{{Username: "alice", PasswordHash: string(hash), Scopes: []string{"admin"}}}})
+
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		credentials Credentials
+		scope       APIScope
+		expected    error
+	}{
+		{name: "query reads", credentials: Credentials{Token: "viewer-token"}, scope: APIScopeQuery},
+		{name: "query cannot operate", credentials: Credentials{Token: "viewer-token"}, scope: APIScopeOperate, expected: ErrAPIKeyForbidden},
+		{name: "operate reads", credentials: Credentials{Token: "oncall-token"}, scope: APIScopeQuery},
+		{name: "operate operates", credentials: Credentials{Token: "oncall-token"}, scope: APIScopeOperate},
+		{name: "operate cannot administer", credentials: Credentials{Token: "oncall-token"}, scope: APIScopeAdmin, expected: ErrAPIKeyForbidden},
+		{name: "operate cannot ingest", credentials: Credentials{Token: "oncall-token"}, scope: APIScopeIngest, expected: ErrAPIKeyForbidden},
+		{name: "basic auth user", credentials: Credentials{Username: "alice", Password: "hunter2"}, scope: APIScopeAdmin},
+		{name: "wrong password", credentials: Credentials{Username: "alice", Password: "hunter3"}, scope: APIScopeQuery, expected: ErrAPIKeyInvalid},
+		{name: "unknown user", credentials: Credentials{Username: "bob", Password: "hunter2"}, scope: APIScopeQuery, expected: ErrAPIKeyInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := manager.Authorize(ctx, tt.credentials, tt.scope)
+			if tt.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expected)
+			}
+		})
+	}
+
+	usage := manager.Usage()
+	require.Len(t, usage, 3)
+	assert.Equal(t, "alice", usage[2].Name, "Expected basic auth users to be listed with the keys")
+	assert.Equal(t, int64(1), usage[2].Allowed)
+}
+
+func TestCredentialsFromHeaders(t *testing.T) {
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("alice:pass:word"))
+	assert.Equal(t, Credentials{Token: "abc"}, CredentialsFromHeaders("Bearer abc", ""))
+	assert.Equal(t, Credentials{Token: "abc"}, CredentialsFromHeaders("bearer  abc", "ignored"))
+	assert.Equal(t, Credentials{Username: "alice", Password: "pass:word"}, CredentialsFromHeaders(basic, ""))
+	assert.Equal(t, Credentials{Token: "key"}, CredentialsFromHeaders("", "key"))
+	assert.Equal(t, Credentials{Token: "key"}, CredentialsFromHeaders("Basic !!!", "key"))
+}
+
+func TestFramework_ProtectStatus(t *testing.T) {
+	config := &FrameworkConfig{
+		LogLevel:  "info",
+		LogFormat: "text",
+		LogOutput: "stdout",
+		APIKeys:   []APIKeyConfig{{Name: "viewer", Token: "viewer-token", Scopes: []string{"query"}}},
+	}
+	get := func(handler http.Handler, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	open := NewFramework(config).Handler()
+	assert.Equal(t, http.StatusOK, get(open, "/status", "").Code, "Expected status to be open by default")
+
+	config.Auth.ProtectStatus = true
+	protected := NewFramework(config).Handler()
+	for _, path := range []string{"/status", "/status.html", "/metrics"} {
+		rec := get(protected, path, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, path)
+		assert.Equal(t, `Bearer realm="agent"`, rec.Header().Get("WWW-Authenticate"))
+		assert.Equal(t, http.StatusOK, get(protected, path, "viewer-token").Code, path)
+	}
+	assert.NotEqual(t, http.StatusUnauthorized, get(protected, "/health", "").Code, "Expected probes to stay open")
+}
@@ -0,0 +1,192 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Steps of a remediation recorded in the approval audit trail
+const (
+	ApprovalEventRequested = "requested"
+	ApprovalEventApproved  = "approved"
+	ApprovalEventRejected  = "rejected"
+	ApprovalEventExpired   = "expired"
+	ApprovalEventExecuted  = "executed"
+	ApprovalEventFailed    = "failed"
+)
+
+// defaultAuditRetention is how long the audit trail is kept when no retention is configured
+const defaultAuditRetention = 90 * 24 * time.Hour
+
+// ApprovalAuditEntry is one step of a remediation: held for approval, decided, expired, or
+// carried out
+type ApprovalAuditEntry struct {
+	Time          time.Time `json:"time"`
+	RemediationID string    `json:"remediation_id"`
+	Event         string    `json:"event"`
+	// Actor is who made the decision, or who requested the action for other events
+	Actor      string `json:"actor,omitempty"`
+	Service    string `json:"service,omitempty"`
+	Action     string `json:"action,omitempty"`
+	WorkflowID string `json:"workflow_id,omitempty"`
+	Responder  string `json:"responder,omitempty"`
+	IncidentID string `json:"incident_id,omitempty"`
+	Detail     string `json:"detail,omitempty"`
+}
+
+// ApprovalAuditLog keeps the approval audit trail in the framework's store, so it survives
+// restarts with a persistent store
+type ApprovalAuditLog struct {
+	store     Store
+	retention time.Duration
+	lastPrune time.Time
+	mu        sync.Mutex
+}
+
+// NewApprovalAuditLog creates an audit log that keeps entries for the retention period
+func NewApprovalAuditLog(store Store, retention time.Duration) *ApprovalAuditLog {
+	if retention <= 0 {
+		retention = defaultAuditRetention
+	}
+	return &ApprovalAuditLog{store: store, retention: retention}
+}
+
+// Record stores an entry and occasionally deletes entries older than the retention
+func (l *ApprovalAuditLog) Record(ctx context.Context, entry ApprovalAuditEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	key := fmt.Sprintf("%020d-%s-%s", entry.Time.UnixNano(), entry.RemediationID, entry.Event)
+	if err := PutJSON(ctx, l.store, StoreCollectionApprovals, key, entry); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if entry.Time.Sub(l.lastPrune) < historyPruneInterval {
+		return nil
+	}
+	l.lastPrune = entry.Time
+	return pruneHistory(ctx, l.store, StoreCollectionApprovals, entry.Time.Add(-l.retention))
+}
+
+// List returns the entries of a remediation, or of every remediation when the ID is
+// empty, oldest first
+func (l *ApprovalAuditLog) List(ctx context.Context, remediationID string) ([]ApprovalAuditEntry, error) {
+	var entries []ApprovalAuditEntry
+	err := ListJSON(ctx, l.store, StoreCollectionApprovals, func(key string, unmarshal func(v interface{}) error) error {
+		var entry ApprovalAuditEntry
+		if err := unmarshal(&entry); err != nil {
+			return err
+		}
+		if remediationID == "" || entry.RemediationID == remediationID {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// audit records a step of a remediation, logging rather than failing when the store does
+// not take it
+func (f *Framework) audit(ctx context.Context, remediation Remediation, event, actor, detail string) {
+	entry := ApprovalAuditEntry{
+		RemediationID: remediation.ID,
+		Event:         event,
+		Actor:         actor,
+		Service:       remediation.Service,
+		Action:        remediation.Action,
+		WorkflowID:    remediation.WorkflowID,
+		Responder:     remediation.Responder,
+		IncidentID:    remediation.IncidentID,
+		Detail:        detail,
+	}
+	slog.Info("Remediation audit", "remediation", entry.RemediationID, "event", event, "actor", actor,
+		"service", entry.Service, "action", entry.Action, "detail", detail)
+	if err := f.approvalAudit.Record(context.WithoutCancel(ctx), entry); err != nil {
+		slog.Error("Failed to record remediation audit entry", "remediation", entry.RemediationID, "event", event, "error", err)
+	}
+}
+
+// ApprovalAudit returns the approval audit trail of a remediation, or of every remediation
+// when the ID is empty, oldest first
+func (f *Framework) ApprovalAudit(ctx context.Context, remediationID string) ([]ApprovalAuditEntry, error) {
+	return f.approvalAudit.List(ctx, remediationID)
+}
+
+// requestApproval records that a remediation waits for approval and tells the approval
+// responders, whose messages may offer approve and reject buttons
+func (f *Framework) requestApproval(ctx context.Context, held Remediation) {
+	f.audit(ctx, held, ApprovalEventRequested, held.RequestedBy, held.Reason)
+
+	responders := f.config.Remediation.ApprovalResponders
+	if len(responders) == 0 {
+		return
+	}
+	details := map[string]interface{}{
+		"remediation_id": held.ID,
+		"action":         held.Action,
+		"service":        held.Service,
+		"requested_by":   held.RequestedBy,
+		"reason":         held.Reason,
+	}
+	if held.IncidentID != "" {
+		details["incident_id"] = held.IncidentID
+	}
+	if ttl := f.config.Remediation.ApprovalTTL; ttl > 0 {
+		details["expires_at"] = held.RequestedAt.Add(ttl)
+	}
+	analysis := &Analysis{
+		Type:      AnalysisTypeApprovalRequest,
+		Severity:  "high",
+		Source:    "remediation",
+		Summary:   fmt.Sprintf("%s %s needs approval (%s): %s", held.Action, serviceKey(held.Service), held.ID, held.Reason),
+		Timestamp: held.RequestedAt,
+		Details:   details,
+	}
+	if err := f.PostAnalysis(ctx, analysis, responders); err != nil {
+		slog.Error("Failed to request approval", "remediation", held.ID, "error", err)
+	}
+}
+
+// expireApprovals expires remediations that waited for approval longer than the approval
+// TTL, so a stale action cannot be approved long after the problem it was for
+func (f *Framework) expireApprovals(ctx context.Context, now time.Time) {
+	ttl := f.config.Remediation.ApprovalTTL
+	if ttl <= 0 {
+		return
+	}
+	for _, expired := range f.remediations.expire(now.Add(-ttl), ttl) {
+		f.audit(ctx, expired, ApprovalEventExpired, "", expired.Reason)
+		if expired.IncidentID != "" {
+			f.incidents.Annotate(expired.IncidentID, "remediation_expired", "remediation",
+				fmt.Sprintf("%s %s (%s) expired without approval", expired.Action, expired.Service, expired.ID))
+		}
+	}
+}
+
+// approvalWorker periodically expires remediations left waiting for approval
+func (f *Framework) approvalWorker(ctx context.Context) {
+	defer f.wg.Done()
+
+	interval := f.config.Remediation.ApprovalTTL / 4
+	if interval > 30*time.Second {
+		interval = 30 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Approval worker stopping due to context cancellation")
+			return
+		case now := <-ticker.C:
+			f.expireApprovals(ctx, now)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditEvents returns the events of a remediation's audit trail in order
+func auditEvents(t *testing.T, framework *Framework, id string) []string {
+	entries, err := framework.ApprovalAudit(context.Background(), id)
+	require.NoError(t, err)
+	events := make([]string, len(entries))
+	for i, entry := range entries {
+		events[i] = entry.Event
+	}
+	return events
+}
+
+func TestFramework_ApprovalFlow(t *testing.T) {
+	framework, engine := newRemediationFramework(&FrameworkConfig{Remediation: RemediationConfig{
+		ActionWorkflows:    map[string]string{AgentActionRestart: "restart-service"},
+		ActionResponders:   map[string]string{AgentActionRollback: "deployer"},
+		ApprovalResponders: []string{"slack"},
+		ApprovalTTL:        time.Hour,
+	}})
+	slack := &severityResponder{MockPlugin: MockPlugin{name: "slack", pluginType: PluginTypeResponder}, severity: "high"}
+	deployer := &severityResponder{MockPlugin: MockPlugin{name: "deployer", pluginType: PluginTypeResponder}, severity: "high"}
+	require.NoError(t, framework.LoadPlugin(slack))
+	require.NoError(t, framework.LoadPlugin(deployer))
+	ctx := context.Background()
+	incident, _ := framework.GetIncidentManager().Track(&Analysis{Summary: "api down", Source: "probe"})
+
+	restart, err := framework.ProposeAgentAction(ctx, AgentAction{Type: AgentActionRestart, Target: "api", Risk: ActionRiskLow}, "ai-agent", incident.ID)
+	require.NoError(t, err)
+	require.Len(t, slack.handled, 1, "Expected the approval responders to be asked")
+	request := slack.handled[0]
+	assert.Equal(t, AnalysisTypeApprovalRequest, request.Type)
+	assert.Equal(t, restart.ID, request.Details["remediation_id"])
+	assert.Equal(t, incident.ID, request.Details["incident_id"])
+	assert.Empty(t, deployer.handled)
+
+	approved, err := framework.ApproveRemediation(ctx, restart.ID, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, RemediationStatusExecuted, approved.Status)
+	assert.Equal(t, []string{"restart-service"}, engine.executed)
+	assert.Equal(t, []string{ApprovalEventRequested, ApprovalEventApproved, ApprovalEventExecuted}, auditEvents(t, framework, restart.ID))
+
+	// Types without a workflow are carried out by their responder once approved
+	rollback, err := framework.ProposeAgentAction(ctx, AgentAction{Type: AgentActionRollback, Target: "api", Risk: ActionRiskHigh}, "ai-agent", "")
+	require.NoError(t, err)
+	assert.Equal(t, "deployer", rollback.Responder)
+	approved, err = framework.ApproveRemediation(ctx, rollback.ID, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, RemediationStatusExecuted, approved.Status)
+	require.Len(t, deployer.handled, 1)
+	assert.Equal(t, AnalysisTypeRemediation, deployer.handled[0].Type)
+	assert.Equal(t, "bob", deployer.handled[0].Details["approved_by"])
+	assert.Len(t, engine.executed, 1, "Expected no workflow for an action carried out by a responder")
+
+	rejected, err := framework.ProposeAgentAction(ctx, AgentAction{Type: AgentActionRestart, Target: "db", Risk: ActionRiskLow}, "ai-agent", "")
+	require.NoError(t, err)
+	_, err = framework.RejectRemediation(rejected.ID, "carol")
+	require.NoError(t, err)
+	entries, err := framework.ApprovalAudit(ctx, rejected.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, ApprovalEventRejected, entries[1].Event)
+	assert.Equal(t, "carol", entries[1].Actor)
+
+	all, err := framework.ApprovalAudit(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, all, 8)
+}
+
+func TestFramework_ApprovalExpiry(t *testing.T) {
+	framework, engine := newRemediationFramework(&FrameworkConfig{Remediation: RemediationConfig{
+		ActionWorkflows: map[string]string{AgentActionRestart: "restart-service"},
+		ApprovalTTL:     time.Hour,
+	}})
+	ctx := context.Background()
+	incident, _ := framework.GetIncidentManager().Track(&Analysis{Summary: "api down", Source: "probe"})
+
+	held, err := framework.ProposeAgentAction(ctx, AgentAction{Type: AgentActionRestart, Target: "api", Risk: ActionRiskLow}, "ai-agent", incident.ID)
+	require.NoError(t, err)
+	fresh, err := framework.ProposeAgentAction(ctx, AgentAction{Type: AgentActionRestart, Target: "db", Risk: ActionRiskLow}, "ai-agent", "")
+	require.NoError(t, err)
+
+	framework.expireApprovals(ctx, time.Now().Add(30*time.Minute))
+	assert.Equal(t, RemediationStatusPending, remediationStatus(framework, held.ID), "Expected approvals within the TTL to wait")
+
+	framework.remediations.mu.Lock()
+	framework.remediations.actions[held.ID].RequestedAt = time.Now().Add(-2 * time.Hour)
+	framework.remediations.mu.Unlock()
+
+	_, err = framework.ApproveRemediation(ctx, held.ID, "alice")
+	assert.Error(t, err, "Expected a stale approval to be refused")
+	assert.Equal(t, RemediationStatusExpired, remediationStatus(framework, held.ID))
+	assert.Empty(t, engine.executed)
+	assert.Equal(t, []string{ApprovalEventRequested, ApprovalEventExpired}, auditEvents(t, framework, held.ID))
+	assert.Equal(t, RemediationStatusPending, remediationStatus(framework, fresh.ID))
+
+	incident, err = framework.GetIncidentManager().Get(incident.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "remediation_expired", incident.Timeline[len(incident.Timeline)-1].Type)
+}
+
+// remediationStatus returns the current status of a remediation
+func remediationStatus(framework *Framework, id string) RemediationStatus {
+	for _, remediation := range framework.GetRemediationGovernor().List() {
+		if remediation.ID == id {
+			return remediation.Status
+		}
+	}
+	return ""
+}
+
+func TestFramework_SlackApproval(t *testing.T) {
+	framework, engine := newRemediationFramework(&FrameworkConfig{Remediation: RemediationConfig{
+		ActionWorkflows: map[string]string{AgentActionRestart: "restart-service"},
+	}})
+	ctx := context.Background()
+
+	held, err := framework.ProposeAgentAction(ctx, AgentAction{Type: AgentActionRestart, Target: "api", Risk: ActionRiskLow}, "ai-agent", "")
+	require.NoError(t, err)
+	message, err := framework.applySlackAction(ctx, SlackActionApprove, `{"remediation_id":"`+held.ID+`"}`, "alice")
+	require.NoError(t, err)
+	assert.Contains(t, message, "approved by alice")
+	require.Eventually(t, func() bool { return remediationStatus(framework, held.ID) == RemediationStatusExecuted },
+		time.Second, 10*time.Millisecond)
+	engine.mu.Lock()
+	assert.Equal(t, []string{"restart-service"}, engine.executed)
+	engine.mu.Unlock()
+
+	held, err = framework.ProposeAgentAction(ctx, AgentAction{Type: AgentActionRestart, Target: "db", Risk: ActionRiskLow}, "ai-agent", "")
+	require.NoError(t, err)
+	_, err = framework.applySlackAction(ctx, SlackActionReject, `{"remediation_id":"`+held.ID+`"}`, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, RemediationStatusRejected, remediationStatus(framework, held.ID))
+	_, err = framework.applySlackAction(ctx, SlackActionApprove, `{"remediation_id":"`+held.ID+`"}`, "alice")
+	assert.Error(t, err, "Expected a rejected remediation not to be approved")
+}
+
+func TestFramework_ApprovalAuditAPI(t *testing.T) {
+	framework, _ := newRemediationFramework(&FrameworkConfig{Remediation: RemediationConfig{
+		ActionWorkflows: map[string]string{AgentActionRestart: "restart-service"},
+	}})
+	held, err := framework.ProposeAgentAction(context.Background(), AgentAction{Type: AgentActionRestart, Target: "api", Risk: ActionRiskLow}, "ai-agent", "")
+	require.NoError(t, err)
+	mux := http.NewServeMux()
+	framework.registerAPIRoutes(mux)
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/remediations/audit?remediation="+held.ID, nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"event":"requested"`)
+
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/remediations/audit?remediation=REM-404", nil))
+	assert.Equal(t, "[]\n", recorder.Body.String())
+}
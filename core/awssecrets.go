@@ -0,0 +1,92 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// awsSecretBackend resolves ${aws:secret-id#key} from AWS Secrets Manager. The secret id
+// is a name or ARN; key picks a field of a secret stored as a JSON object.
+type awsSecretBackend struct {
+	config     AWSSecretsConfig
+	httpClient *http.Client
+}
+
+func newAWSSecretBackend(config AWSSecretsConfig, timeout time.Duration) *awsSecretBackend {
+	if config.Region == "" {
+		config.Region = os.Getenv("AWS_REGION")
+	}
+	if config.AccessKeyID == "" && config.SecretAccessKey == "" {
+		config.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		config.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		config.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if config.Endpoint == "" && config.Region != "" {
+		config.Endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", config.Region)
+	}
+	return &awsSecretBackend{config: config, httpClient: &http.Client{Timeout: timeout}}
+}
+
+func (a *awsSecretBackend) Scheme() string { return "aws" }
+
+func (a *awsSecretBackend) Resolve(ctx context.Context, reference string) (string, error) {
+	if a.config.Region == "" {
+		return "", fmt.Errorf("aws region not configured")
+	}
+	if a.config.AccessKeyID == "" || a.config.SecretAccessKey == "" {
+		return "", fmt.Errorf("aws credentials not configured")
+	}
+
+	secretID, key := splitSecretKey(reference)
+	payload, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	SignAWSRequest(req, payload, AWSCredentials{
+		AccessKeyID:     a.config.AccessKeyID,
+		SecretAccessKey: a.config.SecretAccessKey,
+		SessionToken:    a.config.SessionToken,
+	}, a.config.Region, "secretsmanager", time.Now())
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("secrets manager request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("secrets manager returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var body struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid secrets manager response: %w", err)
+	}
+	if body.SecretString == nil {
+		return "", fmt.Errorf("secret %s is binary; only string secrets are supported", secretID)
+	}
+	if key == "" {
+		return *body.SecretString, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(*body.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object, so it has no field %q", secretID, key)
+	}
+	return secretField(fields, key)
+}
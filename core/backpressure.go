@@ -0,0 +1,338 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Data channel overflow policies
+const (
+	OverflowBlock      = "block"       // collectors wait for room
+	OverflowDropOldest = "drop-oldest" // the oldest waiting batch makes room
+	OverflowDropNewest = "drop-newest" // the new batch is dropped
+	OverflowSpill      = "spill"       // the new batch is written to disk and replayed later
+)
+
+// defaultDegradedAfter is how long the data channel must stay full before the framework
+// reports itself degraded when no threshold is configured
+const defaultDegradedAfter = 30 * time.Second
+
+// spillReplayInterval is how often spilled batches are looked for
+const spillReplayInterval = time.Second
+
+// backpressure tracks how long the data channel has been full and keeps the batches
+// spilled to disk
+type backpressure struct {
+	config BackpressureConfig
+	now    func() time.Time
+
+	mu        sync.Mutex
+	fullSince time.Time
+	degraded  bool
+	seq       uint64
+}
+
+// newBackpressure creates the backpressure tracker
+func newBackpressure(config BackpressureConfig) *backpressure {
+	return &backpressure{config: config, now: time.Now}
+}
+
+// full records that a batch found the data channel full. It reports whether the channel has
+// now been full for long enough to count as degraded, the first time it does.
+func (b *backpressure) full() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if b.fullSince.IsZero() {
+		b.fullSince = now
+	}
+	threshold := b.config.DegradedAfter
+	if threshold <= 0 {
+		threshold = defaultDegradedAfter
+	}
+	fullFor := now.Sub(b.fullSince)
+	if b.degraded || fullFor < threshold {
+		return false, fullFor
+	}
+	b.degraded = true
+	return true, fullFor
+}
+
+// relieved records that a batch found room in the data channel. It reports whether the
+// channel was degraded until now.
+func (b *backpressure) relieved() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasDegraded := b.degraded
+	b.fullSince = time.Time{}
+	b.degraded = false
+	return wasDegraded
+}
+
+// isDegraded reports whether the data channel has been full for longer than the threshold
+func (b *backpressure) isDegraded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.degraded
+}
+
+// spill writes a batch to the spill directory. Files are named so that they sort in the
+// order they were written, and are renamed into place so a reader never sees half a batch.
+func (b *backpressure) spill(data []DataPoint) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return WrapError(err, ErrorTypeInternal, "framework", "spill", "failed to encode batch")
+	}
+	if b.config.SpillMaxBytes > 0 {
+		_, size, err := b.spilled()
+		if err != nil {
+			return err
+		}
+		if size+int64(len(encoded)) > b.config.SpillMaxBytes {
+			return NewInternalError("framework", "spill", fmt.Sprintf("spill directory is over its %d byte limit", b.config.SpillMaxBytes))
+		}
+	}
+	if err := os.MkdirAll(b.config.SpillDir, 0o750); err != nil {
+		return WrapError(err, ErrorTypeInternal, "framework", "spill", "failed to create spill directory")
+	}
+
+	b.mu.Lock()
+	b.seq++
+	name := fmt.Sprintf("%019d-%06d.json", b.now().UnixNano(), b.seq%1000000)
+	b.mu.Unlock()
+
+	path := filepath.Join(b.config.SpillDir, name)
+	if err := os.WriteFile(path+".tmp", encoded, 0o640); err != nil {
+		return WrapError(err, ErrorTypeInternal, "framework", "spill", "failed to write batch")
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return WrapError(err, ErrorTypeInternal, "framework", "spill", "failed to write batch")
+	}
+	return nil
+}
+
+// spilled lists the spilled batches, oldest first, and the bytes they take
+func (b *backpressure) spilled() ([]string, int64, error) {
+	entries, err := os.ReadDir(b.config.SpillDir)
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, WrapError(err, ErrorTypeInternal, "framework", "spill", "failed to list spill directory")
+	}
+
+	var paths []string
+	var size int64
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			size += info.Size()
+		}
+		paths = append(paths, filepath.Join(b.config.SpillDir, entry.Name()))
+	}
+	sort.Strings(paths)
+	return paths, size, nil
+}
+
+// Ingest errors
+var (
+	// ErrShuttingDown rejects data arriving while the pipeline drains for shutdown, which
+	// might not be processed
+	ErrShuttingDown = errors.New("shutting down")
+	// ErrDataChannelFull rejects data under the block policy while the data channel is full
+	ErrDataChannelFull = errors.New("data channel full")
+)
+
+// Ingest hands data points sent to the agent, over the API or by remote agents, to the
+// pipeline. Senders are not held up for room in the data channel; under the block policy a
+// full channel rejects the batch, and the other policies apply as for collectors.
+func (f *Framework) Ingest(ctx context.Context, data []DataPoint) error {
+	if len(data) == 0 {
+		return nil
+	}
+	f.mu.RLock()
+	shutdown := f.shutdown
+	f.mu.RUnlock()
+	if shutdown {
+		return ErrShuttingDown
+	}
+
+	// Processing the batch continues the sender's trace
+	ctx, span := f.startSpan(ctx, "ingest", attribute.Int("data_points", len(data)))
+	defer span.End()
+
+	if f.overflowPolicy() != OverflowBlock {
+		f.enqueue(ctx, data)
+		return nil
+	}
+	f.logBatch(data)
+	f.tagBatch(ctx, data)
+	select {
+	case f.dataChannel <- data:
+		return nil
+	default:
+		f.ackBatch(data)
+		slog.Warn("Data channel full, rejecting ingested data", "data_points", len(data))
+		return ErrDataChannelFull
+	}
+}
+
+// enqueue hands a collected batch to the data processors, applying the overflow policy when
+// the data channel is full. Only the block policy waits, and it returns the context's error
+// if the context ends first. The batch continues the trace of the context's span.
+func (f *Framework) enqueue(ctx context.Context, data []DataPoint) error {
+	f.logBatch(data)
+	f.tagBatch(ctx, data)
+	select {
+	case f.dataChannel <- data:
+		if f.backpressure.relieved() {
+			slog.Info("Data channel has room again")
+			f.publishEvent(EventDataChannelRecovered, map[string]interface{}{"depth": len(f.dataChannel)})
+		}
+		return nil
+	default:
+	}
+
+	if degraded, fullFor := f.backpressure.full(); degraded {
+		slog.Warn("Data channel has been full for too long, collected data is backing up",
+			"full_for", fullFor, "policy", f.overflowPolicy())
+		f.publishEvent(EventDataChannelDegraded, map[string]interface{}{
+			"full_for": fullFor.String(),
+			"policy":   f.overflowPolicy(),
+			"capacity": cap(f.dataChannel),
+		})
+	}
+
+	switch f.overflowPolicy() {
+	case OverflowDropNewest:
+		f.dropBatch(data, "newest")
+	case OverflowDropOldest:
+		// Another collector may take the freed slot first, so try a few times
+		for attempt := 0; attempt < 3; attempt++ {
+			select {
+			case oldest := <-f.dataChannel:
+				f.dropBatch(oldest, "oldest")
+			default:
+			}
+			select {
+			case f.dataChannel <- data:
+				return nil
+			default:
+			}
+		}
+		f.dropBatch(data, "newest")
+	case OverflowSpill:
+		if err := f.backpressure.spill(data); err != nil {
+			slog.Warn("Failed to spill batch, dropping it", "error", err)
+			f.dropBatch(data, "newest")
+			return nil
+		}
+		f.metricsCollector.IncrementCounter("framework_data_batches_spilled_total", nil)
+		// The spill file keeps the batch from here on
+		f.ackBatch(data)
+	default:
+		select {
+		case f.dataChannel <- data:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// overflowPolicy returns the configured overflow policy
+func (f *Framework) overflowPolicy() string {
+	if f.config.Backpressure.Overflow == "" {
+		return OverflowBlock
+	}
+	return f.config.Backpressure.Overflow
+}
+
+// dropBatch counts a batch the overflow policy dropped
+func (f *Framework) dropBatch(data []DataPoint, which string) {
+	f.ackBatch(data)
+	labels := map[string]string{"batch": which}
+	f.metricsCollector.IncrementCounter("framework_data_batches_dropped_total", labels)
+	f.metricsCollector.AddCounter("framework_data_points_dropped_total", float64(len(data)), labels)
+	slog.Debug("Data channel full, dropping batch", "batch", which, "data_points", len(data))
+}
+
+// spillWorker replays spilled batches into the data channel, oldest first, including those
+// left by an earlier run
+func (f *Framework) spillWorker(ctx context.Context) {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(spillReplayInterval)
+	defer ticker.Stop()
+
+	for {
+		if !f.replaySpilled(ctx) {
+			slog.Info("Spill worker stopping due to context cancellation")
+			return
+		}
+		select {
+		case <-ctx.Done():
+			slog.Info("Spill worker stopping due to context cancellation")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// replaySpilled sends every spilled batch to the data channel, waiting for room. It returns
+// false if the context ended.
+func (f *Framework) replaySpilled(ctx context.Context) bool {
+	paths, _, err := f.backpressure.spilled()
+	if err != nil {
+		slog.Error("Failed to list spilled batches", "error", err)
+		return true
+	}
+	for _, path := range paths {
+		encoded, err := os.ReadFile(path)
+		if err != nil {
+			slog.Error("Failed to read spilled batch", "file", path, "error", err)
+			continue
+		}
+		var data []DataPoint
+		if err := json.Unmarshal(encoded, &data); err != nil {
+			slog.Error("Discarding unreadable spilled batch", "file", path, "error", err)
+			os.Remove(path)
+			continue
+		}
+
+		// Removing the file first means a batch is never replayed twice
+		if err := os.Remove(path); err != nil {
+			slog.Error("Failed to remove spilled batch, leaving it", "file", path, "error", err)
+			continue
+		}
+
+		f.logBatch(data)
+		select {
+		case f.dataChannel <- data:
+			f.metricsCollector.IncrementCounter("framework_data_batches_replayed_total", nil)
+		case <-ctx.Done():
+			// Keep the batch for the next run
+			f.ackBatch(data)
+			if err := f.backpressure.spill(data); err != nil {
+				slog.Error("Failed to spill batch back on shutdown", "error", err)
+			}
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBatch(metric string) []DataPoint {
+	return []DataPoint{{Source: "prometheus", Metric: metric, Value: 1, Timestamp: time.Now()}}
+}
+
+func TestFramework_OverflowPolicies(t *testing.T) {
+	tests := []struct {
+		policy   string
+		kept     string
+		spilled  bool
+		expected string
+	}{
+		{policy: OverflowDropNewest, kept: "first", expected: `framework_data_batches_dropped_total{batch="newest"} 1`},
+		{policy: OverflowDropOldest, kept: "second", expected: `framework_data_batches_dropped_total{batch="oldest"} 1`},
+		{policy: OverflowSpill, kept: "first", spilled: true, expected: `framework_data_batches_spilled_total 1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			framework := NewFramework(&FrameworkConfig{
+				LogLevel:        "info",
+				LogFormat:       "text",
+				LogOutput:       "stdout",
+				DataChannelSize: 1,
+				Backpressure:    BackpressureConfig{Overflow: tt.policy, SpillDir: t.TempDir()},
+			})
+			ctx := context.Background()
+
+			require.NoError(t, framework.enqueue(ctx, testBatch("first")))
+			require.NoError(t, framework.enqueue(ctx, testBatch("second")), "Expected a full channel not to block")
+			require.Len(t, framework.dataChannel, 1)
+			assert.Equal(t, tt.kept, (<-framework.dataChannel)[0].Metric)
+
+			var buf bytes.Buffer
+			require.NoError(t, framework.WriteMetrics(&buf))
+			assert.Contains(t, buf.String(), tt.expected)
+
+			paths, _, err := framework.backpressure.spilled()
+			require.NoError(t, err)
+			if !tt.spilled {
+				assert.Empty(t, paths)
+				return
+			}
+			require.Len(t, paths, 1)
+			assert.Contains(t, buf.String(), "framework_data_spilled_batches 1")
+
+			assert.True(t, framework.replaySpilled(ctx))
+			assert.Equal(t, "second", (<-framework.dataChannel)[0].Metric, "Expected the spilled batch to be replayed")
+			paths, _, err = framework.backpressure.spilled()
+			require.NoError(t, err)
+			assert.Empty(t, paths)
+		})
+	}
+}
+
+func TestFramework_OverflowBlock(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout", DataChannelSize: 1})
+	require.NoError(t, framework.enqueue(context.Background(), testBatch("first")))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, framework.enqueue(ctx, testBatch("second")), context.DeadlineExceeded,
+		"Expected the block policy to wait for room until the context ends")
+}
+
+func TestBackpressure_SpillLimit(t *testing.T) {
+	b := newBackpressure(BackpressureConfig{SpillDir: t.TempDir(), SpillMaxBytes: 200})
+	require.NoError(t, b.spill(testBatch("first")))
+	assert.Error(t, b.spill(testBatch("second")), "Expected a spill over the byte limit to fail")
+}
+
+func TestFramework_BackpressureDegraded(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{
+		LogLevel:        "info",
+		LogFormat:       "text",
+		LogOutput:       "stdout",
+		DataChannelSize: 1,
+		Backpressure:    BackpressureConfig{Overflow: OverflowDropNewest, DegradedAfter: time.Minute},
+	})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	framework.backpressure.now = func() time.Time { return now }
+
+	events := make(chan Event, 10)
+	subscribe := func(event Event) error {
+		events <- event
+		return nil
+	}
+	for _, eventType := range []string{EventDataChannelDegraded, EventDataChannelRecovered} {
+		_, err := framework.eventBus.Subscribe(eventType, subscribe)
+		require.NoError(t, err)
+	}
+
+	ctx := context.Background()
+	require.NoError(t, framework.enqueue(ctx, testBatch("first")))
+	require.NoError(t, framework.enqueue(ctx, testBatch("second")))
+	assert.False(t, framework.backpressure.isDegraded(), "Expected a briefly full channel not to degrade the framework")
+
+	now = now.Add(time.Minute)
+	require.NoError(t, framework.enqueue(ctx, testBatch("third")))
+	assert.True(t, framework.backpressure.isDegraded())
+	assert.Equal(t, "degraded", framework.GetHealthStatus(ctx).Checks["data_channel"].Status)
+	select {
+	case event := <-events:
+		assert.Equal(t, EventDataChannelDegraded, event.Type)
+		assert.Equal(t, OverflowDropNewest, event.Data["policy"])
+	case <-time.After(time.Second):
+		t.Fatal("Expected a data_channel_degraded event")
+	}
+
+	<-framework.dataChannel
+	require.NoError(t, framework.enqueue(ctx, testBatch("fourth")))
+	assert.False(t, framework.backpressure.isDegraded())
+	assert.Equal(t, "healthy", framework.GetHealthStatus(ctx).Checks["data_channel"].Status)
+	select {
+	case event := <-events:
+		assert.Equal(t, EventDataChannelRecovered, event.Type)
+	case <-time.After(time.Second):
+		t.Fatal("Expected a data_channel_recovered event")
+	}
+}
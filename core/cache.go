@@ -0,0 +1,148 @@
+package core
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// defaultCacheCapacity is the number of entries an LRU cache holds when none is given
+const defaultCacheCapacity = 1000
+
+// lruEntry is a cached value and when it expires; a zero expiry never expires
+type lruEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// LRUCache implements Cache with a bounded number of entries, each with a TTL. When full,
+// the least recently used entry is evicted; expired entries are dropped when read.
+type LRUCache struct {
+	capacity   int
+	defaultTTL time.Duration
+	entries    map[string]*list.Element
+	order      *list.List
+	hits       int64
+	misses     int64
+	evictions  int64
+	now        func() time.Time
+	mu         sync.Mutex
+}
+
+// CacheStats counts the lookups and evictions of a cache
+type CacheStats struct {
+	Size      int   `json:"size"`
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+}
+
+// NewLRUCache creates a cache holding up to capacity entries. Entries set with a TTL of
+// zero use defaultTTL; a defaultTTL of zero keeps them until evicted.
+func NewLRUCache(capacity int, defaultTTL time.Duration) *LRUCache {
+	if capacity <= 0 {
+		capacity = defaultCacheCapacity
+	}
+	return &LRUCache{
+		capacity:   capacity,
+		defaultTTL: defaultTTL,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		now:        time.Now,
+	}
+}
+
+// Get returns a value that has not expired and marks it as recently used
+func (c *LRUCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	entry := element.Value.(*lruEntry)
+	if !entry.expires.IsZero() && !c.now().Before(entry.expires) {
+		c.remove(element)
+		c.misses++
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	c.hits++
+	return entry.value, true
+}
+
+// Set stores a value for ttl, or the cache's default TTL if ttl is zero
+func (c *LRUCache) Set(key string, value interface{}, ttl time.Duration) error {
+	if ttl < 0 {
+		return NewValidationError("cache", "set", "TTL must not be negative")
+	}
+	if ttl == 0 {
+		ttl = c.defaultTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.now().Add(ttl)
+	}
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value = value
+		entry.expires = expires
+		c.order.MoveToFront(element)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+	return nil
+}
+
+// Delete removes a value; deleting a missing key is not an error
+func (c *LRUCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	return nil
+}
+
+// Clear removes every value
+func (c *LRUCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+	return nil
+}
+
+// Size returns the number of entries, including expired ones not yet read
+func (c *LRUCache) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Stats returns the cache's size and lookup counts
+func (c *LRUCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Size: c.order.Len(), Hits: c.hits, Misses: c.misses, Evictions: c.evictions}
+}
+
+// remove drops an entry; the caller holds the lock
+func (c *LRUCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*lruEntry).key)
+}
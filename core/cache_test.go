@@ -0,0 +1,52 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewLRUCache(2, 0)
+	require.NoError(t, cache.Set("a", 1, 0))
+	require.NoError(t, cache.Set("b", 2, 0))
+
+	_, ok := cache.Get("a")
+	require.True(t, ok)
+	require.NoError(t, cache.Set("c", 3, 0))
+
+	_, ok = cache.Get("b")
+	assert.False(t, ok, "Expected the least recently used entry to be evicted")
+	value, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 2, cache.Size())
+	assert.Equal(t, CacheStats{Size: 2, Hits: 2, Misses: 1, Evictions: 1}, cache.Stats())
+
+	require.NoError(t, cache.Delete("a"))
+	require.NoError(t, cache.Clear())
+	assert.Equal(t, 0, cache.Size())
+}
+
+func TestLRUCache_TTL(t *testing.T) {
+	now := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	cache := NewLRUCache(10, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	require.NoError(t, cache.Set("default", "x", 0))
+	require.NoError(t, cache.Set("short", "y", 10*time.Second))
+	assert.Error(t, cache.Set("negative", "z", -time.Second))
+
+	now = now.Add(30 * time.Second)
+	_, ok := cache.Get("short")
+	assert.False(t, ok, "Expected the entry to expire after its own TTL")
+	_, ok = cache.Get("default")
+	assert.True(t, ok)
+
+	now = now.Add(30 * time.Second)
+	_, ok = cache.Get("default")
+	assert.False(t, ok, "Expected the entry to expire after the default TTL")
+	assert.Equal(t, 0, cache.Size(), "Expected expired entries to be dropped when read")
+}
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AnalysisRef identifies an upstream analysis that contributed to a chained analysis
+type AnalysisRef struct {
+	ID          string       `json:"id"`
+	Fingerprint string       `json:"fingerprint"`
+	Source      string       `json:"source"`
+	Type        AnalysisType `json:"type"`
+	Severity    string       `json:"severity"`
+	Summary     string       `json:"summary"`
+}
+
+// analyzerChains is the DAG of analyzers fed by other analyzers. Analyzers that do not
+// appear in it run on raw data as before.
+type analyzerChains struct {
+	inputs   map[string][]string
+	consumed map[string]bool
+}
+
+// newAnalyzerChains builds the chain DAG, rejecting analyzers chained twice and cycles
+func newAnalyzerChains(configs []AnalyzerChainConfig) (*analyzerChains, error) {
+	chains := &analyzerChains{
+		inputs:   make(map[string][]string),
+		consumed: make(map[string]bool),
+	}
+	for _, chain := range configs {
+		if _, ok := chains.inputs[chain.Analyzer]; ok {
+			return nil, NewConfigurationError("chains", "build", fmt.Sprintf("analyzer %q is chained more than once", chain.Analyzer))
+		}
+		if len(chain.Inputs) == 0 {
+			return nil, NewConfigurationError("chains", "build", fmt.Sprintf("analyzer %q has no inputs", chain.Analyzer))
+		}
+		chains.inputs[chain.Analyzer] = chain.Inputs
+		if chain.Consume {
+			for _, input := range chain.Inputs {
+				chains.consumed[input] = true
+			}
+		}
+	}
+
+	// Depth-first search for cycles, reporting the first one found
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return NewConfigurationError("chains", "build", fmt.Sprintf("analyzer chain cycle: %s -> %s", strings.Join(path, " -> "), name))
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, input := range chains.inputs[name] {
+			if err := visit(input); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		return nil
+	}
+
+	names := make([]string, 0, len(chains.inputs))
+	for name := range chains.inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return chains, nil
+}
+
+// Inputs returns the analyzers whose analyses feed the named analyzer
+func (c *analyzerChains) Inputs(name string) []string {
+	if c == nil {
+		return nil
+	}
+	return c.inputs[name]
+}
+
+// Consumed reports whether the named analyzer's analyses only feed chains and are not sent
+// to responders on their own
+func (c *analyzerChains) Consumed(name string) bool {
+	return c != nil && c.consumed[name]
+}
+
+// order sorts analyzers so every analyzer runs after its inputs, keeping names in
+// alphabetical order among analyzers that are ready at the same time
+func (c *analyzerChains) order(analyzers []DataAnalyzer) []DataAnalyzer {
+	sort.Slice(analyzers, func(i, j int) bool { return analyzers[i].Name() < analyzers[j].Name() })
+	if c == nil || len(c.inputs) == 0 {
+		return analyzers
+	}
+
+	registered := make(map[string]bool, len(analyzers))
+	for _, analyzer := range analyzers {
+		registered[analyzer.Name()] = true
+	}
+
+	ordered := make([]DataAnalyzer, 0, len(analyzers))
+	placed := make(map[string]bool, len(analyzers))
+	for len(ordered) < len(analyzers) {
+		progress := false
+		for _, analyzer := range analyzers {
+			name := analyzer.Name()
+			if placed[name] {
+				continue
+			}
+			ready := true
+			for _, input := range c.inputs[name] {
+				// Inputs that are not loaded never produce analyses, so they do not block
+				if registered[input] && !placed[input] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				ordered = append(ordered, analyzer)
+				placed[name] = true
+				progress = true
+			}
+		}
+		// Cycles are rejected when the chains are built, this only guards against loops
+		if !progress {
+			break
+		}
+	}
+	return ordered
+}
+
+// stages groups analyzers into the stages of their chains: each analyzer is in the stage
+// after its latest input, so the analyzers of a stage can run at the same time. Stages keep
+// the order of order.
+func (c *analyzerChains) stages(analyzers []DataAnalyzer) [][]DataAnalyzer {
+	var stages [][]DataAnalyzer
+	level := make(map[string]int, len(analyzers))
+	for _, analyzer := range c.order(analyzers) {
+		stage := 0
+		for _, input := range c.Inputs(analyzer.Name()) {
+			if inputLevel, ok := level[input]; ok && inputLevel+1 > stage {
+				stage = inputLevel + 1
+			}
+		}
+		level[analyzer.Name()] = stage
+		for len(stages) <= stage {
+			stages = append(stages, nil)
+		}
+		stages[stage] = append(stages[stage], analyzer)
+	}
+	return stages
+}
+
+// provenance lists the analyses a chained analysis was derived from, ancestors first and
+// without duplicates when several paths share an upstream analysis
+func provenance(inputs []*Analysis) []AnalysisRef {
+	var refs []AnalysisRef
+	seen := make(map[string]bool)
+	add := func(ref AnalysisRef) {
+		if ref.ID != "" && seen[ref.ID] {
+			return
+		}
+		seen[ref.ID] = true
+		refs = append(refs, ref)
+	}
+	for _, input := range inputs {
+		for _, ref := range input.Provenance {
+			add(ref)
+		}
+		add(AnalysisRef{
+			ID:          input.ID,
+			Fingerprint: input.Fingerprint,
+			Source:      input.Source,
+			Type:        input.Type,
+			Severity:    input.Severity,
+			Summary:     input.Summary,
+		})
+	}
+	return refs
+}
+
+// chainedDataPoints returns the data points of the input analyses, each point once
+func chainedDataPoints(inputs []*Analysis) []DataPoint {
+	var points []DataPoint
+	seen := make(map[string]bool)
+	for _, input := range inputs {
+		for _, point := range input.DataPoints {
+			key := fingerprintSeries(point) + "@" + point.Timestamp.String()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			points = append(points, point)
+		}
+	}
+	return points
+}
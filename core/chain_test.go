@@ -0,0 +1,136 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type summarizingAnalyzer struct {
+	MockAnalyzer
+	inputs []*Analysis
+}
+
+func (s *summarizingAnalyzer) AnalyzeChain(inputs []*Analysis, data []DataPoint) (*Analysis, error) {
+	s.inputs = inputs
+	return &Analysis{
+		Severity:  "low",
+		Summary:   fmt.Sprintf("root cause of %d analyses", len(inputs)),
+		Timestamp: time.Now(),
+		Source:    s.name,
+	}, nil
+}
+
+func TestNewAnalyzerChains(t *testing.T) {
+	_, err := newAnalyzerChains([]AnalyzerChainConfig{
+		{Analyzer: "correlation", Inputs: []string{"anomaly"}},
+		{Analyzer: "summary", Inputs: []string{"correlation", "anomaly"}},
+	})
+	assert.NoError(t, err)
+
+	_, err = newAnalyzerChains([]AnalyzerChainConfig{
+		{Analyzer: "a", Inputs: []string{"b"}},
+		{Analyzer: "b", Inputs: []string{"c"}},
+		{Analyzer: "c", Inputs: []string{"a"}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a -> b -> c -> a")
+
+	_, err = newAnalyzerChains([]AnalyzerChainConfig{
+		{Analyzer: "a", Inputs: []string{"b"}},
+		{Analyzer: "a", Inputs: []string{"c"}},
+	})
+	assert.Error(t, err, "Expected an analyzer chained twice to be rejected")
+}
+
+func TestAnalyzerChains_Order(t *testing.T) {
+	chains, err := newAnalyzerChains([]AnalyzerChainConfig{
+		{Analyzer: "a-summary", Inputs: []string{"b-correlation", "missing"}},
+		{Analyzer: "b-correlation", Inputs: []string{"c-anomaly"}},
+	})
+	require.NoError(t, err)
+
+	analyzers := []DataAnalyzer{
+		&MockAnalyzer{MockPlugin{name: "a-summary"}},
+		&MockAnalyzer{MockPlugin{name: "c-anomaly"}},
+		&MockAnalyzer{MockPlugin{name: "b-correlation"}},
+		&MockAnalyzer{MockPlugin{name: "d-threshold"}},
+	}
+
+	var names []string
+	for _, analyzer := range chains.order(analyzers) {
+		names = append(names, analyzer.Name())
+	}
+	assert.Equal(t, []string{"c-anomaly", "d-threshold", "b-correlation", "a-summary"}, names)
+}
+
+func TestAnalyzerChains_Stages(t *testing.T) {
+	chains, err := newAnalyzerChains([]AnalyzerChainConfig{
+		{Analyzer: "a-summary", Inputs: []string{"b-correlation", "d-threshold"}},
+		{Analyzer: "b-correlation", Inputs: []string{"c-anomaly", "missing"}},
+	})
+	require.NoError(t, err)
+
+	analyzers := []DataAnalyzer{
+		&MockAnalyzer{MockPlugin{name: "a-summary"}},
+		&MockAnalyzer{MockPlugin{name: "c-anomaly"}},
+		&MockAnalyzer{MockPlugin{name: "b-correlation"}},
+		&MockAnalyzer{MockPlugin{name: "d-threshold"}},
+	}
+
+	var stages [][]string
+	for _, stage := range chains.stages(analyzers) {
+		var names []string
+		for _, analyzer := range stage {
+			names = append(names, analyzer.Name())
+		}
+		stages = append(stages, names)
+	}
+	assert.Equal(t, [][]string{{"c-anomaly", "d-threshold"}, {"b-correlation"}, {"a-summary"}}, stages)
+
+	var unchained *analyzerChains
+	assert.Len(t, unchained.stages(analyzers), 1, "Expected analyzers without chains to share one stage")
+}
+
+func TestFramework_AnalyzerChain(t *testing.T) {
+	config := &FrameworkConfig{
+		LogLevel:  "info",
+		LogFormat: "text",
+		LogOutput: "stdout",
+		AnalyzerChains: []AnalyzerChainConfig{
+			{Analyzer: "correlate", Inputs: []string{"spikes"}, Consume: true},
+			{Analyzer: "summarize", Inputs: []string{"correlate"}, Consume: true},
+		},
+	}
+	framework := NewFramework(config)
+
+	spikes := &reportingAnalyzer{MockAnalyzer{MockPlugin{name: "spikes", pluginType: PluginTypeAnalyzer}}}
+	correlate := &reportingAnalyzer{MockAnalyzer{MockPlugin{name: "correlate", pluginType: PluginTypeAnalyzer}}}
+	summarize := &summarizingAnalyzer{MockAnalyzer: MockAnalyzer{MockPlugin{name: "summarize", pluginType: PluginTypeAnalyzer}}}
+	responder := &severityResponder{MockPlugin: MockPlugin{name: "log", pluginType: PluginTypeResponder}, severity: "low"}
+	for _, plugin := range []Plugin{spikes, correlate, summarize, responder} {
+		require.NoError(t, framework.LoadPlugin(plugin))
+	}
+
+	framework.processData(context.Background(), []DataPoint{{Metric: "cpu", Value: 99}})
+
+	// spikes and correlate feed a consuming chain, so only summarize reaches responders
+	require.Len(t, responder.handled, 1)
+	result := responder.handled[0]
+	assert.Equal(t, "summarize", result.Source)
+	assert.Equal(t, AnalysisTypeComposite, result.Type)
+
+	require.Len(t, result.Provenance, 2)
+	assert.Equal(t, "spikes", result.Provenance[0].Source)
+	assert.Equal(t, "correlate", result.Provenance[1].Source)
+	assert.NotEmpty(t, result.Provenance[0].ID)
+
+	require.Len(t, summarize.inputs, 1)
+	assert.Equal(t, "correlate", summarize.inputs[0].Source)
+	assert.Equal(t, []DataPoint{{Metric: "cpu", Value: 99}}, summarize.inputs[0].DataPoints,
+		"Expected a plain chained analyzer to receive its input's data points")
+}
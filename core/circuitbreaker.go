@@ -0,0 +1,168 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states reported by GetState
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// ErrCircuitOpen is returned by a circuit breaker that is failing calls fast
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreakerConfig configures the default circuit breaker. A FailureThreshold of zero
+// disables it.
+type CircuitBreakerConfig struct {
+	FailureThreshold int           `yaml:"failure_threshold" json:"failure_threshold" validate:"min=0"`
+	ResetTimeout     time.Duration `yaml:"reset_timeout" json:"reset_timeout" validate:"min=0"`
+}
+
+// NewCircuitBreaker creates the default circuit breaker for config, or returns nil when
+// it is disabled
+func NewCircuitBreaker(config CircuitBreakerConfig) CircuitBreaker {
+	if config.FailureThreshold <= 0 {
+		return nil
+	}
+	return NewConsecutiveFailureBreaker(config.FailureThreshold, config.ResetTimeout)
+}
+
+// ParseCircuitBreakerConfig reads failure_threshold and reset_timeout from a plugin's
+// configuration section, keeping the defaults for keys not given
+func ParseCircuitBreakerConfig(raw interface{}, defaults CircuitBreakerConfig) (CircuitBreakerConfig, error) {
+	config := defaults
+	section, ok := raw.(map[string]interface{})
+	if !ok {
+		if raw != nil {
+			return config, NewValidationError("circuit-breaker", "configure", "circuit breaker configuration must be a map")
+		}
+		return config, nil
+	}
+
+	switch value := section["failure_threshold"].(type) {
+	case int:
+		config.FailureThreshold = value
+	case float64:
+		config.FailureThreshold = int(value)
+	}
+	if config.FailureThreshold < 0 {
+		return config, NewValidationError("circuit-breaker", "configure", "failure_threshold must not be negative")
+	}
+	if text, ok := section["reset_timeout"].(string); ok {
+		timeout, err := time.ParseDuration(text)
+		if err != nil || timeout < 0 {
+			return config, NewValidationError("circuit-breaker", "configure", fmt.Sprintf("invalid reset_timeout %q", text))
+		}
+		config.ResetTimeout = timeout
+	}
+	return config, nil
+}
+
+// ConsecutiveFailureBreaker is the default implementation of CircuitBreaker. It opens
+// after a number of failures in a row, fails every call while open, and after the reset
+// timeout lets one call through: success closes it again, failure reopens it.
+type ConsecutiveFailureBreaker struct {
+	failureThreshold int
+	resetTimeout     time.Duration
+	state            string
+	failures         int
+	openedAt         time.Time
+	trialRunning     bool
+	now              func() time.Time
+	mu               sync.Mutex
+}
+
+// NewConsecutiveFailureBreaker creates a closed circuit breaker that opens after
+// failureThreshold failures in a row and tries again after resetTimeout
+func NewConsecutiveFailureBreaker(failureThreshold int, resetTimeout time.Duration) *ConsecutiveFailureBreaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	return &ConsecutiveFailureBreaker{
+		failureThreshold: failureThreshold,
+		resetTimeout:     resetTimeout,
+		state:            CircuitClosed,
+		now:              time.Now,
+	}
+}
+
+// Execute runs the operation unless the circuit is open
+func (b *ConsecutiveFailureBreaker) Execute(ctx context.Context, operation func() error) error {
+	if err := b.acquire(); err != nil {
+		return err
+	}
+	err := operation()
+	b.release(err)
+	return err
+}
+
+// acquire decides whether a call may run, moving an open circuit to half-open once the
+// reset timeout has passed
+func (b *ConsecutiveFailureBreaker) acquire() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.resetTimeout {
+			return ErrCircuitOpen
+		}
+		b.state = CircuitHalfOpen
+		b.trialRunning = true
+	case CircuitHalfOpen:
+		// Only the trial call runs until it decides the state
+		if b.trialRunning {
+			return ErrCircuitOpen
+		}
+		b.trialRunning = true
+	}
+	return nil
+}
+
+// release records the outcome of a call
+func (b *ConsecutiveFailureBreaker) release(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitHalfOpen {
+		b.trialRunning = false
+	}
+	if err == nil {
+		b.state = CircuitClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.failureThreshold {
+		b.state = CircuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+// GetState returns closed, open, or half-open
+func (b *ConsecutiveFailureBreaker) GetState() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.resetTimeout {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// Reset closes the circuit and forgets past failures
+func (b *ConsecutiveFailureBreaker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = CircuitClosed
+	b.failures = 0
+	b.trialRunning = false
+}
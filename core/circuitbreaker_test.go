@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsecutiveFailureBreaker(t *testing.T) {
+	breaker := NewConsecutiveFailureBreaker(2, time.Minute)
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	calls := 0
+	fail := func() error { calls++; return fmt.Errorf("boom") }
+	succeed := func() error { calls++; return nil }
+
+	assert.Error(t, breaker.Execute(ctx, fail))
+	assert.Equal(t, CircuitClosed, breaker.GetState())
+	assert.NoError(t, breaker.Execute(ctx, succeed), "Expected a success to reset the failure count")
+	assert.Error(t, breaker.Execute(ctx, fail))
+	assert.Error(t, breaker.Execute(ctx, fail))
+	assert.Equal(t, CircuitOpen, breaker.GetState())
+
+	assert.ErrorIs(t, breaker.Execute(ctx, succeed), ErrCircuitOpen)
+	assert.Equal(t, 4, calls, "Expected an open circuit to fail without calling")
+
+	// After the reset timeout one trial call decides the state
+	now = now.Add(time.Minute)
+	assert.Equal(t, CircuitHalfOpen, breaker.GetState())
+	assert.Error(t, breaker.Execute(ctx, fail))
+	assert.Equal(t, CircuitOpen, breaker.GetState(), "Expected a failed trial to reopen the circuit")
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, breaker.Execute(ctx, succeed))
+	assert.Equal(t, CircuitClosed, breaker.GetState())
+
+	assert.Error(t, breaker.Execute(ctx, fail))
+	assert.Error(t, breaker.Execute(ctx, fail))
+	breaker.Reset()
+	assert.Equal(t, CircuitClosed, breaker.GetState())
+}
+
+func TestParseCircuitBreakerConfig(t *testing.T) {
+	defaults := CircuitBreakerConfig{FailureThreshold: 5, ResetTimeout: 30 * time.Second}
+
+	config, err := ParseCircuitBreakerConfig(nil, defaults)
+	assert.NoError(t, err)
+	assert.Equal(t, defaults, config)
+
+	config, err = ParseCircuitBreakerConfig(map[string]interface{}{"failure_threshold": 3.0, "reset_timeout": "1m"}, defaults)
+	assert.NoError(t, err)
+	assert.Equal(t, CircuitBreakerConfig{FailureThreshold: 3, ResetTimeout: time.Minute}, config)
+	assert.Equal(t, CircuitClosed, NewCircuitBreaker(config).GetState())
+
+	config, err = ParseCircuitBreakerConfig(map[string]interface{}{"failure_threshold": 0}, defaults)
+	assert.NoError(t, err)
+	assert.Nil(t, NewCircuitBreaker(config), "Expected a zero threshold to disable the breaker")
+
+	_, err = ParseCircuitBreakerConfig(map[string]interface{}{"reset_timeout": "soon"}, defaults)
+	assert.Error(t, err)
+	_, err = ParseCircuitBreakerConfig("5", defaults)
+	assert.Error(t, err)
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// consulDiscovery discovers services from a Consul agent. A service's type is its Consul
+// service name, and only instances passing their health checks are discovered.
+type consulDiscovery struct {
+	config     ConsulDiscoveryConfig
+	httpClient *http.Client
+}
+
+func newConsulDiscovery(config ConsulDiscoveryConfig, timeout time.Duration) *consulDiscovery {
+	if config.Address == "" {
+		config.Address = os.Getenv("CONSUL_HTTP_ADDR")
+	}
+	if config.Address == "" {
+		config.Address = "http://127.0.0.1:8500"
+	}
+	if !strings.Contains(config.Address, "://") {
+		config.Address = "http://" + config.Address
+	}
+	if config.Token == "" {
+		config.Token = os.Getenv("CONSUL_HTTP_TOKEN")
+	}
+	return &consulDiscovery{config: config, httpClient: &http.Client{Timeout: timeout}}
+}
+
+// consulService is a service instance as the Consul agent API describes it
+type consulService struct {
+	ID      string            `json:"ID"`
+	Service string            `json:"Service"`
+	Address string            `json:"Address"`
+	Port    int               `json:"Port"`
+	Tags    []string          `json:"Tags"`
+	Meta    map[string]string `json:"Meta"`
+}
+
+// RegisterService registers a service instance with the Consul agent, with an HTTP check
+// of its health check URL if it has one
+func (c *consulDiscovery) RegisterService(service ServiceInfo) error {
+	name := service.Type
+	if name == "" {
+		name = service.Name
+	}
+	registration := map[string]interface{}{
+		"ID":      service.ID,
+		"Name":    name,
+		"Address": service.Address,
+		"Port":    service.Port,
+		"Tags":    service.Tags,
+		"Meta":    service.Metadata,
+	}
+	if service.HealthCheck != "" {
+		registration["Check"] = map[string]interface{}{"HTTP": service.HealthCheck, "Interval": "10s"}
+	}
+	body, err := json.Marshal(registration)
+	if err != nil {
+		return err
+	}
+	return c.do(http.MethodPut, "/v1/agent/service/register", nil, body, nil)
+}
+
+// UnregisterService removes a service instance from the Consul agent
+func (c *consulDiscovery) UnregisterService(serviceID string) error {
+	return c.do(http.MethodPut, "/v1/agent/service/deregister/"+url.PathEscape(serviceID), nil, nil, nil)
+}
+
+// DiscoverServices returns the healthy instances of a Consul service
+func (c *consulDiscovery) DiscoverServices(serviceType string) ([]ServiceInfo, error) {
+	query := url.Values{"passing": []string{"true"}}
+	if c.config.Datacenter != "" {
+		query.Set("dc", c.config.Datacenter)
+	}
+	var entries []struct {
+		Node struct {
+			Node    string `json:"Node"`
+			Address string `json:"Address"`
+		} `json:"Node"`
+		Service consulService `json:"Service"`
+	}
+	if err := c.do(http.MethodGet, "/v1/health/service/"+url.PathEscape(serviceType), query, nil, &entries); err != nil {
+		return nil, err
+	}
+
+	instances := make([]ServiceInfo, 0, len(entries))
+	for _, entry := range entries {
+		instance := entry.Service.info()
+		// Services registered without an address are reached at their node's
+		if instance.Address == "" {
+			instance.Address = entry.Node.Address
+		}
+		instance.HealthCheck = "passing"
+		if instance.Metadata == nil {
+			instance.Metadata = make(map[string]string)
+		}
+		instance.Metadata["node"] = entry.Node.Node
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// GetService returns a service instance registered with the Consul agent
+func (c *consulDiscovery) GetService(serviceID string) (*ServiceInfo, error) {
+	var service consulService
+	if err := c.do(http.MethodGet, "/v1/agent/service/"+url.PathEscape(serviceID), nil, nil, &service); err != nil {
+		return nil, err
+	}
+	instance := service.info()
+	return &instance, nil
+}
+
+func (s consulService) info() ServiceInfo {
+	return ServiceInfo{
+		ID:       s.ID,
+		Name:     s.Service,
+		Type:     s.Service,
+		Address:  s.Address,
+		Port:     s.Port,
+		Metadata: s.Meta,
+		Tags:     s.Tags,
+	}
+}
+
+// do calls the Consul HTTP API, decoding the response into result if it is not nil
+func (c *consulDiscovery) do(method, path string, query url.Values, body []byte, result interface{}) error {
+	endpoint := strings.TrimSuffix(c.config.Address, "/") + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(context.Background(), method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if c.config.Token != "" {
+		req.Header.Set("X-Consul-Token", c.config.Token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("consul request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrServiceNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("consul returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("invalid consul response: %w", err)
+	}
+	return nil
+}
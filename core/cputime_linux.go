@@ -0,0 +1,18 @@
+package core
+
+import (
+	"syscall"
+	"time"
+)
+
+// rusageThread asks getrusage for the calling thread only
+const rusageThread = 1
+
+// threadCPUTime returns the user and system CPU time of the calling OS thread
+func threadCPUTime() time.Duration {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(rusageThread, &usage); err != nil {
+		return 0
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package core
+
+import "time"
+
+// threadCPUTime returns zero where the CPU time of a single thread cannot be read, so
+// plugin CPU time is only accounted on Linux
+func threadCPUTime() time.Duration {
+	return 0
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Pipeline stages recorded in the debug log
+const (
+	DebugStageBatch     = "batch"
+	DebugStageAnalyzer  = "analyzer"
+	DebugStageIncident  = "incident"
+	DebugStageResponder = "responder"
+	DebugStageAgent     = "agent"
+)
+
+// DebugEvent is one step of the pipeline as written to the debug log
+type DebugEvent struct {
+	TraceID   string                 `json:"trace_id"`
+	Timestamp time.Time              `json:"timestamp"`
+	Stage     string                 `json:"stage"`
+	Plugin    string                 `json:"plugin,omitempty"`
+	Decision  string                 `json:"decision"`
+	Reason    string                 `json:"reason,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// DebugLog writes pipeline events as JSON Lines so a single batch can be followed from
+// collection to responders by its trace ID. A nil log discards events.
+type DebugLog struct {
+	closer  io.Closer
+	encoder *json.Encoder
+	mu      sync.Mutex
+}
+
+// NewDebugLog creates a debug log that writes to w
+func NewDebugLog(w io.Writer) *DebugLog {
+	return &DebugLog{encoder: json.NewEncoder(w)}
+}
+
+// OpenDebugLog opens or creates a debug log file, appending to existing content
+func OpenDebugLog(path string) (*DebugLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, WrapError(err, ErrorTypeConfiguration, "debug_log", "open", fmt.Sprintf("failed to open %s", path))
+	}
+	log := NewDebugLog(file)
+	log.closer = file
+	return log, nil
+}
+
+// Record writes an event, stamping it with the current time if it has none
+func (l *DebugLog) Record(event DebugEvent) {
+	if l == nil {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// The debug log must never interfere with the pipeline, so write errors are dropped.
+	// Data that cannot be encoded is replaced so the decision itself is still recorded.
+	if err := l.encoder.Encode(event); err != nil && event.Data != nil {
+		event.Data = map[string]interface{}{"encode_error": err.Error()}
+		_ = l.encoder.Encode(event)
+	}
+}
+
+// Close closes the underlying file, if the log opened one
+func (l *DebugLog) Close() error {
+	if l == nil || l.closer == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closer.Close()
+}
+
+// ReadTrace returns the events of one trace in the order they were written
+func ReadTrace(r io.Reader, traceID string) ([]DebugEvent, error) {
+	var events []DebugEvent
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		var event DebugEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, WrapError(err, ErrorTypeValidation, "debug_log", "read", fmt.Sprintf("invalid event on line %d", line))
+		}
+		if event.TraceID == traceID {
+			events = append(events, event)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, WrapError(err, ErrorTypeInternal, "debug_log", "read", "failed to read debug log")
+	}
+	return events, nil
+}
+
+type traceIDKey struct{}
+
+// NewTraceID returns a random identifier for a pass through the pipeline
+func NewTraceID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// WithTraceID returns a context carrying the trace ID
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceIDFromContext returns the trace ID carried by the context, if any
+func TraceIDFromContext(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reportingAnalyzer struct {
+	MockAnalyzer
+}
+
+func (r *reportingAnalyzer) Analyze(data []DataPoint) (*Analysis, error) {
+	return &Analysis{
+		Type:       AnalysisTypeAnomaly,
+		Severity:   "low",
+		Summary:    "cpu spike",
+		DataPoints: data,
+		Timestamp:  time.Now(),
+		Source:     r.name,
+	}, nil
+}
+
+type severityResponder struct {
+	MockPlugin
+	severity string
+	handled  []*Analysis
+}
+
+func (s *severityResponder) Respond(ctx context.Context, analysis *Analysis) error {
+	s.handled = append(s.handled, analysis)
+	return nil
+}
+
+func (s *severityResponder) CanHandle(analysis *Analysis) bool {
+	return analysis.Severity == s.severity
+}
+
+func TestFramework_DebugLogTrace(t *testing.T) {
+	config := &FrameworkConfig{
+		LogLevel:  "info",
+		LogFormat: "text",
+		LogOutput: "stdout",
+		Plugins:   []PluginConfig{},
+	}
+	framework := NewFramework(config)
+
+	var buf bytes.Buffer
+	framework.SetDebugLog(NewDebugLog(&buf))
+
+	analyzer := &reportingAnalyzer{MockAnalyzer{MockPlugin{name: "spikes", pluginType: PluginTypeAnalyzer}}}
+	logger := &severityResponder{MockPlugin: MockPlugin{name: "log", pluginType: PluginTypeResponder}, severity: "low"}
+	pager := &severityResponder{MockPlugin: MockPlugin{name: "pager", pluginType: PluginTypeResponder}, severity: "critical"}
+	require.NoError(t, framework.LoadPlugin(analyzer))
+	require.NoError(t, framework.LoadPlugin(logger))
+	require.NoError(t, framework.LoadPlugin(pager))
+
+	framework.processData(context.Background(), []DataPoint{{Metric: "cpu", Value: 99}})
+	framework.processData(context.Background(), []DataPoint{{Metric: "memory", Value: 1}})
+
+	require.Len(t, logger.handled, 2)
+	traceID, ok := logger.handled[0].Details["trace_id"].(string)
+	require.True(t, ok, "Expected responders to see the trace ID")
+	assert.NotEqual(t, traceID, logger.handled[1].Details["trace_id"], "Expected each batch to get its own trace")
+
+	events, err := ReadTrace(bytes.NewReader(buf.Bytes()), traceID)
+	require.NoError(t, err)
+
+	stages := make([]string, 0, len(events))
+	decisions := make(map[string]string)
+	for _, event := range events {
+		stages = append(stages, event.Stage)
+		decisions[event.Stage+"/"+event.Plugin] = event.Decision
+	}
+	assert.Equal(t, []string{DebugStageBatch, DebugStageAnalyzer, DebugStageIncident, DebugStageResponder, DebugStageResponder}, stages)
+	assert.Equal(t, "reported", decisions[DebugStageAnalyzer+"/spikes"])
+	assert.Equal(t, "responded", decisions[DebugStageResponder+"/log"])
+	assert.Equal(t, "skipped", decisions[DebugStageResponder+"/pager"], "Expected the pager's refusal to be recorded")
+	assert.Empty(t, pager.handled)
+}
+
+func TestFramework_SetDebugLogWhileProcessing(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout"})
+
+	// Run with -race: replacing the log must not race with the pipeline writing to it
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			framework.recordAnalyzerDecision("trace", "spikes", nil, nil)
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		framework.SetDebugLog(NewDebugLog(io.Discard))
+	}
+	<-done
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Dedup decisions for an observed analysis
+const (
+	DedupNotify   = "notify"
+	DedupRepeat   = "repeat"
+	DedupFlapping = "flapping"
+)
+
+// alertState tracks one firing condition, identified by its analysis fingerprint
+type alertState struct {
+	analysis     *Analysis
+	incidentID   string
+	firstSeen    time.Time
+	lastSeen     time.Time
+	lastNotified time.Time
+}
+
+// flapHistory remembers when a condition last resolved and when it fired again since
+type flapHistory struct {
+	resolvedAt time.Time
+	refires    []time.Time
+}
+
+// ResolvedAlert is a condition that stopped firing, with the last analysis that reported it
+type ResolvedAlert struct {
+	Analysis   *Analysis
+	IncidentID string
+	FiringFor  time.Duration
+	// Notified is false when responders never heard of the alert, e.g. while it was flapping
+	Notified bool
+}
+
+// Deduplicator decides which analyses reach responders. Repeats of a firing condition are
+// suppressed until the repeat interval passes, conditions that keep resolving and firing
+// again are held back as flapping, and conditions not seen for the resolve timeout resolve.
+type Deduplicator struct {
+	config  DedupConfig
+	active  map[string]*alertState
+	history map[string]*flapHistory
+	mu      sync.Mutex
+}
+
+// NewDeduplicator creates a deduplicator; a zero repeat interval disables it
+func NewDeduplicator(config DedupConfig) *Deduplicator {
+	return &Deduplicator{
+		config:  config,
+		active:  make(map[string]*alertState),
+		history: make(map[string]*flapHistory),
+	}
+}
+
+// Enabled reports whether deduplication is configured
+func (d *Deduplicator) Enabled() bool {
+	return d != nil && d.config.RepeatInterval > 0
+}
+
+// Observe records an analysis of a firing condition and returns whether responders should
+// be notified, with the decision that led there
+func (d *Deduplicator) Observe(analysis *Analysis, incidentID string, now time.Time) (bool, string) {
+	if !d.Enabled() {
+		return true, DedupNotify
+	}
+	analysis.EnsureIdentity()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	fingerprint := analysis.Fingerprint
+	state, firing := d.active[fingerprint]
+	if !firing {
+		state = &alertState{firstSeen: now}
+		d.active[fingerprint] = state
+		if history, ok := d.history[fingerprint]; ok {
+			history.refires = append(history.refires, now)
+		}
+	}
+	state.analysis = analysis
+	state.incidentID = incidentID
+	state.lastSeen = now
+
+	if d.flapping(fingerprint, now) {
+		return false, DedupFlapping
+	}
+	if !state.lastNotified.IsZero() && now.Sub(state.lastNotified) < d.config.RepeatInterval {
+		return false, DedupRepeat
+	}
+	state.lastNotified = now
+	return true, DedupNotify
+}
+
+// flapping reports whether the condition fired again after resolving too often within the
+// flap window. Callers hold the lock.
+func (d *Deduplicator) flapping(fingerprint string, now time.Time) bool {
+	history, ok := d.history[fingerprint]
+	if !ok || d.config.FlapThreshold <= 0 {
+		return false
+	}
+	cutoff := now.Add(-d.config.FlapWindow)
+	drop := 0
+	for drop < len(history.refires) && history.refires[drop].Before(cutoff) {
+		drop++
+	}
+	history.refires = history.refires[drop:]
+	return len(history.refires) >= d.config.FlapThreshold
+}
+
+// Resolve returns the conditions not seen for the resolve timeout and forgets them, oldest
+// first
+func (d *Deduplicator) Resolve(now time.Time) []ResolvedAlert {
+	if !d.Enabled() || d.config.ResolveTimeout <= 0 {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var resolved []ResolvedAlert
+	for fingerprint, state := range d.active {
+		if now.Sub(state.lastSeen) < d.config.ResolveTimeout {
+			continue
+		}
+		resolved = append(resolved, ResolvedAlert{
+			Analysis:   state.analysis,
+			IncidentID: state.incidentID,
+			FiringFor:  state.lastSeen.Sub(state.firstSeen),
+			Notified:   !state.lastNotified.IsZero() && !d.flapping(fingerprint, now),
+		})
+		delete(d.active, fingerprint)
+
+		history, ok := d.history[fingerprint]
+		if !ok {
+			history = &flapHistory{}
+			d.history[fingerprint] = history
+		}
+		history.resolvedAt = now
+	}
+
+	// Conditions that resolved longer than a flap window ago can no longer be flapping
+	for fingerprint, history := range d.history {
+		if _, firing := d.active[fingerprint]; !firing && now.Sub(history.resolvedAt) > d.config.FlapWindow {
+			delete(d.history, fingerprint)
+		}
+	}
+
+	sort.Slice(resolved, func(i, j int) bool {
+		return resolved[i].Analysis.Timestamp.Before(resolved[j].Analysis.Timestamp)
+	})
+	return resolved
+}
+
+// resolvedAnalysis returns the notification sent to responders when a condition clears
+func resolvedAnalysis(alert ResolvedAlert, now time.Time) *Analysis {
+	resolved := *alert.Analysis
+	resolved.ID = NewAnalysisID()
+	resolved.Resolved = true
+	resolved.Timestamp = now
+	resolved.Summary = fmt.Sprintf("Resolved: %s", alert.Analysis.Summary)
+	resolved.Details = make(map[string]interface{}, len(alert.Analysis.Details)+2)
+	for k, v := range alert.Analysis.Details {
+		resolved.Details[k] = v
+	}
+	resolved.Details["firing_for"] = alert.FiringFor.String()
+	resolved.Details["last_analysis_id"] = alert.Analysis.ID
+	return &resolved
+}
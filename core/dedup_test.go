@@ -0,0 +1,122 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cpuSpike() *Analysis {
+	return &Analysis{Type: AnalysisTypeAnomaly, Severity: "low", Summary: "cpu spike", Source: "spikes",
+		DataPoints: []DataPoint{{Metric: "cpu", Labels: map[string]string{"instance": "web-1"}}}, Timestamp: time.Now()}
+}
+
+func TestDeduplicator_SuppressesRepeats(t *testing.T) {
+	dedup := NewDeduplicator(DedupConfig{RepeatInterval: time.Hour})
+	now := time.Now()
+
+	notify, decision := dedup.Observe(cpuSpike(), "INC-1", now)
+	assert.True(t, notify)
+	assert.Equal(t, DedupNotify, decision)
+
+	notify, decision = dedup.Observe(cpuSpike(), "INC-1", now.Add(time.Minute))
+	assert.False(t, notify, "Expected repeats within the repeat interval to be suppressed")
+	assert.Equal(t, DedupRepeat, decision)
+
+	other := cpuSpike()
+	other.DataPoints[0].Labels = map[string]string{"instance": "web-2"}
+	notify, _ = dedup.Observe(other, "INC-2", now.Add(time.Minute))
+	assert.True(t, notify, "Expected a different label set to be a separate condition")
+
+	notify, _ = dedup.Observe(cpuSpike(), "INC-1", now.Add(time.Hour+time.Minute))
+	assert.True(t, notify, "Expected a reminder once the repeat interval passed")
+}
+
+func TestDeduplicator_Disabled(t *testing.T) {
+	dedup := NewDeduplicator(DedupConfig{})
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		notify, _ := dedup.Observe(cpuSpike(), "INC-1", now)
+		assert.True(t, notify)
+	}
+	assert.Empty(t, dedup.Resolve(now.Add(time.Hour)))
+}
+
+func TestDeduplicator_Resolve(t *testing.T) {
+	dedup := NewDeduplicator(DedupConfig{RepeatInterval: time.Hour, ResolveTimeout: 5 * time.Minute})
+	start := time.Now()
+
+	dedup.Observe(cpuSpike(), "INC-1", start)
+	dedup.Observe(cpuSpike(), "INC-1", start.Add(2*time.Minute))
+	assert.Empty(t, dedup.Resolve(start.Add(5*time.Minute)), "Expected a recently seen condition to keep firing")
+
+	resolved := dedup.Resolve(start.Add(8 * time.Minute))
+	require.Len(t, resolved, 1)
+	assert.Equal(t, "INC-1", resolved[0].IncidentID)
+	assert.Equal(t, 2*time.Minute, resolved[0].FiringFor)
+	assert.True(t, resolved[0].Notified)
+
+	notify, _ := dedup.Observe(cpuSpike(), "INC-2", start.Add(9*time.Minute))
+	assert.True(t, notify, "Expected a condition firing again after resolving to notify")
+}
+
+func TestDeduplicator_Flapping(t *testing.T) {
+	dedup := NewDeduplicator(DedupConfig{RepeatInterval: time.Hour, ResolveTimeout: time.Minute,
+		FlapWindow: 30 * time.Minute, FlapThreshold: 2})
+	now := time.Now()
+
+	fire := func() (bool, string) {
+		notify, decision := dedup.Observe(cpuSpike(), "INC-1", now)
+		now = now.Add(2 * time.Minute)
+		require.Len(t, dedup.Resolve(now), 1)
+		now = now.Add(time.Minute)
+		return notify, decision
+	}
+
+	notify, _ := fire()
+	assert.True(t, notify)
+	notify, _ = fire()
+	assert.True(t, notify, "Expected one re-fire to stay below the flap threshold")
+	notify, decision := fire()
+	assert.False(t, notify, "Expected a flapping condition to be held back")
+	assert.Equal(t, DedupFlapping, decision)
+
+	now = now.Add(time.Hour)
+	dedup.Resolve(now)
+	notify, _ = dedup.Observe(cpuSpike(), "INC-1", now)
+	assert.True(t, notify, "Expected flapping to end once the flap window passed")
+}
+
+func TestFramework_DedupAndResolve(t *testing.T) {
+	config := &FrameworkConfig{
+		LogLevel:  "info",
+		LogFormat: "text",
+		LogOutput: "stdout",
+		Dedup:     DedupConfig{RepeatInterval: time.Hour, ResolveTimeout: 5 * time.Minute},
+		Plugins:   []PluginConfig{},
+	}
+	framework := NewFramework(config)
+
+	responder := &severityResponder{MockPlugin: MockPlugin{name: "log", pluginType: PluginTypeResponder}, severity: "low"}
+	require.NoError(t, framework.LoadPlugin(responder))
+
+	ctx := context.Background()
+	framework.handleAnalysis(ctx, "spikes", cpuSpike())
+	framework.handleAnalysis(ctx, "spikes", cpuSpike())
+	require.Len(t, responder.handled, 1, "Expected the repeat to be deduplicated")
+	incidentID := responder.handled[0].Details["incident_id"]
+
+	framework.resolveAlerts(ctx, time.Now().Add(10*time.Minute))
+	require.Len(t, responder.handled, 2)
+	resolved := responder.handled[1]
+	assert.True(t, resolved.Resolved)
+	assert.Equal(t, "Resolved: cpu spike", resolved.Summary)
+	assert.Equal(t, incidentID, resolved.Details["incident_id"])
+
+	incident, err := framework.GetIncidentManager().Get(incidentID.(string))
+	require.NoError(t, err)
+	assert.Equal(t, IncidentStatusResolved, incident.Status)
+}
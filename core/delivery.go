@@ -0,0 +1,529 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// StoreCollectionDeliveries holds failed deliveries waiting to be retried
+const StoreCollectionDeliveries = "deliveries"
+
+// QueuedDelivery is an analysis a responder failed to deliver, waiting to be retried
+type QueuedDelivery struct {
+	ID        string    `json:"id"`
+	Responder string    `json:"responder"`
+	TraceID   string    `json:"trace_id,omitempty"`
+	Analysis  *Analysis `json:"analysis"`
+	QueuedAt  time.Time `json:"queued_at"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error"`
+}
+
+// DeliveryStatus is the delivery state of one responder
+type DeliveryStatus struct {
+	Responder           string    `json:"responder"`
+	Queued              int       `json:"queued"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Reachable           bool      `json:"reachable"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastFailure         time.Time `json:"last_failure,omitempty"`
+	Failover            string    `json:"failover,omitempty"`
+	// FailingOver is true while deliveries go to the failover responder
+	FailingOver bool `json:"failing_over"`
+	// Breaker is the state of the responder's circuit breaker, if breakers are enabled
+	Breaker string `json:"breaker,omitempty"`
+
+	// Delivery receipts since the framework started
+	Attempts         int64     `json:"attempts"`
+	Delivered        int64     `json:"delivered"`
+	Failed           int64     `json:"failed"`
+	Dropped          int64     `json:"dropped"`
+	LastDelivered    time.Time `json:"last_delivered,omitempty"`
+	AverageLatencyMS int64     `json:"average_latency_ms"`
+}
+
+// destinationState tracks the deliveries of one responder
+type destinationState struct {
+	queue       []*QueuedDelivery
+	failures    int
+	unreachable bool
+	lastError   string
+	lastFailure time.Time
+}
+
+// deliveryQueue keeps failed deliveries per responder, persisted so they survive a
+// restart, along with how each responder's destination is doing
+type deliveryQueue struct {
+	config   DeliveryConfig
+	store    Store
+	states   map[string]*destinationState
+	sequence int
+	mu       sync.Mutex
+}
+
+// newDeliveryQueue creates a delivery queue, restoring deliveries queued before a restart
+func newDeliveryQueue(ctx context.Context, store Store, config DeliveryConfig) (*deliveryQueue, error) {
+	q := &deliveryQueue{config: config, store: store, states: make(map[string]*destinationState)}
+	err := ListJSON(ctx, store, StoreCollectionDeliveries, func(key string, unmarshal func(v interface{}) error) error {
+		var delivery QueuedDelivery
+		if err := unmarshal(&delivery); err != nil {
+			return err
+		}
+		state := q.state(delivery.Responder)
+		state.queue = append(state.queue, &delivery)
+		return nil
+	})
+	for _, state := range q.states {
+		sort.Slice(state.queue, func(i, j int) bool { return state.queue[i].ID < state.queue[j].ID })
+	}
+	return q, err
+}
+
+// state returns a responder's state, creating it; callers hold the lock
+func (q *deliveryQueue) state(responder string) *destinationState {
+	state, ok := q.states[responder]
+	if !ok {
+		state = &destinationState{}
+		q.states[responder] = state
+	}
+	return state
+}
+
+// enqueue queues a failed delivery for retry. When the queue is full the oldest delivery
+// is dropped and returned.
+func (q *deliveryQueue) enqueue(ctx context.Context, responder string, analysis *Analysis, err error) *QueuedDelivery {
+	if q.config.QueueSize <= 0 {
+		return nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	q.sequence++
+	delivery := &QueuedDelivery{
+		// Sorts in queue order, which restoring relies on
+		ID:        fmt.Sprintf("%020d-%06d", now.UnixNano(), q.sequence%1000000),
+		Responder: responder,
+		TraceID:   TraceIDFromContext(ctx),
+		Analysis:  analysis,
+		QueuedAt:  now,
+		Attempts:  1,
+		LastError: err.Error(),
+	}
+	state := q.state(responder)
+	state.queue = append(state.queue, delivery)
+	q.persist(delivery)
+	if len(state.queue) <= q.config.QueueSize {
+		return nil
+	}
+	dropped := state.queue[0]
+	state.queue = state.queue[1:]
+	q.delete(dropped)
+	slog.Error("Delivery queue full, dropping the oldest delivery", "responder", responder,
+		"analysis", dropped.Analysis.ID, "queued_at", dropped.QueuedAt)
+	return dropped
+}
+
+// recordResult counts a delivery attempt towards the responder's consecutive failures
+func (q *deliveryQueue) recordResult(responder string, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	state := q.state(responder)
+	if err == nil {
+		state.failures = 0
+		return
+	}
+	state.failures++
+	state.lastError = err.Error()
+	state.lastFailure = time.Now()
+}
+
+// setReachable records the result of a destination check
+func (q *deliveryQueue) setReachable(responder string, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	state := q.state(responder)
+	state.unreachable = err != nil
+	if err != nil {
+		state.lastError = err.Error()
+		state.lastFailure = time.Now()
+	}
+}
+
+// unreachable reports whether the responder's last destination check failed
+func (q *deliveryQueue) unreachable(responder string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	state, ok := q.states[responder]
+	return ok && state.unreachable
+}
+
+// down reports whether the responder's deliveries should go to its failover
+func (q *deliveryQueue) down(responder string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.downLocked(responder)
+}
+
+// downLocked is down for callers holding the lock
+func (q *deliveryQueue) downLocked(responder string) bool {
+	state, ok := q.states[responder]
+	if !ok {
+		return false
+	}
+	return state.unreachable || (q.config.FailoverAfter > 0 && state.failures >= q.config.FailoverAfter)
+}
+
+// halfOpen lets the next delivery try a responder that was failed over for failing,
+// so it is taken back once it recovers; one more failure fails it over again
+func (q *deliveryQueue) halfOpen(responder string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if state, ok := q.states[responder]; ok && q.config.FailoverAfter > 0 && state.failures >= q.config.FailoverAfter {
+		state.failures = q.config.FailoverAfter - 1
+	}
+}
+
+// queued returns the responders with queued deliveries and a copy of their queues
+func (q *deliveryQueue) queued() map[string][]*QueuedDelivery {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queued := make(map[string][]*QueuedDelivery)
+	for responder, state := range q.states {
+		if len(state.queue) > 0 {
+			queued[responder] = append([]*QueuedDelivery(nil), state.queue...)
+		}
+	}
+	return queued
+}
+
+// retried records another failed attempt of a queued delivery
+func (q *deliveryQueue) retried(delivery *QueuedDelivery, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delivery.Attempts++
+	delivery.LastError = err.Error()
+	q.persist(delivery)
+}
+
+// remove takes a delivery off its queue
+func (q *deliveryQueue) remove(delivery *QueuedDelivery) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	state := q.state(delivery.Responder)
+	for i, queued := range state.queue {
+		if queued == delivery {
+			state.queue = append(state.queue[:i], state.queue[i+1:]...)
+			break
+		}
+	}
+	q.delete(delivery)
+}
+
+// expire drops and returns deliveries queued longer than the maximum age
+func (q *deliveryQueue) expire(now time.Time) []*QueuedDelivery {
+	if q.config.MaxAge <= 0 {
+		return nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var expired []*QueuedDelivery
+	for responder, state := range q.states {
+		kept := state.queue[:0]
+		for _, delivery := range state.queue {
+			if now.Sub(delivery.QueuedAt) > q.config.MaxAge {
+				q.delete(delivery)
+				slog.Error("Dropping delivery that could not be sent in time", "responder", responder,
+					"analysis", delivery.Analysis.ID, "attempts", delivery.Attempts, "last_error", delivery.LastError)
+				expired = append(expired, delivery)
+				continue
+			}
+			kept = append(kept, delivery)
+		}
+		state.queue = kept
+	}
+	return expired
+}
+
+// status returns the delivery state of a responder
+func (q *deliveryQueue) status(responder string) DeliveryStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	status := DeliveryStatus{Responder: responder, Reachable: true, Failover: q.config.Failover[responder]}
+	if state, ok := q.states[responder]; ok {
+		status.Queued = len(state.queue)
+		status.ConsecutiveFailures = state.failures
+		status.Reachable = !state.unreachable
+		status.LastError = state.lastError
+		status.LastFailure = state.lastFailure
+		status.FailingOver = status.Failover != "" && q.downLocked(responder)
+	}
+	return status
+}
+
+// responderBreakers holds a circuit breaker per responder, created on first use
+type responderBreakers struct {
+	threshold    int
+	resetTimeout time.Duration
+	breakers     map[string]*ConsecutiveFailureBreaker
+	mu           sync.Mutex
+}
+
+// newResponderBreakers creates the breakers configured for deliveries
+func newResponderBreakers(config DeliveryConfig) *responderBreakers {
+	return &responderBreakers{
+		threshold:    config.BreakerThreshold,
+		resetTimeout: config.BreakerResetTimeout,
+		breakers:     make(map[string]*ConsecutiveFailureBreaker),
+	}
+}
+
+// get returns the responder's breaker, or nil when breakers are disabled
+func (b *responderBreakers) get(responder string) *ConsecutiveFailureBreaker {
+	if b.threshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	breaker, ok := b.breakers[responder]
+	if !ok {
+		breaker = NewConsecutiveFailureBreaker(b.threshold, b.resetTimeout)
+		b.breakers[responder] = breaker
+	}
+	return breaker
+}
+
+// newDeliveryRetrier creates the retry executor for responder calls
+func newDeliveryRetrier(config DeliveryConfig) RetryExecutor {
+	return NewBackoffRetryExecutor(RetryPolicy{
+		MaxAttempts:  config.MaxAttempts,
+		InitialDelay: config.RetryBackoff,
+		MaxDelay:     config.MaxRetryBackoff,
+		Multiplier:   2,
+		Jitter:       true,
+	})
+}
+
+// persist saves a delivery; callers hold the lock. A store outage keeps it in memory.
+func (q *deliveryQueue) persist(delivery *QueuedDelivery) {
+	if err := PutJSON(context.Background(), q.store, StoreCollectionDeliveries, delivery.ID, delivery); err != nil {
+		slog.Error("Failed to persist queued delivery", "responder", delivery.Responder, "error", err)
+	}
+}
+
+// delete removes a persisted delivery; callers hold the lock
+func (q *deliveryQueue) delete(delivery *QueuedDelivery) {
+	if err := q.store.Delete(context.Background(), StoreCollectionDeliveries, delivery.ID); err != nil && !errors.Is(err, ErrStoreNotFound) {
+		slog.Error("Failed to delete queued delivery", "responder", delivery.Responder, "error", err)
+	}
+}
+
+// send delivers an analysis to a responder. While the responder is down its failover gets
+// the analysis instead, and a delivery that fails is queued for retry.
+func (f *Framework) send(ctx context.Context, responder DataResponder, analysis *Analysis) {
+	target := f.deliveryTarget(ctx, responder, analysis)
+	err := f.attemptDelivery(ctx, target, analysis, 1)
+	// This failure may be the one that fails the responder over
+	if err != nil && target == responder && f.failoverTarget(responder, analysis) != nil {
+		target = f.deliveryTarget(ctx, responder, analysis)
+		err = f.attemptDelivery(ctx, target, analysis, 1)
+	}
+	if err != nil {
+		f.queueDelivery(ctx, target.Name(), analysis, err)
+	}
+}
+
+// attemptDelivery calls a responder and records the outcome; attempt counts from one
+func (f *Framework) attemptDelivery(ctx context.Context, responder DataResponder, analysis *Analysis, attempt int) error {
+	ctx, span := f.startSpan(ctx, "respond",
+		attribute.String("responder", responder.Name()),
+		attribute.String("analysis_id", analysis.ID),
+		attribute.Int("attempt", attempt))
+	err := f.callResponder(ctx, responder.Name(), func(ctx context.Context) (err error) {
+		f.sandbox.run(responder.Name(), func() error { err = responder.Respond(ctx, analysis); return err })
+		return err
+	})
+	f.recordResponse(ctx, responder.Name(), err)
+	f.deliveries.recordResult(responder.Name(), err)
+	f.recordDelivery(responder.Name(), analysis, attempt, err)
+	endSpan(span, err)
+	return err
+}
+
+// callResponder makes a responder call with the delivery timeout, retrying it with
+// backoff and failing fast while the responder's circuit breaker is open
+func (f *Framework) callResponder(ctx context.Context, responder string, call func(ctx context.Context) error) error {
+	attempt := func() error {
+		if f.config.Delivery.Timeout > 0 {
+			ctx, cancel := context.WithTimeout(ctx, f.config.Delivery.Timeout)
+			defer cancel()
+			return call(ctx)
+		}
+		return call(ctx)
+	}
+	breaker := f.breakers.get(responder)
+	return f.retrier.Execute(ctx, func() error {
+		if breaker == nil {
+			return attempt()
+		}
+		return breaker.Execute(ctx, attempt)
+	})
+}
+
+// queueDelivery queues a failed delivery for retry
+func (f *Framework) queueDelivery(ctx context.Context, responder string, analysis *Analysis, err error) {
+	if dropped := f.deliveries.enqueue(ctx, responder, analysis, err); dropped != nil {
+		f.recordDropped(dropped, "queue full")
+	}
+}
+
+// deliveryTarget returns the responder's failover while the responder is down and the
+// failover can take the analysis, and the responder itself otherwise
+func (f *Framework) deliveryTarget(ctx context.Context, responder DataResponder, analysis *Analysis) DataResponder {
+	failover := f.failoverTarget(responder, analysis)
+	if failover == nil {
+		return responder
+	}
+
+	reason := fmt.Sprintf("%s failed %d deliveries in a row", responder.Name(), f.deliveries.status(responder.Name()).ConsecutiveFailures)
+	if f.deliveries.unreachable(responder.Name()) {
+		reason = fmt.Sprintf("destination of %s is unreachable", responder.Name())
+	}
+	f.debugLog.Load().Record(DebugEvent{
+		TraceID:  TraceIDFromContext(ctx),
+		Stage:    DebugStageResponder,
+		Plugin:   responder.Name(),
+		Decision: "failed_over",
+		Reason:   reason,
+		Data:     map[string]interface{}{"analysis_id": analysis.ID, "failover": failover.Name()},
+	})
+	return failover
+}
+
+// failoverTarget returns the responder configured to take over from a responder that is
+// down, if it is loaded, allowed to run, and handles the analysis
+func (f *Framework) failoverTarget(responder DataResponder, analysis *Analysis) DataResponder {
+	name, ok := f.config.Delivery.Failover[responder.Name()]
+	if !ok || !f.deliveries.down(responder.Name()) {
+		return nil
+	}
+	plugin, err := f.registry.GetPlugin(name)
+	if err != nil {
+		slog.Warn("Failover responder not loaded", "responder", responder.Name(), "failover", name)
+		return nil
+	}
+	failover, ok := plugin.(DataResponder)
+	if !ok || (f.config.ReadOnly && !isReadOnlyResponder(failover)) || !failover.CanHandle(analysis) {
+		return nil
+	}
+	return failover
+}
+
+// DeliveryStatus returns the delivery state of every responder
+func (f *Framework) DeliveryStatus() []DeliveryStatus {
+	var statuses []DeliveryStatus
+	for _, plugin := range f.registry.ListPluginsByType(PluginTypeResponder) {
+		status := f.deliveries.status(plugin.Name())
+		f.receipts.fill(&status)
+		if breaker := f.breakers.get(plugin.Name()); breaker != nil {
+			status.Breaker = breaker.GetState()
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Responder < statuses[j].Responder })
+	return statuses
+}
+
+// deliveryWorker periodically checks destinations and retries queued deliveries
+func (f *Framework) deliveryWorker(ctx context.Context) {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(f.config.Delivery.RetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Delivery worker stopping due to context cancellation")
+			return
+		case now := <-ticker.C:
+			f.retryDeliveries(ctx, now)
+		}
+	}
+}
+
+// retryDeliveries checks every responder's destination, then retries queued deliveries
+// oldest first, stopping at the first one that still fails
+func (f *Framework) retryDeliveries(ctx context.Context, now time.Time) {
+	f.dropExpiredDeliveries(now)
+
+	for _, plugin := range f.registry.ListPluginsByType(PluginTypeResponder) {
+		if checker, ok := plugin.(DestinationChecker); ok {
+			err := checker.CheckDestination(ctx)
+			if err != nil && !f.deliveries.unreachable(plugin.Name()) {
+				slog.Warn("Responder destination unreachable", "responder", plugin.Name(), "error", err)
+			}
+			f.deliveries.setReachable(plugin.Name(), err)
+		}
+		f.deliveries.halfOpen(plugin.Name())
+	}
+
+	for name, deliveries := range f.deliveries.queued() {
+		plugin, err := f.registry.GetPlugin(name)
+		if err != nil {
+			continue
+		}
+		responder, ok := plugin.(DataResponder)
+		if !ok || (f.config.ReadOnly && !isReadOnlyResponder(responder)) {
+			continue
+		}
+
+		for i := 0; i < len(deliveries); {
+			delivery := deliveries[i]
+			traceID := delivery.TraceID
+			if traceID == "" {
+				traceID = NewTraceID()
+			}
+			deliveryCtx := WithTraceID(ctx, traceID)
+
+			target := f.deliveryTarget(deliveryCtx, responder, delivery.Analysis)
+			if target == responder && f.deliveries.unreachable(name) {
+				// Keep the queue until the destination is back
+				break
+			}
+			err := f.attemptDelivery(deliveryCtx, target, delivery.Analysis, delivery.Attempts+1)
+			if err == nil {
+				f.deliveries.remove(delivery)
+				i++
+				continue
+			}
+			f.deliveries.retried(delivery, err)
+			// Retry this delivery on the failover if the failure just failed the responder over
+			if target == responder && f.failoverTarget(responder, delivery.Analysis) != nil {
+				continue
+			}
+			break
+		}
+	}
+}
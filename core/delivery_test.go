@@ -0,0 +1,240 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type flakyResponder struct {
+	severityResponder
+	failing     bool
+	unreachable bool
+	attempts    int
+}
+
+func (r *flakyResponder) Respond(ctx context.Context, analysis *Analysis) error {
+	r.attempts++
+	if r.failing {
+		return fmt.Errorf("connection refused")
+	}
+	return r.severityResponder.Respond(ctx, analysis)
+}
+
+func (r *flakyResponder) CheckDestination(ctx context.Context) error {
+	if r.unreachable {
+		return fmt.Errorf("no route to host")
+	}
+	return nil
+}
+
+func newFlakyResponder(name string) *flakyResponder {
+	return &flakyResponder{severityResponder: severityResponder{MockPlugin: MockPlugin{name: name, pluginType: PluginTypeResponder}, severity: "high"}}
+}
+
+func deliveryFramework(t *testing.T, delivery DeliveryConfig, responders ...DataResponder) *Framework {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout", Delivery: delivery})
+	for _, responder := range responders {
+		require.NoError(t, framework.LoadPlugin(responder))
+	}
+	return framework
+}
+
+func TestFramework_DeliveryQueueRetries(t *testing.T) {
+	slack := newFlakyResponder("slack")
+	slack.failing = true
+	framework := deliveryFramework(t, DeliveryConfig{QueueSize: 2, MaxAge: time.Hour}, slack)
+
+	ctx := context.Background()
+	for _, id := range []string{"a1", "a2", "a3"} {
+		framework.respond(ctx, &Analysis{ID: id, Severity: "high"}, nil)
+	}
+	status := framework.DeliveryStatus()
+	require.Len(t, status, 1)
+	assert.Equal(t, 2, status[0].Queued, "Expected the oldest delivery to be dropped when the queue is full")
+	assert.Equal(t, 3, status[0].ConsecutiveFailures)
+	assert.Equal(t, "connection refused", status[0].LastError)
+
+	// Queued deliveries survive a restart
+	restored, err := newDeliveryQueue(ctx, framework.store, framework.config.Delivery)
+	require.NoError(t, err)
+	queued := restored.queued()["slack"]
+	require.Len(t, queued, 2)
+	assert.Equal(t, "a2", queued[0].Analysis.ID)
+	assert.Equal(t, "a3", queued[1].Analysis.ID)
+	framework.deliveries = restored
+
+	framework.retryDeliveries(ctx, time.Now())
+	assert.Equal(t, 4, slack.attempts, "Expected retries to stop at the first delivery that still fails")
+	assert.Equal(t, 2, restored.queued()["slack"][0].Attempts)
+
+	slack.failing = false
+	framework.retryDeliveries(ctx, time.Now())
+	require.Len(t, slack.handled, 2)
+	assert.Equal(t, "a2", slack.handled[0].ID)
+	assert.Equal(t, "a3", slack.handled[1].ID)
+	assert.Empty(t, restored.queued())
+	records, err := framework.store.List(ctx, StoreCollectionDeliveries)
+	require.NoError(t, err)
+	assert.Empty(t, records, "Expected delivered entries to be removed from the store")
+
+	// Deliveries that could not be sent in time are dropped
+	slack.failing = true
+	framework.respond(ctx, &Analysis{ID: "a4", Severity: "high"}, nil)
+	framework.retryDeliveries(ctx, time.Now().Add(2*time.Hour))
+	assert.Empty(t, restored.queued())
+}
+
+func TestFramework_DeliveryFailover(t *testing.T) {
+	pager := newFlakyResponder("pager")
+	backup := newFlakyResponder("backup")
+	framework := deliveryFramework(t, DeliveryConfig{
+		QueueSize:     10,
+		FailoverAfter: 2,
+		Failover:      map[string]string{"pager": "backup"},
+	}, pager, backup)
+
+	ctx := context.Background()
+	pager.failing = true
+	framework.respond(ctx, &Analysis{ID: "a1", Severity: "high"}, []string{"pager"})
+	assert.Empty(t, backup.handled, "Expected a single failure to be retried, not failed over")
+	assert.Equal(t, 1, framework.deliveries.status("pager").Queued)
+
+	framework.respond(ctx, &Analysis{ID: "a2", Severity: "high"}, []string{"pager"})
+	require.Len(t, backup.handled, 1, "Expected the failure reaching failover_after to go to the failover")
+	assert.Equal(t, "a2", backup.handled[0].ID)
+	assert.True(t, framework.deliveries.status("pager").FailingOver)
+
+	framework.respond(ctx, &Analysis{ID: "a3", Severity: "high"}, []string{"pager"})
+	assert.Equal(t, 2, pager.attempts, "Expected a failed-over responder not to be called")
+	assert.Len(t, backup.handled, 2)
+
+	// The retry round tries the pager once, then hands its queue to the failover
+	framework.retryDeliveries(ctx, time.Now())
+	assert.Equal(t, 3, pager.attempts)
+	require.Len(t, backup.handled, 3)
+	assert.Equal(t, "a1", backup.handled[2].ID)
+	assert.Empty(t, framework.deliveries.queued())
+
+	// Once the pager works again the next round takes it back
+	pager.failing = false
+	framework.retryDeliveries(ctx, time.Now())
+	framework.respond(ctx, &Analysis{ID: "a4", Severity: "high"}, []string{"pager"})
+	require.Len(t, pager.handled, 1)
+	assert.False(t, framework.deliveries.status("pager").FailingOver)
+}
+
+func TestFramework_DeliveryUnreachableDestination(t *testing.T) {
+	pager := newFlakyResponder("pager")
+	backup := newFlakyResponder("backup")
+	framework := deliveryFramework(t, DeliveryConfig{
+		QueueSize:     10,
+		FailoverAfter: 3,
+		Failover:      map[string]string{"pager": "backup"},
+	}, pager, backup)
+
+	ctx := context.Background()
+	pager.unreachable = true
+	framework.retryDeliveries(ctx, time.Now())
+	status := framework.deliveries.status("pager")
+	assert.False(t, status.Reachable)
+	assert.True(t, status.FailingOver, "Expected an unreachable destination to fail over before any delivery fails")
+
+	framework.respond(ctx, &Analysis{ID: "a1", Severity: "high"}, []string{"pager"})
+	assert.Zero(t, pager.attempts)
+	assert.Len(t, backup.handled, 1)
+
+	pager.unreachable = false
+	framework.retryDeliveries(ctx, time.Now())
+	framework.respond(ctx, &Analysis{ID: "a2", Severity: "high"}, []string{"pager"})
+	assert.Len(t, pager.handled, 1)
+}
+
+func TestFramework_DeliveryReceipts(t *testing.T) {
+	pager := newFlakyResponder("pager")
+	pager.failing = true
+	framework := deliveryFramework(t, DeliveryConfig{QueueSize: 10}, pager)
+
+	ctx := context.Background()
+	framework.handleAnalysis(ctx, "errors", &Analysis{Type: AnalysisTypeAnomaly, Severity: "high", Summary: "Error rate 12%",
+		Timestamp: time.Now().Add(-2 * time.Second)})
+	framework.retryDeliveries(ctx, time.Now())
+	pager.failing = false
+	framework.retryDeliveries(ctx, time.Now())
+	require.Len(t, pager.handled, 1)
+
+	status := framework.DeliveryStatus()
+	require.Len(t, status, 1)
+	assert.Equal(t, int64(3), status[0].Attempts)
+	assert.Equal(t, int64(1), status[0].Delivered)
+	assert.Equal(t, int64(2), status[0].Failed)
+	assert.GreaterOrEqual(t, status[0].AverageLatencyMS, int64(2000), "Expected latency to count from the analysis's creation")
+
+	incidents := framework.incidents.List()
+	require.Len(t, incidents, 1)
+	var events []string
+	for _, event := range incidents[0].Timeline {
+		events = append(events, event.Type)
+	}
+	assert.Equal(t, []string{"opened", "delivery_failed", "delivered"}, events, "Expected only the first failure on the timeline")
+	delivered := incidents[0].Timeline[2]
+	assert.Equal(t, "pager", delivered.Actor)
+	assert.Contains(t, delivered.Message, "after detection on attempt 3")
+
+	var buf bytes.Buffer
+	require.NoError(t, framework.WriteMetrics(&buf))
+	metrics := buf.String()
+	assert.Contains(t, metrics, `framework_delivery_attempts_total{responder="pager"} 3`)
+	assert.Contains(t, metrics, `framework_delivery_success_total{responder="pager"} 1`)
+	assert.Contains(t, metrics, `framework_delivery_failures_total{responder="pager"} 2`)
+	assert.Contains(t, metrics, `framework_delivery_latency_seconds_bucket{responder="pager",le="1"} 0`)
+	assert.Contains(t, metrics, `framework_delivery_latency_seconds_bucket{responder="pager",le="2.5"} 1`)
+	assert.Contains(t, metrics, `framework_delivery_latency_seconds_count{responder="pager"} 1`)
+	assert.Contains(t, metrics, `framework_delivery_queued{responder="pager"} 0`)
+	assert.Contains(t, metrics, `framework_responder_failures_total{responder="pager"} 2`)
+}
+
+type hangingResponder struct {
+	severityResponder
+}
+
+func (h *hangingResponder) Respond(ctx context.Context, analysis *Analysis) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestFramework_DeliveryRetryAndCircuitBreaker(t *testing.T) {
+	pager := newFlakyResponder("pager")
+	pager.failing = true
+	hanging := &hangingResponder{severityResponder{MockPlugin: MockPlugin{name: "hanging", pluginType: PluginTypeResponder}, severity: "high"}}
+	framework := deliveryFramework(t, DeliveryConfig{
+		Timeout:             20 * time.Millisecond,
+		MaxAttempts:         3,
+		RetryBackoff:        time.Millisecond,
+		BreakerThreshold:    3,
+		BreakerResetTimeout: time.Hour,
+		QueueSize:           10,
+	}, pager, hanging)
+
+	ctx := context.Background()
+	framework.respond(ctx, &Analysis{ID: "a1", Severity: "high"}, []string{"pager"})
+	assert.Equal(t, 3, pager.attempts, "Expected failed calls to be retried")
+	assert.Equal(t, 1, framework.deliveries.status("pager").Queued)
+
+	statuses := framework.DeliveryStatus()
+	assert.Equal(t, CircuitOpen, statuses[1].Breaker, "Expected the failures to open the pager's breaker")
+
+	framework.respond(ctx, &Analysis{ID: "a2", Severity: "high"}, []string{"pager"})
+	assert.Equal(t, 3, pager.attempts, "Expected an open breaker to fail without calling the responder")
+	assert.Equal(t, 2, framework.deliveries.status("pager").Queued)
+
+	start := time.Now()
+	framework.respond(ctx, &Analysis{ID: "a3", Severity: "high"}, []string{"hanging"})
+	assert.Less(t, time.Since(start), time.Second, "Expected the delivery timeout to stop hanging calls")
+	assert.Equal(t, "context deadline exceeded", framework.deliveries.status("hanging").LastError)
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Service discovery errors
+var (
+	// ErrServiceNotFound is returned for a service instance the backend does not know
+	ErrServiceNotFound = errors.New("service not found")
+	// ErrRegistrationNotSupported is returned by backends that only discover services
+	ErrRegistrationNotSupported = errors.New("service registration not supported by this discovery backend")
+)
+
+// ServiceDiscoveryAware is implemented by plugins that resolve their targets from the
+// framework's service discovery backend
+type ServiceDiscoveryAware interface {
+	SetServiceDiscovery(discovery ServiceDiscovery)
+}
+
+// newServiceDiscovery returns the backend selected by the config, wrapped so lookups are
+// reused for the refresh interval, or nil if none is configured
+func newServiceDiscovery(config DiscoveryConfig, metricsCollector MetricsCollector) ServiceDiscovery {
+	var backend ServiceDiscovery
+	var name string
+	switch {
+	case config.Consul != nil:
+		backend, name = newConsulDiscovery(*config.Consul, config.Timeout), "consul"
+	case config.Kubernetes != nil:
+		backend, name = newKubernetesDiscovery(*config.Kubernetes, config.Timeout), "kubernetes"
+	default:
+		return nil
+	}
+	return &cachedDiscovery{
+		ServiceDiscovery: backend,
+		backend:          name,
+		refresh:          config.RefreshInterval,
+		metricsCollector: metricsCollector,
+		now:              time.Now,
+		lookups:          make(map[string]discoveryLookup),
+	}
+}
+
+// discoveryLookup is the outcome of the last successful lookup of a service
+type discoveryLookup struct {
+	instances []ServiceInfo
+	at        time.Time
+}
+
+// cachedDiscovery reuses the instances found for a service for the refresh interval, so
+// several collectors resolving the same service make one request. A failed lookup is
+// counted and returned; the next call tries again.
+type cachedDiscovery struct {
+	ServiceDiscovery
+	backend          string
+	refresh          time.Duration
+	metricsCollector MetricsCollector
+	now              func() time.Time
+
+	mu      sync.Mutex
+	lookups map[string]discoveryLookup
+}
+
+// DiscoverServices returns the instances of a service sorted by ID
+func (c *cachedDiscovery) DiscoverServices(serviceType string) ([]ServiceInfo, error) {
+	c.mu.Lock()
+	lookup, ok := c.lookups[serviceType]
+	c.mu.Unlock()
+	if ok && c.now().Sub(lookup.at) < c.refresh {
+		return lookup.instances, nil
+	}
+
+	instances, err := c.ServiceDiscovery.DiscoverServices(serviceType)
+	labels := map[string]string{"backend": c.backend, "service": serviceType, "result": "success"}
+	if err != nil {
+		labels["result"] = "error"
+		c.metricsCollector.IncrementCounter("framework_discovery_lookups_total", labels)
+		return nil, err
+	}
+	c.metricsCollector.IncrementCounter("framework_discovery_lookups_total", labels)
+	sort.Slice(instances, func(i, j int) bool { return instances[i].ID < instances[j].ID })
+	c.metricsCollector.SetGauge("framework_discovery_instances", float64(len(instances)),
+		map[string]string{"backend": c.backend, "service": serviceType})
+
+	if ok && !sameInstances(lookup.instances, instances) {
+		slog.Info("Discovered service instances changed", "backend", c.backend, "service", serviceType,
+			"previous", len(lookup.instances), "instances", len(instances))
+	}
+	c.mu.Lock()
+	c.lookups[serviceType] = discoveryLookup{instances: instances, at: c.now()}
+	c.mu.Unlock()
+	return instances, nil
+}
+
+// sameInstances reports whether two sorted lookups found the same instances at the same
+// addresses
+func sameInstances(a, b []ServiceInfo) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID || a[i].Address != b[i].Address || a[i].Port != b[i].Port {
+			return false
+		}
+	}
+	return true
+}
+
+// ServiceDiscovery returns the framework's service discovery backend, or nil if none is
+// configured
+func (f *Framework) ServiceDiscovery() ServiceDiscovery {
+	return f.discovery
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingDiscovery returns fixed instances and counts its lookups
+type countingDiscovery struct {
+	ServiceDiscovery
+	instances []ServiceInfo
+	err       error
+	lookups   int
+}
+
+func (c *countingDiscovery) DiscoverServices(string) ([]ServiceInfo, error) {
+	c.lookups++
+	return c.instances, c.err
+}
+
+func TestConsulDiscovery(t *testing.T) {
+	var registered map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Consul-Token"))
+		switch r.URL.Path {
+		case "/v1/health/service/prometheus":
+			assert.Equal(t, "true", r.URL.Query().Get("passing"))
+			assert.Equal(t, "eu-1", r.URL.Query().Get("dc"))
+			w.Write([]byte(`[
+				{"Node": {"Node": "node-a", "Address": "10.0.0.1"},
+				 "Service": {"ID": "prom-a", "Service": "prometheus", "Port": 9090, "Tags": ["primary"], "Meta": {"cluster": "east"}}},
+				{"Node": {"Node": "node-b", "Address": "10.0.0.2"},
+				 "Service": {"ID": "prom-b", "Service": "prometheus", "Address": "10.1.0.2", "Port": 9091}}
+			]`))
+		case "/v1/agent/service/prom-a":
+			w.Write([]byte(`{"ID": "prom-a", "Service": "prometheus", "Address": "10.0.0.1", "Port": 9090}`))
+		case "/v1/agent/service/register":
+			assert.Equal(t, http.MethodPut, r.Method)
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&registered))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	discovery := newConsulDiscovery(ConsulDiscoveryConfig{Address: server.URL, Token: "secret", Datacenter: "eu-1"}, time.Second)
+	instances, err := discovery.DiscoverServices("prometheus")
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+	assert.Equal(t, ServiceInfo{
+		ID: "prom-a", Name: "prometheus", Type: "prometheus", Address: "10.0.0.1", Port: 9090, HealthCheck: "passing",
+		Metadata: map[string]string{"cluster": "east", "node": "node-a"}, Tags: []string{"primary"},
+	}, instances[0])
+	assert.Equal(t, "10.1.0.2", instances[1].Address, "Expected the service address over the node's")
+
+	instance, err := discovery.GetService("prom-a")
+	require.NoError(t, err)
+	assert.Equal(t, 9090, instance.Port)
+	_, err = discovery.GetService("missing")
+	assert.ErrorIs(t, err, ErrServiceNotFound)
+
+	require.NoError(t, discovery.RegisterService(ServiceInfo{ID: "agent-1", Type: "agent", Address: "10.0.0.5", Port: 8080, HealthCheck: "http://10.0.0.5:8080/health"}))
+	assert.Equal(t, "agent", registered["Name"])
+	assert.Equal(t, map[string]interface{}{"HTTP": "http://10.0.0.5:8080/health", "Interval": "10s"}, registered["Check"])
+}
+
+func TestKubernetesDiscovery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token-1", r.Header.Get("Authorization"))
+		if r.URL.Path != "/apis/discovery.k8s.io/v1/namespaces/monitoring/endpointslices" {
+			http.NotFound(w, r)
+			return
+		}
+		assert.Equal(t, "kubernetes.io/service-name=payments", r.URL.Query().Get("labelSelector"))
+		w.Write([]byte(`{"items": [{
+			"endpoints": [
+				{"addresses": ["10.2.0.1"], "conditions": {"ready": true}, "nodeName": "node-a", "targetRef": {"kind": "Pod", "name": "payments-abc"}},
+				{"addresses": ["10.2.0.2"], "conditions": {"ready": false}, "targetRef": {"kind": "Pod", "name": "payments-def"}},
+				{"addresses": ["10.2.0.3"]}
+			],
+			"ports": [{"name": "grpc", "port": 9000}, {"name": "metrics", "port": 9100}]
+		}]}`))
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("token-1\n"), 0o600))
+	discovery := newKubernetesDiscovery(KubernetesDiscoveryConfig{APIServer: server.URL, Namespace: "default", TokenFile: tokenFile}, time.Second)
+
+	instances, err := discovery.DiscoverServices("monitoring/payments:grpc")
+	require.NoError(t, err)
+	require.Len(t, instances, 2, "Expected the ready endpoints' grpc ports")
+	assert.Equal(t, "monitoring/payments/payments-abc:9000", instances[0].ID)
+	assert.Equal(t, map[string]string{"namespace": "monitoring", "service": "payments", "port_name": "grpc", "pod": "payments-abc", "node": "node-a"}, instances[0].Metadata)
+	assert.Equal(t, "monitoring/payments/10.2.0.3:9000", instances[1].ID, "Expected endpoints without a ready condition to be ready")
+
+	all, err := discovery.DiscoverServices("monitoring/payments")
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+
+	instance, err := discovery.GetService("monitoring/payments/payments-abc:9100")
+	require.NoError(t, err)
+	assert.Equal(t, "10.2.0.1", instance.Address)
+	_, err = discovery.GetService("monitoring/payments/payments-def:9000")
+	assert.ErrorIs(t, err, ErrServiceNotFound)
+	assert.ErrorIs(t, discovery.RegisterService(ServiceInfo{ID: "x"}), ErrRegistrationNotSupported)
+}
+
+func TestKubernetesDiscovery_OutsideCluster(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	discovery := newKubernetesDiscovery(KubernetesDiscoveryConfig{}, time.Second)
+	_, err := discovery.DiscoverServices("payments")
+	assert.ErrorContains(t, err, "not running in a cluster")
+}
+
+func TestCachedDiscovery(t *testing.T) {
+	backend := &countingDiscovery{instances: []ServiceInfo{{ID: "b", Port: 2}, {ID: "a", Port: 1}}}
+	metrics := NewPrometheusMetricsCollector()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	discovery := &cachedDiscovery{
+		ServiceDiscovery: backend,
+		backend:          "consul",
+		refresh:          30 * time.Second,
+		metricsCollector: metrics,
+		now:              func() time.Time { return now },
+		lookups:          make(map[string]discoveryLookup),
+	}
+
+	instances, err := discovery.DiscoverServices("api")
+	require.NoError(t, err)
+	assert.Equal(t, "a", instances[0].ID, "Expected instances sorted by ID")
+	_, err = discovery.DiscoverServices("api")
+	require.NoError(t, err)
+	assert.Equal(t, 1, backend.lookups, "Expected lookups to be reused within the refresh interval")
+
+	now = now.Add(30 * time.Second)
+	backend.err = assert.AnError
+	_, err = discovery.DiscoverServices("api")
+	assert.ErrorIs(t, err, assert.AnError)
+	_, err = discovery.DiscoverServices("api")
+	assert.Error(t, err, "Expected a failed lookup to be retried")
+	assert.Equal(t, 3, backend.lookups)
+}
+
+func TestValidateFrameworkConfig_OneDiscoveryBackend(t *testing.T) {
+	config := reloadConfig()
+	config.Discovery = DiscoveryConfig{Consul: &ConsulDiscoveryConfig{}, Kubernetes: &KubernetesDiscoveryConfig{}}
+	assert.ErrorContains(t, ValidateFrameworkConfig(config), "not both")
+	config.Discovery.Kubernetes = nil
+	assert.NoError(t, ValidateFrameworkConfig(config))
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// defaultShutdownTimeout bounds Stop when no shutdown timeout is configured
+const defaultShutdownTimeout = 30 * time.Second
+
+// shutdownGrace is how long workers get to return once their context is canceled, even
+// when draining used up the shutdown timeout
+const shutdownGrace = time.Second
+
+// shutdownTimeout returns how long Stop may take to drain the pipeline and stop workers
+func (f *Framework) shutdownTimeout() time.Duration {
+	if f.config.ShutdownTimeout <= 0 {
+		return defaultShutdownTimeout
+	}
+	return f.config.ShutdownTimeout
+}
+
+// drain lets collected data reach analyzers and responders before they stop. It waits for
+// the collector workers, whose contexts the caller canceled, stops the collectors, and then
+// has the data processors finish the batches in the data channel. It reports whether the
+// pipeline drained before the deadline.
+func (f *Framework) drain(deadline time.Time) bool {
+	if !waitUntil(&f.collectors, deadline) {
+		slog.Warn("Timeout waiting for collectors to stop")
+	}
+	for _, plugin := range f.registry.ListPluginsByType(PluginTypeCollector) {
+		if err := plugin.Stop(); err != nil {
+			slog.Error("Failed to stop plugin", "plugin", plugin.Name(), "error", err)
+		}
+	}
+
+	slog.Info("Draining data channel...", "batches", len(f.dataChannel))
+	close(f.draining)
+	if !waitUntil(&f.processors, deadline) {
+		slog.Warn("Timeout draining data channel", "batches_left", len(f.dataChannel))
+		return false
+	}
+	slog.Info("Data channel drained")
+	return true
+}
+
+// drainDataChannel processes batches until the data channel is empty or the context ends
+func (f *Framework) drainDataChannel(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case data := <-f.dataChannel:
+			f.processData(ctx, data)
+		default:
+			return
+		}
+	}
+}
+
+// keepUndrained saves the batches still in the data channel after the shutdown timeout:
+// they are spilled under the spill policy, and otherwise left to the WAL, if any, to
+// replay on the next start
+func (f *Framework) keepUndrained() {
+	left := 0
+	for {
+		select {
+		case data := <-f.dataChannel:
+			left++
+			if f.overflowPolicy() != OverflowSpill {
+				continue
+			}
+			if err := f.backpressure.spill(data); err != nil {
+				slog.Error("Failed to spill batch on shutdown", "error", err)
+				continue
+			}
+			f.ackBatch(data)
+		default:
+			if left > 0 {
+				slog.Warn("Batches left unprocessed at shutdown", "batches", left,
+					"policy", f.overflowPolicy(), "wal", f.wal != nil)
+			}
+			return
+		}
+	}
+}
+
+// waitUntil waits for the wait group until the deadline, reporting whether it finished
+func waitUntil(wg *sync.WaitGroup, deadline time.Time) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
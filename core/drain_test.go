@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stopRecorder records the order plugins are stopped in
+type stopRecorder struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (r *stopRecorder) stopped(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names = append(r.names, name)
+}
+
+type orderedCollector struct {
+	MockCollector
+	stops *stopRecorder
+}
+
+func (c *orderedCollector) Stop() error {
+	c.stops.stopped(c.name)
+	return c.MockCollector.Stop()
+}
+
+// slowResponder takes a while over each analysis and records when it is stopped
+type slowResponder struct {
+	severityResponder
+	stops *stopRecorder
+}
+
+func (s *slowResponder) Respond(ctx context.Context, analysis *Analysis) error {
+	time.Sleep(20 * time.Millisecond)
+	return s.severityResponder.Respond(ctx, analysis)
+}
+
+func (s *slowResponder) Stop() error {
+	s.stops.stopped(s.name)
+	return s.severityResponder.Stop()
+}
+
+func TestFramework_StopDrainsDataChannel(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{
+		LogLevel: "info", LogFormat: "text", LogOutput: "stdout",
+		DataChannelSize: 10, WorkerPoolSize: 1, ShutdownTimeout: 5 * time.Second,
+	})
+	stops := &stopRecorder{}
+	responder := &slowResponder{
+		severityResponder: severityResponder{MockPlugin: MockPlugin{name: "log", pluginType: PluginTypeResponder}, severity: "low"},
+		stops:             stops,
+	}
+	collector := &orderedCollector{
+		MockCollector: MockCollector{MockPlugin: MockPlugin{name: "metrics", pluginType: PluginTypeCollector}, interval: time.Hour},
+		stops:         stops,
+	}
+	require.NoError(t, framework.LoadPlugin(&reportingAnalyzer{MockAnalyzer{MockPlugin{name: "spikes", pluginType: PluginTypeAnalyzer}}}))
+	require.NoError(t, framework.LoadPlugin(responder))
+	require.NoError(t, framework.LoadPlugin(collector))
+	require.NoError(t, framework.Start(context.Background()))
+
+	for i := 0; i < 5; i++ {
+		framework.GetDataChannel() <- []DataPoint{{Metric: "cpu", Value: float64(i)}}
+	}
+	require.NoError(t, framework.Stop())
+
+	assert.Len(t, responder.handled, 5, "Expected every queued batch to reach the responder before it stopped")
+	assert.Empty(t, framework.GetDataChannel())
+	assert.Equal(t, []string{"metrics", "log"}, stops.names, "Expected collectors to stop before responders")
+}
+
+func TestFramework_StopHonorsShutdownTimeout(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{
+		LogLevel: "info", LogFormat: "text", LogOutput: "stdout",
+		DataChannelSize: 10, WorkerPoolSize: 1, ShutdownTimeout: 100 * time.Millisecond,
+	})
+	hanging := &hangingResponder{severityResponder{MockPlugin: MockPlugin{name: "hanging", pluginType: PluginTypeResponder}, severity: "low"}}
+	require.NoError(t, framework.LoadPlugin(&reportingAnalyzer{MockAnalyzer{MockPlugin{name: "spikes", pluginType: PluginTypeAnalyzer}}}))
+	require.NoError(t, framework.LoadPlugin(hanging))
+	require.NoError(t, framework.Start(context.Background()))
+
+	for i := 0; i < 3; i++ {
+		framework.GetDataChannel() <- []DataPoint{{Metric: "cpu", Value: float64(i)}}
+	}
+	started := time.Now()
+	require.NoError(t, framework.Stop())
+	assert.Less(t, time.Since(started), 2*time.Second, "Expected Stop to give up on draining after the shutdown timeout")
+}
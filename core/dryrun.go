@@ -0,0 +1,105 @@
+package core
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxDryRunEntries bounds how many simulated actions the dry-run report remembers
+const maxDryRunEntries = 1000
+
+// SimulatedAction describes what a responder would have done with an analysis
+type SimulatedAction struct {
+	// Action names the side effect, such as post_message or create_ticket
+	Action string `json:"action"`
+	// Target is where the action would have gone, such as a channel or a URL
+	Target string `json:"target,omitempty"`
+	// Payload is the message, ticket, or command that would have been sent
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+// DryRunEntry is one action a responder would have taken in dry-run mode
+type DryRunEntry struct {
+	Time        time.Time `json:"time"`
+	TraceID     string    `json:"trace_id,omitempty"`
+	Responder   string    `json:"responder"`
+	AnalysisID  string    `json:"analysis_id"`
+	Fingerprint string    `json:"fingerprint"`
+	Source      string    `json:"source"`
+	Severity    string    `json:"severity"`
+	Summary     string    `json:"summary"`
+	SimulatedAction
+}
+
+// DryRunCount is how often a responder would have acted on a source's analyses
+type DryRunCount struct {
+	Responder string `json:"responder"`
+	Source    string `json:"source"`
+	Count     int    `json:"count"`
+}
+
+// DryRunSummary is the would-have-fired report returned by the API
+type DryRunSummary struct {
+	Since   time.Time     `json:"since"`
+	Total   int           `json:"total"`
+	Counts  []DryRunCount `json:"counts"`
+	Entries []DryRunEntry `json:"entries"`
+}
+
+// DryRunReport collects the actions responders would have taken while the framework runs
+// in dry-run mode, for tuning analyzers and routes before they are allowed to act
+type DryRunReport struct {
+	since   time.Time
+	total   int
+	counts  map[[2]string]int
+	entries []DryRunEntry
+	mu      sync.Mutex
+}
+
+// NewDryRunReport creates an empty report
+func NewDryRunReport() *DryRunReport {
+	return &DryRunReport{since: time.Now(), counts: make(map[[2]string]int)}
+}
+
+// Record adds a simulated action; only the most recent entries are kept but every action
+// is counted
+func (r *DryRunReport) Record(entry DryRunEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.total++
+	r.counts[[2]string{entry.Responder, entry.Source}]++
+	r.entries = append(r.entries, entry)
+	if len(r.entries) > maxDryRunEntries {
+		r.entries = r.entries[len(r.entries)-maxDryRunEntries:]
+	}
+}
+
+// Summary returns the counts by responder and source, busiest first, with the recent
+// entries
+func (r *DryRunReport) Summary() DryRunSummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := make([]DryRunCount, 0, len(r.counts))
+	for key, count := range r.counts {
+		counts = append(counts, DryRunCount{Responder: key[0], Source: key[1], Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		if counts[i].Responder != counts[j].Responder {
+			return counts[i].Responder < counts[j].Responder
+		}
+		return counts[i].Source < counts[j].Source
+	})
+
+	return DryRunSummary{
+		Since:   r.since,
+		Total:   r.total,
+		Counts:  counts,
+		Entries: append([]DryRunEntry{}, r.entries...),
+	}
+}
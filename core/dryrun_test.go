@@ -0,0 +1,66 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type simulatingResponder struct {
+	severityResponder
+}
+
+func (s *simulatingResponder) Simulate(analysis *Analysis) (*SimulatedAction, error) {
+	return &SimulatedAction{Action: "create_ticket", Target: "OPS", Payload: map[string]string{"title": analysis.Summary}}, nil
+}
+
+func TestFramework_DryRun(t *testing.T) {
+	config := &FrameworkConfig{
+		LogLevel:  "info",
+		LogFormat: "text",
+		LogOutput: "stdout",
+		DryRun:    true,
+		Plugins:   []PluginConfig{},
+	}
+	framework := NewFramework(config)
+
+	logger := &readOnlyResponder{severityResponder{MockPlugin: MockPlugin{name: "log", pluginType: PluginTypeResponder}, severity: "high"}}
+	tickets := &simulatingResponder{severityResponder{MockPlugin: MockPlugin{name: "tickets", pluginType: PluginTypeResponder}, severity: "high"}}
+	pager := &severityResponder{MockPlugin: MockPlugin{name: "pager", pluginType: PluginTypeResponder}, severity: "high"}
+	require.NoError(t, framework.LoadPlugin(logger))
+	require.NoError(t, framework.LoadPlugin(tickets))
+	require.NoError(t, framework.LoadPlugin(pager))
+
+	ctx := context.Background()
+	framework.handleAnalysis(ctx, "spikes", &Analysis{Type: AnalysisTypeAnomaly, Severity: "high", Summary: "cpu spike", Source: "spikes"})
+	framework.handleAnalysis(ctx, "spikes", &Analysis{Type: AnalysisTypeAnomaly, Severity: "low", Summary: "cpu wobble", Source: "spikes"})
+
+	assert.Len(t, logger.handled, 1, "Expected side-effect-free responders to run for real")
+	assert.Empty(t, tickets.handled)
+	assert.Empty(t, pager.handled)
+
+	summary := framework.GetDryRunReport().Summary()
+	assert.Equal(t, 2, summary.Total, "Expected analyses responders do not handle to be left out")
+	require.Len(t, summary.Entries, 2)
+	byResponder := map[string]DryRunEntry{}
+	for _, entry := range summary.Entries {
+		byResponder[entry.Responder] = entry
+	}
+	assert.Equal(t, "create_ticket", byResponder["tickets"].Action)
+	assert.Equal(t, map[string]string{"title": "cpu spike"}, byResponder["tickets"].Payload)
+	assert.Equal(t, "respond", byResponder["pager"].Action, "Expected a generic action for responders that cannot simulate")
+	assert.Equal(t, []DryRunCount{{Responder: "pager", Source: "spikes", Count: 1}, {Responder: "tickets", Source: "spikes", Count: 1}}, summary.Counts)
+}
+
+func TestDryRunReport_KeepsRecentEntries(t *testing.T) {
+	report := NewDryRunReport()
+	for i := 0; i < maxDryRunEntries+5; i++ {
+		report.Record(DryRunEntry{Responder: "slack", Source: "spikes"})
+	}
+	summary := report.Summary()
+	assert.Equal(t, maxDryRunEntries+5, summary.Total)
+	assert.Len(t, summary.Entries, maxDryRunEntries)
+	assert.Equal(t, maxDryRunEntries+5, summary.Counts[0].Count)
+}
@@ -0,0 +1,218 @@
+package core
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Framework event types
+const (
+	EventPluginLoaded       = "plugin_loaded"
+	EventPluginUnloaded     = "plugin_unloaded"
+	EventPluginReconfigured = "plugin_reconfigured"
+	EventFrameworkStarted   = "framework_started"
+	EventFrameworkStopped   = "framework_stopped"
+	EventAnalysisCreated    = "analysis_created"
+	EventResponderFailed    = "responder_failed"
+	EventConfigReloaded     = "config_reloaded"
+
+	// The data channel stayed full for longer than backpressure.degraded_after, and later
+	// had room again
+	EventDataChannelDegraded  = "data_channel_degraded"
+	EventDataChannelRecovered = "data_channel_recovered"
+
+	// The supervisor found a plugin failed, restarted it, saw it recover, or quarantined it
+	// after too many restarts
+	EventPluginFailed      = "plugin_failed"
+	EventPluginRestarted   = "plugin_restarted"
+	EventPluginRecovered   = "plugin_recovered"
+	EventPluginQuarantined = "plugin_quarantined"
+
+	// A remote agent registered with this coordinator, stopped sending heartbeats, or sent
+	// them again
+	EventFleetAgentRegistered = "fleet_agent_registered"
+	EventFleetAgentLost       = "fleet_agent_lost"
+	EventFleetAgentRecovered  = "fleet_agent_recovered"
+
+	// EventTypeAll subscribes a handler to every event type
+	EventTypeAll = "*"
+)
+
+// defaultEventBufferSize is the number of events buffered per subscription
+const defaultEventBufferSize = 100
+
+// EventAware is implemented by plugins that subscribe to or publish framework events. The
+// framework provides its event bus when the plugin is loaded.
+type EventAware interface {
+	SetEventBus(bus EventBus)
+}
+
+// eventSubscription delivers events to one handler from its own buffer, so a slow handler
+// only delays itself
+type eventSubscription struct {
+	id        SubscriptionID
+	eventType string
+	handler   EventHandler
+	events    chan Event
+	done      chan struct{}
+}
+
+// run calls the handler for each event until the buffer is closed and drained
+func (s *eventSubscription) run() {
+	defer close(s.done)
+	for event := range s.events {
+		if err := s.handler(event); err != nil {
+			slog.Warn("Event handler failed", "event", event.Type, "error", err)
+		}
+	}
+}
+
+// InProcessEventBus implements EventBus with asynchronous, buffered delivery to handlers
+// in the same process. Events published while a subscription's buffer is full are dropped
+// for that subscription rather than blocking the publisher.
+type InProcessEventBus struct {
+	bufferSize    int
+	subscriptions []*eventSubscription
+	// Unsubscribed handlers still finishing their queued events
+	draining []*eventSubscription
+	lastID   SubscriptionID
+	dropped  atomic.Int64
+	closed   bool
+	mu       sync.RWMutex
+}
+
+// NewInProcessEventBus creates an event bus buffering bufferSize events per subscription
+func NewInProcessEventBus(bufferSize int) *InProcessEventBus {
+	if bufferSize <= 0 {
+		bufferSize = defaultEventBufferSize
+	}
+	return &InProcessEventBus{bufferSize: bufferSize}
+}
+
+// Publish queues an event for every handler subscribed to its type
+func (b *InProcessEventBus) Publish(event Event) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return NewInternalError("eventbus", "publish", "event bus is closed")
+	}
+	for _, subscription := range b.subscriptions {
+		if subscription.eventType != event.Type && subscription.eventType != EventTypeAll {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			b.dropped.Add(1)
+			slog.Warn("Event buffer full, dropping event", "event", event.Type, "subscription", subscription.eventType)
+		}
+	}
+	return nil
+}
+
+// Dropped returns the number of events dropped because a subscription's buffer was full
+func (b *InProcessEventBus) Dropped() int64 {
+	return b.dropped.Load()
+}
+
+// Subscribe calls handler for every event of eventType, or of every type with EventTypeAll
+func (b *InProcessEventBus) Subscribe(eventType string, handler EventHandler) (SubscriptionID, error) {
+	if eventType == "" || handler == nil {
+		return 0, NewValidationError("eventbus", "subscribe", "event type and handler are required")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return 0, NewInternalError("eventbus", "subscribe", "event bus is closed")
+	}
+	b.lastID++
+	subscription := &eventSubscription{
+		id:        b.lastID,
+		eventType: eventType,
+		handler:   handler,
+		events:    make(chan Event, b.bufferSize),
+		done:      make(chan struct{}),
+	}
+	b.subscriptions = append(b.subscriptions, subscription)
+	go subscription.run()
+	return subscription.id, nil
+}
+
+// Unsubscribe removes the subscription with the given ID. Events already queued for it are
+// still delivered.
+func (b *InProcessEventBus) Unsubscribe(id SubscriptionID) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Forget unsubscribed handlers that have finished their queued events
+	draining := b.draining[:0]
+	for _, subscription := range b.draining {
+		select {
+		case <-subscription.done:
+		default:
+			draining = append(draining, subscription)
+		}
+	}
+	clear(b.draining[len(draining):])
+	b.draining = draining
+
+	for i, subscription := range b.subscriptions {
+		if subscription.id == id {
+			b.subscriptions = append(b.subscriptions[:i], b.subscriptions[i+1:]...)
+			b.draining = append(b.draining, subscription)
+			close(subscription.events)
+			return nil
+		}
+	}
+	return NewValidationError("eventbus", "unsubscribe", fmt.Sprintf("no subscription with ID %d", id))
+}
+
+// Close stops accepting events and waits for handlers to finish the events already queued
+func (b *InProcessEventBus) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	for _, subscription := range b.subscriptions {
+		close(subscription.events)
+	}
+	subscriptions := append(b.subscriptions, b.draining...)
+	b.subscriptions = nil
+	b.draining = nil
+	b.mu.Unlock()
+
+	for _, subscription := range subscriptions {
+		<-subscription.done
+	}
+	return nil
+}
+
+// publishEvent sends a framework event to live streams and, if the framework has an event
+// bus, publishes it
+func (f *Framework) publishEvent(eventType string, data map[string]interface{}) {
+	event := Event{
+		Type:      eventType,
+		Source:    "framework",
+		Timestamp: time.Now(),
+		Data:      data,
+	}
+	f.feed.publishEvent(event)
+	if f.eventBus == nil {
+		return
+	}
+	if err := f.eventBus.Publish(event); err != nil {
+		slog.Debug("Failed to publish event", "event", eventType, "error", err)
+	}
+}
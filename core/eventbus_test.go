@@ -0,0 +1,143 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInProcessEventBus_Subscribe(t *testing.T) {
+	bus := NewInProcessEventBus(10)
+
+	var mu sync.Mutex
+	var loaded, all []string
+	onLoaded := func(event Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		loaded = append(loaded, event.Data["plugin_name"].(string))
+		return nil
+	}
+	id, err := bus.Subscribe(EventPluginLoaded, onLoaded)
+	require.NoError(t, err)
+	_, err = bus.Subscribe(EventTypeAll, func(event Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		all = append(all, event.Type)
+		return errors.New("handler errors are logged, not returned")
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, bus.Publish(Event{Type: EventPluginLoaded, Data: map[string]interface{}{"plugin_name": "a"}}))
+	require.NoError(t, bus.Publish(Event{Type: EventResponderFailed}))
+	require.NoError(t, bus.Unsubscribe(id))
+	require.NoError(t, bus.Publish(Event{Type: EventPluginLoaded, Data: map[string]interface{}{"plugin_name": "b"}}))
+	require.NoError(t, bus.Close())
+
+	assert.Equal(t, []string{"a"}, loaded)
+	assert.Equal(t, []string{EventPluginLoaded, EventResponderFailed, EventPluginLoaded}, all)
+	assert.Error(t, bus.Unsubscribe(id))
+	assert.Error(t, bus.Publish(Event{Type: EventPluginLoaded}), "Expected publishing to a closed bus to fail")
+}
+
+func TestInProcessEventBus_DropsWhenFull(t *testing.T) {
+	bus := NewInProcessEventBus(1)
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	_, err := bus.Subscribe(EventAnalysisCreated, func(event Event) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, bus.Publish(Event{Type: EventAnalysisCreated}))
+	<-started
+	require.NoError(t, bus.Publish(Event{Type: EventAnalysisCreated}))
+	require.NoError(t, bus.Publish(Event{Type: EventAnalysisCreated}))
+	assert.Equal(t, int64(1), bus.Dropped(), "Expected the publisher not to block on a slow handler")
+
+	close(release)
+	require.NoError(t, bus.Close())
+}
+
+// eventCounter is a plugin whose method value is subscribed to the bus
+type eventCounter struct {
+	mu     sync.Mutex
+	events int
+}
+
+func (c *eventCounter) record(event Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events++
+	return nil
+}
+
+func TestInProcessEventBus_UnsubscribeByID(t *testing.T) {
+	bus := NewInProcessEventBus(10)
+
+	// Method values of the same method share their code, so only the ID tells them apart
+	first, second := &eventCounter{}, &eventCounter{}
+	firstID, err := bus.Subscribe(EventPluginLoaded, first.record)
+	require.NoError(t, err)
+	_, err = bus.Subscribe(EventPluginLoaded, second.record)
+	require.NoError(t, err)
+
+	require.NoError(t, bus.Publish(Event{Type: EventPluginLoaded}))
+	require.NoError(t, bus.Unsubscribe(firstID))
+	require.NoError(t, bus.Publish(Event{Type: EventPluginLoaded}))
+
+	// Unsubscribing forgets earlier subscriptions that have finished draining
+	otherID, err := bus.Subscribe(EventResponderFailed, first.record)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		select {
+		case <-bus.draining[0].done:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, bus.Unsubscribe(otherID))
+	bus.mu.RLock()
+	assert.Len(t, bus.draining, 1, "Expected only the latest unsubscribed handler to be draining")
+	bus.mu.RUnlock()
+	require.NoError(t, bus.Close())
+
+	assert.Equal(t, 1, first.events)
+	assert.Equal(t, 2, second.events)
+}
+
+type MockEventPlugin struct {
+	MockPlugin
+	bus EventBus
+}
+
+func (m *MockEventPlugin) SetEventBus(bus EventBus) { m.bus = bus }
+
+func TestFramework_EventAwarePlugin(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout", DataChannelSize: 10})
+	plugin := &MockEventPlugin{MockPlugin: MockPlugin{name: "watcher", pluginType: PluginTypeResponder}}
+	require.NoError(t, framework.LoadPlugin(plugin))
+	require.NotNil(t, plugin.bus, "Expected the framework to hand its event bus to the plugin")
+
+	events := make(chan Event, 10)
+	_, err := plugin.bus.Subscribe(EventPluginLoaded, func(event Event) error {
+		events <- event
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, framework.LoadPlugin(&MockPlugin{name: "other", pluginType: PluginTypeCollector}))
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "framework", event.Source)
+		assert.Equal(t, "other", event.Data["plugin_name"])
+	case <-time.After(time.Second):
+		t.Fatal("Expected a plugin_loaded event")
+	}
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Verdicts an analyzer can reach for a data point
+const (
+	VerdictNormal    = "normal"
+	VerdictAnomalous = "anomalous"
+	VerdictSkipped   = "skipped"
+)
+
+// defaultExplainRetention is how long verdicts are kept when no retention is configured
+const defaultExplainRetention = 6 * time.Hour
+
+// Verdict is an analyzer's judgement of a single data point together with the view it was
+// judged against, kept so "why did/didn't this alert fire?" can be answered later
+type Verdict struct {
+	Analyzer   string    `json:"analyzer"`
+	Series     string    `json:"series"`
+	Metric     string    `json:"metric"`
+	Timestamp  time.Time `json:"timestamp"`
+	Value      float64   `json:"value"`
+	WindowSize int       `json:"window_size"`
+	Mean       float64   `json:"mean"`
+	StdDev     float64   `json:"std_dev"`
+	Threshold  float64   `json:"threshold"`
+	Deviation  float64   `json:"deviation"`
+	Verdict    string    `json:"verdict"`
+	Reason     string    `json:"reason,omitempty"`
+}
+
+// VerdictReporter is implemented by analyzers that can explain their decisions. The
+// framework calls AnalyzeWithVerdicts in place of Analyze, so the verdicts it records are
+// those of the same batch even while batches are analyzed concurrently.
+type VerdictReporter interface {
+	AnalyzeWithVerdicts(data []DataPoint) (*Analysis, []Verdict, error)
+}
+
+// VerdictHistory keeps recent verdicts per analyzer and series
+type VerdictHistory struct {
+	retention time.Duration
+	verdicts  map[string][]Verdict
+	mu        sync.RWMutex
+}
+
+// NewVerdictHistory creates a history that keeps verdicts for the retention period
+func NewVerdictHistory(retention time.Duration) *VerdictHistory {
+	if retention <= 0 {
+		retention = defaultExplainRetention
+	}
+	return &VerdictHistory{
+		retention: retention,
+		verdicts:  make(map[string][]Verdict),
+	}
+}
+
+// Record stores verdicts, dropping those of the same series older than the retention
+func (h *VerdictHistory) Record(verdicts []Verdict) {
+	if h == nil || len(verdicts) == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, verdict := range verdicts {
+		key := verdict.Analyzer + "\x00" + verdict.Series
+		history := append(h.verdicts[key], verdict)
+
+		cutoff := verdict.Timestamp.Add(-h.retention)
+		drop := 0
+		for drop < len(history) && history[drop].Timestamp.Before(cutoff) {
+			drop++
+		}
+		h.verdicts[key] = history[drop:]
+	}
+}
+
+// Explain returns, for every analyzer and series of the metric, the verdict closest to the
+// given time
+func (h *VerdictHistory) Explain(metric string, at time.Time) []Verdict {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var explained []Verdict
+	for _, history := range h.verdicts {
+		if len(history) == 0 || history[0].Metric != metric {
+			continue
+		}
+		closest := history[0]
+		for _, verdict := range history[1:] {
+			if absDuration(verdict.Timestamp.Sub(at)) < absDuration(closest.Timestamp.Sub(at)) {
+				closest = verdict
+			}
+		}
+		explained = append(explained, closest)
+	}
+
+	sort.Slice(explained, func(i, j int) bool {
+		if explained[i].Analyzer != explained[j].Analyzer {
+			return explained[i].Analyzer < explained[j].Analyzer
+		}
+		return explained[i].Series < explained[j].Series
+	})
+	return explained
+}
+
+// absDuration returns the absolute value of a duration
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
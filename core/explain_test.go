@@ -0,0 +1,120 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerdictHistory_Explain(t *testing.T) {
+	history := NewVerdictHistory(time.Hour)
+	start := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 90; i++ {
+		history.Record([]Verdict{
+			{Analyzer: "anomaly", Series: `cpu{host="a"}`, Metric: "cpu", Timestamp: start.Add(time.Duration(i) * time.Minute), Value: float64(i), Verdict: VerdictNormal},
+			{Analyzer: "anomaly", Series: "memory", Metric: "memory", Timestamp: start.Add(time.Duration(i) * time.Minute), Verdict: VerdictNormal},
+		})
+	}
+
+	verdicts := history.Explain("cpu", start.Add(75*time.Minute+20*time.Second))
+	require.Len(t, verdicts, 1, "Expected only series of the requested metric")
+	assert.Equal(t, 75.0, verdicts[0].Value, "Expected the verdict closest to the requested time")
+
+	verdicts = history.Explain("cpu", start.Add(5*time.Minute))
+	require.Len(t, verdicts, 1)
+	assert.Equal(t, 29.0, verdicts[0].Value, "Expected verdicts older than the retention to be dropped")
+
+	assert.Empty(t, history.Explain("disk", start))
+}
+
+// explainingAnalyzer judges every point normal, remembering how many batches it saw
+type explainingAnalyzer struct {
+	MockAnalyzer
+	batches int
+	mu      sync.Mutex
+}
+
+func (e *explainingAnalyzer) AnalyzeWithVerdicts(data []DataPoint) (*Analysis, []Verdict, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.batches++
+	var verdicts []Verdict
+	for _, point := range data {
+		verdicts = append(verdicts, Verdict{
+			Analyzer:  e.name,
+			Series:    point.Metric,
+			Metric:    point.Metric,
+			Timestamp: point.Timestamp,
+			Value:     point.Value,
+			Verdict:   VerdictNormal,
+		})
+	}
+	return nil, verdicts, nil
+}
+
+func TestFramework_ExplainAPI(t *testing.T) {
+	config := &FrameworkConfig{
+		LogLevel:      "info",
+		LogFormat:     "text",
+		LogOutput:     "stdout",
+		MetricAliases: map[string][]string{"cpu_usage_percent": {"node_cpu_percent"}},
+		Plugins:       []PluginConfig{},
+	}
+	framework := NewFramework(config)
+	require.NoError(t, framework.LoadPlugin(&explainingAnalyzer{MockAnalyzer: MockAnalyzer{MockPlugin{name: "anomaly", pluginType: PluginTypeAnalyzer}}}))
+
+	at := time.Date(2026, 3, 2, 14, 3, 0, 0, time.UTC)
+	framework.processData(context.Background(), []DataPoint{{Metric: "node_cpu_percent", Value: 42, Timestamp: at}})
+
+	mux := http.NewServeMux()
+	framework.registerAPIRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/explain?metric=node_cpu_percent&at=2026-03-02T14:03:00Z", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response explainResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	require.Len(t, response.Verdicts, 1, "Expected aliases to resolve to the canonical metric")
+	assert.Equal(t, "cpu_usage_percent", response.Verdicts[0].Metric)
+	assert.Equal(t, 42.0, response.Verdicts[0].Value)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/explain", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestFramework_VerdictsOfConcurrentBatches(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout"})
+	analyzer := &explainingAnalyzer{MockAnalyzer: MockAnalyzer{MockPlugin{name: "anomaly", pluginType: PluginTypeAnalyzer}}}
+	require.NoError(t, framework.LoadPlugin(analyzer))
+
+	start := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	var wg sync.WaitGroup
+	for _, metric := range []string{"cpu", "disk"} {
+		wg.Add(1)
+		go func(metric string) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				framework.processData(context.Background(), []DataPoint{{Metric: metric, Value: float64(i), Timestamp: start.Add(time.Duration(i) * time.Minute)}})
+			}
+		}(metric)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 100, analyzer.batches)
+	for _, metric := range []string{"cpu", "disk"} {
+		verdicts := framework.verdicts.Explain(metric, start.Add(49*time.Minute))
+		require.Len(t, verdicts, 1, "Expected the verdicts of every %s batch to be recorded", metric)
+		assert.Equal(t, metric, verdicts[0].Metric)
+		assert.Equal(t, 49.0, verdicts[0].Value, "Expected each batch to record its own verdicts")
+	}
+}
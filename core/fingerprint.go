@@ -0,0 +1,72 @@
+package core
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AnalysisFingerprint identifies analyses describing the same condition: the same analyzer
+// and analysis type over the same series. It is stable across restarts and independent of
+// the order of data points and labels.
+func AnalysisFingerprint(analysis *Analysis) string {
+	seen := make(map[string]bool)
+	series := make([]string, 0, len(analysis.DataPoints))
+	for _, point := range analysis.DataPoints {
+		key := fingerprintSeries(point)
+		if !seen[key] {
+			seen[key] = true
+			series = append(series, key)
+		}
+	}
+	sort.Strings(series)
+
+	sum := sha256.Sum256([]byte(analysis.Source + "|" + string(analysis.Type) + "|" + strings.Join(series, ",")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// fingerprintSeries renders a series as metric{k=v,...} with sorted labels
+func fingerprintSeries(point DataPoint) string {
+	keys := make([]string, 0, len(point.Labels))
+	for k := range point.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(point.Metric)
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%q", k, point.Labels[k])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// NewAnalysisID returns a unique ID for one occurrence of an analysis. IDs sort by the
+// time they were issued.
+func NewAnalysisID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("an-%x", time.Now().UnixNano())
+	}
+	return fmt.Sprintf("an-%x-%s", time.Now().UnixMilli(), hex.EncodeToString(b))
+}
+
+// EnsureIdentity assigns the analysis a fingerprint and occurrence ID unless the analyzer
+// already set them
+func (a *Analysis) EnsureIdentity() {
+	if a.Fingerprint == "" {
+		a.Fingerprint = AnalysisFingerprint(a)
+	}
+	if a.ID == "" {
+		a.ID = NewAnalysisID()
+	}
+}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalysisFingerprint(t *testing.T) {
+	analysis := func(points ...DataPoint) *Analysis {
+		return &Analysis{Type: AnalysisTypeAnomaly, Source: "anomaly", DataPoints: points}
+	}
+	webA := DataPoint{Metric: "cpu", Value: 91, Labels: map[string]string{"host": "a", "env": "prod"}}
+	webA2 := DataPoint{Metric: "cpu", Value: 97, Labels: map[string]string{"env": "prod", "host": "a"}}
+	webB := DataPoint{Metric: "cpu", Value: 91, Labels: map[string]string{"host": "b", "env": "prod"}}
+
+	fingerprint := AnalysisFingerprint(analysis(webA, webB))
+	assert.Equal(t, fingerprint, AnalysisFingerprint(analysis(webB, webA2)),
+		"Expected values and point and label order not to change the fingerprint")
+	assert.NotEqual(t, fingerprint, AnalysisFingerprint(analysis(webA)), "Expected labels to be part of the fingerprint")
+
+	trend := analysis(webA, webB)
+	trend.Type = AnalysisTypeTrend
+	assert.NotEqual(t, fingerprint, AnalysisFingerprint(trend), "Expected the type to be part of the fingerprint")
+}
+
+func TestAnalysis_EnsureIdentity(t *testing.T) {
+	first := &Analysis{Type: AnalysisTypeAnomaly, Source: "anomaly", DataPoints: []DataPoint{{Metric: "cpu"}}}
+	second := &Analysis{Type: AnalysisTypeAnomaly, Source: "anomaly", DataPoints: []DataPoint{{Metric: "cpu"}}}
+	first.EnsureIdentity()
+	second.EnsureIdentity()
+
+	assert.Equal(t, first.Fingerprint, second.Fingerprint)
+	assert.NotEmpty(t, first.ID)
+	assert.NotEqual(t, first.ID, second.ID, "Expected each occurrence to get its own ID")
+
+	id := first.ID
+	first.EnsureIdentity()
+	assert.Equal(t, id, first.ID, "Expected existing IDs to be kept")
+}
@@ -0,0 +1,336 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultFleetHeartbeatInterval is how often remote agents send heartbeats when the
+// coordinator sets no interval
+const defaultFleetHeartbeatInterval = 10 * time.Second
+
+// FleetAgentLabel names the remote agent on the data points and analyses it forwards
+const FleetAgentLabel = "fleet_agent"
+
+// ErrUnknownRemoteAgent is returned for calls from a remote agent that has not registered,
+// or registered with a coordinator that has since restarted
+var ErrUnknownRemoteAgent = errors.New("remote agent is not registered")
+
+// Forwarder sends the data points and analyses of a remote agent on to its coordinator. Its
+// methods are called from the pipeline and must not block.
+type Forwarder interface {
+	ForwardData(data []DataPoint)
+	ForwardAnalysis(analyzer string, analysis *Analysis)
+}
+
+// RemoteAgent is a remote agent registered with this agent as its coordinator
+type RemoteAgent struct {
+	ID            string            `json:"id"`
+	Hostname      string            `json:"hostname,omitempty"`
+	Version       string            `json:"version,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	RegisteredAt  time.Time         `json:"registered_at"`
+	LastHeartbeat time.Time         `json:"last_heartbeat"`
+	Online        bool              `json:"online"`
+	// ConfigVersion is the version of the pushed configuration the agent last reported running
+	ConfigVersion string `json:"config_version,omitempty"`
+	ConfigError   string `json:"config_error,omitempty"`
+	DataPoints    int64  `json:"data_points"`
+	Analyses      int64  `json:"analyses"`
+}
+
+// RemoteAgentConfig is the configuration a coordinator pushes to a remote agent
+type RemoteAgentConfig struct {
+	// Version identifies the plugins; it is empty when the agent keeps its own plugins
+	Version           string
+	Plugins           []PluginConfig
+	HeartbeatInterval time.Duration
+}
+
+// ForwardedAnalysis is an analysis a remote agent's analyzer made
+type ForwardedAnalysis struct {
+	Analyzer string
+	Analysis *Analysis
+}
+
+// fleetCoordinator tracks the remote agents of a coordinator and the plugins pushed to them
+type fleetCoordinator struct {
+	config FleetConfig
+	now    func() time.Time
+
+	mu      sync.Mutex
+	agents  map[string]*RemoteAgent
+	plugins map[string][]PluginConfig
+}
+
+func newFleetCoordinator(config FleetConfig) *fleetCoordinator {
+	plugins := make(map[string][]PluginConfig, len(config.Agents))
+	for _, agent := range config.Agents {
+		plugins[agent.Agent] = agent.Plugins
+	}
+	return &fleetCoordinator{
+		config:  config,
+		now:     time.Now,
+		agents:  make(map[string]*RemoteAgent),
+		plugins: plugins,
+	}
+}
+
+// heartbeatInterval returns how often remote agents send heartbeats
+func (c *fleetCoordinator) heartbeatInterval() time.Duration {
+	if c.config.HeartbeatInterval <= 0 {
+		return defaultFleetHeartbeatInterval
+	}
+	return c.config.HeartbeatInterval
+}
+
+// agentTimeout returns how long a remote agent may go without a heartbeat
+func (c *fleetCoordinator) agentTimeout() time.Duration {
+	if c.config.AgentTimeout <= 0 {
+		return 3 * c.heartbeatInterval()
+	}
+	return c.config.AgentTimeout
+}
+
+// agentConfig returns the configuration pushed to a remote agent; callers must hold c.mu
+func (c *fleetCoordinator) agentConfig(id string) RemoteAgentConfig {
+	plugins, ok := c.plugins[id]
+	if !ok {
+		plugins = c.plugins["*"]
+	}
+	return RemoteAgentConfig{
+		Version:           pluginsVersion(plugins),
+		Plugins:           plugins,
+		HeartbeatInterval: c.heartbeatInterval(),
+	}
+}
+
+// pluginsVersion identifies a set of pushed plugins, so remote agents can tell when theirs
+// changed; no plugins have no version
+func pluginsVersion(plugins []PluginConfig) string {
+	if len(plugins) == 0 {
+		return ""
+	}
+	encoded, err := json.Marshal(plugins)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:8])
+}
+
+// RegisterRemoteAgent registers a remote agent with this coordinator, or registers it again
+// after it restarted, and returns the configuration pushed to it
+func (f *Framework) RegisterRemoteAgent(agent RemoteAgent) (RemoteAgentConfig, error) {
+	if agent.ID == "" {
+		return RemoteAgentConfig{}, NewValidationError("fleet", "register", "remote agent ID is required")
+	}
+
+	c := f.fleet
+	c.mu.Lock()
+	now := c.now()
+	previous, known := c.agents[agent.ID]
+	agent.RegisteredAt = now
+	agent.LastHeartbeat = now
+	agent.Online = true
+	agent.ConfigVersion = ""
+	agent.ConfigError = ""
+	if known {
+		agent.DataPoints = previous.DataPoints
+		agent.Analyses = previous.Analyses
+	}
+	c.agents[agent.ID] = &agent
+	config := c.agentConfig(agent.ID)
+	c.mu.Unlock()
+
+	slog.Info("Remote agent registered", "agent", agent.ID, "hostname", agent.Hostname, "version", agent.Version,
+		"config_version", config.Version)
+	f.publishEvent(EventFleetAgentRegistered, map[string]interface{}{
+		"agent":    agent.ID,
+		"hostname": agent.Hostname,
+		"version":  agent.Version,
+		"labels":   agent.Labels,
+	})
+	return config, nil
+}
+
+// RemoteAgentHeartbeat records a heartbeat of a remote agent running the given version of
+// its pushed configuration. It returns the agent's configuration and whether it differs
+// from the one the agent runs.
+func (f *Framework) RemoteAgentHeartbeat(id, configVersion, configError string) (RemoteAgentConfig, bool, error) {
+	c := f.fleet
+	c.mu.Lock()
+	agent, ok := c.agents[id]
+	if !ok {
+		c.mu.Unlock()
+		return RemoteAgentConfig{}, false, ErrUnknownRemoteAgent
+	}
+	agent.LastHeartbeat = c.now()
+	recovered := !agent.Online
+	agent.Online = true
+	agent.ConfigVersion = configVersion
+	agent.ConfigError = f.secrets.redact(configError)
+	redacted := agent.ConfigError
+	config := c.agentConfig(id)
+	c.mu.Unlock()
+
+	if redacted != "" {
+		slog.Warn("Remote agent failed to apply its configuration", "agent", id, "config_version", configVersion, "error", redacted)
+	}
+	if recovered {
+		slog.Info("Remote agent sends heartbeats again", "agent", id)
+		f.publishEvent(EventFleetAgentRecovered, map[string]interface{}{"agent": id})
+	}
+	return config, config.Version != configVersion, nil
+}
+
+// ForwardFromRemoteAgent hands the data points and analyses a remote agent forwarded to the
+// pipeline, labeled with the agent's ID. Analyses go to responders before it returns.
+func (f *Framework) ForwardFromRemoteAgent(ctx context.Context, id string, data []DataPoint, analyses []ForwardedAnalysis) error {
+	c := f.fleet
+	c.mu.Lock()
+	agent, ok := c.agents[id]
+	if ok {
+		agent.DataPoints += int64(len(data))
+		agent.Analyses += int64(len(analyses))
+	}
+	c.mu.Unlock()
+	if !ok {
+		return ErrUnknownRemoteAgent
+	}
+
+	for i := range data {
+		data[i].Labels = withLabel(data[i].Labels, FleetAgentLabel, id)
+	}
+	if err := f.Ingest(ctx, data); err != nil {
+		return err
+	}
+
+	f.mu.RLock()
+	shutdown := f.shutdown
+	f.mu.RUnlock()
+	if shutdown && len(analyses) > 0 {
+		return ErrShuttingDown
+	}
+	for _, forwarded := range analyses {
+		if forwarded.Analysis == nil {
+			continue
+		}
+		if forwarded.Analysis.Details == nil {
+			forwarded.Analysis.Details = make(map[string]interface{})
+		}
+		forwarded.Analysis.Details[FleetAgentLabel] = id
+		f.handleAnalysis(ctx, forwarded.Analyzer, forwarded.Analysis)
+	}
+	return nil
+}
+
+// withLabel returns labels with one label set, copying them so the sender's map is kept
+func withLabel(labels map[string]string, name, value string) map[string]string {
+	labeled := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		labeled[k] = v
+	}
+	labeled[name] = value
+	return labeled
+}
+
+// RemoteAgents returns the remote agents registered with this coordinator, sorted by ID
+func (f *Framework) RemoteAgents() []RemoteAgent {
+	c := f.fleet
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	agents := make([]RemoteAgent, 0, len(c.agents))
+	for _, agent := range c.agents {
+		agents = append(agents, *agent)
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].ID < agents[j].ID })
+	return agents
+}
+
+// SetRemoteAgentPlugins changes the plugins pushed to a remote agent, or with "*" to remote
+// agents without their own. Agents get them with their next heartbeat; no plugins leave an
+// agent with the plugins of its own configuration.
+func (f *Framework) SetRemoteAgentPlugins(id string, plugins []PluginConfig) (RemoteAgentConfig, error) {
+	if id == "" {
+		return RemoteAgentConfig{}, NewValidationError("fleet", "configure", "remote agent ID is required")
+	}
+	for i := range plugins {
+		if plugins[i].Name == "" || plugins[i].Type == "" {
+			return RemoteAgentConfig{}, NewValidationError("fleet", "configure", "plugins need a name and a type")
+		}
+	}
+
+	c := f.fleet
+	c.mu.Lock()
+	if len(plugins) == 0 {
+		delete(c.plugins, id)
+	} else {
+		c.plugins[id] = plugins
+	}
+	config := c.agentConfig(id)
+	c.mu.Unlock()
+
+	slog.Info("Remote agent configuration changed", "agent", id, "plugins", len(plugins), "config_version", config.Version)
+	return config, nil
+}
+
+// FleetConfig returns the framework's fleet settings
+func (f *Framework) FleetConfig() FleetConfig {
+	return f.config.Fleet
+}
+
+// SetForwarder makes the framework a remote agent sending what it collects and analyzes on
+// through the forwarder. It must be called before Start.
+func (f *Framework) SetForwarder(forwarder Forwarder) {
+	f.forwarder = forwarder
+}
+
+// fleetWorker reports remote agents that stopped sending heartbeats
+func (f *Framework) fleetWorker(ctx context.Context) {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(f.fleet.heartbeatInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			f.checkRemoteAgents()
+		}
+	}
+}
+
+// checkRemoteAgents marks remote agents without a recent heartbeat as lost
+func (f *Framework) checkRemoteAgents() {
+	c := f.fleet
+	c.mu.Lock()
+	now := c.now()
+	var lost []RemoteAgent
+	for _, agent := range c.agents {
+		if agent.Online && now.Sub(agent.LastHeartbeat) > c.agentTimeout() {
+			agent.Online = false
+			lost = append(lost, *agent)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, agent := range lost {
+		slog.Warn("Remote agent stopped sending heartbeats", "agent", agent.ID, "last_heartbeat", agent.LastHeartbeat)
+		f.publishEvent(EventFleetAgentLost, map[string]interface{}{
+			"agent":          agent.ID,
+			"hostname":       agent.Hostname,
+			"last_heartbeat": agent.LastHeartbeat,
+		})
+	}
+}
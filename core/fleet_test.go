@@ -0,0 +1,188 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingForwarder records what a remote agent forwards
+type recordingForwarder struct {
+	data     chan []DataPoint
+	analyses chan string
+}
+
+func (r *recordingForwarder) ForwardData(data []DataPoint) {
+	r.data <- data
+}
+
+func (r *recordingForwarder) ForwardAnalysis(analyzer string, analysis *Analysis) {
+	r.analyses <- analyzer
+}
+
+func newFleetFramework(fleet FleetConfig) *Framework {
+	return NewFramework(&FrameworkConfig{
+		LogLevel: "info", LogFormat: "text", LogOutput: "stdout",
+		DataChannelSize: 10,
+		Fleet:           fleet,
+	})
+}
+
+func TestFramework_RemoteAgentConfiguration(t *testing.T) {
+	framework := newFleetFramework(FleetConfig{
+		HeartbeatInterval: 5 * time.Second,
+		Agents: []FleetAgentConfig{
+			{Agent: "*", Plugins: []PluginConfig{{Name: "cpu", Type: "collector", Enabled: true}}},
+		},
+	})
+
+	_, err := framework.RegisterRemoteAgent(RemoteAgent{})
+	assert.Error(t, err, "Expected an agent ID to be required")
+	_, _, err = framework.RemoteAgentHeartbeat("web-1", "", "")
+	assert.ErrorIs(t, err, ErrUnknownRemoteAgent)
+
+	config, err := framework.RegisterRemoteAgent(RemoteAgent{ID: "web-1", Hostname: "web-1.internal", Version: "1.2.0"})
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, config.HeartbeatInterval)
+	require.Len(t, config.Plugins, 1, "Expected the plugins pushed to all agents")
+	assert.NotEmpty(t, config.Version)
+
+	_, changed, err := framework.RemoteAgentHeartbeat("web-1", config.Version, "")
+	require.NoError(t, err)
+	assert.False(t, changed, "Expected no change while the agent runs its configuration")
+
+	// The agent's own plugins replace those pushed to all agents
+	pushed, err := framework.SetRemoteAgentPlugins("web-1", []PluginConfig{
+		{Name: "cpu", Type: "collector", Enabled: true},
+		{Name: "disk", Type: "collector", Enabled: true},
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, config.Version, pushed.Version)
+	next, changed, err := framework.RemoteAgentHeartbeat("web-1", config.Version, "")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Len(t, next.Plugins, 2)
+
+	_, _, err = framework.RemoteAgentHeartbeat("web-1", next.Version, "plugin disk: unknown type")
+	require.NoError(t, err)
+	agents := framework.RemoteAgents()
+	require.Len(t, agents, 1)
+	assert.Equal(t, next.Version, agents[0].ConfigVersion)
+	assert.Equal(t, "plugin disk: unknown type", agents[0].ConfigError)
+
+	_, err = framework.SetRemoteAgentPlugins("web-1", []PluginConfig{{Name: "disk"}})
+	assert.Error(t, err, "Expected plugins without a type to be rejected")
+
+	// Without plugins of its own or for all agents, an agent keeps its configuration
+	_, err = framework.SetRemoteAgentPlugins("*", nil)
+	require.NoError(t, err)
+	cleared, err := framework.SetRemoteAgentPlugins("web-1", nil)
+	require.NoError(t, err)
+	assert.Empty(t, cleared.Version)
+	assert.Empty(t, cleared.Plugins)
+}
+
+func TestFramework_ForwardFromRemoteAgent(t *testing.T) {
+	framework := newFleetFramework(FleetConfig{})
+	responder := &severityResponder{MockPlugin: MockPlugin{name: "pager", pluginType: PluginTypeResponder}, severity: "high"}
+	require.NoError(t, framework.LoadPlugin(responder))
+	ctx := context.Background()
+
+	data := []DataPoint{{Timestamp: time.Now(), Source: "cpu", Metric: "cpu_usage", Value: 97, Labels: map[string]string{"host": "web-1"}}}
+	analyses := []ForwardedAnalysis{{Analyzer: "threshold", Analysis: &Analysis{ID: "a1", Severity: "high", Summary: "CPU high"}}}
+	assert.ErrorIs(t, framework.ForwardFromRemoteAgent(ctx, "web-1", data, analyses), ErrUnknownRemoteAgent)
+
+	_, err := framework.RegisterRemoteAgent(RemoteAgent{ID: "web-1"})
+	require.NoError(t, err)
+	require.NoError(t, framework.ForwardFromRemoteAgent(ctx, "web-1", data, analyses))
+
+	select {
+	case batch := <-framework.dataChannel:
+		require.Len(t, batch, 1)
+		assert.Equal(t, map[string]string{"host": "web-1", FleetAgentLabel: "web-1"}, batch[0].Labels)
+	default:
+		t.Fatal("Expected the forwarded data points to be ingested")
+	}
+	require.Len(t, responder.handled, 1, "Expected the forwarded analysis to reach responders")
+	assert.Equal(t, "web-1", responder.handled[0].Details[FleetAgentLabel])
+
+	agents := framework.RemoteAgents()
+	require.Len(t, agents, 1)
+	assert.Equal(t, int64(1), agents[0].DataPoints)
+	assert.Equal(t, int64(1), agents[0].Analyses)
+}
+
+func TestFramework_RemoteAgentLostAndRecovered(t *testing.T) {
+	framework := newFleetFramework(FleetConfig{HeartbeatInterval: 10 * time.Second, AgentTimeout: 30 * time.Second})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	framework.fleet.now = func() time.Time { return now }
+
+	events := make(chan Event, 10)
+	for _, eventType := range []string{EventFleetAgentRegistered, EventFleetAgentLost, EventFleetAgentRecovered} {
+		_, err := framework.eventBus.Subscribe(eventType, func(event Event) error {
+			events <- event
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	expectEvent := func(eventType string) Event {
+		t.Helper()
+		select {
+		case event := <-events:
+			require.Equal(t, eventType, event.Type)
+			return event
+		case <-time.After(time.Second):
+			t.Fatalf("Expected a %s event", eventType)
+			return Event{}
+		}
+	}
+
+	_, err := framework.RegisterRemoteAgent(RemoteAgent{ID: "web-1", Hostname: "web-1.internal"})
+	require.NoError(t, err)
+	assert.Equal(t, "web-1", expectEvent(EventFleetAgentRegistered).Data["agent"])
+
+	now = now.Add(30 * time.Second)
+	framework.checkRemoteAgents()
+	assert.True(t, framework.RemoteAgents()[0].Online, "Expected an agent within the timeout to stay online")
+
+	now = now.Add(time.Second)
+	framework.checkRemoteAgents()
+	assert.Equal(t, "web-1.internal", expectEvent(EventFleetAgentLost).Data["hostname"])
+	assert.False(t, framework.RemoteAgents()[0].Online)
+	framework.checkRemoteAgents()
+
+	_, _, err = framework.RemoteAgentHeartbeat("web-1", "", "")
+	require.NoError(t, err)
+	expectEvent(EventFleetAgentRecovered)
+	assert.True(t, framework.RemoteAgents()[0].Online)
+	select {
+	case event := <-events:
+		t.Fatalf("Expected one lost event, got %s", event.Type)
+	default:
+	}
+}
+
+func TestFramework_ForwardsToCoordinator(t *testing.T) {
+	framework := newFleetFramework(FleetConfig{})
+	forwarder := &recordingForwarder{data: make(chan []DataPoint, 1), analyses: make(chan string, 1)}
+	framework.SetForwarder(forwarder)
+	analyzer := &reportingAnalyzer{MockAnalyzer{MockPlugin{name: "spikes", pluginType: PluginTypeAnalyzer}}}
+	require.NoError(t, framework.LoadPlugin(analyzer))
+
+	framework.processData(context.Background(), []DataPoint{{Timestamp: time.Now(), Source: "cpu", Metric: "cpu_usage", Value: 97}})
+	select {
+	case data := <-forwarder.data:
+		assert.Equal(t, "cpu_usage", data[0].Metric)
+	default:
+		t.Fatal("Expected the data points to be forwarded")
+	}
+	select {
+	case analyzer := <-forwarder.analyses:
+		assert.Equal(t, "spikes", analyzer)
+	default:
+		t.Fatal("Expected the analysis to be forwarded")
+	}
+}
@@ -2,11 +2,22 @@ package core
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Framework manages all plugins and orchestrates their interactions
@@ -16,16 +27,76 @@ type Framework struct {
 	configManager    ConfigurationManager
 	healthChecker    HealthChecker
 	metricsCollector MetricsCollector
+	metricsRegistry  *prometheus.Registry
 	eventBus         EventBus
+	feed             *analysisFeed
+	sandbox          *pluginSandbox
+	apiKeys          *APIKeyManager
+	secrets          *secretResolver
+	discovery        ServiceDiscovery
+	supervisor       *pluginSupervisor
+	fleet            *fleetCoordinator
+	forwarder        Forwarder
+	serverTLS        *tls.Config
+	serverTLSErr     error
+	serverTLSOnce    sync.Once
+	incidents        *IncidentManager
+	store            Store
+	history          *AnalysisHistory
+	dataHistory      *DataPointHistory
+	silences         *SilenceManager
+	dedup            *Deduplicator
+	dryRun           *DryRunReport
+	remediations     *RemediationGovernor
+	approvalAudit    *ApprovalAuditLog
+	metadata         *MetricMetadataRegistry
+	normalizer       *MetricNormalizer
+	chains           *analyzerChains
+	routes           analyzerRoutes
+	processing       *processingStage
+	recordingRules   *recordingRules
+	pipelines        pipelines
+	analyzerCalls    analyzerCalls
+	responderRoutes  *responderRoute
+	groups           *groupDispatcher
+	deliveries       *deliveryQueue
+	receipts         *deliveryReceipts
+	breakers         *responderBreakers
+	retrier          RetryExecutor
+	latest           *latestValues
+	agentContext     agentContext
+	agentLimiter     RateLimiter
+	verdicts         *VerdictHistory
+	debugLog         atomic.Pointer[DebugLog] // swapped by SetDebugLog while the pipeline runs
+	workflowEngine   WorkflowEngine
+	onCall           OnCallProvider
 	config           *FrameworkConfig
 	running          bool
 	mu               sync.RWMutex
 	dataChannel      chan []DataPoint
+	backpressure     *backpressure
+	wal              *WAL
+	walBatches       sync.Map // first data point of a logged batch -> WAL sequence number
+	batchSpans       sync.Map // first data point of a traced batch -> span it was collected in
+	tracer           trace.Tracer
+	tracerProvider   *sdktrace.TracerProvider
 	wg               sync.WaitGroup
+	collectors       sync.WaitGroup // collector workers, stopped first on shutdown
+	processors       sync.WaitGroup // data processors, which drain the data channel on shutdown
+	draining         chan struct{}  // closed once collectors have stopped, to drain the data channel
 	shutdown         bool
 	ctx              context.Context
 	cancel           context.CancelFunc
 	startTime        time.Time
+	pluginStarts     map[string]PluginStartResult
+	// pluginWorkers stops the collector or evaluation worker of each plugin that has one
+	pluginWorkers map[string]context.CancelFunc
+	// pluginSettings holds the settings of plugins loaded from configuration, as changed since
+	pluginSettings map[string]map[string]interface{}
+	reloadMu       sync.Mutex
+	// tenant is the name of the tenant a tenant's framework runs; empty for the process's
+	tenant  string
+	tenants []*tenant
 }
 
 // NewFramework creates a new framework instance with default dependencies
@@ -33,22 +104,129 @@ func NewFramework(config *FrameworkConfig) *Framework {
 	// Initialize global logger with configuration
 	InitLogger(config)
 
+	framework := newFramework(config)
+	framework.initTenants()
+	return framework
+}
+
+// newFramework creates a framework with default dependencies, leaving the global logger
+// alone
+func newFramework(config *FrameworkConfig) *Framework {
 	// Create default dependencies
 	registry := NewDefaultPluginRegistry()
 	factory := NewDefaultPluginFactory()
 
+	// Fall back to memory so a database outage at startup does not stop the pipeline
+	store, err := OpenStore(config.Store)
+	if err != nil {
+		slog.Error("Failed to open store, keeping state in memory", "driver", config.Store.Driver, "error", err)
+		store = NewMemoryStore()
+	}
+	incidents, err := NewIncidentManagerWithStore(context.Background(), store)
+	if err != nil {
+		slog.Error("Failed to restore incidents", "error", err)
+		incidents = NewIncidentManager()
+	}
+	deliveries, err := newDeliveryQueue(context.Background(), store, config.Delivery)
+	if err != nil {
+		slog.Error("Failed to restore queued deliveries", "error", err)
+	}
+
 	framework := &Framework{
-		registry:    registry,
-		factory:     factory,
-		config:      config,
-		running:     false,
-		dataChannel: make(chan []DataPoint, config.DataChannelSize),
-		wg:          sync.WaitGroup{},
+		registry:      registry,
+		factory:       factory,
+		apiKeys:       NewAPIKeyManager(config.APIKeys, config.Auth),
+		secrets:       newSecretResolver(config.Secrets),
+		supervisor:    newPluginSupervisor(config.Supervisor),
+		fleet:         newFleetCoordinator(config.Fleet),
+		incidents:     incidents,
+		store:         store,
+		history:       NewAnalysisHistory(store, config.AnalysisRetention),
+		dataHistory:   NewDataPointHistory(store, config.DataPointRetention),
+		feed:          newAnalysisFeed(),
+		silences:      NewSilenceManager(),
+		dedup:         NewDeduplicator(config.Dedup),
+		groups:        newGroupDispatcher(),
+		deliveries:    deliveries,
+		receipts:      newDeliveryReceipts(),
+		breakers:      newResponderBreakers(config.Delivery),
+		retrier:       newDeliveryRetrier(config.Delivery),
+		dryRun:        NewDryRunReport(),
+		remediations:  NewRemediationGovernor(config.Remediation.MaxActionsPerHour),
+		approvalAudit: NewApprovalAuditLog(store, config.Remediation.AuditRetention),
+		metadata:      NewMetricMetadataRegistry(config.MetricMetadata),
+		latest:        newLatestValues(config.StatusMetrics),
+		agentLimiter:  newAgentQueryLimiter(config.AgentQueries),
+		verdicts:      NewVerdictHistory(config.ExplainRetention),
+		config:        config,
+		running:       false,
+		dataChannel:   make(chan []DataPoint, config.DataChannelSize),
+		backpressure:  newBackpressure(config.Backpressure),
+		wal:           openWAL(config.WAL),
+		wg:            sync.WaitGroup{},
 	}
 
 	// Create health checker with framework reference
 	healthChecker := NewFrameworkHealthChecker(framework, config.HealthCheckTimeout)
 	framework.healthChecker = healthChecker
+	framework.metricsCollector = NewPrometheusMetricsCollector()
+	framework.sandbox = newPluginSandbox(config.PluginBudgets, framework.metricsCollector)
+	framework.discovery = newServiceDiscovery(config.Discovery, framework.metricsCollector)
+	framework.eventBus = NewInProcessEventBus(config.EventBufferSize)
+	framework.metricsRegistry = newFrameworkRegistry(framework)
+
+	// Invalid aliases are rejected by config validation, so errors here only come from
+	// configs built in code
+	normalizer, err := NewMetricNormalizer(config.MetricAliases)
+	if err != nil {
+		slog.Error("Failed to create metric normalizer", "error", err)
+	}
+	framework.normalizer = normalizer
+
+	// Chains are validated with the config as well, so this only fails for code-built configs
+	chains, err := newAnalyzerChains(config.AnalyzerChains)
+	if err != nil {
+		slog.Error("Failed to build analyzer chains", "error", err)
+	}
+	framework.chains = chains
+
+	routes, err := newAnalyzerRoutes(config.AnalyzerRoutes)
+	if err != nil {
+		slog.Error("Failed to build analyzer routes", "error", err)
+	}
+	framework.routes = routes
+
+	framework.processing = newFrameworkProcessing(config)
+	framework.recordingRules = newFrameworkRecordingRules(config)
+
+	pipelines, err := newPipelines(config.Pipelines)
+	if err != nil {
+		slog.Error("Failed to build pipelines", "error", err)
+	}
+	framework.pipelines = pipelines
+
+	windows, err := NewMaintenanceWindows(config.MaintenanceWindows)
+	if err != nil {
+		slog.Error("Failed to build maintenance windows", "error", err)
+	}
+	framework.silences.SetMaintenanceWindows(windows)
+
+	responderRoutes, err := newResponderRoutes(config.ResponderRoute)
+	if err != nil {
+		slog.Error("Failed to build responder routes", "error", err)
+	}
+	framework.responderRoutes = responderRoutes
+
+	framework.onCall = newFrameworkOnCall(config)
+
+	if config.DebugLogPath != "" {
+		debugLog, err := OpenDebugLog(config.DebugLogPath)
+		if err != nil {
+			slog.Error("Failed to open debug log", "path", config.DebugLogPath, "error", err)
+		}
+		framework.debugLog.Store(debugLog)
+	}
+	framework.initTracing()
 
 	return framework
 }
@@ -66,18 +244,68 @@ func NewFrameworkWithDependencies(
 	// Initialize global logger with configuration
 	InitLogger(config)
 
-	return &Framework{
+	if metricsCollector == nil {
+		metricsCollector = NewPrometheusMetricsCollector()
+	}
+
+	store := NewMemoryStore()
+	deliveries, _ := newDeliveryQueue(context.Background(), store, config.Delivery)
+	framework := &Framework{
 		registry:         registry,
 		factory:          factory,
 		configManager:    configManager,
 		healthChecker:    healthChecker,
 		metricsCollector: metricsCollector,
 		eventBus:         eventBus,
+		apiKeys:          NewAPIKeyManager(config.APIKeys, config.Auth),
+		secrets:          newSecretResolver(config.Secrets),
+		supervisor:       newPluginSupervisor(config.Supervisor),
+		fleet:            newFleetCoordinator(config.Fleet),
+		incidents:        NewIncidentManager(),
+		store:            store,
+		history:          NewAnalysisHistory(store, config.AnalysisRetention),
+		dataHistory:      NewDataPointHistory(store, config.DataPointRetention),
+		feed:             newAnalysisFeed(),
+		silences:         NewSilenceManager(),
+		dedup:            NewDeduplicator(config.Dedup),
+		groups:           newGroupDispatcher(),
+		deliveries:       deliveries,
+		receipts:         newDeliveryReceipts(),
+		breakers:         newResponderBreakers(config.Delivery),
+		retrier:          newDeliveryRetrier(config.Delivery),
+		dryRun:           NewDryRunReport(),
+		remediations:     NewRemediationGovernor(config.Remediation.MaxActionsPerHour),
+		approvalAudit:    NewApprovalAuditLog(store, config.Remediation.AuditRetention),
+		metadata:         NewMetricMetadataRegistry(config.MetricMetadata),
+		latest:           newLatestValues(config.StatusMetrics),
+		agentLimiter:     newAgentQueryLimiter(config.AgentQueries),
+		verdicts:         NewVerdictHistory(config.ExplainRetention),
 		config:           config,
 		running:          false,
 		dataChannel:      make(chan []DataPoint, config.DataChannelSize),
+		backpressure:     newBackpressure(config.Backpressure),
+		wal:              openWAL(config.WAL),
 		wg:               sync.WaitGroup{},
 	}
+	framework.sandbox = newPluginSandbox(config.PluginBudgets, metricsCollector)
+	framework.discovery = newServiceDiscovery(config.Discovery, metricsCollector)
+	framework.processing = newFrameworkProcessing(config)
+	framework.recordingRules = newFrameworkRecordingRules(config)
+	framework.onCall = newFrameworkOnCall(config)
+	framework.metricsRegistry = newFrameworkRegistry(framework)
+	framework.initTracing()
+	framework.initTenants()
+	return framework
+}
+
+// newFrameworkOnCall resolves the on-call provider if one is configured. On-call settings
+// are rejected by config validation, so errors here only come from configs built in code.
+func newFrameworkOnCall(config *FrameworkConfig) OnCallProvider {
+	onCall, err := NewOnCallProvider(config.OnCall)
+	if err != nil {
+		slog.Error("Failed to create on-call provider, notifications won't name the on-call person", "error", err)
+	}
+	return onCall
 }
 
 // LoadPlugin loads a plugin into the framework
@@ -86,32 +314,95 @@ func (f *Framework) LoadPlugin(plugin Plugin) error {
 		return WrapError(err, ErrorTypePlugin, "framework", "load", "failed to register plugin")
 	}
 
-	// Publish plugin loaded event
-	if f.eventBus != nil {
-		event := Event{
-			Type:      "plugin_loaded",
-			Source:    "framework",
-			Timestamp: time.Now(),
-			Data: map[string]interface{}{
-				"plugin_name": plugin.Name(),
-				"plugin_type": plugin.Type(),
-			},
+	if aware, ok := plugin.(MetricMetadataAware); ok {
+		aware.SetMetricMetadata(f.metadata)
+	}
+	if aware, ok := plugin.(StatusPageAware); ok {
+		aware.SetStatusPageProvider(f)
+	}
+	if aware, ok := plugin.(AgentToolsAware); ok {
+		aware.SetAgentTools(f)
+	}
+	if aware, ok := plugin.(IncidentSummaryAware); ok {
+		aware.SetIncidentSummaryProvider(f)
+	}
+	if aware, ok := plugin.(StoreAware); ok {
+		aware.SetStore(f.store)
+	}
+	if aware, ok := plugin.(ServiceDiscoveryAware); ok && f.discovery != nil {
+		aware.SetServiceDiscovery(f.discovery)
+	}
+	if aware, ok := plugin.(EventAware); ok && f.eventBus != nil {
+		aware.SetEventBus(f.eventBus)
+	}
+	if aware, ok := plugin.(AgentQuerierAware); ok {
+		aware.SetAgentQuerier(f)
+	}
+	// A plugin that runs workflows runs those of remediations, unless an engine was set
+	if engine, ok := plugin.(WorkflowEngine); ok {
+		f.mu.Lock()
+		if f.workflowEngine == nil {
+			f.workflowEngine = engine
 		}
-		f.eventBus.Publish(event)
+		f.mu.Unlock()
 	}
 
+	f.publishEvent(EventPluginLoaded, map[string]interface{}{
+		"plugin_name": plugin.Name(),
+		"plugin_type": plugin.Type(),
+	})
+
 	slog.Info("Plugin loaded", "plugin", plugin.Name(), "type", plugin.Type())
 	return nil
 }
 
-// LoadPluginFromConfig loads a plugin from configuration
+// LoadPluginFromConfig loads a plugin from configuration. If the framework is running the
+// plugin is started too, along with its collector or evaluation worker.
 func (f *Framework) LoadPluginFromConfig(config PluginConfig) error {
-	plugin, err := f.factory.CreatePlugin(config)
+	resolved, err := f.resolvePluginSecrets(context.Background(), config)
+	if err != nil {
+		return err
+	}
+	plugin, err := f.factory.CreatePlugin(resolved)
 	if err != nil {
 		return WrapError(err, ErrorTypePlugin, "framework", "load", "failed to create plugin from config")
 	}
 
-	return f.LoadPlugin(plugin)
+	if err := f.activatePlugin(plugin); err != nil {
+		return err
+	}
+	f.recordPluginSettings(config)
+	return nil
+}
+
+// activatePlugin loads a plugin and, if the framework runs, starts it and its worker. A
+// plugin that fails to start is unloaded again, so the load can be retried.
+func (f *Framework) activatePlugin(plugin Plugin) error {
+	if err := f.LoadPlugin(plugin); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	if !f.running {
+		f.mu.Unlock()
+		return nil
+	}
+	// Plugins keep the context they start with, so they get the framework's rather than
+	// the caller's
+	result := f.startPlugins(f.ctx, []Plugin{plugin})[plugin.Name()]
+	if result.Err == nil {
+		f.pluginStarts[plugin.Name()] = result
+		f.startPluginWorker(plugin)
+	}
+	f.mu.Unlock()
+
+	if result.Err != nil {
+		if err := f.UnloadPlugin(plugin.Name()); err != nil {
+			slog.Warn("Failed to unload plugin that did not start", "plugin", plugin.Name(), "error", err)
+		}
+		return result.Err
+	}
+	return nil
 }
 
 // UnloadPlugin removes a plugin from the framework
@@ -121,6 +412,19 @@ func (f *Framework) UnloadPlugin(name string) error {
 		return WrapError(err, ErrorTypePlugin, "framework", "unload", "plugin not found")
 	}
 
+	f.mu.Lock()
+	if stopWorker, ok := f.pluginWorkers[name]; ok {
+		stopWorker()
+		delete(f.pluginWorkers, name)
+	}
+	delete(f.pluginStarts, name)
+	delete(f.pluginSettings, name)
+	if engine, ok := plugin.(WorkflowEngine); ok && f.workflowEngine == engine {
+		f.workflowEngine = nil
+	}
+	f.mu.Unlock()
+	f.sandbox.forget(name)
+
 	// Stop the plugin if it's running
 	if plugin.Status() == PluginStatusRunning {
 		if err := plugin.Stop(); err != nil {
@@ -133,25 +437,68 @@ func (f *Framework) UnloadPlugin(name string) error {
 		return WrapError(err, ErrorTypePlugin, "framework", "unload", "failed to unregister plugin")
 	}
 
-	// Publish plugin unloaded event
-	if f.eventBus != nil {
-		event := Event{
-			Type:      "plugin_unloaded",
-			Source:    "framework",
-			Timestamp: time.Now(),
-			Data: map[string]interface{}{
-				"plugin_name": name,
-				"plugin_type": plugin.Type(),
-			},
-		}
-		f.eventBus.Publish(event)
-	}
+	f.publishEvent(EventPluginUnloaded, map[string]interface{}{
+		"plugin_name": name,
+		"plugin_type": plugin.Type(),
+	})
 
 	slog.Info("Plugin unloaded", "plugin", name)
 	return nil
 }
 
-// Start begins the framework's operation
+// ErrRuntimePluginsRefused is returned for plugin changes through an API without
+// authentication
+var ErrRuntimePluginsRefused = errors.New("loading and reconfiguring plugins needs API authentication or allow_runtime_plugins")
+
+// AllowsRuntimePlugins reports whether API callers may load and reconfigure plugins: when
+// the API requires authentication, or allow_runtime_plugins opts an open API in
+func (f *Framework) AllowsRuntimePlugins() bool {
+	return f.apiKeys.Enabled() || f.config.AllowRuntimePlugins
+}
+
+// ReconfigurePlugin changes the settings of a running plugin without restarting it
+func (f *Framework) ReconfigurePlugin(ctx context.Context, name string, config map[string]interface{}) error {
+	plugin, err := f.registry.GetPlugin(name)
+	if err != nil {
+		return WrapError(err, ErrorTypePlugin, "framework", "reconfigure", "plugin not found")
+	}
+
+	reconfigurable, ok := plugin.(ReconfigurablePlugin)
+	if !ok {
+		return NewValidationError("framework", "reconfigure", fmt.Sprintf("plugin %s cannot be reconfigured while running", name))
+	}
+	resolved, err := f.secrets.resolveSettings(ctx, config)
+	if err != nil {
+		return WrapError(err, ErrorTypeConfiguration, "framework", "reconfigure", fmt.Sprintf("failed to resolve secrets for plugin %s", name))
+	}
+	if err := reconfigurable.Reconfigure(ctx, resolved); err != nil {
+		return WrapError(err, ErrorTypeConfiguration, "framework", "reconfigure", fmt.Sprintf("failed to reconfigure plugin %s", name))
+	}
+
+	keys := make([]string, 0, len(config))
+	f.mu.Lock()
+	settings := f.pluginSettings[name]
+	for key, value := range config {
+		keys = append(keys, key)
+		if settings != nil {
+			settings[key] = value
+		}
+	}
+	f.mu.Unlock()
+	sort.Strings(keys)
+	slog.Info("Plugin reconfigured", "plugin", name, "settings", keys)
+
+	f.publishEvent(EventPluginReconfigured, map[string]interface{}{
+		"plugin_name": name,
+		"plugin_type": plugin.Type(),
+		"settings":    keys,
+	})
+	return nil
+}
+
+// Start begins the framework's operation. Plugins are started concurrently; if any fail or
+// time out, the framework keeps running with the rest and Start returns a *PluginStartError
+// naming them.
 func (f *Framework) Start(ctx context.Context) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -163,105 +510,215 @@ func (f *Framework) Start(ctx context.Context) error {
 	f.running = true
 	f.startTime = time.Now()
 	f.ctx, f.cancel = context.WithCancel(ctx)
+	f.draining = make(chan struct{})
 	slog.Info("Starting framework...")
+	if f.config.ReadOnly {
+		slog.Warn("Framework is read-only: only side-effect-free responders run and runbooks are blocked")
+	}
+	if f.config.DryRun {
+		slog.Warn("Framework is in dry-run mode: responders with side effects are simulated")
+	}
 
 	// Start all plugins
 	plugins := f.registry.ListPlugins()
+	f.pipelines.warnUnknownPlugins(plugins)
+	f.pluginStarts = f.startPlugins(f.ctx, plugins)
+	startErr := pluginStartError(f.pluginStarts)
+
+	// Start data collection workers for collectors and evaluation workers for analyzers
+	// that also run on a timer
+	f.pluginWorkers = make(map[string]context.CancelFunc)
 	for _, plugin := range plugins {
-		if err := plugin.Start(f.ctx); err != nil {
-			slog.Error("Failed to start plugin", "plugin", plugin.Name(), "error", err)
+		if f.pluginStarts[plugin.Name()].Err != nil {
 			continue
 		}
+		f.startPluginWorker(plugin)
 	}
 
-	// Start data collection workers for collectors
-	collectors := f.registry.ListPluginsByType(PluginTypeCollector)
-	for _, plugin := range collectors {
-		if collector, ok := plugin.(DataCollector); ok {
-			f.wg.Add(1)
-			go f.collectorWorker(f.ctx, collector)
-		}
+	// Start the data processing workers; batches are processed concurrently, so with more
+	// than one worker they may reach analyzers out of order
+	for i := 0; i < f.workerPoolSize(); i++ {
+		f.wg.Add(1)
+		f.processors.Add(1)
+		go f.dataProcessor(f.ctx)
 	}
 
-	// Start data processing worker
-	f.wg.Add(1)
-	go f.dataProcessor(f.ctx)
+	// Start the worker replaying batches spilled while the data channel was full
+	if f.overflowPolicy() == OverflowSpill {
+		f.wg.Add(1)
+		go f.spillWorker(f.ctx)
+	}
+
+	// Start the worker replaying what the WAL kept from an earlier run
+	if replay := f.wal.takeReplay(); len(replay) > 0 {
+		f.wg.Add(1)
+		go f.walReplayWorker(f.ctx, replay)
+	}
+
+	// Start the worker resolving deduplicated conditions that stopped firing
+	if f.dedup.Enabled() && f.config.Dedup.ResolveTimeout > 0 {
+		f.wg.Add(1)
+		go f.resolveWorker(f.ctx)
+	}
 
-	// Start health endpoints
+	// Start the worker re-checking the metrics of executed remediations
+	if f.config.Remediation.VerifyAfter > 0 {
+		f.wg.Add(1)
+		go f.verificationWorker(f.ctx)
+	}
+
+	// Start the worker expiring remediations left waiting for approval
+	if f.config.Remediation.ApprovalTTL > 0 {
+		f.wg.Add(1)
+		go f.approvalWorker(f.ctx)
+	}
+
+	// Start the worker sending grouped analyses to responders
+	if f.responderRoutes.grouped() {
+		f.wg.Add(1)
+		go f.groupWorker(f.ctx)
+	}
+
+	// Start the worker checking responder destinations and retrying failed deliveries
+	if delivery := f.config.Delivery; delivery.RetryInterval > 0 && (delivery.QueueSize > 0 || len(delivery.Failover) > 0) {
+		f.wg.Add(1)
+		go f.deliveryWorker(f.ctx)
+	}
+
+	// Start the worker restarting failed plugins
+	if f.config.Supervisor.CheckInterval > 0 {
+		f.wg.Add(1)
+		go f.supervisorWorker(f.ctx)
+	}
+
+	// Start the worker reporting remote agents that stopped sending heartbeats
 	f.wg.Add(1)
-	go f.startHealthEndpoints(f.ctx)
-
-	// Publish framework started event
-	if f.eventBus != nil {
-		event := Event{
-			Type:      "framework_started",
-			Source:    "framework",
-			Timestamp: time.Now(),
-			Data: map[string]interface{}{
-				"plugin_count": len(plugins),
-			},
+	go f.fleetWorker(f.ctx)
+
+	// Start a worker per recording rule, evaluating it on its interval
+	for _, rule := range f.recordingRules.rules {
+		f.wg.Add(1)
+		go f.recordingRuleWorker(f.ctx, rule)
+	}
+
+	// Start the worker sending the noisiest alerts report
+	if f.config.NoiseReport.Interval > 0 {
+		f.wg.Add(1)
+		go f.noiseReportWorker(f.ctx)
+	}
+
+	// Start the tenants' pipelines beside the framework's
+	if failures := f.startTenants(f.ctx); len(failures) > 0 {
+		var failed []PluginStartResult
+		if pluginErr, ok := startErr.(*PluginStartError); ok {
+			failed = pluginErr.Failures
 		}
-		f.eventBus.Publish(event)
+		startErr = &PluginStartError{Failures: append(failed, failures...)}
 	}
 
+	// Start health endpoints; tenants are served by the framework's under /tenants/
+	if f.tenant == "" {
+		f.wg.Add(1)
+		go f.startHealthEndpoints(f.ctx)
+	}
+
+	f.publishEvent(EventFrameworkStarted, map[string]interface{}{
+		"plugin_count": len(plugins),
+	})
+
+	if startErr != nil {
+		slog.Warn("Framework started with failed plugins", "plugin_count", len(plugins), "error", startErr)
+		return startErr
+	}
 	slog.Info("Framework started successfully", "plugin_count", len(plugins))
 	return nil
 }
 
-// Stop gracefully stops the framework
+// Stop gracefully stops the framework. Collectors stop first; the batches they already
+// collected are then processed through analyzers and responders before those stop. The
+// whole shutdown is bounded by the shutdown timeout.
 func (f *Framework) Stop() error {
 	f.mu.Lock()
-	defer f.mu.Unlock()
-
 	if !f.running {
+		f.mu.Unlock()
 		return NewInternalError("framework", "stop", "framework is not running")
 	}
 
 	f.running = false
 	f.shutdown = true
-	slog.Info("Stopping framework...")
+	deadline := time.Now().Add(f.shutdownTimeout())
+	slog.Info("Stopping framework...", "timeout", f.shutdownTimeout())
 
-	// Cancel context to signal workers to stop
-	if f.cancel != nil {
-		f.cancel()
+	// Stop collecting so no new data enters the pipeline
+	for _, plugin := range f.registry.ListPluginsByType(PluginTypeCollector) {
+		if stopWorker, ok := f.pluginWorkers[plugin.Name()]; ok {
+			stopWorker()
+		}
 	}
+	f.mu.Unlock()
 
-	// Wait for all workers to finish with timeout
-	slog.Info("Waiting for workers to finish...")
-	done := make(chan struct{})
+	// Tenants shut down alongside the framework
+	var tenants sync.WaitGroup
+	tenants.Add(1)
 	go func() {
-		f.wg.Wait()
-		close(done)
+		defer tenants.Done()
+		f.stopTenants()
 	}()
 
-	select {
-	case <-done:
+	// Processing takes the lock, so it is not held while the data channel drains
+	f.drain(deadline)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Cancel context to signal the remaining workers to stop
+	if f.cancel != nil {
+		f.cancel()
+	}
+
+	slog.Info("Waiting for workers to finish...")
+	if grace := time.Now().Add(shutdownGrace); deadline.Before(grace) {
+		deadline = grace
+	}
+	if waitUntil(&f.wg, deadline) {
 		slog.Info("All workers finished gracefully")
-	case <-time.After(30 * time.Second):
+	} else {
 		slog.Warn("Timeout waiting for workers to finish")
 	}
+	f.keepUndrained()
 
-	// Stop all plugins
-	plugins := f.registry.ListPlugins()
-	for _, plugin := range plugins {
+	// Stop the plugins downstream of the collectors, which drain already stopped
+	for _, plugin := range f.registry.ListPlugins() {
+		if plugin.Type() == PluginTypeCollector {
+			continue
+		}
 		if err := plugin.Stop(); err != nil {
 			slog.Error("Failed to stop plugin", "plugin", plugin.Name(), "error", err)
 		}
 	}
 
-	// Publish framework stopped event
-	if f.eventBus != nil {
-		event := Event{
-			Type:      "framework_stopped",
-			Source:    "framework",
-			Timestamp: time.Now(),
-			Data: map[string]interface{}{
-				"uptime": time.Since(f.startTime),
-			},
+	f.publishEvent(EventFrameworkStopped, map[string]interface{}{
+		"uptime": time.Since(f.startTime),
+	})
+	// Handlers finish the events already queued, including framework_stopped
+	if closer, ok := f.eventBus.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			slog.Error("Failed to close event bus", "error", err)
 		}
-		f.eventBus.Publish(event)
 	}
 
+	if err := f.debugLog.Load().Close(); err != nil {
+		slog.Error("Failed to close debug log", "error", err)
+	}
+	if err := f.store.Close(); err != nil {
+		slog.Error("Failed to close store", "error", err)
+	}
+	if err := f.wal.Close(); err != nil {
+		slog.Error("Failed to close WAL", "error", err)
+	}
+	f.shutdownTracing()
+	tenants.Wait()
+
 	slog.Info("Framework stopped")
 	return nil
 }
@@ -281,7 +738,13 @@ func (f *Framework) QueryAgent(ctx context.Context, agentName, query string) (*A
 		return nil, NewPluginError("framework", "query", fmt.Sprintf("plugin %s is not an agent", agentName))
 	}
 
-	return agentPlugin.ProcessQuery(ctx, query)
+	ctx, span := f.startSpan(ctx, "agent.query", attribute.String("agent", agentName))
+	var response *AgentResponse
+	f.sandbox.run(agentName, func() error { response, err = agentPlugin.ProcessQuery(ctx, query); return err })
+	f.recordAgentQuery(TraceIDFromContext(ctx), agentName, query, response, err)
+	endSpan(span, err)
+
+	return response, err
 }
 
 // QueryDefaultAgent processes a query through the default agent
@@ -293,6 +756,13 @@ func (f *Framework) QueryDefaultAgent(ctx context.Context, query string) (*Agent
 	return f.QueryAgent(ctx, f.config.DefaultAgent, query)
 }
 
+// DefaultAgent returns the name of the agent queries go to when none is named
+func (f *Framework) DefaultAgent() string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.config.DefaultAgent
+}
+
 // GetDataChannel returns the data channel for testing purposes
 func (f *Framework) GetDataChannel() chan []DataPoint {
 	return f.dataChannel
@@ -307,14 +777,32 @@ func (f *Framework) GetStatus() map[string]interface{} {
 	pluginStatus := make(map[string]interface{})
 
 	for _, plugin := range plugins {
-		pluginStatus[plugin.Name()] = map[string]interface{}{
+		entry := map[string]interface{}{
 			"type":   plugin.Type(),
 			"status": plugin.Status(),
 		}
+		if start, ok := f.pluginStarts[plugin.Name()]; ok {
+			entry["start_duration"] = start.Duration.String()
+			if start.Err != nil {
+				entry["start_error"] = f.secrets.redact(start.Err.Error())
+			}
+		}
+		if supervision, ok := f.supervisor.supervision(plugin.Name()); ok {
+			entry["supervision"] = supervision
+		}
+		if usage, ok := f.sandbox.pluginUsage(plugin.Name()); ok {
+			entry["usage"] = usage
+		}
+		if reporter, ok := plugin.(StatusReporter); ok {
+			entry["details"] = reporter.StatusDetails()
+		}
+		pluginStatus[plugin.Name()] = entry
 	}
 
 	status := map[string]interface{}{
 		"running":       f.running,
+		"read_only":     f.config.ReadOnly,
+		"dry_run":       f.config.DryRun,
 		"total_plugins": len(plugins),
 		"collectors":    f.registry.GetPluginCountByType(PluginTypeCollector),
 		"analyzers":     f.registry.GetPluginCountByType(PluginTypeAnalyzer),
@@ -330,6 +818,78 @@ func (f *Framework) GetStatus() map[string]interface{} {
 	return status
 }
 
+// FrameworkStatus is the summary of the framework served on /status
+type FrameworkStatus struct {
+	Running      bool                     `json:"running"`
+	ReadOnly     bool                     `json:"read_only"`
+	DryRun       bool                     `json:"dry_run"`
+	TotalPlugins int                      `json:"total_plugins"`
+	Collectors   int                      `json:"collectors"`
+	Analyzers    int                      `json:"analyzers"`
+	Responders   int                      `json:"responders"`
+	Agents       int                      `json:"agents"`
+	Uptime       string                   `json:"uptime,omitempty"`
+	Plugins      map[string]PluginSummary `json:"plugins"`
+	Tenants      []TenantSummary          `json:"tenants,omitempty"`
+}
+
+// PluginSummary is a plugin's entry in the framework status
+type PluginSummary struct {
+	Type          PluginType   `json:"type"`
+	Status        PluginStatus `json:"status"`
+	StartDuration string       `json:"start_duration,omitempty"`
+	StartError    string       `json:"start_error,omitempty"`
+	// Restarts and quarantine of a plugin the supervisor has seen fail
+	Supervision *PluginSupervision `json:"supervision,omitempty"`
+	// Calls, errors, and goroutines of a plugin that has been called or runs goroutines
+	Usage *PluginUsage `json:"usage,omitempty"`
+	// Details a plugin reports about its state
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Status returns the summary of the framework served on /status
+func (f *Framework) Status() FrameworkStatus {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	plugins := f.registry.ListPlugins()
+	status := FrameworkStatus{
+		Running:      f.running,
+		ReadOnly:     f.config.ReadOnly,
+		DryRun:       f.config.DryRun,
+		TotalPlugins: len(plugins),
+		Collectors:   f.registry.GetPluginCountByType(PluginTypeCollector),
+		Analyzers:    f.registry.GetPluginCountByType(PluginTypeAnalyzer),
+		Responders:   f.registry.GetPluginCountByType(PluginTypeResponder),
+		Agents:       f.registry.GetPluginCountByType(PluginTypeAgent),
+		Plugins:      make(map[string]PluginSummary, len(plugins)),
+	}
+	if !f.startTime.IsZero() {
+		status.Uptime = time.Since(f.startTime).String()
+	}
+	for _, plugin := range plugins {
+		summary := PluginSummary{Type: plugin.Type(), Status: plugin.Status()}
+		if start, ok := f.pluginStarts[plugin.Name()]; ok {
+			summary.StartDuration = start.Duration.String()
+			if start.Err != nil {
+				summary.StartError = f.secrets.redact(start.Err.Error())
+			}
+		}
+		if supervision, ok := f.supervisor.supervision(plugin.Name()); ok {
+			summary.Supervision = &supervision
+		}
+		if usage, ok := f.sandbox.pluginUsage(plugin.Name()); ok {
+			summary.Usage = &usage
+		}
+		if reporter, ok := plugin.(StatusReporter); ok {
+			summary.Details = reporter.StatusDetails()
+		}
+		status.Plugins[plugin.Name()] = summary
+	}
+	status.Tenants = f.tenantSummaries()
+	return status
+}
+
 // GetHealthStatus returns the health status of the framework
 func (f *Framework) GetHealthStatus(ctx context.Context) HealthStatus {
 	if f.healthChecker != nil {
@@ -344,9 +904,30 @@ func (f *Framework) GetHealthStatus(ctx context.Context) HealthStatus {
 	}
 }
 
+// startPluginWorker starts the collector or evaluation worker a plugin needs, stopped when
+// the plugin is unloaded or the framework stops. The caller holds the lock.
+func (f *Framework) startPluginWorker(plugin Plugin) {
+	var worker func(ctx context.Context)
+	switch p := plugin.(type) {
+	case DataCollector:
+		f.collectors.Add(1)
+		worker = func(ctx context.Context) { f.collectorWorker(ctx, p) }
+	case ScheduledAnalyzer:
+		worker = func(ctx context.Context) { f.scheduledAnalyzerWorker(ctx, p) }
+	default:
+		return
+	}
+
+	ctx, cancel := context.WithCancel(f.ctx)
+	f.pluginWorkers[plugin.Name()] = cancel
+	f.wg.Add(1)
+	go worker(ctx)
+}
+
 // collectorWorker runs a collector in a separate goroutine
 func (f *Framework) collectorWorker(ctx context.Context, collector DataCollector) {
 	defer f.wg.Done()
+	defer f.collectors.Done()
 
 	interval := collector.GetCollectionInterval()
 	if interval == 0 {
@@ -372,17 +953,24 @@ func (f *Framework) collectorWorker(ctx context.Context, collector DataCollector
 				return
 			}
 
-			data, err := collector.Collect(ctx)
+			if !f.sandbox.allow(collector.Name()) {
+				slog.Debug("Skipping collection of throttled collector", "collector", collector.Name())
+				continue
+			}
+			collectCtx, span := f.startSpan(ctx, "collect", attribute.String("collector", collector.Name()))
+			var data []DataPoint
+			var err error
+			f.sandbox.run(collector.Name(), func() error { data, err = collector.Collect(collectCtx); return err })
+			span.SetAttributes(attribute.Int("data_points", len(data)))
+			endSpan(span, err)
 			if err != nil {
-				slog.Error("Failed to collect data", "collector", collector.Name(), "error", err)
+				slog.ErrorContext(collectCtx, "Failed to collect data", "collector", collector.Name(), "error", err)
 				continue
 			}
 
 			if len(data) > 0 {
 				// Send data to processing pipeline
-				select {
-				case f.dataChannel <- data:
-				case <-ctx.Done():
+				if err := f.enqueue(collectCtx, data); err != nil {
 					slog.Info("Collector worker stopping, dropping data", "collector", collector.Name())
 					return
 				}
@@ -391,15 +979,123 @@ func (f *Framework) collectorWorker(ctx context.Context, collector DataCollector
 	}
 }
 
-// dataProcessor processes collected data through analyzers and responders
+// scheduledAnalyzerWorker periodically evaluates an analyzer that runs on a timer
+func (f *Framework) scheduledAnalyzerWorker(ctx context.Context, analyzer ScheduledAnalyzer) {
+	defer f.wg.Done()
+
+	interval := analyzer.GetEvaluationInterval()
+	if interval == 0 {
+		interval = 30 * time.Second // default
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Analyzer worker stopping due to context cancellation", "analyzer", analyzer.Name())
+			return
+		case now := <-ticker.C:
+			if !f.sandbox.allow(analyzer.Name()) {
+				slog.Debug("Skipping evaluation of throttled analyzer", "analyzer", analyzer.Name())
+				continue
+			}
+			analyzeCtx, span := f.startSpan(ctx, "analyze", attribute.String("analyzer", analyzer.Name()))
+			var analysis *Analysis
+			var err error
+			f.sandbox.run(analyzer.Name(), func() error { analysis, err = analyzer.Evaluate(now); return err })
+			f.recordAnalyzerDecision(TraceIDFromContext(analyzeCtx), analyzer.Name(), analysis, err)
+			if err != nil {
+				slog.ErrorContext(analyzeCtx, "Failed to evaluate analyzer", "analyzer", analyzer.Name(), "error", err)
+				endSpan(span, err)
+				continue
+			}
+			if analysis != nil {
+				if p := f.pipelines.owner(analyzer.Name()); p != nil {
+					markPipeline(analysis, p)
+				}
+				f.handleAnalysis(analyzeCtx, analyzer.Name(), analysis)
+			}
+			endSpan(span, nil)
+		}
+	}
+}
+
+// resolveWorker periodically resolves conditions not seen for the resolve timeout
+func (f *Framework) resolveWorker(ctx context.Context) {
+	defer f.wg.Done()
+
+	interval := f.config.Dedup.ResolveTimeout / 2
+	if interval > 30*time.Second {
+		interval = 30 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Resolve worker stopping due to context cancellation")
+			return
+		case now := <-ticker.C:
+			f.resolveAlerts(ctx, now)
+		}
+	}
+}
+
+// resolveAlerts resolves the incidents of conditions that stopped firing and notifies
+// responders that heard about them
+func (f *Framework) resolveAlerts(ctx context.Context, now time.Time) {
+	for _, alert := range f.dedup.Resolve(now) {
+		traceID := NewTraceID()
+		if incident, err := f.incidents.Get(alert.IncidentID); err == nil && incident.Status != IncidentStatusResolved {
+			if _, err := f.incidents.Resolve(incident.ID, "framework"); err != nil {
+				slog.Error("Failed to resolve incident", "incident", incident.ID, "error", err)
+			}
+		}
+		f.debugLog.Load().Record(DebugEvent{
+			TraceID:  traceID,
+			Stage:    DebugStageIncident,
+			Plugin:   alert.Analysis.Source,
+			Decision: "resolved",
+			Reason:   fmt.Sprintf("condition not seen for %s", f.config.Dedup.ResolveTimeout),
+			Data: map[string]interface{}{
+				"incident_id": alert.IncidentID,
+				"analysis_id": alert.Analysis.ID,
+				"firing_for":  alert.FiringFor.String(),
+			},
+		})
+		if !alert.Notified {
+			continue
+		}
+		f.notify(WithTraceID(ctx, traceID), resolvedAnalysis(alert, now))
+	}
+}
+
+// workerPoolSize returns the number of data processing workers
+func (f *Framework) workerPoolSize() int {
+	if f.config.WorkerPoolSize < 1 {
+		return 1
+	}
+	return f.config.WorkerPoolSize
+}
+
+// dataProcessor is a data processing worker, taking batches off the data channel. Once
+// the framework starts draining it processes the batches left and stops.
 func (f *Framework) dataProcessor(ctx context.Context) {
 	defer f.wg.Done()
+	defer f.processors.Done()
 
 	for {
 		select {
 		case <-ctx.Done():
 			slog.Info("Data processor stopping due to context cancellation")
 			return
+		case <-f.draining:
+			f.drainDataChannel(ctx)
+			return
 		case data, ok := <-f.dataChannel:
 			if !ok {
 				slog.Info("Data processor stopping due to channel closure")
@@ -412,7 +1108,53 @@ func (f *Framework) dataProcessor(ctx context.Context) {
 
 // processData runs data through analyzers and triggers responders
 func (f *Framework) processData(ctx context.Context, data []DataPoint) {
+	defer f.ackBatch(data)
+
+	// Continue the trace of the collection or ingest request that produced the batch; every
+	// batch gets a trace ID so the debug log can follow it through the pipeline
+	ctx, span := f.startSpan(f.batchContext(ctx, data), "process", attribute.Int("data_points", len(data)))
+	defer span.End()
+	traceID := TraceIDFromContext(ctx)
+
+	// Give metrics reported under different names by different collectors one name
+	data = f.normalizer.Normalize(data)
+
+	// Drop, aggregate, downsample, and derive data points before anything else sees them
+	processed := f.processing.process(ctx, data)
+	if len(processed) != len(data) {
+		f.debugLog.Load().Record(DebugEvent{
+			TraceID:  traceID,
+			Stage:    DebugStageBatch,
+			Decision: "processed",
+			Reason:   fmt.Sprintf("processors turned %d data points into %d", len(data), len(processed)),
+		})
+		if len(processed) == 0 {
+			return
+		}
+	}
+	data = processed
+	f.recordingRules.observe(data)
+
+	f.metricsCollector.IncrementCounter("framework_data_batches_total", nil)
+	f.metricsCollector.AddCounter("framework_data_points_processed_total", float64(len(data)), nil)
+	f.latest.observe(data)
+	if err := f.dataHistory.Record(ctx, data); err != nil {
+		slog.ErrorContext(ctx, "Failed to record data point history", "error", err)
+	}
+	f.remediations.observe(data)
+	if f.forwarder != nil {
+		f.forwarder.ForwardData(data)
+	}
+
+	f.debugLog.Load().Record(DebugEvent{
+		TraceID:  traceID,
+		Stage:    DebugStageBatch,
+		Decision: "received",
+		Data:     map[string]interface{}{"data_points": data},
+	})
+
 	// Update agent context
+	f.agentContext.set(data)
 	agents := f.registry.ListPluginsByType(PluginTypeAgent)
 	for _, plugin := range agents {
 		if agent, ok := plugin.(AgentPlugin); ok {
@@ -420,45 +1162,529 @@ func (f *Framework) processData(ctx context.Context, data []DataPoint) {
 		}
 	}
 
-	// Run analyzers
-	analyzers := f.registry.ListPluginsByType(PluginTypeAnalyzer)
-	for _, plugin := range analyzers {
-		analyzer, ok := plugin.(DataAnalyzer)
-		if !ok {
+	// Run analyzers, each after the analyzers chained into it
+	var analyzers []DataAnalyzer
+	for _, plugin := range f.registry.ListPluginsByType(PluginTypeAnalyzer) {
+		if analyzer, ok := plugin.(DataAnalyzer); ok {
+			analyzers = append(analyzers, analyzer)
+		}
+	}
+	if len(f.pipelines) == 0 {
+		f.analyzeBatch(ctx, data, analyzers, nil)
+		return
+	}
+
+	// Each pipeline runs its own analyzers on what its collectors and processors pass on
+	for _, p := range f.pipelines {
+		input := p.input(data)
+		if len(input) == 0 {
 			continue
 		}
+		f.debugLog.Load().Record(DebugEvent{
+			TraceID:  traceID,
+			Stage:    DebugStageBatch,
+			Decision: "routed",
+			Reason:   fmt.Sprintf("%d of %d data points enter pipeline %s", len(input), len(data), p.name),
+			Data:     map[string]interface{}{"pipeline": p.name},
+		})
+
+		var members []DataAnalyzer
+		for _, analyzer := range analyzers {
+			if p.analyzers[analyzer.Name()] {
+				members = append(members, analyzer)
+			}
+		}
+		f.analyzeBatch(ctx, input, members, p)
+	}
+}
+
+// analyzeBatch runs analyzers over a batch in chain stages and hands their analyses on. The
+// analyses of a pipeline's analyzers are marked with the pipeline, which limits who hears
+// of them.
+func (f *Framework) analyzeBatch(ctx context.Context, data []DataPoint, analyzers []DataAnalyzer, p *pipeline) {
+	traceID := TraceIDFromContext(ctx)
+	results := make(map[string]*Analysis)
+	for _, stage := range f.chains.stages(analyzers) {
+		// The analyzers of a stage run concurrently; their analyses are handled in order once
+		// the whole stage is done, so later stages see every input
+		analyses := make([]*Analysis, len(stage))
+		var wg sync.WaitGroup
+		for i, analyzer := range stage {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				analyses[i] = f.runAnalyzer(ctx, analyzer, data, results)
+			}()
+		}
+		wg.Wait()
+
+		for i, analyzer := range stage {
+			analysis := analyses[i]
+			if analysis == nil {
+				continue
+			}
+			results[analyzer.Name()] = analysis
+
+			if f.chains.Consumed(analyzer.Name()) {
+				f.debugLog.Load().Record(DebugEvent{
+					TraceID:  traceID,
+					Stage:    DebugStageAnalyzer,
+					Plugin:   analyzer.Name(),
+					Decision: "consumed",
+					Reason:   "analysis only feeds analyzer chains",
+					Data:     map[string]interface{}{"analysis_id": analysis.ID},
+				})
+				continue
+			}
+
+			if p != nil {
+				markPipeline(analysis, p)
+			}
+			f.handleAnalysis(ctx, analyzer.Name(), analysis)
+		}
+	}
+}
+
+// runAnalyzer runs an analyzer on the batch or, when it is chained, on the analyses its
+// inputs produced for the batch. Analyses of chained analyzers carry their provenance.
+func (f *Framework) runAnalyzer(ctx context.Context, analyzer DataAnalyzer, data []DataPoint, results map[string]*Analysis) *Analysis {
+	ctx, span := f.startSpan(ctx, "analyze", attribute.String("analyzer", analyzer.Name()))
+	defer span.End()
+	traceID := TraceIDFromContext(ctx)
+
+	skip := func(reason string) *Analysis {
+		f.debugLog.Load().Record(DebugEvent{
+			TraceID:  traceID,
+			Stage:    DebugStageAnalyzer,
+			Plugin:   analyzer.Name(),
+			Decision: "skipped",
+			Reason:   reason,
+		})
+		return nil
+	}
+
+	inputNames := f.chains.Inputs(analyzer.Name())
+	var inputs []*Analysis
+	for _, name := range inputNames {
+		if input := results[name]; input != nil {
+			inputs = append(inputs, input)
+		}
+	}
 
+	if !f.sandbox.allow(analyzer.Name()) {
+		return skip("analyzer is throttled for exceeding its resource budget")
+	}
+	if f.analyzerCalls.stuck(analyzer.Name()) {
+		return skip("analyzer has not returned from a call that timed out")
+	}
+
+	// Routing applies before dispatch, to raw batches and to chained data points alike
+	data = f.routes.filter(analyzer.Name(), data)
+
+	// Analyzers that explain their decisions return the verdicts of the batch with its analysis
+	var verdicts []Verdict
+	analyze := func(points []DataPoint) func() (*Analysis, error) {
+		return func() (*Analysis, error) {
+			if reporter, ok := analyzer.(VerdictReporter); ok {
+				analysis, batchVerdicts, err := reporter.AnalyzeWithVerdicts(points)
+				verdicts = batchVerdicts
+				return analysis, err
+			}
+			return analyzer.Analyze(points)
+		}
+	}
+
+	var analysis *Analysis
+	var err error
+	start := time.Now()
+	if len(inputNames) == 0 {
+		if len(data) == 0 {
+			return skip("no data points match the analyzer's route")
+		}
 		if !analyzer.CanAnalyze(data) {
+			return skip("analyzer cannot analyze this batch")
+		}
+		analysis, err = f.callAnalyzer(analyzer.Name(), analyze(data))
+	} else {
+		if len(inputs) == 0 {
+			return skip("no input analyses in this batch")
+		}
+		if chained, ok := analyzer.(ChainedAnalyzer); ok {
+			analysis, err = f.callAnalyzer(analyzer.Name(), func() (*Analysis, error) { return chained.AnalyzeChain(inputs, data) })
+		} else {
+			points := f.routes.filter(analyzer.Name(), chainedDataPoints(inputs))
+			if len(points) == 0 {
+				return skip("no data points of its inputs match the analyzer's route")
+			}
+			if !analyzer.CanAnalyze(points) {
+				return skip("analyzer cannot analyze the data points of its inputs")
+			}
+			analysis, err = f.callAnalyzer(analyzer.Name(), analyze(points))
+		}
+		if err == nil && analysis != nil {
+			analysis.Provenance = provenance(inputs)
+			if analysis.Type == "" {
+				analysis.Type = AnalysisTypeComposite
+			}
+		}
+	}
+
+	labels := map[string]string{"analyzer": analyzer.Name()}
+	f.metricsCollector.ObserveHistogram("framework_analyzer_duration_seconds", time.Since(start).Seconds(), labels)
+	if err != nil {
+		f.metricsCollector.IncrementCounter("framework_analyzer_errors_total", labels)
+	}
+
+	f.recordAnalyzerDecision(traceID, analyzer.Name(), analysis, err)
+	// A call that timed out may still set verdicts, so they are only read after a success
+	if err == nil {
+		f.verdicts.Record(verdicts)
+	}
+	if err != nil {
+		failSpan(span, err)
+		slog.ErrorContext(ctx, "Failed to analyze data", "analyzer", analyzer.Name(), "error", err)
+		return nil
+	}
+	return analysis
+}
+
+// callAnalyzer makes an analyzer call in the sandbox, giving up on it after the analyzer
+// timeout so one slow analyzer cannot hold up the batch
+func (f *Framework) callAnalyzer(name string, call func() (*Analysis, error)) (*Analysis, error) {
+	analysis, timedOut, err := f.analyzerCalls.run(name, f.config.AnalyzerTimeout, func() (*Analysis, error) {
+		var analysis *Analysis
+		var err error
+		f.sandbox.run(name, func() error { analysis, err = call(); return err })
+		return analysis, err
+	})
+	if timedOut {
+		f.metricsCollector.IncrementCounter("framework_analyzer_timeouts_total", map[string]string{"analyzer": name})
+	}
+	return analysis, err
+}
+
+// handleAnalysis tracks an analysis against its incident and triggers responders
+func (f *Framework) handleAnalysis(ctx context.Context, analyzerName string, analysis *Analysis) {
+	// The analysis is kept in the WAL until responders have been given it
+	defer f.wal.Ack(f.wal.AppendAnalysis(analyzerName, analysis))
+
+	// Track the analysis against its incident and skip silenced incidents
+	traceID := TraceIDFromContext(ctx)
+	analysis.EnsureIdentity()
+	incident, suppressed := f.incidents.Track(analysis)
+	if analysis.Details == nil {
+		analysis.Details = make(map[string]interface{})
+	}
+	analysis.Details["incident_id"] = incident.ID
+	if traceID != "" {
+		analysis.Details["trace_id"] = traceID
+	}
+	if err := f.history.Record(ctx, analysis); err != nil {
+		slog.ErrorContext(ctx, "Failed to record analysis history", "analysis", analysis.ID, "error", err)
+	}
+	f.feed.publish(analysis)
+	if f.forwarder != nil {
+		f.forwarder.ForwardAnalysis(analyzerName, analysis)
+	}
+	f.publishEvent(EventAnalysisCreated, map[string]interface{}{
+		"analyzer":    analyzerName,
+		"analysis_id": analysis.ID,
+		"incident_id": incident.ID,
+		"severity":    analysis.Severity,
+		"summary":     analysis.Summary,
+	})
+	if suppressed {
+		f.debugLog.Load().Record(DebugEvent{
+			TraceID:  traceID,
+			Stage:    DebugStageIncident,
+			Plugin:   analyzerName,
+			Decision: "suppressed",
+			Reason:   fmt.Sprintf("incident %s is silenced until %s", incident.ID, incident.SilencedUntil.Format(time.RFC3339)),
+			Data:     map[string]interface{}{"incident_id": incident.ID, "analysis_id": analysis.ID},
+		})
+		slog.Debug("Incident silenced, skipping responders", "incident", incident.ID, "analyzer", analyzerName)
+		return
+	}
+	if silenceID, silenced := f.silences.Suppress(analysis); silenced {
+		f.debugLog.Load().Record(DebugEvent{
+			TraceID:  traceID,
+			Stage:    DebugStageIncident,
+			Plugin:   analyzerName,
+			Decision: "suppressed",
+			Reason:   fmt.Sprintf("analysis matches silence or maintenance window %s", silenceID),
+			Data:     map[string]interface{}{"incident_id": incident.ID, "analysis_id": analysis.ID, "silence_id": silenceID},
+		})
+		slog.Debug("Analysis matches a silence, skipping responders", "silence", silenceID, "analyzer", analyzerName)
+		return
+	}
+	if notify, decision := f.dedup.Observe(analysis, incident.ID, time.Now()); !notify {
+		reason := fmt.Sprintf("responders were notified less than %s ago", f.config.Dedup.RepeatInterval)
+		if decision == DedupFlapping {
+			reason = fmt.Sprintf("condition fired again %d times within %s", f.config.Dedup.FlapThreshold, f.config.Dedup.FlapWindow)
+		}
+		f.debugLog.Load().Record(DebugEvent{
+			TraceID:  traceID,
+			Stage:    DebugStageIncident,
+			Plugin:   analyzerName,
+			Decision: decision,
+			Reason:   reason,
+			Data:     map[string]interface{}{"incident_id": incident.ID, "analysis_id": analysis.ID, "fingerprint": analysis.Fingerprint},
+		})
+		slog.Debug("Analysis deduplicated, skipping responders", "fingerprint", analysis.Fingerprint, "decision", decision, "analyzer", analyzerName)
+		return
+	}
+	f.debugLog.Load().Record(DebugEvent{
+		TraceID:  traceID,
+		Stage:    DebugStageIncident,
+		Plugin:   analyzerName,
+		Decision: "tracked",
+		Data: map[string]interface{}{
+			"incident_id": incident.ID,
+			"analysis_id": analysis.ID,
+			"status":      incident.Status,
+			"occurrences": incident.Occurrences,
+		},
+	})
+	f.assignOnCall(ctx, analysis, incident.ID)
+	f.notify(ctx, analysis)
+}
+
+// respond sends an analysis to the named responders that handle it; nil names every
+// responder
+func (f *Framework) respond(ctx context.Context, analysis *Analysis, names []string) {
+	traceID := TraceIDFromContext(ctx)
+	for _, responder := range f.selectResponders(traceID, names) {
+		if !responder.CanHandle(analysis) {
+			f.debugLog.Load().Record(DebugEvent{
+				TraceID:  traceID,
+				Stage:    DebugStageResponder,
+				Plugin:   responder.Name(),
+				Decision: "skipped",
+				Reason:   fmt.Sprintf("responder does not handle %s analyses with severity %s", analysis.Type, analysis.Severity),
+			})
 			continue
 		}
+		f.deliver(ctx, responder, analysis)
+	}
+}
 
-		analysis, err := analyzer.Analyze(data)
+// deliver sends an analysis to a responder, or in dry-run mode records what a responder
+// with side effects would have done
+func (f *Framework) deliver(ctx context.Context, responder DataResponder, analysis *Analysis) {
+	traceID := TraceIDFromContext(ctx)
+	if !f.config.DryRun || isReadOnlyResponder(responder) {
+		f.send(ctx, responder, analysis)
+		return
+	}
+
+	action := &SimulatedAction{Action: "respond"}
+	if simulating, ok := responder.(SimulatingResponder); ok {
+		simulated, err := simulating.Simulate(analysis)
 		if err != nil {
-			slog.Error("Failed to analyze data", "analyzer", analyzer.Name(), "error", err)
-			continue
+			f.recordResponse(ctx, responder.Name(), err)
+			return
+		}
+		action = simulated
+	}
+
+	f.dryRun.Record(DryRunEntry{
+		Time:            time.Now(),
+		TraceID:         traceID,
+		Responder:       responder.Name(),
+		AnalysisID:      analysis.ID,
+		Fingerprint:     analysis.Fingerprint,
+		Source:          analysis.Source,
+		Severity:        analysis.Severity,
+		Summary:         analysis.Summary,
+		SimulatedAction: *action,
+	})
+	f.debugLog.Load().Record(DebugEvent{
+		TraceID:  traceID,
+		Stage:    DebugStageResponder,
+		Plugin:   responder.Name(),
+		Decision: "simulated",
+		Reason:   fmt.Sprintf("dry run: would have run %s", action.Action),
+		Data:     map[string]interface{}{"analysis_id": analysis.ID, "target": action.Target},
+	})
+	slog.Info("Dry run: responder would have acted", "responder", responder.Name(), "action", action.Action,
+		"target", action.Target, "analysis", analysis.ID, "summary", analysis.Summary)
+}
+
+// selectResponders returns the named responders, or every responder for nil names,
+// skipping those with side effects while the framework is read-only
+func (f *Framework) selectResponders(traceID string, names []string) []DataResponder {
+	var wanted map[string]bool
+	if names != nil {
+		wanted = make(map[string]bool, len(names))
+		for _, name := range names {
+			wanted[name] = true
 		}
+	}
 
-		if analysis == nil {
+	var selected []DataResponder
+	for _, plugin := range f.registry.ListPluginsByType(PluginTypeResponder) {
+		responder, ok := plugin.(DataResponder)
+		if !ok || (wanted != nil && !wanted[responder.Name()]) {
+			continue
+		}
+		if f.config.ReadOnly && !isReadOnlyResponder(responder) {
+			f.debugLog.Load().Record(DebugEvent{
+				TraceID:  traceID,
+				Stage:    DebugStageResponder,
+				Plugin:   responder.Name(),
+				Decision: "skipped",
+				Reason:   "responder has side effects and the framework is read-only",
+			})
 			continue
 		}
+		selected = append(selected, responder)
+	}
+	return selected
+}
 
-		// Trigger responders
-		responders := f.registry.ListPluginsByType(PluginTypeResponder)
-		for _, plugin := range responders {
-			responder, ok := plugin.(DataResponder)
-			if !ok {
-				continue
-			}
+// recordResponse writes the outcome of a responder call to the debug log
+func (f *Framework) recordResponse(ctx context.Context, responder string, err error) {
+	traceID := TraceIDFromContext(ctx)
+	if err != nil {
+		f.debugLog.Load().Record(DebugEvent{
+			TraceID:  traceID,
+			Stage:    DebugStageResponder,
+			Plugin:   responder,
+			Decision: "failed",
+			Reason:   err.Error(),
+		})
+		slog.ErrorContext(ctx, "Failed to respond", "responder", responder, "error", err)
+		f.publishEvent(EventResponderFailed, map[string]interface{}{
+			"responder": responder,
+			"error":     err.Error(),
+		})
+		return
+	}
+	f.debugLog.Load().Record(DebugEvent{
+		TraceID:  traceID,
+		Stage:    DebugStageResponder,
+		Plugin:   responder,
+		Decision: "responded",
+	})
+}
 
-			if !responder.CanHandle(analysis) {
-				continue
-			}
+// isReadOnlyResponder reports whether a responder may run in read-only mode
+func isReadOnlyResponder(responder DataResponder) bool {
+	readOnly, ok := responder.(ReadOnlyResponder)
+	return ok && readOnly.ReadOnly()
+}
 
-			if err := responder.Respond(ctx, analysis); err != nil {
-				slog.Error("Failed to respond", "responder", responder.Name(), "error", err)
-			}
+// recordAnalyzerDecision writes an analyzer's verdict to the debug log
+func (f *Framework) recordAnalyzerDecision(traceID, analyzerName string, analysis *Analysis, err error) {
+	event := DebugEvent{
+		TraceID:  traceID,
+		Stage:    DebugStageAnalyzer,
+		Plugin:   analyzerName,
+		Decision: "no_result",
+		Reason:   "analyzer found nothing to report",
+	}
+	switch {
+	case err != nil:
+		event.Decision = "failed"
+		event.Reason = err.Error()
+	case analysis != nil:
+		analysis.EnsureIdentity()
+		event.Decision = "reported"
+		event.Reason = analysis.Summary
+		event.Data = map[string]interface{}{
+			"analysis_id": analysis.ID,
+			"fingerprint": analysis.Fingerprint,
+			"type":        analysis.Type,
+			"severity":    analysis.Severity,
+			"confidence":  analysis.Confidence,
+			"details":     analysis.Details,
+		}
+		if len(analysis.Provenance) > 0 {
+			event.Data["provenance"] = analysis.Provenance
+		}
+	}
+	f.debugLog.Load().Record(event)
+}
+
+// assignOnCall addresses the analysis to the current on-call person and records the page
+func (f *Framework) assignOnCall(ctx context.Context, analysis *Analysis, incidentID string) {
+	f.mu.RLock()
+	provider := f.onCall
+	f.mu.RUnlock()
+
+	if provider == nil {
+		return
+	}
+
+	person, err := provider.CurrentOnCall(ctx, time.Now())
+	if err != nil {
+		slog.Warn("Failed to resolve on-call person", "incident", incidentID, "error", err)
+		return
+	}
+
+	analysis.Details["on_call"] = person.Name
+	if person.SlackID != "" {
+		analysis.Details["on_call_slack_id"] = person.SlackID
+	}
+	if person.Email != "" {
+		analysis.Details["on_call_email"] = person.Email
+	}
+
+	if _, err := f.incidents.RecordPage(incidentID, *person); err != nil {
+		slog.Warn("Failed to record page", "incident", incidentID, "error", err)
+	}
+}
+
+// AcknowledgeIncident acknowledges an incident and the incidents responders opened for it
+// in external systems
+func (f *Framework) AcknowledgeIncident(ctx context.Context, id, actor string) (Incident, error) {
+	incident, err := f.incidents.Acknowledge(id, actor)
+	if err != nil {
+		return Incident{}, err
+	}
+
+	traceID := TraceIDFromContext(ctx)
+	for _, responder := range f.selectResponders(traceID, nil) {
+		acknowledging, ok := responder.(AcknowledgingResponder)
+		if !ok {
+			continue
+		}
+		if f.config.DryRun && !isReadOnlyResponder(responder) {
+			f.dryRun.Record(DryRunEntry{
+				Time:            time.Now(),
+				TraceID:         traceID,
+				Responder:       responder.Name(),
+				AnalysisID:      incident.LastAnalysisID,
+				Fingerprint:     incident.Fingerprint,
+				Source:          incident.Source,
+				Severity:        incident.Severity,
+				Summary:         incident.Summary,
+				SimulatedAction: SimulatedAction{Action: "acknowledge", Target: incident.ID},
+			})
+			continue
+		}
+		if err := acknowledging.Acknowledge(ctx, incident, actor); err != nil {
+			slog.Error("Failed to acknowledge incident in responder", "responder", responder.Name(), "incident", incident.ID, "error", err)
 		}
 	}
+	return incident, nil
+}
+
+// SetOnCallProvider sets the provider used to address notifications
+func (f *Framework) SetOnCallProvider(provider OnCallProvider) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onCall = provider
+}
+
+// Explain returns each analyzer's view of the metric closest to the given time
+func (f *Framework) Explain(metric string, at time.Time) []Verdict {
+	return f.verdicts.Explain(f.normalizer.Canonical(metric), at)
+}
+
+// SetDebugLog sets the log that pipeline events are written to
+func (f *Framework) SetDebugLog(log *DebugLog) {
+	f.debugLog.Store(log)
 }
 
 // GetRegistry returns the plugin registry
@@ -471,30 +1697,76 @@ func (f *Framework) GetFactory() PluginFactory {
 	return f.factory
 }
 
+// GetIncidentManager returns the incident manager
+func (f *Framework) GetIncidentManager() *IncidentManager {
+	return f.incidents
+}
+
+// GetStore returns the store holding the framework's persistent state
+func (f *Framework) GetStore() Store {
+	return f.store
+}
+
+// GetEventBus returns the bus framework events are published on
+func (f *Framework) GetEventBus() EventBus {
+	return f.eventBus
+}
+
+// GetDryRunReport returns the actions responders would have taken in dry-run mode
+func (f *Framework) GetDryRunReport() *DryRunReport {
+	return f.dryRun
+}
+
+// GetAnalysisHistory returns the history of analyses the pipeline produced
+func (f *Framework) GetAnalysisHistory() *AnalysisHistory {
+	return f.history
+}
+
+// GetSilenceManager returns the silence manager
+func (f *Framework) GetSilenceManager() *SilenceManager {
+	return f.silences
+}
+
+// GetMetricMetadata returns the metric metadata registry
+func (f *Framework) GetMetricMetadata() *MetricMetadataRegistry {
+	return f.metadata
+}
+
+// GetAPIKeys returns the API key manager that guards the management APIs
+func (f *Framework) GetAPIKeys() *APIKeyManager {
+	return f.apiKeys
+}
+
+// SetWorkflowEngine sets the engine used to run workflows triggered by interactions. A
+// loaded plugin implementing WorkflowEngine is used when none is set.
+func (f *Framework) SetWorkflowEngine(engine WorkflowEngine) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.workflowEngine = engine
+}
+
 // GetHealthChecker returns the health checker
 func (f *Framework) GetHealthChecker() HealthChecker {
 	return f.healthChecker
 }
 
-// startHealthEndpoints starts HTTP health check endpoints
-func (f *Framework) startHealthEndpoints(ctx context.Context) {
-	defer f.wg.Done()
-
+// Handler returns the framework's HTTP handler: health, metrics, and status endpoints and
+// the management API
+func (f *Framework) Handler() http.Handler {
 	mux := http.NewServeMux()
 
-	// Health check endpoint
+	// Health check endpoint. The status code only reflects whether the framework is running,
+	// so probes do not restart it over a failing plugin; the body carries the full status.
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		f.mu.RLock()
 		running := f.running && !f.shutdown
 		f.mu.RUnlock()
 
-		if running {
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte("OK"))
-		} else {
-			w.WriteHeader(http.StatusServiceUnavailable)
-			w.Write([]byte("Service Unavailable"))
+		code := http.StatusOK
+		if !running {
+			code = http.StatusServiceUnavailable
 		}
+		writeJSON(w, code, f.GetHealthStatus(r.Context()))
 	})
 
 	// Readiness check endpoint
@@ -513,60 +1785,75 @@ func (f *Framework) startHealthEndpoints(ctx context.Context) {
 		}
 	})
 
-	// Metrics endpoint (basic)
-	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
-		f.mu.RLock()
-		status := f.GetStatus()
-		f.mu.RUnlock()
-
-		w.Header().Set("Content-Type", "text/plain")
-		w.WriteHeader(http.StatusOK)
+	// The status and metrics endpoints are open unless auth.protect_status asks for the
+	// query scope; probes keep using /health and /ready without credentials
+	protect := func(handler http.HandlerFunc) http.HandlerFunc {
+		if !f.config.Auth.ProtectStatus {
+			return handler
+		}
+		return f.apiKeys.Require(APIScopeQuery, handler)
+	}
 
-		fmt.Fprintf(w, "# Agent Framework Metrics\n")
-		fmt.Fprintf(w, "framework_running %t\n", status["running"])
-		fmt.Fprintf(w, "framework_total_plugins %d\n", status["total_plugins"])
-		fmt.Fprintf(w, "framework_collectors %d\n", status["collectors"])
-		fmt.Fprintf(w, "framework_analyzers %d\n", status["analyzers"])
-		fmt.Fprintf(w, "framework_responders %d\n", status["responders"])
-		fmt.Fprintf(w, "framework_agents %d\n", status["agents"])
-	})
+	// Metrics endpoint. A plugin exporting invalid metrics is logged rather than failing the scrape.
+	mux.HandleFunc("/metrics", protect(promhttp.HandlerFor(f.metricsGatherer(), promhttp.HandlerOpts{
+		ErrorLog:      slog.NewLogLogger(slog.Default().Handler(), slog.LevelError),
+		ErrorHandling: promhttp.ContinueOnError,
+	}).ServeHTTP))
 
 	// Status endpoint (JSON)
-	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
-		f.mu.RLock()
-		status := f.GetStatus()
-		f.mu.RUnlock()
+	mux.HandleFunc("/status", protect(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, f.Status())
+	}))
+
+	// Status page endpoint (HTML)
+	mux.HandleFunc("/status.html", protect(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := RenderStatusPage(w, f.StatusPage(r.Context())); err != nil {
+			slog.Error("Failed to render status page", "error", err)
+		}
+	}))
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-
-		// Simple JSON response
-		fmt.Fprintf(w, `{
-			"running": %t,
-			"total_plugins": %d,
-			"collectors": %d,
-			"analyzers": %d,
-			"responders": %d,
-			"agents": %d,
-			"uptime": "%v"
-		}`,
-			status["running"],
-			status["total_plugins"],
-			status["collectors"],
-			status["analyzers"],
-			status["responders"],
-			status["agents"],
-			status["uptime"])
+	// Management API endpoints
+	f.registerAPIRoutes(mux)
+	f.registerTenantRoutes(mux)
+
+	// Ingest and queries continue the trace of callers sending a traceparent header
+	return withTraceContext(mux)
+}
+
+// ServerTLSConfig returns the TLS configuration shared by the framework's servers, or nil
+// when TLS is off. Certificates are loaded once and reloaded when their files change.
+func (f *Framework) ServerTLSConfig() (*tls.Config, error) {
+	f.serverTLSOnce.Do(func() {
+		f.serverTLS, f.serverTLSErr = NewServerTLSConfig(f.config.TLS)
 	})
+	return f.serverTLS, f.serverTLSErr
+}
 
+// startHealthEndpoints serves the framework's HTTP handler until the context is done
+func (f *Framework) startHealthEndpoints(ctx context.Context) {
+	defer f.wg.Done()
+
+	tlsConfig, err := f.ServerTLSConfig()
+	if err != nil {
+		slog.Error("Health endpoints server error", "error", err)
+		return
+	}
 	server := &http.Server{
-		Addr:    fmt.Sprintf("%s:%d", f.config.ServerHost, f.config.ServerPort),
-		Handler: mux,
+		Addr:      fmt.Sprintf("%s:%d", f.config.ServerHost, f.config.ServerPort),
+		Handler:   f.Handler(),
+		TLSConfig: tlsConfig,
 	}
 
 	// Start server in goroutine
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if tlsConfig != nil {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			slog.Error("Health endpoints server error", "error", err)
 		}
 	}()
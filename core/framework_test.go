@@ -1,7 +1,15 @@
 package core
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -92,6 +100,45 @@ func TestFramework_StartStop(t *testing.T) {
 	assert.False(t, framework.running, "Expected framework to be stopped")
 }
 
+func TestFramework_StartPluginsConcurrently(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{
+		LogLevel:           "info",
+		LogFormat:          "text",
+		LogOutput:          "stdout",
+		PluginStartTimeout: 300 * time.Millisecond,
+	})
+	release := make(chan struct{})
+	defer close(release)
+	for _, plugin := range []Plugin{
+		&MockStartPlugin{MockPlugin: MockPlugin{name: "slow-a", pluginType: PluginTypeCollector}, delay: 200 * time.Millisecond},
+		&MockStartPlugin{MockPlugin: MockPlugin{name: "slow-b", pluginType: PluginTypeAnalyzer}, delay: 200 * time.Millisecond},
+		&MockStartPlugin{MockPlugin: MockPlugin{name: "broken", pluginType: PluginTypeResponder}, err: fmt.Errorf("connection refused")},
+		&MockStartPlugin{MockPlugin: MockPlugin{name: "stuck", pluginType: PluginTypeResponder}, block: release},
+	} {
+		require.NoError(t, framework.LoadPlugin(plugin))
+	}
+
+	started := time.Now()
+	err := framework.Start(context.Background())
+	defer framework.Stop()
+	assert.Less(t, time.Since(started), 390*time.Millisecond, "Expected plugins to start concurrently")
+
+	var startErr *PluginStartError
+	require.ErrorAs(t, err, &startErr)
+	require.Len(t, startErr.Failures, 2)
+	assert.Equal(t, "broken", startErr.Failures[0].Plugin)
+	assert.Equal(t, "stuck", startErr.Failures[1].Plugin)
+	assert.Equal(t, ErrorTypeTimeout, GetErrorType(startErr.Failures[1].Err))
+	assert.Contains(t, err.Error(), "connection refused")
+	assert.True(t, framework.running, "Expected the framework to keep running without the failed plugins")
+
+	plugins := framework.GetStatus()["plugins"].(map[string]interface{})
+	slow := plugins["slow-a"].(map[string]interface{})
+	assert.NotEmpty(t, slow["start_duration"])
+	assert.NotContains(t, slow, "start_error")
+	assert.Contains(t, plugins["broken"].(map[string]interface{})["start_error"], "connection refused")
+}
+
 func TestFramework_GetStatus(t *testing.T) {
 	config := &FrameworkConfig{
 		LogLevel:  "info",
@@ -128,6 +175,81 @@ func TestFramework_GetStatus(t *testing.T) {
 	assert.Equal(t, 1, status["analyzers"], "Expected 1 analyzer")
 }
 
+// reportingPlugin reports a fixed detail of its state
+type reportingPlugin struct {
+	MockPlugin
+}
+
+func (p *reportingPlugin) StatusDetails() map[string]interface{} {
+	return map[string]interface{}{"tokens_today": 1200}
+}
+
+func TestFramework_StatusDetails(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout"})
+	require.NoError(t, framework.LoadPlugin(&reportingPlugin{MockPlugin{name: "ai", pluginType: PluginTypeAgent}}))
+	require.NoError(t, framework.LoadPlugin(&MockPlugin{name: "plain", pluginType: PluginTypeResponder}))
+
+	status := framework.Status()
+	assert.Equal(t, map[string]interface{}{"tokens_today": 1200}, status.Plugins["ai"].Details)
+	assert.Nil(t, status.Plugins["plain"].Details)
+
+	entry := framework.GetStatus()["plugins"].(map[string]interface{})["ai"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"tokens_today": 1200}, entry["details"])
+}
+
+func TestFramework_HealthStatusDegraded(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout"})
+	collector := &MockPlugin{name: "prometheus", pluginType: PluginTypeCollector, status: PluginStatusRunning}
+	analyzer := &MockPlugin{name: "anomaly", pluginType: PluginTypeAnalyzer, status: PluginStatusRunning}
+	require.NoError(t, framework.LoadPlugin(collector))
+	require.NoError(t, framework.LoadPlugin(analyzer))
+
+	ctx := context.Background()
+	health := framework.GetHealthStatus(ctx)
+	assert.Equal(t, "healthy", health.Checks["plugins_healthy"].Status)
+	assert.Equal(t, "unhealthy", health.Status, "Expected a stopped framework to be unhealthy")
+
+	collector.status = PluginStatusError
+	check := framework.GetHealthStatus(ctx).Checks["plugins_healthy"]
+	assert.Equal(t, "degraded", check.Status, "Expected one failing plugin of two to degrade the framework")
+	assert.Contains(t, check.Error, "prometheus")
+
+	analyzer.status = PluginStatusError
+	assert.Equal(t, "unhealthy", framework.GetHealthStatus(ctx).Checks["plugins_healthy"].Status)
+}
+
+func TestFramework_ScheduledAnalyzer(t *testing.T) {
+	config := &FrameworkConfig{
+		LogLevel:  "info",
+		LogFormat: "text",
+		LogOutput: "stdout",
+		Plugins:   []PluginConfig{},
+	}
+
+	framework := NewFramework(config)
+	framework.LoadPlugin(&MockScheduledAnalyzer{
+		MockAnalyzer: MockAnalyzer{
+			MockPlugin: MockPlugin{
+				name:       "scheduled-analyzer",
+				pluginType: PluginTypeAnalyzer,
+				status:     PluginStatusStopped,
+			},
+		},
+		interval: 50 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	require.NoError(t, framework.Start(ctx))
+	defer framework.Stop()
+
+	// Evaluations are tracked as incidents even though no data was collected
+	assert.Eventually(t, func() bool {
+		return len(framework.GetIncidentManager().List()) > 0
+	}, time.Second, 20*time.Millisecond, "Expected the scheduled evaluation to open an incident")
+}
+
 // Mock implementations for testing
 
 type MockPlugin struct {
@@ -152,6 +274,25 @@ func (m *MockPlugin) Status() PluginStatus             { return m.status }
 func (m *MockPlugin) Health(ctx context.Context) error { return nil }
 func (m *MockPlugin) GetCapabilities() []string        { return []string{"test"} }
 
+type MockStartPlugin struct {
+	MockPlugin
+	delay time.Duration
+	err   error
+	block chan struct{}
+}
+
+func (m *MockStartPlugin) Start(ctx context.Context) error {
+	time.Sleep(m.delay)
+	if m.block != nil {
+		<-m.block
+		return nil
+	}
+	if m.err != nil {
+		return m.err
+	}
+	return m.MockPlugin.Start(ctx)
+}
+
 type MockCollector struct {
 	MockPlugin
 	interval time.Duration
@@ -184,3 +325,257 @@ func (m *MockAnalyzer) Analyze(data []DataPoint) (*Analysis, error) {
 func (m *MockAnalyzer) CanAnalyze(data []DataPoint) bool {
 	return len(data) > 0
 }
+
+type MockScheduledAnalyzer struct {
+	MockAnalyzer
+	interval time.Duration
+}
+
+func (m *MockScheduledAnalyzer) Evaluate(now time.Time) (*Analysis, error) {
+	return &Analysis{
+		Type:       AnalysisTypeAlert,
+		Confidence: 1.0,
+		Severity:   "high",
+		Summary:    "No data received",
+		Timestamp:  now,
+		Source:     m.name,
+	}, nil
+}
+
+func (m *MockScheduledAnalyzer) GetEvaluationInterval() time.Duration {
+	return m.interval
+}
+
+type readOnlyResponder struct {
+	severityResponder
+}
+
+func (r *readOnlyResponder) ReadOnly() bool {
+	return true
+}
+
+func TestFramework_ReadOnly(t *testing.T) {
+	config := &FrameworkConfig{
+		LogLevel:  "info",
+		LogFormat: "text",
+		LogOutput: "stdout",
+		ReadOnly:  true,
+		Plugins:   []PluginConfig{},
+	}
+	framework := NewFramework(config)
+
+	logger := &readOnlyResponder{severityResponder{MockPlugin: MockPlugin{name: "log", pluginType: PluginTypeResponder}, severity: "low"}}
+	pager := &severityResponder{MockPlugin: MockPlugin{name: "pager", pluginType: PluginTypeResponder}, severity: "low"}
+	require.NoError(t, framework.LoadPlugin(logger))
+	require.NoError(t, framework.LoadPlugin(pager))
+
+	framework.handleAnalysis(context.Background(), "spikes", &Analysis{Type: AnalysisTypeAnomaly, Severity: "low", Summary: "cpu spike"})
+	assert.Len(t, logger.handled, 1, "Expected side-effect-free responders to run")
+	assert.Empty(t, pager.handled, "Expected responders with side effects to be skipped")
+	assert.Equal(t, true, framework.GetStatus()["read_only"])
+
+	_, err := framework.applySlackAction(context.Background(), SlackActionRunbook, `{"incident_id":"INC-1","workflow_id":"restart"}`, "alice")
+	assert.ErrorContains(t, err, "read-only")
+}
+
+type reconfigurablePlugin struct {
+	MockPlugin
+	settings map[string]interface{}
+}
+
+func (p *reconfigurablePlugin) Reconfigure(ctx context.Context, config map[string]interface{}) error {
+	if _, ok := config["model"]; !ok {
+		return fmt.Errorf("only model can change")
+	}
+	p.settings = config
+	return nil
+}
+
+func TestFramework_ReconfigurePlugin(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout", AllowRuntimePlugins: true})
+	agent := &reconfigurablePlugin{MockPlugin: MockPlugin{name: "ai", pluginType: PluginTypeAgent}}
+	require.NoError(t, framework.LoadPlugin(agent))
+	require.NoError(t, framework.LoadPlugin(&MockPlugin{name: "fixed", pluginType: PluginTypeAgent}))
+
+	mux := http.NewServeMux()
+	framework.registerAPIRoutes(mux)
+	put := func(path, body string) int {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, path, strings.NewReader(body)))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, put("/api/v1/plugins/ai/config", `{"model": "gpt-4o-mini"}`))
+	assert.Equal(t, "gpt-4o-mini", agent.settings["model"])
+
+	assert.Equal(t, http.StatusBadRequest, put("/api/v1/plugins/ai/config", `{"temperature": 0.2}`), "Expected settings the plugin refuses to be rejected")
+	assert.Equal(t, http.StatusBadRequest, put("/api/v1/plugins/fixed/config", `{"model": "gpt-4o-mini"}`), "Expected plugins without Reconfigure to be rejected")
+	assert.Equal(t, http.StatusNotFound, put("/api/v1/plugins/missing/config", `{"model": "gpt-4o-mini"}`))
+	assert.Equal(t, http.StatusBadRequest, put("/api/v1/plugins/ai/config", `{}`))
+}
+
+func TestFramework_PluginSettings(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout", AllowRuntimePlugins: true})
+	framework.GetFactory().RegisterPluginCreator("ai", func(config PluginConfig) (Plugin, error) {
+		return &reconfigurablePlugin{MockPlugin: MockPlugin{name: config.Name, pluginType: PluginTypeAgent}}, nil
+	})
+	require.NoError(t, framework.LoadPluginFromConfig(PluginConfig{Name: "ai", Type: "ai", Enabled: true, Config: map[string]interface{}{
+		"model":   "gpt-4o",
+		"api_key": "sk-secret",
+		"backend": map[string]interface{}{"url": "https://api.example.com", "token": "abc"},
+	}}))
+
+	mux := http.NewServeMux()
+	framework.registerAPIRoutes(mux)
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := request(http.MethodGet, "/api/v1/plugins/ai", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var state PluginState
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+	assert.Equal(t, "ai", state.Name)
+
+	require.Equal(t, http.StatusOK, request(http.MethodPut, "/api/v1/plugins/ai/config", `{"model": "gpt-4o-mini"}`).Code)
+	rec = request(http.MethodGet, "/api/v1/plugins/ai/config", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"model": "gpt-4o-mini", "api_key": "********", "backend": {"url": "https://api.example.com", "token": "********"}}`,
+		rec.Body.String(), "Expected reconfigured settings with credentials redacted")
+
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/api/v1/plugins/missing", "").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/api/v1/plugins/ai/status", "").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, request(http.MethodPost, "/api/v1/plugins/ai", "").Code)
+
+	require.Equal(t, http.StatusOK, request(http.MethodDelete, "/api/v1/plugins/ai", "").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/api/v1/plugins/ai/config", "").Code)
+}
+
+type countingCollector struct {
+	MockCollector
+	collections *atomic.Int32
+}
+
+func (c *countingCollector) Collect(ctx context.Context) ([]DataPoint, error) {
+	c.collections.Add(1)
+	return c.MockCollector.Collect(ctx)
+}
+
+func TestFramework_LoadPluginAtRuntime(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout", AllowRuntimePlugins: true})
+	collections := &atomic.Int32{}
+	framework.GetFactory().RegisterPluginCreator("counting", func(config PluginConfig) (Plugin, error) {
+		return &countingCollector{
+			MockCollector: MockCollector{MockPlugin: MockPlugin{name: config.Name, pluginType: PluginTypeCollector}, interval: 10 * time.Millisecond},
+			collections:   collections,
+		}, nil
+	})
+	framework.GetFactory().RegisterPluginCreator("broken", func(config PluginConfig) (Plugin, error) {
+		return &MockStartPlugin{MockPlugin: MockPlugin{name: config.Name, pluginType: PluginTypeCollector}, err: fmt.Errorf("no backend")}, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, framework.Start(ctx))
+	defer framework.Stop()
+
+	mux := http.NewServeMux()
+	framework.registerAPIRoutes(mux)
+	request := func(method, path, body string) int {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusCreated, request(http.MethodPost, "/api/v1/plugins", `{"name": "extra", "type": "counting"}`))
+	assert.Eventually(t, func() bool { return collections.Load() > 0 }, time.Second, 10*time.Millisecond,
+		"Expected a collector loaded at runtime to get a collection worker")
+	assert.Equal(t, http.StatusConflict, request(http.MethodPost, "/api/v1/plugins", `{"name": "extra", "type": "counting"}`))
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/api/v1/plugins", `{"type": "counting"}`))
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/api/v1/plugins", `{"name": "other", "type": "unknown"}`))
+
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/api/v1/plugins", `{"name": "broken", "type": "broken"}`))
+	_, err := framework.GetRegistry().GetPlugin("broken")
+	assert.Error(t, err, "Expected a plugin that fails to start to be unloaded again")
+
+	assert.Equal(t, http.StatusOK, request(http.MethodDelete, "/api/v1/plugins/extra", ""))
+	_, err = framework.GetRegistry().GetPlugin("extra")
+	assert.Error(t, err)
+	time.Sleep(30 * time.Millisecond)
+	stopped := collections.Load()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, stopped, collections.Load(), "Expected the collection worker to stop with the plugin")
+	assert.Equal(t, http.StatusNotFound, request(http.MethodDelete, "/api/v1/plugins/extra", ""))
+}
+
+func TestFramework_RuntimePluginsNeedAuthentication(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   FrameworkConfig
+		token    string
+		expected int
+	}{
+		{name: "open API", expected: http.StatusForbidden},
+		{name: "open API opted in", config: FrameworkConfig{AllowRuntimePlugins: true}, expected: http.StatusCreated},
+		{name: "admin key", config: FrameworkConfig{APIKeys: []APIKeyConfig{{Name: "ops", Token: "admin-token", Scopes: []string{"admin"}}}},
+			token: "admin-token", expected: http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.LogLevel, config.LogFormat, config.LogOutput = "info", "text", "stdout"
+			framework := NewFramework(&config)
+			framework.GetFactory().RegisterPluginCreator("ai", func(config PluginConfig) (Plugin, error) {
+				return &reconfigurablePlugin{MockPlugin: MockPlugin{name: config.Name, pluginType: PluginTypeAgent}}, nil
+			})
+			mux := http.NewServeMux()
+			framework.registerAPIRoutes(mux)
+			request := func(method, path, body string) int {
+				req := httptest.NewRequest(method, path, strings.NewReader(body))
+				if tt.token != "" {
+					req.Header.Set("Authorization", "Bearer "+tt.token)
+				}
+				rec := httptest.NewRecorder()
+				mux.ServeHTTP(rec, req)
+				return rec.Code
+			}
+
+			assert.Equal(t, tt.expected, request(http.MethodPost, "/api/v1/plugins", `{"name": "ai", "type": "ai"}`))
+			if tt.expected == http.StatusForbidden {
+				_, err := framework.GetRegistry().GetPlugin("ai")
+				assert.Error(t, err, "Expected the plugin not to be loaded")
+				require.NoError(t, framework.LoadPluginFromConfig(PluginConfig{Name: "ai", Type: "ai", Enabled: true}),
+					"Expected plugins from configuration to load")
+				assert.Equal(t, http.StatusForbidden, request(http.MethodPut, "/api/v1/plugins/ai/config", `{"model": "gpt-4o-mini"}`))
+			} else {
+				assert.Equal(t, http.StatusOK, request(http.MethodPut, "/api/v1/plugins/ai/config", `{"model": "gpt-4o-mini"}`))
+			}
+		})
+	}
+}
+
+type exportingPlugin struct {
+	MockPlugin
+}
+
+func (e *exportingPlugin) WriteMetrics(w io.Writer) {
+	fmt.Fprintf(w, "%s_series 1\n", e.name)
+}
+
+func TestFramework_PluginMetrics(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout"})
+	require.NoError(t, framework.LoadPlugin(&exportingPlugin{MockPlugin{name: "b", pluginType: PluginTypeResponder}}))
+	require.NoError(t, framework.LoadPlugin(&exportingPlugin{MockPlugin{name: "a", pluginType: PluginTypeResponder}}))
+	require.NoError(t, framework.LoadPlugin(&MockPlugin{name: "c", pluginType: PluginTypeResponder}))
+
+	var buf bytes.Buffer
+	require.NoError(t, framework.WriteMetrics(&buf))
+	metrics := buf.String()
+	assert.Contains(t, metrics, "\na_series 1\n")
+	assert.Contains(t, metrics, "\nb_series 1\n")
+	assert.Contains(t, metrics, `framework_plugin_status{plugin="c",status="",type="responder"} 1`)
+	assert.Contains(t, metrics, "framework_responders 3")
+	assert.Contains(t, metrics, "framework_running 0")
+}
@@ -0,0 +1,409 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// responderRoute is a compiled node of the responder routing tree. Unset settings are
+// inherited from the parent when the tree is built.
+type responderRoute struct {
+	id            string
+	responders    []string // nil sends to every responder
+	severities    map[string]bool
+	sources       []string
+	matchers      []LabelMatcher
+	groupBy       []string
+	groupWait     time.Duration
+	groupInterval time.Duration
+	cont          bool
+	routes        []*responderRoute
+}
+
+// newResponderRoutes compiles the routing tree; a nil config routes every analysis to
+// every responder straight away
+func newResponderRoutes(config *ResponderRouteConfig) (*responderRoute, error) {
+	if config == nil {
+		return nil, nil
+	}
+	return compileResponderRoute(*config, &responderRoute{}, "root")
+}
+
+// compileResponderRoute compiles a route and its children
+func compileResponderRoute(config ResponderRouteConfig, parent *responderRoute, id string) (*responderRoute, error) {
+	route := &responderRoute{
+		id:            id,
+		responders:    parent.responders,
+		sources:       config.Sources,
+		groupBy:       parent.groupBy,
+		groupWait:     parent.groupWait,
+		groupInterval: parent.groupInterval,
+		cont:          config.Continue,
+	}
+	if len(config.Responders) > 0 {
+		route.responders = config.Responders
+	}
+	if len(config.GroupBy) > 0 {
+		route.groupBy = config.GroupBy
+	}
+	if config.GroupWait > 0 {
+		route.groupWait = config.GroupWait
+	}
+	if config.GroupInterval > 0 {
+		route.groupInterval = config.GroupInterval
+	}
+	if route.groupInterval == 0 {
+		route.groupInterval = route.groupWait
+	}
+
+	if len(config.Severities) > 0 {
+		route.severities = make(map[string]bool, len(config.Severities))
+		for _, severity := range config.Severities {
+			route.severities[severity] = true
+		}
+	}
+	for _, pattern := range config.Sources {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, NewConfigurationError("routing", "build", fmt.Sprintf("invalid source pattern %q in responder route %s", pattern, id))
+		}
+	}
+	if config.Labels != "" {
+		matchers, err := ParseMatchers(config.Labels)
+		if err != nil {
+			return nil, WrapError(err, ErrorTypeConfiguration, "routing", "build", fmt.Sprintf("invalid label selector in responder route %s", id))
+		}
+		route.matchers = matchers
+	}
+
+	for i, child := range config.Routes {
+		compiled, err := compileResponderRoute(child, route, fmt.Sprintf("%s.%d", id, i))
+		if err != nil {
+			return nil, err
+		}
+		route.routes = append(route.routes, compiled)
+	}
+	return route, nil
+}
+
+// grouped reports whether the route or any route below it holds analyses back
+func (r *responderRoute) grouped() bool {
+	if r == nil {
+		return false
+	}
+	if r.groupWait > 0 {
+		return true
+	}
+	for _, child := range r.routes {
+		if child.grouped() {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether the analysis satisfies the route's own conditions
+func (r *responderRoute) matches(analysis *Analysis, labels map[string]string) bool {
+	if r.severities != nil && !r.severities[analysis.Severity] {
+		return false
+	}
+	if len(r.sources) > 0 {
+		matched := false
+		for _, pattern := range r.sources {
+			if ok, _ := path.Match(pattern, analysis.Source); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for _, matcher := range r.matchers {
+		if !matcher.Matches(labels[matcher.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// match returns the routes an analysis is delivered through. Children are tried in order
+// and the first match wins unless it sets continue; a route whose children all miss
+// handles the analysis itself.
+func (r *responderRoute) match(analysis *Analysis, labels map[string]string) []*responderRoute {
+	if !r.matches(analysis, labels) {
+		return nil
+	}
+	var matched []*responderRoute
+	for _, child := range r.routes {
+		routes := child.match(analysis, labels)
+		matched = append(matched, routes...)
+		if len(routes) > 0 && !child.cont {
+			break
+		}
+	}
+	if len(matched) == 0 {
+		matched = []*responderRoute{r}
+	}
+	return matched
+}
+
+// analysisLabels returns the labels every data point of the analysis shares
+func analysisLabels(analysis *Analysis) map[string]string {
+	labels := make(map[string]string)
+	for i, point := range analysis.DataPoints {
+		if i == 0 {
+			for name, value := range point.Labels {
+				labels[name] = value
+			}
+			continue
+		}
+		for name, value := range labels {
+			if point.Labels[name] != value {
+				delete(labels, name)
+			}
+		}
+	}
+	return labels
+}
+
+// groupLabels returns the route's group_by values for the analysis. The source, severity,
+// and type of the analysis can be grouped on when no label of that name exists.
+func (r *responderRoute) groupLabels(analysis *Analysis, labels map[string]string) map[string]string {
+	grouped := make(map[string]string, len(r.groupBy))
+	for _, name := range r.groupBy {
+		value, ok := labels[name]
+		if !ok {
+			switch name {
+			case "source":
+				value = analysis.Source
+			case "severity":
+				value = analysis.Severity
+			case "type":
+				value = string(analysis.Type)
+			}
+		}
+		grouped[name] = value
+	}
+	return grouped
+}
+
+// groupKey identifies a group by its route and group_by values
+func groupKey(routeID string, labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf("%s=%q", name, labels[name])
+	}
+	return fmt.Sprintf("%s{%s}", routeID, strings.Join(pairs, ","))
+}
+
+// pendingGroup collects analyses for a group until its next flush
+type pendingGroup struct {
+	route      *responderRoute
+	responders []string
+	labels     map[string]string
+	analyses   []*Analysis
+	nextFlush  time.Time
+}
+
+// groupDelivery is a group due to be sent to the responders of its route
+type groupDelivery struct {
+	route      *responderRoute
+	responders []string
+	group      *AnalysisGroup
+}
+
+// groupDispatcher holds analyses back so each group is sent once after group_wait and at
+// most once per group_interval after that
+type groupDispatcher struct {
+	groups map[string]*pendingGroup
+	mu     sync.Mutex
+}
+
+// newGroupDispatcher creates an empty dispatcher
+func newGroupDispatcher() *groupDispatcher {
+	return &groupDispatcher{groups: make(map[string]*pendingGroup)}
+}
+
+// add queues an analysis in its group, to be sent to the given responders, and returns the
+// group key. Analyses of different pipelines are never grouped together.
+func (d *groupDispatcher) add(route *responderRoute, analysis *Analysis, labels map[string]string, responders []string, now time.Time) string {
+	grouped := route.groupLabels(analysis, labels)
+	key := groupKey(route.id, grouped)
+	if pipeline := analysisPipeline(analysis); pipeline != "" {
+		key = pipeline + "/" + key
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	group, ok := d.groups[key]
+	if !ok {
+		group = &pendingGroup{route: route, responders: responders, labels: grouped, nextFlush: now.Add(route.groupWait)}
+		d.groups[key] = group
+	}
+	group.analyses = append(group.analyses, analysis)
+	return key
+}
+
+// due returns the groups whose flush time has passed, ordered by key. Groups with nothing
+// new since their last flush are forgotten, so the next analysis waits group_wait again.
+func (d *groupDispatcher) due(now time.Time) []groupDelivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var deliveries []groupDelivery
+	for key, group := range d.groups {
+		if now.Before(group.nextFlush) {
+			continue
+		}
+		if len(group.analyses) == 0 {
+			delete(d.groups, key)
+			continue
+		}
+		deliveries = append(deliveries, groupDelivery{
+			route:      group.route,
+			responders: group.responders,
+			group:      &AnalysisGroup{Key: key, Labels: group.labels, Analyses: group.analyses},
+		})
+		group.analyses = nil
+		group.nextFlush = now.Add(group.route.groupInterval)
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].group.Key < deliveries[j].group.Key })
+	return deliveries
+}
+
+// notify sends an analysis through the responder routing tree, holding it back when its
+// route groups analyses. Analyses of a pipeline only reach the pipeline's responders.
+func (f *Framework) notify(ctx context.Context, analysis *Analysis) {
+	var allowed []string
+	if p := f.pipelines.get(analysisPipeline(analysis)); p != nil {
+		allowed = p.responders
+	}
+	if f.responderRoutes == nil {
+		f.respond(ctx, analysis, allowed)
+		return
+	}
+
+	traceID := TraceIDFromContext(ctx)
+	labels := analysisLabels(analysis)
+	routes := f.responderRoutes.match(analysis, labels)
+	for _, route := range routes {
+		responders := restrictResponders(route.responders, allowed)
+		if responders != nil && len(responders) == 0 {
+			f.debugLog.Load().Record(DebugEvent{
+				TraceID:  traceID,
+				Stage:    DebugStageResponder,
+				Plugin:   analysis.Source,
+				Decision: "skipped",
+				Reason:   fmt.Sprintf("none of route %s's responders are in pipeline %s", route.id, analysisPipeline(analysis)),
+				Data:     map[string]interface{}{"analysis_id": analysis.ID, "route": route.id},
+			})
+			continue
+		}
+		if route.groupWait == 0 {
+			f.respond(ctx, analysis, responders)
+			continue
+		}
+		key := f.groups.add(route, analysis, labels, responders, time.Now())
+		f.debugLog.Load().Record(DebugEvent{
+			TraceID:  traceID,
+			Stage:    DebugStageResponder,
+			Plugin:   analysis.Source,
+			Decision: "grouped",
+			Data:     map[string]interface{}{"analysis_id": analysis.ID, "route": route.id, "group": key},
+		})
+	}
+}
+
+// groupWorker sends groups to responders as their flush times pass
+func (f *Framework) groupWorker(ctx context.Context) {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Group worker stopping due to context cancellation")
+			return
+		case now := <-ticker.C:
+			f.flushGroups(ctx, now)
+		}
+	}
+}
+
+// flushGroups sends every due group through its route
+func (f *Framework) flushGroups(ctx context.Context, now time.Time) {
+	for _, delivery := range f.groups.due(now) {
+		groupCtx, span := f.startSpan(ctx, "respond.group",
+			attribute.String("group", delivery.group.Key), attribute.Int("analyses", len(delivery.group.Analyses)))
+		f.respondGroup(groupCtx, delivery.group, delivery.responders)
+		span.End()
+	}
+}
+
+// respondGroup sends the analyses of a group that each responder handles, in one call to
+// responders implementing GroupResponder and one call per analysis to the rest
+func (f *Framework) respondGroup(ctx context.Context, group *AnalysisGroup, names []string) {
+	traceID := TraceIDFromContext(ctx)
+	for _, responder := range f.selectResponders(traceID, names) {
+		var handled []*Analysis
+		for _, analysis := range group.Analyses {
+			if responder.CanHandle(analysis) {
+				handled = append(handled, analysis)
+			}
+		}
+		if len(handled) == 0 {
+			f.debugLog.Load().Record(DebugEvent{
+				TraceID:  traceID,
+				Stage:    DebugStageResponder,
+				Plugin:   responder.Name(),
+				Decision: "skipped",
+				Reason:   fmt.Sprintf("responder handles none of the %d analyses in group %s", len(group.Analyses), group.Key),
+			})
+			continue
+		}
+
+		// Dry runs simulate each analysis so the report shows them individually, and while
+		// the responder is failed over each analysis goes to the failover
+		grouped, ok := responder.(GroupResponder)
+		_, hasFailover := f.config.Delivery.Failover[responder.Name()]
+		failingOver := hasFailover && f.deliveries.down(responder.Name())
+		if ok && !(f.config.DryRun && !isReadOnlyResponder(responder)) && !failingOver {
+			respondCtx, span := f.startSpan(ctx, "respond",
+				attribute.String("responder", responder.Name()), attribute.Int("analyses", len(handled)))
+			err := f.callResponder(respondCtx, responder.Name(), func(ctx context.Context) error {
+				return grouped.RespondGroup(ctx, &AnalysisGroup{Key: group.Key, Labels: group.Labels, Analyses: handled})
+			})
+			f.recordResponse(respondCtx, responder.Name(), err)
+			endSpan(span, err)
+			f.deliveries.recordResult(responder.Name(), err)
+			for _, analysis := range handled {
+				f.recordDelivery(responder.Name(), analysis, 1, err)
+				if err != nil {
+					// Queued analyses are retried one at a time
+					f.queueDelivery(ctx, responder.Name(), analysis, err)
+				}
+			}
+			continue
+		}
+		for _, analysis := range handled {
+			f.deliver(ctx, responder, analysis)
+		}
+	}
+}
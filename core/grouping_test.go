@@ -0,0 +1,119 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type groupingResponder struct {
+	severityResponder
+	groups []*AnalysisGroup
+}
+
+func (g *groupingResponder) RespondGroup(ctx context.Context, group *AnalysisGroup) error {
+	g.groups = append(g.groups, group)
+	return nil
+}
+
+func (g *groupingResponder) CanHandle(analysis *Analysis) bool {
+	return true
+}
+
+func serviceAnalysis(service, severity, source string) *Analysis {
+	return &Analysis{Type: AnalysisTypeAnomaly, Severity: severity, Summary: service + " " + severity, Source: source,
+		DataPoints: []DataPoint{{Metric: "latency", Labels: map[string]string{"service": service, "instance": "a"}},
+			{Metric: "latency", Labels: map[string]string{"service": service, "instance": "b"}}}}
+}
+
+func TestResponderRoutes_Match(t *testing.T) {
+	root, err := newResponderRoutes(&ResponderRouteConfig{
+		Responders: []string{"slack"},
+		Routes: []ResponderRouteConfig{
+			{Severities: []string{"critical"}, Responders: []string{"pager"}, Continue: true},
+			{Labels: `service="db"`, Responders: []string{"dba"}},
+			{Sources: []string{"latency-*"}, GroupWait: time.Minute},
+		},
+	})
+	require.NoError(t, err)
+
+	responders := func(analysis *Analysis) [][]string {
+		var names [][]string
+		for _, route := range root.match(analysis, analysisLabels(analysis)) {
+			names = append(names, route.responders)
+		}
+		return names
+	}
+
+	assert.Equal(t, [][]string{{"slack"}}, responders(serviceAnalysis("api", "low", "errors")),
+		"Expected the root to handle analyses no child matches")
+	assert.Equal(t, [][]string{{"pager"}, {"dba"}}, responders(serviceAnalysis("db", "critical", "errors")),
+		"Expected continue to keep matching later routes")
+	assert.Equal(t, [][]string{{"dba"}}, responders(serviceAnalysis("db", "low", "latency-p99")),
+		"Expected the first matching route to win")
+
+	routes := root.match(serviceAnalysis("api", "low", "latency-p99"), nil)
+	require.Len(t, routes, 1)
+	assert.Equal(t, []string{"slack"}, routes[0].responders, "Expected responders to be inherited")
+	assert.Equal(t, time.Minute, routes[0].groupInterval, "Expected group_interval to default to group_wait")
+	assert.True(t, root.grouped())
+}
+
+func TestResponderRoutes_Invalid(t *testing.T) {
+	_, err := newResponderRoutes(&ResponderRouteConfig{Routes: []ResponderRouteConfig{{Labels: `service=`}}})
+	assert.Error(t, err)
+
+	_, err = newResponderRoutes(&ResponderRouteConfig{Sources: []string{"["}})
+	assert.Error(t, err)
+
+	err = NewValidator().ValidateStruct(&ResponderRouteConfig{Routes: []ResponderRouteConfig{{Severities: []string{"urgent"}}}})
+	assert.Error(t, err, "Expected unknown severities in nested routes to be rejected")
+}
+
+func TestAnalysisLabels(t *testing.T) {
+	labels := analysisLabels(serviceAnalysis("api", "low", "errors"))
+	assert.Equal(t, map[string]string{"service": "api"}, labels, "Expected only labels shared by every data point")
+}
+
+func TestFramework_GroupedNotifications(t *testing.T) {
+	config := &FrameworkConfig{
+		LogLevel:  "info",
+		LogFormat: "text",
+		LogOutput: "stdout",
+		ResponderRoute: &ResponderRouteConfig{
+			Routes: []ResponderRouteConfig{
+				{Severities: []string{"critical"}, Responders: []string{"pager"}, Continue: true},
+				{Severities: []string{"medium", "high"}, Responders: []string{"slack"}, GroupBy: []string{"service"}, GroupWait: 30 * time.Second},
+			},
+		},
+		Plugins: []PluginConfig{},
+	}
+	framework := NewFramework(config)
+
+	slack := &groupingResponder{severityResponder: severityResponder{MockPlugin: MockPlugin{name: "slack", pluginType: PluginTypeResponder}}}
+	pager := &severityResponder{MockPlugin: MockPlugin{name: "pager", pluginType: PluginTypeResponder}, severity: "critical"}
+	require.NoError(t, framework.LoadPlugin(slack))
+	require.NoError(t, framework.LoadPlugin(pager))
+
+	ctx := context.Background()
+	framework.handleAnalysis(ctx, "latency", serviceAnalysis("api", "high", "latency"))
+	framework.handleAnalysis(ctx, "errors", serviceAnalysis("api", "critical", "errors"))
+	framework.handleAnalysis(ctx, "latency", serviceAnalysis("db", "high", "latency"))
+
+	require.Len(t, pager.handled, 1, "Expected critical analyses to page immediately")
+	assert.Empty(t, slack.groups, "Expected grouped analyses to wait for group_wait")
+
+	framework.flushGroups(ctx, time.Now().Add(31*time.Second))
+	require.Len(t, slack.groups, 2)
+	assert.Equal(t, map[string]string{"service": "api"}, slack.groups[0].Labels)
+	assert.Len(t, slack.groups[0].Analyses, 1, "Expected the critical analysis to go only to the pager route")
+	assert.Equal(t, "api high", slack.groups[0].Analyses[0].Summary)
+	assert.Equal(t, map[string]string{"service": "db"}, slack.groups[1].Labels)
+	assert.Empty(t, slack.handled, "Expected group responders to get groups, not single analyses")
+
+	framework.flushGroups(ctx, time.Now().Add(2*time.Minute))
+	assert.Len(t, slack.groups, 2, "Expected groups with nothing new not to be sent again")
+}
@@ -2,11 +2,33 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 )
 
+// degradedError is a failed health check that leaves the framework working with reduced
+// capacity
+type degradedError struct {
+	err error
+}
+
+func (e *degradedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *degradedError) Unwrap() error {
+	return e.err
+}
+
+// Degraded marks a health check failure as degrading the framework rather than making it
+// unhealthy, such as one plugin of several failing
+func Degraded(err error) error {
+	return &degradedError{err: err}
+}
+
 // DefaultHealthChecker is the default implementation of HealthChecker
 type DefaultHealthChecker struct {
 	checks  map[string]HealthCheckFunc
@@ -92,6 +114,14 @@ func (h *DefaultHealthChecker) runHealthCheck(ctx context.Context, name string,
 	// Wait for result or timeout
 	select {
 	case err := <-resultChan:
+		var degraded *degradedError
+		if errors.As(err, &degraded) {
+			return CheckResult{
+				Status:  "degraded",
+				Message: "Health check degraded",
+				Error:   err.Error(),
+			}
+		}
 		if err != nil {
 			return CheckResult{
 				Status:  "unhealthy",
@@ -142,38 +172,37 @@ func (f *FrameworkHealthChecker) registerDefaultChecks() {
 		return nil
 	})
 
-	// Plugin health checks
+	// Plugin health checks: some failing plugins degrade the framework, all of them failing
+	// makes it unhealthy
 	f.RegisterHealthCheck("plugins_healthy", func(ctx context.Context) error {
-		status := f.framework.GetStatus()
-		plugins := status["plugins"].(map[string]interface{})
+		plugins := f.framework.PluginStates(ctx)
 
 		unhealthyPlugins := make([]string, 0)
-		for name, pluginInfo := range plugins {
-			info := pluginInfo.(map[string]interface{})
-			if pluginStatus, ok := info["status"].(string); !ok || pluginStatus == string(PluginStatusError) {
-				unhealthyPlugins = append(unhealthyPlugins, name)
+		for _, plugin := range plugins {
+			if !plugin.Healthy || plugin.Status == PluginStatusError {
+				unhealthyPlugins = append(unhealthyPlugins, plugin.Name)
 			}
 		}
 
-		if len(unhealthyPlugins) > 0 {
-			return NewInternalError("framework", "health",
-				fmt.Sprintf("unhealthy plugins: %v", unhealthyPlugins))
+		if len(unhealthyPlugins) == 0 {
+			return nil
 		}
-		return nil
+		err := NewInternalError("framework", "health",
+			fmt.Sprintf("unhealthy plugins: %s", strings.Join(unhealthyPlugins, ", ")))
+		if len(unhealthyPlugins) < len(plugins) {
+			return Degraded(err)
+		}
+		return err
 	})
 
-	// Data channel health check
+	// Data channel health check: a channel that stays full means collected data is backing
+	// up faster than it is processed
 	f.RegisterHealthCheck("data_channel", func(ctx context.Context) error {
-		// Check if data channel is not blocked
-		select {
-		case <-f.framework.GetDataChannel():
-			// Channel is not blocked
-			return nil
-		default:
-			// Channel might be blocked, but this is not necessarily an error
-			// We'll consider it healthy for now
-			return nil
+		if f.framework.backpressure.isDegraded() {
+			return Degraded(NewInternalError("framework", "health",
+				fmt.Sprintf("data channel has stayed full, overflow policy %s applies", f.framework.overflowPolicy())))
 		}
+		return nil
 	})
 }
 
@@ -0,0 +1,359 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Retention used when none is configured
+const (
+	defaultAnalysisRetention  = 24 * time.Hour
+	defaultDataPointRetention = time.Hour
+)
+
+// Result limits of history queries
+const (
+	defaultHistoryLimit = 1000
+	maxHistoryLimit     = 10000
+)
+
+// historyPruneInterval bounds how often expired analyses are deleted
+const historyPruneInterval = time.Minute
+
+// AnalysisHistory keeps the analyses the pipeline produced in the framework's store
+type AnalysisHistory struct {
+	store     Store
+	retention time.Duration
+	lastPrune time.Time
+	mu        sync.Mutex
+}
+
+// NewAnalysisHistory creates a history that keeps analyses for the retention period
+func NewAnalysisHistory(store Store, retention time.Duration) *AnalysisHistory {
+	if retention <= 0 {
+		retention = defaultAnalysisRetention
+	}
+	return &AnalysisHistory{store: store, retention: retention}
+}
+
+// historyKey orders analyses by time so expired ones are found first
+func historyKey(analysis *Analysis) string {
+	at := analysis.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	return fmt.Sprintf("%020d-%s", at.UnixNano(), analysis.ID)
+}
+
+// historyKeyTime returns the time encoded in a history key
+func historyKeyTime(key string) time.Time {
+	nanos, err := strconv.ParseInt(strings.SplitN(key, "-", 2)[0], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// Record stores an analysis and occasionally deletes analyses older than the retention
+func (h *AnalysisHistory) Record(ctx context.Context, analysis *Analysis) error {
+	if h == nil {
+		return nil
+	}
+	analysis.EnsureIdentity()
+	if err := PutJSON(ctx, h.store, StoreCollectionAnalyses, historyKey(analysis), analysis); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	if now.Sub(h.lastPrune) < historyPruneInterval {
+		return nil
+	}
+	h.lastPrune = now
+	return h.prune(ctx, now.Add(-h.retention))
+}
+
+// prune deletes analyses recorded before the cutoff
+func (h *AnalysisHistory) prune(ctx context.Context, cutoff time.Time) error {
+	return pruneHistory(ctx, h.store, StoreCollectionAnalyses, cutoff)
+}
+
+// pruneHistory deletes the records of a collection with time-ordered keys from before the
+// cutoff
+func pruneHistory(ctx context.Context, store Store, collection string, cutoff time.Time) error {
+	records, err := store.List(ctx, collection)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if !historyKeyTime(record.Key).Before(cutoff) {
+			break
+		}
+		if err := store.Delete(ctx, collection, record.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the analyses recorded since the given time, oldest first
+func (h *AnalysisHistory) List(ctx context.Context, since time.Time) ([]Analysis, error) {
+	if h == nil {
+		return nil, nil
+	}
+	var analyses []Analysis
+	err := ListJSON(ctx, h.store, StoreCollectionAnalyses, func(key string, unmarshal func(v interface{}) error) error {
+		if historyKeyTime(key).Before(since) {
+			return nil
+		}
+		var analysis Analysis
+		if err := unmarshal(&analysis); err != nil {
+			return err
+		}
+		analyses = append(analyses, analysis)
+		return nil
+	})
+	return analyses, err
+}
+
+// Query returns the analyses matching the query, oldest first; when more match than the
+// limit, the latest are returned
+func (h *AnalysisHistory) Query(ctx context.Context, query HistoryQuery) ([]Analysis, error) {
+	if h == nil {
+		return nil, nil
+	}
+	var analyses []Analysis
+	err := ListJSON(ctx, h.store, StoreCollectionAnalyses, func(key string, unmarshal func(v interface{}) error) error {
+		if at := historyKeyTime(key); at.Before(query.Start) || (!query.End.IsZero() && at.After(query.End)) {
+			return nil
+		}
+		var analysis Analysis
+		if err := unmarshal(&analysis); err != nil {
+			return err
+		}
+		if query.matchesAnalysis(&analysis) {
+			analyses = append(analyses, analysis)
+		}
+		return nil
+	})
+	if limit := query.limit(); len(analyses) > limit {
+		analyses = analyses[len(analyses)-limit:]
+	}
+	return analyses, err
+}
+
+// DataPointHistory keeps the batches the pipeline processed in the framework's store
+type DataPointHistory struct {
+	store     Store
+	retention time.Duration
+	lastPrune time.Time
+	seq       uint64
+	mu        sync.Mutex
+}
+
+// NewDataPointHistory creates a history that keeps data points for the retention period
+func NewDataPointHistory(store Store, retention time.Duration) *DataPointHistory {
+	if retention <= 0 {
+		retention = defaultDataPointRetention
+	}
+	return &DataPointHistory{store: store, retention: retention}
+}
+
+// Record stores a batch and occasionally deletes batches older than the retention
+func (h *DataPointHistory) Record(ctx context.Context, data []DataPoint) error {
+	if h == nil || len(data) == 0 {
+		return nil
+	}
+	h.mu.Lock()
+	h.seq++
+	now := time.Now()
+	key := fmt.Sprintf("%020d-%06d", now.UnixNano(), h.seq%1000000)
+	prune := now.Sub(h.lastPrune) >= historyPruneInterval
+	if prune {
+		h.lastPrune = now
+	}
+	h.mu.Unlock()
+
+	if err := PutJSON(ctx, h.store, StoreCollectionDataPoints, key, data); err != nil {
+		return err
+	}
+	if !prune {
+		return nil
+	}
+	return pruneHistory(ctx, h.store, StoreCollectionDataPoints, now.Add(-h.retention))
+}
+
+// Query returns the data points matching the query in the order they were processed; when
+// more match than the limit, the latest are returned
+func (h *DataPointHistory) Query(ctx context.Context, query HistoryQuery) ([]DataPoint, error) {
+	if h == nil {
+		return nil, nil
+	}
+	var points []DataPoint
+	err := ListJSON(ctx, h.store, StoreCollectionDataPoints, func(key string, unmarshal func(v interface{}) error) error {
+		// A batch holds points from before it was processed, never after
+		if historyKeyTime(key).Before(query.Start) {
+			return nil
+		}
+		var batch []DataPoint
+		if err := unmarshal(&batch); err != nil {
+			return err
+		}
+		for _, point := range batch {
+			if query.matchesPoint(point) {
+				points = append(points, point)
+			}
+		}
+		return nil
+	})
+	if limit := query.limit(); len(points) > limit {
+		points = points[len(points)-limit:]
+	}
+	return points, err
+}
+
+// HistoryQuery selects data points or analyses from the history
+type HistoryQuery struct {
+	// Time range; a zero End is open-ended
+	Start time.Time
+	End   time.Time
+	// Glob over metric names; an analysis matches if one of its data points does
+	Metric string
+	// Glob over the collector a data point came from or the analyzer that produced an analysis
+	Source string
+	// Matchers must match a data point's labels, or the labels every data point of an
+	// analysis shares
+	Matchers []LabelMatcher
+	// Drops less severe analyses
+	MinSeverity string
+	// Most results returned; zero is the default limit
+	Limit int
+}
+
+// limit returns the effective result limit
+func (q HistoryQuery) limit() int {
+	if q.Limit <= 0 {
+		return defaultHistoryLimit
+	}
+	return q.Limit
+}
+
+// inRange reports whether a time falls in the query's range
+func (q HistoryQuery) inRange(at time.Time) bool {
+	return !at.Before(q.Start) && (q.End.IsZero() || !at.After(q.End))
+}
+
+// matchesPoint reports whether a data point passes the query
+func (q HistoryQuery) matchesPoint(point DataPoint) bool {
+	if !q.inRange(point.Timestamp) || !globMatches(q.Metric, point.Metric) || !globMatches(q.Source, point.Source) {
+		return false
+	}
+	for _, matcher := range q.Matchers {
+		if !matcher.Matches(point.Labels[matcher.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAnalysis reports whether an analysis passes the query
+func (q HistoryQuery) matchesAnalysis(analysis *Analysis) bool {
+	if !q.inRange(analysis.Timestamp) || !globMatches(q.Source, analysis.Source) {
+		return false
+	}
+	if q.MinSeverity != "" && severityOrder[analysis.Severity] < severityOrder[q.MinSeverity] {
+		return false
+	}
+	if q.Metric != "" {
+		matched := false
+		for _, point := range analysis.DataPoints {
+			if globMatches(q.Metric, point.Metric) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(q.Matchers) > 0 {
+		labels := analysisLabels(analysis)
+		for _, matcher := range q.Matchers {
+			if !matcher.Matches(labels[matcher.Name]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// globMatches reports whether value matches pattern; an empty pattern matches everything
+func globMatches(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, value)
+	return ok
+}
+
+// parseHistoryQuery reads a history query from the start (or since), end, metric, source,
+// labels, severity, and limit query parameters. Times are RFC 3339 or a duration before now.
+func parseHistoryQuery(r *http.Request, now time.Time) (HistoryQuery, error) {
+	params := r.URL.Query()
+	var query HistoryQuery
+
+	parseTime := func(name, raw string) (time.Time, error) {
+		if raw == "" {
+			return time.Time{}, nil
+		}
+		if window, err := time.ParseDuration(raw); err == nil {
+			return now.Add(-window), nil
+		}
+		at, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return time.Time{}, NewValidationError("history", "query", fmt.Sprintf("%s must be an RFC 3339 time or a duration", name))
+		}
+		return at, nil
+	}
+	start := params.Get("start")
+	if start == "" {
+		start = params.Get("since")
+	}
+	var err error
+	if query.Start, err = parseTime("start", start); err != nil {
+		return query, err
+	}
+	if query.End, err = parseTime("end", params.Get("end")); err != nil {
+		return query, err
+	}
+
+	query.Metric = params.Get("metric")
+	query.Source = params.Get("source")
+	for name, pattern := range map[string]string{"metric": query.Metric, "source": query.Source} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return query, NewValidationError("history", "query", fmt.Sprintf("invalid %s pattern %q", name, pattern))
+		}
+	}
+	if selector := params.Get("labels"); selector != "" {
+		if query.Matchers, err = ParseMatchers(selector); err != nil {
+			return query, err
+		}
+	}
+	query.MinSeverity = params.Get("severity")
+	if _, ok := severityOrder[query.MinSeverity]; query.MinSeverity != "" && !ok {
+		return query, NewValidationError("history", "query", fmt.Sprintf("unknown severity %q", query.MinSeverity))
+	}
+	if raw := params.Get("limit"); raw != "" {
+		if query.Limit, err = strconv.Atoi(raw); err != nil || query.Limit < 1 || query.Limit > maxHistoryLimit {
+			return query, NewValidationError("history", "query", fmt.Sprintf("limit must be between 1 and %d", maxHistoryLimit))
+		}
+	}
+	return query, nil
+}
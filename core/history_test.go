@@ -0,0 +1,147 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataPointHistory_Query(t *testing.T) {
+	ctx := context.Background()
+	history := NewDataPointHistory(NewMemoryStore(), time.Hour)
+
+	now := time.Now()
+	require.NoError(t, history.Record(ctx, []DataPoint{
+		{Source: "prometheus", Metric: "cpu_usage", Labels: map[string]string{"service": "api"}, Timestamp: now.Add(-10 * time.Minute)},
+		{Source: "prometheus", Metric: "memory_usage", Labels: map[string]string{"service": "api"}, Timestamp: now.Add(-10 * time.Minute)},
+	}))
+	require.NoError(t, history.Record(ctx, []DataPoint{
+		{Source: "node", Metric: "cpu_usage", Labels: map[string]string{"service": "web"}, Timestamp: now.Add(-time.Minute)},
+	}))
+
+	matchers, err := ParseMatchers(`{service="api"}`)
+	require.NoError(t, err)
+	tests := []struct {
+		name     string
+		query    HistoryQuery
+		expected []string
+	}{
+		{name: "all", expected: []string{"prometheus/cpu_usage", "prometheus/memory_usage", "node/cpu_usage"}},
+		{name: "metric", query: HistoryQuery{Metric: "cpu_*"}, expected: []string{"prometheus/cpu_usage", "node/cpu_usage"}},
+		{name: "source", query: HistoryQuery{Source: "node"}, expected: []string{"node/cpu_usage"}},
+		{name: "labels", query: HistoryQuery{Matchers: matchers}, expected: []string{"prometheus/cpu_usage", "prometheus/memory_usage"}},
+		{name: "start", query: HistoryQuery{Start: now.Add(-5 * time.Minute)}, expected: []string{"node/cpu_usage"}},
+		{name: "end", query: HistoryQuery{End: now.Add(-5 * time.Minute)}, expected: []string{"prometheus/cpu_usage", "prometheus/memory_usage"}},
+		{name: "limit keeps the latest", query: HistoryQuery{Limit: 2}, expected: []string{"prometheus/memory_usage", "node/cpu_usage"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, err := history.Query(ctx, tt.query)
+			require.NoError(t, err)
+			var got []string
+			for _, point := range points {
+				got = append(got, point.Source+"/"+point.Metric)
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestAnalysisHistory_Query(t *testing.T) {
+	ctx := context.Background()
+	history := NewAnalysisHistory(NewMemoryStore(), time.Hour)
+
+	now := time.Now()
+	api := testAnalysis("cpu_usage")
+	api.Summary = "api"
+	api.Severity = "low"
+	api.Timestamp = now.Add(-10 * time.Minute)
+	api.DataPoints[0].Labels = map[string]string{"service": "api"}
+	web := testAnalysis("memory_usage")
+	web.Summary = "web"
+	web.Source = "threshold"
+	web.Timestamp = now.Add(-time.Minute)
+	web.DataPoints[0].Labels = map[string]string{"service": "web"}
+	require.NoError(t, history.Record(ctx, api))
+	require.NoError(t, history.Record(ctx, web))
+
+	matchers, err := ParseMatchers(`{service="api"}`)
+	require.NoError(t, err)
+	tests := []struct {
+		name     string
+		query    HistoryQuery
+		expected []string
+	}{
+		{name: "all", expected: []string{"api", "web"}},
+		{name: "metric", query: HistoryQuery{Metric: "memory_*"}, expected: []string{"web"}},
+		{name: "source", query: HistoryQuery{Source: "anomaly-*"}, expected: []string{"api"}},
+		{name: "labels", query: HistoryQuery{Matchers: matchers}, expected: []string{"api"}},
+		{name: "severity", query: HistoryQuery{MinSeverity: "medium"}, expected: []string{"web"}},
+		{name: "time range", query: HistoryQuery{Start: now.Add(-time.Hour), End: now.Add(-5 * time.Minute)}, expected: []string{"api"}},
+		{name: "limit keeps the latest", query: HistoryQuery{Limit: 1}, expected: []string{"web"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyses, err := history.Query(ctx, tt.query)
+			require.NoError(t, err)
+			var got []string
+			for _, analysis := range analyses {
+				got = append(got, analysis.Summary)
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestFramework_HistoryAPI(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout"})
+	ctx := context.Background()
+	framework.processData(ctx, []DataPoint{
+		{Source: "prometheus", Metric: "cpu_usage", Value: 90, Labels: map[string]string{"service": "api"}, Timestamp: time.Now()},
+		{Source: "prometheus", Metric: "memory_usage", Value: 40, Labels: map[string]string{"service": "api"}, Timestamp: time.Now()},
+	})
+	require.NoError(t, framework.GetAnalysisHistory().Record(ctx, testAnalysis("cpu_usage")))
+
+	get := func(path string, query url.Values) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		framework.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path+"?"+query.Encode(), nil))
+		return recorder
+	}
+
+	recorder := get("/api/v1/datapoints", url.Values{"metric": {"cpu_*"}, "labels": {`{service="api"}`}, "start": {"1h"}})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var points []DataPoint
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &points))
+	require.Len(t, points, 1)
+	assert.Equal(t, "cpu_usage", points[0].Metric)
+
+	recorder = get("/api/v1/analyses", url.Values{"metric": {"cpu_usage"}, "severity": {"high"}, "since": {"1h"}})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var analyses []Analysis
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &analyses))
+	assert.Len(t, analyses, 1)
+
+	recorder = get("/api/v1/analyses", url.Values{"metric": {"disk_*"}})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, "[]", recorder.Body.String())
+
+	for _, query := range []url.Values{
+		{"start": {"yesterday"}},
+		{"metric": {"["}},
+		{"limit": {"0"}},
+		{"labels": {"{service"}},
+	} {
+		assert.Equal(t, http.StatusBadRequest, get("/api/v1/analyses", query).Code, "Expected %v to be rejected", query)
+	}
+	assert.Equal(t, http.StatusBadRequest, get("/api/v1/datapoints", url.Values{"severity": {"high"}}).Code,
+		"Expected severity to be rejected for data points")
+}
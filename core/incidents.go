@@ -0,0 +1,251 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IncidentStatus represents the lifecycle state of an incident
+type IncidentStatus string
+
+const (
+	IncidentStatusOpen         IncidentStatus = "open"
+	IncidentStatusAcknowledged IncidentStatus = "acknowledged"
+	IncidentStatusSilenced     IncidentStatus = "silenced"
+	IncidentStatusResolved     IncidentStatus = "resolved"
+)
+
+// IncidentEvent is a single entry in an incident's timeline
+type IncidentEvent struct {
+	Type      string    `json:"type"`
+	Actor     string    `json:"actor,omitempty"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Incident groups repeated analyses with the same fingerprint
+type Incident struct {
+	ID             string          `json:"id"`
+	Fingerprint    string          `json:"fingerprint"`
+	Status         IncidentStatus  `json:"status"`
+	Summary        string          `json:"summary"`
+	Severity       string          `json:"severity"`
+	Source         string          `json:"source"`
+	Occurrences    int             `json:"occurrences"`
+	LastAnalysisID string          `json:"last_analysis_id"`
+	OpenedAt       time.Time       `json:"opened_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	AcknowledgedBy string          `json:"acknowledged_by,omitempty"`
+	SilencedUntil  time.Time       `json:"silenced_until,omitempty"`
+	PagedTo        []string        `json:"paged_to,omitempty"`
+	Timeline       []IncidentEvent `json:"timeline"`
+}
+
+// IncidentManager tracks incidents opened by analyses and their acknowledgement state
+type IncidentManager struct {
+	incidents     map[string]*Incident
+	byFingerprint map[string]string
+	nextID        int
+	store         Store
+	mu            sync.RWMutex
+}
+
+// NewIncidentManager creates a new incident manager
+func NewIncidentManager() *IncidentManager {
+	return &IncidentManager{
+		incidents:     make(map[string]*Incident),
+		byFingerprint: make(map[string]string),
+	}
+}
+
+// NewIncidentManagerWithStore creates an incident manager that persists incidents in the
+// store, restoring the ones already stored
+func NewIncidentManagerWithStore(ctx context.Context, store Store) (*IncidentManager, error) {
+	m := NewIncidentManager()
+	m.store = store
+
+	err := ListJSON(ctx, store, StoreCollectionIncidents, func(key string, unmarshal func(v interface{}) error) error {
+		var incident Incident
+		if err := unmarshal(&incident); err != nil {
+			return err
+		}
+		m.incidents[incident.ID] = &incident
+		// Resolved incidents never take new occurrences, so an open one wins the fingerprint
+		if current, ok := m.byFingerprint[incident.Fingerprint]; !ok || m.incidents[current].Status == IncidentStatusResolved {
+			m.byFingerprint[incident.Fingerprint] = incident.ID
+		}
+		if n, err := strconv.Atoi(strings.TrimPrefix(incident.ID, "INC-")); err == nil && n > m.nextID {
+			m.nextID = n
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// persist writes an incident to the store, if there is one. Callers hold the lock.
+func (m *IncidentManager) persist(incident *Incident) {
+	if m.store == nil {
+		return
+	}
+	if err := PutJSON(context.Background(), m.store, StoreCollectionIncidents, incident.ID, incident); err != nil {
+		slog.Error("Failed to persist incident", "incident", incident.ID, "error", err)
+	}
+}
+
+// Track records an analysis against its incident, opening one if needed. It
+// returns a copy of the incident and whether notifications should be suppressed.
+func (m *IncidentManager) Track(analysis *Analysis) (Incident, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	analysis.EnsureIdentity()
+	fingerprint := analysis.Fingerprint
+
+	if id, ok := m.byFingerprint[fingerprint]; ok {
+		incident := m.incidents[id]
+		if incident.Status != IncidentStatusResolved {
+			incident.Occurrences++
+			incident.UpdatedAt = now
+			incident.Severity = analysis.Severity
+			incident.Summary = analysis.Summary
+			incident.LastAnalysisID = analysis.ID
+
+			if incident.Status == IncidentStatusSilenced && now.After(incident.SilencedUntil) {
+				incident.Status = IncidentStatusOpen
+				incident.addEvent("unsilenced", "", "Silence expired", now)
+			}
+			m.persist(incident)
+			return *incident, incident.Status == IncidentStatusSilenced
+		}
+	}
+
+	m.nextID++
+	incident := &Incident{
+		ID:             fmt.Sprintf("INC-%d", m.nextID),
+		Fingerprint:    fingerprint,
+		Status:         IncidentStatusOpen,
+		Summary:        analysis.Summary,
+		Severity:       analysis.Severity,
+		Source:         analysis.Source,
+		Occurrences:    1,
+		LastAnalysisID: analysis.ID,
+		OpenedAt:       now,
+		UpdatedAt:      now,
+	}
+	incident.addEvent("opened", analysis.Source, analysis.Summary, now)
+
+	m.incidents[incident.ID] = incident
+	m.byFingerprint[fingerprint] = incident.ID
+	m.persist(incident)
+	return *incident, false
+}
+
+// Acknowledge marks an incident as acknowledged by the given actor
+func (m *IncidentManager) Acknowledge(id, actor string) (Incident, error) {
+	return m.update(id, "acknowledge", func(incident *Incident, now time.Time) {
+		incident.Status = IncidentStatusAcknowledged
+		incident.AcknowledgedBy = actor
+		incident.addEvent("acknowledged", actor, "Incident acknowledged", now)
+	})
+}
+
+// Silence suppresses notifications for an incident for the given duration
+func (m *IncidentManager) Silence(id, actor string, duration time.Duration) (Incident, error) {
+	return m.update(id, "silence", func(incident *Incident, now time.Time) {
+		incident.Status = IncidentStatusSilenced
+		incident.SilencedUntil = now.Add(duration)
+		incident.addEvent("silenced", actor, fmt.Sprintf("Silenced for %s", duration), now)
+	})
+}
+
+// Resolve closes an incident
+func (m *IncidentManager) Resolve(id, actor string) (Incident, error) {
+	return m.update(id, "resolve", func(incident *Incident, now time.Time) {
+		incident.Status = IncidentStatusResolved
+		incident.addEvent("resolved", actor, "Incident resolved", now)
+	})
+}
+
+// Annotate appends a free-form event to an incident's timeline
+func (m *IncidentManager) Annotate(id, eventType, actor, message string) (Incident, error) {
+	return m.update(id, "annotate", func(incident *Incident, now time.Time) {
+		incident.addEvent(eventType, actor, message, now)
+	})
+}
+
+// RecordPage notes that the given person was notified about an incident
+func (m *IncidentManager) RecordPage(id string, person OnCallPerson) (Incident, error) {
+	return m.update(id, "page", func(incident *Incident, now time.Time) {
+		for _, name := range incident.PagedTo {
+			if name == person.Name {
+				return
+			}
+		}
+		incident.PagedTo = append(incident.PagedTo, person.Name)
+		incident.addEvent("paged", person.Name, fmt.Sprintf("Paged %s (%s)", person.Name, person.Schedule), now)
+	})
+}
+
+// Get returns a copy of the incident with the given ID
+func (m *IncidentManager) Get(id string) (Incident, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	incident, ok := m.incidents[id]
+	if !ok {
+		return Incident{}, NewValidationError("incidents", "get", fmt.Sprintf("incident %s not found", id))
+	}
+	return *incident, nil
+}
+
+// List returns copies of all incidents, most recently updated first
+func (m *IncidentManager) List() []Incident {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	incidents := make([]Incident, 0, len(m.incidents))
+	for _, incident := range m.incidents {
+		incidents = append(incidents, *incident)
+	}
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[i].UpdatedAt.After(incidents[j].UpdatedAt)
+	})
+	return incidents
+}
+
+// update applies a mutation to an incident under lock
+func (m *IncidentManager) update(id, operation string, mutate func(*Incident, time.Time)) (Incident, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	incident, ok := m.incidents[id]
+	if !ok {
+		return Incident{}, NewValidationError("incidents", operation, fmt.Sprintf("incident %s not found", id))
+	}
+
+	now := time.Now()
+	mutate(incident, now)
+	incident.UpdatedAt = now
+	m.persist(incident)
+	return *incident, nil
+}
+
+// addEvent appends an event to the incident timeline
+func (i *Incident) addEvent(eventType, actor, message string, at time.Time) {
+	i.Timeline = append(i.Timeline, IncidentEvent{
+		Type:      eventType,
+		Actor:     actor,
+		Message:   message,
+		Timestamp: at,
+	})
+}
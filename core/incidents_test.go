@@ -0,0 +1,127 @@
+package core
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAnalysis(metric string) *Analysis {
+	return &Analysis{
+		Type:       AnalysisTypeAnomaly,
+		Severity:   "high",
+		Summary:    "cpu spike",
+		Source:     "anomaly-analyzer",
+		DataPoints: []DataPoint{{Metric: metric, Value: 99}},
+		Timestamp:  time.Now(),
+	}
+}
+
+func TestIncidentManager_TrackGroupsByFingerprint(t *testing.T) {
+	manager := NewIncidentManager()
+
+	first, suppressed := manager.Track(testAnalysis("cpu"))
+	assert.False(t, suppressed)
+	second, _ := manager.Track(testAnalysis("cpu"))
+	other, _ := manager.Track(testAnalysis("memory"))
+
+	assert.Equal(t, first.ID, second.ID, "Expected repeated analysis to join the open incident")
+	assert.Equal(t, 2, second.Occurrences)
+	assert.NotEqual(t, first.ID, other.ID, "Expected different metric to open a new incident")
+	assert.NotEqual(t, first.LastAnalysisID, second.LastAnalysisID, "Expected the incident to reference the latest occurrence")
+}
+
+func TestIncidentManager_SilenceSuppresses(t *testing.T) {
+	manager := NewIncidentManager()
+	incident, _ := manager.Track(testAnalysis("cpu"))
+
+	_, err := manager.Silence(incident.ID, "alice", time.Hour)
+	require.NoError(t, err)
+
+	_, suppressed := manager.Track(testAnalysis("cpu"))
+	assert.True(t, suppressed, "Expected silenced incident to suppress notifications")
+
+	_, err = manager.Acknowledge("INC-404", "alice")
+	assert.Error(t, err, "Expected error for unknown incident")
+}
+
+func TestFramework_SlackInteraction(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{SlackSigningSecret: "secret"})
+	incident, _ := framework.GetIncidentManager().Track(testAnalysis("cpu"))
+
+	value, _ := json.Marshal(SlackActionValue{IncidentID: incident.ID})
+	payload := fmt.Sprintf(`{"type":"block_actions","user":{"id":"U1","username":"alice"},"actions":[{"action_id":"ack","value":%q}]}`, value)
+	body := url.Values{"payload": {payload}}.Encode()
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/interactions/slack", strings.NewReader(body))
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	rec := httptest.NewRecorder()
+	framework.handleSlackInteraction(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	updated, err := framework.GetIncidentManager().Get(incident.ID)
+	require.NoError(t, err)
+	assert.Equal(t, IncidentStatusAcknowledged, updated.Status)
+	assert.Equal(t, "alice", updated.AcknowledgedBy)
+
+	// Tampered signatures are rejected
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/interactions/slack", strings.NewReader(body))
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0=deadbeef")
+	rec = httptest.NewRecorder()
+	framework.handleSlackInteraction(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+type acknowledgingResponder struct {
+	severityResponder
+	acknowledged []string
+}
+
+func (a *acknowledgingResponder) Acknowledge(ctx context.Context, incident Incident, actor string) error {
+	a.acknowledged = append(a.acknowledged, incident.Fingerprint+" by "+actor)
+	return nil
+}
+
+func TestFramework_AcknowledgeIncident(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{})
+	responder := &acknowledgingResponder{severityResponder: severityResponder{MockPlugin: MockPlugin{name: "pager", pluginType: PluginTypeResponder}}}
+	require.NoError(t, framework.LoadPlugin(responder))
+
+	incident, _ := framework.GetIncidentManager().Track(testAnalysis("cpu"))
+	acknowledged, err := framework.AcknowledgeIncident(context.Background(), incident.ID, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, IncidentStatusAcknowledged, acknowledged.Status)
+	assert.Equal(t, []string{incident.Fingerprint + " by alice"}, responder.acknowledged)
+
+	_, err = framework.AcknowledgeIncident(context.Background(), "INC-404", "alice")
+	assert.Error(t, err)
+	assert.Len(t, responder.acknowledged, 1)
+
+	dryRun := NewFramework(&FrameworkConfig{DryRun: true})
+	simulated := &acknowledgingResponder{severityResponder: severityResponder{MockPlugin: MockPlugin{name: "pager", pluginType: PluginTypeResponder}}}
+	require.NoError(t, dryRun.LoadPlugin(simulated))
+	incident, _ = dryRun.GetIncidentManager().Track(testAnalysis("cpu"))
+	_, err = dryRun.AcknowledgeIncident(context.Background(), incident.ID, "alice")
+	require.NoError(t, err)
+	assert.Empty(t, simulated.acknowledged, "Expected dry runs not to acknowledge in external systems")
+	assert.Equal(t, "acknowledge", dryRun.GetDryRunReport().Summary().Entries[0].Action)
+}
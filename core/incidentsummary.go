@@ -0,0 +1,56 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// IncidentSummaryProvider gives plugins that write up incidents what they need: the history
+// around an analysis, an agent to write the summary, and responders to post it through
+type IncidentSummaryProvider interface {
+	AgentToolProvider
+
+	// QueryAgent processes a query through the named agent
+	QueryAgent(ctx context.Context, agentName, query string) (*AgentResponse, error)
+	// DefaultAgent returns the name of the agent queries go to when none is named
+	DefaultAgent() string
+	// PostAnalysis delivers an analysis to the named responders without tracking it as an
+	// incident
+	PostAnalysis(ctx context.Context, analysis *Analysis, responders []string) error
+}
+
+// IncidentSummaryAware is implemented by plugins that summarize incidents. The framework
+// provides itself as the provider when the plugin is loaded.
+type IncidentSummaryAware interface {
+	SetIncidentSummaryProvider(provider IncidentSummaryProvider)
+}
+
+// PostAnalysis delivers an analysis to the named responders, like the noise report, without
+// tracking it as an incident or passing it through dedup and routing
+func (f *Framework) PostAnalysis(ctx context.Context, analysis *Analysis, responders []string) error {
+	if len(responders) == 0 {
+		return NewValidationError("framework", "post-analysis", "no responders given")
+	}
+	var missing []string
+	for _, name := range responders {
+		plugin, err := f.registry.GetPlugin(name)
+		if err != nil {
+			missing = append(missing, name)
+			continue
+		}
+		if _, ok := plugin.(DataResponder); !ok {
+			return NewPluginError("framework", "post-analysis", fmt.Sprintf("plugin %s is not a responder", name))
+		}
+	}
+	if len(missing) > 0 {
+		return NewPluginError("framework", "post-analysis", fmt.Sprintf("responders not found: %s", strings.Join(missing, ", ")))
+	}
+
+	analysis.EnsureIdentity()
+	if TraceIDFromContext(ctx) == "" {
+		ctx = WithTraceID(ctx, NewTraceID())
+	}
+	f.respond(ctx, analysis, responders)
+	return nil
+}
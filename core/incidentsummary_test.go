@@ -0,0 +1,41 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// summaryPlugin records the provider it is given
+type summaryPlugin struct {
+	MockPlugin
+	provider IncidentSummaryProvider
+}
+
+func (p *summaryPlugin) SetIncidentSummaryProvider(provider IncidentSummaryProvider) {
+	p.provider = provider
+}
+
+func TestFramework_PostAnalysis(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout"})
+	slack := &severityResponder{MockPlugin: MockPlugin{name: "slack", pluginType: PluginTypeResponder}, severity: "high"}
+	other := &severityResponder{MockPlugin: MockPlugin{name: "other", pluginType: PluginTypeResponder}, severity: "high"}
+	summarizer := &summaryPlugin{MockPlugin: MockPlugin{name: "summarizer", pluginType: PluginTypeResponder}}
+	require.NoError(t, framework.LoadPlugin(slack))
+	require.NoError(t, framework.LoadPlugin(other))
+	require.NoError(t, framework.LoadPlugin(summarizer))
+	require.NotNil(t, summarizer.provider, "Expected the framework to provide itself")
+
+	ctx := context.Background()
+	summary := &Analysis{Type: AnalysisTypeIncidentSummary, Severity: "high", Summary: "cpu saturated", Source: "summarizer"}
+	require.NoError(t, summarizer.provider.PostAnalysis(ctx, summary, []string{"slack"}))
+	require.Len(t, slack.handled, 1)
+	assert.Empty(t, other.handled, "Expected the analysis to go to the named responders only")
+	assert.NotEmpty(t, slack.handled[0].ID, "Expected the analysis to be given an identity")
+	assert.Empty(t, framework.GetIncidentManager().List(), "Expected no incident to be tracked")
+
+	assert.ErrorContains(t, framework.PostAnalysis(ctx, summary, []string{"slack", "teams"}), "teams")
+	assert.Error(t, framework.PostAnalysis(ctx, summary, nil))
+}
@@ -27,6 +27,7 @@ type ConfigurationManager interface {
 // MetricsCollector collects and exposes framework metrics
 type MetricsCollector interface {
 	IncrementCounter(name string, labels map[string]string)
+	AddCounter(name string, value float64, labels map[string]string)
 	SetGauge(name string, value float64, labels map[string]string)
 	ObserveHistogram(name string, value float64, labels map[string]string)
 	GetMetrics() map[string]interface{}
@@ -60,10 +61,15 @@ type CheckResult struct {
 // EventBus handles event publishing and subscription
 type EventBus interface {
 	Publish(event Event) error
-	Subscribe(eventType string, handler EventHandler) error
-	Unsubscribe(eventType string, handler EventHandler) error
+	// Subscribe returns the ID that Unsubscribe removes the subscription by
+	Subscribe(eventType string, handler EventHandler) (SubscriptionID, error)
+	Unsubscribe(id SubscriptionID) error
 }
 
+// SubscriptionID identifies one subscription to an event bus. Handlers are not comparable,
+// so two plugins subscribing the same method or closure are told apart by their IDs.
+type SubscriptionID uint64
+
 // Event represents a framework event
 type Event struct {
 	Type      string                 `json:"type"`
@@ -0,0 +1,180 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultKnowledgePage and maxKnowledgePage bound how many documents a listing returns
+	defaultKnowledgePage = 100
+	maxKnowledgePage     = 1000
+	// defaultKnowledgeResults and maxKnowledgeResults bound how many documents a search returns
+	defaultKnowledgeResults = 5
+	maxKnowledgeResults     = 50
+)
+
+// KnowledgeDocument is a document in an agent's knowledge base
+type KnowledgeDocument struct {
+	ID        string                 `json:"id"`
+	Content   string                 `json:"content"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// KnowledgePage is one page of the documents in a knowledge base, ordered by ID
+type KnowledgePage struct {
+	Documents []KnowledgeDocument `json:"documents"`
+	// Next is the offset of the following page; empty on the last page
+	Next string `json:"next,omitempty"`
+}
+
+// KnowledgeBaseAgent is implemented by agents that answer from a knowledge base of
+// documents, so operators can curate it without writing code
+type KnowledgeBaseAgent interface {
+	AgentPlugin
+
+	// AddKnowledge adds documents, replacing those with the same IDs
+	AddKnowledge(ctx context.Context, docs []KnowledgeDocument) error
+	// ListKnowledge returns up to limit documents with IDs after offset
+	ListKnowledge(ctx context.Context, offset string, limit int) (KnowledgePage, error)
+	// DeleteKnowledge removes documents; IDs not in the knowledge base are ignored
+	DeleteKnowledge(ctx context.Context, ids []string) error
+	// SearchKnowledge returns up to limit documents relevant to a query, the most relevant first
+	SearchKnowledge(ctx context.Context, query string, limit int) ([]KnowledgeDocument, error)
+	// KnowledgeStats describes the knowledge base, such as its documents per category
+	KnowledgeStats(ctx context.Context) (map[string]interface{}, error)
+}
+
+// parseKnowledgeLimit reads the limit parameter of a listing or search; 0 when it is not given
+func parseKnowledgeLimit(raw string, max int) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 1 || limit > max {
+		return 0, NewValidationError("framework", "knowledge", fmt.Sprintf("limit must be between 1 and %d", max))
+	}
+	return limit, nil
+}
+
+// knowledgeBase finds the named agent, or the default agent when no name is given, and
+// checks that it keeps a knowledge base
+func (f *Framework) knowledgeBase(operation, agentName string) (string, KnowledgeBaseAgent, error) {
+	if agentName == "" {
+		agentName = f.config.DefaultAgent
+	}
+	if agentName == "" {
+		return "", nil, NewValidationError("framework", operation, "no agent given and no default agent configured")
+	}
+
+	f.mu.RLock()
+	plugin, err := f.registry.GetPlugin(agentName)
+	f.mu.RUnlock()
+	if err != nil {
+		return "", nil, NewPluginError("framework", operation, fmt.Sprintf("agent %s not found", agentName))
+	}
+	agent, ok := plugin.(KnowledgeBaseAgent)
+	if !ok {
+		return "", nil, NewValidationError("framework", operation, fmt.Sprintf("agent %s has no knowledge base", agentName))
+	}
+	return agentName, agent, nil
+}
+
+// AddKnowledge adds documents to an agent's knowledge base. Documents without a
+// timestamp are given the current time.
+func (f *Framework) AddKnowledge(ctx context.Context, agentName string, docs []KnowledgeDocument) error {
+	if len(docs) == 0 {
+		return NewValidationError("framework", "add-knowledge", "no documents given")
+	}
+	now := time.Now()
+	for i := range docs {
+		if docs[i].ID == "" || docs[i].Content == "" {
+			return NewValidationError("framework", "add-knowledge", fmt.Sprintf("document %d needs an id and content", i))
+		}
+		if docs[i].Timestamp.IsZero() {
+			docs[i].Timestamp = now
+		}
+	}
+
+	name, agent, err := f.knowledgeBase("add-knowledge", agentName)
+	if err != nil {
+		return err
+	}
+	f.sandbox.run(name, func() error { err = agent.AddKnowledge(ctx, docs); return err })
+	return err
+}
+
+// ListKnowledge lists the documents in an agent's knowledge base a page at a time,
+// starting after offset
+func (f *Framework) ListKnowledge(ctx context.Context, agentName, offset string, limit int) (KnowledgePage, error) {
+	if limit <= 0 {
+		limit = defaultKnowledgePage
+	}
+	if limit > maxKnowledgePage {
+		limit = maxKnowledgePage
+	}
+
+	name, agent, err := f.knowledgeBase("list-knowledge", agentName)
+	if err != nil {
+		return KnowledgePage{}, err
+	}
+	var page KnowledgePage
+	f.sandbox.run(name, func() error { page, err = agent.ListKnowledge(ctx, offset, limit); return err })
+	if page.Documents == nil {
+		page.Documents = []KnowledgeDocument{}
+	}
+	return page, err
+}
+
+// DeleteKnowledge removes documents from an agent's knowledge base
+func (f *Framework) DeleteKnowledge(ctx context.Context, agentName string, ids []string) error {
+	if len(ids) == 0 {
+		return NewValidationError("framework", "delete-knowledge", "no document ids given")
+	}
+
+	name, agent, err := f.knowledgeBase("delete-knowledge", agentName)
+	if err != nil {
+		return err
+	}
+	f.sandbox.run(name, func() error { err = agent.DeleteKnowledge(ctx, ids); return err })
+	return err
+}
+
+// SearchKnowledge finds the documents of an agent's knowledge base it would answer a
+// query from
+func (f *Framework) SearchKnowledge(ctx context.Context, agentName, query string, limit int) ([]KnowledgeDocument, error) {
+	if query == "" {
+		return nil, NewValidationError("framework", "search-knowledge", "no query given")
+	}
+	if limit <= 0 {
+		limit = defaultKnowledgeResults
+	}
+	if limit > maxKnowledgeResults {
+		limit = maxKnowledgeResults
+	}
+
+	name, agent, err := f.knowledgeBase("search-knowledge", agentName)
+	if err != nil {
+		return nil, err
+	}
+	var docs []KnowledgeDocument
+	f.sandbox.run(name, func() error { docs, err = agent.SearchKnowledge(ctx, query, limit); return err })
+	if docs == nil {
+		docs = []KnowledgeDocument{}
+	}
+	return docs, err
+}
+
+// KnowledgeStats describes an agent's knowledge base
+func (f *Framework) KnowledgeStats(ctx context.Context, agentName string) (map[string]interface{}, error) {
+	name, agent, err := f.knowledgeBase("knowledge-stats", agentName)
+	if err != nil {
+		return nil, err
+	}
+	var stats map[string]interface{}
+	f.sandbox.run(name, func() error { stats, err = agent.KnowledgeStats(ctx); return err })
+	return stats, err
+}
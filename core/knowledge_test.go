@@ -0,0 +1,143 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// knowledgeAgent keeps its knowledge base in a map and finds documents containing the query
+type knowledgeAgent struct {
+	batchAgent
+	docs map[string]KnowledgeDocument
+}
+
+func (a *knowledgeAgent) AddKnowledge(ctx context.Context, docs []KnowledgeDocument) error {
+	for _, doc := range docs {
+		if doc.Content == "unembeddable" {
+			return fmt.Errorf("embeddings API unavailable")
+		}
+		a.docs[doc.ID] = doc
+	}
+	return nil
+}
+
+func (a *knowledgeAgent) ids() []string {
+	ids := make([]string, 0, len(a.docs))
+	for id := range a.docs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (a *knowledgeAgent) ListKnowledge(ctx context.Context, offset string, limit int) (KnowledgePage, error) {
+	var page KnowledgePage
+	for _, id := range a.ids() {
+		if id <= offset {
+			continue
+		}
+		if len(page.Documents) == limit {
+			page.Next = page.Documents[limit-1].ID
+			break
+		}
+		page.Documents = append(page.Documents, a.docs[id])
+	}
+	return page, nil
+}
+
+func (a *knowledgeAgent) DeleteKnowledge(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		delete(a.docs, id)
+	}
+	return nil
+}
+
+func (a *knowledgeAgent) SearchKnowledge(ctx context.Context, query string, limit int) ([]KnowledgeDocument, error) {
+	var found []KnowledgeDocument
+	for _, id := range a.ids() {
+		if strings.Contains(a.docs[id].Content, query) && len(found) < limit {
+			found = append(found, a.docs[id])
+		}
+	}
+	return found, nil
+}
+
+func (a *knowledgeAgent) KnowledgeStats(ctx context.Context) (map[string]interface{}, error) {
+	return map[string]interface{}{"total_documents": len(a.docs)}, nil
+}
+
+func TestFramework_KnowledgeAPI(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout", DefaultAgent: "rag"})
+	agent := &knowledgeAgent{batchAgent: batchAgent{MockPlugin: MockPlugin{name: "rag", pluginType: PluginTypeAgent}}, docs: make(map[string]KnowledgeDocument)}
+	require.NoError(t, framework.LoadPlugin(agent))
+	require.NoError(t, framework.LoadPlugin(&batchAgent{MockPlugin: MockPlugin{name: "ai", pluginType: PluginTypeAgent}}))
+
+	mux := http.NewServeMux()
+	framework.registerAPIRoutes(mux)
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := request(http.MethodPost, "/api/v1/knowledge", `{"documents": [
+		{"id": "runbooks/disk.md#0", "content": "Free space on a full disk", "metadata": {"type": "runbook"}},
+		{"id": "cpu", "content": "Scale out on high cpu"},
+		{"id": "orders", "content": "Restart orders when the disk is full"}]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var added []KnowledgeDocument
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&added))
+	require.Len(t, added, 3)
+	assert.False(t, added[0].Timestamp.IsZero(), "Expected documents to be given the current time")
+	assert.Equal(t, "runbook", agent.docs["runbooks/disk.md#0"].Metadata["type"])
+
+	rec = request(http.MethodGet, "/api/v1/knowledge?limit=2", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var page KnowledgePage
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	require.Len(t, page.Documents, 2)
+	assert.Equal(t, "cpu", page.Documents[0].ID)
+	assert.Equal(t, "orders", page.Next)
+	rec = request(http.MethodGet, "/api/v1/knowledge?agent=rag&limit=2&offset="+page.Next, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	page = KnowledgePage{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	require.Len(t, page.Documents, 1)
+	assert.Empty(t, page.Next)
+
+	rec = request(http.MethodGet, "/api/v1/knowledge/search?q=disk", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var found []KnowledgeDocument
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&found))
+	assert.Len(t, found, 2)
+
+	rec = request(http.MethodGet, "/api/v1/knowledge/stats", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"total_documents": 3}`, rec.Body.String())
+
+	assert.Equal(t, http.StatusNoContent, request(http.MethodDelete, "/api/v1/knowledge/runbooks%2Fdisk.md%230", "").Code)
+	assert.NotContains(t, agent.docs, "runbooks/disk.md#0", "Expected escaped IDs to be deleted")
+
+	for _, bad := range []struct{ method, path, body string }{
+		{http.MethodPost, "/api/v1/knowledge", `{"documents": []}`},
+		{http.MethodPost, "/api/v1/knowledge", `{"documents": [{"id": "empty"}]}`},
+		{http.MethodPost, "/api/v1/knowledge", `{"agent": "ai", "documents": [{"id": "a", "content": "b"}]}`},
+		{http.MethodGet, "/api/v1/knowledge?limit=0", ""},
+		{http.MethodGet, "/api/v1/knowledge/search", ""},
+		{http.MethodGet, "/api/v1/knowledge/search?q=disk&limit=500", ""},
+	} {
+		assert.Equal(t, http.StatusBadRequest, request(bad.method, bad.path, bad.body).Code, "%s %s %s", bad.method, bad.path, bad.body)
+	}
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/api/v1/knowledge/stats?agent=missing", "").Code)
+	assert.Equal(t, http.StatusBadGateway, request(http.MethodPost, "/api/v1/knowledge", `{"documents": [{"id": "a", "content": "unembeddable"}]}`).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, request(http.MethodPut, "/api/v1/knowledge/cpu", "").Code)
+}
@@ -0,0 +1,219 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// serviceAccountDir holds the credentials Kubernetes mounts into every pod
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// kubernetesDiscovery discovers the ready endpoints of Kubernetes services from their
+// EndpointSlices. A service's type is its name, optionally prefixed with a namespace and
+// suffixed with a port name: [namespace/]name[:port]. Kubernetes registers services
+// itself, so registering through the agent is not supported.
+type kubernetesDiscovery struct {
+	config     KubernetesDiscoveryConfig
+	httpClient *http.Client
+	// setupErr is why the client could not be set up, such as a missing CA bundle outside
+	// a cluster; it is returned by every lookup
+	setupErr error
+}
+
+func newKubernetesDiscovery(config KubernetesDiscoveryConfig, timeout time.Duration) *kubernetesDiscovery {
+	k := &kubernetesDiscovery{httpClient: &http.Client{Timeout: timeout}}
+	inCluster := config.APIServer == ""
+	if inCluster {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			k.setupErr = fmt.Errorf("kubernetes api_server not configured and not running in a cluster")
+		}
+		config.APIServer = "https://" + net.JoinHostPort(host, port)
+	}
+	if config.TokenFile == "" && inCluster {
+		config.TokenFile = serviceAccountDir + "/token"
+	}
+	if config.CAFile == "" && inCluster {
+		config.CAFile = serviceAccountDir + "/ca.crt"
+	}
+	if config.Namespace == "" {
+		config.Namespace = "default"
+		if namespace, err := os.ReadFile(serviceAccountDir + "/namespace"); err == nil {
+			config.Namespace = strings.TrimSpace(string(namespace))
+		}
+	}
+	k.config = config
+
+	if config.CAFile != "" && k.setupErr == nil {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			k.setupErr = fmt.Errorf("failed to read kubernetes CA bundle: %w", err)
+			return k
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			k.setupErr = fmt.Errorf("no certificates in kubernetes CA bundle %s", config.CAFile)
+			return k
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+		k.httpClient.Transport = transport
+	}
+	return k
+}
+
+// RegisterService is not supported; Kubernetes registers the endpoints of its services
+func (k *kubernetesDiscovery) RegisterService(ServiceInfo) error {
+	return ErrRegistrationNotSupported
+}
+
+// UnregisterService is not supported; Kubernetes registers the endpoints of its services
+func (k *kubernetesDiscovery) UnregisterService(string) error {
+	return ErrRegistrationNotSupported
+}
+
+// DiscoverServices returns an instance for each ready endpoint address and port of a
+// service. IDs have the form namespace/service/pod:port, using the endpoint's address for
+// endpoints that are not pods.
+func (k *kubernetesDiscovery) DiscoverServices(serviceType string) ([]ServiceInfo, error) {
+	namespace, name, portName := k.parseService(serviceType)
+	query := url.Values{"labelSelector": []string{"kubernetes.io/service-name=" + name}}
+	var slices struct {
+		Items []struct {
+			Endpoints []struct {
+				Addresses  []string `json:"addresses"`
+				Conditions struct {
+					Ready *bool `json:"ready"`
+				} `json:"conditions"`
+				NodeName  string `json:"nodeName"`
+				TargetRef *struct {
+					Kind string `json:"kind"`
+					Name string `json:"name"`
+				} `json:"targetRef"`
+			} `json:"endpoints"`
+			Ports []struct {
+				Name string `json:"name"`
+				Port *int   `json:"port"`
+			} `json:"ports"`
+		} `json:"items"`
+	}
+	path := "/apis/discovery.k8s.io/v1/namespaces/" + url.PathEscape(namespace) + "/endpointslices"
+	if err := k.get(path, query, &slices); err != nil {
+		return nil, err
+	}
+
+	var instances []ServiceInfo
+	for _, slice := range slices.Items {
+		for _, endpoint := range slice.Endpoints {
+			// Endpoints without a ready condition are ready, as the API defines
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+			for _, port := range slice.Ports {
+				if port.Port == nil || (portName != "" && port.Name != portName) {
+					continue
+				}
+				for _, address := range endpoint.Addresses {
+					instance := ServiceInfo{
+						Name:        name,
+						Type:        serviceType,
+						Address:     address,
+						Port:        *port.Port,
+						HealthCheck: "ready",
+						Metadata:    map[string]string{"namespace": namespace, "service": name, "port_name": port.Name},
+					}
+					target := address
+					if endpoint.TargetRef != nil && endpoint.TargetRef.Kind == "Pod" {
+						target = endpoint.TargetRef.Name
+						instance.Metadata["pod"] = endpoint.TargetRef.Name
+					}
+					if endpoint.NodeName != "" {
+						instance.Metadata["node"] = endpoint.NodeName
+					}
+					instance.ID = namespace + "/" + name + "/" + target + ":" + strconv.Itoa(*port.Port)
+					instances = append(instances, instance)
+				}
+			}
+		}
+	}
+	return instances, nil
+}
+
+// GetService returns the ready endpoint with the given ID
+func (k *kubernetesDiscovery) GetService(serviceID string) (*ServiceInfo, error) {
+	parts := strings.SplitN(serviceID, "/", 3)
+	if len(parts) != 3 {
+		return nil, ErrServiceNotFound
+	}
+	instances, err := k.DiscoverServices(parts[0] + "/" + parts[1])
+	if err != nil {
+		return nil, err
+	}
+	for i := range instances {
+		if instances[i].ID == serviceID {
+			return &instances[i], nil
+		}
+	}
+	return nil, ErrServiceNotFound
+}
+
+// parseService splits [namespace/]name[:port] into its parts
+func (k *kubernetesDiscovery) parseService(serviceType string) (namespace, name, portName string) {
+	namespace, name = k.config.Namespace, serviceType
+	if i := strings.Index(name, "/"); i >= 0 {
+		namespace, name = name[:i], name[i+1:]
+	}
+	if i := strings.Index(name, ":"); i >= 0 {
+		name, portName = name[:i], name[i+1:]
+	}
+	return namespace, name, portName
+}
+
+// get calls the Kubernetes API, reading the token each time so a rotated service account
+// token is picked up
+func (k *kubernetesDiscovery) get(path string, query url.Values, result interface{}) error {
+	if k.setupErr != nil {
+		return k.setupErr
+	}
+	endpoint := strings.TrimSuffix(k.config.APIServer, "/") + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if k.config.TokenFile != "" {
+		token, err := os.ReadFile(k.config.TokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read kubernetes token file: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := k.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("kubernetes request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("kubernetes returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("invalid kubernetes response: %w", err)
+	}
+	return nil
+}
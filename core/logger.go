@@ -6,14 +6,12 @@ import (
 	"os"
 )
 
+// logLevel is the level of the default logger, kept separately so it can change at runtime
+var logLevel = new(slog.LevelVar)
+
 // InitLogger initializes the default slog logger with configuration
 func InitLogger(config *FrameworkConfig) {
 	// Set defaults
-	logLevel := config.LogLevel
-	if logLevel == "" {
-		logLevel = "info"
-	}
-
 	logFormat := config.LogFormat
 	if logFormat == "" {
 		logFormat = "text"
@@ -24,20 +22,7 @@ func InitLogger(config *FrameworkConfig) {
 		logOutput = "stdout"
 	}
 
-	// Parse log level
-	var slogLevel slog.Level
-	switch logLevel {
-	case "debug":
-		slogLevel = slog.LevelDebug
-	case "info":
-		slogLevel = slog.LevelInfo
-	case "warn", "warning":
-		slogLevel = slog.LevelWarn
-	case "error":
-		slogLevel = slog.LevelError
-	default:
-		slogLevel = slog.LevelInfo
-	}
+	SetLogLevel(config.LogLevel)
 
 	// Determine output destination
 	var output io.Writer
@@ -60,7 +45,7 @@ func InitLogger(config *FrameworkConfig) {
 	// Create handler based on format
 	var handler slog.Handler
 	opts := &slog.HandlerOptions{
-		Level: slogLevel,
+		Level: logLevel,
 	}
 
 	switch logFormat {
@@ -70,6 +55,20 @@ func InitLogger(config *FrameworkConfig) {
 		handler = slog.NewTextHandler(output, opts)
 	}
 
-	// Set the default logger
-	slog.SetDefault(slog.New(handler))
+	// Set the default logger; records logged with a context carry its trace and span IDs
+	slog.SetDefault(slog.New(traceLogHandler{handler}))
+}
+
+// SetLogLevel changes the level of the default logger; unknown levels mean info
+func SetLogLevel(level string) {
+	switch level {
+	case "debug":
+		logLevel.Set(slog.LevelDebug)
+	case "warn", "warning":
+		logLevel.Set(slog.LevelWarn)
+	case "error":
+		logLevel.Set(slog.LevelError)
+	default:
+		logLevel.Set(slog.LevelInfo)
+	}
 }
@@ -0,0 +1,243 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// weekdays maps the day names accepted in maintenance window config
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// MaintenanceWindow is a silence declared in config. It either covers one time range or
+// recurs on given weekdays at a time of day for a duration.
+type MaintenanceWindow struct {
+	ID              string               `json:"id"`
+	Name            string               `json:"name"`
+	Matchers        []LabelMatcher       `json:"matchers"`
+	MetricPattern   string               `json:"metric_pattern,omitempty"`
+	Comment         string               `json:"comment,omitempty"`
+	StartsAt        time.Time            `json:"starts_at,omitempty"`
+	EndsAt          time.Time            `json:"ends_at,omitempty"`
+	Days            []time.Weekday       `json:"days,omitempty"`
+	Start           string               `json:"start,omitempty"`
+	Duration        time.Duration        `json:"duration,omitempty"`
+	Timezone        string               `json:"timezone,omitempty"`
+	SuppressedCount int                  `json:"suppressed_count"`
+	Suppressed      []SuppressedAnalysis `json:"suppressed"`
+	metric          *regexp.Regexp
+	location        *time.Location
+	startOffset     time.Duration
+}
+
+// MaintenanceWindowStatus is a maintenance window with its state and next start
+type MaintenanceWindowStatus struct {
+	MaintenanceWindow
+	State     string    `json:"state"`
+	NextStart time.Time `json:"next_start,omitempty"`
+}
+
+// NewMaintenanceWindow validates and compiles a configured maintenance window
+func NewMaintenanceWindow(config MaintenanceWindowConfig) (*MaintenanceWindow, error) {
+	window := &MaintenanceWindow{
+		ID:            "MW-" + config.Name,
+		Name:          config.Name,
+		MetricPattern: config.Metric,
+		Comment:       config.Comment,
+		StartsAt:      config.StartsAt,
+		EndsAt:        config.EndsAt,
+		Start:         config.Start,
+		Duration:      config.Duration,
+		Timezone:      config.Timezone,
+		location:      time.UTC,
+	}
+	invalid := func(message string) error {
+		return NewConfigurationError("maintenance", "build", fmt.Sprintf("maintenance window %q: %s", config.Name, message))
+	}
+
+	if config.Matchers != "" {
+		matchers, err := ParseMatchers(config.Matchers)
+		if err != nil {
+			return nil, WrapError(err, ErrorTypeConfiguration, "maintenance", "build", fmt.Sprintf("maintenance window %q has an invalid selector", config.Name))
+		}
+		window.Matchers = matchers
+	}
+	if config.Metric != "" {
+		re, err := regexp.Compile("^(?:" + config.Metric + ")$")
+		if err != nil {
+			return nil, invalid(fmt.Sprintf("invalid metric pattern %q", config.Metric))
+		}
+		window.metric = re
+	}
+	if len(window.Matchers) == 0 && window.metric == nil {
+		return nil, invalid("needs a selector or a metric pattern")
+	}
+
+	recurring := config.Start != "" || config.Duration > 0 || len(config.Days) > 0
+	oneOff := !config.StartsAt.IsZero() || !config.EndsAt.IsZero()
+	switch {
+	case recurring && oneOff:
+		return nil, invalid("set either starts_at and ends_at or a recurring start and duration, not both")
+	case oneOff:
+		if !config.EndsAt.After(config.StartsAt) {
+			return nil, invalid("must end after it starts")
+		}
+	case recurring:
+		start, err := time.Parse("15:04", config.Start)
+		if err != nil {
+			return nil, invalid(fmt.Sprintf("start %q is not HH:MM", config.Start))
+		}
+		window.startOffset = time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute
+		if config.Duration <= 0 {
+			return nil, invalid("recurring windows need a duration")
+		}
+		for _, name := range config.Days {
+			day, ok := weekdays[strings.ToLower(name)[:min(3, len(name))]]
+			if !ok {
+				return nil, invalid(fmt.Sprintf("unknown day %q", name))
+			}
+			window.Days = append(window.Days, day)
+		}
+		if config.Timezone != "" {
+			location, err := time.LoadLocation(config.Timezone)
+			if err != nil {
+				return nil, invalid(fmt.Sprintf("unknown timezone %q", config.Timezone))
+			}
+			window.location = location
+		}
+	default:
+		return nil, invalid("needs starts_at and ends_at or a recurring start and duration")
+	}
+	return window, nil
+}
+
+// NewMaintenanceWindows compiles the configured maintenance windows, rejecting duplicate names
+func NewMaintenanceWindows(configs []MaintenanceWindowConfig) ([]*MaintenanceWindow, error) {
+	windows := make([]*MaintenanceWindow, 0, len(configs))
+	seen := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		if seen[config.Name] {
+			return nil, NewConfigurationError("maintenance", "build", fmt.Sprintf("maintenance window %q is declared twice", config.Name))
+		}
+		seen[config.Name] = true
+
+		window, err := NewMaintenanceWindow(config)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+// recurring reports whether the window repeats
+func (w *MaintenanceWindow) recurring() bool {
+	return w.Duration > 0
+}
+
+// onDay reports whether a recurring window starts on the weekday; no days means every day
+func (w *MaintenanceWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, allowed := range w.Days {
+		if allowed == day {
+			return true
+		}
+	}
+	return false
+}
+
+// startOn returns when a recurring window starts on the calendar day of t
+func (w *MaintenanceWindow) startOn(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, w.location).Add(w.startOffset)
+}
+
+// Active reports whether the window is in effect at the given time
+func (w *MaintenanceWindow) Active(now time.Time) bool {
+	if !w.recurring() {
+		return !now.Before(w.StartsAt) && now.Before(w.EndsAt)
+	}
+
+	// A window that started on an earlier day may still be running
+	local := now.In(w.location)
+	lookback := int(w.Duration/(24*time.Hour)) + 1
+	for days := 0; days <= lookback; days++ {
+		start := w.startOn(local.AddDate(0, 0, -days))
+		if w.onDay(start.Weekday()) && !now.Before(start) && now.Before(start.Add(w.Duration)) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextStart returns when the window next starts after the given time, or the zero time
+// when a one-off window has already started
+func (w *MaintenanceWindow) NextStart(now time.Time) time.Time {
+	if !w.recurring() {
+		if now.Before(w.StartsAt) {
+			return w.StartsAt
+		}
+		return time.Time{}
+	}
+
+	local := now.In(w.location)
+	for days := 0; days <= 7; days++ {
+		start := w.startOn(local.AddDate(0, 0, days))
+		if w.onDay(start.Weekday()) && start.After(now) {
+			return start
+		}
+	}
+	return time.Time{}
+}
+
+// State returns active, pending, or expired; recurring windows are never expired
+func (w *MaintenanceWindow) State(now time.Time) string {
+	switch {
+	case w.Active(now):
+		return "active"
+	case w.recurring() || now.Before(w.StartsAt):
+		return "pending"
+	default:
+		return "expired"
+	}
+}
+
+// Matches reports whether every series of the analysis is covered by the window
+func (w *MaintenanceWindow) Matches(analysis *Analysis) bool {
+	return matchesAllSeries(analysis, w.metric, w.Matchers)
+}
+
+// SetMaintenanceWindows replaces the maintenance windows checked after silences
+func (m *SilenceManager) SetMaintenanceWindows(windows []*MaintenanceWindow) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.windows = windows
+}
+
+// MaintenanceWindows returns the maintenance windows with their state, in config order
+func (m *SilenceManager) MaintenanceWindows() []MaintenanceWindowStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	statuses := make([]MaintenanceWindowStatus, 0, len(m.windows))
+	for _, window := range m.windows {
+		statuses = append(statuses, MaintenanceWindowStatus{
+			MaintenanceWindow: *window,
+			State:             window.State(now),
+			NextStart:         window.NextStart(now),
+		})
+	}
+	return statuses
+}
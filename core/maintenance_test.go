@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceWindow_Recurring(t *testing.T) {
+	window, err := NewMaintenanceWindow(MaintenanceWindowConfig{
+		Name:     "db-patching",
+		Matchers: `service="db"`,
+		Days:     []string{"sat", "sunday"},
+		Start:    "23:00",
+		Duration: 3 * time.Hour,
+	})
+	require.NoError(t, err)
+
+	saturday := time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+	assert.False(t, window.Active(saturday.Add(22*time.Hour)))
+	assert.True(t, window.Active(saturday.Add(23*time.Hour+30*time.Minute)))
+	assert.True(t, window.Active(saturday.Add(25*time.Hour)), "Expected a window to run past midnight")
+	assert.True(t, window.Active(saturday.Add(24*time.Hour+23*time.Hour+30*time.Minute)), "Expected Sunday's window")
+	assert.False(t, window.Active(saturday.Add(48*time.Hour+23*time.Hour+30*time.Minute)), "Expected no window on Monday")
+	assert.True(t, window.Active(saturday.Add(48*time.Hour+time.Hour)), "Expected Sunday's window to run into Monday")
+
+	assert.Equal(t, saturday.Add(23*time.Hour), window.NextStart(saturday))
+	assert.Equal(t, "pending", window.State(saturday))
+}
+
+func TestMaintenanceWindow_Timezone(t *testing.T) {
+	window, err := NewMaintenanceWindow(MaintenanceWindowConfig{
+		Name:     "nightly",
+		Metric:   "backup_.*",
+		Start:    "02:00",
+		Duration: time.Hour,
+		Timezone: "America/New_York",
+	})
+	require.NoError(t, err)
+
+	// 02:30 in New York during daylight saving time is 06:30 UTC
+	assert.True(t, window.Active(time.Date(2026, time.October, 16, 6, 30, 0, 0, time.UTC)))
+	assert.False(t, window.Active(time.Date(2026, time.October, 16, 2, 30, 0, 0, time.UTC)))
+}
+
+func TestMaintenanceWindow_Invalid(t *testing.T) {
+	now := time.Now()
+	configs := map[string]MaintenanceWindowConfig{
+		"no selector":   {Name: "a", Start: "01:00", Duration: time.Hour},
+		"no schedule":   {Name: "a", Matchers: `env="prod"`},
+		"both":          {Name: "a", Matchers: `env="prod"`, Start: "01:00", Duration: time.Hour, StartsAt: now, EndsAt: now.Add(time.Hour)},
+		"bad start":     {Name: "a", Matchers: `env="prod"`, Start: "25:00", Duration: time.Hour},
+		"no duration":   {Name: "a", Matchers: `env="prod"`, Start: "01:00"},
+		"bad day":       {Name: "a", Matchers: `env="prod"`, Start: "01:00", Duration: time.Hour, Days: []string{"someday"}},
+		"bad timezone":  {Name: "a", Matchers: `env="prod"`, Start: "01:00", Duration: time.Hour, Timezone: "Mars/Olympus"},
+		"ends first":    {Name: "a", Matchers: `env="prod"`, StartsAt: now, EndsAt: now.Add(-time.Hour)},
+		"bad selector":  {Name: "a", Matchers: `env=`, StartsAt: now, EndsAt: now.Add(time.Hour)},
+		"bad metric re": {Name: "a", Metric: "(", StartsAt: now, EndsAt: now.Add(time.Hour)},
+	}
+	for name, config := range configs {
+		_, err := NewMaintenanceWindow(config)
+		assert.Error(t, err, name)
+	}
+
+	_, err := NewMaintenanceWindows([]MaintenanceWindowConfig{
+		{Name: "a", Matchers: `env="prod"`, Start: "01:00", Duration: time.Hour},
+		{Name: "a", Matchers: `env="dev"`, Start: "01:00", Duration: time.Hour},
+	})
+	assert.Error(t, err, "Expected duplicate names to be rejected")
+}
+
+func TestSilenceManager_MaintenanceWindows(t *testing.T) {
+	now := time.Now()
+	windows, err := NewMaintenanceWindows([]MaintenanceWindowConfig{
+		{Name: "migration", Matchers: `service="db"`, StartsAt: now.Add(-time.Minute), EndsAt: now.Add(time.Hour)},
+		{Name: "later", Matchers: `service="api"`, StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)},
+	})
+	require.NoError(t, err)
+
+	manager := NewSilenceManager()
+	manager.SetMaintenanceWindows(windows)
+
+	db := &Analysis{ID: "a1", Summary: "db slow", DataPoints: []DataPoint{{Metric: "latency", Labels: map[string]string{"service": "db"}}}}
+	id, suppressed := manager.Suppress(db)
+	assert.True(t, suppressed)
+	assert.Equal(t, "MW-migration", id)
+
+	api := &Analysis{ID: "a2", Summary: "api slow", DataPoints: []DataPoint{{Metric: "latency", Labels: map[string]string{"service": "api"}}}}
+	_, suppressed = manager.Suppress(api)
+	assert.False(t, suppressed, "Expected windows that have not started not to suppress")
+
+	silence, err := manager.Create(Silence{Matchers: []LabelMatcher{{Name: "service", Type: MatchEqual, Value: "db"}}, EndsAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	id, _ = manager.Suppress(db)
+	assert.Equal(t, silence.ID, id, "Expected silences to take precedence over maintenance windows")
+
+	statuses := manager.MaintenanceWindows()
+	require.Len(t, statuses, 2)
+	assert.Equal(t, "active", statuses[0].State)
+	assert.Equal(t, 1, statuses[0].SuppressedCount)
+	assert.Equal(t, "pending", statuses[1].State)
+	assert.Equal(t, windows[1].StartsAt, statuses[1].NextStart)
+}
+
+func TestFramework_MaintenanceWindow(t *testing.T) {
+	config := &FrameworkConfig{
+		LogLevel:  "info",
+		LogFormat: "text",
+		LogOutput: "stdout",
+		Plugins:   []PluginConfig{},
+		MaintenanceWindows: []MaintenanceWindowConfig{
+			{Name: "always", Matchers: `env="staging"`, Start: "00:00", Duration: 24 * time.Hour},
+		},
+	}
+	framework := NewFramework(config)
+	responder := &severityResponder{MockPlugin: MockPlugin{name: "log", pluginType: PluginTypeResponder}, severity: "high"}
+	require.NoError(t, framework.LoadPlugin(responder))
+
+	staging := &Analysis{Severity: "high", Timestamp: time.Now(), DataPoints: []DataPoint{{Metric: "cpu", Labels: map[string]string{"env": "staging"}}}}
+	framework.handleAnalysis(context.Background(), "anomaly", staging)
+	assert.Empty(t, responder.handled, "Expected the analysis in a maintenance window to skip responders")
+
+	recorded, err := framework.GetAnalysisHistory().List(context.Background(), time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, recorded, 1, "Expected the suppressed analysis to still be recorded")
+	assert.Equal(t, staging.ID, recorded[0].ID)
+}
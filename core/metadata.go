@@ -0,0 +1,205 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metric units understood by FormatValue
+const (
+	UnitBytes   = "bytes"
+	UnitSeconds = "seconds"
+	UnitPercent = "percent"
+	UnitRatio   = "ratio"
+)
+
+// MetricMetadata describes what a metric measures and what values are plausible
+type MetricMetadata struct {
+	Name        string   `yaml:"name" json:"name" validate:"required"`
+	Unit        string   `yaml:"unit,omitempty" json:"unit,omitempty"`
+	Type        string   `yaml:"type,omitempty" json:"type,omitempty" validate:"omitempty,oneof=counter gauge histogram summary"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Min         *float64 `yaml:"min,omitempty" json:"min,omitempty"`
+	Max         *float64 `yaml:"max,omitempty" json:"max,omitempty"`
+}
+
+// InRange reports whether a value is within the metric's expected range; metrics without
+// bounds accept any value
+func (m MetricMetadata) InRange(value float64) bool {
+	if m.Min != nil && value < *m.Min {
+		return false
+	}
+	if m.Max != nil && value > *m.Max {
+		return false
+	}
+	return true
+}
+
+// MetricMetadataAware is implemented by plugins that use metric metadata, e.g. for sanity
+// bounds or formatting. The framework provides its registry when the plugin is loaded.
+type MetricMetadataAware interface {
+	SetMetricMetadata(registry *MetricMetadataRegistry)
+}
+
+// MetricMetadataRegistry holds metadata for known metrics. Configured entries take
+// precedence over entries discovered from collectors.
+type MetricMetadataRegistry struct {
+	metrics    map[string]MetricMetadata
+	configured map[string]bool
+	mu         sync.RWMutex
+}
+
+// NewMetricMetadataRegistry creates a registry seeded with configured metadata
+func NewMetricMetadataRegistry(configured []MetricMetadata) *MetricMetadataRegistry {
+	r := &MetricMetadataRegistry{
+		metrics:    make(map[string]MetricMetadata),
+		configured: make(map[string]bool),
+	}
+	for _, metadata := range configured {
+		r.metrics[metadata.Name] = metadata
+		r.configured[metadata.Name] = true
+	}
+	return r
+}
+
+// Register adds discovered metadata, filling in only what configuration did not specify
+func (r *MetricMetadataRegistry) Register(metadata MetricMetadata) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.metrics[metadata.Name]
+	if !ok || !r.configured[metadata.Name] {
+		r.metrics[metadata.Name] = metadata
+		return
+	}
+
+	if existing.Unit == "" {
+		existing.Unit = metadata.Unit
+	}
+	if existing.Type == "" {
+		existing.Type = metadata.Type
+	}
+	if existing.Description == "" {
+		existing.Description = metadata.Description
+	}
+	r.metrics[metadata.Name] = existing
+}
+
+// Get returns the metadata for a metric. Unknown metrics and metrics without a unit get
+// one inferred from naming conventions such as a _bytes or _seconds suffix. A nil registry
+// behaves as an empty one so plugins can use it before the framework provides one.
+func (r *MetricMetadataRegistry) Get(metric string) (MetricMetadata, bool) {
+	var metadata MetricMetadata
+	ok := false
+	if r != nil {
+		r.mu.RLock()
+		metadata, ok = r.metrics[metric]
+		r.mu.RUnlock()
+	}
+
+	if !ok {
+		metadata = MetricMetadata{Name: metric}
+	}
+	if metadata.Unit == "" {
+		metadata.Unit = InferUnit(metric)
+	}
+	return metadata, ok
+}
+
+// List returns all registered metadata sorted by metric name
+func (r *MetricMetadataRegistry) List() []MetricMetadata {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]MetricMetadata, 0, len(r.metrics))
+	for _, metadata := range r.metrics {
+		list = append(list, metadata)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// InRange reports whether a value is within the metric's expected range
+func (r *MetricMetadataRegistry) InRange(metric string, value float64) bool {
+	metadata, ok := r.Get(metric)
+	return !ok || metadata.InRange(value)
+}
+
+// FormatValue formats a metric value for humans using the metric's unit
+func (r *MetricMetadataRegistry) FormatValue(metric string, value float64) string {
+	metadata, _ := r.Get(metric)
+	return FormatValue(value, metadata.Unit)
+}
+
+// InferUnit guesses a unit from Prometheus naming conventions
+func InferUnit(metric string) string {
+	name := strings.TrimSuffix(strings.TrimSuffix(metric, "_total"), "_count")
+	switch {
+	case strings.HasSuffix(name, "_bytes"):
+		return UnitBytes
+	case strings.HasSuffix(name, "_seconds"):
+		return UnitSeconds
+	case strings.HasSuffix(name, "_percent"), strings.HasSuffix(name, "_pct"):
+		return UnitPercent
+	case strings.HasSuffix(name, "_ratio"):
+		return UnitRatio
+	}
+	return ""
+}
+
+// FormatValue formats a value with its unit, e.g. 1288490188.8 bytes as "1.2 GB"
+func FormatValue(value float64, unit string) string {
+	switch unit {
+	case UnitBytes:
+		return formatBytes(value)
+	case UnitSeconds:
+		return formatSeconds(value)
+	case UnitPercent:
+		return fmt.Sprintf("%.1f%%", value)
+	case UnitRatio:
+		return fmt.Sprintf("%.1f%%", value*100)
+	case "":
+		return formatNumber(value)
+	default:
+		return fmt.Sprintf("%s %s", formatNumber(value), unit)
+	}
+}
+
+// formatBytes formats a byte count with binary multiples
+func formatBytes(value float64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB", "PB"}
+	i := 0
+	for math.Abs(value) >= 1024 && i < len(units)-1 {
+		value /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", value, units[i])
+	}
+	return fmt.Sprintf("%.1f %s", value, units[i])
+}
+
+// formatSeconds formats seconds as a duration, keeping millisecond precision
+func formatSeconds(value float64) string {
+	d := time.Duration(value * float64(time.Second))
+	switch {
+	case d >= time.Minute:
+		return d.Round(time.Second).String()
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
+
+// formatNumber trims needless precision from plain numbers
+func formatNumber(value float64) string {
+	if value == math.Trunc(value) && math.Abs(value) < 1e15 {
+		return fmt.Sprintf("%.0f", value)
+	}
+	return fmt.Sprintf("%.2f", value)
+}
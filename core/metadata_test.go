@@ -0,0 +1,55 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		value    float64
+		unit     string
+		expected string
+	}{
+		{value: 1288490188.8, unit: UnitBytes, expected: "1.2 GB"},
+		{value: 512, unit: UnitBytes, expected: "512 B"},
+		{value: 0.25, unit: UnitSeconds, expected: "250ms"},
+		{value: 90.5, unit: UnitSeconds, expected: "1m31s"},
+		{value: 87.345, unit: UnitPercent, expected: "87.3%"},
+		{value: 0.42, unit: UnitRatio, expected: "42.0%"},
+		{value: 42, unit: "", expected: "42"},
+		{value: 3.14159, unit: "requests/s", expected: "3.14 requests/s"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, FormatValue(tt.value, tt.unit), "Formatting %v %s", tt.value, tt.unit)
+	}
+}
+
+func TestMetricMetadataRegistry(t *testing.T) {
+	max := 100.0
+	registry := NewMetricMetadataRegistry([]MetricMetadata{
+		{Name: "cpu_usage", Unit: UnitPercent, Max: &max},
+	})
+
+	// Discovered metadata fills gaps but does not override configuration
+	registry.Register(MetricMetadata{Name: "cpu_usage", Unit: "cores", Description: "CPU usage"})
+	registry.Register(MetricMetadata{Name: "heap", Unit: UnitBytes})
+
+	cpu, ok := registry.Get("cpu_usage")
+	assert.True(t, ok)
+	assert.Equal(t, UnitPercent, cpu.Unit)
+	assert.Equal(t, "CPU usage", cpu.Description)
+
+	assert.True(t, registry.InRange("cpu_usage", 99))
+	assert.False(t, registry.InRange("cpu_usage", 130))
+	assert.True(t, registry.InRange("unknown_metric", 1e12), "Expected metrics without bounds to accept any value")
+
+	assert.Equal(t, "1.0 MB", registry.FormatValue("heap", 1048576))
+	assert.Equal(t, "2.0 KB", registry.FormatValue("process_resident_memory_bytes", 2048), "Expected units to be inferred from the name")
+	assert.Len(t, registry.List(), 2)
+
+	var empty *MetricMetadataRegistry
+	assert.Equal(t, "1.5s", empty.FormatValue("request_duration_seconds", 1.5), "Expected a nil registry to fall back to naming conventions")
+}
@@ -0,0 +1,361 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// metricHelp documents the metrics the framework records through its MetricsCollector
+var metricHelp = map[string]string{
+	"framework_data_batches_total":                    "Batches of data points received from collectors",
+	"framework_data_points_processed_total":           "Data points run through the analyzers",
+	"framework_analyzer_duration_seconds":             "Time analyzers took to analyze a batch",
+	"framework_analyzer_errors_total":                 "Batches analyzers failed to analyze",
+	"framework_responder_failures_total":              "Failed calls to responders",
+	"framework_plugin_calls_total":                    "Calls made to each plugin",
+	"framework_recording_rule_evaluations_total":      "Evaluations of each recording rule",
+	"framework_recording_rule_samples":                "Data points the last evaluation of each recording rule produced",
+	"framework_discovery_lookups_total":               "Lookups of discovered services by result",
+	"framework_discovery_instances":                   "Instances found by the last lookup of each discovered service",
+	"framework_plugin_cpu_seconds_total":              "CPU time plugin calls used on their own thread",
+	"framework_plugin_alloc_bytes_total":              "Bytes allocated while each plugin's calls ran, including concurrent work",
+	"framework_plugin_call_duration_seconds":          "Time each plugin's calls took, including collections",
+	"framework_plugin_errors_total":                   "Plugin calls that returned an error",
+	"framework_plugin_last_success_timestamp_seconds": "Unix time of each plugin's last call that succeeded",
+	"framework_plugin_budget_exceeded_total":          "Times a plugin went over its per-minute resource budget",
+	"framework_plugin_skipped_calls_total":            "Plugin calls skipped while the plugin was throttled",
+	"framework_plugin_throttled":                      "Whether a plugin is throttled for exceeding its resource budget",
+	"framework_plugin_restarts_total":                 "Restarts of failed plugins by the supervisor",
+	"framework_plugin_quarantined":                    "Whether a plugin is quarantined for failing to recover",
+}
+
+// PrometheusMetricsCollector implements MetricsCollector with a Prometheus registry. Metrics
+// are created on first use, with the label names of that first use.
+type PrometheusMetricsCollector struct {
+	registry   *prometheus.Registry
+	counters   map[string]*prometheus.CounterVec
+	gauges     map[string]*prometheus.GaugeVec
+	histograms map[string]*prometheus.HistogramVec
+	labelNames map[string][]string
+	mu         sync.Mutex
+}
+
+// NewPrometheusMetricsCollector creates a metrics collector with its own registry, which
+// also exports Go runtime and process metrics
+func NewPrometheusMetricsCollector() *PrometheusMetricsCollector {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector())
+	registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	return &PrometheusMetricsCollector{
+		registry:   registry,
+		counters:   make(map[string]*prometheus.CounterVec),
+		gauges:     make(map[string]*prometheus.GaugeVec),
+		histograms: make(map[string]*prometheus.HistogramVec),
+		labelNames: make(map[string][]string),
+	}
+}
+
+// Register adds a collector to the registry
+func (p *PrometheusMetricsCollector) Register(collector prometheus.Collector) error {
+	return p.registry.Register(collector)
+}
+
+// Gather implements prometheus.Gatherer
+func (p *PrometheusMetricsCollector) Gather() ([]*dto.MetricFamily, error) {
+	return p.registry.Gather()
+}
+
+// IncrementCounter adds one to a counter
+func (p *PrometheusMetricsCollector) IncrementCounter(name string, labels map[string]string) {
+	p.AddCounter(name, 1, labels)
+}
+
+// AddCounter adds a value to a counter
+func (p *PrometheusMetricsCollector) AddCounter(name string, value float64, labels map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	vec, ok := p.counters[name]
+	if !ok {
+		vec = prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help(name)}, labelNames(labels))
+		if !p.register(name, labels, vec) {
+			return
+		}
+		p.counters[name] = vec
+	}
+	if values, ok := p.labelValues(name, labels); ok {
+		vec.WithLabelValues(values...).Add(value)
+	}
+}
+
+// SetGauge sets a gauge
+func (p *PrometheusMetricsCollector) SetGauge(name string, value float64, labels map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	vec, ok := p.gauges[name]
+	if !ok {
+		vec = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help(name)}, labelNames(labels))
+		if !p.register(name, labels, vec) {
+			return
+		}
+		p.gauges[name] = vec
+	}
+	if values, ok := p.labelValues(name, labels); ok {
+		vec.WithLabelValues(values...).Set(value)
+	}
+}
+
+// ObserveHistogram adds an observation to a histogram with the default buckets
+func (p *PrometheusMetricsCollector) ObserveHistogram(name string, value float64, labels map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	vec, ok := p.histograms[name]
+	if !ok {
+		vec = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help(name)}, labelNames(labels))
+		if !p.register(name, labels, vec) {
+			return
+		}
+		p.histograms[name] = vec
+	}
+	if values, ok := p.labelValues(name, labels); ok {
+		vec.WithLabelValues(values...).Observe(value)
+	}
+}
+
+// GetMetrics returns the current value of every series, keyed by metric{label="value"}.
+// Histograms are reported by their count and sum.
+func (p *PrometheusMetricsCollector) GetMetrics() map[string]interface{} {
+	families, err := p.registry.Gather()
+	if err != nil {
+		slog.Error("Failed to gather metrics", "error", err)
+	}
+
+	metrics := make(map[string]interface{})
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			series := seriesName(family.GetName(), metric.GetLabel())
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				metrics[series] = metric.GetCounter().GetValue()
+			case dto.MetricType_GAUGE:
+				metrics[series] = metric.GetGauge().GetValue()
+			case dto.MetricType_HISTOGRAM:
+				metrics[series] = map[string]interface{}{
+					"count": metric.GetHistogram().GetSampleCount(),
+					"sum":   metric.GetHistogram().GetSampleSum(),
+				}
+			case dto.MetricType_UNTYPED:
+				metrics[series] = metric.GetUntyped().GetValue()
+			}
+		}
+	}
+	return metrics
+}
+
+// register registers a new metric and remembers its label names; callers hold the lock
+func (p *PrometheusMetricsCollector) register(name string, labels map[string]string, collector prometheus.Collector) bool {
+	if err := p.registry.Register(collector); err != nil {
+		slog.Error("Failed to register metric", "metric", name, "error", err)
+		return false
+	}
+	p.labelNames[name] = labelNames(labels)
+	return true
+}
+
+// labelValues orders label values like the metric's label names, failing when the labels
+// differ from those the metric was created with; callers hold the lock
+func (p *PrometheusMetricsCollector) labelValues(name string, labels map[string]string) ([]string, bool) {
+	names := p.labelNames[name]
+	if len(names) != len(labels) {
+		slog.Debug("Metric recorded with different labels", "metric", name, "labels", labels)
+		return nil, false
+	}
+	values := make([]string, len(names))
+	for i, label := range names {
+		value, ok := labels[label]
+		if !ok {
+			slog.Debug("Metric recorded with different labels", "metric", name, "labels", labels)
+			return nil, false
+		}
+		values[i] = value
+	}
+	return values, true
+}
+
+// labelNames returns the sorted names of a label set
+func labelNames(labels map[string]string) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// help returns the help text of a metric
+func help(name string) string {
+	if text, ok := metricHelp[name]; ok {
+		return text
+	}
+	return strings.ReplaceAll(name, "_", " ")
+}
+
+// seriesName renders a series as metric{label="value",...}
+func seriesName(name string, labels []*dto.LabelPair) string {
+	if len(labels) == 0 {
+		return name
+	}
+	pairs := make([]string, len(labels))
+	for i, label := range labels {
+		pairs[i] = fmt.Sprintf("%s=%q", label.GetName(), label.GetValue())
+	}
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// frameworkCollector exports the framework's state, API key usage, and delivery receipts
+// when /metrics is scraped
+type frameworkCollector struct {
+	framework *Framework
+}
+
+// Describe sends no descriptors, making the collector unchecked, since the series depend on
+// the plugins and responders loaded
+func (c frameworkCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector
+func (c frameworkCollector) Collect(ch chan<- prometheus.Metric) {
+	f := c.framework
+	status := f.GetStatus()
+
+	gauge := func(name, help string, value float64) {
+		ch <- prometheus.MustNewConstMetric(prometheus.NewDesc(name, help, nil, nil), prometheus.GaugeValue, value)
+	}
+	gauge("framework_running", "Whether the framework is running", boolValue(status["running"].(bool)))
+	gauge("framework_read_only", "Whether the framework runs in read-only mode", boolValue(status["read_only"].(bool)))
+	gauge("framework_dry_run", "Whether the framework runs in dry-run mode", boolValue(status["dry_run"].(bool)))
+	gauge("framework_total_plugins", "Loaded plugins", float64(status["total_plugins"].(int)))
+	gauge("framework_collectors", "Loaded collectors", float64(status["collectors"].(int)))
+	gauge("framework_analyzers", "Loaded analyzers", float64(status["analyzers"].(int)))
+	gauge("framework_responders", "Loaded responders", float64(status["responders"].(int)))
+	gauge("framework_agents", "Loaded agents", float64(status["agents"].(int)))
+	gauge("framework_data_channel_depth", "Batches waiting to be processed", float64(len(f.dataChannel)))
+	gauge("framework_data_channel_capacity", "Batches the data channel can hold", float64(cap(f.dataChannel)))
+	gauge("framework_data_channel_degraded", "Whether the data channel has stayed full for longer than the degraded threshold",
+		boolValue(f.backpressure.isDegraded()))
+	if f.overflowPolicy() == OverflowSpill {
+		if paths, size, err := f.backpressure.spilled(); err == nil {
+			gauge("framework_data_spilled_batches", "Batches spilled to disk waiting for room in the data channel", float64(len(paths)))
+			gauge("framework_data_spilled_bytes", "Bytes of batches spilled to disk", float64(size))
+		}
+	}
+
+	if f.wal != nil {
+		stats := f.wal.Stats()
+		gauge("framework_wal_segments", "Segment files of the write-ahead log", float64(stats.Segments))
+		gauge("framework_wal_bytes", "Bytes the write-ahead log takes on disk", float64(stats.Bytes))
+		gauge("framework_wal_pending_entries", "Batches and analyses in the write-ahead log not yet processed", float64(stats.Pending))
+		ch <- prometheus.MustNewConstMetric(prometheus.NewDesc("framework_wal_dropped_entries_total",
+			"Write-ahead log entries dropped by retention before they were processed", nil, nil),
+			prometheus.CounterValue, float64(stats.Dropped))
+	}
+
+	pluginStatus := prometheus.NewDesc("framework_plugin_status", "Current status of each plugin, 1 for the status it is in",
+		[]string{"plugin", "type", "status"}, nil)
+	for _, plugin := range f.registry.ListPlugins() {
+		ch <- prometheus.MustNewConstMetric(pluginStatus, prometheus.GaugeValue, 1,
+			plugin.Name(), string(plugin.Type()), string(plugin.Status()))
+	}
+
+	pluginGoroutines := prometheus.NewDesc("framework_plugin_goroutines",
+		"Goroutines running each plugin's calls or started by them", []string{"plugin"}, nil)
+	for plugin, count := range f.sandbox.goroutines() {
+		ch <- prometheus.MustNewConstMetric(pluginGoroutines, prometheus.GaugeValue, float64(count), plugin)
+	}
+
+	f.collectAPIKeyMetrics(ch)
+	f.collectDeliveryMetrics(ch)
+}
+
+// boolValue converts a boolean to a gauge value
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// pluginMetricsGatherer gathers the series of plugins that export their own metrics in the
+// text exposition format
+type pluginMetricsGatherer struct {
+	framework *Framework
+}
+
+// Gather implements prometheus.Gatherer
+func (g pluginMetricsGatherer) Gather() ([]*dto.MetricFamily, error) {
+	plugins := g.framework.registry.ListPlugins()
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name() < plugins[j].Name() })
+
+	var families []*dto.MetricFamily
+	for _, plugin := range plugins {
+		exporter, ok := plugin.(MetricsExporter)
+		if !ok {
+			continue
+		}
+		var buf bytes.Buffer
+		exporter.WriteMetrics(&buf)
+
+		var parser expfmt.TextParser
+		parsed, err := parser.TextToMetricFamilies(&buf)
+		if err != nil {
+			return families, fmt.Errorf("invalid metrics from plugin %s: %w", plugin.Name(), err)
+		}
+		for _, family := range parsed {
+			families = append(families, family)
+		}
+	}
+	return families, nil
+}
+
+// metricsGatherer gathers everything exposed on /metrics
+func (f *Framework) metricsGatherer() prometheus.Gatherer {
+	gatherers := prometheus.Gatherers{f.metricsRegistry, pluginMetricsGatherer{framework: f}}
+	if gatherer, ok := f.metricsCollector.(prometheus.Gatherer); ok {
+		gatherers = append(gatherers, gatherer)
+	}
+	return gatherers
+}
+
+// WriteMetrics writes every metric exposed on /metrics in the Prometheus text format
+func (f *Framework) WriteMetrics(w io.Writer) error {
+	families, err := f.metricsGatherer().Gather()
+	if err != nil {
+		slog.Error("Failed to gather metrics", "error", err)
+	}
+	encoder := expfmt.NewEncoder(w, expfmt.FmtText)
+	for _, family := range families {
+		if err := encoder.Encode(family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newFrameworkRegistry creates the registry of the framework's scrape-time metrics
+func newFrameworkRegistry(f *Framework) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(frameworkCollector{framework: f})
+	return registry
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusMetricsCollector(t *testing.T) {
+	collector := NewPrometheusMetricsCollector()
+
+	collector.IncrementCounter("jobs_total", map[string]string{"queue": "a"})
+	collector.AddCounter("jobs_total", 2, map[string]string{"queue": "a"})
+	collector.IncrementCounter("jobs_total", map[string]string{"queue": "b"})
+	collector.IncrementCounter("jobs_total", map[string]string{"worker": "1"})
+	collector.SetGauge("queue_depth", 7, nil)
+	collector.ObserveHistogram("job_seconds", 0.2, map[string]string{"queue": "a"})
+	collector.ObserveHistogram("job_seconds", 0.4, map[string]string{"queue": "a"})
+
+	metrics := collector.GetMetrics()
+	assert.Equal(t, 3.0, metrics[`jobs_total{queue="a"}`])
+	assert.Equal(t, 1.0, metrics[`jobs_total{queue="b"}`])
+	assert.NotContains(t, metrics, `jobs_total{worker="1"}`, "Expected labels that differ from the first use to be ignored")
+	assert.Equal(t, 7.0, metrics["queue_depth"])
+	histogram := metrics[`job_seconds{queue="a"}`].(map[string]interface{})
+	assert.Equal(t, uint64(2), histogram["count"])
+	assert.InDelta(t, 0.6, histogram["sum"], 1e-9)
+	assert.Contains(t, metrics, "go_goroutines", "Expected Go runtime metrics")
+}
+
+func TestFramework_PipelineMetrics(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout", DataChannelSize: 10})
+	require.NoError(t, framework.LoadPlugin(&MockAnalyzer{MockPlugin{name: "anomaly", pluginType: PluginTypeAnalyzer, status: PluginStatusRunning}}))
+
+	now := time.Now()
+	framework.processData(context.Background(), []DataPoint{
+		{Metric: "cpu_usage_percent", Value: 40, Timestamp: now},
+		{Metric: "cpu_usage_percent", Value: 45, Timestamp: now},
+		{Metric: "memory_bytes", Value: 1024, Timestamp: now},
+	})
+	framework.dataChannel <- []DataPoint{{Metric: "cpu_usage_percent", Value: 50, Timestamp: now}}
+
+	var buf bytes.Buffer
+	require.NoError(t, framework.WriteMetrics(&buf))
+	metrics := buf.String()
+	assert.Contains(t, metrics, "framework_data_batches_total 1")
+	assert.Contains(t, metrics, "framework_data_points_processed_total 3")
+	assert.Contains(t, metrics, `framework_analyzer_duration_seconds_count{analyzer="anomaly"} 1`)
+	assert.Contains(t, metrics, "framework_data_channel_depth 1")
+	assert.Contains(t, metrics, "framework_data_channel_capacity 10")
+	assert.Contains(t, metrics, `framework_plugin_status{plugin="anomaly",status="running",type="analyzer"} 1`)
+	assert.Contains(t, metrics, "# HELP framework_data_points_processed_total Data points run through the analyzers")
+}
@@ -0,0 +1,261 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Noise report defaults used when the configuration leaves them unset
+const (
+	defaultNoiseReportWindow = 7 * 24 * time.Hour
+	defaultNoiseReportTop    = 10
+)
+
+// noiseMinAlerts is how many alerts a metric needs before a threshold change is recommended
+const noiseMinAlerts = 5
+
+// noiseReportSource is the Source of the analyses delivering noise reports
+const noiseReportSource = "noise-report"
+
+// MetricNoise scores how much one metric's alerts were acted on. Alerts that are rarely
+// acknowledged, or that resolve on their own within minutes, are noise.
+type MetricNoise struct {
+	Metric       string  `json:"metric"`
+	Alerts       int     `json:"alerts"`
+	Incidents    int     `json:"incidents"`
+	Acknowledged int     `json:"acknowledged"`
+	AckRate      float64 `json:"ack_rate"`
+	Resolved     int     `json:"resolved"`
+	// MeanTimeToResolve is averaged over the resolved incidents
+	MeanTimeToResolve time.Duration `json:"mean_time_to_resolve"`
+	// Score is the number of alerts nobody acknowledged; higher is noisier
+	Score          float64 `json:"score"`
+	Recommendation string  `json:"recommendation,omitempty"`
+}
+
+// NoiseReport ranks the noisiest alerting metrics over a window
+type NoiseReport struct {
+	GeneratedAt time.Time     `json:"generated_at"`
+	Window      time.Duration `json:"window"`
+	Metrics     []MetricNoise `json:"metrics"`
+	// Draft is the configured agent's suggested threshold changes, if one was asked
+	Draft string `json:"draft,omitempty"`
+}
+
+// NoiseReport scores the alerts raised in the given window, noisiest first, keeping the
+// configured number of metrics. A zero window uses the configured one.
+func (f *Framework) NoiseReport(ctx context.Context, window time.Duration) (*NoiseReport, error) {
+	config := f.config.NoiseReport
+	if window <= 0 {
+		window = config.Window
+	}
+	if window <= 0 {
+		window = defaultNoiseReportWindow
+	}
+	top := config.Top
+	if top <= 0 {
+		top = defaultNoiseReportTop
+	}
+
+	now := time.Now()
+	analyses, err := f.history.List(ctx, now.Add(-window))
+	if err != nil {
+		return nil, WrapError(err, ErrorTypeInternal, "noise-report", "list-analyses", "failed to list analyses")
+	}
+
+	report := &NoiseReport{GeneratedAt: now, Window: window}
+	report.Metrics = scoreNoise(analyses, f.incidents.List(), now.Add(-window))
+	if len(report.Metrics) > top {
+		report.Metrics = report.Metrics[:top]
+	}
+
+	if config.Agent != "" && len(report.Metrics) > 0 {
+		response, err := f.QueryAgent(ctx, config.Agent, noiseReportPrompt(report))
+		if err != nil {
+			slog.Warn("Failed to draft noise report recommendations", "agent", config.Agent, "error", err)
+		} else {
+			report.Draft = response.Response
+		}
+	}
+	return report, nil
+}
+
+// scoreNoise counts the alerts and incidents of each metric since the given time and
+// ranks the metrics by how many of their alerts went unacknowledged
+func scoreNoise(analyses []Analysis, incidents []Incident, since time.Time) []MetricNoise {
+	byMetric := make(map[string]*MetricNoise)
+	metricOf := make(map[string]string) // fingerprint -> metric
+	for i := range analyses {
+		analysis := &analyses[i]
+		if analysis.Source == noiseReportSource || analysis.Resolved {
+			continue
+		}
+		metric := analysisMetric(analysis)
+		noise, ok := byMetric[metric]
+		if !ok {
+			noise = &MetricNoise{Metric: metric}
+			byMetric[metric] = noise
+		}
+		noise.Alerts++
+		metricOf[analysis.Fingerprint] = metric
+	}
+
+	resolveTime := make(map[string]time.Duration)
+	for _, incident := range incidents {
+		metric, ok := metricOf[incident.Fingerprint]
+		if !ok || incident.OpenedAt.Before(since) {
+			continue
+		}
+		noise := byMetric[metric]
+		noise.Incidents++
+		acknowledged := false
+		for _, event := range incident.Timeline {
+			switch event.Type {
+			case "acknowledged":
+				acknowledged = true
+			case "resolved":
+				noise.Resolved++
+				resolveTime[metric] += event.Timestamp.Sub(incident.OpenedAt)
+			}
+		}
+		if acknowledged {
+			noise.Acknowledged++
+		}
+	}
+
+	metrics := make([]MetricNoise, 0, len(byMetric))
+	for metric, noise := range byMetric {
+		if noise.Incidents > 0 {
+			noise.AckRate = float64(noise.Acknowledged) / float64(noise.Incidents)
+		}
+		if noise.Resolved > 0 {
+			noise.MeanTimeToResolve = resolveTime[metric] / time.Duration(noise.Resolved)
+		}
+		noise.Score = float64(noise.Alerts) * (1 - noise.AckRate)
+		noise.Recommendation = noiseRecommendation(noise)
+		metrics = append(metrics, *noise)
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].Score != metrics[j].Score {
+			return metrics[i].Score > metrics[j].Score
+		}
+		return metrics[i].Metric < metrics[j].Metric
+	})
+	return metrics
+}
+
+// noiseRecommendation suggests how to tune a metric's alerting, or returns "" when its
+// alerts look worth keeping as they are
+func noiseRecommendation(noise *MetricNoise) string {
+	if noise.Alerts < noiseMinAlerts {
+		return ""
+	}
+	switch {
+	case noise.Incidents > 0 && noise.AckRate < 0.2:
+		return fmt.Sprintf("%.0f%% of incidents acknowledged; raise the threshold or lower the severity", noise.AckRate*100)
+	case noise.Resolved > 0 && noise.MeanTimeToResolve < 10*time.Minute:
+		return fmt.Sprintf("incidents resolve in %s on average; require the condition to hold longer before alerting",
+			noise.MeanTimeToResolve.Round(time.Second))
+	case noise.Incidents > 0 && float64(noise.Alerts)/float64(noise.Incidents) > 10:
+		return "repeats many times per incident; increase the dedup repeat interval"
+	}
+	return ""
+}
+
+// analysisMetric names the metric an analysis is about: its first data point's, or the
+// source analyzer's name when it has none
+func analysisMetric(analysis *Analysis) string {
+	for _, point := range analysis.DataPoints {
+		if point.Metric != "" {
+			return point.Metric
+		}
+	}
+	if metric, ok := analysis.Details["metric"].(string); ok && metric != "" {
+		return metric
+	}
+	return analysis.Source
+}
+
+// noiseReportPrompt asks an agent for threshold changes for the report's metrics
+func noiseReportPrompt(report *NoiseReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "These alerts were the noisiest over the last %s. ", report.Window)
+	b.WriteString("Suggest concrete threshold or duration changes for each, in one line per metric.\n")
+	for _, noise := range report.Metrics {
+		fmt.Fprintf(&b, "- %s: %d alerts, %d incidents, %.0f%% acknowledged, mean time to resolve %s\n",
+			noise.Metric, noise.Alerts, noise.Incidents, noise.AckRate*100, noise.MeanTimeToResolve.Round(time.Second))
+	}
+	return b.String()
+}
+
+// noiseReportAnalysis wraps a report in an analysis so responders can deliver it
+func noiseReportAnalysis(report *NoiseReport) *Analysis {
+	summary := fmt.Sprintf("Noisiest alerts over the last %s", report.Window)
+	lines := make([]string, 0, len(report.Metrics))
+	for _, noise := range report.Metrics {
+		line := fmt.Sprintf("%s: %d alerts, %.0f%% acknowledged", noise.Metric, noise.Alerts, noise.AckRate*100)
+		if noise.Recommendation != "" {
+			line += " (" + noise.Recommendation + ")"
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) > 0 {
+		summary += ": " + strings.Join(lines, "; ")
+	}
+
+	analysis := &Analysis{
+		Type:       AnalysisTypeReport,
+		Confidence: 1.0,
+		Severity:   "low",
+		Summary:    summary,
+		Details:    map[string]interface{}{"noise_report": report},
+		Timestamp:  report.GeneratedAt,
+		Source:     noiseReportSource,
+	}
+	if report.Draft != "" {
+		analysis.Details["draft"] = report.Draft
+	}
+	analysis.EnsureIdentity()
+	return analysis
+}
+
+// noiseReportWorker periodically sends the noise report to the configured responders
+func (f *Framework) noiseReportWorker(ctx context.Context) {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(f.config.NoiseReport.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Noise report worker stopping due to context cancellation")
+			return
+		case <-ticker.C:
+			f.sendNoiseReport(ctx)
+		}
+	}
+}
+
+// sendNoiseReport builds the noise report and delivers it like an analysis, without
+// tracking it as an incident. It only goes to the configured responders; with none, it is
+// not sent rather than fanned out to every responder.
+func (f *Framework) sendNoiseReport(ctx context.Context) {
+	if len(f.config.NoiseReport.Responders) == 0 {
+		slog.Warn("Noise report not sent: no noise_report.responders configured")
+		return
+	}
+	report, err := f.NoiseReport(ctx, 0)
+	if err != nil {
+		slog.Error("Failed to build noise report", "error", err)
+		return
+	}
+	if len(report.Metrics) == 0 {
+		return
+	}
+	f.respond(WithTraceID(ctx, NewTraceID()), noiseReportAnalysis(report), f.config.NoiseReport.Responders)
+}
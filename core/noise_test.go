@@ -0,0 +1,123 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreNoise(t *testing.T) {
+	now := time.Now()
+	var analyses []Analysis
+	for i := 0; i < 8; i++ {
+		analysis := testAnalysis("cpu")
+		analysis.EnsureIdentity()
+		analyses = append(analyses, *analysis)
+	}
+	for i := 0; i < 6; i++ {
+		analysis := testAnalysis("disk")
+		analysis.EnsureIdentity()
+		analyses = append(analyses, *analysis)
+	}
+
+	incidents := []Incident{
+		{
+			Fingerprint: analyses[0].Fingerprint,
+			OpenedAt:    now.Add(-time.Hour),
+			Timeline: []IncidentEvent{
+				{Type: "opened", Timestamp: now.Add(-time.Hour)},
+				{Type: "resolved", Timestamp: now.Add(-time.Hour + 2*time.Minute)},
+			},
+		},
+		{
+			Fingerprint: analyses[8].Fingerprint,
+			OpenedAt:    now.Add(-time.Hour),
+			Timeline: []IncidentEvent{
+				{Type: "opened", Timestamp: now.Add(-time.Hour)},
+				{Type: "acknowledged", Actor: "alice", Timestamp: now.Add(-50 * time.Minute)},
+				{Type: "resolved", Timestamp: now.Add(-30 * time.Minute)},
+			},
+		},
+	}
+
+	metrics := scoreNoise(analyses, incidents, now.Add(-24*time.Hour))
+	require.Len(t, metrics, 2)
+
+	cpu := metrics[0]
+	assert.Equal(t, "cpu", cpu.Metric, "Expected unacknowledged alerts to rank first")
+	assert.Equal(t, 8, cpu.Alerts)
+	assert.Equal(t, 1, cpu.Incidents)
+	assert.Equal(t, 0.0, cpu.AckRate)
+	assert.Equal(t, 2*time.Minute, cpu.MeanTimeToResolve)
+	assert.Equal(t, 8.0, cpu.Score)
+	assert.Contains(t, cpu.Recommendation, "raise the threshold")
+
+	disk := metrics[1]
+	assert.Equal(t, 1.0, disk.AckRate)
+	assert.Equal(t, 0.0, disk.Score)
+	assert.Empty(t, disk.Recommendation, "Expected acted-on alerts to be left alone")
+}
+
+func TestFramework_NoiseReport(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{
+		LogLevel:    "info",
+		LogFormat:   "text",
+		LogOutput:   "stdout",
+		NoiseReport: NoiseReportConfig{Top: 1, Responders: []string{"digest"}},
+	})
+	digest := &severityResponder{MockPlugin: MockPlugin{name: "digest", pluginType: PluginTypeResponder}, severity: "low"}
+	other := &severityResponder{MockPlugin: MockPlugin{name: "other", pluginType: PluginTypeResponder}, severity: "low"}
+	require.NoError(t, framework.LoadPlugin(digest))
+	require.NoError(t, framework.LoadPlugin(other))
+
+	ctx := context.Background()
+	for _, metric := range []string{"cpu", "cpu", "memory"} {
+		analysis := testAnalysis(metric)
+		framework.GetIncidentManager().Track(analysis)
+		require.NoError(t, framework.GetAnalysisHistory().Record(ctx, analysis))
+	}
+
+	framework.config.NoiseReport.Responders = nil
+	framework.sendNoiseReport(ctx)
+	assert.Empty(t, digest.handled, "Expected no report without configured responders")
+	assert.Empty(t, other.handled, "Expected the report never to fan out to every responder")
+
+	framework.config.NoiseReport.Responders = []string{"digest"}
+	framework.sendNoiseReport(ctx)
+	require.Len(t, digest.handled, 1)
+	assert.Empty(t, other.handled, "Expected the report to go to the configured responders only")
+	delivered := digest.handled[0]
+	assert.Equal(t, AnalysisTypeReport, delivered.Type)
+	assert.Contains(t, delivered.Summary, "cpu: 2 alerts")
+	report := delivered.Details["noise_report"].(*NoiseReport)
+	require.Len(t, report.Metrics, 1, "Expected the report to keep the configured number of metrics")
+	assert.Equal(t, 7*24*time.Hour, report.Window)
+
+	mux := http.NewServeMux()
+	framework.registerAPIRoutes(mux)
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/reports/noise?window=1h", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"metric":"cpu"`)
+
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/reports/noise?window=soon", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestValidateFrameworkConfig_NoiseReport(t *testing.T) {
+	config := reloadConfig()
+	config.NoiseReport = NoiseReportConfig{Interval: 24 * time.Hour}
+	assert.ErrorContains(t, ValidateFrameworkConfig(config), "noise_report.responders", "Expected a scheduled report to name its responders")
+	config.NoiseReport.Responders = []string{""}
+	assert.Error(t, ValidateFrameworkConfig(config))
+	config.NoiseReport.Responders = []string{"slack"}
+	assert.NoError(t, ValidateFrameworkConfig(config))
+	config.NoiseReport = NoiseReportConfig{}
+	assert.NoError(t, ValidateFrameworkConfig(config), "Expected an unscheduled report to need no responders")
+}
@@ -0,0 +1,63 @@
+package core
+
+import "fmt"
+
+// MetricNormalizer renames metrics that different collectors report under different names,
+// e.g. node_cpu_percent and container_cpu_pct, to one canonical name so analyzers, agents,
+// and the knowledge base treat them as the same concept
+type MetricNormalizer struct {
+	canonical map[string]string
+}
+
+// NewMetricNormalizer creates a normalizer from canonical names and their aliases
+func NewMetricNormalizer(aliases map[string][]string) (*MetricNormalizer, error) {
+	n := &MetricNormalizer{canonical: make(map[string]string)}
+	for canonical, names := range aliases {
+		for _, alias := range names {
+			if alias == canonical {
+				continue
+			}
+			if existing, ok := n.canonical[alias]; ok && existing != canonical {
+				return nil, NewConfigurationError("metrics", "normalize",
+					fmt.Sprintf("alias %q maps to both %q and %q", alias, existing, canonical))
+			}
+			if _, ok := aliases[alias]; ok {
+				return nil, NewConfigurationError("metrics", "normalize",
+					fmt.Sprintf("alias %q is also a canonical name", alias))
+			}
+			n.canonical[alias] = canonical
+		}
+	}
+	return n, nil
+}
+
+// Canonical returns the canonical name for a metric
+func (n *MetricNormalizer) Canonical(metric string) string {
+	if n == nil {
+		return metric
+	}
+	if canonical, ok := n.canonical[metric]; ok {
+		return canonical
+	}
+	return metric
+}
+
+// Normalize renames aliased metrics in place, keeping the reported name in the point's
+// metadata under "original_metric"
+func (n *MetricNormalizer) Normalize(data []DataPoint) []DataPoint {
+	if n == nil || len(n.canonical) == 0 {
+		return data
+	}
+	for i := range data {
+		canonical, ok := n.canonical[data[i].Metric]
+		if !ok {
+			continue
+		}
+		if data[i].Metadata == nil {
+			data[i].Metadata = make(map[string]interface{})
+		}
+		data[i].Metadata["original_metric"] = data[i].Metric
+		data[i].Metric = canonical
+	}
+	return data
+}
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricNormalizer_Normalize(t *testing.T) {
+	normalizer, err := NewMetricNormalizer(map[string][]string{
+		"cpu_usage_percent": {"node_cpu_percent", "container_cpu_pct"},
+	})
+	require.NoError(t, err)
+
+	data := normalizer.Normalize([]DataPoint{
+		{Metric: "node_cpu_percent", Value: 40},
+		{Metric: "container_cpu_pct", Value: 55, Metadata: map[string]interface{}{"pod": "api-1"}},
+		{Metric: "memory_usage_percent", Value: 70},
+	})
+
+	assert.Equal(t, "cpu_usage_percent", data[0].Metric)
+	assert.Equal(t, "node_cpu_percent", data[0].Metadata["original_metric"])
+	assert.Equal(t, "cpu_usage_percent", data[1].Metric)
+	assert.Equal(t, "api-1", data[1].Metadata["pod"], "Expected existing metadata to be kept")
+	assert.Equal(t, "memory_usage_percent", data[2].Metric)
+	assert.Nil(t, data[2].Metadata, "Expected unaliased points to be untouched")
+}
+
+func TestMetricNormalizer_RejectsConflicts(t *testing.T) {
+	_, err := NewMetricNormalizer(map[string][]string{
+		"cpu_usage_percent": {"cpu"},
+		"cpu_seconds_total": {"cpu"},
+	})
+	assert.Error(t, err, "Expected an alias with two canonical names to be rejected")
+
+	_, err = NewMetricNormalizer(map[string][]string{
+		"cpu_usage_percent": {"node_cpu_percent"},
+		"node_cpu_percent":  {"cpu"},
+	})
+	assert.Error(t, err, "Expected chained aliases to be rejected")
+}
@@ -0,0 +1,429 @@
+package core
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OIDC token validation settings
+const (
+	defaultRolesClaim = "roles"
+	// Signing keys are fetched again after this long, or sooner for a token signed with an
+	// unknown key, but never more often than jwksMinRefresh, even after a failed fetch
+	jwksRefreshInterval = time.Hour
+	jwksMinRefresh      = time.Minute
+	jwksFetchTimeout    = 10 * time.Second
+	// Allowed difference between our clock and the identity provider's
+	jwtClockSkew = time.Minute
+)
+
+// jwtHashes are the signature algorithms accepted, with the hash each signs
+var jwtHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// oidcValidator checks JWTs issued by an OpenID Connect provider and maps the roles they
+// carry to scopes. Signing keys are fetched on first use and cached.
+type oidcValidator struct {
+	config OIDCConfig
+	client *http.Client
+
+	keys map[string]crypto.PublicKey
+	// fetched is when the keys were last fetched, attempted when a fetch last started
+	fetched   time.Time
+	attempted time.Time
+	// fetchErr is why the last fetch failed
+	fetchErr error
+	// refresh is closed when the fetch in flight finishes, and is nil when there is none;
+	// requests needing new keys wait for it rather than fetching again
+	refresh chan struct{}
+	mu      sync.Mutex
+}
+
+// jwtClaims are the registered claims checked on every token
+type jwtClaims struct {
+	Issuer    string      `json:"iss"`
+	Subject   string      `json:"sub"`
+	Audience  jwtAudience `json:"aud"`
+	ExpiresAt int64       `json:"exp"`
+	NotBefore int64       `json:"nbf"`
+}
+
+// jwtAudience is the aud claim, which is a string or an array of strings
+type jwtAudience []string
+
+// UnmarshalJSON accepts either form of the audience claim
+func (a *jwtAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = jwtAudience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+// jsonWebKey is one key of a JWKS document; only RSA and EC keys are used
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+// newOIDCValidator creates a validator for the provider's tokens
+func newOIDCValidator(config OIDCConfig) *oidcValidator {
+	if config.RolesClaim == "" {
+		config.RolesClaim = defaultRolesClaim
+	}
+	return &oidcValidator{config: config, client: &http.Client{Timeout: jwksFetchTimeout}}
+}
+
+// looksLikeJWT reports whether a bearer token has the three parts of a JWT, so it is
+// validated as one rather than looked up as an API key
+func looksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// Validate checks a token's signature, issuer, audience, and lifetime, and returns its
+// subject with the scopes its roles grant
+func (v *oidcValidator) Validate(ctx context.Context, token string) (string, map[APIScope]bool, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", nil, NewValidationError("oidc", "validate", "malformed token")
+	}
+
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return "", nil, NewValidationError("oidc", "validate", fmt.Sprintf("malformed token header: %v", err))
+	}
+	hash, ok := jwtHashes[header.Algorithm]
+	if !ok {
+		return "", nil, NewValidationError("oidc", "validate", fmt.Sprintf("unsupported signing algorithm %q", header.Algorithm))
+	}
+	key, err := v.key(ctx, header.KeyID)
+	if err != nil {
+		return "", nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, NewValidationError("oidc", "validate", "malformed token signature")
+	}
+	if err := verifyJWTSignature(header.Algorithm, hash, key, parts[0]+"."+parts[1], signature); err != nil {
+		return "", nil, NewValidationError("oidc", "validate", err.Error())
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return "", nil, NewValidationError("oidc", "validate", fmt.Sprintf("malformed token claims: %v", err))
+	}
+	if err := v.checkClaims(claims, time.Now()); err != nil {
+		return "", nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := decodeJWTPart(parts[1], &raw); err != nil {
+		return "", nil, NewValidationError("oidc", "validate", fmt.Sprintf("malformed token claims: %v", err))
+	}
+	scopes := make(map[APIScope]bool)
+	for _, role := range claimValues(raw[v.config.RolesClaim]) {
+		for _, scope := range v.config.Roles[role] {
+			scopes[APIScope(scope)] = true
+		}
+	}
+	return claims.Subject, scopes, nil
+}
+
+// checkClaims checks the issuer, audience, and lifetime of a token
+func (v *oidcValidator) checkClaims(claims jwtClaims, now time.Time) error {
+	if strings.TrimSuffix(claims.Issuer, "/") != strings.TrimSuffix(v.config.Issuer, "/") {
+		return NewValidationError("oidc", "validate", fmt.Sprintf("token issued by %q", claims.Issuer))
+	}
+	audience := false
+	for _, value := range claims.Audience {
+		audience = audience || value == v.config.Audience
+	}
+	if !audience {
+		return NewValidationError("oidc", "validate", "token is not meant for this audience")
+	}
+	if claims.ExpiresAt == 0 || now.Add(-jwtClockSkew).After(time.Unix(claims.ExpiresAt, 0)) {
+		return NewValidationError("oidc", "validate", "token has expired")
+	}
+	if claims.NotBefore != 0 && now.Add(jwtClockSkew).Before(time.Unix(claims.NotBefore, 0)) {
+		return NewValidationError("oidc", "validate", "token is not valid yet")
+	}
+	return nil
+}
+
+// key returns the signing key with the given ID, fetching the keys again when they are old
+// or the ID is unknown. The fetch runs without the lock, so requests with known keys are not
+// held up by a slow provider.
+func (v *oidcValidator) key(ctx context.Context, id string) (crypto.PublicKey, error) {
+	for {
+		v.mu.Lock()
+		key, known := v.lookup(id)
+		if known && time.Since(v.fetched) < jwksRefreshInterval {
+			v.mu.Unlock()
+			return key, nil
+		}
+
+		refresh := v.refresh
+		if refresh == nil {
+			if !v.attempted.IsZero() && time.Since(v.attempted) < jwksMinRefresh {
+				fetchErr := v.fetchErr
+				v.mu.Unlock()
+				switch {
+				case known:
+					// Keep using the cached keys while the provider cannot be reached
+					return key, nil
+				case fetchErr != nil:
+					return nil, WrapError(fetchErr, ErrorTypeNetwork, "oidc", "key", "failed to fetch signing keys")
+				default:
+					return nil, NewValidationError("oidc", "key", fmt.Sprintf("unknown signing key %q", id))
+				}
+			}
+
+			refresh = make(chan struct{})
+			v.refresh, v.attempted = refresh, time.Now()
+			v.mu.Unlock()
+			keys, err := v.fetchKeys(ctx)
+			v.mu.Lock()
+			if err == nil {
+				v.keys, v.fetched = keys, time.Now()
+			}
+			v.fetchErr, v.refresh = err, nil
+			close(refresh)
+			v.mu.Unlock()
+			continue
+		}
+		v.mu.Unlock()
+
+		if known {
+			// Another request is fetching newer keys
+			return key, nil
+		}
+		select {
+		case <-refresh:
+		case <-ctx.Done():
+			return nil, WrapError(ctx.Err(), ErrorTypeNetwork, "oidc", "key", "cancelled waiting for signing keys")
+		}
+	}
+}
+
+// lookup finds a cached key; tokens without a key ID match a provider's only key
+func (v *oidcValidator) lookup(id string) (crypto.PublicKey, bool) {
+	if id == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[id]
+	return key, ok
+}
+
+// fetchKeys reads the provider's signing keys, finding the JWKS URL through discovery if
+// it is not configured
+func (v *oidcValidator) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	url := v.config.JWKSURL
+	if url == "" {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := v.getJSON(ctx, strings.TrimSuffix(v.config.Issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+			return nil, err
+		}
+		if discovery.JWKSURI == "" {
+			return nil, errors.New("discovery document has no jwks_uri")
+		}
+		url = discovery.JWKSURI
+	}
+
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJSON(ctx, url, &document); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(document.Keys))
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// One key the validator cannot use must not lock out tokens signed by the others
+			slog.Warn("Skipping OIDC signing key", "kid", jwk.KeyID, "kty", jwk.KeyType, "error", err)
+			continue
+		}
+		if key != nil {
+			keys[jwk.KeyID] = key
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s has no usable signing keys", url)
+	}
+	return keys, nil
+}
+
+// getJSON fetches and decodes a JSON document
+func (v *oidcValidator) getJSON(ctx context.Context, url string, target interface{}) error {
+	// Keys are cached for every later request, so a fetch is not cut short by the request
+	// that happened to trigger it
+	request, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	response, err := v.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", url, response.Status)
+	}
+	return json.NewDecoder(response.Body).Decode(target)
+}
+
+// publicKey decodes an RSA or EC key; other key types are skipped
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeJWKNumber(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJWKNumber(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("RSA exponent is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := decodeJWKNumber(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJWKNumber(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// verifyJWTSignature checks a token's signature over its header and claims
+func verifyJWTSignature(algorithm string, hash crypto.Hash, key crypto.PublicKey, signed string, signature []byte) error {
+	hasher := hash.New()
+	hasher.Write([]byte(signed))
+	digest := hasher.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(algorithm, "RS") {
+			return fmt.Errorf("%s token signed with an RSA key", algorithm)
+		}
+		if err := rsa.VerifyPKCS1v15(key, hash, digest, signature); err != nil {
+			return errors.New("invalid token signature")
+		}
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(algorithm, "ES") {
+			return fmt.Errorf("%s token signed with an EC key", algorithm)
+		}
+		// The signature is r and s, each as long as the curve's byte size
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid token signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return errors.New("invalid token signature")
+		}
+	default:
+		return errors.New("unsupported signing key")
+	}
+	return nil
+}
+
+// decodeJWTPart decodes a base64url JSON part of a token
+func decodeJWTPart(part string, target interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// decodeJWKNumber decodes a base64url big-endian number of a JWK
+func decodeJWKNumber(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("malformed key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// claimValues reads a roles claim, either an array or a space-separated string
+func claimValues(claim interface{}) []string {
+	switch claim := claim.(type) {
+	case string:
+		return strings.Fields(claim)
+	case []interface{}:
+		values := make([]string, 0, len(claim))
+		for _, value := range claim {
+			if value, ok := value.(string); ok {
+				values = append(values, value)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,276 @@
+package core
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testIdentityProvider serves a discovery document and JWKS, and signs tokens
+type testIdentityProvider struct {
+	server     *httptest.Server
+	rsaKey     *rsa.PrivateKey
+	ecKey      *ecdsa.PrivateKey
+	keyFetches atomic.Int32
+}
+
+func newTestIdentityProvider(t *testing.T) *testIdentityProvider {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	provider := &testIdentityProvider{rsaKey: rsaKey, ecKey: ecKey}
+
+	encode := func(n *big.Int) string { return base64.RawURLEncoding.EncodeToString(n.Bytes()) }
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"issuer": provider.server.URL, "jwks_uri": provider.server.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		provider.keyFetches.Add(1)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa", "use": "sig", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+			{"kty": "EC", "kid": "ec", "crv": "P-256", "x": encode(ecKey.X), "y": encode(ecKey.Y)},
+		}})
+	})
+	provider.server = httptest.NewServer(mux)
+	t.Cleanup(provider.server.Close)
+	return provider
+}
+
+// sign creates a token with the given claims, signed by the key named by kid
+func (p *testIdentityProvider) sign(t *testing.T, kid string, claims map[string]interface{}) string {
+	algorithm := "RS256"
+	if kid == "ec" {
+		algorithm = "ES256"
+	}
+	header, err := json.Marshal(map[string]string{"alg": algorithm, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	if kid == "ec" {
+		r, s, err := ecdsa.Sign(rand.Reader, p.ecKey, digest[:])
+		require.NoError(t, err)
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	} else {
+		signature, err = rsa.SignPKCS1v15(rand.Reader, p.rsaKey, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestAPIKeyManager_OIDC(t *testing.T) {
+	provider := newTestIdentityProvider(t)
+	manager := NewAPIKeyManager(nil, AuthConfig{OIDC: &OIDCConfig{
+		Issuer:   provider.server.URL,
+		Audience: "agent",
+		Roles:    map[string][]string{"sre": {"operate"}, "viewer": {"query"}},
+	}})
+
+	claims := func(changes map[string]interface{}) map[string]interface{} {
+		result := map[string]interface{}{
+			"iss":   provider.server.URL,
+			"sub":   "alice",
+			"aud":   []string{"agent", "other"},
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"roles": []string{"sre"},
+		}
+		for key, value := range changes {
+			result[key] = value
+		}
+		return result
+	}
+
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		token    string
+		scope    APIScope
+		expected error
+	}{
+		{name: "RSA token", token: provider.sign(t, "rsa", claims(nil)), scope: APIScopeOperate},
+		{name: "EC token", token: provider.sign(t, "ec", claims(nil)), scope: APIScopeQuery},
+		{name: "audience string", token: provider.sign(t, "rsa", claims(map[string]interface{}{"aud": "agent"})), scope: APIScopeQuery},
+		{name: "space-separated roles", token: provider.sign(t, "rsa", claims(map[string]interface{}{"roles": "viewer other"})), scope: APIScopeQuery},
+		{name: "role without the scope", token: provider.sign(t, "rsa", claims(map[string]interface{}{"roles": []string{"viewer"}})),
+			scope: APIScopeOperate, expected: ErrAPIKeyForbidden},
+		{name: "unknown role", token: provider.sign(t, "rsa", claims(map[string]interface{}{"roles": []string{"guest"}})),
+			scope: APIScopeQuery, expected: ErrAPIKeyForbidden},
+		{name: "expired", token: provider.sign(t, "rsa", claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})),
+			scope: APIScopeQuery, expected: ErrAPIKeyInvalid},
+		{name: "not yet valid", token: provider.sign(t, "rsa", claims(map[string]interface{}{"nbf": time.Now().Add(time.Hour).Unix()})),
+			scope: APIScopeQuery, expected: ErrAPIKeyInvalid},
+		{name: "other audience", token: provider.sign(t, "rsa", claims(map[string]interface{}{"aud": "billing"})),
+			scope: APIScopeQuery, expected: ErrAPIKeyInvalid},
+		{name: "other issuer", token: provider.sign(t, "rsa", claims(map[string]interface{}{"iss": "https://evil.example.com"})),
+			scope: APIScopeQuery, expected: ErrAPIKeyInvalid},
+		{name: "unknown key", token: provider.sign(t, "rotated", claims(nil)), scope: APIScopeQuery, expected: ErrAPIKeyInvalid},
+		{name: "tampered claims", token: tamper(provider.sign(t, "rsa", claims(map[string]interface{}{"roles": []string{"viewer"}}))),
+			scope: APIScopeOperate, expected: ErrAPIKeyInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := manager.Authorize(ctx, Credentials{Token: tt.token}, tt.scope)
+			if tt.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expected)
+			}
+		})
+	}
+
+	// Keys are cached, and an unknown key does not fetch them again so soon after
+	assert.Equal(t, int32(1), provider.keyFetches.Load())
+	usage := manager.Usage()
+	require.Len(t, usage, 1)
+	assert.Equal(t, oidcUsageName, usage[0].Name)
+	assert.Equal(t, int64(4), usage[0].Allowed)
+	assert.Equal(t, int64(2), usage[0].Forbidden)
+}
+
+// tamper swaps a token's claims for claims granting the sre role, keeping the signature
+func tamper(token string) string {
+	parts := strings.Split(token, ".")
+	claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	claims = []byte(strings.Replace(string(claims), `"viewer"`, `"sre"`, 1))
+	return parts[0] + "." + base64.RawURLEncoding.EncodeToString(claims) + "." + parts[2]
+}
+
+func TestOIDCValidator_RejectsUnsignedTokens(t *testing.T) {
+	validator := newOIDCValidator(OIDCConfig{Issuer: "https://id.example.com", Audience: "agent"})
+	for _, algorithm := range []string{"none", "HS256"} {
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"` + algorithm + `"}`))
+		payload := base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"https://id.example.com","aud":"agent"}`))
+		_, _, err := validator.Validate(context.Background(), header+"."+payload+".")
+		assert.Error(t, err, "Expected %s tokens to be rejected", algorithm)
+	}
+}
+
+// jwksServer serves the keys from keys(), counting the fetches
+func jwksServer(t *testing.T, fetches *atomic.Int32, keys func(w http.ResponseWriter)) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		keys(w)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOIDCValidator_FetchesKeysOnce(t *testing.T) {
+	provider := newTestIdentityProvider(t)
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	server := jwksServer(t, &fetches, func(w http.ResponseWriter) {
+		<-release
+		provider.server.Config.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/keys", nil))
+	})
+	validator := newOIDCValidator(OIDCConfig{Issuer: provider.server.URL, Audience: "agent", JWKSURL: server.URL})
+	ctx := context.Background()
+
+	// Requests arriving while the keys are fetched wait for that fetch
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			_, err := validator.key(ctx, "rsa")
+			errs <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, <-errs)
+	}
+	assert.Equal(t, int32(1), fetches.Load())
+
+	// A refresh of old keys does not hold up requests with a cached key
+	validator.mu.Lock()
+	validator.fetched = time.Now().Add(-2 * jwksRefreshInterval)
+	validator.attempted = validator.fetched
+	validator.mu.Unlock()
+	release = make(chan struct{})
+	refreshed := make(chan error, 1)
+	go func() {
+		_, err := validator.key(ctx, "rsa")
+		refreshed <- err
+	}()
+	require.Eventually(t, func() bool { return fetches.Load() == 2 }, time.Second, 5*time.Millisecond)
+	done := make(chan error, 1)
+	go func() {
+		_, err := validator.key(ctx, "ec")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Expected a cached key to be returned while the keys are fetched")
+	}
+	close(release)
+	require.NoError(t, <-refreshed)
+}
+
+func TestOIDCValidator_RateLimitsFailedFetches(t *testing.T) {
+	var fetches atomic.Int32
+	server := jwksServer(t, &fetches, func(w http.ResponseWriter) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	validator := newOIDCValidator(OIDCConfig{Issuer: "https://id.example.com", Audience: "agent", JWKSURL: server.URL})
+
+	for i := 0; i < 3; i++ {
+		_, err := validator.key(context.Background(), "rotated")
+		assert.ErrorContains(t, err, "failed to fetch signing keys")
+	}
+	assert.Equal(t, int32(1), fetches.Load(), "Expected a failed fetch not to be retried at once")
+}
+
+func TestOIDCValidator_SkipsUnsupportedKeys(t *testing.T) {
+	provider := newTestIdentityProvider(t)
+	encode := func(n *big.Int) string { return base64.RawURLEncoding.EncodeToString(n.Bytes()) }
+	unsupported := map[string]string{"kty": "EC", "kid": "old", "crv": "P-192", "x": "AQ", "y": "AQ"}
+
+	tests := []struct {
+		name     string
+		keys     []map[string]string
+		expected string
+	}{
+		{name: "usable key remains", keys: []map[string]string{unsupported,
+			{"kty": "RSA", "kid": "rsa", "n": encode(provider.rsaKey.N), "e": encode(big.NewInt(int64(provider.rsaKey.E)))}}},
+		{name: "no usable key", keys: []map[string]string{unsupported}, expected: "failed to fetch signing keys"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetches atomic.Int32
+			server := jwksServer(t, &fetches, func(w http.ResponseWriter) {
+				writeJSON(w, http.StatusOK, map[string]interface{}{"keys": tt.keys})
+			})
+			validator := newOIDCValidator(OIDCConfig{Issuer: provider.server.URL, Audience: "agent", JWKSURL: server.URL})
+			_, err := validator.key(context.Background(), "rsa")
+			if tt.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OnCallPerson identifies the person currently responsible for incidents
+type OnCallPerson struct {
+	Name     string `yaml:"name" json:"name"`
+	Email    string `yaml:"email" json:"email,omitempty"`
+	SlackID  string `yaml:"slack_id" json:"slack_id,omitempty"`
+	Schedule string `yaml:"-" json:"schedule,omitempty"`
+}
+
+// OnCallProvider resolves who is on call at a given time
+type OnCallProvider interface {
+	CurrentOnCall(ctx context.Context, at time.Time) (*OnCallPerson, error)
+}
+
+// NewOnCallProvider creates the provider selected in configuration, or nil if none is configured
+func NewOnCallProvider(config OnCallConfig) (OnCallProvider, error) {
+	var provider OnCallProvider
+	switch config.Provider {
+	case "":
+		return nil, nil
+	case "yaml":
+		rota, err := LoadOnCallRota(config.RotaFile)
+		if err != nil {
+			return nil, WrapError(err, ErrorTypeConfiguration, "oncall", "create", "failed to load on-call rota")
+		}
+		provider = rota
+	case "pagerduty":
+		if config.PagerDutyToken == "" || config.PagerDutyScheduleID == "" {
+			return nil, NewConfigurationError("oncall", "create", "the pagerduty on-call provider needs pagerduty_token and pagerduty_schedule_id")
+		}
+		provider = NewPagerDutyScheduleProvider(config.PagerDutyToken, config.PagerDutyScheduleID)
+	default:
+		return nil, NewConfigurationError("oncall", "create", fmt.Sprintf("unknown on-call provider: %s", config.Provider))
+	}
+
+	return newCachedOnCallProvider(provider, config.CacheTTL), nil
+}
+
+// OnCallRota is a local YAML rotation with optional overrides
+type OnCallRota struct {
+	Name     string `yaml:"name"`
+	Rotation struct {
+		Start   time.Time      `yaml:"start"`
+		Shift   time.Duration  `yaml:"shift"`
+		Members []OnCallPerson `yaml:"members"`
+	} `yaml:"rotation"`
+	Overrides []OnCallOverride `yaml:"overrides"`
+}
+
+// OnCallOverride replaces the rotation for a fixed time range
+type OnCallOverride struct {
+	Start  time.Time    `yaml:"start"`
+	End    time.Time    `yaml:"end"`
+	Member OnCallPerson `yaml:"member"`
+}
+
+// LoadOnCallRota loads a rota from a YAML file
+func LoadOnCallRota(filename string) (*OnCallRota, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, NewConfigurationError("oncall", "load", fmt.Sprintf("failed to read rota file: %v", err))
+	}
+
+	var rota OnCallRota
+	if err := yaml.Unmarshal(data, &rota); err != nil {
+		return nil, NewConfigurationError("oncall", "parse", fmt.Sprintf("failed to parse rota file: %v", err))
+	}
+	if len(rota.Rotation.Members) == 0 {
+		return nil, NewConfigurationError("oncall", "parse", "rota has no members")
+	}
+	if rota.Rotation.Shift <= 0 {
+		rota.Rotation.Shift = 7 * 24 * time.Hour
+	}
+	return &rota, nil
+}
+
+// CurrentOnCall returns the override covering the given time, or the rotation member whose shift it is
+func (r *OnCallRota) CurrentOnCall(ctx context.Context, at time.Time) (*OnCallPerson, error) {
+	for _, override := range r.Overrides {
+		if !at.Before(override.Start) && at.Before(override.End) {
+			person := override.Member
+			person.Schedule = r.Name
+			return &person, nil
+		}
+	}
+
+	if at.Before(r.Rotation.Start) {
+		return nil, NewValidationError("oncall", "resolve", "time is before the rotation start")
+	}
+
+	shifts := int64(at.Sub(r.Rotation.Start) / r.Rotation.Shift)
+	person := r.Rotation.Members[shifts%int64(len(r.Rotation.Members))]
+	person.Schedule = r.Name
+	return &person, nil
+}
+
+// PagerDutyScheduleProvider resolves the on-call person from a PagerDuty schedule
+type PagerDutyScheduleProvider struct {
+	token      string
+	scheduleID string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewPagerDutyScheduleProvider creates a provider backed by the PagerDuty REST API
+func NewPagerDutyScheduleProvider(token, scheduleID string) *PagerDutyScheduleProvider {
+	return &PagerDutyScheduleProvider{
+		token:      token,
+		scheduleID: scheduleID,
+		baseURL:    "https://api.pagerduty.com",
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// CurrentOnCall queries the oncalls endpoint for the schedule at the given time
+func (p *PagerDutyScheduleProvider) CurrentOnCall(ctx context.Context, at time.Time) (*OnCallPerson, error) {
+	query := url.Values{}
+	query.Set("schedule_ids[]", p.scheduleID)
+	query.Set("since", at.UTC().Format(time.RFC3339))
+	query.Set("until", at.Add(time.Minute).UTC().Format(time.RFC3339))
+	query.Set("include[]", "users")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/oncalls?"+query.Encode(), nil)
+	if err != nil {
+		return nil, WrapError(err, ErrorTypeNetwork, "oncall", "pagerduty", "failed to create request")
+	}
+	req.Header.Set("Authorization", "Token token="+p.token)
+	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, WrapError(err, ErrorTypeNetwork, "oncall", "pagerduty", "request failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, NewNetworkError("oncall", "pagerduty", fmt.Sprintf("PagerDuty returned status %d", resp.StatusCode))
+	}
+
+	var body struct {
+		Oncalls []struct {
+			EscalationLevel int `json:"escalation_level"`
+			User            struct {
+				Name  string `json:"name"`
+				Email string `json:"email"`
+			} `json:"user"`
+			Schedule struct {
+				Summary string `json:"summary"`
+			} `json:"schedule"`
+		} `json:"oncalls"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, WrapError(err, ErrorTypeNetwork, "oncall", "pagerduty", "failed to decode response")
+	}
+
+	for _, oncall := range body.Oncalls {
+		if oncall.EscalationLevel <= 1 {
+			return &OnCallPerson{
+				Name:     oncall.User.Name,
+				Email:    oncall.User.Email,
+				Schedule: oncall.Schedule.Summary,
+			}, nil
+		}
+	}
+	return nil, NewValidationError("oncall", "pagerduty", "nobody is on call for the schedule")
+}
+
+// cachedOnCallProvider avoids resolving the on-call person for every analysis
+type cachedOnCallProvider struct {
+	provider OnCallProvider
+	ttl      time.Duration
+	person   *OnCallPerson
+	expires  time.Time
+	mu       sync.Mutex
+}
+
+// newCachedOnCallProvider wraps a provider with a short-lived cache
+func newCachedOnCallProvider(provider OnCallProvider, ttl time.Duration) *cachedOnCallProvider {
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	return &cachedOnCallProvider{provider: provider, ttl: ttl}
+}
+
+// CurrentOnCall returns the cached person while fresh, otherwise resolves again
+func (c *cachedOnCallProvider) CurrentOnCall(ctx context.Context, at time.Time) (*OnCallPerson, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.person != nil && at.Before(c.expires) {
+		return c.person, nil
+	}
+
+	person, err := c.provider.CurrentOnCall(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+	c.person = person
+	c.expires = at.Add(c.ttl)
+	return person, nil
+}
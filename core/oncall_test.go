@@ -0,0 +1,108 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRota = `
+name: platform
+rotation:
+  start: 2026-01-05T09:00:00Z
+  shift: 168h
+  members:
+    - name: alice
+      slack_id: U1
+    - name: bob
+overrides:
+  - start: 2026-01-20T00:00:00Z
+    end: 2026-01-21T00:00:00Z
+    member:
+      name: carol
+`
+
+func TestOnCallRota_CurrentOnCall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rota.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testRota), 0644))
+
+	rota, err := LoadOnCallRota(path)
+	require.NoError(t, err)
+
+	tests := []struct {
+		at       string
+		expected string
+	}{
+		{at: "2026-01-05T10:00:00Z", expected: "alice"},
+		{at: "2026-01-13T10:00:00Z", expected: "bob"},
+		{at: "2026-01-20T10:00:00Z", expected: "carol"},
+		{at: "2026-01-26T10:00:00Z", expected: "bob"},
+	}
+
+	for _, tt := range tests {
+		at, _ := time.Parse(time.RFC3339, tt.at)
+		person, err := rota.CurrentOnCall(context.Background(), at)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, person.Name, "on call at %s", tt.at)
+		assert.Equal(t, "platform", person.Schedule)
+	}
+
+	_, err = rota.CurrentOnCall(context.Background(), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err, "Expected error before rotation start")
+}
+
+func TestIncidentManager_RecordPage(t *testing.T) {
+	manager := NewIncidentManager()
+	incident, _ := manager.Track(testAnalysis("cpu"))
+
+	manager.RecordPage(incident.ID, OnCallPerson{Name: "alice"})
+	updated, err := manager.RecordPage(incident.ID, OnCallPerson{Name: "alice"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"alice"}, updated.PagedTo, "Expected a person to be paged only once")
+}
+
+func TestValidateFrameworkConfig_OnCall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rota.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testRota), 0644))
+
+	tests := []struct {
+		name    string
+		onCall  OnCallConfig
+		wantErr string
+	}{
+		{"none", OnCallConfig{}, ""},
+		{"rota", OnCallConfig{Provider: "yaml", RotaFile: path}, ""},
+		{"missing rota", OnCallConfig{Provider: "yaml", RotaFile: filepath.Join(t.TempDir(), "missing.yaml")}, "on-call rota"},
+		{"pagerduty", OnCallConfig{Provider: "pagerduty", PagerDutyToken: "token", PagerDutyScheduleID: "P1"}, ""},
+		{"pagerduty without schedule", OnCallConfig{Provider: "pagerduty", PagerDutyToken: "token"}, "pagerduty_schedule_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := reloadConfig()
+			config.OnCall = tt.onCall
+			err := ValidateFrameworkConfig(config)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestNewFrameworkWithDependencies_OnCall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rota.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testRota), 0644))
+
+	config := &FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout",
+		OnCall: OnCallConfig{Provider: "yaml", RotaFile: path}}
+	framework := NewFrameworkWithDependencies(config, NewDefaultPluginRegistry(), NewDefaultPluginFactory(),
+		nil, nil, nil, NewInProcessEventBus(0))
+	assert.NotNil(t, framework.onCall, "Expected the configured on-call provider to be wired in")
+}
@@ -0,0 +1,361 @@
+package core
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiOperation describes one management API operation for the OpenAPI document
+type apiOperation struct {
+	Method  string
+	Path    string
+	Scope   APIScope // empty when the operation is not behind API keys
+	Summary string
+	Params  []apiParam
+	// Values of the request and response body types; nil when there is no body
+	Request  interface{}
+	Response interface{}
+	// Status of a successful response
+	Status int
+}
+
+// apiParam is a path or query parameter of an operation
+type apiParam struct {
+	Name        string
+	In          string
+	Description string
+}
+
+// pathParam describes a parameter taken from the path
+func pathParam(name, description string) apiParam {
+	return apiParam{Name: name, In: "path", Description: description}
+}
+
+// queryParam describes a query string parameter
+func queryParam(name, description string) apiParam {
+	return apiParam{Name: name, In: "query", Description: description}
+}
+
+// historyParams are the filters shared by the history endpoints
+var historyParams = []apiParam{
+	queryParam("start", "Earliest time, RFC 3339 or a duration before now such as 1h"),
+	queryParam("since", "Alias of start"),
+	queryParam("end", "Latest time, RFC 3339 or a duration before now"),
+	queryParam("metric", "Glob over metric names"),
+	queryParam("source", "Glob over the collector or analyzer"),
+	queryParam("labels", `Label selector such as {service="api"}`),
+	queryParam("limit", "Most results returned, the latest when more match"),
+}
+
+// streamParams are the filters shared by the streaming endpoints
+var streamParams = []apiParam{
+	queryParam("severity", "Minimum severity"),
+	queryParam("labels", "Label selector"),
+	queryParam("since", "Send analyses from the history since this time or duration first"),
+	queryParam("rate", "Most analyses sent per second"),
+}
+
+// knowledgeAgentParam names the agent whose knowledge base an operation uses
+var knowledgeAgentParam = queryParam("agent", "Agent name; the default agent when not given")
+
+// apiOperations lists the management API. It is kept next to registerAPIRoutes by hand, and
+// a test checks that every operation listed is served.
+var apiOperations = []apiOperation{
+	{Method: http.MethodGet, Path: "/health", Summary: "Framework and plugin health", Response: HealthStatus{}},
+	{Method: http.MethodGet, Path: "/status", Summary: "Framework status", Response: FrameworkStatus{}},
+	{Method: http.MethodGet, Path: "/api/v1/openapi.json", Summary: "This OpenAPI document", Response: map[string]interface{}{}},
+	{Method: http.MethodPost, Path: "/api/v1/ingest", Scope: APIScopeIngest, Summary: "Push data points into the pipeline",
+		Request: []DataPoint{}, Status: http.StatusAccepted},
+	{Method: http.MethodPost, Path: "/api/v1/query", Scope: APIScopeQuery, Summary: "Query an agent, or the default agent",
+		Request: queryRequest{}, Response: AgentResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/query/batch", Scope: APIScopeQuery, Summary: "Send several queries to an agent at once",
+		Request: queryBatchRequest{}, Response: []AgentBatchResult{}},
+	{Method: http.MethodPost, Path: "/api/v1/query/stream", Scope: APIScopeQuery,
+		Summary: "Server-sent events streaming an agent's answer as it is generated: chunk events, then a response or error event",
+		Request: queryRequest{}, Response: QueryStreamMessage{}},
+	{Method: http.MethodGet, Path: "/api/v1/keys", Scope: APIScopeAdmin, Summary: "Per-key usage counters", Response: []APIKeyUsage{}},
+	{Method: http.MethodGet, Path: "/api/v1/incidents", Scope: APIScopeQuery, Summary: "Tracked incidents",
+		Params: []apiParam{queryParam("fingerprint", "Only incidents with this fingerprint")}, Response: []Incident{}},
+	{Method: http.MethodGet, Path: "/api/v1/analyses", Scope: APIScopeQuery, Summary: "Analyses in the history",
+		Params: append(append([]apiParam(nil), historyParams...), queryParam("severity", "Minimum severity")), Response: []Analysis{}},
+	{Method: http.MethodGet, Path: "/api/v1/analyses/stream", Scope: APIScopeQuery,
+		Summary: "WebSocket streaming analyses as they happen, one JSON message each",
+		Params:  streamParams, Response: StreamMessage{}, Status: http.StatusSwitchingProtocols},
+	{Method: http.MethodGet, Path: "/api/v1/stream", Scope: APIScopeQuery,
+		Summary: "Server-sent events (or a WebSocket on upgrade) streaming analyses and framework events as they happen",
+		Params: append(append([]apiParam(nil), streamParams...),
+			queryParam("events", "Comma-separated globs of the event types to send, all by default"),
+			queryParam("analyses", "false to send events only"),
+		), Response: StreamMessage{}},
+	{Method: http.MethodGet, Path: "/api/v1/datapoints", Scope: APIScopeQuery, Summary: "Processed data points in the history",
+		Params: historyParams, Response: []DataPoint{}},
+	{Method: http.MethodGet, Path: "/api/v1/reports/noise", Scope: APIScopeQuery, Summary: "The noisiest alerting metrics",
+		Params: []apiParam{queryParam("window", "Window of the report, the configured window by default")}, Response: NoiseReport{}},
+	{Method: http.MethodGet, Path: "/api/v1/metadata", Scope: APIScopeQuery, Summary: "Known metric metadata", Response: []MetricMetadata{}},
+	{Method: http.MethodGet, Path: "/api/v1/explain", Scope: APIScopeQuery, Summary: "How analyzers judged a metric around a time",
+		Params:   []apiParam{queryParam("metric", "Metric name (required)"), queryParam("at", "RFC 3339 time, now by default")},
+		Response: explainResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/dry-run", Scope: APIScopeQuery, Summary: "What responders would have done in dry-run mode",
+		Response: DryRunSummary{}},
+	{Method: http.MethodGet, Path: "/api/v1/deliveries", Scope: APIScopeQuery, Summary: "Queued deliveries and destination health per responder",
+		Response: []DeliveryStatus{}},
+	{Method: http.MethodGet, Path: "/api/v1/remediations", Scope: APIScopeQuery, Summary: "Remediation actions, newest first",
+		Response: []Remediation{}},
+	{Method: http.MethodGet, Path: "/api/v1/remediations/audit", Scope: APIScopeQuery,
+		Summary: "Approval audit trail of remediations, oldest first",
+		Params:  []apiParam{queryParam("remediation", "Only this remediation's entries")}, Response: []ApprovalAuditEntry{}},
+	{Method: http.MethodPost, Path: "/api/v1/remediations/{id}/approve", Scope: APIScopeOperate, Summary: "Approve a held remediation",
+		Params: []apiParam{pathParam("id", "Remediation ID")}, Request: remediationDecision{}, Response: Remediation{}},
+	{Method: http.MethodPost, Path: "/api/v1/remediations/{id}/reject", Scope: APIScopeOperate, Summary: "Reject a held remediation",
+		Params: []apiParam{pathParam("id", "Remediation ID")}, Request: remediationDecision{}, Response: Remediation{}},
+	{Method: http.MethodPost, Path: "/api/v1/workflows/{id}/start", Scope: APIScopeOperate,
+		Summary: "Run a workflow; it counts against the remediation cap and is simulated in dry-run mode",
+		Params:  []apiParam{pathParam("id", "Workflow ID")}, Request: startWorkflowRequest{}, Response: Remediation{}},
+	{Method: http.MethodPost, Path: "/api/v1/actions", Scope: APIScopeOperate,
+		Summary: "Hold an action an agent proposed as a remediation waiting for approval",
+		Request: proposeActionRequest{}, Response: Remediation{}, Status: http.StatusAccepted},
+	{Method: http.MethodGet, Path: "/api/v1/plugins", Scope: APIScopeQuery, Summary: "Loaded plugins with their status and health",
+		Response: []PluginState{}},
+	{Method: http.MethodPost, Path: "/api/v1/plugins", Scope: APIScopeAdmin, Summary: "Load a plugin, starting it on a running framework",
+		Request: PluginConfig{}, Response: PluginState{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api/v1/plugins/{name}", Scope: APIScopeQuery, Summary: "A plugin's status and health",
+		Params: []apiParam{pathParam("name", "Plugin name")}, Response: PluginState{}},
+	{Method: http.MethodDelete, Path: "/api/v1/plugins/{name}", Scope: APIScopeAdmin, Summary: "Stop and unload a plugin",
+		Params: []apiParam{pathParam("name", "Plugin name")}, Response: pluginActionResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/plugins/{name}/config", Scope: APIScopeAdmin,
+		Summary: "A plugin's settings, with credentials redacted",
+		Params:  []apiParam{pathParam("name", "Plugin name")}, Response: map[string]interface{}{}},
+	{Method: http.MethodPut, Path: "/api/v1/plugins/{name}/config", Scope: APIScopeAdmin, Summary: "Change settings of a running plugin",
+		Params: []apiParam{pathParam("name", "Plugin name")}, Request: map[string]interface{}{}, Response: pluginActionResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/maintenance-windows", Scope: APIScopeQuery, Summary: "Maintenance windows declared in config",
+		Response: []MaintenanceWindowStatus{}},
+	{Method: http.MethodGet, Path: "/api/v1/silences", Scope: APIScopeQuery, Summary: "Silences and the analyses they suppressed",
+		Response: []SilenceStatus{}},
+	{Method: http.MethodPost, Path: "/api/v1/silences", Scope: APIScopeOperate, Summary: "Create a silence",
+		Request: silenceRequest{}, Response: Silence{}, Status: http.StatusCreated},
+	{Method: http.MethodDelete, Path: "/api/v1/silences/{id}", Scope: APIScopeOperate, Summary: "Expire a silence",
+		Params: []apiParam{pathParam("id", "Silence ID")}, Response: Silence{}},
+	{Method: http.MethodGet, Path: "/api/v1/snapshots", Scope: APIScopeQuery, Summary: "Saved context snapshots",
+		Response: []ContextSnapshotSummary{}},
+	{Method: http.MethodPost, Path: "/api/v1/snapshots", Scope: APIScopeOperate, Summary: "Capture a context snapshot",
+		Request: snapshotRequest{}, Response: ContextSnapshotSummary{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api/v1/snapshots/{name}", Scope: APIScopeQuery, Summary: "A context snapshot",
+		Params: []apiParam{pathParam("name", "Snapshot name")}, Response: ContextSnapshot{}},
+	{Method: http.MethodDelete, Path: "/api/v1/snapshots/{name}", Scope: APIScopeOperate, Summary: "Delete a context snapshot",
+		Params: []apiParam{pathParam("name", "Snapshot name")}, Status: http.StatusNoContent},
+	{Method: http.MethodPost, Path: "/api/v1/snapshots/{name}/query", Scope: APIScopeQuery, Summary: "Query an agent as of a snapshot",
+		Params: []apiParam{pathParam("name", "Snapshot name")}, Request: queryRequest{}, Response: AgentResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/knowledge", Scope: APIScopeQuery, Summary: "A page of the documents in an agent's knowledge base",
+		Params: []apiParam{knowledgeAgentParam, queryParam("offset", "Return documents after this ID, the next of the previous page"),
+			queryParam("limit", "Most documents returned, 100 by default")}, Response: KnowledgePage{}},
+	{Method: http.MethodPost, Path: "/api/v1/knowledge", Scope: APIScopeOperate, Summary: "Add documents to an agent's knowledge base",
+		Request: knowledgeRequest{}, Response: []KnowledgeDocument{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api/v1/knowledge/search", Scope: APIScopeQuery, Summary: "Documents an agent would answer a query from",
+		Params:   []apiParam{knowledgeAgentParam, queryParam("q", "The query"), queryParam("limit", "Most documents returned, 5 by default")},
+		Response: []KnowledgeDocument{}},
+	{Method: http.MethodGet, Path: "/api/v1/knowledge/stats", Scope: APIScopeQuery, Summary: "Statistics of an agent's knowledge base",
+		Params: []apiParam{knowledgeAgentParam}, Response: map[string]interface{}{}},
+	{Method: http.MethodDelete, Path: "/api/v1/knowledge/{id}", Scope: APIScopeOperate, Summary: "Delete a document from an agent's knowledge base",
+		Params: []apiParam{pathParam("id", "Document ID"), knowledgeAgentParam}, Status: http.StatusNoContent},
+}
+
+// OpenAPISpec returns an OpenAPI 3 document describing the management API, from which
+// clients can be generated
+func OpenAPISpec() map[string]interface{} {
+	schemas := make(map[string]interface{})
+	paths := make(map[string]interface{})
+	for _, op := range apiOperations {
+		operation := map[string]interface{}{
+			"summary":     op.Summary,
+			"operationId": operationID(op),
+		}
+		if op.Scope != "" {
+			operation["description"] = "Requires an API key, user, or OIDC token granting the " + string(op.Scope) +
+				" scope when authentication is configured."
+			operation["security"] = []interface{}{
+				map[string]interface{}{"bearerAuth": []string{}},
+				map[string]interface{}{"apiKeyHeader": []string{}},
+				map[string]interface{}{"basicAuth": []string{}},
+			}
+		}
+
+		if len(op.Params) > 0 {
+			params := make([]interface{}, 0, len(op.Params))
+			for _, param := range op.Params {
+				params = append(params, map[string]interface{}{
+					"name":        param.Name,
+					"in":          param.In,
+					"required":    param.In == "path",
+					"description": param.Description,
+					"schema":      map[string]interface{}{"type": "string"},
+				})
+			}
+			operation["parameters"] = params
+		}
+		if op.Request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(schemaOf(reflect.TypeOf(op.Request), schemas)),
+			}
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		response := map[string]interface{}{"description": http.StatusText(status)}
+		if op.Response != nil {
+			response["content"] = jsonContent(schemaOf(reflect.TypeOf(op.Response), schemas))
+		}
+		operation["responses"] = map[string]interface{}{
+			strconv.Itoa(status): response,
+			"default":            map[string]interface{}{"description": "Error, as a plain text message"},
+		}
+
+		item, _ := paths[op.Path].(map[string]interface{})
+		if item == nil {
+			item = make(map[string]interface{})
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Agent Framework Management API",
+			"version": "v1",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth":   map[string]interface{}{"type": "http", "scheme": "bearer"},
+				"apiKeyHeader": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"basicAuth":    map[string]interface{}{"type": "http", "scheme": "basic"},
+			},
+		},
+	}
+}
+
+// handleOpenAPI serves the OpenAPI document
+func (f *Framework) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, OpenAPISpec())
+}
+
+// operationID derives an operation ID from the method and path, e.g. getPluginsNameConfig
+func operationID(op apiOperation) string {
+	var id strings.Builder
+	id.WriteString(strings.ToLower(op.Method))
+	for _, part := range strings.FieldsFunc(strings.TrimPrefix(op.Path, "/api/v1"), func(r rune) bool {
+		return r == '/' || r == '-' || r == '.' || r == '{' || r == '}'
+	}) {
+		id.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return id.String()
+}
+
+// jsonContent wraps a schema as JSON content
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf returns the JSON schema of a type as encoding/json writes it. Named structs are
+// added to schemas and referenced.
+func schemaOf(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		// Unexported request types get exported names in generated clients
+		name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		if _, ok := schemas[name]; !ok {
+			// Placeholder first so self-referencing types end
+			schemas[name] = map[string]interface{}{}
+			schemas[name] = structSchema(t, schemas)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return structSchema(t, schemas)
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	default:
+		// Interfaces can hold any JSON value
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema returns the object schema of a struct's JSON fields, with embedded structs'
+// fields promoted
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	var addFields func(t reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, options, _ := strings.Cut(tag, ",")
+			if field.Anonymous && name == "" {
+				embedded := field.Type
+				if embedded.Kind() == reflect.Pointer {
+					embedded = embedded.Elem()
+				}
+				if embedded.Kind() == reflect.Struct {
+					addFields(embedded)
+					continue
+				}
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if kind := field.Type.Kind(); kind == reflect.Chan || kind == reflect.Func {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = schemaOf(field.Type, schemas)
+			if !strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Pointer {
+				required = append(required, name)
+			}
+		}
+	}
+	addFields(t)
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
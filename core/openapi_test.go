@@ -0,0 +1,66 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPISpec(t *testing.T) {
+	encoded, err := json.Marshal(OpenAPISpec())
+	require.NoError(t, err)
+
+	var spec struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(encoded, &spec))
+	assert.Equal(t, "3.0.3", spec.OpenAPI)
+
+	analyses := spec.Paths["/api/v1/analyses"]["get"]
+	require.NotNil(t, analyses)
+	assert.Equal(t, "getAnalyses", analyses["operationId"])
+	assert.NotEmpty(t, analyses["security"])
+	assert.Contains(t, spec.Paths["/api/v1/plugins/{name}/config"], "put")
+	assert.NotContains(t, spec.Paths["/health"]["get"], "security", "Expected health not to need a key")
+
+	analysis := spec.Components.Schemas["Analysis"]
+	require.NotNil(t, analysis.Properties)
+	assert.Equal(t, "string", analysis.Properties["severity"]["type"])
+	assert.Equal(t, "#/components/schemas/DataPoint", analysis.Properties["data_points"]["items"].(map[string]interface{})["$ref"])
+	assert.Equal(t, "date-time", analysis.Properties["timestamp"]["format"])
+	assert.Contains(t, spec.Components.Schemas["SnapshotRequest"].Properties, "window", "Expected request types to be exported")
+}
+
+func TestOpenAPISpec_OperationsAreServed(t *testing.T) {
+	handler := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout"}).Handler()
+	// Streams end at once rather than waiting for analyses
+	stopped, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, op := range apiOperations {
+		path := op.Path
+		for _, param := range op.Params {
+			path = strings.ReplaceAll(path, "{"+param.Name+"}", "missing")
+		}
+
+		rec := httptest.NewRecorder()
+		request := httptest.NewRequest(op.Method, path, strings.NewReader("{}"))
+		if strings.HasSuffix(op.Path, "/stream") {
+			request = request.WithContext(stopped)
+		}
+		handler.ServeHTTP(rec, request)
+		assert.NotEqual(t, http.StatusMethodNotAllowed, rec.Code, "%s %s", op.Method, op.Path)
+		assert.NotEqual(t, "404 page not found\n", rec.Body.String(), "Expected %s %s to be routed", op.Method, op.Path)
+	}
+}
@@ -0,0 +1,161 @@
+package core
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+)
+
+// pipeline is a compiled pipeline config
+type pipeline struct {
+	name       string
+	sources    []string
+	processors []*processor
+	analyzers  map[string]bool
+	responders []string // nil sends to every responder
+}
+
+// pipelines holds the configured pipelines in order. Without any, every analyzer sees
+// every batch and every responder hears of every analysis.
+type pipelines []*pipeline
+
+// newPipelines compiles the configured pipelines
+func newPipelines(configs []PipelineConfig) (pipelines, error) {
+	compiled := make(pipelines, 0, len(configs))
+	seen := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		if seen[config.Name] {
+			return nil, NewConfigurationError("pipeline", "build", fmt.Sprintf("pipeline %q is declared more than once", config.Name))
+		}
+		seen[config.Name] = true
+
+		for _, pattern := range config.Collectors {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, NewConfigurationError("pipeline", "build", fmt.Sprintf("invalid collector pattern %q in pipeline %q", pattern, config.Name))
+			}
+		}
+		p := &pipeline{
+			name:      config.Name,
+			sources:   config.Collectors,
+			analyzers: make(map[string]bool, len(config.Analyzers)),
+		}
+		for _, analyzer := range config.Analyzers {
+			p.analyzers[analyzer] = true
+		}
+		if len(config.Responders) > 0 {
+			p.responders = config.Responders
+		}
+
+		for i, processorConfig := range config.Processors {
+			processor, err := newProcessor(processorConfig)
+			if err != nil {
+				return nil, WrapError(err, ErrorTypeConfiguration, "pipeline", "build", fmt.Sprintf("invalid processor %d in pipeline %q", i, config.Name))
+			}
+			p.processors = append(p.processors, processor)
+		}
+		compiled = append(compiled, p)
+	}
+	return compiled, nil
+}
+
+// input returns the data points of a batch the pipeline takes in, after its processors.
+// Points are selected by their source, which collectors set to their own name.
+func (p *pipeline) input(data []DataPoint) []DataPoint {
+	selected := data
+	if len(p.sources) > 0 {
+		selected = make([]DataPoint, 0, len(data))
+		for _, point := range data {
+			for _, pattern := range p.sources {
+				if ok, _ := path.Match(pattern, point.Source); ok {
+					selected = append(selected, point)
+					break
+				}
+			}
+		}
+	}
+	for _, processor := range p.processors {
+		if len(selected) == 0 {
+			break
+		}
+		selected = processor.apply(selected)
+	}
+	return selected
+}
+
+// get returns the named pipeline
+func (ps pipelines) get(name string) *pipeline {
+	for _, p := range ps {
+		if p.name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// owner returns the first pipeline running the analyzer, whose responders hear of the
+// analyses it produces outside of batches
+func (ps pipelines) owner(analyzer string) *pipeline {
+	for _, p := range ps {
+		if p.analyzers[analyzer] {
+			return p
+		}
+	}
+	return nil
+}
+
+// warnUnknownPlugins logs the analyzers and responders pipelines name that are not loaded,
+// since a misspelt name silently leaves a pipeline without them
+func (ps pipelines) warnUnknownPlugins(plugins []Plugin) {
+	loaded := make(map[string]PluginType, len(plugins))
+	for _, plugin := range plugins {
+		loaded[plugin.Name()] = plugin.Type()
+	}
+	for _, p := range ps {
+		for analyzer := range p.analyzers {
+			if loaded[analyzer] != PluginTypeAnalyzer {
+				slog.Warn("Pipeline names an analyzer that is not loaded", "pipeline", p.name, "analyzer", analyzer)
+			}
+		}
+		for _, responder := range p.responders {
+			if loaded[responder] != PluginTypeResponder {
+				slog.Warn("Pipeline names a responder that is not loaded", "pipeline", p.name, "responder", responder)
+			}
+		}
+	}
+}
+
+// analysisPipeline returns the name of the pipeline an analysis came from, if any
+func analysisPipeline(analysis *Analysis) string {
+	name, _ := analysis.Details["pipeline"].(string)
+	return name
+}
+
+// markPipeline records the pipeline an analysis came from
+func markPipeline(analysis *Analysis, p *pipeline) {
+	if analysis.Details == nil {
+		analysis.Details = make(map[string]interface{})
+	}
+	analysis.Details["pipeline"] = p.name
+}
+
+// restrictResponders narrows a route's responders to a pipeline's; nil means every
+// responder on either side, and an empty result means none
+func restrictResponders(route, pipeline []string) []string {
+	if pipeline == nil {
+		return route
+	}
+	if route == nil {
+		return pipeline
+	}
+	allowed := make(map[string]bool, len(pipeline))
+	for _, name := range pipeline {
+		allowed[name] = true
+	}
+	restricted := []string{}
+	for _, name := range route {
+		if allowed[name] {
+			restricted = append(restricted, name)
+		}
+	}
+	return restricted
+}
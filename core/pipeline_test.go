@@ -0,0 +1,154 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type capturingAnalyzer struct {
+	reportingAnalyzer
+	batches [][]DataPoint
+}
+
+func (c *capturingAnalyzer) Analyze(data []DataPoint) (*Analysis, error) {
+	c.batches = append(c.batches, data)
+	return c.reportingAnalyzer.Analyze(data)
+}
+
+func newCapturingAnalyzer(name string) *capturingAnalyzer {
+	return &capturingAnalyzer{reportingAnalyzer: reportingAnalyzer{MockAnalyzer{MockPlugin{name: name, pluginType: PluginTypeAnalyzer}}}}
+}
+
+func TestNewPipelines(t *testing.T) {
+	_, err := newPipelines([]PipelineConfig{
+		{Name: "payments", Analyzers: []string{"a"}},
+		{Name: "payments", Analyzers: []string{"b"}},
+	})
+	assert.Error(t, err, "Expected duplicate pipeline names to be rejected")
+
+	_, err = newPipelines([]PipelineConfig{{Name: "p", Collectors: []string{"api-["}, Analyzers: []string{"a"}}})
+	assert.Error(t, err, "Expected an invalid collector pattern to be rejected")
+
+	for _, processor := range []ProcessorConfig{
+		{Type: ProcessorFilter},
+		{Type: ProcessorDrop, Labels: `env=prod`},
+		{Type: ProcessorLabels},
+		{Type: "rename"},
+	} {
+		_, err = newPipelines([]PipelineConfig{{Name: "p", Analyzers: []string{"a"}, Processors: []ProcessorConfig{processor}}})
+		assert.Error(t, err, "Expected processor %+v to be rejected", processor)
+	}
+}
+
+func TestPipeline_Input(t *testing.T) {
+	pipelines, err := newPipelines([]PipelineConfig{{
+		Name:       "payments",
+		Collectors: []string{"payments-*"},
+		Processors: []ProcessorConfig{
+			{Type: ProcessorFilter, Labels: `{env="prod"}`},
+			{Type: ProcessorDrop, Metrics: []string{"debug_*"}},
+			{Type: ProcessorLabels, Set: map[string]string{"domain": "payments"}},
+		},
+		Analyzers: []string{"a"},
+	}})
+	require.NoError(t, err)
+
+	prod := map[string]string{"env": "prod"}
+	data := []DataPoint{
+		{Source: "payments-api", Metric: "latency", Labels: prod},
+		{Source: "payments-api", Metric: "debug_queue", Labels: prod},
+		{Source: "payments-api", Metric: "latency", Labels: map[string]string{"env": "staging"}},
+		{Source: "node-exporter", Metric: "cpu", Labels: prod},
+	}
+	input := pipelines[0].input(data)
+	require.Len(t, input, 1)
+	assert.Equal(t, "latency", input[0].Metric)
+	assert.Equal(t, map[string]string{"env": "prod", "domain": "payments"}, input[0].Labels)
+	assert.Equal(t, map[string]string{"env": "prod"}, data[0].Labels, "Expected processors to leave the batch unchanged")
+}
+
+func TestFramework_Pipelines(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{
+		LogLevel:  "info",
+		LogFormat: "text",
+		LogOutput: "stdout",
+		Pipelines: []PipelineConfig{
+			{
+				Name:       "payments",
+				Collectors: []string{"payments-*"},
+				Processors: []ProcessorConfig{{Type: ProcessorLabels, Set: map[string]string{"domain": "payments"}}},
+				Analyzers:  []string{"payments-anomaly"},
+				Responders: []string{"payments-pager"},
+			},
+			{
+				Name:       "infra",
+				Collectors: []string{"node-exporter"},
+				Analyzers:  []string{"infra-anomaly"},
+				Responders: []string{"infra-slack"},
+			},
+		},
+	})
+
+	paymentsAnalyzer := newCapturingAnalyzer("payments-anomaly")
+	infraAnalyzer := newCapturingAnalyzer("infra-anomaly")
+	strayAnalyzer := newCapturingAnalyzer("stray")
+	paymentsPager := &severityResponder{MockPlugin: MockPlugin{name: "payments-pager", pluginType: PluginTypeResponder}, severity: "low"}
+	infraSlack := &severityResponder{MockPlugin: MockPlugin{name: "infra-slack", pluginType: PluginTypeResponder}, severity: "low"}
+	for _, plugin := range []Plugin{paymentsAnalyzer, infraAnalyzer, strayAnalyzer, paymentsPager, infraSlack} {
+		require.NoError(t, framework.LoadPlugin(plugin))
+	}
+
+	framework.processData(context.Background(), []DataPoint{
+		{Source: "payments-api", Metric: "latency", Value: 900, Timestamp: time.Now()},
+		{Source: "node-exporter", Metric: "cpu", Value: 97, Timestamp: time.Now()},
+		{Source: "ingest", Metric: "disk", Value: 12, Timestamp: time.Now()},
+	})
+
+	require.Len(t, paymentsAnalyzer.batches, 1)
+	require.Len(t, paymentsAnalyzer.batches[0], 1)
+	assert.Equal(t, "latency", paymentsAnalyzer.batches[0][0].Metric)
+	assert.Equal(t, "payments", paymentsAnalyzer.batches[0][0].Labels["domain"])
+	require.Len(t, infraAnalyzer.batches, 1)
+	assert.Equal(t, "cpu", infraAnalyzer.batches[0][0].Metric)
+	assert.Empty(t, strayAnalyzer.batches, "Expected analyzers outside every pipeline not to see batches")
+
+	require.Len(t, paymentsPager.handled, 1, "Expected a pipeline's analyses to reach its responders")
+	assert.Equal(t, "payments-anomaly", paymentsPager.handled[0].Source)
+	assert.Equal(t, "payments", paymentsPager.handled[0].Details["pipeline"])
+	require.Len(t, infraSlack.handled, 1, "Expected a pipeline's analyses to reach only its responders")
+	assert.Equal(t, "infra-anomaly", infraSlack.handled[0].Source)
+}
+
+func TestFramework_PipelinesWithResponderRoutes(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{
+		LogLevel:  "info",
+		LogFormat: "text",
+		LogOutput: "stdout",
+		ResponderRoute: &ResponderRouteConfig{
+			Routes: []ResponderRouteConfig{{Severities: []string{"low"}, Responders: []string{"pager", "chat"}, GroupWait: time.Minute}},
+		},
+		Pipelines: []PipelineConfig{
+			{Name: "payments", Analyzers: []string{"payments-anomaly"}, Responders: []string{"pager"}},
+			{Name: "infra", Analyzers: []string{"infra-anomaly"}, Responders: []string{"chat"}},
+		},
+	})
+	pager := &groupingResponder{severityResponder: severityResponder{MockPlugin: MockPlugin{name: "pager", pluginType: PluginTypeResponder}}}
+	chat := &groupingResponder{severityResponder: severityResponder{MockPlugin: MockPlugin{name: "chat", pluginType: PluginTypeResponder}}}
+	for _, plugin := range []Plugin{newCapturingAnalyzer("payments-anomaly"), newCapturingAnalyzer("infra-anomaly"), pager, chat} {
+		require.NoError(t, framework.LoadPlugin(plugin))
+	}
+
+	framework.processData(context.Background(), []DataPoint{{Source: "api", Metric: "latency", Value: 900, Timestamp: time.Now()}})
+	framework.flushGroups(context.Background(), time.Now().Add(2*time.Minute))
+
+	require.Len(t, pager.groups, 1, "Expected each pipeline's analyses to be grouped apart")
+	require.Len(t, pager.groups[0].Analyses, 1)
+	assert.Equal(t, "payments-anomaly", pager.groups[0].Analyses[0].Source)
+	require.Len(t, chat.groups, 1)
+	require.Len(t, chat.groups[0].Analyses, 1)
+	assert.Equal(t, "infra-anomaly", chat.groups[0].Analyses[0].Source)
+}
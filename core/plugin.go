@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"io"
 	"time"
 )
 
@@ -78,6 +79,28 @@ type DataAnalyzer interface {
 	CanAnalyze(data []DataPoint) bool
 }
 
+// ChainedAnalyzer is implemented by analyzers that consume the analyses of other analyzers,
+// such as correlation or root-cause summarizers. Analyzers chained without implementing it
+// are given the data points of their inputs' analyses instead.
+type ChainedAnalyzer interface {
+	DataAnalyzer
+
+	// AnalyzeChain processes the analyses produced by the analyzer's inputs for a batch
+	AnalyzeChain(inputs []*Analysis, data []DataPoint) (*Analysis, error)
+}
+
+// ScheduledAnalyzer is implemented by analyzers that must also run on a timer, such as
+// missing-data detection where the absence of data points is itself the signal
+type ScheduledAnalyzer interface {
+	DataAnalyzer
+
+	// Evaluate checks state accumulated from earlier batches at the given time
+	Evaluate(now time.Time) (*Analysis, error)
+
+	// GetEvaluationInterval returns how often Evaluate should run
+	GetEvaluationInterval() time.Duration
+}
+
 // DataResponder defines the interface for plugins that respond to analysis results
 type DataResponder interface {
 	Plugin
@@ -89,6 +112,78 @@ type DataResponder interface {
 	CanHandle(analysis *Analysis) bool
 }
 
+// GroupResponder is implemented by responders that notify about a group of analyses in one
+// message. Grouped analyses are sent one at a time to responders that do not implement it.
+type GroupResponder interface {
+	DataResponder
+
+	// RespondGroup takes action on the analyses of a group the responder can handle
+	RespondGroup(ctx context.Context, group *AnalysisGroup) error
+}
+
+// SimulatingResponder is implemented by responders that can describe what they would do
+// with an analysis without doing it, for the would-have-fired report of dry-run mode
+type SimulatingResponder interface {
+	DataResponder
+
+	// Simulate returns the action Respond would take for the analysis
+	Simulate(analysis *Analysis) (*SimulatedAction, error)
+}
+
+// ReadOnlyResponder is implemented by responders without side effects outside the agent,
+// such as logging. They are the only responders that run in read-only mode.
+type ReadOnlyResponder interface {
+	DataResponder
+
+	// ReadOnly reports whether responding leaves everything outside the agent untouched
+	ReadOnly() bool
+}
+
+// AcknowledgingResponder is implemented by responders that open incidents in an external
+// system, so acknowledging the framework's incident acknowledges theirs too
+type AcknowledgingResponder interface {
+	DataResponder
+
+	// Acknowledge marks the external incident opened for the incident's fingerprint
+	Acknowledge(ctx context.Context, incident Incident, actor string) error
+}
+
+// ReconfigurablePlugin is implemented by plugins whose settings can change while they run
+type ReconfigurablePlugin interface {
+	Plugin
+
+	// Reconfigure applies the given settings, keeping those not given. Work already in
+	// progress finishes with the previous settings.
+	Reconfigure(ctx context.Context, config map[string]interface{}) error
+}
+
+// DestinationChecker is implemented by responders that deliver to an external destination,
+// so the framework can notice it is unreachable before a delivery fails
+type DestinationChecker interface {
+	DataResponder
+
+	// CheckDestination returns an error when the destination cannot be reached
+	CheckDestination(ctx context.Context) error
+}
+
+// MetricsExporter is implemented by plugins that publish their own series on the
+// framework's /metrics endpoint
+type MetricsExporter interface {
+	Plugin
+
+	// WriteMetrics writes the plugin's series in the Prometheus text format
+	WriteMetrics(w io.Writer)
+}
+
+// StatusReporter is implemented by plugins that report details of their state, such as
+// usage against a budget, in the framework status
+type StatusReporter interface {
+	Plugin
+
+	// StatusDetails returns the details, which must encode as JSON
+	StatusDetails() map[string]interface{}
+}
+
 // AgentPlugin defines the interface for AI agent plugins
 type AgentPlugin interface {
 	Plugin
@@ -103,6 +198,38 @@ type AgentPlugin interface {
 	GetAvailableQueries() []string
 }
 
+// SnapshotAgent is implemented by agents that can answer a query against given data
+// instead of the latest data passed to SetContext, so the queries of a batch all see the
+// same snapshot even as new data arrives
+type SnapshotAgent interface {
+	AgentPlugin
+
+	// ProcessQueryWithContext answers a query using data as the current system context
+	ProcessQueryWithContext(ctx context.Context, query string, data []DataPoint) (*AgentResponse, error)
+}
+
+// StreamingAgent is implemented by agents that can send their answer as it is generated,
+// so long answers can be shown as they arrive
+type StreamingAgent interface {
+	AgentPlugin
+
+	// ProcessQueryStream answers a query like ProcessQuery, calling onChunk with each piece
+	// of the answer as it arrives. An error from onChunk stops the query.
+	ProcessQueryStream(ctx context.Context, query string, onChunk func(chunk string) error) (*AgentResponse, error)
+}
+
+// AgentQuerier sends queries to the framework's agents by name
+type AgentQuerier interface {
+	QueryAgent(ctx context.Context, agentName, query string) (*AgentResponse, error)
+}
+
+// AgentQuerierAware is implemented by plugins that ask other agents, such as an
+// orchestrator running workflows across them. The framework provides itself when the
+// plugin is loaded.
+type AgentQuerierAware interface {
+	SetAgentQuerier(querier AgentQuerier)
+}
+
 // AgentResponse represents a response from an agent plugin
 type AgentResponse struct {
 	Query      string                 `json:"query"`
@@ -113,11 +240,16 @@ type AgentResponse struct {
 	Timestamp  time.Time              `json:"timestamp"`
 }
 
-// AgentAction represents an action the agent can take
+// AgentAction is an action an agent proposes. Validate checks it against the schema of its
+// type, and ProposeAgentAction holds it for approval as a remediation.
 type AgentAction struct {
-	Type        string                 `json:"type"`
-	Description string                 `json:"description"`
-	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	// Type is one of the AgentAction* action types
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	// Target is the service or resource the action acts on
+	Target     string                 `json:"target,omitempty"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Risk       ActionRisk             `json:"risk,omitempty"`
 }
 
 // PluginConfig represents configuration for a plugin
@@ -140,6 +272,23 @@ type AnomalyAnalyzerConfig struct {
 	Threshold  float64 `yaml:"threshold" env:"AGENT_ANOMALY_THRESHOLD" envDefault:"0.8" validate:"min=0,max=1"`
 	WindowSize int     `yaml:"window_size" env:"AGENT_ANOMALY_WINDOW_SIZE" envDefault:"100" validate:"min=1"`
 	Algorithm  string  `yaml:"algorithm" env:"AGENT_ANOMALY_ALGORITHM" envDefault:"statistical" validate:"oneof=statistical machine_learning"`
+	// MinSamples is how many values a series' window needs before its points are judged
+	MinSamples int `yaml:"min_samples" env:"AGENT_ANOMALY_MIN_SAMPLES" envDefault:"5" validate:"min=0"`
+	// Baseline selects time-of-day profiles ("hour_of_day", "hour_of_week") that points are judged against
+	Baseline           string `yaml:"baseline" env:"AGENT_ANOMALY_BASELINE" validate:"omitempty,oneof=none hour_of_day hour_of_week"`
+	BaselineMinSamples int    `yaml:"baseline_min_samples" env:"AGENT_ANOMALY_BASELINE_MIN_SAMPLES" envDefault:"10" validate:"min=0"`
+	Timezone           string `yaml:"timezone" env:"AGENT_ANOMALY_TIMEZONE" envDefault:"UTC"`
+	// Calendars of expected unusual days widen thresholds for the metric groups they name
+	MetricGroups map[string][]string `yaml:"metric_groups,omitempty"`
+	Calendars    []CalendarConfig    `yaml:"calendars,omitempty" validate:"dive"`
+}
+
+// CalendarConfig references an ICS or YAML calendar of special events
+type CalendarConfig struct {
+	Path                string   `yaml:"path" validate:"required"`
+	Groups              []string `yaml:"groups,omitempty"`
+	ThresholdMultiplier float64  `yaml:"threshold_multiplier" validate:"min=0"`
+	IgnoreBaseline      bool     `yaml:"ignore_baseline"`
 }
 
 // LoggerResponderConfig represents configuration for logger responder
@@ -167,7 +316,7 @@ type RAGAgentConfig struct {
 	APIURL              string  `yaml:"api_url" env:"AGENT_AI_API_URL" envDefault:"https://api.openai.com/v1" validate:"required,url"`
 	KnowledgeBasePath   string  `yaml:"knowledge_base_path" env:"AGENT_RAG_KNOWLEDGE_PATH" envDefault:"./knowledge" validate:"required"`
 	MaxContextLength    int     `yaml:"max_context_length" env:"AGENT_RAG_MAX_CONTEXT" envDefault:"4000" validate:"min=1,max=8000"`
-	EmbeddingModel      string  `yaml:"embedding_model" env:"AGENT_RAG_EMBEDDING_MODEL" envDefault:"text-embedding-ada-002" validate:"required"`
+	EmbeddingModel      string  `yaml:"embedding_model" env:"AGENT_RAG_EMBEDDING_MODEL" envDefault:"text-embedding-3-small" validate:"required"`
 	SimilarityThreshold float64 `yaml:"similarity_threshold" env:"AGENT_RAG_SIMILARITY_THRESHOLD" envDefault:"0.7" validate:"min=0,max=1"`
 	MaxDocuments        int     `yaml:"max_documents" env:"AGENT_RAG_MAX_DOCUMENTS" envDefault:"5" validate:"min=1,max=20"`
 }
@@ -182,6 +331,12 @@ type FrameworkConfig struct {
 	// Server configuration
 	ServerHost string `yaml:"server_host" env:"AGENT_SERVER_HOST" envDefault:"0.0.0.0" validate:"required"`
 	ServerPort int    `yaml:"server_port" env:"AGENT_SERVER_PORT" envDefault:"9090" validate:"min=1,max=65535"`
+	// Port of the gRPC control plane; 0 leaves it off
+	GRPCPort int `yaml:"grpc_port" env:"AGENT_GRPC_PORT" validate:"min=0,max=65535"`
+	// TLS for the HTTP and gRPC servers; off unless a certificate is set
+	TLS ServerTLSConfig `yaml:"tls,omitempty"`
+	// Fleet mode: a remote agent forwarding to a coordinator, or the coordinator's settings
+	Fleet FleetConfig `yaml:"fleet"`
 
 	// Agent configuration
 	DefaultAgent string `yaml:"default_agent" env:"AGENT_DEFAULT_AGENT" envDefault:""`
@@ -202,7 +357,575 @@ type FrameworkConfig struct {
 	DataChannelSize int           `yaml:"data_channel_size" env:"AGENT_DATA_CHANNEL_SIZE" envDefault:"100" validate:"min=1"`
 	WorkerPoolSize  int           `yaml:"worker_pool_size" env:"AGENT_WORKER_POOL_SIZE" envDefault:"4" validate:"min=1"`
 	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"AGENT_SHUTDOWN_TIMEOUT" envDefault:"30s" validate:"min=1s"`
+	EventBufferSize int           `yaml:"event_buffer_size" env:"AGENT_EVENT_BUFFER_SIZE" envDefault:"100" validate:"min=0"`
+
+	// What collectors do with a batch when the data channel is full
+	Backpressure BackpressureConfig `yaml:"backpressure"`
+
+	// Disk-backed log of collected batches and analyses, replayed after a restart
+	WAL WALConfig `yaml:"wal"`
+
+	// Plugins start concurrently; one taking longer than this is reported as failed. Zero
+	// waits for every plugin without a limit.
+	PluginStartTimeout time.Duration `yaml:"plugin_start_timeout" env:"AGENT_PLUGIN_START_TIMEOUT" envDefault:"30s" validate:"min=0"`
+
+	// Restarts of plugins that fail, with backoff, and quarantine of those that keep failing
+	Supervisor SupervisorConfig `yaml:"supervisor"`
+
+	// An analyzer call taking longer than this is given up on so it cannot hold up the batch
+	AnalyzerTimeout time.Duration `yaml:"analyzer_timeout" env:"AGENT_ANALYZER_TIMEOUT" envDefault:"30s"`
+
+	// How long the configuration files must go unchanged before a change to them is
+	// applied without a restart; zero turns hot reload off
+	ConfigWatchInterval time.Duration `yaml:"config_watch_interval" env:"AGENT_CONFIG_WATCH_INTERVAL" envDefault:"1s" validate:"min=0"`
+
+	// Directory external plugin binaries must be in; their paths are resolved inside it.
+	// Without it external plugins cannot be loaded.
+	ExternalPluginsDir string `yaml:"external_plugins_dir" env:"AGENT_EXTERNAL_PLUGINS_DIR"`
+
+	// Lets the API and control plane load and reconfigure plugins without API keys, users,
+	// or OIDC configured. Plugin settings can start programs and read secrets, so an open
+	// API refuses these changes unless this is set.
+	AllowRuntimePlugins bool `yaml:"allow_runtime_plugins" env:"AGENT_ALLOW_RUNTIME_PLUGINS"`
+
+	// Read-only mode keeps only side-effect-free responders and blocks runbook execution,
+	// for observing what the agent would do without letting it act
+	ReadOnly bool `yaml:"read_only" env:"AGENT_READ_ONLY"`
+
+	// Dry-run mode records what responders with side effects would have done in a
+	// would-have-fired report instead of calling them
+	DryRun bool `yaml:"dry_run" env:"AGENT_DRY_RUN"`
+
+	// Slack interactivity configuration
+	SlackSigningSecret string `yaml:"slack_signing_secret" env:"AGENT_SLACK_SIGNING_SECRET"`
+
+	// Storage for incidents, analysis history, workflows, and knowledge bases
+	Store StoreConfig `yaml:"store"`
+
+	// How long analyses are kept in the analysis history
+	AnalysisRetention time.Duration `yaml:"analysis_retention" env:"AGENT_ANALYSIS_RETENTION" envDefault:"24h"`
+
+	// How long processed data points are kept for the history API
+	DataPointRetention time.Duration `yaml:"datapoint_retention" env:"AGENT_DATAPOINT_RETENTION" envDefault:"1h"`
+
+	// Deduplication of repeat analyses, flap suppression, and resolve notifications
+	Dedup DedupConfig `yaml:"dedup"`
+
+	// Cap on automated remediation actions
+	Remediation RemediationConfig `yaml:"remediation"`
+
+	// On-call schedule configuration
+	OnCall OnCallConfig `yaml:"on_call"`
+
+	// Parallelism and rate limit of batched agent queries
+	AgentQueries AgentQueryConfig `yaml:"agent_queries"`
+
+	// Retry queue for failed responder deliveries and failover to secondary responders
+	Delivery DeliveryConfig `yaml:"delivery"`
+
+	// Periodic report of the noisiest alerting metrics, sent to responders
+	NoiseReport NoiseReportConfig `yaml:"noise_report"`
+
+	// Metric aliases, keyed by canonical name, normalized before analysis
+	MetricAliases map[string][]string `yaml:"metric_aliases,omitempty"`
+
+	// Debug event log (JSON Lines) of every pipeline step; empty disables it
+	DebugLogPath string `yaml:"debug_log_path,omitempty" env:"AGENT_DEBUG_LOG"`
+
+	// OpenTelemetry spans of collect, process, analyze, respond, and agent queries
+	Tracing TracingConfig `yaml:"tracing"`
+
+	// How long analyzer verdicts are kept for explaining past decisions
+	ExplainRetention time.Duration `yaml:"explain_retention" env:"AGENT_EXPLAIN_RETENTION" envDefault:"6h"`
+
+	// Status page: title and metrics whose latest values are shown
+	StatusPageTitle string   `yaml:"status_page_title,omitempty" env:"AGENT_STATUS_PAGE_TITLE"`
+	StatusMetrics   []string `yaml:"status_metrics,omitempty"`
+
+	// Metric metadata (units, types, expected ranges); collectors may discover more
+	MetricMetadata []MetricMetadata `yaml:"metric_metadata,omitempty" validate:"dive"`
+
+	// Metric and label routes limiting the data points individual analyzers see
+	AnalyzerRoutes []AnalyzerRouteConfig `yaml:"analyzer_routes,omitempty" validate:"dive"`
+
+	// Analyzers fed by the analyses of other analyzers; together they must form a DAG
+	AnalyzerChains []AnalyzerChainConfig `yaml:"analyzer_chains,omitempty" validate:"dive"`
+
+	// Routing tree deciding which responders get an analysis and how analyses are grouped;
+	// without it every responder gets every analysis as it happens
+	ResponderRoute *ResponderRouteConfig `yaml:"responder_route,omitempty"`
+
+	// Processors every batch goes through before analysis, after metric aliases
+	Processors []ProcessorConfig `yaml:"processors,omitempty" validate:"dive"`
+
+	// Expressions over incoming data points recorded as new metrics on an interval
+	RecordingRules []RecordingRuleConfig `yaml:"recording_rules,omitempty" validate:"dive"`
+
+	// Named pipelines wiring collectors through processors to analyzers and responders;
+	// without any, every analyzer sees every batch and every responder every analysis
+	Pipelines []PipelineConfig `yaml:"pipelines,omitempty" validate:"dive"`
+
+	// Silences declared in config: one-off time ranges or recurring maintenance windows
+	MaintenanceWindows []MaintenanceWindowConfig `yaml:"maintenance_windows,omitempty" validate:"dive"`
+
+	// Management API keys (without keys, basic auth users, or OIDC the API is unauthenticated)
+	APIKeys []APIKeyConfig `yaml:"api_keys,omitempty" validate:"dive"`
+
+	// Basic auth users, OIDC tokens, and whether the status endpoints need a credential
+	Auth AuthConfig `yaml:"auth,omitempty"`
+
+	// Backends resolving ${vault:...} and ${aws:...} references in plugin settings
+	Secrets SecretsConfig `yaml:"secrets,omitempty"`
+
+	// Consul or Kubernetes backend collectors discover their targets from
+	Discovery DiscoveryConfig `yaml:"discovery,omitempty"`
+
+	// Per-minute CPU and allocation budgets for individual plugins
+	PluginBudgets []PluginBudgetConfig `yaml:"plugin_budgets,omitempty" validate:"dive"`
+
+	// Isolated pipelines of teams sharing the process, each with its own plugins, data
+	// channel, and API under /tenants/<name>/
+	Tenants []TenantConfig `yaml:"tenants,omitempty" validate:"dive"`
 
 	// Plugin configurations (loaded from file or environment)
 	Plugins []PluginConfig `yaml:"plugins,omitempty"`
 }
+
+// PluginBudgetConfig caps the CPU time and memory a plugin's calls may use per minute. A
+// plugin over budget is logged and, with Throttle, skipped for the rest of the minute;
+// responders and agents are never skipped, so alerts and queries still go through.
+type PluginBudgetConfig struct {
+	// Plugin name, or * for every plugin without a budget of its own
+	Plugin              string        `yaml:"plugin" validate:"required"`
+	CPUPerMinute        time.Duration `yaml:"cpu_per_minute"`
+	AllocBytesPerMinute int64         `yaml:"alloc_bytes_per_minute" validate:"min=0"`
+	Throttle            bool          `yaml:"throttle"`
+}
+
+// AnalyzerRouteConfig limits an analyzer to data points of matching metrics and labels
+type AnalyzerRouteConfig struct {
+	Analyzer string `yaml:"analyzer" validate:"required"`
+	// Metric name globs such as cpu_*; empty allows every metric
+	Metrics []string `yaml:"metrics"`
+	// Label selector such as {env="prod", instance=~"web-.*"}
+	Labels string `yaml:"labels"`
+}
+
+// TenantConfig declares a tenant: a pipeline of its own plugins running beside the
+// framework's, with its own data channel, workers, and store. Other settings are the
+// framework's.
+type TenantConfig struct {
+	// Lowercase letters, digits, dashes, and underscores; the tenant's API is under
+	// /tenants/<name>/
+	Name string `yaml:"name" validate:"required"`
+	// Size of the tenant's data channel and processing workers; zero takes the framework's
+	DataChannelSize int              `yaml:"data_channel_size,omitempty" validate:"min=0"`
+	WorkerPoolSize  int              `yaml:"worker_pool_size,omitempty" validate:"min=0"`
+	Plugins         []PluginConfig   `yaml:"plugins,omitempty"`
+	Pipelines       []PipelineConfig `yaml:"pipelines,omitempty" validate:"dive"`
+	// Storage for the tenant's incidents and history; in memory when not set
+	Store *StoreConfig `yaml:"store,omitempty"`
+}
+
+// PipelineConfig declares a pipeline: the data points of its collectors go through its
+// processors to its analyzers, and their analyses only to its responders
+type PipelineConfig struct {
+	Name string `yaml:"name" validate:"required"`
+	// Collector name globs matched against data point sources; empty takes every batch
+	Collectors []string          `yaml:"collectors,omitempty"`
+	Processors []ProcessorConfig `yaml:"processors,omitempty" validate:"dive"`
+	Analyzers  []string          `yaml:"analyzers" validate:"required,min=1"`
+	// Responder names; empty means every responder
+	Responders []string `yaml:"responders,omitempty"`
+}
+
+// ProcessorConfig is a step of a processor chain, before analysis or in a pipeline: filter
+// keeps and drop removes the data points matching metrics, pattern, and labels; labels sets
+// the labels in set; aggregate combines the points that differ only in the labels it drops;
+// downsample keeps one point per series and interval; and derive adds a ratio of two
+// metrics or the per-second rate of a counter.
+type ProcessorConfig struct {
+	Type string `yaml:"type" validate:"required,oneof=filter drop labels aggregate downsample derive"`
+	// Collector name globs; points from other collectors pass through unchanged
+	Collectors []string `yaml:"collectors,omitempty"`
+	// Metric name globs, a metric name regular expression, and a label selector selecting
+	// the points the processor applies to
+	Metrics []string          `yaml:"metrics,omitempty"`
+	Pattern string            `yaml:"pattern,omitempty"`
+	Labels  string            `yaml:"labels,omitempty"`
+	Set     map[string]string `yaml:"set,omitempty"`
+	// aggregate: the labels kept (by) or dropped (without), and sum, avg, min, max, or count
+	By       []string `yaml:"by,omitempty"`
+	Without  []string `yaml:"without,omitempty"`
+	Function string   `yaml:"function,omitempty" validate:"omitempty,oneof=sum avg min max count"`
+	// downsample: the shortest time between two kept points of a series
+	Interval time.Duration `yaml:"interval,omitempty"`
+	// derive: the derived metric's name, and the metrics of a ratio or the counter of a rate
+	Metric      string `yaml:"metric,omitempty"`
+	Numerator   string `yaml:"numerator,omitempty"`
+	Denominator string `yaml:"denominator,omitempty"`
+	Rate        string `yaml:"rate,omitempty"`
+}
+
+// RecordingRuleConfig records the result of an expression over incoming data points as a
+// metric, every interval. Expressions select metrics such as errors_total{job="api"}, take
+// rate(m[5m]) or increase(m[5m]) of counters, aggregate with sum, avg, min, max, or count
+// by or without labels, and combine with + - * / on matching labels or numbers.
+type RecordingRuleConfig struct {
+	Record string `yaml:"record" validate:"required"`
+	Expr   string `yaml:"expr" validate:"required"`
+	// How often the rule is evaluated; every minute when not set
+	Interval time.Duration `yaml:"interval" validate:"min=0"`
+	// Labels set on every recorded data point
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+// AnalyzerChainConfig feeds the analyses of input analyzers into another analyzer
+type AnalyzerChainConfig struct {
+	Analyzer string   `yaml:"analyzer" validate:"required"`
+	Inputs   []string `yaml:"inputs" validate:"required,min=1"`
+	// Consume keeps the inputs' analyses from responders so only the chain's result is sent
+	Consume bool `yaml:"consume"`
+}
+
+// ResponderRouteConfig is a node of the responder routing tree. An analysis descends into
+// the first child route it matches, or every matching child up to one without continue,
+// and is handled by the deepest routes it reaches. Unset settings are inherited.
+type ResponderRouteConfig struct {
+	// Responder names to notify; empty at the root means every responder
+	Responders []string `yaml:"responders,omitempty"`
+	// Conditions, all optional: severities, analyzer name globs, and a label selector
+	Severities []string `yaml:"severities,omitempty" validate:"dive,oneof=low medium high critical"`
+	Sources    []string `yaml:"sources,omitempty"`
+	Labels     string   `yaml:"labels,omitempty"`
+	// Labels analyses are grouped by; source, severity, and type are also accepted
+	GroupBy []string `yaml:"group_by,omitempty"`
+	// How long a new group collects analyses before it is sent; zero sends immediately
+	GroupWait time.Duration `yaml:"group_wait,omitempty"`
+	// How long a group collects further analyses between sends; defaults to group_wait
+	GroupInterval time.Duration          `yaml:"group_interval,omitempty"`
+	Continue      bool                   `yaml:"continue,omitempty"`
+	Routes        []ResponderRouteConfig `yaml:"routes,omitempty" validate:"dive"`
+}
+
+// MaintenanceWindowConfig declares a silence in config. Set starts_at and ends_at for one
+// time range, or start (HH:MM) and duration, optionally limited to days, to recur.
+type MaintenanceWindowConfig struct {
+	Name string `yaml:"name" validate:"required"`
+	// Label selector such as {service="db"} and metric name regex; at least one is needed
+	Matchers string `yaml:"matchers"`
+	Metric   string `yaml:"metric"`
+	Comment  string `yaml:"comment"`
+
+	StartsAt time.Time `yaml:"starts_at"`
+	EndsAt   time.Time `yaml:"ends_at"`
+
+	// Weekday names such as mon or saturday; empty means every day
+	Days     []string      `yaml:"days"`
+	Start    string        `yaml:"start"`
+	Duration time.Duration `yaml:"duration"`
+	// IANA timezone for start, UTC by default
+	Timezone string `yaml:"timezone"`
+}
+
+// StoreConfig selects where framework state is persisted
+type StoreConfig struct {
+	Driver string `yaml:"driver" env:"AGENT_STORE_DRIVER" envDefault:"memory" validate:"omitempty,oneof=memory sqlite postgres"`
+	// File path for sqlite, connection URL for postgres
+	DSN string `yaml:"dsn" env:"AGENT_STORE_DSN"`
+}
+
+// DedupConfig controls which analyses of a firing condition reach responders. A zero
+// repeat interval disables deduplication and every analysis is sent.
+type DedupConfig struct {
+	// How long repeats of a firing condition are suppressed after a notification
+	RepeatInterval time.Duration `yaml:"repeat_interval" env:"AGENT_DEDUP_REPEAT_INTERVAL" envDefault:"1h"`
+	// How long a condition must go unseen before it resolves; zero never resolves
+	ResolveTimeout time.Duration `yaml:"resolve_timeout" env:"AGENT_DEDUP_RESOLVE_TIMEOUT" envDefault:"5m"`
+	// A condition firing again FlapThreshold times within FlapWindow is held back as flapping
+	FlapWindow    time.Duration `yaml:"flap_window" env:"AGENT_DEDUP_FLAP_WINDOW" envDefault:"30m"`
+	FlapThreshold int           `yaml:"flap_threshold" env:"AGENT_DEDUP_FLAP_THRESHOLD" envDefault:"4" validate:"min=0"`
+}
+
+// BackpressureConfig controls what happens to collected batches when the data processors
+// fall behind and the data channel is full
+type BackpressureConfig struct {
+	// block, drop-oldest, drop-newest, or spill
+	Overflow string `yaml:"overflow" env:"AGENT_BACKPRESSURE_OVERFLOW" envDefault:"block" validate:"omitempty,oneof=block drop-oldest drop-newest spill"`
+	// Directory the spill policy writes batches to until there is room for them
+	SpillDir string `yaml:"spill_dir" env:"AGENT_BACKPRESSURE_SPILL_DIR"`
+	// Bytes of spilled batches kept on disk before further batches are dropped; zero is no limit
+	SpillMaxBytes int64 `yaml:"spill_max_bytes" env:"AGENT_BACKPRESSURE_SPILL_MAX_BYTES" validate:"min=0"`
+	// How long the data channel must stay full before the framework reports itself degraded
+	DegradedAfter time.Duration `yaml:"degraded_after" env:"AGENT_BACKPRESSURE_DEGRADED_AFTER" envDefault:"30s"`
+}
+
+// WALConfig controls the write-ahead log, which keeps collected batches and produced
+// analyses on disk until they have been processed, so they survive a restart
+type WALConfig struct {
+	Enabled bool   `yaml:"enabled" env:"AGENT_WAL_ENABLED"`
+	Dir     string `yaml:"dir" env:"AGENT_WAL_DIR" envDefault:"data/wal"`
+	// Size a segment file grows to before the next one is started
+	SegmentBytes int64 `yaml:"segment_bytes" env:"AGENT_WAL_SEGMENT_BYTES" envDefault:"16777216" validate:"min=0"`
+	// Segments beyond this many bytes, or older than MaxAge, are dropped even if they hold
+	// entries not yet processed; zero is no limit
+	MaxBytes int64         `yaml:"max_bytes" env:"AGENT_WAL_MAX_BYTES" envDefault:"1073741824" validate:"min=0"`
+	MaxAge   time.Duration `yaml:"max_age" env:"AGENT_WAL_MAX_AGE" envDefault:"24h"`
+	// Sync every record to disk before going on, at some cost to throughput
+	Sync bool `yaml:"sync" env:"AGENT_WAL_SYNC"`
+}
+
+// RemediationConfig caps automated remediation so loops cannot thrash production
+type RemediationConfig struct {
+	// Actions allowed per service per hour before further ones need approval; zero allows all
+	MaxActionsPerHour int `yaml:"max_actions_per_hour" env:"AGENT_REMEDIATION_MAX_PER_HOUR" envDefault:"3" validate:"min=0"`
+	// Label naming the service an analysis is about
+	ServiceLabel string `yaml:"service_label" env:"AGENT_REMEDIATION_SERVICE_LABEL" envDefault:"service"`
+	// How long after an action the triggering metric is re-checked; zero disables verification
+	VerifyAfter time.Duration `yaml:"verify_after" env:"AGENT_REMEDIATION_VERIFY_AFTER" envDefault:"5m" validate:"min=0"`
+	// Fraction the metric must recover by for the action to count as having worked
+	MinImprovement float64 `yaml:"min_improvement" env:"AGENT_REMEDIATION_MIN_IMPROVEMENT" envDefault:"0.1" validate:"min=0,max=1"`
+	// Workflow that carries out approved agent actions, by action type
+	ActionWorkflows map[string]string `yaml:"action_workflows,omitempty"`
+	// Responder that carries out approved agent actions of types without a workflow
+	ActionResponders map[string]string `yaml:"action_responders,omitempty"`
+	// Responders told when an action waits for approval, such as Slack with approve and
+	// reject buttons
+	ApprovalResponders []string `yaml:"approval_responders,omitempty"`
+	// How long an action waits for approval before it expires; zero keeps it waiting
+	ApprovalTTL time.Duration `yaml:"approval_ttl" env:"AGENT_REMEDIATION_APPROVAL_TTL" envDefault:"4h" validate:"min=0"`
+	// How long the approval audit trail is kept; zero keeps 90 days
+	AuditRetention time.Duration `yaml:"audit_retention" env:"AGENT_REMEDIATION_AUDIT_RETENTION" validate:"min=0"`
+}
+
+// AgentQueryConfig bounds how batched agent queries call the agent's API
+type AgentQueryConfig struct {
+	// Queries of one batch run at the same time; zero uses the default of 4
+	Concurrency int `yaml:"concurrency" env:"AGENT_QUERY_CONCURRENCY" envDefault:"4" validate:"min=0"`
+	// Queries per second across all batches; zero is unlimited
+	RateLimit float64 `yaml:"rate_limit" env:"AGENT_QUERY_RATE_LIMIT" validate:"min=0"`
+	Burst     int     `yaml:"burst" env:"AGENT_QUERY_BURST" validate:"min=0"`
+}
+
+// DeliveryConfig controls how responders are called and what happens when one cannot
+// reach its destination. Each call is retried with backoff and guarded by a circuit
+// breaker. Deliveries that still fail are queued and retried, and a responder that keeps
+// failing hands its deliveries to its failover responder until it recovers.
+type DeliveryConfig struct {
+	// Time limit for one responder call; zero leaves it to the responder
+	Timeout time.Duration `yaml:"timeout" env:"AGENT_DELIVERY_TIMEOUT" envDefault:"10s" validate:"min=0"`
+	// Calls per delivery before it fails, with the backoff doubling from retry_backoff up to
+	// max_retry_backoff in between; zero or one calls once
+	MaxAttempts     int           `yaml:"max_attempts" env:"AGENT_DELIVERY_MAX_ATTEMPTS" envDefault:"3" validate:"min=0"`
+	RetryBackoff    time.Duration `yaml:"retry_backoff" env:"AGENT_DELIVERY_RETRY_BACKOFF" envDefault:"500ms" validate:"min=0"`
+	MaxRetryBackoff time.Duration `yaml:"max_retry_backoff" env:"AGENT_DELIVERY_MAX_RETRY_BACKOFF" envDefault:"5s" validate:"min=0"`
+	// Failed calls in a row that open a responder's circuit breaker; zero disables breakers
+	BreakerThreshold int `yaml:"breaker_threshold" env:"AGENT_DELIVERY_BREAKER_THRESHOLD" envDefault:"5" validate:"min=0"`
+	// How long an open breaker fails calls before letting one through to test the responder
+	BreakerResetTimeout time.Duration `yaml:"breaker_reset_timeout" env:"AGENT_DELIVERY_BREAKER_RESET_TIMEOUT" envDefault:"1m" validate:"min=0"`
+
+	// Failed deliveries kept per responder, oldest dropped first; zero drops them at once
+	QueueSize int `yaml:"queue_size" env:"AGENT_DELIVERY_QUEUE_SIZE" envDefault:"1000" validate:"min=0"`
+	// How often queued deliveries are retried and destinations checked
+	RetryInterval time.Duration `yaml:"retry_interval" env:"AGENT_DELIVERY_RETRY_INTERVAL" envDefault:"30s" validate:"min=0"`
+	// Queued deliveries older than this are dropped; zero keeps them until delivered
+	MaxAge time.Duration `yaml:"max_age" env:"AGENT_DELIVERY_MAX_AGE" envDefault:"24h" validate:"min=0"`
+	// Failed deliveries in a row after which a responder's failover takes over; zero never fails over
+	FailoverAfter int `yaml:"failover_after" env:"AGENT_DELIVERY_FAILOVER_AFTER" envDefault:"3" validate:"min=0"`
+	// Failover responder by primary responder name
+	Failover map[string]string `yaml:"failover,omitempty"`
+}
+
+// SupervisorConfig controls how failed plugins are restarted. A plugin has failed when it
+// reports the error status, its health check fails, or it did not start.
+type SupervisorConfig struct {
+	// How often plugins are checked; zero turns the supervisor off
+	CheckInterval time.Duration `yaml:"check_interval" env:"AGENT_SUPERVISOR_CHECK_INTERVAL" envDefault:"30s" validate:"min=0"`
+	// Wait before the second restart of a failed plugin, doubling for each later one up to
+	// max_backoff; the first restart is attempted right away
+	InitialBackoff time.Duration `yaml:"initial_backoff" env:"AGENT_SUPERVISOR_INITIAL_BACKOFF" envDefault:"10s" validate:"min=0"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env:"AGENT_SUPERVISOR_MAX_BACKOFF" envDefault:"5m" validate:"min=0"`
+	// Restarts without recovering after which a plugin is quarantined; zero keeps restarting
+	MaxRestarts int `yaml:"max_restarts" env:"AGENT_SUPERVISOR_MAX_RESTARTS" envDefault:"5" validate:"min=0"`
+	// How long a quarantined plugin stays stopped before it is tried again; zero keeps it
+	// stopped until the agent restarts
+	QuarantineDuration time.Duration `yaml:"quarantine_duration" env:"AGENT_SUPERVISOR_QUARANTINE_DURATION" envDefault:"1h" validate:"min=0"`
+}
+
+// FleetConfig sets up fleet mode. A remote agent, one with a coordinator, forwards the data
+// points and analyses it produces to the coordinator over gRPC, and runs the plugins the
+// coordinator pushes to it. The coordinator, any agent serving the gRPC control plane, runs
+// the heavy analyzers and AI agents on what its remote agents forward.
+type FleetConfig struct {
+	// gRPC address (host:port) of the coordinator; empty unless this is a remote agent
+	Coordinator string `yaml:"coordinator" env:"AGENT_FLEET_COORDINATOR"`
+	// ID the remote agent registers under; the hostname when empty
+	AgentID string `yaml:"agent_id" env:"AGENT_FLEET_AGENT_ID"`
+	// Labels the remote agent registers with
+	Labels map[string]string `yaml:"labels,omitempty"`
+	// API key with the ingest scope presented to the coordinator
+	Token string `yaml:"token" env:"AGENT_FLEET_TOKEN"`
+	// TLS to the coordinator (server_name, insecure_skip_verify, ca_file, cert_file,
+	// key_file); plaintext without it
+	TLS map[string]interface{} `yaml:"tls,omitempty"`
+	// Batches and analyses held while the coordinator cannot be reached; later ones are
+	// dropped
+	QueueSize int `yaml:"queue_size" env:"AGENT_FLEET_QUEUE_SIZE" envDefault:"1000" validate:"min=0"`
+
+	// How often the coordinator has remote agents send heartbeats
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval" env:"AGENT_FLEET_HEARTBEAT_INTERVAL" envDefault:"10s" validate:"min=0"`
+	// A remote agent without a heartbeat for this long is reported lost; three heartbeat
+	// intervals when zero
+	AgentTimeout time.Duration `yaml:"agent_timeout" env:"AGENT_FLEET_AGENT_TIMEOUT" envDefault:"30s" validate:"min=0"`
+	// Plugins the coordinator pushes to remote agents
+	Agents []FleetAgentConfig `yaml:"agents,omitempty" validate:"dive"`
+}
+
+// FleetAgentConfig is the plugins a coordinator pushes to a remote agent, which replace
+// the plugins of the agent's own configuration
+type FleetAgentConfig struct {
+	// A remote agent's ID, or "*" for agents without their own entry
+	Agent   string         `yaml:"agent" validate:"required"`
+	Plugins []PluginConfig `yaml:"plugins"`
+}
+
+// NoiseReportConfig schedules the report ranking metrics by how many of their alerts
+// nobody acted on, with suggested threshold changes
+type NoiseReportConfig struct {
+	// How often the report is sent; zero disables it
+	Interval time.Duration `yaml:"interval" env:"AGENT_NOISE_REPORT_INTERVAL" validate:"min=0"`
+	// How far back alerts are counted
+	Window time.Duration `yaml:"window" env:"AGENT_NOISE_REPORT_WINDOW" envDefault:"168h" validate:"min=0"`
+	// Metrics listed, noisiest first
+	Top int `yaml:"top" env:"AGENT_NOISE_REPORT_TOP" envDefault:"10" validate:"min=0"`
+	// Agent asked to draft threshold changes; empty leaves the built-in recommendations only
+	Agent string `yaml:"agent" env:"AGENT_NOISE_REPORT_AGENT"`
+	// Responders the report is sent to, required when it is scheduled so it never pages
+	Responders []string `yaml:"responders,omitempty"`
+}
+
+// OnCallConfig selects where the current on-call person is looked up
+type OnCallConfig struct {
+	Provider            string        `yaml:"provider" env:"AGENT_ONCALL_PROVIDER" validate:"omitempty,oneof=yaml pagerduty"`
+	RotaFile            string        `yaml:"rota_file" env:"AGENT_ONCALL_ROTA_FILE"`
+	PagerDutyToken      string        `yaml:"pagerduty_token" env:"AGENT_PAGERDUTY_TOKEN"`
+	PagerDutyScheduleID string        `yaml:"pagerduty_schedule_id" env:"AGENT_PAGERDUTY_SCHEDULE_ID"`
+	CacheTTL            time.Duration `yaml:"cache_ttl" env:"AGENT_ONCALL_CACHE_TTL" envDefault:"1m"`
+}
+
+// APIKeyConfig represents a management API token with its scopes and quota
+type APIKeyConfig struct {
+	Name      string   `yaml:"name" validate:"required"`
+	Token     string   `yaml:"token" validate:"required"`
+	Scopes    []string `yaml:"scopes" validate:"min=1,dive,oneof=ingest query operate admin"`
+	RateLimit float64  `yaml:"rate_limit" validate:"min=0"` // requests per second, 0 means unlimited
+	Burst     int      `yaml:"burst" validate:"min=0"`
+	// Tenants whose API the key may use; a key without tenants may use every tenant's and
+	// the framework's, one with tenants only theirs
+	Tenants []string `yaml:"tenants,omitempty"`
+}
+
+// AuthConfig configures ways to authenticate with the HTTP server besides API keys
+type AuthConfig struct {
+	// Users signing in with HTTP basic auth
+	BasicUsers []BasicUserConfig `yaml:"basic_users,omitempty" validate:"dive"`
+	// JWT bearer tokens issued by an OpenID Connect provider
+	OIDC *OIDCConfig `yaml:"oidc,omitempty"`
+	// Require the query scope for /status, /status.html, and /metrics too; /health and
+	// /ready stay open for probes
+	ProtectStatus bool `yaml:"protect_status" env:"AGENT_AUTH_PROTECT_STATUS"`
+}
+
+// BasicUserConfig represents a basic auth user with its scopes and quota
+type BasicUserConfig struct {
+	Username string `yaml:"username" validate:"required"`
+	// bcrypt hash of the password, as written by htpasswd -nB
+	PasswordHash string   `yaml:"password_hash" validate:"required"`
+	Scopes       []string `yaml:"scopes" validate:"min=1,dive,oneof=ingest query operate admin"`
+	RateLimit    float64  `yaml:"rate_limit" validate:"min=0"` // requests per second, 0 means unlimited
+	Burst        int      `yaml:"burst" validate:"min=0"`
+}
+
+// OIDCConfig selects the identity provider whose JWTs are accepted and how their roles map
+// to scopes
+type OIDCConfig struct {
+	Issuer   string `yaml:"issuer" validate:"required,url"`
+	Audience string `yaml:"audience" validate:"required"`
+	// Where the signing keys are published; found through the issuer's discovery document
+	// when empty
+	JWKSURL string `yaml:"jwks_url,omitempty" validate:"omitempty,url"`
+	// Claim listing the caller's roles, either an array or a space-separated string; roles
+	// by default
+	RolesClaim string `yaml:"roles_claim,omitempty"`
+	// Scopes granted to each role of the identity provider
+	Roles map[string][]string `yaml:"roles" validate:"min=1,dive,min=1,dive,oneof=ingest query operate admin"`
+}
+
+// SecretsConfig configures how secret references in plugin settings are resolved. The env
+// and file schemes need no configuration; vault and aws are available once configured.
+type SecretsConfig struct {
+	// How long resolved secrets are reused by later plugin loads; 0 resolves them every time
+	CacheTTL time.Duration `yaml:"cache_ttl" env:"AGENT_SECRETS_CACHE_TTL" envDefault:"5m" validate:"min=0"`
+	// Bound on one request to a secrets backend
+	Timeout time.Duration       `yaml:"timeout" env:"AGENT_SECRETS_TIMEOUT" envDefault:"10s" validate:"min=0"`
+	Vault   *VaultSecretsConfig `yaml:"vault,omitempty"`
+	AWS     *AWSSecretsConfig   `yaml:"aws,omitempty"`
+}
+
+// DiscoveryConfig selects the service discovery backend collectors resolve their targets
+// from; at most one backend may be set
+type DiscoveryConfig struct {
+	// How long discovered instances are reused before collectors look them up again
+	RefreshInterval time.Duration `yaml:"refresh_interval" env:"AGENT_DISCOVERY_REFRESH_INTERVAL" envDefault:"30s" validate:"min=0"`
+	// Bound on one request to the discovery backend
+	Timeout    time.Duration              `yaml:"timeout" env:"AGENT_DISCOVERY_TIMEOUT" envDefault:"10s" validate:"min=0"`
+	Consul     *ConsulDiscoveryConfig     `yaml:"consul,omitempty"`
+	Kubernetes *KubernetesDiscoveryConfig `yaml:"kubernetes,omitempty"`
+}
+
+// ConsulDiscoveryConfig selects the Consul agent services are discovered from
+type ConsulDiscoveryConfig struct {
+	// Agent address; CONSUL_HTTP_ADDR when empty, else http://127.0.0.1:8500
+	Address string `yaml:"address" validate:"omitempty,url"`
+	// ACL token; CONSUL_HTTP_TOKEN when empty
+	Token string `yaml:"token,omitempty"`
+	// Datacenter to look services up in; the agent's own when empty
+	Datacenter string `yaml:"datacenter,omitempty"`
+}
+
+// KubernetesDiscoveryConfig selects the Kubernetes API services are discovered from. Left
+// empty, it uses the pod's service account.
+type KubernetesDiscoveryConfig struct {
+	// API server address; the in-cluster address when empty
+	APIServer string `yaml:"api_server,omitempty" validate:"omitempty,url"`
+	// Namespace of the services; the pod's own namespace when empty
+	Namespace string `yaml:"namespace,omitempty"`
+	// Bearer token and CA bundle files; the service account's when empty
+	TokenFile string `yaml:"token_file,omitempty"`
+	CAFile    string `yaml:"ca_file,omitempty"`
+}
+
+// VaultSecretsConfig selects the HashiCorp Vault server ${vault:...} references are read from
+type VaultSecretsConfig struct {
+	// Server address; VAULT_ADDR when empty
+	Address string `yaml:"address" validate:"omitempty,url"`
+	// Token to authenticate with, or a file holding it such as a Vault agent sink;
+	// VAULT_TOKEN when both are empty
+	Token     string `yaml:"token,omitempty"`
+	TokenFile string `yaml:"token_file,omitempty"`
+	// Enterprise namespace, if any
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// AWSSecretsConfig selects the AWS Secrets Manager region ${aws:...} references are read from
+type AWSSecretsConfig struct {
+	// Region; AWS_REGION when empty
+	Region string `yaml:"region"`
+	// Static credentials; AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN
+	// when empty
+	AccessKeyID     string `yaml:"access_key_id,omitempty"`
+	SecretAccessKey string `yaml:"secret_access_key,omitempty"`
+	SessionToken    string `yaml:"session_token,omitempty"`
+	// Endpoint override for VPC endpoints or local emulators
+	Endpoint string `yaml:"endpoint,omitempty" validate:"omitempty,url"`
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// redactedSetting replaces the values of settings that look like credentials
+const redactedSetting = "********"
+
+// secretSettingWords mark setting names whose values are credentials
+var secretSettingWords = []string{"key", "token", "secret", "password", "credential", "dsn"}
+
+// recordPluginSettings keeps the settings a plugin was loaded with
+func (f *Framework) recordPluginSettings(config PluginConfig) {
+	settings := make(map[string]interface{})
+	if values, ok := config.Config.(map[string]interface{}); ok {
+		for key, value := range values {
+			settings[key] = value
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.pluginSettings == nil {
+		f.pluginSettings = make(map[string]map[string]interface{})
+	}
+	f.pluginSettings[config.Name] = settings
+}
+
+// PluginSettings returns the settings a plugin is running with, with credentials redacted.
+// Plugins loaded in code rather than from configuration have no known settings.
+func (f *Framework) PluginSettings(name string) (map[string]interface{}, error) {
+	if _, err := f.registry.GetPlugin(name); err != nil {
+		return nil, WrapError(err, ErrorTypePlugin, "framework", "settings", fmt.Sprintf("plugin %s not found", name))
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return redactSettings(f.pluginSettings[name]), nil
+}
+
+// redactSettings copies settings, replacing the values of credentials, including those in
+// nested settings
+func redactSettings(settings map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		nested, isMap := value.(map[string]interface{})
+		switch {
+		case isSecretSetting(key):
+			redacted[key] = redactedSetting
+		case isMap:
+			redacted[key] = redactSettings(nested)
+		default:
+			redacted[key] = value
+		}
+	}
+	return redacted
+}
+
+// isSecretSetting reports whether a setting name looks like a credential
+func isSecretSetting(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range secretSettingWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
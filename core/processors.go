@@ -0,0 +1,435 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"path"
+	"reflect"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Data point processor types, run before analysis or in a pipeline
+const (
+	ProcessorFilter     = "filter"     // keep matching data points
+	ProcessorDrop       = "drop"       // remove matching data points
+	ProcessorLabels     = "labels"     // set labels on every data point
+	ProcessorAggregate  = "aggregate"  // combine points that differ only in dropped labels
+	ProcessorDownsample = "downsample" // keep one point per series and interval
+	ProcessorDerive     = "derive"     // add a ratio of two metrics or a counter's rate
+)
+
+// processor is a compiled processor config. Downsample and rate processors remember the
+// series they have seen, so each keeps its state across batches.
+type processor struct {
+	kind       string
+	collectors []string
+	match      *analyzerRoute
+	pattern    *regexp.Regexp
+	set        map[string]string
+	by         map[string]bool
+	without    map[string]bool
+	function   string
+	interval   time.Duration
+	// derived metric, the metrics of its ratio, or the counter of its rate
+	metric      string
+	numerator   string
+	denominator string
+	rate        string
+
+	mu sync.Mutex
+	// kept holds when a downsample processor last kept a point of each series
+	kept map[string]time.Time
+	// previous holds the last point of each series of a rate's counter
+	previous map[string]DataPoint
+}
+
+// newProcessor compiles a processor; the points it applies to are matched the way analyzer
+// routes match them, and by the metric pattern
+func newProcessor(config ProcessorConfig) (*processor, error) {
+	p := &processor{kind: config.Type, collectors: config.Collectors}
+	for _, pattern := range config.Collectors {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, NewConfigurationError("processor", "build", fmt.Sprintf("invalid collector pattern %q", pattern))
+		}
+	}
+	if len(config.Metrics) > 0 || config.Labels != "" {
+		routes, err := newAnalyzerRoutes([]AnalyzerRouteConfig{{Analyzer: config.Type, Metrics: config.Metrics, Labels: config.Labels}})
+		if err != nil {
+			return nil, err
+		}
+		p.match = routes[config.Type]
+	}
+	if config.Pattern != "" {
+		pattern, err := regexp.Compile(config.Pattern)
+		if err != nil {
+			return nil, NewConfigurationError("processor", "build", fmt.Sprintf("invalid metric pattern %q: %v", config.Pattern, err))
+		}
+		p.pattern = pattern
+	}
+
+	switch config.Type {
+	case ProcessorFilter, ProcessorDrop:
+		if p.match == nil && p.pattern == nil {
+			return nil, NewConfigurationError("processor", "build", fmt.Sprintf("%s processor needs metrics, a pattern, or labels", config.Type))
+		}
+	case ProcessorLabels:
+		if len(config.Set) == 0 {
+			return nil, NewConfigurationError("processor", "build", "labels processor needs labels to set")
+		}
+		p.set = config.Set
+	case ProcessorAggregate:
+		if (len(config.By) == 0) == (len(config.Without) == 0) {
+			return nil, NewConfigurationError("processor", "build", "aggregate processor needs either by or without labels")
+		}
+		p.by, p.without = labelSet(config.By), labelSet(config.Without)
+		p.function = config.Function
+		if p.function == "" {
+			p.function = "sum"
+		}
+	case ProcessorDownsample:
+		if config.Interval <= 0 {
+			return nil, NewConfigurationError("processor", "build", "downsample processor needs an interval")
+		}
+		p.interval = config.Interval
+		p.kept = make(map[string]time.Time)
+	case ProcessorDerive:
+		ratio := config.Numerator != "" && config.Denominator != ""
+		if config.Metric == "" || ratio == (config.Rate != "") {
+			return nil, NewConfigurationError("processor", "build", "derive processor needs a metric and either a numerator and denominator or a rate")
+		}
+		p.metric, p.numerator, p.denominator, p.rate = config.Metric, config.Numerator, config.Denominator, config.Rate
+		p.previous = make(map[string]DataPoint)
+	default:
+		return nil, NewConfigurationError("processor", "build", fmt.Sprintf("unknown processor type %q", config.Type))
+	}
+	return p, nil
+}
+
+// labelSet indexes label names
+func labelSet(names []string) map[string]bool {
+	if len(names) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// inScope reports whether a point comes from one of the processor's collectors
+func (p *processor) inScope(point DataPoint) bool {
+	if len(p.collectors) == 0 {
+		return true
+	}
+	for _, pattern := range p.collectors {
+		if ok, _ := path.Match(pattern, point.Source); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether a point matches the processor's metrics, pattern, and labels;
+// without any, every point matches
+func (p *processor) matches(point DataPoint) bool {
+	if p.match != nil && !p.match.matches(point) {
+		return false
+	}
+	return p.pattern == nil || p.pattern.MatchString(point.Metric)
+}
+
+// apply runs the processor over a batch; the batch is not modified
+func (p *processor) apply(data []DataPoint) []DataPoint {
+	switch p.kind {
+	case ProcessorAggregate:
+		return p.aggregate(data)
+	case ProcessorDownsample:
+		return p.downsample(data)
+	case ProcessorDerive:
+		return p.derive(data)
+	}
+
+	processed := make([]DataPoint, 0, len(data))
+	for _, point := range data {
+		if !p.inScope(point) {
+			processed = append(processed, point)
+			continue
+		}
+		switch p.kind {
+		case ProcessorFilter, ProcessorDrop:
+			if p.matches(point) != (p.kind == ProcessorFilter) {
+				continue
+			}
+		case ProcessorLabels:
+			labels := make(map[string]string, len(point.Labels)+len(p.set))
+			for name, value := range point.Labels {
+				labels[name] = value
+			}
+			for name, value := range p.set {
+				labels[name] = value
+			}
+			point.Labels = labels
+		}
+		processed = append(processed, point)
+	}
+	return processed
+}
+
+// aggregate combines the matching points of each metric that have the same labels once
+// the dropped labels are removed. A combined point takes the place of the first point it
+// combines and the latest timestamp of them.
+func (p *processor) aggregate(data []DataPoint) []DataPoint {
+	processed := make([]DataPoint, 0, len(data))
+	groups := make(map[string]int)
+	counts := make(map[string]int)
+	for _, point := range data {
+		if !p.inScope(point) || !p.matches(point) {
+			processed = append(processed, point)
+			continue
+		}
+		labels := make(map[string]string, len(point.Labels))
+		for name, value := range point.Labels {
+			if (p.by != nil && p.by[name]) || (p.without != nil && !p.without[name]) {
+				labels[name] = value
+			}
+		}
+		point.Labels = labels
+		point.Metadata = nil
+		key := fingerprintSeries(point)
+		counts[key]++
+
+		i, ok := groups[key]
+		if !ok {
+			groups[key] = len(processed)
+			if p.function == "count" {
+				point.Value = 1
+			}
+			processed = append(processed, point)
+			continue
+		}
+		combined := &processed[i]
+		if point.Timestamp.After(combined.Timestamp) {
+			combined.Timestamp = point.Timestamp
+		}
+		switch p.function {
+		case "sum", "avg":
+			combined.Value += point.Value
+		case "min":
+			combined.Value = math.Min(combined.Value, point.Value)
+		case "max":
+			combined.Value = math.Max(combined.Value, point.Value)
+		case "count":
+			combined.Value++
+		}
+	}
+	if p.function == "avg" {
+		for key, i := range groups {
+			processed[i].Value /= float64(counts[key])
+		}
+	}
+	return processed
+}
+
+// downsample drops the matching points of a series that come less than the interval after
+// the last point it kept. A point older than the last kept one restarts the series, as
+// after a collector's clock was set back.
+func (p *processor) downsample(data []DataPoint) []DataPoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	processed := make([]DataPoint, 0, len(data))
+	for _, point := range data {
+		if !p.inScope(point) || !p.matches(point) {
+			processed = append(processed, point)
+			continue
+		}
+		key := point.Source + "/" + fingerprintSeries(point)
+		if last, ok := p.kept[key]; ok && !point.Timestamp.Before(last) && point.Timestamp.Sub(last) < p.interval {
+			continue
+		}
+		p.kept[key] = point.Timestamp
+		processed = append(processed, point)
+	}
+	return processed
+}
+
+// derive adds the derived points after the batch's own. A ratio is derived for each
+// numerator point with a denominator point of the same labels, skipping zero denominators;
+// a rate for each counter point following an earlier one of its series, treating a
+// decrease as a counter reset.
+func (p *processor) derive(data []DataPoint) []DataPoint {
+	processed := append(make([]DataPoint, 0, len(data)), data...)
+	if p.rate != "" {
+		return append(processed, p.deriveRates(data)...)
+	}
+
+	denominators := make(map[string]DataPoint)
+	for _, point := range data {
+		if point.Metric == p.denominator && p.inScope(point) && p.matches(point) {
+			denominators[labelsKey(point.Labels)] = point
+		}
+	}
+	for _, point := range data {
+		if point.Metric != p.numerator || !p.inScope(point) || !p.matches(point) {
+			continue
+		}
+		denominator, ok := denominators[labelsKey(point.Labels)]
+		if !ok || denominator.Value == 0 {
+			continue
+		}
+		derived := p.derived(point, point.Value/denominator.Value)
+		if denominator.Timestamp.After(derived.Timestamp) {
+			derived.Timestamp = denominator.Timestamp
+		}
+		processed = append(processed, derived)
+	}
+	return processed
+}
+
+// deriveRates returns the per-second rates of the counter's points in a batch
+func (p *processor) deriveRates(data []DataPoint) []DataPoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var rates []DataPoint
+	for _, point := range data {
+		if point.Metric != p.rate || !p.inScope(point) || !p.matches(point) {
+			continue
+		}
+		key := point.Source + "/" + fingerprintSeries(point)
+		previous, ok := p.previous[key]
+		if ok && !point.Timestamp.After(previous.Timestamp) {
+			continue
+		}
+		p.previous[key] = point
+		if !ok {
+			continue
+		}
+		increase := point.Value - previous.Value
+		if increase < 0 {
+			increase = point.Value
+		}
+		rates = append(rates, p.derived(point, increase/point.Timestamp.Sub(previous.Timestamp).Seconds()))
+	}
+	return rates
+}
+
+// derived returns a point of the derived metric with the labels and source of the point
+// it was derived from
+func (p *processor) derived(from DataPoint, value float64) DataPoint {
+	labels := make(map[string]string, len(from.Labels))
+	for name, value := range from.Labels {
+		labels[name] = value
+	}
+	return DataPoint{Timestamp: from.Timestamp, Source: from.Source, Metric: p.metric, Value: value, Labels: labels}
+}
+
+// labelsKey renders labels in sorted order, to match the points of different metrics
+func labelsKey(labels map[string]string) string {
+	return fingerprintSeries(DataPoint{Labels: labels})
+}
+
+// processingStage is the stage every batch goes through before analysis: the configured
+// processors, then the processor functions added with AddProcessor
+type processingStage struct {
+	processors []*processor
+	mu         sync.RWMutex
+	funcs      []DataProcessorFunc
+}
+
+// newProcessingStage compiles the configured processors
+func newProcessingStage(configs []ProcessorConfig) (*processingStage, error) {
+	stage := &processingStage{}
+	for i, config := range configs {
+		p, err := newProcessor(config)
+		if err != nil {
+			return nil, WrapError(err, ErrorTypeConfiguration, "processor", "build", fmt.Sprintf("invalid processor %d", i))
+		}
+		stage.processors = append(stage.processors, p)
+	}
+	return stage, nil
+}
+
+// newFrameworkProcessing builds the processing stage of a framework. Processors are
+// validated with the config, so this only fails for configs built in code, which then
+// run without them.
+func newFrameworkProcessing(config *FrameworkConfig) *processingStage {
+	stage, err := newProcessingStage(config.Processors)
+	if err != nil {
+		slog.Error("Failed to build processors", "error", err)
+		return &processingStage{}
+	}
+	return stage
+}
+
+// process runs a batch through the stage. A processor function that fails is skipped, so
+// the batch goes on as it was before it.
+func (s *processingStage) process(ctx context.Context, data []DataPoint) []DataPoint {
+	for _, p := range s.processors {
+		if len(data) == 0 {
+			return data
+		}
+		data = p.apply(data)
+	}
+
+	s.mu.RLock()
+	funcs := s.funcs
+	s.mu.RUnlock()
+	for _, fn := range funcs {
+		if len(data) == 0 {
+			return data
+		}
+		processed, err := fn(ctx, data)
+		if err != nil {
+			slog.WarnContext(ctx, "Data processor failed, passing the batch on without it", "error", err)
+			continue
+		}
+		data = processed
+	}
+	return data
+}
+
+// ProcessData hands a batch to the pipeline, as Ingest does
+func (f *Framework) ProcessData(ctx context.Context, data []DataPoint) error {
+	return f.Ingest(ctx, data)
+}
+
+// AddProcessor adds a function every batch goes through before analysis, after the
+// configured processors and the functions added before it
+func (f *Framework) AddProcessor(processor DataProcessorFunc) error {
+	if processor == nil {
+		return NewValidationError("framework", "add-processor", "processor is nil")
+	}
+	f.processing.mu.Lock()
+	defer f.processing.mu.Unlock()
+	// Batches being processed keep the functions they started with
+	funcs := make([]DataProcessorFunc, 0, len(f.processing.funcs)+1)
+	f.processing.funcs = append(append(funcs, f.processing.funcs...), processor)
+	return nil
+}
+
+// RemoveProcessor removes a function added with AddProcessor. Functions are compared by
+// their code, so closures of the same function literal are not told apart; the one added
+// last is removed.
+func (f *Framework) RemoveProcessor(processor DataProcessorFunc) error {
+	if processor == nil {
+		return NewValidationError("framework", "remove-processor", "processor is nil")
+	}
+	target := reflect.ValueOf(processor).Pointer()
+	f.processing.mu.Lock()
+	defer f.processing.mu.Unlock()
+	for i := len(f.processing.funcs) - 1; i >= 0; i-- {
+		if reflect.ValueOf(f.processing.funcs[i]).Pointer() != target {
+			continue
+		}
+		funcs := make([]DataProcessorFunc, 0, len(f.processing.funcs)-1)
+		f.processing.funcs = append(append(funcs, f.processing.funcs[:i]...), f.processing.funcs[i+1:]...)
+		return nil
+	}
+	return NewValidationError("framework", "remove-processor", "processor not found")
+}
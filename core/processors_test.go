@@ -0,0 +1,167 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProcessor(t *testing.T) {
+	for _, config := range []ProcessorConfig{
+		{Type: ProcessorDrop, Pattern: "debug_("},
+		{Type: ProcessorDrop, Metrics: []string{"x"}, Collectors: []string{"api-["}},
+		{Type: ProcessorAggregate},
+		{Type: ProcessorAggregate, By: []string{"job"}, Without: []string{"instance"}},
+		{Type: ProcessorDownsample},
+		{Type: ProcessorDerive, Numerator: "errors", Denominator: "requests"},
+		{Type: ProcessorDerive, Metric: "ratio", Numerator: "errors", Denominator: "requests", Rate: "requests"},
+	} {
+		_, err := newProcessor(config)
+		assert.Error(t, err, "Expected processor %+v to be rejected", config)
+	}
+}
+
+func TestProcessor_DropByPattern(t *testing.T) {
+	p, err := newProcessor(ProcessorConfig{Type: ProcessorDrop, Pattern: `^go_(gc|memstats)_`, Collectors: []string{"node-*"}})
+	require.NoError(t, err)
+
+	processed := p.apply([]DataPoint{
+		{Source: "node-exporter", Metric: "go_gc_duration_seconds"},
+		{Source: "node-exporter", Metric: "cpu_usage_percent"},
+		{Source: "api", Metric: "go_memstats_alloc_bytes"},
+	})
+	require.Len(t, processed, 2)
+	assert.Equal(t, "cpu_usage_percent", processed[0].Metric)
+	assert.Equal(t, "api", processed[1].Source, "Expected points from other collectors to pass through")
+}
+
+func TestProcessor_Aggregate(t *testing.T) {
+	now := time.Now()
+	data := []DataPoint{
+		{Metric: "requests", Value: 10, Timestamp: now, Labels: map[string]string{"job": "api", "instance": "a"}},
+		{Metric: "cpu", Value: 50, Timestamp: now, Labels: map[string]string{"instance": "a"}},
+		{Metric: "requests", Value: 30, Timestamp: now.Add(time.Second), Labels: map[string]string{"job": "api", "instance": "b"}},
+		{Metric: "requests", Value: 5, Timestamp: now, Labels: map[string]string{"job": "web", "instance": "c"}},
+	}
+
+	tests := []struct {
+		config   ProcessorConfig
+		expected []float64
+	}{
+		{config: ProcessorConfig{Without: []string{"instance"}, Metrics: []string{"requests"}}, expected: []float64{40, 50, 5}},
+		{config: ProcessorConfig{By: []string{"job"}, Function: "avg", Metrics: []string{"requests"}}, expected: []float64{20, 50, 5}},
+		{config: ProcessorConfig{Without: []string{"instance", "job"}, Function: "max", Metrics: []string{"requests"}}, expected: []float64{30, 50}},
+		{config: ProcessorConfig{Without: []string{"instance"}, Function: "count", Metrics: []string{"requests"}}, expected: []float64{2, 50, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v", tt.config), func(t *testing.T) {
+			tt.config.Type = ProcessorAggregate
+			p, err := newProcessor(tt.config)
+			require.NoError(t, err)
+			processed := p.apply(data)
+			values := make([]float64, len(processed))
+			for i, point := range processed {
+				values[i] = point.Value
+			}
+			assert.Equal(t, tt.expected, values)
+		})
+	}
+
+	p, err := newProcessor(ProcessorConfig{Type: ProcessorAggregate, Without: []string{"instance"}})
+	require.NoError(t, err)
+	processed := p.apply(data)
+	assert.Equal(t, map[string]string{"job": "api"}, processed[0].Labels)
+	assert.Equal(t, now.Add(time.Second), processed[0].Timestamp, "Expected the latest timestamp of the combined points")
+	assert.Equal(t, "a", data[0].Labels["instance"], "Expected the batch to be left unchanged")
+}
+
+func TestProcessor_Downsample(t *testing.T) {
+	p, err := newProcessor(ProcessorConfig{Type: ProcessorDownsample, Interval: time.Minute, Collectors: []string{"fast"}})
+	require.NoError(t, err)
+	start := time.Now()
+	batch := func(offset time.Duration) []DataPoint {
+		return []DataPoint{
+			{Source: "fast", Metric: "cpu", Timestamp: start.Add(offset)},
+			{Source: "slow", Metric: "cpu", Timestamp: start.Add(offset)},
+		}
+	}
+
+	assert.Len(t, p.apply(batch(0)), 2)
+	assert.Len(t, p.apply(batch(30*time.Second)), 1, "Expected points within the interval to be dropped")
+	assert.Len(t, p.apply(batch(time.Minute)), 2, "Expected a point once the interval has passed")
+	assert.Len(t, p.apply(batch(-time.Hour)), 2, "Expected an older point to restart the series")
+}
+
+func TestProcessor_Derive(t *testing.T) {
+	now := time.Now()
+	ratio, err := newProcessor(ProcessorConfig{Type: ProcessorDerive, Metric: "error_ratio", Numerator: "errors", Denominator: "requests"})
+	require.NoError(t, err)
+	api := map[string]string{"job": "api"}
+	processed := ratio.apply([]DataPoint{
+		{Source: "prom", Metric: "errors", Value: 5, Timestamp: now, Labels: api},
+		{Source: "prom", Metric: "requests", Value: 50, Timestamp: now, Labels: api},
+		{Source: "prom", Metric: "errors", Value: 1, Timestamp: now, Labels: map[string]string{"job": "idle"}},
+		{Source: "prom", Metric: "requests", Value: 0, Timestamp: now, Labels: map[string]string{"job": "idle"}},
+	})
+	require.Len(t, processed, 5, "Expected one ratio, skipping the zero denominator")
+	assert.Equal(t, DataPoint{Source: "prom", Metric: "error_ratio", Value: 0.1, Timestamp: now, Labels: api}, processed[4])
+
+	rate, err := newProcessor(ProcessorConfig{Type: ProcessorDerive, Metric: "requests_per_second", Rate: "requests_total"})
+	require.NoError(t, err)
+	counter := func(value float64, offset time.Duration) []DataPoint {
+		return []DataPoint{{Source: "prom", Metric: "requests_total", Value: value, Timestamp: now.Add(offset), Labels: api}}
+	}
+	assert.Len(t, rate.apply(counter(100, 0)), 1, "Expected no rate without an earlier point")
+	processed = rate.apply(counter(160, 30*time.Second))
+	require.Len(t, processed, 2)
+	assert.Equal(t, 2.0, processed[1].Value)
+	processed = rate.apply(counter(30, time.Minute))
+	require.Len(t, processed, 2)
+	assert.Equal(t, 1.0, processed[1].Value, "Expected a decrease to count as a counter reset")
+}
+
+func TestFramework_Processors(t *testing.T) {
+	config := reloadConfig()
+	config.Processors = []ProcessorConfig{
+		{Type: ProcessorDrop, Pattern: "^debug_"},
+		{Type: ProcessorDerive, Metric: "error_ratio", Numerator: "errors", Denominator: "requests"},
+	}
+	framework := NewFramework(config)
+	analyzer := newCapturingAnalyzer("threshold")
+	require.NoError(t, framework.LoadPlugin(analyzer))
+
+	double := func(ctx context.Context, data []DataPoint) ([]DataPoint, error) {
+		processed := make([]DataPoint, len(data))
+		for i, point := range data {
+			point.Value *= 2
+			processed[i] = point
+		}
+		return processed, nil
+	}
+	failing := func(ctx context.Context, data []DataPoint) ([]DataPoint, error) {
+		return nil, assert.AnError
+	}
+	require.NoError(t, framework.AddProcessor(double))
+	require.NoError(t, framework.AddProcessor(failing))
+
+	now := time.Now()
+	framework.processData(context.Background(), []DataPoint{
+		{Metric: "debug_queue", Value: 1, Timestamp: now},
+		{Metric: "errors", Value: 2, Timestamp: now},
+		{Metric: "requests", Value: 8, Timestamp: now},
+	})
+	require.Len(t, analyzer.batches, 1)
+	batch := analyzer.batches[0]
+	require.Len(t, batch, 3, "Expected the dropped metric gone and the derived one added")
+	assert.Equal(t, "error_ratio", batch[2].Metric)
+	assert.Equal(t, 0.5, batch[2].Value, "Expected added processors to run after the configured ones")
+
+	require.NoError(t, framework.RemoveProcessor(double))
+	assert.Error(t, framework.RemoveProcessor(double))
+	framework.processData(context.Background(), []DataPoint{{Metric: "debug_queue", Value: 1, Timestamp: now}})
+	assert.Len(t, analyzer.batches, 1, "Expected a batch the processors emptied not to reach analyzers")
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// QueryStreamMessage is a server-sent event of a streamed query: a chunk of the answer,
+// the whole response once it is complete, or the error that ended it
+type QueryStreamMessage struct {
+	Chunk    string         `json:"chunk,omitempty"`
+	Response *AgentResponse `json:"response,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// StreamAgentQuery processes a query through the specified agent, calling onChunk with the
+// answer as it is generated. Agents that do not implement StreamingAgent answer in one
+// chunk once they are done.
+func (f *Framework) StreamAgentQuery(ctx context.Context, agentName, query string, onChunk func(chunk string) error) (*AgentResponse, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	plugin, err := f.registry.GetPlugin(agentName)
+	if err != nil {
+		return nil, NewPluginError("framework", "query-stream", fmt.Sprintf("agent %s not found", agentName))
+	}
+
+	agentPlugin, ok := plugin.(AgentPlugin)
+	if !ok {
+		return nil, NewPluginError("framework", "query-stream", fmt.Sprintf("plugin %s is not an agent", agentName))
+	}
+
+	process := func(ctx context.Context) (*AgentResponse, error) {
+		response, err := agentPlugin.ProcessQuery(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		return response, onChunk(response.Response)
+	}
+	if streamingAgent, ok := agentPlugin.(StreamingAgent); ok {
+		process = func(ctx context.Context) (*AgentResponse, error) {
+			return streamingAgent.ProcessQueryStream(ctx, query, onChunk)
+		}
+	}
+
+	ctx, span := f.startSpan(ctx, "agent.query", attribute.String("agent", agentName), attribute.Bool("stream", true))
+	var response *AgentResponse
+	f.sandbox.run(agentName, func() error { response, err = process(ctx); return err })
+	f.recordAgentQuery(TraceIDFromContext(ctx), agentName, query, response, err)
+	endSpan(span, err)
+
+	return response, err
+}
+
+// handleQueryStream sends a query to the requested or default agent, streaming the answer
+// as server-sent events: chunk events as it is generated, then a response or error event.
+// A query that fails before any of the answer is sent gets an error status instead.
+func (f *Framework) handleQueryStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req queryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Query == "" {
+		http.Error(w, "request must include a query", http.StatusBadRequest)
+		return
+	}
+	agent := req.Agent
+	if agent == "" {
+		agent = f.DefaultAgent()
+	}
+	if agent == "" {
+		http.Error(w, "no agent given and no default agent configured", http.StatusBadRequest)
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	started := false
+	send := func(name string, message QueryStreamMessage) error {
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("X-Accel-Buffering", "no")
+			w.WriteHeader(http.StatusOK)
+		}
+		data, err := json.Marshal(message)
+		if err != nil {
+			return err
+		}
+		controller.SetWriteDeadline(time.Now().Add(streamWriteDeadline))
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+			return err
+		}
+		return controller.Flush()
+	}
+
+	response, err := f.StreamAgentQuery(r.Context(), agent, req.Query, func(chunk string) error {
+		return send("chunk", QueryStreamMessage{Chunk: chunk})
+	})
+	switch {
+	case err != nil && !started:
+		status := http.StatusBadGateway
+		if GetErrorType(err) == ErrorTypePlugin {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	case err != nil:
+		err = send("error", QueryStreamMessage{Error: err.Error()})
+	default:
+		err = send("response", QueryStreamMessage{Response: response})
+	}
+	if err != nil {
+		slog.Debug("Query stream closed", "agent", agent, "error", err)
+	}
+}
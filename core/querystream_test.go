@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamingAgent answers with the words of the query, one chunk each
+type streamingAgent struct {
+	batchAgent
+}
+
+func (a *streamingAgent) ProcessQueryStream(ctx context.Context, query string, onChunk func(chunk string) error) (*AgentResponse, error) {
+	words := strings.Fields(query)
+	for i, word := range words {
+		if word == "fail" {
+			return nil, fmt.Errorf("model stopped")
+		}
+		if i < len(words)-1 {
+			word += " "
+		}
+		if err := onChunk(word); err != nil {
+			return nil, err
+		}
+	}
+	return &AgentResponse{Query: query, Response: query}, nil
+}
+
+func TestFramework_StreamAgentQuery(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout"})
+	require.NoError(t, framework.LoadPlugin(&streamingAgent{batchAgent{MockPlugin: MockPlugin{name: "streaming", pluginType: PluginTypeAgent}}}))
+	require.NoError(t, framework.LoadPlugin(&batchAgent{MockPlugin: MockPlugin{name: "blocking", pluginType: PluginTypeAgent}}))
+
+	var chunks []string
+	collect := func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	}
+	response, err := framework.StreamAgentQuery(context.Background(), "streaming", "why is CPU high", collect)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"why ", "is ", "CPU ", "high"}, chunks)
+	assert.Equal(t, "why is CPU high", response.Response)
+
+	chunks = nil
+	response, err = framework.StreamAgentQuery(context.Background(), "blocking", "summary", collect)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"summary (0 points)"}, chunks, "Expected an agent that cannot stream to answer in one chunk")
+	assert.Equal(t, "summary (0 points)", response.Response)
+
+	_, err = framework.StreamAgentQuery(context.Background(), "streaming", "why", func(string) error { return assert.AnError })
+	assert.ErrorIs(t, err, assert.AnError, "Expected an error from onChunk to stop the query")
+
+	_, err = framework.StreamAgentQuery(context.Background(), "missing", "why", collect)
+	assert.Equal(t, ErrorTypePlugin, GetErrorType(err))
+}
+
+func TestFramework_QueryStreamAPI(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout", DefaultAgent: "ai"})
+	require.NoError(t, framework.LoadPlugin(&streamingAgent{batchAgent{MockPlugin: MockPlugin{name: "ai", pluginType: PluginTypeAgent}}}))
+
+	mux := http.NewServeMux()
+	framework.registerAPIRoutes(mux)
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/query/stream", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := post(`{"query": "top errors"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "event: chunk\ndata: {\"chunk\":\"top \"}\n\n"+
+		"event: chunk\ndata: {\"chunk\":\"errors\"}\n\n", rec.Body.String()[:strings.Index(rec.Body.String(), "event: response")])
+	assert.Contains(t, rec.Body.String(), "event: response\ndata: {\"response\":{\"query\":\"top errors\"")
+
+	rec = post(`{"query": "partial fail"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "event: error\ndata: {\"error\":\"model stopped\"}", "Expected a failure mid-answer as an event")
+
+	assert.Equal(t, http.StatusBadGateway, post(`{"query": "fail"}`).Code, "Expected a failure before any answer as a status")
+	assert.Equal(t, http.StatusNotFound, post(`{"agent": "missing", "query": "why"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{}`).Code)
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiterConfig configures the default rate limiter. A Rate of zero means unlimited.
+type RateLimiterConfig struct {
+	Rate  float64 `yaml:"rate" json:"rate" validate:"min=0"` // events per second
+	Burst int     `yaml:"burst" json:"burst" validate:"min=0"`
+}
+
+// NewRateLimiter creates the default rate limiter for config, or returns nil when the rate
+// is unlimited
+func NewRateLimiter(config RateLimiterConfig) RateLimiter {
+	if config.Rate <= 0 {
+		return nil
+	}
+	return NewTokenBucketRateLimiter(config.Rate, config.Burst)
+}
+
+// ParseRateLimiterConfig reads rate and burst from a plugin's configuration section,
+// keeping the defaults for keys not given
+func ParseRateLimiterConfig(raw interface{}, defaults RateLimiterConfig) (RateLimiterConfig, error) {
+	config := defaults
+	section, ok := raw.(map[string]interface{})
+	if !ok {
+		if raw != nil {
+			return config, NewValidationError("rate-limiter", "configure", "rate limit configuration must be a map")
+		}
+		return config, nil
+	}
+
+	switch value := section["rate"].(type) {
+	case int:
+		config.Rate = float64(value)
+	case float64:
+		config.Rate = value
+	}
+	switch value := section["burst"].(type) {
+	case int:
+		config.Burst = value
+	case float64:
+		config.Burst = int(value)
+	}
+	if config.Rate < 0 || config.Burst < 0 {
+		return config, NewValidationError("rate-limiter", "configure", "rate and burst must not be negative")
+	}
+	return config, nil
+}
+
+// TokenBucketRateLimiter is the default implementation of RateLimiter
+type TokenBucketRateLimiter struct {
+	rate     float64 // tokens added per second
+	burst    float64
+	tokens   float64
+	lastFill time.Time
+	mu       sync.Mutex
+}
+
+// NewTokenBucketRateLimiter creates a rate limiter that allows rate events per
+// second with bursts of up to burst events
+func NewTokenBucketRateLimiter(rate float64, burst int) *TokenBucketRateLimiter {
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	return &TokenBucketRateLimiter{
+		rate:     rate,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		lastFill: time.Now(),
+	}
+}
+
+// Allow reports whether a single event may happen now
+func (r *TokenBucketRateLimiter) Allow() bool {
+	return r.AllowN(1)
+}
+
+// AllowN reports whether n events may happen now
+func (r *TokenBucketRateLimiter) AllowN(n int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refill(time.Now())
+	if r.tokens < float64(n) {
+		return false
+	}
+	r.tokens -= float64(n)
+	return true
+}
+
+// Wait blocks until a single event may happen or the context is done
+func (r *TokenBucketRateLimiter) Wait(ctx context.Context) error {
+	return r.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n events may happen or the context is done
+func (r *TokenBucketRateLimiter) WaitN(ctx context.Context, n int) error {
+	if float64(n) > r.burst {
+		return NewValidationError("rate-limiter", "wait", fmt.Sprintf("requested %d tokens exceeds burst of %.0f", n, r.burst))
+	}
+
+	for {
+		r.mu.Lock()
+		r.refill(time.Now())
+		if r.tokens >= float64(n) {
+			r.tokens -= float64(n)
+			r.mu.Unlock()
+			return nil
+		}
+		var delay time.Duration
+		if r.rate > 0 {
+			delay = time.Duration((float64(n) - r.tokens) / r.rate * float64(time.Second))
+		} else {
+			delay = time.Second
+		}
+		r.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return NewTimeoutError("rate-limiter", "wait", "context done while waiting for rate limiter")
+		case <-timer.C:
+		}
+	}
+}
+
+// refill adds tokens accumulated since the last refill; callers must hold r.mu
+func (r *TokenBucketRateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(r.lastFill).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	r.tokens = math.Min(r.burst, r.tokens+elapsed*r.rate)
+	r.lastFill = now
+}
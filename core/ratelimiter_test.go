@@ -0,0 +1,40 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucketRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(RateLimiterConfig{Rate: 1000, Burst: 2})
+	require.NotNil(t, limiter)
+
+	assert.True(t, limiter.Allow())
+	assert.True(t, limiter.Allow())
+	assert.False(t, limiter.Allow(), "Expected the burst to be used up")
+	require.NoError(t, limiter.Wait(context.Background()))
+	assert.Error(t, limiter.WaitN(context.Background(), 3), "Expected waiting for more than the burst to fail")
+
+	slow := NewRateLimiter(RateLimiterConfig{Rate: 0.001, Burst: 1})
+	require.True(t, slow.Allow())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, slow.Wait(ctx), "Expected waiting to stop when the context is done")
+}
+
+func TestParseRateLimiterConfig(t *testing.T) {
+	config, err := ParseRateLimiterConfig(nil, RateLimiterConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, NewRateLimiter(config), "Expected no limiter without a rate")
+
+	config, err = ParseRateLimiterConfig(map[string]interface{}{"rate": 2, "burst": 5.0}, RateLimiterConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, RateLimiterConfig{Rate: 2, Burst: 5}, config)
+
+	_, err = ParseRateLimiterConfig(map[string]interface{}{"rate": -1.0}, RateLimiterConfig{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,203 @@
+package core
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// deliveryLatencyBuckets are the upper bounds, in seconds, of the histogram of time from
+// an analysis being created to a responder delivering it
+var deliveryLatencyBuckets = []float64{0.5, 1, 2.5, 5, 10, 30, 60, 300, 900, 3600}
+
+// deliveryStats counts the delivery attempts of one responder
+type deliveryStats struct {
+	attempts      int64
+	successes     int64
+	failures      int64
+	dropped       int64
+	lastDelivered time.Time
+	// Cumulative counts per latency bucket, plus the sum and count of all observations
+	latencyBuckets []int64
+	latencySum     float64
+	latencyCount   int64
+}
+
+// deliveryReceipts records every delivery attempt per responder, so whether and when an
+// analysis reached its destination can be verified afterwards
+type deliveryReceipts struct {
+	stats map[string]*deliveryStats
+	mu    sync.Mutex
+}
+
+// newDeliveryReceipts creates empty delivery receipts
+func newDeliveryReceipts() *deliveryReceipts {
+	return &deliveryReceipts{stats: make(map[string]*deliveryStats)}
+}
+
+// responderStats returns a responder's stats, creating them; callers hold the lock
+func (r *deliveryReceipts) responderStats(responder string) *deliveryStats {
+	stats, ok := r.stats[responder]
+	if !ok {
+		stats = &deliveryStats{latencyBuckets: make([]int64, len(deliveryLatencyBuckets))}
+		r.stats[responder] = stats
+	}
+	return stats
+}
+
+// record counts a delivery attempt and returns how long after its creation the analysis
+// was delivered, or zero if it was not or its creation time is unknown
+func (r *deliveryReceipts) record(responder string, analysis *Analysis, err error, now time.Time) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.responderStats(responder)
+	stats.attempts++
+	if err != nil {
+		stats.failures++
+		return 0
+	}
+	stats.successes++
+	stats.lastDelivered = now
+
+	if analysis.Timestamp.IsZero() {
+		return 0
+	}
+	latency := now.Sub(analysis.Timestamp)
+	seconds := latency.Seconds()
+	for i, bound := range deliveryLatencyBuckets {
+		if seconds <= bound {
+			stats.latencyBuckets[i]++
+		}
+	}
+	stats.latencySum += seconds
+	stats.latencyCount++
+	return latency
+}
+
+// recordDropped counts a delivery given up on
+func (r *deliveryReceipts) recordDropped(responder string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.responderStats(responder).dropped++
+}
+
+// fill adds a responder's counters to its delivery status
+func (r *deliveryReceipts) fill(status *DeliveryStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats, ok := r.stats[status.Responder]
+	if !ok {
+		return
+	}
+	status.Attempts = stats.attempts
+	status.Delivered = stats.successes
+	status.Failed = stats.failures
+	status.Dropped = stats.dropped
+	status.LastDelivered = stats.lastDelivered
+	if stats.latencyCount > 0 {
+		status.AverageLatencyMS = int64(stats.latencySum / float64(stats.latencyCount) * 1000)
+	}
+}
+
+// Descriptors of the per-responder delivery metrics
+var (
+	deliveryAttemptsDesc = prometheus.NewDesc("framework_delivery_attempts_total",
+		"Delivery attempts per responder", []string{"responder"}, nil)
+	deliverySuccessDesc = prometheus.NewDesc("framework_delivery_success_total",
+		"Successful deliveries per responder", []string{"responder"}, nil)
+	deliveryFailuresDesc = prometheus.NewDesc("framework_delivery_failures_total",
+		"Failed delivery attempts per responder", []string{"responder"}, nil)
+	deliveryDroppedDesc = prometheus.NewDesc("framework_delivery_dropped_total",
+		"Deliveries given up on per responder", []string{"responder"}, nil)
+	deliveryLatencyDesc = prometheus.NewDesc("framework_delivery_latency_seconds",
+		"Time from an analysis being created to its delivery", []string{"responder"}, nil)
+	deliveryQueuedDesc = prometheus.NewDesc("framework_delivery_queued",
+		"Deliveries waiting to be retried per responder", []string{"responder"}, nil)
+	deliveryCircuitOpenDesc = prometheus.NewDesc("framework_delivery_circuit_open",
+		"Whether the circuit breaker of a responder is open", []string{"responder"}, nil)
+)
+
+// collect exports the delivery counters and latency histogram
+func (r *deliveryReceipts) collect(ch chan<- prometheus.Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for responder, stats := range r.stats {
+		ch <- prometheus.MustNewConstMetric(deliveryAttemptsDesc, prometheus.CounterValue, float64(stats.attempts), responder)
+		ch <- prometheus.MustNewConstMetric(deliverySuccessDesc, prometheus.CounterValue, float64(stats.successes), responder)
+		ch <- prometheus.MustNewConstMetric(deliveryFailuresDesc, prometheus.CounterValue, float64(stats.failures), responder)
+		ch <- prometheus.MustNewConstMetric(deliveryDroppedDesc, prometheus.CounterValue, float64(stats.dropped), responder)
+
+		buckets := make(map[float64]uint64, len(deliveryLatencyBuckets))
+		for i, bound := range deliveryLatencyBuckets {
+			buckets[bound] = uint64(stats.latencyBuckets[i])
+		}
+		ch <- prometheus.MustNewConstHistogram(deliveryLatencyDesc, uint64(stats.latencyCount), stats.latencySum, buckets, responder)
+	}
+}
+
+// collectDeliveryMetrics exports delivery counters and queue lengths per responder
+func (f *Framework) collectDeliveryMetrics(ch chan<- prometheus.Metric) {
+	f.receipts.collect(ch)
+	for _, status := range f.DeliveryStatus() {
+		ch <- prometheus.MustNewConstMetric(deliveryQueuedDesc, prometheus.GaugeValue, float64(status.Queued), status.Responder)
+		if status.Breaker != "" {
+			ch <- prometheus.MustNewConstMetric(deliveryCircuitOpenDesc, prometheus.GaugeValue,
+				boolValue(status.Breaker == CircuitOpen), status.Responder)
+		}
+	}
+}
+
+// recordDelivery counts a delivery attempt and notes it on the analysis's incident. Every
+// delivery is noted, but only the first failure, so retries do not flood the timeline.
+func (f *Framework) recordDelivery(responder string, analysis *Analysis, attempt int, err error) {
+	latency := f.receipts.record(responder, analysis, err, time.Now())
+	if err != nil {
+		f.metricsCollector.IncrementCounter("framework_responder_failures_total", map[string]string{"responder": responder})
+	}
+
+	switch {
+	case err == nil:
+		message := fmt.Sprintf("Delivered to %s", responder)
+		if latency > 0 {
+			message += fmt.Sprintf(" %s after detection", latency.Round(time.Millisecond))
+		}
+		if attempt > 1 {
+			message += fmt.Sprintf(" on attempt %d", attempt)
+		}
+		f.annotateDelivery(analysis, "delivered", responder, message)
+	case attempt == 1:
+		f.annotateDelivery(analysis, "delivery_failed", responder, fmt.Sprintf("Delivery to %s failed: %v", responder, err))
+	}
+}
+
+// recordDropped counts and notes a delivery that was given up on
+func (f *Framework) recordDropped(delivery *QueuedDelivery, reason string) {
+	f.receipts.recordDropped(delivery.Responder)
+	f.annotateDelivery(delivery.Analysis, "delivery_dropped", delivery.Responder,
+		fmt.Sprintf("Gave up delivering to %s after %d attempts (%s): %s", delivery.Responder, delivery.Attempts, reason, delivery.LastError))
+}
+
+// annotateDelivery adds a delivery event to the timeline of the analysis's incident
+func (f *Framework) annotateDelivery(analysis *Analysis, eventType, responder, message string) {
+	incidentID, ok := analysis.Details["incident_id"].(string)
+	if !ok {
+		return
+	}
+	if _, err := f.incidents.Annotate(incidentID, eventType, responder, message); err != nil {
+		slog.Debug("Failed to note delivery on incident", "incident", incidentID, "error", err)
+	}
+}
+
+// dropExpiredDeliveries drops queued deliveries that could not be sent in time
+func (f *Framework) dropExpiredDeliveries(now time.Time) {
+	for _, delivery := range f.deliveries.expire(now) {
+		f.recordDropped(delivery, "too old")
+	}
+}
@@ -0,0 +1,262 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+// RecordingRuleSource is the source of the data points recording rules produce, which
+// pipelines can select like a collector's
+const RecordingRuleSource = "recording-rules"
+
+const (
+	// defaultRecordingInterval is how often a rule without an interval is evaluated
+	defaultRecordingInterval = time.Minute
+	// recordingLookback is how far back a selector without a range looks for the latest
+	// point of a series; series without a point since are stale and left out
+	recordingLookback = 5 * time.Minute
+)
+
+// recordingRule is a compiled recording rule
+type recordingRule struct {
+	config RecordingRuleConfig
+	expr   ruleExpr
+}
+
+// recordingRules evaluates the configured recording rules over the data points the
+// framework processes
+type recordingRules struct {
+	rules []*recordingRule
+	store *recordingStore
+}
+
+// newRecordingRules compiles the configured rules
+func newRecordingRules(configs []RecordingRuleConfig) (*recordingRules, error) {
+	engine := &recordingRules{store: &recordingStore{
+		window:  recordingLookback,
+		metrics: make(map[string]bool),
+		series:  make(map[string]*recordedSeries),
+	}}
+	for _, config := range configs {
+		expr, err := parseRuleExpr(config.Expr)
+		if err != nil {
+			return nil, NewConfigurationError("recording-rules", "build", fmt.Sprintf("invalid expression of recording rule %s: %v", config.Record, err))
+		}
+		if config.Interval <= 0 {
+			config.Interval = defaultRecordingInterval
+		}
+		expr.walk(func(selector *selectorExpr) {
+			engine.store.metrics[selector.metric] = true
+			if selector.rng > engine.store.window {
+				engine.store.window = selector.rng
+			}
+		})
+		engine.rules = append(engine.rules, &recordingRule{config: config, expr: expr})
+	}
+	return engine, nil
+}
+
+// newFrameworkRecordingRules builds the recording rules of a framework. Rules are
+// validated with the config, so this only fails for configs built in code, which then
+// run without them.
+func newFrameworkRecordingRules(config *FrameworkConfig) *recordingRules {
+	engine, err := newRecordingRules(config.RecordingRules)
+	if err != nil {
+		slog.Error("Failed to build recording rules", "error", err)
+		engine, _ = newRecordingRules(nil)
+	}
+	return engine
+}
+
+// observe keeps the points of the metrics rules read
+func (r *recordingRules) observe(data []DataPoint) {
+	if len(r.rules) > 0 {
+		r.store.observe(data, time.Now())
+	}
+}
+
+// evaluate evaluates a rule, returning its data points. Results that are not finite, such
+// as divisions by zero, are left out.
+func (r *recordingRules) evaluate(rule *recordingRule, now time.Time) []DataPoint {
+	value := rule.expr.eval(r.store, now)
+	if value.scalar {
+		value.samples = []ruleSample{{labels: map[string]string{}, value: value.value}}
+	}
+
+	points := make([]DataPoint, 0, len(value.samples))
+	for _, sample := range value.samples {
+		if math.IsNaN(sample.value) || math.IsInf(sample.value, 0) {
+			continue
+		}
+		labels := make(map[string]string, len(sample.labels)+len(rule.config.Labels))
+		for name, value := range sample.labels {
+			labels[name] = value
+		}
+		for name, value := range rule.config.Labels {
+			labels[name] = value
+		}
+		points = append(points, DataPoint{
+			Timestamp: now,
+			Source:    RecordingRuleSource,
+			Metric:    rule.config.Record,
+			Value:     sample.value,
+			Labels:    labels,
+		})
+	}
+	return points
+}
+
+// recordingStore holds the recent points of the metrics recording rules read
+type recordingStore struct {
+	// window is how long points are kept: the longest range of a rule, or the lookback
+	window  time.Duration
+	metrics map[string]bool
+	series  map[string]*recordedSeries
+	mu      sync.Mutex
+}
+
+// recordedSeries holds the recent points of a series in time order
+type recordedSeries struct {
+	metric  string
+	labels  map[string]string
+	samples []recordedSample
+}
+
+type recordedSample struct {
+	at    time.Time
+	value float64
+}
+
+// observe adds the points of the metrics rules read, dropping points older than the
+// window. A point no newer than the latest of its series is ignored.
+func (s *recordingStore) observe(data []DataPoint, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, point := range data {
+		if !s.metrics[point.Metric] {
+			continue
+		}
+		key := fingerprintSeries(point)
+		series, ok := s.series[key]
+		if !ok {
+			series = &recordedSeries{metric: point.Metric, labels: point.Labels}
+			s.series[key] = series
+		}
+		if n := len(series.samples); n > 0 && !point.Timestamp.After(series.samples[n-1].at) {
+			continue
+		}
+		series.samples = append(series.samples, recordedSample{at: point.Timestamp, value: point.Value})
+	}
+	s.prune(now)
+}
+
+// prune drops points older than the window, and series left without points
+func (s *recordingStore) prune(now time.Time) {
+	cutoff := now.Add(-s.window)
+	for key, series := range s.series {
+		i := 0
+		for i < len(series.samples) && series.samples[i].at.Before(cutoff) {
+			i++
+		}
+		if i == len(series.samples) {
+			delete(s.series, key)
+			continue
+		}
+		series.samples = series.samples[i:]
+	}
+}
+
+// selectSamples returns a sample for each series a selector matches: its latest value
+// within the lookback, or its rate or increase over the selector's range. A rate or
+// increase needs two points in the range; a decrease between points counts as a reset.
+func (s *recordingStore) selectSamples(selector *selectorExpr, now time.Time) []ruleSample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var samples []ruleSample
+	for _, series := range s.series {
+		if series.metric != selector.metric || !matchesAllLabels(selector.matchers, series.labels) {
+			continue
+		}
+		if selector.function == "" {
+			latest := series.samples[len(series.samples)-1]
+			if now.Sub(latest.at) <= recordingLookback {
+				samples = append(samples, ruleSample{labels: series.labels, value: latest.value})
+			}
+			continue
+		}
+
+		var inRange []recordedSample
+		for _, sample := range series.samples {
+			if now.Sub(sample.at) <= selector.rng && !sample.at.After(now) {
+				inRange = append(inRange, sample)
+			}
+		}
+		if len(inRange) < 2 {
+			continue
+		}
+		increase := 0.0
+		for i := 1; i < len(inRange); i++ {
+			if delta := inRange[i].value - inRange[i-1].value; delta >= 0 {
+				increase += delta
+			} else {
+				increase += inRange[i].value
+			}
+		}
+		value := increase
+		if selector.function == "rate" {
+			value = increase / inRange[len(inRange)-1].at.Sub(inRange[0].at).Seconds()
+		}
+		samples = append(samples, ruleSample{labels: series.labels, value: value})
+	}
+	return samples
+}
+
+// matchesAllLabels reports whether labels satisfy every matcher
+func matchesAllLabels(matchers []LabelMatcher, labels map[string]string) bool {
+	for _, matcher := range matchers {
+		if !matcher.Matches(labels[matcher.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// recordingRuleWorker evaluates a recording rule every interval, handing the data points
+// it produces to the pipeline like a collector's
+func (f *Framework) recordingRuleWorker(ctx context.Context, rule *recordingRule) {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(rule.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			f.mu.RLock()
+			shutdown := f.shutdown
+			f.mu.RUnlock()
+			if shutdown {
+				return
+			}
+
+			points := f.recordingRules.evaluate(rule, time.Now())
+			labels := map[string]string{"rule": rule.config.Record}
+			f.metricsCollector.IncrementCounter("framework_recording_rule_evaluations_total", labels)
+			f.metricsCollector.SetGauge("framework_recording_rule_samples", float64(len(points)), labels)
+			if len(points) == 0 {
+				continue
+			}
+			if err := f.enqueue(ctx, points); err != nil {
+				slog.Warn("Failed to queue recording rule results", "rule", rule.config.Record, "error", err)
+			}
+		}
+	}
+}
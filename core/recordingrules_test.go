@@ -0,0 +1,148 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRuleExpr(t *testing.T) {
+	for _, expr := range []string{
+		`up`,
+		`sum(up)`,
+		`sum by (job) (rate(http_requests_total{code=~"5.."}[5m]))`,
+		`sum(increase(errors[1m])) without (instance)`,
+		`-up * 100 / (2 + count(up))`,
+	} {
+		_, err := parseRuleExpr(expr)
+		assert.NoError(t, err, "Expected %q to parse", expr)
+	}
+
+	for _, expr := range []string{
+		``,
+		`rate(http_requests_total)`,
+		`up[5m]`,
+		`sum by job (up)`,
+		`up{job=api}`,
+		`(up`,
+		`up +`,
+		`up 2`,
+	} {
+		_, err := parseRuleExpr(expr)
+		assert.Error(t, err, "Expected %q to be rejected", expr)
+	}
+}
+
+func TestRecordingRules_Evaluate(t *testing.T) {
+	rules, err := newRecordingRules([]RecordingRuleConfig{
+		{Record: "up:latest", Expr: `up{job="api"}`},
+		{Record: "job:requests:rate", Expr: `sum by (job) (rate(requests_total[2m]))`},
+		{Record: "requests:increase", Expr: `increase(requests_total{instance="a"}[2m])`},
+		{Record: "job:error_ratio", Expr: `sum by (job) (errors) / sum by (job) (requests)`, Labels: map[string]string{"team": "platform"}},
+		{Record: "constant", Expr: `2 * (3 + 1)`},
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	api := func(instance string) map[string]string {
+		return map[string]string{"job": "api", "instance": instance}
+	}
+	rules.store.observe([]DataPoint{
+		{Metric: "up", Value: 1, Timestamp: now.Add(-10 * time.Minute), Labels: api("stale")},
+		{Metric: "up", Value: 1, Timestamp: now, Labels: api("a")},
+		{Metric: "up", Value: 0, Timestamp: now, Labels: map[string]string{"job": "web"}},
+		{Metric: "requests_total", Value: 100, Timestamp: now.Add(-time.Minute), Labels: api("a")},
+		{Metric: "requests_total", Value: 160, Timestamp: now, Labels: api("a")},
+		{Metric: "requests_total", Value: 500, Timestamp: now.Add(-time.Minute), Labels: api("b")},
+		{Metric: "requests_total", Value: 30, Timestamp: now, Labels: api("b")},
+		{Metric: "errors", Value: 2, Timestamp: now, Labels: api("a")},
+		{Metric: "errors", Value: 3, Timestamp: now, Labels: api("b")},
+		{Metric: "requests", Value: 20, Timestamp: now, Labels: api("a")},
+		{Metric: "requests", Value: 30, Timestamp: now, Labels: api("b")},
+		{Metric: "errors", Value: 1, Timestamp: now, Labels: map[string]string{"job": "idle"}},
+		{Metric: "requests", Value: 0, Timestamp: now, Labels: map[string]string{"job": "idle"}},
+	}, now)
+
+	evaluate := func(i int) []DataPoint {
+		points := rules.evaluate(rules.rules[i], now)
+		sort.Slice(points, func(a, b int) bool { return labelsKey(points[a].Labels) < labelsKey(points[b].Labels) })
+		return points
+	}
+
+	points := evaluate(0)
+	require.Len(t, points, 1, "Expected the stale series and other jobs left out")
+	assert.Equal(t, DataPoint{Timestamp: now, Source: RecordingRuleSource, Metric: "up:latest", Value: 1, Labels: api("a")}, points[0])
+
+	points = evaluate(1)
+	require.Len(t, points, 1)
+	assert.Equal(t, map[string]string{"job": "api"}, points[0].Labels)
+	assert.Equal(t, 1.5, points[0].Value, "Expected a decrease to count as a counter reset")
+
+	points = evaluate(2)
+	require.Len(t, points, 1)
+	assert.Equal(t, 60.0, points[0].Value)
+
+	points = evaluate(3)
+	require.Len(t, points, 1, "Expected the division by zero left out")
+	assert.Equal(t, map[string]string{"job": "api", "team": "platform"}, points[0].Labels)
+	assert.Equal(t, 0.1, points[0].Value)
+
+	points = evaluate(4)
+	require.Len(t, points, 1)
+	assert.Equal(t, 8.0, points[0].Value)
+	assert.Empty(t, points[0].Labels)
+}
+
+func TestRecordingStore_KeepsReferencedMetrics(t *testing.T) {
+	rules, err := newRecordingRules([]RecordingRuleConfig{{Record: "r", Expr: `rate(requests_total[10m])`}})
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, rules.store.window, "Expected points kept for the longest range")
+
+	now := time.Now()
+	rules.store.observe([]DataPoint{
+		{Metric: "cpu", Value: 1, Timestamp: now},
+		{Metric: "requests_total", Value: 1, Timestamp: now.Add(-time.Hour)},
+		{Metric: "requests_total", Value: 2, Timestamp: now, Labels: map[string]string{"job": "api"}},
+	}, now)
+	assert.Len(t, rules.store.series, 1, "Expected unreferenced metrics and old points dropped")
+}
+
+func TestFramework_RecordingRules(t *testing.T) {
+	config := reloadConfig()
+	config.RecordingRules = []RecordingRuleConfig{{Record: "up:sum", Expr: `sum(up)`, Interval: 20 * time.Millisecond}}
+	framework := NewFramework(config)
+
+	recorded := make(chan DataPoint, 10)
+	require.NoError(t, framework.AddProcessor(func(ctx context.Context, data []DataPoint) ([]DataPoint, error) {
+		for _, point := range data {
+			if point.Source == RecordingRuleSource {
+				select {
+				case recorded <- point:
+				default:
+				}
+			}
+		}
+		return data, nil
+	}))
+
+	now := time.Now()
+	framework.processData(context.Background(), []DataPoint{
+		{Source: "prom", Metric: "up", Value: 1, Timestamp: now, Labels: map[string]string{"instance": "a"}},
+		{Source: "prom", Metric: "up", Value: 1, Timestamp: now, Labels: map[string]string{"instance": "b"}},
+	})
+
+	require.NoError(t, framework.Start(context.Background()))
+	defer framework.Stop()
+
+	select {
+	case point := <-recorded:
+		assert.Equal(t, "up:sum", point.Metric)
+		assert.Equal(t, 2.0, point.Value)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the recorded metric to be processed")
+	}
+}
@@ -0,0 +1,296 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ConfigReload reports what applying a new configuration changed. Settings other than the
+// log level and plugins are only read at startup; they are listed in RestartRequired.
+type ConfigReload struct {
+	LogLevel     string   `json:"log_level,omitempty"`
+	Added        []string `json:"added,omitempty"`
+	Removed      []string `json:"removed,omitempty"`
+	Reconfigured []string `json:"reconfigured,omitempty"`
+	// Replaced plugins could not be reconfigured in place and were recreated
+	Replaced []string `json:"replaced,omitempty"`
+	// Failed maps plugins whose change could not be applied to the reason
+	Failed          map[string]string `json:"failed,omitempty"`
+	RestartRequired []string          `json:"restart_required,omitempty"`
+}
+
+// Changed reports whether the reload applied or attempted anything
+func (r *ConfigReload) Changed() bool {
+	return r.LogLevel != "" || len(r.Added) > 0 || len(r.Removed) > 0 || len(r.Reconfigured) > 0 ||
+		len(r.Replaced) > 0 || len(r.Failed) > 0 || len(r.RestartRequired) > 0
+}
+
+// SetConfigurationManager sets the manager used to watch the configuration for changes
+func (f *Framework) SetConfigurationManager(manager ConfigurationManager) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.configManager = manager
+}
+
+// WatchConfig applies every valid change the configuration manager sees in filename until
+// ctx is done
+func (f *Framework) WatchConfig(ctx context.Context, filename string) error {
+	f.mu.RLock()
+	manager := f.configManager
+	f.mu.RUnlock()
+	if manager == nil {
+		return NewConfigurationError("framework", "watch-config", "no configuration manager set")
+	}
+
+	updates, err := manager.WatchConfig(filename)
+	if err != nil {
+		return WrapError(err, ErrorTypeConfiguration, "framework", "watch-config", fmt.Sprintf("failed to watch %s", filename))
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case config, ok := <-updates:
+				if !ok {
+					return
+				}
+				if _, err := f.ApplyConfig(ctx, config); err != nil {
+					slog.Error("Failed to apply reloaded configuration", "file", filename, "error", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// ApplyConfig switches a framework to a new configuration without restarting it. The log
+// level changes at once; plugins removed or disabled are unloaded, new ones are loaded and
+// started, and plugins whose settings changed are reconfigured in place when they support
+// it or replaced otherwise. An invalid configuration changes nothing.
+func (f *Framework) ApplyConfig(ctx context.Context, config *FrameworkConfig) (*ConfigReload, error) {
+	if err := ValidateFrameworkConfig(config); err != nil {
+		return nil, err
+	}
+
+	// Reloads run one at a time, so each diffs against the configuration the last applied
+	f.reloadMu.Lock()
+	defer f.reloadMu.Unlock()
+
+	f.mu.RLock()
+	current := *f.config
+	current.Plugins = append([]PluginConfig(nil), f.config.Plugins...)
+	f.mu.RUnlock()
+
+	reload := &ConfigReload{Failed: make(map[string]string)}
+	if config.LogLevel != current.LogLevel {
+		SetLogLevel(config.LogLevel)
+		reload.LogLevel = config.LogLevel
+	}
+
+	previous := enabledPlugins(current.Plugins)
+	next := enabledPlugins(config.Plugins)
+	for _, name := range sortedKeys(previous) {
+		if _, ok := next[name]; ok {
+			continue
+		}
+		if err := f.UnloadPlugin(name); err != nil {
+			reload.Failed[name] = err.Error()
+			continue
+		}
+		reload.Removed = append(reload.Removed, name)
+	}
+	for _, name := range sortedKeys(next) {
+		pluginConfig := next[name]
+		old, existed := previous[name]
+		switch {
+		case !existed:
+			if err := f.LoadPluginFromConfig(pluginConfig); err != nil {
+				reload.Failed[name] = err.Error()
+				continue
+			}
+			reload.Added = append(reload.Added, name)
+		case old.Type != pluginConfig.Type:
+			if err := f.replacePlugin(pluginConfig); err != nil {
+				reload.Failed[name] = err.Error()
+				continue
+			}
+			reload.Replaced = append(reload.Replaced, name)
+		case !reflect.DeepEqual(old.Config, pluginConfig.Config):
+			if changed, ok := changedSettings(old.Config, pluginConfig.Config); ok {
+				if err := f.ReconfigurePlugin(ctx, name, changed); err == nil {
+					reload.Reconfigured = append(reload.Reconfigured, name)
+					continue
+				}
+			}
+			if err := f.replacePlugin(pluginConfig); err != nil {
+				reload.Failed[name] = err.Error()
+				continue
+			}
+			reload.Replaced = append(reload.Replaced, name)
+		}
+	}
+	f.applyTenants(ctx, config, reload)
+	reload.RestartRequired = restartRequired(&current, config)
+
+	// Keep the startup-only settings in effect so the running configuration stays truthful
+	f.mu.Lock()
+	f.config.LogLevel = config.LogLevel
+	f.config.Plugins = f.appliedPlugins(current.Plugins, config.Plugins, reload.Failed)
+	f.config.Tenants = tenantsWithPlugins(f.config.Tenants, config.Tenants)
+	f.mu.Unlock()
+
+	if len(reload.Failed) == 0 {
+		reload.Failed = nil
+	}
+	if reload.Changed() {
+		slog.Info("Configuration reloaded", "log_level", reload.LogLevel, "added", reload.Added, "removed", reload.Removed,
+			"reconfigured", reload.Reconfigured, "replaced", reload.Replaced, "failed", len(reload.Failed))
+		if len(reload.RestartRequired) > 0 {
+			slog.Warn("Some configuration changes take effect only after a restart", "settings", reload.RestartRequired)
+		}
+		f.publishEvent(EventConfigReloaded, map[string]interface{}{
+			"added":            reload.Added,
+			"removed":          reload.Removed,
+			"reconfigured":     reload.Reconfigured,
+			"replaced":         reload.Replaced,
+			"failed":           reload.Failed,
+			"restart_required": reload.RestartRequired,
+		})
+	}
+	return reload, nil
+}
+
+// appliedPlugins returns the plugin configurations now in effect, which the next reload
+// diffs against. A plugin whose change failed keeps its previous configuration if it still
+// runs and is left out if it does not, so the same configuration is tried again.
+func (f *Framework) appliedPlugins(previous, next []PluginConfig, failed map[string]string) []PluginConfig {
+	previousByName := make(map[string]PluginConfig, len(previous))
+	for _, plugin := range previous {
+		previousByName[plugin.Name] = plugin
+	}
+
+	applied := make([]PluginConfig, 0, len(next))
+	seen := make(map[string]bool, len(next))
+	keep := func(plugin PluginConfig) {
+		if _, isFailed := failed[plugin.Name]; !isFailed {
+			applied = append(applied, plugin)
+			return
+		}
+		if _, err := f.registry.GetPlugin(plugin.Name); err != nil {
+			return
+		}
+		if old, ok := previousByName[plugin.Name]; ok {
+			applied = append(applied, old)
+		}
+	}
+	for _, plugin := range next {
+		seen[plugin.Name] = true
+		keep(plugin)
+	}
+	// Plugins dropped from the configuration that could not be unloaded
+	for _, plugin := range previous {
+		if !seen[plugin.Name] {
+			if _, isFailed := failed[plugin.Name]; isFailed {
+				keep(plugin)
+			}
+		}
+	}
+	return applied
+}
+
+// replacePlugin swaps a plugin for a new one created from config. The new plugin is
+// created first, so a configuration the factory rejects leaves the old one running.
+func (f *Framework) replacePlugin(config PluginConfig) error {
+	resolved, err := f.resolvePluginSecrets(context.Background(), config)
+	if err != nil {
+		return err
+	}
+	plugin, err := f.factory.CreatePlugin(resolved)
+	if err != nil {
+		return WrapError(err, ErrorTypePlugin, "framework", "replace-plugin", "failed to create plugin from config")
+	}
+	if err := f.UnloadPlugin(config.Name); err != nil {
+		return err
+	}
+	if err := f.activatePlugin(plugin); err != nil {
+		return err
+	}
+	f.recordPluginSettings(config)
+	return nil
+}
+
+// enabledPlugins indexes the enabled plugin configurations by name
+func enabledPlugins(plugins []PluginConfig) map[string]PluginConfig {
+	enabled := make(map[string]PluginConfig, len(plugins))
+	for _, plugin := range plugins {
+		if plugin.Enabled {
+			enabled[plugin.Name] = plugin
+		}
+	}
+	return enabled
+}
+
+// sortedKeys returns the names of the plugin configurations in order
+func sortedKeys(plugins map[string]PluginConfig) []string {
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// changedSettings returns the plugin settings that were added or changed. It fails when a
+// setting was removed, since reconfiguring cannot unset one.
+func changedSettings(old, updated interface{}) (map[string]interface{}, bool) {
+	oldSettings, _ := old.(map[string]interface{})
+	newSettings, ok := updated.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	for key := range oldSettings {
+		if _, ok := newSettings[key]; !ok {
+			return nil, false
+		}
+	}
+	changed := make(map[string]interface{})
+	for key, value := range newSettings {
+		if !reflect.DeepEqual(oldSettings[key], value) {
+			changed[key] = value
+		}
+	}
+	return changed, true
+}
+
+// restartRequired lists the top-level settings that changed but are only read at startup
+func restartRequired(current, updated *FrameworkConfig) []string {
+	var settings []string
+	currentValue := reflect.ValueOf(*current)
+	updatedValue := reflect.ValueOf(*updated)
+	configType := currentValue.Type()
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" || name == "log_level" || name == "plugins" {
+			continue
+		}
+		currentField := currentValue.Field(i).Interface()
+		// The tenants' plugins are applied; the tenants themselves are only read at startup
+		if name == "tenants" {
+			currentField = tenantsWithPlugins(current.Tenants, updated.Tenants)
+		}
+		if !reflect.DeepEqual(currentField, updatedValue.Field(i).Interface()) {
+			settings = append(settings, name)
+		}
+	}
+	return settings
+}
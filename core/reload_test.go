@@ -0,0 +1,152 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reloadConfig returns a valid configuration with the given plugins
+func reloadConfig(plugins ...PluginConfig) *FrameworkConfig {
+	return &FrameworkConfig{
+		LogLevel:           "info",
+		LogFormat:          "text",
+		LogOutput:          "stdout",
+		ServerHost:         "0.0.0.0",
+		ServerPort:         9090,
+		HealthCheckTimeout: 5 * time.Second,
+		DataChannelSize:    100,
+		WorkerPoolSize:     1,
+		ShutdownTimeout:    5 * time.Second,
+		Plugins:            plugins,
+	}
+}
+
+func TestFramework_ApplyConfig(t *testing.T) {
+	defer SetLogLevel("info")
+
+	config := reloadConfig(
+		PluginConfig{Name: "old", Type: "responder", Enabled: true},
+		PluginConfig{Name: "ai", Type: "agent", Config: map[string]interface{}{"model": "gpt-4"}, Enabled: true},
+		PluginConfig{Name: "log", Type: "responder", Config: map[string]interface{}{"level": "info"}, Enabled: true},
+	)
+	framework := NewFramework(config)
+	created := make(map[string]Plugin)
+	creator := func(config PluginConfig) (Plugin, error) {
+		var plugin Plugin = &MockPlugin{name: config.Name, pluginType: PluginType(config.Type)}
+		if config.Name == "ai" {
+			plugin = &reconfigurablePlugin{MockPlugin: MockPlugin{name: config.Name, pluginType: PluginTypeAgent}}
+		}
+		created[config.Name] = plugin
+		return plugin, nil
+	}
+	require.NoError(t, framework.GetFactory().RegisterPluginCreator("responder", creator))
+	require.NoError(t, framework.GetFactory().RegisterPluginCreator("agent", creator))
+	for _, pluginConfig := range config.Plugins {
+		require.NoError(t, framework.LoadPluginFromConfig(pluginConfig))
+	}
+	agent := created["ai"].(*reconfigurablePlugin)
+	logger := created["log"]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, framework.Start(ctx))
+	defer framework.Stop()
+
+	updated := reloadConfig(
+		PluginConfig{Name: "ai", Type: "agent", Config: map[string]interface{}{"model": "gpt-4o-mini"}, Enabled: true},
+		PluginConfig{Name: "log", Type: "responder", Config: map[string]interface{}{"level": "debug"}, Enabled: true},
+		PluginConfig{Name: "new", Type: "responder", Enabled: true},
+	)
+	updated.LogLevel = "debug"
+	updated.ServerPort = 8080
+
+	reload, err := framework.ApplyConfig(ctx, updated)
+	require.NoError(t, err)
+	assert.Equal(t, "debug", reload.LogLevel)
+	assert.Equal(t, []string{"old"}, reload.Removed)
+	assert.Equal(t, []string{"new"}, reload.Added)
+	assert.Equal(t, []string{"ai"}, reload.Reconfigured)
+	assert.Equal(t, []string{"log"}, reload.Replaced, "Expected plugins that cannot be reconfigured to be recreated")
+	assert.Empty(t, reload.Failed)
+	assert.Equal(t, []string{"server_port"}, reload.RestartRequired)
+
+	assert.Equal(t, "gpt-4o-mini", agent.settings["model"], "Expected only the changed setting to be passed")
+	assert.Equal(t, PluginStatusStopped, logger.Status(), "Expected the replaced plugin to be stopped")
+	_, err = framework.GetRegistry().GetPlugin("old")
+	assert.Error(t, err)
+	added, err := framework.GetRegistry().GetPlugin("new")
+	require.NoError(t, err)
+	assert.Equal(t, PluginStatusRunning, added.Status(), "Expected plugins added while running to be started")
+	assert.Equal(t, "debug", framework.config.LogLevel)
+	assert.Equal(t, 9090, framework.config.ServerPort, "Expected startup-only settings to stay in effect")
+
+	// Applying the same configuration again leaves the plugins alone
+	reload, err = framework.ApplyConfig(ctx, updated)
+	require.NoError(t, err)
+	assert.Empty(t, reload.Added)
+	assert.Empty(t, reload.Removed)
+	assert.Empty(t, reload.Reconfigured)
+	assert.Empty(t, reload.Replaced)
+
+	invalid := reloadConfig()
+	invalid.LogLevel = "loud"
+	_, err = framework.ApplyConfig(ctx, invalid)
+	assert.Error(t, err)
+	_, err = framework.GetRegistry().GetPlugin("new")
+	assert.NoError(t, err, "Expected an invalid configuration to change nothing")
+}
+
+func TestFramework_ApplyConfigRetriesFailedPlugins(t *testing.T) {
+	config := reloadConfig(PluginConfig{Name: "log", Type: "responder", Config: map[string]interface{}{"level": "info"}, Enabled: true})
+	framework := NewFramework(config)
+	broken := true
+	require.NoError(t, framework.GetFactory().RegisterPluginCreator("responder", func(config PluginConfig) (Plugin, error) {
+		if settings, _ := config.Config.(map[string]interface{}); broken && settings["level"] == "broken" {
+			return nil, fmt.Errorf("no such level")
+		}
+		return &MockPlugin{name: config.Name, pluginType: PluginTypeResponder}, nil
+	}))
+	require.NoError(t, framework.LoadPluginFromConfig(config.Plugins[0]))
+	ctx := context.Background()
+
+	updated := reloadConfig(
+		PluginConfig{Name: "log", Type: "responder", Config: map[string]interface{}{"level": "broken"}, Enabled: true},
+		PluginConfig{Name: "new", Type: "responder", Config: map[string]interface{}{"level": "broken"}, Enabled: true},
+	)
+	reload, err := framework.ApplyConfig(ctx, updated)
+	require.NoError(t, err)
+	assert.Contains(t, reload.Failed, "log")
+	assert.Contains(t, reload.Failed, "new")
+
+	// The same configuration is tried again rather than taken as applied
+	reload, err = framework.ApplyConfig(ctx, updated)
+	require.NoError(t, err)
+	assert.Contains(t, reload.Failed, "log")
+	assert.Contains(t, reload.Failed, "new")
+
+	broken = false
+	reload, err = framework.ApplyConfig(ctx, updated)
+	require.NoError(t, err)
+	assert.Empty(t, reload.Failed)
+	assert.Equal(t, []string{"new"}, reload.Added)
+	assert.Equal(t, []string{"log"}, reload.Replaced)
+
+	// Removing a plugin that never loaded is not reported as an unload failure
+	broken = true
+	_, err = framework.ApplyConfig(ctx, reloadConfig(
+		PluginConfig{Name: "log", Type: "responder", Config: map[string]interface{}{"level": "info"}, Enabled: true},
+		PluginConfig{Name: "other", Type: "responder", Config: map[string]interface{}{"level": "broken"}, Enabled: true},
+	))
+	require.NoError(t, err)
+	reload, err = framework.ApplyConfig(ctx, reloadConfig(
+		PluginConfig{Name: "log", Type: "responder", Config: map[string]interface{}{"level": "info"}, Enabled: true},
+	))
+	require.NoError(t, err)
+	assert.Empty(t, reload.Failed)
+	assert.Empty(t, reload.Removed)
+}
@@ -0,0 +1,451 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RemediationStatus is the lifecycle state of a remediation action
+type RemediationStatus string
+
+const (
+	RemediationStatusPending  RemediationStatus = "pending_approval"
+	RemediationStatusRunning  RemediationStatus = "running"
+	RemediationStatusExecuted RemediationStatus = "executed"
+	RemediationStatusFailed   RemediationStatus = "failed"
+	RemediationStatusRejected RemediationStatus = "rejected"
+	RemediationStatusExpired  RemediationStatus = "expired"
+)
+
+// remediationWindow is the period the per-service action cap applies to
+const remediationWindow = time.Hour
+
+// remediationRetention is how long finished remediations stay listed
+const remediationRetention = 24 * time.Hour
+
+// unknownService buckets remediations that do not name a service
+const unknownService = "unknown"
+
+// Remediation is an action that changes production, such as a restart or a scaling, run
+// as a workflow or, when Responder is set instead, carried out by that responder
+type Remediation struct {
+	ID          string                 `json:"id"`
+	Service     string                 `json:"service"`
+	Action      string                 `json:"action"`
+	WorkflowID  string                 `json:"workflow_id"`
+	Responder   string                 `json:"responder,omitempty"`
+	Input       map[string]interface{} `json:"input,omitempty"`
+	IncidentID  string                 `json:"incident_id,omitempty"`
+	RequestedBy string                 `json:"requested_by"`
+	RequestedAt time.Time              `json:"requested_at"`
+	// ApprovedBy is set when a person started or approved the action; approved actions
+	// count against the cap but are never held
+	ApprovedBy string            `json:"approved_by,omitempty"`
+	Status     RemediationStatus `json:"status"`
+	Reason     string            `json:"reason,omitempty"`
+	ExecutedAt time.Time         `json:"executed_at,omitempty"`
+	Result     *WorkflowResult   `json:"result,omitempty"`
+	// Verification re-checks the metric that triggered the action once it has run
+	Verification *RemediationVerification `json:"verification,omitempty"`
+}
+
+// clone returns a copy that does not share the verification state
+func (r *Remediation) clone() Remediation {
+	clone := *r
+	if r.Verification != nil {
+		verification := *r.Verification
+		clone.Verification = &verification
+	}
+	return clone
+}
+
+// RemediationGovernor caps how many remediation actions run per service per hour. Actions
+// beyond the cap wait for a person to approve them, so a remediation loop cannot keep
+// restarting or scaling a service.
+type RemediationGovernor struct {
+	maxPerHour int
+	executed   map[string][]time.Time
+	actions    map[string]*Remediation
+	verifying  map[string]*Remediation
+	nextID     int
+	mu         sync.Mutex
+}
+
+// NewRemediationGovernor creates a governor; a cap of zero or less allows every action
+func NewRemediationGovernor(maxPerHour int) *RemediationGovernor {
+	return &RemediationGovernor{
+		maxPerHour: maxPerHour,
+		executed:   make(map[string][]time.Time),
+		actions:    make(map[string]*Remediation),
+		verifying:  make(map[string]*Remediation),
+	}
+}
+
+// RemediationForAnalysis creates a remediation for the service an analysis is about, taken
+// from the given label shared by all of its data points. The first data point is the
+// metric re-checked after the action runs, expected to fall back from its current value;
+// set Verification.Increase when it fired for being too low.
+func RemediationForAnalysis(analysis *Analysis, serviceLabel, action, workflowID string) Remediation {
+	remediation := Remediation{
+		Service:     analysisLabels(analysis)[serviceLabel],
+		Action:      action,
+		WorkflowID:  workflowID,
+		RequestedBy: analysis.Source,
+	}
+	if incidentID, ok := analysis.Details["incident_id"].(string); ok {
+		remediation.IncidentID = incidentID
+	}
+	if len(analysis.DataPoints) > 0 {
+		point := analysis.DataPoints[0]
+		remediation.Verification = &RemediationVerification{
+			Metric:   point.Metric,
+			Labels:   point.Labels,
+			Baseline: point.Value,
+		}
+	}
+	return remediation
+}
+
+// serviceKey returns the bucket a remediation counts against
+func serviceKey(service string) string {
+	if service == "" {
+		return unknownService
+	}
+	return service
+}
+
+// recent returns the service's executions within the window, dropping older ones. Callers
+// hold the lock.
+func (g *RemediationGovernor) recent(service string, now time.Time) []time.Time {
+	cutoff := now.Add(-remediationWindow)
+	executed := g.executed[service]
+	drop := 0
+	for drop < len(executed) && !executed[drop].After(cutoff) {
+		drop++
+	}
+	executed = executed[drop:]
+	g.executed[service] = executed
+	return executed
+}
+
+// admit registers a new remediation and reports whether it may run now. Held remediations
+// are left pending for approval and a copy is returned, since approval may change them.
+func (g *RemediationGovernor) admit(request Remediation, now time.Time) (*Remediation, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	remediation := g.register(request, now)
+	service := serviceKey(remediation.Service)
+	recent := g.recent(service, now)
+	if remediation.ApprovedBy == "" && g.maxPerHour > 0 && len(recent) >= g.maxPerHour {
+		remediation.Status = RemediationStatusPending
+		remediation.Reason = fmt.Sprintf("%d actions already ran for %s in the last hour (cap %d)", len(recent), service, g.maxPerHour)
+		held := remediation.clone()
+		return &held, false
+	}
+	remediation.Status = RemediationStatusRunning
+	g.executed[service] = append(recent, now)
+	return remediation, true
+}
+
+// hold registers a new remediation that waits for approval whatever the cap, such as an
+// action an agent proposed
+func (g *RemediationGovernor) hold(request Remediation, reason string, now time.Time) Remediation {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	remediation := g.register(request, now)
+	remediation.Status = RemediationStatusPending
+	remediation.Reason = reason
+	return remediation.clone()
+}
+
+// register adds a new remediation under the next ID, dropping finished ones past their
+// retention. Callers hold the lock.
+func (g *RemediationGovernor) register(request Remediation, now time.Time) *Remediation {
+	for id, remediation := range g.actions {
+		if remediation.Status != RemediationStatusPending && remediation.Status != RemediationStatusRunning &&
+			now.Sub(remediation.RequestedAt) > remediationRetention {
+			delete(g.actions, id)
+		}
+	}
+
+	g.nextID++
+	registered := request.clone()
+	remediation := &registered
+	remediation.ID = fmt.Sprintf("REM-%d", g.nextID)
+	remediation.RequestedAt = now
+	g.actions[remediation.ID] = remediation
+	return remediation
+}
+
+// approve releases a held remediation so it can run
+func (g *RemediationGovernor) approve(id, actor string, now time.Time) (*Remediation, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	remediation, err := g.pending(id)
+	if err != nil {
+		return nil, err
+	}
+	remediation.ApprovedBy = actor
+	remediation.Reason = ""
+	remediation.Status = RemediationStatusRunning
+	service := serviceKey(remediation.Service)
+	g.executed[service] = append(g.recent(service, now), now)
+	return remediation, nil
+}
+
+// reject discards a held remediation
+func (g *RemediationGovernor) reject(id, actor string) (Remediation, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	remediation, err := g.pending(id)
+	if err != nil {
+		return Remediation{}, err
+	}
+	remediation.Status = RemediationStatusRejected
+	remediation.Reason = fmt.Sprintf("rejected by %s", actor)
+	return remediation.clone(), nil
+}
+
+// expire marks the remediations waiting for approval since before the cutoff as expired
+func (g *RemediationGovernor) expire(cutoff time.Time, ttl time.Duration) []Remediation {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var expired []Remediation
+	for _, remediation := range g.actions {
+		if remediation.Status != RemediationStatusPending || !remediation.RequestedAt.Before(cutoff) {
+			continue
+		}
+		remediation.Status = RemediationStatusExpired
+		remediation.Reason = fmt.Sprintf("not approved within %s", ttl)
+		expired = append(expired, remediation.clone())
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].RequestedAt.Before(expired[j].RequestedAt) })
+	return expired
+}
+
+// pending returns a remediation waiting for approval. Callers hold the lock.
+func (g *RemediationGovernor) pending(id string) (*Remediation, error) {
+	remediation, ok := g.actions[id]
+	if !ok {
+		return nil, NewValidationError("remediation", "approve", fmt.Sprintf("remediation %s not found", id))
+	}
+	if remediation.Status != RemediationStatusPending {
+		return nil, NewValidationError("remediation", "approve", fmt.Sprintf("remediation %s is %s, not pending approval", id, remediation.Status))
+	}
+	return remediation, nil
+}
+
+// finish records the outcome of a remediation that ran and, when verifyAt is set,
+// schedules the re-check of a successful action's metric
+func (g *RemediationGovernor) finish(remediation *Remediation, result *WorkflowResult, err error, now, verifyAt time.Time) Remediation {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	remediation.ExecutedAt = now
+	remediation.Result = result
+	remediation.Status = RemediationStatusExecuted
+	if err != nil {
+		remediation.Status = RemediationStatusFailed
+		remediation.Reason = err.Error()
+	}
+	if err == nil && remediation.Verification != nil && !verifyAt.IsZero() {
+		remediation.Verification.Status = VerificationPending
+		remediation.Verification.CheckAt = verifyAt
+		g.verifying[remediation.ID] = remediation
+	}
+	return remediation.clone()
+}
+
+// List returns every remediation, newest first
+func (g *RemediationGovernor) List() []Remediation {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	remediations := make([]Remediation, 0, len(g.actions))
+	for _, remediation := range g.actions {
+		remediations = append(remediations, remediation.clone())
+	}
+	sort.Slice(remediations, func(i, j int) bool {
+		return remediations[i].RequestedAt.After(remediations[j].RequestedAt)
+	})
+	return remediations
+}
+
+// ExecuteRemediation runs a remediation workflow unless the service has used up its hourly
+// cap, in which case the remediation is held for approval. Every automated action must go
+// through here.
+func (f *Framework) ExecuteRemediation(ctx context.Context, remediation Remediation) (Remediation, error) {
+	if f.config.ReadOnly {
+		return Remediation{}, NewValidationError("remediation", "execute", "remediations cannot run while the framework is read-only")
+	}
+
+	admitted, ok := f.remediations.admit(remediation, time.Now())
+	if !ok {
+		held := *admitted
+		slog.Warn("Remediation held for approval", "remediation", held.ID, "service", held.Service,
+			"action", held.Action, "reason", held.Reason)
+		if held.IncidentID != "" {
+			f.incidents.Annotate(held.IncidentID, "remediation_held", held.RequestedBy,
+				fmt.Sprintf("%s %s held for approval as %s: %s", held.Action, held.Service, held.ID, held.Reason))
+		}
+		f.requestApproval(ctx, held)
+		return held, nil
+	}
+	if admitted.ApprovedBy != "" {
+		f.audit(ctx, *admitted, ApprovalEventApproved, admitted.ApprovedBy, "started by a person")
+	}
+	return f.runRemediation(ctx, admitted), nil
+}
+
+// ApproveRemediation runs a remediation held by the governor
+func (f *Framework) ApproveRemediation(ctx context.Context, id, actor string) (Remediation, error) {
+	remediation, err := f.releaseRemediation(ctx, id, actor)
+	if err != nil {
+		return Remediation{}, err
+	}
+	return f.runRemediation(ctx, remediation), nil
+}
+
+// releaseRemediation approves a held remediation, leaving it to the caller to run, unless
+// it expired
+func (f *Framework) releaseRemediation(ctx context.Context, id, actor string) (*Remediation, error) {
+	if f.config.ReadOnly {
+		return nil, NewValidationError("remediation", "approve", "remediations cannot run while the framework is read-only")
+	}
+
+	now := time.Now()
+	f.expireApprovals(ctx, now)
+	remediation, err := f.remediations.approve(id, actor, now)
+	if err != nil {
+		return nil, err
+	}
+	f.audit(ctx, *remediation, ApprovalEventApproved, actor, "")
+	return remediation, nil
+}
+
+// RejectRemediation discards a remediation held by the governor
+func (f *Framework) RejectRemediation(id, actor string) (Remediation, error) {
+	ctx := context.Background()
+	f.expireApprovals(ctx, time.Now())
+	remediation, err := f.remediations.reject(id, actor)
+	if err != nil {
+		return Remediation{}, err
+	}
+	f.audit(ctx, remediation, ApprovalEventRejected, actor, "")
+	if remediation.IncidentID != "" {
+		f.incidents.Annotate(remediation.IncidentID, "remediation_rejected", actor,
+			fmt.Sprintf("%s %s (%s) rejected", remediation.Action, remediation.Service, remediation.ID))
+	}
+	return remediation, nil
+}
+
+// runRemediation executes the workflow of an admitted remediation, or records it in the
+// would-have-fired report in dry-run mode
+func (f *Framework) runRemediation(ctx context.Context, remediation *Remediation) Remediation {
+	if f.config.DryRun {
+		f.dryRun.Record(DryRunEntry{
+			Time:      time.Now(),
+			TraceID:   TraceIDFromContext(ctx),
+			Responder: "remediation",
+			Source:    remediation.RequestedBy,
+			Summary:   fmt.Sprintf("%s %s", remediation.Action, remediation.Service),
+			SimulatedAction: SimulatedAction{
+				Action:  "execute_workflow",
+				Target:  remediation.WorkflowID,
+				Payload: remediation.Input,
+			},
+		})
+		finished := f.remediations.finish(remediation, &WorkflowResult{WorkflowID: remediation.WorkflowID, Status: "simulated"}, nil, time.Now(), time.Time{})
+		f.audit(ctx, finished, ApprovalEventExecuted, remediationActor(finished), "simulated in dry-run mode")
+		return finished
+	}
+
+	var result *WorkflowResult
+	var err error
+	if remediation.Responder != "" {
+		err = f.remediateThroughResponder(ctx, remediation)
+	} else {
+		f.mu.RLock()
+		engine := f.workflowEngine
+		f.mu.RUnlock()
+
+		err = NewConfigurationError("remediation", "execute", "no workflow engine configured")
+		if engine != nil {
+			result, err = engine.ExecuteWorkflow(ctx, remediation.WorkflowID, remediation.Input)
+		}
+	}
+	now := time.Now()
+	var verifyAt time.Time
+	if f.config.Remediation.VerifyAfter > 0 {
+		verifyAt = now.Add(f.config.Remediation.VerifyAfter)
+	}
+	finished := f.remediations.finish(remediation, result, err, now, verifyAt)
+	if err != nil {
+		slog.Error("Remediation failed", "remediation", finished.ID, "workflow", finished.WorkflowID,
+			"responder", finished.Responder, "error", err)
+		f.audit(ctx, finished, ApprovalEventFailed, remediationActor(finished), err.Error())
+	} else {
+		f.audit(ctx, finished, ApprovalEventExecuted, remediationActor(finished), "")
+	}
+	return finished
+}
+
+// remediationActor is who an action ran for: the person who approved it, or what requested it
+func remediationActor(remediation Remediation) string {
+	if remediation.ApprovedBy != "" {
+		return remediation.ApprovedBy
+	}
+	return remediation.RequestedBy
+}
+
+// remediateThroughResponder asks a responder to carry out a remediation, such as a
+// webhook to deployment tooling, with the delivery timeout, retries, and circuit breaker
+func (f *Framework) remediateThroughResponder(ctx context.Context, remediation *Remediation) error {
+	plugin, err := f.registry.GetPlugin(remediation.Responder)
+	if err != nil {
+		return NewPluginError("remediation", "execute", fmt.Sprintf("responder %s not found", remediation.Responder))
+	}
+	responder, ok := plugin.(DataResponder)
+	if !ok {
+		return NewPluginError("remediation", "execute", fmt.Sprintf("plugin %s is not a responder", remediation.Responder))
+	}
+
+	details := map[string]interface{}{
+		"remediation_id": remediation.ID,
+		"action":         remediation.Action,
+		"service":        remediation.Service,
+		"input":          remediation.Input,
+		"requested_by":   remediation.RequestedBy,
+		"approved_by":    remediation.ApprovedBy,
+	}
+	if remediation.IncidentID != "" {
+		details["incident_id"] = remediation.IncidentID
+	}
+	analysis := &Analysis{
+		Type:      AnalysisTypeRemediation,
+		Severity:  "high",
+		Source:    "remediation",
+		Summary:   fmt.Sprintf("%s %s (%s)", remediation.Action, serviceKey(remediation.Service), remediation.ID),
+		Timestamp: time.Now(),
+		Details:   details,
+	}
+	analysis.EnsureIdentity()
+	if TraceIDFromContext(ctx) == "" {
+		ctx = WithTraceID(ctx, NewTraceID())
+	}
+	return f.attemptDelivery(ctx, responder, analysis, 1)
+}
+
+// GetRemediationGovernor returns the governor capping remediation actions
+func (f *Framework) GetRemediationGovernor() *RemediationGovernor {
+	return f.remediations
+}
@@ -0,0 +1,248 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingWorkflowEngine struct {
+	executed []string
+	inputs   []map[string]interface{}
+	mu       sync.Mutex
+}
+
+func (e *recordingWorkflowEngine) CreateWorkflow(workflow *Workflow) error {
+	return nil
+}
+
+func (e *recordingWorkflowEngine) ExecuteWorkflow(ctx context.Context, workflowID string, input map[string]interface{}) (*WorkflowResult, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.executed = append(e.executed, workflowID)
+	e.inputs = append(e.inputs, input)
+	return &WorkflowResult{WorkflowID: workflowID, Status: "completed"}, nil
+}
+
+func (e *recordingWorkflowEngine) GetWorkflowStatus(workflowID string) (*WorkflowStatus, error) {
+	return nil, nil
+}
+
+func (e *recordingWorkflowEngine) CancelWorkflow(workflowID string) error {
+	return nil
+}
+
+func newRemediationFramework(config *FrameworkConfig) (*Framework, *recordingWorkflowEngine) {
+	config.LogLevel = "info"
+	config.LogFormat = "text"
+	config.LogOutput = "stdout"
+	framework := NewFramework(config)
+	engine := &recordingWorkflowEngine{}
+	framework.SetWorkflowEngine(engine)
+	return framework, engine
+}
+
+func TestFramework_RemediationCap(t *testing.T) {
+	framework, engine := newRemediationFramework(&FrameworkConfig{Remediation: RemediationConfig{MaxActionsPerHour: 2, ServiceLabel: "service"}})
+	ctx := context.Background()
+
+	analysis := &Analysis{Source: "crashloop", DataPoints: []DataPoint{{Metric: "restarts", Labels: map[string]string{"service": "api"}}}}
+	restart := RemediationForAnalysis(analysis, "service", "restart", "restart-api")
+	assert.Equal(t, "api", restart.Service)
+
+	for i := 0; i < 2; i++ {
+		remediation, err := framework.ExecuteRemediation(ctx, restart)
+		require.NoError(t, err)
+		assert.Equal(t, RemediationStatusExecuted, remediation.Status)
+	}
+
+	held, err := framework.ExecuteRemediation(ctx, restart)
+	require.NoError(t, err)
+	assert.Equal(t, RemediationStatusPending, held.Status, "Expected actions beyond the cap to wait for approval")
+	assert.Len(t, engine.executed, 2)
+
+	other, err := framework.ExecuteRemediation(ctx, Remediation{Service: "db", Action: "restart", WorkflowID: "restart-db"})
+	require.NoError(t, err)
+	assert.Equal(t, RemediationStatusExecuted, other.Status, "Expected the cap to apply per service")
+
+	manual, err := framework.ExecuteRemediation(ctx, Remediation{Service: "api", Action: "runbook", WorkflowID: "restart-api", ApprovedBy: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, RemediationStatusExecuted, manual.Status, "Expected actions a person started never to be held")
+
+	approved, err := framework.ApproveRemediation(ctx, held.ID, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, RemediationStatusExecuted, approved.Status)
+	assert.Equal(t, "bob", approved.ApprovedBy)
+	assert.Len(t, engine.executed, 5)
+
+	_, err = framework.ApproveRemediation(ctx, held.ID, "bob")
+	assert.Error(t, err, "Expected a remediation to be approved only once")
+}
+
+func TestFramework_RemediationReject(t *testing.T) {
+	framework, engine := newRemediationFramework(&FrameworkConfig{Remediation: RemediationConfig{MaxActionsPerHour: 1}})
+	ctx := context.Background()
+
+	incident, _ := framework.GetIncidentManager().Track(&Analysis{Summary: "api down", Source: "probe"})
+	scale := Remediation{Service: "api", Action: "scale", WorkflowID: "scale-api", IncidentID: incident.ID, RequestedBy: "probe"}
+	_, err := framework.ExecuteRemediation(ctx, scale)
+	require.NoError(t, err)
+	held, err := framework.ExecuteRemediation(ctx, scale)
+	require.NoError(t, err)
+	require.Equal(t, RemediationStatusPending, held.Status)
+
+	rejected, err := framework.RejectRemediation(held.ID, "carol")
+	require.NoError(t, err)
+	assert.Equal(t, RemediationStatusRejected, rejected.Status)
+	assert.Len(t, engine.executed, 1)
+
+	incident, err = framework.GetIncidentManager().Get(incident.ID)
+	require.NoError(t, err)
+	var events []string
+	for _, event := range incident.Timeline {
+		events = append(events, event.Type)
+	}
+	assert.Contains(t, events, "remediation_held")
+	assert.Contains(t, events, "remediation_rejected")
+}
+
+func TestFramework_RemediationModes(t *testing.T) {
+	readOnly, engine := newRemediationFramework(&FrameworkConfig{ReadOnly: true})
+	_, err := readOnly.ExecuteRemediation(context.Background(), Remediation{Service: "api", WorkflowID: "restart-api"})
+	assert.Error(t, err)
+	assert.Empty(t, engine.executed)
+
+	dryRun, engine := newRemediationFramework(&FrameworkConfig{DryRun: true})
+	remediation, err := dryRun.ExecuteRemediation(context.Background(), Remediation{Service: "api", Action: "restart", WorkflowID: "restart-api"})
+	require.NoError(t, err)
+	assert.Equal(t, "simulated", remediation.Result.Status)
+	assert.Empty(t, engine.executed, "Expected dry runs not to execute workflows")
+	assert.Equal(t, 1, dryRun.GetDryRunReport().Summary().Total)
+}
+
+// workflowPlugin is a plugin that runs workflows and asks other agents
+type workflowPlugin struct {
+	MockPlugin
+	recordingWorkflowEngine
+	querier AgentQuerier
+}
+
+func (p *workflowPlugin) SetAgentQuerier(querier AgentQuerier) {
+	p.querier = querier
+}
+
+func TestFramework_WorkflowEnginePlugin(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout"})
+	plugin := &workflowPlugin{MockPlugin: MockPlugin{name: "orchestrator", pluginType: PluginTypeAgent}}
+	require.NoError(t, framework.LoadPlugin(plugin))
+	assert.Equal(t, framework, plugin.querier, "Expected the framework to answer the plugin's queries")
+
+	remediation, err := framework.ExecuteRemediation(context.Background(), Remediation{Service: "api", Action: "restart", WorkflowID: "restart-api"})
+	require.NoError(t, err)
+	assert.Equal(t, RemediationStatusExecuted, remediation.Status)
+	assert.Equal(t, []string{"restart-api"}, plugin.executed, "Expected the plugin to run remediation workflows")
+
+	require.NoError(t, framework.UnloadPlugin("orchestrator"))
+	remediation, _ = framework.ExecuteRemediation(context.Background(), Remediation{Service: "db", Action: "restart", WorkflowID: "restart-db"})
+	assert.Equal(t, RemediationStatusFailed, remediation.Status, "Expected an unloaded plugin to run no more workflows")
+
+	configured, engine := newRemediationFramework(&FrameworkConfig{})
+	other := &workflowPlugin{MockPlugin: MockPlugin{name: "orchestrator", pluginType: PluginTypeAgent}}
+	require.NoError(t, configured.LoadPlugin(other))
+	_, err = configured.ExecuteRemediation(context.Background(), Remediation{Service: "api", Action: "restart", WorkflowID: "restart-api"})
+	require.NoError(t, err)
+	assert.Len(t, engine.executed, 1, "Expected a set engine to be kept")
+	assert.Empty(t, other.executed)
+}
+
+func TestRemediationGovernor_WindowExpires(t *testing.T) {
+	governor := NewRemediationGovernor(1)
+	now := time.Now()
+
+	_, ok := governor.admit(Remediation{Service: "api"}, now)
+	assert.True(t, ok)
+	_, ok = governor.admit(Remediation{Service: "api"}, now.Add(30*time.Minute))
+	assert.False(t, ok)
+	_, ok = governor.admit(Remediation{Service: "api"}, now.Add(61*time.Minute))
+	assert.True(t, ok, "Expected the cap to reset after an hour")
+}
+
+func TestFramework_RemediationVerification(t *testing.T) {
+	framework, _ := newRemediationFramework(&FrameworkConfig{
+		Remediation: RemediationConfig{ServiceLabel: "service", VerifyAfter: 5 * time.Minute, MinImprovement: 0.1},
+	})
+	responder := &severityResponder{MockPlugin: MockPlugin{name: "pager", pluginType: PluginTypeResponder}, severity: "critical"}
+	require.NoError(t, framework.LoadPlugin(responder))
+	ctx := context.Background()
+
+	latency := func(service string, value float64) *Analysis {
+		return &Analysis{Source: "latency", Summary: service + " slow", Details: map[string]interface{}{},
+			DataPoints: []DataPoint{{Metric: "p99_latency", Value: value, Labels: map[string]string{"service": service}}}}
+	}
+	remediate := func(analysis *Analysis) (Remediation, Incident) {
+		incident, _ := framework.GetIncidentManager().Track(analysis)
+		analysis.Details["incident_id"] = incident.ID
+		remediation, err := framework.ExecuteRemediation(ctx, RemediationForAnalysis(analysis, "service", "restart", "restart"))
+		require.NoError(t, err)
+		require.NotNil(t, remediation.Verification)
+		assert.Equal(t, VerificationPending, remediation.Verification.Status)
+		return remediation, incident
+	}
+
+	fixed, fixedIncident := remediate(latency("api", 2.0))
+	stuck, stuckIncident := remediate(latency("db", 2.0))
+	silent, _ := remediate(latency("cache", 2.0))
+
+	after := time.Now().Add(time.Minute)
+	framework.processData(ctx, []DataPoint{
+		{Timestamp: after, Metric: "p99_latency", Value: 0.4, Labels: map[string]string{"service": "api"}},
+		{Timestamp: after, Metric: "p99_latency", Value: 1.9, Labels: map[string]string{"service": "db"}},
+		{Timestamp: after, Metric: "p99_latency", Value: 0.1, Labels: map[string]string{"service": "web"}},
+	})
+
+	framework.verifyRemediations(ctx, time.Now().Add(time.Minute))
+	assert.Empty(t, responder.handled, "Expected nothing to be verified before the check time")
+
+	framework.verifyRemediations(ctx, time.Now().Add(6*time.Minute))
+	statuses := make(map[string]VerificationStatus)
+	for _, remediation := range framework.GetRemediationGovernor().List() {
+		statuses[remediation.ID] = remediation.Verification.Status
+	}
+	assert.Equal(t, VerificationImproved, statuses[fixed.ID])
+	assert.Equal(t, VerificationNotImproved, statuses[stuck.ID], "Expected a 5% drop to fall short of the 10% improvement")
+	assert.Equal(t, VerificationNoData, statuses[silent.ID])
+
+	require.Len(t, responder.handled, 2, "Expected remediations that did not help to be escalated")
+	escalated := make(map[interface{}]interface{})
+	for _, analysis := range responder.handled {
+		escalated[analysis.Details["remediation_id"]] = analysis.Details["incident_id"]
+	}
+	assert.Equal(t, stuckIncident.ID, escalated[stuck.ID])
+	assert.Contains(t, escalated, silent.ID)
+
+	timelineTypes := func(id string) []string {
+		incident, err := framework.GetIncidentManager().Get(id)
+		require.NoError(t, err)
+		var events []string
+		for _, event := range incident.Timeline {
+			events = append(events, event.Type)
+		}
+		return events
+	}
+	assert.Contains(t, timelineTypes(fixedIncident.ID), "remediation_verified")
+	assert.Contains(t, timelineTypes(stuckIncident.ID), "remediation_failed")
+
+	framework.verifyRemediations(ctx, time.Now().Add(time.Hour))
+	assert.Len(t, responder.handled, 2, "Expected each remediation to be verified once")
+}
+
+func TestRemediationVerification_Increase(t *testing.T) {
+	verification := &RemediationVerification{Baseline: 10, Increase: true, Value: 12}
+	assert.True(t, verification.improved(0.1))
+	verification.Value = 8
+	assert.False(t, verification.improved(0.1), "Expected a metric that fired for being low to need to rise")
+}
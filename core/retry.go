@@ -0,0 +1,119 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryableError is implemented by errors that know whether retrying can help, such as an
+// HTTP error by its status code. Errors that don't implement it are retried.
+type RetryableError interface {
+	error
+	Retryable() bool
+}
+
+// RetryAfterError is implemented by errors that say when to retry, such as one carrying an
+// HTTP Retry-After header. A positive wait replaces the backoff delay.
+type RetryAfterError interface {
+	error
+	RetryAfter() time.Duration
+}
+
+// BackoffRetryExecutor is the default implementation of RetryExecutor, retrying failed
+// operations with exponential backoff
+type BackoffRetryExecutor struct {
+	policy RetryPolicy
+}
+
+// NewBackoffRetryExecutor creates a retry executor whose Execute uses the given policy
+func NewBackoffRetryExecutor(policy RetryPolicy) *BackoffRetryExecutor {
+	return &BackoffRetryExecutor{policy: policy}
+}
+
+// Execute runs the operation with the executor's policy
+func (r *BackoffRetryExecutor) Execute(ctx context.Context, operation func() error) error {
+	return r.ExecuteWithPolicy(ctx, r.policy, operation)
+}
+
+// ExecuteWithPolicy runs the operation until it succeeds or MaxAttempts attempts have
+// failed, returning the last error. An open circuit breaker is not retried, and neither
+// is an error that says retrying cannot help, or anything once the context is done. An
+// error that says when to retry is retried then, unless that is beyond MaxDelay.
+func (r *BackoffRetryExecutor) ExecuteWithPolicy(ctx context.Context, policy RetryPolicy, operation func() error) error {
+	delay := policy.InitialDelay
+	for attempt := 1; ; attempt++ {
+		err := operation()
+		if err == nil || attempt >= policy.MaxAttempts || errors.Is(err, ErrCircuitOpen) || ctx.Err() != nil {
+			return err
+		}
+		var retryable RetryableError
+		if errors.As(err, &retryable) && !retryable.Retryable() {
+			return err
+		}
+
+		wait := delay
+		if policy.Jitter && wait > 0 {
+			// Spread retries of many callers over the second half of the delay
+			wait = wait/2 + time.Duration(rand.Int64N(int64(wait/2)+1))
+		}
+		var hinted RetryAfterError
+		if errors.As(err, &hinted) && hinted.RetryAfter() > 0 {
+			if policy.MaxDelay > 0 && hinted.RetryAfter() > policy.MaxDelay {
+				return err
+			}
+			wait = hinted.RetryAfter()
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		if policy.Multiplier > 1 {
+			delay = time.Duration(float64(delay) * policy.Multiplier)
+		}
+		if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+	}
+}
+
+// ParseRetryPolicy reads max_attempts, initial_delay, and max_delay from a plugin's
+// configuration section, keeping the defaults for keys not given
+func ParseRetryPolicy(raw interface{}, defaults RetryPolicy) (RetryPolicy, error) {
+	policy := defaults
+	section, ok := raw.(map[string]interface{})
+	if !ok {
+		if raw != nil {
+			return policy, NewValidationError("retry", "configure", "retry configuration must be a map")
+		}
+		return policy, nil
+	}
+
+	switch value := section["max_attempts"].(type) {
+	case int:
+		policy.MaxAttempts = value
+	case float64:
+		policy.MaxAttempts = int(value)
+	}
+	if policy.MaxAttempts < 1 {
+		return policy, NewValidationError("retry", "configure", "max_attempts must be at least 1")
+	}
+	for key, target := range map[string]*time.Duration{"initial_delay": &policy.InitialDelay, "max_delay": &policy.MaxDelay} {
+		text, ok := section[key].(string)
+		if !ok {
+			continue
+		}
+		delay, err := time.ParseDuration(text)
+		if err != nil || delay < 0 {
+			return policy, NewValidationError("retry", "configure", fmt.Sprintf("invalid %s %q", key, text))
+		}
+		*target = delay
+	}
+	return policy, nil
+}
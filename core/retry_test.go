@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoffRetryExecutor(t *testing.T) {
+	executor := NewBackoffRetryExecutor(RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond, Multiplier: 2})
+	ctx := context.Background()
+
+	calls := 0
+	err := executor.Execute(ctx, func() error {
+		calls++
+		if calls < 3 {
+			return fmt.Errorf("attempt %d failed", calls)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = executor.Execute(ctx, func() error { calls++; return fmt.Errorf("attempt %d failed", calls) })
+	assert.EqualError(t, err, "attempt 3 failed", "Expected the last error after MaxAttempts")
+
+	calls = 0
+	err = executor.Execute(ctx, func() error { calls++; return ErrCircuitOpen })
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 1, calls, "Expected an open circuit not to be retried")
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	calls = 0
+	executor.ExecuteWithPolicy(canceled, RetryPolicy{MaxAttempts: 5, InitialDelay: time.Hour}, func() error { calls++; return fmt.Errorf("failed") })
+	assert.Equal(t, 1, calls, "Expected no retries once the context is done")
+
+	calls = 0
+	executor.ExecuteWithPolicy(ctx, RetryPolicy{}, func() error { calls++; return fmt.Errorf("failed") })
+	assert.Equal(t, 1, calls, "Expected a zero policy to call once")
+}
+
+// hintedError says whether and when to retry
+type hintedError struct {
+	retryable bool
+	after     time.Duration
+}
+
+func (e hintedError) Error() string             { return "hinted" }
+func (e hintedError) Retryable() bool           { return e.retryable }
+func (e hintedError) RetryAfter() time.Duration { return e.after }
+
+func TestBackoffRetryExecutor_Hints(t *testing.T) {
+	executor := NewBackoffRetryExecutor(RetryPolicy{MaxAttempts: 3, InitialDelay: time.Hour, MaxDelay: time.Second})
+	ctx := context.Background()
+
+	calls := 0
+	err := executor.Execute(ctx, func() error { calls++; return fmt.Errorf("wrapped: %w", hintedError{retryable: false}) })
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls, "Expected an error that cannot be fixed by retrying not to be retried")
+
+	calls = 0
+	start := time.Now()
+	err = executor.Execute(ctx, func() error {
+		calls++
+		if calls < 2 {
+			return hintedError{retryable: true, after: 10 * time.Millisecond}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Minute, "Expected the hinted wait rather than the backoff delay")
+
+	calls = 0
+	err = executor.Execute(ctx, func() error { calls++; return hintedError{retryable: true, after: time.Minute} })
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls, "Expected a wait beyond MaxDelay to give up")
+}
+
+func TestParseRetryPolicy(t *testing.T) {
+	defaults := RetryPolicy{MaxAttempts: 3, InitialDelay: time.Second, MaxDelay: 10 * time.Second, Multiplier: 2, Jitter: true}
+	policy, err := ParseRetryPolicy(nil, defaults)
+	assert.NoError(t, err)
+	assert.Equal(t, defaults, policy)
+
+	policy, err = ParseRetryPolicy(map[string]interface{}{"max_attempts": 5, "max_delay": "1m"}, defaults)
+	assert.NoError(t, err)
+	assert.Equal(t, RetryPolicy{MaxAttempts: 5, InitialDelay: time.Second, MaxDelay: time.Minute, Multiplier: 2, Jitter: true}, policy)
+
+	_, err = ParseRetryPolicy(map[string]interface{}{"max_attempts": 0}, defaults)
+	assert.Error(t, err)
+	_, err = ParseRetryPolicy(map[string]interface{}{"initial_delay": "soon"}, defaults)
+	assert.Error(t, err)
+	_, err = ParseRetryPolicy("always", defaults)
+	assert.Error(t, err)
+}
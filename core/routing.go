@@ -0,0 +1,80 @@
+package core
+
+import (
+	"fmt"
+	"path"
+)
+
+// analyzerRoute restricts the data points an analyzer is given
+type analyzerRoute struct {
+	metrics  []string
+	matchers []LabelMatcher
+}
+
+// matches reports whether the point's metric matches one of the route's globs and its
+// labels satisfy every matcher
+func (r *analyzerRoute) matches(point DataPoint) bool {
+	if len(r.metrics) > 0 {
+		matched := false
+		for _, pattern := range r.metrics {
+			if ok, _ := path.Match(pattern, point.Metric); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for _, matcher := range r.matchers {
+		if !matcher.Matches(point.Labels[matcher.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// analyzerRoutes holds the routes by analyzer name. Analyzers without a route see every
+// data point.
+type analyzerRoutes map[string]*analyzerRoute
+
+// newAnalyzerRoutes compiles the configured routes
+func newAnalyzerRoutes(configs []AnalyzerRouteConfig) (analyzerRoutes, error) {
+	routes := make(analyzerRoutes, len(configs))
+	for _, config := range configs {
+		if _, ok := routes[config.Analyzer]; ok {
+			return nil, NewConfigurationError("routing", "build", fmt.Sprintf("analyzer %q has more than one route", config.Analyzer))
+		}
+
+		route := &analyzerRoute{metrics: config.Metrics}
+		for _, pattern := range config.Metrics {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, NewConfigurationError("routing", "build", fmt.Sprintf("invalid metric pattern %q for analyzer %q", pattern, config.Analyzer))
+			}
+		}
+		if config.Labels != "" {
+			matchers, err := ParseMatchers(config.Labels)
+			if err != nil {
+				return nil, WrapError(err, ErrorTypeConfiguration, "routing", "build", fmt.Sprintf("invalid label selector for analyzer %q", config.Analyzer))
+			}
+			route.matchers = matchers
+		}
+		routes[config.Analyzer] = route
+	}
+	return routes, nil
+}
+
+// filter returns the data points routed to the analyzer
+func (r analyzerRoutes) filter(analyzer string, data []DataPoint) []DataPoint {
+	route, ok := r[analyzer]
+	if !ok {
+		return data
+	}
+	routed := make([]DataPoint, 0, len(data))
+	for _, point := range data {
+		if route.matches(point) {
+			routed = append(routed, point)
+		}
+	}
+	return routed
+}
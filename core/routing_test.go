@@ -0,0 +1,70 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingAnalyzer struct {
+	MockAnalyzer
+	seen []string
+}
+
+func (r *recordingAnalyzer) Analyze(data []DataPoint) (*Analysis, error) {
+	for _, point := range data {
+		r.seen = append(r.seen, point.Metric)
+	}
+	return nil, nil
+}
+
+func TestNewAnalyzerRoutes(t *testing.T) {
+	_, err := newAnalyzerRoutes([]AnalyzerRouteConfig{{Analyzer: "anomaly", Metrics: []string{"cpu_[", "memory_*"}}})
+	assert.Error(t, err, "Expected an invalid metric pattern to be rejected")
+
+	_, err = newAnalyzerRoutes([]AnalyzerRouteConfig{{Analyzer: "anomaly", Labels: `env=prod`}})
+	assert.Error(t, err, "Expected an invalid label selector to be rejected")
+
+	_, err = newAnalyzerRoutes([]AnalyzerRouteConfig{
+		{Analyzer: "anomaly", Metrics: []string{"cpu_*"}},
+		{Analyzer: "anomaly", Metrics: []string{"memory_*"}},
+	})
+	assert.Error(t, err, "Expected a second route for the same analyzer to be rejected")
+}
+
+func TestFramework_AnalyzerRouting(t *testing.T) {
+	config := &FrameworkConfig{
+		LogLevel:  "info",
+		LogFormat: "text",
+		LogOutput: "stdout",
+		AnalyzerRoutes: []AnalyzerRouteConfig{
+			{Analyzer: "anomaly", Metrics: []string{"cpu_*", "memory_*"}},
+			{Analyzer: "slo", Metrics: []string{"http_requests_total"}, Labels: `{env="prod"}`},
+		},
+	}
+	framework := NewFramework(config)
+
+	anomaly := &recordingAnalyzer{MockAnalyzer: MockAnalyzer{MockPlugin{name: "anomaly", pluginType: PluginTypeAnalyzer}}}
+	slo := &recordingAnalyzer{MockAnalyzer: MockAnalyzer{MockPlugin{name: "slo", pluginType: PluginTypeAnalyzer}}}
+	unrouted := &recordingAnalyzer{MockAnalyzer: MockAnalyzer{MockPlugin{name: "unrouted", pluginType: PluginTypeAnalyzer}}}
+	for _, plugin := range []Plugin{anomaly, slo, unrouted} {
+		require.NoError(t, framework.LoadPlugin(plugin))
+	}
+
+	framework.processData(context.Background(), []DataPoint{
+		{Metric: "cpu_usage", Value: 50},
+		{Metric: "memory_used", Value: 1024},
+		{Metric: "http_requests_total", Value: 10, Labels: map[string]string{"env": "prod"}},
+		{Metric: "http_requests_total", Value: 3, Labels: map[string]string{"env": "staging"}},
+		{Metric: "disk_free", Value: 7},
+	})
+
+	assert.Equal(t, []string{"cpu_usage", "memory_used"}, anomaly.seen)
+	assert.Equal(t, []string{"http_requests_total"}, slo.seen)
+	assert.Len(t, unrouted.seen, 5, "Expected analyzers without a route to see every data point")
+
+	framework.processData(context.Background(), []DataPoint{{Metric: "disk_free", Value: 6}})
+	assert.Len(t, anomaly.seen, 2, "Expected an analyzer to be skipped when nothing matches its route")
+}
@@ -0,0 +1,443 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ruleSample is one labelled value of a recording rule expression
+type ruleSample struct {
+	labels map[string]string
+	value  float64
+}
+
+// ruleValue is what a recording rule expression evaluates to: a number, or a sample for
+// each matching series
+type ruleValue struct {
+	scalar  bool
+	value   float64
+	samples []ruleSample
+}
+
+// ruleExpr is a parsed recording rule expression
+type ruleExpr interface {
+	eval(store *recordingStore, now time.Time) ruleValue
+	// walk calls fn with every selector of the expression
+	walk(fn func(*selectorExpr))
+}
+
+// numberExpr is a number literal
+type numberExpr struct {
+	value float64
+}
+
+func (e *numberExpr) eval(*recordingStore, time.Time) ruleValue {
+	return ruleValue{scalar: true, value: e.value}
+}
+
+func (e *numberExpr) walk(func(*selectorExpr)) {}
+
+// selectorExpr selects the series of a metric matching label matchers: their latest
+// values, or with a function the rate or increase over a range
+type selectorExpr struct {
+	metric   string
+	matchers []LabelMatcher
+	function string
+	rng      time.Duration
+}
+
+func (e *selectorExpr) eval(store *recordingStore, now time.Time) ruleValue {
+	return ruleValue{samples: store.selectSamples(e, now)}
+}
+
+func (e *selectorExpr) walk(fn func(*selectorExpr)) {
+	fn(e)
+}
+
+// aggregateExpr aggregates the samples of an expression into one per group of labels
+type aggregateExpr struct {
+	op      string
+	labels  []string
+	without bool
+	expr    ruleExpr
+}
+
+func (e *aggregateExpr) eval(store *recordingStore, now time.Time) ruleValue {
+	input := e.expr.eval(store, now)
+	if input.scalar {
+		input.samples = []ruleSample{{labels: map[string]string{}, value: input.value}}
+	}
+
+	grouping := make(map[string]bool, len(e.labels))
+	for _, label := range e.labels {
+		grouping[label] = true
+	}
+	type group struct {
+		sample ruleSample
+		count  int
+	}
+	groups := make(map[string]*group)
+	var order []string
+	for _, sample := range input.samples {
+		labels := make(map[string]string)
+		for name, value := range sample.labels {
+			if grouping[name] != e.without {
+				labels[name] = value
+			}
+		}
+		key := labelsKey(labels)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{sample: ruleSample{labels: labels, value: sample.value}}
+			groups[key] = g
+			order = append(order, key)
+		} else {
+			switch e.op {
+			case "sum", "avg":
+				g.sample.value += sample.value
+			case "min":
+				g.sample.value = math.Min(g.sample.value, sample.value)
+			case "max":
+				g.sample.value = math.Max(g.sample.value, sample.value)
+			}
+		}
+		g.count++
+	}
+
+	result := ruleValue{samples: make([]ruleSample, 0, len(order))}
+	for _, key := range order {
+		g := groups[key]
+		switch e.op {
+		case "avg":
+			g.sample.value /= float64(g.count)
+		case "count":
+			g.sample.value = float64(g.count)
+		}
+		result.samples = append(result.samples, g.sample)
+	}
+	return result
+}
+
+func (e *aggregateExpr) walk(fn func(*selectorExpr)) {
+	e.expr.walk(fn)
+}
+
+// binaryExpr combines two expressions. Samples of two vectors are matched by their
+// labels and keep the left side's; a number applies to every sample.
+type binaryExpr struct {
+	op          byte
+	left, right ruleExpr
+}
+
+func (e *binaryExpr) eval(store *recordingStore, now time.Time) ruleValue {
+	left, right := e.left.eval(store, now), e.right.eval(store, now)
+	switch {
+	case left.scalar && right.scalar:
+		return ruleValue{scalar: true, value: applyRuleOp(e.op, left.value, right.value)}
+	case right.scalar:
+		result := ruleValue{samples: make([]ruleSample, 0, len(left.samples))}
+		for _, sample := range left.samples {
+			result.samples = append(result.samples, ruleSample{labels: sample.labels, value: applyRuleOp(e.op, sample.value, right.value)})
+		}
+		return result
+	case left.scalar:
+		result := ruleValue{samples: make([]ruleSample, 0, len(right.samples))}
+		for _, sample := range right.samples {
+			result.samples = append(result.samples, ruleSample{labels: sample.labels, value: applyRuleOp(e.op, left.value, sample.value)})
+		}
+		return result
+	}
+
+	rights := make(map[string]float64, len(right.samples))
+	for _, sample := range right.samples {
+		rights[labelsKey(sample.labels)] = sample.value
+	}
+	result := ruleValue{samples: make([]ruleSample, 0, len(left.samples))}
+	for _, sample := range left.samples {
+		if value, ok := rights[labelsKey(sample.labels)]; ok {
+			result.samples = append(result.samples, ruleSample{labels: sample.labels, value: applyRuleOp(e.op, sample.value, value)})
+		}
+	}
+	return result
+}
+
+func (e *binaryExpr) walk(fn func(*selectorExpr)) {
+	e.left.walk(fn)
+	e.right.walk(fn)
+}
+
+// applyRuleOp applies an arithmetic operator
+func applyRuleOp(op byte, left, right float64) float64 {
+	switch op {
+	case '+':
+		return left + right
+	case '-':
+		return left - right
+	case '*':
+		return left * right
+	default:
+		return left / right
+	}
+}
+
+// ruleParser parses recording rule expressions by recursive descent
+type ruleParser struct {
+	input string
+	pos   int
+}
+
+// parseRuleExpr parses a recording rule expression
+func parseRuleExpr(input string) (ruleExpr, error) {
+	p := &ruleParser{input: input}
+	expr, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.input[p.pos:], p.pos)
+	}
+	return expr, nil
+}
+
+// parseSum parses terms joined by + and -
+func (p *ruleParser) parseSum() (ruleExpr, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for p.skipSpace(); p.pos < len(p.input) && (p.input[p.pos] == '+' || p.input[p.pos] == '-'); p.skipSpace() {
+		op := p.input[p.pos]
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseProduct parses factors joined by * and /
+func (p *ruleParser) parseProduct() (ruleExpr, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for p.skipSpace(); p.pos < len(p.input) && (p.input[p.pos] == '*' || p.input[p.pos] == '/'); p.skipSpace() {
+		op := p.input[p.pos]
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseFactor parses a number, a parenthesized expression, a negation, an aggregation, a
+// function of a range selector, or a selector
+func (p *ruleParser) parseFactor() (ruleExpr, error) {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	switch c := p.input[p.pos]; {
+	case c == '(':
+		p.pos++
+		expr, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expect(')')
+	case c == '-':
+		p.pos++
+		expr, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryExpr{op: '*', left: &numberExpr{value: -1}, right: expr}, nil
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] >= '0' && p.input[p.pos] <= '9' || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		value, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", p.input[start:p.pos])
+		}
+		return &numberExpr{value: value}, nil
+	}
+
+	name := p.ident()
+	if name == "" {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.input[p.pos:], p.pos)
+	}
+	switch name {
+	case "sum", "avg", "min", "max", "count":
+		return p.parseAggregation(name)
+	case "rate", "increase":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		selector, err := p.parseSelector(p.ident())
+		if err != nil {
+			return nil, err
+		}
+		if selector.rng <= 0 {
+			return nil, fmt.Errorf("%s needs a range such as %s[5m]", name, selector.metric)
+		}
+		selector.function = name
+		return selector, p.expect(')')
+	}
+	selector, err := p.parseSelector(name)
+	if err != nil {
+		return nil, err
+	}
+	if selector.rng > 0 {
+		return nil, fmt.Errorf("range of %s needs rate or increase", name)
+	}
+	return selector, nil
+}
+
+// parseAggregation parses the grouping and argument of an aggregation; the by or without
+// clause may come before or after the argument
+func (p *ruleParser) parseAggregation(op string) (ruleExpr, error) {
+	aggregate := &aggregateExpr{op: op}
+	grouped, err := p.parseGrouping(aggregate)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	if aggregate.expr, err = p.parseSum(); err != nil {
+		return nil, err
+	}
+	if err := p.expect(')'); err != nil {
+		return nil, err
+	}
+	if !grouped {
+		if _, err := p.parseGrouping(aggregate); err != nil {
+			return nil, err
+		}
+	}
+	return aggregate, nil
+}
+
+// parseGrouping parses an optional by (...) or without (...) clause
+func (p *ruleParser) parseGrouping(aggregate *aggregateExpr) (bool, error) {
+	start := p.pos
+	keyword := p.ident()
+	if keyword != "by" && keyword != "without" {
+		p.pos = start
+		return false, nil
+	}
+	aggregate.without = keyword == "without"
+	if err := p.expect('('); err != nil {
+		return false, err
+	}
+	for {
+		p.skipSpace()
+		if p.pos < len(p.input) && p.input[p.pos] == ')' {
+			p.pos++
+			return true, nil
+		}
+		label := p.ident()
+		if label == "" {
+			return false, fmt.Errorf("expected a label name in %s clause at position %d", keyword, p.pos)
+		}
+		aggregate.labels = append(aggregate.labels, label)
+		p.skipSpace()
+		if p.pos < len(p.input) && p.input[p.pos] == ',' {
+			p.pos++
+		}
+	}
+}
+
+// parseSelector parses the label matchers and range following a metric name
+func (p *ruleParser) parseSelector(metric string) (*selectorExpr, error) {
+	if metric == "" {
+		return nil, fmt.Errorf("expected a metric name at position %d", p.pos)
+	}
+	selector := &selectorExpr{metric: metric}
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == '{' {
+		end := p.closing('}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed label matchers of %s", metric)
+		}
+		matchers, err := ParseMatchers(p.input[p.pos : end+1])
+		if err != nil {
+			return nil, err
+		}
+		selector.matchers = matchers
+		p.pos = end + 1
+		p.skipSpace()
+	}
+	if p.pos < len(p.input) && p.input[p.pos] == '[' {
+		end := strings.IndexByte(p.input[p.pos:], ']')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed range of %s", metric)
+		}
+		rng, err := time.ParseDuration(strings.TrimSpace(p.input[p.pos+1 : p.pos+end]))
+		if err != nil || rng <= 0 {
+			return nil, fmt.Errorf("invalid range of %s: %q", metric, p.input[p.pos+1:p.pos+end])
+		}
+		selector.rng = rng
+		p.pos += end + 1
+	}
+	return selector, nil
+}
+
+// closing returns the index of the delimiter closing the one at the current position,
+// skipping quoted strings, or -1
+func (p *ruleParser) closing(delimiter byte) int {
+	quoted := false
+	for i := p.pos + 1; i < len(p.input); i++ {
+		switch c := p.input[i]; {
+		case quoted && c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case !quoted && c == delimiter:
+			return i
+		}
+	}
+	return -1
+}
+
+// ident reads a metric, label, or function name
+func (p *ruleParser) ident() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		if c == '_' || c == ':' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || (p.pos > start && c >= '0' && c <= '9') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.input[start:p.pos]
+}
+
+// expect consumes the given character
+func (p *ruleParser) expect(c byte) error {
+	p.skipSpace()
+	if p.pos >= len(p.input) || p.input[p.pos] != c {
+		return fmt.Errorf("expected %q at position %d", c, p.pos)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *ruleParser) skipSpace() {
+	for p.pos < len(p.input) && strings.ContainsRune(" \t\n\r", rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
@@ -0,0 +1,322 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"runtime"
+	"runtime/metrics"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// budgetWindow is the period plugin budgets apply to
+const budgetWindow = time.Minute
+
+// goroutineSampleAge is how long a count of plugin goroutines is reused, since counting
+// them takes a goroutine profile
+const goroutineSampleAge = time.Second
+
+// pluginLabel is the profiler label plugin calls, and the goroutines they start, carry
+const pluginLabel = "plugin"
+
+// PluginUsage is the resources a plugin's calls have used since the framework was created
+type PluginUsage struct {
+	Plugin     string        `json:"plugin"`
+	Calls      int64         `json:"calls"`
+	CPUTime    time.Duration `json:"cpu_time"`
+	WallTime   time.Duration `json:"wall_time"`
+	AllocBytes uint64        `json:"alloc_bytes"`
+	// Skipped counts the calls not made because the plugin was over its budget
+	Skipped   int64 `json:"skipped"`
+	Throttled bool  `json:"throttled"`
+	// Errors counts the calls that returned an error
+	Errors       int64         `json:"errors"`
+	ErrorRate    float64       `json:"error_rate"`
+	LastDuration time.Duration `json:"last_duration"`
+	LastSuccess  *time.Time    `json:"last_success,omitempty"`
+	LastError    *time.Time    `json:"last_error,omitempty"`
+	// Goroutines counts the goroutines running the plugin's code: its calls in progress and
+	// the goroutines they or its Start started, which outlive an unloaded plugin if it leaks them
+	Goroutines int `json:"goroutines"`
+}
+
+// pluginAccount is the usage of one plugin, overall and in the current budget window
+type pluginAccount struct {
+	usage          PluginUsage
+	windowStart    time.Time
+	windowCPU      time.Duration
+	windowAlloc    uint64
+	overBudget     bool
+	throttledUntil time.Time
+}
+
+// pluginSandbox measures the CPU time and allocations of plugin calls and holds back
+// plugins that exceed their budgets.
+//
+// CPU time is that of the OS thread the call runs on, which the call has to itself; work a
+// plugin hands to goroutines of its own is not counted. Allocations are those of the whole
+// process during the call, so they overstate a plugin's share while others run alongside
+// it. Both are meant to find the plugin that misbehaves, not to bill it precisely.
+type pluginSandbox struct {
+	budgets  map[string]PluginBudgetConfig
+	accounts map[string]*pluginAccount
+	metrics  MetricsCollector
+	now      func() time.Time
+	mu       sync.Mutex
+
+	// goroutineCounts is the last count of plugin goroutines, taken at goroutinesSampled
+	goroutineCounts   map[string]int
+	goroutinesSampled time.Time
+}
+
+// newPluginSandbox creates a sandbox enforcing the given budgets
+func newPluginSandbox(budgets []PluginBudgetConfig, metricsCollector MetricsCollector) *pluginSandbox {
+	byPlugin := make(map[string]PluginBudgetConfig, len(budgets))
+	for _, budget := range budgets {
+		byPlugin[budget.Plugin] = budget
+	}
+	return &pluginSandbox{
+		budgets:  byPlugin,
+		accounts: make(map[string]*pluginAccount),
+		metrics:  metricsCollector,
+		now:      time.Now,
+	}
+}
+
+// budget returns the budget of a plugin, falling back to the * budget
+func (s *pluginSandbox) budget(plugin string) (PluginBudgetConfig, bool) {
+	if budget, ok := s.budgets[plugin]; ok {
+		return budget, true
+	}
+	budget, ok := s.budgets["*"]
+	return budget, ok
+}
+
+// account returns the account of a plugin, starting a new budget window when the last one
+// is over; callers must hold s.mu
+func (s *pluginSandbox) account(plugin string, now time.Time) *pluginAccount {
+	account, ok := s.accounts[plugin]
+	if !ok {
+		account = &pluginAccount{usage: PluginUsage{Plugin: plugin}, windowStart: now}
+		s.accounts[plugin] = account
+	}
+	if now.Sub(account.windowStart) >= budgetWindow {
+		account.windowStart = now
+		account.windowCPU = 0
+		account.windowAlloc = 0
+		account.overBudget = false
+	}
+	return account
+}
+
+// allow reports whether a plugin may be called, counting the call as skipped if not
+func (s *pluginSandbox) allow(plugin string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	account := s.account(plugin, now)
+	if account.throttledUntil.IsZero() {
+		return true
+	}
+	if !now.Before(account.throttledUntil) {
+		account.throttledUntil = time.Time{}
+		account.usage.Throttled = false
+		s.metrics.SetGauge("framework_plugin_throttled", 0, map[string]string{"plugin": plugin})
+		slog.Info("Plugin no longer throttled", "plugin", plugin)
+		return true
+	}
+	account.usage.Skipped++
+	s.metrics.IncrementCounter("framework_plugin_skipped_calls_total", map[string]string{"plugin": plugin})
+	return false
+}
+
+// run calls a plugin and records what the call used and whether it failed. The call runs
+// under the plugin's profiler label, which goroutines it starts inherit.
+func (s *pluginSandbox) run(plugin string, call func() error) {
+	// The goroutine keeps its thread for the call, so the thread's CPU time is the call's
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var err error
+	startCPU := threadCPUTime()
+	startAlloc := heapAllocBytes()
+	start := time.Now()
+	pprof.Do(context.Background(), pprof.Labels(pluginLabel, plugin), func(context.Context) { err = call() })
+	wall := time.Since(start)
+	cpu := threadCPUTime() - startCPU
+	alloc := heapAllocBytes() - startAlloc
+
+	s.record(plugin, cpu, wall, alloc, err)
+}
+
+// record adds a call's usage to a plugin's account, throttling the plugin if it went over
+// its budget
+func (s *pluginSandbox) record(plugin string, cpu, wall time.Duration, alloc uint64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	labels := map[string]string{"plugin": plugin}
+	s.metrics.IncrementCounter("framework_plugin_calls_total", labels)
+	s.metrics.AddCounter("framework_plugin_cpu_seconds_total", cpu.Seconds(), labels)
+	s.metrics.AddCounter("framework_plugin_alloc_bytes_total", float64(alloc), labels)
+	s.metrics.ObserveHistogram("framework_plugin_call_duration_seconds", wall.Seconds(), labels)
+	if err != nil {
+		s.metrics.IncrementCounter("framework_plugin_errors_total", labels)
+	} else {
+		s.metrics.SetGauge("framework_plugin_last_success_timestamp_seconds", float64(now.Unix()), labels)
+	}
+
+	account := s.account(plugin, now)
+	account.usage.Calls++
+	account.usage.CPUTime += cpu
+	account.usage.WallTime += wall
+	account.usage.AllocBytes += alloc
+	account.usage.LastDuration = wall
+	if err != nil {
+		account.usage.Errors++
+		account.usage.LastError = &now
+	} else {
+		account.usage.LastSuccess = &now
+	}
+	account.usage.ErrorRate = float64(account.usage.Errors) / float64(account.usage.Calls)
+	account.windowCPU += cpu
+	account.windowAlloc += alloc
+
+	budget, ok := s.budget(plugin)
+	if !ok || account.overBudget {
+		return
+	}
+	overCPU := budget.CPUPerMinute > 0 && account.windowCPU > budget.CPUPerMinute
+	overAlloc := budget.AllocBytesPerMinute > 0 && account.windowAlloc > uint64(budget.AllocBytesPerMinute)
+	if !overCPU && !overAlloc {
+		return
+	}
+
+	account.overBudget = true
+	s.metrics.IncrementCounter("framework_plugin_budget_exceeded_total", labels)
+	if !budget.Throttle {
+		slog.Warn("Plugin exceeded its resource budget", "plugin", plugin, "cpu", account.windowCPU,
+			"alloc_bytes", account.windowAlloc, "cpu_budget", budget.CPUPerMinute, "alloc_budget", budget.AllocBytesPerMinute)
+		return
+	}
+	account.throttledUntil = account.windowStart.Add(budgetWindow)
+	account.usage.Throttled = true
+	s.metrics.SetGauge("framework_plugin_throttled", 1, labels)
+	slog.Warn("Plugin exceeded its resource budget, throttling it", "plugin", plugin, "until", account.throttledUntil,
+		"cpu", account.windowCPU, "alloc_bytes", account.windowAlloc, "cpu_budget", budget.CPUPerMinute, "alloc_budget", budget.AllocBytesPerMinute)
+}
+
+// forget drops the account of an unloaded plugin
+func (s *pluginSandbox) forget(plugin string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.accounts, plugin)
+}
+
+// usage returns the usage of every plugin called so far, sorted by plugin name
+func (s *pluginSandbox) usage() []PluginUsage {
+	goroutines := s.goroutines()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	usage := make([]PluginUsage, 0, len(s.accounts))
+	for _, account := range s.accounts {
+		entry := account.usage
+		entry.Goroutines = goroutines[entry.Plugin]
+		usage = append(usage, entry)
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Plugin < usage[j].Plugin })
+	return usage
+}
+
+// pluginUsage returns the usage of one plugin, if it has been called or runs goroutines
+func (s *pluginSandbox) pluginUsage(plugin string) (PluginUsage, bool) {
+	goroutines := s.goroutines()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accounts[plugin]
+	if !ok {
+		return PluginUsage{Plugin: plugin, Goroutines: goroutines[plugin]}, goroutines[plugin] > 0
+	}
+	usage := account.usage
+	usage.Goroutines = goroutines[plugin]
+	return usage, true
+}
+
+// goroutines returns the number of goroutines labeled with each plugin, counting them again
+// when the last count is older than goroutineSampleAge
+func (s *pluginSandbox) goroutines() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.goroutineCounts == nil || now.Sub(s.goroutinesSampled) >= goroutineSampleAge {
+		s.goroutineCounts = countPluginGoroutines()
+		s.goroutinesSampled = now
+	}
+	return s.goroutineCounts
+}
+
+// PluginUsage returns the CPU time, allocations, calls, errors, and goroutines of every
+// plugin called so far
+func (f *Framework) PluginUsage() []PluginUsage {
+	return f.sandbox.usage()
+}
+
+// countPluginGoroutines counts the goroutines carrying each plugin's profiler label, from
+// the text form of the goroutine profile, whose stacks are followed by lines such as
+//
+//	# labels: {"plugin":"metrics"}
+func countPluginGoroutines() map[string]int {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		slog.Debug("Failed to take goroutine profile", "error", err)
+		return map[string]int{}
+	}
+
+	counts := make(map[string]int)
+	stackCount := 0
+	scanner := bufio.NewScanner(&buf)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if count, _, ok := strings.Cut(line, " @ "); ok {
+			stackCount, _ = strconv.Atoi(count)
+			continue
+		}
+		encoded, ok := strings.CutPrefix(line, "# labels: ")
+		if !ok {
+			continue
+		}
+		var labels map[string]string
+		if err := json.Unmarshal([]byte(encoded), &labels); err != nil {
+			continue
+		}
+		if plugin := labels[pluginLabel]; plugin != "" {
+			counts[plugin] += stackCount
+		}
+	}
+	return counts
+}
+
+// heapAllocBytes returns the bytes allocated on the heap since the process started
+func heapAllocBytes() uint64 {
+	sample := []metrics.Sample{{Name: "/gc/heap/allocs:bytes"}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginSandbox_Run(t *testing.T) {
+	sandbox := newPluginSandbox(nil, NewPrometheusMetricsCollector())
+
+	var sink [][]byte
+	sandbox.run("busy", func() error {
+		deadline := time.Now().Add(20 * time.Millisecond)
+		for time.Now().Before(deadline) {
+			sink = append(sink, make([]byte, 64<<10))
+		}
+		return nil
+	})
+	require.NotEmpty(t, sink)
+
+	usage := sandbox.usage()
+	require.Len(t, usage, 1)
+	assert.Equal(t, "busy", usage[0].Plugin)
+	assert.Equal(t, int64(1), usage[0].Calls)
+	assert.GreaterOrEqual(t, usage[0].WallTime, 20*time.Millisecond)
+	assert.GreaterOrEqual(t, usage[0].AllocBytes, uint64(64<<10))
+	if runtime.GOOS == "linux" {
+		assert.Greater(t, usage[0].CPUTime, time.Duration(0), "Expected the call's CPU time to be measured")
+	}
+}
+
+func TestPluginSandbox_RecordsErrorsAndGoroutines(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	sandbox := newPluginSandbox(nil, NewPrometheusMetricsCollector())
+	sandbox.now = func() time.Time { return now }
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	defer func() {
+		close(release)
+		<-stopped
+	}()
+	sandbox.run("leaky", func() error {
+		go func() {
+			defer close(stopped)
+			close(started)
+			<-release
+		}()
+		return nil
+	})
+	<-started
+	now = now.Add(time.Minute)
+	sandbox.run("leaky", func() error { return fmt.Errorf("connection refused") })
+	sandbox.run("leaky", func() error { return fmt.Errorf("connection refused") })
+
+	usage, ok := sandbox.pluginUsage("leaky")
+	require.True(t, ok)
+	assert.Equal(t, int64(3), usage.Calls)
+	assert.Equal(t, int64(2), usage.Errors)
+	assert.InDelta(t, 2.0/3, usage.ErrorRate, 0.001)
+	require.NotNil(t, usage.LastSuccess)
+	require.NotNil(t, usage.LastError)
+	assert.Equal(t, now.Add(-time.Minute), *usage.LastSuccess)
+	assert.Equal(t, now, *usage.LastError)
+	assert.Equal(t, 1, usage.Goroutines, "Expected the goroutine the call started to be counted")
+
+	_, ok = sandbox.pluginUsage("idle")
+	assert.False(t, ok)
+}
+
+func TestPluginSandbox_Budgets(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	sandbox := newPluginSandbox([]PluginBudgetConfig{
+		{Plugin: "*", AllocBytesPerMinute: 1 << 20, Throttle: true},
+		{Plugin: "watched", CPUPerMinute: time.Second},
+	}, NewPrometheusMetricsCollector())
+	sandbox.now = func() time.Time { return now }
+
+	sandbox.record("spiky", 0, 0, 512<<10, nil)
+	assert.True(t, sandbox.allow("spiky"), "Expected a plugin within budget to run")
+	sandbox.record("spiky", 0, 0, 1<<20, nil)
+	assert.False(t, sandbox.allow("spiky"), "Expected a plugin over the default budget to be throttled")
+	assert.False(t, sandbox.allow("spiky"))
+
+	sandbox.record("watched", 2*time.Second, 0, 0, nil)
+	sandbox.record("watched", 0, 0, 2<<20, nil)
+	assert.True(t, sandbox.allow("watched"), "Expected a budget without throttle to only report the plugin")
+
+	usage := sandbox.usage()
+	require.Len(t, usage, 2)
+	assert.Equal(t, int64(2), usage[0].Skipped)
+	assert.True(t, usage[0].Throttled)
+
+	now = now.Add(budgetWindow)
+	assert.True(t, sandbox.allow("spiky"), "Expected the throttle to lift when the budget window ends")
+	assert.False(t, sandbox.usage()[0].Throttled)
+}
+
+func TestFramework_ThrottlesAnalyzerOverBudget(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{
+		LogLevel:      "info",
+		LogFormat:     "text",
+		LogOutput:     "stdout",
+		PluginBudgets: []PluginBudgetConfig{{Plugin: "greedy", AllocBytesPerMinute: 1, Throttle: true}},
+	})
+	analyzer := &MockAnalyzer{MockPlugin: MockPlugin{name: "greedy", pluginType: PluginTypeAnalyzer}}
+	require.NoError(t, framework.LoadPlugin(analyzer))
+
+	data := []DataPoint{{Metric: "cpu", Value: 1, Timestamp: time.Now()}}
+	framework.runAnalyzer(context.Background(), analyzer, data, nil)
+	framework.sandbox.record("greedy", 0, 0, 2, nil)
+	framework.runAnalyzer(context.Background(), analyzer, data, nil)
+
+	usage := framework.PluginUsage()
+	require.Len(t, usage, 1)
+	assert.Equal(t, int64(2), usage[0].Calls)
+	assert.Equal(t, int64(1), usage[0].Skipped, "Expected the batch after the budget was exceeded to be skipped")
+	assert.True(t, usage[0].Throttled)
+
+	var buf bytes.Buffer
+	require.NoError(t, framework.WriteMetrics(&buf))
+	assert.Contains(t, buf.String(), `framework_plugin_cpu_seconds_total{plugin="greedy"}`)
+	assert.Contains(t, buf.String(), `framework_plugin_throttled{plugin="greedy"} 1`)
+	assert.Contains(t, buf.String(), `framework_plugin_skipped_calls_total{plugin="greedy"} 1`)
+	assert.Contains(t, buf.String(), `framework_plugin_call_duration_seconds_count{plugin="greedy"} 2`)
+	assert.Contains(t, buf.String(), `framework_plugin_last_success_timestamp_seconds{plugin="greedy"}`)
+	assert.Equal(t, int64(2), framework.Status().Plugins["greedy"].Usage.Calls)
+}
@@ -0,0 +1,295 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultSecretsTimeout bounds a request to a secrets backend when none is configured
+const defaultSecretsTimeout = 10 * time.Second
+
+// minRedactedSecretLength is the shortest resolved value redacted from output; shorter
+// values would mangle unrelated text
+const minRedactedSecretLength = 4
+
+// SecretBackend resolves the references of one scheme, such as ${vault:...}, to secret values
+type SecretBackend interface {
+	// Scheme is the prefix of the references the backend resolves, such as vault
+	Scheme() string
+	// Resolve returns the value a reference points to; the reference excludes the scheme
+	Resolve(ctx context.Context, reference string) (string, error)
+}
+
+// SecretReference is a ${scheme:reference} found in a setting value
+type SecretReference struct {
+	Scheme    string
+	Reference string
+}
+
+// String formats the reference as it is written in settings
+func (r SecretReference) String() string {
+	return "${" + r.Scheme + ":" + r.Reference + "}"
+}
+
+// IsSecretScheme reports whether a ${...} body starting with name: is a secret reference
+// rather than an environment variable with a :- default. Schemes are lowercase words.
+func IsSecretScheme(body string) bool {
+	scheme, rest, ok := strings.Cut(body, ":")
+	if !ok || scheme == "" || strings.HasPrefix(rest, "-") {
+		return false
+	}
+	for _, r := range scheme {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// secretResolver replaces secret references in plugin settings using the registered backends
+type secretResolver struct {
+	ttl     time.Duration
+	timeout time.Duration
+
+	mu       sync.Mutex
+	backends map[string]SecretBackend
+	cache    map[SecretReference]cachedSecret
+	// resolved holds every value handed to a plugin, so output can be redacted
+	resolved map[string]bool
+}
+
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+// newSecretResolver creates a resolver with the env and file schemes and, when configured,
+// the vault and aws schemes
+func newSecretResolver(config SecretsConfig) *secretResolver {
+	r := &secretResolver{
+		ttl:      config.CacheTTL,
+		timeout:  config.Timeout,
+		backends: make(map[string]SecretBackend),
+		cache:    make(map[SecretReference]cachedSecret),
+		resolved: make(map[string]bool),
+	}
+	if r.timeout <= 0 {
+		r.timeout = defaultSecretsTimeout
+	}
+	r.register(envSecretBackend{})
+	r.register(fileSecretBackend{})
+	if config.Vault != nil {
+		r.register(newVaultSecretBackend(*config.Vault, r.timeout))
+	}
+	if config.AWS != nil {
+		r.register(newAWSSecretBackend(*config.AWS, r.timeout))
+	}
+	return r
+}
+
+// register adds a backend, replacing any with the same scheme
+func (r *secretResolver) register(backend SecretBackend) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.backends[backend.Scheme()] = backend
+}
+
+// RegisterSecretBackend adds a backend for ${scheme:...} references in plugin settings,
+// replacing any backend with the same scheme. Plugins loaded afterwards can use it.
+func (f *Framework) RegisterSecretBackend(backend SecretBackend) {
+	f.secrets.register(backend)
+}
+
+// resolvePluginSecrets returns a copy of the plugin configuration with its secret
+// references replaced by their values. The original keeps the references, so the settings
+// recorded for the API never contain the secrets themselves.
+func (f *Framework) resolvePluginSecrets(ctx context.Context, config PluginConfig) (PluginConfig, error) {
+	settings, ok := config.Config.(map[string]interface{})
+	if !ok {
+		return config, nil
+	}
+	resolved, err := f.secrets.resolveSettings(ctx, settings)
+	if err != nil {
+		return config, WrapError(err, ErrorTypeConfiguration, "framework", "resolve-secrets",
+			fmt.Sprintf("failed to resolve secrets for plugin %s", config.Name))
+	}
+	config.Config = resolved
+	return config, nil
+}
+
+// resolveSettings copies settings, resolving references in string values at any depth
+func (r *secretResolver) resolveSettings(ctx context.Context, settings map[string]interface{}) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		result, err := r.resolveValue(ctx, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		resolved[key] = result
+	}
+	return resolved, nil
+}
+
+func (r *secretResolver) resolveValue(ctx context.Context, value interface{}) (interface{}, error) {
+	switch typed := value.(type) {
+	case string:
+		return r.resolveString(ctx, typed)
+	case map[string]interface{}:
+		return r.resolveSettings(ctx, typed)
+	case []interface{}:
+		resolved := make([]interface{}, len(typed))
+		for i, item := range typed {
+			result, err := r.resolveValue(ctx, item)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			resolved[i] = result
+		}
+		return resolved, nil
+	default:
+		return value, nil
+	}
+}
+
+// resolveString replaces the references in one value; $${ stands for a literal ${
+func (r *secretResolver) resolveString(ctx context.Context, value string) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+
+	var out strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			out.WriteString(value)
+			return out.String(), nil
+		}
+		end := strings.IndexByte(value[start:], '}')
+		if end < 0 || !IsSecretScheme(value[start+2:start+end]) {
+			// Not a secret reference; keep it as written
+			out.WriteString(value[:start+2])
+			value = value[start+2:]
+			continue
+		}
+		if start > 0 && value[start-1] == '$' {
+			out.WriteString(value[:start-1] + value[start:start+end+1])
+			value = value[start+end+1:]
+			continue
+		}
+
+		scheme, reference, _ := strings.Cut(value[start+2:start+end], ":")
+		secret, err := r.resolve(ctx, SecretReference{Scheme: scheme, Reference: reference})
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(value[:start] + secret)
+		value = value[start+end+1:]
+	}
+}
+
+// resolve returns the value of a reference, from the cache while it is fresh
+func (r *secretResolver) resolve(ctx context.Context, ref SecretReference) (string, error) {
+	r.mu.Lock()
+	backend, ok := r.backends[ref.Scheme]
+	cached, hit := r.cache[ref]
+	r.mu.Unlock()
+
+	if !ok {
+		return "", fmt.Errorf("no secrets backend for %s", ref)
+	}
+	if hit && time.Now().Before(cached.expires) {
+		return cached.value, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	value, err := backend.Resolve(ctx, ref.Reference)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ttl > 0 {
+		r.cache[ref] = cachedSecret{value: value, expires: time.Now().Add(r.ttl)}
+	}
+	if len(value) >= minRedactedSecretLength {
+		r.resolved[value] = true
+	}
+	return value, nil
+}
+
+// redact replaces every resolved secret in text, such as an error a plugin built from its
+// settings
+func (r *secretResolver) redact(text string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for value := range r.resolved {
+		text = strings.ReplaceAll(text, value, redactedSetting)
+	}
+	return text
+}
+
+// envSecretBackend resolves ${env:NAME} from the environment when the plugin loads, unlike
+// ${NAME}, which is substituted when the configuration file is read
+type envSecretBackend struct{}
+
+func (envSecretBackend) Scheme() string { return "env" }
+
+func (envSecretBackend) Resolve(ctx context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// fileSecretBackend resolves ${file:/path} to the file's contents without the trailing
+// newline, as written by Kubernetes and Docker secret mounts
+type fileSecretBackend struct{}
+
+func (fileSecretBackend) Scheme() string { return "file" }
+
+func (fileSecretBackend) Resolve(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// splitSecretKey splits a reference into the secret's path and the #key of one of its
+// fields
+func splitSecretKey(reference string) (string, string) {
+	path, key, _ := strings.Cut(reference, "#")
+	return path, key
+}
+
+// secretField picks the field key of a secret with several fields. Without a key, a
+// secret with a single field resolves to it.
+func secretField(fields map[string]interface{}, key string) (string, error) {
+	if key == "" {
+		if len(fields) != 1 {
+			return "", fmt.Errorf("secret has %d fields; name one with #key", len(fields))
+		}
+		for name := range fields {
+			key = name
+		}
+	}
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret has no field %q", key)
+	}
+	switch typed := value.(type) {
+	case string:
+		return typed, nil
+	case nil:
+		return "", nil
+	default:
+		return fmt.Sprint(typed), nil
+	}
+}
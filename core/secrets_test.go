@@ -0,0 +1,202 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretResolver_ResolveSettings(t *testing.T) {
+	t.Setenv("TEST_SECRET_TOKEN", "env-token")
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("file-key\n"), 0o600))
+
+	resolver := newSecretResolver(SecretsConfig{})
+	resolved, err := resolver.resolveSettings(context.Background(), map[string]interface{}{
+		"token":    "${env:TEST_SECRET_TOKEN}",
+		"dsn":      "postgres://agent:${file:" + keyFile + "}@db/agent",
+		"literal":  "$${env:TEST_SECRET_TOKEN} and ${NOT_A_SECRET}",
+		"interval": 30,
+		"backend":  map[string]interface{}{"headers": []interface{}{"Bearer ${env:TEST_SECRET_TOKEN}"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"token":    "env-token",
+		"dsn":      "postgres://agent:file-key@db/agent",
+		"literal":  "${env:TEST_SECRET_TOKEN} and ${NOT_A_SECRET}",
+		"interval": 30,
+		"backend":  map[string]interface{}{"headers": []interface{}{"Bearer env-token"}},
+	}, resolved)
+
+	_, err = resolver.resolveSettings(context.Background(), map[string]interface{}{"token": "${env:TEST_SECRET_UNSET}"})
+	assert.ErrorContains(t, err, "TEST_SECRET_UNSET")
+	_, err = resolver.resolveSettings(context.Background(), map[string]interface{}{"token": "${vault:secret/data/agent#token}"})
+	assert.ErrorContains(t, err, "no secrets backend", "Expected vault references to need a configured backend")
+
+	assert.Equal(t, "login with ******** failed", resolver.redact("login with env-token failed"))
+}
+
+func TestSecretResolver_Cache(t *testing.T) {
+	backend := &countingSecretBackend{}
+	resolver := newSecretResolver(SecretsConfig{CacheTTL: time.Minute})
+	resolver.register(backend)
+
+	for i := 0; i < 3; i++ {
+		value, err := resolver.resolveString(context.Background(), "${count:a}")
+		require.NoError(t, err)
+		assert.Equal(t, "value-1", value)
+	}
+	assert.Equal(t, 1, backend.calls)
+
+	uncached := newSecretResolver(SecretsConfig{})
+	uncached.register(backend)
+	uncached.resolveString(context.Background(), "${count:a}")
+	uncached.resolveString(context.Background(), "${count:a}")
+	assert.Equal(t, 3, backend.calls)
+}
+
+type countingSecretBackend struct {
+	calls int
+}
+
+func (b *countingSecretBackend) Scheme() string { return "count" }
+
+func (b *countingSecretBackend) Resolve(ctx context.Context, reference string) (string, error) {
+	b.calls++
+	return fmt.Sprintf("value-%d", b.calls), nil
+}
+
+func TestVaultSecretBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" || r.Header.Get("X-Vault-Namespace") != "ops" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/agent":
+			writeJSON(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+				"data":     map[string]interface{}{"api_key": "kv2-key", "token": "kv2-token"},
+				"metadata": map[string]interface{}{"version": 3},
+			}})
+		case "/v1/kv/agent":
+			writeJSON(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"password": "kv1-password"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("vault-token\n"), 0o600))
+	backend := newVaultSecretBackend(VaultSecretsConfig{Address: server.URL, TokenFile: tokenFile, Namespace: "ops"}, time.Second)
+	ctx := context.Background()
+
+	value, err := backend.Resolve(ctx, "secret/data/agent#api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "kv2-key", value)
+	value, err = backend.Resolve(ctx, "kv/agent")
+	require.NoError(t, err)
+	assert.Equal(t, "kv1-password", value, "Expected a secret with one field to resolve without a key")
+
+	_, err = backend.Resolve(ctx, "secret/data/agent")
+	assert.ErrorContains(t, err, "#key")
+	_, err = backend.Resolve(ctx, "secret/data/agent#missing")
+	assert.ErrorContains(t, err, "missing")
+	_, err = backend.Resolve(ctx, "secret/data/other#token")
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestAWSSecretBackend(t *testing.T) {
+	var target, authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target = r.Header.Get("X-Amz-Target")
+		authorization = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		var request struct{ SecretId string }
+		json.Unmarshal(body, &request)
+		switch request.SecretId {
+		case "agent/slack":
+			writeJSON(w, http.StatusOK, map[string]string{"SecretString": `{"webhook":"https://hooks.example.com/x"}`})
+		case "agent/token":
+			writeJSON(w, http.StatusOK, map[string]string{"SecretString": "plain-token"})
+		default:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"__type": "ResourceNotFoundException"})
+		}
+	}))
+	defer server.Close()
+
+	backend := newAWSSecretBackend(AWSSecretsConfig{
+		Region: "eu-west-1", AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret", Endpoint: server.URL,
+	}, time.Second)
+	ctx := context.Background()
+
+	value, err := backend.Resolve(ctx, "agent/slack#webhook")
+	require.NoError(t, err)
+	assert.Equal(t, "https://hooks.example.com/x", value)
+	assert.Equal(t, "secretsmanager.GetSecretValue", target)
+	assert.True(t, strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"), authorization)
+	assert.Contains(t, authorization, "/eu-west-1/secretsmanager/aws4_request")
+
+	value, err = backend.Resolve(ctx, "agent/token")
+	require.NoError(t, err)
+	assert.Equal(t, "plain-token", value)
+
+	_, err = backend.Resolve(ctx, "agent/token#field")
+	assert.ErrorContains(t, err, "not a JSON object")
+	_, err = backend.Resolve(ctx, "agent/missing")
+	assert.ErrorContains(t, err, "ResourceNotFoundException")
+}
+
+func TestFramework_PluginSecrets(t *testing.T) {
+	t.Setenv("TEST_SECRET_TOKEN", "s3cr3t-token")
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout"})
+
+	var received map[string]interface{}
+	framework.GetFactory().RegisterPluginCreator("ai", func(config PluginConfig) (Plugin, error) {
+		received = config.Config.(map[string]interface{})
+		return &reconfigurablePlugin{MockPlugin: MockPlugin{name: config.Name, pluginType: PluginTypeAgent}}, nil
+	})
+	framework.GetFactory().RegisterPluginCreator("broken", func(config PluginConfig) (Plugin, error) {
+		settings := config.Config.(map[string]interface{})
+		return &MockStartPlugin{MockPlugin: MockPlugin{name: config.Name, pluginType: PluginTypeCollector},
+			err: fmt.Errorf("login with %s rejected", settings["auth"])}, nil
+	})
+
+	require.NoError(t, framework.LoadPluginFromConfig(PluginConfig{Name: "ai", Type: "ai", Enabled: true, Config: map[string]interface{}{
+		"model": "gpt-4o",
+		"auth":  "${env:TEST_SECRET_TOKEN}",
+	}}))
+	assert.Equal(t, "s3cr3t-token", received["auth"], "Expected the plugin to get the secret's value")
+	settings, err := framework.PluginSettings("ai")
+	require.NoError(t, err)
+	assert.Equal(t, "${env:TEST_SECRET_TOKEN}", settings["auth"], "Expected recorded settings to keep the reference")
+
+	err = framework.LoadPluginFromConfig(PluginConfig{Name: "other", Type: "ai", Enabled: true, Config: map[string]interface{}{
+		"auth": "${env:TEST_SECRET_UNSET}",
+	}})
+	assert.Equal(t, ErrorTypeConfiguration, GetErrorType(err))
+
+	require.NoError(t, framework.LoadPluginFromConfig(PluginConfig{Name: "broken", Type: "broken", Enabled: true, Config: map[string]interface{}{
+		"auth": "${env:TEST_SECRET_TOKEN}",
+	}}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	framework.Start(ctx)
+	defer framework.Stop()
+
+	status, err := json.Marshal(framework.GetStatus())
+	require.NoError(t, err)
+	assert.Contains(t, string(status), "login with ******** rejected")
+	assert.NotContains(t, string(status), "s3cr3t-token")
+}
@@ -0,0 +1,330 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MatchType is how a label matcher compares a label value
+type MatchType string
+
+const (
+	MatchEqual     MatchType = "="
+	MatchNotEqual  MatchType = "!="
+	MatchRegexp    MatchType = "=~"
+	MatchNotRegexp MatchType = "!~"
+)
+
+// maxSuppressedPerSilence bounds how many suppressed analyses each silence remembers
+const maxSuppressedPerSilence = 50
+
+// expiredSilenceRetention is how long expired silences stay listed
+const expiredSilenceRetention = 24 * time.Hour
+
+// LabelMatcher selects series by one label, like an Alertmanager matcher. A missing label
+// matches as the empty string.
+type LabelMatcher struct {
+	Name  string    `json:"name"`
+	Type  MatchType `json:"type"`
+	Value string    `json:"value"`
+	re    *regexp.Regexp
+}
+
+// NewLabelMatcher creates a matcher, compiling regular expressions anchored at both ends
+func NewLabelMatcher(name string, matchType MatchType, value string) (LabelMatcher, error) {
+	m := LabelMatcher{Name: name, Type: matchType, Value: value}
+	switch matchType {
+	case MatchEqual, MatchNotEqual:
+	case MatchRegexp, MatchNotRegexp:
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return m, NewValidationError("silences", "matcher", fmt.Sprintf("invalid regex %q: %v", value, err))
+		}
+		m.re = re
+	default:
+		return m, NewValidationError("silences", "matcher", fmt.Sprintf("unknown match type %q", matchType))
+	}
+	return m, nil
+}
+
+// Matches reports whether the label value satisfies the matcher
+func (m LabelMatcher) Matches(value string) bool {
+	switch m.Type {
+	case MatchEqual:
+		return value == m.Value
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	case MatchNotRegexp:
+		return !m.re.MatchString(value)
+	}
+	return false
+}
+
+// String renders the matcher in selector syntax
+func (m LabelMatcher) String() string {
+	return fmt.Sprintf("%s%s%s", m.Name, m.Type, strconv.Quote(m.Value))
+}
+
+// selectorPattern matches one name<op>"value" term of a selector
+var selectorPattern = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*"((?:[^"\\]|\\.)*)"\s*(?:,|$)`)
+
+// ParseMatchers parses a selector such as {service="api", env=~"prod|staging"}; the braces
+// are optional
+func ParseMatchers(selector string) ([]LabelMatcher, error) {
+	rest := strings.TrimSpace(selector)
+	rest = strings.TrimSuffix(strings.TrimPrefix(rest, "{"), "}")
+
+	var matchers []LabelMatcher
+	for strings.TrimSpace(rest) != "" {
+		match := selectorPattern.FindStringSubmatch(rest)
+		if match == nil {
+			return nil, NewValidationError("silences", "parse", fmt.Sprintf("invalid selector %q", selector))
+		}
+		value, err := strconv.Unquote(`"` + match[3] + `"`)
+		if err != nil {
+			return nil, NewValidationError("silences", "parse", fmt.Sprintf("invalid value in selector %q", selector))
+		}
+		matcher, err := NewLabelMatcher(match[1], MatchType(match[2]), value)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+		rest = rest[len(match[0]):]
+	}
+	return matchers, nil
+}
+
+// SuppressedAnalysis records an analysis a silence kept from responders
+type SuppressedAnalysis struct {
+	AnalysisID  string    `json:"analysis_id"`
+	Fingerprint string    `json:"fingerprint"`
+	Summary     string    `json:"summary"`
+	Severity    string    `json:"severity"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// Silence mutes analyses whose series all match its selectors for a period of time
+type Silence struct {
+	ID              string               `json:"id"`
+	Matchers        []LabelMatcher       `json:"matchers"`
+	MetricPattern   string               `json:"metric_pattern,omitempty"`
+	Comment         string               `json:"comment,omitempty"`
+	CreatedBy       string               `json:"created_by,omitempty"`
+	CreatedAt       time.Time            `json:"created_at"`
+	StartsAt        time.Time            `json:"starts_at"`
+	EndsAt          time.Time            `json:"ends_at"`
+	SuppressedCount int                  `json:"suppressed_count"`
+	Suppressed      []SuppressedAnalysis `json:"suppressed"`
+	metric          *regexp.Regexp
+}
+
+// Active reports whether the silence is in effect at the given time
+func (s *Silence) Active(now time.Time) bool {
+	return !now.Before(s.StartsAt) && now.Before(s.EndsAt)
+}
+
+// State returns pending, active, or expired
+func (s *Silence) State(now time.Time) string {
+	switch {
+	case now.Before(s.StartsAt):
+		return "pending"
+	case s.Active(now):
+		return "active"
+	default:
+		return "expired"
+	}
+}
+
+// matchesAllSeries reports whether every series of the analysis matches the metric regex
+// and label matchers, so a silence for one host never hides an analysis that also
+// involves another
+func matchesAllSeries(analysis *Analysis, metric *regexp.Regexp, matchers []LabelMatcher) bool {
+	if len(analysis.DataPoints) == 0 {
+		return false
+	}
+	for _, point := range analysis.DataPoints {
+		if metric != nil && !metric.MatchString(point.Metric) {
+			return false
+		}
+		for _, matcher := range matchers {
+			if !matcher.Matches(point.Labels[matcher.Name]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Matches reports whether every series of the analysis is covered by the silence
+func (s *Silence) Matches(analysis *Analysis) bool {
+	return matchesAllSeries(analysis, s.metric, s.Matchers)
+}
+
+// SilenceStatus is a silence together with its state at the time it was listed
+type SilenceStatus struct {
+	Silence
+	State string `json:"state"`
+}
+
+// SilenceManager holds silences and records the analyses they suppress
+type SilenceManager struct {
+	silences map[string]*Silence
+	windows  []*MaintenanceWindow
+	nextID   int
+	mu       sync.RWMutex
+}
+
+// NewSilenceManager creates a new silence manager
+func NewSilenceManager() *SilenceManager {
+	return &SilenceManager{silences: make(map[string]*Silence)}
+}
+
+// Create validates and stores a silence. A silence without a start time starts now.
+func (m *SilenceManager) Create(silence Silence) (Silence, error) {
+	if len(silence.Matchers) == 0 && silence.MetricPattern == "" {
+		return Silence{}, NewValidationError("silences", "create", "silence needs at least one matcher or a metric pattern")
+	}
+	for i, matcher := range silence.Matchers {
+		compiled, err := NewLabelMatcher(matcher.Name, matcher.Type, matcher.Value)
+		if err != nil {
+			return Silence{}, err
+		}
+		silence.Matchers[i] = compiled
+	}
+	if silence.MetricPattern != "" {
+		re, err := regexp.Compile("^(?:" + silence.MetricPattern + ")$")
+		if err != nil {
+			return Silence{}, NewValidationError("silences", "create", fmt.Sprintf("invalid metric pattern %q: %v", silence.MetricPattern, err))
+		}
+		silence.metric = re
+	}
+
+	now := time.Now()
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = now
+	}
+	if !silence.EndsAt.After(silence.StartsAt) {
+		return Silence{}, NewValidationError("silences", "create", "silence must end after it starts")
+	}
+	if !silence.EndsAt.After(now) {
+		return Silence{}, NewValidationError("silences", "create", "silence would already be expired")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	silence.ID = fmt.Sprintf("SIL-%d", m.nextID)
+	silence.CreatedAt = now
+	silence.SuppressedCount = 0
+	silence.Suppressed = nil
+	m.silences[silence.ID] = &silence
+	return silence, nil
+}
+
+// Expire ends a silence immediately
+func (m *SilenceManager) Expire(id string) (Silence, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	silence, ok := m.silences[id]
+	if !ok {
+		return Silence{}, NewValidationError("silences", "expire", fmt.Sprintf("silence %s not found", id))
+	}
+	now := time.Now()
+	if silence.EndsAt.After(now) {
+		silence.EndsAt = now
+	}
+	if silence.StartsAt.After(now) {
+		silence.StartsAt = now
+	}
+	return *silence, nil
+}
+
+// Get returns a copy of a silence
+func (m *SilenceManager) Get(id string) (Silence, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	silence, ok := m.silences[id]
+	if !ok {
+		return Silence{}, NewValidationError("silences", "get", fmt.Sprintf("silence %s not found", id))
+	}
+	return *silence, nil
+}
+
+// List returns all silences with their state, most recently created first. Silences that
+// expired more than a day ago are dropped.
+func (m *SilenceManager) List() []SilenceStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]SilenceStatus, 0, len(m.silences))
+	for id, silence := range m.silences {
+		if now.Sub(silence.EndsAt) > expiredSilenceRetention {
+			delete(m.silences, id)
+			continue
+		}
+		statuses = append(statuses, SilenceStatus{Silence: *silence, State: silence.State(now)})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].CreatedAt.After(statuses[j].CreatedAt)
+	})
+	return statuses
+}
+
+// Suppress checks the analysis against active silences, then maintenance windows. When
+// one matches it records the analysis on it and returns its ID.
+func (m *SilenceManager) Suppress(analysis *Analysis) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var matched *Silence
+	for _, silence := range m.silences {
+		if !silence.Active(now) || !silence.Matches(analysis) {
+			continue
+		}
+		// Prefer the oldest silence so repeats are always attributed to the same one
+		if matched == nil || silence.CreatedAt.Before(matched.CreatedAt) {
+			matched = silence
+		}
+	}
+	if matched == nil {
+		for _, window := range m.windows {
+			if window.Active(now) && window.Matches(analysis) {
+				window.SuppressedCount++
+				window.Suppressed = appendSuppressed(window.Suppressed, analysis, now)
+				return window.ID, true
+			}
+		}
+		return "", false
+	}
+
+	matched.SuppressedCount++
+	matched.Suppressed = appendSuppressed(matched.Suppressed, analysis, now)
+	return matched.ID, true
+}
+
+// appendSuppressed records a suppressed analysis, keeping only the most recent ones
+func appendSuppressed(suppressed []SuppressedAnalysis, analysis *Analysis, now time.Time) []SuppressedAnalysis {
+	suppressed = append(suppressed, SuppressedAnalysis{
+		AnalysisID:  analysis.ID,
+		Fingerprint: analysis.Fingerprint,
+		Summary:     analysis.Summary,
+		Severity:    analysis.Severity,
+		Timestamp:   now,
+	})
+	if len(suppressed) > maxSuppressedPerSilence {
+		suppressed = suppressed[len(suppressed)-maxSuppressedPerSilence:]
+	}
+	return suppressed
+}
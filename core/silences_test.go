@@ -0,0 +1,119 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMatchers(t *testing.T) {
+	matchers, err := ParseMatchers(`{service="checkout", env=~"prod|staging", host!="canary-1", zone!~"us-.*"}`)
+	require.NoError(t, err)
+	require.Len(t, matchers, 4)
+	assert.Equal(t, `service="checkout"`, matchers[0].String())
+	assert.True(t, matchers[1].Matches("staging"))
+	assert.False(t, matchers[1].Matches("prod-eu"), "Expected regexes to be anchored")
+	assert.True(t, matchers[2].Matches("web-1"))
+	assert.False(t, matchers[3].Matches("us-east-1"))
+
+	matchers, err = ParseMatchers(`path="/api/\"v1\""`)
+	require.NoError(t, err)
+	assert.Equal(t, `/api/"v1"`, matchers[0].Value)
+
+	_, err = ParseMatchers(`service=checkout`)
+	assert.Error(t, err, "Expected unquoted values to be rejected")
+	_, err = ParseMatchers(`env=~"("`)
+	assert.Error(t, err, "Expected invalid regexes to be rejected")
+}
+
+func TestSilenceManager_Suppress(t *testing.T) {
+	manager := NewSilenceManager()
+	matchers, err := ParseMatchers(`service="checkout"`)
+	require.NoError(t, err)
+
+	_, err = manager.Create(Silence{Matchers: matchers, EndsAt: time.Now().Add(-time.Minute)})
+	assert.Error(t, err, "Expected already expired silences to be rejected")
+	_, err = manager.Create(Silence{EndsAt: time.Now().Add(time.Hour)})
+	assert.Error(t, err, "Expected a silence to need a selector")
+
+	silence, err := manager.Create(Silence{Matchers: matchers, MetricPattern: "cpu_.*", EndsAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	point := func(metric, service string) DataPoint {
+		return DataPoint{Metric: metric, Labels: map[string]string{"service": service}}
+	}
+	analysis := &Analysis{ID: "an-1", Summary: "cpu spike", DataPoints: []DataPoint{point("cpu_usage_percent", "checkout")}}
+	id, suppressed := manager.Suppress(analysis)
+	assert.True(t, suppressed)
+	assert.Equal(t, silence.ID, id)
+
+	_, suppressed = manager.Suppress(&Analysis{DataPoints: []DataPoint{point("memory_bytes", "checkout")}})
+	assert.False(t, suppressed, "Expected the metric pattern to apply")
+	_, suppressed = manager.Suppress(&Analysis{DataPoints: []DataPoint{point("cpu_usage_percent", "checkout"), point("cpu_usage_percent", "search")}})
+	assert.False(t, suppressed, "Expected analyses involving unsilenced series to get through")
+
+	listed, err := manager.Get(silence.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, listed.SuppressedCount)
+	require.Len(t, listed.Suppressed, 1)
+	assert.Equal(t, "an-1", listed.Suppressed[0].AnalysisID)
+
+	_, err = manager.Expire(silence.ID)
+	require.NoError(t, err)
+	_, suppressed = manager.Suppress(analysis)
+	assert.False(t, suppressed, "Expected expired silences to stop suppressing")
+	assert.Equal(t, "expired", manager.List()[0].State)
+}
+
+func TestFramework_SilenceAPI(t *testing.T) {
+	config := &FrameworkConfig{
+		LogLevel:  "info",
+		LogFormat: "text",
+		LogOutput: "stdout",
+		Plugins:   []PluginConfig{},
+	}
+	framework := NewFramework(config)
+	responder := &severityResponder{MockPlugin: MockPlugin{name: "log", pluginType: PluginTypeResponder}, severity: "high"}
+	require.NoError(t, framework.LoadPlugin(responder))
+
+	mux := http.NewServeMux()
+	framework.registerAPIRoutes(mux)
+
+	body := `{"matchers": "env=\"staging\"", "duration": "2h", "comment": "load test", "created_by": "alice"}`
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/silences", strings.NewReader(body)))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var silence Silence
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&silence))
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), silence.EndsAt, time.Minute)
+
+	staging := &Analysis{Severity: "high", DataPoints: []DataPoint{{Metric: "cpu", Labels: map[string]string{"env": "staging"}}}}
+	prod := &Analysis{Severity: "high", DataPoints: []DataPoint{{Metric: "cpu", Labels: map[string]string{"env": "prod"}}}}
+	framework.handleAnalysis(context.Background(), "anomaly", staging)
+	framework.handleAnalysis(context.Background(), "anomaly", prod)
+	require.Len(t, responder.handled, 1, "Expected the silenced analysis to skip responders")
+	assert.Equal(t, prod, responder.handled[0])
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/silences", nil))
+	var silences []SilenceStatus
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&silences))
+	require.Len(t, silences, 1)
+	assert.Equal(t, "active", silences[0].State)
+	assert.Equal(t, 1, silences[0].SuppressedCount)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/silences/"+silence.ID, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/silences/SIL-404", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
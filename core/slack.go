@@ -0,0 +1,244 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Slack interactive button action IDs understood by the framework
+const (
+	SlackActionAcknowledge = "ack"
+	SlackActionSilence     = "silence_1h"
+	SlackActionRunbook     = "run_runbook"
+	SlackActionApprove     = "approve_remediation"
+	SlackActionReject      = "reject_remediation"
+)
+
+// SlackActionValue is the value attached to interactive buttons by the Slack responder
+type SlackActionValue struct {
+	IncidentID    string `json:"incident_id"`
+	WorkflowID    string `json:"workflow_id,omitempty"`
+	RemediationID string `json:"remediation_id,omitempty"`
+}
+
+// slackInteraction is the subset of Slack's block_actions payload used by the framework
+type slackInteraction struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+	ResponseURL string `json:"response_url"`
+}
+
+// slackSignatureMaxAge bounds how old a signed Slack request may be
+const slackSignatureMaxAge = 5 * time.Minute
+
+// handleSlackInteraction processes interactive button callbacks from Slack
+func (f *Framework) handleSlackInteraction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if f.config.SlackSigningSecret == "" {
+		http.Error(w, "slack interactivity is not configured", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	}
+
+	if err := verifySlackSignature(f.config.SlackSigningSecret, r.Header, body, time.Now()); err != nil {
+		slog.Warn("Rejected Slack interaction", "error", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form body", http.StatusBadRequest)
+		return
+	}
+
+	var interaction slackInteraction
+	if err := json.Unmarshal([]byte(form.Get("payload")), &interaction); err != nil {
+		http.Error(w, "invalid interaction payload", http.StatusBadRequest)
+		return
+	}
+
+	actor := interaction.User.Username
+	if actor == "" {
+		actor = interaction.User.ID
+	}
+
+	for _, action := range interaction.Actions {
+		message, err := f.applySlackAction(r.Context(), action.ActionID, action.Value, actor)
+		if err != nil {
+			slog.Error("Failed to apply Slack action", "action", action.ActionID, "error", err)
+			message = fmt.Sprintf("Failed to %s: %v", action.ActionID, err)
+		}
+		if interaction.ResponseURL != "" {
+			go postSlackResponse(interaction.ResponseURL, message)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// applySlackAction updates incident or remediation state for a single button press
+func (f *Framework) applySlackAction(ctx context.Context, actionID, rawValue, actor string) (string, error) {
+	var value SlackActionValue
+	if err := json.Unmarshal([]byte(rawValue), &value); err != nil {
+		return "", NewValidationError("slack", "interaction", "invalid action value")
+	}
+
+	switch actionID {
+	case SlackActionAcknowledge:
+		if _, err := f.AcknowledgeIncident(ctx, value.IncidentID, actor); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s acknowledged by %s", value.IncidentID, actor), nil
+
+	case SlackActionSilence:
+		if _, err := f.incidents.Silence(value.IncidentID, actor, time.Hour); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s silenced for 1h by %s", value.IncidentID, actor), nil
+
+	case SlackActionRunbook:
+		if f.config.ReadOnly {
+			return "", NewValidationError("slack", "interaction", "runbooks cannot run while the framework is read-only")
+		}
+
+		f.mu.RLock()
+		engine := f.workflowEngine
+		f.mu.RUnlock()
+
+		if engine == nil || value.WorkflowID == "" {
+			return "", NewConfigurationError("slack", "interaction", "no runbook workflow configured")
+		}
+
+		incident, err := f.incidents.Annotate(value.IncidentID, "runbook_started", actor,
+			fmt.Sprintf("Runbook workflow %s started", value.WorkflowID))
+		if err != nil {
+			return "", err
+		}
+
+		// A person pressed the button, so the runbook counts against the remediation cap
+		// but is not held for approval
+		go func() {
+			remediation, err := f.ExecuteRemediation(context.WithoutCancel(ctx), Remediation{
+				Action:     "runbook",
+				WorkflowID: value.WorkflowID,
+				Input: map[string]interface{}{
+					"incident_id": incident.ID,
+					"summary":     incident.Summary,
+					"severity":    incident.Severity,
+					"actor":       actor,
+				},
+				IncidentID:  incident.ID,
+				RequestedBy: actor,
+				ApprovedBy:  actor,
+			})
+			message := fmt.Sprintf("Runbook workflow %s finished", value.WorkflowID)
+			if err == nil && remediation.Status == RemediationStatusFailed {
+				err = fmt.Errorf("%s", remediation.Reason)
+			}
+			if err != nil {
+				message = fmt.Sprintf("Runbook workflow %s failed: %v", value.WorkflowID, err)
+			} else if remediation.Result != nil {
+				message = fmt.Sprintf("Runbook workflow %s finished with status %s", value.WorkflowID, remediation.Result.Status)
+			}
+			f.incidents.Annotate(incident.ID, "runbook_finished", actor, message)
+		}()
+		return fmt.Sprintf("Runbook %s started for %s by %s", value.WorkflowID, value.IncidentID, actor), nil
+
+	case SlackActionApprove:
+		remediation, err := f.releaseRemediation(ctx, value.RemediationID, actor)
+		if err != nil {
+			return "", err
+		}
+		message := fmt.Sprintf("%s %s (%s) approved by %s", remediation.Action, remediation.Service, remediation.ID, actor)
+		// Slack expects an answer within seconds, so the action runs in the background
+		go func() {
+			finished := f.runRemediation(context.WithoutCancel(ctx), remediation)
+			if finished.IncidentID != "" {
+				f.incidents.Annotate(finished.IncidentID, "remediation_finished", actor,
+					fmt.Sprintf("%s %s (%s) %s", finished.Action, finished.Service, finished.ID, finished.Status))
+			}
+		}()
+		return message, nil
+
+	case SlackActionReject:
+		remediation, err := f.RejectRemediation(value.RemediationID, actor)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s (%s) rejected by %s", remediation.Action, remediation.Service, remediation.ID, actor), nil
+
+	default:
+		return "", NewValidationError("slack", "interaction", fmt.Sprintf("unknown action %s", actionID))
+	}
+}
+
+// verifySlackSignature checks the v0 request signature Slack attaches to callbacks
+func verifySlackSignature(secret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	signature := header.Get("X-Slack-Signature")
+	if timestamp == "" || signature == "" {
+		return NewValidationError("slack", "verify", "missing signature headers")
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return NewValidationError("slack", "verify", "invalid request timestamp")
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > slackSignatureMaxAge || age < -slackSignatureMaxAge {
+		return NewValidationError("slack", "verify", "request timestamp outside allowed window")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return NewValidationError("slack", "verify", "signature mismatch")
+	}
+	return nil
+}
+
+// postSlackResponse posts a follow-up message to an interaction's response URL
+func postSlackResponse(responseURL, text string) {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"text":             text,
+		"replace_original": false,
+		"response_type":    "in_channel",
+	})
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(responseURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		slog.Error("Failed to post Slack response", "error", err)
+		return
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,193 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// StoreCollectionSnapshots holds named context snapshots
+const StoreCollectionSnapshots = "snapshots"
+
+// defaultSnapshotWindow is how far back analyses are captured when no window is given
+const defaultSnapshotWindow = time.Hour
+
+// ErrSnapshotNotFound is returned when no snapshot has the requested name
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// ErrSnapshotExists is returned when capturing a snapshot under a name already in use
+var ErrSnapshotExists = errors.New("snapshot already exists")
+
+// snapshotNamePattern keeps snapshot names usable in URLs and store keys
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// ContextSnapshot freezes the data agents were given and the recent analyses at one
+// moment, so agent queries can be re-run against it during a postmortem after the live
+// data has moved on
+type ContextSnapshot struct {
+	Name      string      `json:"name"`
+	Comment   string      `json:"comment,omitempty"`
+	CreatedBy string      `json:"created_by,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      []DataPoint `json:"data"`
+	Analyses  []Analysis  `json:"analyses"`
+}
+
+// ContextSnapshotSummary describes a snapshot without its contents
+type ContextSnapshotSummary struct {
+	Name       string    `json:"name"`
+	Comment    string    `json:"comment,omitempty"`
+	CreatedBy  string    `json:"created_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	DataPoints int       `json:"data_points"`
+	Analyses   int       `json:"analyses"`
+}
+
+// Summary describes the snapshot without its contents
+func (s *ContextSnapshot) Summary() ContextSnapshotSummary {
+	return ContextSnapshotSummary{
+		Name:       s.Name,
+		Comment:    s.Comment,
+		CreatedBy:  s.CreatedBy,
+		CreatedAt:  s.CreatedAt,
+		DataPoints: len(s.Data),
+		Analyses:   len(s.Analyses),
+	}
+}
+
+// CaptureSnapshot saves the data agents currently see and the analyses of the preceding
+// window under a name. Snapshots are kept until deleted.
+func (f *Framework) CaptureSnapshot(ctx context.Context, name, comment, createdBy string, window time.Duration) (*ContextSnapshot, error) {
+	if !snapshotNamePattern.MatchString(name) {
+		return nil, NewValidationError("snapshots", "capture",
+			fmt.Sprintf("invalid snapshot name %q: use letters, digits, '.', '_', or '-'", name))
+	}
+	if window <= 0 {
+		window = defaultSnapshotWindow
+	}
+
+	if _, err := f.store.Get(ctx, StoreCollectionSnapshots, name); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrSnapshotExists, name)
+	} else if !errors.Is(err, ErrStoreNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	analyses, err := f.history.List(ctx, now.Add(-window))
+	if err != nil {
+		return nil, err
+	}
+	data := f.agentContext.snapshot()
+	if data == nil {
+		data = []DataPoint{}
+	}
+	if analyses == nil {
+		analyses = []Analysis{}
+	}
+
+	snapshot := &ContextSnapshot{
+		Name:      name,
+		Comment:   comment,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		Data:      data,
+		Analyses:  analyses,
+	}
+	if err := PutJSON(ctx, f.store, StoreCollectionSnapshots, name, snapshot); err != nil {
+		return nil, err
+	}
+
+	slog.Info("Context snapshot captured", "snapshot", name, "data_points", len(data), "analyses", len(analyses))
+	return snapshot, nil
+}
+
+// GetSnapshot returns a snapshot by name
+func (f *Framework) GetSnapshot(ctx context.Context, name string) (*ContextSnapshot, error) {
+	value, err := f.store.Get(ctx, StoreCollectionSnapshots, name)
+	if errors.Is(err, ErrStoreNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrSnapshotNotFound, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot ContextSnapshot
+	if err := json.Unmarshal(value, &snapshot); err != nil {
+		return nil, WrapError(err, ErrorTypeInternal, "snapshots", "decode", fmt.Sprintf("failed to decode snapshot %s", name))
+	}
+	return &snapshot, nil
+}
+
+// ListSnapshots describes the saved snapshots, newest first
+func (f *Framework) ListSnapshots(ctx context.Context) ([]ContextSnapshotSummary, error) {
+	summaries := []ContextSnapshotSummary{}
+	err := ListJSON(ctx, f.store, StoreCollectionSnapshots, func(key string, unmarshal func(v interface{}) error) error {
+		var snapshot ContextSnapshot
+		if err := unmarshal(&snapshot); err != nil {
+			return err
+		}
+		summaries = append(summaries, snapshot.Summary())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].CreatedAt.After(summaries[j].CreatedAt) })
+	return summaries, nil
+}
+
+// DeleteSnapshot removes a snapshot
+func (f *Framework) DeleteSnapshot(ctx context.Context, name string) error {
+	if _, err := f.store.Get(ctx, StoreCollectionSnapshots, name); err != nil {
+		if errors.Is(err, ErrStoreNotFound) {
+			return fmt.Errorf("%w: %s", ErrSnapshotNotFound, name)
+		}
+		return err
+	}
+	return f.store.Delete(ctx, StoreCollectionSnapshots, name)
+}
+
+// QueryAgentAsOf answers a query with the data saved in a snapshot instead of the live
+// data. The agent must implement SnapshotAgent.
+func (f *Framework) QueryAgentAsOf(ctx context.Context, agentName, snapshotName, query string) (*AgentResponse, error) {
+	f.mu.RLock()
+	plugin, err := f.registry.GetPlugin(agentName)
+	f.mu.RUnlock()
+	if err != nil {
+		return nil, NewPluginError("framework", "query-snapshot", fmt.Sprintf("agent %s not found", agentName))
+	}
+	agent, ok := plugin.(SnapshotAgent)
+	if !ok {
+		return nil, NewValidationError("framework", "query-snapshot", fmt.Sprintf("agent %s cannot answer from a snapshot", agentName))
+	}
+
+	snapshot, err := f.GetSnapshot(ctx, snapshotName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, span := f.startSpan(ctx, "agent.query",
+		attribute.String("agent", agentName), attribute.String("snapshot", snapshot.Name))
+	var response *AgentResponse
+	f.sandbox.run(agentName, func() error {
+		response, err = agent.ProcessQueryWithContext(ctx, query, snapshot.Data)
+		return err
+	})
+	if response != nil {
+		if response.Metadata == nil {
+			response.Metadata = make(map[string]interface{})
+		}
+		response.Metadata["snapshot"] = snapshot.Name
+		response.Metadata["snapshot_created_at"] = snapshot.CreatedAt
+	}
+	f.recordAgentQuery(TraceIDFromContext(ctx), agentName, query, response, err)
+	endSpan(span, err)
+	return response, err
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFramework_ContextSnapshots(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout"})
+	agent := &batchAgent{MockPlugin: MockPlugin{name: "ai", pluginType: PluginTypeAgent}}
+	require.NoError(t, framework.LoadPlugin(agent))
+	ctx := context.Background()
+
+	framework.agentContext.set([]DataPoint{{Metric: "cpu", Value: 97}, {Metric: "memory", Value: 80}})
+	old := &Analysis{Summary: "old", Timestamp: time.Now().Add(-3 * time.Hour)}
+	recent := &Analysis{Summary: "CPU high", Severity: "high", Timestamp: time.Now().Add(-10 * time.Minute)}
+	require.NoError(t, framework.history.Record(ctx, old))
+	require.NoError(t, framework.history.Record(ctx, recent))
+
+	snapshot, err := framework.CaptureSnapshot(ctx, "checkout-outage", "INC-231 at peak", "alice", 0)
+	require.NoError(t, err)
+	assert.Len(t, snapshot.Data, 2)
+	require.Len(t, snapshot.Analyses, 1, "Expected only analyses within the window")
+	assert.Equal(t, "CPU high", snapshot.Analyses[0].Summary)
+
+	_, err = framework.CaptureSnapshot(ctx, "checkout-outage", "", "", 0)
+	assert.True(t, errors.Is(err, ErrSnapshotExists))
+	_, err = framework.CaptureSnapshot(ctx, "../etc", "", "", 0)
+	assert.Equal(t, ErrorTypeValidation, GetErrorType(err))
+
+	// Live data moves on; queries as of the snapshot still see the captured data
+	framework.agentContext.set([]DataPoint{{Metric: "cpu", Value: 12}})
+	response, err := framework.QueryAgentAsOf(ctx, "ai", "checkout-outage", "why was CPU high?")
+	require.NoError(t, err)
+	assert.Equal(t, "why was CPU high? (2 points)", response.Response)
+	assert.Equal(t, "checkout-outage", response.Metadata["snapshot"])
+
+	_, err = framework.QueryAgentAsOf(ctx, "ai", "missing", "why?")
+	assert.True(t, errors.Is(err, ErrSnapshotNotFound))
+	require.NoError(t, framework.LoadPlugin(&MockPlugin{name: "plain", pluginType: PluginTypeAgent}))
+	_, err = framework.QueryAgentAsOf(ctx, "plain", "checkout-outage", "why?")
+	assert.Equal(t, ErrorTypeValidation, GetErrorType(err), "Expected agents without snapshot support to be refused")
+
+	summaries, err := framework.ListSnapshots(ctx)
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, ContextSnapshotSummary{Name: "checkout-outage", Comment: "INC-231 at peak", CreatedBy: "alice",
+		CreatedAt: summaries[0].CreatedAt, DataPoints: 2, Analyses: 1}, summaries[0])
+
+	require.NoError(t, framework.DeleteSnapshot(ctx, "checkout-outage"))
+	assert.True(t, errors.Is(framework.DeleteSnapshot(ctx, "checkout-outage"), ErrSnapshotNotFound))
+}
+
+func TestFramework_SnapshotAPI(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout", DefaultAgent: "ai"})
+	require.NoError(t, framework.LoadPlugin(&batchAgent{MockPlugin: MockPlugin{name: "ai", pluginType: PluginTypeAgent}}))
+	framework.agentContext.set([]DataPoint{{Metric: "cpu", Value: 97}})
+
+	mux := http.NewServeMux()
+	framework.registerAPIRoutes(mux)
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusCreated, request(http.MethodPost, "/api/v1/snapshots", `{"name": "peak", "window": "2h"}`).Code)
+	assert.Equal(t, http.StatusConflict, request(http.MethodPost, "/api/v1/snapshots", `{"name": "peak"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/api/v1/snapshots", `{"name": "peak 2"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/api/v1/snapshots", `{"name": "later", "window": "soon"}`).Code)
+
+	rec := request(http.MethodPost, "/api/v1/snapshots/peak/query", `{"query": "why?"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var response AgentResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, "why? (1 points)", response.Response)
+
+	rec = request(http.MethodGet, "/api/v1/snapshots/peak", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var snapshot ContextSnapshot
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&snapshot))
+	assert.Equal(t, "peak", snapshot.Name)
+	assert.Len(t, snapshot.Data, 1)
+
+	assert.Equal(t, http.StatusNotFound, request(http.MethodPost, "/api/v1/snapshots/missing/query", `{"query": "why?"}`).Code)
+	assert.Equal(t, http.StatusNoContent, request(http.MethodDelete, "/api/v1/snapshots/peak", "").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/api/v1/snapshots/peak", "").Code)
+}
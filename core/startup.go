@@ -0,0 +1,131 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PluginStartResult records how starting one plugin went
+type PluginStartResult struct {
+	Plugin   string        `json:"plugin"`
+	Duration time.Duration `json:"duration"`
+	Err      error         `json:"-"`
+}
+
+// PluginStartError reports every plugin that failed or timed out during Framework.Start
+type PluginStartError struct {
+	Failures []PluginStartResult
+}
+
+// Error lists the failed plugins and their errors
+func (e *PluginStartError) Error() string {
+	messages := make([]string, len(e.Failures))
+	for i, failure := range e.Failures {
+		messages[i] = fmt.Sprintf("%s: %v", failure.Plugin, failure.Err)
+	}
+	return fmt.Sprintf("%d plugin(s) failed to start: %s", len(e.Failures), strings.Join(messages, "; "))
+}
+
+// Unwrap returns the individual plugin errors so errors.Is and errors.As see them
+func (e *PluginStartError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, failure := range e.Failures {
+		errs[i] = failure.Err
+	}
+	return errs
+}
+
+// Failed reports whether the named plugin failed to start
+func (e *PluginStartError) Failed(plugin string) bool {
+	for _, failure := range e.Failures {
+		if failure.Plugin == plugin {
+			return true
+		}
+	}
+	return false
+}
+
+// startPlugins starts plugins concurrently, giving each the configured start timeout, or
+// no limit when it is zero. A plugin that times out keeps its Start call running in the
+// background and is reported as failed; the context it was given is the framework's, so it
+// is not cancelled early.
+func (f *Framework) startPlugins(ctx context.Context, plugins []Plugin) map[string]PluginStartResult {
+	timeout := f.config.PluginStartTimeout
+
+	results := make(map[string]PluginStartResult, len(plugins))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, plugin := range plugins {
+		wg.Add(1)
+		go func(plugin Plugin) {
+			defer wg.Done()
+			result := startPlugin(ctx, plugin, timeout)
+			if result.Err != nil {
+				slog.Error("Failed to start plugin", "plugin", plugin.Name(), "duration", result.Duration, "error", result.Err)
+			} else {
+				slog.Debug("Plugin started", "plugin", plugin.Name(), "duration", result.Duration)
+			}
+			mu.Lock()
+			results[plugin.Name()] = result
+			mu.Unlock()
+		}(plugin)
+	}
+	wg.Wait()
+	return results
+}
+
+// startPlugin calls a plugin's Start and waits at most timeout for it to return; a zero
+// timeout waits until it does
+func startPlugin(ctx context.Context, plugin Plugin, timeout time.Duration) PluginStartResult {
+	started := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		// Goroutines the plugin starts carry its label, so they count as its goroutines
+		pprof.Do(ctx, pprof.Labels(pluginLabel, plugin.Name()), func(ctx context.Context) {
+			done <- plugin.Start(ctx)
+		})
+	}()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	result := PluginStartResult{Plugin: plugin.Name()}
+	select {
+	case err := <-done:
+		if err != nil {
+			result.Err = WrapError(err, ErrorTypePlugin, plugin.Name(), "start", "plugin failed to start")
+		}
+	case <-expired:
+		result.Err = NewTimeoutError(plugin.Name(), "start", fmt.Sprintf("plugin did not start within %s", timeout))
+	case <-ctx.Done():
+		result.Err = WrapError(ctx.Err(), ErrorTypeInternal, plugin.Name(), "start", "framework stopped while the plugin was starting")
+	}
+	result.Duration = time.Since(started)
+	return result
+}
+
+// pluginStartError aggregates the failed start results, or returns nil if every plugin
+// started
+func pluginStartError(results map[string]PluginStartResult) error {
+	var failures []PluginStartResult
+	for _, result := range results {
+		if result.Err != nil {
+			failures = append(failures, result)
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Plugin < failures[j].Plugin })
+	return &PluginStartError{Failures: failures}
+}
@@ -0,0 +1,258 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatusPage is a point-in-time view of the framework for the static status page
+type StatusPage struct {
+	Title       string         `json:"title"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Running     bool           `json:"running"`
+	Uptime      time.Duration  `json:"uptime"`
+	Health      string         `json:"health"`
+	Plugins     []PluginState  `json:"plugins"`
+	Incidents   []Incident     `json:"incidents"`
+	Metrics     []StatusMetric `json:"metrics"`
+}
+
+// PluginState is the status and health of a single plugin
+type PluginState struct {
+	Name    string       `json:"name"`
+	Type    PluginType   `json:"type"`
+	Status  PluginStatus `json:"status"`
+	Healthy bool         `json:"healthy"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// StatusMetric is the latest value of a key metric series
+type StatusMetric struct {
+	Series    string    `json:"series"`
+	Value     string    `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// StatusPageProvider builds status pages
+type StatusPageProvider interface {
+	StatusPage(ctx context.Context) StatusPage
+}
+
+// StatusPageAware is implemented by plugins that publish the status page. The framework
+// provides itself as the source when the plugin is loaded.
+type StatusPageAware interface {
+	SetStatusPageProvider(provider StatusPageProvider)
+}
+
+// latestValues keeps the most recent point of each series of the configured key metrics
+type latestValues struct {
+	metrics map[string]bool
+	points  map[string]DataPoint
+	mu      sync.RWMutex
+}
+
+// newLatestValues creates a tracker for the given metric names
+func newLatestValues(metrics []string) *latestValues {
+	l := &latestValues{
+		metrics: make(map[string]bool),
+		points:  make(map[string]DataPoint),
+	}
+	for _, metric := range metrics {
+		l.metrics[metric] = true
+	}
+	return l
+}
+
+// observe records the points of key metrics
+func (l *latestValues) observe(data []DataPoint) {
+	if l == nil || len(l.metrics) == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, point := range data {
+		if !l.metrics[point.Metric] {
+			continue
+		}
+		key := statusSeriesName(point)
+		if existing, ok := l.points[key]; ok && existing.Timestamp.After(point.Timestamp) {
+			continue
+		}
+		l.points[key] = point
+	}
+}
+
+// list returns the latest points sorted by series name
+func (l *latestValues) list() []DataPoint {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	points := make([]DataPoint, 0, len(l.points))
+	for _, point := range l.points {
+		points = append(points, point)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return statusSeriesName(points[i]) < statusSeriesName(points[j])
+	})
+	return points
+}
+
+// statusSeriesName renders a series as metric{label="value",...}
+func statusSeriesName(point DataPoint) string {
+	if len(point.Labels) == 0 {
+		return point.Metric
+	}
+	keys := make([]string, 0, len(point.Labels))
+	for k := range point.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf("%s=%q", k, point.Labels[k])
+	}
+	return point.Metric + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// StatusPage builds a status page from plugin health, unresolved incidents, and the latest
+// values of the configured status metrics
+func (f *Framework) StatusPage(ctx context.Context) StatusPage {
+	f.mu.RLock()
+	running := f.running && !f.shutdown
+	startTime := f.startTime
+	f.mu.RUnlock()
+
+	page := StatusPage{
+		Title:       f.config.StatusPageTitle,
+		GeneratedAt: time.Now(),
+		Running:     running,
+		Health:      f.GetHealthStatus(ctx).Status,
+	}
+	if page.Title == "" {
+		page.Title = "Agent Status"
+	}
+	if !startTime.IsZero() {
+		page.Uptime = time.Since(startTime).Round(time.Second)
+	}
+
+	page.Plugins = f.PluginStates(ctx)
+
+	for _, incident := range f.incidents.List() {
+		if incident.Status != IncidentStatusResolved {
+			page.Incidents = append(page.Incidents, incident)
+		}
+	}
+
+	for _, point := range f.latest.list() {
+		page.Metrics = append(page.Metrics, StatusMetric{
+			Series:    statusSeriesName(point),
+			Value:     f.metadata.FormatValue(point.Metric, point.Value),
+			Timestamp: point.Timestamp,
+		})
+	}
+
+	return page
+}
+
+// PluginStates returns the status and health of every loaded plugin, sorted by name
+func (f *Framework) PluginStates(ctx context.Context) []PluginState {
+	plugins := f.registry.ListPlugins()
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name() < plugins[j].Name() })
+	states := make([]PluginState, 0, len(plugins))
+	for _, plugin := range plugins {
+		states = append(states, pluginState(ctx, plugin))
+	}
+	return states
+}
+
+// PluginState returns the status and health of a loaded plugin
+func (f *Framework) PluginState(ctx context.Context, name string) (PluginState, error) {
+	plugin, err := f.registry.GetPlugin(name)
+	if err != nil {
+		return PluginState{}, WrapError(err, ErrorTypePlugin, "framework", "plugin-state", fmt.Sprintf("plugin %s not found", name))
+	}
+	return pluginState(ctx, plugin), nil
+}
+
+// pluginState reports the status and health of a plugin
+func pluginState(ctx context.Context, plugin Plugin) PluginState {
+	state := PluginState{Name: plugin.Name(), Type: plugin.Type(), Status: plugin.Status(), Healthy: true}
+	if err := plugin.Health(ctx); err != nil {
+		state.Healthy = false
+		state.Error = err.Error()
+	}
+	return state
+}
+
+// statusPageTemplate renders a self-contained page with inline styles and no scripts so it
+// can be served from static hosting such as S3
+var statusPageTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"timestamp": func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04:05 MST") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem auto; max-width: 960px; color: #24292f; padding: 0 1rem; }
+h1 { margin-bottom: 0.25rem; }
+.meta { color: #57606a; margin-bottom: 1.5rem; }
+.banner { padding: 1rem; border-radius: 6px; font-weight: 600; margin-bottom: 2rem; }
+.ok { background: #dafbe1; color: #116329; }
+.degraded { background: #fff8c5; color: #7d4e00; }
+.down { background: #ffebe9; color: #a40e26; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2rem; }
+th, td { text-align: left; padding: 0.5rem; border-bottom: 1px solid #d0d7de; }
+th { background: #f6f8fa; }
+.badge { padding: 0.1rem 0.5rem; border-radius: 1rem; font-size: 0.85rem; }
+.empty { color: #57606a; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="meta">Generated {{timestamp .GeneratedAt}}{{if .Running}} &middot; up {{.Uptime}}{{end}}</div>
+{{if not .Running}}<div class="banner down">Agent is not running</div>
+{{else if .Incidents}}<div class="banner degraded">{{len .Incidents}} open incident(s)</div>
+{{else}}<div class="banner ok">All systems operational</div>
+{{end}}
+<h2>Incidents</h2>
+{{if .Incidents}}<table>
+<tr><th>ID</th><th>Severity</th><th>Status</th><th>Summary</th><th>Opened</th><th>Occurrences</th></tr>
+{{range .Incidents}}<tr><td>{{.ID}}</td><td>{{.Severity}}</td><td>{{.Status}}</td><td>{{.Summary}}</td><td>{{timestamp .OpenedAt}}</td><td>{{.Occurrences}}</td></tr>
+{{end}}</table>
+{{else}}<p class="empty">No open incidents.</p>
+{{end}}
+<h2>Key Metrics</h2>
+{{if .Metrics}}<table>
+<tr><th>Series</th><th>Value</th><th>Updated</th></tr>
+{{range .Metrics}}<tr><td>{{.Series}}</td><td>{{.Value}}</td><td>{{timestamp .Timestamp}}</td></tr>
+{{end}}</table>
+{{else}}<p class="empty">No key metrics configured.</p>
+{{end}}
+<h2>Plugins</h2>
+<table>
+<tr><th>Name</th><th>Type</th><th>Status</th><th>Health</th></tr>
+{{range .Plugins}}<tr><td>{{.Name}}</td><td>{{.Type}}</td><td>{{.Status}}</td><td>{{if .Healthy}}<span class="badge ok">healthy</span>{{else}}<span class="badge down" title="{{.Error}}">unhealthy</span>{{end}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// RenderStatusPage writes the status page as a self-contained HTML document
+func RenderStatusPage(w io.Writer, page StatusPage) error {
+	if err := statusPageTemplate.Execute(w, page); err != nil {
+		return WrapError(err, ErrorTypeInternal, "status_page", "render", "failed to render status page")
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFramework_StatusPage(t *testing.T) {
+	config := &FrameworkConfig{
+		LogLevel:        "info",
+		LogFormat:       "text",
+		LogOutput:       "stdout",
+		StatusPageTitle: "Payments <prod>",
+		StatusMetrics:   []string{"cpu_usage_percent"},
+		Plugins:         []PluginConfig{},
+	}
+	framework := NewFramework(config)
+	require.NoError(t, framework.LoadPlugin(&MockPlugin{name: "prometheus", pluginType: PluginTypeCollector, status: PluginStatusRunning}))
+
+	now := time.Now()
+	framework.processData(context.Background(), []DataPoint{
+		{Metric: "cpu_usage_percent", Value: 40, Labels: map[string]string{"host": "a"}, Timestamp: now.Add(-time.Minute)},
+		{Metric: "cpu_usage_percent", Value: 87.5, Labels: map[string]string{"host": "a"}, Timestamp: now},
+		{Metric: "memory_bytes", Value: 1024, Timestamp: now},
+	})
+
+	open, _ := framework.incidents.Track(testAnalysis("cpu"))
+	resolved, _ := framework.incidents.Track(testAnalysis("memory"))
+	_, err := framework.incidents.Resolve(resolved.ID, "alice")
+	require.NoError(t, err)
+
+	page := framework.StatusPage(context.Background())
+	assert.Equal(t, "Payments <prod>", page.Title)
+	require.Len(t, page.Plugins, 1)
+	assert.True(t, page.Plugins[0].Healthy)
+	require.Len(t, page.Incidents, 1, "Expected resolved incidents to be left off the page")
+	assert.Equal(t, open.ID, page.Incidents[0].ID)
+	require.Len(t, page.Metrics, 1, "Expected only configured status metrics")
+	assert.Equal(t, `cpu_usage_percent{host="a"}`, page.Metrics[0].Series)
+	assert.Equal(t, "87.5%", page.Metrics[0].Value)
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderStatusPage(&buf, page))
+	html := buf.String()
+	assert.Contains(t, html, "<title>Payments &lt;prod&gt;</title>")
+	assert.Contains(t, html, open.ID)
+	assert.Contains(t, html, "87.5%")
+	assert.Contains(t, html, "Agent is not running")
+	assert.NotContains(t, html, "<script")
+}
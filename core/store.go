@@ -0,0 +1,189 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Store drivers selectable in config
+const (
+	StoreDriverMemory   = "memory"
+	StoreDriverSQLite   = "sqlite"
+	StoreDriverPostgres = "postgres"
+)
+
+// Collections used by the framework's subsystems
+const (
+	StoreCollectionIncidents  = "incidents"
+	StoreCollectionAnalyses   = "analyses"
+	StoreCollectionDataPoints = "datapoints"
+	StoreCollectionWorkflows  = "workflows"
+	StoreCollectionDocuments  = "documents"
+	StoreCollectionApprovals  = "approval_audit"
+	// StoreCollectionKnowledgeFiles records which knowledge base files a RAG agent indexed
+	StoreCollectionKnowledgeFiles = "knowledge_files"
+)
+
+// ErrStoreNotFound is returned by Store.Get when a key does not exist
+var ErrStoreNotFound = errors.New("store: record not found")
+
+// StoreRecord is a stored value together with its key
+type StoreRecord struct {
+	Key   string
+	Value []byte
+}
+
+// Store persists framework state as JSON documents grouped in collections, so incidents,
+// analysis history, workflows, and knowledge bases share one code path whether they are
+// kept in memory or in a database
+type Store interface {
+	// Put creates or replaces the value stored under the key
+	Put(ctx context.Context, collection, key string, value []byte) error
+
+	// Get returns the value stored under the key or ErrStoreNotFound
+	Get(ctx context.Context, collection, key string) ([]byte, error)
+
+	// Delete removes the key; deleting a missing key is not an error
+	Delete(ctx context.Context, collection, key string) error
+
+	// List returns every record of a collection ordered by key
+	List(ctx context.Context, collection string) ([]StoreRecord, error)
+
+	// Close releases the store's resources
+	Close() error
+}
+
+// StoreAware is implemented by plugins that persist state in the framework's store
+type StoreAware interface {
+	SetStore(store Store)
+}
+
+// OpenStore opens the store selected by the config
+func OpenStore(config StoreConfig) (Store, error) {
+	switch config.Driver {
+	case "", StoreDriverMemory:
+		return NewMemoryStore(), nil
+	case StoreDriverSQLite, StoreDriverPostgres:
+		return OpenSQLStore(config.Driver, config.DSN)
+	default:
+		return nil, NewConfigurationError("store", "open", fmt.Sprintf("unknown store driver %q", config.Driver))
+	}
+}
+
+// PutJSON encodes a value as JSON and stores it
+func PutJSON(ctx context.Context, store Store, collection, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return WrapError(err, ErrorTypeInternal, "store", "encode", fmt.Sprintf("failed to encode %s/%s", collection, key))
+	}
+	return store.Put(ctx, collection, key, data)
+}
+
+// ListJSON decodes every record of a collection, calling decode with each key and a
+// function that unmarshals its value
+func ListJSON(ctx context.Context, store Store, collection string, decode func(key string, unmarshal func(v interface{}) error) error) error {
+	records, err := store.List(ctx, collection)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		value := record.Value
+		unmarshal := func(v interface{}) error {
+			if err := json.Unmarshal(value, v); err != nil {
+				return WrapError(err, ErrorTypeInternal, "store", "decode", fmt.Sprintf("failed to decode %s/%s", collection, record.Key))
+			}
+			return nil
+		}
+		if err := decode(record.Key, unmarshal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MemoryStore keeps records in memory; state is lost when the process exits
+type MemoryStore struct {
+	collections map[string]map[string][]byte
+	mu          sync.RWMutex
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{collections: make(map[string]map[string][]byte)}
+}
+
+// Put creates or replaces the value stored under the key
+func (s *MemoryStore) Put(ctx context.Context, collection, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, ok := s.collections[collection]
+	if !ok {
+		records = make(map[string][]byte)
+		s.collections[collection] = records
+	}
+	records[key] = append([]byte(nil), value...)
+	return nil
+}
+
+// Get returns the value stored under the key or ErrStoreNotFound
+func (s *MemoryStore) Get(ctx context.Context, collection, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.collections[collection][key]
+	if !ok {
+		return nil, ErrStoreNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+// Delete removes the key
+func (s *MemoryStore) Delete(ctx context.Context, collection, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.collections[collection], key)
+	return nil
+}
+
+// List returns every record of a collection ordered by key
+func (s *MemoryStore) List(ctx context.Context, collection string) ([]StoreRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := make([]StoreRecord, 0, len(s.collections[collection]))
+	for key, value := range s.collections[collection] {
+		records = append(records, StoreRecord{Key: key, Value: append([]byte(nil), value...)})
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Key < records[j].Key })
+	return records, nil
+}
+
+// Close is a no-op for the in-memory store
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// SaveWorkflow persists a workflow definition, for WorkflowEngine implementations
+func SaveWorkflow(ctx context.Context, store Store, workflow *Workflow) error {
+	return PutJSON(ctx, store, StoreCollectionWorkflows, workflow.ID, workflow)
+}
+
+// LoadWorkflows returns every stored workflow definition ordered by ID
+func LoadWorkflows(ctx context.Context, store Store) ([]*Workflow, error) {
+	var workflows []*Workflow
+	err := ListJSON(ctx, store, StoreCollectionWorkflows, func(key string, unmarshal func(v interface{}) error) error {
+		var workflow Workflow
+		if err := unmarshal(&workflow); err != nil {
+			return err
+		}
+		workflows = append(workflows, &workflow)
+		return nil
+	})
+	return workflows, err
+}
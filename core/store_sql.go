@@ -0,0 +1,144 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	// Database drivers for the sqlite and postgres store drivers
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// sqlDialect holds the statements that differ between databases
+type sqlDialect struct {
+	schema string
+	put    string
+	get    string
+	delete string
+	list   string
+}
+
+// sqlDialects are keyed by store driver, which is also the database/sql driver name
+var sqlDialects = map[string]sqlDialect{
+	StoreDriverSQLite: {
+		schema: `CREATE TABLE IF NOT EXISTS agent_store (
+			collection TEXT NOT NULL,
+			key TEXT NOT NULL,
+			value BLOB NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (collection, key))`,
+		put: `INSERT INTO agent_store (collection, key, value, updated_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT (collection, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		get:    `SELECT value FROM agent_store WHERE collection = ? AND key = ?`,
+		delete: `DELETE FROM agent_store WHERE collection = ? AND key = ?`,
+		list:   `SELECT key, value FROM agent_store WHERE collection = ? ORDER BY key`,
+	},
+	StoreDriverPostgres: {
+		schema: `CREATE TABLE IF NOT EXISTS agent_store (
+			collection TEXT NOT NULL,
+			key TEXT NOT NULL,
+			value BYTEA NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (collection, key))`,
+		put: `INSERT INTO agent_store (collection, key, value, updated_at) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (collection, key) DO UPDATE SET value = EXCLUDED.value, updated_at = EXCLUDED.updated_at`,
+		get:    `SELECT value FROM agent_store WHERE collection = $1 AND key = $2`,
+		delete: `DELETE FROM agent_store WHERE collection = $1 AND key = $2`,
+		list:   `SELECT key, value FROM agent_store WHERE collection = $1 ORDER BY key`,
+	},
+}
+
+// SQLStore keeps records in a single table of a SQLite or Postgres database
+type SQLStore struct {
+	db      *sql.DB
+	dialect sqlDialect
+}
+
+// OpenSQLStore connects to the database and creates the store table if needed. For
+// SQLite the DSN is a file path; for Postgres it is a connection URL or keyword string.
+func OpenSQLStore(driver, dsn string) (*SQLStore, error) {
+	dialect, ok := sqlDialects[driver]
+	if !ok {
+		return nil, NewConfigurationError("store", "open", fmt.Sprintf("unknown SQL store driver %q", driver))
+	}
+	if dsn == "" {
+		return nil, NewConfigurationError("store", "open", fmt.Sprintf("the %s store needs a DSN", driver))
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, WrapError(err, ErrorTypeConfiguration, "store", "open", fmt.Sprintf("failed to open %s store", driver))
+	}
+	if driver == StoreDriverSQLite {
+		// SQLite allows one writer at a time
+		db.SetMaxOpenConns(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := db.ExecContext(ctx, dialect.schema); err != nil {
+		db.Close()
+		return nil, WrapError(err, ErrorTypeNetwork, "store", "open", fmt.Sprintf("failed to create %s store table", driver))
+	}
+
+	return &SQLStore{db: db, dialect: dialect}, nil
+}
+
+// Put creates or replaces the value stored under the key
+func (s *SQLStore) Put(ctx context.Context, collection, key string, value []byte) error {
+	if _, err := s.db.ExecContext(ctx, s.dialect.put, collection, key, value, time.Now().UTC()); err != nil {
+		return WrapError(err, ErrorTypeNetwork, "store", "put", fmt.Sprintf("failed to write %s/%s", collection, key))
+	}
+	return nil
+}
+
+// Get returns the value stored under the key or ErrStoreNotFound
+func (s *SQLStore) Get(ctx context.Context, collection, key string) ([]byte, error) {
+	var value []byte
+	err := s.db.QueryRowContext(ctx, s.dialect.get, collection, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrStoreNotFound
+	}
+	if err != nil {
+		return nil, WrapError(err, ErrorTypeNetwork, "store", "get", fmt.Sprintf("failed to read %s/%s", collection, key))
+	}
+	return value, nil
+}
+
+// Delete removes the key
+func (s *SQLStore) Delete(ctx context.Context, collection, key string) error {
+	if _, err := s.db.ExecContext(ctx, s.dialect.delete, collection, key); err != nil {
+		return WrapError(err, ErrorTypeNetwork, "store", "delete", fmt.Sprintf("failed to delete %s/%s", collection, key))
+	}
+	return nil
+}
+
+// List returns every record of a collection ordered by key
+func (s *SQLStore) List(ctx context.Context, collection string) ([]StoreRecord, error) {
+	rows, err := s.db.QueryContext(ctx, s.dialect.list, collection)
+	if err != nil {
+		return nil, WrapError(err, ErrorTypeNetwork, "store", "list", fmt.Sprintf("failed to list %s", collection))
+	}
+	defer rows.Close()
+
+	var records []StoreRecord
+	for rows.Next() {
+		var record StoreRecord
+		if err := rows.Scan(&record.Key, &record.Value); err != nil {
+			return nil, WrapError(err, ErrorTypeNetwork, "store", "list", fmt.Sprintf("failed to read %s", collection))
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, WrapError(err, ErrorTypeNetwork, "store", "list", fmt.Sprintf("failed to list %s", collection))
+	}
+	return records, nil
+}
+
+// Close closes the database connection
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
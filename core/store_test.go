@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStoreContract checks the behaviour every Store driver must share
+func testStoreContract(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	_, err := store.Get(ctx, "things", "missing")
+	assert.ErrorIs(t, err, ErrStoreNotFound)
+
+	require.NoError(t, store.Put(ctx, "things", "b", []byte(`{"n":2}`)))
+	require.NoError(t, store.Put(ctx, "things", "a", []byte(`{"n":1}`)))
+	require.NoError(t, store.Put(ctx, "other", "a", []byte(`{"n":9}`)))
+	require.NoError(t, store.Put(ctx, "things", "b", []byte(`{"n":3}`)), "Expected Put to replace existing values")
+
+	value, err := store.Get(ctx, "things", "b")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"n":3}`, string(value))
+
+	records, err := store.List(ctx, "things")
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "a", records[0].Key)
+	assert.Equal(t, "b", records[1].Key)
+
+	require.NoError(t, store.Delete(ctx, "things", "a"))
+	require.NoError(t, store.Delete(ctx, "things", "a"), "Expected deleting a missing key to succeed")
+	records, err = store.List(ctx, "things")
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStoreContract(t, NewMemoryStore())
+}
+
+func TestSQLiteStore(t *testing.T) {
+	store, err := OpenStore(StoreConfig{Driver: StoreDriverSQLite, DSN: filepath.Join(t.TempDir(), "agent.db")})
+	require.NoError(t, err)
+	defer store.Close()
+	testStoreContract(t, store)
+}
+
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("AGENT_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("AGENT_TEST_POSTGRES_DSN not set")
+	}
+	store, err := OpenStore(StoreConfig{Driver: StoreDriverPostgres, DSN: dsn})
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx := context.Background()
+	for _, collection := range []string{"things", "other"} {
+		records, err := store.List(ctx, collection)
+		require.NoError(t, err)
+		for _, record := range records {
+			require.NoError(t, store.Delete(ctx, collection, record.Key))
+		}
+	}
+	testStoreContract(t, store)
+}
+
+func TestOpenStore_Errors(t *testing.T) {
+	_, err := OpenStore(StoreConfig{Driver: "cassandra"})
+	assert.Error(t, err)
+
+	_, err = OpenStore(StoreConfig{Driver: StoreDriverSQLite})
+	assert.Error(t, err, "Expected a SQL store without a DSN to be rejected")
+}
+
+func TestIncidentManager_RestoresFromStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := OpenStore(StoreConfig{Driver: StoreDriverSQLite, DSN: filepath.Join(t.TempDir(), "agent.db")})
+	require.NoError(t, err)
+	defer store.Close()
+
+	manager, err := NewIncidentManagerWithStore(ctx, store)
+	require.NoError(t, err)
+	analysis := &Analysis{Type: AnalysisTypeAnomaly, Severity: "high", Summary: "cpu spike", Source: "anomaly",
+		DataPoints: []DataPoint{{Metric: "cpu"}}}
+	first, _ := manager.Track(analysis)
+	_, err = manager.Acknowledge(first.ID, "alice")
+	require.NoError(t, err)
+
+	restored, err := NewIncidentManagerWithStore(ctx, store)
+	require.NoError(t, err)
+	incident, err := restored.Get(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, IncidentStatusAcknowledged, incident.Status)
+	assert.Equal(t, "alice", incident.AcknowledgedBy)
+
+	repeat, _ := restored.Track(&Analysis{Type: AnalysisTypeAnomaly, Severity: "high", Summary: "cpu spike", Source: "anomaly",
+		DataPoints: []DataPoint{{Metric: "cpu"}}})
+	assert.Equal(t, first.ID, repeat.ID, "Expected repeats to join the restored incident")
+	assert.Equal(t, 2, repeat.Occurrences)
+
+	other, _ := restored.Track(&Analysis{Type: AnalysisTypeAnomaly, Summary: "disk full", Source: "anomaly",
+		DataPoints: []DataPoint{{Metric: "disk"}}})
+	assert.Equal(t, "INC-2", other.ID, "Expected incident IDs to continue after restored ones")
+}
+
+func TestAnalysisHistory(t *testing.T) {
+	ctx := context.Background()
+	history := NewAnalysisHistory(NewMemoryStore(), time.Hour)
+
+	now := time.Now()
+	old := &Analysis{Summary: "old", Timestamp: now.Add(-2 * time.Hour)}
+	recent := &Analysis{Summary: "recent", Timestamp: now.Add(-time.Minute)}
+	require.NoError(t, history.Record(ctx, old))
+	require.NoError(t, history.Record(ctx, recent))
+
+	analyses, err := history.List(ctx, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, analyses, 1, "Expected analyses older than the retention to be pruned")
+	assert.Equal(t, "recent", analyses[0].Summary)
+	assert.NotEmpty(t, analyses[0].ID)
+
+	analyses, err = history.List(ctx, now)
+	require.NoError(t, err)
+	assert.Empty(t, analyses, "Expected List to skip analyses before the given time")
+}
@@ -0,0 +1,412 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Stream defaults used when the client does not ask for a rate
+const (
+	defaultStreamRate   = 10.0
+	defaultStreamBurst  = 20
+	streamBufferSize    = 100
+	streamWriteDeadline = 10 * time.Second
+	streamKeepalive     = 15 * time.Second
+)
+
+// severityOrder ranks severities so streams can be filtered to a minimum severity
+var severityOrder = map[string]int{
+	"low":      0,
+	"medium":   1,
+	"high":     2,
+	"critical": 3,
+}
+
+// StreamMessage is one message of a stream: an analysis or, on the combined stream, a
+// framework event. Dropped counts the messages skipped since the previous message because
+// the client was reading too slowly or over its rate.
+type StreamMessage struct {
+	Analysis *Analysis `json:"analysis,omitempty"`
+	Event    *Event    `json:"event,omitempty"`
+	Dropped  int       `json:"dropped,omitempty"`
+}
+
+// StreamFilter selects the analyses a stream sends
+type StreamFilter struct {
+	// MinSeverity drops less severe analyses; empty sends every severity
+	MinSeverity string
+	// Matchers must all match the labels every data point of the analysis shares
+	Matchers []LabelMatcher
+}
+
+// Matches reports whether the analysis passes the filter
+func (f StreamFilter) Matches(analysis *Analysis) bool {
+	if f.MinSeverity != "" && severityOrder[analysis.Severity] < severityOrder[f.MinSeverity] {
+		return false
+	}
+	if len(f.Matchers) == 0 {
+		return true
+	}
+	labels := analysisLabels(analysis)
+	for _, matcher := range f.Matchers {
+		if !matcher.Matches(labels[matcher.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// analysisSubscriber is one live stream; messages it cannot take are counted as dropped
+type analysisSubscriber struct {
+	options streamOptions
+	// analyses is nil for streams of events only, and events for streams of analyses only
+	analyses chan *Analysis
+	events   chan Event
+	dropped  int
+}
+
+// analysisFeed fans new analyses and framework events out to live streams without ever
+// blocking the pipeline
+type analysisFeed struct {
+	subscribers map[*analysisSubscriber]bool
+	mu          sync.Mutex
+}
+
+// newAnalysisFeed creates a feed with no subscribers
+func newAnalysisFeed() *analysisFeed {
+	return &analysisFeed{subscribers: make(map[*analysisSubscriber]bool)}
+}
+
+// subscribe adds a stream getting the analyses and event types the options ask for; the
+// returned function removes it
+func (f *analysisFeed) subscribe(options streamOptions) (*analysisSubscriber, func()) {
+	subscriber := &analysisSubscriber{options: options}
+	if options.analyses {
+		subscriber.analyses = make(chan *Analysis, streamBufferSize)
+	}
+	if options.events != nil {
+		subscriber.events = make(chan Event, streamBufferSize)
+	}
+	f.mu.Lock()
+	f.subscribers[subscriber] = true
+	f.mu.Unlock()
+	return subscriber, func() {
+		f.mu.Lock()
+		delete(f.subscribers, subscriber)
+		f.mu.Unlock()
+	}
+}
+
+// publish offers an analysis to every stream, dropping it for those whose buffer is full.
+// Streams get a copy, since responders may still add details to the original.
+func (f *analysisFeed) publish(analysis *Analysis) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.subscribers) == 0 {
+		return
+	}
+	copied := *analysis
+	copied.Details = make(map[string]interface{}, len(analysis.Details))
+	for key, value := range analysis.Details {
+		copied.Details[key] = value
+	}
+	analysis = &copied
+	for subscriber := range f.subscribers {
+		if subscriber.analyses == nil {
+			continue
+		}
+		select {
+		case subscriber.analyses <- analysis:
+		default:
+			subscriber.dropped++
+		}
+	}
+}
+
+// publishEvent offers a framework event to every stream that asked for its type
+func (f *analysisFeed) publishEvent(event Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for subscriber := range f.subscribers {
+		if subscriber.events == nil || !subscriber.options.wantsEvent(event.Type) {
+			continue
+		}
+		select {
+		case subscriber.events <- event:
+		default:
+			subscriber.dropped++
+		}
+	}
+}
+
+// takeDropped returns and clears the number of analyses dropped for a stream
+func (f *analysisFeed) takeDropped(subscriber *analysisSubscriber) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	dropped := subscriber.dropped
+	subscriber.dropped = 0
+	return dropped
+}
+
+// streamOptions are the query parameters of a stream
+type streamOptions struct {
+	filter StreamFilter
+	since  time.Time
+	rate   float64
+	// analyses is false for streams of events only
+	analyses bool
+	// events are globs of the event types to send; nil sends no events
+	events []string
+}
+
+// wantsEvent reports whether a stream sends an event of the given type. Streams that send
+// analyses skip analysis_created, since they send the analysis itself.
+func (o streamOptions) wantsEvent(eventType string) bool {
+	if o.analyses && eventType == EventAnalysisCreated {
+		return false
+	}
+	for _, pattern := range o.events {
+		if globMatches(pattern, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseStreamOptions reads the severity, labels, since, and rate query parameters and, for
+// streams with events, the events and analyses parameters
+func parseStreamOptions(r *http.Request, events bool) (streamOptions, error) {
+	options := streamOptions{rate: defaultStreamRate, analyses: true}
+	var err error
+	if options.filter, err = parseStreamFilter(r); err != nil {
+		return options, err
+	}
+
+	params := r.URL.Query()
+	if raw := params.Get("since"); raw != "" {
+		if window, err := time.ParseDuration(raw); err == nil {
+			options.since = time.Now().Add(-window)
+		} else if options.since, err = time.Parse(time.RFC3339, raw); err != nil {
+			return options, NewValidationError("stream", "options", "since must be an RFC 3339 time or a duration")
+		}
+	}
+	if raw := params.Get("rate"); raw != "" {
+		if options.rate, err = strconv.ParseFloat(raw, 64); err != nil || options.rate <= 0 {
+			return options, NewValidationError("stream", "options", "rate must be a positive number")
+		}
+	}
+	if !events {
+		return options, nil
+	}
+
+	options.events = []string{EventTypeAll}
+	if raw := params.Get("events"); raw != "" {
+		options.events = strings.Split(raw, ",")
+		for _, pattern := range options.events {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return options, NewValidationError("stream", "options", fmt.Sprintf("invalid event pattern %q", pattern))
+			}
+		}
+	}
+	if raw := params.Get("analyses"); raw != "" {
+		if options.analyses, err = strconv.ParseBool(raw); err != nil {
+			return options, NewValidationError("stream", "options", "analyses must be true or false")
+		}
+	}
+	return options, nil
+}
+
+// parseStreamFilter reads the severity and labels query parameters
+func parseStreamFilter(r *http.Request) (StreamFilter, error) {
+	filter := StreamFilter{MinSeverity: r.URL.Query().Get("severity")}
+	if _, ok := severityOrder[filter.MinSeverity]; filter.MinSeverity != "" && !ok {
+		return filter, NewValidationError("stream", "filter", fmt.Sprintf("unknown severity %q", filter.MinSeverity))
+	}
+	if selector := r.URL.Query().Get("labels"); selector != "" {
+		matchers, err := ParseMatchers(selector)
+		if err != nil {
+			return filter, err
+		}
+		filter.Matchers = matchers
+	}
+	return filter, nil
+}
+
+// handleAnalysisStream streams analyses over a WebSocket or as server-sent events as they
+// happen, after those since the since parameter. Streams are filtered by minimum severity
+// and a label selector, and limited to rate analyses per second so a storm cannot flood a
+// terminal.
+func (f *Framework) handleAnalysisStream(w http.ResponseWriter, r *http.Request) {
+	f.serveStream(w, r, false)
+}
+
+// handleStream streams analyses and framework events, such as plugins being loaded or the
+// data channel degrading. The events parameter limits the event types with globs, and
+// analyses=false sends events only.
+func (f *Framework) handleStream(w http.ResponseWriter, r *http.Request) {
+	f.serveStream(w, r, true)
+}
+
+// serveStream streams over a WebSocket when the client asks to upgrade, and as server-sent
+// events otherwise
+func (f *Framework) serveStream(w http.ResponseWriter, r *http.Request, events bool) {
+	options, err := parseStreamOptions(r, events)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		server := websocket.Server{Handler: func(conn *websocket.Conn) {
+			defer conn.Close()
+			// Clients never send anything; a read returning means they went away
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				var discard []byte
+				for websocket.Message.Receive(conn, &discard) == nil {
+				}
+			}()
+			f.stream(r.Context(), closed, options, func(message StreamMessage) error {
+				conn.SetWriteDeadline(time.Now().Add(streamWriteDeadline))
+				return websocket.JSON.Send(conn, message)
+			})
+		}}
+		server.ServeHTTP(w, r)
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := controller.Flush(); err != nil {
+		slog.Debug("Stream cannot be flushed", "error", err)
+		return
+	}
+	f.stream(r.Context(), nil, options, func(message StreamMessage) error {
+		controller.SetWriteDeadline(time.Now().Add(streamWriteDeadline))
+		if err := writeServerSentEvent(w, message); err != nil {
+			return err
+		}
+		return controller.Flush()
+	})
+}
+
+// writeServerSentEvent writes a message as a server-sent event named analysis, event, or
+// keepalive, with the message as JSON data
+func writeServerSentEvent(w io.Writer, message StreamMessage) error {
+	name := "keepalive"
+	switch {
+	case message.Analysis != nil:
+		name = "analysis"
+	case message.Event != nil:
+		name = "event"
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+	return err
+}
+
+// stream sends the recorded analyses since the given time, then new analyses and events
+// until the client disconnects or the framework stops. Server-sent event streams also get
+// an empty message now and then, so proxies keep idle connections open.
+func (f *Framework) stream(ctx context.Context, closed <-chan struct{}, options streamOptions, send func(StreamMessage) error) {
+	// Subscribe before reading the history so nothing falls between the two
+	subscriber, unsubscribe := f.feed.subscribe(options)
+	defer unsubscribe()
+
+	limiter := NewRateLimiter(RateLimiterConfig{Rate: options.rate, Burst: max(defaultStreamBurst, int(options.rate))})
+	dropped := 0
+	deliver := func(message StreamMessage) bool {
+		message.Dropped = dropped + f.feed.takeDropped(subscriber)
+		if err := send(message); err != nil {
+			slog.Debug("Stream closed", "error", err)
+			return false
+		}
+		dropped = 0
+		return true
+	}
+	sendAnalysis := func(analysis *Analysis) bool {
+		if !options.filter.Matches(analysis) {
+			return true
+		}
+		if !limiter.Allow() {
+			dropped++
+			return true
+		}
+		return deliver(StreamMessage{Analysis: analysis})
+	}
+
+	seen := make(map[string]bool)
+	if !options.since.IsZero() && options.analyses {
+		recorded, err := f.history.List(ctx, options.since)
+		if err != nil {
+			slog.Error("Failed to list analyses for stream", "error", err)
+		}
+		for i := range recorded {
+			seen[recorded[i].ID] = true
+			if !sendAnalysis(&recorded[i]) {
+				return
+			}
+		}
+	}
+
+	var keepalive <-chan time.Time
+	if closed == nil {
+		ticker := time.NewTicker(streamKeepalive)
+		defer ticker.Stop()
+		keepalive = ticker.C
+	}
+	stopped := f.ctxDone()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stopped:
+			return
+		case <-closed:
+			return
+		case <-keepalive:
+			if !deliver(StreamMessage{}) {
+				return
+			}
+		case analysis := <-subscriber.analyses:
+			if seen[analysis.ID] {
+				continue
+			}
+			if !sendAnalysis(analysis) {
+				return
+			}
+		case event := <-subscriber.events:
+			if !deliver(StreamMessage{Event: &event}) {
+				return
+			}
+		}
+	}
+}
+
+// ctxDone returns a channel closed when the framework stops, or nil before it starts
+func (f *Framework) ctxDone() <-chan struct{} {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.ctx == nil {
+		return nil
+	}
+	return f.ctx.Done()
+}
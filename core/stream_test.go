@@ -0,0 +1,176 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func TestStreamFilter_Matches(t *testing.T) {
+	matchers, err := ParseMatchers(`{service="api"}`)
+	require.NoError(t, err)
+	filter := StreamFilter{MinSeverity: "medium", Matchers: matchers}
+
+	analysis := &Analysis{Severity: "high", DataPoints: []DataPoint{
+		{Metric: "cpu", Labels: map[string]string{"service": "api", "instance": "a"}},
+		{Metric: "cpu", Labels: map[string]string{"service": "api", "instance": "b"}},
+	}}
+	assert.True(t, filter.Matches(analysis))
+
+	analysis.Severity = "low"
+	assert.False(t, filter.Matches(analysis), "Expected less severe analyses to be filtered out")
+
+	analysis.Severity = "critical"
+	analysis.DataPoints[1].Labels["service"] = "web"
+	assert.False(t, filter.Matches(analysis), "Expected labels the data points don't share to not match")
+}
+
+func TestFramework_AnalysisStream(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{
+		LogLevel:  "info",
+		LogFormat: "text",
+		LogOutput: "stdout",
+		APIKeys:   []APIKeyConfig{{Name: "ops", Token: "secret", Scopes: []string{"query"}}},
+	})
+	ctx := context.Background()
+	earlier := testAnalysis("cpu")
+	earlier.ID = "earlier"
+	earlier.Timestamp = time.Now().Add(-time.Minute)
+	require.NoError(t, framework.GetAnalysisHistory().Record(ctx, earlier))
+
+	server := httptest.NewServer(framework.Handler())
+	defer server.Close()
+	dial := func(query, token string) (*websocket.Conn, error) {
+		config, err := websocket.NewConfig("ws"+strings.TrimPrefix(server.URL, "http")+"/api/v1/analyses/stream?"+query, server.URL)
+		require.NoError(t, err)
+		config.Header.Set("Authorization", "Bearer "+token)
+		return websocket.DialConfig(config)
+	}
+
+	_, err := dial("", "wrong")
+	assert.Error(t, err, "Expected the stream to require a query token")
+
+	conn, err := dial("since=1h&severity=high", "secret")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var message StreamMessage
+	require.NoError(t, websocket.JSON.Receive(conn, &message))
+	require.NotNil(t, message.Analysis)
+	assert.Equal(t, "earlier", message.Analysis.ID, "Expected analyses since the given time first")
+
+	low := testAnalysis("memory")
+	low.ID, low.Severity = "low", "low"
+	live := testAnalysis("disk")
+	live.ID = "live"
+	require.Eventually(t, func() bool {
+		framework.feed.mu.Lock()
+		defer framework.feed.mu.Unlock()
+		return len(framework.feed.subscribers) == 1
+	}, time.Second, 10*time.Millisecond)
+	framework.feed.publish(low)
+	framework.feed.publish(live)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, websocket.JSON.Receive(conn, &message))
+	require.NotNil(t, message.Analysis)
+	assert.Equal(t, "live", message.Analysis.ID, "Expected analyses below the minimum severity to be skipped")
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/analyses/stream?severity=urgent", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	framework.Handler().ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestFramework_EventStream(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout"})
+	server := httptest.NewServer(framework.Handler())
+	// Closing waits for the streams, so it runs after they are cancelled
+	t.Cleanup(server.Close)
+
+	// open reads the server-sent events of a stream into a channel
+	open := func(query string) <-chan StreamMessage {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/stream?"+query, nil)
+		require.NoError(t, err)
+		response, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+		messages := make(chan StreamMessage, 10)
+		go func() {
+			defer response.Body.Close()
+			scanner := bufio.NewScanner(response.Body)
+			for scanner.Scan() {
+				if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+					var message StreamMessage
+					if json.Unmarshal([]byte(data), &message) == nil {
+						messages <- message
+					}
+				}
+			}
+		}()
+		return messages
+	}
+	receive := func(messages <-chan StreamMessage) StreamMessage {
+		select {
+		case message := <-messages:
+			return message
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected a stream message")
+			return StreamMessage{}
+		}
+	}
+
+	all := open("")
+	pluginEvents := open("events=plugin_*&analyses=false")
+	require.Eventually(t, func() bool {
+		framework.feed.mu.Lock()
+		defer framework.feed.mu.Unlock()
+		return len(framework.feed.subscribers) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	framework.publishEvent(EventConfigReloaded, map[string]interface{}{"changed": 1})
+	require.NoError(t, framework.LoadPlugin(&MockPlugin{name: "collector", pluginType: PluginTypeCollector, status: PluginStatusStopped}))
+	framework.feed.publish(testAnalysis("cpu"))
+
+	// Events keep their order, but may come before or after the analysis
+	var received []string
+	for i := 0; i < 3; i++ {
+		message := receive(all)
+		if message.Analysis != nil {
+			received = append(received, "analysis")
+		} else if message.Event != nil {
+			received = append(received, message.Event.Type)
+		}
+	}
+	assert.ElementsMatch(t, []string{EventConfigReloaded, EventPluginLoaded, "analysis"}, received)
+
+	message := receive(pluginEvents)
+	require.NotNil(t, message.Event, "Expected only the event types asked for")
+	assert.Equal(t, EventPluginLoaded, message.Event.Type)
+	assert.Equal(t, "collector", message.Event.Data["plugin_name"])
+	select {
+	case message := <-pluginEvents:
+		t.Fatalf("Expected no analyses on a stream of events only, got %+v", message)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	for _, query := range []string{"events=[", "analyses=maybe", "rate=0"} {
+		recorder := httptest.NewRecorder()
+		framework.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/stream?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, "Expected %s to be rejected", query)
+	}
+}
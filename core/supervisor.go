@@ -0,0 +1,312 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// maxSupervisorBackoff caps the wait between restarts when no max_backoff is configured
+const maxSupervisorBackoff = 24 * time.Hour
+
+// pluginSupervisor tracks failed plugins between the supervisor's checks
+type pluginSupervisor struct {
+	config SupervisorConfig
+
+	mu     sync.Mutex
+	states map[string]*supervisedPlugin
+}
+
+// supervisedPlugin is what the supervisor knows of a plugin that failed
+type supervisedPlugin struct {
+	plugin Plugin
+	// failing is set from the check that found the plugin failed until it recovers
+	failing bool
+	// restarts counts restarts since the plugin last recovered
+	restarts    int
+	nextRestart time.Time
+	quarantined bool
+	// quarantinedUntil is zero while a quarantine lasts until the agent restarts
+	quarantinedUntil time.Time
+	lastError        string
+}
+
+// PluginSupervision is the supervisor's view of a plugin, shown in the framework status
+type PluginSupervision struct {
+	Restarts         int        `json:"restarts"`
+	Quarantined      bool       `json:"quarantined"`
+	QuarantinedUntil *time.Time `json:"quarantined_until,omitempty"`
+	LastError        string     `json:"last_error,omitempty"`
+}
+
+func newPluginSupervisor(config SupervisorConfig) *pluginSupervisor {
+	return &pluginSupervisor{config: config, states: make(map[string]*supervisedPlugin)}
+}
+
+// backoff returns the wait after a plugin's restarts-th restart before the next one
+func (s *pluginSupervisor) backoff(restarts int) time.Duration {
+	maxBackoff := s.config.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = maxSupervisorBackoff
+	}
+	wait := s.config.InitialBackoff
+	for i := 1; i < restarts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
+
+// supervision returns the supervisor's view of a plugin it has seen fail
+func (s *pluginSupervisor) supervision(name string) (PluginSupervision, bool) {
+	if s == nil {
+		return PluginSupervision{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[name]
+	if !ok {
+		return PluginSupervision{}, false
+	}
+	supervision := PluginSupervision{Restarts: state.restarts, Quarantined: state.quarantined, LastError: state.lastError}
+	if state.quarantined && !state.quarantinedUntil.IsZero() {
+		until := state.quarantinedUntil
+		supervision.QuarantinedUntil = &until
+	}
+	return supervision, true
+}
+
+// supervisorWorker periodically checks plugins and restarts those that failed
+func (f *Framework) supervisorWorker(ctx context.Context) {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(f.config.Supervisor.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Supervisor worker stopping due to context cancellation")
+			return
+		case now := <-ticker.C:
+			f.supervisePlugins(ctx, now)
+		}
+	}
+}
+
+// supervisePlugins checks every loaded plugin once, restarting failed plugins whose backoff
+// has passed and quarantining those restarted too often without recovering
+func (f *Framework) supervisePlugins(ctx context.Context, now time.Time) {
+	f.mu.RLock()
+	shutdown := f.shutdown
+	f.mu.RUnlock()
+	if shutdown {
+		return
+	}
+
+	plugins := f.registry.ListPlugins()
+	loaded := make(map[string]bool, len(plugins))
+	for _, plugin := range plugins {
+		loaded[plugin.Name()] = true
+		f.supervisePlugin(ctx, plugin, now)
+	}
+
+	// Forget unloaded plugins, so one loaded again under the name starts afresh
+	f.supervisor.mu.Lock()
+	for name := range f.supervisor.states {
+		if !loaded[name] {
+			delete(f.supervisor.states, name)
+		}
+	}
+	f.supervisor.mu.Unlock()
+}
+
+// supervisePlugin checks one plugin and acts on its state
+func (f *Framework) supervisePlugin(ctx context.Context, plugin Plugin, now time.Time) {
+	s := f.supervisor
+	name := plugin.Name()
+
+	s.mu.Lock()
+	state, known := s.states[name]
+	if known && state.plugin != plugin {
+		// The plugin was replaced by a reload
+		delete(s.states, name)
+		known = false
+	}
+	if known && state.quarantined {
+		if state.quarantinedUntil.IsZero() || now.Before(state.quarantinedUntil) {
+			s.mu.Unlock()
+			return
+		}
+		// The quarantine is over; the plugin is started again with a fresh set of restarts
+		state.quarantined = false
+		state.restarts = 0
+		s.mu.Unlock()
+		f.metricsCollector.SetGauge("framework_plugin_quarantined", 0, map[string]string{"plugin": name})
+		slog.Info("Plugin quarantine ended, restarting it", "plugin", name)
+		f.restartFailedPlugin(plugin, now)
+		return
+	}
+	s.mu.Unlock()
+
+	err := f.pluginFailure(ctx, plugin)
+	if err == nil {
+		if known && state.failing {
+			s.mu.Lock()
+			restarts := state.restarts
+			delete(s.states, name)
+			s.mu.Unlock()
+			slog.Info("Plugin recovered", "plugin", name, "restarts", restarts)
+			f.publishEvent(EventPluginRecovered, map[string]interface{}{
+				"plugin_name": name,
+				"plugin_type": plugin.Type(),
+				"restarts":    restarts,
+			})
+		}
+		return
+	}
+
+	s.mu.Lock()
+	if !known {
+		state = &supervisedPlugin{plugin: plugin, nextRestart: now}
+		s.states[name] = state
+	}
+	newlyFailed := !state.failing
+	state.failing = true
+	state.lastError = f.secrets.redact(err.Error())
+	due := !now.Before(state.nextRestart)
+	quarantine := due && s.config.MaxRestarts > 0 && state.restarts >= s.config.MaxRestarts
+	if quarantine {
+		state.quarantined = true
+		state.quarantinedUntil = time.Time{}
+		if s.config.QuarantineDuration > 0 {
+			state.quarantinedUntil = now.Add(s.config.QuarantineDuration)
+		}
+	}
+	restarts := state.restarts
+	lastError := state.lastError
+	s.mu.Unlock()
+
+	if newlyFailed {
+		slog.Error("Plugin failed", "plugin", name, "error", lastError)
+		f.publishEvent(EventPluginFailed, map[string]interface{}{
+			"plugin_name": name,
+			"plugin_type": plugin.Type(),
+			"error":       lastError,
+		})
+	}
+	switch {
+	case quarantine:
+		f.quarantinePlugin(plugin, restarts, lastError)
+	case due:
+		f.restartFailedPlugin(plugin, now)
+	}
+}
+
+// pluginFailure returns why a plugin counts as failed, or nil if it is working
+func (f *Framework) pluginFailure(ctx context.Context, plugin Plugin) error {
+	f.mu.RLock()
+	start, started := f.pluginStarts[plugin.Name()]
+	f.mu.RUnlock()
+	if started && start.Err != nil {
+		return start.Err
+	}
+	if plugin.Status() == PluginStatusError {
+		return NewPluginError(plugin.Name(), "supervise", "plugin is in error state")
+	}
+
+	timeout := f.config.HealthCheckTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return plugin.Health(ctx)
+}
+
+// restartFailedPlugin restarts a failed plugin and schedules the next restart in case it
+// does not recover
+func (f *Framework) restartFailedPlugin(plugin Plugin, now time.Time) {
+	name := plugin.Name()
+	err := f.restartPlugin(plugin)
+
+	s := f.supervisor
+	s.mu.Lock()
+	state := s.states[name]
+	state.restarts++
+	state.nextRestart = now.Add(s.backoff(state.restarts))
+	restarts := state.restarts
+	next := state.nextRestart
+	s.mu.Unlock()
+
+	f.metricsCollector.IncrementCounter("framework_plugin_restarts_total", map[string]string{"plugin": name})
+	data := map[string]interface{}{
+		"plugin_name": name,
+		"plugin_type": plugin.Type(),
+		"restarts":    restarts,
+	}
+	if err != nil {
+		data["error"] = f.secrets.redact(err.Error())
+		slog.Error("Failed to restart plugin", "plugin", name, "restarts", restarts, "next_restart", next, "error", data["error"])
+	} else {
+		slog.Info("Plugin restarted", "plugin", name, "restarts", restarts)
+	}
+	f.publishEvent(EventPluginRestarted, data)
+}
+
+// restartPlugin stops a plugin and its worker and starts them again
+func (f *Framework) restartPlugin(plugin Plugin) error {
+	name := plugin.Name()
+	f.mu.Lock()
+	if stopWorker, ok := f.pluginWorkers[name]; ok {
+		stopWorker()
+		delete(f.pluginWorkers, name)
+	}
+	f.mu.Unlock()
+	if err := plugin.Stop(); err != nil {
+		slog.Warn("Failed to stop plugin before restarting it", "plugin", name, "error", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.running || f.shutdown {
+		return NewInternalError("framework", "restart", "framework is not running")
+	}
+	result := f.startPlugins(f.ctx, []Plugin{plugin})[name]
+	f.pluginStarts[name] = result
+	if result.Err != nil {
+		return result.Err
+	}
+	f.startPluginWorker(plugin)
+	return nil
+}
+
+// quarantinePlugin stops a plugin that kept failing after restarts, so it no longer holds up
+// the pipeline or floods the log
+func (f *Framework) quarantinePlugin(plugin Plugin, restarts int, lastError string) {
+	name := plugin.Name()
+	f.mu.Lock()
+	if stopWorker, ok := f.pluginWorkers[name]; ok {
+		stopWorker()
+		delete(f.pluginWorkers, name)
+	}
+	f.mu.Unlock()
+	if err := plugin.Stop(); err != nil {
+		slog.Warn("Failed to stop quarantined plugin", "plugin", name, "error", err)
+	}
+
+	until := "agent restart"
+	if duration := f.supervisor.config.QuarantineDuration; duration > 0 {
+		until = duration.String()
+	}
+	slog.Error("Plugin quarantined after failing to recover", "plugin", name, "restarts", restarts, "for", until)
+	f.metricsCollector.SetGauge("framework_plugin_quarantined", 1, map[string]string{"plugin": name})
+	f.publishEvent(EventPluginQuarantined, map[string]interface{}{
+		"plugin_name": name,
+		"plugin_type": plugin.Type(),
+		"restarts":    restarts,
+		"error":       lastError,
+		"reason":      fmt.Sprintf("still failing after %d restarts", restarts),
+	})
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingPlugin fails its health check while broken
+type failingPlugin struct {
+	MockPlugin
+	broken atomic.Bool
+	starts atomic.Int32
+}
+
+func (p *failingPlugin) Start(ctx context.Context) error {
+	p.starts.Add(1)
+	return p.MockPlugin.Start(ctx)
+}
+
+func (p *failingPlugin) Health(ctx context.Context) error {
+	if p.broken.Load() {
+		return fmt.Errorf("connection refused")
+	}
+	return nil
+}
+
+func TestPluginSupervisor_Backoff(t *testing.T) {
+	s := newPluginSupervisor(SupervisorConfig{InitialBackoff: 10 * time.Second, MaxBackoff: time.Minute})
+	assert.Equal(t, 10*time.Second, s.backoff(1))
+	assert.Equal(t, 20*time.Second, s.backoff(2))
+	assert.Equal(t, 40*time.Second, s.backoff(3))
+	assert.Equal(t, time.Minute, s.backoff(4))
+	assert.Equal(t, time.Minute, s.backoff(50))
+}
+
+func TestFramework_SupervisorRestartsFailedPlugins(t *testing.T) {
+	framework := NewFramework(&FrameworkConfig{
+		LogLevel: "info", LogFormat: "text", LogOutput: "stdout",
+		Supervisor: SupervisorConfig{
+			InitialBackoff:     10 * time.Second,
+			MaxBackoff:         time.Minute,
+			MaxRestarts:        2,
+			QuarantineDuration: time.Hour,
+		},
+	})
+	events := make(chan Event, 20)
+	for _, eventType := range []string{EventPluginFailed, EventPluginRestarted, EventPluginRecovered, EventPluginQuarantined} {
+		_, err := framework.eventBus.Subscribe(eventType, func(event Event) error {
+			events <- event
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	expectEvent := func(eventType string) Event {
+		t.Helper()
+		select {
+		case event := <-events:
+			require.Equal(t, eventType, event.Type)
+			return event
+		case <-time.After(time.Second):
+			t.Fatalf("Expected a %s event", eventType)
+			return Event{}
+		}
+	}
+
+	plugin := &failingPlugin{MockPlugin: MockPlugin{name: "ai", pluginType: PluginTypeAgent}}
+	require.NoError(t, framework.LoadPlugin(plugin))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, framework.Start(ctx))
+	defer framework.Stop()
+	require.Equal(t, int32(1), plugin.starts.Load())
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	framework.supervisePlugins(ctx, now)
+	assert.Equal(t, int32(1), plugin.starts.Load(), "Expected a working plugin to be left alone")
+
+	plugin.broken.Store(true)
+	framework.supervisePlugins(ctx, now)
+	assert.Equal(t, "connection refused", expectEvent(EventPluginFailed).Data["error"])
+	assert.Equal(t, 1, expectEvent(EventPluginRestarted).Data["restarts"])
+	assert.Equal(t, int32(2), plugin.starts.Load(), "Expected the first restart right away")
+
+	framework.supervisePlugins(ctx, now.Add(5*time.Second))
+	assert.Equal(t, int32(2), plugin.starts.Load(), "Expected no restart before the backoff")
+	framework.supervisePlugins(ctx, now.Add(10*time.Second))
+	expectEvent(EventPluginRestarted)
+	assert.Equal(t, int32(3), plugin.starts.Load())
+
+	// Two restarts without recovering quarantine the plugin
+	framework.supervisePlugins(ctx, now.Add(30*time.Second))
+	expectEvent(EventPluginQuarantined)
+	assert.Equal(t, PluginStatusStopped, plugin.Status())
+	summary := framework.Status().Plugins["ai"]
+	require.NotNil(t, summary.Supervision)
+	assert.True(t, summary.Supervision.Quarantined)
+	assert.Equal(t, "connection refused", summary.Supervision.LastError)
+	framework.supervisePlugins(ctx, now.Add(time.Minute))
+	assert.Equal(t, int32(3), plugin.starts.Load(), "Expected a quarantined plugin not to be restarted")
+
+	// Once the quarantine ends the plugin is started again, and recovers
+	plugin.broken.Store(false)
+	framework.supervisePlugins(ctx, now.Add(31*time.Second+time.Hour))
+	expectEvent(EventPluginRestarted)
+	assert.Equal(t, int32(4), plugin.starts.Load())
+	framework.supervisePlugins(ctx, now.Add(32*time.Second+time.Hour))
+	assert.Equal(t, 1, expectEvent(EventPluginRecovered).Data["restarts"])
+	assert.Nil(t, framework.Status().Plugins["ai"].Supervision)
+}
@@ -0,0 +1,234 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// tenantNamePattern keeps tenant names usable in URL paths and directory names
+var tenantNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// tenant is a team's pipeline, run by a framework of its own inside the process's. Its
+// framework has its own plugins, data channel, workers, and store; it shares the process's
+// plugin factory, secret backends, service discovery, tracer, and API keys.
+type tenant struct {
+	config    TenantConfig
+	framework *Framework
+	// loaded is set once the tenant's configured plugins have been loaded
+	loaded bool
+}
+
+// TenantSummary is a tenant's entry in the framework status and on /tenants
+type TenantSummary struct {
+	Name          string `json:"name"`
+	Running       bool   `json:"running"`
+	Plugins       int    `json:"plugins"`
+	QueuedBatches int    `json:"queued_batches"`
+}
+
+// tenantFrameworkConfig returns the configuration a tenant's framework runs with: the
+// framework's, with the tenant's plugins, pipelines, sizes, and store. API keys, servers,
+// fleet mode, tracing, and the debug log stay with the process's framework, and routes,
+// chains, budgets, and the default agent are left out since they name its plugins.
+func tenantFrameworkConfig(parent *FrameworkConfig, t TenantConfig) *FrameworkConfig {
+	config := *parent
+	config.Tenants = nil
+	config.Plugins = t.Plugins
+	config.Pipelines = t.Pipelines
+	if t.DataChannelSize > 0 {
+		config.DataChannelSize = t.DataChannelSize
+	}
+	if t.WorkerPoolSize > 0 {
+		config.WorkerPoolSize = t.WorkerPoolSize
+	}
+	config.Store = StoreConfig{Driver: "memory"}
+	if t.Store != nil {
+		config.Store = *t.Store
+	}
+
+	// The framework's keys guard the tenant's API, limited to those allowed to use it
+	config.APIKeys = nil
+	config.Fleet = FleetConfig{}
+	config.Tracing.Endpoint = ""
+	config.DebugLogPath = ""
+	config.DefaultAgent = ""
+	config.AnalyzerRoutes = nil
+	config.AnalyzerChains = nil
+	config.ResponderRoute = nil
+	config.PluginBudgets = nil
+
+	// Tenants keep their logged and spilled batches apart from the framework's
+	if config.WAL.Dir != "" {
+		config.WAL.Dir = filepath.Join(config.WAL.Dir, "tenants", t.Name)
+	}
+	if config.Backpressure.SpillDir != "" {
+		config.Backpressure.SpillDir = filepath.Join(config.Backpressure.SpillDir, "tenants", t.Name)
+	}
+	return &config
+}
+
+// initTenants creates the framework of each configured tenant
+func (f *Framework) initTenants() {
+	for _, config := range f.config.Tenants {
+		child := newFramework(tenantFrameworkConfig(f.config, config))
+		child.tenant = config.Name
+		child.factory = f.factory
+		child.secrets = f.secrets
+		child.discovery = f.discovery
+		child.tracer = f.tracer
+		child.apiKeys = f.apiKeys.forTenant(config.Name)
+		f.tenants = append(f.tenants, &tenant{config: config, framework: child})
+	}
+}
+
+// Tenant returns the framework running a tenant's pipeline
+func (f *Framework) Tenant(name string) (*Framework, bool) {
+	for _, t := range f.tenants {
+		if t.config.Name == name {
+			return t.framework, true
+		}
+	}
+	return nil, false
+}
+
+// startTenants loads each tenant's configured plugins, the first time, and starts its
+// framework. Plugins that fail to load or start are reported as tenant/plugin, and a
+// tenant that fails to start as a whole under its name.
+func (f *Framework) startTenants(ctx context.Context) []PluginStartResult {
+	var failures []PluginStartResult
+	for _, t := range f.tenants {
+		if !t.loaded {
+			for _, plugin := range t.config.Plugins {
+				if !plugin.Enabled {
+					continue
+				}
+				if err := t.framework.LoadPluginFromConfig(plugin); err != nil {
+					failures = append(failures, PluginStartResult{Plugin: t.config.Name + "/" + plugin.Name, Err: err})
+				}
+			}
+			t.loaded = true
+		}
+
+		err := t.framework.Start(ctx)
+		var startErr *PluginStartError
+		switch {
+		case errors.As(err, &startErr):
+			for _, failure := range startErr.Failures {
+				failure.Plugin = t.config.Name + "/" + failure.Plugin
+				failures = append(failures, failure)
+			}
+		case err != nil:
+			failures = append(failures, PluginStartResult{Plugin: t.config.Name, Err: err})
+		}
+	}
+	return failures
+}
+
+// stopTenants stops the running tenants' frameworks concurrently, each within its own
+// shutdown timeout
+func (f *Framework) stopTenants() {
+	var wg sync.WaitGroup
+	for _, t := range f.tenants {
+		t.framework.mu.RLock()
+		running := t.framework.running
+		t.framework.mu.RUnlock()
+		if !running {
+			continue
+		}
+
+		wg.Add(1)
+		go func(t *tenant) {
+			defer wg.Done()
+			if err := t.framework.Stop(); err != nil {
+				slog.Error("Failed to stop tenant", "tenant", t.config.Name, "error", err)
+			}
+		}(t)
+	}
+	wg.Wait()
+}
+
+// tenantSummaries returns the status of each tenant in configured order
+func (f *Framework) tenantSummaries() []TenantSummary {
+	summaries := make([]TenantSummary, 0, len(f.tenants))
+	for _, t := range f.tenants {
+		child := t.framework
+		child.mu.RLock()
+		summaries = append(summaries, TenantSummary{
+			Name:          t.config.Name,
+			Running:       child.running && !child.shutdown,
+			Plugins:       child.registry.GetPluginCount(),
+			QueuedBatches: len(child.dataChannel),
+		})
+		child.mu.RUnlock()
+	}
+	return summaries
+}
+
+// registerTenantRoutes serves the list of tenants and each tenant's endpoints and API
+// under /tenants/<name>/. A tenant's API takes the keys allowed to use it.
+func (f *Framework) registerTenantRoutes(mux *http.ServeMux) {
+	if len(f.tenants) == 0 {
+		return
+	}
+	mux.HandleFunc("/tenants", f.apiKeys.Require(APIScopeQuery, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, f.tenantSummaries())
+	}))
+	for _, t := range f.tenants {
+		prefix := "/tenants/" + t.config.Name
+		mux.Handle(prefix+"/", http.StripPrefix(prefix, t.framework.Handler()))
+	}
+}
+
+// applyTenants applies the plugin changes of tenants in both configurations, reporting
+// them as tenant/plugin. Tenants added or removed, and changes to their other settings,
+// take effect after a restart.
+func (f *Framework) applyTenants(ctx context.Context, config *FrameworkConfig, reload *ConfigReload) {
+	for _, t := range f.tenants {
+		for _, updated := range config.Tenants {
+			if updated.Name != t.config.Name {
+				continue
+			}
+			tenantReload, err := t.framework.ApplyConfig(ctx, tenantFrameworkConfig(config, updated))
+			if err != nil {
+				reload.Failed[t.config.Name] = err.Error()
+				continue
+			}
+			prefix := t.config.Name + "/"
+			reload.Added = append(reload.Added, prefixed(prefix, tenantReload.Added)...)
+			reload.Removed = append(reload.Removed, prefixed(prefix, tenantReload.Removed)...)
+			reload.Reconfigured = append(reload.Reconfigured, prefixed(prefix, tenantReload.Reconfigured)...)
+			reload.Replaced = append(reload.Replaced, prefixed(prefix, tenantReload.Replaced)...)
+			for name, reason := range tenantReload.Failed {
+				reload.Failed[prefix+name] = reason
+			}
+		}
+	}
+}
+
+// tenantsWithPlugins returns the current tenants with the plugins of the same-named
+// updated tenants, which a reload applies
+func tenantsWithPlugins(current, updated []TenantConfig) []TenantConfig {
+	tenants := append([]TenantConfig(nil), current...)
+	for i := range tenants {
+		for _, t := range updated {
+			if t.Name == tenants[i].Name {
+				tenants[i].Plugins = t.Plugins
+			}
+		}
+	}
+	return tenants
+}
+
+// prefixed returns the names with the prefix
+func prefixed(prefix string, names []string) []string {
+	result := make([]string, len(names))
+	for i, name := range names {
+		result[i] = prefix + name
+	}
+	return result
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tenantsConfig returns a valid configuration with tenants a and b, each with a responder
+func tenantsConfig() *FrameworkConfig {
+	config := reloadConfig()
+	config.Tenants = []TenantConfig{
+		{Name: "a", DataChannelSize: 5, Plugins: []PluginConfig{{Name: "notify", Type: "responder", Enabled: true}}},
+		{Name: "b", Plugins: []PluginConfig{{Name: "notify", Type: "responder", Enabled: true}}},
+	}
+	config.APIKeys = []APIKeyConfig{
+		{Name: "ops", Token: "ops-token", Scopes: []string{"admin"}},
+		{Name: "team-a", Token: "a-token", Scopes: []string{"query"}, Tenants: []string{"a"}},
+	}
+	return config
+}
+
+func TestFramework_Tenants(t *testing.T) {
+	framework := NewFramework(tenantsConfig())
+	require.NoError(t, framework.GetFactory().RegisterPluginCreator("responder", func(config PluginConfig) (Plugin, error) {
+		return &MockPlugin{name: config.Name, pluginType: PluginTypeResponder}, nil
+	}))
+	require.NoError(t, framework.Start(context.Background()))
+
+	a, ok := framework.Tenant("a")
+	require.True(t, ok)
+	b, ok := framework.Tenant("b")
+	require.True(t, ok)
+	assert.Equal(t, 5, cap(a.dataChannel), "Expected the tenant's own data channel size")
+	assert.Equal(t, 100, cap(b.dataChannel), "Expected the framework's data channel size by default")
+	assert.Equal(t, 0, framework.GetRegistry().GetPluginCount(), "Expected tenant plugins to stay out of the framework")
+	notifyA, err := a.GetRegistry().GetPlugin("notify")
+	require.NoError(t, err)
+	notifyB, err := b.GetRegistry().GetPlugin("notify")
+	require.NoError(t, err)
+	assert.NotSame(t, notifyA, notifyB, "Expected each tenant to create its own plugins")
+	assert.Equal(t, PluginStatusRunning, notifyA.Status())
+
+	assert.Equal(t, []TenantSummary{
+		{Name: "a", Running: true, Plugins: 1},
+		{Name: "b", Running: true, Plugins: 1},
+	}, framework.Status().Tenants)
+
+	require.NoError(t, framework.Stop())
+	assert.False(t, a.Status().Running, "Expected tenants to stop with the framework")
+	assert.Equal(t, PluginStatusStopped, notifyB.Status())
+}
+
+func TestFramework_TenantAPIScoping(t *testing.T) {
+	framework := NewFramework(tenantsConfig())
+	handler := framework.Handler()
+
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+	}{
+		{name: "tenant key on its tenant", path: "/tenants/a/api/v1/analyses", token: "a-token", status: http.StatusOK},
+		{name: "tenant key on another tenant", path: "/tenants/b/api/v1/analyses", token: "a-token", status: http.StatusForbidden},
+		{name: "tenant key on the framework", path: "/api/v1/analyses", token: "a-token", status: http.StatusForbidden},
+		{name: "tenant key listing tenants", path: "/tenants", token: "a-token", status: http.StatusForbidden},
+		{name: "unlimited key on a tenant", path: "/tenants/b/api/v1/analyses", token: "ops-token", status: http.StatusOK},
+		{name: "unknown tenant", path: "/tenants/c/api/v1/analyses", token: "ops-token", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("X-API-Key", tt.token)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/tenants", nil)
+	req.Header.Set("X-API-Key", "ops-token")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var tenants []TenantSummary
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&tenants))
+	require.Len(t, tenants, 2)
+	assert.Equal(t, "a", tenants[0].Name)
+
+	for _, usage := range framework.GetAPIKeys().Usage() {
+		if usage.Name == "team-a" {
+			assert.Equal(t, []string{"a"}, usage.Tenants)
+			assert.Equal(t, int64(3), usage.Forbidden, "Expected tenants to share the framework's usage counters")
+		}
+	}
+}
+
+func TestFramework_ApplyConfigTenants(t *testing.T) {
+	config := tenantsConfig()
+	framework := NewFramework(config)
+	require.NoError(t, framework.GetFactory().RegisterPluginCreator("responder", func(config PluginConfig) (Plugin, error) {
+		return &MockPlugin{name: config.Name, pluginType: PluginTypeResponder}, nil
+	}))
+	require.NoError(t, framework.Start(context.Background()))
+	defer framework.Stop()
+
+	updated := tenantsConfig()
+	updated.Tenants[0].Plugins = append(updated.Tenants[0].Plugins, PluginConfig{Name: "page", Type: "responder", Enabled: true})
+	reload, err := framework.ApplyConfig(context.Background(), updated)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/page"}, reload.Added)
+	assert.Empty(t, reload.RestartRequired, "Expected tenant plugin changes to apply without a restart")
+	a, _ := framework.Tenant("a")
+	_, err = a.GetRegistry().GetPlugin("page")
+	assert.NoError(t, err)
+
+	updated = tenantsConfig()
+	updated.Tenants[0].Plugins = append(updated.Tenants[0].Plugins, PluginConfig{Name: "page", Type: "responder", Enabled: true})
+	updated.Tenants[1].WorkerPoolSize = 8
+	reload, err = framework.ApplyConfig(context.Background(), updated)
+	require.NoError(t, err)
+	assert.Empty(t, reload.Added)
+	assert.Equal(t, []string{"tenants"}, reload.RestartRequired)
+}
+
+func TestValidateFrameworkConfig_Tenants(t *testing.T) {
+	config := tenantsConfig()
+	require.NoError(t, ValidateFrameworkConfig(config))
+
+	config.Tenants[1].Name = "a"
+	assert.ErrorContains(t, ValidateFrameworkConfig(config), "duplicate tenant")
+
+	config = tenantsConfig()
+	config.Tenants[1].Name = "Team B"
+	assert.ErrorContains(t, ValidateFrameworkConfig(config), "lowercase")
+
+	config = tenantsConfig()
+	config.APIKeys[1].Tenants = []string{"c"}
+	assert.ErrorContains(t, ValidateFrameworkConfig(config), "unknown tenant c")
+
+	config = tenantsConfig()
+	config.Tenants[0].Plugins[0].Type = "printer"
+	assert.ErrorContains(t, ValidateFrameworkConfig(config), "tenant a is invalid")
+}
@@ -0,0 +1,265 @@
+package core
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// tlsReloadInterval is how often certificate files are checked for changes
+var tlsReloadInterval = 10 * time.Second
+
+// ServerTLSConfig configures TLS for the framework's HTTP and gRPC servers
+type ServerTLSConfig struct {
+	// Certificate and key served to clients; an empty CertFile serves plain HTTP
+	CertFile string `yaml:"cert_file" env:"AGENT_TLS_CERT_FILE"`
+	KeyFile  string `yaml:"key_file" env:"AGENT_TLS_KEY_FILE"`
+	// CA bundle that client certificates must chain to; setting it requires mutual TLS
+	ClientCAFile string `yaml:"client_ca_file" env:"AGENT_TLS_CLIENT_CA_FILE"`
+	// Lowest TLS version accepted, 1.2 or 1.3
+	MinVersion string `yaml:"min_version" env:"AGENT_TLS_MIN_VERSION" validate:"omitempty,oneof=1.2 1.3"`
+}
+
+// Enabled reports whether the servers should use TLS
+func (c ServerTLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// NewServerTLSConfig builds the servers' TLS configuration, or nil when TLS is off. The
+// certificate and client CA bundle are reloaded when their files change.
+func NewServerTLSConfig(config ServerTLSConfig) (*tls.Config, error) {
+	if !config.Enabled() {
+		return nil, nil
+	}
+	if config.KeyFile == "" {
+		return nil, NewConfigurationError("tls", "server-config", "tls cert_file needs a key_file")
+	}
+
+	certificate, err := newKeyPairReloader(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return certificate.Certificate(), nil
+		},
+	}
+	if config.MinVersion == "1.3" {
+		tlsConfig.MinVersion = tls.VersionTLS13
+	}
+
+	if config.ClientCAFile != "" {
+		clientCAs, err := newCAPoolReloader(config.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		// Client certificates are verified against the current bundle rather than a
+		// fixed ClientCAs pool, so a rotated CA takes effect without a restart
+		tlsConfig.ClientAuth = tls.RequireAnyClientCert
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return fmt.Errorf("client certificate required")
+			}
+			intermediates := x509.NewCertPool()
+			for _, cert := range state.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+			_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+				Roots:         clientCAs.Pool(),
+				Intermediates: intermediates,
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			})
+			return err
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// NewClientTLSConfig builds TLS settings for outbound connections from plugin
+// configuration. It accepts server_name, insecure_skip_verify, ca_file, and a
+// cert_file/key_file client certificate, which is reloaded when its files change.
+func NewClientTLSConfig(settings map[string]interface{}) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if serverName, ok := settings["server_name"].(string); ok {
+		tlsConfig.ServerName = serverName
+	}
+	if skip, ok := settings["insecure_skip_verify"].(bool); ok {
+		tlsConfig.InsecureSkipVerify = skip
+	}
+
+	if caFile, ok := settings["ca_file"].(string); ok && caFile != "" {
+		pool, err := loadCAPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	certFile, _ := settings["cert_file"].(string)
+	keyFile, _ := settings["key_file"].(string)
+	if certFile != "" || keyFile != "" {
+		certificate, err := newKeyPairReloader(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return certificate.Certificate(), nil
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// fileReloader reruns load when any of its files changes. Changes are noticed on use, at
+// most every tlsReloadInterval; a failed reload keeps the previous value.
+type fileReloader struct {
+	files []string
+	load  func() error
+
+	mu       sync.Mutex
+	modTimes []time.Time
+	checked  time.Time
+}
+
+func newFileReloader(load func() error, files ...string) (*fileReloader, error) {
+	r := &fileReloader{files: files, load: load}
+	r.modTimes, _ = r.stat()
+	if err := load(); err != nil {
+		return nil, err
+	}
+	r.checked = time.Now()
+	return r, nil
+}
+
+// refresh reloads the files if they changed since the last load
+func (r *fileReloader) refresh() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checked) < tlsReloadInterval {
+		return
+	}
+	r.checked = time.Now()
+
+	modTimes, err := r.stat()
+	if err != nil {
+		slog.Warn("Failed to check TLS files for changes", "files", r.files, "error", err)
+		return
+	}
+	changed := len(modTimes) != len(r.modTimes)
+	for i := 0; !changed && i < len(modTimes); i++ {
+		if !modTimes[i].Equal(r.modTimes[i]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	if err := r.load(); err != nil {
+		slog.Warn("Failed to reload TLS files, keeping the previous ones", "files", r.files, "error", err)
+		return
+	}
+	r.modTimes = modTimes
+	slog.Info("Reloaded TLS files", "files", r.files)
+}
+
+func (r *fileReloader) stat() ([]time.Time, error) {
+	modTimes := make([]time.Time, len(r.files))
+	for i, file := range r.files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// keyPairReloader serves a certificate and key from disk
+type keyPairReloader struct {
+	*fileReloader
+	mu          sync.RWMutex
+	certificate *tls.Certificate
+}
+
+func newKeyPairReloader(certFile, keyFile string) (*keyPairReloader, error) {
+	k := &keyPairReloader{}
+	reloader, err := newFileReloader(func() error {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return NewConfigurationError("tls", "load-certificate", fmt.Sprintf("failed to load certificate %s: %v", certFile, err))
+		}
+		k.mu.Lock()
+		k.certificate = &cert
+		k.mu.Unlock()
+		return nil
+	}, certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	k.fileReloader = reloader
+	return k, nil
+}
+
+// Certificate returns the current certificate, reloading it if the files changed
+func (k *keyPairReloader) Certificate() *tls.Certificate {
+	k.refresh()
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.certificate
+}
+
+// caPoolReloader serves a CA bundle from disk
+type caPoolReloader struct {
+	*fileReloader
+	mu   sync.RWMutex
+	pool *x509.CertPool
+}
+
+func newCAPoolReloader(caFile string) (*caPoolReloader, error) {
+	c := &caPoolReloader{}
+	reloader, err := newFileReloader(func() error {
+		pool, err := loadCAPool(caFile)
+		if err != nil {
+			return err
+		}
+		c.mu.Lock()
+		c.pool = pool
+		c.mu.Unlock()
+		return nil
+	}, caFile)
+	if err != nil {
+		return nil, err
+	}
+	c.fileReloader = reloader
+	return c, nil
+}
+
+// Pool returns the current CA pool, reloading it if the file changed
+func (c *caPoolReloader) Pool() *x509.CertPool {
+	c.refresh()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.pool
+}
+
+// loadCAPool reads a PEM bundle of CA certificates
+func loadCAPool(caFile string) (*x509.CertPool, error) {
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, NewConfigurationError("tls", "load-ca", fmt.Sprintf("failed to read CA file: %v", err))
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, NewConfigurationError("tls", "load-ca", fmt.Sprintf("no certificates found in CA file %s", caFile))
+	}
+	return pool, nil
+}
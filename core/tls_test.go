@@ -0,0 +1,164 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA issues certificates for TLS tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue writes a certificate and key signed by the CA to dir, returning their paths
+func (ca *testCA) issue(t *testing.T, dir, name string, usage x509.ExtKeyUsage) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func (ca *testCA) write(t *testing.T, path string) string {
+	require.NoError(t, os.WriteFile(path, ca.pem, 0o600))
+	return path
+}
+
+// serveTLS serves a handler answering 200 with the given server TLS configuration,
+// returning its URL
+func serveTLS(t *testing.T, tlsConfig *tls.Config) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &http.Server{
+		Handler:  http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }),
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+	go server.Serve(tls.NewListener(listener, tlsConfig))
+	t.Cleanup(func() { server.Close() })
+	return "https://" + listener.Addr().String()
+}
+
+// get requests url with a client built from plugin-style TLS settings
+func get(t *testing.T, url string, settings map[string]interface{}) error {
+	tlsConfig, err := NewClientTLSConfig(settings)
+	require.NoError(t, err)
+	client := &http.Client{Timeout: 5 * time.Second, Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func TestServerTLSConfig_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "agent-ca")
+	caFile := ca.write(t, filepath.Join(dir, "ca.crt"))
+	serverCert, serverKey := ca.issue(t, dir, "server", x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, dir, "client", x509.ExtKeyUsageClientAuth)
+	otherCA := newTestCA(t, "other-ca")
+	otherCert, otherKey := otherCA.issue(t, dir, "other", x509.ExtKeyUsageClientAuth)
+
+	tlsConfig, err := NewServerTLSConfig(ServerTLSConfig{CertFile: serverCert, KeyFile: serverKey, ClientCAFile: caFile})
+	require.NoError(t, err)
+	url := serveTLS(t, tlsConfig)
+
+	assert.NoError(t, get(t, url, map[string]interface{}{"ca_file": caFile, "cert_file": clientCert, "key_file": clientKey}))
+	assert.Error(t, get(t, url, map[string]interface{}{"ca_file": caFile}), "Expected a client without a certificate to be rejected")
+	assert.Error(t, get(t, url, map[string]interface{}{"ca_file": caFile, "cert_file": otherCert, "key_file": otherKey}),
+		"Expected a certificate from another CA to be rejected")
+	assert.Error(t, get(t, url, map[string]interface{}{"cert_file": clientCert, "key_file": clientKey}),
+		"Expected the server certificate to be checked against the system roots")
+}
+
+func TestServerTLSConfig_Reload(t *testing.T) {
+	dir := t.TempDir()
+	oldCA := newTestCA(t, "old-ca")
+	newCA := newTestCA(t, "new-ca")
+	certFile, keyFile := oldCA.issue(t, dir, "server", x509.ExtKeyUsageServerAuth)
+	oldCAFile := oldCA.write(t, filepath.Join(dir, "old-ca.crt"))
+	newCAFile := newCA.write(t, filepath.Join(dir, "new-ca.crt"))
+
+	tlsConfig, err := NewServerTLSConfig(ServerTLSConfig{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.3"})
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MinVersion)
+	url := serveTLS(t, tlsConfig)
+	require.NoError(t, get(t, url, map[string]interface{}{"ca_file": oldCAFile}))
+
+	// Rotate the certificate in place; it is picked up on the next check
+	newCA.issue(t, dir, "server", x509.ExtKeyUsageServerAuth)
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+	require.NoError(t, os.Chtimes(keyFile, later, later))
+	assert.NoError(t, get(t, url, map[string]interface{}{"ca_file": oldCAFile}), "Expected the old certificate until the next check")
+
+	// Check the files on every handshake from here on
+	defer func(interval time.Duration) { tlsReloadInterval = interval }(tlsReloadInterval)
+	tlsReloadInterval = 0
+	assert.NoError(t, get(t, url, map[string]interface{}{"ca_file": newCAFile}))
+	assert.Error(t, get(t, url, map[string]interface{}{"ca_file": oldCAFile}))
+
+	// A broken rotation keeps serving the last good certificate
+	require.NoError(t, os.WriteFile(keyFile, []byte("not a key"), 0o600))
+	later = later.Add(time.Minute)
+	require.NoError(t, os.Chtimes(keyFile, later, later))
+	assert.NoError(t, get(t, url, map[string]interface{}{"ca_file": newCAFile}))
+}
@@ -0,0 +1,231 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// tracerName names the framework's tracer, the instrumentation scope of its spans
+const tracerName = "github.com/habruzzo/agent/core"
+
+// traceContext reads and writes W3C traceparent headers
+var traceContext = propagation.TraceContext{}
+
+// TracingConfig configures OpenTelemetry tracing of the data pipeline and agent queries
+type TracingConfig struct {
+	// OTLP/HTTP endpoint spans are exported to, such as http://otel-collector:4318;
+	// tracing is off when empty
+	Endpoint string `yaml:"endpoint" env:"AGENT_TRACING_ENDPOINT" validate:"omitempty,url"`
+	// Headers sent with every export, such as the API key of a hosted backend
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// service.name of the exported spans
+	ServiceName string `yaml:"service_name" env:"AGENT_TRACING_SERVICE_NAME" envDefault:"ops-agent"`
+	// Fraction of traces kept, between 0 and 1; unset keeps every trace
+	SampleRatio float64 `yaml:"sample_ratio" env:"AGENT_TRACING_SAMPLE_RATIO" validate:"min=0,max=1"`
+}
+
+// newTracerProvider creates a provider exporting spans over OTLP, or nil when tracing is off
+func newTracerProvider(config TracingConfig) (*sdktrace.TracerProvider, error) {
+	if config.Endpoint == "" {
+		return nil, nil
+	}
+
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(config.Endpoint)}
+	if len(config.Headers) > 0 {
+		options = append(options, otlptracehttp.WithHeaders(config.Headers))
+	}
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+	if err != nil {
+		return nil, NewConfigurationError("tracing", "exporter", err.Error())
+	}
+
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = "ops-agent"
+	}
+	sampler := sdktrace.AlwaysSample()
+	if config.SampleRatio > 0 && config.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(config.SampleRatio)
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	), nil
+}
+
+// initTracing sets up the tracer of the framework's tracing configuration. Spans go to a
+// no-op tracer when tracing is off or the exporter cannot be created.
+func (f *Framework) initTracing() {
+	provider, err := newTracerProvider(f.config.Tracing)
+	if err != nil {
+		slog.Error("Failed to set up tracing", "endpoint", f.config.Tracing.Endpoint, "error", err)
+	}
+	if provider == nil {
+		f.tracer = noop.NewTracerProvider().Tracer(tracerName)
+		return
+	}
+	f.tracerProvider = provider
+	f.tracer = provider.Tracer(tracerName)
+}
+
+// SetTracerProvider makes the framework trace with the provider, replacing the one built
+// from its tracing configuration. Call it before Start.
+func (f *Framework) SetTracerProvider(provider trace.TracerProvider) {
+	f.tracer = provider.Tracer(tracerName)
+	for _, tenant := range f.tenants {
+		tenant.framework.tracer = f.tracer
+	}
+}
+
+// ContextManager returns the framework's ContextManager, which reads trace and span IDs
+// from contexts the framework passes to plugins
+func (f *Framework) ContextManager() ContextManager {
+	return tracingContextManager{}
+}
+
+// shutdownTracing exports the spans still buffered and stops the exporter
+func (f *Framework) shutdownTracing() {
+	if f.tracerProvider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := f.tracerProvider.Shutdown(ctx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+}
+
+// startSpan starts a span of the framework's tracer. The returned context also carries the
+// trace ID the debug log records, which is the span's trace ID when it is sampled.
+func (f *Framework) startSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := f.tracer
+	if tracer == nil {
+		tracer = noop.NewTracerProvider().Tracer(tracerName)
+	}
+
+	ctx, span := tracer.Start(ctx, name, trace.WithAttributes(attributes...))
+	if spanContext := span.SpanContext(); spanContext.IsValid() {
+		return WithTraceID(ctx, spanContext.TraceID().String()), span
+	}
+	if TraceIDFromContext(ctx) == "" {
+		ctx = WithTraceID(ctx, NewTraceID())
+	}
+	return ctx, span
+}
+
+// endSpan ends a span, marking it failed when err is set
+func endSpan(span trace.Span, err error) {
+	failSpan(span, err)
+	span.End()
+}
+
+// failSpan marks a span failed when err is set
+func failSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// tagBatch remembers the span a batch was collected or ingested in, so processing the
+// batch continues its trace. Batches are recognized by their first data point, as in the WAL.
+func (f *Framework) tagBatch(ctx context.Context, data []DataPoint) {
+	if len(data) == 0 {
+		return
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		f.batchSpans.Store(&data[0], spanContext)
+	}
+}
+
+// batchContext returns ctx carrying the span a batch was tagged with, if any
+func (f *Framework) batchContext(ctx context.Context, data []DataPoint) context.Context {
+	if len(data) == 0 {
+		return ctx
+	}
+	if spanContext, ok := f.batchSpans.LoadAndDelete(&data[0]); ok {
+		return trace.ContextWithSpanContext(ctx, spanContext.(trace.SpanContext))
+	}
+	return ctx
+}
+
+// withTraceContext continues the trace of requests carrying a traceparent header
+func withTraceContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("traceparent") != "" {
+			r = r.WithContext(traceContext.Extract(r.Context(), propagation.HeaderCarrier(r.Header)))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tracingContextManager implements ContextManager over OpenTelemetry span contexts, falling
+// back to the debug log's trace IDs when a context has no span
+type tracingContextManager struct{}
+
+func (tracingContextManager) WithContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+func (tracingContextManager) WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, timeout)
+}
+
+func (tracingContextManager) WithCancel(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithCancel(ctx)
+}
+
+func (tracingContextManager) GetTraceID(ctx context.Context) string {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		return spanContext.TraceID().String()
+	}
+	return TraceIDFromContext(ctx)
+}
+
+func (tracingContextManager) GetSpanID(ctx context.Context) string {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasSpanID() {
+		return spanContext.SpanID().String()
+	}
+	return ""
+}
+
+// traceLogHandler adds the trace and span IDs of the context to log records, so logs
+// written with slog's Context functions can be found from a trace
+type traceLogHandler struct {
+	slog.Handler
+}
+
+func (h traceLogHandler) Handle(ctx context.Context, record slog.Record) error {
+	var manager tracingContextManager
+	if traceID := manager.GetTraceID(ctx); traceID != "" {
+		record.AddAttrs(slog.String("trace_id", traceID))
+	}
+	if spanID := manager.GetSpanID(ctx); spanID != "" {
+		record.AddAttrs(slog.String("span_id", spanID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h traceLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceLogHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceLogHandler) WithGroup(name string) slog.Handler {
+	return traceLogHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTracedFramework returns a framework recording its spans, with an analyzer reporting
+// every batch to a responder
+func newTracedFramework(t *testing.T) (*Framework, *tracetest.SpanRecorder, *severityResponder) {
+	t.Helper()
+	framework := NewFramework(&FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout", DataChannelSize: 1})
+	recorder := tracetest.NewSpanRecorder()
+	framework.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	responder := &severityResponder{MockPlugin: MockPlugin{name: "log", pluginType: PluginTypeResponder}, severity: "low"}
+	require.NoError(t, framework.LoadPlugin(&reportingAnalyzer{MockAnalyzer{MockPlugin{name: "spikes", pluginType: PluginTypeAnalyzer}}}))
+	require.NoError(t, framework.LoadPlugin(responder))
+	return framework, recorder, responder
+}
+
+// spansByName indexes ended spans by name
+func spansByName(recorder *tracetest.SpanRecorder) map[string]sdktrace.ReadOnlySpan {
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	return spans
+}
+
+func TestFramework_TracesPipeline(t *testing.T) {
+	framework, recorder, responder := newTracedFramework(t)
+
+	data := []DataPoint{{Metric: "cpu", Value: 99}}
+	ctx, collect := framework.startSpan(context.Background(), "collect")
+	require.NoError(t, framework.enqueue(ctx, data))
+	collect.End()
+	framework.processData(context.Background(), <-framework.GetDataChannel())
+	_, tagged := framework.batchSpans.Load(&data[0])
+	assert.False(t, tagged, "Expected the processed batch's span to be forgotten")
+
+	spans := spansByName(recorder)
+	require.Contains(t, spans, "process")
+	require.Contains(t, spans, "analyze")
+	require.Contains(t, spans, "respond")
+	traceID := collect.SpanContext().TraceID()
+	assert.Equal(t, collect.SpanContext().SpanID(), spans["process"].Parent().SpanID(), "Expected processing to continue the collection's trace")
+	assert.Equal(t, spans["process"].SpanContext().SpanID(), spans["analyze"].Parent().SpanID())
+	for _, name := range []string{"process", "analyze", "respond"} {
+		assert.Equal(t, traceID, spans[name].SpanContext().TraceID(), name)
+	}
+
+	require.Len(t, responder.handled, 1)
+	assert.Equal(t, traceID.String(), responder.handled[0].Details["trace_id"], "Expected the debug log trace ID to be the span's")
+}
+
+func TestFramework_TracesIngestAndQueries(t *testing.T) {
+	framework, recorder, _ := newTracedFramework(t)
+	agent := &batchAgent{MockPlugin: MockPlugin{name: "ai", pluginType: PluginTypeAgent}}
+	require.NoError(t, framework.LoadPlugin(agent))
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/ingest", strings.NewReader(`[{"metric": "cpu", "value": 99}]`))
+	req.Header.Set("traceparent", parent)
+	rec := httptest.NewRecorder()
+	framework.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	framework.processData(context.Background(), <-framework.GetDataChannel())
+
+	spans := spansByName(recorder)
+	require.Contains(t, spans, "ingest")
+	require.Contains(t, spans, "process")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans["process"].SpanContext().TraceID().String(),
+		"Expected ingested batches to continue the caller's trace")
+	assert.Equal(t, spans["ingest"].SpanContext().SpanID(), spans["process"].Parent().SpanID())
+
+	_, err := framework.QueryAgent(context.Background(), "ai", "fail")
+	require.Error(t, err)
+	spans = spansByName(recorder)
+	require.Contains(t, spans, "agent.query")
+	assert.Equal(t, codes.Error, spans["agent.query"].Status().Code)
+}
+
+func TestTracingContextManager(t *testing.T) {
+	framework, _, _ := newTracedFramework(t)
+	manager := framework.ContextManager()
+
+	assert.Empty(t, manager.GetTraceID(context.Background()))
+	assert.Equal(t, "abc", manager.GetTraceID(WithTraceID(context.Background(), "abc")), "Expected debug log trace IDs without a span")
+
+	ctx, span := framework.startSpan(context.Background(), "query")
+	defer span.End()
+	assert.Equal(t, span.SpanContext().TraceID().String(), manager.GetTraceID(ctx))
+	assert.Equal(t, span.SpanContext().SpanID().String(), manager.GetSpanID(ctx))
+
+	var buf bytes.Buffer
+	slog.New(traceLogHandler{slog.NewJSONHandler(&buf, nil)}).With("component", "test").InfoContext(ctx, "traced")
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, span.SpanContext().TraceID().String(), record["trace_id"])
+	assert.Equal(t, span.SpanContext().SpanID().String(), record["span_id"])
+}
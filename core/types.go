@@ -22,16 +22,37 @@ const (
 	AnalysisTypeTrend       AnalysisType = "trend"
 	AnalysisTypeCorrelation AnalysisType = "correlation"
 	AnalysisTypeAlert       AnalysisType = "alert"
+	AnalysisTypeComposite   AnalysisType = "composite"
+	AnalysisTypeReport      AnalysisType = "report"
+	// Written by an agent about another analysis; see IncidentSummaryProvider
+	AnalysisTypeIncidentSummary AnalysisType = "incident_summary"
+	// Sent while a remediation waits for approval, and to the responder carrying out an
+	// approved one; see RemediationConfig
+	AnalysisTypeApprovalRequest AnalysisType = "approval_request"
+	AnalysisTypeRemediation     AnalysisType = "remediation"
 )
 
+// AnalysisGroup is a batch of analyses that share the group_by labels of a responder route
+type AnalysisGroup struct {
+	Key      string            `json:"key"`
+	Labels   map[string]string `json:"labels"`
+	Analyses []*Analysis       `json:"analyses"`
+}
+
 // Analysis represents the result of analyzing data points
 type Analysis struct {
-	Type       AnalysisType           `json:"type"`
-	Confidence float64                `json:"confidence"` // 0.0 to 1.0
-	Severity   string                 `json:"severity"`   // low, medium, high, critical
-	Summary    string                 `json:"summary"`
-	Details    map[string]interface{} `json:"details"`
-	DataPoints []DataPoint            `json:"data_points"`
-	Timestamp  time.Time              `json:"timestamp"`
-	Source     string                 `json:"source"`
+	ID          string                 `json:"id"`          // unique per occurrence
+	Fingerprint string                 `json:"fingerprint"` // identical for repeats of the same condition
+	Type        AnalysisType           `json:"type"`
+	Confidence  float64                `json:"confidence"` // 0.0 to 1.0
+	Severity    string                 `json:"severity"`   // low, medium, high, critical
+	Summary     string                 `json:"summary"`
+	Details     map[string]interface{} `json:"details"`
+	DataPoints  []DataPoint            `json:"data_points"`
+	Timestamp   time.Time              `json:"timestamp"`
+	Source      string                 `json:"source"`
+	// Upstream analyses this analysis was derived from when produced by an analyzer chain
+	Provenance []AnalysisRef `json:"provenance,omitempty"`
+	// Set on the notification sent when a deduplicated condition stops firing
+	Resolved bool `json:"resolved,omitempty"`
 }
@@ -48,6 +48,138 @@ func (v *Validator) ValidateFrameworkConfig(config *FrameworkConfig) error {
 		}
 	}
 
+	// Each alias must map to exactly one canonical metric name
+	if _, err := NewMetricNormalizer(config.MetricAliases); err != nil {
+		return err
+	}
+
+	// Analyzer routes must use valid metric patterns and label selectors
+	if _, err := newAnalyzerRoutes(config.AnalyzerRoutes); err != nil {
+		return err
+	}
+
+	// Responder routes must use valid source patterns and label selectors
+	if _, err := newResponderRoutes(config.ResponderRoute); err != nil {
+		return err
+	}
+
+	// Maintenance windows must have valid selectors and time ranges
+	if _, err := NewMaintenanceWindows(config.MaintenanceWindows); err != nil {
+		return err
+	}
+
+	// Processors need valid patterns and the settings of their type
+	if _, err := newProcessingStage(config.Processors); err != nil {
+		return err
+	}
+
+	// Recording rules need expressions that parse
+	if _, err := newRecordingRules(config.RecordingRules); err != nil {
+		return err
+	}
+
+	// Pipelines need unique names, valid collector patterns, and valid processors
+	if _, err := newPipelines(config.Pipelines); err != nil {
+		return err
+	}
+
+	// Analyzer chains must not contain cycles
+	if _, err := newAnalyzerChains(config.AnalyzerChains); err != nil {
+		return err
+	}
+
+	// The on-call rota must load, and PagerDuty schedules need a token and schedule ID
+	if _, err := NewOnCallProvider(config.OnCall); err != nil {
+		return err
+	}
+
+	// The spill overflow policy needs somewhere to spill to
+	if config.Backpressure.Overflow == OverflowSpill && config.Backpressure.SpillDir == "" {
+		return NewValidationError("validator", "validate-backpressure", "backpressure overflow policy spill needs a spill_dir")
+	}
+
+	// Collectors discover their targets from a single backend
+	if config.Discovery.Consul != nil && config.Discovery.Kubernetes != nil {
+		return NewValidationError("validator", "validate-discovery", "discovery can use consul or kubernetes, not both")
+	}
+
+	// Tenants need unique names usable in URLs, and valid plugins and pipelines of their own
+	if err := v.validateTenants(config); err != nil {
+		return err
+	}
+
+	// Agent actions are carried out by workflows of the known action types
+	for actionType, workflowID := range config.Remediation.ActionWorkflows {
+		if _, ok := agentActionTypes[actionType]; !ok || actionType == AgentActionRunWorkflow {
+			return NewValidationError("validator", "validate-remediation",
+				fmt.Sprintf("remediation.action_workflows has unknown action type %s", actionType))
+		}
+		if workflowID == "" {
+			return NewValidationError("validator", "validate-remediation",
+				fmt.Sprintf("remediation.action_workflows.%s needs a workflow ID", actionType))
+		}
+	}
+	for actionType, responder := range config.Remediation.ActionResponders {
+		if _, ok := agentActionTypes[actionType]; !ok || actionType == AgentActionRunWorkflow {
+			return NewValidationError("validator", "validate-remediation",
+				fmt.Sprintf("remediation.action_responders has unknown action type %s", actionType))
+		}
+		if responder == "" {
+			return NewValidationError("validator", "validate-remediation",
+				fmt.Sprintf("remediation.action_responders.%s needs a responder name", actionType))
+		}
+	}
+	for _, responder := range config.Remediation.ApprovalResponders {
+		if responder == "" {
+			return NewValidationError("validator", "validate-remediation", "remediation.approval_responders has an empty name")
+		}
+	}
+
+	// The noise report goes only where it is sent on purpose, never to paging responders by default
+	if config.NoiseReport.Interval > 0 {
+		if len(config.NoiseReport.Responders) == 0 {
+			return NewValidationError("validator", "validate-noise-report", "noise_report.responders must name the responders the report is sent to")
+		}
+		for _, responder := range config.NoiseReport.Responders {
+			if responder == "" {
+				return NewValidationError("validator", "validate-noise-report", "noise_report.responders has an empty name")
+			}
+		}
+	}
+
+	// The server certificate and client CA bundle must load
+	if _, err := NewServerTLSConfig(config.TLS); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateTenants validates each tenant's configuration as the framework it runs as, and
+// that API keys are only limited to configured tenants
+func (v *Validator) validateTenants(config *FrameworkConfig) error {
+	names := make(map[string]bool, len(config.Tenants))
+	for _, tenant := range config.Tenants {
+		if !tenantNamePattern.MatchString(tenant.Name) {
+			return NewValidationError("validator", "validate-tenants",
+				fmt.Sprintf("tenant name %q must be lowercase letters, digits, dashes, and underscores", tenant.Name))
+		}
+		if names[tenant.Name] {
+			return NewValidationError("validator", "validate-tenants", fmt.Sprintf("duplicate tenant %s", tenant.Name))
+		}
+		names[tenant.Name] = true
+		if err := v.ValidateFrameworkConfig(tenantFrameworkConfig(config, tenant)); err != nil {
+			return WrapError(err, ErrorTypeValidation, "validator", "validate-tenants", fmt.Sprintf("tenant %s is invalid", tenant.Name))
+		}
+	}
+	for _, key := range config.APIKeys {
+		for _, tenant := range key.Tenants {
+			if !names[tenant] {
+				return NewValidationError("validator", "validate-tenants",
+					fmt.Sprintf("API key %s names unknown tenant %s", key.Name, tenant))
+			}
+		}
+	}
 	return nil
 }
 
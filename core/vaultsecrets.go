@@ -0,0 +1,94 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// vaultSecretBackend resolves ${vault:path#key} from HashiCorp Vault. The path is the
+// secret's API path, such as secret/data/agent for the agent secret of a KV version 2
+// mount; key picks one of its fields.
+type vaultSecretBackend struct {
+	config     VaultSecretsConfig
+	httpClient *http.Client
+}
+
+func newVaultSecretBackend(config VaultSecretsConfig, timeout time.Duration) *vaultSecretBackend {
+	if config.Address == "" {
+		config.Address = os.Getenv("VAULT_ADDR")
+	}
+	return &vaultSecretBackend{config: config, httpClient: &http.Client{Timeout: timeout}}
+}
+
+func (v *vaultSecretBackend) Scheme() string { return "vault" }
+
+func (v *vaultSecretBackend) Resolve(ctx context.Context, reference string) (string, error) {
+	if v.config.Address == "" {
+		return "", fmt.Errorf("vault address not configured")
+	}
+	token, err := v.token()
+	if err != nil {
+		return "", err
+	}
+
+	path, key := splitSecretKey(reference)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(v.config.Address, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if v.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.config.Namespace)
+	}
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("vault returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid vault response: %w", err)
+	}
+	// KV version 2 nests the fields under data, beside the version metadata
+	fields := body.Data
+	if nested, ok := fields["data"].(map[string]interface{}); ok {
+		if _, versioned := fields["metadata"]; versioned {
+			fields = nested
+		}
+	}
+	return secretField(fields, key)
+}
+
+// token returns the configured token, reading the token file each time so a token renewed
+// by a Vault agent is picked up
+func (v *vaultSecretBackend) token() (string, error) {
+	switch {
+	case v.config.Token != "":
+		return v.config.Token, nil
+	case v.config.TokenFile != "":
+		data, err := os.ReadFile(v.config.TokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read vault token file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	case os.Getenv("VAULT_TOKEN") != "":
+		return os.Getenv("VAULT_TOKEN"), nil
+	default:
+		return "", fmt.Errorf("vault token not configured")
+	}
+}
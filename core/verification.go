@@ -0,0 +1,211 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+)
+
+// VerificationStatus is the outcome of re-checking a remediation's metric
+type VerificationStatus string
+
+const (
+	VerificationPending     VerificationStatus = "pending"
+	VerificationImproved    VerificationStatus = "improved"
+	VerificationNotImproved VerificationStatus = "not_improved"
+	VerificationNoData      VerificationStatus = "no_data"
+)
+
+// RemediationVerification is the metric a remediation is expected to fix. It is re-checked
+// once the action has had time to take effect rather than assuming the action worked.
+type RemediationVerification struct {
+	Metric   string            `json:"metric"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Baseline float64           `json:"baseline"`
+	// Increase is set when the metric fired for being too low, so recovery means it rises
+	Increase   bool               `json:"increase,omitempty"`
+	Status     VerificationStatus `json:"status,omitempty"`
+	CheckAt    time.Time          `json:"check_at,omitempty"`
+	Value      float64            `json:"value,omitempty"`
+	ObservedAt time.Time          `json:"observed_at,omitempty"`
+	CheckedAt  time.Time          `json:"checked_at,omitempty"`
+}
+
+// covers reports whether the point belongs to the series being verified
+func (v *RemediationVerification) covers(point DataPoint) bool {
+	if point.Metric != v.Metric {
+		return false
+	}
+	for name, value := range v.Labels {
+		if point.Labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// improved reports whether the latest value recovered from the baseline by at least the
+// given fraction of it
+func (v *RemediationVerification) improved(minImprovement float64) bool {
+	change := v.Baseline - v.Value
+	if v.Increase {
+		change = -change
+	}
+	return change > 0 && change >= minImprovement*math.Abs(v.Baseline)
+}
+
+// observe records the latest value of series under verification. Only points taken after
+// the action ran count.
+func (g *RemediationGovernor) observe(data []DataPoint) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.verifying) == 0 {
+		return
+	}
+	for _, remediation := range g.verifying {
+		verification := remediation.Verification
+		for _, point := range data {
+			if !verification.covers(point) || point.Timestamp.Before(remediation.ExecutedAt) ||
+				point.Timestamp.Before(verification.ObservedAt) {
+				continue
+			}
+			verification.Value = point.Value
+			verification.ObservedAt = point.Timestamp
+		}
+	}
+}
+
+// dueVerifications settles the verifications whose check time has passed and returns the
+// remediations, oldest first
+func (g *RemediationGovernor) dueVerifications(now time.Time, minImprovement float64) []Remediation {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var due []Remediation
+	for id, remediation := range g.verifying {
+		verification := remediation.Verification
+		if now.Before(verification.CheckAt) {
+			continue
+		}
+		delete(g.verifying, id)
+
+		verification.CheckedAt = now
+		switch {
+		case verification.ObservedAt.IsZero():
+			verification.Status = VerificationNoData
+		case verification.improved(minImprovement):
+			verification.Status = VerificationImproved
+		default:
+			verification.Status = VerificationNotImproved
+		}
+		due = append(due, remediation.clone())
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ExecutedAt.Before(due[j].ExecutedAt) })
+	return due
+}
+
+// verificationWorker periodically re-checks the metrics of executed remediations
+func (f *Framework) verificationWorker(ctx context.Context) {
+	defer f.wg.Done()
+
+	interval := f.config.Remediation.VerifyAfter / 2
+	if interval > 30*time.Second {
+		interval = 30 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Verification worker stopping due to context cancellation")
+			return
+		case now := <-ticker.C:
+			f.verifyRemediations(ctx, now)
+		}
+	}
+}
+
+// verifyRemediations annotates the incidents of remediations whose metric recovered and
+// escalates the ones whose metric did not
+func (f *Framework) verifyRemediations(ctx context.Context, now time.Time) {
+	for _, remediation := range f.remediations.dueVerifications(now, f.config.Remediation.MinImprovement) {
+		verification := remediation.Verification
+		if verification.Status == VerificationImproved {
+			slog.Info("Remediation verified", "remediation", remediation.ID, "metric", verification.Metric,
+				"baseline", verification.Baseline, "value", verification.Value)
+			if remediation.IncidentID != "" {
+				f.incidents.Annotate(remediation.IncidentID, "remediation_verified", "framework",
+					fmt.Sprintf("%s %s (%s) worked: %s went from %g to %g", remediation.Action, remediation.Service,
+						remediation.ID, verification.Metric, verification.Baseline, verification.Value))
+			}
+			continue
+		}
+
+		outcome := fmt.Sprintf("%s did not improve from %g (now %g)", verification.Metric, verification.Baseline, verification.Value)
+		if verification.Status == VerificationNoData {
+			outcome = fmt.Sprintf("no %s data arrived after it ran", verification.Metric)
+		}
+		slog.Warn("Remediation did not fix the condition, escalating", "remediation", remediation.ID,
+			"metric", verification.Metric, "status", verification.Status)
+		if remediation.IncidentID != "" {
+			f.incidents.Annotate(remediation.IncidentID, "remediation_failed", "framework",
+				fmt.Sprintf("%s %s (%s) failed: %s", remediation.Action, remediation.Service, remediation.ID, outcome))
+		}
+		f.escalateRemediation(WithTraceID(ctx, NewTraceID()), remediation, outcome)
+	}
+}
+
+// escalateRemediation pages the on-call person and notifies responders that a remediation
+// did not fix the condition it ran for
+func (f *Framework) escalateRemediation(ctx context.Context, remediation Remediation, outcome string) {
+	verification := remediation.Verification
+	analysis := &Analysis{
+		Type:       AnalysisTypeAlert,
+		Confidence: 1.0,
+		Severity:   "critical",
+		Summary:    fmt.Sprintf("Remediation %s (%s %s) failed: %s", remediation.ID, remediation.Action, remediation.Service, outcome),
+		Details: map[string]interface{}{
+			"remediation_id": remediation.ID,
+			"workflow_id":    remediation.WorkflowID,
+			"verification":   string(verification.Status),
+			"baseline":       verification.Baseline,
+		},
+		Timestamp: time.Now(),
+		Source:    "remediation",
+	}
+	if !verification.ObservedAt.IsZero() {
+		analysis.DataPoints = []DataPoint{{
+			Timestamp: verification.ObservedAt,
+			Metric:    verification.Metric,
+			Value:     verification.Value,
+			Labels:    verification.Labels,
+		}}
+	}
+	analysis.EnsureIdentity()
+
+	if traceID := TraceIDFromContext(ctx); traceID != "" {
+		analysis.Details["trace_id"] = traceID
+	}
+	if remediation.IncidentID != "" {
+		analysis.Details["incident_id"] = remediation.IncidentID
+		f.assignOnCall(ctx, analysis, remediation.IncidentID)
+	}
+	if err := f.history.Record(ctx, analysis); err != nil {
+		slog.Error("Failed to record analysis history", "analysis", analysis.ID, "error", err)
+	}
+	f.debugLog.Load().Record(DebugEvent{
+		TraceID:  TraceIDFromContext(ctx),
+		Stage:    DebugStageIncident,
+		Plugin:   "remediation",
+		Decision: "escalated",
+		Reason:   outcome,
+		Data:     map[string]interface{}{"remediation_id": remediation.ID, "incident_id": remediation.IncidentID},
+	})
+	f.notify(ctx, analysis)
+}
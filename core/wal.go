@@ -0,0 +1,423 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Write-ahead log record kinds
+const (
+	walBatch    = "batch"
+	walAnalysis = "analysis"
+	walAck      = "ack"
+)
+
+// defaultWALSegmentBytes is the size a WAL segment grows to before a new one is started
+const defaultWALSegmentBytes = 16 << 20
+
+// walRecord is one line of a WAL segment
+type walRecord struct {
+	Seq      uint64      `json:"seq"`
+	Kind     string      `json:"kind"`
+	Time     time.Time   `json:"time"`
+	Ack      uint64      `json:"ack,omitempty"`
+	Data     []DataPoint `json:"data,omitempty"`
+	Analyzer string      `json:"analyzer,omitempty"`
+	Analysis *Analysis   `json:"analysis,omitempty"`
+}
+
+// walSegment is one file of the WAL
+type walSegment struct {
+	path    string
+	size    int64
+	newest  time.Time
+	pending map[uint64]bool
+}
+
+// WALStats describes the WAL for metrics
+type WALStats struct {
+	Segments int
+	Bytes    int64
+	Pending  int
+	Dropped  int64
+}
+
+// WAL is a disk-backed write-ahead log of collected batches and produced analyses. Each entry
+// stays pending until it is acknowledged, once the batch has been processed or the analysis
+// handed to responders; entries still pending when the agent stops are replayed on the
+// next start.
+//
+// The log is a series of segment files of JSON lines, each named after its first sequence
+// number. Acknowledgements are records too, so segments are only removed from the front:
+// the oldest segment goes once none of its entries are pending, or when retention drops
+// it along with whatever is still pending in it.
+type WAL struct {
+	config WALConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	seq      uint64
+	segments []*walSegment // oldest first; the last is written to
+	file     *os.File
+	owner    map[uint64]*walSegment
+	dropped  int64
+	replay   []walRecord
+}
+
+// OpenWAL opens the WAL in the configured directory, reading back the entries left pending
+// by an earlier run, and starts a new segment for this one
+func OpenWAL(config WALConfig) (*WAL, error) {
+	if config.SegmentBytes <= 0 {
+		config.SegmentBytes = defaultWALSegmentBytes
+	}
+	if err := os.MkdirAll(config.Dir, 0o750); err != nil {
+		return nil, WrapError(err, ErrorTypeConfiguration, "wal", "open", "failed to create WAL directory")
+	}
+
+	w := &WAL{config: config, now: time.Now, owner: make(map[uint64]*walSegment)}
+	if err := w.load(); err != nil {
+		return nil, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.trimLocked()
+	if err := w.rotateLocked(); err != nil {
+		return nil, err
+	}
+	w.enforceRetentionLocked()
+	return w, nil
+}
+
+// load reads the existing segments. A record cut short by a crash ends its segment.
+func (w *WAL) load() error {
+	paths, err := filepath.Glob(filepath.Join(w.config.Dir, "*.wal"))
+	if err != nil {
+		return WrapError(err, ErrorTypeInternal, "wal", "open", "failed to list WAL segments")
+	}
+	sort.Strings(paths)
+
+	records := make(map[uint64]walRecord)
+	for _, path := range paths {
+		segment := &walSegment{path: path, pending: make(map[uint64]bool)}
+		if info, err := os.Stat(path); err == nil {
+			segment.size = info.Size()
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return WrapError(err, ErrorTypeInternal, "wal", "open", fmt.Sprintf("failed to read WAL segment %s", path))
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 64<<20)
+		for scanner.Scan() {
+			var record walRecord
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				slog.Warn("Ignoring the rest of a damaged WAL segment", "segment", path, "error", err)
+				break
+			}
+			if record.Seq > w.seq {
+				w.seq = record.Seq
+			}
+			if record.Time.After(segment.newest) {
+				segment.newest = record.Time
+			}
+			if record.Kind == walAck {
+				if owner := w.owner[record.Ack]; owner != nil {
+					delete(owner.pending, record.Ack)
+					delete(w.owner, record.Ack)
+					delete(records, record.Ack)
+				}
+				continue
+			}
+			segment.pending[record.Seq] = true
+			w.owner[record.Seq] = segment
+			records[record.Seq] = record
+		}
+		file.Close()
+		w.segments = append(w.segments, segment)
+	}
+
+	for _, record := range records {
+		w.replay = append(w.replay, record)
+	}
+	sort.Slice(w.replay, func(i, j int) bool { return w.replay[i].Seq < w.replay[j].Seq })
+	return nil
+}
+
+// takeReplay returns the entries left pending by an earlier run, oldest first. They stay
+// pending until acknowledged.
+func (w *WAL) takeReplay() []walRecord {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	replay := w.replay
+	w.replay = nil
+	return replay
+}
+
+// AppendBatch logs a collected batch and returns its sequence number
+func (w *WAL) AppendBatch(data []DataPoint) uint64 {
+	return w.append(walRecord{Kind: walBatch, Data: data})
+}
+
+// AppendAnalysis logs an analysis and returns its sequence number
+func (w *WAL) AppendAnalysis(analyzer string, analysis *Analysis) uint64 {
+	return w.append(walRecord{Kind: walAnalysis, Analyzer: analyzer, Analysis: analysis})
+}
+
+// append writes an entry, returning zero when the WAL is off or the write failed; the
+// agent carries on without the entry rather than stopping the pipeline
+func (w *WAL) append(record walRecord) uint64 {
+	if w == nil {
+		return 0
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.seq++
+	record.Seq = w.seq
+	if err := w.writeLocked(record); err != nil {
+		slog.Error("Failed to write to the WAL", "kind", record.Kind, "error", err)
+		return 0
+	}
+	segment := w.segments[len(w.segments)-1]
+	segment.pending[record.Seq] = true
+	w.owner[record.Seq] = segment
+
+	if segment.size >= w.config.SegmentBytes {
+		if err := w.rotateLocked(); err != nil {
+			slog.Error("Failed to start a new WAL segment", "error", err)
+		}
+		w.enforceRetentionLocked()
+	}
+	return record.Seq
+}
+
+// Ack marks an entry as done with
+func (w *WAL) Ack(seq uint64) {
+	if w == nil || seq == 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	segment := w.owner[seq]
+	if segment == nil {
+		return
+	}
+	w.seq++
+	if err := w.writeLocked(walRecord{Seq: w.seq, Kind: walAck, Ack: seq}); err != nil {
+		slog.Error("Failed to write to the WAL", "kind", walAck, "error", err)
+		return
+	}
+	delete(segment.pending, seq)
+	delete(w.owner, seq)
+	w.trimLocked()
+}
+
+// Stats returns the WAL's size and backlog
+func (w *WAL) Stats() WALStats {
+	if w == nil {
+		return WALStats{}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stats := WALStats{Segments: len(w.segments), Pending: len(w.owner), Dropped: w.dropped}
+	for _, segment := range w.segments {
+		stats.Bytes += segment.size
+	}
+	return stats
+}
+
+// Close closes the current segment
+func (w *WAL) Close() error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// writeLocked appends a record to the current segment
+func (w *WAL) writeLocked(record walRecord) error {
+	if w.file == nil {
+		return NewInternalError("wal", "write", "WAL is closed")
+	}
+	record.Time = w.now()
+	line, err := json.Marshal(record)
+	if err != nil {
+		return WrapError(err, ErrorTypeInternal, "wal", "write", "failed to encode record")
+	}
+	line = append(line, '\n')
+	if _, err := w.file.Write(line); err != nil {
+		return WrapError(err, ErrorTypeInternal, "wal", "write", "failed to write record")
+	}
+	if w.config.Sync {
+		if err := w.file.Sync(); err != nil {
+			return WrapError(err, ErrorTypeInternal, "wal", "write", "failed to sync segment")
+		}
+	}
+	segment := w.segments[len(w.segments)-1]
+	segment.size += int64(len(line))
+	segment.newest = record.Time
+	return nil
+}
+
+// rotateLocked closes the current segment and starts a new one
+func (w *WAL) rotateLocked() error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			slog.Error("Failed to close WAL segment", "error", err)
+		}
+		w.file = nil
+	}
+	path := filepath.Join(w.config.Dir, fmt.Sprintf("%020d.wal", w.seq+1))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return WrapError(err, ErrorTypeInternal, "wal", "rotate", "failed to create WAL segment")
+	}
+	w.file = file
+	w.segments = append(w.segments, &walSegment{path: path, newest: w.now(), pending: make(map[uint64]bool)})
+	w.trimLocked()
+	return nil
+}
+
+// trimLocked removes the oldest segments while none of their entries are pending
+func (w *WAL) trimLocked() {
+	for len(w.segments) > 1 && len(w.segments[0].pending) == 0 {
+		w.removeOldestLocked()
+	}
+}
+
+// enforceRetentionLocked drops the oldest segments, pending entries and all, while the WAL
+// is over its size limit or they are older than the age limit
+func (w *WAL) enforceRetentionLocked() {
+	for len(w.segments) > 1 {
+		oldest := w.segments[0]
+		var total int64
+		for _, segment := range w.segments {
+			total += segment.size
+		}
+		overSize := w.config.MaxBytes > 0 && total > w.config.MaxBytes
+		overAge := w.config.MaxAge > 0 && w.now().Sub(oldest.newest) > w.config.MaxAge
+		if !overSize && !overAge {
+			return
+		}
+		if len(oldest.pending) > 0 {
+			slog.Warn("WAL retention dropped entries that were never acknowledged",
+				"segment", filepath.Base(oldest.path), "entries", len(oldest.pending))
+			w.dropped += int64(len(oldest.pending))
+			for seq := range oldest.pending {
+				delete(w.owner, seq)
+			}
+			w.dropReplayLocked(oldest)
+		}
+		w.removeOldestLocked()
+	}
+}
+
+// dropReplayLocked forgets the entries of a dropped segment that were waiting for replay
+func (w *WAL) dropReplayLocked(segment *walSegment) {
+	kept := w.replay[:0]
+	for _, record := range w.replay {
+		if !segment.pending[record.Seq] {
+			kept = append(kept, record)
+		}
+	}
+	w.replay = kept
+}
+
+// removeOldestLocked deletes the oldest segment's file
+func (w *WAL) removeOldestLocked() {
+	oldest := w.segments[0]
+	if err := os.Remove(oldest.path); err != nil && !os.IsNotExist(err) {
+		slog.Error("Failed to remove WAL segment", "segment", oldest.path, "error", err)
+	}
+	w.segments = w.segments[1:]
+}
+
+// openWAL opens the WAL when it is enabled. A WAL that cannot be opened is logged and left
+// out, so the agent still runs.
+func openWAL(config WALConfig) *WAL {
+	if !config.Enabled {
+		return nil
+	}
+	wal, err := OpenWAL(config)
+	if err != nil {
+		slog.Error("Failed to open WAL, running without it", "dir", config.Dir, "error", err)
+		return nil
+	}
+	return wal
+}
+
+// logBatch logs a batch on its way to the data channel until it has been processed
+func (f *Framework) logBatch(data []DataPoint) {
+	if f.wal == nil || len(data) == 0 {
+		return
+	}
+	if seq := f.wal.AppendBatch(data); seq != 0 {
+		f.walBatches.Store(&data[0], seq)
+	}
+}
+
+// ackBatch marks a logged batch as done with and forgets the span it was traced in.
+// Batches are recognized by their first data point, which stays put while the batch passes
+// through the data channel.
+func (f *Framework) ackBatch(data []DataPoint) {
+	if len(data) == 0 {
+		return
+	}
+	f.batchSpans.Delete(&data[0])
+	if f.wal == nil {
+		return
+	}
+	if seq, ok := f.walBatches.LoadAndDelete(&data[0]); ok {
+		f.wal.Ack(seq.(uint64))
+	}
+}
+
+// walReplayWorker feeds what the WAL kept from an earlier run back in: batches go through
+// the data channel again and analyses to responders. Each entry is acknowledged once it is
+// logged again or handled.
+func (f *Framework) walReplayWorker(ctx context.Context, replay []walRecord) {
+	defer f.wg.Done()
+
+	slog.Info("Replaying entries kept in the WAL", "entries", len(replay))
+	for _, record := range replay {
+		switch record.Kind {
+		case walBatch:
+			if err := f.enqueue(ctx, record.Data); err != nil {
+				slog.Info("WAL replay stopping due to context cancellation")
+				return
+			}
+			f.metricsCollector.IncrementCounter("framework_wal_replayed_total", map[string]string{"kind": walBatch})
+		case walAnalysis:
+			if record.Analysis == nil {
+				break
+			}
+			f.handleAnalysis(ctx, record.Analyzer, record.Analysis)
+			f.metricsCollector.IncrementCounter("framework_wal_replayed_total", map[string]string{"kind": walAnalysis})
+		}
+		f.wal.Ack(record.Seq)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
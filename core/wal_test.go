@@ -0,0 +1,148 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWAL_ReplaysPendingEntries(t *testing.T) {
+	dir := t.TempDir()
+	wal, err := OpenWAL(WALConfig{Dir: dir})
+	require.NoError(t, err)
+
+	first := wal.AppendBatch(testBatch("first"))
+	second := wal.AppendBatch(testBatch("second"))
+	analysis := wal.AppendAnalysis("anomaly", &Analysis{ID: "a1", Severity: "high"})
+	wal.Ack(second)
+	assert.Equal(t, 2, wal.Stats().Pending)
+	require.NoError(t, wal.Close())
+
+	wal, err = OpenWAL(WALConfig{Dir: dir})
+	require.NoError(t, err)
+	defer wal.Close()
+
+	replay := wal.takeReplay()
+	require.Len(t, replay, 2, "Expected only unacknowledged entries to be replayed")
+	assert.Equal(t, first, replay[0].Seq)
+	assert.Equal(t, "first", replay[0].Data[0].Metric)
+	assert.Equal(t, analysis, replay[1].Seq)
+	assert.Equal(t, "anomaly", replay[1].Analyzer)
+	assert.Equal(t, "a1", replay[1].Analysis.ID)
+
+	assert.Greater(t, wal.AppendBatch(testBatch("third")), analysis, "Expected sequence numbers to carry on")
+	wal.Ack(first)
+	wal.Ack(analysis)
+	assert.Equal(t, 1, wal.Stats().Pending)
+}
+
+func TestWAL_RemovesProcessedSegments(t *testing.T) {
+	dir := t.TempDir()
+	wal, err := OpenWAL(WALConfig{Dir: dir, SegmentBytes: 1})
+	require.NoError(t, err)
+	defer wal.Close()
+
+	var seqs []uint64
+	for _, metric := range []string{"a", "b", "c"} {
+		seqs = append(seqs, wal.AppendBatch(testBatch(metric)))
+	}
+	assert.Equal(t, 4, wal.Stats().Segments, "Expected a segment per batch and the current one")
+
+	// Segments go from the front only, so acknowledging the second batch frees nothing
+	wal.Ack(seqs[1])
+	assert.Equal(t, 4, wal.Stats().Segments)
+	wal.Ack(seqs[0])
+	wal.Ack(seqs[2])
+	assert.Equal(t, 1, wal.Stats().Segments)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.wal"))
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestWAL_Retention(t *testing.T) {
+	wal, err := OpenWAL(WALConfig{Dir: t.TempDir(), SegmentBytes: 1, MaxAge: time.Hour})
+	require.NoError(t, err)
+	defer wal.Close()
+	now := time.Now()
+	wal.now = func() time.Time { return now }
+
+	wal.AppendBatch(testBatch("old"))
+	now = now.Add(2 * time.Hour)
+	wal.AppendBatch(testBatch("new"))
+
+	stats := wal.Stats()
+	assert.Equal(t, int64(1), stats.Dropped, "Expected the batch past the age limit to be dropped")
+	assert.Equal(t, 1, stats.Pending)
+
+	wal, err = OpenWAL(WALConfig{Dir: t.TempDir(), SegmentBytes: 1, MaxBytes: 1})
+	require.NoError(t, err)
+	defer wal.Close()
+	wal.AppendBatch(testBatch("a"))
+	wal.AppendBatch(testBatch("b"))
+	assert.Equal(t, int64(2), wal.Stats().Dropped, "Expected segments over the size limit to be dropped")
+}
+
+func TestWAL_DamagedSegment(t *testing.T) {
+	dir := t.TempDir()
+	wal, err := OpenWAL(WALConfig{Dir: dir})
+	require.NoError(t, err)
+	wal.AppendBatch(testBatch("kept"))
+	require.NoError(t, wal.Close())
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.wal"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	file, err := os.OpenFile(files[0], os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"seq":2,"kind":"batch","data":[{"met`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	wal, err = OpenWAL(WALConfig{Dir: dir})
+	require.NoError(t, err)
+	defer wal.Close()
+	replay := wal.takeReplay()
+	require.Len(t, replay, 1, "Expected a record cut short by a crash to be ignored")
+	assert.Equal(t, "kept", replay[0].Data[0].Metric)
+}
+
+func TestFramework_WALReplay(t *testing.T) {
+	dir := t.TempDir()
+	config := &FrameworkConfig{LogLevel: "info", LogFormat: "text", LogOutput: "stdout", DataChannelSize: 10, WAL: WALConfig{Enabled: true, Dir: dir}}
+	ctx := context.Background()
+
+	// A batch still waiting in the data channel and an analysis not yet handled when the
+	// agent stops
+	framework := NewFramework(config)
+	require.NotNil(t, framework.wal)
+	require.NoError(t, framework.enqueue(ctx, testBatch("latency")))
+	framework.wal.AppendAnalysis("anomaly", &Analysis{ID: "a1", Source: "anomaly", Severity: "low", Summary: "latency spike", Timestamp: time.Now()})
+	require.NoError(t, framework.wal.Close())
+
+	framework = NewFramework(config)
+	defer framework.wal.Close()
+	analyzer := newCapturingAnalyzer("threshold")
+	responder := &severityResponder{MockPlugin: MockPlugin{name: "responder", pluginType: PluginTypeResponder}, severity: "low"}
+	require.NoError(t, framework.LoadPlugin(analyzer))
+	require.NoError(t, framework.LoadPlugin(responder))
+
+	replay := framework.wal.takeReplay()
+	require.Len(t, replay, 2)
+	framework.wg.Add(1)
+	framework.walReplayWorker(ctx, replay)
+
+	require.Len(t, responder.handled, 1, "Expected the kept analysis to reach responders")
+	assert.Equal(t, "a1", responder.handled[0].ID)
+	require.Len(t, framework.dataChannel, 1, "Expected the kept batch to be fed to the data channel")
+
+	framework.processData(ctx, <-framework.dataChannel)
+	require.Len(t, analyzer.batches, 1)
+	assert.Equal(t, "latency", analyzer.batches[0][0].Metric)
+	assert.Equal(t, 0, framework.wal.Stats().Pending, "Expected everything to be acknowledged once processed")
+}
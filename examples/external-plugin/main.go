@@ -0,0 +1,91 @@
+// Command external-plugin is an example collector that runs as a separate binary. Build it
+// and load it with the external plugin type:
+//
+//	go build -o bin/disk-usage ./examples/external-plugin
+//
+//	external_plugins_dir: bin
+//
+//	plugins:
+//	  - name: disk-usage
+//	    type: external
+//	    config:
+//	      path: disk-usage
+//	      config:
+//	        paths: ["/var/log", "/tmp"]
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/habruzzo/agent/plugins/external"
+)
+
+// DiskUsageCollector reports the bytes used by the files under each configured path
+type DiskUsageCollector struct {
+	paths  []string
+	status core.PluginStatus
+}
+
+func (c *DiskUsageCollector) Name() string          { return "disk-usage" }
+func (c *DiskUsageCollector) Type() core.PluginType { return core.PluginTypeCollector }
+func (c *DiskUsageCollector) Version() string       { return "1.0.0" }
+
+func (c *DiskUsageCollector) Configure(config map[string]interface{}) error {
+	paths, ok := config["paths"].([]interface{})
+	if !ok || len(paths) == 0 {
+		return fmt.Errorf("paths not specified")
+	}
+	c.paths = nil
+	for _, path := range paths {
+		c.paths = append(c.paths, fmt.Sprint(path))
+	}
+	return nil
+}
+
+func (c *DiskUsageCollector) Start(ctx context.Context) error {
+	c.status = core.PluginStatusRunning
+	return nil
+}
+
+func (c *DiskUsageCollector) Stop() error {
+	c.status = core.PluginStatusStopped
+	return nil
+}
+
+func (c *DiskUsageCollector) Status() core.PluginStatus    { return c.status }
+func (c *DiskUsageCollector) Health(context.Context) error { return nil }
+func (c *DiskUsageCollector) GetCapabilities() []string    { return []string{"collect_metrics"} }
+
+func (c *DiskUsageCollector) Collect(ctx context.Context) ([]core.DataPoint, error) {
+	var points []core.DataPoint
+	for _, root := range c.paths {
+		var size int64
+		err := filepath.Walk(root, func(_ string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				size += info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, core.DataPoint{
+			Timestamp: time.Now(),
+			Metric:    "disk_usage_bytes",
+			Value:     float64(size),
+			Labels:    map[string]string{"path": root},
+		})
+	}
+	return points, nil
+}
+
+func (c *DiskUsageCollector) GetCollectionInterval() time.Duration { return time.Minute }
+
+func main() {
+	external.Serve(&DiskUsageCollector{status: core.PluginStatusStopped})
+}
@@ -0,0 +1,108 @@
+package agents
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// actionsFence opens the block in which the model proposes actions after its answer
+const actionsFence = "```actions"
+
+// actionInstructions asks the model to propose actions as JSON matching the action schema
+var actionInstructions = func() string {
+	schema, _ := json.Marshal(core.AgentActionSchema())
+	return `If an action would help, such as restarting or scaling a service, end your answer with a block
+that starts with ` + actionsFence + ` on its own line, holds a JSON array of the proposed actions, and ends
+with ` + "```" + `. Propose only actions the data supports, and leave the block out otherwise. Actions are
+only carried out once a person approves them. The array must match this JSON schema:
+` + string(schema)
+}()
+
+// parseAgentActions splits the block of proposed actions off an answer. Actions that do not
+// match the schema are dropped, and the reasons returned as invalid.
+func parseAgentActions(content string) (text string, actions []core.AgentAction, invalid []string) {
+	start := strings.LastIndex(content, actionsFence)
+	if start < 0 {
+		return content, nil, nil
+	}
+	text = strings.TrimRight(content[:start], " \t\n")
+	block := content[start+len(actionsFence):]
+	if end := strings.Index(block, "```"); end >= 0 {
+		block = block[:end]
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal([]byte(strings.TrimSpace(block)), &raw); err != nil {
+		return text, nil, []string{fmt.Sprintf("actions block is not a JSON array: %v", err)}
+	}
+	for i, entry := range raw {
+		var action core.AgentAction
+		decoder := json.NewDecoder(bytes.NewReader(entry))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&action); err != nil {
+			invalid = append(invalid, fmt.Sprintf("action %d: %v", i+1, err))
+			continue
+		}
+		if err := action.Validate(); err != nil {
+			invalid = append(invalid, fmt.Sprintf("action %d: %v", i+1, err))
+			continue
+		}
+		actions = append(actions, action)
+	}
+	return text, actions, invalid
+}
+
+// actionBlockFilter passes a streamed answer on until the block of proposed actions
+// starts, so the user sees the answer without the JSON
+type actionBlockFilter struct {
+	onChunk func(chunk string) error
+	// pending holds back text that may be the start of the fence
+	pending string
+	inBlock bool
+}
+
+// write passes on the part of a chunk before the actions block
+func (f *actionBlockFilter) write(chunk string) error {
+	if f.inBlock {
+		return nil
+	}
+	text := f.pending + chunk
+	f.pending = ""
+	if start := strings.Index(text, actionsFence); start >= 0 {
+		f.inBlock = true
+		if text = strings.TrimRight(text[:start], " \t\n"); text == "" {
+			return nil
+		}
+		return f.onChunk(text)
+	}
+
+	// Trailing newlines are held back too, as the answer ends there if the block follows
+	hold := len(text)
+	for n := min(len(actionsFence)-1, len(text)); n > 0; n-- {
+		if strings.HasSuffix(text, actionsFence[:n]) {
+			hold -= n
+			break
+		}
+	}
+	hold = len(strings.TrimRight(text[:hold], "\n"))
+	f.pending = text[hold:]
+	text = text[:hold]
+	if text == "" {
+		return nil
+	}
+	return f.onChunk(text)
+}
+
+// flush passes on text held back at the end of the answer
+func (f *actionBlockFilter) flush() error {
+	if f.inBlock || f.pending == "" {
+		return nil
+	}
+	text := f.pending
+	f.pending = ""
+	return f.onChunk(text)
+}
@@ -0,0 +1,89 @@
+package agents
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAIAgent_ProposesActions(t *testing.T) {
+	answer := "Checkout is out of memory since the 14:00 deploy.\n\n```actions\n" +
+		`[{"type": "rollback", "description": "The deploy doubled memory use", "target": "checkout", "risk": "medium"},` +
+		`{"type": "scale", "target": "checkout", "risk": "low", "parameters": {"replicas": "lots"}}]` + "\n```"
+	server, systems := newPromQLServer(t, "", answer)
+	agent := NewAIAgent("ai")
+	require.NoError(t, agent.Configure(map[string]interface{}{"api_key": "key", "api_url": server.URL}))
+	ctx := context.Background()
+	require.NoError(t, agent.Start(ctx))
+
+	response, err := agent.ProcessQuery(ctx, "why does checkout keep crashing?")
+	require.NoError(t, err)
+	assert.Contains(t, <-systems, actionsFence, "Expected the model to be asked for actions")
+	assert.Equal(t, "Checkout is out of memory since the 14:00 deploy.", response.Response)
+	require.Len(t, response.Actions, 1, "Expected the action that does not match the schema to be dropped")
+	assert.Equal(t, core.AgentAction{
+		Type: core.AgentActionRollback, Description: "The deploy doubled memory use", Target: "checkout", Risk: core.ActionRiskMedium,
+	}, response.Actions[0])
+	require.Len(t, response.Metadata["invalid_actions"], 1)
+	assert.Contains(t, response.Metadata["invalid_actions"].([]string)[0], "replicas")
+
+	// The actions are not streamed as part of the answer
+	var chunks []string
+	response, err = agent.ProcessQueryStream(ctx, "why does checkout keep crashing?", func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Checkout is out of memory since the 14:00 deploy.", strings.Join(chunks, ""))
+	assert.Len(t, response.Actions, 1)
+}
+
+func TestParseAgentActions(t *testing.T) {
+	text, actions, invalid := parseAgentActions("Restart the pod if it happens again.")
+	assert.Equal(t, "Restart the pod if it happens again.", text)
+	assert.Empty(t, actions, "Expected no actions without an actions block")
+	assert.Empty(t, invalid)
+
+	text, actions, invalid = parseAgentActions("Disk is full.\n```actions\nnot json\n```")
+	assert.Equal(t, "Disk is full.", text)
+	assert.Empty(t, actions)
+	assert.Len(t, invalid, 1)
+
+	_, actions, invalid = parseAgentActions("```actions\n[" +
+		`{"type": "restart", "target": "api", "risk": "low"},` +
+		`{"type": "restart", "target": "api", "risk": "low", "force": true},` +
+		`{"type": "delete", "target": "api", "risk": "high"},` +
+		`{"type": "restart", "target": "api", "risk": "none"},` +
+		`{"type": "run_workflow", "target": "api", "risk": "low", "parameters": {"workflow_id": "drain", "input": {"zone": "b"}}}` +
+		"]\n```")
+	require.Len(t, actions, 2)
+	assert.Equal(t, core.AgentActionRestart, actions[0].Type)
+	assert.Equal(t, "drain", actions[1].Parameters["workflow_id"])
+	assert.Len(t, invalid, 3, "Expected unknown fields, types, and risks to be rejected")
+}
+
+func TestActionBlockFilter(t *testing.T) {
+	var out []string
+	filter := &actionBlockFilter{onChunk: func(chunk string) error {
+		out = append(out, chunk)
+		return nil
+	}}
+	for _, chunk := range []string{"Use `kubectl`", " to check.\n\n``", "`act", "ions\n[{\"type\":", "\"restart\"}]\n```"} {
+		require.NoError(t, filter.write(chunk))
+	}
+	require.NoError(t, filter.flush())
+	assert.Equal(t, "Use `kubectl` to check.", strings.Join(out, ""), "Expected a fence split across chunks to be held back")
+
+	out = nil
+	filter = &actionBlockFilter{onChunk: func(chunk string) error {
+		out = append(out, chunk)
+		return nil
+	}}
+	require.NoError(t, filter.write("Values end in ``"))
+	require.NoError(t, filter.flush())
+	assert.Equal(t, "Values end in ``", strings.Join(out, ""), "Expected held back text to be passed on at the end")
+}
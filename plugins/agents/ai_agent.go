@@ -3,8 +3,11 @@ package agents
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"strings"
@@ -14,16 +17,62 @@ import (
 	"github.com/habruzzo/agent/core"
 )
 
+// defaultAIAPIURL and defaultAIModel are used for OpenAI when the configuration names none
+const (
+	defaultAIAPIURL = "https://api.openai.com/v1/chat/completions"
+	defaultAIModel  = "gpt-3.5-turbo"
+)
+
+// defaultResponseCacheSize bounds the cached responses when response_cache_size is not set
+const defaultResponseCacheSize = 100
+
+// defaultAIBreakerConfig stops calling a provider after repeated failures, so queries fail
+// fast while it is down rather than each waiting out the HTTP timeout
+var defaultAIBreakerConfig = core.CircuitBreakerConfig{FailureThreshold: 5, ResetTimeout: 30 * time.Second}
+
+// defaultAIRetryPolicy retries rate limits and provider failures a couple of times, so a
+// brief outage does not reach the user as an error
+var defaultAIRetryPolicy = core.RetryPolicy{MaxAttempts: 3, InitialDelay: time.Second, MaxDelay: 30 * time.Second, Multiplier: 2, Jitter: true}
+
+// aiSettings selects the provider and model. It is never modified once built, so a
+// request keeps the settings it started with when the agent is reconfigured.
+type aiSettings struct {
+	provider aiProvider
+	apiKey   string
+	apiURL   string
+	model    string
+	// exchangeLog records full prompts and completions when debug_log is set
+	exchangeLog *aiExchangeLog
+}
+
 // AIAgent implements the AgentPlugin interface using external AI APIs
 type AIAgent struct {
-	name        string
-	version     string
-	status      core.PluginStatus
-	apiKey      string
-	apiURL      string
-	model       string
-	httpClient  *http.Client
+	name       string
+	version    string
+	status     core.PluginStatus
+	settings   *aiSettings
+	httpClient *http.Client
+	// Exchange log limits from Configure, used when debug_log is switched on later
+	debugMaxBytes int
+	debugRedact   []string
+	// responses caches answers to identical prompts for response_cache_ttl; nil disables it
+	responses core.Cache
+	// breaker fails queries fast while the provider keeps failing; nil disables it
+	breaker core.CircuitBreaker
+	// limiter paces queries to the provider's rate limit; nil means unlimited
+	limiter core.RateLimiter
+	// retrier retries requests that failed in a way that may be transient
+	retrier core.RetryExecutor
+	// usage adds up the tokens and cost of calls, kept across reconfiguration
+	usage *aiUsageTracker
+	// tools the model may call, up to maxToolRounds rounds per query, through agentTools
+	tools         []aiTool
+	maxToolRounds int
+	agentTools    core.AgentToolProvider
+	// promQL translates questions into PromQL run through agentTools; nil disables it
+	promQL      *aiPromQL
 	contextData []core.DataPoint
+	metadata    *core.MetricMetadataRegistry
 	mu          sync.RWMutex
 }
 
@@ -34,6 +83,7 @@ func NewAIAgent(name string) *AIAgent {
 		version:    "1.0.0",
 		status:     core.PluginStatusStopped,
 		httpClient: &http.Client{Timeout: 30 * time.Second},
+		usage:      newAIUsageTracker(),
 	}
 }
 
@@ -54,27 +104,228 @@ func (a *AIAgent) Version() string {
 
 // Configure initializes the plugin with configuration
 func (a *AIAgent) Configure(config map[string]interface{}) error {
-	apiKey, ok := config["api_key"].(string)
-	if !ok {
+	provider, err := newAIProvider(config)
+	if err != nil {
+		return err
+	}
+	apiKey, _ := config["api_key"].(string)
+	if apiKey == "" && provider.requiresKey() {
 		return fmt.Errorf("API key not specified")
 	}
-	a.apiKey = apiKey
-
+	settings := &aiSettings{provider: provider, apiKey: apiKey, apiURL: provider.defaultURL(), model: provider.defaultModel()}
 	if apiURL, ok := config["api_url"].(string); ok {
-		a.apiURL = apiURL
-	} else {
-		a.apiURL = "https://api.openai.com/v1/chat/completions"
+		settings.apiURL = apiURL
 	}
-
 	if model, ok := config["model"].(string); ok {
-		a.model = model
-	} else {
-		a.model = "gpt-3.5-turbo"
+		settings.model = model
+	}
+	if err := checkProviderSettings(settings); err != nil {
+		return err
+	}
+
+	maxBytes := 0
+	switch value := config["debug_log_max_bytes"].(type) {
+	case int:
+		maxBytes = value
+	case float64:
+		maxBytes = int(value)
+	}
+	var redact []string
+	if patterns, ok := config["debug_log_redact"].([]interface{}); ok {
+		for _, pattern := range patterns {
+			if text, ok := pattern.(string); ok {
+				redact = append(redact, text)
+			}
+		}
+	}
+	var responses core.Cache
+	if ttlStr, ok := config["response_cache_ttl"].(string); ok {
+		ttl, err := time.ParseDuration(ttlStr)
+		if err != nil || ttl < 0 {
+			return fmt.Errorf("invalid response_cache_ttl %q", ttlStr)
+		}
+		size := defaultResponseCacheSize
+		switch value := config["response_cache_size"].(type) {
+		case int:
+			size = value
+		case float64:
+			size = int(value)
+		}
+		if ttl > 0 {
+			responses = core.NewLRUCache(size, ttl)
+		}
+	}
+
+	breakerConfig, err := core.ParseCircuitBreakerConfig(config["circuit_breaker"], defaultAIBreakerConfig)
+	if err != nil {
+		return err
+	}
+	limiterConfig, err := core.ParseRateLimiterConfig(config["rate_limit"], core.RateLimiterConfig{})
+	if err != nil {
+		return err
+	}
+	retryPolicy, err := core.ParseRetryPolicy(config["retry"], defaultAIRetryPolicy)
+	if err != nil {
+		return err
+	}
+	pricing, budget, err := parseAIUsageConfig(config)
+	if err != nil {
+		return err
+	}
+	tools, err := parseAITools(config["tools"])
+	if err != nil {
+		return err
+	}
+	maxToolRounds := defaultMaxToolRounds
+	switch value := config["max_tool_rounds"].(type) {
+	case int:
+		maxToolRounds = value
+	case float64:
+		maxToolRounds = int(value)
+	}
+	if maxToolRounds < 1 {
+		return fmt.Errorf("max_tool_rounds must be at least 1")
+	}
+	promQL, err := parseAIPromQL(config["promql"])
+	if err != nil {
+		return err
+	}
+
+	a.debugMaxBytes = maxBytes
+	a.debugRedact = redact
+	if path, ok := config["debug_log"].(string); ok && path != "" {
+		log, err := openAIExchangeLog(path, maxBytes, redact)
+		if err != nil {
+			return err
+		}
+		settings.exchangeLog = log
+		slog.Warn("AI exchange logging enabled; prompts and completions are written to disk", "plugin", a.name, "path", path)
+	}
+
+	a.mu.Lock()
+	previous := a.settings
+	a.settings = settings
+	a.responses = responses
+	a.breaker = core.NewCircuitBreaker(breakerConfig)
+	a.limiter = core.NewRateLimiter(limiterConfig)
+	a.retrier = core.NewBackoffRetryExecutor(retryPolicy)
+	a.tools = tools
+	a.maxToolRounds = maxToolRounds
+	a.promQL = promQL
+	a.mu.Unlock()
+	a.usage.configure(pricing, budget)
+	if previous != nil {
+		previous.exchangeLog.Close()
+	}
+	return nil
+}
+
+// Reconfigure switches a running agent to another model or provider. Settings not given
+// are kept, except that switching provider moves to its default URL and model unless
+// those are given too. The new settings are checked against the API before use, and
+// requests already in flight finish with the settings they started with.
+func (a *AIAgent) Reconfigure(ctx context.Context, config map[string]interface{}) error {
+	current := a.currentSettings()
+	if current == nil {
+		return a.Configure(config)
+	}
+
+	updated := *current
+	providerConfig := map[string]interface{}{"provider": current.provider.name()}
+	if azure, ok := current.provider.(azureProvider); ok {
+		providerConfig["deployment"] = azure.deployment
+		providerConfig["api_version"] = azure.apiVersion
+	}
+	for key, value := range config {
+		text, ok := value.(string)
+		if key == "debug_log" && ok {
+			// An empty path turns exchange logging off
+			updated.exchangeLog = nil
+			if text != "" {
+				log, err := openAIExchangeLog(text, a.debugMaxBytes, a.debugRedact)
+				if err != nil {
+					return err
+				}
+				updated.exchangeLog = log
+			}
+			continue
+		}
+		if !ok || text == "" {
+			return fmt.Errorf("%s must be a non-empty string", key)
+		}
+		switch key {
+		case "api_key":
+			updated.apiKey = text
+		case "api_url":
+			updated.apiURL = text
+		case "model":
+			updated.model = text
+		case "provider", "deployment", "api_version":
+			providerConfig[key] = text
+		default:
+			return fmt.Errorf("%s cannot be changed without a restart", key)
+		}
+	}
+	provider, err := newAIProvider(providerConfig)
+	if err != nil {
+		return err
+	}
+	updated.provider = provider
+	if provider.name() != current.provider.name() {
+		if _, ok := config["api_url"]; !ok {
+			updated.apiURL = provider.defaultURL()
+		}
+		if _, ok := config["model"]; !ok {
+			updated.model = provider.defaultModel()
+		}
+		if err := checkProviderSettings(&updated); err != nil {
+			return err
+		}
+	}
+
+	if a.Status() == core.PluginStatusRunning {
+		if err := a.checkSettings(ctx, &updated); err != nil {
+			if updated.exchangeLog != current.exchangeLog {
+				updated.exchangeLog.Close()
+			}
+			return fmt.Errorf("new settings failed the health check, keeping %s: %w", current.model, err)
+		}
 	}
 
+	a.mu.Lock()
+	a.settings = &updated
+	// Failures of the previous provider say nothing about the new one
+	if a.breaker != nil && (updated.apiURL != current.apiURL || provider.name() != current.provider.name()) {
+		a.breaker.Reset()
+	}
+	a.mu.Unlock()
+	// Requests still using the old log lose their entry rather than block the switch
+	if current.exchangeLog != updated.exchangeLog {
+		current.exchangeLog.Close()
+	}
+	slog.Info("AI agent reconfigured", "plugin", a.name, "provider", provider.name(), "model", updated.model,
+		"previous_model", current.model, "api_url", updated.apiURL, "debug_log", updated.exchangeLog != nil)
 	return nil
 }
 
+// checkProviderSettings checks the settings a provider has no default for are set
+func checkProviderSettings(settings *aiSettings) error {
+	if settings.apiURL == "" {
+		return fmt.Errorf("api_url must be set for the %s provider", settings.provider.name())
+	}
+	if settings.model == "" {
+		return fmt.Errorf("model must be set for the %s provider", settings.provider.name())
+	}
+	return nil
+}
+
+// currentSettings returns the settings new requests use
+func (a *AIAgent) currentSettings() *aiSettings {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.settings
+}
+
 // Start begins the plugin's operation
 func (a *AIAgent) Start(ctx context.Context) error {
 	a.mu.Lock()
@@ -87,8 +338,14 @@ func (a *AIAgent) Start(ctx context.Context) error {
 	a.status = core.PluginStatusStarting
 	slog.Info("Starting AI agent", "plugin", a.name, "type", a.Type())
 
+	// Local servers list their models, so a model that was never pulled is reported as such
+	if err := a.checkModel(ctx, a.settings); err != nil {
+		a.status = core.PluginStatusError
+		return err
+	}
+
 	// Test API connectivity
-	if err := a.Health(ctx); err != nil {
+	if err := a.checkSettings(ctx, a.settings); err != nil {
 		a.status = core.PluginStatusError
 		return fmt.Errorf("health check failed: %w", err)
 	}
@@ -124,13 +381,36 @@ func (a *AIAgent) Status() core.PluginStatus {
 
 // Health checks if the plugin is healthy
 func (a *AIAgent) Health(ctx context.Context) error {
-	if a.apiKey == "" {
+	return a.checkSettings(ctx, a.currentSettings())
+}
+
+// checkModel checks the model is among those the provider lists, for providers that can
+// list them
+func (a *AIAgent) checkModel(ctx context.Context, settings *aiSettings) error {
+	lister, ok := settings.provider.(aiModelLister)
+	if !ok {
+		return nil
+	}
+	models, err := lister.models(ctx, a.httpClient, settings)
+	if err != nil {
+		return fmt.Errorf("failed to list models of %s: %w", settings.apiURL, err)
+	}
+	slog.Info("AI models available", "plugin", a.name, "provider", settings.provider.name(), "models", models)
+	if !hasModel(models, settings.model) {
+		return fmt.Errorf("model %s is not available from %s; available models: %s",
+			settings.model, settings.apiURL, strings.Join(models, ", "))
+	}
+	return nil
+}
+
+// checkSettings tests API connectivity with a simple request
+func (a *AIAgent) checkSettings(ctx context.Context, settings *aiSettings) error {
+	if settings == nil || (settings.apiKey == "" && settings.provider.requiresKey()) {
 		return fmt.Errorf("API key not configured")
 	}
 
-	// Test API connectivity with a simple request
 	testRequest := map[string]interface{}{
-		"model": a.model,
+		"model": settings.model,
 		"messages": []map[string]string{
 			{
 				"role":    "user",
@@ -140,7 +420,7 @@ func (a *AIAgent) Health(ctx context.Context) error {
 		"max_tokens": 5,
 	}
 
-	_, err := a.callAIAPI(testRequest)
+	_, err := a.callAIAPI(ctx, settings, testRequest)
 	return err
 }
 
@@ -158,24 +438,266 @@ func (a *AIAgent) GetCapabilities() []string {
 
 // ProcessQuery handles user queries and returns responses
 func (a *AIAgent) ProcessQuery(ctx context.Context, query string) (*core.AgentResponse, error) {
-	if a.status != core.PluginStatusRunning {
+	a.mu.RLock()
+	data := a.contextData
+	a.mu.RUnlock()
+	return a.ProcessQueryWithContext(ctx, query, data)
+}
+
+// ProcessQueryWithContext answers a query using the given data as the system context
+func (a *AIAgent) ProcessQueryWithContext(ctx context.Context, query string, data []core.DataPoint) (*core.AgentResponse, error) {
+	return a.answer(ctx, aiQuery{text: query, data: data}, nil)
+}
+
+// ProcessQueryStream answers a query like ProcessQuery, passing the answer to onChunk as
+// the provider generates it. A cached answer is passed in one chunk.
+func (a *AIAgent) ProcessQueryStream(ctx context.Context, query string, onChunk func(chunk string) error) (*core.AgentResponse, error) {
+	a.mu.RLock()
+	data := a.contextData
+	a.mu.RUnlock()
+	return a.answer(ctx, aiQuery{text: query, data: data}, onChunk)
+}
+
+// aiQuery is a query with the context it is answered in
+type aiQuery struct {
+	text string
+	data []core.DataPoint
+	// prompt builds the prompt for the model; nil builds the agent's prompt from the data
+	prompt func(model string) map[string]interface{}
+	// metadata is added to the response's metadata
+	metadata map[string]interface{}
+}
+
+// answer answers a query with its context, streaming the answer to onChunk unless it is
+// nil. When the model may call tools, the answer is only known once it stops calling
+// them, so it is passed to onChunk in one chunk.
+func (a *AIAgent) answer(ctx context.Context, q aiQuery, onChunk func(chunk string) error) (*core.AgentResponse, error) {
+	if a.Status() != core.PluginStatusRunning {
 		return nil, fmt.Errorf("agent is not running")
 	}
+	query, data := q.text, q.data
+
+	// The whole request uses one snapshot of the settings
+	settings := a.currentSettings()
 
 	// Prepare context-aware prompt
-	prompt := a.buildPrompt(query)
+	var prompt map[string]interface{}
+	if q.prompt != nil {
+		prompt = q.prompt(settings.model)
+	} else {
+		prompt = a.buildPrompt(settings.model, query, data)
+	}
+
+	// The same prompt, model, and provider within the cache TTL gets the same answer
+	a.mu.RLock()
+	responses := a.responses
+	tools, maxToolRounds, agentTools, promQL := a.tools, a.maxToolRounds, a.agentTools, a.promQL
+	a.mu.RUnlock()
+	guard := a.callGuard()
+	caller, canCallTools := settings.provider.(aiToolCaller)
+	useTools := len(tools) > 0 && agentTools != nil && canCallTools
+
+	// Once today's budget is used up, queries are answered as such rather than sent
+	refuse := func(reason string) (*core.AgentResponse, error) {
+		slog.Warn("AI budget exceeded, not calling the provider", "plugin", a.name, "budget", reason)
+		response := budgetExceededResponse(query, reason)
+		if onChunk != nil {
+			if err := onChunk(response.Response); err != nil {
+				return nil, err
+			}
+		}
+		return response, nil
+	}
+
+	// A question about metric values is first translated into PromQL, and the answer is
+	// based on the result of running it
+	var promQLRun *promQLResult
+	footer := ""
+	if promQL != nil && agentTools != nil {
+		if reason, exceeded := a.usage.exceeded(); exceeded {
+			return refuse(reason)
+		}
+		var err error
+		promQLRun, err = a.runPromQL(ctx, settings, guard, agentTools, promQL, query, data)
+		if err != nil {
+			return nil, fmt.Errorf("AI API call failed: %w", err)
+		}
+		if promQLRun != nil {
+			withPromQL(prompt, promQLRun)
+			footer = promQLFooter(promQLRun)
+		}
+	}
+
+	var cacheKey string
+	if responses != nil {
+		cacheKey = responseCacheKey(settings, prompt)
+		if cached, ok := responses.Get(cacheKey); ok {
+			response := cachedResponse(cached.(*core.AgentResponse))
+			if onChunk != nil {
+				if err := onChunk(response.Response); err != nil {
+					return nil, err
+				}
+			}
+			return response, nil
+		}
+	}
+
+	if reason, exceeded := a.usage.exceeded(); exceeded {
+		return refuse(reason)
+	}
 
-	// Call AI API
-	response, err := a.callAIAPI(prompt)
+	// Call AI API, within the provider's rate limit, unless it keeps failing, and retrying
+	// failures that may be transient
+	var content string
+	var toolCalls []core.AgentToolCall
+	var err error
+	switch {
+	case useTools:
+		content, toolCalls, err = a.callWithTools(ctx, settings, caller, agentTools, tools, maxToolRounds, guard, prompt)
+		if text, _, _ := parseAgentActions(content); err == nil && onChunk != nil && text != "" {
+			err = onChunk(text + footer)
+		}
+	case onChunk != nil:
+		// Once part of the answer is out, a retry would send it again. Proposed actions are
+		// held back from the stream and returned as the response's actions.
+		sent := false
+		filter := &actionBlockFilter{onChunk: onChunk}
+		err = guard.do(ctx, func() error {
+			var streamErr error
+			content, streamErr = a.streamAIAPI(ctx, settings, prompt, func(chunk string) error {
+				sent = true
+				return filter.write(chunk)
+			})
+			if streamErr != nil && sent {
+				return interruptedStream{err: streamErr}
+			}
+			return streamErr
+		})
+		if err == nil {
+			err = filter.flush()
+		}
+		if err == nil && footer != "" && content != "" {
+			err = onChunk(footer)
+		}
+	default:
+		err = guard.do(ctx, func() (err error) {
+			content, err = a.callAIAPI(ctx, settings, prompt)
+			return err
+		})
+	}
 	if err != nil {
 		return nil, fmt.Errorf("AI API call failed: %w", err)
 	}
 
-	// Convert response to AgentResponse
-	agentResponse := a.convertResponseToAgentResponse(response, query)
+	// Convert response to AgentResponse, showing the query the answer was based on
+	agentResponse := a.convertResponseToAgentResponse(content, settings.model, query)
+	if content != "" {
+		agentResponse.Response += footer
+	}
+	if promQLRun != nil {
+		addPromQLMetadata(agentResponse, promQLRun)
+	}
+	for key, value := range q.metadata {
+		if agentResponse.Metadata == nil {
+			agentResponse.Metadata = make(map[string]interface{})
+		}
+		agentResponse.Metadata[key] = value
+	}
+	if len(toolCalls) > 0 {
+		if agentResponse.Metadata == nil {
+			agentResponse.Metadata = make(map[string]interface{})
+		}
+		agentResponse.Metadata["tool_calls"] = toolCalls
+	}
+	if responses != nil {
+		if err := responses.Set(cacheKey, agentResponse, 0); err != nil {
+			slog.Debug("Failed to cache AI response", "plugin", a.name, "error", err)
+		}
+	}
 	return agentResponse, nil
 }
 
+// aiCallGuard paces, guards, and retries the requests of one query to the provider
+type aiCallGuard struct {
+	breaker core.CircuitBreaker
+	limiter core.RateLimiter
+	retrier core.RetryExecutor
+}
+
+// callGuard returns the guard for a query's requests
+func (a *AIAgent) callGuard() aiCallGuard {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return aiCallGuard{breaker: a.breaker, limiter: a.limiter, retrier: a.retrier}
+}
+
+// do makes a request within the rate limit and circuit breaker, retrying it as the retry
+// policy allows. Each attempt counts against the rate limit and the breaker.
+func (g aiCallGuard) do(ctx context.Context, request func() error) error {
+	attempt := func() error {
+		if g.limiter != nil {
+			if err := g.limiter.Wait(ctx); err != nil {
+				return err
+			}
+		}
+		if g.breaker != nil {
+			return g.breaker.Execute(ctx, request)
+		}
+		return request()
+	}
+	if g.retrier != nil {
+		return g.retrier.Execute(ctx, attempt)
+	}
+	return attempt()
+}
+
+// budgetExceededResponse answers a query the agent's budget leaves no room for
+func budgetExceededResponse(query, reason string) *core.AgentResponse {
+	return &core.AgentResponse{
+		Query:    query,
+		Response: fmt.Sprintf("The AI budget for today is used up (%s). Try again after midnight UTC.", reason),
+		Metadata: map[string]interface{}{
+			"budget_exceeded": true,
+			"budget":          reason,
+		},
+		Timestamp: time.Now(),
+	}
+}
+
+// WriteMetrics writes the calls, tokens, and cost of the agent's provider requests
+func (a *AIAgent) WriteMetrics(w io.Writer) {
+	a.usage.writeMetrics(w, a.name)
+}
+
+// StatusDetails reports the agent's provider, model, and usage against its budget
+func (a *AIAgent) StatusDetails() map[string]interface{} {
+	details := map[string]interface{}{"usage": a.usage.snapshot()}
+	if settings := a.currentSettings(); settings != nil {
+		details["provider"] = settings.provider.name()
+		details["model"] = settings.model
+	}
+	return details
+}
+
+// responseCacheKey identifies a prompt sent to a provider and model
+func responseCacheKey(settings *aiSettings, prompt map[string]interface{}) string {
+	encoded, _ := json.Marshal(prompt)
+	sum := sha256.Sum256(append([]byte(settings.provider.name()+"\x00"+settings.apiURL+"\x00"), encoded...))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedResponse copies a cached response, marking it as served from the cache so callers
+// may change it without affecting the cache
+func cachedResponse(response *core.AgentResponse) *core.AgentResponse {
+	copied := *response
+	copied.Metadata = make(map[string]interface{}, len(response.Metadata)+1)
+	for key, value := range response.Metadata {
+		copied.Metadata[key] = value
+	}
+	copied.Metadata["cached"] = true
+	return &copied
+}
+
 // SetContext provides the agent with current system data
 func (a *AIAgent) SetContext(data []core.DataPoint) {
 	a.mu.Lock()
@@ -196,10 +718,10 @@ func (a *AIAgent) GetAvailableQueries() []string {
 }
 
 // buildPrompt creates a context-aware prompt for the AI
-func (a *AIAgent) buildPrompt(query string) map[string]interface{} {
+func (a *AIAgent) buildPrompt(model, query string, data []core.DataPoint) map[string]interface{} {
 	contextInfo := ""
-	if len(a.contextData) > 0 {
-		contextInfo = a.formatContextData()
+	if len(data) > 0 {
+		contextInfo = a.formatContextData(data)
 	}
 
 	systemPrompt := `You are an observability expert AI agent. You have access to real-time system metrics and can help with:
@@ -212,10 +734,12 @@ func (a *AIAgent) buildPrompt(query string) map[string]interface{} {
 Current system context:
 ` + contextInfo + `
 
-Respond in a helpful, technical manner. If you need more specific data, ask for it.`
+Respond in a helpful, technical manner. If you need more specific data, ask for it.
+
+` + actionInstructions
 
 	return map[string]interface{}{
-		"model": a.model,
+		"model": model,
 		"messages": []map[string]string{
 			{
 				"role":    "system",
@@ -230,20 +754,47 @@ Respond in a helpful, technical manner. If you need more specific data, ask for
 	}
 }
 
-// callAIAPI makes the actual API call to the AI service
-func (a *AIAgent) callAIAPI(request map[string]interface{}) (map[string]interface{}, error) {
+// callAIAPI sends a chat request to the provider, returning the completion
+func (a *AIAgent) callAIAPI(ctx context.Context, settings *aiSettings, request map[string]interface{}) (string, error) {
+	body, err := a.sendAIRequest(ctx, settings, settings.provider.endpoint(settings), settings.provider.body(request))
+	if err != nil {
+		return "", err
+	}
+	return settings.provider.content(body)
+}
+
+// sendAIRequest posts a request body to the provider, returning the body of a successful
+// response
+func (a *AIAgent) sendAIRequest(ctx context.Context, settings *aiSettings, endpoint string, request interface{}) (body []byte, err error) {
 	jsonData, err := json.Marshal(request)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", a.apiURL, bytes.NewBuffer(jsonData))
+	exchange := AIExchange{
+		Timestamp: time.Now(),
+		Agent:     a.name,
+		Model:     settings.model,
+		URL:       endpoint,
+		Request:   string(jsonData),
+	}
+	if settings.exchangeLog != nil {
+		defer func() {
+			exchange.DurationMS = time.Since(exchange.Timestamp).Milliseconds()
+			if err != nil {
+				exchange.Error = err.Error()
+			}
+			settings.exchangeLog.Record(exchange, settings.apiKey)
+		}()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+a.apiKey)
+	settings.provider.authorize(req.Header, settings.apiKey)
 
 	resp, err := a.httpClient.Do(req)
 	if err != nil {
@@ -251,43 +802,26 @@ func (a *AIAgent) callAIAPI(request map[string]interface{}) (map[string]interfac
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
-
-	var response map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	body, err = io.ReadAll(resp.Body)
+	exchange.StatusCode = resp.StatusCode
+	exchange.Response = string(body)
+	if err != nil {
 		return nil, err
 	}
 
-	return response, nil
-}
-
-// convertResponseToAgentResponse converts AI response to AgentResponse format
-func (a *AIAgent) convertResponseToAgentResponse(response map[string]interface{}, query string) *core.AgentResponse {
-	// Extract content from AI response
-	choices, ok := response["choices"].([]interface{})
-	if !ok || len(choices) == 0 {
-		return &core.AgentResponse{
-			Query:      query,
-			Response:   "I'm sorry, I couldn't process your request.",
-			Confidence: 0.0,
-			Timestamp:  time.Now(),
-		}
-	}
-
-	choice, ok := choices[0].(map[string]interface{})
-	if !ok {
-		return &core.AgentResponse{
-			Query:      query,
-			Response:   "I'm sorry, I couldn't process your request.",
-			Confidence: 0.0,
-			Timestamp:  time.Now(),
-		}
+	if resp.StatusCode != http.StatusOK {
+		return nil, withRetryAfter(settings.provider.failure(resp.StatusCode, body), resp.Header)
 	}
+	usage := settings.provider.usage(body)
+	exchange.PromptTokens, exchange.CompletionTokens = usage.promptTokens, usage.completionTokens
+	a.usage.record(usage)
+	return body, nil
+}
 
-	message, ok := choice["message"].(map[string]interface{})
-	if !ok {
+// convertResponseToAgentResponse converts a completion to AgentResponse format, splitting
+// off the actions the model proposed
+func (a *AIAgent) convertResponseToAgentResponse(content, model, query string) *core.AgentResponse {
+	if content == "" {
 		return &core.AgentResponse{
 			Query:      query,
 			Response:   "I'm sorry, I couldn't process your request.",
@@ -296,59 +830,63 @@ func (a *AIAgent) convertResponseToAgentResponse(response map[string]interface{}
 		}
 	}
 
-	content, ok := message["content"].(string)
-	if !ok {
-		return &core.AgentResponse{
-			Query:      query,
-			Response:   "I'm sorry, I couldn't process your request.",
-			Confidence: 0.0,
-			Timestamp:  time.Now(),
-		}
+	text, actions, invalid := parseAgentActions(content)
+	if invalid != nil {
+		slog.Warn("Dropped actions that do not match the action schema", "plugin", a.name, "reasons", invalid)
 	}
 
 	// Calculate confidence based on response length and context
 	confidence := 0.8
-	if len(content) < 50 {
+	if len(text) < 50 {
 		confidence = 0.6
 	}
 
-	return &core.AgentResponse{
+	response := &core.AgentResponse{
 		Query:      query,
-		Response:   content,
+		Response:   text,
 		Confidence: confidence,
-		Actions:    a.extractActions(content),
+		Actions:    actions,
 		Metadata: map[string]interface{}{
-			"model":     a.model,
+			"model":     model,
 			"timestamp": time.Now(),
 		},
 		Timestamp: time.Now(),
 	}
+	if invalid != nil {
+		response.Metadata["invalid_actions"] = invalid
+	}
+	return response
 }
 
 // formatContextData formats the current context data for the AI
-func (a *AIAgent) formatContextData() string {
-	if len(a.contextData) == 0 {
+func (a *AIAgent) formatContextData(data []core.DataPoint) string {
+	if len(data) == 0 {
 		return "No current data available"
 	}
 
 	summary := make(map[string]interface{})
 	metrics := make(map[string][]float64)
 
-	for _, point := range a.contextData {
+	for _, point := range data {
 		metrics[point.Metric] = append(metrics[point.Metric], point.Value)
 	}
 
+	// Values are formatted with their units so the model sees "1.2 GB" rather than raw bytes
 	for metric, values := range metrics {
 		if len(values) > 0 {
 			sum := 0.0
 			for _, v := range values {
 				sum += v
 			}
-			summary[metric] = map[string]interface{}{
+			entry := map[string]interface{}{
 				"count":  len(values),
-				"avg":    sum / float64(len(values)),
-				"latest": values[len(values)-1],
+				"avg":    a.metadata.FormatValue(metric, sum/float64(len(values))),
+				"latest": a.metadata.FormatValue(metric, values[len(values)-1]),
+			}
+			if metadata, ok := a.metadata.Get(metric); ok && metadata.Description != "" {
+				entry["description"] = metadata.Description
 			}
+			summary[metric] = entry
 		}
 	}
 
@@ -356,30 +894,7 @@ func (a *AIAgent) formatContextData() string {
 	return string(jsonData)
 }
 
-// extractActions attempts to extract actionable items from the AI response
-func (a *AIAgent) extractActions(content string) []core.AgentAction {
-	// Simple action extraction - in practice, you'd use more sophisticated NLP
-	actions := []core.AgentAction{}
-
-	// Look for common action patterns
-	if contains(content, "restart") || contains(content, "reboot") {
-		actions = append(actions, core.AgentAction{
-			Type:        "restart",
-			Description: "Restart the service",
-		})
-	}
-
-	if contains(content, "scale") || contains(content, "increase") {
-		actions = append(actions, core.AgentAction{
-			Type:        "scale",
-			Description: "Scale up resources",
-		})
-	}
-
-	return actions
-}
-
-// contains checks if a string contains a substring (case-insensitive)
-func contains(s, substr string) bool {
-	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+// SetMetricMetadata provides units and descriptions used to format context for the model
+func (a *AIAgent) SetMetricMetadata(registry *core.MetricMetadataRegistry) {
+	a.metadata = registry
 }
@@ -0,0 +1,271 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// modelServer answers chat completions with the model the request named, holding requests
+// for a blocked model until released
+type modelServer struct {
+	*httptest.Server
+	blocked  string
+	release  chan struct{}
+	received chan string
+	reject   map[string]bool
+	mu       sync.Mutex
+}
+
+func newModelServer(t *testing.T) *modelServer {
+	server := &modelServer{release: make(chan struct{}), received: make(chan string, 10), reject: make(map[string]bool)}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Model     string `json:"model"`
+			MaxTokens int    `json:"max_tokens"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+
+		server.mu.Lock()
+		rejected := server.reject[request.Model]
+		blocked := request.Model == server.blocked && request.MaxTokens == 0
+		server.mu.Unlock()
+		if rejected {
+			http.Error(w, "unknown model", http.StatusNotFound)
+			return
+		}
+		if blocked {
+			server.received <- request.Model
+			<-server.release
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{
+				"message": map[string]interface{}{"role": "assistant", "content": "answered by " + request.Model},
+			}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAIAgent_ReconfigureModel(t *testing.T) {
+	server := newModelServer(t)
+	server.blocked = "gpt-4"
+
+	agent := NewAIAgent("ai")
+	require.NoError(t, agent.Configure(map[string]interface{}{"api_key": "key", "api_url": server.URL, "model": "gpt-4"}))
+	ctx := context.Background()
+	require.NoError(t, agent.Start(ctx))
+
+	inFlight := make(chan string)
+	go func() {
+		response, err := agent.ProcessQuery(ctx, "why is CPU high?")
+		if err != nil {
+			inFlight <- err.Error()
+			return
+		}
+		inFlight <- response.Response
+	}()
+	assert.Equal(t, "gpt-4", <-server.received)
+
+	require.NoError(t, agent.Reconfigure(ctx, map[string]interface{}{"model": "gpt-4o-mini"}))
+	close(server.release)
+	assert.Equal(t, "answered by gpt-4", <-inFlight, "Expected the in-flight request to finish on the old model")
+
+	response, err := agent.ProcessQuery(ctx, "and now?")
+	require.NoError(t, err)
+	assert.Equal(t, "answered by gpt-4o-mini", response.Response)
+	assert.Equal(t, "gpt-4o-mini", response.Metadata["model"])
+	assert.Equal(t, "key", agent.currentSettings().apiKey, "Expected settings not given to be kept")
+}
+
+func TestAIAgent_ReconfigureRejected(t *testing.T) {
+	server := newModelServer(t)
+	server.reject["gpt-5-typo"] = true
+
+	agent := NewAIAgent("ai")
+	require.NoError(t, agent.Configure(map[string]interface{}{"api_key": "key", "api_url": server.URL, "model": "gpt-4"}))
+	require.NoError(t, agent.Start(context.Background()))
+
+	err := agent.Reconfigure(context.Background(), map[string]interface{}{"model": "gpt-5-typo"})
+	assert.Error(t, err, "Expected settings failing the health check to be refused")
+	assert.Equal(t, "gpt-4", agent.currentSettings().model)
+
+	assert.Error(t, agent.Reconfigure(context.Background(), map[string]interface{}{"temperature": "0.2"}),
+		"Expected unknown settings to be refused")
+}
+
+func TestAIAgent_ResponseCache(t *testing.T) {
+	var mu sync.Mutex
+	queries := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			MaxTokens int `json:"max_tokens"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		if request.MaxTokens == 0 {
+			mu.Lock()
+			queries++
+			mu.Unlock()
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]interface{}{"content": "restart the pod"}}},
+		})
+	}))
+	defer server.Close()
+
+	agent := NewAIAgent("ai")
+	require.NoError(t, agent.Configure(map[string]interface{}{
+		"api_key": "key", "api_url": server.URL, "response_cache_ttl": "1m",
+	}))
+	ctx := context.Background()
+	require.NoError(t, agent.Start(ctx))
+
+	first, err := agent.ProcessQuery(ctx, "why is CPU high?")
+	require.NoError(t, err)
+	second, err := agent.ProcessQuery(ctx, "why is CPU high?")
+	require.NoError(t, err)
+	assert.Equal(t, first.Response, second.Response)
+	assert.Equal(t, true, second.Metadata["cached"])
+	assert.Nil(t, first.Metadata["cached"])
+
+	_, err = agent.ProcessQuery(ctx, "what about memory?")
+	require.NoError(t, err)
+	agent.SetContext([]core.DataPoint{{Metric: "cpu_usage_percent", Value: 97}})
+	_, err = agent.ProcessQuery(ctx, "why is CPU high?")
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 3, queries, "Expected only the repeated prompt to be served from the cache")
+
+	assert.Error(t, agent.Configure(map[string]interface{}{"api_key": "key", "response_cache_ttl": "soon"}))
+}
+
+func TestAIAgent_CircuitBreaker(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			MaxTokens int `json:"max_tokens"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		if request.MaxTokens > 0 {
+			// Health checks pass so the agent starts
+			json.NewEncoder(w).Encode(map[string]interface{}{"choices": []interface{}{}})
+			return
+		}
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	agent := NewAIAgent("ai")
+	require.NoError(t, agent.Configure(map[string]interface{}{
+		"api_key":         "key",
+		"api_url":         server.URL,
+		"circuit_breaker": map[string]interface{}{"failure_threshold": 2, "reset_timeout": "1m"},
+		"retry":           map[string]interface{}{"max_attempts": 1},
+	}))
+	ctx := context.Background()
+	require.NoError(t, agent.Start(ctx))
+
+	for i := 0; i < 2; i++ {
+		_, err := agent.ProcessQuery(ctx, "why is CPU high?")
+		require.Error(t, err)
+	}
+	_, err := agent.ProcessQuery(ctx, "why is CPU high?")
+	assert.ErrorIs(t, err, core.ErrCircuitOpen)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, calls, "Expected no request to the provider while the breaker is open")
+
+	assert.Error(t, agent.Configure(map[string]interface{}{"api_key": "key", "rate_limit": map[string]interface{}{"rate": -1}}))
+}
+
+func TestAIAgent_Retry(t *testing.T) {
+	var mu sync.Mutex
+	var failures []int
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			MaxTokens int `json:"max_tokens"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		if request.MaxTokens > 0 {
+			json.NewEncoder(w).Encode(map[string]interface{}{"choices": []interface{}{}})
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if len(failures) > 0 {
+			status := failures[0]
+			failures = failures[1:]
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "120")
+			}
+			http.Error(w, `{"error": {"message": "try later"}}`, status)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"choices": []map[string]interface{}{{"message": map[string]interface{}{"content": "recovered"}}}})
+	}))
+	defer server.Close()
+	fail := func(statuses ...int) {
+		mu.Lock()
+		defer mu.Unlock()
+		failures, calls = statuses, 0
+	}
+
+	agent := NewAIAgent("ai")
+	require.NoError(t, agent.Configure(map[string]interface{}{
+		"api_key": "key", "api_url": server.URL,
+		"retry": map[string]interface{}{"initial_delay": "1ms", "max_delay": "10ms"},
+	}))
+	ctx := context.Background()
+	require.NoError(t, agent.Start(ctx))
+
+	fail(http.StatusBadGateway, http.StatusServiceUnavailable)
+	response, err := agent.ProcessQuery(ctx, "why is CPU high?")
+	require.NoError(t, err, "Expected provider failures to be retried")
+	assert.Equal(t, "recovered", response.Response)
+	assert.Equal(t, 3, calls)
+
+	fail(http.StatusBadRequest)
+	_, err = agent.ProcessQuery(ctx, "why is memory high?")
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls, "Expected a bad request not to be retried")
+
+	// The provider asks for a longer wait than max_delay allows
+	fail(http.StatusTooManyRequests)
+	_, err = agent.ProcessQuery(ctx, "why is disk high?")
+	var providerErr *AIProviderError
+	require.ErrorAs(t, err, &providerErr)
+	assert.Equal(t, 2*time.Minute, providerErr.RetryAfter())
+	assert.Equal(t, 1, calls)
+
+	assert.Error(t, agent.Configure(map[string]interface{}{"api_key": "key", "retry": map[string]interface{}{"max_attempts": 0}}))
+}
+
+func TestWithRetryAfter(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	err := withRetryAfter(&AIProviderError{StatusCode: http.StatusServiceUnavailable}, header)
+	delay := err.(*AIProviderError).RetryAfter()
+	assert.InDelta(t, time.Minute.Seconds(), delay.Seconds(), 2, "Expected a date to be read as the wait until it")
+
+	plain := errors.New("connection reset")
+	assert.Equal(t, plain, withRetryAfter(plain, header))
+}
@@ -0,0 +1,142 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// defaultExchangeMaxBytes bounds how much of each prompt and completion is logged
+const defaultExchangeMaxBytes = 16 * 1024
+
+// redacted replaces secrets in logged prompts and completions
+const redacted = "[REDACTED]"
+
+// secretPatterns match credentials that commonly end up in prompts, such as tokens pasted
+// into a question or found in metric labels and documents
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{16,}`),
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{20,}`),
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]{8,}`),
+}
+
+// secretAssignment matches key=value and "key": "value" pairs whose key names a secret;
+// the key is kept and the value replaced
+var secretAssignment = regexp.MustCompile(`(?i)((?:password|passwd|secret|token|api[_-]?key|authorization|credentials?)\\?"?\s*[:=]\s*\\?"?)([^"\\\s,}&]+)`)
+
+// AIExchange is one request to the AI API and its response, as written to the exchange log
+type AIExchange struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Agent      string    `json:"agent"`
+	Model      string    `json:"model"`
+	URL        string    `json:"url"`
+	DurationMS int64     `json:"duration_ms"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Request    string    `json:"request"`
+	Response   string    `json:"response,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	// Tokens the call used, as the provider reported them
+	PromptTokens     int64 `json:"prompt_tokens,omitempty"`
+	CompletionTokens int64 `json:"completion_tokens,omitempty"`
+}
+
+// aiExchangeLog writes full prompts and completions as JSON Lines for diagnosing bad
+// answers. Secrets are redacted and long bodies truncated. A nil log discards exchanges.
+type aiExchangeLog struct {
+	path     string
+	maxBytes int
+	patterns []*regexp.Regexp
+	closer   io.Closer
+	encoder  *json.Encoder
+	mu       sync.Mutex
+}
+
+// newAIExchangeLog creates an exchange log writing to w
+func newAIExchangeLog(w io.Writer, maxBytes int, extraPatterns []string) (*aiExchangeLog, error) {
+	if maxBytes <= 0 {
+		maxBytes = defaultExchangeMaxBytes
+	}
+	patterns := append([]*regexp.Regexp{}, secretPatterns...)
+	for _, pattern := range extraPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid debug_log_redact pattern %q: %w", pattern, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return &aiExchangeLog{maxBytes: maxBytes, patterns: patterns, encoder: json.NewEncoder(w)}, nil
+}
+
+// openAIExchangeLog opens or creates an exchange log file, appending to existing content.
+// The file is only readable by its owner since it holds prompts.
+func openAIExchangeLog(path string, maxBytes int, extraPatterns []string) (*aiExchangeLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open debug log %s: %w", path, err)
+	}
+	log, err := newAIExchangeLog(file, maxBytes, extraPatterns)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	log.path = path
+	log.closer = file
+	return log, nil
+}
+
+// Record redacts, truncates, and writes an exchange. The API key in use is always redacted.
+func (l *aiExchangeLog) Record(exchange AIExchange, apiKey string) {
+	if l == nil {
+		return
+	}
+	exchange.Request = l.clean(exchange.Request, apiKey)
+	exchange.Response = l.clean(exchange.Response, apiKey)
+	exchange.Error = l.clean(exchange.Error, apiKey)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// Logging must never fail a query, so write errors are dropped
+	_ = l.encoder.Encode(exchange)
+}
+
+// clean redacts secrets and truncates the text to the size limit
+func (l *aiExchangeLog) clean(text, apiKey string) string {
+	if text == "" {
+		return text
+	}
+	if apiKey != "" {
+		text = strings.ReplaceAll(text, apiKey, redacted)
+	}
+	for _, pattern := range l.patterns {
+		text = pattern.ReplaceAllString(text, redacted)
+	}
+	text = secretAssignment.ReplaceAllString(text, "${1}"+redacted)
+
+	if len(text) > l.maxBytes {
+		cut := l.maxBytes
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = fmt.Sprintf("%s…[truncated %d bytes]", text[:cut], len(text)-cut)
+	}
+	return text
+}
+
+// Close closes the underlying file, if the log opened one
+func (l *aiExchangeLog) Close() error {
+	if l == nil || l.closer == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closer.Close()
+}
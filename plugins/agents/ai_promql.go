@@ -0,0 +1,217 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// maxPromQLMetrics bounds the metric names listed for the model to translate against
+const maxPromQLMetrics = 100
+
+// promQLSystemPrompt asks the model for a query rather than an answer
+const promQLSystemPrompt = `You translate questions about system metrics into PromQL for Prometheus.
+Reply with a single PromQL expression and nothing else: no explanation and no code fences.
+The query is evaluated as an instant query at the current time, so express a time range such
+as "last hour" with a range selector, for example rate(http_requests_total[1h]).
+If the question is not about metric values, or no metric fits, reply with NONE.`
+
+// aiPromQL turns questions into PromQL, which is run through a collector so the model
+// answers from the real result
+type aiPromQL struct {
+	// collector runs the queries; empty picks the first collector that supports queries
+	collector string
+}
+
+// promQLResult is the query a question was translated to and what running it returned
+type promQLResult struct {
+	query  string
+	points []core.DataPoint
+	// err is set when the query could not be run
+	err error
+}
+
+// parseAIPromQL reads the promql setting: true, or a map with an optional collector and
+// enabled flag
+func parseAIPromQL(value interface{}) (*aiPromQL, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		if !v {
+			return nil, nil
+		}
+		return &aiPromQL{}, nil
+	case map[string]interface{}:
+		if enabled, ok := v["enabled"]; ok {
+			on, isBool := enabled.(bool)
+			if !isBool {
+				return nil, fmt.Errorf("promql.enabled must be true or false")
+			}
+			if !on {
+				return nil, nil
+			}
+		}
+		promQL := &aiPromQL{}
+		if collector, ok := v["collector"]; ok {
+			name, isString := collector.(string)
+			if !isString {
+				return nil, fmt.Errorf("promql.collector must be a string")
+			}
+			promQL.collector = name
+		}
+		return promQL, nil
+	default:
+		return nil, fmt.Errorf("promql must be true or a map")
+	}
+}
+
+// runPromQL translates a question into PromQL and runs it. It returns nil when the model
+// finds the question is not about metric values.
+func (a *AIAgent) runPromQL(ctx context.Context, settings *aiSettings, guard aiCallGuard, tools core.AgentToolProvider, promQL *aiPromQL, question string, data []core.DataPoint) (*promQLResult, error) {
+	request := map[string]interface{}{
+		"model": settings.model,
+		"messages": []map[string]string{
+			{"role": "system", "content": promQLSystemPrompt + a.promQLMetrics(data)},
+			{"role": "user", "content": question},
+		},
+		"temperature": 0.0,
+	}
+	var content string
+	err := guard.do(ctx, func() (err error) {
+		content, err = a.callAIAPI(ctx, settings, request)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	query := cleanPromQL(content)
+	if query == "" {
+		return nil, nil
+	}
+
+	result := &promQLResult{query: query}
+	result.points, result.err = tools.QueryCollector(ctx, promQL.collector, query)
+	if result.err != nil {
+		slog.Warn("Generated PromQL query failed", "plugin", a.name, "query", query, "error", result.err)
+	}
+	return result, nil
+}
+
+// promQLMetrics lists the metrics the model can query, with their units and descriptions
+func (a *AIAgent) promQLMetrics(data []core.DataPoint) string {
+	seen := make(map[string]bool)
+	var names []string
+	if a.metadata != nil {
+		for _, metadata := range a.metadata.List() {
+			seen[metadata.Name] = true
+			names = append(names, metadata.Name)
+		}
+	}
+	for _, point := range data {
+		if !seen[point.Metric] {
+			seen[point.Metric] = true
+			names = append(names, point.Metric)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	if len(names) > maxPromQLMetrics {
+		names = names[:maxPromQLMetrics]
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\nKnown metrics:\n")
+	for _, name := range names {
+		metadata, _ := a.metadata.Get(name)
+		b.WriteString("- " + name)
+		if metadata.Unit != "" {
+			b.WriteString(" (" + metadata.Unit + ")")
+		}
+		if metadata.Description != "" {
+			b.WriteString(": " + metadata.Description)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// cleanPromQL extracts the query from the model's reply, which may wrap it in a code
+// fence or backticks despite being asked not to. NONE becomes empty.
+func cleanPromQL(content string) string {
+	query := strings.TrimSpace(content)
+	if strings.HasPrefix(query, "```") {
+		query = strings.TrimPrefix(query, "```")
+		if newline := strings.Index(query, "\n"); newline >= 0 {
+			query = query[newline+1:]
+		}
+		query = strings.TrimSuffix(strings.TrimSpace(query), "```")
+	}
+	query = strings.TrimSpace(strings.Trim(query, "`"))
+	if strings.EqualFold(query, "NONE") {
+		return ""
+	}
+	return query
+}
+
+// withPromQL adds the query and its result to the system prompt of a chat request
+func withPromQL(prompt map[string]interface{}, result *promQLResult) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n\nThe question was translated to this PromQL query, which was run against Prometheus:\n%s\n", result.query)
+	switch {
+	case result.err != nil:
+		fmt.Fprintf(&b, "The query failed: %v\nSay that the data could not be retrieved rather than guessing values.", result.err)
+	case len(result.points) == 0:
+		b.WriteString("The query returned no series. Say so rather than guessing values.")
+	default:
+		b.WriteString("Result:\n")
+		points := result.points
+		if len(points) > maxToolResults {
+			points = points[:maxToolResults]
+		}
+		for _, point := range points {
+			fmt.Fprintf(&b, "- %s %g\n", promQLSeries(point), point.Value)
+		}
+		b.WriteString("Answer from this result.")
+	}
+
+	messages := prompt["messages"].([]map[string]string)
+	messages[0]["content"] += b.String()
+}
+
+// promQLFooter shows the user the query an answer was based on
+func promQLFooter(result *promQLResult) string {
+	return fmt.Sprintf("\n\nPromQL: `%s`", result.query)
+}
+
+// addPromQLMetadata records the query and its outcome in a response's metadata
+func addPromQLMetadata(response *core.AgentResponse, result *promQLResult) {
+	if response.Metadata == nil {
+		response.Metadata = make(map[string]interface{})
+	}
+	response.Metadata["promql"] = result.query
+	response.Metadata["promql_series"] = len(result.points)
+	if result.err != nil {
+		response.Metadata["promql_error"] = result.err.Error()
+	}
+}
+
+// promQLSeries renders a result series as metric{k="v",...} with sorted labels
+func promQLSeries(point core.DataPoint) string {
+	keys := make([]string, 0, len(point.Labels))
+	for k := range point.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, point.Labels[k]))
+	}
+	return point.Metric + "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,135 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPromQLServer replies to translation requests with translation and to other chat
+// requests with answer, streamed when asked, recording the system prompts of the answers
+func newPromQLServer(t *testing.T, translation, answer string) (*httptest.Server, chan string) {
+	systems := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body struct {
+			Stream   bool `json:"stream"`
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.Unmarshal(data, &body)
+		reply := answer
+		if strings.HasPrefix(body.Messages[0].Content, "You translate questions") {
+			reply = translation
+		} else if body.Messages[0].Role == "system" {
+			systems <- body.Messages[0].Content
+		}
+		encoded, _ := json.Marshal(reply)
+		if body.Stream {
+			fmt.Fprintf(w, "data: {\"choices\": [{\"delta\": {\"content\": %s}}]}\n\ndata: [DONE]\n\n", encoded)
+			return
+		}
+		fmt.Fprintf(w, `{"choices": [{"message": {"content": %s}}]}`, encoded)
+	}))
+	t.Cleanup(server.Close)
+	return server, systems
+}
+
+// promQLTools runs PromQL queries against fixed results, recording the queries
+type promQLTools struct {
+	fakeAgentTools
+	points  []core.DataPoint
+	err     error
+	queries []string
+}
+
+func (p *promQLTools) QueryCollector(ctx context.Context, collector, query string) ([]core.DataPoint, error) {
+	p.queries = append(p.queries, collector+"|"+query)
+	return p.points, p.err
+}
+
+func TestAIAgent_PromQL(t *testing.T) {
+	server, systems := newPromQLServer(t,
+		"```promql\nhistogram_quantile(0.99, sum(rate(http_request_duration_seconds_bucket{service=\"checkout\"}[1h])) by (le))\n```",
+		"p99 latency of checkout over the last hour is 420ms.")
+	agent := NewAIAgent("ai")
+	require.NoError(t, agent.Configure(map[string]interface{}{
+		"api_key": "key", "api_url": server.URL, "promql": map[string]interface{}{"collector": "prometheus"},
+	}))
+	tools := &promQLTools{points: []core.DataPoint{{Metric: "", Value: 0.42, Labels: map[string]string{"service": "checkout"}}}}
+	agent.SetAgentTools(tools)
+	ctx := context.Background()
+	require.NoError(t, agent.Start(ctx))
+
+	response, err := agent.ProcessQuery(ctx, "p99 latency of checkout last hour")
+	require.NoError(t, err)
+	query := `histogram_quantile(0.99, sum(rate(http_request_duration_seconds_bucket{service="checkout"}[1h])) by (le))`
+	assert.Equal(t, []string{"prometheus|" + query}, tools.queries, "Expected the generated query to be run through the configured collector")
+	assert.Equal(t, query, response.Metadata["promql"])
+	assert.Equal(t, 1, response.Metadata["promql_series"])
+	assert.True(t, strings.HasSuffix(response.Response, "PromQL: `"+query+"`"), "Expected the answer to show its query")
+
+	system := <-systems
+	assert.Contains(t, system, query)
+	assert.Contains(t, system, `{service="checkout"} 0.42`, "Expected the answer to be based on the real result")
+
+	// A failed query is reported to the model and the user rather than failing the answer
+	tools.err = fmt.Errorf("parse error")
+	var chunks []string
+	response, err = agent.ProcessQueryStream(ctx, "p99 latency of checkout last hour", func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "parse error", response.Metadata["promql_error"])
+	assert.Contains(t, <-systems, "The query failed: parse error")
+	require.NotEmpty(t, chunks)
+	assert.Contains(t, chunks[len(chunks)-1], "PromQL:", "Expected the streamed answer to end with its query")
+}
+
+func TestAIAgent_PromQLNotAboutMetrics(t *testing.T) {
+	server, _ := newPromQLServer(t, "NONE", "Restart the pod with kubectl rollout restart.")
+	agent := NewAIAgent("ai")
+	require.NoError(t, agent.Configure(map[string]interface{}{"api_key": "key", "api_url": server.URL, "promql": true}))
+	tools := &promQLTools{}
+	agent.SetAgentTools(tools)
+	ctx := context.Background()
+	require.NoError(t, agent.Start(ctx))
+
+	response, err := agent.ProcessQuery(ctx, "how do I restart the checkout pod?")
+	require.NoError(t, err)
+	assert.Empty(t, tools.queries, "Expected no query for a question that is not about metric values")
+	assert.NotContains(t, response.Metadata, "promql")
+	assert.Equal(t, "Restart the pod with kubectl rollout restart.", response.Response)
+}
+
+func TestParseAIPromQL(t *testing.T) {
+	promQL, err := parseAIPromQL(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, promQL)
+	promQL, err = parseAIPromQL(map[string]interface{}{"enabled": false, "collector": "prometheus"})
+	assert.NoError(t, err)
+	assert.Nil(t, promQL)
+	promQL, err = parseAIPromQL(map[string]interface{}{"collector": "prometheus"})
+	require.NoError(t, err)
+	assert.Equal(t, "prometheus", promQL.collector)
+	_, err = parseAIPromQL("yes")
+	assert.Error(t, err)
+	_, err = parseAIPromQL(map[string]interface{}{"collector": 3})
+	assert.Error(t, err)
+
+	assert.Equal(t, "up", cleanPromQL("  `up`\n"))
+	assert.Equal(t, "rate(x[5m])", cleanPromQL("```\nrate(x[5m])\n```"))
+	assert.Equal(t, "", cleanPromQL("none"))
+}
@@ -0,0 +1,736 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Providers an AI agent can call, chosen with the provider setting
+const (
+	AIProviderOpenAI    = "openai"
+	AIProviderAnthropic = "anthropic"
+	AIProviderGemini    = "gemini"
+	AIProviderAzure     = "azure"
+	AIProviderOllama    = "ollama"
+	// AIProviderCompatible is any server implementing the OpenAI chat completions API,
+	// such as vLLM, LM Studio, or llama.cpp
+	AIProviderCompatible = "openai_compatible"
+)
+
+const (
+	// anthropicVersion is the version of the Anthropic Messages API requests are written for
+	anthropicVersion = "2023-06-01"
+	// anthropicMaxTokens bounds completions when a request sets no limit, which the
+	// Anthropic API requires
+	anthropicMaxTokens = 1024
+	// defaultAzureAPIVersion is the Azure OpenAI API version used when api_version is not set
+	defaultAzureAPIVersion = "2024-06-01"
+	// modelListLimit bounds the body of a model listing read from a local server
+	modelListLimit = 1 << 20
+)
+
+// aiProvider maps chat requests to a provider's API and its responses back. Requests are
+// built by the agents in the OpenAI chat completions shape: a model, messages with roles,
+// and optional temperature and max_tokens.
+type aiProvider interface {
+	// name is the provider setting that selects it
+	name() string
+	// defaultURL and defaultModel are used when the configuration names none
+	defaultURL() string
+	defaultModel() string
+	// requiresKey reports whether requests need an API key; local servers usually don't
+	requiresKey() bool
+	// endpoint returns the URL a request is sent to
+	endpoint(settings *aiSettings) string
+	// authorize sets the headers that authenticate a request
+	authorize(header http.Header, apiKey string)
+	// body maps a chat request to the provider's request body
+	body(chat map[string]interface{}) interface{}
+	// content extracts the completion from a successful response; an empty one means the
+	// provider gave no answer
+	content(body []byte) (string, error)
+	// failure describes an unsuccessful response
+	failure(status int, body []byte) error
+	// usage extracts the tokens a response or streamed event reports; streams report
+	// running totals, and events without counts report zero
+	usage(data []byte) aiUsage
+	// stream returns the endpoint and body of a request for the answer as it is generated,
+	// which comes back as server-sent events or JSON lines
+	stream(settings *aiSettings, chat map[string]interface{}) (string, interface{})
+	// chunk extracts the text of one streamed event; done reports the answer is complete
+	chunk(data []byte) (text string, done bool, err error)
+}
+
+// aiModelLister is a provider whose models can be listed, which local servers offer to
+// check a model is there before it is asked anything
+type aiModelLister interface {
+	models(ctx context.Context, client *http.Client, settings *aiSettings) ([]string, error)
+}
+
+// AIProviderError is an error response from an AI provider
+type AIProviderError struct {
+	Provider   string
+	StatusCode int
+	// Type is the provider's classification of the error, such as rate_limit_error
+	Type    string
+	Message string
+	// RetryDelay is how long the provider asked to wait before retrying, from Retry-After
+	RetryDelay time.Duration
+}
+
+// Retryable reports whether sending the request again may succeed: after a rate limit or
+// a failure of the provider, but not after a bad request or a failure partway through a
+// streamed answer
+func (e *AIProviderError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// RetryAfter returns how long the provider asked to wait before retrying
+func (e *AIProviderError) RetryAfter() time.Duration {
+	return e.RetryDelay
+}
+
+// withRetryAfter adds the wait a failed response's Retry-After header asks for, given in
+// seconds or as a date, to a provider error
+func withRetryAfter(err error, header http.Header) error {
+	var providerErr *AIProviderError
+	value := header.Get("Retry-After")
+	if value == "" || !errors.As(err, &providerErr) {
+		return err
+	}
+	if seconds, parseErr := strconv.Atoi(value); parseErr == nil && seconds > 0 {
+		providerErr.RetryDelay = time.Duration(seconds) * time.Second
+	} else if at, parseErr := http.ParseTime(value); parseErr == nil {
+		providerErr.RetryDelay = time.Until(at)
+	}
+	return err
+}
+
+func (e *AIProviderError) Error() string {
+	// Errors sent in the middle of a stream come with the status of the stream
+	text := "API stream failed"
+	if e.StatusCode != http.StatusOK {
+		text = fmt.Sprintf("API returned status %d", e.StatusCode)
+	}
+	if e.Type != "" {
+		text += " (" + e.Type + ")"
+	}
+	if e.Message != "" {
+		text += ": " + e.Message
+	}
+	return text
+}
+
+// newAIProvider returns the provider a plugin config selects, OpenAI by default
+func newAIProvider(config map[string]interface{}) (aiProvider, error) {
+	name, _ := config["provider"].(string)
+	switch name {
+	case "", AIProviderOpenAI:
+		return openAIProvider{}, nil
+	case AIProviderAnthropic:
+		return anthropicProvider{}, nil
+	case AIProviderGemini:
+		return geminiProvider{}, nil
+	case AIProviderAzure:
+		provider := azureProvider{apiVersion: defaultAzureAPIVersion}
+		provider.deployment, _ = config["deployment"].(string)
+		if version, ok := config["api_version"].(string); ok && version != "" {
+			provider.apiVersion = version
+		}
+		return provider, nil
+	case AIProviderOllama:
+		return ollamaProvider{}, nil
+	case AIProviderCompatible:
+		return compatibleProvider{}, nil
+	default:
+		return nil, fmt.Errorf("unknown AI provider %q; use openai, anthropic, gemini, azure, ollama, or openai_compatible", name)
+	}
+}
+
+// chatMessages returns the messages of a chat request
+func chatMessages(chat map[string]interface{}) []map[string]string {
+	messages, _ := chat["messages"].([]map[string]string)
+	return messages
+}
+
+// splitSystemPrompt separates the system messages of a chat request, which Anthropic and
+// Gemini take apart from the conversation
+func splitSystemPrompt(chat map[string]interface{}) (string, []map[string]string) {
+	var system []string
+	var conversation []map[string]string
+	for _, message := range chatMessages(chat) {
+		if message["role"] == "system" {
+			system = append(system, message["content"])
+			continue
+		}
+		conversation = append(conversation, message)
+	}
+	return strings.Join(system, "\n\n"), conversation
+}
+
+// openAIProvider calls the OpenAI chat completions API, or any API compatible with it
+type openAIProvider struct{}
+
+func (openAIProvider) name() string         { return AIProviderOpenAI }
+func (openAIProvider) defaultURL() string   { return defaultAIAPIURL }
+func (openAIProvider) requiresKey() bool    { return true }
+func (openAIProvider) defaultModel() string { return defaultAIModel }
+
+func (openAIProvider) endpoint(settings *aiSettings) string {
+	return settings.apiURL
+}
+
+func (openAIProvider) authorize(header http.Header, apiKey string) {
+	header.Set("Authorization", "Bearer "+apiKey)
+}
+
+func (openAIProvider) body(chat map[string]interface{}) interface{} {
+	return chat
+}
+
+func (openAIProvider) content(body []byte) (string, error) {
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", err
+	}
+	if len(response.Choices) == 0 {
+		return "", nil
+	}
+	return response.Choices[0].Message.Content, nil
+}
+
+func (p openAIProvider) failure(status int, body []byte) error {
+	return openAIFailure(p.name(), status, body)
+}
+
+// stream asks for the usage too, which comes in a last event without choices
+func (p openAIProvider) stream(settings *aiSettings, chat map[string]interface{}) (string, interface{}) {
+	return p.endpoint(settings), withStreamUsage(streaming(p.body(chat)))
+}
+
+func (p openAIProvider) chunk(data []byte) (string, bool, error) {
+	return openAIChunk(p.name(), data)
+}
+
+// streaming adds the stream flag to a request body
+func streaming(body interface{}) interface{} {
+	fields, _ := body.(map[string]interface{})
+	streamed := make(map[string]interface{}, len(fields)+1)
+	for key, value := range fields {
+		streamed[key] = value
+	}
+	streamed["stream"] = true
+	return streamed
+}
+
+// withStreamUsage asks the OpenAI API to report the usage of a streamed answer
+func withStreamUsage(body interface{}) interface{} {
+	fields, _ := body.(map[string]interface{})
+	fields["stream_options"] = map[string]interface{}{"include_usage": true}
+	return fields
+}
+
+// openAIChunk reads a streamed event of the OpenAI API, which ends with [DONE]
+func openAIChunk(provider string, data []byte) (string, bool, error) {
+	if string(data) == "[DONE]" {
+		return "", true, nil
+	}
+	var event struct {
+		Choices []struct {
+			Delta struct {
+				Content string `json:"content"`
+			} `json:"delta"`
+		} `json:"choices"`
+		Error *struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return "", false, err
+	}
+	if event.Error != nil {
+		return "", false, &AIProviderError{Provider: provider, StatusCode: http.StatusOK, Type: event.Error.Type, Message: event.Error.Message}
+	}
+	if len(event.Choices) == 0 {
+		return "", false, nil
+	}
+	return event.Choices[0].Delta.Content, false, nil
+}
+
+// openAIFailure reads an error response of the OpenAI API, which Azure shares
+func openAIFailure(provider string, status int, body []byte) error {
+	var response struct {
+		Error struct {
+			Type    string      `json:"type"`
+			Code    interface{} `json:"code"`
+			Message string      `json:"message"`
+		} `json:"error"`
+	}
+	_ = json.Unmarshal(body, &response)
+	errorType := response.Error.Type
+	if code, ok := response.Error.Code.(string); ok && errorType == "" {
+		errorType = code
+	}
+	return &AIProviderError{Provider: provider, StatusCode: status, Type: errorType, Message: response.Error.Message}
+}
+
+// azureProvider calls a deployment of Azure OpenAI. The api_url setting is the resource
+// endpoint, and the deployment defaults to the model name.
+type azureProvider struct {
+	deployment string
+	apiVersion string
+}
+
+func (azureProvider) name() string         { return AIProviderAzure }
+func (azureProvider) defaultURL() string   { return "" }
+func (azureProvider) requiresKey() bool    { return true }
+func (azureProvider) defaultModel() string { return defaultAIModel }
+
+func (p azureProvider) endpoint(settings *aiSettings) string {
+	deployment := p.deployment
+	if deployment == "" {
+		deployment = settings.model
+	}
+	return fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+		strings.TrimSuffix(settings.apiURL, "/"), url.PathEscape(deployment), url.QueryEscape(p.apiVersion))
+}
+
+func (azureProvider) authorize(header http.Header, apiKey string) {
+	header.Set("api-key", apiKey)
+}
+
+// body leaves out the model, which the deployment decides
+func (azureProvider) body(chat map[string]interface{}) interface{} {
+	body := make(map[string]interface{}, len(chat))
+	for key, value := range chat {
+		if key != "model" {
+			body[key] = value
+		}
+	}
+	return body
+}
+
+func (azureProvider) content(body []byte) (string, error) {
+	return openAIProvider{}.content(body)
+}
+
+func (p azureProvider) failure(status int, body []byte) error {
+	return openAIFailure(p.name(), status, body)
+}
+
+func (p azureProvider) stream(settings *aiSettings, chat map[string]interface{}) (string, interface{}) {
+	return p.endpoint(settings), withStreamUsage(streaming(p.body(chat)))
+}
+
+func (p azureProvider) chunk(data []byte) (string, bool, error) {
+	return openAIChunk(p.name(), data)
+}
+
+// anthropicProvider calls the Anthropic Messages API
+type anthropicProvider struct{}
+
+func (anthropicProvider) name() string         { return AIProviderAnthropic }
+func (anthropicProvider) defaultURL() string   { return "https://api.anthropic.com/v1/messages" }
+func (anthropicProvider) requiresKey() bool    { return true }
+func (anthropicProvider) defaultModel() string { return "claude-3-5-haiku-latest" }
+
+func (anthropicProvider) endpoint(settings *aiSettings) string {
+	return settings.apiURL
+}
+
+func (anthropicProvider) authorize(header http.Header, apiKey string) {
+	header.Set("x-api-key", apiKey)
+	header.Set("anthropic-version", anthropicVersion)
+}
+
+func (anthropicProvider) body(chat map[string]interface{}) interface{} {
+	system, conversation := splitSystemPrompt(chat)
+	body := map[string]interface{}{
+		"model":      chat["model"],
+		"messages":   conversation,
+		"max_tokens": anthropicMaxTokens,
+	}
+	if system != "" {
+		body["system"] = system
+	}
+	if maxTokens, ok := chat["max_tokens"]; ok {
+		body["max_tokens"] = maxTokens
+	}
+	if temperature, ok := chat["temperature"]; ok {
+		body["temperature"] = temperature
+	}
+	return body
+}
+
+func (anthropicProvider) content(body []byte) (string, error) {
+	var response struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", err
+	}
+	var text strings.Builder
+	for _, block := range response.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	return text.String(), nil
+}
+
+func (p anthropicProvider) failure(status int, body []byte) error {
+	var response struct {
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	_ = json.Unmarshal(body, &response)
+	return &AIProviderError{Provider: p.name(), StatusCode: status, Type: response.Error.Type, Message: response.Error.Message}
+}
+
+func (p anthropicProvider) stream(settings *aiSettings, chat map[string]interface{}) (string, interface{}) {
+	return p.endpoint(settings), streaming(p.body(chat))
+}
+
+// chunk reads the text deltas of a streamed message, which ends with message_stop
+func (p anthropicProvider) chunk(data []byte) (string, bool, error) {
+	var event struct {
+		Type  string `json:"type"`
+		Delta struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"delta"`
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return "", false, err
+	}
+	switch event.Type {
+	case "content_block_delta":
+		if event.Delta.Type == "text_delta" {
+			return event.Delta.Text, false, nil
+		}
+	case "message_stop":
+		return "", true, nil
+	case "error":
+		return "", false, &AIProviderError{Provider: p.name(), StatusCode: http.StatusOK, Type: event.Error.Type, Message: event.Error.Message}
+	}
+	return "", false, nil
+}
+
+// geminiProvider calls the Google Gemini generateContent API. The api_url setting is the
+// API base, under which each model has its own endpoint.
+type geminiProvider struct{}
+
+func (geminiProvider) name() string         { return AIProviderGemini }
+func (geminiProvider) defaultURL() string   { return "https://generativelanguage.googleapis.com/v1beta" }
+func (geminiProvider) requiresKey() bool    { return true }
+func (geminiProvider) defaultModel() string { return "gemini-1.5-flash" }
+
+func (geminiProvider) endpoint(settings *aiSettings) string {
+	return fmt.Sprintf("%s/models/%s:generateContent", strings.TrimSuffix(settings.apiURL, "/"), url.PathEscape(settings.model))
+}
+
+func (geminiProvider) authorize(header http.Header, apiKey string) {
+	header.Set("x-goog-api-key", apiKey)
+}
+
+// body maps the conversation to contents, where the assistant's role is called model
+func (geminiProvider) body(chat map[string]interface{}) interface{} {
+	type part struct {
+		Text string `json:"text"`
+	}
+	type content struct {
+		Role  string `json:"role,omitempty"`
+		Parts []part `json:"parts"`
+	}
+
+	system, conversation := splitSystemPrompt(chat)
+	contents := make([]content, 0, len(conversation))
+	for _, message := range conversation {
+		role := "user"
+		if message["role"] == "assistant" {
+			role = "model"
+		}
+		contents = append(contents, content{Role: role, Parts: []part{{Text: message["content"]}}})
+	}
+	body := map[string]interface{}{"contents": contents}
+	if system != "" {
+		body["systemInstruction"] = content{Parts: []part{{Text: system}}}
+	}
+	generation := make(map[string]interface{})
+	if maxTokens, ok := chat["max_tokens"]; ok {
+		generation["maxOutputTokens"] = maxTokens
+	}
+	if temperature, ok := chat["temperature"]; ok {
+		generation["temperature"] = temperature
+	}
+	if len(generation) > 0 {
+		body["generationConfig"] = generation
+	}
+	return body
+}
+
+// content fails for a prompt Gemini blocked, which it answers without candidates
+func (geminiProvider) content(body []byte) (string, error) {
+	var response struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"content"`
+		} `json:"candidates"`
+		PromptFeedback struct {
+			BlockReason string `json:"blockReason"`
+		} `json:"promptFeedback"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", err
+	}
+	if reason := response.PromptFeedback.BlockReason; reason != "" {
+		return "", fmt.Errorf("prompt blocked by the provider: %s", reason)
+	}
+	if len(response.Candidates) == 0 {
+		return "", nil
+	}
+	var text strings.Builder
+	for _, part := range response.Candidates[0].Content.Parts {
+		text.WriteString(part.Text)
+	}
+	return text.String(), nil
+}
+
+func (p geminiProvider) failure(status int, body []byte) error {
+	var response struct {
+		Error struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	_ = json.Unmarshal(body, &response)
+	return &AIProviderError{Provider: p.name(), StatusCode: status, Type: response.Error.Status, Message: response.Error.Message}
+}
+
+// stream asks for server-sent events, each a partial response
+func (geminiProvider) stream(settings *aiSettings, chat map[string]interface{}) (string, interface{}) {
+	endpoint := fmt.Sprintf("%s/models/%s:streamGenerateContent?alt=sse", strings.TrimSuffix(settings.apiURL, "/"), url.PathEscape(settings.model))
+	return endpoint, geminiProvider{}.body(chat)
+}
+
+func (p geminiProvider) chunk(data []byte) (string, bool, error) {
+	text, err := p.content(data)
+	return text, false, err
+}
+
+// authorizeIfKeyed sends an API key as a bearer token when one is set, for local servers
+// behind an authenticating proxy
+func authorizeIfKeyed(header http.Header, apiKey string) {
+	if apiKey != "" {
+		header.Set("Authorization", "Bearer "+apiKey)
+	}
+}
+
+// fetchModels reads a model listing into v
+func fetchModels(ctx context.Context, client *http.Client, settings *aiSettings, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	authorizeIfKeyed(req.Header, settings.apiKey)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, modelListLimit))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return settings.provider.failure(resp.StatusCode, body)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// ollamaProvider calls a local Ollama server. The api_url setting is the server's
+// address, and no API key is needed.
+type ollamaProvider struct{}
+
+func (ollamaProvider) name() string         { return AIProviderOllama }
+func (ollamaProvider) defaultURL() string   { return "http://localhost:11434" }
+func (ollamaProvider) requiresKey() bool    { return false }
+func (ollamaProvider) defaultModel() string { return "llama3.2" }
+
+func (ollamaProvider) endpoint(settings *aiSettings) string {
+	return strings.TrimSuffix(settings.apiURL, "/") + "/api/chat"
+}
+
+func (ollamaProvider) authorize(header http.Header, apiKey string) {
+	authorizeIfKeyed(header, apiKey)
+}
+
+// body asks for the whole answer at once rather than streamed
+func (ollamaProvider) body(chat map[string]interface{}) interface{} {
+	body := map[string]interface{}{
+		"model":    chat["model"],
+		"messages": chatMessages(chat),
+		"stream":   false,
+	}
+	options := make(map[string]interface{})
+	if maxTokens, ok := chat["max_tokens"]; ok {
+		options["num_predict"] = maxTokens
+	}
+	if temperature, ok := chat["temperature"]; ok {
+		options["temperature"] = temperature
+	}
+	if len(options) > 0 {
+		body["options"] = options
+	}
+	return body
+}
+
+func (ollamaProvider) content(body []byte) (string, error) {
+	var response struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", err
+	}
+	return response.Message.Content, nil
+}
+
+func (p ollamaProvider) failure(status int, body []byte) error {
+	var response struct {
+		Error string `json:"error"`
+	}
+	_ = json.Unmarshal(body, &response)
+	return &AIProviderError{Provider: p.name(), StatusCode: status, Message: response.Error}
+}
+
+func (p ollamaProvider) stream(settings *aiSettings, chat map[string]interface{}) (string, interface{}) {
+	return p.endpoint(settings), streaming(p.body(chat))
+}
+
+// chunk reads a line of a streamed answer, the last of which is marked done
+func (p ollamaProvider) chunk(data []byte) (string, bool, error) {
+	var line struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		Done  bool   `json:"done"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &line); err != nil {
+		return "", false, err
+	}
+	if line.Error != "" {
+		return "", false, &AIProviderError{Provider: p.name(), StatusCode: http.StatusOK, Message: line.Error}
+	}
+	return line.Message.Content, line.Done, nil
+}
+
+func (ollamaProvider) models(ctx context.Context, client *http.Client, settings *aiSettings) ([]string, error) {
+	var response struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := fetchModels(ctx, client, settings, strings.TrimSuffix(settings.apiURL, "/")+"/api/tags", &response); err != nil {
+		return nil, err
+	}
+	names := make([]string, len(response.Models))
+	for i, model := range response.Models {
+		names[i] = model.Name
+	}
+	return names, nil
+}
+
+// compatibleProvider calls a server implementing the OpenAI API. The api_url setting is
+// its base URL, such as http://localhost:8000/v1, and the API key is optional.
+type compatibleProvider struct{}
+
+func (compatibleProvider) name() string         { return AIProviderCompatible }
+func (compatibleProvider) defaultURL() string   { return "" }
+func (compatibleProvider) requiresKey() bool    { return false }
+func (compatibleProvider) defaultModel() string { return "" }
+
+func (compatibleProvider) endpoint(settings *aiSettings) string {
+	return strings.TrimSuffix(settings.apiURL, "/") + "/chat/completions"
+}
+
+func (compatibleProvider) authorize(header http.Header, apiKey string) {
+	authorizeIfKeyed(header, apiKey)
+}
+
+func (compatibleProvider) body(chat map[string]interface{}) interface{} {
+	return chat
+}
+
+func (compatibleProvider) content(body []byte) (string, error) {
+	return openAIProvider{}.content(body)
+}
+
+func (p compatibleProvider) failure(status int, body []byte) error {
+	return openAIFailure(p.name(), status, body)
+}
+
+func (p compatibleProvider) stream(settings *aiSettings, chat map[string]interface{}) (string, interface{}) {
+	return p.endpoint(settings), streaming(p.body(chat))
+}
+
+func (p compatibleProvider) chunk(data []byte) (string, bool, error) {
+	return openAIChunk(p.name(), data)
+}
+
+func (compatibleProvider) models(ctx context.Context, client *http.Client, settings *aiSettings) ([]string, error) {
+	var response struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := fetchModels(ctx, client, settings, strings.TrimSuffix(settings.apiURL, "/")+"/models", &response); err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(response.Data))
+	for i, model := range response.Data {
+		ids[i] = model.ID
+	}
+	return ids, nil
+}
+
+// hasModel reports whether a listing includes a model. Ollama names a model without a
+// tag by its latest tag.
+func hasModel(models []string, model string) bool {
+	for _, name := range models {
+		if name == model || name == model+":latest" {
+			return true
+		}
+	}
+	return false
+}
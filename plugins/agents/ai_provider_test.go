@@ -0,0 +1,254 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// providerRequest is a request an AI provider received
+type providerRequest struct {
+	path   string
+	query  string
+	header http.Header
+	body   map[string]interface{}
+}
+
+// newProviderServer answers every request with the given status and body, recording the
+// requests it received
+func newProviderServer(t *testing.T, status int, response string) (*httptest.Server, chan providerRequest) {
+	requests := make(chan providerRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		json.Unmarshal(data, &body)
+		requests <- providerRequest{path: r.URL.Path, query: r.URL.RawQuery, header: r.Header, body: body}
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func TestAIProviders(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   map[string]interface{}
+		response string
+		check    func(t *testing.T, request providerRequest)
+	}{
+		{
+			name:     "anthropic",
+			config:   map[string]interface{}{"provider": "anthropic", "model": "claude-3-5-sonnet-latest"},
+			response: `{"content": [{"type": "text", "text": "answered "}, {"type": "text", "text": "by anthropic"}]}`,
+			check: func(t *testing.T, request providerRequest) {
+				assert.Equal(t, "key", request.header.Get("x-api-key"))
+				assert.Equal(t, anthropicVersion, request.header.Get("anthropic-version"))
+				assert.Equal(t, "claude-3-5-sonnet-latest", request.body["model"])
+				assert.Contains(t, request.body["system"], "observability expert")
+				assert.Equal(t, float64(anthropicMaxTokens), request.body["max_tokens"])
+				messages := request.body["messages"].([]interface{})
+				require.Len(t, messages, 1, "Expected the system prompt taken out of the messages")
+				assert.Equal(t, "user", messages[0].(map[string]interface{})["role"])
+			},
+		},
+		{
+			name:     "gemini",
+			config:   map[string]interface{}{"provider": "gemini"},
+			response: `{"candidates": [{"content": {"role": "model", "parts": [{"text": "answered by gemini"}]}}]}`,
+			check: func(t *testing.T, request providerRequest) {
+				assert.Equal(t, "/models/gemini-1.5-flash:generateContent", request.path)
+				assert.Equal(t, "key", request.header.Get("x-goog-api-key"))
+				assert.Contains(t, request.body, "systemInstruction")
+				contents := request.body["contents"].([]interface{})
+				require.Len(t, contents, 1)
+				assert.Equal(t, "user", contents[0].(map[string]interface{})["role"])
+				assert.Equal(t, 0.1, request.body["generationConfig"].(map[string]interface{})["temperature"])
+			},
+		},
+		{
+			name:     "azure",
+			config:   map[string]interface{}{"provider": "azure", "deployment": "ops-gpt4", "api_version": "2024-10-21"},
+			response: `{"choices": [{"message": {"role": "assistant", "content": "answered by azure"}}]}`,
+			check: func(t *testing.T, request providerRequest) {
+				assert.Equal(t, "/openai/deployments/ops-gpt4/chat/completions", request.path)
+				assert.Equal(t, "api-version=2024-10-21", request.query)
+				assert.Equal(t, "key", request.header.Get("api-key"))
+				assert.Empty(t, request.header.Get("Authorization"))
+				assert.NotContains(t, request.body, "model", "Expected the deployment to decide the model")
+				assert.Len(t, request.body["messages"], 2)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newProviderServer(t, http.StatusOK, tt.response)
+			tt.config["api_key"] = "key"
+			tt.config["api_url"] = server.URL
+
+			agent := NewAIAgent("ai")
+			require.NoError(t, agent.Configure(tt.config))
+			ctx := context.Background()
+			require.NoError(t, agent.Start(ctx))
+			<-requests // health check
+
+			response, err := agent.ProcessQuery(ctx, "why is CPU high?")
+			require.NoError(t, err)
+			assert.Equal(t, "answered by "+tt.name, response.Response)
+			tt.check(t, <-requests)
+		})
+	}
+}
+
+func TestAIProviders_Errors(t *testing.T) {
+	tests := []struct {
+		provider string
+		status   int
+		response string
+		expected AIProviderError
+	}{
+		{
+			provider: "openai",
+			status:   http.StatusTooManyRequests,
+			response: `{"error": {"message": "Rate limit reached", "type": "requests", "code": "rate_limit_exceeded"}}`,
+			expected: AIProviderError{Provider: "openai", StatusCode: 429, Type: "requests", Message: "Rate limit reached"},
+		},
+		{
+			provider: "anthropic",
+			status:   529,
+			response: `{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`,
+			expected: AIProviderError{Provider: "anthropic", StatusCode: 529, Type: "overloaded_error", Message: "Overloaded"},
+		},
+		{
+			provider: "gemini",
+			status:   http.StatusBadRequest,
+			response: `{"error": {"code": 400, "message": "API key not valid", "status": "INVALID_ARGUMENT"}}`,
+			expected: AIProviderError{Provider: "gemini", StatusCode: 400, Type: "INVALID_ARGUMENT", Message: "API key not valid"},
+		},
+		{
+			provider: "azure",
+			status:   http.StatusNotFound,
+			response: `{"error": {"code": "DeploymentNotFound", "message": "The API deployment for this resource does not exist"}}`,
+			expected: AIProviderError{Provider: "azure", StatusCode: 404, Type: "DeploymentNotFound", Message: "The API deployment for this resource does not exist"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			server, _ := newProviderServer(t, tt.status, tt.response)
+			agent := NewAIAgent("ai")
+			require.NoError(t, agent.Configure(map[string]interface{}{"api_key": "key", "api_url": server.URL, "provider": tt.provider}))
+
+			err := agent.Health(context.Background())
+			var providerErr *AIProviderError
+			require.True(t, errors.As(err, &providerErr), "Expected a provider error, got %v", err)
+			assert.Equal(t, tt.expected, *providerErr)
+		})
+	}
+
+	server, _ := newProviderServer(t, http.StatusOK, `{"promptFeedback": {"blockReason": "SAFETY"}}`)
+	agent := NewAIAgent("ai")
+	require.NoError(t, agent.Configure(map[string]interface{}{"api_key": "key", "api_url": server.URL, "provider": "gemini"}))
+	assert.ErrorContains(t, agent.Health(context.Background()), "SAFETY", "Expected a blocked prompt to fail")
+}
+
+func TestAIAgent_ConfigureProvider(t *testing.T) {
+	agent := NewAIAgent("ai")
+	assert.Error(t, agent.Configure(map[string]interface{}{"api_key": "key", "provider": "bard"}))
+	assert.Error(t, agent.Configure(map[string]interface{}{"api_key": "key", "provider": "azure"}),
+		"Expected azure to need its resource endpoint")
+
+	require.NoError(t, agent.Configure(map[string]interface{}{"api_key": "key", "provider": "anthropic"}))
+	settings := agent.currentSettings()
+	assert.Equal(t, "https://api.anthropic.com/v1/messages", settings.apiURL)
+	assert.Equal(t, "claude-3-5-haiku-latest", settings.model)
+
+	// Switching provider moves to its defaults unless they are given
+	server, _ := newProviderServer(t, http.StatusOK, `{"candidates": []}`)
+	require.NoError(t, agent.Reconfigure(context.Background(), map[string]interface{}{"provider": "gemini", "api_url": server.URL}))
+	settings = agent.currentSettings()
+	assert.Equal(t, AIProviderGemini, settings.provider.name())
+	assert.Equal(t, server.URL, settings.apiURL)
+	assert.Equal(t, "gemini-1.5-flash", settings.model)
+	assert.Error(t, agent.Reconfigure(context.Background(), map[string]interface{}{"provider": "azure"}))
+}
+
+// newLocalModelServer serves a local model server's listing at listPath and answers chats
+// at chatPath, recording the chat requests it received
+func newLocalModelServer(t *testing.T, listPath, listing, chatPath, answer string) (*httptest.Server, chan providerRequest) {
+	requests := make(chan providerRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case listPath:
+			io.WriteString(w, listing)
+		case chatPath:
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			requests <- providerRequest{path: r.URL.Path, header: r.Header, body: body}
+			io.WriteString(w, answer)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func TestAIAgent_Ollama(t *testing.T) {
+	server, requests := newLocalModelServer(t,
+		"/api/tags", `{"models": [{"name": "llama3.2:latest"}, {"name": "mistral:7b"}]}`,
+		"/api/chat", `{"model": "llama3.2", "message": {"role": "assistant", "content": "answered by ollama"}, "done": true}`)
+
+	agent := NewAIAgent("ai")
+	require.NoError(t, agent.Configure(map[string]interface{}{"provider": "ollama", "api_url": server.URL}),
+		"Expected no API key to be needed")
+	ctx := context.Background()
+	require.NoError(t, agent.Start(ctx))
+	<-requests // health check
+
+	response, err := agent.ProcessQuery(ctx, "why is CPU high?")
+	require.NoError(t, err)
+	assert.Equal(t, "answered by ollama", response.Response)
+	request := <-requests
+	assert.Empty(t, request.header.Get("Authorization"))
+	assert.Equal(t, "llama3.2", request.body["model"])
+	assert.Equal(t, false, request.body["stream"])
+	assert.Equal(t, 0.1, request.body["options"].(map[string]interface{})["temperature"])
+
+	missing := NewAIAgent("ai")
+	require.NoError(t, missing.Configure(map[string]interface{}{"provider": "ollama", "api_url": server.URL, "model": "llama3:70b"}))
+	err = missing.Start(ctx)
+	assert.ErrorContains(t, err, "mistral:7b", "Expected a missing model to fail with the models available")
+}
+
+func TestAIAgent_OpenAICompatible(t *testing.T) {
+	server, requests := newLocalModelServer(t,
+		"/v1/models", `{"object": "list", "data": [{"id": "qwen2.5-7b-instruct"}]}`,
+		"/v1/chat/completions", `{"choices": [{"message": {"content": "answered locally"}}]}`)
+
+	agent := NewAIAgent("ai")
+	assert.Error(t, agent.Configure(map[string]interface{}{"provider": "openai_compatible", "api_url": server.URL + "/v1"}),
+		"Expected the model to be required")
+	assert.Error(t, agent.Configure(map[string]interface{}{"provider": "openai_compatible", "model": "qwen2.5-7b-instruct"}),
+		"Expected the base URL to be required")
+
+	require.NoError(t, agent.Configure(map[string]interface{}{
+		"provider": "openai_compatible", "api_url": server.URL + "/v1/", "model": "qwen2.5-7b-instruct", "api_key": "local",
+	}))
+	ctx := context.Background()
+	require.NoError(t, agent.Start(ctx))
+	<-requests // health check
+
+	response, err := agent.ProcessQuery(ctx, "why is CPU high?")
+	require.NoError(t, err)
+	assert.Equal(t, "answered locally", response.Response)
+	request := <-requests
+	assert.Equal(t, "Bearer local", request.header.Get("Authorization"))
+	assert.Equal(t, "qwen2.5-7b-instruct", request.body["model"])
+}
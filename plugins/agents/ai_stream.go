@@ -0,0 +1,147 @@
+package agents
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxStreamLine bounds a line of a streamed answer
+const maxStreamLine = 1 << 20
+
+// streamAIAPI sends a chat request asking for the answer as it is generated, passing each
+// piece of it to onChunk and returning the whole answer. The HTTP client's timeout bounds
+// the wait for each piece rather than the whole answer, so long answers are not cut off.
+func (a *AIAgent) streamAIAPI(ctx context.Context, settings *aiSettings, request map[string]interface{}, onChunk func(chunk string) error) (content string, err error) {
+	endpoint, body := settings.provider.stream(settings, request)
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	exchange := AIExchange{
+		Timestamp: time.Now(),
+		Agent:     a.name,
+		Model:     settings.model,
+		URL:       endpoint,
+		Request:   string(jsonData),
+	}
+	var answer strings.Builder
+	if settings.exchangeLog != nil {
+		defer func() {
+			exchange.DurationMS = time.Since(exchange.Timestamp).Milliseconds()
+			if exchange.Response == "" {
+				exchange.Response = answer.String()
+			}
+			if err != nil {
+				exchange.Error = err.Error()
+			}
+			settings.exchangeLog.Record(exchange, settings.apiKey)
+		}()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	idle := a.httpClient.Timeout
+	var idleTimer *time.Timer
+	if idle > 0 {
+		idleTimer = time.AfterFunc(idle, cancel)
+		defer idleTimer.Stop()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	settings.provider.authorize(req.Header, settings.apiKey)
+
+	client := *a.httpClient
+	client.Timeout = 0
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	exchange.StatusCode = resp.StatusCode
+	if resp.StatusCode != http.StatusOK {
+		failure, err := io.ReadAll(resp.Body)
+		exchange.Response = string(failure)
+		if err != nil {
+			return "", err
+		}
+		return "", withRetryAfter(settings.provider.failure(resp.StatusCode, failure), resp.Header)
+	}
+
+	var usage aiUsage
+	defer func() {
+		exchange.PromptTokens, exchange.CompletionTokens = usage.promptTokens, usage.completionTokens
+		a.usage.record(usage)
+	}()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+	for scanner.Scan() {
+		if idleTimer != nil {
+			idleTimer.Reset(idle)
+		}
+		data, ok := streamData(scanner.Bytes())
+		if !ok {
+			continue
+		}
+		usage.merge(settings.provider.usage(data))
+		text, done, err := settings.provider.chunk(data)
+		if err != nil {
+			return answer.String(), err
+		}
+		if text != "" {
+			answer.WriteString(text)
+			if err := onChunk(text); err != nil {
+				return answer.String(), err
+			}
+		}
+		if done {
+			return answer.String(), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if ctx.Err() != nil && idle > 0 {
+			return answer.String(), fmt.Errorf("no part of the answer arrived within %s: %w", idle, err)
+		}
+		return answer.String(), err
+	}
+	return answer.String(), nil
+}
+
+// interruptedStream is a streamed answer that failed after part of it was sent, which is
+// not retried so the part is not sent twice
+type interruptedStream struct {
+	err error
+}
+
+func (e interruptedStream) Error() string   { return e.err.Error() }
+func (e interruptedStream) Unwrap() error   { return e.err }
+func (e interruptedStream) Retryable() bool { return false }
+
+// streamData returns the payload of a line of a streamed answer: the data of a server-sent
+// event, or a JSON line as is. Blank lines, comments, and other event fields have none.
+func streamData(line []byte) ([]byte, bool) {
+	line = bytes.TrimSpace(line)
+	switch {
+	case len(line) == 0, line[0] == ':':
+		return nil, false
+	case bytes.HasPrefix(line, []byte("data:")):
+		return bytes.TrimSpace(line[len("data:"):]), true
+	case bytes.HasPrefix(line, []byte("event:")), bytes.HasPrefix(line, []byte("id:")), bytes.HasPrefix(line, []byte("retry:")):
+		return nil, false
+	}
+	return line, true
+}
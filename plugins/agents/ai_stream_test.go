@@ -0,0 +1,138 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStreamingServer answers health checks in full and streamed requests with the given
+// body, flushing each line
+func newStreamingServer(t *testing.T, complete, streamed string) (*httptest.Server, chan map[string]interface{}) {
+	requests := make(chan map[string]interface{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["stream"] != true && !strings.Contains(r.URL.Path, "stream") {
+			io.WriteString(w, complete)
+			return
+		}
+		requests <- body
+		for _, line := range strings.SplitAfter(streamed, "\n") {
+			io.WriteString(w, line)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func TestAIAgent_ProcessQueryStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		complete string
+		streamed string
+	}{
+		{
+			name:     "openai",
+			provider: "openai",
+			complete: `{"choices": []}`,
+			streamed: "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"Restart \"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"the pod\"}}]}\n\n" +
+				"data: [DONE]\n\n",
+		},
+		{
+			name:     "anthropic",
+			provider: "anthropic",
+			complete: `{"content": []}`,
+			streamed: "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{}}\n\n" +
+				"event: ping\ndata: {\"type\": \"ping\"}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Restart \"}}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"the pod\"}}\n\n" +
+				"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n",
+		},
+		{
+			name:     "gemini",
+			provider: "gemini",
+			complete: `{"candidates": []}`,
+			streamed: "data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"Restart \"}]}}]}\n\n" +
+				"data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"the pod\"}]},\"finishReason\":\"STOP\"}]}\n\n",
+		},
+		{
+			name:     "ollama",
+			provider: "ollama",
+			complete: `{"message": {"content": ""}}`,
+			streamed: "{\"message\":{\"content\":\"Restart \"},\"done\":false}\n" +
+				"{\"message\":{\"content\":\"the pod\"},\"done\":false}\n" +
+				"{\"message\":{\"content\":\"\"},\"done\":true}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newStreamingServer(t, tt.complete, tt.streamed)
+			agent := NewAIAgent("ai")
+			// Ollama's model listing is not served, so it is configured after starting
+			require.NoError(t, agent.Configure(map[string]interface{}{"api_key": "key", "api_url": server.URL}))
+			ctx := context.Background()
+			require.NoError(t, agent.Start(ctx))
+			require.NoError(t, agent.Reconfigure(ctx, map[string]interface{}{"provider": tt.provider, "api_url": server.URL}))
+
+			var chunks []string
+			response, err := agent.ProcessQueryStream(ctx, "why is CPU high?", func(chunk string) error {
+				chunks = append(chunks, chunk)
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"Restart ", "the pod"}, chunks)
+			assert.Equal(t, "Restart the pod", response.Response)
+			assert.Empty(t, response.Actions, "Expected no actions from prose that mentions one")
+			if tt.provider != "gemini" {
+				request := <-requests
+				assert.Equal(t, true, request["stream"])
+				if tt.provider == "openai" {
+					assert.Equal(t, map[string]interface{}{"include_usage": true}, request["stream_options"],
+						"Expected the usage of the streamed answer to be asked for")
+				}
+			}
+		})
+	}
+}
+
+func TestAIAgent_ProcessQueryStreamErrors(t *testing.T) {
+	server, _ := newStreamingServer(t, `{"choices": []}`,
+		"data: {\"choices\":[{\"delta\":{\"content\":\"Restart \"}}]}\n\n"+
+			"data: {\"error\":{\"type\":\"server_error\",\"message\":\"The server had an error\"}}\n\n")
+	agent := NewAIAgent("ai")
+	require.NoError(t, agent.Configure(map[string]interface{}{"api_key": "key", "api_url": server.URL, "response_cache_ttl": "1m"}))
+	ctx := context.Background()
+	require.NoError(t, agent.Start(ctx))
+
+	var chunks []string
+	collect := func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	}
+	_, err := agent.ProcessQueryStream(ctx, "why is CPU high?", collect)
+	var providerErr *AIProviderError
+	require.ErrorAs(t, err, &providerErr)
+	assert.Equal(t, "API stream failed (server_error): The server had an error", providerErr.Error())
+	assert.Equal(t, []string{"Restart "}, chunks)
+
+	// A cached answer comes in one chunk
+	_, err = agent.ProcessQuery(ctx, "what about memory?")
+	require.NoError(t, err)
+	chunks = nil
+	response, err := agent.ProcessQueryStream(ctx, "what about memory?", collect)
+	require.NoError(t, err)
+	assert.Equal(t, true, response.Metadata["cached"])
+	assert.Equal(t, []string{response.Response}, chunks)
+}
@@ -0,0 +1,625 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// defaultMaxToolRounds bounds the rounds of tool calls when max_tool_rounds is not set
+const defaultMaxToolRounds = 5
+
+// maxToolResults bounds the data points or analyses a tool returns to the model
+const maxToolResults = 100
+
+// defaultToolWindow is how far back history tools look when the model gives no since
+const defaultToolWindow = time.Hour
+
+// aiTool is a framework tool the model may call while answering
+type aiTool struct {
+	name        string
+	description string
+	// parameters is the JSON schema of the tool's arguments
+	parameters map[string]interface{}
+	run        func(ctx context.Context, tools core.AgentToolProvider, args map[string]interface{}) (interface{}, error)
+}
+
+// aiToolCall is a call of a tool the model asked for. Arguments the model sent that are
+// not a JSON object leave err set, which is returned to the model as the call's result.
+type aiToolCall struct {
+	id        string
+	name      string
+	arguments map[string]interface{}
+	err       error
+}
+
+// aiToolRound is a response in which the model called tools, and the results sent back
+type aiToolRound struct {
+	text    string
+	calls   []aiToolCall
+	results []string
+}
+
+// aiToolCaller is a provider whose models can call tools. A tool request is a chat
+// request followed by the rounds of tool calls made so far.
+type aiToolCaller interface {
+	// toolBody maps a chat request, the tools offered, and the rounds so far to the
+	// provider's request body; final asks for an answer without further calls
+	toolBody(chat map[string]interface{}, tools []aiTool, rounds []aiToolRound, final bool) interface{}
+	// toolCalls extracts the text and the tool calls of a successful response
+	toolCalls(body []byte) (string, []aiToolCall, error)
+}
+
+// aiTools are the tools the tools setting can offer, by name
+var aiTools = map[string]aiTool{
+	"query_history": {
+		name:        "query_history",
+		description: "Query recorded metric data points. Returns the most recent matching points, oldest first.",
+		parameters: toolSchema(map[string]interface{}{
+			"metric": toolParam("string", "Metric name; * and ? match any characters"),
+			"source": toolParam("string", "Collector the points came from; * and ? match any characters"),
+			"labels": toolParam("string", `Label selector such as {service="api", env=~"prod|staging"}`),
+			"since":  toolParam("string", "How far back to look, as a duration such as 30m or 6h; defaults to 1h"),
+			"limit":  toolParam("integer", "Most points returned; defaults to 50"),
+		}, "metric"),
+		run: func(ctx context.Context, tools core.AgentToolProvider, args map[string]interface{}) (interface{}, error) {
+			query, err := toolHistoryQuery(args, 50)
+			if err != nil {
+				return nil, err
+			}
+			points, err := tools.QueryDataPoints(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+			if points == nil {
+				points = []core.DataPoint{}
+			}
+			return points, nil
+		},
+	},
+	"list_analyses": {
+		name:        "list_analyses",
+		description: "List recent analyses the framework's analyzers produced, such as detected anomalies, oldest first.",
+		parameters: toolSchema(map[string]interface{}{
+			"metric":       toolParam("string", "Only analyses of this metric; * and ? match any characters"),
+			"source":       toolParam("string", "Only analyses of this analyzer; * and ? match any characters"),
+			"min_severity": toolParam("string", "Least severe analyses listed: low, medium, high, or critical"),
+			"since":        toolParam("string", "How far back to look, as a duration such as 30m or 6h; defaults to 1h"),
+			"limit":        toolParam("integer", "Most analyses returned; defaults to 20"),
+		}),
+		run: func(ctx context.Context, tools core.AgentToolProvider, args map[string]interface{}) (interface{}, error) {
+			query, err := toolHistoryQuery(args, 20)
+			if err != nil {
+				return nil, err
+			}
+			query.MinSeverity = stringArg(args, "min_severity")
+			analyses, err := tools.QueryAnalyses(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+			// The data points behind each analysis would crowd out the rest of the answer
+			summaries := make([]map[string]interface{}, 0, len(analyses))
+			for _, analysis := range analyses {
+				summaries = append(summaries, map[string]interface{}{
+					"id":         analysis.ID,
+					"timestamp":  analysis.Timestamp,
+					"source":     analysis.Source,
+					"type":       analysis.Type,
+					"severity":   analysis.Severity,
+					"confidence": analysis.Confidence,
+					"summary":    analysis.Summary,
+					"resolved":   analysis.Resolved,
+				})
+			}
+			return summaries, nil
+		},
+	},
+	"prometheus_query": {
+		name:        "prometheus_query",
+		description: "Run an instant PromQL query through a Prometheus collector and return the resulting samples.",
+		parameters: toolSchema(map[string]interface{}{
+			"query":     toolParam("string", "PromQL expression"),
+			"collector": toolParam("string", "Collector to query; defaults to the first that supports queries"),
+		}, "query"),
+		run: func(ctx context.Context, tools core.AgentToolProvider, args map[string]interface{}) (interface{}, error) {
+			query := stringArg(args, "query")
+			if query == "" {
+				return nil, fmt.Errorf("query is required")
+			}
+			points, err := tools.QueryCollector(ctx, stringArg(args, "collector"), query)
+			if err != nil {
+				return nil, err
+			}
+			if len(points) > maxToolResults {
+				points = points[:maxToolResults]
+			}
+			if points == nil {
+				points = []core.DataPoint{}
+			}
+			return points, nil
+		},
+	},
+	"plugin_status": {
+		name:        "plugin_status",
+		description: "Report the status and health of every plugin loaded in the framework.",
+		parameters:  toolSchema(map[string]interface{}{}),
+		run: func(ctx context.Context, tools core.AgentToolProvider, args map[string]interface{}) (interface{}, error) {
+			return tools.PluginStates(ctx), nil
+		},
+	},
+}
+
+// parseAITools returns the tools named by the tools setting, in a stable order
+func parseAITools(value interface{}) ([]aiTool, error) {
+	if value == nil {
+		return nil, nil
+	}
+	names, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("tools must be a list of tool names")
+	}
+	tools := make([]aiTool, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, raw := range names {
+		name, _ := raw.(string)
+		tool, ok := aiTools[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown tool %v; available tools: %s", raw, strings.Join(aiToolNames(), ", "))
+		}
+		if !seen[name] {
+			seen[name] = true
+			tools = append(tools, tool)
+		}
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].name < tools[j].name })
+	return tools, nil
+}
+
+// aiToolNames lists the names of the available tools
+func aiToolNames() []string {
+	names := make([]string, 0, len(aiTools))
+	for name := range aiTools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// toolSchema describes a tool's arguments as a JSON schema object
+func toolSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// toolParam describes one argument of a tool
+func toolParam(kind, description string) map[string]interface{} {
+	return map[string]interface{}{"type": kind, "description": description}
+}
+
+// stringArg returns a string argument, or "" when it is missing
+func stringArg(args map[string]interface{}, name string) string {
+	value, _ := args[name].(string)
+	return value
+}
+
+// toolHistoryQuery builds a history query from the arguments the history tools share
+func toolHistoryQuery(args map[string]interface{}, defaultLimit int) (core.HistoryQuery, error) {
+	query := core.HistoryQuery{Metric: stringArg(args, "metric"), Source: stringArg(args, "source"), Limit: defaultLimit}
+	window := defaultToolWindow
+	if since := stringArg(args, "since"); since != "" {
+		parsed, err := time.ParseDuration(since)
+		if err != nil || parsed <= 0 {
+			return query, fmt.Errorf("since must be a positive duration such as 30m")
+		}
+		window = parsed
+	}
+	query.Start = time.Now().Add(-window)
+	if selector := stringArg(args, "labels"); selector != "" {
+		matchers, err := core.ParseMatchers(selector)
+		if err != nil {
+			return query, err
+		}
+		query.Matchers = matchers
+	}
+	// JSON numbers arrive as float64
+	if limit, ok := args["limit"].(float64); ok && limit > 0 {
+		query.Limit = int(limit)
+	}
+	if query.Limit > maxToolResults {
+		query.Limit = maxToolResults
+	}
+	return query, nil
+}
+
+// SetAgentTools provides the framework tools the model may call
+func (a *AIAgent) SetAgentTools(tools core.AgentToolProvider) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.agentTools = tools
+}
+
+// callWithTools asks for an answer, running the tools the model calls and sending it their
+// results until it answers. After maxRounds rounds of calls it must answer without more.
+func (a *AIAgent) callWithTools(ctx context.Context, settings *aiSettings, caller aiToolCaller, provider core.AgentToolProvider,
+	tools []aiTool, maxRounds int, guard aiCallGuard, chat map[string]interface{}) (string, []core.AgentToolCall, error) {
+	var rounds []aiToolRound
+	var calls []core.AgentToolCall
+	for {
+		// Every round is a request of its own, retried on its own so tools are not run again
+		final := len(rounds) >= maxRounds
+		var body []byte
+		err := guard.do(ctx, func() (err error) {
+			body, err = a.sendAIRequest(ctx, settings, settings.provider.endpoint(settings), caller.toolBody(chat, tools, rounds, final))
+			return err
+		})
+		if err != nil {
+			return "", calls, err
+		}
+		text, requested, err := caller.toolCalls(body)
+		if err != nil {
+			return "", calls, err
+		}
+		if len(requested) == 0 || final {
+			return text, calls, nil
+		}
+
+		round := aiToolRound{text: text, calls: requested}
+		for _, call := range requested {
+			record, result := runAITool(ctx, provider, tools, call)
+			slog.Debug("AI agent called a tool", "plugin", a.name, "tool", call.name, "error", record.Error)
+			calls = append(calls, record)
+			round.results = append(round.results, result)
+		}
+		rounds = append(rounds, round)
+	}
+}
+
+// runAITool runs a tool the model called, returning the record of the call and the result
+// sent back to the model. Failures are sent back too, so the model can correct its call.
+func runAITool(ctx context.Context, provider core.AgentToolProvider, tools []aiTool, call aiToolCall) (core.AgentToolCall, string) {
+	record := core.AgentToolCall{Tool: call.name, Arguments: call.arguments}
+	err := call.err
+	if err == nil {
+		var result interface{}
+		result, err = findAITool(tools, call.name).run(ctx, provider, call.arguments)
+		if err == nil {
+			encoded, marshalErr := json.Marshal(result)
+			if marshalErr == nil {
+				record.Result = result
+				return record, string(encoded)
+			}
+			err = marshalErr
+		}
+	}
+	record.Error = err.Error()
+	encoded, _ := json.Marshal(map[string]string{"error": record.Error})
+	return record, string(encoded)
+}
+
+// findAITool returns the offered tool with the given name, or one that fails for a tool
+// that was not offered
+func findAITool(tools []aiTool, name string) aiTool {
+	for _, tool := range tools {
+		if tool.name == name {
+			return tool
+		}
+	}
+	return aiTool{name: name, run: func(context.Context, core.AgentToolProvider, map[string]interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("unknown tool %s", name)
+	}}
+}
+
+// toolArguments decodes the arguments of a tool call; no arguments is an empty object
+func toolArguments(raw []byte) (map[string]interface{}, error) {
+	arguments := make(map[string]interface{})
+	if len(strings.TrimSpace(string(raw))) == 0 || string(raw) == "null" {
+		return arguments, nil
+	}
+	if err := json.Unmarshal(raw, &arguments); err != nil {
+		return nil, fmt.Errorf("arguments are not a JSON object: %w", err)
+	}
+	return arguments, nil
+}
+
+// fieldsOf copies a request body into generic JSON fields, so tool rounds can be added to
+// the body a provider builds for a plain chat request
+func fieldsOf(body interface{}) map[string]interface{} {
+	data, _ := json.Marshal(body)
+	fields := make(map[string]interface{})
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
+
+// openAIToolBody adds tools and the rounds so far to a chat completions request body, which
+// Azure and compatible servers share
+func openAIToolBody(body interface{}, tools []aiTool, rounds []aiToolRound, final bool) interface{} {
+	fields := fieldsOf(body)
+	messages, _ := fields["messages"].([]interface{})
+	for _, round := range rounds {
+		calls := make([]interface{}, len(round.calls))
+		for i, call := range round.calls {
+			arguments, _ := json.Marshal(toolInput(call))
+			calls[i] = map[string]interface{}{
+				"id":       call.id,
+				"type":     "function",
+				"function": map[string]interface{}{"name": call.name, "arguments": string(arguments)},
+			}
+		}
+		var content interface{}
+		if round.text != "" {
+			content = round.text
+		}
+		messages = append(messages, map[string]interface{}{"role": "assistant", "content": content, "tool_calls": calls})
+		for i, call := range round.calls {
+			messages = append(messages, map[string]interface{}{"role": "tool", "tool_call_id": call.id, "content": round.results[i]})
+		}
+	}
+	fields["messages"] = messages
+	fields["tools"] = openAIToolDeclarations(tools)
+	if final {
+		fields["tool_choice"] = "none"
+	}
+	return fields
+}
+
+// openAIToolDeclarations describes the tools offered as chat completions functions
+func openAIToolDeclarations(tools []aiTool) []interface{} {
+	declarations := make([]interface{}, len(tools))
+	for i, tool := range tools {
+		declarations[i] = map[string]interface{}{
+			"type":     "function",
+			"function": map[string]interface{}{"name": tool.name, "description": tool.description, "parameters": tool.parameters},
+		}
+	}
+	return declarations
+}
+
+// openAIToolCalls reads a chat completions response that may call tools
+func openAIToolCalls(body []byte) (string, []aiToolCall, error) {
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content   string `json:"content"`
+				ToolCalls []struct {
+					ID       string `json:"id"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", nil, err
+	}
+	if len(response.Choices) == 0 {
+		return "", nil, nil
+	}
+	message := response.Choices[0].Message
+	calls := make([]aiToolCall, len(message.ToolCalls))
+	for i, call := range message.ToolCalls {
+		arguments, err := toolArguments([]byte(call.Function.Arguments))
+		calls[i] = aiToolCall{id: call.ID, name: call.Function.Name, arguments: arguments, err: err}
+	}
+	return message.Content, calls, nil
+}
+
+func (p openAIProvider) toolBody(chat map[string]interface{}, tools []aiTool, rounds []aiToolRound, final bool) interface{} {
+	return openAIToolBody(p.body(chat), tools, rounds, final)
+}
+
+func (openAIProvider) toolCalls(body []byte) (string, []aiToolCall, error) {
+	return openAIToolCalls(body)
+}
+
+func (p azureProvider) toolBody(chat map[string]interface{}, tools []aiTool, rounds []aiToolRound, final bool) interface{} {
+	return openAIToolBody(p.body(chat), tools, rounds, final)
+}
+
+func (azureProvider) toolCalls(body []byte) (string, []aiToolCall, error) {
+	return openAIToolCalls(body)
+}
+
+func (p compatibleProvider) toolBody(chat map[string]interface{}, tools []aiTool, rounds []aiToolRound, final bool) interface{} {
+	return openAIToolBody(p.body(chat), tools, rounds, final)
+}
+
+func (compatibleProvider) toolCalls(body []byte) (string, []aiToolCall, error) {
+	return openAIToolCalls(body)
+}
+
+// toolBody sends arguments as objects rather than encoded, and has no way to refuse further
+// calls, so the final request offers no tools
+func (p ollamaProvider) toolBody(chat map[string]interface{}, tools []aiTool, rounds []aiToolRound, final bool) interface{} {
+	fields := fieldsOf(p.body(chat))
+	messages, _ := fields["messages"].([]interface{})
+	for _, round := range rounds {
+		calls := make([]interface{}, len(round.calls))
+		for i, call := range round.calls {
+			calls[i] = map[string]interface{}{"function": map[string]interface{}{"name": call.name, "arguments": toolInput(call)}}
+		}
+		messages = append(messages, map[string]interface{}{"role": "assistant", "content": round.text, "tool_calls": calls})
+		for i, call := range round.calls {
+			messages = append(messages, map[string]interface{}{"role": "tool", "tool_name": call.name, "content": round.results[i]})
+		}
+	}
+	fields["messages"] = messages
+	if !final {
+		fields["tools"] = openAIToolDeclarations(tools)
+	}
+	return fields
+}
+
+func (ollamaProvider) toolCalls(body []byte) (string, []aiToolCall, error) {
+	var response struct {
+		Message struct {
+			Content   string `json:"content"`
+			ToolCalls []struct {
+				Function struct {
+					Name      string          `json:"name"`
+					Arguments json.RawMessage `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", nil, err
+	}
+	calls := make([]aiToolCall, len(response.Message.ToolCalls))
+	for i, call := range response.Message.ToolCalls {
+		arguments, err := toolArguments(call.Function.Arguments)
+		calls[i] = aiToolCall{id: fmt.Sprintf("call_%d", i), name: call.Function.Name, arguments: arguments, err: err}
+	}
+	return response.Message.Content, calls, nil
+}
+
+// toolBody sends each round as an assistant message of tool_use blocks answered by a user
+// message of tool_result blocks
+func (p anthropicProvider) toolBody(chat map[string]interface{}, tools []aiTool, rounds []aiToolRound, final bool) interface{} {
+	fields := fieldsOf(p.body(chat))
+	messages, _ := fields["messages"].([]interface{})
+	for _, round := range rounds {
+		var uses, results []interface{}
+		if round.text != "" {
+			uses = append(uses, map[string]interface{}{"type": "text", "text": round.text})
+		}
+		for i, call := range round.calls {
+			uses = append(uses, map[string]interface{}{"type": "tool_use", "id": call.id, "name": call.name, "input": toolInput(call)})
+			results = append(results, map[string]interface{}{"type": "tool_result", "tool_use_id": call.id, "content": round.results[i]})
+		}
+		messages = append(messages,
+			map[string]interface{}{"role": "assistant", "content": uses},
+			map[string]interface{}{"role": "user", "content": results})
+	}
+	fields["messages"] = messages
+
+	declarations := make([]interface{}, len(tools))
+	for i, tool := range tools {
+		declarations[i] = map[string]interface{}{"name": tool.name, "description": tool.description, "input_schema": tool.parameters}
+	}
+	fields["tools"] = declarations
+	if final {
+		fields["tool_choice"] = map[string]interface{}{"type": "none"}
+	}
+	return fields
+}
+
+func (anthropicProvider) toolCalls(body []byte) (string, []aiToolCall, error) {
+	var response struct {
+		Content []struct {
+			Type  string          `json:"type"`
+			Text  string          `json:"text"`
+			ID    string          `json:"id"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", nil, err
+	}
+	var text strings.Builder
+	var calls []aiToolCall
+	for _, block := range response.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			arguments, err := toolArguments(block.Input)
+			calls = append(calls, aiToolCall{id: block.ID, name: block.Name, arguments: arguments, err: err})
+		}
+	}
+	return text.String(), calls, nil
+}
+
+// toolBody sends each round as model content of function calls answered by user content
+// of function responses, which must be objects
+func (p geminiProvider) toolBody(chat map[string]interface{}, tools []aiTool, rounds []aiToolRound, final bool) interface{} {
+	fields := fieldsOf(p.body(chat))
+	contents, _ := fields["contents"].([]interface{})
+	for _, round := range rounds {
+		var calls, responses []interface{}
+		if round.text != "" {
+			calls = append(calls, map[string]interface{}{"text": round.text})
+		}
+		for i, call := range round.calls {
+			calls = append(calls, map[string]interface{}{"functionCall": map[string]interface{}{"name": call.name, "args": toolInput(call)}})
+			responses = append(responses, map[string]interface{}{"functionResponse": map[string]interface{}{
+				"name":     call.name,
+				"response": map[string]interface{}{"content": json.RawMessage(round.results[i])},
+			}})
+		}
+		contents = append(contents,
+			map[string]interface{}{"role": "model", "parts": calls},
+			map[string]interface{}{"role": "user", "parts": responses})
+	}
+	fields["contents"] = contents
+
+	declarations := make([]interface{}, len(tools))
+	for i, tool := range tools {
+		declaration := map[string]interface{}{"name": tool.name, "description": tool.description}
+		// Gemini rejects an object schema without properties
+		if properties, _ := tool.parameters["properties"].(map[string]interface{}); len(properties) > 0 {
+			declaration["parameters"] = tool.parameters
+		}
+		declarations[i] = declaration
+	}
+	fields["tools"] = []interface{}{map[string]interface{}{"functionDeclarations": declarations}}
+	if final {
+		fields["toolConfig"] = map[string]interface{}{"functionCallingConfig": map[string]interface{}{"mode": "NONE"}}
+	}
+	return fields
+}
+
+// toolCalls fails for a prompt Gemini blocked, like content
+func (p geminiProvider) toolCalls(body []byte) (string, []aiToolCall, error) {
+	var response struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text         string `json:"text"`
+					FunctionCall *struct {
+						Name string          `json:"name"`
+						Args json.RawMessage `json:"args"`
+					} `json:"functionCall"`
+				} `json:"parts"`
+			} `json:"content"`
+		} `json:"candidates"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", nil, err
+	}
+	if len(response.Candidates) == 0 {
+		text, err := p.content(body)
+		return text, nil, err
+	}
+	var text strings.Builder
+	var calls []aiToolCall
+	for _, part := range response.Candidates[0].Content.Parts {
+		if part.FunctionCall == nil {
+			text.WriteString(part.Text)
+			continue
+		}
+		arguments, err := toolArguments(part.FunctionCall.Args)
+		calls = append(calls, aiToolCall{id: fmt.Sprintf("call_%d", len(calls)), name: part.FunctionCall.Name, arguments: arguments, err: err})
+	}
+	return text.String(), calls, nil
+}
+
+// toolInput returns the arguments of a call as sent back to the model, which needs an
+// object even for arguments it sent malformed
+func toolInput(call aiToolCall) map[string]interface{} {
+	if call.arguments == nil {
+		return map[string]interface{}{}
+	}
+	return call.arguments
+}
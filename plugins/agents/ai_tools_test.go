@@ -0,0 +1,183 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAgentTools answers tool queries from fixed data, recording the history queries
+type fakeAgentTools struct {
+	queries []core.HistoryQuery
+}
+
+func (f *fakeAgentTools) QueryDataPoints(ctx context.Context, query core.HistoryQuery) ([]core.DataPoint, error) {
+	f.queries = append(f.queries, query)
+	return []core.DataPoint{{Timestamp: time.Now(), Source: "prometheus", Metric: "cpu_usage", Value: 0.97}}, nil
+}
+
+func (f *fakeAgentTools) QueryAnalyses(ctx context.Context, query core.HistoryQuery) ([]core.Analysis, error) {
+	f.queries = append(f.queries, query)
+	return []core.Analysis{{ID: "a1", Severity: "high", Summary: "CPU spike", DataPoints: []core.DataPoint{{Metric: "cpu_usage"}}}}, nil
+}
+
+func (f *fakeAgentTools) QueryCollector(ctx context.Context, collector, query string) ([]core.DataPoint, error) {
+	return []core.DataPoint{{Source: "prometheus", Metric: query, Value: 1}}, nil
+}
+
+func (f *fakeAgentTools) PluginStates(ctx context.Context) []core.PluginState {
+	return []core.PluginState{{Name: "prometheus", Type: core.PluginTypeCollector, Status: core.PluginStatusRunning, Healthy: true}}
+}
+
+// newScriptedServer answers the health check and then each chat request with the next of
+// the given responses, recording the chat request bodies
+func newScriptedServer(t *testing.T, responses ...string) (*httptest.Server, chan map[string]interface{}) {
+	requests := make(chan map[string]interface{}, len(responses)+1)
+	next := make(chan string, len(responses))
+	for _, response := range responses {
+		next <- response
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		json.Unmarshal(data, &body)
+		if _, ok := body["tools"]; !ok {
+			io.WriteString(w, `{"choices": [{"message": {"content": "ok"}}], "content": [{"type": "text", "text": "ok"}]}`)
+			return
+		}
+		requests <- body
+		io.WriteString(w, <-next)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func TestAIAgent_Tools(t *testing.T) {
+	server, requests := newScriptedServer(t,
+		`{"choices": [{"message": {"content": null, "tool_calls": [
+			{"id": "call_1", "type": "function", "function": {"name": "query_history", "arguments": "{\"metric\": \"cpu_*\", \"since\": \"30m\"}"}},
+			{"id": "call_2", "type": "function", "function": {"name": "plugin_status", "arguments": "{}"}}
+		]}}]}`,
+		`{"choices": [{"message": {"content": "CPU has been at 97% for 30 minutes."}}]}`)
+
+	agent := NewAIAgent("ai")
+	require.NoError(t, agent.Configure(map[string]interface{}{
+		"api_key": "key", "api_url": server.URL, "tools": []interface{}{"query_history", "plugin_status"},
+	}))
+	tools := &fakeAgentTools{}
+	agent.SetAgentTools(tools)
+	ctx := context.Background()
+	require.NoError(t, agent.Start(ctx))
+
+	response, err := agent.ProcessQuery(ctx, "why is CPU high?")
+	require.NoError(t, err)
+	assert.Equal(t, "CPU has been at 97% for 30 minutes.", response.Response)
+
+	calls, ok := response.Metadata["tool_calls"].([]core.AgentToolCall)
+	require.True(t, ok, "Expected the tool calls in the metadata")
+	require.Len(t, calls, 2)
+	assert.Equal(t, "query_history", calls[0].Tool)
+	assert.Equal(t, "cpu_*", calls[0].Arguments["metric"])
+	assert.Len(t, calls[0].Result, 1)
+	assert.Empty(t, calls[0].Error)
+	assert.Equal(t, "plugin_status", calls[1].Tool)
+	require.Len(t, tools.queries, 1)
+	assert.Equal(t, "cpu_*", tools.queries[0].Metric)
+	assert.WithinDuration(t, time.Now().Add(-30*time.Minute), tools.queries[0].Start, time.Minute)
+
+	first := <-requests
+	declared := first["tools"].([]interface{})
+	require.Len(t, declared, 2)
+	assert.Equal(t, "plugin_status", declared[0].(map[string]interface{})["function"].(map[string]interface{})["name"])
+
+	// The second request carries the calls and their results
+	second := <-requests
+	messages := second["messages"].([]interface{})
+	require.Len(t, messages, 5)
+	assert.Len(t, messages[2].(map[string]interface{})["tool_calls"], 2)
+	result := messages[3].(map[string]interface{})
+	assert.Equal(t, "tool", result["role"])
+	assert.Equal(t, "call_1", result["tool_call_id"])
+	assert.Contains(t, result["content"], "cpu_usage")
+}
+
+func TestAIAgent_ToolsFailuresAndRounds(t *testing.T) {
+	useTool := `{"content": [{"type": "tool_use", "id": "toolu_1", "name": "list_analyses", "input": {"since": "yesterday"}}]}`
+	server, requests := newScriptedServer(t, useTool, useTool,
+		`{"content": [{"type": "text", "text": "I could not list the analyses."}]}`)
+
+	agent := NewAIAgent("ai")
+	require.NoError(t, agent.Configure(map[string]interface{}{
+		"api_key": "key", "api_url": server.URL, "provider": "anthropic",
+		"tools": []interface{}{"list_analyses"}, "max_tool_rounds": 2,
+	}))
+	agent.SetAgentTools(&fakeAgentTools{})
+	ctx := context.Background()
+	require.NoError(t, agent.Start(ctx))
+
+	response, err := agent.ProcessQuery(ctx, "what happened?")
+	require.NoError(t, err)
+	assert.Equal(t, "I could not list the analyses.", response.Response)
+	calls := response.Metadata["tool_calls"].([]core.AgentToolCall)
+	require.Len(t, calls, 2)
+	assert.Contains(t, calls[0].Error, "since", "Expected a bad argument to be reported as the call's error")
+	assert.Nil(t, calls[0].Result)
+
+	<-requests
+	second := <-requests
+	assert.NotContains(t, second, "tool_choice")
+	messages := second["messages"].([]interface{})
+	require.Len(t, messages, 3)
+	results := messages[2].(map[string]interface{})["content"].([]interface{})
+	assert.Equal(t, "toolu_1", results[0].(map[string]interface{})["tool_use_id"])
+	assert.Contains(t, results[0].(map[string]interface{})["content"], "error")
+
+	final := <-requests
+	assert.Equal(t, map[string]interface{}{"type": "none"}, final["tool_choice"],
+		"Expected the last round to ask for an answer without more calls")
+}
+
+func TestAIAgent_ConfigureTools(t *testing.T) {
+	agent := NewAIAgent("ai")
+	assert.ErrorContains(t, agent.Configure(map[string]interface{}{"api_key": "key", "tools": []interface{}{"shell"}}),
+		"plugin_status", "Expected an unknown tool to fail with the tools available")
+	assert.Error(t, agent.Configure(map[string]interface{}{"api_key": "key", "tools": "all"}))
+	assert.Error(t, agent.Configure(map[string]interface{}{"api_key": "key", "tools": []interface{}{"plugin_status"}, "max_tool_rounds": 0}))
+	require.NoError(t, agent.Configure(map[string]interface{}{"api_key": "key", "tools": []interface{}{"prometheus_query", "plugin_status"}}))
+	assert.Len(t, agent.tools, 2)
+}
+
+func TestGeminiToolBody(t *testing.T) {
+	tools, err := parseAITools([]interface{}{"plugin_status", "prometheus_query"})
+	require.NoError(t, err)
+	chat := map[string]interface{}{"messages": []map[string]string{{"role": "user", "content": "is prometheus up?"}}}
+	rounds := []aiToolRound{{
+		calls:   []aiToolCall{{id: "call_0", name: "prometheus_query", arguments: map[string]interface{}{"query": "up"}}},
+		results: []string{`[{"metric":"up","value":1}]`},
+	}}
+
+	data, err := json.Marshal(geminiProvider{}.toolBody(chat, tools, rounds, true))
+	require.NoError(t, err)
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &body))
+
+	contents := body["contents"].([]interface{})
+	require.Len(t, contents, 3)
+	call := contents[1].(map[string]interface{})["parts"].([]interface{})[0].(map[string]interface{})["functionCall"]
+	assert.Equal(t, "prometheus_query", call.(map[string]interface{})["name"])
+	response := contents[2].(map[string]interface{})["parts"].([]interface{})[0].(map[string]interface{})["functionResponse"]
+	assert.Len(t, response.(map[string]interface{})["response"].(map[string]interface{})["content"], 1)
+
+	declarations := body["tools"].([]interface{})[0].(map[string]interface{})["functionDeclarations"].([]interface{})
+	assert.NotContains(t, declarations[0], "parameters", "Expected no schema for a tool without arguments")
+	assert.Contains(t, declarations[1], "parameters")
+	assert.Equal(t, "NONE", body["toolConfig"].(map[string]interface{})["functionCallingConfig"].(map[string]interface{})["mode"])
+}
@@ -0,0 +1,273 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// aiUsage is the tokens one call used
+type aiUsage struct {
+	promptTokens     int64
+	completionTokens int64
+}
+
+// merge takes the counts a streamed event reports, which are running totals
+func (u *aiUsage) merge(event aiUsage) {
+	if event.promptTokens > 0 {
+		u.promptTokens = event.promptTokens
+	}
+	if event.completionTokens > 0 {
+		u.completionTokens = event.completionTokens
+	}
+}
+
+// aiPricing is what the provider charges per million tokens, in the currency budgets use
+type aiPricing struct {
+	promptPerMillion     float64
+	completionPerMillion float64
+}
+
+// cost returns what a call's tokens cost
+func (p aiPricing) cost(usage aiUsage) float64 {
+	return (float64(usage.promptTokens)*p.promptPerMillion + float64(usage.completionTokens)*p.completionPerMillion) / 1e6
+}
+
+// aiBudget limits the tokens and cost an agent uses per UTC day; zero is unlimited
+type aiBudget struct {
+	dailyTokens int64
+	dailyCost   float64
+}
+
+// aiUsageTracker adds up the tokens and cost of an agent's calls, in total and for the
+// current UTC day, which budgets apply to
+type aiUsageTracker struct {
+	pricing aiPricing
+	budget  aiBudget
+	now     func() time.Time
+
+	calls            int64
+	promptTokens     int64
+	completionTokens int64
+	cost             float64
+	// refused counts queries answered as over budget
+	refused int64
+
+	day       time.Time
+	dayTokens int64
+	dayCost   float64
+	mu        sync.Mutex
+}
+
+// newAIUsageTracker creates a tracker without pricing or budget
+func newAIUsageTracker() *aiUsageTracker {
+	return &aiUsageTracker{now: time.Now}
+}
+
+// parseAIUsageConfig reads the pricing and budget settings
+func parseAIUsageConfig(config map[string]interface{}) (aiPricing, aiBudget, error) {
+	var pricing aiPricing
+	var budget aiBudget
+	number := func(section map[string]interface{}, key string) (float64, error) {
+		var value float64
+		switch v := section[key].(type) {
+		case nil:
+			return 0, nil
+		case int:
+			value = float64(v)
+		case float64:
+			value = v
+		default:
+			return 0, fmt.Errorf("%s must be a number", key)
+		}
+		if value < 0 {
+			return 0, fmt.Errorf("%s must not be negative", key)
+		}
+		return value, nil
+	}
+
+	var err error
+	if section, ok := config["pricing"].(map[string]interface{}); ok {
+		if pricing.promptPerMillion, err = number(section, "prompt_per_million"); err != nil {
+			return pricing, budget, err
+		}
+		if pricing.completionPerMillion, err = number(section, "completion_per_million"); err != nil {
+			return pricing, budget, err
+		}
+	}
+	if section, ok := config["budget"].(map[string]interface{}); ok {
+		tokens, err := number(section, "daily_tokens")
+		if err != nil {
+			return pricing, budget, err
+		}
+		budget.dailyTokens = int64(tokens)
+		if budget.dailyCost, err = number(section, "daily_cost"); err != nil {
+			return pricing, budget, err
+		}
+	}
+	if budget.dailyCost > 0 && pricing == (aiPricing{}) {
+		return pricing, budget, fmt.Errorf("budget.daily_cost needs pricing to work out what calls cost")
+	}
+	return pricing, budget, nil
+}
+
+// configure sets the pricing and budget, keeping the usage so far
+func (t *aiUsageTracker) configure(pricing aiPricing, budget aiBudget) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pricing = pricing
+	t.budget = budget
+}
+
+// rollover starts a new day's usage once the UTC day changes. The caller holds the lock.
+func (t *aiUsageTracker) rollover() {
+	day := t.now().UTC().Truncate(24 * time.Hour)
+	if !day.Equal(t.day) {
+		t.day = day
+		t.dayTokens = 0
+		t.dayCost = 0
+	}
+}
+
+// record adds a call's usage
+func (t *aiUsageTracker) record(usage aiUsage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover()
+	cost := t.pricing.cost(usage)
+	t.calls++
+	t.promptTokens += usage.promptTokens
+	t.completionTokens += usage.completionTokens
+	t.cost += cost
+	t.dayTokens += usage.promptTokens + usage.completionTokens
+	t.dayCost += cost
+}
+
+// exceeded reports which of today's budgets is used up, counting the query it refuses
+func (t *aiUsageTracker) exceeded() (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover()
+	var reason string
+	switch {
+	case t.budget.dailyTokens > 0 && t.dayTokens >= t.budget.dailyTokens:
+		reason = fmt.Sprintf("%d of %d tokens used today", t.dayTokens, t.budget.dailyTokens)
+	case t.budget.dailyCost > 0 && t.dayCost >= t.budget.dailyCost:
+		reason = fmt.Sprintf("%.2f of %.2f spent today", t.dayCost, t.budget.dailyCost)
+	default:
+		return "", false
+	}
+	t.refused++
+	return reason, true
+}
+
+// snapshot returns the usage for the agent's status
+func (t *aiUsageTracker) snapshot() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover()
+	today := map[string]interface{}{
+		"date":   t.day.Format("2006-01-02"),
+		"tokens": t.dayTokens,
+		"cost":   t.dayCost,
+	}
+	if t.budget.dailyTokens > 0 {
+		today["token_budget"] = t.budget.dailyTokens
+	}
+	if t.budget.dailyCost > 0 {
+		today["cost_budget"] = t.budget.dailyCost
+	}
+	return map[string]interface{}{
+		"calls":             t.calls,
+		"prompt_tokens":     t.promptTokens,
+		"completion_tokens": t.completionTokens,
+		"cost":              t.cost,
+		"budget_refusals":   t.refused,
+		"today":             today,
+	}
+}
+
+// writeMetrics writes the usage series of an agent
+func (t *aiUsageTracker) writeMetrics(w io.Writer, agent string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover()
+
+	fmt.Fprintf(w, "# HELP agent_ai_calls_total Calls the AI agent made to its provider\n")
+	fmt.Fprintf(w, "# TYPE agent_ai_calls_total counter\n")
+	fmt.Fprintf(w, "agent_ai_calls_total{agent=%q} %d\n", agent, t.calls)
+	fmt.Fprintf(w, "# HELP agent_ai_tokens_total Tokens the AI agent's calls used, by kind\n")
+	fmt.Fprintf(w, "# TYPE agent_ai_tokens_total counter\n")
+	fmt.Fprintf(w, "agent_ai_tokens_total{agent=%q,kind=\"prompt\"} %d\n", agent, t.promptTokens)
+	fmt.Fprintf(w, "agent_ai_tokens_total{agent=%q,kind=\"completion\"} %d\n", agent, t.completionTokens)
+	fmt.Fprintf(w, "# HELP agent_ai_cost_total What the AI agent's calls cost at the configured pricing\n")
+	fmt.Fprintf(w, "# TYPE agent_ai_cost_total counter\n")
+	fmt.Fprintf(w, "agent_ai_cost_total{agent=%q} %g\n", agent, t.cost)
+	fmt.Fprintf(w, "# HELP agent_ai_budget_refusals_total Queries the AI agent answered as over its daily budget\n")
+	fmt.Fprintf(w, "# TYPE agent_ai_budget_refusals_total counter\n")
+	fmt.Fprintf(w, "agent_ai_budget_refusals_total{agent=%q} %d\n", agent, t.refused)
+	fmt.Fprintf(w, "# HELP agent_ai_daily_tokens Tokens the AI agent used today (UTC)\n")
+	fmt.Fprintf(w, "# TYPE agent_ai_daily_tokens gauge\n")
+	fmt.Fprintf(w, "agent_ai_daily_tokens{agent=%q} %d\n", agent, t.dayTokens)
+	fmt.Fprintf(w, "# HELP agent_ai_daily_cost What the AI agent's calls cost today (UTC)\n")
+	fmt.Fprintf(w, "# TYPE agent_ai_daily_cost gauge\n")
+	fmt.Fprintf(w, "agent_ai_daily_cost{agent=%q} %g\n", agent, t.dayCost)
+}
+
+// openAIUsage reads the usage of a chat completions response or streamed event
+func openAIUsage(data []byte) aiUsage {
+	var response struct {
+		Usage struct {
+			PromptTokens     int64 `json:"prompt_tokens"`
+			CompletionTokens int64 `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	_ = json.Unmarshal(data, &response)
+	return aiUsage{promptTokens: response.Usage.PromptTokens, completionTokens: response.Usage.CompletionTokens}
+}
+
+func (openAIProvider) usage(data []byte) aiUsage     { return openAIUsage(data) }
+func (azureProvider) usage(data []byte) aiUsage      { return openAIUsage(data) }
+func (compatibleProvider) usage(data []byte) aiUsage { return openAIUsage(data) }
+
+// usage reads a message's usage, which a stream reports in its message_start and
+// message_delta events
+func (anthropicProvider) usage(data []byte) aiUsage {
+	type counts struct {
+		InputTokens  int64 `json:"input_tokens"`
+		OutputTokens int64 `json:"output_tokens"`
+	}
+	var response struct {
+		Usage   counts `json:"usage"`
+		Message struct {
+			Usage counts `json:"usage"`
+		} `json:"message"`
+	}
+	_ = json.Unmarshal(data, &response)
+	usage := aiUsage{promptTokens: response.Message.Usage.InputTokens, completionTokens: response.Message.Usage.OutputTokens}
+	usage.merge(aiUsage{promptTokens: response.Usage.InputTokens, completionTokens: response.Usage.OutputTokens})
+	return usage
+}
+
+func (geminiProvider) usage(data []byte) aiUsage {
+	var response struct {
+		UsageMetadata struct {
+			PromptTokenCount     int64 `json:"promptTokenCount"`
+			CandidatesTokenCount int64 `json:"candidatesTokenCount"`
+		} `json:"usageMetadata"`
+	}
+	_ = json.Unmarshal(data, &response)
+	return aiUsage{promptTokens: response.UsageMetadata.PromptTokenCount, completionTokens: response.UsageMetadata.CandidatesTokenCount}
+}
+
+// usage reads the counts Ollama reports with a whole answer or the last line of a stream
+func (ollamaProvider) usage(data []byte) aiUsage {
+	var response struct {
+		PromptEvalCount int64 `json:"prompt_eval_count"`
+		EvalCount       int64 `json:"eval_count"`
+	}
+	_ = json.Unmarshal(data, &response)
+	return aiUsage{promptTokens: response.PromptEvalCount, completionTokens: response.EvalCount}
+}
@@ -0,0 +1,134 @@
+package agents
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAIAgent_Usage(t *testing.T) {
+	server, _ := newProviderServer(t, http.StatusOK,
+		`{"choices": [{"message": {"content": "CPU is high"}}], "usage": {"prompt_tokens": 100, "completion_tokens": 50, "total_tokens": 150}}`)
+	agent := NewAIAgent("ai")
+	require.NoError(t, agent.Configure(map[string]interface{}{
+		"api_key": "key", "api_url": server.URL,
+		"pricing": map[string]interface{}{"prompt_per_million": 1000, "completion_per_million": 2000},
+	}))
+	ctx := context.Background()
+	require.NoError(t, agent.Start(ctx))
+	_, err := agent.ProcessQuery(ctx, "why is CPU high?")
+	require.NoError(t, err)
+
+	usage := agent.StatusDetails()["usage"].(map[string]interface{})
+	assert.Equal(t, int64(2), usage["calls"], "Expected the health check and the query")
+	assert.Equal(t, int64(200), usage["prompt_tokens"])
+	assert.Equal(t, int64(100), usage["completion_tokens"])
+	assert.InDelta(t, 0.4, usage["cost"], 1e-9)
+	assert.Equal(t, int64(300), usage["today"].(map[string]interface{})["tokens"])
+
+	var metrics strings.Builder
+	agent.WriteMetrics(&metrics)
+	assert.Contains(t, metrics.String(), `agent_ai_tokens_total{agent="ai",kind="prompt"} 200`)
+	assert.Contains(t, metrics.String(), `agent_ai_tokens_total{agent="ai",kind="completion"} 100`)
+	assert.Contains(t, metrics.String(), `agent_ai_cost_total{agent="ai"} 0.4`)
+}
+
+func TestAIAgent_Budget(t *testing.T) {
+	server, _ := newProviderServer(t, http.StatusOK,
+		`{"choices": [{"message": {"content": "CPU is high"}}], "usage": {"prompt_tokens": 100, "completion_tokens": 50}}`)
+	agent := NewAIAgent("ai")
+	require.NoError(t, agent.Configure(map[string]interface{}{
+		"api_key": "key", "api_url": server.URL,
+		"budget": map[string]interface{}{"daily_tokens": 200},
+	}))
+	now := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	agent.usage.now = func() time.Time { return now }
+	ctx := context.Background()
+	require.NoError(t, agent.Start(ctx))
+
+	// The health check used 150 tokens, leaving room for one query
+	response, err := agent.ProcessQuery(ctx, "why is CPU high?")
+	require.NoError(t, err)
+	assert.Equal(t, "CPU is high", response.Response)
+
+	response, err = agent.ProcessQuery(ctx, "and memory?")
+	require.NoError(t, err, "Expected a query over budget to be answered rather than fail")
+	assert.Equal(t, true, response.Metadata["budget_exceeded"])
+	assert.Contains(t, response.Response, "300 of 200 tokens")
+
+	var chunks []string
+	_, err = agent.ProcessQueryStream(ctx, "and disk?", func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+	assert.Contains(t, chunks[0], "budget")
+	assert.Equal(t, int64(2), agent.StatusDetails()["usage"].(map[string]interface{})["budget_refusals"])
+
+	// The budget is daily
+	now = now.Add(2 * time.Hour)
+	response, err = agent.ProcessQuery(ctx, "and now?")
+	require.NoError(t, err)
+	assert.Equal(t, "CPU is high", response.Response)
+}
+
+func TestAIAgent_ConfigureBudget(t *testing.T) {
+	agent := NewAIAgent("ai")
+	assert.ErrorContains(t, agent.Configure(map[string]interface{}{"api_key": "key", "budget": map[string]interface{}{"daily_cost": 5}}),
+		"pricing", "Expected a cost budget to need pricing")
+	assert.Error(t, agent.Configure(map[string]interface{}{"api_key": "key", "budget": map[string]interface{}{"daily_tokens": -1}}))
+	assert.Error(t, agent.Configure(map[string]interface{}{"api_key": "key", "pricing": map[string]interface{}{"prompt_per_million": "cheap"}}))
+	require.NoError(t, agent.Configure(map[string]interface{}{
+		"api_key": "key",
+		"pricing": map[string]interface{}{"prompt_per_million": 0.15, "completion_per_million": 0.6},
+		"budget":  map[string]interface{}{"daily_cost": 5.0},
+	}))
+}
+
+func TestAIProviders_Usage(t *testing.T) {
+	tests := []struct {
+		provider aiProvider
+		events   []string
+		expected aiUsage
+	}{
+		{
+			provider: openAIProvider{},
+			events:   []string{`{"choices": [{"delta": {"content": "hi"}}]}`, `{"choices": [], "usage": {"prompt_tokens": 12, "completion_tokens": 3}}`},
+			expected: aiUsage{promptTokens: 12, completionTokens: 3},
+		},
+		{
+			provider: anthropicProvider{},
+			events: []string{
+				`{"type": "message_start", "message": {"usage": {"input_tokens": 25, "output_tokens": 1}}}`,
+				`{"type": "content_block_delta", "delta": {"type": "text_delta", "text": "hi"}}`,
+				`{"type": "message_delta", "usage": {"output_tokens": 15}}`,
+			},
+			expected: aiUsage{promptTokens: 25, completionTokens: 15},
+		},
+		{
+			provider: geminiProvider{},
+			events:   []string{`{"candidates": [], "usageMetadata": {"promptTokenCount": 8, "candidatesTokenCount": 4, "totalTokenCount": 12}}`},
+			expected: aiUsage{promptTokens: 8, completionTokens: 4},
+		},
+		{
+			provider: ollamaProvider{},
+			events:   []string{`{"message": {"content": "hi"}, "done": false}`, `{"done": true, "prompt_eval_count": 30, "eval_count": 9}`},
+			expected: aiUsage{promptTokens: 30, completionTokens: 9},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider.name(), func(t *testing.T) {
+			var usage aiUsage
+			for _, event := range tt.events {
+				usage.merge(tt.provider.usage([]byte(event)))
+			}
+			assert.Equal(t, tt.expected, usage)
+		})
+	}
+}
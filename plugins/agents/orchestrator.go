@@ -2,6 +2,7 @@ package agents
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -10,18 +11,50 @@ import (
 	"github.com/habruzzo/agent/core"
 )
 
-// AgentOrchestrator coordinates multiple agents to work together
+// Trigger protection applied to workflows that do not set their own
+const (
+	defaultWorkflowCooldown      = 5 * time.Minute
+	defaultWorkflowFlapWindow    = time.Hour
+	defaultWorkflowFlapThreshold = 3
+	// defaultStepTimeout bounds steps that do not set their own timeout
+	defaultStepTimeout = time.Minute
+)
+
+// ErrTriggerSuppressed is wrapped by StartWorkflow errors for triggers held back by a
+// running workflow, its cool-down, or flap protection
+var ErrTriggerSuppressed = errors.New("workflow trigger suppressed")
+
+// AgentOrchestrator coordinates multiple agents to work together. Loaded as a plugin, its
+// workflows are declared in the configuration and their steps ask the framework's agents.
 type AgentOrchestrator struct {
-	name         string
-	status       core.PluginStatus
-	agents       map[string]Agent
+	name   string
+	status core.PluginStatus
+	agents map[string]Agent
+	// querier asks the framework's agents for steps naming no registered agent
+	querier      core.AgentQuerier
 	workflows    map[string]*Workflow
+	triggers     map[string]*workflowTrigger
 	messageBus   *MessageBus
 	stateManager *StateManager
 	monitor      *AgentMonitor
 	mu           sync.RWMutex
 }
 
+// workflowTrigger tracks when a workflow ran so repeated triggers can be held back
+type workflowTrigger struct {
+	running bool
+	// cancel stops the running workflow, which started at startedAt, finished done steps,
+	// and is at step
+	cancel     context.CancelFunc
+	startedAt  time.Time
+	done       int
+	step       string
+	finishedAt time.Time
+	starts     []time.Time
+	flapping   bool
+	suppressed int
+}
+
 // Agent represents any agent that can be orchestrated
 type Agent interface {
 	GetName() string
@@ -55,6 +88,12 @@ type Workflow struct {
 	CreatedAt time.Time              `json:"created_at"`
 	UpdatedAt time.Time              `json:"updated_at"`
 	Metadata  map[string]interface{} `json:"metadata"`
+	// Cooldown is how long after finishing the workflow ignores triggers
+	Cooldown time.Duration `json:"cooldown,omitempty"`
+	// FlapThreshold runs within FlapWindow mark the trigger as flapping, and further
+	// triggers are ignored until the oldest run leaves the window
+	FlapWindow    time.Duration `json:"flap_window,omitempty"`
+	FlapThreshold int           `json:"flap_threshold,omitempty"`
 }
 
 // WorkflowStep represents a single step in a workflow
@@ -91,6 +130,26 @@ const (
 	WorkflowStateCancelled
 )
 
+// String returns the name of the state
+func (s WorkflowState) String() string {
+	switch s {
+	case WorkflowStatePending:
+		return "pending"
+	case WorkflowStateRunning:
+		return "running"
+	case WorkflowStatePaused:
+		return "paused"
+	case WorkflowStateCompleted:
+		return "completed"
+	case WorkflowStateFailed:
+		return "failed"
+	case WorkflowStateCancelled:
+		return "cancelled"
+	default:
+		return fmt.Sprintf("WorkflowState(%d)", int(s))
+	}
+}
+
 // StepStatus represents the status of a workflow step
 type StepStatus int
 
@@ -152,6 +211,7 @@ func NewAgentOrchestrator(name string) *AgentOrchestrator {
 		status:       core.PluginStatusStopped,
 		agents:       make(map[string]Agent),
 		workflows:    make(map[string]*Workflow),
+		triggers:     make(map[string]*workflowTrigger),
 		messageBus:   NewMessageBus(),
 		stateManager: NewStateManager(),
 		monitor:      NewAgentMonitor(),
@@ -172,105 +232,268 @@ func (o *AgentOrchestrator) RegisterAgent(agent Agent) error {
 	return nil
 }
 
-// StartWorkflow starts a workflow execution
+// StartWorkflow starts a workflow execution. Triggers arriving while the workflow runs,
+// during its cool-down, or while it is flapping are ignored with an error wrapping
+// ErrTriggerSuppressed, so an anomaly reappearing during stabilization cannot restart it.
 func (o *AgentOrchestrator) StartWorkflow(ctx context.Context, workflowID string) error {
-	o.mu.RLock()
-	workflow, exists := o.workflows[workflowID]
-	o.mu.RUnlock()
+	workflow, runCtx, err := o.beginWorkflow(ctx, workflowID)
+	if err != nil {
+		return err
+	}
+
+	// Execute workflow steps
+	go o.executeWorkflow(runCtx, workflow, nil)
+
+	return nil
+}
 
+// beginWorkflow marks a workflow as running, unless its trigger is held back, and returns
+// the context of the run, which CancelWorkflow and Stop cancel
+func (o *AgentOrchestrator) beginWorkflow(ctx context.Context, workflowID string) (*Workflow, context.Context, error) {
+	o.mu.Lock()
+	workflow, exists := o.workflows[workflowID]
 	if !exists {
-		return core.NewPluginError("orchestrator", "execute-workflow", fmt.Sprintf("workflow %s not found", workflowID))
+		o.mu.Unlock()
+		return nil, nil, core.NewPluginError("orchestrator", "execute-workflow", fmt.Sprintf("workflow %s not found", workflowID))
 	}
 
+	now := time.Now()
+	trigger := o.trigger(workflowID)
+	if reason := o.holdTrigger(workflow, trigger, now); reason != "" {
+		trigger.suppressed++
+		o.mu.Unlock()
+		slog.Info("Workflow trigger suppressed",
+			"orchestrator", o.name,
+			"workflow", workflowID,
+			"reason", reason)
+		return nil, nil, core.WrapError(ErrTriggerSuppressed, core.ErrorTypeValidation, "orchestrator", "execute-workflow",
+			fmt.Sprintf("workflow %s not started: %s", workflowID, reason))
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	trigger.running = true
+	trigger.cancel = cancel
+	trigger.startedAt = now
+	trigger.done = 0
+	trigger.step = ""
+	trigger.starts = append(trigger.starts, now)
 	workflow.State = WorkflowStateRunning
-	workflow.UpdatedAt = time.Now()
+	workflow.UpdatedAt = now
+	o.mu.Unlock()
 
 	slog.Info("Starting workflow",
 		"orchestrator", o.name,
 		"workflow", workflowID,
 		"steps", len(workflow.Steps))
+	return workflow, runCtx, nil
+}
 
-	// Execute workflow steps
-	go o.executeWorkflow(ctx, workflow)
+// trigger returns the trigger state of a workflow. Callers hold the lock.
+func (o *AgentOrchestrator) trigger(workflowID string) *workflowTrigger {
+	trigger, ok := o.triggers[workflowID]
+	if !ok {
+		trigger = &workflowTrigger{}
+		o.triggers[workflowID] = trigger
+	}
+	return trigger
+}
 
-	return nil
+// triggerSettings returns the workflow's cool-down and flap settings, falling back to the
+// defaults for unset ones
+func triggerSettings(workflow *Workflow) (cooldown, flapWindow time.Duration, flapThreshold int) {
+	cooldown, flapWindow, flapThreshold = workflow.Cooldown, workflow.FlapWindow, workflow.FlapThreshold
+	if cooldown == 0 {
+		cooldown = defaultWorkflowCooldown
+	}
+	if flapWindow == 0 {
+		flapWindow = defaultWorkflowFlapWindow
+	}
+	if flapThreshold == 0 {
+		flapThreshold = defaultWorkflowFlapThreshold
+	}
+	return cooldown, flapWindow, flapThreshold
+}
+
+// holdTrigger returns why a trigger must be ignored, or an empty string when the workflow
+// may start. A negative cool-down or flap threshold turns that protection off. Callers
+// hold the lock.
+func (o *AgentOrchestrator) holdTrigger(workflow *Workflow, trigger *workflowTrigger, now time.Time) string {
+	if trigger.running {
+		return "already running"
+	}
+
+	cooldown, flapWindow, flapThreshold := triggerSettings(workflow)
+	if cooldown > 0 && !trigger.finishedAt.IsZero() && now.Before(trigger.finishedAt.Add(cooldown)) {
+		return fmt.Sprintf("cooling down until %s", trigger.finishedAt.Add(cooldown).Format(time.RFC3339))
+	}
+
+	cutoff := now.Add(-flapWindow)
+	drop := 0
+	for drop < len(trigger.starts) && !trigger.starts[drop].After(cutoff) {
+		drop++
+	}
+	trigger.starts = trigger.starts[drop:]
+
+	if flapThreshold <= 0 || len(trigger.starts) < flapThreshold {
+		trigger.flapping = false
+		return ""
+	}
+	if !trigger.flapping {
+		trigger.flapping = true
+		o.monitor.RaiseAlert(Alert{
+			ID:        fmt.Sprintf("%s-flapping-%d", workflow.ID, now.Unix()),
+			AgentName: o.name,
+			Type:      "workflow_flapping",
+			Severity:  "warning",
+			Message:   fmt.Sprintf("Workflow %s ran %d times in %s; ignoring triggers until it settles", workflow.ID, len(trigger.starts), flapWindow),
+			Data:      map[string]interface{}{"workflow": workflow.ID, "runs": len(trigger.starts)},
+			Timestamp: now,
+		})
+	}
+	return fmt.Sprintf("flapping, ran %d times in %s", len(trigger.starts), flapWindow)
 }
 
-// executeWorkflow executes a workflow step by step
-func (o *AgentOrchestrator) executeWorkflow(ctx context.Context, workflow *Workflow) {
-	for i, step := range workflow.Steps {
-		select {
-		case <-ctx.Done():
-			workflow.State = WorkflowStateCancelled
-			return
-		default:
-			// Execute step
-			err := o.executeStep(ctx, workflow, &workflow.Steps[i])
-			if err != nil {
-				slog.Error("Workflow step failed",
-					"orchestrator", o.name,
-					"workflow", workflow.ID,
-					"step", step.ID,
-					"error", err)
-
-				workflow.State = WorkflowStateFailed
-				return
+// finishWorkflow records that a workflow run ended in the given state, starting its cool-down
+func (o *AgentOrchestrator) finishWorkflow(workflow *Workflow, state WorkflowState) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	trigger := o.trigger(workflow.ID)
+	if trigger.cancel != nil {
+		trigger.cancel()
+		trigger.cancel = nil
+	}
+	trigger.running = false
+	trigger.step = ""
+	trigger.finishedAt = time.Now()
+	workflow.State = state
+	workflow.UpdatedAt = trigger.finishedAt
+}
+
+// executeWorkflow executes a workflow step by step, giving each step the run's input merged
+// with its own, and returns the output of each step by step ID
+func (o *AgentOrchestrator) executeWorkflow(ctx context.Context, workflow *Workflow, input map[string]interface{}) (map[string]interface{}, error) {
+	outputs := make(map[string]interface{}, len(workflow.Steps))
+	for i := range workflow.Steps {
+		step := &workflow.Steps[i]
+		if err := ctx.Err(); err != nil {
+			o.finishWorkflow(workflow, WorkflowStateCancelled)
+			return outputs, core.WrapError(err, core.ErrorTypePlugin, "orchestrator", "execute-workflow",
+				fmt.Sprintf("workflow %s cancelled before step %s", workflow.ID, step.ID))
+		}
+
+		o.mu.Lock()
+		o.trigger(workflow.ID).step = step.ID
+		o.mu.Unlock()
+
+		if err := o.executeStep(ctx, workflow, step, input); err != nil {
+			slog.Error("Workflow step failed",
+				"orchestrator", o.name,
+				"workflow", workflow.ID,
+				"step", step.ID,
+				"error", err)
+
+			state := WorkflowStateFailed
+			if ctx.Err() != nil {
+				state = WorkflowStateCancelled
 			}
+			o.finishWorkflow(workflow, state)
+			return outputs, core.WrapError(err, core.ErrorTypePlugin, "orchestrator", "execute-workflow",
+				fmt.Sprintf("workflow %s failed at step %s", workflow.ID, step.ID))
 		}
+		outputs[step.ID] = step.Output
+
+		o.mu.Lock()
+		o.trigger(workflow.ID).done++
+		o.mu.Unlock()
 	}
 
-	workflow.State = WorkflowStateCompleted
-	workflow.UpdatedAt = time.Now()
+	o.finishWorkflow(workflow, WorkflowStateCompleted)
 
 	slog.Info("Workflow completed",
 		"orchestrator", o.name,
 		"workflow", workflow.ID)
+	return outputs, nil
 }
 
-// executeStep executes a single workflow step
-func (o *AgentOrchestrator) executeStep(ctx context.Context, workflow *Workflow, step *WorkflowStep) error {
+// executeStep executes a single workflow step, trying it again up to its retry count. Steps
+// naming an agent not registered with the orchestrator ask the framework's agent of that name.
+func (o *AgentOrchestrator) executeStep(ctx context.Context, workflow *Workflow, step *WorkflowStep, input map[string]interface{}) error {
 	step.Status = StepStatusRunning
 
 	// Get the agent for this step
 	o.mu.RLock()
 	agent, exists := o.agents[step.Agent]
+	querier := o.querier
 	o.mu.RUnlock()
 
+	if !exists && querier != nil {
+		agent, exists = &frameworkAgent{name: step.Agent, querier: querier}, true
+	}
 	if !exists {
+		step.Status = StepStatusFailed
 		return core.NewPluginError("orchestrator", "execute-step", fmt.Sprintf("agent %s not found", step.Agent))
 	}
 
-	// Create message for the agent
-	msg := &Message{
-		ID:        fmt.Sprintf("%s-%s-%d", workflow.ID, step.ID, time.Now().Unix()),
-		From:      o.name,
-		To:        step.Agent,
-		Type:      step.Action,
-		Content:   step.Name,
-		Data:      step.Input,
-		Timestamp: time.Now(),
-		Priority:  1,
+	data := make(map[string]interface{}, len(input)+len(step.Input))
+	for key, value := range input {
+		data[key] = value
+	}
+	for key, value := range step.Input {
+		data[key] = value
 	}
 
-	// Execute with timeout
-	ctx, cancel := context.WithTimeout(ctx, step.Timeout)
-	defer cancel()
-
-	// Send message to agent
-	response, err := agent.ProcessMessage(ctx, msg)
-	if err != nil {
-		step.Status = StepStatusFailed
-		return err
+	timeout := step.Timeout
+	if timeout <= 0 {
+		timeout = defaultStepTimeout
 	}
 
-	// Store output
-	step.Output = response.Data
-	step.Status = StepStatusCompleted
+	var err error
+	for attempt := 0; attempt <= step.RetryCount; attempt++ {
+		if attempt > 0 {
+			if ctx.Err() != nil {
+				break
+			}
+			slog.Warn("Retrying workflow step",
+				"orchestrator", o.name,
+				"workflow", workflow.ID,
+				"step", step.ID,
+				"attempt", attempt+1,
+				"error", err)
+		}
 
-	// Update metrics
-	o.monitor.RecordMessage(step.Agent, time.Since(msg.Timestamp), err == nil)
+		// Create message for the agent
+		msg := &Message{
+			ID:        fmt.Sprintf("%s-%s-%d", workflow.ID, step.ID, time.Now().Unix()),
+			From:      o.name,
+			To:        step.Agent,
+			Type:      step.Action,
+			Content:   step.Name,
+			Data:      data,
+			Timestamp: time.Now(),
+			Priority:  1,
+		}
 
-	return nil
+		// Execute with timeout
+		stepCtx, cancel := context.WithTimeout(ctx, timeout)
+		var response *Message
+		response, err = agent.ProcessMessage(stepCtx, msg)
+		cancel()
+
+		// Update metrics
+		o.monitor.RecordMessage(step.Agent, time.Since(msg.Timestamp), err == nil)
+
+		if err == nil {
+			// Store output
+			if response != nil {
+				step.Output = response.Data
+			}
+			step.Status = StepStatusCompleted
+			return nil
+		}
+	}
+
+	step.Status = StepStatusFailed
+	return err
 }
 
 // SendMessage sends a message between agents
@@ -302,11 +525,19 @@ func (o *AgentOrchestrator) GetStatus() map[string]interface{} {
 
 	workflowStatuses := make(map[string]interface{})
 	for id, workflow := range o.workflows {
-		workflowStatuses[id] = map[string]interface{}{
+		status := map[string]interface{}{
 			"name":  workflow.Name,
 			"state": workflow.State,
 			"steps": len(workflow.Steps),
 		}
+		if trigger, ok := o.triggers[id]; ok {
+			status["flapping"] = trigger.flapping
+			status["suppressed_triggers"] = trigger.suppressed
+			if cooldown, _, _ := triggerSettings(workflow); cooldown > 0 && !trigger.finishedAt.IsZero() {
+				status["cooldown_until"] = trigger.finishedAt.Add(cooldown)
+			}
+		}
+		workflowStatuses[id] = status
 	}
 
 	return map[string]interface{}{
@@ -388,6 +619,23 @@ func (am *AgentMonitor) RecordMessage(agentName string, latency time.Duration, s
 	metrics.AverageLatency = (metrics.AverageLatency*time.Duration(metrics.MessagesProcessed-1) + latency) / time.Duration(metrics.MessagesProcessed)
 }
 
+// RaiseAlert records a monitoring alert
+func (am *AgentMonitor) RaiseAlert(alert Alert) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	am.alerts = append(am.alerts, alert)
+	slog.Warn("Orchestrator alert", "agent", alert.AgentName, "type", alert.Type, "message", alert.Message)
+}
+
+// GetAlerts returns the alerts raised so far
+func (am *AgentMonitor) GetAlerts() []Alert {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+
+	return append([]Alert{}, am.alerts...)
+}
+
 // GetMetrics returns all agent metrics
 func (am *AgentMonitor) GetMetrics() map[string]*AgentMetrics {
 	am.mu.RLock()
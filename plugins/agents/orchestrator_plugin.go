@@ -0,0 +1,394 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// Name returns the plugin name
+func (o *AgentOrchestrator) Name() string {
+	return o.name
+}
+
+// Type returns the plugin type
+func (o *AgentOrchestrator) Type() core.PluginType {
+	return core.PluginTypeAgent
+}
+
+// Version returns the plugin version
+func (o *AgentOrchestrator) Version() string {
+	return "1.0.0"
+}
+
+// Configure adds the workflows declared under workflows. Each step names the framework
+// agent it asks; durations are strings such as "30s".
+func (o *AgentOrchestrator) Configure(config map[string]interface{}) error {
+	workflows, err := parseOrchestratorWorkflows(config)
+	if err != nil {
+		return err
+	}
+	for _, workflow := range workflows {
+		o.AddWorkflow(workflow)
+	}
+	return nil
+}
+
+// parseOrchestratorWorkflows reads the workflows of the orchestrator's configuration
+func parseOrchestratorWorkflows(config map[string]interface{}) ([]*Workflow, error) {
+	if config["workflows"] == nil {
+		return nil, nil
+	}
+	entries, ok := config["workflows"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("workflows must be a list")
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool, len(entries))
+	workflows := make([]*Workflow, 0, len(entries))
+	for i, entry := range entries {
+		section, ok := entry.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("workflows[%d] must be a map", i)
+		}
+		id, _ := section["id"].(string)
+		if id == "" {
+			return nil, fmt.Errorf("workflows[%d] needs an id", i)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("workflow %s is declared twice", id)
+		}
+		seen[id] = true
+
+		workflow := &Workflow{ID: id, Name: id, CreatedAt: now, UpdatedAt: now, Metadata: make(map[string]interface{})}
+		if name, ok := section["name"].(string); ok && name != "" {
+			workflow.Name = name
+		}
+		for key, target := range map[string]*time.Duration{
+			"cooldown":    &workflow.Cooldown,
+			"flap_window": &workflow.FlapWindow,
+		} {
+			if raw, ok := section[key]; ok {
+				duration, err := parseWorkflowDuration(raw)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s %v of workflow %s", key, raw, id)
+				}
+				*target = duration
+			}
+		}
+		if raw, ok := section["flap_threshold"]; ok {
+			threshold, isInt := configInt(raw)
+			if !isInt {
+				return nil, fmt.Errorf("invalid flap_threshold %v of workflow %s", raw, id)
+			}
+			workflow.FlapThreshold = threshold
+		}
+
+		steps, _ := section["steps"].([]interface{})
+		if len(steps) == 0 {
+			return nil, fmt.Errorf("workflow %s needs a list of steps", id)
+		}
+		for j, rawStep := range steps {
+			step, err := parseWorkflowStep(rawStep, j)
+			if err != nil {
+				return nil, fmt.Errorf("workflow %s: %w", id, err)
+			}
+			workflow.Steps = append(workflow.Steps, step)
+		}
+		workflows = append(workflows, workflow)
+	}
+	return workflows, nil
+}
+
+// parseWorkflowStep reads one step of a declared workflow
+func parseWorkflowStep(raw interface{}, index int) (WorkflowStep, error) {
+	section, ok := raw.(map[string]interface{})
+	if !ok {
+		return WorkflowStep{}, fmt.Errorf("steps[%d] must be a map", index)
+	}
+	step := WorkflowStep{ID: fmt.Sprintf("step-%d", index+1), Input: make(map[string]interface{})}
+	if id, ok := section["id"].(string); ok && id != "" {
+		step.ID = id
+	}
+	step.Name = step.ID
+	if name, ok := section["name"].(string); ok && name != "" {
+		step.Name = name
+	}
+	step.Agent, _ = section["agent"].(string)
+	if step.Agent == "" {
+		return WorkflowStep{}, fmt.Errorf("step %s needs an agent", step.ID)
+	}
+	step.Action, _ = section["action"].(string)
+
+	if rawInput, ok := section["input"]; ok {
+		input, isMap := rawInput.(map[string]interface{})
+		if !isMap {
+			return WorkflowStep{}, fmt.Errorf("input of step %s must be a map", step.ID)
+		}
+		step.Input = input
+	}
+	if rawTimeout, ok := section["timeout"]; ok {
+		timeout, err := parseWorkflowDuration(rawTimeout)
+		if err != nil {
+			return WorkflowStep{}, fmt.Errorf("invalid timeout %v of step %s", rawTimeout, step.ID)
+		}
+		step.Timeout = timeout
+	}
+	if rawRetries, ok := section["retry_count"]; ok {
+		retries, isInt := configInt(rawRetries)
+		if !isInt || retries < 0 {
+			return WorkflowStep{}, fmt.Errorf("invalid retry_count %v of step %s", rawRetries, step.ID)
+		}
+		step.RetryCount = retries
+	}
+	return step, nil
+}
+
+// parseWorkflowDuration reads a duration such as "5m"; negative durations are allowed, as
+// they turn trigger protection off
+func parseWorkflowDuration(raw interface{}) (time.Duration, error) {
+	text, ok := raw.(string)
+	if !ok {
+		return 0, fmt.Errorf("expected a duration string")
+	}
+	return time.ParseDuration(text)
+}
+
+// configInt reads a whole number, which YAML decodes as int and JSON as float64
+func configInt(raw interface{}) (int, bool) {
+	switch value := raw.(type) {
+	case int:
+		return value, true
+	case float64:
+		return int(value), value == float64(int(value))
+	default:
+		return 0, false
+	}
+}
+
+// Start starts the orchestrator
+func (o *AgentOrchestrator) Start(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.status == core.PluginStatusRunning {
+		return fmt.Errorf("agent is already running")
+	}
+
+	o.status = core.PluginStatusRunning
+	slog.Info("Agent orchestrator started", "plugin", o.name, "type", o.Type(), "workflows", len(o.workflows))
+	return nil
+}
+
+// Stop stops the orchestrator, cancelling running workflows
+func (o *AgentOrchestrator) Stop() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.status != core.PluginStatusRunning {
+		return fmt.Errorf("agent is not running")
+	}
+
+	o.status = core.PluginStatusStopping
+	for _, trigger := range o.triggers {
+		if trigger.cancel != nil {
+			trigger.cancel()
+		}
+	}
+
+	o.status = core.PluginStatusStopped
+	slog.Info("Agent orchestrator stopped", "plugin", o.name, "type", o.Type())
+	return nil
+}
+
+// Status returns the current status of the plugin
+func (o *AgentOrchestrator) Status() core.PluginStatus {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.status
+}
+
+// Health checks if the plugin is healthy
+func (o *AgentOrchestrator) Health(ctx context.Context) error {
+	if status := o.Status(); status != core.PluginStatusRunning {
+		return core.NewPluginError(o.name, "health", fmt.Sprintf("orchestrator is %s", status))
+	}
+	return nil
+}
+
+// GetCapabilities returns the capabilities of the orchestrator
+func (o *AgentOrchestrator) GetCapabilities() []string {
+	return []string{
+		"run_workflows",
+		"coordinate_agents",
+	}
+}
+
+// StatusDetails reports the orchestrator's agents and workflows on the status page
+func (o *AgentOrchestrator) StatusDetails() map[string]interface{} {
+	return o.GetStatus()
+}
+
+// SetAgentQuerier sets how steps ask the framework's agents
+func (o *AgentOrchestrator) SetAgentQuerier(querier core.AgentQuerier) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.querier = querier
+}
+
+// frameworkAgent runs workflow steps on an agent of the framework
+type frameworkAgent struct {
+	name    string
+	querier core.AgentQuerier
+}
+
+func (a *frameworkAgent) GetName() string {
+	return a.name
+}
+
+func (a *frameworkAgent) GetStatus() core.PluginStatus {
+	return core.PluginStatusRunning
+}
+
+func (a *frameworkAgent) GetCapabilities() []string {
+	return nil
+}
+
+func (a *frameworkAgent) Start(ctx context.Context) error {
+	return nil
+}
+
+func (a *frameworkAgent) Stop() error {
+	return nil
+}
+
+// ProcessMessage asks the agent the step's query input, or its name when it has none
+func (a *frameworkAgent) ProcessMessage(ctx context.Context, msg *Message) (*Message, error) {
+	query, _ := msg.Data["query"].(string)
+	if query == "" {
+		query = msg.Content
+	}
+	response, err := a.querier.QueryAgent(ctx, a.name, query)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{
+		From:       a.name,
+		To:         msg.From,
+		Type:       "response",
+		Content:    response.Response,
+		Data:       map[string]interface{}{"response": response.Response, "confidence": response.Confidence},
+		Timestamp:  time.Now(),
+		ResponseTo: msg.ID,
+	}, nil
+}
+
+// CreateWorkflow adds a workflow given in the framework's form. Each step's config names
+// its agent under agent; the rest of the config is the step's input, and its type is the
+// step's action.
+func (o *AgentOrchestrator) CreateWorkflow(workflow *core.Workflow) error {
+	if workflow == nil || workflow.ID == "" {
+		return core.NewValidationError("orchestrator", "create-workflow", "workflow needs an id")
+	}
+	if len(workflow.Steps) == 0 {
+		return core.NewValidationError("orchestrator", "create-workflow", fmt.Sprintf("workflow %s has no steps", workflow.ID))
+	}
+
+	now := time.Now()
+	converted := &Workflow{ID: workflow.ID, Name: workflow.Name, CreatedAt: now, UpdatedAt: now, Metadata: workflow.Metadata}
+	for _, step := range workflow.Steps {
+		agent, _ := step.Config["agent"].(string)
+		if agent == "" {
+			return core.NewValidationError("orchestrator", "create-workflow", fmt.Sprintf("step %s of workflow %s names no agent", step.ID, workflow.ID))
+		}
+		input := make(map[string]interface{}, len(step.Config))
+		for key, value := range step.Config {
+			if key != "agent" {
+				input[key] = value
+			}
+		}
+		converted.Steps = append(converted.Steps, WorkflowStep{
+			ID:         step.ID,
+			Name:       step.Name,
+			Agent:      agent,
+			Action:     step.Type,
+			Input:      input,
+			Condition:  step.Condition,
+			Timeout:    step.Timeout,
+			RetryCount: step.RetryCount,
+		})
+	}
+	o.AddWorkflow(converted)
+	return nil
+}
+
+// ExecuteWorkflow runs a workflow and waits for it to finish, returning the output of each
+// step by step ID. Triggers held back as by StartWorkflow are reported as errors.
+func (o *AgentOrchestrator) ExecuteWorkflow(ctx context.Context, workflowID string, input map[string]interface{}) (*core.WorkflowResult, error) {
+	workflow, runCtx, err := o.beginWorkflow(ctx, workflowID)
+	if err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	outputs, err := o.executeWorkflow(runCtx, workflow, input)
+	o.mu.RLock()
+	state := workflow.State
+	o.mu.RUnlock()
+
+	result := &core.WorkflowResult{
+		WorkflowID: workflowID,
+		Status:     state.String(),
+		Output:     outputs,
+		Duration:   time.Since(started),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result, err
+}
+
+// GetWorkflowStatus returns the state of a workflow and, while it runs, how far it got
+func (o *AgentOrchestrator) GetWorkflowStatus(workflowID string) (*core.WorkflowStatus, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	workflow, exists := o.workflows[workflowID]
+	if !exists {
+		return nil, core.NewPluginError("orchestrator", "workflow-status", fmt.Sprintf("workflow %s not found", workflowID))
+	}
+	status := &core.WorkflowStatus{
+		WorkflowID: workflowID,
+		Status:     workflow.State.String(),
+		UpdatedAt:  workflow.UpdatedAt,
+	}
+	if trigger, ok := o.triggers[workflowID]; ok {
+		status.StartedAt = trigger.startedAt
+		status.CurrentStep = trigger.step
+		if len(workflow.Steps) > 0 {
+			status.Progress = float64(trigger.done) / float64(len(workflow.Steps))
+		}
+	}
+	return status, nil
+}
+
+// CancelWorkflow cancels a running workflow; the step running is given up
+func (o *AgentOrchestrator) CancelWorkflow(workflowID string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, exists := o.workflows[workflowID]; !exists {
+		return core.NewPluginError("orchestrator", "cancel-workflow", fmt.Sprintf("workflow %s not found", workflowID))
+	}
+	trigger, ok := o.triggers[workflowID]
+	if !ok || !trigger.running || trigger.cancel == nil {
+		return core.NewValidationError("orchestrator", "cancel-workflow", fmt.Sprintf("workflow %s is not running", workflowID))
+	}
+	trigger.cancel()
+	slog.Info("Workflow cancelled", "orchestrator", o.name, "workflow", workflowID)
+	return nil
+}
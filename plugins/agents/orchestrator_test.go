@@ -0,0 +1,217 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type echoAgent struct {
+	name string
+}
+
+func (a *echoAgent) GetName() string                 { return a.name }
+func (a *echoAgent) GetStatus() core.PluginStatus    { return core.PluginStatusRunning }
+func (a *echoAgent) GetCapabilities() []string       { return []string{"echo"} }
+func (a *echoAgent) Start(ctx context.Context) error { return nil }
+func (a *echoAgent) Stop() error                     { return nil }
+
+func (a *echoAgent) ProcessMessage(ctx context.Context, msg *Message) (*Message, error) {
+	return &Message{From: a.name, To: msg.From, Data: msg.Data}, nil
+}
+
+func newTestOrchestrator(workflow *Workflow) *AgentOrchestrator {
+	orchestrator := NewAgentOrchestrator("test")
+	orchestrator.RegisterAgent(&echoAgent{name: "echo"})
+	workflow.Steps = []WorkflowStep{{ID: "echo", Agent: "echo", Action: "echo", Timeout: time.Second}}
+	orchestrator.AddWorkflow(workflow)
+	return orchestrator
+}
+
+func waitForFinish(t *testing.T, orchestrator *AgentOrchestrator, workflowID string) {
+	require.Eventually(t, func() bool {
+		orchestrator.mu.RLock()
+		defer orchestrator.mu.RUnlock()
+		return !orchestrator.triggers[workflowID].running
+	}, time.Second, time.Millisecond)
+}
+
+func TestAgentOrchestrator_TriggerCooldown(t *testing.T) {
+	orchestrator := newTestOrchestrator(&Workflow{ID: "incident-response", Cooldown: 50 * time.Millisecond, FlapThreshold: -1})
+	ctx := context.Background()
+
+	require.NoError(t, orchestrator.StartWorkflow(ctx, "incident-response"))
+	waitForFinish(t, orchestrator, "incident-response")
+
+	err := orchestrator.StartWorkflow(ctx, "incident-response")
+	assert.True(t, errors.Is(err, ErrTriggerSuppressed), "Expected a trigger right after the run to be ignored")
+
+	time.Sleep(60 * time.Millisecond)
+	assert.NoError(t, orchestrator.StartWorkflow(ctx, "incident-response"), "Expected the workflow to start after its cool-down")
+	waitForFinish(t, orchestrator, "incident-response")
+
+	status := orchestrator.GetStatus()["workflows"].(map[string]interface{})["incident-response"].(map[string]interface{})
+	assert.Equal(t, 1, status["suppressed_triggers"])
+}
+
+func TestAgentOrchestrator_TriggerFlapping(t *testing.T) {
+	orchestrator := newTestOrchestrator(&Workflow{ID: "restart", Cooldown: -1, FlapWindow: time.Hour, FlapThreshold: 2})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, orchestrator.StartWorkflow(ctx, "restart"))
+		waitForFinish(t, orchestrator, "restart")
+	}
+
+	err := orchestrator.StartWorkflow(ctx, "restart")
+	assert.True(t, errors.Is(err, ErrTriggerSuppressed), "Expected a third run within the window to be held back")
+	assert.True(t, errors.Is(orchestrator.StartWorkflow(ctx, "restart"), ErrTriggerSuppressed))
+
+	alerts := orchestrator.monitor.GetAlerts()
+	require.Len(t, alerts, 1, "Expected one alert when the workflow starts flapping")
+	assert.Equal(t, "workflow_flapping", alerts[0].Type)
+}
+
+// stubQuerier answers queries to the framework's agents, failing those of agents it fails
+type stubQuerier struct {
+	failures map[string]int
+	queries  []string
+	mu       sync.Mutex
+}
+
+func (q *stubQuerier) QueryAgent(ctx context.Context, agentName, query string) (*core.AgentResponse, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.queries = append(q.queries, agentName+": "+query)
+	if q.failures[agentName] > 0 {
+		q.failures[agentName]--
+		return nil, fmt.Errorf("agent %s unavailable", agentName)
+	}
+	return &core.AgentResponse{Query: query, Response: "answer from " + agentName, Confidence: 0.9}, nil
+}
+
+// waitAgent blocks until its message is cancelled
+type waitAgent struct {
+	echoAgent
+}
+
+func (a *waitAgent) ProcessMessage(ctx context.Context, msg *Message) (*Message, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestAgentOrchestrator_Configure(t *testing.T) {
+	orchestrator := NewAgentOrchestrator("orchestrator")
+	require.NoError(t, orchestrator.Configure(map[string]interface{}{
+		"workflows": []interface{}{map[string]interface{}{
+			"id":             "restart-api",
+			"name":           "Restart the API",
+			"cooldown":       "10m",
+			"flap_threshold": 2,
+			"steps": []interface{}{
+				map[string]interface{}{"id": "diagnose", "agent": "rag", "input": map[string]interface{}{"query": "Why?"}, "timeout": "30s", "retry_count": float64(2)},
+				map[string]interface{}{"agent": "ai", "action": "plan"},
+			},
+		}},
+	}))
+
+	workflow := orchestrator.workflows["restart-api"]
+	require.NotNil(t, workflow)
+	assert.Equal(t, "Restart the API", workflow.Name)
+	assert.Equal(t, 10*time.Minute, workflow.Cooldown)
+	assert.Equal(t, 2, workflow.FlapThreshold)
+	require.Len(t, workflow.Steps, 2)
+	assert.Equal(t, WorkflowStep{ID: "diagnose", Name: "diagnose", Agent: "rag", Input: map[string]interface{}{"query": "Why?"}, Timeout: 30 * time.Second, RetryCount: 2}, workflow.Steps[0])
+	assert.Equal(t, "step-2", workflow.Steps[1].ID, "Expected steps without an id to be numbered")
+	assert.Equal(t, "plan", workflow.Steps[1].Action)
+
+	step := map[string]interface{}{"agent": "ai"}
+	for _, bad := range []interface{}{
+		"restart-api",
+		[]interface{}{map[string]interface{}{"steps": []interface{}{step}}},
+		[]interface{}{map[string]interface{}{"id": "a"}},
+		[]interface{}{map[string]interface{}{"id": "a", "steps": []interface{}{step}}, map[string]interface{}{"id": "a", "steps": []interface{}{step}}},
+		[]interface{}{map[string]interface{}{"id": "a", "cooldown": 5, "steps": []interface{}{step}}},
+		[]interface{}{map[string]interface{}{"id": "a", "steps": []interface{}{map[string]interface{}{"id": "no-agent"}}}},
+		[]interface{}{map[string]interface{}{"id": "a", "steps": []interface{}{map[string]interface{}{"agent": "ai", "timeout": "soon"}}}},
+		[]interface{}{map[string]interface{}{"id": "a", "steps": []interface{}{map[string]interface{}{"agent": "ai", "retry_count": -1}}}},
+	} {
+		assert.Error(t, NewAgentOrchestrator("orchestrator").Configure(map[string]interface{}{"workflows": bad}), "%v", bad)
+	}
+}
+
+func TestAgentOrchestrator_PluginLifecycle(t *testing.T) {
+	orchestrator := NewAgentOrchestrator("orchestrator")
+	ctx := context.Background()
+	assert.Error(t, orchestrator.Health(ctx))
+
+	require.NoError(t, orchestrator.Start(ctx))
+	assert.Error(t, orchestrator.Start(ctx), "Expected a running orchestrator not to start again")
+	assert.Equal(t, core.PluginStatusRunning, orchestrator.Status())
+	assert.NoError(t, orchestrator.Health(ctx))
+
+	require.NoError(t, orchestrator.Stop())
+	assert.Error(t, orchestrator.Stop())
+	assert.Equal(t, core.PluginStatusStopped, orchestrator.Status())
+}
+
+func TestAgentOrchestrator_ExecuteWorkflow(t *testing.T) {
+	orchestrator := NewAgentOrchestrator("orchestrator")
+	querier := &stubQuerier{failures: map[string]int{"rag": 1}}
+	orchestrator.SetAgentQuerier(querier)
+	require.NoError(t, orchestrator.CreateWorkflow(&core.Workflow{ID: "restart-api", Steps: []core.WorkflowStep{
+		{ID: "diagnose", Name: "Diagnose", Config: map[string]interface{}{"agent": "rag", "query": "Why is the API failing?"}, RetryCount: 1},
+		{ID: "plan", Name: "Plan the restart", Config: map[string]interface{}{"agent": "ai"}},
+	}}))
+	assert.Error(t, orchestrator.CreateWorkflow(&core.Workflow{ID: "broken", Steps: []core.WorkflowStep{{ID: "diagnose"}}}))
+
+	result, err := orchestrator.ExecuteWorkflow(context.Background(), "restart-api", map[string]interface{}{"service": "api"})
+	require.NoError(t, err)
+	assert.Equal(t, "completed", result.Status)
+	assert.Equal(t, map[string]interface{}{"response": "answer from rag", "confidence": 0.9}, result.Output["diagnose"])
+	assert.Equal(t, []string{"rag: Why is the API failing?", "rag: Why is the API failing?", "ai: Plan the restart"}, querier.queries,
+		"Expected a failed step to be retried and steps without a query to ask their name")
+
+	status, err := orchestrator.GetWorkflowStatus("restart-api")
+	require.NoError(t, err)
+	assert.Equal(t, "completed", status.Status)
+	assert.Equal(t, 1.0, status.Progress)
+
+	orchestrator.AddWorkflow(&Workflow{ID: "escalate", Cooldown: -1, Steps: []WorkflowStep{{ID: "page", Agent: "pager"}}})
+	querier.failures["pager"] = 1
+	result, err = orchestrator.ExecuteWorkflow(context.Background(), "escalate", nil)
+	assert.Error(t, err)
+	assert.Equal(t, "failed", result.Status)
+	_, err = orchestrator.ExecuteWorkflow(context.Background(), "restart-api", nil)
+	assert.True(t, errors.Is(err, ErrTriggerSuppressed), "Expected the cool-down to apply to executed workflows")
+}
+
+func TestAgentOrchestrator_CancelWorkflow(t *testing.T) {
+	orchestrator := NewAgentOrchestrator("orchestrator")
+	orchestrator.RegisterAgent(&waitAgent{echoAgent{name: "wait"}})
+	orchestrator.AddWorkflow(&Workflow{ID: "drain", Steps: []WorkflowStep{{ID: "wait", Agent: "wait", Timeout: time.Minute}}})
+	ctx := context.Background()
+	assert.Error(t, orchestrator.CancelWorkflow("drain"), "Expected a workflow that is not running not to be cancelled")
+
+	require.NoError(t, orchestrator.Start(ctx))
+	require.NoError(t, orchestrator.StartWorkflow(ctx, "drain"))
+	require.Eventually(t, func() bool {
+		status, err := orchestrator.GetWorkflowStatus("drain")
+		return err == nil && status.CurrentStep == "wait"
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, orchestrator.CancelWorkflow("drain"))
+	waitForFinish(t, orchestrator, "drain")
+	status, err := orchestrator.GetWorkflowStatus("drain")
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", status.Status)
+	assert.Equal(t, 0.0, status.Progress)
+	require.NoError(t, orchestrator.Stop())
+}
@@ -0,0 +1,134 @@
+package agents
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// updateGolden rewrites the prompt snapshots: go test ./plugins/agents -run Prompt -update
+var updateGolden = flag.Bool("update", false, "rewrite golden prompt snapshots in testdata/prompts")
+
+// promptTime is the fixed time used for data in prompt snapshots
+var promptTime = time.Date(2026, 3, 2, 14, 3, 12, 0, time.UTC)
+
+// renderPrompt renders a chat request as reviewable text: the parameters, then each message
+func renderPrompt(request map[string]interface{}) string {
+	var b strings.Builder
+	var params []string
+	for key, value := range request {
+		if key != "messages" {
+			params = append(params, fmt.Sprintf("%s: %v", key, value))
+		}
+	}
+	sort.Strings(params)
+	for _, param := range params {
+		b.WriteString(param + "\n")
+	}
+	for _, message := range request["messages"].([]map[string]string) {
+		fmt.Fprintf(&b, "\n--- %s ---\n%s\n", message["role"], message["content"])
+	}
+	return b.String()
+}
+
+// assertGolden compares the rendered prompt with testdata/prompts/<name>.golden
+func assertGolden(t *testing.T, name string, request map[string]interface{}) {
+	t.Helper()
+	path := filepath.Join("testdata", "prompts", name+".golden")
+	actual := renderPrompt(request)
+
+	if *updateGolden {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(actual), 0644))
+	}
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err, "Missing snapshot; run go test ./plugins/agents -run Prompt -update")
+	assert.Equal(t, string(expected), actual, "Prompt changed; review it and run with -update if intended")
+}
+
+func promptMetadata() *core.MetricMetadataRegistry {
+	return core.NewMetricMetadataRegistry([]core.MetricMetadata{
+		{Name: "node_memory_used", Unit: "bytes", Description: "Memory in use on the node"},
+		{Name: "cpu_usage_percent", Unit: "percent"},
+	})
+}
+
+func TestAIAgent_PromptSnapshots(t *testing.T) {
+	tests := []struct {
+		name    string
+		context []core.DataPoint
+		query   string
+	}{
+		{
+			name:  "ai_no_context",
+			query: "Why is the error rate up?",
+		},
+		{
+			name: "ai_metrics_context",
+			context: []core.DataPoint{
+				{Timestamp: promptTime, Metric: "cpu_usage_percent", Value: 71, Source: "prometheus"},
+				{Timestamp: promptTime.Add(30 * time.Second), Metric: "cpu_usage_percent", Value: 93, Source: "prometheus"},
+				{Timestamp: promptTime, Metric: "node_memory_used", Value: 1288490188.8, Source: "prometheus"},
+				{Timestamp: promptTime, Metric: "http_requests_total", Value: 1520, Source: "prometheus"},
+			},
+			query: "What's causing the high CPU usage?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := NewAIAgent("ai")
+			agent.SetMetricMetadata(promptMetadata())
+			assertGolden(t, tt.name, agent.buildPrompt("gpt-4", tt.query, tt.context))
+		})
+	}
+}
+
+func TestRAGAgent_PromptSnapshots(t *testing.T) {
+	tests := []struct {
+		name  string
+		docs  []Document
+		query string
+	}{
+		{
+			name:  "rag_no_documents",
+			query: "How do I restart the orders service?",
+		},
+		{
+			name: "rag_documents",
+			docs: []Document{
+				{ID: "runbook-orders", Content: "Runbook: restart the orders service with kubectl rollout restart deploy/orders."},
+				{ID: "incident-42", Content: "Incident 42: orders latency spiked after a bad deploy; rolled back at 14:20."},
+			},
+			query: "How do I restart the orders service?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := NewRAGAgent("rag")
+			agent.SetMetricMetadata(promptMetadata())
+			context := agent.buildContextFromDocuments(tt.docs)
+			assertGolden(t, tt.name, agent.buildRAGPrompt("gpt-4", tt.query, context))
+		})
+	}
+
+	t.Run("rag_metric_document", func(t *testing.T) {
+		agent := NewRAGAgent("rag")
+		agent.SetMetricMetadata(promptMetadata())
+		doc := Document{Content: agent.formatMetricAsDocument(core.DataPoint{
+			Timestamp: promptTime, Metric: "node_memory_used", Value: 1288490188.8, Source: "prometheus",
+		})}
+		context := agent.buildContextFromDocuments([]Document{doc})
+		assertGolden(t, "rag_metric_document", agent.buildRAGPrompt("gpt-4", "Is memory on web-1 a problem?", context))
+	})
+}
@@ -4,8 +4,6 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"math"
-	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -16,9 +14,27 @@ import (
 // RAGAgent extends the AI agent with retrieval-augmented generation capabilities
 type RAGAgent struct {
 	*AIAgent
-	knowledgeBase map[string][]Document
-	embeddings    map[string][]float64
-	mu            sync.RWMutex
+	vectors ragVectorStore
+	// pending holds documents added since the last retrieval, which embeds them
+	pending  []Document
+	embedder *ragEmbedder
+	store    core.Store
+	mu       sync.RWMutex
+	// embedding serializes embedPending, so documents are embedded once
+	embedding sync.Mutex
+	// ingester loads knowledge base files while the agent runs; nil when there are none
+	ingester *ragIngester
+	// retention bounds the metric documents; nil keeps them all
+	retention *ragRetention
+	// retrieval answers ProcessQuery from the knowledge base; off, queries are answered
+	// like the AI agent's
+	retrieval bool
+	// hybrid finds documents by keyword too, through the keywords index; nil for vector only
+	hybrid   *ragHybrid
+	keywords *keywordIndex
+	// stopBackground stops ingestion and retention, which background waits for
+	stopBackground context.CancelFunc
+	background     sync.WaitGroup
 }
 
 // Document represents a piece of knowledge in the RAG system
@@ -40,30 +56,294 @@ type RAGConfig struct {
 func NewRAGAgent(name string) *RAGAgent {
 	baseAgent := NewAIAgent(name)
 	return &RAGAgent{
-		AIAgent:       baseAgent,
-		knowledgeBase: make(map[string][]Document),
-		embeddings:    make(map[string][]float64),
+		AIAgent:   baseAgent,
+		vectors:   newMemoryVectorStore(),
+		retrieval: true,
 	}
 }
 
-// AddDocument adds a document to the knowledge base
-func (r *RAGAgent) AddDocument(doc Document) {
+// Configure configures the AI agent, the embeddings documents are retrieved by, the
+// vector store they are kept in, the files they are ingested from, and how long metric
+// documents are kept
+func (r *RAGAgent) Configure(config map[string]interface{}) error {
+	if err := r.AIAgent.Configure(config); err != nil {
+		return err
+	}
+	embedder, err := parseRAGEmbeddings(config, r.currentSettings(), r.httpClient)
+	if err != nil {
+		return core.NewConfigurationError("rag-agent", "configure", err.Error())
+	}
+	ingester, err := parseRAGIngest(config)
+	if err != nil {
+		return core.NewConfigurationError("rag-agent", "configure", err.Error())
+	}
+	retention, err := parseRAGRetention(config)
+	if err != nil {
+		return core.NewConfigurationError("rag-agent", "configure", err.Error())
+	}
+	hybrid, err := parseRAGHybrid(config, r.httpClient)
+	if err != nil {
+		return core.NewConfigurationError("rag-agent", "configure", err.Error())
+	}
+	retrieval := true
+	if value, ok := config["retrieval"]; ok {
+		enabled, isBool := value.(bool)
+		if !isBool {
+			return core.NewConfigurationError("rag-agent", "configure", "retrieval must be true or false")
+		}
+		retrieval = enabled
+	}
+	vectors, err := openRAGVectorStore(config["vector_store"], r.name)
+	if err != nil {
+		return core.WrapError(err, core.ErrorTypeConfiguration, "rag-agent", "configure", "failed to open vector store")
+	}
+
 	r.mu.Lock()
-	defer r.mu.Unlock()
+	previous := r.vectors
+	// Documents kept in memory would be lost with the store
+	if _, inMemory := vectors.(*memoryVectorStore); inMemory {
+		if _, wasInMemory := previous.(*memoryVectorStore); wasInMemory {
+			vectors, previous = previous, nil
+		}
+	}
+	r.vectors = vectors
+	r.embedder = embedder
+	r.ingester = ingester
+	r.retention = retention
+	r.retrieval = retrieval
+	r.hybrid = hybrid
+	r.keywords = nil
+	if hybrid != nil {
+		r.keywords = newKeywordIndex()
+	}
+	r.mu.Unlock()
 
-	// Generate embedding for the document
-	embedding := r.generateEmbedding(doc.Content)
+	if previous != nil {
+		if err := previous.close(); err != nil {
+			slog.Warn("Failed to close vector store", "plugin", r.name, "type", "agent", "error", err)
+		}
+	}
+	return nil
+}
 
-	// Store document and embedding
-	category := r.categorizeDocument(doc)
-	r.knowledgeBase[category] = append(r.knowledgeBase[category], doc)
-	r.embeddings[doc.ID] = embedding
+// SetStore persists the knowledge base in the framework's store, restoring documents
+// this agent stored earlier. A persistent vector store keeps the documents itself.
+func (r *RAGAgent) SetStore(store core.Store) {
+	r.mu.Lock()
+	r.store = store
+	persistent := r.vectors.persistent()
+	r.mu.Unlock()
+	if persistent {
+		return
+	}
+
+	prefix := r.name + "/"
+	var restored []Document
+	err := core.ListJSON(context.Background(), store, core.StoreCollectionDocuments, func(key string, unmarshal func(v interface{}) error) error {
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		var doc Document
+		if err := unmarshal(&doc); err != nil {
+			return err
+		}
+		restored = append(restored, doc)
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to restore knowledge base", "plugin", r.name, "type", "agent", "error", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = append(r.pending, restored...)
+	if len(restored) > 0 {
+		slog.Info("Knowledge base restored", "plugin", r.name, "type", "agent", "documents", len(restored))
+	}
+}
+
+// AddDocument adds a document to the knowledge base. It is embedded and stored in the
+// vector store before the next retrieval.
+func (r *RAGAgent) AddDocument(doc Document) {
+	r.addDocuments([]Document{doc})
 
 	slog.Info("Document added to knowledge base",
 		"plugin", r.name,
 		"type", "agent",
 		"doc_id", doc.ID,
-		"category", category)
+		"category", r.categorizeDocument(doc))
+}
+
+// addDocuments queues documents to be embedded, saving them in the framework's store when
+// the vector store does not keep them
+func (r *RAGAgent) addDocuments(docs []Document) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pending = append(r.pending, docs...)
+	if r.store == nil || r.vectors.persistent() {
+		return
+	}
+	for _, doc := range docs {
+		if err := core.PutJSON(context.Background(), r.store, core.StoreCollectionDocuments, r.name+"/"+doc.ID, doc); err != nil {
+			slog.Error("Failed to persist document", "plugin", r.name, "type", "agent", "doc_id", doc.ID, "error", err)
+		}
+	}
+}
+
+// RemoveDocuments removes documents from the knowledge base
+func (r *RAGAgent) RemoveDocuments(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	removed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		removed[id] = true
+	}
+
+	r.mu.Lock()
+	pending := r.pending[:0]
+	for _, doc := range r.pending {
+		if !removed[doc.ID] {
+			pending = append(pending, doc)
+		}
+	}
+	r.pending = pending
+	vectors, store, keywords := r.vectors, r.store, r.keywords
+	r.mu.Unlock()
+
+	if keywords != nil {
+		keywords.remove(ids)
+	}
+	if store != nil && !vectors.persistent() {
+		for _, id := range ids {
+			if err := store.Delete(ctx, core.StoreCollectionDocuments, r.name+"/"+id); err != nil {
+				return err
+			}
+		}
+	}
+	return vectors.remove(ctx, ids)
+}
+
+// embedPending embeds the documents added since the last retrieval, and those embedded
+// with another model, without holding the lock while the embeddings API is called
+func (r *RAGAgent) embedPending(ctx context.Context) error {
+	r.embedding.Lock()
+	defer r.embedding.Unlock()
+
+	r.mu.RLock()
+	embedder, vectors := r.embedder, r.vectors
+	pending := append([]Document(nil), r.pending...)
+	r.mu.RUnlock()
+	if embedder == nil {
+		return core.NewConfigurationError("rag-agent", "embed", "embeddings are not configured")
+	}
+
+	if len(pending) > 0 {
+		if err := r.storeEmbedded(ctx, embedder, vectors, pending); err != nil {
+			return err
+		}
+		r.mu.Lock()
+		// Documents added meanwhile stay pending, as do all of them if the store changed
+		if r.vectors == vectors {
+			r.pending = r.pending[len(pending):]
+		}
+		r.mu.Unlock()
+	}
+
+	// Vectors of another model cannot be compared with the question's
+	for {
+		stale, err := vectors.stale(ctx, embedder.id(), embedder.batchSize)
+		if err != nil {
+			return err
+		}
+		if len(stale) == 0 {
+			return nil
+		}
+		if err := r.storeEmbedded(ctx, embedder, vectors, stale); err != nil {
+			return err
+		}
+	}
+}
+
+// storeEmbedded embeds documents and stores them in the vector store
+func (r *RAGAgent) storeEmbedded(ctx context.Context, embedder *ragEmbedder, vectors ragVectorStore, docs []Document) error {
+	contents := make([]string, len(docs))
+	for i, doc := range docs {
+		contents[i] = doc.Content
+	}
+	embedded, err := embedder.embed(ctx, contents)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]ragVector, len(docs))
+	for i, doc := range docs {
+		entries[i] = ragVector{document: doc, category: r.categorizeDocument(doc), model: embedder.id(), vector: embedded[i]}
+	}
+	if err := vectors.upsert(ctx, entries); err != nil {
+		return err
+	}
+	r.mu.RLock()
+	keywords := r.keywords
+	r.mu.RUnlock()
+	if keywords != nil {
+		keywords.add(docs)
+	}
+	return nil
+}
+
+// Start starts the agent, the ingestion of knowledge base files, and the retention of
+// metric documents
+func (r *RAGAgent) Start(ctx context.Context) error {
+	if err := r.AIAgent.Start(ctx); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	backgroundCtx, cancel := context.WithCancel(context.Background())
+	r.stopBackground = cancel
+	if ingester := r.ingester; ingester != nil {
+		r.background.Add(1)
+		go func() {
+			defer r.background.Done()
+			r.ingestLoop(backgroundCtx, ingester)
+		}()
+	}
+	if retention := r.retention; retention != nil {
+		r.background.Add(1)
+		go func() {
+			defer r.background.Done()
+			r.retentionLoop(backgroundCtx, retention)
+		}()
+	}
+	return nil
+}
+
+// Stop stops ingestion and retention, embeds the documents still pending so a persistent
+// vector store keeps them, and stops the agent
+func (r *RAGAgent) Stop() error {
+	r.mu.Lock()
+	cancel := r.stopBackground
+	r.stopBackground = nil
+	r.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		r.background.Wait()
+	}
+
+	r.mu.RLock()
+	waiting := len(r.pending) > 0 && r.vectors.persistent() && r.embedder != nil
+	r.mu.RUnlock()
+	if waiting {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := r.embedPending(ctx); err != nil {
+			slog.Error("Failed to store pending documents", "plugin", r.name, "type", "agent", "error", err)
+		}
+		cancel()
+	}
+	return r.AIAgent.Stop()
 }
 
 // AddMetricsData adds metrics data as documents to the knowledge base
@@ -73,7 +353,7 @@ func (r *RAGAgent) AddMetricsData(data []core.DataPoint) {
 			ID:      fmt.Sprintf("metric_%s_%d", point.Metric, point.Timestamp.Unix()),
 			Content: r.formatMetricAsDocument(point),
 			Metadata: map[string]interface{}{
-				"type":      "metric",
+				"type":      ragMetricType,
 				"metric":    point.Metric,
 				"value":     point.Value,
 				"timestamp": point.Timestamp,
@@ -85,79 +365,87 @@ func (r *RAGAgent) AddMetricsData(data []core.DataPoint) {
 	}
 }
 
-// ProcessQueryWithRAG processes a query using RAG
+// ProcessQuery answers a query from the knowledge base documents most relevant to it, or
+// like the AI agent when retrieval is off
+func (r *RAGAgent) ProcessQuery(ctx context.Context, query string) (*core.AgentResponse, error) {
+	return r.ProcessQueryStream(ctx, query, nil)
+}
+
+// ProcessQueryStream answers a query like ProcessQuery, passing the answer to onChunk as
+// the provider generates it
+func (r *RAGAgent) ProcessQueryStream(ctx context.Context, query string, onChunk func(chunk string) error) (*core.AgentResponse, error) {
+	r.mu.RLock()
+	retrieval := r.retrieval
+	r.mu.RUnlock()
+	if !retrieval {
+		return r.AIAgent.ProcessQueryStream(ctx, query, onChunk)
+	}
+	return r.answerWithRAG(ctx, query, onChunk)
+}
+
+// ProcessQueryWithRAG processes a query using RAG, whether or not retrieval is on
 func (r *RAGAgent) ProcessQueryWithRAG(ctx context.Context, query string) (*core.AgentResponse, error) {
-	if r.status != core.PluginStatusRunning {
+	return r.answerWithRAG(ctx, query, nil)
+}
+
+// answerWithRAG answers a query with the documents relevant to it as the context. The
+// answer is cached, budgeted, and streamed like the AI agent's.
+func (r *RAGAgent) answerWithRAG(ctx context.Context, query string, onChunk func(chunk string) error) (*core.AgentResponse, error) {
+	if r.Status() != core.PluginStatusRunning {
 		return nil, core.NewPluginError("rag-agent", "process-query", "agent is not running")
 	}
 
 	// Retrieve relevant documents
-	relevantDocs := r.retrieveRelevantDocuments(query, 5)
-
-	// Build context from retrieved documents
-	contextInfo := r.buildContextFromDocuments(relevantDocs)
-
-	// Create enhanced prompt with retrieved context
-	enhancedPrompt := r.buildRAGPrompt(query, contextInfo)
-
-	// Call AI API with enhanced context
-	response, err := r.callAIAPI(enhancedPrompt)
+	relevantDocs, err := r.retrieveRelevantDocuments(ctx, query, 5)
 	if err != nil {
-		return nil, core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "ai-api-call", "AI API call failed")
+		return nil, err
 	}
 
-	// Convert response to AgentResponse
-	agentResponse := r.convertResponseToAgentResponse(response, query)
-
-	// Add RAG metadata
-	agentResponse.Metadata["rag_documents_used"] = len(relevantDocs)
-	agentResponse.Metadata["rag_sources"] = r.extractSources(relevantDocs)
+	// Build context from retrieved documents
+	contextInfo := r.buildContextFromDocuments(relevantDocs)
 
-	return agentResponse, nil
+	return r.answer(ctx, aiQuery{
+		text: query,
+		// Create enhanced prompt with retrieved context
+		prompt: func(model string) map[string]interface{} {
+			return r.buildRAGPrompt(model, query, contextInfo)
+		},
+		metadata: map[string]interface{}{
+			"rag_documents_used": len(relevantDocs),
+			"rag_sources":        r.extractSources(relevantDocs),
+		},
+	}, onChunk)
 }
 
 // retrieveRelevantDocuments finds documents relevant to the query
-func (r *RAGAgent) retrieveRelevantDocuments(query string, maxDocs int) []Document {
+func (r *RAGAgent) retrieveRelevantDocuments(ctx context.Context, query string, maxDocs int) ([]Document, error) {
+	if err := r.embedPending(ctx); err != nil {
+		return nil, err
+	}
 	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	// Generate embedding for the query
-	queryEmbedding := r.generateEmbedding(query)
-
-	var scoredDocs []ScoredDocument
-
-	// Search through all documents
-	for category, docs := range r.knowledgeBase {
-		for _, doc := range docs {
-			if embedding, exists := r.embeddings[doc.ID]; exists {
-				similarity := r.cosineSimilarity(queryEmbedding, embedding)
-				if similarity > 0.3 { // Threshold for relevance
-					scoredDocs = append(scoredDocs, ScoredDocument{
-						Document: doc,
-						Score:    similarity,
-						Category: category,
-					})
-				}
-			}
-		}
+	embedder, vectors, hybrid, keywords := r.embedder, r.vectors, r.hybrid, r.keywords
+	r.mu.RUnlock()
+	embedded, err := embedder.embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
 	}
 
-	// Sort by similarity score
-	sort.Slice(scoredDocs, func(i, j int) bool {
-		return scoredDocs[i].Score > scoredDocs[j].Score
-	})
-
-	// Return top documents
-	if len(scoredDocs) > maxDocs {
-		scoredDocs = scoredDocs[:maxDocs]
+	limit := maxDocs
+	if hybrid != nil {
+		limit = hybrid.candidates
+	}
+	scored, err := vectors.search(ctx, embedder.id(), embedded[0], limit, 0.3) // Threshold for relevance
+	if err != nil {
+		return nil, err
+	}
+	if hybrid != nil {
+		return r.retrieveHybrid(ctx, hybrid, keywords, vectors, query, scored, maxDocs)
 	}
-
 	var result []Document
-	for _, scored := range scoredDocs {
-		result = append(result, scored.Document)
+	for _, match := range scored {
+		result = append(result, match.Document)
 	}
-
-	return result
+	return result, nil
 }
 
 // ScoredDocument represents a document with its relevance score
@@ -167,46 +455,8 @@ type ScoredDocument struct {
 	Category string
 }
 
-// generateEmbedding creates a simple embedding for text (in production, use OpenAI embeddings)
-func (r *RAGAgent) generateEmbedding(text string) []float64 {
-	// Simple word-based embedding (in production, use OpenAI's embedding API)
-	words := strings.Fields(strings.ToLower(text))
-	embedding := make([]float64, 100) // 100-dimensional embedding
-
-	for i, word := range words {
-		if i >= 100 {
-			break
-		}
-		// Simple hash-based embedding
-		hash := 0
-		for _, char := range word {
-			hash = hash*31 + int(char)
-		}
-		embedding[i] = float64(hash%1000) / 1000.0
-	}
-
-	return embedding
-}
-
-// cosineSimilarity calculates cosine similarity between two embeddings
-func (r *RAGAgent) cosineSimilarity(a, b []float64) float64 {
-	if len(a) != len(b) {
-		return 0
-	}
-
-	var dotProduct, normA, normB float64
-	for i := range a {
-		dotProduct += a[i] * b[i]
-		normA += a[i] * a[i]
-		normB += b[i] * b[i]
-	}
-
-	if normA == 0 || normB == 0 {
-		return 0
-	}
-
-	return dotProduct / (math.Sqrt(normA) * math.Sqrt(normB))
-}
+// ragCategories are the categories categorizeDocument files documents under
+var ragCategories = []string{"system_metrics", "errors", "application_metrics", "network_metrics", "general"}
 
 // categorizeDocument determines the category of a document
 func (r *RAGAgent) categorizeDocument(doc Document) string {
@@ -230,8 +480,8 @@ func (r *RAGAgent) categorizeDocument(doc Document) string {
 
 // formatMetricAsDocument formats a metric as a document
 func (r *RAGAgent) formatMetricAsDocument(point core.DataPoint) string {
-	return fmt.Sprintf("Metric: %s, Value: %.2f, Source: %s, Timestamp: %s",
-		point.Metric, point.Value, point.Source, point.Timestamp.Format(time.RFC3339))
+	return fmt.Sprintf("Metric: %s, Value: %s, Source: %s, Timestamp: %s",
+		point.Metric, r.metadata.FormatValue(point.Metric, point.Value), point.Source, point.Timestamp.Format(time.RFC3339))
 }
 
 // buildContextFromDocuments builds context string from retrieved documents
@@ -249,7 +499,7 @@ func (r *RAGAgent) buildContextFromDocuments(docs []Document) string {
 }
 
 // buildRAGPrompt creates a prompt with retrieved context
-func (r *RAGAgent) buildRAGPrompt(query, context string) map[string]interface{} {
+func (r *RAGAgent) buildRAGPrompt(model, query, context string) map[string]interface{} {
 	systemPrompt := `You are an observability expert AI agent with access to real-time system data. 
 You have been provided with relevant context from the system's knowledge base.
 
@@ -262,10 +512,12 @@ Instructions:
 - Provide specific details from the context when available
 - Maintain a helpful, technical tone
 
-User Query: ` + query
+User Query: ` + query + `
+
+` + actionInstructions
 
 	return map[string]interface{}{
-		"model": r.model,
+		"model": model,
 		"messages": []map[string]string{
 			{
 				"role":    "system",
@@ -295,23 +547,27 @@ func (r *RAGAgent) extractSources(docs []Document) []string {
 // GetKnowledgeBaseStats returns statistics about the knowledge base
 func (r *RAGAgent) GetKnowledgeBaseStats() map[string]interface{} {
 	r.mu.RLock()
-	defer r.mu.RUnlock()
+	vectors := r.vectors
+	pending := append([]Document(nil), r.pending...)
+	r.mu.RUnlock()
 
-	stats := map[string]interface{}{
-		"total_documents": 0,
-		"categories":      make(map[string]int),
+	categories, err := vectors.counts(context.Background())
+	if err != nil {
+		slog.Error("Failed to count documents", "plugin", r.name, "type", "agent", "error", err)
+		categories = make(map[string]int)
+	}
+	for _, doc := range pending {
+		categories[r.categorizeDocument(doc)]++
 	}
 
 	totalDocs := 0
-	categories := make(map[string]int)
-
-	for category, docs := range r.knowledgeBase {
-		categories[category] = len(docs)
-		totalDocs += len(docs)
+	for _, count := range categories {
+		totalDocs += count
 	}
 
-	stats["total_documents"] = totalDocs
-	stats["categories"] = categories
-
-	return stats
+	return map[string]interface{}{
+		"total_documents":   totalDocs,
+		"categories":        categories,
+		"pending_documents": len(pending),
+	}
 }
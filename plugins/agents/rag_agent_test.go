@@ -0,0 +1,63 @@
+package agents
+
+import (
+	"context"
+	"testing"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRAGAgent_ProcessQueryRetrieves(t *testing.T) {
+	server, batches := newEmbeddingServer(t)
+	rag := NewRAGAgent("rag")
+	var agent core.AgentPlugin = rag
+	require.NoError(t, agent.Configure(map[string]interface{}{
+		"api_key":            "key",
+		"api_url":            server.URL + "/v1/chat/completions",
+		"response_cache_ttl": "1m",
+	}))
+	ctx := context.Background()
+	require.NoError(t, agent.Start(ctx))
+	defer agent.Stop()
+	rag.AddDocument(Document{ID: "runbook-orders", Content: "Restart the orders service with kubectl.", Metadata: map[string]interface{}{"source": "runbooks"}})
+
+	response, err := agent.ProcessQuery(ctx, "How do I restart orders?")
+	require.NoError(t, err)
+	assert.Equal(t, "Restart it.", response.Response)
+	assert.Equal(t, 1, response.Metadata["rag_documents_used"])
+	assert.Equal(t, []string{"runbooks"}, response.Metadata["rag_sources"])
+	assert.Len(t, batches(), 2, "Expected the document and the question to be embedded")
+
+	cached, err := agent.ProcessQuery(ctx, "How do I restart orders?")
+	require.NoError(t, err)
+	assert.Equal(t, true, cached.Metadata["cached"])
+	assert.Equal(t, 1, cached.Metadata["rag_documents_used"])
+}
+
+func TestRAGAgent_ProcessQueryWithoutRetrieval(t *testing.T) {
+	server, batches := newEmbeddingServer(t)
+	agent := NewRAGAgent("rag")
+	require.NoError(t, agent.Configure(map[string]interface{}{
+		"api_key":   "key",
+		"api_url":   server.URL + "/v1/chat/completions",
+		"retrieval": false,
+	}))
+	ctx := context.Background()
+	require.NoError(t, agent.Start(ctx))
+	defer agent.Stop()
+	agent.AddDocument(Document{ID: "runbook-orders", Content: "Restart the orders service with kubectl."})
+
+	response, err := agent.ProcessQuery(ctx, "How do I restart orders?")
+	require.NoError(t, err)
+	assert.Equal(t, "Restart it.", response.Response)
+	assert.NotContains(t, response.Metadata, "rag_documents_used")
+	assert.Empty(t, batches(), "Expected nothing to be embedded")
+
+	response, err = agent.ProcessQueryWithRAG(ctx, "How do I restart orders?")
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.Metadata["rag_documents_used"], "Expected ProcessQueryWithRAG to retrieve regardless")
+
+	assert.Error(t, agent.Configure(map[string]interface{}{"api_key": "key", "retrieval": "yes"}))
+}
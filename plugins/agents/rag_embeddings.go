@@ -0,0 +1,291 @@
+package agents
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// Embedding providers selectable in the RAG agent's embeddings config
+const (
+	EmbeddingProviderOpenAI = "openai"
+	// EmbeddingProviderSentenceTransformers is a local server running a sentence-transformers
+	// model behind the text-embeddings-inference API
+	EmbeddingProviderSentenceTransformers = "sentence_transformers"
+)
+
+const (
+	defaultEmbeddingURL       = "https://api.openai.com/v1/embeddings"
+	defaultEmbeddingModel     = "text-embedding-3-small"
+	defaultEmbeddingBatchSize = 64
+	defaultEmbeddingCacheSize = 10000
+	// defaultSentenceTransformersURL is where text-embeddings-inference listens by default
+	defaultSentenceTransformersURL   = "http://localhost:8080"
+	defaultSentenceTransformersModel = "all-MiniLM-L6-v2"
+)
+
+// embeddingProvider is the request and response format of an embeddings API
+type embeddingProvider interface {
+	name() string
+	// endpoint returns the URL embeddings are requested from
+	endpoint(apiURL string) string
+	body(model string, texts []string) interface{}
+	// vectors extracts one vector per text, in the order the texts were sent
+	vectors(body []byte, count int) ([][]float64, error)
+	failure(status int, body []byte) error
+}
+
+// ragEmbedder turns documents and questions into vectors, sending texts in batches and
+// caching vectors by the hash of their text, so unchanged documents are embedded once
+type ragEmbedder struct {
+	provider  embeddingProvider
+	apiURL    string
+	apiKey    string
+	model     string
+	batchSize int
+	cache     core.Cache
+	retrier   core.RetryExecutor
+	client    *http.Client
+}
+
+// parseRAGEmbeddings reads the embeddings setting, and embedding_model as a shorthand for
+// its model. Without the setting, an agent using OpenAI or an OpenAI-compatible server
+// embeds through the same API with text-embedding-3-small; other providers have no
+// embeddings API of their own, so they need it.
+func parseRAGEmbeddings(config map[string]interface{}, settings *aiSettings, client *http.Client) (*ragEmbedder, error) {
+	section, ok := config["embeddings"].(map[string]interface{})
+	if !ok && config["embeddings"] != nil {
+		return nil, fmt.Errorf("embeddings must be a map")
+	}
+
+	providerName, _ := section["provider"].(string)
+	if providerName == "" {
+		providerName = EmbeddingProviderOpenAI
+	}
+	embedder := &ragEmbedder{batchSize: defaultEmbeddingBatchSize, client: client}
+	switch providerName {
+	case EmbeddingProviderOpenAI:
+		embedder.provider = openAIEmbeddings{}
+		embedder.model = defaultEmbeddingModel
+		embedder.apiURL = defaultEmbeddingURL
+		switch settings.provider.name() {
+		case AIProviderOpenAI:
+			embedder.apiURL = strings.TrimSuffix(settings.apiURL, "/chat/completions") + "/embeddings"
+			embedder.apiKey = settings.apiKey
+		case AIProviderCompatible:
+			embedder.apiURL = strings.TrimSuffix(settings.apiURL, "/") + "/embeddings"
+			embedder.apiKey = settings.apiKey
+		default:
+			if section == nil {
+				return nil, fmt.Errorf("provider %s has no embeddings API; configure embeddings", settings.provider.name())
+			}
+		}
+	case EmbeddingProviderSentenceTransformers:
+		embedder.provider = sentenceTransformersEmbeddings{}
+		embedder.model = defaultSentenceTransformersModel
+		embedder.apiURL = defaultSentenceTransformersURL
+	default:
+		return nil, fmt.Errorf("unknown embeddings provider %q", providerName)
+	}
+
+	if model, ok := config["embedding_model"].(string); ok && model != "" {
+		embedder.model = model
+	}
+	for key, target := range map[string]*string{"model": &embedder.model, "api_url": &embedder.apiURL, "api_key": &embedder.apiKey} {
+		if raw, ok := section[key]; ok {
+			text, isString := raw.(string)
+			if !isString {
+				return nil, fmt.Errorf("embeddings.%s must be a string", key)
+			}
+			if text != "" {
+				*target = text
+			}
+		}
+	}
+	if providerName == EmbeddingProviderOpenAI && embedder.apiKey == "" && settings.provider.name() != AIProviderCompatible {
+		return nil, fmt.Errorf("embeddings.api_key is required for provider %s", providerName)
+	}
+
+	cacheSize := defaultEmbeddingCacheSize
+	for key, target := range map[string]*int{"batch_size": &embedder.batchSize, "cache_size": &cacheSize} {
+		switch raw := section[key].(type) {
+		case nil:
+		case int:
+			*target = raw
+		case float64:
+			*target = int(raw)
+		default:
+			return nil, fmt.Errorf("embeddings.%s must be a number", key)
+		}
+		if *target < 1 {
+			return nil, fmt.Errorf("embeddings.%s must be at least 1", key)
+		}
+	}
+	embedder.cache = core.NewLRUCache(cacheSize, 0)
+
+	retryPolicy, err := core.ParseRetryPolicy(section["retry"], defaultAIRetryPolicy)
+	if err != nil {
+		return nil, err
+	}
+	embedder.retrier = core.NewBackoffRetryExecutor(retryPolicy)
+	return embedder, nil
+}
+
+// id identifies the provider and model; vectors of different models cannot be compared
+func (e *ragEmbedder) id() string {
+	return e.provider.name() + "/" + e.model
+}
+
+// cacheKey identifies a text's vector
+func (e *ragEmbedder) cacheKey(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return e.id() + "/" + hex.EncodeToString(sum[:])
+}
+
+// embed returns a vector for each text, requesting the ones not cached in batches
+func (e *ragEmbedder) embed(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	var missing []int
+	for i, text := range texts {
+		if cached, ok := e.cache.Get(e.cacheKey(text)); ok {
+			vectors[i] = cached.([]float64)
+		} else {
+			missing = append(missing, i)
+		}
+	}
+
+	for start := 0; start < len(missing); start += e.batchSize {
+		end := start + e.batchSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+		batch := make([]string, 0, end-start)
+		for _, i := range missing[start:end] {
+			batch = append(batch, texts[i])
+		}
+
+		var embedded [][]float64
+		err := e.retrier.Execute(ctx, func() (err error) {
+			embedded, err = e.request(ctx, batch)
+			return err
+		})
+		if err != nil {
+			return nil, core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "embed", "embedding request failed")
+		}
+		for j, i := range missing[start:end] {
+			vectors[i] = embedded[j]
+			_ = e.cache.Set(e.cacheKey(texts[i]), embedded[j], 0)
+		}
+	}
+	return vectors, nil
+}
+
+// request embeds one batch of texts
+func (e *ragEmbedder) request(ctx context.Context, texts []string) ([][]float64, error) {
+	jsonData, err := json.Marshal(e.provider.body(e.model, texts))
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", e.provider.endpoint(e.apiURL), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	authorizeIfKeyed(req.Header, e.apiKey)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, withRetryAfter(e.provider.failure(resp.StatusCode, body), resp.Header)
+	}
+	return e.provider.vectors(body, len(texts))
+}
+
+// openAIEmbeddings calls the OpenAI embeddings API, which OpenAI-compatible servers offer too
+type openAIEmbeddings struct{}
+
+func (openAIEmbeddings) name() string { return EmbeddingProviderOpenAI }
+
+func (openAIEmbeddings) endpoint(apiURL string) string {
+	return apiURL
+}
+
+func (openAIEmbeddings) body(model string, texts []string) interface{} {
+	return map[string]interface{}{"model": model, "input": texts}
+}
+
+func (openAIEmbeddings) vectors(body []byte, count int) ([][]float64, error) {
+	var response struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Data) != count {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", count, len(response.Data))
+	}
+	sort.Slice(response.Data, func(i, j int) bool { return response.Data[i].Index < response.Data[j].Index })
+	vectors := make([][]float64, count)
+	for i, data := range response.Data {
+		vectors[i] = data.Embedding
+	}
+	return vectors, nil
+}
+
+func (p openAIEmbeddings) failure(status int, body []byte) error {
+	return openAIFailure(p.name(), status, body)
+}
+
+// sentenceTransformersEmbeddings calls a text-embeddings-inference server, which serves the
+// one model it was started with
+type sentenceTransformersEmbeddings struct{}
+
+func (sentenceTransformersEmbeddings) name() string { return EmbeddingProviderSentenceTransformers }
+
+func (sentenceTransformersEmbeddings) endpoint(apiURL string) string {
+	return strings.TrimSuffix(apiURL, "/") + "/embed"
+}
+
+func (sentenceTransformersEmbeddings) body(model string, texts []string) interface{} {
+	return map[string]interface{}{"inputs": texts}
+}
+
+func (sentenceTransformersEmbeddings) vectors(body []byte, count int) ([][]float64, error) {
+	var vectors [][]float64
+	if err := json.Unmarshal(body, &vectors); err != nil {
+		return nil, err
+	}
+	if len(vectors) != count {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", count, len(vectors))
+	}
+	return vectors, nil
+}
+
+func (p sentenceTransformersEmbeddings) failure(status int, body []byte) error {
+	var response struct {
+		Error     string `json:"error"`
+		ErrorType string `json:"error_type"`
+	}
+	_ = json.Unmarshal(body, &response)
+	return &AIProviderError{Provider: p.name(), StatusCode: status, Type: response.ErrorType, Message: response.Error}
+}
@@ -0,0 +1,174 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// topicVector embeds a text as a vector of the topics it mentions
+func topicVector(text string) []float64 {
+	vector := make([]float64, 3)
+	for i, topic := range []string{"orders", "payments", "disk"} {
+		if strings.Contains(strings.ToLower(text), topic) {
+			vector[i] = 1
+		}
+	}
+	return vector
+}
+
+// newEmbeddingServer serves chat completions and OpenAI embeddings, recording the texts of
+// each embeddings request
+func newEmbeddingServer(t *testing.T) (*httptest.Server, func() [][]string) {
+	var batches [][]string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/chat/completions" {
+			io.WriteString(w, `{"choices": [{"message": {"content": "Restart it."}}]}`)
+			return
+		}
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		var request struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, defaultEmbeddingModel, request.Model)
+		mu.Lock()
+		batches = append(batches, request.Input)
+		mu.Unlock()
+
+		// The API may return the vectors in any order; their index says which text they are for
+		var data []string
+		for i := len(request.Input) - 1; i >= 0; i-- {
+			vector, _ := json.Marshal(topicVector(request.Input[i]))
+			data = append(data, fmt.Sprintf(`{"index": %d, "embedding": %s}`, i, vector))
+		}
+		fmt.Fprintf(w, `{"data": [%s]}`, strings.Join(data, ","))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() [][]string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([][]string(nil), batches...)
+	}
+}
+
+func TestRAGAgent_OpenAIEmbeddings(t *testing.T) {
+	server, batches := newEmbeddingServer(t)
+	agent := NewRAGAgent("rag")
+	require.NoError(t, agent.Configure(map[string]interface{}{
+		"api_key":    "key",
+		"api_url":    server.URL + "/v1/chat/completions",
+		"embeddings": map[string]interface{}{"batch_size": 2},
+	}))
+	ctx := context.Background()
+	require.NoError(t, agent.Start(ctx))
+
+	agent.AddDocument(Document{ID: "runbook-orders", Content: "Restart the orders service with kubectl.", Metadata: map[string]interface{}{"source": "runbooks"}})
+	agent.AddDocument(Document{ID: "runbook-payments", Content: "Payments retries are safe.", Metadata: map[string]interface{}{"source": "runbooks"}})
+	agent.AddDocument(Document{ID: "disk-alert", Content: "Disk alerts fire at 90%.", Metadata: map[string]interface{}{"source": "alerts"}})
+	assert.Empty(t, batches(), "Expected documents to be embedded when they are first needed")
+
+	response, err := agent.ProcessQueryWithRAG(ctx, "How do I restart orders?")
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.Metadata["rag_documents_used"])
+	assert.Equal(t, []string{"runbooks"}, response.Metadata["rag_sources"])
+	embedded := batches()
+	require.Len(t, embedded, 3, "Expected two batches of documents and one for the question")
+	assert.Len(t, embedded[0], 2)
+	assert.Len(t, embedded[1], 1)
+	assert.Equal(t, []string{"How do I restart orders?"}, embedded[2])
+
+	// Documents and questions seen before come from the cache
+	agent.AddDocument(Document{ID: "runbook-orders-copy", Content: "Restart the orders service with kubectl."})
+	_, err = agent.ProcessQueryWithRAG(ctx, "How do I restart orders?")
+	require.NoError(t, err)
+	assert.Len(t, batches(), 3, "Expected no embeddings requests for cached texts")
+}
+
+func TestRAGAgent_SentenceTransformersEmbeddings(t *testing.T) {
+	var attempts int
+	embeddings := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/embed", r.URL.Path)
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			io.WriteString(w, `{"error": "model is loading", "error_type": "overloaded"}`)
+			return
+		}
+		var request struct {
+			Inputs []string `json:"inputs"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		vectors := make([][]float64, len(request.Inputs))
+		for i, input := range request.Inputs {
+			vectors[i] = topicVector(input)
+		}
+		json.NewEncoder(w).Encode(vectors)
+	}))
+	t.Cleanup(embeddings.Close)
+
+	agent := NewRAGAgent("rag")
+	require.NoError(t, agent.Configure(map[string]interface{}{
+		"provider": "anthropic",
+		"api_key":  "key",
+		"embeddings": map[string]interface{}{
+			"provider": EmbeddingProviderSentenceTransformers,
+			"api_url":  embeddings.URL,
+			"retry":    map[string]interface{}{"initial_delay": "1ms"},
+		},
+	}))
+	agent.AddDocument(Document{ID: "disk-alert", Content: "Disk alerts fire at 90%."})
+	agent.AddDocument(Document{ID: "runbook-orders", Content: "Restart the orders service with kubectl."})
+
+	docs, err := agent.retrieveRelevantDocuments(context.Background(), "Is the disk full?", 5)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "disk-alert", docs[0].ID)
+	assert.Equal(t, 3, attempts, "Expected the unavailable server to be retried")
+}
+
+func TestParseRAGEmbeddings(t *testing.T) {
+	agent := NewRAGAgent("rag")
+	err := agent.Configure(map[string]interface{}{"provider": "anthropic", "api_key": "key"})
+	assert.Error(t, err, "Expected a provider without an embeddings API to need the embeddings setting")
+
+	err = agent.Configure(map[string]interface{}{
+		"provider":   "anthropic",
+		"api_key":    "key",
+		"embeddings": map[string]interface{}{"provider": "openai"},
+	})
+	assert.Error(t, err, "Expected OpenAI embeddings to need their own key")
+
+	for _, embeddings := range []map[string]interface{}{
+		{"provider": "word2vec"},
+		{"batch_size": 0},
+		{"cache_size": "big"},
+		{"model": 3},
+	} {
+		err = agent.Configure(map[string]interface{}{"api_key": "key", "embeddings": embeddings})
+		assert.Error(t, err, "embeddings %v", embeddings)
+	}
+
+	require.NoError(t, agent.Configure(map[string]interface{}{"api_key": "key"}))
+	assert.Equal(t, "https://api.openai.com/v1/embeddings", agent.embedder.apiURL)
+	assert.Equal(t, "key", agent.embedder.apiKey)
+	assert.Equal(t, defaultEmbeddingModel, agent.embedder.model)
+
+	require.NoError(t, agent.Configure(map[string]interface{}{"api_key": "key", "embedding_model": "text-embedding-3-large"}))
+	assert.Equal(t, "text-embedding-3-large", agent.embedder.model)
+
+	require.NoError(t, agent.Configure(map[string]interface{}{"provider": "openai_compatible", "api_url": "http://vllm:8000/v1", "model": "llama3"}))
+	assert.Equal(t, "http://vllm:8000/v1/embeddings", agent.embedder.apiURL)
+}
@@ -0,0 +1,377 @@
+package agents
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// Formats of the files the knowledge base ingests, by extension
+var ragDocumentFormats = map[string]string{
+	".md":       "markdown",
+	".markdown": "markdown",
+	".html":     "html",
+	".htm":      "html",
+	".pdf":      "pdf",
+}
+
+// ragDocumentFormat returns the format of a file, or "" for files that are not ingested
+func ragDocumentFormat(path string) string {
+	return ragDocumentFormats[strings.ToLower(filepath.Ext(path))]
+}
+
+// extractDocumentText returns the text of a Markdown, HTML, or PDF file and its title when
+// it has one
+func extractDocumentText(format string, data []byte) (text, title string, err error) {
+	switch format {
+	case "markdown":
+		text, title = extractMarkdown(string(data))
+	case "html":
+		text, title, err = extractHTML(data)
+	case "pdf":
+		text, err = extractPDF(data)
+	default:
+		return "", "", fmt.Errorf("unsupported format %q", format)
+	}
+	return text, title, err
+}
+
+// extractMarkdown drops YAML front matter, keeping the Markdown as it reads well to a model;
+// the title is the first top-level heading
+func extractMarkdown(text string) (string, string) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if strings.HasPrefix(text, "---\n") {
+		if end := strings.Index(text[4:], "\n---\n"); end >= 0 {
+			text = text[4+end+5:]
+		}
+	}
+	var title string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "# ") {
+			title = strings.TrimSpace(line[2:])
+			break
+		}
+	}
+	return text, title
+}
+
+// htmlBlocks are elements that start a new line of text
+var htmlBlocks = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "pre": true, "section": true, "article": true,
+	"table": true, "ul": true, "ol": true, "blockquote": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "header": true, "footer": true, "dt": true, "dd": true,
+}
+
+// extractHTML returns the visible text of a page, one line per block; the title is the
+// page title or else the first h1
+func extractHTML(data []byte) (string, string, error) {
+	root, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return "", "", err
+	}
+
+	var text strings.Builder
+	var title, heading string
+	var walk func(node *html.Node, pre bool)
+	walk = func(node *html.Node, pre bool) {
+		if node.Type == html.ElementNode {
+			switch node.Data {
+			case "script", "style", "noscript", "template":
+				return
+			case "title":
+				if node.FirstChild != nil {
+					title = strings.TrimSpace(node.FirstChild.Data)
+				}
+				return
+			case "pre":
+				pre = true
+			case "h1":
+				if heading == "" {
+					heading = strings.TrimSpace(htmlNodeText(node))
+				}
+			}
+			if htmlBlocks[node.Data] {
+				text.WriteString("\n")
+			}
+		}
+		if node.Type == html.TextNode {
+			if pre {
+				text.WriteString(node.Data)
+			} else if words := strings.Fields(node.Data); len(words) > 0 {
+				if unicode.IsSpace(rune(node.Data[0])) {
+					text.WriteString(" ")
+				}
+				text.WriteString(strings.Join(words, " "))
+				if unicode.IsSpace(rune(node.Data[len(node.Data)-1])) {
+					text.WriteString(" ")
+				}
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child, pre)
+		}
+		if node.Type == html.ElementNode && htmlBlocks[node.Data] {
+			text.WriteString("\n")
+		}
+	}
+	walk(root, false)
+
+	if title == "" {
+		title = heading
+	}
+	return tidyLines(text.String()), title, nil
+}
+
+// htmlNodeText returns the text inside an element
+func htmlNodeText(node *html.Node) string {
+	var text strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextNode {
+			text.WriteString(child.Data)
+		} else {
+			text.WriteString(htmlNodeText(child))
+		}
+	}
+	return text.String()
+}
+
+// tidyLines trims each line and keeps at most one blank line between paragraphs
+func tidyLines(text string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if strings.TrimSpace(line) == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// extractPDF returns the text drawn by a PDF's content streams. It reads uncompressed and
+// Flate-compressed streams and text in simple fonts; scanned pages and text in composite
+// fonts are not extracted.
+func extractPDF(data []byte) (string, error) {
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return "", fmt.Errorf("not a PDF file")
+	}
+
+	var text strings.Builder
+	rest := data
+	for {
+		start := bytes.Index(rest, []byte("stream"))
+		if start < 0 {
+			break
+		}
+		// The stream's dictionary follows the obj keyword before it
+		dictionary := rest[:start]
+		if obj := bytes.LastIndex(dictionary, []byte(" obj")); obj >= 0 {
+			dictionary = dictionary[obj:]
+		}
+		body := rest[start+len("stream"):]
+		body = bytes.TrimPrefix(body, []byte("\r"))
+		body = bytes.TrimPrefix(body, []byte("\n"))
+		end := bytes.Index(body, []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		rest = body[end+len("endstream"):]
+		if bytes.HasSuffix(dictionary, []byte("end")) {
+			continue // Matched the end of the previous stream
+		}
+		if bytes.Contains(dictionary, []byte("/Image")) || bytes.Contains(dictionary, []byte("/ObjStm")) || bytes.Contains(dictionary, []byte("/XRef")) {
+			continue
+		}
+
+		content := body[:end]
+		if bytes.Contains(dictionary, []byte("/Filter")) {
+			if !bytes.Contains(dictionary, []byte("/FlateDecode")) {
+				continue
+			}
+			reader, err := zlib.NewReader(bytes.NewReader(content))
+			if err != nil {
+				continue
+			}
+			inflated, err := io.ReadAll(reader)
+			if err != nil && len(inflated) == 0 {
+				continue
+			}
+			content = inflated
+		}
+		text.WriteString(pdfContentText(content))
+	}
+
+	extracted := tidyLines(text.String())
+	if strings.TrimSpace(extracted) == "" {
+		return "", fmt.Errorf("no text found in PDF")
+	}
+	return extracted, nil
+}
+
+// pdfContentText returns the text the operators of a content stream show
+func pdfContentText(content []byte) string {
+	var text strings.Builder
+	var shown []string
+	var numbers []float64
+	inArray := false
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			s, n := pdfLiteralString(content[i:])
+			shown = append(shown, s)
+			i += n
+		case c == '<' && i+1 < len(content) && content[i+1] == '<':
+			i += 2
+		case c == '>' && i+1 < len(content) && content[i+1] == '>':
+			i += 2
+		case c == '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				return text.String()
+			}
+			shown = append(shown, pdfHexString(content[i+1:i+end]))
+			i += end + 1
+		case c == '[':
+			inArray = true
+			i++
+		case c == ']':
+			inArray = false
+			i++
+		case c == '/':
+			i++
+			for i < len(content) && !pdfDelimiter(content[i]) {
+				i++
+			}
+		case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+			start := i
+			i++
+			for i < len(content) && (content[i] == '.' || (content[i] >= '0' && content[i] <= '9')) {
+				i++
+			}
+			value, _ := strconv.ParseFloat(string(content[start:i]), 64)
+			// A large negative adjustment in a TJ array is the gap between words
+			if inArray && value < -200 {
+				shown = append(shown, " ")
+			}
+			numbers = append(numbers, value)
+		case pdfDelimiter(c):
+			i++
+		default:
+			start := i
+			for i < len(content) && !pdfDelimiter(content[i]) {
+				i++
+			}
+			switch string(content[start:i]) {
+			case "Tj", "TJ":
+				text.WriteString(strings.Join(shown, ""))
+			case "'", "\"":
+				text.WriteString("\n" + strings.Join(shown, ""))
+			case "T*", "ET":
+				text.WriteString("\n")
+			case "Td", "TD":
+				// Moving down starts a new line; moving along the line does not
+				if len(numbers) >= 2 && numbers[len(numbers)-1] != 0 {
+					text.WriteString("\n")
+				} else {
+					text.WriteString(" ")
+				}
+			}
+			shown, numbers = shown[:0], numbers[:0]
+		}
+	}
+	return text.String()
+}
+
+// pdfDelimiter reports whether a byte ends a PDF token
+func pdfDelimiter(c byte) bool {
+	return strings.IndexByte(" \t\r\n\f\x00()<>[]{}/%", c) >= 0
+}
+
+// pdfLiteralString decodes a (string) at the start of data, returning it and the bytes used
+func pdfLiteralString(data []byte) (string, int) {
+	var text []rune
+	depth := 0
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '(':
+			if depth > 0 {
+				text = append(text, '(')
+			}
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return string(text), i + 1
+			}
+			text = append(text, ')')
+		case c == '\\' && i+1 < len(data):
+			i++
+			switch escaped := data[i]; escaped {
+			case 'n':
+				text = append(text, '\n')
+			case 'r':
+				text = append(text, '\r')
+			case 't':
+				text = append(text, '\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// A line continuation
+			default:
+				if escaped >= '0' && escaped <= '7' {
+					end := i
+					for end < len(data) && end < i+3 && data[end] >= '0' && data[end] <= '7' {
+						end++
+					}
+					code, _ := strconv.ParseUint(string(data[i:end]), 8, 8)
+					text = append(text, rune(code))
+					i = end - 1
+				} else {
+					text = append(text, rune(escaped))
+				}
+			}
+		default:
+			text = append(text, rune(c))
+		}
+	}
+	return string(text), len(data)
+}
+
+// pdfHexString decodes the digits of a <hex string>
+func pdfHexString(digits []byte) string {
+	var clean []byte
+	for _, c := range digits {
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') {
+			clean = append(clean, c)
+		}
+	}
+	if len(clean)%2 == 1 {
+		clean = append(clean, '0')
+	}
+	var text []rune
+	for i := 0; i < len(clean); i += 2 {
+		value, _ := strconv.ParseUint(string(clean[i:i+2]), 16, 8)
+		if unicode.IsPrint(rune(value)) {
+			text = append(text, rune(value))
+		}
+	}
+	return string(text)
+}
@@ -0,0 +1,464 @@
+package agents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Rerank providers selectable in the RAG agent's hybrid.rerank config
+const (
+	// RerankProviderSentenceTransformers is a cross-encoder served by text-embeddings-inference
+	RerankProviderSentenceTransformers = "sentence_transformers"
+	// RerankProviderCohere is the Cohere rerank API, which other rerank services offer too
+	RerankProviderCohere = "cohere"
+)
+
+const (
+	defaultVectorWeight     = 0.7
+	defaultKeywordWeight    = 0.3
+	defaultHybridCandidates = 20
+	defaultCohereRerankURL  = "https://api.cohere.com/v2/rerank"
+	defaultCohereModel      = "rerank-v3.5"
+	// BM25 term frequency saturation and document length normalization
+	bm25K1 = 1.2
+	bm25B  = 0.75
+	// keywordIndexPage is how many documents are read at a time to build the keyword index
+	keywordIndexPage = 500
+)
+
+// ragHybrid finds documents by keyword as well as by vector, so exact metric names and
+// error strings are found when their embeddings are not similar to the question's. The
+// scores of both are weighted and summed, and a cross-encoder may rerank the best.
+type ragHybrid struct {
+	vectorWeight  float64
+	keywordWeight float64
+	// candidates is how many documents each search contributes and the reranker sees
+	candidates int
+	reranker   *ragReranker
+}
+
+// parseRAGHybrid reads the hybrid setting; without it documents are found by vector only
+func parseRAGHybrid(config map[string]interface{}, client *http.Client) (*ragHybrid, error) {
+	if config["hybrid"] == nil {
+		return nil, nil
+	}
+	section, ok := config["hybrid"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("hybrid must be a map")
+	}
+
+	hybrid := &ragHybrid{vectorWeight: defaultVectorWeight, keywordWeight: defaultKeywordWeight, candidates: defaultHybridCandidates}
+	for key, target := range map[string]*float64{"vector_weight": &hybrid.vectorWeight, "keyword_weight": &hybrid.keywordWeight} {
+		switch raw := section[key].(type) {
+		case nil:
+		case int:
+			*target = float64(raw)
+		case float64:
+			*target = raw
+		default:
+			return nil, fmt.Errorf("hybrid.%s must be a number", key)
+		}
+		if *target < 0 {
+			return nil, fmt.Errorf("hybrid.%s must not be negative", key)
+		}
+	}
+	if hybrid.vectorWeight+hybrid.keywordWeight == 0 {
+		return nil, fmt.Errorf("hybrid.vector_weight and hybrid.keyword_weight cannot both be 0")
+	}
+	switch raw := section["candidates"].(type) {
+	case nil:
+	case int:
+		hybrid.candidates = raw
+	case float64:
+		hybrid.candidates = int(raw)
+	default:
+		return nil, fmt.Errorf("hybrid.candidates must be a number")
+	}
+	if hybrid.candidates < 1 {
+		return nil, fmt.Errorf("hybrid.candidates must be at least 1")
+	}
+
+	reranker, err := parseRAGReranker(section["rerank"], client)
+	if err != nil {
+		return nil, err
+	}
+	hybrid.reranker = reranker
+	return hybrid, nil
+}
+
+// keywordTokens splits text into lowercase words, keeping underscores so metric names such
+// as http_requests_total stay whole
+func keywordTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+}
+
+// keywordIndex scores documents against a query with BM25. It holds term counts only; the
+// documents themselves are read from the vector store.
+type keywordIndex struct {
+	// postings holds how often each term appears in each document
+	postings map[string]map[string]int
+	// terms holds each document's distinct terms, to find its postings when it is removed
+	terms map[string][]string
+	// lengths holds the number of terms in each document
+	lengths     map[string]int
+	totalLength int
+	// loaded is set once the documents already in the vector store are indexed
+	loaded bool
+	mu     sync.RWMutex
+	// loading serializes building the index from the vector store
+	loading sync.Mutex
+}
+
+func newKeywordIndex() *keywordIndex {
+	return &keywordIndex{postings: make(map[string]map[string]int), terms: make(map[string][]string), lengths: make(map[string]int)}
+}
+
+// add indexes documents, replacing any with the same IDs
+func (k *keywordIndex) add(docs []Document) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, doc := range docs {
+		k.removeLocked(doc.ID)
+		terms := keywordTokens(doc.Content)
+		for _, term := range terms {
+			if k.postings[term] == nil {
+				k.postings[term] = make(map[string]int)
+			}
+			if k.postings[term][doc.ID] == 0 {
+				k.terms[doc.ID] = append(k.terms[doc.ID], term)
+			}
+			k.postings[term][doc.ID]++
+		}
+		k.lengths[doc.ID] = len(terms)
+		k.totalLength += len(terms)
+	}
+}
+
+// remove drops documents from the index
+func (k *keywordIndex) remove(ids []string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, id := range ids {
+		k.removeLocked(id)
+	}
+}
+
+func (k *keywordIndex) removeLocked(id string) {
+	length, ok := k.lengths[id]
+	if !ok {
+		return
+	}
+	for _, term := range k.terms[id] {
+		delete(k.postings[term], id)
+		if len(k.postings[term]) == 0 {
+			delete(k.postings, term)
+		}
+	}
+	delete(k.terms, id)
+	delete(k.lengths, id)
+	k.totalLength -= length
+}
+
+// load indexes the documents already in the vector store, once
+func (k *keywordIndex) load(ctx context.Context, vectors ragVectorStore) error {
+	k.loading.Lock()
+	defer k.loading.Unlock()
+	k.mu.RLock()
+	loaded := k.loaded
+	k.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	offset := ""
+	for {
+		docs, next, err := vectors.page(ctx, offset, keywordIndexPage)
+		if err != nil {
+			return err
+		}
+		k.add(docs)
+		if next == "" || len(docs) == 0 {
+			break
+		}
+		offset = next
+	}
+	k.mu.Lock()
+	k.loaded = true
+	k.mu.Unlock()
+	return nil
+}
+
+// keywordMatch is a document's BM25 score for a query
+type keywordMatch struct {
+	id    string
+	score float64
+}
+
+// search returns the limit documents scoring highest for the query's terms
+func (k *keywordIndex) search(query string, limit int) []keywordMatch {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	count := float64(len(k.lengths))
+	if count == 0 {
+		return nil
+	}
+	averageLength := float64(k.totalLength) / count
+	scores := make(map[string]float64)
+	seen := make(map[string]bool)
+	for _, term := range keywordTokens(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		docs := k.postings[term]
+		if len(docs) == 0 {
+			continue
+		}
+		frequency := float64(len(docs))
+		idf := math.Log(1 + (count-frequency+0.5)/(frequency+0.5))
+		for id, occurrences := range docs {
+			tf := float64(occurrences)
+			length := float64(k.lengths[id])
+			scores[id] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*length/averageLength))
+		}
+	}
+
+	matches := make([]keywordMatch, 0, len(scores))
+	for id, score := range scores {
+		matches = append(matches, keywordMatch{id: id, score: score})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].id < matches[j].id
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// hybridCandidate is a document found by either search, with its score from each
+type hybridCandidate struct {
+	id       string
+	document *Document
+	vector   float64
+	keyword  float64
+	score    float64
+}
+
+// retrieveHybrid merges the vector matches with the keyword matches for the query. Keyword
+// scores are scaled so the best match scores 1, like an identical vector. The merged
+// candidates are reranked when a reranker is configured.
+func (r *RAGAgent) retrieveHybrid(ctx context.Context, hybrid *ragHybrid, keywords *keywordIndex, vectors ragVectorStore, query string, vectorMatches []ScoredDocument, maxDocs int) ([]Document, error) {
+	if err := keywords.load(ctx, vectors); err != nil {
+		return nil, err
+	}
+
+	candidates := make(map[string]*hybridCandidate)
+	for i := range vectorMatches {
+		match := vectorMatches[i]
+		candidates[match.Document.ID] = &hybridCandidate{id: match.Document.ID, document: &match.Document, vector: match.Score}
+	}
+	keywordMatches := keywords.search(query, hybrid.candidates)
+	var missing []string
+	for _, match := range keywordMatches {
+		candidate, ok := candidates[match.id]
+		if !ok {
+			candidate = &hybridCandidate{id: match.id}
+			candidates[match.id] = candidate
+			missing = append(missing, match.id)
+		}
+		candidate.keyword = match.score / keywordMatches[0].score
+	}
+	if len(missing) > 0 {
+		found, err := vectors.get(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		for i := range found {
+			candidates[found[i].ID].document = &found[i]
+		}
+	}
+
+	ranked := make([]*hybridCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		// A document removed since it was indexed is skipped
+		if candidate.document == nil {
+			continue
+		}
+		candidate.score = hybrid.vectorWeight*candidate.vector + hybrid.keywordWeight*candidate.keyword
+		ranked = append(ranked, candidate)
+	}
+	sortCandidates(ranked)
+	if len(ranked) > hybrid.candidates {
+		ranked = ranked[:hybrid.candidates]
+	}
+
+	if hybrid.reranker != nil && len(ranked) > 1 {
+		texts := make([]string, len(ranked))
+		for i, candidate := range ranked {
+			texts[i] = candidate.document.Content
+		}
+		scores, err := hybrid.reranker.rerank(ctx, query, texts)
+		if err != nil {
+			// The merged order is still a good answer without the reranker
+			slog.Warn("Failed to rerank documents", "plugin", r.name, "type", "agent", "error", err)
+		} else {
+			for i, candidate := range ranked {
+				candidate.score = scores[i]
+			}
+			sortCandidates(ranked)
+		}
+	}
+
+	if len(ranked) > maxDocs {
+		ranked = ranked[:maxDocs]
+	}
+	docs := make([]Document, len(ranked))
+	for i, candidate := range ranked {
+		docs[i] = *candidate.document
+	}
+	return docs, nil
+}
+
+// sortCandidates orders candidates by score, best first
+func sortCandidates(candidates []*hybridCandidate) {
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].id < candidates[j].id
+	})
+}
+
+// ragReranker scores how well each document answers a question with a cross-encoder,
+// which reads the two together and so ranks more precisely than comparing embeddings
+type ragReranker struct {
+	provider string
+	apiURL   string
+	apiKey   string
+	model    string
+	client   *http.Client
+}
+
+// parseRAGReranker reads the hybrid.rerank setting; without it candidates are not reranked
+func parseRAGReranker(value interface{}, client *http.Client) (*ragReranker, error) {
+	if value == nil {
+		return nil, nil
+	}
+	section, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("hybrid.rerank must be a map")
+	}
+	reranker := &ragReranker{provider: RerankProviderSentenceTransformers, client: client}
+	for key, target := range map[string]*string{"provider": &reranker.provider, "api_url": &reranker.apiURL, "api_key": &reranker.apiKey, "model": &reranker.model} {
+		if raw, ok := section[key]; ok {
+			text, isString := raw.(string)
+			if !isString {
+				return nil, fmt.Errorf("hybrid.rerank.%s must be a string", key)
+			}
+			if text != "" {
+				*target = text
+			}
+		}
+	}
+
+	switch reranker.provider {
+	case RerankProviderSentenceTransformers:
+		if reranker.apiURL == "" {
+			return nil, fmt.Errorf("hybrid.rerank.api_url is required for provider %s", reranker.provider)
+		}
+		reranker.apiURL = strings.TrimSuffix(reranker.apiURL, "/") + "/rerank"
+	case RerankProviderCohere:
+		if reranker.apiURL == "" {
+			reranker.apiURL = defaultCohereRerankURL
+		}
+		if reranker.model == "" {
+			reranker.model = defaultCohereModel
+		}
+	default:
+		return nil, fmt.Errorf("unknown rerank provider %q", reranker.provider)
+	}
+	return reranker, nil
+}
+
+// rerank returns a score for each text, in the order the texts were given
+func (rr *ragReranker) rerank(ctx context.Context, query string, texts []string) ([]float64, error) {
+	var body interface{}
+	if rr.provider == RerankProviderCohere {
+		body = map[string]interface{}{"model": rr.model, "query": query, "documents": texts}
+	} else {
+		body = map[string]interface{}{"query": query, "texts": texts, "truncate": true}
+	}
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", rr.apiURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	authorizeIfKeyed(req.Header, rr.apiKey)
+
+	resp, err := rr.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &AIProviderError{Provider: rr.provider, StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+	}
+
+	type ranked struct {
+		Index          int     `json:"index"`
+		Score          float64 `json:"score"`
+		RelevanceScore float64 `json:"relevance_score"`
+	}
+	var results []ranked
+	if rr.provider == RerankProviderCohere {
+		var response struct {
+			Results []ranked `json:"results"`
+		}
+		err = json.Unmarshal(data, &response)
+		results = response.Results
+	} else {
+		err = json.Unmarshal(data, &results)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(results) != len(texts) {
+		return nil, fmt.Errorf("expected %d rerank scores, got %d", len(texts), len(results))
+	}
+	scores := make([]float64, len(texts))
+	for _, result := range results {
+		if result.Index < 0 || result.Index >= len(texts) {
+			return nil, fmt.Errorf("rerank score for unknown text %d", result.Index)
+		}
+		scores[result.Index] = result.Score
+		if rr.provider == RerankProviderCohere {
+			scores[result.Index] = result.RelevanceScore
+		}
+	}
+	return scores, nil
+}
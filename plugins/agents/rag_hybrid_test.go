@@ -0,0 +1,228 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeywordIndex(t *testing.T) {
+	index := newKeywordIndex()
+	index.add([]Document{
+		{ID: "requests", Content: "Alert when http_requests_total drops to zero."},
+		{ID: "refused", Content: "dial tcp: ECONNREFUSED from the payments database. Check the payments pods."},
+		{ID: "payments", Content: "The payments service retries payments payments payments."},
+	})
+
+	matches := index.search("Why is http_requests_total flat?", 5)
+	require.Len(t, matches, 1, "Expected metric names to be matched whole")
+	assert.Equal(t, "requests", matches[0].id)
+
+	matches = index.search("econnrefused payments", 5)
+	require.Len(t, matches, 2)
+	assert.Equal(t, "refused", matches[0].id, "Expected the rarer term to outweigh repetitions of a common one")
+	assert.Equal(t, "payments", matches[1].id)
+	assert.Len(t, index.search("econnrefused payments", 1), 1)
+
+	index.add([]Document{{ID: "refused", Content: "Connection reset by peer."}})
+	matches = index.search("econnrefused", 5)
+	assert.Empty(t, matches, "Expected re-adding a document to replace its terms")
+
+	index.remove([]string{"requests", "missing"})
+	assert.Empty(t, index.search("http_requests_total", 5))
+	assert.Len(t, index.lengths, 2)
+	assert.NotContains(t, index.postings, "http_requests_total")
+}
+
+func TestParseRAGHybrid(t *testing.T) {
+	hybrid, err := parseRAGHybrid(map[string]interface{}{}, http.DefaultClient)
+	require.NoError(t, err)
+	assert.Nil(t, hybrid)
+
+	hybrid, err = parseRAGHybrid(map[string]interface{}{"hybrid": map[string]interface{}{}}, http.DefaultClient)
+	require.NoError(t, err)
+	assert.Equal(t, defaultVectorWeight, hybrid.vectorWeight)
+	assert.Equal(t, defaultKeywordWeight, hybrid.keywordWeight)
+	assert.Equal(t, defaultHybridCandidates, hybrid.candidates)
+	assert.Nil(t, hybrid.reranker)
+
+	hybrid, err = parseRAGHybrid(map[string]interface{}{"hybrid": map[string]interface{}{
+		"vector_weight":  0.5,
+		"keyword_weight": 1,
+		"candidates":     50,
+		"rerank":         map[string]interface{}{"api_url": "http://localhost:8080/"},
+	}}, http.DefaultClient)
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, hybrid.vectorWeight)
+	assert.Equal(t, 1.0, hybrid.keywordWeight)
+	assert.Equal(t, 50, hybrid.candidates)
+	assert.Equal(t, RerankProviderSentenceTransformers, hybrid.reranker.provider)
+	assert.Equal(t, "http://localhost:8080/rerank", hybrid.reranker.apiURL)
+
+	hybrid, err = parseRAGHybrid(map[string]interface{}{"hybrid": map[string]interface{}{
+		"rerank": map[string]interface{}{"provider": RerankProviderCohere, "api_key": "key"},
+	}}, http.DefaultClient)
+	require.NoError(t, err)
+	assert.Equal(t, defaultCohereRerankURL, hybrid.reranker.apiURL)
+	assert.Equal(t, defaultCohereModel, hybrid.reranker.model)
+
+	for _, section := range []interface{}{
+		"on",
+		map[string]interface{}{"vector_weight": "high"},
+		map[string]interface{}{"keyword_weight": -1},
+		map[string]interface{}{"vector_weight": 0, "keyword_weight": 0},
+		map[string]interface{}{"candidates": 0},
+		map[string]interface{}{"rerank": "cohere"},
+		map[string]interface{}{"rerank": map[string]interface{}{}},
+		map[string]interface{}{"rerank": map[string]interface{}{"provider": "openai"}},
+		map[string]interface{}{"rerank": map[string]interface{}{"provider": RerankProviderCohere, "model": 3}},
+	} {
+		_, err := parseRAGHybrid(map[string]interface{}{"hybrid": section}, http.DefaultClient)
+		assert.Error(t, err, "Expected %v to be rejected", section)
+	}
+}
+
+func TestRAGAgent_HybridRetrieval(t *testing.T) {
+	server, _ := newEmbeddingServer(t)
+	config := map[string]interface{}{
+		"api_key":      "key",
+		"api_url":      server.URL + "/v1/chat/completions",
+		"vector_store": map[string]interface{}{"backend": VectorStoreSQLite, "path": filepath.Join(t.TempDir(), "rag.db")},
+	}
+	ctx := context.Background()
+
+	agent := NewRAGAgent("rag")
+	require.NoError(t, agent.Configure(config))
+	agent.AddDocument(Document{ID: "runbook-orders", Content: "Restart the orders service with kubectl."})
+	agent.AddDocument(Document{ID: "refused", Content: "ECONNREFUSED means the database is down; fail over to the replica."})
+	agent.AddDocument(Document{ID: "payments", Content: "Payments are retried three times."})
+
+	// The error string shares no topic with the question's embedding
+	docs, err := agent.retrieveRelevantDocuments(ctx, "Orders fail with ECONNREFUSED", 5)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "runbook-orders", docs[0].ID)
+	require.NoError(t, agent.vectors.close())
+
+	// A restarted agent builds the keyword index from the stored documents
+	config["hybrid"] = map[string]interface{}{}
+	restarted := NewRAGAgent("rag")
+	require.NoError(t, restarted.Configure(config))
+	defer restarted.vectors.close()
+	docs, err = restarted.retrieveRelevantDocuments(ctx, "Orders fail with ECONNREFUSED", 5)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "runbook-orders", docs[0].ID, "Expected the vector match to outweigh the keyword match")
+	assert.Equal(t, "refused", docs[1].ID)
+
+	docs, err = restarted.retrieveRelevantDocuments(ctx, "Orders fail with ECONNREFUSED", 1)
+	require.NoError(t, err)
+	assert.Len(t, docs, 1)
+
+	restarted.AddDocument(Document{ID: "timeout", Content: "ETIMEDOUT means the network is partitioned."})
+	docs, err = restarted.retrieveRelevantDocuments(ctx, "What does ETIMEDOUT mean?", 5)
+	require.NoError(t, err)
+	require.Len(t, docs, 1, "Expected added documents to be indexed")
+	assert.Equal(t, "timeout", docs[0].ID)
+
+	require.NoError(t, restarted.RemoveDocuments(ctx, "refused"))
+	docs, err = restarted.retrieveRelevantDocuments(ctx, "ECONNREFUSED", 5)
+	require.NoError(t, err)
+	assert.Empty(t, docs, "Expected removed documents to leave the keyword index")
+}
+
+func TestRAGAgent_HybridRerank(t *testing.T) {
+	for _, provider := range []string{RerankProviderSentenceTransformers, RerankProviderCohere} {
+		t.Run(provider, func(t *testing.T) {
+			var failing atomic.Bool
+			reranker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "Bearer rerank-key", r.Header.Get("Authorization"))
+				if failing.Load() {
+					http.Error(w, "overloaded", http.StatusServiceUnavailable)
+					return
+				}
+				var request struct {
+					Query     string   `json:"query"`
+					Texts     []string `json:"texts"`
+					Documents []string `json:"documents"`
+					Model     string   `json:"model"`
+				}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+				assert.Equal(t, "Orders fail with ECONNREFUSED", request.Query)
+				texts := request.Texts
+				if provider == RerankProviderCohere {
+					assert.Equal(t, defaultCohereModel, request.Model)
+					texts = request.Documents
+				}
+				// The cross-encoder prefers the document explaining the error
+				var results []map[string]interface{}
+				for i, text := range texts {
+					score := 0.1
+					if text == "ECONNREFUSED means the database is down; fail over to the replica." {
+						score = 0.9
+					}
+					if provider == RerankProviderCohere {
+						results = append(results, map[string]interface{}{"index": i, "relevance_score": score})
+					} else {
+						results = append(results, map[string]interface{}{"index": i, "score": score})
+					}
+				}
+				if provider == RerankProviderCohere {
+					json.NewEncoder(w).Encode(map[string]interface{}{"results": results})
+				} else {
+					json.NewEncoder(w).Encode(results)
+				}
+			}))
+			defer reranker.Close()
+
+			server, _ := newEmbeddingServer(t)
+			rerank := map[string]interface{}{"provider": provider, "api_key": "rerank-key"}
+			if provider == RerankProviderCohere {
+				rerank["api_url"] = reranker.URL + "/v2/rerank"
+			} else {
+				rerank["api_url"] = reranker.URL
+			}
+			agent := NewRAGAgent("rag")
+			require.NoError(t, agent.Configure(map[string]interface{}{
+				"api_key": "key",
+				"api_url": server.URL + "/v1/chat/completions",
+				"hybrid":  map[string]interface{}{"rerank": rerank},
+			}))
+			ctx := context.Background()
+			agent.AddDocument(Document{ID: "runbook-orders", Content: "Restart the orders service with kubectl."})
+			agent.AddDocument(Document{ID: "refused", Content: "ECONNREFUSED means the database is down; fail over to the replica."})
+
+			docs, err := agent.retrieveRelevantDocuments(ctx, "Orders fail with ECONNREFUSED", 5)
+			require.NoError(t, err)
+			require.Len(t, docs, 2)
+			assert.Equal(t, "refused", docs[0].ID)
+
+			// Without the reranker the merged order is kept
+			failing.Store(true)
+			docs, err = agent.retrieveRelevantDocuments(ctx, "Orders fail with ECONNREFUSED", 5)
+			require.NoError(t, err)
+			require.Len(t, docs, 2)
+			assert.Equal(t, "runbook-orders", docs[0].ID)
+		})
+	}
+}
+
+func TestRAGReranker_RejectsMismatchedScores(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `[{"index": 0, "score": 0.5}]`)
+	}))
+	defer server.Close()
+	reranker, err := parseRAGReranker(map[string]interface{}{"api_url": server.URL}, server.Client())
+	require.NoError(t, err)
+
+	_, err = reranker.rerank(context.Background(), "query", []string{"one", "two"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,426 @@
+package agents
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/habruzzo/agent/core"
+)
+
+const (
+	defaultChunkSize      = 1000
+	defaultChunkOverlap   = 200
+	defaultIngestInterval = 5 * time.Minute
+	// ragIngestMarker is written into the .git directory of checkouts the agent cloned, the
+	// only ones it updates
+	ragIngestMarker = "rag-ingest-checkout"
+)
+
+// ragIngester loads the runbooks and docs under the knowledge base path into the knowledge
+// base, optionally keeping the path a checkout of a Git repository. Files are chunked with
+// overlap, and on each pass only files that changed are indexed again.
+type ragIngester struct {
+	path         string
+	gitURL       string
+	gitRef       string
+	chunkSize    int
+	chunkOverlap int
+	// interval is how often the path is checked for changes; zero ingests once at start
+	interval time.Duration
+	// files is what was indexed from each file, by path relative to the knowledge base
+	files  map[string]ingestedFile
+	loaded bool
+}
+
+// ingestedFile is what was indexed from a file. It is kept in the framework's store, so
+// files that did not change are not indexed again after a restart.
+type ingestedFile struct {
+	Hash   string `json:"hash"`
+	Chunks int    `json:"chunks"`
+}
+
+// ragIngestResult counts the files a pass indexed and removed
+type ragIngestResult struct {
+	Indexed   int
+	Unchanged int
+	Removed   int
+	Failed    int
+}
+
+// parseRAGIngest reads knowledge_base_path and the ingest setting; without a path there is
+// nothing to ingest
+func parseRAGIngest(config map[string]interface{}) (*ragIngester, error) {
+	section, ok := config["ingest"].(map[string]interface{})
+	if !ok && config["ingest"] != nil {
+		return nil, fmt.Errorf("ingest must be a map")
+	}
+	path, _ := config["knowledge_base_path"].(string)
+	if path == "" {
+		if section != nil {
+			return nil, fmt.Errorf("ingest needs knowledge_base_path")
+		}
+		return nil, nil
+	}
+
+	ingester := &ragIngester{
+		path:         path,
+		chunkSize:    defaultChunkSize,
+		chunkOverlap: defaultChunkOverlap,
+		interval:     defaultIngestInterval,
+		files:        make(map[string]ingestedFile),
+	}
+	for key, target := range map[string]*string{"git_url": &ingester.gitURL, "git_ref": &ingester.gitRef} {
+		if raw, ok := section[key]; ok {
+			text, isString := raw.(string)
+			if !isString {
+				return nil, fmt.Errorf("ingest.%s must be a string", key)
+			}
+			// git would read a value starting with a dash as an option
+			if strings.HasPrefix(text, "-") {
+				return nil, fmt.Errorf("ingest.%s must not start with '-'", key)
+			}
+			*target = text
+		}
+	}
+	for key, target := range map[string]*int{"chunk_size": &ingester.chunkSize, "chunk_overlap": &ingester.chunkOverlap} {
+		switch raw := section[key].(type) {
+		case nil:
+		case int:
+			*target = raw
+		case float64:
+			*target = int(raw)
+		default:
+			return nil, fmt.Errorf("ingest.%s must be a number", key)
+		}
+	}
+	if ingester.chunkSize < 100 {
+		return nil, fmt.Errorf("ingest.chunk_size must be at least 100")
+	}
+	if ingester.chunkOverlap < 0 || ingester.chunkOverlap >= ingester.chunkSize/2 {
+		return nil, fmt.Errorf("ingest.chunk_overlap must be at least 0 and less than half of chunk_size")
+	}
+	if raw, ok := section["interval"]; ok {
+		text, isString := raw.(string)
+		interval, err := time.ParseDuration(text)
+		if !isString || err != nil || interval < 0 {
+			return nil, fmt.Errorf("invalid ingest.interval %v", raw)
+		}
+		ingester.interval = interval
+	}
+	return ingester, nil
+}
+
+// ingestLoop ingests the knowledge base at once and then every interval
+func (r *RAGAgent) ingestLoop(ctx context.Context, ingester *ragIngester) {
+	r.ingestOnce(ctx, ingester)
+	if ingester.interval == 0 {
+		return
+	}
+	ticker := time.NewTicker(ingester.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.ingestOnce(ctx, ingester)
+		}
+	}
+}
+
+// ingestOnce runs a pass and logs what it did
+func (r *RAGAgent) ingestOnce(ctx context.Context, ingester *ragIngester) {
+	result, err := r.ingest(ctx, ingester)
+	if err != nil {
+		slog.Error("Knowledge base ingestion failed", "plugin", r.name, "type", "agent", "path", ingester.path, "error", err)
+		return
+	}
+	if result.Indexed > 0 || result.Removed > 0 || result.Failed > 0 {
+		slog.Info("Knowledge base ingested", "plugin", r.name, "type", "agent", "path", ingester.path,
+			"indexed", result.Indexed, "unchanged", result.Unchanged, "removed", result.Removed, "failed", result.Failed)
+	}
+}
+
+// ingest brings the knowledge base in line with the files under the path: changed files
+// are chunked and indexed again, and the chunks of deleted files are removed. Chunks are
+// embedded before it returns.
+func (r *RAGAgent) ingest(ctx context.Context, ingester *ragIngester) (ragIngestResult, error) {
+	var result ragIngestResult
+	if ingester.gitURL != "" {
+		if err := ingester.syncGit(ctx); err != nil {
+			return result, err
+		}
+	}
+	r.mu.RLock()
+	store := r.store
+	r.mu.RUnlock()
+	if err := ingester.load(ctx, store, r.name); err != nil {
+		return result, err
+	}
+
+	seen := make(map[string]bool)
+	err := filepath.WalkDir(ingester.path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// A knowledge base that does not exist yet is empty
+			if path == ingester.path && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() {
+			if path != ingester.path && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		format := ragDocumentFormat(path)
+		if format == "" {
+			return nil
+		}
+		relative, err := filepath.Rel(ingester.path, path)
+		if err != nil {
+			return err
+		}
+		relative = filepath.ToSlash(relative)
+		seen[relative] = true
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+		previous, known := ingester.files[relative]
+		if known && previous.Hash == hash {
+			result.Unchanged++
+			return nil
+		}
+
+		text, title, err := extractDocumentText(format, data)
+		if err != nil {
+			slog.Warn("Skipping knowledge base file", "plugin", r.name, "type", "agent", "file", relative, "error", err)
+			result.Failed++
+			return nil
+		}
+		modified := time.Now()
+		if info, err := entry.Info(); err == nil {
+			modified = info.ModTime()
+		}
+		chunks := chunkText(text, ingester.chunkSize, ingester.chunkOverlap)
+		docs := make([]Document, len(chunks))
+		for i, chunk := range chunks {
+			docs[i] = Document{
+				ID:      chunkID(relative, i),
+				Content: chunk,
+				Metadata: map[string]interface{}{
+					"type":   "runbook",
+					"source": relative,
+					"format": format,
+					"title":  title,
+					"chunk":  i,
+				},
+				Timestamp: modified,
+			}
+		}
+		r.addDocuments(docs)
+		// Chunks beyond the new count are left over from the previous version
+		if err := r.RemoveDocuments(ctx, chunkIDs(relative, len(chunks), previous.Chunks)...); err != nil {
+			return err
+		}
+		if err := ingester.record(ctx, store, r.name, relative, ingestedFile{Hash: hash, Chunks: len(chunks)}); err != nil {
+			return err
+		}
+		result.Indexed++
+		return nil
+	})
+	if err != nil {
+		return result, core.WrapError(err, core.ErrorTypeInternal, "rag-agent", "ingest", fmt.Sprintf("failed to read %s", ingester.path))
+	}
+
+	for relative, file := range ingester.files {
+		if seen[relative] {
+			continue
+		}
+		if err := r.RemoveDocuments(ctx, chunkIDs(relative, 0, file.Chunks)...); err != nil {
+			return result, err
+		}
+		if err := ingester.record(ctx, store, r.name, relative, ingestedFile{}); err != nil {
+			return result, err
+		}
+		result.Removed++
+	}
+
+	if result.Indexed > 0 {
+		r.mu.RLock()
+		configured := r.embedder != nil
+		r.mu.RUnlock()
+		if configured {
+			if err := r.embedPending(ctx); err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// load reads what earlier runs indexed from the framework's store, once
+func (i *ragIngester) load(ctx context.Context, store core.Store, agent string) error {
+	if i.loaded || store == nil {
+		return nil
+	}
+	prefix := agent + "/"
+	err := core.ListJSON(ctx, store, core.StoreCollectionKnowledgeFiles, func(key string, unmarshal func(v interface{}) error) error {
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		var file ingestedFile
+		if err := unmarshal(&file); err != nil {
+			return err
+		}
+		i.files[strings.TrimPrefix(key, prefix)] = file
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	i.loaded = true
+	return nil
+}
+
+// record notes what was indexed from a file; a zero file means it was removed
+func (i *ragIngester) record(ctx context.Context, store core.Store, agent, relative string, file ingestedFile) error {
+	if file.Hash == "" {
+		delete(i.files, relative)
+	} else {
+		i.files[relative] = file
+	}
+	if store == nil {
+		return nil
+	}
+	key := agent + "/" + relative
+	if file.Hash == "" {
+		return store.Delete(ctx, core.StoreCollectionKnowledgeFiles, key)
+	}
+	return core.PutJSON(ctx, store, core.StoreCollectionKnowledgeFiles, key, file)
+}
+
+// syncGit clones the repository into the path, or fetches the ref from the configured URL
+// and checks it out when the path is a checkout the agent cloned. Other checkouts are left
+// alone, as resetting them would discard their local changes.
+func (i *ragIngester) syncGit(ctx context.Context) error {
+	gitDir := filepath.Join(i.path, ".git")
+	if _, err := os.Stat(gitDir); err != nil {
+		args := []string{"clone", "--depth", "1"}
+		if i.gitRef != "" {
+			args = append(args, "--branch", i.gitRef)
+		}
+		if err := runGit(ctx, "", append(args, "--", i.gitURL, i.path)...); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(gitDir, ragIngestMarker), []byte(i.gitURL+"\n"), 0o644); err != nil {
+			return core.WrapError(err, core.ErrorTypeInternal, "rag-agent", "git", "failed to mark the checkout as the agent's")
+		}
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(gitDir, ragIngestMarker)); err != nil {
+		return core.NewConfigurationError("rag-agent", "git",
+			fmt.Sprintf("%s is a git checkout the agent did not clone; point knowledge_base_path at an empty directory", i.path))
+	}
+
+	ref := i.gitRef
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if err := runGit(ctx, i.path, "fetch", "--depth", "1", "--", i.gitURL, ref); err != nil {
+		return err
+	}
+	return runGit(ctx, i.path, "reset", "--hard", "FETCH_HEAD")
+}
+
+// runGit runs a git command, returning its output with any error
+func runGit(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "git", fmt.Sprintf("git %s failed: %s", args[0], strings.TrimSpace(string(output))))
+	}
+	return nil
+}
+
+// chunkID names a chunk of a knowledge base file
+func chunkID(relative string, chunk int) string {
+	return fmt.Sprintf("%s#%d", relative, chunk)
+}
+
+// chunkIDs names the chunks of a file from one number up to another
+func chunkIDs(relative string, from, to int) []string {
+	var ids []string
+	for chunk := from; chunk < to; chunk++ {
+		ids = append(ids, chunkID(relative, chunk))
+	}
+	return ids
+}
+
+// chunkText splits text into chunks of at most size characters, each starting with the
+// last overlap characters of the one before so a passage split between chunks is found
+// whole in one of them. Chunks end at a paragraph break or else a space where possible.
+func chunkText(text string, size, overlap int) []string {
+	runes := []rune(strings.TrimSpace(text))
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := start + size
+		if end >= len(runes) {
+			chunks = appendChunk(chunks, runes[start:])
+			break
+		}
+		end = chunkEnd(runes, start, end)
+		chunks = appendChunk(chunks, runes[start:end])
+
+		// Start the overlap at the beginning of a word
+		next := end - overlap
+		for next < end && next > start && !unicode.IsSpace(runes[next-1]) {
+			next++
+		}
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+	return chunks
+}
+
+// chunkEnd moves the end of a chunk back to the last paragraph break in its second half,
+// or else the last space
+func chunkEnd(runes []rune, start, end int) int {
+	half := start + (end-start)/2
+	for i := end; i > half+1; i-- {
+		if runes[i-1] == '\n' && runes[i-2] == '\n' {
+			return i
+		}
+	}
+	for i := end; i > half; i-- {
+		if unicode.IsSpace(runes[i-1]) {
+			return i
+		}
+	}
+	return end
+}
+
+// appendChunk adds a chunk unless it is only whitespace
+func appendChunk(chunks []string, runes []rune) []string {
+	if chunk := strings.TrimSpace(string(runes)); chunk != "" {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
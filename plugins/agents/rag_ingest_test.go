@@ -0,0 +1,262 @@
+package agents
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkText(t *testing.T) {
+	words := make([]string, 300)
+	for i := range words {
+		words[i] = fmt.Sprintf("word%03d", i)
+	}
+	text := strings.Join(words, " ")
+
+	chunks := chunkText(text, 200, 50)
+	require.Greater(t, len(chunks), 1)
+	for i, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk), 200)
+		assert.True(t, strings.HasPrefix(chunk, "word"), "Expected chunk %d to start at a word", i)
+		fields := strings.Fields(chunk)
+		assert.Len(t, fields[len(fields)-1], len("word000"), "Expected chunk %d to end at a word", i)
+		if i > 0 {
+			previous := strings.Fields(chunks[i-1])
+			assert.Contains(t, chunk, previous[len(previous)-1], "Expected chunk %d to overlap the one before", i)
+		}
+	}
+	assert.True(t, strings.HasSuffix(chunks[len(chunks)-1], "word299"))
+
+	assert.Equal(t, []string{"short text"}, chunkText("  short text\n", 200, 50))
+	assert.Empty(t, chunkText(" \n ", 200, 50))
+}
+
+func TestExtractDocumentText(t *testing.T) {
+	text, title, err := extractDocumentText("markdown", []byte("---\nowner: sre\n---\n# Orders outage\n\nRestart the orders service.\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "Orders outage", title)
+	assert.NotContains(t, text, "owner")
+	assert.Contains(t, text, "Restart the orders service.")
+
+	text, title, err = extractDocumentText("html", []byte(`<html><head><title>Disk full</title><style>p {}</style></head>
+<body><h1>Disk</h1><p>Clean   the
+logs.</p><script>alert(1)</script><pre>df -h
+du -sh /var</pre></body></html>`))
+	require.NoError(t, err)
+	assert.Equal(t, "Disk full", title)
+	assert.Equal(t, "Disk\n\nClean the logs.\n\ndf -h\ndu -sh /var", text)
+
+	_, _, err = extractDocumentText("docx", nil)
+	assert.Error(t, err)
+}
+
+// testPDF builds a PDF with a Flate-compressed content stream showing the lines
+func testPDF(t *testing.T, lines ...string) []byte {
+	var content strings.Builder
+	content.WriteString("BT /F1 12 Tf 72 720 Td\n")
+	for i, line := range lines {
+		if i > 0 {
+			content.WriteString("0 -14 Td\n")
+		}
+		fmt.Fprintf(&content, "(%s) Tj\n", strings.NewReplacer("(", `\(`, ")", `\)`).Replace(line))
+	}
+	content.WriteString("ET\n")
+
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	_, err := writer.Write([]byte(content.String()))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj << /Length %d /Filter /FlateDecode >>\nstream\n", compressed.Len())
+	pdf.Write(compressed.Bytes())
+	pdf.WriteString("\nendstream\nendobj\ntrailer << /Root 1 0 R >>\n%%EOF\n")
+	return pdf.Bytes()
+}
+
+func TestExtractPDF(t *testing.T) {
+	text, err := extractPDF(testPDF(t, "Payments runbook", "Check the queue (payments-in) first."))
+	require.NoError(t, err)
+	assert.Equal(t, "Payments runbook\nCheck the queue (payments-in) first.", text)
+
+	_, err = extractPDF([]byte("%PDF-1.4\n%%EOF\n"))
+	assert.Error(t, err, "Expected a PDF without text to fail")
+	_, err = extractPDF([]byte("not a pdf"))
+	assert.Error(t, err)
+}
+
+func TestParseRAGIngest(t *testing.T) {
+	ingester, err := parseRAGIngest(map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Nil(t, ingester)
+
+	ingester, err = parseRAGIngest(map[string]interface{}{
+		"knowledge_base_path": "/runbooks",
+		"ingest":              map[string]interface{}{"git_url": "https://git.example.com/runbooks.git", "chunk_size": 500, "interval": "1m"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/runbooks", ingester.path)
+	assert.Equal(t, "https://git.example.com/runbooks.git", ingester.gitURL)
+	assert.Equal(t, 500, ingester.chunkSize)
+	assert.Equal(t, defaultChunkOverlap, ingester.chunkOverlap)
+	assert.Equal(t, "1m0s", ingester.interval.String())
+
+	for _, config := range []map[string]interface{}{
+		{"ingest": map[string]interface{}{}},
+		{"knowledge_base_path": "/runbooks", "ingest": "often"},
+		{"knowledge_base_path": "/runbooks", "ingest": map[string]interface{}{"chunk_size": 50}},
+		{"knowledge_base_path": "/runbooks", "ingest": map[string]interface{}{"chunk_overlap": 600}},
+		{"knowledge_base_path": "/runbooks", "ingest": map[string]interface{}{"interval": "soon"}},
+		{"knowledge_base_path": "/runbooks", "ingest": map[string]interface{}{"git_url": "--upload-pack=touch /tmp/pwned"}},
+		{"knowledge_base_path": "/runbooks", "ingest": map[string]interface{}{"git_url": "https://git.example.com/runbooks.git", "git_ref": "-b"}},
+	} {
+		_, err := parseRAGIngest(config)
+		assert.Error(t, err, "Expected %v to be rejected", config)
+	}
+}
+
+func TestRAGAgent_IngestDirectory(t *testing.T) {
+	server, batches := newEmbeddingServer(t)
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	write("orders.md", "# Orders\n\nRestart the orders service with kubectl.")
+	write("guides/disk.html", "<h1>Disk</h1><p>Disk alerts fire at 90%.</p>")
+	write("notes.txt", "Not ingested.")
+	write(".drafts/payments.md", "Not ingested either.")
+
+	config := map[string]interface{}{
+		"api_key":             "key",
+		"api_url":             server.URL + "/v1/chat/completions",
+		"knowledge_base_path": dir,
+		"ingest":              map[string]interface{}{"interval": "0s"},
+	}
+	store := core.NewMemoryStore()
+	ctx := context.Background()
+
+	agent := NewRAGAgent("rag")
+	require.NoError(t, agent.Configure(config))
+	agent.SetStore(store)
+	result, err := agent.ingest(ctx, agent.ingester)
+	require.NoError(t, err)
+	assert.Equal(t, ragIngestResult{Indexed: 2}, result)
+	assert.Len(t, batches(), 1, "Expected ingested files to be embedded")
+
+	docs, err := agent.retrieveRelevantDocuments(ctx, "How do I restart orders?", 5)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "orders.md#0", docs[0].ID)
+	assert.Equal(t, "runbook", docs[0].Metadata["type"])
+	assert.Equal(t, "Orders", docs[0].Metadata["title"])
+
+	// Only the changed file is indexed again, and deleted files leave the knowledge base
+	write("orders.md", "# Orders\n\nScale the orders deployment up; payments depend on it.")
+	require.NoError(t, os.Remove(filepath.Join(dir, "guides/disk.html")))
+	result, err = agent.ingest(ctx, agent.ingester)
+	require.NoError(t, err)
+	assert.Equal(t, ragIngestResult{Indexed: 1, Removed: 1}, result)
+	assert.Equal(t, []string{"# Orders\n\nScale the orders deployment up; payments depend on it."}, batches()[2])
+	docs, err = agent.retrieveRelevantDocuments(ctx, "payments", 5)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Contains(t, docs[0].Content, "Scale the orders deployment")
+	docs, err = agent.retrieveRelevantDocuments(ctx, "disk", 5)
+	require.NoError(t, err)
+	assert.Empty(t, docs)
+
+	// A restarted agent remembers what was indexed
+	restarted := NewRAGAgent("rag")
+	require.NoError(t, restarted.Configure(config))
+	restarted.SetStore(store)
+	result, err = restarted.ingest(ctx, restarted.ingester)
+	require.NoError(t, err)
+	assert.Equal(t, ragIngestResult{Unchanged: 1}, result)
+
+	// Files are removed when the knowledge base goes away
+	require.NoError(t, os.RemoveAll(dir))
+	result, err = restarted.ingest(ctx, restarted.ingester)
+	require.NoError(t, err)
+	assert.Equal(t, ragIngestResult{Removed: 1}, result)
+	assert.Equal(t, 0, restarted.GetKnowledgeBaseStats()["pending_documents"])
+}
+
+func TestRAGAgent_IngestGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	git := func(dir string, args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.email=test@example.com", "-c", "user.name=test"}, args...)...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+	repo := t.TempDir()
+	git(repo, "init", "--quiet")
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "orders.md"), []byte("Restart the orders service."), 0o644))
+	git(repo, "add", ".")
+	git(repo, "commit", "--quiet", "-m", "Add runbook")
+
+	server, _ := newEmbeddingServer(t)
+	checkout := filepath.Join(t.TempDir(), "runbooks")
+	agent := NewRAGAgent("rag")
+	require.NoError(t, agent.Configure(map[string]interface{}{
+		"api_key":             "key",
+		"api_url":             server.URL + "/v1/chat/completions",
+		"knowledge_base_path": checkout,
+		"ingest":              map[string]interface{}{"git_url": repo},
+	}))
+	ctx := context.Background()
+	result, err := agent.ingest(ctx, agent.ingester)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Indexed)
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "payments.md"), []byte("Check the payments queue."), 0o644))
+	git(repo, "add", ".")
+	git(repo, "commit", "--quiet", "-m", "Add another runbook")
+	result, err = agent.ingest(ctx, agent.ingester)
+	require.NoError(t, err)
+	assert.Equal(t, ragIngestResult{Indexed: 1, Unchanged: 1}, result, "Expected the checkout to be updated")
+	assert.Equal(t, 2, agent.GetKnowledgeBaseStats()["total_documents"])
+
+	// A changed URL is fetched from, not the remote of the first clone
+	moved := t.TempDir()
+	git(moved, "init", "--quiet")
+	require.NoError(t, os.WriteFile(filepath.Join(moved, "disk.md"), []byte("Free space on the disk."), 0o644))
+	git(moved, "add", ".")
+	git(moved, "commit", "--quiet", "-m", "Add disk runbook")
+	agent.ingester.gitURL = moved
+	result, err = agent.ingest(ctx, agent.ingester)
+	require.NoError(t, err)
+	assert.Equal(t, ragIngestResult{Indexed: 1, Removed: 2}, result, "Expected the new repository to be checked out")
+
+	// A checkout the agent did not clone keeps its local changes
+	working := t.TempDir()
+	git(working, "clone", "--quiet", repo, ".")
+	require.NoError(t, os.WriteFile(filepath.Join(working, "orders.md"), []byte("Work in progress."), 0o644))
+	other := NewRAGAgent("other")
+	require.NoError(t, other.Configure(map[string]interface{}{
+		"api_key":             "key",
+		"api_url":             server.URL + "/v1/chat/completions",
+		"knowledge_base_path": working,
+		"ingest":              map[string]interface{}{"git_url": repo},
+	}))
+	_, err = other.ingest(ctx, other.ingester)
+	assert.ErrorContains(t, err, "did not clone")
+	content, err := os.ReadFile(filepath.Join(working, "orders.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "Work in progress.", string(content))
+}
@@ -0,0 +1,70 @@
+package agents
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// AddKnowledge adds documents to the knowledge base and embeds them at once, so a failing
+// embeddings API is reported to whoever added them. Documents that could not be embedded
+// stay pending and are embedded before the next retrieval.
+func (r *RAGAgent) AddKnowledge(ctx context.Context, docs []core.KnowledgeDocument) error {
+	added := make([]Document, len(docs))
+	for i, doc := range docs {
+		added[i] = Document{ID: doc.ID, Content: doc.Content, Metadata: doc.Metadata, Timestamp: doc.Timestamp}
+	}
+	r.addDocuments(added)
+	slog.Info("Documents added to knowledge base", "plugin", r.name, "type", "agent", "documents", len(added))
+	return r.embedPending(ctx)
+}
+
+// ListKnowledge returns a page of the documents in the vector store, after embedding those
+// still pending so they are listed too
+func (r *RAGAgent) ListKnowledge(ctx context.Context, offset string, limit int) (core.KnowledgePage, error) {
+	if err := r.embedPending(ctx); err != nil {
+		return core.KnowledgePage{}, err
+	}
+	r.mu.RLock()
+	vectors := r.vectors
+	r.mu.RUnlock()
+
+	docs, next, err := vectors.page(ctx, offset, limit)
+	if err != nil {
+		return core.KnowledgePage{}, err
+	}
+	return core.KnowledgePage{Documents: knowledgeDocuments(docs), Next: next}, nil
+}
+
+// DeleteKnowledge removes documents from the knowledge base
+func (r *RAGAgent) DeleteKnowledge(ctx context.Context, ids []string) error {
+	if err := r.RemoveDocuments(ctx, ids...); err != nil {
+		return err
+	}
+	slog.Info("Documents removed from knowledge base", "plugin", r.name, "type", "agent", "documents", len(ids))
+	return nil
+}
+
+// SearchKnowledge returns the documents a query would be answered from
+func (r *RAGAgent) SearchKnowledge(ctx context.Context, query string, limit int) ([]core.KnowledgeDocument, error) {
+	docs, err := r.retrieveRelevantDocuments(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	return knowledgeDocuments(docs), nil
+}
+
+// KnowledgeStats returns the statistics of GetKnowledgeBaseStats
+func (r *RAGAgent) KnowledgeStats(ctx context.Context) (map[string]interface{}, error) {
+	return r.GetKnowledgeBaseStats(), nil
+}
+
+// knowledgeDocuments converts documents to the framework's type
+func knowledgeDocuments(docs []Document) []core.KnowledgeDocument {
+	converted := make([]core.KnowledgeDocument, len(docs))
+	for i, doc := range docs {
+		converted[i] = core.KnowledgeDocument{ID: doc.ID, Content: doc.Content, Metadata: doc.Metadata, Timestamp: doc.Timestamp}
+	}
+	return converted
+}
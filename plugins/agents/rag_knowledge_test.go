@@ -0,0 +1,54 @@
+package agents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRAGAgent_KnowledgeBase(t *testing.T) {
+	server, batches := newEmbeddingServer(t)
+	rag := NewRAGAgent("rag")
+	require.NoError(t, rag.Configure(map[string]interface{}{"api_key": "key", "api_url": server.URL + "/v1/chat/completions"}))
+	var agent core.KnowledgeBaseAgent = rag
+	ctx := context.Background()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, agent.AddKnowledge(ctx, []core.KnowledgeDocument{
+		{ID: "orders", Content: "Restart the orders service with kubectl.", Metadata: map[string]interface{}{"source": "runbooks"}, Timestamp: now},
+		{ID: "disk", Content: "Disk alerts fire at 90%.", Timestamp: now},
+	}))
+	assert.Len(t, batches(), 1, "Expected added documents to be embedded at once")
+	rag.AddDocument(Document{ID: "payments", Content: "Payments retry three times."})
+
+	page, err := agent.ListKnowledge(ctx, "", 2)
+	require.NoError(t, err)
+	require.Len(t, page.Documents, 2)
+	assert.Equal(t, "disk", page.Documents[0].ID)
+	assert.Equal(t, "orders", page.Next)
+	assert.Equal(t, "runbooks", page.Documents[1].Metadata["source"])
+	assert.True(t, page.Documents[1].Timestamp.Equal(now))
+	page, err = agent.ListKnowledge(ctx, page.Next, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Documents, 1, "Expected pending documents to be listed")
+	assert.Equal(t, "payments", page.Documents[0].ID)
+	assert.Empty(t, page.Next)
+
+	found, err := agent.SearchKnowledge(ctx, "How do I restart orders?", 5)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "orders", found[0].ID)
+
+	require.NoError(t, agent.DeleteKnowledge(ctx, []string{"orders", "missing"}))
+	found, err = agent.SearchKnowledge(ctx, "How do I restart orders?", 5)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	stats, err := agent.KnowledgeStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats["total_documents"])
+}
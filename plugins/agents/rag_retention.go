@@ -0,0 +1,353 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// Types of the documents metrics become
+const (
+	ragMetricType       = "metric"
+	ragMetricRollupType = "metric_rollup"
+)
+
+const (
+	defaultRetentionInterval = 10 * time.Minute
+	// ragRetentionBatch is how many documents a retention pass reads at a time
+	ragRetentionBatch = 500
+)
+
+// ragRetention bounds the metric documents AddMetricsData adds. Metric documents past the
+// TTL are deleted, or rolled up into one aggregate document per metric, source, and window
+// when rollups are on; each category keeps at most its cap of metric documents.
+type ragRetention struct {
+	ttl time.Duration
+	// rollupWindow is the time each rollup aggregates; zero deletes expired documents
+	rollupWindow time.Duration
+	// rollupTTL is how long rollups are kept; zero keeps them
+	rollupTTL time.Duration
+	// maxDocuments caps the metric documents of a category, deleting the oldest
+	maxDocuments map[string]int
+	interval     time.Duration
+}
+
+// ragRetentionResult counts the documents a retention pass removed
+type ragRetentionResult struct {
+	Expired  int
+	RolledUp int
+	Evicted  int
+}
+
+// parseRAGRetention reads the retention setting; without it metric documents are kept
+func parseRAGRetention(config map[string]interface{}) (*ragRetention, error) {
+	if config["retention"] == nil {
+		return nil, nil
+	}
+	section, ok := config["retention"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("retention must be a map")
+	}
+
+	retention := &ragRetention{interval: defaultRetentionInterval, maxDocuments: make(map[string]int)}
+	for key, target := range map[string]*time.Duration{
+		"ttl":           &retention.ttl,
+		"rollup_window": &retention.rollupWindow,
+		"rollup_ttl":    &retention.rollupTTL,
+		"interval":      &retention.interval,
+	} {
+		raw, ok := section[key]
+		if !ok {
+			continue
+		}
+		text, isString := raw.(string)
+		duration, err := time.ParseDuration(text)
+		if !isString || err != nil || duration < 0 {
+			return nil, fmt.Errorf("invalid retention.%s %v", key, raw)
+		}
+		*target = duration
+	}
+	if retention.interval == 0 {
+		return nil, fmt.Errorf("retention.interval must be positive")
+	}
+	if retention.rollupWindow > 0 && retention.ttl == 0 {
+		return nil, fmt.Errorf("retention.rollup_window needs retention.ttl, as documents are rolled up when they expire")
+	}
+	if retention.rollupTTL > 0 && retention.rollupWindow == 0 {
+		return nil, fmt.Errorf("retention.rollup_ttl needs retention.rollup_window")
+	}
+
+	if raw, ok := section["max_documents"]; ok {
+		caps, isMap := raw.(map[string]interface{})
+		if !isMap {
+			return nil, fmt.Errorf("retention.max_documents must be a map of categories to counts")
+		}
+		for category, value := range caps {
+			if !isRAGCategory(category) {
+				return nil, fmt.Errorf("unknown category %q in retention.max_documents", category)
+			}
+			var limit int
+			switch value := value.(type) {
+			case int:
+				limit = value
+			case float64:
+				limit = int(value)
+			}
+			if limit < 1 {
+				return nil, fmt.Errorf("retention.max_documents.%s must be a positive number", category)
+			}
+			retention.maxDocuments[category] = limit
+		}
+	}
+
+	if retention.ttl == 0 && len(retention.maxDocuments) == 0 {
+		return nil, fmt.Errorf("retention needs ttl or max_documents")
+	}
+	return retention, nil
+}
+
+// isRAGCategory reports whether categorizeDocument files documents under the category
+func isRAGCategory(category string) bool {
+	for _, known := range ragCategories {
+		if known == category {
+			return true
+		}
+	}
+	return false
+}
+
+// retentionLoop applies the retention policy every interval
+func (r *RAGAgent) retentionLoop(ctx context.Context, retention *ragRetention) {
+	ticker := time.NewTicker(retention.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := r.applyRetention(ctx, retention, time.Now())
+			if err != nil {
+				slog.Error("Knowledge base retention failed", "plugin", r.name, "type", "agent", "error", err)
+				continue
+			}
+			if result != (ragRetentionResult{}) {
+				slog.Info("Knowledge base retention applied", "plugin", r.name, "type", "agent",
+					"expired", result.Expired, "rolled_up", result.RolledUp, "evicted", result.Evicted)
+			}
+		}
+	}
+}
+
+// applyRetention removes the metric documents the policy no longer keeps. Pending
+// documents are embedded first, so the vector store holds every document.
+func (r *RAGAgent) applyRetention(ctx context.Context, retention *ragRetention, now time.Time) (ragRetentionResult, error) {
+	var result ragRetentionResult
+	if err := r.embedPending(ctx); err != nil {
+		return result, err
+	}
+	r.mu.RLock()
+	vectors := r.vectors
+	r.mu.RUnlock()
+
+	if retention.ttl > 0 {
+		cutoff := now.Add(-retention.ttl)
+		var rollUp func(docs []Document) error
+		if retention.rollupWindow > 0 {
+			// Only whole windows are rolled up, so a window is not summarized twice
+			cutoff = cutoff.Truncate(retention.rollupWindow)
+			rollUp = func(docs []Document) error {
+				return r.rollUp(ctx, vectors, retention.rollupWindow, docs)
+			}
+		}
+		removed, err := r.removeOldest(ctx, vectors, ragMetricType, "", cutoff, -1, rollUp)
+		if err != nil {
+			return result, err
+		}
+		if rollUp != nil {
+			result.RolledUp += removed
+		} else {
+			result.Expired += removed
+		}
+	}
+
+	if retention.rollupTTL > 0 {
+		removed, err := r.removeOldest(ctx, vectors, ragMetricRollupType, "", now.Add(-retention.rollupTTL), -1, nil)
+		if err != nil {
+			return result, err
+		}
+		result.Expired += removed
+	}
+
+	for _, category := range ragCategories {
+		limit, capped := retention.maxDocuments[category]
+		if !capped {
+			continue
+		}
+		count, err := vectors.count(ctx, ragMetricType, category)
+		if err != nil {
+			return result, err
+		}
+		if count <= limit {
+			continue
+		}
+		removed, err := r.removeOldest(ctx, vectors, ragMetricType, category, time.Time{}, count-limit, nil)
+		if err != nil {
+			return result, err
+		}
+		result.Evicted += removed
+	}
+	return result, nil
+}
+
+// removeOldest removes documents of a type, oldest first, that are timestamped before a
+// time when it is not zero, up to max of them unless it is negative. Each batch is passed
+// to the function, when there is one, before it is removed.
+func (r *RAGAgent) removeOldest(ctx context.Context, vectors ragVectorStore, docType, category string, before time.Time, max int, each func(docs []Document) error) (int, error) {
+	removed := 0
+	for max < 0 || removed < max {
+		limit := ragRetentionBatch
+		if max >= 0 && max-removed < limit {
+			limit = max - removed
+		}
+		docs, err := vectors.oldest(ctx, docType, category, before, limit)
+		if err != nil {
+			return removed, err
+		}
+		if len(docs) == 0 {
+			break
+		}
+		if each != nil {
+			if err := each(docs); err != nil {
+				return removed, err
+			}
+		}
+		ids := make([]string, len(docs))
+		for i, doc := range docs {
+			ids[i] = doc.ID
+		}
+		if err := r.RemoveDocuments(ctx, ids...); err != nil {
+			return removed, err
+		}
+		removed += len(docs)
+	}
+	return removed, nil
+}
+
+// metricRollup aggregates the values of a metric from a source over a window
+type metricRollup struct {
+	metric string
+	source string
+	start  time.Time
+	window time.Duration
+	count  int
+	sum    float64
+	min    float64
+	max    float64
+}
+
+// add adds samples to the rollup
+func (m *metricRollup) add(count int, sum, min, max float64) {
+	if m.count == 0 {
+		m.min, m.max = min, max
+	}
+	m.count += count
+	m.sum += sum
+	m.min = math.Min(m.min, min)
+	m.max = math.Max(m.max, max)
+}
+
+// rollupID names the rollup of a metric's window
+func rollupID(metric, source string, start time.Time) string {
+	return fmt.Sprintf("rollup_%s_%s_%d", metric, source, start.Unix())
+}
+
+// rollUp folds metric documents into the rollups of their windows, merging with rollups
+// stored by earlier passes, and stores the rollups before the documents are removed
+func (r *RAGAgent) rollUp(ctx context.Context, vectors ragVectorStore, window time.Duration, docs []Document) error {
+	rollups := make(map[string]*metricRollup)
+	var ids []string
+	for _, doc := range docs {
+		value, ok := metadataNumber(doc.Metadata["value"])
+		if !ok {
+			continue
+		}
+		metric, _ := doc.Metadata["metric"].(string)
+		source, _ := doc.Metadata["source"].(string)
+		start := doc.Timestamp.Truncate(window)
+		id := rollupID(metric, source, start)
+		rollup, ok := rollups[id]
+		if !ok {
+			rollup = &metricRollup{metric: metric, source: source, start: start, window: window}
+			rollups[id] = rollup
+			ids = append(ids, id)
+		}
+		rollup.add(1, value, value, value)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	stored, err := vectors.get(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, doc := range stored {
+		count, _ := metadataNumber(doc.Metadata["count"])
+		sum, _ := metadataNumber(doc.Metadata["sum"])
+		min, _ := metadataNumber(doc.Metadata["min"])
+		max, _ := metadataNumber(doc.Metadata["max"])
+		if rollup, ok := rollups[doc.ID]; ok && count > 0 {
+			rollup.add(int(count), sum, min, max)
+		}
+	}
+
+	rolled := make([]Document, len(ids))
+	for i, id := range ids {
+		rolled[i] = r.rollupDocument(id, rollups[id])
+	}
+	r.addDocuments(rolled)
+	return r.embedPending(ctx)
+}
+
+// rollupDocument formats a rollup as a document
+func (r *RAGAgent) rollupDocument(id string, rollup *metricRollup) Document {
+	end := rollup.start.Add(rollup.window)
+	format := func(value float64) string {
+		return r.metadata.FormatValue(rollup.metric, value)
+	}
+	average := rollup.sum / float64(rollup.count)
+	return Document{
+		ID: id,
+		Content: fmt.Sprintf("Metric rollup: %s, Source: %s, From: %s, To: %s, Samples: %d, Min: %s, Average: %s, Max: %s",
+			rollup.metric, rollup.source, rollup.start.Format(time.RFC3339), end.Format(time.RFC3339),
+			rollup.count, format(rollup.min), format(average), format(rollup.max)),
+		Metadata: map[string]interface{}{
+			"type":         ragMetricRollupType,
+			"metric":       rollup.metric,
+			"source":       rollup.source,
+			"window_start": rollup.start,
+			"window_end":   end,
+			"count":        rollup.count,
+			"sum":          rollup.sum,
+			"min":          rollup.min,
+			"max":          rollup.max,
+		},
+		Timestamp: rollup.start,
+	}
+}
+
+// metadataNumber reads a number from document metadata, which holds float64s once the
+// document has been stored as JSON
+func metadataNumber(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case float64:
+		return value, true
+	case int:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	}
+	return 0, false
+}
@@ -0,0 +1,178 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRAGRetention(t *testing.T) {
+	retention, err := parseRAGRetention(map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Nil(t, retention)
+
+	retention, err = parseRAGRetention(map[string]interface{}{"retention": map[string]interface{}{
+		"ttl":           "24h",
+		"rollup_window": "1h",
+		"max_documents": map[string]interface{}{"system_metrics": 1000},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, retention.ttl)
+	assert.Equal(t, time.Hour, retention.rollupWindow)
+	assert.Equal(t, time.Duration(0), retention.rollupTTL)
+	assert.Equal(t, defaultRetentionInterval, retention.interval)
+	assert.Equal(t, map[string]int{"system_metrics": 1000}, retention.maxDocuments)
+
+	for _, section := range []interface{}{
+		"24h",
+		map[string]interface{}{},
+		map[string]interface{}{"ttl": "a day"},
+		map[string]interface{}{"ttl": "24h", "interval": "0s"},
+		map[string]interface{}{"rollup_window": "1h"},
+		map[string]interface{}{"ttl": "24h", "rollup_ttl": "720h"},
+		map[string]interface{}{"max_documents": map[string]interface{}{"runbooks": 10}},
+		map[string]interface{}{"max_documents": map[string]interface{}{"errors": 0}},
+	} {
+		_, err := parseRAGRetention(map[string]interface{}{"retention": section})
+		assert.Error(t, err, "Expected %v to be rejected", section)
+	}
+}
+
+// retentionBackends runs a test against the memory and SQLite vector stores
+func retentionBackends(t *testing.T, test func(t *testing.T, vectorStore map[string]interface{})) {
+	t.Run("memory", func(t *testing.T) { test(t, nil) })
+	t.Run("sqlite", func(t *testing.T) {
+		test(t, map[string]interface{}{"backend": VectorStoreSQLite, "path": filepath.Join(t.TempDir(), "rag.db")})
+	})
+}
+
+func TestRAGAgent_RetentionRollsUpExpiredMetrics(t *testing.T) {
+	retentionBackends(t, func(t *testing.T, vectorStore map[string]interface{}) {
+		server, _ := newEmbeddingServer(t)
+		agent := NewRAGAgent("rag")
+		config := map[string]interface{}{
+			"api_key":   "key",
+			"api_url":   server.URL + "/v1/chat/completions",
+			"retention": map[string]interface{}{"ttl": "2h", "rollup_window": "1h", "rollup_ttl": "24h"},
+		}
+		if vectorStore != nil {
+			config["vector_store"] = vectorStore
+		}
+		require.NoError(t, agent.Configure(config))
+		defer agent.vectors.close()
+		agent.SetStore(core.NewMemoryStore())
+		ctx := context.Background()
+
+		day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+		point := func(hour, minute int, value float64) core.DataPoint {
+			return core.DataPoint{Metric: "cpu_usage", Source: "prometheus", Value: value, Timestamp: day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)}
+		}
+		agent.AddMetricsData([]core.DataPoint{point(8, 10, 10), point(8, 40, 30), point(9, 50, 50), point(10, 15, 70), point(12, 0, 90)})
+		agent.AddDocument(Document{ID: "cpu-runbook", Content: "High cpu: scale out.", Metadata: map[string]interface{}{"type": "runbook"}, Timestamp: day})
+
+		// Whole windows before 10:30 minus the TTL are rolled up; 10:15 waits for its window to expire
+		now := day.Add(12*time.Hour + 30*time.Minute)
+		result, err := agent.applyRetention(ctx, agent.retention, now)
+		require.NoError(t, err)
+		assert.Equal(t, ragRetentionResult{RolledUp: 3}, result)
+
+		rollup8 := rollupID("cpu_usage", "prometheus", day.Add(8*time.Hour))
+		rollup9 := rollupID("cpu_usage", "prometheus", day.Add(9*time.Hour))
+		docs, err := agent.vectors.get(ctx, []string{rollup8, rollup9, "cpu-runbook"})
+		require.NoError(t, err)
+		require.Len(t, docs, 3)
+		byID := make(map[string]Document)
+		for _, doc := range docs {
+			byID[doc.ID] = doc
+		}
+		assert.Equal(t, ragMetricRollupType, byID[rollup8].Metadata["type"])
+		assert.EqualValues(t, 2, byID[rollup8].Metadata["count"])
+		assert.EqualValues(t, 10, byID[rollup8].Metadata["min"])
+		assert.EqualValues(t, 30, byID[rollup8].Metadata["max"])
+		assert.Contains(t, byID[rollup8].Content, "Samples: 2")
+		assert.True(t, byID[rollup8].Timestamp.Equal(day.Add(8*time.Hour)))
+		assert.EqualValues(t, 1, byID[rollup9].Metadata["count"])
+
+		remaining, err := agent.vectors.oldest(ctx, ragMetricType, "", time.Time{}, 10)
+		require.NoError(t, err)
+		assert.Len(t, remaining, 2)
+
+		// A late sample is merged into the stored rollup of its window
+		agent.AddMetricsData([]core.DataPoint{point(8, 20, 110)})
+		result, err = agent.applyRetention(ctx, agent.retention, now)
+		require.NoError(t, err)
+		assert.Equal(t, ragRetentionResult{RolledUp: 1}, result)
+		docs, err = agent.vectors.get(ctx, []string{rollup8})
+		require.NoError(t, err)
+		require.Len(t, docs, 1)
+		assert.EqualValues(t, 3, docs[0].Metadata["count"])
+		assert.EqualValues(t, 150, docs[0].Metadata["sum"])
+		assert.EqualValues(t, 110, docs[0].Metadata["max"])
+
+		// A day later the remaining samples are rolled up and rollups past their TTL deleted
+		result, err = agent.applyRetention(ctx, agent.retention, now.Add(22*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, ragRetentionResult{RolledUp: 2, Expired: 3}, result)
+		rollups, err := agent.vectors.oldest(ctx, ragMetricRollupType, "", time.Time{}, 10)
+		require.NoError(t, err)
+		require.Len(t, rollups, 1)
+		assert.Equal(t, rollupID("cpu_usage", "prometheus", day.Add(12*time.Hour)), rollups[0].ID)
+
+		docs, err = agent.vectors.get(ctx, []string{"cpu-runbook"})
+		require.NoError(t, err)
+		assert.Len(t, docs, 1, "Expected documents other than metrics to be kept")
+	})
+}
+
+func TestRAGAgent_RetentionCapsCategories(t *testing.T) {
+	retentionBackends(t, func(t *testing.T, vectorStore map[string]interface{}) {
+		server, _ := newEmbeddingServer(t)
+		agent := NewRAGAgent("rag")
+		config := map[string]interface{}{
+			"api_key":   "key",
+			"api_url":   server.URL + "/v1/chat/completions",
+			"retention": map[string]interface{}{"max_documents": map[string]interface{}{"system_metrics": 2}},
+		}
+		if vectorStore != nil {
+			config["vector_store"] = vectorStore
+		}
+		require.NoError(t, agent.Configure(config))
+		defer agent.vectors.close()
+		store := core.NewMemoryStore()
+		agent.SetStore(store)
+		ctx := context.Background()
+
+		now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		var points []core.DataPoint
+		for i := 0; i < 4; i++ {
+			points = append(points, core.DataPoint{Metric: "cpu_usage", Source: "prometheus", Value: float64(i), Timestamp: now.Add(time.Duration(i) * time.Minute)})
+		}
+		points = append(points, core.DataPoint{Metric: "network_latency", Source: "prometheus", Value: 5, Timestamp: now})
+		agent.AddMetricsData(points)
+
+		result, err := agent.applyRetention(ctx, agent.retention, now)
+		require.NoError(t, err)
+		assert.Equal(t, ragRetentionResult{Evicted: 2}, result)
+
+		remaining, err := agent.vectors.oldest(ctx, ragMetricType, "", time.Time{}, 10)
+		require.NoError(t, err)
+		var ids []string
+		for _, doc := range remaining {
+			ids = append(ids, doc.ID)
+		}
+		assert.ElementsMatch(t, []string{"metric_network_latency_" + fmt.Sprint(now.Unix()), "metric_cpu_usage_" + fmt.Sprint(now.Add(2*time.Minute).Unix()),
+			"metric_cpu_usage_" + fmt.Sprint(now.Add(3*time.Minute).Unix())}, ids)
+
+		if vectorStore == nil {
+			records, err := store.List(ctx, core.StoreCollectionDocuments)
+			require.NoError(t, err)
+			assert.Len(t, records, 3, "Expected evicted documents to leave the framework's store")
+		}
+	})
+}
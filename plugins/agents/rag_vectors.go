@@ -0,0 +1,309 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Vector store backends selectable in the RAG agent's vector_store config
+const (
+	VectorStoreMemory   = "memory"
+	VectorStoreSQLite   = "sqlite"
+	VectorStorePGVector = "pgvector"
+	VectorStoreQdrant   = "qdrant"
+)
+
+// ragVector is a document with the vector it was embedded as
+type ragVector struct {
+	document Document
+	category string
+	// model identifies the embeddings model; vectors of different models cannot be compared
+	model  string
+	vector []float64
+}
+
+// ragVectorStore keeps a RAG agent's documents with their vectors and finds the ones
+// nearest a question. Each store holds the documents of one agent.
+type ragVectorStore interface {
+	// upsert stores documents, replacing any with the same IDs
+	upsert(ctx context.Context, entries []ragVector) error
+	// remove deletes documents; removing a missing document is not an error
+	remove(ctx context.Context, ids []string) error
+	// search returns up to limit documents embedded with the model whose cosine similarity
+	// to the vector is above minScore, most similar first
+	search(ctx context.Context, model string, vector []float64, limit int, minScore float64) ([]ScoredDocument, error)
+	// stale returns up to limit documents embedded with a model other than the given one
+	stale(ctx context.Context, model string, limit int) ([]Document, error)
+	// page returns up to limit documents after an offset, which is "" for the first page,
+	// and the offset of the next page; it is "" when no documents follow, though the next
+	// page may also turn out empty
+	page(ctx context.Context, offset string, limit int) ([]Document, string, error)
+	// get returns the documents with the IDs that are stored
+	get(ctx context.Context, ids []string) ([]Document, error)
+	// oldest returns up to limit documents of a type, oldest first, keeping to a category
+	// and to documents timestamped before a time when they are not zero
+	oldest(ctx context.Context, docType, category string, before time.Time, limit int) ([]Document, error)
+	// count returns the number of documents of a type in a category
+	count(ctx context.Context, docType, category string) (int, error)
+	// counts returns the number of documents in each category
+	counts(ctx context.Context) (map[string]int, error)
+	// persistent reports whether the documents survive a restart
+	persistent() bool
+	close() error
+}
+
+// documentType returns the type a document's metadata gives it, such as metric or runbook
+func documentType(doc Document) string {
+	docType, _ := doc.Metadata["type"].(string)
+	return docType
+}
+
+// openRAGVectorStore opens the backend the vector_store setting selects; without the
+// setting documents are kept in memory
+func openRAGVectorStore(value interface{}, agent string) (ragVectorStore, error) {
+	section, ok := value.(map[string]interface{})
+	if !ok && value != nil {
+		return nil, fmt.Errorf("vector_store must be a map")
+	}
+	settings := make(map[string]string, len(section))
+	for key, raw := range section {
+		if key == "dimensions" {
+			continue
+		}
+		text, isString := raw.(string)
+		if !isString {
+			return nil, fmt.Errorf("vector_store.%s must be a string", key)
+		}
+		settings[key] = text
+	}
+	required := func(key string) (string, error) {
+		if settings[key] == "" {
+			return "", fmt.Errorf("vector_store.%s is required for the %s backend", key, settings["backend"])
+		}
+		return settings[key], nil
+	}
+
+	switch settings["backend"] {
+	case "", VectorStoreMemory:
+		return newMemoryVectorStore(), nil
+	case VectorStoreSQLite:
+		path, err := required("path")
+		if err != nil {
+			return nil, err
+		}
+		return openSQLiteVectorStore(path, agent)
+	case VectorStorePGVector:
+		dsn, err := required("dsn")
+		if err != nil {
+			return nil, err
+		}
+		var dimensions int
+		switch raw := section["dimensions"].(type) {
+		case int:
+			dimensions = raw
+		case float64:
+			dimensions = int(raw)
+		}
+		if dimensions < 1 {
+			return nil, fmt.Errorf("vector_store.dimensions is required for the pgvector backend, as the size of the model's vectors")
+		}
+		table := settings["table"]
+		if table == "" {
+			table = defaultPGVectorTable
+		}
+		return openPGVectorStore(dsn, table, dimensions, agent)
+	case VectorStoreQdrant:
+		url, err := required("url")
+		if err != nil {
+			return nil, err
+		}
+		collection := settings["collection"]
+		if collection == "" {
+			collection = defaultQdrantCollection
+		}
+		return newQdrantVectorStore(url, settings["api_key"], collection, agent), nil
+	default:
+		return nil, fmt.Errorf("unknown vector_store backend %q", settings["backend"])
+	}
+}
+
+// memoryVectorStore keeps documents in memory and compares the question with each of them
+type memoryVectorStore struct {
+	entries map[string]ragVector
+	mu      sync.RWMutex
+}
+
+func newMemoryVectorStore() *memoryVectorStore {
+	return &memoryVectorStore{entries: make(map[string]ragVector)}
+}
+
+func (s *memoryVectorStore) upsert(ctx context.Context, entries []ragVector) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range entries {
+		s.entries[entry.document.ID] = entry
+	}
+	return nil
+}
+
+func (s *memoryVectorStore) remove(ctx context.Context, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		delete(s.entries, id)
+	}
+	return nil
+}
+
+func (s *memoryVectorStore) search(ctx context.Context, model string, vector []float64, limit int, minScore float64) ([]ScoredDocument, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var scored []ScoredDocument
+	for _, entry := range s.entries {
+		if entry.model != model {
+			continue
+		}
+		if similarity := cosineSimilarity(vector, entry.vector); similarity > minScore {
+			scored = append(scored, ScoredDocument{Document: entry.document, Score: similarity, Category: entry.category})
+		}
+	}
+	return topScored(scored, limit), nil
+}
+
+func (s *memoryVectorStore) stale(ctx context.Context, model string, limit int) ([]Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var docs []Document
+	for _, entry := range s.entries {
+		if len(docs) == limit {
+			break
+		}
+		if entry.model != model {
+			docs = append(docs, entry.document)
+		}
+	}
+	return docs, nil
+}
+
+func (s *memoryVectorStore) page(ctx context.Context, offset string, limit int) ([]Document, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.entries))
+	for id := range s.entries {
+		if id > offset {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	next := ""
+	if len(ids) > limit {
+		ids = ids[:limit]
+		next = ids[limit-1]
+	}
+	docs := make([]Document, len(ids))
+	for i, id := range ids {
+		docs[i] = s.entries[id].document
+	}
+	return docs, next, nil
+}
+
+func (s *memoryVectorStore) get(ctx context.Context, ids []string) ([]Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var docs []Document
+	for _, id := range ids {
+		if entry, ok := s.entries[id]; ok {
+			docs = append(docs, entry.document)
+		}
+	}
+	return docs, nil
+}
+
+func (s *memoryVectorStore) oldest(ctx context.Context, docType, category string, before time.Time, limit int) ([]Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var docs []Document
+	for _, entry := range s.entries {
+		if documentType(entry.document) != docType || (category != "" && entry.category != category) {
+			continue
+		}
+		if before.IsZero() || entry.document.Timestamp.Before(before) {
+			docs = append(docs, entry.document)
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].Timestamp.Before(docs[j].Timestamp)
+	})
+	if len(docs) > limit {
+		docs = docs[:limit]
+	}
+	return docs, nil
+}
+
+func (s *memoryVectorStore) count(ctx context.Context, docType, category string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, entry := range s.entries {
+		if documentType(entry.document) == docType && entry.category == category {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *memoryVectorStore) counts(ctx context.Context) (map[string]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, entry := range s.entries {
+		counts[entry.category]++
+	}
+	return counts, nil
+}
+
+func (s *memoryVectorStore) persistent() bool { return false }
+
+func (s *memoryVectorStore) close() error { return nil }
+
+// topScored sorts documents by score and keeps the best limit of them
+func topScored(scored []ScoredDocument, limit int) []ScoredDocument {
+	sort.Slice(scored, func(i, j int) bool {
+		return scored[i].Score > scored[j].Score
+	})
+	if len(scored) > limit {
+		scored = scored[:limit]
+	}
+	return scored
+}
+
+// cosineSimilarity calculates cosine similarity between two embeddings
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dotProduct, normA, normB float64
+	for i := range a {
+		dotProduct += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dotProduct / (math.Sqrt(normA) * math.Sqrt(normB))
+}
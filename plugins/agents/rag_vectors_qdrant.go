@@ -0,0 +1,361 @@
+package agents
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// defaultQdrantCollection is the collection the qdrant backend keeps documents in
+const defaultQdrantCollection = "agent_rag"
+
+// qdrantVectorStore keeps documents as points of a Qdrant collection, which is created with
+// the size of the first vectors stored. Points carry the agent, model, category, and
+// document as their payload, with the document's type and timestamp to find old ones by.
+type qdrantVectorStore struct {
+	url        string
+	apiKey     string
+	collection string
+	agent      string
+	client     *http.Client
+	// created is set once the collection is known to exist
+	created bool
+	mu      sync.Mutex
+}
+
+func newQdrantVectorStore(url, apiKey, collection, agent string) *qdrantVectorStore {
+	return &qdrantVectorStore{
+		url:        strings.TrimSuffix(url, "/"),
+		apiKey:     apiKey,
+		collection: collection,
+		agent:      agent,
+		client:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// qdrantPayload is what a point stores besides its vector
+type qdrantPayload struct {
+	Agent    string `json:"agent"`
+	Model    string `json:"model"`
+	Category string `json:"category"`
+	Type     string `json:"type"`
+	// Timestamp is the document's timestamp in Unix seconds
+	Timestamp int64    `json:"timestamp"`
+	Document  Document `json:"document"`
+}
+
+// qdrantPoint is a point as Qdrant returns it
+type qdrantPoint struct {
+	Score   float64       `json:"score"`
+	Payload qdrantPayload `json:"payload"`
+}
+
+// call sends a request to the Qdrant API and decodes the result field of the response;
+// it reports whether the API found what was asked for
+func (s *qdrantVectorStore) call(ctx context.Context, method, path string, body, result interface{}) (bool, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return false, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.url+path, reader)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("api-key", s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "qdrant", "Qdrant request failed")
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "qdrant", "Qdrant request failed")
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Status struct {
+				Error string `json:"error"`
+			} `json:"status"`
+		}
+		_ = json.Unmarshal(data, &failure)
+		return false, core.NewPluginError("rag-agent", "qdrant", fmt.Sprintf("Qdrant returned status %d: %s", resp.StatusCode, failure.Status.Error))
+	}
+	if result == nil {
+		return true, nil
+	}
+	var response struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return false, core.WrapError(err, core.ErrorTypeInternal, "rag-agent", "qdrant", "failed to decode Qdrant response")
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return false, core.WrapError(err, core.ErrorTypeInternal, "rag-agent", "qdrant", "failed to decode Qdrant response")
+	}
+	return true, nil
+}
+
+// ensureCollection creates the collection with cosine distance if it does not exist yet
+func (s *qdrantVectorStore) ensureCollection(ctx context.Context, size int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.created {
+		return nil
+	}
+	exists, err := s.call(ctx, http.MethodGet, "/collections/"+s.collection, nil, nil)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		config := map[string]interface{}{"vectors": map[string]interface{}{"size": size, "distance": "Cosine"}}
+		if _, err := s.call(ctx, http.MethodPut, "/collections/"+s.collection, config, nil); err != nil {
+			return err
+		}
+		// Timestamps are indexed as integers, so old documents are scrolled in order
+		for _, field := range [][2]string{{"agent", "keyword"}, {"model", "keyword"}, {"type", "keyword"}, {"timestamp", "integer"}} {
+			index := map[string]interface{}{"field_name": field[0], "field_schema": field[1]}
+			if _, err := s.call(ctx, http.MethodPut, "/collections/"+s.collection+"/index?wait=true", index, nil); err != nil {
+				return err
+			}
+		}
+	}
+	s.created = true
+	return nil
+}
+
+// pointID derives a point ID from the agent and document ID, as Qdrant IDs are UUIDs or
+// numbers
+func (s *qdrantVectorStore) pointID(docID string) string {
+	sum := sha256.Sum256([]byte(s.agent + "/" + docID))
+	id := hex.EncodeToString(sum[:16])
+	return id[0:8] + "-" + id[8:12] + "-" + id[12:16] + "-" + id[16:20] + "-" + id[20:32]
+}
+
+// filter matches the points of the agent, with or without the model
+func (s *qdrantVectorStore) filter(must, mustNot map[string]string) map[string]interface{} {
+	conditions := func(fields map[string]string) []interface{} {
+		var matches []interface{}
+		for key, value := range fields {
+			matches = append(matches, map[string]interface{}{"key": key, "match": map[string]interface{}{"value": value}})
+		}
+		return matches
+	}
+	filter := map[string]interface{}{"must": conditions(must)}
+	if len(mustNot) > 0 {
+		filter["must_not"] = conditions(mustNot)
+	}
+	return filter
+}
+
+func (s *qdrantVectorStore) upsert(ctx context.Context, entries []ragVector) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := s.ensureCollection(ctx, len(entries[0].vector)); err != nil {
+		return err
+	}
+	points := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		points[i] = map[string]interface{}{
+			"id":     s.pointID(entry.document.ID),
+			"vector": entry.vector,
+			"payload": qdrantPayload{
+				Agent:     s.agent,
+				Model:     entry.model,
+				Category:  entry.category,
+				Type:      documentType(entry.document),
+				Timestamp: entry.document.Timestamp.Unix(),
+				Document:  entry.document,
+			},
+		}
+	}
+	_, err := s.call(ctx, http.MethodPut, "/collections/"+s.collection+"/points?wait=true", map[string]interface{}{"points": points}, nil)
+	return err
+}
+
+func (s *qdrantVectorStore) remove(ctx context.Context, ids []string) error {
+	points := make([]string, len(ids))
+	for i, id := range ids {
+		points[i] = s.pointID(id)
+	}
+	_, err := s.call(ctx, http.MethodPost, "/collections/"+s.collection+"/points/delete?wait=true", map[string]interface{}{"points": points}, nil)
+	return err
+}
+
+func (s *qdrantVectorStore) search(ctx context.Context, model string, vector []float64, limit int, minScore float64) ([]ScoredDocument, error) {
+	var points []qdrantPoint
+	found, err := s.call(ctx, http.MethodPost, "/collections/"+s.collection+"/points/search", map[string]interface{}{
+		"vector":          vector,
+		"limit":           limit,
+		"score_threshold": minScore,
+		"with_payload":    true,
+		"filter":          s.filter(map[string]string{"agent": s.agent, "model": model}, nil),
+	}, &points)
+	if err != nil || !found {
+		return nil, err
+	}
+	scored := make([]ScoredDocument, 0, len(points))
+	for _, point := range points {
+		if point.Score > minScore {
+			scored = append(scored, ScoredDocument{Document: point.Payload.Document, Score: point.Score, Category: point.Payload.Category})
+		}
+	}
+	return scored, nil
+}
+
+func (s *qdrantVectorStore) stale(ctx context.Context, model string, limit int) ([]Document, error) {
+	var page struct {
+		Points []qdrantPoint `json:"points"`
+	}
+	found, err := s.call(ctx, http.MethodPost, "/collections/"+s.collection+"/points/scroll", map[string]interface{}{
+		"limit":        limit,
+		"with_payload": true,
+		"filter":       s.filter(map[string]string{"agent": s.agent}, map[string]string{"model": model}),
+	}, &page)
+	if err != nil || !found {
+		return nil, err
+	}
+	docs := make([]Document, len(page.Points))
+	for i, point := range page.Points {
+		docs[i] = point.Payload.Document
+	}
+	return docs, nil
+}
+
+func (s *qdrantVectorStore) page(ctx context.Context, offset string, limit int) ([]Document, string, error) {
+	request := map[string]interface{}{
+		"limit":        limit,
+		"with_payload": true,
+		"filter":       s.filter(map[string]string{"agent": s.agent}, nil),
+	}
+	if offset != "" {
+		request["offset"] = offset
+	}
+	var page struct {
+		Points []qdrantPoint `json:"points"`
+		// NextPageOffset is the point ID the next page starts at
+		NextPageOffset *string `json:"next_page_offset"`
+	}
+	found, err := s.call(ctx, http.MethodPost, "/collections/"+s.collection+"/points/scroll", request, &page)
+	if err != nil || !found {
+		return nil, "", err
+	}
+	docs := make([]Document, len(page.Points))
+	for i, point := range page.Points {
+		docs[i] = point.Payload.Document
+	}
+	next := ""
+	if page.NextPageOffset != nil {
+		next = *page.NextPageOffset
+	}
+	return docs, next, nil
+}
+
+func (s *qdrantVectorStore) get(ctx context.Context, ids []string) ([]Document, error) {
+	points := make([]string, len(ids))
+	for i, id := range ids {
+		points[i] = s.pointID(id)
+	}
+	var found []qdrantPoint
+	exists, err := s.call(ctx, http.MethodPost, "/collections/"+s.collection+"/points", map[string]interface{}{
+		"ids":          points,
+		"with_payload": true,
+	}, &found)
+	if err != nil || !exists {
+		return nil, err
+	}
+	docs := make([]Document, len(found))
+	for i, point := range found {
+		docs[i] = point.Payload.Document
+	}
+	return docs, nil
+}
+
+func (s *qdrantVectorStore) oldest(ctx context.Context, docType, category string, before time.Time, limit int) ([]Document, error) {
+	must := map[string]string{"agent": s.agent, "type": docType}
+	if category != "" {
+		must["category"] = category
+	}
+	filter := s.filter(must, nil)
+	if !before.IsZero() {
+		conditions := filter["must"].([]interface{})
+		filter["must"] = append(conditions, map[string]interface{}{"key": "timestamp", "range": map[string]interface{}{"lt": before.Unix()}})
+	}
+	var page struct {
+		Points []qdrantPoint `json:"points"`
+	}
+	found, err := s.call(ctx, http.MethodPost, "/collections/"+s.collection+"/points/scroll", map[string]interface{}{
+		"limit":        limit,
+		"with_payload": true,
+		"filter":       filter,
+		"order_by":     map[string]interface{}{"key": "timestamp", "direction": "asc"},
+	}, &page)
+	if err != nil || !found {
+		return nil, err
+	}
+	docs := make([]Document, len(page.Points))
+	for i, point := range page.Points {
+		docs[i] = point.Payload.Document
+	}
+	return docs, nil
+}
+
+func (s *qdrantVectorStore) count(ctx context.Context, docType, category string) (int, error) {
+	var result struct {
+		Count int `json:"count"`
+	}
+	_, err := s.call(ctx, http.MethodPost, "/collections/"+s.collection+"/points/count", map[string]interface{}{
+		"exact":  true,
+		"filter": s.filter(map[string]string{"agent": s.agent, "type": docType, "category": category}, nil),
+	}, &result)
+	return result.Count, err
+}
+
+// counts counts the documents of each category the agent files documents under
+func (s *qdrantVectorStore) counts(ctx context.Context) (map[string]int, error) {
+	counts := make(map[string]int)
+	for _, category := range ragCategories {
+		var result struct {
+			Count int `json:"count"`
+		}
+		found, err := s.call(ctx, http.MethodPost, "/collections/"+s.collection+"/points/count", map[string]interface{}{
+			"exact":  true,
+			"filter": s.filter(map[string]string{"agent": s.agent, "category": category}, nil),
+		}, &result)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return counts, nil
+		}
+		if result.Count > 0 {
+			counts[category] = result.Count
+		}
+	}
+	return counts, nil
+}
+
+func (s *qdrantVectorStore) persistent() bool { return true }
+
+func (s *qdrantVectorStore) close() error { return nil }
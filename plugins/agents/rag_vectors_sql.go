@@ -0,0 +1,397 @@
+package agents
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// defaultPGVectorTable is the table the pgvector backend keeps documents in
+const defaultPGVectorTable = "agent_rag_vectors"
+
+// sqliteScanWarnDocuments is how many vectors a SQLite search reads before the store warns
+// that searches will slow down as the knowledge base grows
+const sqliteScanWarnDocuments = 50000
+
+// sqlIdentifier matches table names that are safe to put into statements
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// sqlVectorDialect holds the statements of a SQL vector store. Each takes the agent as
+// its first argument.
+type sqlVectorDialect struct {
+	schema []string
+	upsert string
+	remove string
+	// nearest orders the documents by distance in the database, taking the model, the
+	// vector, and the limit; without it every vector of the model is read and compared
+	nearest string
+	// scan reads every vector of a model
+	scan  string
+	stale string
+	get   string
+	// page takes the ID to start after and the limit
+	page string
+	// oldest takes the type, the category or "", the time as Unix seconds, and the limit
+	oldest string
+	count  string
+	counts string
+	// vector converts a vector to the value of the embedding column
+	vector func(vector []float64) interface{}
+}
+
+// sqlVectorStore keeps documents and vectors in a SQLite file or a Postgres database with
+// the pgvector extension
+type sqlVectorStore struct {
+	db      *sql.DB
+	dialect sqlVectorDialect
+	agent   string
+	// scanWarn is how many vectors a search without nearest reads before warning, once
+	scanWarn int
+	warned   atomic.Bool
+}
+
+// openSQLiteVectorStore keeps documents in a SQLite file. SQLite has no vector index, so a
+// search reads every vector of the model from disk and compares it in Go, taking time in
+// proportion to the size of the knowledge base.
+func openSQLiteVectorStore(path, agent string) (*sqlVectorStore, error) {
+	dialect := sqlVectorDialect{
+		schema: []string{
+			`CREATE TABLE IF NOT EXISTS rag_vectors (
+				agent TEXT NOT NULL,
+				id TEXT NOT NULL,
+				model TEXT NOT NULL,
+				category TEXT NOT NULL,
+				doc_type TEXT NOT NULL,
+				doc_time INTEGER NOT NULL,
+				document BLOB NOT NULL,
+				embedding BLOB NOT NULL,
+				PRIMARY KEY (agent, id))`,
+			`CREATE INDEX IF NOT EXISTS rag_vectors_model ON rag_vectors (agent, model)`,
+			`CREATE INDEX IF NOT EXISTS rag_vectors_type ON rag_vectors (agent, doc_type, doc_time)`,
+		},
+		upsert: `INSERT INTO rag_vectors (agent, id, model, category, doc_type, doc_time, document, embedding) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (agent, id) DO UPDATE SET model = excluded.model, category = excluded.category,
+			doc_type = excluded.doc_type, doc_time = excluded.doc_time, document = excluded.document, embedding = excluded.embedding`,
+		remove: `DELETE FROM rag_vectors WHERE agent = ? AND id = ?`,
+		scan:   `SELECT document, category, embedding FROM rag_vectors WHERE agent = ? AND model = ?`,
+		stale:  `SELECT document FROM rag_vectors WHERE agent = ? AND model <> ? LIMIT ?`,
+		get:    `SELECT document FROM rag_vectors WHERE agent = ? AND id = ?`,
+		page:   `SELECT document FROM rag_vectors WHERE agent = ? AND id > ? ORDER BY id LIMIT ?`,
+		oldest: `SELECT document FROM rag_vectors WHERE agent = ?1 AND doc_type = ?2 AND (?3 = '' OR category = ?3)
+			AND doc_time < ?4 ORDER BY doc_time LIMIT ?5`,
+		count:  `SELECT COUNT(*) FROM rag_vectors WHERE agent = ? AND doc_type = ? AND category = ?`,
+		counts: `SELECT category, COUNT(*) FROM rag_vectors WHERE agent = ? GROUP BY category`,
+		vector: encodeVector,
+	}
+	store, err := openSQLVectorStore("sqlite", path, dialect, agent)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows one writer at a time
+	store.db.SetMaxOpenConns(1)
+	store.scanWarn = sqliteScanWarnDocuments
+	return store, nil
+}
+
+// openPGVectorStore keeps documents in a Postgres table with an HNSW index, so the nearest
+// documents are found in the database
+func openPGVectorStore(dsn, table string, dimensions int, agent string) (*sqlVectorStore, error) {
+	if !sqlIdentifier.MatchString(table) {
+		return nil, fmt.Errorf("vector_store.table %q is not a valid table name", table)
+	}
+	dialect := sqlVectorDialect{
+		schema: []string{
+			`CREATE EXTENSION IF NOT EXISTS vector`,
+			fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+				agent TEXT NOT NULL,
+				id TEXT NOT NULL,
+				model TEXT NOT NULL,
+				category TEXT NOT NULL,
+				doc_type TEXT NOT NULL,
+				doc_time BIGINT NOT NULL,
+				document JSONB NOT NULL,
+				embedding vector(%d) NOT NULL,
+				PRIMARY KEY (agent, id))`, table, dimensions),
+			fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_embedding ON %s USING hnsw (embedding vector_cosine_ops)`, table, table),
+			fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_type ON %s (agent, doc_type, doc_time)`, table, table),
+		},
+		upsert: fmt.Sprintf(`INSERT INTO %s (agent, id, model, category, doc_type, doc_time, document, embedding)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8::vector)
+			ON CONFLICT (agent, id) DO UPDATE SET model = EXCLUDED.model, category = EXCLUDED.category,
+			doc_type = EXCLUDED.doc_type, doc_time = EXCLUDED.doc_time, document = EXCLUDED.document,
+			embedding = EXCLUDED.embedding`, table),
+		remove: fmt.Sprintf(`DELETE FROM %s WHERE agent = $1 AND id = $2`, table),
+		nearest: fmt.Sprintf(`SELECT document, category, 1 - (embedding <=> $3::vector) FROM %s
+			WHERE agent = $1 AND model = $2 ORDER BY embedding <=> $3::vector LIMIT $4`, table),
+		stale: fmt.Sprintf(`SELECT document FROM %s WHERE agent = $1 AND model <> $2 LIMIT $3`, table),
+		get:   fmt.Sprintf(`SELECT document FROM %s WHERE agent = $1 AND id = $2`, table),
+		page:  fmt.Sprintf(`SELECT document FROM %s WHERE agent = $1 AND id > $2 ORDER BY id LIMIT $3`, table),
+		oldest: fmt.Sprintf(`SELECT document FROM %s WHERE agent = $1 AND doc_type = $2 AND ($3 = '' OR category = $3)
+			AND doc_time < $4 ORDER BY doc_time LIMIT $5`, table),
+		count:  fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE agent = $1 AND doc_type = $2 AND category = $3`, table),
+		counts: fmt.Sprintf(`SELECT category, COUNT(*) FROM %s WHERE agent = $1 GROUP BY category`, table),
+		vector: pgVectorLiteral,
+	}
+	return openSQLVectorStore("postgres", dsn, dialect, agent)
+}
+
+// openSQLVectorStore connects to the database and creates the vector table if needed
+func openSQLVectorStore(driver, dsn string, dialect sqlVectorDialect, agent string) (*sqlVectorStore, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, core.WrapError(err, core.ErrorTypeConfiguration, "rag-agent", "open-vector-store", fmt.Sprintf("failed to open %s vector store", driver))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, statement := range dialect.schema {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			db.Close()
+			return nil, core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "open-vector-store", fmt.Sprintf("failed to create %s vector table", driver))
+		}
+	}
+	return &sqlVectorStore{db: db, dialect: dialect, agent: agent}, nil
+}
+
+func (s *sqlVectorStore) upsert(ctx context.Context, entries []ragVector) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "upsert", "failed to store documents")
+	}
+	defer tx.Rollback()
+
+	for _, entry := range entries {
+		document, err := json.Marshal(entry.document)
+		if err != nil {
+			return core.WrapError(err, core.ErrorTypeInternal, "rag-agent", "upsert", fmt.Sprintf("failed to encode document %s", entry.document.ID))
+		}
+		if _, err := tx.ExecContext(ctx, s.dialect.upsert, s.agent, entry.document.ID, entry.model, entry.category,
+			documentType(entry.document), entry.document.Timestamp.Unix(), document, s.dialect.vector(entry.vector)); err != nil {
+			return core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "upsert", fmt.Sprintf("failed to store document %s", entry.document.ID))
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "upsert", "failed to store documents")
+	}
+	return nil
+}
+
+func (s *sqlVectorStore) remove(ctx context.Context, ids []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "remove", "failed to remove documents")
+	}
+	defer tx.Rollback()
+
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, s.dialect.remove, s.agent, id); err != nil {
+			return core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "remove", fmt.Sprintf("failed to remove document %s", id))
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "remove", "failed to remove documents")
+	}
+	return nil
+}
+
+func (s *sqlVectorStore) search(ctx context.Context, model string, vector []float64, limit int, minScore float64) ([]ScoredDocument, error) {
+	if s.dialect.nearest == "" {
+		return s.scan(ctx, model, vector, limit, minScore)
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.dialect.nearest, s.agent, model, s.dialect.vector(vector), limit)
+	if err != nil {
+		return nil, core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "search", "failed to search documents")
+	}
+	defer rows.Close()
+
+	var scored []ScoredDocument
+	for rows.Next() {
+		var document []byte
+		var match ScoredDocument
+		if err := rows.Scan(&document, &match.Category, &match.Score); err != nil {
+			return nil, core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "search", "failed to read documents")
+		}
+		if match.Score <= minScore {
+			break // The rest are further away
+		}
+		if err := json.Unmarshal(document, &match.Document); err != nil {
+			return nil, core.WrapError(err, core.ErrorTypeInternal, "rag-agent", "search", "failed to decode document")
+		}
+		scored = append(scored, match)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "search", "failed to search documents")
+	}
+	return scored, nil
+}
+
+// scan compares the vector with every vector of the model, keeping only the best matches
+// in memory
+func (s *sqlVectorStore) scan(ctx context.Context, model string, vector []float64, limit int, minScore float64) ([]ScoredDocument, error) {
+	rows, err := s.db.QueryContext(ctx, s.dialect.scan, s.agent, model)
+	if err != nil {
+		return nil, core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "search", "failed to search documents")
+	}
+	defer rows.Close()
+
+	var scored []ScoredDocument
+	scanned := 0
+	for rows.Next() {
+		scanned++
+		var document, embedding []byte
+		var category string
+		if err := rows.Scan(&document, &category, &embedding); err != nil {
+			return nil, core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "search", "failed to read documents")
+		}
+		similarity := cosineSimilarity(vector, decodeVector(embedding))
+		if similarity <= minScore {
+			continue
+		}
+		match := ScoredDocument{Score: similarity, Category: category}
+		if err := json.Unmarshal(document, &match.Document); err != nil {
+			return nil, core.WrapError(err, core.ErrorTypeInternal, "rag-agent", "search", "failed to decode document")
+		}
+		scored = append(scored, match)
+		if len(scored) >= 2*limit {
+			scored = topScored(scored, limit)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "search", "failed to search documents")
+	}
+	if s.scanWarn > 0 && scanned >= s.scanWarn && s.warned.CompareAndSwap(false, true) {
+		slog.Warn("Vector store searches read every vector; use the pgvector or qdrant backend for a knowledge base this large",
+			"plugin", s.agent, "type", "agent", "vectors", scanned)
+	}
+	return topScored(scored, limit), nil
+}
+
+func (s *sqlVectorStore) stale(ctx context.Context, model string, limit int) ([]Document, error) {
+	return s.documents(ctx, "stale", s.dialect.stale, s.agent, model, limit)
+}
+
+func (s *sqlVectorStore) page(ctx context.Context, offset string, limit int) ([]Document, string, error) {
+	docs, err := s.documents(ctx, "page", s.dialect.page, s.agent, offset, limit)
+	if err != nil || len(docs) < limit {
+		return docs, "", err
+	}
+	return docs, docs[len(docs)-1].ID, nil
+}
+
+func (s *sqlVectorStore) get(ctx context.Context, ids []string) ([]Document, error) {
+	var docs []Document
+	for _, id := range ids {
+		found, err := s.documents(ctx, "get", s.dialect.get, s.agent, id)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, found...)
+	}
+	return docs, nil
+}
+
+func (s *sqlVectorStore) oldest(ctx context.Context, docType, category string, before time.Time, limit int) ([]Document, error) {
+	until := int64(math.MaxInt64)
+	if !before.IsZero() {
+		until = before.Unix()
+	}
+	return s.documents(ctx, "oldest", s.dialect.oldest, s.agent, docType, category, until, limit)
+}
+
+// documents runs a query selecting documents
+func (s *sqlVectorStore) documents(ctx context.Context, operation, query string, args ...interface{}) ([]Document, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", operation, "failed to list documents")
+	}
+	defer rows.Close()
+
+	var docs []Document
+	for rows.Next() {
+		var document []byte
+		if err := rows.Scan(&document); err != nil {
+			return nil, core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", operation, "failed to read documents")
+		}
+		var doc Document
+		if err := json.Unmarshal(document, &doc); err != nil {
+			return nil, core.WrapError(err, core.ErrorTypeInternal, "rag-agent", operation, "failed to decode document")
+		}
+		docs = append(docs, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", operation, "failed to list documents")
+	}
+	return docs, nil
+}
+
+func (s *sqlVectorStore) count(ctx context.Context, docType, category string) (int, error) {
+	var count int
+	if err := s.db.QueryRowContext(ctx, s.dialect.count, s.agent, docType, category).Scan(&count); err != nil {
+		return 0, core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "count", "failed to count documents")
+	}
+	return count, nil
+}
+
+func (s *sqlVectorStore) counts(ctx context.Context) (map[string]int, error) {
+	rows, err := s.db.QueryContext(ctx, s.dialect.counts, s.agent)
+	if err != nil {
+		return nil, core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "counts", "failed to count documents")
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var category string
+		var count int
+		if err := rows.Scan(&category, &count); err != nil {
+			return nil, core.WrapError(err, core.ErrorTypeNetwork, "rag-agent", "counts", "failed to count documents")
+		}
+		counts[category] = count
+	}
+	return counts, rows.Err()
+}
+
+func (s *sqlVectorStore) persistent() bool { return true }
+
+func (s *sqlVectorStore) close() error {
+	return s.db.Close()
+}
+
+// encodeVector packs a vector into little-endian float64s
+func encodeVector(vector []float64) interface{} {
+	data := make([]byte, 8*len(vector))
+	for i, value := range vector {
+		binary.LittleEndian.PutUint64(data[8*i:], math.Float64bits(value))
+	}
+	return data
+}
+
+// decodeVector unpacks a vector packed by encodeVector
+func decodeVector(data []byte) []float64 {
+	vector := make([]float64, len(data)/8)
+	for i := range vector {
+		vector[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+	}
+	return vector
+}
+
+// pgVectorLiteral formats a vector as pgvector's text input, such as [0.1,0.2]
+func pgVectorLiteral(vector []float64) interface{} {
+	values := make([]string, len(vector))
+	for i, value := range vector {
+		values[i] = strconv.FormatFloat(value, 'g', -1, 64)
+	}
+	return "[" + strings.Join(values, ",") + "]"
+}
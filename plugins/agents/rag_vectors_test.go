@@ -0,0 +1,215 @@
+package agents
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testVectorStoreContract checks the behaviour every vector store backend must share
+func testVectorStoreContract(t *testing.T, open func(agent string) ragVectorStore) {
+	ctx := context.Background()
+	store := open("rag")
+	other := open("other-rag")
+
+	entry := func(id, content, model string, vector ...float64) ragVector {
+		return ragVector{document: Document{ID: id, Content: content}, category: "general", model: model, vector: vector}
+	}
+	require.NoError(t, store.upsert(ctx, []ragVector{
+		entry("orders", "orders runbook", "m1", 1, 0, 0),
+		entry("payments", "payments runbook", "m1", 0, 1, 0),
+		entry("mixed", "orders and payments", "m1", 1, 1, 0),
+		entry("old", "embedded before", "m0", 1, 0, 0),
+	}))
+	require.NoError(t, other.upsert(ctx, []ragVector{entry("orders", "another agent's runbook", "m1", 1, 0, 0)}))
+	require.NoError(t, store.upsert(ctx, []ragVector{entry("payments", "payments runbook v2", "m1", 0, 1, 0)}),
+		"Expected upsert to replace documents")
+
+	scored, err := store.search(ctx, "m1", []float64{1, 0, 0}, 5, 0.3)
+	require.NoError(t, err)
+	require.Len(t, scored, 2, "Expected other models, other agents, and dissimilar documents to be left out")
+	assert.Equal(t, "orders", scored[0].Document.ID)
+	assert.Equal(t, "orders runbook", scored[0].Document.Content)
+	assert.InDelta(t, 1, scored[0].Score, 0.001)
+	assert.Equal(t, "mixed", scored[1].Document.ID)
+	assert.Equal(t, "general", scored[1].Category)
+
+	scored, err = store.search(ctx, "m1", []float64{1, 0, 0}, 1, 0.3)
+	require.NoError(t, err)
+	assert.Len(t, scored, 1)
+
+	stale, err := store.stale(ctx, "m1", 10)
+	require.NoError(t, err)
+	require.Len(t, stale, 1)
+	assert.Equal(t, "old", stale[0].ID)
+
+	counts, err := store.counts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"general": 4}, counts)
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	metric := func(id, category string, age time.Duration) ragVector {
+		doc := Document{ID: id, Content: id, Metadata: map[string]interface{}{"type": ragMetricType}, Timestamp: now.Add(-age)}
+		return ragVector{document: doc, category: category, model: "m1", vector: []float64{0, 0, 1}}
+	}
+	require.NoError(t, store.upsert(ctx, []ragVector{
+		metric("cpu-new", "system_metrics", time.Minute),
+		metric("cpu-old", "system_metrics", time.Hour),
+		metric("latency", "network_metrics", 2*time.Hour),
+	}))
+	ids := func(docs []Document) []string {
+		var ids []string
+		for _, doc := range docs {
+			ids = append(ids, doc.ID)
+		}
+		return ids
+	}
+
+	oldest, err := store.oldest(ctx, ragMetricType, "", time.Time{}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"latency", "cpu-old", "cpu-new"}, ids(oldest))
+	oldest, err = store.oldest(ctx, ragMetricType, "", now.Add(-30*time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"latency", "cpu-old"}, ids(oldest))
+	oldest, err = store.oldest(ctx, ragMetricType, "system_metrics", time.Time{}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cpu-old"}, ids(oldest))
+
+	count, err := store.count(ctx, ragMetricType, "system_metrics")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	found, err := store.get(ctx, []string{"cpu-old", "missing", "orders"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"cpu-old", "orders"}, ids(found))
+
+	page, next, err := store.page(ctx, "", 4)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cpu-new", "cpu-old", "latency", "mixed"}, ids(page))
+	assert.Equal(t, "mixed", next)
+	page, next, err = store.page(ctx, next, 4)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old", "orders", "payments"}, ids(page), "Expected other agents' documents to be left out")
+	assert.Empty(t, next)
+
+	require.NoError(t, store.remove(ctx, []string{"cpu-old", "missing"}))
+	count, err = store.count(ctx, ragMetricType, "system_metrics")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestMemoryVectorStore(t *testing.T) {
+	testVectorStoreContract(t, func(agent string) ragVectorStore {
+		return newMemoryVectorStore()
+	})
+}
+
+func TestSQLiteVectorStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rag.db")
+	testVectorStoreContract(t, func(agent string) ragVectorStore {
+		store, err := openSQLiteVectorStore(path, agent)
+		require.NoError(t, err)
+		t.Cleanup(func() { store.close() })
+		return store
+	})
+}
+
+func TestSQLiteVectorStore_WarnsOnLargeScans(t *testing.T) {
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	ctx := context.Background()
+	store, err := openSQLiteVectorStore(filepath.Join(t.TempDir(), "rag.db"), "rag")
+	require.NoError(t, err)
+	t.Cleanup(func() { store.close() })
+	store.scanWarn = 3
+
+	entry := func(id string) ragVector {
+		return ragVector{document: Document{ID: id, Content: id}, category: "general", model: "m1", vector: []float64{1, 0}}
+	}
+	require.NoError(t, store.upsert(ctx, []ragVector{entry("a"), entry("b")}))
+	_, err = store.search(ctx, "m1", []float64{1, 0}, 5, 0)
+	require.NoError(t, err)
+	assert.Empty(t, logs.String(), "Expected no warning below the threshold")
+
+	require.NoError(t, store.upsert(ctx, []ragVector{entry("c")}))
+	for i := 0; i < 2; i++ {
+		_, err = store.search(ctx, "m1", []float64{1, 0}, 5, 0)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, strings.Count(logs.String(), "read every vector"), "Expected one warning once searches read the threshold")
+	assert.Contains(t, logs.String(), "vectors=3")
+}
+
+func TestPGVectorStore(t *testing.T) {
+	dsn := os.Getenv("AGENT_TEST_PGVECTOR_DSN")
+	if dsn == "" {
+		t.Skip("AGENT_TEST_PGVECTOR_DSN not set")
+	}
+	table := fmt.Sprintf("rag_vectors_test_%d", os.Getpid())
+	testVectorStoreContract(t, func(agent string) ragVectorStore {
+		store, err := openPGVectorStore(dsn, table, 3, agent)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			store.db.Exec("DROP TABLE IF EXISTS " + table)
+			store.close()
+		})
+		return store
+	})
+}
+
+func TestQdrantVectorStore(t *testing.T) {
+	url := os.Getenv("AGENT_TEST_QDRANT_URL")
+	if url == "" {
+		t.Skip("AGENT_TEST_QDRANT_URL not set")
+	}
+	collection := fmt.Sprintf("rag_test_%d", os.Getpid())
+	testVectorStoreContract(t, func(agent string) ragVectorStore {
+		store := newQdrantVectorStore(url, os.Getenv("AGENT_TEST_QDRANT_API_KEY"), collection, agent)
+		t.Cleanup(func() { store.call(context.Background(), "DELETE", "/collections/"+collection, nil, nil) })
+		return store
+	})
+}
+
+func TestRAGAgent_PersistentVectorStore(t *testing.T) {
+	server, batches := newEmbeddingServer(t)
+	config := map[string]interface{}{
+		"api_key":      "key",
+		"api_url":      server.URL + "/v1/chat/completions",
+		"vector_store": map[string]interface{}{"backend": VectorStoreSQLite, "path": filepath.Join(t.TempDir(), "rag.db")},
+	}
+	ctx := context.Background()
+
+	agent := NewRAGAgent("rag")
+	require.NoError(t, agent.Configure(config))
+	require.NoError(t, agent.Start(ctx))
+	agent.AddDocument(Document{ID: "runbook-orders", Content: "Restart the orders service with kubectl."})
+	agent.AddDocument(Document{ID: "disk-alert", Content: "Disk alerts fire at 90%."})
+	assert.Equal(t, 2, agent.GetKnowledgeBaseStats()["pending_documents"])
+	require.NoError(t, agent.Stop(), "Expected pending documents to be stored when the agent stops")
+	assert.Len(t, batches(), 1)
+
+	// A new agent finds the documents without embedding them again
+	restarted := NewRAGAgent("rag")
+	require.NoError(t, restarted.Configure(config))
+	stats := restarted.GetKnowledgeBaseStats()
+	assert.Equal(t, 2, stats["total_documents"])
+	assert.Equal(t, 0, stats["pending_documents"])
+	docs, err := restarted.retrieveRelevantDocuments(ctx, "Is the disk full?", 5)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "disk-alert", docs[0].ID)
+	assert.Len(t, batches(), 2, "Expected only the question to be embedded")
+	require.NoError(t, restarted.vectors.close())
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"math"
+	"sort"
 	"sync"
 	"time"
 
@@ -13,20 +14,35 @@ import (
 
 // AnomalyAnalyzer implements the DataAnalyzer interface for anomaly detection
 type AnomalyAnalyzer struct {
-	name      string
-	version   string
-	status    core.PluginStatus
-	threshold float64
-	mu        sync.RWMutex
+	name               string
+	version            string
+	status             core.PluginStatus
+	threshold          float64
+	windowSize         int
+	forgetAfter        time.Duration
+	minSamples         int
+	windows            *seriesWindows
+	baseline           *baselineProfile
+	baselineMode       string
+	baselineMinSamples int
+	calendar           *eventCalendar
+	metadata           *core.MetricMetadataRegistry
+	attributionDims    []string
+	mu                 sync.RWMutex
 }
 
 // NewAnomalyAnalyzer creates a new anomaly analyzer plugin
 func NewAnomalyAnalyzer(name string) *AnomalyAnalyzer {
 	return &AnomalyAnalyzer{
-		name:      name,
-		version:   "1.0.0",
-		status:    core.PluginStatusStopped,
-		threshold: 2.0,
+		name:               name,
+		version:            "1.0.0",
+		status:             core.PluginStatusStopped,
+		threshold:          2.0,
+		windowSize:         100,
+		forgetAfter:        24 * time.Hour,
+		minSamples:         5,
+		windows:            newSeriesWindows(100, 24*time.Hour),
+		baselineMinSamples: 10,
 	}
 }
 
@@ -51,6 +67,110 @@ func (a *AnomalyAnalyzer) Configure(config map[string]interface{}) error {
 		a.threshold = threshold
 	}
 
+	// Statistics are kept per series over a sliding window that persists between batches
+	if windowSize, ok := configInt(config, "window_size"); ok && windowSize > 0 {
+		a.windowSize = windowSize
+	}
+	// Windows of series that stop reporting are dropped after forget_after
+	if forgetAfter, ok := configDuration(config, "forget_after"); ok && forgetAfter > 0 {
+		a.forgetAfter = forgetAfter
+	}
+	a.windows = newSeriesWindows(a.windowSize, a.forgetAfter)
+
+	if minSamples, ok := configInt(config, "min_samples"); ok && minSamples > 0 {
+		a.minSamples = minSamples
+	}
+
+	// Time-of-day/day-of-week baselines so expected diurnal swings aren't flagged
+	location := time.UTC
+	if tz, ok := config["timezone"].(string); ok {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", tz, err)
+		}
+		location = loc
+	}
+
+	if mode, ok := config["baseline"].(string); ok {
+		profile, err := baselineProfileForMode(mode, location, a.forgetAfter)
+		if err != nil {
+			return err
+		}
+		a.baseline = profile
+		a.baselineMode = mode
+	}
+
+	if minSamples, ok := configInt(config, "baseline_min_samples"); ok && minSamples > 0 {
+		a.baselineMinSamples = minSamples
+	}
+
+	// Holiday and special-event calendars widen thresholds for matching metric groups
+	if rawCalendars, ok := config["calendars"].([]interface{}); ok {
+		metricGroups := make(map[string][]string)
+		if groups, ok := config["metric_groups"].(map[string]interface{}); ok {
+			for name, patterns := range groups {
+				metricGroups[name] = configStringSlice(patterns)
+			}
+		}
+
+		var sources []calendarSource
+		for _, raw := range rawCalendars {
+			entry, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			source := calendarSource{Groups: configStringSlice(entry["groups"])}
+			source.Path, _ = entry["path"].(string)
+			source.ThresholdMultiplier, _ = configFloat(entry, "threshold_multiplier")
+			source.IgnoreBaseline, _ = entry["ignore_baseline"].(bool)
+			sources = append(sources, source)
+		}
+
+		calendar, err := newEventCalendar(sources, metricGroups)
+		if err != nil {
+			return err
+		}
+		a.calendar = calendar
+	}
+
+	// Label dimensions to slice anomalous metrics by; every label the series carry if unset
+	if dimensions, ok := config["attribution_dimensions"]; ok {
+		a.attributionDims = configStringSlice(dimensions)
+	}
+
+	return nil
+}
+
+// Reconfigure changes the detection threshold, the samples needed before judging a series
+// and the attribution dimensions of a running analyzer; history is kept. Other settings
+// need the analyzer recreated.
+func (a *AnomalyAnalyzer) Reconfigure(ctx context.Context, config map[string]interface{}) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	threshold, minSamples, dimensions := a.threshold, a.minSamples, a.attributionDims
+	for key, value := range config {
+		switch key {
+		case "threshold":
+			parsed, ok := configFloat(config, key)
+			if !ok || parsed <= 0 {
+				return fmt.Errorf("threshold must be a positive number, got %v", value)
+			}
+			threshold = parsed
+		case "min_samples":
+			parsed, ok := configInt(config, key)
+			if !ok || parsed <= 0 {
+				return fmt.Errorf("min_samples must be a positive integer, got %v", value)
+			}
+			minSamples = parsed
+		case "attribution_dimensions":
+			dimensions = configStringSlice(value)
+		default:
+			return fmt.Errorf("%s cannot be changed without a restart", key)
+		}
+	}
+
+	a.threshold, a.minSamples, a.attributionDims = threshold, minSamples, dimensions
 	return nil
 }
 
@@ -113,86 +233,275 @@ func (a *AnomalyAnalyzer) GetCapabilities() []string {
 	}
 }
 
-// Analyze detects anomalies in the data points
+// Analyze detects anomalies in the data points. Each point is judged against the sliding
+// window of earlier values of its series, then added to that window, so history carries
+// over between batches of any size.
 func (a *AnomalyAnalyzer) Analyze(data []core.DataPoint) (*core.Analysis, error) {
-	if len(data) < 2 {
-		return nil, nil // Need at least 2 points for comparison
-	}
+	analysis, _, err := a.AnalyzeWithVerdicts(data)
+	return analysis, err
+}
+
+// AnalyzeWithVerdicts analyzes like Analyze and also returns the verdict for each point
+func (a *AnomalyAnalyzer) AnalyzeWithVerdicts(data []core.DataPoint) (*core.Analysis, []core.Verdict, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 
-	// Simple statistical anomaly detection
-	mean, stdDev := a.calculateStats(data)
+	if len(data) == 0 {
+		return nil, nil, nil
+	}
 
 	var anomalies []core.DataPoint
+	var verdicts []core.Verdict
+	var worstMean, worstStdDev float64
+	maxDeviation := 0.0
+	maxScore := 0.0
+	baselineComparisons := 0
+	warmingUp := 0
+	outOfRange := 0
+	activeEvents := make(map[string]bool)
+	deltas := make(map[string][]seriesDelta)
+	now := time.Now()
 	for _, point := range data {
-		if math.Abs(point.Value-mean) > a.threshold*stdDev {
+		// Values outside the metric's expected range are anomalous whatever the statistics say,
+		// and are kept out of the window so they don't distort it
+		key := seriesKey(point)
+		if !a.metadata.InRange(point.Metric, point.Value) {
+			anomalies = append(anomalies, point)
+			outOfRange++
+			maxScore = math.Max(maxScore, 1.0)
+			verdicts = append(verdicts, a.verdict(point, key, 0, 0, 0, a.threshold, core.VerdictAnomalous, "outside the metric's expected range"))
+			continue
+		}
+
+		window := a.windows.stats(key)
+		a.windows.push(key, point.Value, now)
+
+		threshold := a.threshold
+		useBaseline := true
+		if event, ok := a.activeEvent(point); ok {
+			threshold *= event.ThresholdMultiplier
+			useBaseline = !event.IgnoreBaseline
+			activeEvents[event.Name] = true
+		}
+
+		refMean, refStdDev, fromBaseline := window.mean, window.stdDev(), false
+		if useBaseline {
+			refMean, refStdDev, fromBaseline = a.referenceStats(point, refMean, refStdDev)
+		}
+		if fromBaseline {
+			baselineComparisons++
+		} else if window.count < a.minSamples {
+			// Not enough history to say what is normal for this series yet
+			warmingUp++
+			verdicts = append(verdicts, a.verdict(point, key, window.count, refMean, refStdDev, threshold, core.VerdictSkipped,
+				fmt.Sprintf("warming up: %d of %d samples", window.count, a.minSamples)))
+			continue
+		}
+		if refStdDev == 0 {
+			// A perfectly flat history gives no scale to measure deviation against
+			verdicts = append(verdicts, a.verdict(point, key, window.count, refMean, refStdDev, threshold, core.VerdictSkipped,
+				"history is flat, so deviation cannot be measured"))
+			continue
+		}
+
+		deltas[point.Metric] = append(deltas[point.Metric], seriesDelta{point: point, delta: point.Value - refMean})
+
+		reference := "sliding window"
+		if fromBaseline {
+			reference = a.baselineMode + " baseline"
+		}
+		if math.Abs(point.Value-refMean) > threshold*refStdDev {
+			verdicts = append(verdicts, a.verdict(point, key, window.count, refMean, refStdDev, threshold, core.VerdictAnomalous,
+				fmt.Sprintf("more than %.2fσ from the %s mean", threshold, reference)))
 			anomalies = append(anomalies, point)
+
+			// Track how far the worst anomaly is from its expected value, both in
+			// standard deviations and relative to the threshold in effect
+			deviation := math.Abs(point.Value-refMean) / refStdDev
+			if deviation > maxDeviation {
+				maxDeviation = deviation
+				worstMean, worstStdDev = refMean, refStdDev
+			}
+			if score := deviation / threshold; score > maxScore {
+				maxScore = score
+			}
+		} else {
+			verdicts = append(verdicts, a.verdict(point, key, window.count, refMean, refStdDev, threshold, core.VerdictNormal,
+				fmt.Sprintf("within %.2fσ of the %s mean", threshold, reference)))
 		}
 	}
 
+	// Learn from this batch only after judging it against the existing baseline
+	a.observeBaseline(data, now)
+
 	if len(anomalies) == 0 {
-		return nil, nil // No anomalies detected
+		return nil, verdicts, nil // No anomalies detected
 	}
 
-	// Calculate confidence based on how far the anomaly is from the mean
-	maxDeviation := 0.0
-	for _, point := range anomalies {
-		deviation := math.Abs(point.Value-mean) / stdDev
-		if deviation > maxDeviation {
-			maxDeviation = deviation
+	confidence := math.Min(maxScore, 1.0)
+	severity := a.determineSeverity(confidence)
+
+	details := map[string]interface{}{
+		"anomaly_count": len(anomalies),
+		"mean":          worstMean,
+		"std_dev":       worstStdDev,
+		"threshold":     a.threshold,
+		"window_size":   a.windowSize,
+		"warming_up":    warmingUp,
+	}
+	if outOfRange > 0 {
+		details["out_of_range"] = outOfRange
+	}
+	if a.baseline != nil {
+		details["baseline"] = a.baselineMode
+		details["baseline_comparisons"] = baselineComparisons
+		details["baseline_slot"] = a.baseline.slotLabel(anomalies[0].Timestamp)
+	}
+	if len(activeEvents) > 0 {
+		events := make([]string, 0, len(activeEvents))
+		for name := range activeEvents {
+			events = append(events, name)
 		}
+		sort.Strings(events)
+		details["calendar_events"] = events
 	}
 
-	confidence := math.Min(maxDeviation/a.threshold, 1.0)
-	severity := a.determineSeverity(confidence)
+	attributions := a.attribute(anomalies, deltas)
+	if len(attributions) > 0 {
+		details["attribution"] = attributions
+	}
+
+	summary := fmt.Sprintf("Detected %d anomalies with max deviation of %.2fσ", len(anomalies), maxDeviation)
+	if outOfRange > 0 {
+		summary += fmt.Sprintf(", %d outside the expected range", outOfRange)
+	}
+	if len(attributions) > 0 && attributions[0].Contribution >= 0.5 {
+		top := attributions[0]
+		summary += fmt.Sprintf(", mostly %s %s=%s (%.0f%%)", top.Metric, top.Dimension, top.Value, top.Contribution*100)
+	}
 
 	return &core.Analysis{
 		Type:       core.AnalysisTypeAnomaly,
 		Confidence: confidence,
 		Severity:   severity,
-		Summary:    fmt.Sprintf("Detected %d anomalies with max deviation of %.2fσ", len(anomalies), maxDeviation),
-		Details: map[string]interface{}{
-			"anomaly_count": len(anomalies),
-			"mean":          mean,
-			"std_dev":       stdDev,
-			"threshold":     a.threshold,
-		},
+		Summary:    summary,
+		Details:    details,
 		DataPoints: anomalies,
 		Timestamp:  time.Now(),
 		Source:     a.name,
-	}, nil
+	}, verdicts, nil
 }
 
 // CanAnalyze determines if this analyzer can process the given data
 func (a *AnomalyAnalyzer) CanAnalyze(data []core.DataPoint) bool {
-	return len(data) >= 2
+	return len(data) > 0
 }
 
-// calculateStats calculates mean and standard deviation
-func (a *AnomalyAnalyzer) calculateStats(data []core.DataPoint) (float64, float64) {
-	if len(data) == 0 {
-		return 0, 0
+// verdict records how a point was judged so the decision can be explained later
+func (a *AnomalyAnalyzer) verdict(point core.DataPoint, key string, windowSize int, mean, stdDev, threshold float64, verdict, reason string) core.Verdict {
+	timestamp := point.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	deviation := 0.0
+	if stdDev > 0 {
+		deviation = math.Abs(point.Value-mean) / stdDev
+	}
+	return core.Verdict{
+		Analyzer:   a.name,
+		Series:     key,
+		Metric:     point.Metric,
+		Timestamp:  timestamp,
+		Value:      point.Value,
+		WindowSize: windowSize,
+		Mean:       mean,
+		StdDev:     stdDev,
+		Threshold:  threshold,
+		Deviation:  deviation,
+		Verdict:    verdict,
+		Reason:     reason,
 	}
+}
 
-	// Calculate mean
-	sum := 0.0
-	for _, point := range data {
-		sum += point.Value
+// attribute slices each anomalous metric's deviation by label dimension to find which
+// series drive it, largest contribution first
+func (a *AnomalyAnalyzer) attribute(anomalies []core.DataPoint, deltas map[string][]seriesDelta) []Attribution {
+	metrics := make(map[string]bool)
+	for _, point := range anomalies {
+		metrics[point.Metric] = true
+	}
+	names := make([]string, 0, len(metrics))
+	for metric := range metrics {
+		names = append(names, metric)
+	}
+	sort.Strings(names)
+
+	var attributions []Attribution
+	for _, metric := range names {
+		attributions = append(attributions, attributeDeviation(metric, deltas[metric], a.attributionDims)...)
+	}
+	sort.SliceStable(attributions, func(i, j int) bool {
+		return attributions[i].Contribution > attributions[j].Contribution
+	})
+	return attributions
+}
+
+// SetMetricMetadata provides the expected ranges used as sanity bounds
+func (a *AnomalyAnalyzer) SetMetricMetadata(registry *core.MetricMetadataRegistry) {
+	a.metadata = registry
+}
+
+// referenceStats returns the mean and standard deviation a point should be judged against:
+// its time-of-day baseline once enough history exists, otherwise its sliding window
+func (a *AnomalyAnalyzer) referenceStats(point core.DataPoint, windowMean, windowStdDev float64) (float64, float64, bool) {
+	if a.baseline == nil {
+		return windowMean, windowStdDev, false
 	}
-	mean := sum / float64(len(data))
 
-	// Calculate standard deviation
-	sumSquaredDiff := 0.0
+	stats, ok := a.baseline.lookup(seriesKey(point), point.Timestamp)
+	if !ok || stats.count < a.baselineMinSamples || stats.stdDev() == 0 {
+		return windowMean, windowStdDev, false
+	}
+	return stats.mean, stats.stdDev(), true
+}
+
+// observeBaseline folds data points into the time-of-day baseline. Points during calendar
+// events are skipped so unusual days don't skew what is considered normal.
+func (a *AnomalyAnalyzer) observeBaseline(data []core.DataPoint, now time.Time) {
+	if a.baseline == nil {
+		return
+	}
 	for _, point := range data {
-		diff := point.Value - mean
-		sumSquaredDiff += diff * diff
+		if _, ok := a.activeEvent(point); ok {
+			continue
+		}
+		a.baseline.observe(seriesKey(point), point.Timestamp, point.Value, now)
 	}
-	stdDev := math.Sqrt(sumSquaredDiff / float64(len(data)))
+}
 
-	return mean, stdDev
+// activeEvent returns the calendar event in effect for the point, if any
+func (a *AnomalyAnalyzer) activeEvent(point core.DataPoint) (*CalendarEvent, bool) {
+	if a.calendar == nil {
+		return nil, false
+	}
+	return a.calendar.activeEvent(point)
 }
 
 // determineSeverity determines severity based on confidence
 func (a *AnomalyAnalyzer) determineSeverity(confidence float64) string {
+	return severityForConfidence(confidence)
+}
+
+// severityLevels orders severities so the most severe result can be reported
+var severityLevels = map[string]int{
+	"low":      0,
+	"medium":   1,
+	"high":     2,
+	"critical": 3,
+}
+
+// severityForConfidence maps an analysis confidence to a severity level
+func severityForConfidence(confidence float64) string {
 	switch {
 	case confidence >= 0.9:
 		return "critical"
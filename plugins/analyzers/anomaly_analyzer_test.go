@@ -2,6 +2,7 @@ package analyzers
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
@@ -37,6 +38,27 @@ func TestAnomalyAnalyzer_Configure(t *testing.T) {
 	require.NoError(t, err, "Expected no error for invalid config (should use default)")
 }
 
+func TestAnomalyAnalyzer_Reconfigure(t *testing.T) {
+	analyzer := NewAnomalyAnalyzer("test-analyzer")
+
+	err := analyzer.Reconfigure(context.Background(), map[string]interface{}{
+		"threshold":              3,
+		"min_samples":            10.0,
+		"attribution_dimensions": []interface{}{"region"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, analyzer.threshold)
+	assert.Equal(t, 10, analyzer.minSamples)
+	assert.Equal(t, []string{"region"}, analyzer.attributionDims)
+
+	err = analyzer.Reconfigure(context.Background(), map[string]interface{}{"threshold": 1.5, "window_size": 50})
+	assert.Error(t, err, "Expected settings that need a restart to be refused")
+	assert.Equal(t, 3.0, analyzer.threshold, "Expected a refused change to apply nothing")
+
+	err = analyzer.Reconfigure(context.Background(), map[string]interface{}{"threshold": -1.0})
+	assert.Error(t, err)
+}
+
 func TestAnomalyAnalyzer_StartStop(t *testing.T) {
 	analyzer := NewAnomalyAnalyzer("test-analyzer")
 
@@ -112,24 +134,136 @@ func TestAnomalyAnalyzer_Analyze(t *testing.T) {
 func TestAnomalyAnalyzer_CanAnalyze(t *testing.T) {
 	analyzer := NewAnomalyAnalyzer("test-analyzer")
 
-	// Test with insufficient data
-	insufficientData := []core.DataPoint{
+	// Test with no data
+	if analyzer.CanAnalyze([]core.DataPoint{}) {
+		t.Error("Expected CanAnalyze to return false for empty data")
+	}
+
+	// A single point is enough since history is kept between batches
+	singlePoint := []core.DataPoint{
 		{Timestamp: time.Now(), Source: "test", Metric: "cpu", Value: 50.0, Labels: map[string]string{}},
 	}
 
-	if analyzer.CanAnalyze(insufficientData) {
-		t.Error("Expected CanAnalyze to return false for insufficient data")
+	if !analyzer.CanAnalyze(singlePoint) {
+		t.Error("Expected CanAnalyze to return true for a single data point")
 	}
+}
 
-	// Test with sufficient data
-	sufficientData := []core.DataPoint{
-		{Timestamp: time.Now(), Source: "test", Metric: "cpu", Value: 50.0, Labels: map[string]string{}},
-		{Timestamp: time.Now(), Source: "test", Metric: "cpu", Value: 52.0, Labels: map[string]string{}},
+func TestAnomalyAnalyzer_SlidingWindow(t *testing.T) {
+	analyzer := NewAnomalyAnalyzer("test-analyzer")
+	require.NoError(t, analyzer.Configure(map[string]interface{}{
+		"threshold":   2.0,
+		"window_size": 10,
+		"min_samples": 5,
+	}))
+
+	point := func(instance string, value float64) []core.DataPoint {
+		return []core.DataPoint{{
+			Timestamp: time.Now(),
+			Metric:    "cpu",
+			Value:     value,
+			Labels:    map[string]string{"instance": instance},
+		}}
+	}
+
+	// Single-point batches build up history per series
+	for i, v := range []float64{50, 52, 48, 51, 49} {
+		analysis, err := analyzer.Analyze(point("web-1", v))
+		require.NoError(t, err)
+		assert.Nil(t, analysis, "Expected no analysis while warming up (sample %d)", i)
 	}
 
-	if !analyzer.CanAnalyze(sufficientData) {
-		t.Error("Expected CanAnalyze to return true for sufficient data")
+	analysis, err := analyzer.Analyze(point("web-1", 90))
+	require.NoError(t, err)
+	require.NotNil(t, analysis, "Expected a spike to be flagged against the window")
+	assert.InDelta(t, 50.0, analysis.Details["mean"], 0.001)
+
+	// Another series has its own window and is still warming up
+	analysis, err = analyzer.Analyze(point("web-2", 90))
+	require.NoError(t, err)
+	assert.Nil(t, analysis, "Expected series to keep separate windows")
+
+	// Old values fall out of the window once it is full
+	for i := 0; i < 10; i++ {
+		analyzer.Analyze(point("web-1", 90))
+	}
+	analysis, err = analyzer.Analyze(point("web-1", 91))
+	require.NoError(t, err)
+	assert.Nil(t, analysis, "Expected the window to follow the new level")
+}
+
+func TestSeriesWindows_ForgetIdleSeries(t *testing.T) {
+	windows := newSeriesWindows(10, time.Hour)
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	windows.push("pod-1", 1, start)
+	windows.push("pod-2", 2, start)
+	windows.push("pod-1", 3, start.Add(50*time.Minute))
+	assert.Equal(t, 2, windows.len())
+
+	// pod-2 has been idle for more than an hour, pod-1 has not
+	windows.push("pod-3", 4, start.Add(90*time.Minute))
+	assert.Equal(t, 2, windows.len(), "Expected the idle window to be dropped")
+	assert.Equal(t, 0, windows.stats("pod-2").count)
+	assert.Equal(t, 2, windows.stats("pod-1").count)
+
+	forever := newSeriesWindows(10, 0)
+	forever.push("pod-1", 1, start)
+	forever.push("pod-2", 1, start.Add(365*24*time.Hour))
+	assert.Equal(t, 2, forever.len(), "Expected windows to be kept without an idle limit")
+}
+
+func TestAnomalyAnalyzer_Verdicts(t *testing.T) {
+	analyzer := NewAnomalyAnalyzer("test-analyzer")
+	require.NoError(t, analyzer.Configure(map[string]interface{}{"threshold": 2.0, "min_samples": 3}))
+
+	start := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	for i, v := range []float64{50, 52, 48} {
+		_, verdicts, err := analyzer.AnalyzeWithVerdicts([]core.DataPoint{{Timestamp: start.Add(time.Duration(i) * time.Minute), Metric: "cpu", Value: v}})
+		require.NoError(t, err)
+		require.Len(t, verdicts, 1)
+		assert.Equal(t, core.VerdictSkipped, verdicts[0].Verdict, "Expected sample %d to be skipped while warming up", i)
+	}
+
+	analysis, verdicts, err := analyzer.AnalyzeWithVerdicts([]core.DataPoint{
+		{Timestamp: start.Add(3 * time.Minute), Metric: "cpu", Value: 51},
+		{Timestamp: start.Add(4 * time.Minute), Metric: "cpu", Value: 80},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, analysis)
+	require.Len(t, verdicts, 2, "Expected a verdict per point of the call only")
+
+	assert.Equal(t, core.VerdictNormal, verdicts[0].Verdict)
+	assert.Equal(t, 3, verdicts[0].WindowSize)
+	assert.InDelta(t, 50.0, verdicts[0].Mean, 0.001)
+
+	assert.Equal(t, core.VerdictAnomalous, verdicts[1].Verdict)
+	assert.Equal(t, 4, verdicts[1].WindowSize)
+	assert.Greater(t, verdicts[1].Deviation, verdicts[1].Threshold)
+	assert.Equal(t, "test-analyzer", verdicts[1].Analyzer)
+}
+
+func TestAnomalyAnalyzer_VerdictsOfConcurrentBatches(t *testing.T) {
+	analyzer := NewAnomalyAnalyzer("test-analyzer")
+	require.NoError(t, analyzer.Configure(map[string]interface{}{"min_samples": 3}))
+
+	var wg sync.WaitGroup
+	for _, metric := range []string{"cpu", "disk"} {
+		wg.Add(1)
+		go func(metric string) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				batch := []core.DataPoint{{Metric: metric, Value: float64(i % 5)}, {Metric: metric, Value: float64(i%5 + 1)}}
+				_, verdicts, err := analyzer.AnalyzeWithVerdicts(batch)
+				assert.NoError(t, err)
+				if assert.Len(t, verdicts, 2) {
+					assert.Equal(t, metric, verdicts[0].Metric, "Expected a batch's verdicts not to be mixed with another's")
+					assert.Equal(t, metric, verdicts[1].Metric)
+				}
+			}
+		}(metric)
 	}
+	wg.Wait()
 }
 
 func TestAnomalyAnalyzer_Health(t *testing.T) {
@@ -175,3 +309,123 @@ func TestAnomalyAnalyzer_GetCapabilities(t *testing.T) {
 		}
 	}
 }
+
+func TestAnomalyAnalyzer_TimeOfDayBaseline(t *testing.T) {
+	analyzer := NewAnomalyAnalyzer("test-analyzer")
+	err := analyzer.Configure(map[string]interface{}{
+		"threshold":            2.0,
+		"baseline":             "hour_of_day",
+		"baseline_min_samples": 5,
+	})
+	require.NoError(t, err)
+
+	night := time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC)
+	day := time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+	point := func(at time.Time, value float64) core.DataPoint {
+		return core.DataPoint{Timestamp: at, Source: "test", Metric: "requests", Value: value}
+	}
+
+	// Learn two weeks of quiet nights and busy afternoons
+	for d := 0; d < 14; d++ {
+		offset := time.Duration(d) * 24 * time.Hour
+		analyzer.Analyze([]core.DataPoint{
+			point(night.Add(offset), 10+float64(d%3)),
+			point(day.Add(offset), 50+float64(d%3)),
+		})
+	}
+
+	// A busy afternoon among quiet nights looks anomalous to batch statistics but not to the baseline
+	batch := []core.DataPoint{}
+	for i := 0; i < 7; i++ {
+		batch = append(batch, point(night.Add(15*24*time.Hour), 10+float64(i%3)))
+	}
+	batch = append(batch, point(day.Add(15*24*time.Hour), 51))
+
+	analysis, err := analyzer.Analyze(batch)
+	require.NoError(t, err)
+	assert.Nil(t, analysis, "Expected afternoon peak to match its baseline")
+
+	// The same value at night is anomalous
+	analysis, err = analyzer.Analyze([]core.DataPoint{
+		point(night.Add(16*24*time.Hour), 11),
+		point(night.Add(16*24*time.Hour), 51),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, analysis, "Expected night-time peak to be flagged")
+	assert.Equal(t, "hour_of_day", analysis.Details["baseline"])
+
+	err = analyzer.Configure(map[string]interface{}{"baseline": "hourly"})
+	assert.Error(t, err, "Expected error for unknown baseline mode")
+}
+
+func TestAnomalyAnalyzer_MetadataSanityBounds(t *testing.T) {
+	analyzer := NewAnomalyAnalyzer("test-analyzer")
+	min, max := 0.0, 100.0
+	analyzer.SetMetricMetadata(core.NewMetricMetadataRegistry([]core.MetricMetadata{
+		{Name: "cpu_usage_percent", Unit: core.UnitPercent, Min: &min, Max: &max},
+	}))
+
+	// Impossible values are flagged even without any history
+	analysis, err := analyzer.Analyze([]core.DataPoint{
+		{Timestamp: time.Now(), Metric: "cpu_usage_percent", Value: 50},
+		{Timestamp: time.Now(), Metric: "cpu_usage_percent", Value: 140},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, analysis, "Expected out-of-range value to be flagged")
+	assert.Equal(t, 1, analysis.Details["out_of_range"])
+	assert.Equal(t, 140.0, analysis.DataPoints[0].Value)
+}
+
+func TestAnomalyAnalyzer_Attribution(t *testing.T) {
+	analyzer := NewAnomalyAnalyzer("test-analyzer")
+	require.NoError(t, analyzer.Configure(map[string]interface{}{"threshold": 2.0, "min_samples": 3}))
+
+	batch := func(checkout, home float64) []core.DataPoint {
+		var points []core.DataPoint
+		for _, instance := range []string{"web-1", "web-2"} {
+			for endpoint, value := range map[string]float64{"/checkout": checkout, "/home": home} {
+				points = append(points, core.DataPoint{
+					Timestamp: time.Now(),
+					Metric:    "latency_ms",
+					Value:     value,
+					Labels:    map[string]string{"endpoint": endpoint, "instance": instance},
+				})
+			}
+		}
+		return points
+	}
+	for _, v := range []float64{100, 102, 98, 101} {
+		analyzer.Analyze(batch(v, v))
+	}
+
+	analysis, err := analyzer.Analyze(batch(200, 101))
+	require.NoError(t, err)
+	require.NotNil(t, analysis)
+
+	attributions, ok := analysis.Details["attribution"].([]Attribution)
+	require.True(t, ok, "Expected attribution in the analysis details")
+	require.Len(t, attributions, 2, "Expected the top value for each label dimension")
+	assert.Equal(t, "endpoint", attributions[0].Dimension)
+	assert.Equal(t, "/checkout", attributions[0].Value)
+	assert.Greater(t, attributions[0].Contribution, 0.9)
+	assert.Equal(t, "instance", attributions[1].Dimension)
+	assert.InDelta(t, 0.5, attributions[1].Contribution, 0.05, "Expected the spike to be spread evenly over instances")
+	assert.Contains(t, analysis.Summary, "mostly latency_ms endpoint=/checkout")
+
+	// Restricting dimensions leaves the others out
+	analyzer = NewAnomalyAnalyzer("test-analyzer")
+	require.NoError(t, analyzer.Configure(map[string]interface{}{
+		"threshold":              2.0,
+		"min_samples":            3,
+		"attribution_dimensions": []interface{}{"instance"},
+	}))
+	for _, v := range []float64{100, 102, 98, 101} {
+		analyzer.Analyze(batch(v, v))
+	}
+	analysis, err = analyzer.Analyze(batch(200, 101))
+	require.NoError(t, err)
+	require.NotNil(t, analysis)
+	attributions = analysis.Details["attribution"].([]Attribution)
+	require.Len(t, attributions, 1)
+	assert.Equal(t, "instance", attributions[0].Dimension)
+}
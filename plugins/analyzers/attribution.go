@@ -0,0 +1,83 @@
+package analyzers
+
+import (
+	"math"
+	"sort"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// seriesDelta is how far a point was from the mean it was judged against
+type seriesDelta struct {
+	point core.DataPoint
+	delta float64
+}
+
+// Attribution is the share of a metric's deviation explained by the series with one
+// value of a label, such as endpoint="/checkout" accounting for 80% of a latency spike
+type Attribution struct {
+	Metric    string `json:"metric"`
+	Dimension string `json:"dimension"`
+	Value     string `json:"value"`
+	// Delta is the summed deviation from expected of the series with this label value
+	Delta float64 `json:"delta"`
+	// Contribution is Delta as a fraction of the metric's total deviation
+	Contribution float64 `json:"contribution"`
+}
+
+// attributeDeviation slices a metric's deviation by each label dimension and returns the
+// label value contributing most in each dimension, largest contribution first. Dimensions
+// default to every label the series carry; a dimension needs at least two values to
+// say anything.
+func attributeDeviation(metric string, deltas []seriesDelta, dimensions []string) []Attribution {
+	if len(dimensions) == 0 {
+		seen := make(map[string]bool)
+		for _, d := range deltas {
+			for label := range d.point.Labels {
+				if !seen[label] {
+					seen[label] = true
+					dimensions = append(dimensions, label)
+				}
+			}
+		}
+		sort.Strings(dimensions)
+	}
+
+	var attributions []Attribution
+	for _, dimension := range dimensions {
+		groups := make(map[string]float64)
+		total := 0.0
+		for _, d := range deltas {
+			value, ok := d.point.Labels[dimension]
+			if !ok {
+				continue
+			}
+			groups[value] += d.delta
+			total += d.delta
+		}
+		if len(groups) < 2 || total == 0 {
+			continue
+		}
+
+		values := make([]string, 0, len(groups))
+		for value := range groups {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+
+		best := Attribution{Metric: metric, Dimension: dimension, Contribution: math.Inf(-1)}
+		for _, value := range values {
+			if contribution := groups[value] / total; contribution > best.Contribution {
+				best.Value = value
+				best.Delta = groups[value]
+				best.Contribution = contribution
+			}
+		}
+		attributions = append(attributions, best)
+	}
+
+	sort.SliceStable(attributions, func(i, j int) bool {
+		return attributions[i].Contribution > attributions[j].Contribution
+	})
+	return attributions
+}
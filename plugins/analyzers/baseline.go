@@ -0,0 +1,210 @@
+package analyzers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// runningStats accumulates mean and variance incrementally using Welford's algorithm
+type runningStats struct {
+	count int
+	mean  float64
+	m2    float64
+}
+
+// add folds a value into the statistics
+func (s *runningStats) add(value float64) {
+	s.count++
+	delta := value - s.mean
+	s.mean += delta / float64(s.count)
+	s.m2 += delta * (value - s.mean)
+}
+
+// merge combines another set of statistics into this one
+func (s *runningStats) merge(other runningStats) {
+	if other.count == 0 {
+		return
+	}
+	if s.count == 0 {
+		*s = other
+		return
+	}
+	total := s.count + other.count
+	delta := other.mean - s.mean
+	s.mean += delta * float64(other.count) / float64(total)
+	s.m2 += other.m2 + delta*delta*float64(s.count)*float64(other.count)/float64(total)
+	s.count = total
+}
+
+// stdDev returns the population standard deviation
+func (s *runningStats) stdDev() float64 {
+	if s.count == 0 {
+		return 0
+	}
+	return math.Sqrt(s.m2 / float64(s.count))
+}
+
+// baselineProfile keeps running statistics per series and per slot of a repeating period,
+// e.g. hour-of-week, so that expected daily and weekly swings define what is normal
+type baselineProfile struct {
+	period   time.Duration
+	slot     time.Duration
+	location *time.Location
+	stats    map[string]*seriesProfile
+	sweep    idleSweep
+	mu       sync.RWMutex
+}
+
+// seriesProfile holds the per-slot statistics of one series
+type seriesProfile struct {
+	slots    map[int]*runningStats
+	lastSeen time.Time
+}
+
+// newBaselineProfile creates a profile that repeats every period in slots of the given size.
+// Series without values for idle are forgotten; an idle of 0 keeps them.
+func newBaselineProfile(period, slot time.Duration, location *time.Location, idle time.Duration) *baselineProfile {
+	if location == nil {
+		location = time.UTC
+	}
+	return &baselineProfile{
+		period:   period,
+		slot:     slot,
+		location: location,
+		stats:    make(map[string]*seriesProfile),
+		sweep:    idleSweep{idle: idle},
+	}
+}
+
+// baselineProfileForMode creates a profile for a named baseline mode
+func baselineProfileForMode(mode string, location *time.Location, idle time.Duration) (*baselineProfile, error) {
+	switch mode {
+	case "", "none":
+		return nil, nil
+	case "hour_of_day":
+		return newBaselineProfile(24*time.Hour, time.Hour, location, idle), nil
+	case "hour_of_week":
+		return newBaselineProfile(7*24*time.Hour, time.Hour, location, idle), nil
+	default:
+		return nil, fmt.Errorf("unknown baseline mode %q", mode)
+	}
+}
+
+// newSeasonalProfile creates a profile for an arbitrary seasonality period. The period must
+// divide a week evenly so that slots stay aligned to calendar days, and the slot must divide
+// the period.
+func newSeasonalProfile(period, slot time.Duration, location *time.Location, idle time.Duration) (*baselineProfile, error) {
+	if period <= 0 || slot <= 0 {
+		return nil, fmt.Errorf("seasonality period and slot must be positive")
+	}
+	if (7*24*time.Hour)%period != 0 {
+		return nil, fmt.Errorf("seasonality period %s does not divide a week evenly", period)
+	}
+	if period%slot != 0 {
+		return nil, fmt.Errorf("slot %s does not divide seasonality period %s evenly", slot, period)
+	}
+	return newBaselineProfile(period, slot, location, idle), nil
+}
+
+// slotFor returns the slot index of a timestamp within the period, in the profile's time zone.
+// Slots are aligned to Monday 00:00 local time so hour-of-week slots line up with calendar weeks.
+func (p *baselineProfile) slotFor(t time.Time) int {
+	local := t.In(p.location)
+	weekday := (int(local.Weekday()) + 6) % 7 // Monday = 0
+	sinceMonday := time.Duration(weekday)*24*time.Hour +
+		time.Duration(local.Hour())*time.Hour +
+		time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second
+	return int((sinceMonday % p.period) / p.slot)
+}
+
+// slotLabel describes a slot for humans, e.g. "Tue 14:00"
+func (p *baselineProfile) slotLabel(t time.Time) string {
+	local := t.In(p.location).Truncate(p.slot)
+	if p.period < 7*24*time.Hour {
+		return local.Format("15:04")
+	}
+	return local.Format("Mon 15:04")
+}
+
+// lookup returns a copy of the statistics for the series at the timestamp's slot
+func (p *baselineProfile) lookup(series string, t time.Time) (runningStats, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	profile, ok := p.stats[series]
+	if !ok {
+		return runningStats{}, false
+	}
+	stats, ok := profile.slots[p.slotFor(t)]
+	if !ok {
+		return runningStats{}, false
+	}
+	return *stats, true
+}
+
+// observe folds a value into the series statistics for the timestamp's slot, received at now
+func (p *baselineProfile) observe(series string, t time.Time, value float64, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sweep.due(now) {
+		for key, profile := range p.stats {
+			if p.sweep.expired(profile.lastSeen, now) {
+				delete(p.stats, key)
+			}
+		}
+	}
+
+	profile, ok := p.stats[series]
+	if !ok {
+		profile = &seriesProfile{slots: make(map[int]*runningStats)}
+		p.stats[series] = profile
+	}
+	profile.lastSeen = now
+	slot := p.slotFor(t)
+	stats, ok := profile.slots[slot]
+	if !ok {
+		stats = &runningStats{}
+		profile.slots[slot] = stats
+	}
+	stats.add(value)
+}
+
+// len returns the number of series with a profile
+func (p *baselineProfile) len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.stats)
+}
+
+// seriesKey identifies a time series by metric name and sorted labels
+func seriesKey(point core.DataPoint) string {
+	if len(point.Labels) == 0 {
+		return point.Metric
+	}
+
+	keys := make([]string, 0, len(point.Labels))
+	for k := range point.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(point.Metric)
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%q", k, point.Labels[k])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
@@ -0,0 +1,230 @@
+package analyzers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"gopkg.in/yaml.v3"
+)
+
+// CalendarEvent is a period of expected unusual traffic such as a sale or maintenance window
+type CalendarEvent struct {
+	Name                string    `yaml:"name"`
+	Start               time.Time `yaml:"start"`
+	End                 time.Time `yaml:"end"`
+	Groups              []string  `yaml:"groups"`
+	ThresholdMultiplier float64   `yaml:"threshold_multiplier"`
+	// IgnoreBaseline judges points against their sliding window instead of the time-of-day baseline
+	IgnoreBaseline bool `yaml:"ignore_baseline"`
+}
+
+// covers reports whether the event is in effect at the given time
+func (e CalendarEvent) covers(t time.Time) bool {
+	return !t.Before(e.Start) && t.Before(e.End)
+}
+
+// eventCalendar holds calendar events and the metric groups they apply to
+type eventCalendar struct {
+	events       []CalendarEvent
+	metricGroups map[string][]string
+}
+
+// calendarSource describes one calendar file and the defaults applied to its events
+type calendarSource struct {
+	Path                string
+	Groups              []string
+	ThresholdMultiplier float64
+	IgnoreBaseline      bool
+}
+
+// newEventCalendar loads every calendar source; events without their own groups or
+// multiplier inherit the source's defaults
+func newEventCalendar(sources []calendarSource, metricGroups map[string][]string) (*eventCalendar, error) {
+	calendar := &eventCalendar{metricGroups: metricGroups}
+
+	for _, source := range sources {
+		events, err := loadCalendarEvents(source.Path)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			if len(event.Groups) == 0 {
+				event.Groups = source.Groups
+			}
+			if event.ThresholdMultiplier == 0 {
+				event.ThresholdMultiplier = source.ThresholdMultiplier
+			}
+			if event.ThresholdMultiplier == 0 {
+				event.ThresholdMultiplier = 1
+			}
+			event.IgnoreBaseline = event.IgnoreBaseline || source.IgnoreBaseline
+			calendar.events = append(calendar.events, event)
+		}
+	}
+
+	return calendar, nil
+}
+
+// activeEvent returns the event with the widest threshold covering the point, if any
+func (c *eventCalendar) activeEvent(point core.DataPoint) (*CalendarEvent, bool) {
+	var active *CalendarEvent
+	for i := range c.events {
+		event := &c.events[i]
+		if !event.covers(point.Timestamp) || !c.appliesTo(event, point.Metric) {
+			continue
+		}
+		if active == nil || event.ThresholdMultiplier > active.ThresholdMultiplier {
+			active = event
+		}
+	}
+	return active, active != nil
+}
+
+// appliesTo reports whether an event affects the metric; events without groups affect every metric
+func (c *eventCalendar) appliesTo(event *CalendarEvent, metric string) bool {
+	if len(event.Groups) == 0 {
+		return true
+	}
+	for _, group := range event.Groups {
+		for _, pattern := range c.metricGroups[group] {
+			if matched, _ := path.Match(pattern, metric); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// loadCalendarEvents reads events from a YAML or ICS file based on its extension
+func loadCalendarEvents(filename string) ([]CalendarEvent, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read calendar %s: %w", filename, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".ics", ".ical":
+		return parseICS(data)
+	case ".yaml", ".yml":
+		var file struct {
+			Events []CalendarEvent `yaml:"events"`
+		}
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse calendar %s: %w", filename, err)
+		}
+		return file.Events, nil
+	default:
+		return nil, fmt.Errorf("unsupported calendar format: %s", filename)
+	}
+}
+
+// parseICS extracts VEVENTs from an iCalendar document. CATEGORIES map to metric groups and
+// the X-AGENT-THRESHOLD-MULTIPLIER property overrides the calendar's multiplier.
+func parseICS(data []byte) ([]CalendarEvent, error) {
+	var events []CalendarEvent
+	var current *CalendarEvent
+
+	for _, line := range unfoldICS(data) {
+		name, params, value := splitICSLine(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &CalendarEvent{}
+		case name == "END" && value == "VEVENT":
+			if current == nil {
+				continue
+			}
+			if current.Start.IsZero() {
+				return nil, fmt.Errorf("calendar event %q has no DTSTART", current.Name)
+			}
+			if current.End.IsZero() {
+				current.End = current.Start.Add(24 * time.Hour)
+			}
+			events = append(events, *current)
+			current = nil
+		case current == nil:
+			continue
+		case name == "SUMMARY":
+			current.Name = value
+		case name == "DTSTART" || name == "DTEND":
+			t, err := parseICSTime(params, value)
+			if err != nil {
+				return nil, err
+			}
+			if name == "DTSTART" {
+				current.Start = t
+			} else {
+				current.End = t
+			}
+		case name == "CATEGORIES":
+			for _, group := range strings.Split(value, ",") {
+				if group = strings.TrimSpace(group); group != "" {
+					current.Groups = append(current.Groups, group)
+				}
+			}
+		case name == "X-AGENT-THRESHOLD-MULTIPLIER":
+			multiplier, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid threshold multiplier %q: %w", value, err)
+			}
+			current.ThresholdMultiplier = multiplier
+		}
+	}
+
+	return events, nil
+}
+
+// unfoldICS joins continuation lines (those starting with a space or tab) per RFC 5545
+func unfoldICS(data []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitICSLine splits "NAME;PARAM=X:value" into its name, parameters, and value
+func splitICSLine(line string) (string, map[string]string, string) {
+	head, value, _ := strings.Cut(line, ":")
+	parts := strings.Split(head, ";")
+	params := make(map[string]string)
+	for _, param := range parts[1:] {
+		if k, v, ok := strings.Cut(param, "="); ok {
+			params[strings.ToUpper(k)] = v
+		}
+	}
+	return strings.ToUpper(parts[0]), params, value
+}
+
+// parseICSTime parses DATE and DATE-TIME values, honoring TZID when present
+func parseICSTime(params map[string]string, value string) (time.Time, error) {
+	location := time.UTC
+	if tzid, ok := params["TZID"]; ok {
+		loc, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unknown calendar time zone %q: %w", tzid, err)
+		}
+		location = loc
+	}
+
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		return time.ParseInLocation("20060102", value, location)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse("20060102T150405Z", value)
+	}
+	return time.ParseInLocation("20060102T150405", value, location)
+}
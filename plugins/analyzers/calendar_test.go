@@ -0,0 +1,83 @@
+package analyzers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testICS = `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+SUMMARY:Black Friday
+DTSTART;VALUE=DATE:20261127
+DTEND;VALUE=DATE:20261128
+CATEGORIES:checkout
+X-AGENT-THRESHOLD-MULTIPLIER:4
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Database maint
+ enance
+DTSTART:20261201T020000Z
+DTEND:20261201T040000Z
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestParseICS(t *testing.T) {
+	events, err := parseICS([]byte(testICS))
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, "Black Friday", events[0].Name)
+	assert.Equal(t, []string{"checkout"}, events[0].Groups)
+	assert.Equal(t, 4.0, events[0].ThresholdMultiplier)
+	assert.Equal(t, 24*time.Hour, events[0].End.Sub(events[0].Start))
+
+	assert.Equal(t, "Database maintenance", events[1].Name, "Expected folded lines to be joined")
+	assert.Equal(t, 2*time.Hour, events[1].End.Sub(events[1].Start))
+}
+
+func TestAnomalyAnalyzer_CalendarWidensThreshold(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "holidays.ics")
+	require.NoError(t, os.WriteFile(path, []byte(testICS), 0644))
+
+	analyzer := NewAnomalyAnalyzer("test-analyzer")
+	require.NoError(t, analyzer.Configure(map[string]interface{}{
+		"threshold": 1.5,
+		"metric_groups": map[string]interface{}{
+			"checkout": []interface{}{"checkout_*"},
+		},
+		"calendars": []interface{}{
+			map[string]interface{}{"path": path, "threshold_multiplier": 2.0},
+		},
+	}))
+
+	batch := func(at time.Time, metric string) []core.DataPoint {
+		values := []float64{50, 60, 40, 55, 45, 75}
+		points := make([]core.DataPoint, len(values))
+		for i, v := range values {
+			points[i] = core.DataPoint{Timestamp: at, Metric: metric, Value: v}
+		}
+		return points
+	}
+
+	blackFriday := time.Date(2026, 11, 27, 15, 0, 0, 0, time.UTC)
+
+	analysis, err := analyzer.Analyze(batch(blackFriday, "checkout_requests"))
+	require.NoError(t, err)
+	assert.Nil(t, analysis, "Expected Black Friday spike on checkout metrics to be tolerated")
+
+	analysis, err = analyzer.Analyze(batch(blackFriday, "db_connections"))
+	require.NoError(t, err)
+	assert.NotNil(t, analysis, "Expected metrics outside the group to keep normal thresholds")
+
+	analysis, err = analyzer.Analyze(batch(blackFriday.Add(48*time.Hour), "checkout_requests"))
+	require.NoError(t, err)
+	assert.NotNil(t, analysis, "Expected normal thresholds after the event")
+}
@@ -0,0 +1,54 @@
+package analyzers
+
+import "time"
+
+// configFloat reads a numeric config value regardless of whether it was decoded as int or float
+func configFloat(config map[string]interface{}, key string) (float64, bool) {
+	switch v := config[key].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// configInt reads an integer config value regardless of whether it was decoded as int or float
+func configInt(config map[string]interface{}, key string) (int, bool) {
+	if v, ok := configFloat(config, key); ok {
+		return int(v), true
+	}
+	return 0, false
+}
+
+// configDuration reads a duration config value given as a string such as "5m"
+func configDuration(config map[string]interface{}, key string) (time.Duration, bool) {
+	s, ok := config[key].(string)
+	if !ok {
+		return 0, false
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, false
+	}
+	return d, true
+}
+
+// configStringSlice reads a list of strings from a YAML/JSON decoded config value
+func configStringSlice(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
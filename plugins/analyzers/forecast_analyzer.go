@@ -0,0 +1,354 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// smoothingState is the exponential smoothing state of one series. With a season length of
+// zero it is a plain EWMA; otherwise it is additive Holt-Winters triple smoothing.
+type smoothingState struct {
+	count    int
+	level    float64
+	trend    float64
+	seasonal []float64
+	variance float64
+	warmup   []float64
+	lastSeen time.Time
+}
+
+// forecast returns the expected value h steps after the last observation
+func (s *smoothingState) forecast(h int) float64 {
+	value := s.level + float64(h)*s.trend
+	if len(s.seasonal) > 0 {
+		value += s.seasonal[(s.count+h-1)%len(s.seasonal)]
+	}
+	return value
+}
+
+// ForecastAnalyzer implements the DataAnalyzer interface by forecasting each series with
+// exponential smoothing and reporting values that fall outside the prediction interval
+type ForecastAnalyzer struct {
+	name         string
+	version      string
+	status       core.PluginStatus
+	method       string
+	alpha        float64
+	beta         float64
+	gamma        float64
+	seasonLength int
+	width        float64
+	minSamples   int
+	horizon      int
+	states       map[string]*smoothingState
+	sweep        idleSweep
+	mu           sync.RWMutex
+}
+
+// NewForecastAnalyzer creates a new forecasting analyzer plugin using EWMA
+func NewForecastAnalyzer(name string) *ForecastAnalyzer {
+	return &ForecastAnalyzer{
+		name:       name,
+		version:    "1.0.0",
+		status:     core.PluginStatusStopped,
+		method:     "ewma",
+		alpha:      0.3,
+		beta:       0.1,
+		gamma:      0.1,
+		width:      3.0,
+		minSamples: 10,
+		horizon:    1,
+		states:     make(map[string]*smoothingState),
+		sweep:      idleSweep{idle: 24 * time.Hour},
+	}
+}
+
+// Name returns the name of the plugin
+func (f *ForecastAnalyzer) Name() string {
+	return f.name
+}
+
+// Type returns the type of plugin
+func (f *ForecastAnalyzer) Type() core.PluginType {
+	return core.PluginTypeAnalyzer
+}
+
+// Version returns the plugin version
+func (f *ForecastAnalyzer) Version() string {
+	return f.version
+}
+
+// Configure initializes the plugin with configuration
+func (f *ForecastAnalyzer) Configure(config map[string]interface{}) error {
+	if method, ok := config["method"].(string); ok {
+		switch method {
+		case "ewma", "holt_winters":
+			f.method = method
+		default:
+			return fmt.Errorf("unknown forecast method %q", method)
+		}
+	}
+
+	for key, target := range map[string]*float64{"alpha": &f.alpha, "beta": &f.beta, "gamma": &f.gamma} {
+		if value, ok := configFloat(config, key); ok {
+			if value <= 0 || value > 1 {
+				return fmt.Errorf("%s must be in (0, 1], got %v", key, value)
+			}
+			*target = value
+		}
+	}
+
+	if seasonLength, ok := configInt(config, "season_length"); ok && seasonLength > 0 {
+		f.seasonLength = seasonLength
+	}
+	if f.method == "holt_winters" && f.seasonLength < 2 {
+		return fmt.Errorf("holt_winters requires a season_length of at least 2")
+	}
+
+	// Width of the prediction interval in residual standard deviations
+	if width, ok := configFloat(config, "interval_width"); ok && width > 0 {
+		f.width = width
+	}
+
+	if minSamples, ok := configInt(config, "min_samples"); ok && minSamples > 0 {
+		f.minSamples = minSamples
+	}
+
+	if horizon, ok := configInt(config, "horizon"); ok && horizon > 0 {
+		f.horizon = horizon
+	}
+
+	// The state of series that stop reporting is dropped after forget_after
+	if forgetAfter, ok := configDuration(config, "forget_after"); ok && forgetAfter > 0 {
+		f.sweep.idle = forgetAfter
+	}
+
+	f.states = make(map[string]*smoothingState)
+	return nil
+}
+
+// Start begins the plugin's operation
+func (f *ForecastAnalyzer) Start(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.status == core.PluginStatusRunning {
+		return fmt.Errorf("analyzer is already running")
+	}
+
+	f.status = core.PluginStatusStarting
+	slog.Info("Starting forecast analyzer", "plugin", f.name, "type", f.Type(), "method", f.method)
+
+	f.status = core.PluginStatusRunning
+	slog.Info("Forecast analyzer started", "plugin", f.name, "type", f.Type())
+	return nil
+}
+
+// Stop gracefully stops the plugin
+func (f *ForecastAnalyzer) Stop() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.status != core.PluginStatusRunning {
+		return fmt.Errorf("analyzer is not running")
+	}
+
+	f.status = core.PluginStatusStopping
+	slog.Info("Stopping forecast analyzer", "plugin", f.name, "type", f.Type())
+
+	f.status = core.PluginStatusStopped
+	slog.Info("Forecast analyzer stopped", "plugin", f.name, "type", f.Type())
+	return nil
+}
+
+// Status returns the current status of the plugin
+func (f *ForecastAnalyzer) Status() core.PluginStatus {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.status
+}
+
+// Health checks if the plugin is healthy
+func (f *ForecastAnalyzer) Health(ctx context.Context) error {
+	if f.Status() == core.PluginStatusRunning {
+		return nil
+	}
+	return fmt.Errorf("analyzer is not running")
+}
+
+// GetCapabilities returns what this plugin can do
+func (f *ForecastAnalyzer) GetCapabilities() []string {
+	return []string{
+		"forecasting",
+		"trend_analysis",
+		"prediction_intervals",
+	}
+}
+
+// forecastBreach describes a value outside its prediction interval
+type forecastBreach struct {
+	Series   string    `json:"series"`
+	Time     time.Time `json:"time"`
+	Value    float64   `json:"value"`
+	Forecast float64   `json:"forecast"`
+	Lower    float64   `json:"lower"`
+	Upper    float64   `json:"upper"`
+}
+
+// seriesForecast is the forecast for a series after the batch has been applied
+type seriesForecast struct {
+	Series   string  `json:"series"`
+	Horizon  int     `json:"horizon"`
+	Forecast float64 `json:"forecast"`
+	Lower    float64 `json:"lower"`
+	Upper    float64 `json:"upper"`
+}
+
+// Analyze forecasts each point from the state built by earlier points, reports points outside
+// the prediction interval, and then updates the state with the point
+func (f *ForecastAnalyzer) Analyze(data []core.DataPoint) (*core.Analysis, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var breaches []forecastBreach
+	var breached []core.DataPoint
+	touched := make(map[string]*smoothingState)
+	maxScore := 0.0
+	now := time.Now()
+	if f.sweep.due(now) {
+		for key, state := range f.states {
+			if f.sweep.expired(state.lastSeen, now) {
+				delete(f.states, key)
+			}
+		}
+	}
+	for _, point := range data {
+		key := seriesKey(point)
+		state, ok := f.states[key]
+		if !ok {
+			state = &smoothingState{}
+			f.states[key] = state
+		}
+		state.lastSeen = now
+		touched[key] = state
+
+		if state.count >= f.minSamples && state.variance > 0 {
+			expected := state.forecast(1)
+			margin := f.width * math.Sqrt(state.variance)
+			if math.Abs(point.Value-expected) > margin {
+				breaches = append(breaches, forecastBreach{
+					Series:   key,
+					Time:     point.Timestamp,
+					Value:    point.Value,
+					Forecast: expected,
+					Lower:    expected - margin,
+					Upper:    expected + margin,
+				})
+				breached = append(breached, point)
+				if score := math.Abs(point.Value-expected) / margin; score > maxScore {
+					maxScore = score
+				}
+			}
+		}
+
+		f.update(state, point.Value)
+	}
+
+	if len(breaches) == 0 {
+		return nil, nil
+	}
+
+	forecasts := make([]seriesForecast, 0, len(touched))
+	for key, state := range touched {
+		expected := state.forecast(f.horizon)
+		margin := f.width * math.Sqrt(state.variance*float64(f.horizon))
+		forecasts = append(forecasts, seriesForecast{
+			Series:   key,
+			Horizon:  f.horizon,
+			Forecast: expected,
+			Lower:    expected - margin,
+			Upper:    expected + margin,
+		})
+	}
+	sort.Slice(forecasts, func(i, j int) bool { return forecasts[i].Series < forecasts[j].Series })
+
+	confidence := math.Min(maxScore/2, 1.0)
+	first := breaches[0]
+
+	return &core.Analysis{
+		Type:       core.AnalysisTypeTrend,
+		Confidence: confidence,
+		Severity:   severityForConfidence(confidence),
+		Summary: fmt.Sprintf("%d values outside the %s prediction interval; %s was %.2f, forecast %.2f [%.2f, %.2f]",
+			len(breaches), f.method, first.Series, first.Value, first.Forecast, first.Lower, first.Upper),
+		Details: map[string]interface{}{
+			"method":         f.method,
+			"breaches":       breaches,
+			"forecasts":      forecasts,
+			"interval_width": f.width,
+		},
+		DataPoints: breached,
+		Timestamp:  time.Now(),
+		Source:     f.name,
+	}, nil
+}
+
+// CanAnalyze determines if this analyzer can process the given data
+func (f *ForecastAnalyzer) CanAnalyze(data []core.DataPoint) bool {
+	return len(data) > 0
+}
+
+// update folds a value into the smoothing state and the exponentially weighted variance of
+// the one-step forecast error
+func (f *ForecastAnalyzer) update(state *smoothingState, value float64) {
+	if state.count == 0 && len(state.warmup) == 0 {
+		state.level = value
+	}
+
+	// Holt-Winters needs one full season to initialise level and seasonal components
+	if f.method == "holt_winters" && state.seasonal == nil {
+		state.warmup = append(state.warmup, value)
+		if len(state.warmup) < f.seasonLength {
+			return
+		}
+		mean := 0.0
+		for _, v := range state.warmup {
+			mean += v
+		}
+		mean /= float64(len(state.warmup))
+		state.seasonal = make([]float64, f.seasonLength)
+		for i, v := range state.warmup {
+			state.seasonal[i] = v - mean
+		}
+		state.level = mean
+		state.count = f.seasonLength
+		state.warmup = nil
+		return
+	}
+
+	residual := value - state.forecast(1)
+	state.variance = (1-f.alpha)*state.variance + f.alpha*residual*residual
+
+	switch f.method {
+	case "holt_winters":
+		index := state.count % f.seasonLength
+		previousLevel := state.level
+		state.level = f.alpha*(value-state.seasonal[index]) + (1-f.alpha)*(state.level+state.trend)
+		state.trend = f.beta*(state.level-previousLevel) + (1-f.beta)*state.trend
+		state.seasonal[index] = f.gamma*(value-state.level) + (1-f.gamma)*state.seasonal[index]
+	default:
+		state.level = f.alpha*value + (1-f.alpha)*state.level
+	}
+	state.count++
+}
@@ -0,0 +1,99 @@
+package analyzers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func forecastSeries(start time.Time, values ...float64) []core.DataPoint {
+	points := make([]core.DataPoint, len(values))
+	for i, v := range values {
+		points[i] = core.DataPoint{
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Metric:    "queue_depth",
+			Value:     v,
+		}
+	}
+	return points
+}
+
+func TestForecastAnalyzer_EWMA(t *testing.T) {
+	analyzer := NewForecastAnalyzer("forecast")
+	require.NoError(t, analyzer.Configure(map[string]interface{}{"min_samples": 5}))
+
+	start := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	analysis, err := analyzer.Analyze(forecastSeries(start, 100, 102, 98, 101, 99, 100, 103, 97, 100))
+	require.NoError(t, err)
+	assert.Nil(t, analysis, "Expected a steady series to stay within the interval")
+
+	analysis, err = analyzer.Analyze(forecastSeries(start.Add(10*time.Minute), 160))
+	require.NoError(t, err)
+	require.NotNil(t, analysis, "Expected a jump to breach the prediction interval")
+
+	assert.Equal(t, core.AnalysisTypeTrend, analysis.Type)
+	breaches := analysis.Details["breaches"].([]forecastBreach)
+	require.Len(t, breaches, 1)
+	assert.InDelta(t, 100, breaches[0].Forecast, 2)
+	assert.Greater(t, breaches[0].Value, breaches[0].Upper)
+	assert.Len(t, analysis.Details["forecasts"], 1)
+}
+
+func TestForecastAnalyzer_ForgetIdleSeries(t *testing.T) {
+	analyzer := NewForecastAnalyzer("forecast")
+	require.NoError(t, analyzer.Configure(map[string]interface{}{"forget_after": "1h"}))
+
+	start := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	_, err := analyzer.Analyze(forecastSeries(start, 100, 101))
+	require.NoError(t, err)
+	require.Len(t, analyzer.states, 1)
+
+	// The series last reported two hours ago
+	for _, state := range analyzer.states {
+		state.lastSeen = time.Now().Add(-2 * time.Hour)
+	}
+	analyzer.sweep.last = time.Time{}
+	points := forecastSeries(start, 5)
+	points[0].Metric = "other_queue_depth"
+	_, err = analyzer.Analyze(points)
+	require.NoError(t, err)
+	assert.Len(t, analyzer.states, 1, "Expected the idle series to be dropped")
+	assert.Contains(t, analyzer.states, seriesKey(points[0]))
+}
+
+func TestForecastAnalyzer_HoltWinters(t *testing.T) {
+	analyzer := NewForecastAnalyzer("forecast")
+	require.NoError(t, analyzer.Configure(map[string]interface{}{
+		"method":        "holt_winters",
+		"season_length": 4,
+		"min_samples":   8,
+	}))
+
+	// A repeating pattern with a little noise
+	season := []float64{10, 50, 90, 50}
+	var values []float64
+	for cycle := 0; cycle < 6; cycle++ {
+		for i, v := range season {
+			values = append(values, v+float64((cycle+i)%3-1))
+		}
+	}
+
+	start := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	analysis, err := analyzer.Analyze(forecastSeries(start, values...))
+	require.NoError(t, err)
+	assert.Nil(t, analysis, "Expected the seasonal pattern to be forecast")
+
+	// The next value should be a trough; a peak there breaches the interval
+	analysis, err = analyzer.Analyze(forecastSeries(start.Add(time.Hour), 90))
+	require.NoError(t, err)
+	require.NotNil(t, analysis, "Expected an out-of-season value to breach the interval")
+
+	breaches := analysis.Details["breaches"].([]forecastBreach)
+	assert.InDelta(t, 10, breaches[0].Forecast, 5)
+
+	assert.Error(t, NewForecastAnalyzer("forecast").Configure(map[string]interface{}{"method": "holt_winters"}),
+		"Expected holt_winters to require a season length")
+}
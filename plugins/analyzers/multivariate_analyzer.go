@@ -0,0 +1,414 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// vectorWindow holds the most recent feature vectors of a group up to a fixed capacity
+type vectorWindow struct {
+	vectors [][]float64
+	next    int
+}
+
+// push appends a vector, evicting the oldest once the window is full
+func (w *vectorWindow) push(vector []float64, capacity int) {
+	if len(w.vectors) < capacity {
+		w.vectors = append(w.vectors, vector)
+		return
+	}
+	w.vectors[w.next] = vector
+	w.next = (w.next + 1) % capacity
+}
+
+// moments returns the mean vector and covariance matrix of the window
+func (w *vectorWindow) moments() ([]float64, [][]float64) {
+	k := len(w.vectors[0])
+	n := float64(len(w.vectors))
+
+	mean := make([]float64, k)
+	for _, v := range w.vectors {
+		for i := range v {
+			mean[i] += v[i] / n
+		}
+	}
+
+	cov := make([][]float64, k)
+	for i := range cov {
+		cov[i] = make([]float64, k)
+	}
+	for _, v := range w.vectors {
+		for i := 0; i < k; i++ {
+			for j := 0; j < k; j++ {
+				cov[i][j] += (v[i] - mean[i]) * (v[j] - mean[j]) / (n - 1)
+			}
+		}
+	}
+	return mean, cov
+}
+
+// MultivariateAnalyzer implements the DataAnalyzer interface by treating simultaneous values
+// of several metrics as one feature vector and scoring it by its Mahalanobis distance from
+// recent history. This catches combinations that are unusual even when each metric on its
+// own looks normal, such as high traffic with low CPU.
+type MultivariateAnalyzer struct {
+	name       string
+	version    string
+	status     core.PluginStatus
+	metrics    []string
+	groupBy    []string
+	threshold  float64
+	windowSize int
+	minSamples int
+	resolution time.Duration
+	windows    map[string]*vectorWindow
+	mu         sync.RWMutex
+}
+
+// NewMultivariateAnalyzer creates a new multivariate anomaly analyzer plugin
+func NewMultivariateAnalyzer(name string) *MultivariateAnalyzer {
+	return &MultivariateAnalyzer{
+		name:       name,
+		version:    "1.0.0",
+		status:     core.PluginStatusStopped,
+		threshold:  4.0,
+		windowSize: 200,
+		minSamples: 30,
+		resolution: time.Minute,
+		windows:    make(map[string]*vectorWindow),
+	}
+}
+
+// Name returns the name of the plugin
+func (m *MultivariateAnalyzer) Name() string {
+	return m.name
+}
+
+// Type returns the type of plugin
+func (m *MultivariateAnalyzer) Type() core.PluginType {
+	return core.PluginTypeAnalyzer
+}
+
+// Version returns the plugin version
+func (m *MultivariateAnalyzer) Version() string {
+	return m.version
+}
+
+// Configure initializes the plugin with configuration
+func (m *MultivariateAnalyzer) Configure(config map[string]interface{}) error {
+	metrics := configStringSlice(config["metrics"])
+	if len(metrics) < 2 {
+		return fmt.Errorf("multivariate analysis needs at least two metrics")
+	}
+	m.metrics = metrics
+
+	// Labels that identify which series belong to the same vector, e.g. instance
+	m.groupBy = configStringSlice(config["group_by"])
+
+	// Mahalanobis distance above which a vector is reported
+	if threshold, ok := configFloat(config, "threshold"); ok && threshold > 0 {
+		m.threshold = threshold
+	}
+
+	if windowSize, ok := configInt(config, "window_size"); ok && windowSize > 0 {
+		m.windowSize = windowSize
+	}
+
+	if minSamples, ok := configInt(config, "min_samples"); ok && minSamples > 0 {
+		m.minSamples = minSamples
+	}
+	if m.minSamples <= len(m.metrics) {
+		return fmt.Errorf("min_samples must exceed the number of metrics (%d)", len(m.metrics))
+	}
+
+	// Points within the same resolution step count as simultaneous
+	if resolution, ok := configDuration(config, "resolution"); ok && resolution > 0 {
+		m.resolution = resolution
+	}
+
+	m.windows = make(map[string]*vectorWindow)
+	return nil
+}
+
+// Start begins the plugin's operation
+func (m *MultivariateAnalyzer) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.status == core.PluginStatusRunning {
+		return fmt.Errorf("analyzer is already running")
+	}
+
+	m.status = core.PluginStatusStarting
+	slog.Info("Starting multivariate analyzer", "plugin", m.name, "type", m.Type(), "metrics", m.metrics)
+
+	m.status = core.PluginStatusRunning
+	slog.Info("Multivariate analyzer started", "plugin", m.name, "type", m.Type())
+	return nil
+}
+
+// Stop gracefully stops the plugin
+func (m *MultivariateAnalyzer) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.status != core.PluginStatusRunning {
+		return fmt.Errorf("analyzer is not running")
+	}
+
+	m.status = core.PluginStatusStopping
+	slog.Info("Stopping multivariate analyzer", "plugin", m.name, "type", m.Type())
+
+	m.status = core.PluginStatusStopped
+	slog.Info("Multivariate analyzer stopped", "plugin", m.name, "type", m.Type())
+	return nil
+}
+
+// Status returns the current status of the plugin
+func (m *MultivariateAnalyzer) Status() core.PluginStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// Health checks if the plugin is healthy
+func (m *MultivariateAnalyzer) Health(ctx context.Context) error {
+	if m.Status() == core.PluginStatusRunning {
+		return nil
+	}
+	return fmt.Errorf("analyzer is not running")
+}
+
+// GetCapabilities returns what this plugin can do
+func (m *MultivariateAnalyzer) GetCapabilities() []string {
+	return []string{
+		"detect_anomalies",
+		"multivariate_analysis",
+		"mahalanobis_distance",
+	}
+}
+
+// multivariateOutlier describes a feature vector far from its group's recent history
+type multivariateOutlier struct {
+	Group    string             `json:"group"`
+	Time     time.Time          `json:"time"`
+	Values   map[string]float64 `json:"values"`
+	Distance float64            `json:"distance"`
+	// ZScores show how unusual each metric is on its own, which is often not very
+	ZScores map[string]float64 `json:"z_scores"`
+}
+
+// pendingVector collects the metric values of one group at one time step
+type pendingVector struct {
+	group  string
+	at     time.Time
+	values []float64
+	seen   []bool
+	points []core.DataPoint
+}
+
+// Analyze assembles vectors from points of the configured metrics that share a group and
+// time step, scores complete vectors against their group's window, then adds them to it.
+// Vectors missing any metric in the batch are ignored.
+func (m *MultivariateAnalyzer) Analyze(data []core.DataPoint) (*core.Analysis, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	index := make(map[string]int, len(m.metrics))
+	for i, metric := range m.metrics {
+		index[metric] = i
+	}
+
+	now := time.Now()
+	pending := make(map[string]*pendingVector)
+	var order []string
+	for _, point := range data {
+		i, ok := index[point.Metric]
+		if !ok {
+			continue
+		}
+		at := point.Timestamp
+		if at.IsZero() {
+			at = now
+		}
+		at = at.Truncate(m.resolution)
+		group := m.groupKey(point)
+		key := group + "@" + at.Format(time.RFC3339Nano)
+
+		vector, ok := pending[key]
+		if !ok {
+			vector = &pendingVector{
+				group:  group,
+				at:     at,
+				values: make([]float64, len(m.metrics)),
+				seen:   make([]bool, len(m.metrics)),
+			}
+			pending[key] = vector
+			order = append(order, key)
+		}
+		vector.values[i] = point.Value
+		vector.seen[i] = true
+		vector.points = append(vector.points, point)
+	}
+
+	// Score in time order so each vector is judged only against earlier ones
+	sort.SliceStable(order, func(i, j int) bool { return pending[order[i]].at.Before(pending[order[j]].at) })
+
+	var outliers []multivariateOutlier
+	var outlierPoints []core.DataPoint
+	maxScore := 0.0
+	for _, key := range order {
+		vector := pending[key]
+		if !allTrue(vector.seen) {
+			continue
+		}
+
+		window, ok := m.windows[vector.group]
+		if !ok {
+			window = &vectorWindow{}
+			m.windows[vector.group] = window
+		}
+
+		if len(window.vectors) >= m.minSamples {
+			mean, cov := window.moments()
+			if distance, ok := mahalanobis(vector.values, mean, cov); ok && distance > m.threshold {
+				outlier := multivariateOutlier{
+					Group:    vector.group,
+					Time:     vector.at,
+					Values:   make(map[string]float64, len(m.metrics)),
+					Distance: distance,
+					ZScores:  make(map[string]float64, len(m.metrics)),
+				}
+				for i, metric := range m.metrics {
+					outlier.Values[metric] = vector.values[i]
+					if sd := math.Sqrt(cov[i][i]); sd > 0 {
+						outlier.ZScores[metric] = (vector.values[i] - mean[i]) / sd
+					}
+				}
+				outliers = append(outliers, outlier)
+				outlierPoints = append(outlierPoints, vector.points...)
+				maxScore = math.Max(maxScore, distance/m.threshold)
+			}
+		}
+
+		window.push(vector.values, m.windowSize)
+	}
+
+	if len(outliers) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(outliers, func(i, j int) bool { return outliers[i].Distance > outliers[j].Distance })
+	worst := outliers[0]
+	confidence := math.Min(maxScore/2, 1.0)
+
+	group := worst.Group
+	if group == "" {
+		group = "all series"
+	}
+
+	return &core.Analysis{
+		Type:       core.AnalysisTypeAnomaly,
+		Confidence: confidence,
+		Severity:   severityForConfidence(confidence),
+		Summary: fmt.Sprintf("Unusual combination of %s for %s (Mahalanobis distance %.2f, threshold %.2f)",
+			strings.Join(m.metrics, ", "), group, worst.Distance, m.threshold),
+		Details: map[string]interface{}{
+			"outliers":  outliers,
+			"metrics":   m.metrics,
+			"threshold": m.threshold,
+		},
+		DataPoints: outlierPoints,
+		Timestamp:  now,
+		Source:     m.name,
+	}, nil
+}
+
+// CanAnalyze determines if this analyzer can process the given data
+func (m *MultivariateAnalyzer) CanAnalyze(data []core.DataPoint) bool {
+	for _, point := range data {
+		for _, metric := range m.metrics {
+			if point.Metric == metric {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// groupKey renders the group_by labels of a point
+func (m *MultivariateAnalyzer) groupKey(point core.DataPoint) string {
+	parts := make([]string, 0, len(m.groupBy))
+	for _, label := range m.groupBy {
+		parts = append(parts, fmt.Sprintf("%s=%q", label, point.Labels[label]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// allTrue reports whether every element is true
+func allTrue(values []bool) bool {
+	for _, v := range values {
+		if !v {
+			return false
+		}
+	}
+	return true
+}
+
+// mahalanobis returns the Mahalanobis distance of x from a distribution with the given mean
+// and covariance. A small ridge keeps perfectly correlated or constant metrics invertible.
+func mahalanobis(x, mean []float64, cov [][]float64) (float64, bool) {
+	k := len(x)
+	ridge := 0.0
+	for i := 0; i < k; i++ {
+		ridge += cov[i][i]
+	}
+	ridge = math.Max(ridge/float64(k)*1e-6, 1e-12)
+
+	// Solve cov * y = (x - mean) by Gauss-Jordan elimination with partial pivoting
+	a := make([][]float64, k)
+	for i := range a {
+		a[i] = make([]float64, k+1)
+		copy(a[i], cov[i])
+		a[i][i] += ridge
+		a[i][k] = x[i] - mean[i]
+	}
+	for col := 0; col < k; col++ {
+		pivot := col
+		for row := col + 1; row < k; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-15 {
+			return 0, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		for row := 0; row < k; row++ {
+			if row == col {
+				continue
+			}
+			factor := a[row][col] / a[col][col]
+			for c := col; c <= k; c++ {
+				a[row][c] -= factor * a[col][c]
+			}
+		}
+	}
+
+	d2 := 0.0
+	for i := 0; i < k; i++ {
+		d2 += (x[i] - mean[i]) * a[i][k] / a[i][i]
+	}
+	if d2 < 0 {
+		return 0, false
+	}
+	return math.Sqrt(d2), true
+}
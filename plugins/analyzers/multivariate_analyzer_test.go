@@ -0,0 +1,73 @@
+package analyzers
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultivariateAnalyzer_Configure(t *testing.T) {
+	analyzer := NewMultivariateAnalyzer("multivariate")
+	assert.Error(t, analyzer.Configure(map[string]interface{}{"metrics": []interface{}{"cpu"}}),
+		"Expected at least two metrics to be required")
+	assert.Error(t, analyzer.Configure(map[string]interface{}{
+		"metrics":     []interface{}{"cpu", "rps", "latency"},
+		"min_samples": 3,
+	}), "Expected min_samples to exceed the number of metrics")
+}
+
+func TestMultivariateAnalyzer_CorrelatedMetrics(t *testing.T) {
+	analyzer := NewMultivariateAnalyzer("multivariate")
+	require.NoError(t, analyzer.Configure(map[string]interface{}{
+		"metrics":     []interface{}{"requests_per_second", "cpu_usage_percent"},
+		"group_by":    []interface{}{"instance"},
+		"min_samples": 20,
+	}))
+
+	start := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	sample := func(minute int, rps, cpu float64, instance string) []core.DataPoint {
+		at := start.Add(time.Duration(minute) * time.Minute)
+		labels := map[string]string{"instance": instance}
+		return []core.DataPoint{
+			{Timestamp: at, Metric: "requests_per_second", Value: rps, Labels: labels},
+			{Timestamp: at, Metric: "cpu_usage_percent", Value: cpu, Labels: labels},
+		}
+	}
+
+	// CPU tracks traffic closely
+	for i := 0; i < 40; i++ {
+		rps := 100 + 50*math.Sin(float64(i)/3)
+		cpu := rps/2 + float64(i%3-1)
+		analysis, err := analyzer.Analyze(sample(i, rps, cpu, "web-1"))
+		require.NoError(t, err)
+		assert.Nil(t, analysis, "Expected the correlated history to be normal (sample %d)", i)
+	}
+
+	// High traffic with low CPU: each value is within its usual range, the pair is not
+	analysis, err := analyzer.Analyze(sample(40, 140, 35, "web-1"))
+	require.NoError(t, err)
+	require.NotNil(t, analysis, "Expected the unusual combination to be flagged")
+	assert.Equal(t, core.AnalysisTypeAnomaly, analysis.Type)
+
+	outliers := analysis.Details["outliers"].([]multivariateOutlier)
+	require.Len(t, outliers, 1)
+	assert.Equal(t, `instance="web-1"`, outliers[0].Group)
+	for metric, z := range outliers[0].ZScores {
+		assert.Less(t, math.Abs(z), 2.0, "Expected %s to look normal on its own", metric)
+	}
+	assert.Len(t, analysis.DataPoints, 2)
+
+	// Another instance has no history yet
+	analysis, err = analyzer.Analyze(sample(41, 140, 35, "web-2"))
+	require.NoError(t, err)
+	assert.Nil(t, analysis, "Expected groups to keep separate windows")
+
+	// Incomplete vectors are ignored
+	analysis, err = analyzer.Analyze(sample(42, 140, 35, "web-1")[:1])
+	require.NoError(t, err)
+	assert.Nil(t, analysis)
+}
@@ -0,0 +1,263 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// SeasonalAnalyzer implements the DataAnalyzer interface by judging each point against the
+// history of its own series at the same position in a repeating period, e.g. the same hour
+// of the day or week. Predictable peaks become part of what is normal instead of anomalies.
+type SeasonalAnalyzer struct {
+	name        string
+	version     string
+	status      core.PluginStatus
+	threshold   float64
+	minSamples  int
+	period      time.Duration
+	slot        time.Duration
+	forgetAfter time.Duration
+	profile     *baselineProfile
+	mu          sync.RWMutex
+}
+
+// NewSeasonalAnalyzer creates a new seasonal analyzer plugin with an hour-of-week profile
+func NewSeasonalAnalyzer(name string) *SeasonalAnalyzer {
+	return &SeasonalAnalyzer{
+		name:        name,
+		version:     "1.0.0",
+		status:      core.PluginStatusStopped,
+		threshold:   3.0,
+		minSamples:  4,
+		period:      7 * 24 * time.Hour,
+		slot:        time.Hour,
+		forgetAfter: 24 * time.Hour,
+		profile:     newBaselineProfile(7*24*time.Hour, time.Hour, time.UTC, 24*time.Hour),
+	}
+}
+
+// Name returns the name of the plugin
+func (s *SeasonalAnalyzer) Name() string {
+	return s.name
+}
+
+// Type returns the type of plugin
+func (s *SeasonalAnalyzer) Type() core.PluginType {
+	return core.PluginTypeAnalyzer
+}
+
+// Version returns the plugin version
+func (s *SeasonalAnalyzer) Version() string {
+	return s.version
+}
+
+// Configure initializes the plugin with configuration
+func (s *SeasonalAnalyzer) Configure(config map[string]interface{}) error {
+	if threshold, ok := configFloat(config, "threshold"); ok && threshold > 0 {
+		s.threshold = threshold
+	}
+
+	if minSamples, ok := configInt(config, "min_samples"); ok && minSamples > 0 {
+		s.minSamples = minSamples
+	}
+
+	// "daily" and "weekly" are shorthands for the common seasonality periods
+	if period, ok := config["period"].(string); ok {
+		switch period {
+		case "daily":
+			s.period = 24 * time.Hour
+		case "weekly":
+			s.period = 7 * 24 * time.Hour
+		default:
+			d, err := time.ParseDuration(period)
+			if err != nil {
+				return fmt.Errorf("invalid seasonality period %q: %w", period, err)
+			}
+			s.period = d
+		}
+	}
+
+	if slot, ok := configDuration(config, "slot"); ok {
+		s.slot = slot
+	}
+
+	// The profile of a series that stops reporting is dropped after forget_after
+	if forgetAfter, ok := configDuration(config, "forget_after"); ok && forgetAfter > 0 {
+		s.forgetAfter = forgetAfter
+	}
+
+	location := time.UTC
+	if tz, ok := config["timezone"].(string); ok {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", tz, err)
+		}
+		location = loc
+	}
+
+	profile, err := newSeasonalProfile(s.period, s.slot, location, s.forgetAfter)
+	if err != nil {
+		return err
+	}
+	s.profile = profile
+
+	return nil
+}
+
+// Start begins the plugin's operation
+func (s *SeasonalAnalyzer) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status == core.PluginStatusRunning {
+		return fmt.Errorf("analyzer is already running")
+	}
+
+	s.status = core.PluginStatusStarting
+	slog.Info("Starting seasonal analyzer", "plugin", s.name, "type", s.Type(), "period", s.period, "slot", s.slot)
+
+	s.status = core.PluginStatusRunning
+	slog.Info("Seasonal analyzer started", "plugin", s.name, "type", s.Type())
+	return nil
+}
+
+// Stop gracefully stops the plugin
+func (s *SeasonalAnalyzer) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status != core.PluginStatusRunning {
+		return fmt.Errorf("analyzer is not running")
+	}
+
+	s.status = core.PluginStatusStopping
+	slog.Info("Stopping seasonal analyzer", "plugin", s.name, "type", s.Type())
+
+	s.status = core.PluginStatusStopped
+	slog.Info("Seasonal analyzer stopped", "plugin", s.name, "type", s.Type())
+	return nil
+}
+
+// Status returns the current status of the plugin
+func (s *SeasonalAnalyzer) Status() core.PluginStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// Health checks if the plugin is healthy
+func (s *SeasonalAnalyzer) Health(ctx context.Context) error {
+	if s.Status() == core.PluginStatusRunning {
+		return nil
+	}
+	return fmt.Errorf("analyzer is not running")
+}
+
+// GetCapabilities returns what this plugin can do
+func (s *SeasonalAnalyzer) GetCapabilities() []string {
+	return []string{
+		"detect_anomalies",
+		"seasonal_baselines",
+	}
+}
+
+// seasonalDeviation describes a point that deviated from its seasonal baseline
+type seasonalDeviation struct {
+	Series   string  `json:"series"`
+	Slot     string  `json:"slot"`
+	Value    float64 `json:"value"`
+	Expected float64 `json:"expected"`
+	StdDev   float64 `json:"std_dev"`
+	Score    float64 `json:"score"`
+}
+
+// Analyze compares each point to its series' baseline for the same slot of the period.
+// Series still warming up are only learned from.
+func (s *SeasonalAnalyzer) Analyze(data []core.DataPoint) (*core.Analysis, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var deviations []seasonalDeviation
+	var anomalies []core.DataPoint
+	maxScore := 0.0
+	warmingUp := 0
+	for _, point := range data {
+		key := seriesKey(point)
+		stats, ok := s.profile.lookup(key, point.Timestamp)
+		if !ok || stats.count < s.minSamples {
+			warmingUp++
+			continue
+		}
+
+		stdDev := stats.stdDev()
+		if stdDev == 0 {
+			continue
+		}
+
+		z := math.Abs(point.Value-stats.mean) / stdDev
+		if z <= s.threshold {
+			continue
+		}
+
+		deviations = append(deviations, seasonalDeviation{
+			Series:   key,
+			Slot:     s.profile.slotLabel(point.Timestamp),
+			Value:    point.Value,
+			Expected: stats.mean,
+			StdDev:   stdDev,
+			Score:    z,
+		})
+		anomalies = append(anomalies, point)
+		if score := z / s.threshold; score > maxScore {
+			maxScore = score
+		}
+	}
+
+	// Learn from this batch only after judging it against the existing baseline
+	now := time.Now()
+	for _, point := range data {
+		s.profile.observe(seriesKey(point), point.Timestamp, point.Value, now)
+	}
+
+	if len(deviations) == 0 {
+		return nil, nil
+	}
+
+	confidence := math.Min(maxScore/2, 1.0)
+	worst := deviations[0]
+	for _, deviation := range deviations[1:] {
+		if deviation.Score > worst.Score {
+			worst = deviation
+		}
+	}
+
+	return &core.Analysis{
+		Type:       core.AnalysisTypeAnomaly,
+		Confidence: confidence,
+		Severity:   severityForConfidence(confidence),
+		Summary: fmt.Sprintf("Detected %d seasonal anomalies; %s was %.2f at %s, expected %.2f (%.1fσ)",
+			len(deviations), worst.Series, worst.Value, worst.Slot, worst.Expected, worst.Score),
+		Details: map[string]interface{}{
+			"deviations": deviations,
+			"threshold":  s.threshold,
+			"period":     s.period.String(),
+			"slot":       s.slot.String(),
+			"warming_up": warmingUp,
+		},
+		DataPoints: anomalies,
+		Timestamp:  time.Now(),
+		Source:     s.name,
+	}, nil
+}
+
+// CanAnalyze determines if this analyzer can process the given data
+func (s *SeasonalAnalyzer) CanAnalyze(data []core.DataPoint) bool {
+	return len(data) > 0
+}
@@ -0,0 +1,81 @@
+package analyzers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeasonalAnalyzer_DailyPeak(t *testing.T) {
+	analyzer := NewSeasonalAnalyzer("seasonal")
+	require.NoError(t, analyzer.Configure(map[string]interface{}{
+		"period":      "daily",
+		"min_samples": 3,
+	}))
+
+	// Traffic peaks at 09:00 every day and is quiet at 03:00
+	start := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	for day := 0; day < 5; day++ {
+		base := start.Add(time.Duration(day) * 24 * time.Hour)
+		jitter := float64(day % 2)
+		analysis, err := analyzer.Analyze([]core.DataPoint{
+			{Timestamp: base.Add(3 * time.Hour), Metric: "requests_per_second", Value: 10 + jitter},
+			{Timestamp: base.Add(9 * time.Hour), Metric: "requests_per_second", Value: 500 + jitter*10},
+		})
+		require.NoError(t, err)
+		assert.Nil(t, analysis, "Expected the daily peak to be learned, not flagged (day %d)", day)
+	}
+
+	next := start.Add(5 * 24 * time.Hour)
+	analysis, err := analyzer.Analyze([]core.DataPoint{
+		{Timestamp: next.Add(9 * time.Hour), Metric: "requests_per_second", Value: 505},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, analysis, "Expected a normal peak to pass")
+
+	// Peak-level traffic in the middle of the night is anomalous
+	analysis, err = analyzer.Analyze([]core.DataPoint{
+		{Timestamp: next.Add(3 * time.Hour), Metric: "requests_per_second", Value: 500},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, analysis, "Expected off-peak traffic spike to be flagged")
+
+	deviations := analysis.Details["deviations"].([]seasonalDeviation)
+	require.Len(t, deviations, 1)
+	assert.Equal(t, "03:00", deviations[0].Slot)
+	assert.Equal(t, "critical", analysis.Severity)
+}
+
+func TestBaselineProfile_ForgetIdleSeries(t *testing.T) {
+	profile := newBaselineProfile(24*time.Hour, time.Hour, time.UTC, time.Hour)
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	profile.observe("pod-1", start, 1, start)
+	profile.observe("pod-2", start, 2, start)
+	profile.observe("pod-1", start, 3, start.Add(50*time.Minute))
+	assert.Equal(t, 2, profile.len())
+
+	// pod-2 has been idle for more than an hour, pod-1 has not
+	profile.observe("pod-3", start, 4, start.Add(90*time.Minute))
+	assert.Equal(t, 2, profile.len(), "Expected the idle profile to be dropped")
+	_, ok := profile.lookup("pod-2", start)
+	assert.False(t, ok)
+	stats, ok := profile.lookup("pod-1", start)
+	require.True(t, ok)
+	assert.Equal(t, 2, stats.count)
+
+	forever := newBaselineProfile(24*time.Hour, time.Hour, time.UTC, 0)
+	forever.observe("pod-1", start, 1, start)
+	forever.observe("pod-2", start, 1, start.Add(365*24*time.Hour))
+	assert.Equal(t, 2, forever.len(), "Expected profiles to be kept without an idle limit")
+}
+
+func TestSeasonalAnalyzer_ConfigureValidatesPeriod(t *testing.T) {
+	analyzer := NewSeasonalAnalyzer("seasonal")
+	assert.Error(t, analyzer.Configure(map[string]interface{}{"period": "5h"}), "Expected periods that don't divide a week to be rejected")
+	assert.Error(t, analyzer.Configure(map[string]interface{}{"period": "24h", "slot": "7h"}), "Expected slots that don't divide the period to be rejected")
+	assert.NoError(t, analyzer.Configure(map[string]interface{}{"period": "12h", "slot": "30m"}))
+}
@@ -0,0 +1,304 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// seriesActivity tracks when a series last reported and how often it usually does
+type seriesActivity struct {
+	lastPoint core.DataPoint
+	lastSeen  time.Time
+	cadence   time.Duration
+	alerted   bool
+}
+
+// StalenessAnalyzer implements the ScheduledAnalyzer interface to detect series that
+// stop reporting. It learns each series' cadence from incoming batches and, on its own
+// timer, reports series that have been silent for longer than expected.
+type StalenessAnalyzer struct {
+	name            string
+	version         string
+	status          core.PluginStatus
+	staleAfter      time.Duration
+	missedIntervals float64
+	forgetAfter     time.Duration
+	interval        time.Duration
+	severity        string
+	metrics         []string
+	series          map[string]*seriesActivity
+	mu              sync.RWMutex
+}
+
+// NewStalenessAnalyzer creates a new missing-data analyzer plugin
+func NewStalenessAnalyzer(name string) *StalenessAnalyzer {
+	return &StalenessAnalyzer{
+		name:            name,
+		version:         "1.0.0",
+		status:          core.PluginStatusStopped,
+		staleAfter:      5 * time.Minute,
+		missedIntervals: 3,
+		forgetAfter:     24 * time.Hour,
+		interval:        30 * time.Second,
+		severity:        "high",
+		series:          make(map[string]*seriesActivity),
+	}
+}
+
+// Name returns the name of the plugin
+func (s *StalenessAnalyzer) Name() string {
+	return s.name
+}
+
+// Type returns the type of plugin
+func (s *StalenessAnalyzer) Type() core.PluginType {
+	return core.PluginTypeAnalyzer
+}
+
+// Version returns the plugin version
+func (s *StalenessAnalyzer) Version() string {
+	return s.version
+}
+
+// Configure initializes the plugin with configuration
+func (s *StalenessAnalyzer) Configure(config map[string]interface{}) error {
+	if staleAfter, ok := configDuration(config, "stale_after"); ok && staleAfter > 0 {
+		s.staleAfter = staleAfter
+	}
+
+	if missed, ok := configFloat(config, "missed_intervals"); ok && missed > 0 {
+		s.missedIntervals = missed
+	}
+
+	if forgetAfter, ok := configDuration(config, "forget_after"); ok && forgetAfter > 0 {
+		s.forgetAfter = forgetAfter
+	}
+
+	if interval, ok := configDuration(config, "interval"); ok && interval > 0 {
+		s.interval = interval
+	}
+
+	if severity, ok := config["severity"].(string); ok {
+		switch severity {
+		case "low", "medium", "high", "critical":
+			s.severity = severity
+		default:
+			return fmt.Errorf("unknown severity %q", severity)
+		}
+	}
+
+	// Only series whose metric matches one of these globs are tracked
+	if metrics := configStringSlice(config["metrics"]); len(metrics) > 0 {
+		for _, pattern := range metrics {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid metric pattern %q: %w", pattern, err)
+			}
+		}
+		s.metrics = metrics
+	}
+
+	return nil
+}
+
+// Start begins the plugin's operation
+func (s *StalenessAnalyzer) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status == core.PluginStatusRunning {
+		return fmt.Errorf("analyzer is already running")
+	}
+
+	s.status = core.PluginStatusStarting
+	slog.Info("Starting staleness analyzer", "plugin", s.name, "type", s.Type())
+
+	s.status = core.PluginStatusRunning
+	slog.Info("Staleness analyzer started", "plugin", s.name, "type", s.Type())
+	return nil
+}
+
+// Stop gracefully stops the plugin
+func (s *StalenessAnalyzer) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status != core.PluginStatusRunning {
+		return fmt.Errorf("analyzer is not running")
+	}
+
+	s.status = core.PluginStatusStopping
+	slog.Info("Stopping staleness analyzer", "plugin", s.name, "type", s.Type())
+
+	s.status = core.PluginStatusStopped
+	slog.Info("Staleness analyzer stopped", "plugin", s.name, "type", s.Type())
+	return nil
+}
+
+// Status returns the current status of the plugin
+func (s *StalenessAnalyzer) Status() core.PluginStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// Health checks if the plugin is healthy
+func (s *StalenessAnalyzer) Health(ctx context.Context) error {
+	if s.Status() == core.PluginStatusRunning {
+		return nil
+	}
+	return fmt.Errorf("analyzer is not running")
+}
+
+// GetCapabilities returns what this plugin can do
+func (s *StalenessAnalyzer) GetCapabilities() []string {
+	return []string{
+		"detect_missing_data",
+		"cadence_tracking",
+	}
+}
+
+// Analyze records when each series reported; missing data is reported by Evaluate
+func (s *StalenessAnalyzer) Analyze(data []core.DataPoint) (*core.Analysis, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, point := range data {
+		if !s.tracks(point.Metric) {
+			continue
+		}
+
+		seen := point.Timestamp
+		if seen.IsZero() {
+			seen = now
+		}
+
+		key := seriesKey(point)
+		activity, ok := s.series[key]
+		if !ok {
+			s.series[key] = &seriesActivity{lastPoint: point, lastSeen: seen}
+			continue
+		}
+		if !seen.After(activity.lastSeen) {
+			continue
+		}
+
+		// Smooth the observed gap so a single late scrape doesn't redefine the cadence
+		gap := seen.Sub(activity.lastSeen)
+		if activity.cadence == 0 {
+			activity.cadence = gap
+		} else {
+			activity.cadence = time.Duration(0.7*float64(activity.cadence) + 0.3*float64(gap))
+		}
+		activity.lastPoint = point
+		activity.lastSeen = seen
+		activity.alerted = false
+	}
+
+	return nil, nil
+}
+
+// CanAnalyze determines if this analyzer can process the given data
+func (s *StalenessAnalyzer) CanAnalyze(data []core.DataPoint) bool {
+	return len(data) > 0
+}
+
+// staleSeries describes a series that stopped reporting
+type staleSeries struct {
+	Series   string        `json:"series"`
+	LastSeen time.Time     `json:"last_seen"`
+	Silence  time.Duration `json:"silence"`
+	Expected time.Duration `json:"expected"`
+}
+
+// Evaluate reports series that have been silent longer than expected. Each series is
+// reported once per outage and again only after it has reported and gone silent again.
+func (s *StalenessAnalyzer) Evaluate(now time.Time) (*core.Analysis, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stale []staleSeries
+	var lastPoints []core.DataPoint
+	for key, activity := range s.series {
+		silence := now.Sub(activity.lastSeen)
+		if silence > s.forgetAfter {
+			delete(s.series, key)
+			continue
+		}
+
+		expected := s.expectedSilence(activity)
+		if silence <= expected || activity.alerted {
+			continue
+		}
+
+		activity.alerted = true
+		stale = append(stale, staleSeries{
+			Series:   key,
+			LastSeen: activity.lastSeen,
+			Silence:  silence.Round(time.Second),
+			Expected: expected,
+		})
+		lastPoints = append(lastPoints, activity.lastPoint)
+	}
+
+	if len(stale) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(stale, func(i, j int) bool { return stale[i].Series < stale[j].Series })
+	sort.Slice(lastPoints, func(i, j int) bool { return seriesKey(lastPoints[i]) < seriesKey(lastPoints[j]) })
+
+	summary := fmt.Sprintf("No data received for %s for %s", stale[0].Series, stale[0].Silence)
+	if len(stale) > 1 {
+		summary = fmt.Sprintf("No data received for %d series, including %s for %s", len(stale), stale[0].Series, stale[0].Silence)
+	}
+
+	return &core.Analysis{
+		Type:       core.AnalysisTypeAlert,
+		Confidence: 1.0,
+		Severity:   s.severity,
+		Summary:    summary,
+		Details: map[string]interface{}{
+			"stale_series": stale,
+			"stale_count":  len(stale),
+		},
+		DataPoints: lastPoints,
+		Timestamp:  now,
+		Source:     s.name,
+	}, nil
+}
+
+// GetEvaluationInterval returns how often Evaluate should run
+func (s *StalenessAnalyzer) GetEvaluationInterval() time.Duration {
+	return s.interval
+}
+
+// expectedSilence is how long a series may be quiet before it is considered stale: the
+// configured minimum, or several missed intervals for series that report slowly
+func (s *StalenessAnalyzer) expectedSilence(activity *seriesActivity) time.Duration {
+	expected := s.staleAfter
+	if byCadence := time.Duration(s.missedIntervals * float64(activity.cadence)); byCadence > expected {
+		expected = byCadence
+	}
+	return expected
+}
+
+// tracks reports whether the metric is covered by the configured patterns
+func (s *StalenessAnalyzer) tracks(metric string) bool {
+	if len(s.metrics) == 0 {
+		return true
+	}
+	for _, pattern := range s.metrics {
+		if matched, _ := path.Match(pattern, metric); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package analyzers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStalenessAnalyzer_Evaluate(t *testing.T) {
+	analyzer := NewStalenessAnalyzer("stale")
+	require.NoError(t, analyzer.Configure(map[string]interface{}{
+		"stale_after": "5m",
+		"metrics":     []interface{}{"up", "http_*"},
+	}))
+
+	start := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		at := start.Add(time.Duration(i) * 30 * time.Second)
+		analysis, err := analyzer.Analyze([]core.DataPoint{
+			{Timestamp: at, Metric: "up", Value: 1, Labels: map[string]string{"instance": "web-1"}},
+			{Timestamp: at, Metric: "http_requests_total", Value: 10},
+			{Timestamp: at, Metric: "ignored_metric", Value: 1},
+		})
+		require.NoError(t, err)
+		assert.Nil(t, analysis, "Expected Analyze to only record activity")
+	}
+	lastSeen := start.Add(time.Minute)
+
+	analysis, err := analyzer.Evaluate(lastSeen.Add(4 * time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, analysis, "Expected no analysis before stale_after elapses")
+
+	// Only http_requests_total keeps reporting
+	_, err = analyzer.Analyze([]core.DataPoint{
+		{Timestamp: lastSeen.Add(5 * time.Minute), Metric: "http_requests_total", Value: 12},
+	})
+	require.NoError(t, err)
+
+	analysis, err = analyzer.Evaluate(lastSeen.Add(6 * time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, analysis, "Expected stale series to be reported")
+
+	assert.Equal(t, core.AnalysisTypeAlert, analysis.Type)
+	assert.Equal(t, "high", analysis.Severity)
+	assert.Equal(t, `No data received for up{instance="web-1"} for 6m0s`, analysis.Summary)
+	assert.Equal(t, 1, analysis.Details["stale_count"])
+
+	analysis, err = analyzer.Evaluate(lastSeen.Add(7 * time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, analysis, "Expected each outage to be reported once")
+}
+
+func TestStalenessAnalyzer_SlowCadence(t *testing.T) {
+	analyzer := NewStalenessAnalyzer("stale")
+	require.NoError(t, analyzer.Configure(map[string]interface{}{"stale_after": "1m"}))
+
+	start := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		_, err := analyzer.Analyze([]core.DataPoint{
+			{Timestamp: start.Add(time.Duration(i) * 10 * time.Minute), Metric: "backup_age_seconds", Value: 1},
+		})
+		require.NoError(t, err)
+	}
+	lastSeen := start.Add(20 * time.Minute)
+
+	// A series that reports every 10m is not stale after 15m of silence
+	analysis, err := analyzer.Evaluate(lastSeen.Add(15 * time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, analysis)
+
+	analysis, err = analyzer.Evaluate(lastSeen.Add(31 * time.Minute))
+	require.NoError(t, err)
+	assert.NotNil(t, analysis, "Expected the series to be stale after three missed intervals")
+}
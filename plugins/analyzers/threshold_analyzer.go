@@ -0,0 +1,368 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// thresholdOperators are the comparisons a rule may use
+var thresholdOperators = map[string]func(value, threshold float64) bool{
+	">":  func(v, t float64) bool { return v > t },
+	">=": func(v, t float64) bool { return v >= t },
+	"<":  func(v, t float64) bool { return v < t },
+	"<=": func(v, t float64) bool { return v <= t },
+	"==": func(v, t float64) bool { return v == t },
+	"!=": func(v, t float64) bool { return v != t },
+}
+
+// ThresholdRule is a static condition on a metric, like a basic Prometheus alerting rule
+type ThresholdRule struct {
+	Name     string
+	Metric   string
+	Operator string
+	Value    float64
+	For      time.Duration
+	Severity string
+	Labels   map[string]string
+}
+
+// matches reports whether the point belongs to a series covered by the rule
+func (r ThresholdRule) matches(point core.DataPoint) bool {
+	if matched, _ := path.Match(r.Metric, point.Metric); !matched {
+		return false
+	}
+	for k, v := range r.Labels {
+		if point.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleState tracks how long a rule's condition has held for one series
+type ruleState struct {
+	activeSince time.Time
+	lastSeen    time.Time
+	holdFor     time.Duration
+}
+
+// ThresholdAnalyzer implements the DataAnalyzer interface by evaluating static rules.
+// A rule fires once its condition has held for a series for the rule's duration and
+// keeps firing until the condition stops holding, or until the series has sent no
+// values for the rule's duration plus forget_after.
+type ThresholdAnalyzer struct {
+	name    string
+	version string
+	status  core.PluginStatus
+	rules   []ThresholdRule
+	states  map[string]*ruleState
+	sweep   idleSweep
+	mu      sync.RWMutex
+}
+
+// NewThresholdAnalyzer creates a new static rule analyzer plugin
+func NewThresholdAnalyzer(name string) *ThresholdAnalyzer {
+	return &ThresholdAnalyzer{
+		name:    name,
+		version: "1.0.0",
+		status:  core.PluginStatusStopped,
+		states:  make(map[string]*ruleState),
+		sweep:   idleSweep{idle: 5 * time.Minute},
+	}
+}
+
+// Name returns the name of the plugin
+func (t *ThresholdAnalyzer) Name() string {
+	return t.name
+}
+
+// Type returns the type of plugin
+func (t *ThresholdAnalyzer) Type() core.PluginType {
+	return core.PluginTypeAnalyzer
+}
+
+// Version returns the plugin version
+func (t *ThresholdAnalyzer) Version() string {
+	return t.version
+}
+
+// Configure initializes the plugin with configuration
+func (t *ThresholdAnalyzer) Configure(config map[string]interface{}) error {
+	rawRules, ok := config["rules"].([]interface{})
+	if !ok || len(rawRules) == 0 {
+		return fmt.Errorf("threshold rules not specified")
+	}
+
+	rules := make([]ThresholdRule, 0, len(rawRules))
+	names := make(map[string]bool)
+	for i, raw := range rawRules {
+		settings, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("rule %d must be a map", i)
+		}
+		rule, err := parseThresholdRule(settings)
+		if err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("duplicate rule name %q", rule.Name)
+		}
+		names[rule.Name] = true
+		rules = append(rules, rule)
+	}
+
+	// A breached series that stops reporting resolves once it has been silent for the
+	// rule's duration plus forget_after
+	if forgetAfter, ok := configDuration(config, "forget_after"); ok && forgetAfter > 0 {
+		t.sweep.idle = forgetAfter
+	}
+
+	t.rules = rules
+	t.states = make(map[string]*ruleState)
+	return nil
+}
+
+// parseThresholdRule reads a rule from its decoded config
+func parseThresholdRule(settings map[string]interface{}) (ThresholdRule, error) {
+	rule := ThresholdRule{Severity: "medium"}
+
+	rule.Metric, _ = settings["metric"].(string)
+	if rule.Metric == "" {
+		return rule, fmt.Errorf("metric not specified")
+	}
+	if _, err := path.Match(rule.Metric, ""); err != nil {
+		return rule, fmt.Errorf("invalid metric pattern %q: %w", rule.Metric, err)
+	}
+
+	rule.Operator, _ = settings["operator"].(string)
+	if _, ok := thresholdOperators[rule.Operator]; !ok {
+		return rule, fmt.Errorf("unknown operator %q", rule.Operator)
+	}
+
+	value, ok := configFloat(settings, "value")
+	if !ok {
+		return rule, fmt.Errorf("value not specified")
+	}
+	rule.Value = value
+
+	if forStr, ok := settings["for"].(string); ok {
+		duration, err := time.ParseDuration(forStr)
+		if err != nil || duration < 0 {
+			return rule, fmt.Errorf("invalid duration %q", forStr)
+		}
+		rule.For = duration
+	}
+
+	if severity, ok := settings["severity"].(string); ok {
+		if _, known := severityLevels[severity]; !known {
+			return rule, fmt.Errorf("unknown severity %q", severity)
+		}
+		rule.Severity = severity
+	}
+
+	if labels, ok := settings["labels"].(map[string]interface{}); ok {
+		rule.Labels = make(map[string]string, len(labels))
+		for k, v := range labels {
+			rule.Labels[k] = fmt.Sprint(v)
+		}
+	}
+
+	rule.Name, _ = settings["name"].(string)
+	if rule.Name == "" {
+		rule.Name = fmt.Sprintf("%s %s %g", rule.Metric, rule.Operator, rule.Value)
+	}
+
+	return rule, nil
+}
+
+// Start begins the plugin's operation
+func (t *ThresholdAnalyzer) Start(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.status == core.PluginStatusRunning {
+		return fmt.Errorf("analyzer is already running")
+	}
+
+	t.status = core.PluginStatusStarting
+	slog.Info("Starting threshold analyzer", "plugin", t.name, "type", t.Type(), "rules", len(t.rules))
+
+	t.status = core.PluginStatusRunning
+	slog.Info("Threshold analyzer started", "plugin", t.name, "type", t.Type())
+	return nil
+}
+
+// Stop gracefully stops the plugin
+func (t *ThresholdAnalyzer) Stop() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.status != core.PluginStatusRunning {
+		return fmt.Errorf("analyzer is not running")
+	}
+
+	t.status = core.PluginStatusStopping
+	slog.Info("Stopping threshold analyzer", "plugin", t.name, "type", t.Type())
+
+	t.status = core.PluginStatusStopped
+	slog.Info("Threshold analyzer stopped", "plugin", t.name, "type", t.Type())
+	return nil
+}
+
+// Status returns the current status of the plugin
+func (t *ThresholdAnalyzer) Status() core.PluginStatus {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.status
+}
+
+// Health checks if the plugin is healthy
+func (t *ThresholdAnalyzer) Health(ctx context.Context) error {
+	if t.Status() == core.PluginStatusRunning {
+		return nil
+	}
+	return fmt.Errorf("analyzer is not running")
+}
+
+// GetCapabilities returns what this plugin can do
+func (t *ThresholdAnalyzer) GetCapabilities() []string {
+	return []string{
+		"static_thresholds",
+		"alerting_rules",
+	}
+}
+
+// firingRule describes a rule whose condition has held for long enough
+type firingRule struct {
+	Rule        string    `json:"rule"`
+	Series      string    `json:"series"`
+	Value       float64   `json:"value"`
+	Operator    string    `json:"operator"`
+	Threshold   float64   `json:"threshold"`
+	ActiveSince time.Time `json:"active_since"`
+	Severity    string    `json:"severity"`
+}
+
+// Analyze evaluates every rule against the points of the series it covers
+func (t *ThresholdAnalyzer) Analyze(data []core.DataPoint) (*core.Analysis, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if t.sweep.due(now) {
+		t.forgetIdle(now)
+	}
+	firing := make(map[string]firingRule)
+	points := make(map[string]core.DataPoint)
+	for _, point := range data {
+		at := point.Timestamp
+		if at.IsZero() {
+			at = now
+		}
+
+		for _, rule := range t.rules {
+			if !rule.matches(point) {
+				continue
+			}
+
+			series := seriesKey(point)
+			key := rule.Name + "\x00" + series
+			if !thresholdOperators[rule.Operator](point.Value, rule.Value) {
+				delete(t.states, key)
+				delete(firing, key)
+				continue
+			}
+
+			state, ok := t.states[key]
+			if !ok {
+				state = &ruleState{activeSince: at, holdFor: rule.For}
+				t.states[key] = state
+			}
+			state.lastSeen = now
+			if at.Sub(state.activeSince) < rule.For {
+				continue
+			}
+
+			firing[key] = firingRule{
+				Rule:        rule.Name,
+				Series:      series,
+				Value:       point.Value,
+				Operator:    rule.Operator,
+				Threshold:   rule.Value,
+				ActiveSince: state.activeSince,
+				Severity:    rule.Severity,
+			}
+			points[key] = point
+		}
+	}
+
+	if len(firing) == 0 {
+		return nil, nil
+	}
+
+	fired := make([]firingRule, 0, len(firing))
+	for _, f := range firing {
+		fired = append(fired, f)
+	}
+	sort.Slice(fired, func(i, j int) bool {
+		if severityLevels[fired[i].Severity] != severityLevels[fired[j].Severity] {
+			return severityLevels[fired[i].Severity] > severityLevels[fired[j].Severity]
+		}
+		if fired[i].Rule != fired[j].Rule {
+			return fired[i].Rule < fired[j].Rule
+		}
+		return fired[i].Series < fired[j].Series
+	})
+
+	dataPoints := make([]core.DataPoint, 0, len(fired))
+	for _, f := range fired {
+		dataPoints = append(dataPoints, points[f.Rule+"\x00"+f.Series])
+	}
+
+	first := fired[0]
+	summary := fmt.Sprintf("Rule %q firing: %s is %g (%s %g)", first.Rule, first.Series, first.Value, first.Operator, first.Threshold)
+	if len(fired) > 1 {
+		summary = fmt.Sprintf("%d rules firing, including %q: %s is %g (%s %g)",
+			len(fired), first.Rule, first.Series, first.Value, first.Operator, first.Threshold)
+	}
+
+	return &core.Analysis{
+		Type:       core.AnalysisTypeAlert,
+		Confidence: 1.0,
+		Severity:   first.Severity,
+		Summary:    summary,
+		Details: map[string]interface{}{
+			"firing":       fired,
+			"firing_count": len(fired),
+		},
+		DataPoints: dataPoints,
+		Timestamp:  now,
+		Source:     t.name,
+	}, nil
+}
+
+// forgetIdle drops the state of breached series that stopped reporting, so that they resolve
+// instead of firing again as soon as they come back
+func (t *ThresholdAnalyzer) forgetIdle(now time.Time) {
+	for key, state := range t.states {
+		if now.Sub(state.lastSeen) <= state.holdFor+t.sweep.idle {
+			continue
+		}
+		rule, series, _ := strings.Cut(key, "\x00")
+		slog.Info("Threshold rule resolved for a series that stopped reporting", "plugin", t.name, "rule", rule, "series", series)
+		delete(t.states, key)
+	}
+}
+
+// CanAnalyze determines if this analyzer can process the given data
+func (t *ThresholdAnalyzer) CanAnalyze(data []core.DataPoint) bool {
+	return len(data) > 0
+}
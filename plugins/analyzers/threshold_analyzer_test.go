@@ -0,0 +1,108 @@
+package analyzers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThresholdAnalyzer_Configure(t *testing.T) {
+	analyzer := NewThresholdAnalyzer("rules")
+
+	assert.Error(t, analyzer.Configure(map[string]interface{}{}), "Expected rules to be required")
+	assert.Error(t, analyzer.Configure(map[string]interface{}{
+		"rules": []interface{}{map[string]interface{}{"metric": "cpu", "operator": "~", "value": 1}},
+	}), "Expected unknown operators to be rejected")
+	assert.Error(t, analyzer.Configure(map[string]interface{}{
+		"rules": []interface{}{map[string]interface{}{"metric": "cpu", "operator": ">"}},
+	}), "Expected value to be required")
+	assert.Error(t, analyzer.Configure(map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{"name": "cpu", "metric": "cpu", "operator": ">", "value": 1},
+			map[string]interface{}{"name": "cpu", "metric": "cpu", "operator": "<", "value": 0},
+		},
+	}), "Expected duplicate rule names to be rejected")
+}
+
+func TestThresholdAnalyzer_ForDuration(t *testing.T) {
+	analyzer := NewThresholdAnalyzer("rules")
+	require.NoError(t, analyzer.Configure(map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{
+				"name":     "HighCPU",
+				"metric":   "cpu_usage_percent",
+				"operator": ">",
+				"value":    90,
+				"for":      "5m",
+				"severity": "critical",
+				"labels":   map[string]interface{}{"env": "prod"},
+			},
+		},
+	}))
+
+	start := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	point := func(offset time.Duration, value float64, env string) core.DataPoint {
+		return core.DataPoint{
+			Timestamp: start.Add(offset),
+			Metric:    "cpu_usage_percent",
+			Value:     value,
+			Labels:    map[string]string{"env": env, "host": "a"},
+		}
+	}
+
+	analysis, err := analyzer.Analyze([]core.DataPoint{point(0, 95, "prod"), point(0, 99, "staging")})
+	require.NoError(t, err)
+	assert.Nil(t, analysis, "Expected the rule to be pending until the duration has passed")
+
+	analysis, err = analyzer.Analyze([]core.DataPoint{point(5*time.Minute, 96, "prod")})
+	require.NoError(t, err)
+	require.NotNil(t, analysis, "Expected the rule to fire after holding for 5m")
+	assert.Equal(t, core.AnalysisTypeAlert, analysis.Type)
+	assert.Equal(t, "critical", analysis.Severity)
+
+	fired := analysis.Details["firing"].([]firingRule)
+	require.Len(t, fired, 1, "Expected series outside the label selector to be ignored")
+	assert.Equal(t, "HighCPU", fired[0].Rule)
+	assert.Equal(t, start, fired[0].ActiveSince)
+
+	// Dropping below the threshold resets the rule
+	analysis, err = analyzer.Analyze([]core.DataPoint{point(6*time.Minute, 50, "prod"), point(7*time.Minute, 97, "prod")})
+	require.NoError(t, err)
+	assert.Nil(t, analysis, "Expected the rule to be pending again after recovering")
+}
+
+func TestThresholdAnalyzer_ForgetSilentSeries(t *testing.T) {
+	analyzer := NewThresholdAnalyzer("rules")
+	require.NoError(t, analyzer.Configure(map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{"name": "HighCPU", "metric": "cpu_usage_percent", "operator": ">", "value": 90, "for": "5m"},
+		},
+		"forget_after": "10m",
+	}))
+
+	start := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	point := func(offset time.Duration, host string) core.DataPoint {
+		return core.DataPoint{Timestamp: start.Add(offset), Metric: "cpu_usage_percent", Value: 95, Labels: map[string]string{"host": host}}
+	}
+
+	analysis, err := analyzer.Analyze([]core.DataPoint{point(0, "a"), point(0, "b")})
+	require.NoError(t, err)
+	assert.Nil(t, analysis)
+	require.Len(t, analyzer.states, 2)
+
+	// Host a went silent while breached, longer ago than the rule's duration plus forget_after
+	analyzer.states["HighCPU\x00"+seriesKey(point(0, "a"))].lastSeen = time.Now().Add(-20 * time.Minute)
+	analyzer.sweep.last = time.Time{}
+	analysis, err = analyzer.Analyze([]core.DataPoint{point(5*time.Minute, "b")})
+	require.NoError(t, err)
+	require.NotNil(t, analysis, "Expected the series still reporting to keep firing")
+	assert.Len(t, analyzer.states, 1, "Expected the silent series to be forgotten")
+
+	// When host a comes back it has to breach for the rule's duration again
+	analysis, err = analyzer.Analyze([]core.DataPoint{point(30*time.Minute, "a")})
+	require.NoError(t, err)
+	assert.Nil(t, analysis, "Expected the returning series to be pending again")
+}
@@ -0,0 +1,434 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+const week = 7 * 24 * time.Hour
+
+// WeekOverWeekAnalyzer implements the DataAnalyzer interface by comparing current values
+// to the same window in previous weeks. Slow regressions that rolling statistics absorb
+// still show up as a sustained delta against last week.
+//
+// The history is seeded from the data points kept in the framework's store, so the
+// analyzer only stays silent after a restart when datapoint_retention is shorter than the
+// weeks it compares against.
+type WeekOverWeekAnalyzer struct {
+	name        string
+	version     string
+	status      core.PluginStatus
+	weeks       int
+	window      time.Duration
+	tolerance   float64
+	direction   string
+	minSamples  int
+	forgetAfter time.Duration
+	history     *bucketHistory
+	mu          sync.RWMutex
+}
+
+// NewWeekOverWeekAnalyzer creates a new week-over-week analyzer plugin
+func NewWeekOverWeekAnalyzer(name string) *WeekOverWeekAnalyzer {
+	return &WeekOverWeekAnalyzer{
+		name:        name,
+		version:     "1.0.0",
+		status:      core.PluginStatusStopped,
+		weeks:       1,
+		window:      time.Hour,
+		tolerance:   0.2,
+		direction:   "both",
+		minSamples:  1,
+		forgetAfter: 24 * time.Hour,
+		history:     newBucketHistory(5*time.Minute, week+time.Hour, 24*time.Hour),
+	}
+}
+
+// Name returns the name of the plugin
+func (w *WeekOverWeekAnalyzer) Name() string {
+	return w.name
+}
+
+// Type returns the type of plugin
+func (w *WeekOverWeekAnalyzer) Type() core.PluginType {
+	return core.PluginTypeAnalyzer
+}
+
+// Version returns the plugin version
+func (w *WeekOverWeekAnalyzer) Version() string {
+	return w.version
+}
+
+// Configure initializes the plugin with configuration
+func (w *WeekOverWeekAnalyzer) Configure(config map[string]interface{}) error {
+	if weeks, ok := configInt(config, "weeks"); ok && weeks > 0 {
+		w.weeks = weeks
+	}
+
+	if window, ok := configDuration(config, "window"); ok && window > 0 {
+		w.window = window
+	}
+
+	if tolerance, ok := configFloat(config, "tolerance"); ok && tolerance > 0 {
+		w.tolerance = tolerance
+	}
+
+	if direction, ok := config["direction"].(string); ok {
+		switch direction {
+		case "both", "increase", "decrease":
+			w.direction = direction
+		default:
+			return fmt.Errorf("unknown direction %q", direction)
+		}
+	}
+
+	if minSamples, ok := configInt(config, "min_samples"); ok && minSamples > 0 {
+		w.minSamples = minSamples
+	}
+
+	// The history of a series that stops reporting is dropped after forget_after
+	if forgetAfter, ok := configDuration(config, "forget_after"); ok && forgetAfter > 0 {
+		w.forgetAfter = forgetAfter
+	}
+
+	resolution := 5 * time.Minute
+	if r, ok := configDuration(config, "resolution"); ok && r > 0 {
+		resolution = r
+	}
+	w.history = newBucketHistory(resolution, time.Duration(w.weeks)*week+w.window, w.forgetAfter)
+
+	return nil
+}
+
+// SetStore seeds the history with the data points the framework kept in its store, so a
+// restart doesn't leave the analyzer without a reference for weeks
+func (w *WeekOverWeekAnalyzer) SetStore(store core.Store) {
+	now := time.Now()
+	oldest := now.Add(-w.history.retention)
+	seeded := 0
+	err := core.ListJSON(context.Background(), store, core.StoreCollectionDataPoints, func(key string, unmarshal func(v interface{}) error) error {
+		var batch []core.DataPoint
+		if err := unmarshal(&batch); err != nil {
+			return err
+		}
+		for _, point := range batch {
+			if point.Timestamp.Before(oldest) {
+				continue
+			}
+			w.history.observe(seriesKey(point), point.Timestamp, point.Value, now)
+			seeded++
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to seed week-over-week history", "plugin", w.name, "type", w.Type(), "error", err)
+	}
+	if seeded > 0 {
+		slog.Info("Week-over-week history seeded", "plugin", w.name, "type", w.Type(), "points", seeded)
+	}
+}
+
+// Start begins the plugin's operation
+func (w *WeekOverWeekAnalyzer) Start(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.status == core.PluginStatusRunning {
+		return fmt.Errorf("analyzer is already running")
+	}
+
+	w.status = core.PluginStatusStarting
+	slog.Info("Starting week-over-week analyzer", "plugin", w.name, "type", w.Type())
+
+	w.status = core.PluginStatusRunning
+	slog.Info("Week-over-week analyzer started", "plugin", w.name, "type", w.Type())
+	return nil
+}
+
+// Stop gracefully stops the plugin
+func (w *WeekOverWeekAnalyzer) Stop() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.status != core.PluginStatusRunning {
+		return fmt.Errorf("analyzer is not running")
+	}
+
+	w.status = core.PluginStatusStopping
+	slog.Info("Stopping week-over-week analyzer", "plugin", w.name, "type", w.Type())
+
+	w.status = core.PluginStatusStopped
+	slog.Info("Week-over-week analyzer stopped", "plugin", w.name, "type", w.Type())
+	return nil
+}
+
+// Status returns the current status of the plugin
+func (w *WeekOverWeekAnalyzer) Status() core.PluginStatus {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.status
+}
+
+// Health checks if the plugin is healthy
+func (w *WeekOverWeekAnalyzer) Health(ctx context.Context) error {
+	if w.Status() == core.PluginStatusRunning {
+		return nil
+	}
+	return fmt.Errorf("analyzer is not running")
+}
+
+// GetCapabilities returns what this plugin can do
+func (w *WeekOverWeekAnalyzer) GetCapabilities() []string {
+	return []string{
+		"detect_regressions",
+		"week_over_week_comparison",
+	}
+}
+
+// weekOverWeekDelta describes one series that moved outside its tolerance band
+type weekOverWeekDelta struct {
+	Series    string  `json:"series"`
+	Current   float64 `json:"current"`
+	Reference float64 `json:"reference"`
+	Delta     float64 `json:"delta"`
+	Weeks     int     `json:"weeks"`
+}
+
+// Analyze compares the mean of each series in the batch to the same window in previous weeks
+func (w *WeekOverWeekAnalyzer) Analyze(data []core.DataPoint) (*core.Analysis, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	type seriesBatch struct {
+		points []core.DataPoint
+		sum    float64
+		latest time.Time
+	}
+	batches := make(map[string]*seriesBatch)
+	for _, point := range data {
+		key := seriesKey(point)
+		batch, ok := batches[key]
+		if !ok {
+			batch = &seriesBatch{}
+			batches[key] = batch
+		}
+		batch.points = append(batch.points, point)
+		batch.sum += point.Value
+		if point.Timestamp.After(batch.latest) {
+			batch.latest = point.Timestamp
+		}
+	}
+
+	var deltas []weekOverWeekDelta
+	var regressed []core.DataPoint
+	maxScore := 0.0
+	for key, batch := range batches {
+		current := batch.sum / float64(len(batch.points))
+		reference, weeks := w.reference(key, batch.latest)
+		if weeks == 0 || reference == 0 {
+			continue
+		}
+
+		delta := (current - reference) / math.Abs(reference)
+		if !w.exceeds(delta) {
+			continue
+		}
+
+		deltas = append(deltas, weekOverWeekDelta{
+			Series:    key,
+			Current:   current,
+			Reference: reference,
+			Delta:     delta,
+			Weeks:     weeks,
+		})
+		regressed = append(regressed, batch.points...)
+		if score := math.Abs(delta) / w.tolerance; score > maxScore {
+			maxScore = score
+		}
+	}
+
+	// Record the batch only after comparing it, so it never serves as its own reference
+	now := time.Now()
+	for _, point := range data {
+		w.history.observe(seriesKey(point), point.Timestamp, point.Value, now)
+	}
+
+	if len(deltas) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(deltas, func(i, j int) bool {
+		return math.Abs(deltas[i].Delta) > math.Abs(deltas[j].Delta)
+	})
+
+	// A delta right at the tolerance is a coin toss; twice the tolerance is certain
+	confidence := math.Min(maxScore/2, 1.0)
+	worst := deltas[0]
+
+	return &core.Analysis{
+		Type:       core.AnalysisTypeTrend,
+		Confidence: confidence,
+		Severity:   severityForConfidence(confidence),
+		Summary: fmt.Sprintf("%d series changed more than %.0f%% week over week; %s moved %+.1f%%",
+			len(deltas), w.tolerance*100, worst.Series, worst.Delta*100),
+		Details: map[string]interface{}{
+			"deltas":    deltas,
+			"tolerance": w.tolerance,
+			"window":    w.window.String(),
+			"weeks":     w.weeks,
+			"direction": w.direction,
+		},
+		DataPoints: regressed,
+		Timestamp:  now,
+		Source:     w.name,
+	}, nil
+}
+
+// CanAnalyze determines if this analyzer can process the given data
+func (w *WeekOverWeekAnalyzer) CanAnalyze(data []core.DataPoint) bool {
+	return len(data) > 0
+}
+
+// reference averages the window ending at the same time in each previous week that has
+// enough samples, returning the mean and how many weeks contributed
+func (w *WeekOverWeekAnalyzer) reference(series string, at time.Time) (float64, int) {
+	sum := 0.0
+	weeks := 0
+	for k := 1; k <= w.weeks; k++ {
+		end := at.Add(-time.Duration(k) * week)
+		stats := w.history.window(series, end.Add(-w.window), end)
+		if stats.count < w.minSamples {
+			continue
+		}
+		sum += stats.mean
+		weeks++
+	}
+	if weeks == 0 {
+		return 0, 0
+	}
+	return sum / float64(weeks), weeks
+}
+
+// exceeds reports whether a relative delta is outside the tolerance band in the configured direction
+func (w *WeekOverWeekAnalyzer) exceeds(delta float64) bool {
+	switch w.direction {
+	case "increase":
+		return delta > w.tolerance
+	case "decrease":
+		return -delta > w.tolerance
+	default:
+		return math.Abs(delta) > w.tolerance
+	}
+}
+
+// bucketHistory keeps per-series statistics in fixed-width time buckets for a retention period
+type bucketHistory struct {
+	resolution time.Duration
+	retention  time.Duration
+	series     map[string]*bucketSeries
+	newest     time.Time
+	sweep      idleSweep
+	mu         sync.RWMutex
+}
+
+// bucketSeries holds the buckets of one series
+type bucketSeries struct {
+	buckets  map[int64]*runningStats
+	lastSeen time.Time
+}
+
+// newBucketHistory creates a history that aggregates values per resolution-sized bucket.
+// Series without values for idle are forgotten; an idle of 0 keeps them for the retention.
+func newBucketHistory(resolution, retention, idle time.Duration) *bucketHistory {
+	return &bucketHistory{
+		resolution: resolution,
+		retention:  retention,
+		series:     make(map[string]*bucketSeries),
+		sweep:      idleSweep{idle: idle},
+	}
+}
+
+// bucketFor returns the bucket index containing the timestamp
+func (h *bucketHistory) bucketFor(t time.Time) int64 {
+	return t.UnixNano() / int64(h.resolution)
+}
+
+// observe folds a value received at now into its bucket and drops buckets older than the
+// retention period
+func (h *bucketHistory) observe(series string, t time.Time, value float64, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.sweep.due(now) {
+		for key, s := range h.series {
+			if h.sweep.expired(s.lastSeen, now) {
+				delete(h.series, key)
+			}
+		}
+	}
+
+	s, ok := h.series[series]
+	if !ok {
+		s = &bucketSeries{buckets: make(map[int64]*runningStats)}
+		h.series[series] = s
+	}
+	s.lastSeen = now
+	index := h.bucketFor(t)
+	stats, ok := s.buckets[index]
+	if !ok {
+		stats = &runningStats{}
+		s.buckets[index] = stats
+	}
+	stats.add(value)
+
+	// Pruning is only needed when time advances into a new bucket
+	if t.After(h.newest) {
+		advanced := h.bucketFor(t) > h.bucketFor(h.newest)
+		h.newest = t
+		if advanced {
+			h.prune()
+		}
+	}
+}
+
+// prune removes buckets that fell out of the retention period; callers must hold h.mu
+func (h *bucketHistory) prune() {
+	oldest := h.bucketFor(h.newest.Add(-h.retention))
+	for key, s := range h.series {
+		for index := range s.buckets {
+			if index < oldest {
+				delete(s.buckets, index)
+			}
+		}
+		if len(s.buckets) == 0 {
+			delete(h.series, key)
+		}
+	}
+}
+
+// window combines the buckets of a series that overlap [start, end]
+func (h *bucketHistory) window(series string, start, end time.Time) runningStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var combined runningStats
+	s, ok := h.series[series]
+	if !ok {
+		return combined
+	}
+
+	for index := h.bucketFor(start); index <= h.bucketFor(end); index++ {
+		if stats, ok := s.buckets[index]; ok {
+			combined.merge(*stats)
+		}
+	}
+	return combined
+}
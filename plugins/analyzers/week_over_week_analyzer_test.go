@@ -0,0 +1,126 @@
+package analyzers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeekOverWeekAnalyzer_Analyze(t *testing.T) {
+	analyzer := NewWeekOverWeekAnalyzer("wow")
+	require.NoError(t, analyzer.Configure(map[string]interface{}{
+		"window":    "30m",
+		"tolerance": 0.2,
+	}))
+
+	lastWeek := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	thisWeek := lastWeek.Add(7 * 24 * time.Hour)
+
+	batch := func(start time.Time, value float64) []core.DataPoint {
+		var points []core.DataPoint
+		for i := 0; i < 6; i++ {
+			points = append(points, core.DataPoint{
+				Timestamp: start.Add(time.Duration(i) * 5 * time.Minute),
+				Metric:    "latency_ms",
+				Value:     value,
+				Labels:    map[string]string{"service": "checkout"},
+			})
+		}
+		return points
+	}
+
+	analysis, err := analyzer.Analyze(batch(lastWeek, 100))
+	require.NoError(t, err)
+	assert.Nil(t, analysis, "Expected no analysis without history")
+
+	// A 10% rise stays within the tolerance band
+	analysis, err = analyzer.Analyze(batch(thisWeek, 110))
+	require.NoError(t, err)
+	assert.Nil(t, analysis, "Expected no analysis within tolerance")
+
+	analysis, err = analyzer.Analyze(batch(thisWeek.Add(time.Minute), 150))
+	require.NoError(t, err)
+	require.NotNil(t, analysis, "Expected a regression against last week")
+
+	assert.Equal(t, core.AnalysisTypeTrend, analysis.Type)
+	deltas := analysis.Details["deltas"].([]weekOverWeekDelta)
+	require.Len(t, deltas, 1)
+	assert.InDelta(t, 100.0, deltas[0].Reference, 0.001)
+	assert.InDelta(t, 0.5, deltas[0].Delta, 0.001)
+	assert.Equal(t, 1.0, analysis.Confidence)
+}
+
+func TestWeekOverWeekAnalyzer_Direction(t *testing.T) {
+	analyzer := NewWeekOverWeekAnalyzer("wow")
+	require.NoError(t, analyzer.Configure(map[string]interface{}{"direction": "decrease"}))
+
+	lastWeek := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	point := func(at time.Time, value float64) []core.DataPoint {
+		return []core.DataPoint{{Timestamp: at, Metric: "orders_per_minute", Value: value}}
+	}
+
+	_, err := analyzer.Analyze(point(lastWeek, 100))
+	require.NoError(t, err)
+
+	analysis, err := analyzer.Analyze(point(lastWeek.Add(7*24*time.Hour), 200))
+	require.NoError(t, err)
+	assert.Nil(t, analysis, "Expected increases to be ignored when watching for decreases")
+
+	analysis, err = analyzer.Analyze(point(lastWeek.Add(7*24*time.Hour+time.Minute), 50))
+	require.NoError(t, err)
+	assert.NotNil(t, analysis, "Expected a decrease to be flagged")
+
+	assert.Error(t, analyzer.Configure(map[string]interface{}{"direction": "sideways"}))
+}
+
+func TestWeekOverWeekAnalyzer_SeedFromStore(t *testing.T) {
+	thisWeek := time.Now().Truncate(time.Hour)
+	lastWeek := thisWeek.Add(-7 * 24 * time.Hour)
+	batch := func(start time.Time, value float64) []core.DataPoint {
+		var points []core.DataPoint
+		for i := 0; i < 6; i++ {
+			points = append(points, core.DataPoint{
+				Timestamp: start.Add(time.Duration(i) * 5 * time.Minute),
+				Metric:    "latency_ms",
+				Value:     value,
+			})
+		}
+		return points
+	}
+
+	// A batch from before the restart, and one older than the analyzer looks back
+	store := core.NewMemoryStore()
+	ctx := context.Background()
+	require.NoError(t, core.PutJSON(ctx, store, core.StoreCollectionDataPoints, "1", batch(lastWeek.Add(-30*24*time.Hour), 500)))
+	require.NoError(t, core.PutJSON(ctx, store, core.StoreCollectionDataPoints, "2", batch(lastWeek, 100)))
+
+	analyzer := NewWeekOverWeekAnalyzer("wow")
+	require.NoError(t, analyzer.Configure(map[string]interface{}{"window": "30m"}))
+	analyzer.SetStore(store)
+
+	analysis, err := analyzer.Analyze(batch(thisWeek, 150))
+	require.NoError(t, err)
+	require.NotNil(t, analysis, "Expected the stored history to serve as last week")
+	deltas := analysis.Details["deltas"].([]weekOverWeekDelta)
+	require.Len(t, deltas, 1)
+	assert.InDelta(t, 100.0, deltas[0].Reference, 0.001)
+}
+
+func TestBucketHistory_ForgetIdleSeries(t *testing.T) {
+	history := newBucketHistory(5*time.Minute, 7*24*time.Hour, time.Hour)
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	history.observe("pod-1", start, 1, start)
+	history.observe("pod-2", start, 2, start)
+	history.observe("pod-1", start, 3, start.Add(50*time.Minute))
+
+	// pod-2 has been idle for more than an hour, pod-1 has not
+	history.observe("pod-3", start, 4, start.Add(90*time.Minute))
+	assert.Len(t, history.series, 2, "Expected the idle series to be dropped")
+	assert.Equal(t, 0, history.window("pod-2", start, start).count)
+	assert.Equal(t, 2, history.window("pod-1", start, start).count)
+}
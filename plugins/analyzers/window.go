@@ -0,0 +1,133 @@
+package analyzers
+
+import (
+	"sync"
+	"time"
+)
+
+// ringBuffer holds the most recent values of a series up to a fixed capacity
+type ringBuffer struct {
+	values []float64
+	start  int
+	size   int
+}
+
+// newRingBuffer creates a ring buffer that keeps the last capacity values
+func newRingBuffer(capacity int) *ringBuffer {
+	return &ringBuffer{values: make([]float64, capacity)}
+}
+
+// push appends a value, evicting the oldest once the buffer is full
+func (r *ringBuffer) push(value float64) {
+	if r.size < len(r.values) {
+		r.values[(r.start+r.size)%len(r.values)] = value
+		r.size++
+		return
+	}
+	r.values[r.start] = value
+	r.start = (r.start + 1) % len(r.values)
+}
+
+// stats returns the mean and variance of the buffered values
+func (r *ringBuffer) stats() runningStats {
+	var stats runningStats
+	for i := 0; i < r.size; i++ {
+		stats.add(r.values[(r.start+i)%len(r.values)])
+	}
+	return stats
+}
+
+// windowSweepInterval is how often per-series state is searched for idle series
+const windowSweepInterval = time.Minute
+
+// idleSweep paces the search for series that stopped reporting, so per-series state of
+// series that come and go, such as per-pod metrics, doesn't accumulate
+type idleSweep struct {
+	// idle is how long a series may go without values before it is dropped; 0 keeps it
+	idle time.Duration
+	last time.Time
+}
+
+// due reports whether state unused since before now minus idle should be dropped now, at
+// most once per windowSweepInterval
+func (s *idleSweep) due(now time.Time) bool {
+	if s.idle <= 0 || now.Sub(s.last) < windowSweepInterval {
+		return false
+	}
+	s.last = now
+	return true
+}
+
+// expired reports whether state last used at lastSeen has been idle too long
+func (s *idleSweep) expired(lastSeen, now time.Time) bool {
+	return now.Sub(lastSeen) > s.idle
+}
+
+// seriesWindow is the window of one series and when a value was last added to it
+type seriesWindow struct {
+	buffer   *ringBuffer
+	lastPush time.Time
+}
+
+// seriesWindows keeps a sliding window of recent values per series so statistics persist
+// across batches instead of being recomputed from whatever a single batch contains. Windows
+// of series that get no values for idle are dropped, so series that come and go, such as
+// per-pod metrics, don't accumulate.
+type seriesWindows struct {
+	size    int
+	sweep   idleSweep
+	windows map[string]*seriesWindow
+	mu      sync.Mutex
+}
+
+// newSeriesWindows creates per-series windows holding size values each, forgotten after idle
+// without values; an idle of 0 keeps them forever
+func newSeriesWindows(size int, idle time.Duration) *seriesWindows {
+	return &seriesWindows{
+		size:    size,
+		sweep:   idleSweep{idle: idle},
+		windows: make(map[string]*seriesWindow),
+	}
+}
+
+// stats returns the statistics of the series' current window
+func (w *seriesWindows) stats(series string) runningStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	window, ok := w.windows[series]
+	if !ok {
+		return runningStats{}
+	}
+	return window.buffer.stats()
+}
+
+// push adds a value to the series' window, first dropping idle windows if it is time to
+// look for them
+func (w *seriesWindows) push(series string, value float64, now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.sweep.due(now) {
+		for key, window := range w.windows {
+			if w.sweep.expired(window.lastPush, now) {
+				delete(w.windows, key)
+			}
+		}
+	}
+
+	window, ok := w.windows[series]
+	if !ok {
+		window = &seriesWindow{buffer: newRingBuffer(w.size)}
+		w.windows[series] = window
+	}
+	window.buffer.push(value)
+	window.lastPush = now
+}
+
+// len returns how many series have a window
+func (w *seriesWindows) len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.windows)
+}
@@ -0,0 +1,56 @@
+package collectors
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// discoveredService is the service a collector discovers targets from, set with its
+// discover setting. The framework provides the discovery backend once the collector is
+// loaded; lookups are cached by the framework for its refresh interval.
+type discoveredService struct {
+	service   string
+	settings  map[string]interface{}
+	discovery core.ServiceDiscovery
+}
+
+// parseDiscoveredService reads a collector's discover setting, returning nil without one.
+// The service key names the service; other keys are left to the collector.
+func parseDiscoveredService(raw interface{}) (*discoveredService, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	settings, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid discover setting: %v", raw)
+	}
+	service, _ := settings["service"].(string)
+	if service == "" {
+		return nil, fmt.Errorf("discover needs a service")
+	}
+	return &discoveredService{service: service, settings: settings}, nil
+}
+
+// setting returns one of the discover setting's string keys, or the default
+func (d *discoveredService) setting(key, fallback string) string {
+	if value, ok := d.settings[key].(string); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// instances looks up the service's instances
+func (d *discoveredService) instances() ([]core.ServiceInfo, error) {
+	if d.discovery == nil {
+		return nil, fmt.Errorf("service discovery not configured for service %s", d.service)
+	}
+	return d.discovery.DiscoverServices(d.service)
+}
+
+// instanceAddress returns the host:port an instance is reached at
+func instanceAddress(instance core.ServiceInfo) string {
+	return net.JoinHostPort(instance.Address, strconv.Itoa(instance.Port))
+}
@@ -0,0 +1,513 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// defaultClusterLabel is the label added to every data point naming the cluster it came from
+const defaultClusterLabel = "cluster"
+
+// prometheusCluster is one Prometheus endpoint of a federation, queried by its own collector
+type prometheusCluster struct {
+	name string
+	// url is set for discovered clusters, so a moved instance gets a new collector
+	url       string
+	collector *PrometheusCollector
+}
+
+// FederatedPrometheusCollector implements the DataCollector interface for a fleet of
+// Prometheus servers, one per cluster. Every cluster runs the same queries; each data point
+// is labelled with its cluster so anomalies can be attributed to it. Clusters are queried
+// concurrently and an unreachable cluster only drops its own data.
+type FederatedPrometheusCollector struct {
+	name     string
+	version  string
+	status   core.PluginStatus
+	clusters []prometheusCluster
+	// static holds the configured clusters; clusters adds those discovered to them
+	static   []prometheusCluster
+	discover *discoveredService
+	// shared holds the settings every cluster's collector is configured with
+	shared       map[string]interface{}
+	metadata     *core.MetricMetadataRegistry
+	clusterLabel string
+	interval     time.Duration
+	// clusterTimeout bounds each cluster's collection, so a slow cluster cannot hold up
+	// the others
+	clusterTimeout time.Duration
+	// clusterErrors holds the error of each cluster's last collection or health check; nil
+	// means the cluster was reachable
+	clusterErrors map[string]error
+	mu            sync.RWMutex
+}
+
+// NewFederatedPrometheusCollector creates a new federated Prometheus collector plugin
+func NewFederatedPrometheusCollector(name string) *FederatedPrometheusCollector {
+	return &FederatedPrometheusCollector{
+		name:           name,
+		version:        "1.0.0",
+		status:         core.PluginStatusStopped,
+		clusterLabel:   defaultClusterLabel,
+		interval:       30 * time.Second,
+		clusterTimeout: 10 * time.Second,
+		clusterErrors:  make(map[string]error),
+	}
+}
+
+// Name returns the name of the plugin
+func (f *FederatedPrometheusCollector) Name() string {
+	return f.name
+}
+
+// Type returns the type of plugin
+func (f *FederatedPrometheusCollector) Type() core.PluginType {
+	return core.PluginTypeCollector
+}
+
+// Version returns the plugin version
+func (f *FederatedPrometheusCollector) Version() string {
+	return f.version
+}
+
+// Configure initializes the plugin with configuration. Each entry of clusters needs a
+// name and url; every other setting is shared by all clusters and accepts the same keys
+// as the Prometheus collector. Clusters can also be discovered with discover: service
+// names the discovered service, scheme and path complete each instance's URL, and
+// cluster_metadata names the instance metadata holding its cluster name, which is the
+// instance ID without it.
+func (f *FederatedPrometheusCollector) Configure(config map[string]interface{}) error {
+	discover, err := parseDiscoveredService(config["discover"])
+	if err != nil {
+		return err
+	}
+	rawClusters, _ := config["clusters"].([]interface{})
+	if len(rawClusters) == 0 && discover == nil {
+		return fmt.Errorf("prometheus clusters not specified")
+	}
+
+	// Settings shared by every cluster's collector
+	shared := make(map[string]interface{}, len(config))
+	for key, value := range config {
+		switch key {
+		case "clusters", "cluster_label", "cluster_timeout", "discover":
+			continue
+		}
+		shared[key] = value
+	}
+
+	// The shared settings are checked once, so discovered clusters cannot fail on them
+	template := NewPrometheusCollector(f.name)
+	if err := template.Configure(withURL(shared, "http://localhost:9090")); err != nil {
+		return err
+	}
+
+	clusters := make([]prometheusCluster, 0, len(rawClusters))
+	seen := make(map[string]bool)
+	for _, raw := range rawClusters {
+		entry, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("invalid prometheus cluster: %v", raw)
+		}
+		name, _ := entry["name"].(string)
+		url, _ := entry["url"].(string)
+		if name == "" || url == "" {
+			return fmt.Errorf("prometheus cluster needs a name and url: %v", raw)
+		}
+		if seen[name] {
+			return fmt.Errorf("duplicate prometheus cluster %q", name)
+		}
+		seen[name] = true
+
+		collector := NewPrometheusCollector(f.name + "/" + name)
+		if err := collector.Configure(withURL(shared, url)); err != nil {
+			return fmt.Errorf("cluster %s: %w", name, err)
+		}
+		clusters = append(clusters, prometheusCluster{name: name, collector: collector})
+	}
+
+	clusterLabel := defaultClusterLabel
+	if label, ok := config["cluster_label"].(string); ok && label != "" {
+		clusterLabel = label
+	}
+
+	clusterTimeout := f.clusterTimeout
+	if timeoutStr, ok := config["cluster_timeout"].(string); ok {
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid cluster_timeout %q", timeoutStr)
+		}
+		clusterTimeout = timeout
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.discover != nil && discover != nil {
+		discover.discovery = f.discover.discovery
+	}
+	f.clusters = clusters
+	f.static = clusters
+	f.discover = discover
+	f.shared = shared
+	f.clusterLabel = clusterLabel
+	f.clusterTimeout = clusterTimeout
+	f.interval = template.interval
+	f.clusterErrors = make(map[string]error)
+	return nil
+}
+
+// withURL returns the shared settings with a cluster's URL
+func withURL(shared map[string]interface{}, url string) map[string]interface{} {
+	settings := make(map[string]interface{}, len(shared)+1)
+	for key, value := range shared {
+		settings[key] = value
+	}
+	settings["url"] = url
+	return settings
+}
+
+// SetServiceDiscovery provides the backend clusters are discovered from
+func (f *FederatedPrometheusCollector) SetServiceDiscovery(discovery core.ServiceDiscovery) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.discover != nil {
+		f.discover.discovery = discovery
+	}
+}
+
+// SetMetricMetadata provides the registry that discovered metric metadata is added to
+func (f *FederatedPrometheusCollector) SetMetricMetadata(registry *core.MetricMetadataRegistry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.metadata = registry
+	for _, cluster := range f.clusters {
+		cluster.collector.SetMetricMetadata(registry)
+	}
+}
+
+// Start begins the plugin's operation. It fails only if no cluster can be reached; the
+// others are queried again on every collection.
+func (f *FederatedPrometheusCollector) Start(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.status == core.PluginStatusRunning {
+		return fmt.Errorf("collector is already running")
+	}
+
+	f.status = core.PluginStatusStarting
+	slog.Info("Starting federated Prometheus collector", "plugin", f.name, "type", f.Type(), "clusters", len(f.static))
+
+	if f.discover != nil {
+		if f.discover.discovery == nil {
+			f.status = core.PluginStatusError
+			return fmt.Errorf("prometheus clusters are discovered but service discovery is not configured")
+		}
+		instances, err := f.discover.instances()
+		if err != nil && len(f.static) == 0 {
+			f.status = core.PluginStatusError
+			return fmt.Errorf("failed to discover prometheus clusters: %w", err)
+		}
+		if err != nil {
+			slog.Warn("Failed to discover prometheus clusters", "plugin", f.name, "service", f.discover.service, "error", err)
+		} else {
+			f.setDiscoveredClusters(instances)
+		}
+		if len(f.clusters) == 0 {
+			f.status = core.PluginStatusError
+			return fmt.Errorf("no prometheus clusters discovered for service %s", f.discover.service)
+		}
+	}
+
+	errs := f.checkClusters(ctx)
+	reachable := f.recordClusterErrors(errs)
+	if reachable == nil {
+		f.status = core.PluginStatusError
+		return fmt.Errorf("health check failed: %w", allClustersFailed(errs))
+	}
+	for _, cluster := range f.clusters {
+		if err := errs[cluster.name]; err != nil {
+			slog.Warn("Prometheus cluster unreachable, will retry on collection", "plugin", f.name, "cluster", cluster.name, "error", err)
+		}
+	}
+
+	// Metric metadata is shared across the fleet, so one cluster's is enough
+	if reachable.fetchMetadata && reachable.metadata != nil {
+		if err := reachable.loadMetadata(ctx); err != nil {
+			slog.Warn("Failed to fetch metric metadata", "plugin", f.name, "error", err)
+		}
+	}
+
+	f.status = core.PluginStatusRunning
+	slog.Info("Federated Prometheus collector started", "plugin", f.name, "type", f.Type())
+	return nil
+}
+
+// Stop gracefully stops the plugin
+func (f *FederatedPrometheusCollector) Stop() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.status != core.PluginStatusRunning {
+		return fmt.Errorf("collector is not running")
+	}
+
+	f.status = core.PluginStatusStopping
+	slog.Info("Stopping federated Prometheus collector", "plugin", f.name, "type", f.Type())
+
+	f.status = core.PluginStatusStopped
+	slog.Info("Federated Prometheus collector stopped", "plugin", f.name, "type", f.Type())
+	return nil
+}
+
+// Status returns the current status of the plugin
+func (f *FederatedPrometheusCollector) Status() core.PluginStatus {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.status
+}
+
+// Health checks if the plugin is healthy. The collector stays healthy while at least one
+// cluster can be reached.
+func (f *FederatedPrometheusCollector) Health(ctx context.Context) error {
+	f.mu.RLock()
+	errs := f.checkClusters(ctx)
+	f.mu.RUnlock()
+
+	f.mu.Lock()
+	reachable := f.recordClusterErrors(errs)
+	f.mu.Unlock()
+	if reachable == nil {
+		return allClustersFailed(errs)
+	}
+	return nil
+}
+
+// GetCapabilities returns what this plugin can do
+func (f *FederatedPrometheusCollector) GetCapabilities() []string {
+	return []string{
+		"collect_metrics",
+		"query_prometheus",
+		"multi_cluster",
+		"health_check",
+	}
+}
+
+// Collect gathers data points from every cluster concurrently, labelling each with its
+// cluster, and adds a prometheus_cluster_up point per cluster. Clusters that cannot be
+// reached are logged and skipped; an error is returned only when none could be.
+func (f *FederatedPrometheusCollector) Collect(ctx context.Context) ([]core.DataPoint, error) {
+	f.mu.RLock()
+	discover := f.discover
+	f.mu.RUnlock()
+	if discover != nil {
+		instances, err := discover.instances()
+		if err != nil {
+			slog.Warn("Failed to discover prometheus clusters, keeping the last ones", "plugin", f.name, "service", discover.service, "error", err)
+		} else {
+			f.mu.Lock()
+			f.setDiscoveredClusters(instances)
+			f.mu.Unlock()
+		}
+	}
+
+	f.mu.RLock()
+	clusters := f.clusters
+	clusterLabel := f.clusterLabel
+	clusterTimeout := f.clusterTimeout
+	f.mu.RUnlock()
+
+	points := make([][]core.DataPoint, len(clusters))
+	errs := make(map[string]error, len(clusters))
+	var errsMu sync.Mutex
+	var wg sync.WaitGroup
+	for i, cluster := range clusters {
+		wg.Add(1)
+		go func(i int, cluster prometheusCluster) {
+			defer wg.Done()
+			clusterCtx, cancel := context.WithTimeout(ctx, clusterTimeout)
+			defer cancel()
+			collected, err := cluster.collector.collectQueries(clusterCtx)
+			errsMu.Lock()
+			errs[cluster.name] = err
+			errsMu.Unlock()
+			points[i] = collected
+		}(i, cluster)
+	}
+	wg.Wait()
+
+	f.mu.Lock()
+	reachable := f.recordClusterErrors(errs)
+	f.mu.Unlock()
+	if reachable == nil {
+		return nil, allClustersFailed(errs)
+	}
+
+	now := time.Now()
+	var dataPoints []core.DataPoint
+	for i, cluster := range clusters {
+		up := 1.0
+		if err := errs[cluster.name]; err != nil {
+			slog.Warn("Skipping unreachable Prometheus cluster", "plugin", f.name, "cluster", cluster.name, "error", err)
+			up = 0
+		}
+		for _, point := range points[i] {
+			labels := make(map[string]string, len(point.Labels)+1)
+			for name, value := range point.Labels {
+				labels[name] = value
+			}
+			labels[clusterLabel] = cluster.name
+			point.Labels = labels
+			point.Source = f.name
+			dataPoints = append(dataPoints, point)
+		}
+		dataPoints = append(dataPoints, core.DataPoint{
+			Timestamp: now,
+			Source:    f.name,
+			Metric:    "prometheus_cluster_up",
+			Value:     up,
+			Labels:    map[string]string{clusterLabel: cluster.name},
+		})
+	}
+
+	return dataPoints, nil
+}
+
+// GetCollectionInterval returns how often this collector should run
+func (f *FederatedPrometheusCollector) GetCollectionInterval() time.Duration {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.interval
+}
+
+// WriteMetrics writes whether each cluster was reachable at the last collection or health check
+func (f *FederatedPrometheusCollector) WriteMetrics(w io.Writer) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	fmt.Fprintf(w, "# HELP agent_prometheus_cluster_up Whether the collector reached each cluster's Prometheus\n")
+	fmt.Fprintf(w, "# TYPE agent_prometheus_cluster_up gauge\n")
+	for _, cluster := range f.clusters {
+		err, checked := f.clusterErrors[cluster.name]
+		if !checked {
+			continue
+		}
+		up := 1
+		if err != nil {
+			up = 0
+		}
+		fmt.Fprintf(w, "agent_prometheus_cluster_up{plugin=%q,cluster=%q} %d\n", f.name, cluster.name, up)
+	}
+}
+
+// setDiscoveredClusters adds a cluster for each instance of the discovered service to the
+// configured ones. Clusters whose URL did not change keep their collector. Callers must
+// hold f.mu for writing.
+func (f *FederatedPrometheusCollector) setDiscoveredClusters(instances []core.ServiceInfo) {
+	current := make(map[string]prometheusCluster, len(f.clusters))
+	for _, cluster := range f.clusters {
+		current[cluster.name] = cluster
+	}
+	seen := make(map[string]bool, len(f.static)+len(instances))
+	clusters := append([]prometheusCluster(nil), f.static...)
+	for _, cluster := range f.static {
+		seen[cluster.name] = true
+	}
+
+	scheme := f.discover.setting("scheme", "http")
+	path := f.discover.setting("path", "")
+	metadataKey := f.discover.setting("cluster_metadata", "")
+	for _, instance := range instances {
+		name := instance.Metadata[metadataKey]
+		if name == "" || seen[name] {
+			name = instance.ID
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		url := scheme + "://" + instanceAddress(instance) + path
+		if existing, ok := current[name]; ok && existing.url == url {
+			clusters = append(clusters, existing)
+			continue
+		}
+		collector := NewPrometheusCollector(f.name + "/" + name)
+		if err := collector.Configure(withURL(f.shared, url)); err != nil {
+			slog.Warn("Skipping discovered Prometheus cluster", "plugin", f.name, "cluster", name, "error", err)
+			continue
+		}
+		if f.metadata != nil {
+			collector.SetMetricMetadata(f.metadata)
+		}
+		clusters = append(clusters, prometheusCluster{name: name, url: url, collector: collector})
+	}
+
+	for name := range current {
+		if !seen[name] {
+			delete(f.clusterErrors, name)
+		}
+	}
+	f.clusters = clusters
+}
+
+// checkClusters runs every cluster's health check concurrently; callers must hold f.mu
+func (f *FederatedPrometheusCollector) checkClusters(ctx context.Context) map[string]error {
+	errs := make(map[string]error, len(f.clusters))
+	var errsMu sync.Mutex
+	var wg sync.WaitGroup
+	for _, cluster := range f.clusters {
+		wg.Add(1)
+		go func(cluster prometheusCluster) {
+			defer wg.Done()
+			clusterCtx, cancel := context.WithTimeout(ctx, f.clusterTimeout)
+			defer cancel()
+			err := cluster.collector.Health(clusterCtx)
+			errsMu.Lock()
+			errs[cluster.name] = err
+			errsMu.Unlock()
+		}(cluster)
+	}
+	wg.Wait()
+	return errs
+}
+
+// recordClusterErrors keeps each cluster's latest error and returns the collector of the
+// first cluster that was reachable, or nil if none was; callers must hold f.mu for writing
+func (f *FederatedPrometheusCollector) recordClusterErrors(errs map[string]error) *PrometheusCollector {
+	var reachable *PrometheusCollector
+	for _, cluster := range f.clusters {
+		err, checked := errs[cluster.name]
+		if !checked {
+			continue
+		}
+		f.clusterErrors[cluster.name] = err
+		if err == nil && reachable == nil {
+			reachable = cluster.collector
+		}
+	}
+	return reachable
+}
+
+// allClustersFailed combines the errors of clusters that could not be reached
+func allClustersFailed(errs map[string]error) error {
+	names := make([]string, 0, len(errs))
+	for name := range errs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	failures := make([]string, 0, len(names))
+	for _, name := range names {
+		failures = append(failures, fmt.Sprintf("%s: %v", name, errs[name]))
+	}
+	return fmt.Errorf("all %d prometheus clusters failed: %s", len(names), strings.Join(failures, "; "))
+}
@@ -0,0 +1,195 @@
+package collectors
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPrometheusServer serves instant queries with a single up series of the given value
+func newPrometheusServer(t *testing.T, value string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"__name__":"up","job":"api"},"value":[1700000000,"%s"]}]}}`, value)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFederatedPrometheusCollector_Collect(t *testing.T) {
+	east := newPrometheusServer(t, "1")
+	west := newPrometheusServer(t, "0")
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	down.Close()
+
+	collector := NewFederatedPrometheusCollector("fleet")
+	require.NoError(t, collector.Configure(map[string]interface{}{
+		"clusters": []interface{}{
+			map[string]interface{}{"name": "us-east", "url": east.URL},
+			map[string]interface{}{"name": "eu-west", "url": west.URL},
+			map[string]interface{}{"name": "ap-south", "url": down.URL},
+		},
+		"queries":        []interface{}{"up"},
+		"fetch_metadata": false,
+	}))
+	require.NoError(t, collector.Start(context.Background()), "Expected an unreachable cluster not to stop the collector starting")
+	defer collector.Stop()
+
+	points, err := collector.Collect(context.Background())
+	require.NoError(t, err, "Expected a partial failure to still return the reachable clusters' data")
+
+	up := make(map[string]float64)
+	reachable := make(map[string]float64)
+	for _, point := range points {
+		assert.Equal(t, "fleet", point.Source)
+		switch point.Metric {
+		case "up":
+			assert.Equal(t, "api", point.Labels["job"])
+			up[point.Labels["cluster"]] = point.Value
+		case "prometheus_cluster_up":
+			reachable[point.Labels["cluster"]] = point.Value
+		}
+	}
+	assert.Equal(t, map[string]float64{"us-east": 1, "eu-west": 0}, up)
+	assert.Equal(t, map[string]float64{"us-east": 1, "eu-west": 1, "ap-south": 0}, reachable)
+	assert.NoError(t, collector.Health(context.Background()))
+
+	var buf bytes.Buffer
+	collector.WriteMetrics(&buf)
+	assert.Contains(t, buf.String(), `agent_prometheus_cluster_up{plugin="fleet",cluster="ap-south"} 0`)
+	assert.Contains(t, buf.String(), `agent_prometheus_cluster_up{plugin="fleet",cluster="us-east"} 1`)
+}
+
+func TestFederatedPrometheusCollector_AllClustersDown(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	down.Close()
+
+	collector := NewFederatedPrometheusCollector("fleet")
+	require.NoError(t, collector.Configure(map[string]interface{}{
+		"clusters":      []interface{}{map[string]interface{}{"name": "us-east", "url": down.URL}},
+		"cluster_label": "region",
+		"queries":       []interface{}{"up"},
+	}))
+
+	assert.Error(t, collector.Start(context.Background()))
+	assert.Equal(t, core.PluginStatusError, collector.Status())
+	_, err := collector.Collect(context.Background())
+	assert.ErrorContains(t, err, "us-east")
+}
+
+func TestFederatedPrometheusCollector_Configure(t *testing.T) {
+	collector := NewFederatedPrometheusCollector("fleet")
+	assert.Error(t, collector.Configure(map[string]interface{}{}), "Expected clusters to be required")
+	assert.Error(t, collector.Configure(map[string]interface{}{
+		"clusters": []interface{}{map[string]interface{}{"name": "us-east"}},
+	}), "Expected clusters to need a url")
+	assert.Error(t, collector.Configure(map[string]interface{}{
+		"clusters": []interface{}{
+			map[string]interface{}{"name": "us-east", "url": "http://a:9090"},
+			map[string]interface{}{"name": "us-east", "url": "http://b:9090"},
+		},
+	}), "Expected duplicate cluster names to be rejected")
+}
+
+func TestFederatedPrometheusCollector_TLS(t *testing.T) {
+	var clientCerts int
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientCerts = len(r.TLS.PeerCertificates)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"__name__":"up"},"value":[1700000000,"1"]}]}}`)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	// The test server's certificate doubles as the CA bundle and the client certificate
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+	keyDER, err := x509.MarshalPKCS8PrivateKey(server.TLS.Certificates[0].PrivateKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	configure := func(settings map[string]interface{}) *FederatedPrometheusCollector {
+		collector := NewFederatedPrometheusCollector("fleet")
+		require.NoError(t, collector.Configure(map[string]interface{}{
+			"clusters":       []interface{}{map[string]interface{}{"name": "secure", "url": server.URL}},
+			"queries":        []interface{}{"up"},
+			"fetch_metadata": false,
+			"tls":            settings,
+		}))
+		return collector
+	}
+
+	_, err = configure(map[string]interface{}{}).Collect(context.Background())
+	assert.Error(t, err, "Expected the server certificate to be checked against the system roots")
+
+	points, err := configure(map[string]interface{}{"ca_file": certFile, "cert_file": certFile, "key_file": keyFile}).Collect(context.Background())
+	require.NoError(t, err)
+	assert.NotEmpty(t, points)
+	assert.Equal(t, 1, clientCerts, "Expected the client certificate to be presented")
+
+	collector := NewFederatedPrometheusCollector("fleet")
+	assert.Error(t, collector.Configure(map[string]interface{}{
+		"clusters": []interface{}{map[string]interface{}{"name": "secure", "url": server.URL}},
+		"tls":      map[string]interface{}{"ca_file": filepath.Join(dir, "missing.crt")},
+	}), "Expected a missing CA file to be rejected")
+}
+
+func TestFederatedPrometheusCollector_DiscoveredClusters(t *testing.T) {
+	east := newPrometheusServer(t, "1")
+	west := newPrometheusServer(t, "0")
+	eastInstance := serviceInstance(t, "prom-east", east.Listener.Addr().String())
+	eastInstance.Metadata = map[string]string{"cluster": "us-east"}
+	westInstance := serviceInstance(t, "prom-west", west.Listener.Addr().String())
+
+	collector := NewFederatedPrometheusCollector("fleet")
+	require.NoError(t, collector.Configure(map[string]interface{}{
+		"discover":       map[string]interface{}{"service": "prometheus", "cluster_metadata": "cluster"},
+		"queries":        []interface{}{"up"},
+		"fetch_metadata": false,
+	}))
+	discovery := &staticDiscovery{}
+	collector.SetServiceDiscovery(discovery)
+	assert.Error(t, collector.Start(context.Background()), "Expected starting without discovered clusters to fail")
+
+	discovery.instances = []core.ServiceInfo{eastInstance}
+	require.NoError(t, collector.Start(context.Background()))
+	defer collector.Stop()
+
+	up := func() map[string]float64 {
+		points, err := collector.Collect(context.Background())
+		require.NoError(t, err)
+		values := make(map[string]float64)
+		for _, point := range points {
+			if point.Metric == "up" {
+				values[point.Labels["cluster"]] = point.Value
+			}
+		}
+		return values
+	}
+	assert.Equal(t, map[string]float64{"us-east": 1}, up())
+
+	// Instances without the cluster metadata are named by their ID
+	discovery.instances = []core.ServiceInfo{eastInstance, westInstance}
+	assert.Equal(t, map[string]float64{"us-east": 1, "prom-west": 0}, up())
+
+	discovery.instances = []core.ServiceInfo{westInstance}
+	assert.Equal(t, map[string]float64{"prom-west": 0}, up())
+	var buf bytes.Buffer
+	collector.WriteMetrics(&buf)
+	assert.NotContains(t, buf.String(), "us-east", "Expected departed clusters to be forgotten")
+}
@@ -0,0 +1,366 @@
+package collectors
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// GRPCHealthTarget is a single endpoint and service checked by the collector
+type GRPCHealthTarget struct {
+	Address string
+	Service string
+}
+
+// GRPCHealthCollector implements the DataCollector interface by calling the standard
+// grpc.health.v1 Health service on each configured target, and on each instance of a
+// discovered service
+type GRPCHealthCollector struct {
+	name     string
+	version  string
+	status   core.PluginStatus
+	targets  []GRPCHealthTarget
+	discover *discoveredService
+	// discovered holds the targets found by the last successful lookup
+	discovered []GRPCHealthTarget
+	interval   time.Duration
+	timeout    time.Duration
+	tlsConfig  *tls.Config
+	conns      map[string]*grpc.ClientConn
+	mu         sync.RWMutex
+}
+
+// NewGRPCHealthCollector creates a new gRPC health-check collector plugin
+func NewGRPCHealthCollector(name string) *GRPCHealthCollector {
+	return &GRPCHealthCollector{
+		name:     name,
+		version:  "1.0.0",
+		status:   core.PluginStatusStopped,
+		interval: 30 * time.Second,
+		timeout:  5 * time.Second,
+		conns:    make(map[string]*grpc.ClientConn),
+	}
+}
+
+// Name returns the name of the plugin
+func (g *GRPCHealthCollector) Name() string {
+	return g.name
+}
+
+// Type returns the type of plugin
+func (g *GRPCHealthCollector) Type() core.PluginType {
+	return core.PluginTypeCollector
+}
+
+// Version returns the plugin version
+func (g *GRPCHealthCollector) Version() string {
+	return g.version
+}
+
+// Configure initializes the plugin with configuration. Targets are listed in targets, or
+// discovered with discover: service names the discovered service and health_service the
+// service name sent in its health checks.
+func (g *GRPCHealthCollector) Configure(config map[string]interface{}) error {
+	discover, err := parseDiscoveredService(config["discover"])
+	if err != nil {
+		return err
+	}
+	rawTargets, _ := config["targets"].([]interface{})
+	if len(rawTargets) == 0 && discover == nil {
+		return fmt.Errorf("grpc health targets not specified")
+	}
+	if g.discover != nil && discover != nil {
+		discover.discovery = g.discover.discovery
+	}
+	g.discover = discover
+
+	g.targets = nil
+	for _, raw := range rawTargets {
+		switch t := raw.(type) {
+		case string:
+			g.targets = append(g.targets, GRPCHealthTarget{Address: t})
+		case map[string]interface{}:
+			address, _ := t["address"].(string)
+			if address == "" {
+				return fmt.Errorf("grpc health target missing address")
+			}
+			service, _ := t["service"].(string)
+			g.targets = append(g.targets, GRPCHealthTarget{Address: address, Service: service})
+		default:
+			return fmt.Errorf("invalid grpc health target: %v", raw)
+		}
+	}
+
+	if intervalStr, ok := config["interval"].(string); ok {
+		if interval, err := time.ParseDuration(intervalStr); err == nil {
+			g.interval = interval
+		}
+	}
+
+	if timeoutStr, ok := config["timeout"].(string); ok {
+		if timeout, err := time.ParseDuration(timeoutStr); err == nil {
+			g.timeout = timeout
+		}
+	}
+
+	if tlsSettings, ok := config["tls"].(map[string]interface{}); ok {
+		tlsConfig, err := core.NewClientTLSConfig(tlsSettings)
+		if err != nil {
+			return err
+		}
+		g.tlsConfig = tlsConfig
+	}
+
+	return nil
+}
+
+// SetServiceDiscovery provides the backend targets are discovered from
+func (g *GRPCHealthCollector) SetServiceDiscovery(discovery core.ServiceDiscovery) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.discover != nil {
+		g.discover.discovery = discovery
+	}
+}
+
+// Start begins the plugin's operation
+func (g *GRPCHealthCollector) Start(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.status == core.PluginStatusRunning {
+		return fmt.Errorf("collector is already running")
+	}
+
+	g.status = core.PluginStatusStarting
+	slog.Info("Starting gRPC health collector", "plugin", g.name, "type", g.Type(), "targets", len(g.targets))
+
+	if g.discover != nil && g.discover.discovery == nil {
+		g.status = core.PluginStatusError
+		return fmt.Errorf("grpc health targets are discovered but service discovery is not configured")
+	}
+	for _, target := range g.targets {
+		if err := g.connect(target); err != nil {
+			g.closeConns()
+			g.status = core.PluginStatusError
+			return err
+		}
+	}
+
+	g.status = core.PluginStatusRunning
+	slog.Info("gRPC health collector started", "plugin", g.name, "type", g.Type())
+	return nil
+}
+
+// Stop gracefully stops the plugin
+func (g *GRPCHealthCollector) Stop() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.status != core.PluginStatusRunning {
+		return fmt.Errorf("collector is not running")
+	}
+
+	g.status = core.PluginStatusStopping
+	slog.Info("Stopping gRPC health collector", "plugin", g.name, "type", g.Type())
+
+	g.closeConns()
+
+	g.status = core.PluginStatusStopped
+	slog.Info("gRPC health collector stopped", "plugin", g.name, "type", g.Type())
+	return nil
+}
+
+// Status returns the current status of the plugin
+func (g *GRPCHealthCollector) Status() core.PluginStatus {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.status
+}
+
+// Health checks if the plugin is healthy
+func (g *GRPCHealthCollector) Health(ctx context.Context) error {
+	if g.Status() != core.PluginStatusRunning {
+		return fmt.Errorf("collector is not running")
+	}
+	return nil
+}
+
+// GetCapabilities returns what this plugin can do
+func (g *GRPCHealthCollector) GetCapabilities() []string {
+	return []string{
+		"collect_metrics",
+		"grpc_health_check",
+		"health_check",
+	}
+}
+
+// Collect checks every target and emits serving-status and RTT data points
+func (g *GRPCHealthCollector) Collect(ctx context.Context) ([]core.DataPoint, error) {
+	g.refreshTargets()
+
+	g.mu.RLock()
+	targets := append(append([]GRPCHealthTarget(nil), g.targets...), g.discovered...)
+	conns := make(map[string]*grpc.ClientConn, len(g.conns))
+	for address, conn := range g.conns {
+		conns[address] = conn
+	}
+	g.mu.RUnlock()
+
+	var dataPoints []core.DataPoint
+	for _, target := range targets {
+		conn, ok := conns[target.Address]
+		if !ok {
+			continue
+		}
+		dataPoints = append(dataPoints, g.checkTarget(ctx, conn, target)...)
+	}
+
+	return dataPoints, nil
+}
+
+// GetCollectionInterval returns how often this collector should run
+func (g *GRPCHealthCollector) GetCollectionInterval() time.Duration {
+	return g.interval
+}
+
+// checkTarget calls Health/Check on a single target. Every point is labelled with only the
+// target and service, so a target stays one series whatever its status; the serving status
+// is the value of grpc_health_status, and the reason a check failed is logged.
+func (g *GRPCHealthCollector) checkTarget(ctx context.Context, conn *grpc.ClientConn, target GRPCHealthTarget) []core.DataPoint {
+	checkCtx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+
+	labels := func() map[string]string {
+		return map[string]string{"target": target.Address, "service": target.Service}
+	}
+
+	start := time.Now()
+	resp, err := healthpb.NewHealthClient(conn).Check(checkCtx, &healthpb.HealthCheckRequest{Service: target.Service})
+	rtt := time.Since(start)
+
+	servingStatus := healthpb.HealthCheckResponse_UNKNOWN
+	if err != nil {
+		slog.Warn("gRPC health check failed", "plugin", g.name, "target", target.Address, "service", target.Service, "error", err)
+	} else {
+		servingStatus = resp.GetStatus()
+	}
+
+	serving := 0.0
+	if servingStatus == healthpb.HealthCheckResponse_SERVING {
+		serving = 1.0
+	}
+
+	now := time.Now()
+	return []core.DataPoint{
+		{
+			Timestamp: now,
+			Source:    g.name,
+			Metric:    "grpc_health_serving",
+			Value:     serving,
+			Labels:    labels(),
+		},
+		{
+			// The health protocol's status code: 0 unknown or failed, 1 serving, 2 not
+			// serving, 3 service unknown
+			Timestamp: now,
+			Source:    g.name,
+			Metric:    "grpc_health_status",
+			Value:     float64(servingStatus),
+			Labels:    labels(),
+		},
+		{
+			Timestamp: now,
+			Source:    g.name,
+			Metric:    "grpc_health_rtt_seconds",
+			Value:     rtt.Seconds(),
+			Labels:    labels(),
+		},
+	}
+}
+
+// refreshTargets looks up the discovered service, connecting to new instances and closing
+// the connections of instances that went away. A failed lookup keeps the targets found last.
+func (g *GRPCHealthCollector) refreshTargets() {
+	g.mu.RLock()
+	discover := g.discover
+	g.mu.RUnlock()
+	if discover == nil {
+		return
+	}
+	instances, err := discover.instances()
+	if err != nil {
+		slog.Warn("Failed to discover gRPC health targets, keeping the last ones", "plugin", g.name, "service", discover.service, "error", err)
+		return
+	}
+
+	healthService := discover.setting("health_service", "")
+	discovered := make([]GRPCHealthTarget, 0, len(instances))
+	for _, instance := range instances {
+		discovered = append(discovered, GRPCHealthTarget{Address: instanceAddress(instance), Service: healthService})
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.status != core.PluginStatusRunning {
+		return
+	}
+	wanted := make(map[string]bool, len(g.targets)+len(discovered))
+	for _, target := range g.targets {
+		wanted[target.Address] = true
+	}
+	g.discovered = g.discovered[:0]
+	for _, target := range discovered {
+		if err := g.connect(target); err != nil {
+			slog.Warn("Skipping discovered gRPC health target", "plugin", g.name, "target", target.Address, "error", err)
+			continue
+		}
+		wanted[target.Address] = true
+		g.discovered = append(g.discovered, target)
+	}
+	for address, conn := range g.conns {
+		if wanted[address] {
+			continue
+		}
+		if err := conn.Close(); err != nil {
+			slog.Warn("Failed to close gRPC connection", "plugin", g.name, "target", address, "error", err)
+		}
+		delete(g.conns, address)
+	}
+}
+
+// connect creates the client connection of a target unless it has one; callers must hold g.mu
+func (g *GRPCHealthCollector) connect(target GRPCHealthTarget) error {
+	if _, exists := g.conns[target.Address]; exists {
+		return nil
+	}
+	creds := insecure.NewCredentials()
+	if g.tlsConfig != nil {
+		creds = credentials.NewTLS(g.tlsConfig)
+	}
+	conn, err := grpc.NewClient(target.Address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return fmt.Errorf("failed to create gRPC client for %s: %w", target.Address, err)
+	}
+	g.conns[target.Address] = conn
+	return nil
+}
+
+// closeConns closes all client connections; callers must hold g.mu
+func (g *GRPCHealthCollector) closeConns() {
+	for address, conn := range g.conns {
+		if err := conn.Close(); err != nil {
+			slog.Warn("Failed to close gRPC connection", "plugin", g.name, "target", address, "error", err)
+		}
+		delete(g.conns, address)
+	}
+}
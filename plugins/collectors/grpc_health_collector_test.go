@@ -0,0 +1,136 @@
+package collectors
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestGRPCHealthCollector_Collect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("orders", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("payments", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	collector := NewGRPCHealthCollector("grpc")
+	require.NoError(t, collector.Configure(map[string]interface{}{
+		"targets": []interface{}{
+			map[string]interface{}{"address": listener.Addr().String(), "service": "orders"},
+			map[string]interface{}{"address": listener.Addr().String(), "service": "payments"},
+		},
+		"timeout": "2s",
+	}))
+	require.NoError(t, collector.Start(context.Background()))
+	defer collector.Stop()
+
+	points, err := collector.Collect(context.Background())
+	require.NoError(t, err)
+	require.Len(t, points, 6)
+
+	serving := make(map[string]core.DataPoint)
+	status := make(map[string]core.DataPoint)
+	for _, point := range points {
+		assert.Equal(t, map[string]string{"target": listener.Addr().String(), "service": point.Labels["service"]}, point.Labels,
+			"Expected one series per target whatever its status")
+		switch point.Metric {
+		case "grpc_health_serving":
+			serving[point.Labels["service"]] = point
+		case "grpc_health_status":
+			status[point.Labels["service"]] = point
+		}
+	}
+
+	assert.Equal(t, 1.0, serving["orders"].Value)
+	assert.Equal(t, float64(healthpb.HealthCheckResponse_SERVING), status["orders"].Value)
+	assert.Equal(t, 0.0, serving["payments"].Value)
+	assert.Equal(t, float64(healthpb.HealthCheckResponse_NOT_SERVING), status["payments"].Value)
+}
+
+func TestGRPCHealthCollector_ConfigureRequiresTargets(t *testing.T) {
+	collector := NewGRPCHealthCollector("grpc")
+	assert.Error(t, collector.Configure(map[string]interface{}{}))
+	assert.Error(t, collector.Configure(map[string]interface{}{
+		"targets": []interface{}{map[string]interface{}{"service": "orders"}},
+	}))
+}
+
+// staticDiscovery discovers the instances it holds
+type staticDiscovery struct {
+	core.ServiceDiscovery
+	instances []core.ServiceInfo
+	err       error
+}
+
+func (s *staticDiscovery) DiscoverServices(string) ([]core.ServiceInfo, error) {
+	return s.instances, s.err
+}
+
+// serviceInstance returns a discovered instance at a listener's address
+func serviceInstance(t *testing.T, id, address string) core.ServiceInfo {
+	host, port, err := net.SplitHostPort(address)
+	require.NoError(t, err)
+	number, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	return core.ServiceInfo{ID: id, Address: host, Port: number}
+}
+
+func TestGRPCHealthCollector_DiscoveredTargets(t *testing.T) {
+	addresses := make([]string, 2)
+	for i := range addresses {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		server := grpc.NewServer()
+		healthServer := health.NewServer()
+		healthServer.SetServingStatus("payments", healthpb.HealthCheckResponse_SERVING)
+		healthpb.RegisterHealthServer(server, healthServer)
+		go server.Serve(listener)
+		defer server.Stop()
+		addresses[i] = listener.Addr().String()
+	}
+
+	collector := NewGRPCHealthCollector("grpc")
+	require.NoError(t, collector.Configure(map[string]interface{}{
+		"discover": map[string]interface{}{"service": "payments", "health_service": "payments"},
+		"timeout":  "2s",
+	}))
+	assert.Error(t, collector.Start(context.Background()), "Expected discovery to be required")
+
+	discovery := &staticDiscovery{instances: []core.ServiceInfo{serviceInstance(t, "a", addresses[0])}}
+	collector.SetServiceDiscovery(discovery)
+	require.NoError(t, collector.Start(context.Background()))
+	defer collector.Stop()
+
+	targets := func() map[string]float64 {
+		points, err := collector.Collect(context.Background())
+		require.NoError(t, err)
+		serving := make(map[string]float64)
+		for _, point := range points {
+			if point.Metric == "grpc_health_serving" {
+				serving[point.Labels["target"]] = point.Value
+			}
+		}
+		return serving
+	}
+	assert.Equal(t, map[string]float64{addresses[0]: 1}, targets())
+
+	discovery.instances = []core.ServiceInfo{serviceInstance(t, "b", addresses[1])}
+	assert.Equal(t, map[string]float64{addresses[1]: 1}, targets())
+	assert.Len(t, collector.conns, 1, "Expected the connection of the departed instance to be closed")
+
+	discovery.err = assert.AnError
+	assert.Equal(t, map[string]float64{addresses[1]: 1}, targets(), "Expected a failed lookup to keep the last targets")
+}
@@ -4,32 +4,49 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"sync"
 	"time"
 
 	"github.com/habruzzo/agent/core"
 	"github.com/prometheus/client_golang/api"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
 )
 
 // PrometheusCollector implements the DataCollector interface for Prometheus
 type PrometheusCollector struct {
-	name     string
-	version  string
-	status   core.PluginStatus
-	client   v1.API
-	queries  []string
-	interval time.Duration
-	mu       sync.RWMutex
+	name          string
+	version       string
+	status        core.PluginStatus
+	client        v1.API
+	queries       []string
+	interval      time.Duration
+	fetchMetadata bool
+	metadata      *core.MetricMetadataRegistry
+	// results holds query results for cache_ttl, so collecting more often than Prometheus
+	// scrapes does not re-query series that cannot have changed
+	results  core.Cache
+	cacheTTL time.Duration
+	// breaker skips queries while Prometheus keeps failing; nil disables it
+	breaker core.CircuitBreaker
+	// limiter paces queries so large query lists don't overload Prometheus; nil means unlimited
+	limiter core.RateLimiter
+	mu      sync.RWMutex
 }
 
+// defaultPrometheusBreakerConfig stops querying after repeated failures, so a collection
+// against an unreachable server doesn't wait out every query's timeout
+var defaultPrometheusBreakerConfig = core.CircuitBreakerConfig{FailureThreshold: 5, ResetTimeout: 30 * time.Second}
+
 // NewPrometheusCollector creates a new Prometheus collector plugin
 func NewPrometheusCollector(name string) *PrometheusCollector {
 	return &PrometheusCollector{
-		name:     name,
-		version:  "1.0.0",
-		status:   core.PluginStatusStopped,
-		interval: 30 * time.Second,
+		name:          name,
+		version:       "1.0.0",
+		status:        core.PluginStatusStopped,
+		interval:      30 * time.Second,
+		fetchMetadata: true,
 	}
 }
 
@@ -55,9 +72,18 @@ func (p *PrometheusCollector) Configure(config map[string]interface{}) error {
 		return fmt.Errorf("prometheus URL not specified")
 	}
 
-	client, err := api.NewClient(api.Config{
-		Address: url,
-	})
+	clientConfig := api.Config{Address: url}
+	if tlsSettings, ok := config["tls"].(map[string]interface{}); ok {
+		tlsConfig, err := core.NewClientTLSConfig(tlsSettings)
+		if err != nil {
+			return err
+		}
+		transport := api.DefaultRoundTripper.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		clientConfig.RoundTripper = transport
+	}
+
+	client, err := api.NewClient(clientConfig)
 	if err != nil {
 		return fmt.Errorf("failed to create Prometheus client: %w", err)
 	}
@@ -88,9 +114,44 @@ func (p *PrometheusCollector) Configure(config map[string]interface{}) error {
 		}
 	}
 
+	// Query results are cached for cache_ttl, typically the Prometheus scrape interval
+	p.cacheTTL = 0
+	p.results = nil
+	if ttlStr, ok := config["cache_ttl"].(string); ok {
+		ttl, err := time.ParseDuration(ttlStr)
+		if err != nil || ttl < 0 {
+			return fmt.Errorf("invalid cache_ttl %q", ttlStr)
+		}
+		if ttl > 0 {
+			p.cacheTTL = ttl
+			p.results = core.NewLRUCache(len(p.queries), ttl)
+		}
+	}
+
+	breakerConfig, err := core.ParseCircuitBreakerConfig(config["circuit_breaker"], defaultPrometheusBreakerConfig)
+	if err != nil {
+		return err
+	}
+	p.breaker = core.NewCircuitBreaker(breakerConfig)
+	limiterConfig, err := core.ParseRateLimiterConfig(config["rate_limit"], core.RateLimiterConfig{})
+	if err != nil {
+		return err
+	}
+	p.limiter = core.NewRateLimiter(limiterConfig)
+
+	// Units, types, and help text are fetched from the metadata API unless disabled
+	if fetchMetadata, ok := config["fetch_metadata"].(bool); ok {
+		p.fetchMetadata = fetchMetadata
+	}
+
 	return nil
 }
 
+// SetMetricMetadata provides the registry that discovered metric metadata is added to
+func (p *PrometheusCollector) SetMetricMetadata(registry *core.MetricMetadataRegistry) {
+	p.metadata = registry
+}
+
 // Start begins the plugin's operation
 func (p *PrometheusCollector) Start(ctx context.Context) error {
 	p.mu.Lock()
@@ -109,6 +170,12 @@ func (p *PrometheusCollector) Start(ctx context.Context) error {
 		return fmt.Errorf("health check failed: %w", err)
 	}
 
+	if p.fetchMetadata && p.metadata != nil {
+		if err := p.loadMetadata(ctx); err != nil {
+			slog.Warn("Failed to fetch metric metadata", "plugin", p.name, "error", err)
+		}
+	}
+
 	p.status = core.PluginStatusRunning
 	slog.Info("Prometheus collector started", "plugin", p.name, "type", p.Type())
 	return nil
@@ -164,42 +231,155 @@ func (p *PrometheusCollector) Collect(ctx context.Context) ([]core.DataPoint, er
 		return nil, fmt.Errorf("prometheus client not configured")
 	}
 
-	var dataPoints []core.DataPoint
+	dataPoints, _ := p.collectQueries(ctx)
+	return dataPoints, nil
+}
 
+// collectQueries runs every query, returning the data points of those that succeeded.
+// Failed queries are logged and skipped; the error is only returned when every query
+// failed, which usually means Prometheus is unreachable.
+func (p *PrometheusCollector) collectQueries(ctx context.Context) ([]core.DataPoint, error) {
+	var dataPoints []core.DataPoint
+	var lastErr error
+	failed := 0
 	for _, query := range p.queries {
-		result, warnings, err := p.client.Query(ctx, query, time.Now())
+		result, err := p.query(ctx, query)
 		if err != nil {
 			slog.Error("Failed to query Prometheus", "plugin", p.name, "query", query, "error", err)
+			lastErr = err
+			failed++
 			continue
 		}
 
-		if warnings != nil {
-			slog.Warn("Prometheus query warnings", "plugin", p.name, "warnings", warnings)
-		}
-
 		points := p.convertResultToDataPoints(result, query)
 		dataPoints = append(dataPoints, points...)
 	}
 
+	if failed > 0 && failed == len(p.queries) {
+		return nil, lastErr
+	}
 	return dataPoints, nil
 }
 
+// Query runs an ad-hoc PromQL query, such as one an agent's model asked for, returning its
+// result as data points
+func (p *PrometheusCollector) Query(ctx context.Context, query string) ([]core.DataPoint, error) {
+	if p.client == nil {
+		return nil, fmt.Errorf("prometheus client not configured")
+	}
+	result, err := p.query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return p.convertResultToDataPoints(result, query), nil
+}
+
+// query runs a query, or returns its cached result while it is fresh
+func (p *PrometheusCollector) query(ctx context.Context, query string) (model.Value, error) {
+	if p.results != nil {
+		if cached, ok := p.results.Get(query); ok {
+			return cached.(model.Value), nil
+		}
+	}
+
+	if p.limiter != nil {
+		if err := p.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	var result model.Value
+	var warnings v1.Warnings
+	call := func() (err error) {
+		result, warnings, err = p.client.Query(ctx, query, time.Now())
+		return err
+	}
+	var err error
+	if p.breaker != nil {
+		err = p.breaker.Execute(ctx, call)
+	} else {
+		err = call()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if warnings != nil {
+		slog.Warn("Prometheus query warnings", "plugin", p.name, "warnings", warnings)
+	}
+
+	if p.results != nil {
+		if err := p.results.Set(query, result, p.cacheTTL); err != nil {
+			slog.Debug("Failed to cache Prometheus result", "plugin", p.name, "query", query, "error", err)
+		}
+	}
+	return result, nil
+}
+
 // GetCollectionInterval returns how often this collector should run
 func (p *PrometheusCollector) GetCollectionInterval() time.Duration {
 	return p.interval
 }
 
-// convertResultToDataPoints converts Prometheus query result to DataPoints
-func (p *PrometheusCollector) convertResultToDataPoints(result interface{}, query string) []core.DataPoint {
-	// This is a simplified conversion - in practice, you'd handle different result types
-	// based on the Prometheus query result structure
-	return []core.DataPoint{
-		{
-			Timestamp: time.Now(),
-			Source:    p.name,
-			Metric:    query,
-			Value:     1.0, // Placeholder - would extract actual value from result
-			Labels:    map[string]string{"query": query},
-		},
+// loadMetadata registers metric metadata reported by the Prometheus metadata API
+func (p *PrometheusCollector) loadMetadata(ctx context.Context) error {
+	result, err := p.client.Metadata(ctx, "", "")
+	if err != nil {
+		return err
+	}
+
+	for metric, entries := range result {
+		if len(entries) == 0 {
+			continue
+		}
+		p.metadata.Register(core.MetricMetadata{
+			Name:        metric,
+			Unit:        entries[0].Unit,
+			Type:        string(entries[0].Type),
+			Description: entries[0].Help,
+		})
+	}
+
+	slog.Info("Loaded metric metadata", "plugin", p.name, "metrics", len(result))
+	return nil
+}
+
+// convertResultToDataPoints converts a Prometheus query result to DataPoints. Each series
+// of an instant vector becomes a point named after the series, or after the query when the
+// expression drops the name, keeping its labels.
+func (p *PrometheusCollector) convertResultToDataPoints(result model.Value, query string) []core.DataPoint {
+	switch value := result.(type) {
+	case model.Vector:
+		points := make([]core.DataPoint, 0, len(value))
+		for _, sample := range value {
+			metric := query
+			labels := map[string]string{"query": query}
+			for name, labelValue := range sample.Metric {
+				if name == model.MetricNameLabel {
+					metric = string(labelValue)
+					continue
+				}
+				labels[string(name)] = string(labelValue)
+			}
+			points = append(points, core.DataPoint{
+				Timestamp: sample.Timestamp.Time(),
+				Source:    p.name,
+				Metric:    metric,
+				Value:     float64(sample.Value),
+				Labels:    labels,
+			})
+		}
+		return points
+	case *model.Scalar:
+		return []core.DataPoint{
+			{
+				Timestamp: value.Timestamp.Time(),
+				Source:    p.name,
+				Metric:    query,
+				Value:     float64(value.Value),
+				Labels:    map[string]string{"query": query},
+			},
+		}
+	default:
+		slog.Debug("Ignoring unsupported Prometheus result type", "plugin", p.name, "query", query, "type", result.Type())
+		return nil
 	}
 }
@@ -0,0 +1,509 @@
+package external
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/hashicorp/go-hclog"
+	goplugin "github.com/hashicorp/go-plugin"
+)
+
+// Load creates the plugin for an external plugin binary, which must be inside dir. The
+// binary is run once to learn which type of plugin it serves and to validate its
+// configuration, so a bad path or setting fails here rather than when the framework starts.
+func Load(name, dir string, config map[string]interface{}) (core.Plugin, error) {
+	plugin := newExternalPlugin(name, dir)
+	if err := plugin.Configure(config); err != nil {
+		return nil, err
+	}
+
+	switch plugin.info.Type {
+	case core.PluginTypeCollector:
+		return &Collector{plugin}, nil
+	case core.PluginTypeAnalyzer:
+		return &Analyzer{plugin}, nil
+	case core.PluginTypeResponder:
+		return &Responder{plugin}, nil
+	default:
+		return nil, fmt.Errorf("external plugin %s is a %s; only collectors, analyzers, and responders can run out of process", name, plugin.info.Type)
+	}
+}
+
+// externalPlugin runs a plugin binary and forwards the plugin's calls to it. The process
+// runs while the plugin is started; if it exits while the plugin runs, the next call starts
+// it again, no sooner than restart_backoff after the last start.
+type externalPlugin struct {
+	name   string
+	status core.PluginStatus
+	// dir is the directory binaries must be in; path is resolved inside it
+	dir            string
+	path           string
+	args           []string
+	env            []string
+	checksum       []byte
+	settings       map[string]interface{}
+	interval       time.Duration
+	callTimeout    time.Duration
+	startTimeout   time.Duration
+	restartBackoff time.Duration
+	info           pluginInfo
+
+	client     *goplugin.Client
+	remote     *pluginClient
+	startCtx   context.Context
+	launchedAt time.Time
+	restarts   int
+	mu         sync.RWMutex
+}
+
+func newExternalPlugin(name, dir string) *externalPlugin {
+	return &externalPlugin{
+		name:           name,
+		dir:            dir,
+		status:         core.PluginStatusStopped,
+		callTimeout:    30 * time.Second,
+		startTimeout:   time.Minute,
+		restartBackoff: 10 * time.Second,
+	}
+}
+
+// Name returns the name of the plugin
+func (p *externalPlugin) Name() string {
+	return p.name
+}
+
+// Type returns the type of plugin the binary serves
+func (p *externalPlugin) Type() core.PluginType {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.info.Type
+}
+
+// Version returns the version the binary reports
+func (p *externalPlugin) Version() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.info.Version
+}
+
+// Configure initializes the plugin with configuration. The binary is started to check it
+// serves the same type of plugin as before and accepts its config, then stopped again.
+func (p *externalPlugin) Configure(config map[string]interface{}) error {
+	path, _ := config["path"].(string)
+	if path == "" {
+		return fmt.Errorf("external plugin path not specified")
+	}
+	path, err := resolvePluginPath(p.dir, path)
+	if err != nil {
+		return err
+	}
+
+	var args []string
+	if rawArgs, ok := config["args"].([]interface{}); ok {
+		for _, arg := range rawArgs {
+			args = append(args, fmt.Sprint(arg))
+		}
+	}
+
+	var env []string
+	if rawEnv, ok := config["env"].(map[string]interface{}); ok {
+		for key, value := range rawEnv {
+			env = append(env, key+"="+fmt.Sprint(value))
+		}
+		sort.Strings(env)
+	}
+
+	var checksum []byte
+	if sum, ok := config["sha256"].(string); ok && sum != "" {
+		decoded, err := hex.DecodeString(sum)
+		if err != nil || len(decoded) != sha256.Size {
+			return fmt.Errorf("invalid sha256 %q", sum)
+		}
+		checksum = decoded
+	}
+
+	settings, _ := config["config"].(map[string]interface{})
+	if _, ok := config["config"]; ok && settings == nil {
+		return fmt.Errorf("external plugin config must be a map")
+	}
+
+	durations := map[string]*time.Duration{
+		"interval":        &p.interval,
+		"call_timeout":    &p.callTimeout,
+		"start_timeout":   &p.startTimeout,
+		"restart_backoff": &p.restartBackoff,
+	}
+	parsed := make(map[string]time.Duration, len(durations))
+	for key := range durations {
+		value, ok := config[key].(string)
+		if !ok {
+			continue
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration < 0 {
+			return fmt.Errorf("invalid %s %q", key, value)
+		}
+		parsed[key] = duration
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.status == core.PluginStatusRunning {
+		return fmt.Errorf("external plugin %s cannot be reconfigured while running", p.name)
+	}
+
+	previous := p.info.Type
+	p.path, p.args, p.env, p.checksum, p.settings = path, args, env, checksum, settings
+	for key, duration := range parsed {
+		*durations[key] = duration
+	}
+
+	if err := p.launch(); err != nil {
+		return err
+	}
+	defer p.kill()
+	if previous != "" && p.info.Type != previous {
+		return fmt.Errorf("external plugin %s changed from a %s to a %s", p.name, previous, p.info.Type)
+	}
+	return nil
+}
+
+// resolvePluginPath finds a plugin binary inside dir, relative paths being relative to dir.
+// Symlinks are followed first, so no path names a program elsewhere on the host.
+func resolvePluginPath(dir, path string) (string, error) {
+	if dir == "" {
+		return "", fmt.Errorf("external plugins can only run from external_plugins_dir, which is not set")
+	}
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid external_plugins_dir: %w", err)
+	}
+	if root, err = filepath.Abs(root); err != nil {
+		return "", fmt.Errorf("invalid external_plugins_dir: %w", err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("external plugin %s: %w", path, err)
+	}
+	relative, err := filepath.Rel(root, resolved)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("external plugin %s is outside external_plugins_dir %s", path, dir)
+	}
+	return resolved, nil
+}
+
+// launch starts the plugin process, reads what it serves, and passes it its config; callers
+// must hold p.mu
+func (p *externalPlugin) launch() error {
+	cmd := exec.Command(p.path, p.args...)
+	cmd.Env = append(cmd.Env, p.env...)
+
+	clientConfig := &goplugin.ClientConfig{
+		HandshakeConfig:  Handshake,
+		Plugins:          goplugin.PluginSet{pluginKey: &grpcPlugin{}},
+		Cmd:              cmd,
+		AllowedProtocols: []goplugin.Protocol{goplugin.ProtocolGRPC},
+		AutoMTLS:         true,
+		StartTimeout:     p.startTimeout,
+		Logger:           newPluginLogger(p.name),
+	}
+	if p.checksum != nil {
+		clientConfig.SecureConfig = &goplugin.SecureConfig{Checksum: p.checksum, Hash: sha256.New()}
+	}
+
+	client := goplugin.NewClient(clientConfig)
+	protocol, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return fmt.Errorf("failed to start external plugin %s: %w", p.path, err)
+	}
+	dispensed, err := protocol.Dispense(pluginKey)
+	if err != nil {
+		client.Kill()
+		return fmt.Errorf("failed to connect to external plugin %s: %w", p.path, err)
+	}
+	remote := dispensed.(*pluginClient)
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.callTimeout)
+	defer cancel()
+	var info pluginInfo
+	if err := remote.call(ctx, "Info", nil, &info); err != nil {
+		client.Kill()
+		return fmt.Errorf("failed to read external plugin %s: %w", p.path, err)
+	}
+	if err := remote.call(ctx, "Configure", p.settings, nil); err != nil {
+		client.Kill()
+		return fmt.Errorf("external plugin %s rejected its config: %w", p.name, err)
+	}
+
+	p.client, p.remote, p.info = client, remote, info
+	p.launchedAt = time.Now()
+	return nil
+}
+
+// kill stops the plugin process; callers must hold p.mu
+func (p *externalPlugin) kill() {
+	if p.client != nil {
+		p.client.Kill()
+	}
+	p.client, p.remote = nil, nil
+}
+
+// Start starts the plugin process and the plugin in it
+func (p *externalPlugin) Start(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.status == core.PluginStatusRunning {
+		return fmt.Errorf("external plugin is already running")
+	}
+
+	p.status = core.PluginStatusStarting
+	slog.Info("Starting external plugin", "plugin", p.name, "type", p.info.Type, "path", p.path)
+
+	if err := p.startProcess(ctx); err != nil {
+		p.status = core.PluginStatusError
+		return err
+	}
+
+	p.startCtx = ctx
+	p.status = core.PluginStatusRunning
+	slog.Info("External plugin started", "plugin", p.name, "type", p.info.Type, "version", p.info.Version)
+	return nil
+}
+
+// startProcess launches the plugin process and starts the plugin in it; callers must hold p.mu
+func (p *externalPlugin) startProcess(ctx context.Context) error {
+	previous := p.info.Type
+	if err := p.launch(); err != nil {
+		return err
+	}
+	if p.info.Type != previous {
+		p.kill()
+		return fmt.Errorf("external plugin %s changed from a %s to a %s", p.name, previous, p.info.Type)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, p.callTimeout)
+	defer cancel()
+	if err := p.remote.call(callCtx, "Start", nil, nil); err != nil {
+		p.kill()
+		return fmt.Errorf("external plugin %s failed to start: %w", p.name, err)
+	}
+	return nil
+}
+
+// Stop stops the plugin and its process
+func (p *externalPlugin) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.status != core.PluginStatusRunning {
+		return fmt.Errorf("external plugin is not running")
+	}
+
+	p.status = core.PluginStatusStopping
+	slog.Info("Stopping external plugin", "plugin", p.name, "type", p.info.Type)
+
+	var err error
+	if p.remote != nil && !p.client.Exited() {
+		ctx, cancel := context.WithTimeout(context.Background(), p.callTimeout)
+		err = p.remote.call(ctx, "Stop", nil, nil)
+		cancel()
+	}
+	p.kill()
+
+	p.status = core.PluginStatusStopped
+	slog.Info("External plugin stopped", "plugin", p.name, "type", p.info.Type)
+	return err
+}
+
+// Status returns the current status of the plugin
+func (p *externalPlugin) Status() core.PluginStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.status
+}
+
+// Health checks the plugin process is running and the plugin in it is healthy
+func (p *externalPlugin) Health(ctx context.Context) error {
+	p.mu.RLock()
+	remote := p.remote
+	exited := p.client == nil || p.client.Exited()
+	p.mu.RUnlock()
+
+	if exited {
+		return fmt.Errorf("external plugin process is not running")
+	}
+	return remote.call(ctx, "Health", nil, nil)
+}
+
+// GetCapabilities returns the capabilities the binary reports
+func (p *externalPlugin) GetCapabilities() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]string{"external_process"}, p.info.Capabilities...)
+}
+
+// WriteMetrics writes how often the plugin process had to be started again after exiting
+func (p *externalPlugin) WriteMetrics(w io.Writer) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	fmt.Fprintf(w, "# HELP agent_external_plugin_restarts_total Times an external plugin's process was started again after exiting\n")
+	fmt.Fprintf(w, "# TYPE agent_external_plugin_restarts_total counter\n")
+	fmt.Fprintf(w, "agent_external_plugin_restarts_total{plugin=%q} %d\n", p.name, p.restarts)
+}
+
+// call forwards a call to the plugin process, starting the process again first if it
+// exited while the plugin was running
+func (p *externalPlugin) call(ctx context.Context, method string, req, resp interface{}) error {
+	remote, err := p.connection()
+	if err != nil {
+		return err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.callTimeout)
+		defer cancel()
+	}
+	return remote.call(ctx, method, req, resp)
+}
+
+// connection returns the client of the running plugin process
+func (p *externalPlugin) connection() (*pluginClient, error) {
+	p.mu.RLock()
+	if p.status == core.PluginStatusRunning && p.client != nil && !p.client.Exited() {
+		remote := p.remote
+		p.mu.RUnlock()
+		return remote, nil
+	}
+	p.mu.RUnlock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.status != core.PluginStatusRunning {
+		return nil, fmt.Errorf("external plugin %s is not running", p.name)
+	}
+	if p.client != nil && !p.client.Exited() {
+		return p.remote, nil
+	}
+	if wait := p.restartBackoff - time.Since(p.launchedAt); wait > 0 {
+		return nil, fmt.Errorf("external plugin %s process exited; restarting in %s", p.name, wait.Round(time.Second))
+	}
+
+	slog.Warn("External plugin process exited, restarting it", "plugin", p.name, "path", p.path)
+	p.kill()
+	ctx := p.startCtx
+	if ctx == nil || ctx.Err() != nil {
+		ctx = context.Background()
+	}
+	if err := p.startProcess(ctx); err != nil {
+		// Back off from the failed attempt as well as from successful ones
+		p.launchedAt = time.Now()
+		return nil, err
+	}
+	p.restarts++
+	return p.remote, nil
+}
+
+// Collector is an external plugin that collects data
+type Collector struct {
+	*externalPlugin
+}
+
+// Collect gathers data points from the plugin process
+func (c *Collector) Collect(ctx context.Context) ([]core.DataPoint, error) {
+	var points []core.DataPoint
+	if err := c.call(ctx, "Collect", nil, &points); err != nil {
+		return nil, err
+	}
+	for i := range points {
+		if points[i].Source == "" {
+			points[i].Source = c.name
+		}
+	}
+	return points, nil
+}
+
+// GetCollectionInterval returns the configured interval, or else the one the binary reports
+func (c *Collector) GetCollectionInterval() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.interval > 0 {
+		return c.interval
+	}
+	if c.info.CollectionInterval > 0 {
+		return c.info.CollectionInterval
+	}
+	return 30 * time.Second
+}
+
+// Analyzer is an external plugin that analyzes data
+type Analyzer struct {
+	*externalPlugin
+}
+
+// Analyze has the plugin process analyze data points
+func (a *Analyzer) Analyze(data []core.DataPoint) (*core.Analysis, error) {
+	var analysis *core.Analysis
+	if err := a.call(context.Background(), "Analyze", data, &analysis); err != nil {
+		return nil, err
+	}
+	return analysis, nil
+}
+
+// CanAnalyze asks the plugin process whether it can analyze data points
+func (a *Analyzer) CanAnalyze(data []core.DataPoint) bool {
+	var ok bool
+	if err := a.call(context.Background(), "CanAnalyze", data, &ok); err != nil {
+		slog.Debug("External analyzer could not be asked about a batch", "plugin", a.name, "error", err)
+		return false
+	}
+	return ok
+}
+
+// Responder is an external plugin that responds to analyses
+type Responder struct {
+	*externalPlugin
+}
+
+// Respond has the plugin process respond to an analysis
+func (r *Responder) Respond(ctx context.Context, analysis *core.Analysis) error {
+	return r.call(ctx, "Respond", analysis, nil)
+}
+
+// CanHandle asks the plugin process whether it handles an analysis
+func (r *Responder) CanHandle(analysis *core.Analysis) bool {
+	var ok bool
+	if err := r.call(context.Background(), "CanHandle", analysis, &ok); err != nil {
+		slog.Debug("External responder could not be asked about an analysis", "plugin", r.name, "error", err)
+		return false
+	}
+	return ok
+}
+
+// newPluginLogger logs go-plugin's messages, and what the plugin process writes to stderr,
+// through the agent's logger
+func newPluginLogger(name string) hclog.Logger {
+	return hclog.FromStandardLogger(slog.NewLogLogger(slog.Default().Handler(), slog.LevelInfo), &hclog.LoggerOptions{
+		Name:  "plugin." + name,
+		Level: hclog.Info,
+	})
+}
@@ -0,0 +1,248 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPluginEnv names the plugin the test binary serves when the agent runs it as a plugin
+const testPluginEnv = "AGENT_TEST_EXTERNAL_PLUGIN"
+
+// testCrashMarkerEnv names a file; the collector exits the first time it collects if the
+// file does not exist yet, creating it so the restarted process does not
+const testCrashMarkerEnv = "AGENT_TEST_CRASH_MARKER"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(testPluginEnv) {
+	case "":
+		os.Exit(m.Run())
+	case "collector":
+		Serve(&testCollector{testPlugin: testPlugin{name: "test-collector", pluginType: core.PluginTypeCollector}})
+	case "analyzer":
+		Serve(&testAnalyzer{testPlugin: testPlugin{name: "test-analyzer", pluginType: core.PluginTypeAnalyzer}})
+	case "agent":
+		Serve(&testPlugin{name: "test-agent", pluginType: core.PluginTypeAgent})
+	}
+	os.Exit(0)
+}
+
+// testPlugin is the base of the plugins the test binary serves
+type testPlugin struct {
+	name       string
+	pluginType core.PluginType
+	config     map[string]interface{}
+	status     core.PluginStatus
+}
+
+func (p *testPlugin) Name() string                 { return p.name }
+func (p *testPlugin) Type() core.PluginType        { return p.pluginType }
+func (p *testPlugin) Version() string              { return "0.1.0" }
+func (p *testPlugin) Status() core.PluginStatus    { return p.status }
+func (p *testPlugin) Health(context.Context) error { return nil }
+func (p *testPlugin) GetCapabilities() []string    { return []string{"test"} }
+
+func (p *testPlugin) Configure(config map[string]interface{}) error {
+	if _, ok := config["invalid"]; ok {
+		return fmt.Errorf("invalid setting")
+	}
+	p.config = config
+	return nil
+}
+
+func (p *testPlugin) Start(context.Context) error {
+	p.status = core.PluginStatusRunning
+	return nil
+}
+
+func (p *testPlugin) Stop() error {
+	p.status = core.PluginStatusStopped
+	return nil
+}
+
+type testCollector struct {
+	testPlugin
+}
+
+func (c *testCollector) Collect(context.Context) ([]core.DataPoint, error) {
+	if marker := os.Getenv(testCrashMarkerEnv); marker != "" {
+		if _, err := os.Stat(marker); os.IsNotExist(err) {
+			_ = os.WriteFile(marker, nil, 0o600)
+			os.Exit(2)
+		}
+	}
+	return []core.DataPoint{
+		{Timestamp: time.Now(), Metric: "queue_depth", Value: 42, Labels: map[string]string{"queue": fmt.Sprint(c.config["queue"])}},
+	}, nil
+}
+
+func (c *testCollector) GetCollectionInterval() time.Duration { return 15 * time.Second }
+
+type testAnalyzer struct {
+	testPlugin
+}
+
+func (a *testAnalyzer) Analyze(data []core.DataPoint) (*core.Analysis, error) {
+	if data[0].Value < 0 {
+		return nil, fmt.Errorf("negative value")
+	}
+	if data[0].Value < 100 {
+		return nil, nil
+	}
+	return &core.Analysis{Type: core.AnalysisTypeAlert, Severity: "high", Summary: "too high", DataPoints: data, Source: a.name}, nil
+}
+
+func (a *testAnalyzer) CanAnalyze(data []core.DataPoint) bool {
+	return len(data) > 0
+}
+
+// loadTestPlugin loads the test binary as an external plugin serving the given plugin
+func loadTestPlugin(t *testing.T, kind string, config map[string]interface{}) (core.Plugin, error) {
+	t.Helper()
+	env := map[string]interface{}{testPluginEnv: kind}
+	if extra, ok := config["env"].(map[string]interface{}); ok {
+		for key, value := range extra {
+			env[key] = value
+		}
+	}
+	config["path"] = filepath.Base(os.Args[0])
+	config["env"] = env
+	return Load("ext-"+kind, filepath.Dir(os.Args[0]), config)
+}
+
+func TestExternalPlugin_Collector(t *testing.T) {
+	plugin, err := loadTestPlugin(t, "collector", map[string]interface{}{
+		"config": map[string]interface{}{"queue": "orders"},
+	})
+	require.NoError(t, err)
+	collector, ok := plugin.(core.DataCollector)
+	require.True(t, ok, "Expected the plugin to be loaded as a collector")
+	assert.Equal(t, core.PluginTypeCollector, collector.Type())
+	assert.Equal(t, "0.1.0", collector.Version())
+	assert.Equal(t, 15*time.Second, collector.GetCollectionInterval())
+	assert.Equal(t, core.PluginStatusStopped, collector.Status())
+
+	_, err = collector.Collect(context.Background())
+	assert.Error(t, err, "Expected calls to fail before the plugin is started")
+
+	require.NoError(t, collector.Start(context.Background()))
+	defer collector.Stop()
+	assert.NoError(t, collector.Health(context.Background()))
+
+	points, err := collector.Collect(context.Background())
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+	assert.Equal(t, "queue_depth", points[0].Metric)
+	assert.Equal(t, 42.0, points[0].Value)
+	assert.Equal(t, "orders", points[0].Labels["queue"])
+	assert.Equal(t, "ext-collector", points[0].Source)
+}
+
+func TestExternalPlugin_Analyzer(t *testing.T) {
+	plugin, err := loadTestPlugin(t, "analyzer", map[string]interface{}{})
+	require.NoError(t, err)
+	analyzer, ok := plugin.(core.DataAnalyzer)
+	require.True(t, ok, "Expected the plugin to be loaded as an analyzer")
+	_, isCollector := plugin.(core.DataCollector)
+	assert.False(t, isCollector)
+
+	require.NoError(t, analyzer.Start(context.Background()))
+	defer analyzer.Stop()
+
+	assert.False(t, analyzer.CanAnalyze(nil))
+	data := []core.DataPoint{{Metric: "latency", Value: 250}}
+	assert.True(t, analyzer.CanAnalyze(data))
+
+	analysis, err := analyzer.Analyze(data)
+	require.NoError(t, err)
+	require.NotNil(t, analysis)
+	assert.Equal(t, "too high", analysis.Summary)
+	assert.Equal(t, 250.0, analysis.DataPoints[0].Value)
+
+	analysis, err = analyzer.Analyze([]core.DataPoint{{Metric: "latency", Value: 10}})
+	require.NoError(t, err)
+	assert.Nil(t, analysis)
+
+	_, err = analyzer.Analyze([]core.DataPoint{{Metric: "latency", Value: -1}})
+	assert.EqualError(t, err, "negative value", "Expected the plugin's error to be returned unchanged")
+}
+
+func TestExternalPlugin_RestartsAfterCrash(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "crashed")
+	plugin, err := loadTestPlugin(t, "collector", map[string]interface{}{
+		"env":             map[string]interface{}{testCrashMarkerEnv: marker},
+		"restart_backoff": "0s",
+	})
+	require.NoError(t, err)
+	collector := plugin.(core.DataCollector)
+	require.NoError(t, collector.Start(context.Background()))
+	defer collector.Stop()
+
+	_, err = collector.Collect(context.Background())
+	require.Error(t, err, "Expected the crash to fail the call")
+	assert.FileExists(t, marker)
+	require.Eventually(t, func() bool {
+		err := collector.Health(context.Background())
+		return err != nil && err.Error() == "external plugin process is not running"
+	}, 5*time.Second, 10*time.Millisecond, "Expected the exited process to be reported unhealthy")
+
+	points, err := collector.Collect(context.Background())
+	require.NoError(t, err, "Expected the next call to start the plugin process again")
+	assert.Len(t, points, 1)
+	assert.NoError(t, collector.Health(context.Background()))
+
+	var buf bytes.Buffer
+	plugin.(core.MetricsExporter).WriteMetrics(&buf)
+	assert.Contains(t, buf.String(), `agent_external_plugin_restarts_total{plugin="ext-collector"} 1`)
+}
+
+func TestExternalPlugin_PathOutsideDir(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "tool")
+	require.NoError(t, os.WriteFile(outside, []byte("#!/bin/sh\n"), 0o755))
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "link")))
+
+	tests := []struct {
+		name     string
+		dir      string
+		path     string
+		expected string
+	}{
+		{name: "no directory", path: outside, expected: "external_plugins_dir, which is not set"},
+		{name: "absolute path", dir: dir, path: outside, expected: "outside external_plugins_dir"},
+		{name: "parent directory", dir: dir, path: filepath.Join("..", filepath.Base(filepath.Dir(outside)), "tool"), expected: "outside external_plugins_dir"},
+		{name: "symlink", dir: dir, path: "link", expected: "outside external_plugins_dir"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load("ext", tt.dir, map[string]interface{}{"path": tt.path})
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestExternalPlugin_LoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := Load("ext", dir, map[string]interface{}{})
+	assert.ErrorContains(t, err, "path not specified")
+
+	_, err = Load("ext", dir, map[string]interface{}{"path": filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+
+	_, err = loadTestPlugin(t, "collector", map[string]interface{}{"config": map[string]interface{}{"invalid": true}})
+	assert.ErrorContains(t, err, "invalid setting", "Expected the plugin's config error to be returned")
+
+	_, err = loadTestPlugin(t, "collector", map[string]interface{}{"sha256": fmt.Sprintf("%064x", 0)})
+	assert.Error(t, err, "Expected a binary that does not match its checksum to be refused")
+
+	_, err = loadTestPlugin(t, "agent", map[string]interface{}{})
+	assert.ErrorContains(t, err, "only collectors, analyzers, and responders")
+}
@@ -0,0 +1,149 @@
+// Package external runs collectors, analyzers, and responders as separate binaries that the
+// agent talks to over gRPC using hashicorp/go-plugin. A plugin binary calls Serve with its
+// plugin; the agent loads it with the external plugin type. A plugin that crashes only
+// takes its own process down, and the agent starts it again on its next call.
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	goplugin "github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Handshake is the go-plugin handshake agent plugins are started with. A binary that was
+// not built with Serve refuses to run as a plugin, and one built against another protocol
+// version is rejected.
+var Handshake = goplugin.HandshakeConfig{
+	ProtocolVersion:  1,
+	MagicCookieKey:   "AGENT_PLUGIN",
+	MagicCookieValue: "b8e1a4f0-ops-agent-plugin",
+}
+
+// pluginKey is the name the plugin is dispensed under; each binary serves one plugin
+const pluginKey = "plugin"
+
+// serviceName is the gRPC service a plugin binary serves. Requests and responses are JSON
+// documents carried in BytesValue messages, so the service needs no generated code and the
+// wire types are the core types plugins already use.
+const serviceName = "agent.plugin.v1.Plugin"
+
+// pluginInfo is what a plugin binary reports about the plugin it serves
+type pluginInfo struct {
+	Type               core.PluginType `json:"type"`
+	Version            string          `json:"version"`
+	Capabilities       []string        `json:"capabilities"`
+	CollectionInterval time.Duration   `json:"collection_interval,omitempty"`
+}
+
+// grpcPlugin connects go-plugin to the plugin service. On the plugin side impl is the
+// plugin being served; on the agent side it is unused.
+type grpcPlugin struct {
+	goplugin.NetRPCUnsupportedPlugin
+	impl core.Plugin
+}
+
+// GRPCServer registers the plugin service for the served plugin
+func (p *grpcPlugin) GRPCServer(_ *goplugin.GRPCBroker, server *grpc.Server) error {
+	server.RegisterService(&serviceDesc, &pluginServer{impl: p.impl})
+	return nil
+}
+
+// GRPCClient returns a client of the plugin service on the plugin's connection
+func (p *grpcPlugin) GRPCClient(_ context.Context, _ *goplugin.GRPCBroker, conn *grpc.ClientConn) (interface{}, error) {
+	return &pluginClient{conn: conn}, nil
+}
+
+// pluginClient calls the plugin service of a plugin process
+type pluginClient struct {
+	conn *grpc.ClientConn
+}
+
+// call invokes a method with req encoded as JSON and decodes the reply into resp, which
+// may be nil when the method returns nothing
+func (c *pluginClient) call(ctx context.Context, method string, req, resp interface{}) error {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	var reply wrapperspb.BytesValue
+	if err := c.conn.Invoke(ctx, "/"+serviceName+"/"+method, wrapperspb.Bytes(payload), &reply); err != nil {
+		// Errors the plugin returned keep their message; transport errors keep their status
+		if s, ok := status.FromError(err); ok && s.Code() == codes.Unknown {
+			return &pluginError{message: s.Message()}
+		}
+		return err
+	}
+	if resp == nil || len(reply.Value) == 0 {
+		return nil
+	}
+	return json.Unmarshal(reply.Value, resp)
+}
+
+// pluginError is an error returned by the plugin itself rather than by the transport
+type pluginError struct {
+	message string
+}
+
+func (e *pluginError) Error() string {
+	return e.message
+}
+
+// methodHandler serves one method of the plugin service, given its decoded JSON request
+type methodHandler func(s *pluginServer, ctx context.Context, req []byte) (interface{}, error)
+
+// unaryMethod adapts a methodHandler to a gRPC method of the plugin service
+func unaryMethod(name string, handler methodHandler) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			var req wrapperspb.BytesValue
+			if err := dec(&req); err != nil {
+				return nil, err
+			}
+			call := func(ctx context.Context, req interface{}) (interface{}, error) {
+				resp, err := handler(srv.(*pluginServer), ctx, req.(*wrapperspb.BytesValue).Value)
+				if err != nil {
+					if _, ok := status.FromError(err); !ok {
+						err = status.Error(codes.Unknown, err.Error())
+					}
+					return nil, err
+				}
+				payload, err := json.Marshal(resp)
+				if err != nil {
+					return nil, status.Error(codes.Internal, err.Error())
+				}
+				return wrapperspb.Bytes(payload), nil
+			}
+			if interceptor == nil {
+				return call(ctx, &req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/" + name}
+			return interceptor(ctx, &req, info, call)
+		},
+	}
+}
+
+// serviceDesc describes the plugin service for registration with a gRPC server
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("Info", (*pluginServer).info),
+		unaryMethod("Configure", (*pluginServer).configure),
+		unaryMethod("Start", (*pluginServer).start),
+		unaryMethod("Stop", (*pluginServer).stop),
+		unaryMethod("Health", (*pluginServer).health),
+		unaryMethod("Collect", (*pluginServer).collect),
+		unaryMethod("Analyze", (*pluginServer).analyze),
+		unaryMethod("CanAnalyze", (*pluginServer).canAnalyze),
+		unaryMethod("Respond", (*pluginServer).respond),
+		unaryMethod("CanHandle", (*pluginServer).canHandle),
+	},
+}
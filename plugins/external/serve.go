@@ -0,0 +1,132 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/hashicorp/go-hclog"
+	goplugin "github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Serve runs a collector, analyzer, or responder as a plugin of the agent. It is called
+// from the plugin binary's main and does not return until the agent stops the plugin.
+func Serve(plugin core.Plugin) {
+	goplugin.Serve(&goplugin.ServeConfig{
+		HandshakeConfig: Handshake,
+		Plugins:         goplugin.PluginSet{pluginKey: &grpcPlugin{impl: plugin}},
+		GRPCServer:      goplugin.DefaultGRPCServer,
+		Logger: hclog.New(&hclog.LoggerOptions{
+			Name:       plugin.Name(),
+			Output:     os.Stderr,
+			Level:      hclog.Info,
+			JSONFormat: true,
+		}),
+	})
+}
+
+// pluginServer serves the plugin service for the plugin in this process
+type pluginServer struct {
+	impl core.Plugin
+}
+
+func (s *pluginServer) info(_ context.Context, _ []byte) (interface{}, error) {
+	info := pluginInfo{
+		Type:         s.impl.Type(),
+		Version:      s.impl.Version(),
+		Capabilities: s.impl.GetCapabilities(),
+	}
+	if collector, ok := s.impl.(core.DataCollector); ok {
+		info.CollectionInterval = collector.GetCollectionInterval()
+	}
+	return info, nil
+}
+
+func (s *pluginServer) configure(_ context.Context, req []byte) (interface{}, error) {
+	var config map[string]interface{}
+	if err := json.Unmarshal(req, &config); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if config == nil {
+		config = make(map[string]interface{})
+	}
+	return nil, s.impl.Configure(config)
+}
+
+func (s *pluginServer) start(ctx context.Context, _ []byte) (interface{}, error) {
+	// The plugin keeps the context it starts with, which must outlive this call
+	return nil, s.impl.Start(context.WithoutCancel(ctx))
+}
+
+func (s *pluginServer) stop(_ context.Context, _ []byte) (interface{}, error) {
+	return nil, s.impl.Stop()
+}
+
+func (s *pluginServer) health(ctx context.Context, _ []byte) (interface{}, error) {
+	return nil, s.impl.Health(ctx)
+}
+
+func (s *pluginServer) collect(ctx context.Context, _ []byte) (interface{}, error) {
+	collector, ok := s.impl.(core.DataCollector)
+	if !ok {
+		return nil, unimplemented(s.impl, "collector")
+	}
+	return collector.Collect(ctx)
+}
+
+func (s *pluginServer) analyze(_ context.Context, req []byte) (interface{}, error) {
+	analyzer, ok := s.impl.(core.DataAnalyzer)
+	if !ok {
+		return nil, unimplemented(s.impl, "analyzer")
+	}
+	var data []core.DataPoint
+	if err := json.Unmarshal(req, &data); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return analyzer.Analyze(data)
+}
+
+func (s *pluginServer) canAnalyze(_ context.Context, req []byte) (interface{}, error) {
+	analyzer, ok := s.impl.(core.DataAnalyzer)
+	if !ok {
+		return nil, unimplemented(s.impl, "analyzer")
+	}
+	var data []core.DataPoint
+	if err := json.Unmarshal(req, &data); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return analyzer.CanAnalyze(data), nil
+}
+
+func (s *pluginServer) respond(ctx context.Context, req []byte) (interface{}, error) {
+	responder, ok := s.impl.(core.DataResponder)
+	if !ok {
+		return nil, unimplemented(s.impl, "responder")
+	}
+	var analysis core.Analysis
+	if err := json.Unmarshal(req, &analysis); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil, responder.Respond(ctx, &analysis)
+}
+
+func (s *pluginServer) canHandle(_ context.Context, req []byte) (interface{}, error) {
+	responder, ok := s.impl.(core.DataResponder)
+	if !ok {
+		return nil, unimplemented(s.impl, "responder")
+	}
+	var analysis core.Analysis
+	if err := json.Unmarshal(req, &analysis); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return responder.CanHandle(&analysis), nil
+}
+
+// unimplemented is the error for a call the served plugin's type does not support
+func unimplemented(plugin core.Plugin, role string) error {
+	return status.Error(codes.Unimplemented, fmt.Sprintf("plugin %s is a %s, not a %s", plugin.Name(), plugin.Type(), role))
+}
@@ -0,0 +1,416 @@
+package responders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// Defaults for the archive responder
+const (
+	defaultArchiveMaxSizeMB     = 100
+	defaultArchiveMaxFiles      = 5
+	defaultArchiveFlushInterval = time.Minute
+	defaultArchiveMaxRecords    = 1000
+	// archiveMaxPendingBatches bounds how many batches are held while uploads fail
+	archiveMaxPendingBatches = 10
+)
+
+// archiveRecord is one archived analysis
+type archiveRecord struct {
+	ArchivedAt time.Time `json:"archived_at"`
+	core.Analysis
+}
+
+// ArchiveResponder implements the DataResponder interface for an audit trail of every
+// analysis as JSON Lines, either appended to a size-rotated file or uploaded in batches to
+// S3-compatible object storage
+type ArchiveResponder struct {
+	name              string
+	version           string
+	status            core.PluginStatus
+	includeDataPoints bool
+
+	// File archive
+	path     string
+	maxBytes int64
+	maxFiles int
+	file     *os.File
+	size     int64
+
+	// Object storage archive
+	s3            *s3Client
+	prefix        string
+	flushInterval time.Duration
+	maxRecords    int
+	buffer        bytes.Buffer
+	records       int
+	pending       [][]byte
+	sequence      int
+	cancel        context.CancelFunc
+	done          chan struct{}
+
+	mu sync.Mutex
+}
+
+// NewArchiveResponder creates a new archive responder plugin
+func NewArchiveResponder(name string) *ArchiveResponder {
+	return &ArchiveResponder{
+		name:          name,
+		version:       "1.0.0",
+		status:        core.PluginStatusStopped,
+		maxBytes:      defaultArchiveMaxSizeMB * 1024 * 1024,
+		maxFiles:      defaultArchiveMaxFiles,
+		flushInterval: defaultArchiveFlushInterval,
+		maxRecords:    defaultArchiveMaxRecords,
+	}
+}
+
+// Name returns the name of the plugin
+func (a *ArchiveResponder) Name() string {
+	return a.name
+}
+
+// Type returns the type of plugin
+func (a *ArchiveResponder) Type() core.PluginType {
+	return core.PluginTypeResponder
+}
+
+// Version returns the plugin version
+func (a *ArchiveResponder) Version() string {
+	return a.version
+}
+
+// Configure initializes the plugin with configuration
+func (a *ArchiveResponder) Configure(config map[string]interface{}) error {
+	if include, ok := config["include_data_points"].(bool); ok {
+		a.includeDataPoints = include
+	}
+
+	filePath, _ := config["path"].(string)
+	s3Config, hasS3 := config["s3"].(map[string]interface{})
+	if (filePath == "") == !hasS3 {
+		return fmt.Errorf("archive needs exactly one of path or s3")
+	}
+
+	if filePath != "" {
+		a.path = filePath
+		if maxSize, ok := numberSetting(config["max_size_mb"]); ok {
+			if maxSize <= 0 {
+				return fmt.Errorf("max_size_mb must be positive")
+			}
+			a.maxBytes = int64(maxSize * 1024 * 1024)
+		}
+		if maxFiles, ok := numberSetting(config["max_files"]); ok {
+			if maxFiles < 0 {
+				return fmt.Errorf("max_files must not be negative")
+			}
+			a.maxFiles = int(maxFiles)
+		}
+		return nil
+	}
+
+	client := &s3Client{region: "us-east-1", pathStyle: true, httpClient: &http.Client{Timeout: 30 * time.Second}}
+	for key, target := range map[string]*string{
+		"endpoint":          &client.endpoint,
+		"bucket":            &client.bucket,
+		"access_key_id":     &client.accessKeyID,
+		"secret_access_key": &client.secretAccessKey,
+	} {
+		value, ok := s3Config[key].(string)
+		if !ok || value == "" {
+			return fmt.Errorf("s3 %s not specified", key)
+		}
+		*target = value
+	}
+	if region, ok := s3Config["region"].(string); ok && region != "" {
+		client.region = region
+	}
+	if token, ok := s3Config["session_token"].(string); ok {
+		client.sessionToken = token
+	}
+	if pathStyle, ok := s3Config["path_style"].(bool); ok {
+		client.pathStyle = pathStyle
+	}
+	if _, err := client.objectURL("check"); err != nil {
+		return err
+	}
+	a.s3 = client
+
+	if prefix, ok := s3Config["prefix"].(string); ok {
+		a.prefix = prefix
+	}
+	if interval, ok := s3Config["flush_interval"].(string); ok {
+		parsed, err := time.ParseDuration(interval)
+		if err != nil || parsed <= 0 {
+			return fmt.Errorf("invalid s3 flush_interval %q", interval)
+		}
+		a.flushInterval = parsed
+	}
+	if maxRecords, ok := numberSetting(s3Config["max_records"]); ok {
+		if maxRecords < 1 {
+			return fmt.Errorf("s3 max_records must be at least 1")
+		}
+		a.maxRecords = int(maxRecords)
+	}
+	return nil
+}
+
+// numberSetting reads a number from YAML or JSON config
+func numberSetting(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// Start begins the plugin's operation
+func (a *ArchiveResponder) Start(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.status == core.PluginStatusRunning {
+		return fmt.Errorf("responder is already running")
+	}
+
+	a.status = core.PluginStatusStarting
+	slog.Info("Starting archive responder", "plugin", a.name, "type", a.Type())
+
+	if a.path != "" {
+		if err := a.openFile(); err != nil {
+			a.status = core.PluginStatusError
+			return err
+		}
+	} else {
+		flushCtx, cancel := context.WithCancel(context.Background())
+		a.cancel = cancel
+		a.done = make(chan struct{})
+		go a.flushLoop(flushCtx)
+	}
+
+	a.status = core.PluginStatusRunning
+	slog.Info("Archive responder started", "plugin", a.name, "type", a.Type())
+	return nil
+}
+
+// Stop gracefully stops the plugin, uploading buffered records
+func (a *ArchiveResponder) Stop() error {
+	a.mu.Lock()
+	if a.status != core.PluginStatusRunning {
+		a.mu.Unlock()
+		return fmt.Errorf("responder is not running")
+	}
+	a.status = core.PluginStatusStopping
+	slog.Info("Stopping archive responder", "plugin", a.name, "type", a.Type())
+	cancel, done := a.cancel, a.done
+	a.mu.Unlock()
+
+	var err error
+	if cancel != nil {
+		cancel()
+		<-done
+		a.mu.Lock()
+		err = a.flushLocked(context.Background())
+		a.mu.Unlock()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file != nil {
+		if closeErr := a.file.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		a.file = nil
+	}
+	a.status = core.PluginStatusStopped
+	slog.Info("Archive responder stopped", "plugin", a.name, "type", a.Type())
+	return err
+}
+
+// Status returns the current status of the plugin
+func (a *ArchiveResponder) Status() core.PluginStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.status
+}
+
+// Health checks if the plugin is healthy
+func (a *ArchiveResponder) Health(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.status != core.PluginStatusRunning {
+		return fmt.Errorf("responder is not running")
+	}
+	if len(a.pending) > 0 {
+		return fmt.Errorf("%d archive batches waiting to be uploaded", len(a.pending))
+	}
+	return nil
+}
+
+// GetCapabilities returns what this plugin can do
+func (a *ArchiveResponder) GetCapabilities() []string {
+	return []string{
+		"analysis_archive",
+		"audit_trail",
+		"file_rotation",
+		"object_storage",
+	}
+}
+
+// CanHandle determines if this responder can handle the given analysis
+func (a *ArchiveResponder) CanHandle(analysis *core.Analysis) bool {
+	// Every analysis is archived
+	return true
+}
+
+// ReadOnly reports that a local file archive has no side effects outside the agent, so it
+// keeps recording in read-only mode; uploads to object storage do
+func (a *ArchiveResponder) ReadOnly() bool {
+	return a.s3 == nil
+}
+
+// Respond appends the analysis to the archive
+func (a *ArchiveResponder) Respond(ctx context.Context, analysis *core.Analysis) error {
+	record := archiveRecord{ArchivedAt: time.Now(), Analysis: *analysis}
+	if !a.includeDataPoints {
+		record.DataPoints = nil
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode analysis %s: %w", analysis.ID, err)
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.status != core.PluginStatusRunning {
+		return fmt.Errorf("responder is not running")
+	}
+	if a.s3 != nil {
+		a.buffer.Write(line)
+		a.records++
+		if a.records >= a.maxRecords {
+			return a.flushLocked(ctx)
+		}
+		return nil
+	}
+	return a.writeFile(line)
+}
+
+// openFile opens the archive file for appending
+func (a *ArchiveResponder) openFile() error {
+	file, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open archive %s: %w", a.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat archive %s: %w", a.path, err)
+	}
+	a.file = file
+	a.size = info.Size()
+	return nil
+}
+
+// writeFile appends a line, rotating first if it would exceed the size limit
+func (a *ArchiveResponder) writeFile(line []byte) error {
+	if a.size > 0 && a.size+int64(len(line)) > a.maxBytes {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := a.file.Write(line)
+	a.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write archive %s: %w", a.path, err)
+	}
+	return nil
+}
+
+// rotate renames archive.jsonl to archive.jsonl.1, shifting older files up and dropping
+// the oldest, then starts a new file
+func (a *ArchiveResponder) rotate() error {
+	if err := a.file.Close(); err != nil {
+		return fmt.Errorf("failed to close archive %s: %w", a.path, err)
+	}
+	a.file = nil
+
+	if a.maxFiles == 0 {
+		if err := os.Remove(a.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove archive %s: %w", a.path, err)
+		}
+	} else {
+		os.Remove(fmt.Sprintf("%s.%d", a.path, a.maxFiles))
+		for i := a.maxFiles - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", a.path, i), fmt.Sprintf("%s.%d", a.path, i+1))
+		}
+		if err := os.Rename(a.path, a.path+".1"); err != nil {
+			return fmt.Errorf("failed to rotate archive %s: %w", a.path, err)
+		}
+	}
+	slog.Info("Archive rotated", "plugin", a.name, "path", a.path)
+	return a.openFile()
+}
+
+// flushLoop uploads buffered records every flush interval
+func (a *ArchiveResponder) flushLoop(ctx context.Context) {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.mu.Lock()
+			if err := a.flushLocked(ctx); err != nil {
+				slog.Error("Failed to upload archive", "plugin", a.name, "error", err)
+			}
+			a.mu.Unlock()
+		}
+	}
+}
+
+// flushLocked uploads the buffered records as one object, along with batches whose
+// upload failed earlier. Callers hold the lock.
+func (a *ArchiveResponder) flushLocked(ctx context.Context) error {
+	if a.buffer.Len() > 0 {
+		a.pending = append(a.pending, append([]byte(nil), a.buffer.Bytes()...))
+		a.buffer.Reset()
+		a.records = 0
+	}
+	if len(a.pending) > archiveMaxPendingBatches {
+		dropped := len(a.pending) - archiveMaxPendingBatches
+		slog.Error("Dropping archive batches that could not be uploaded", "plugin", a.name, "batches", dropped)
+		a.pending = a.pending[dropped:]
+	}
+
+	for len(a.pending) > 0 {
+		a.sequence++
+		now := time.Now().UTC()
+		key := path.Join(a.prefix, now.Format("2006/01/02"),
+			fmt.Sprintf("%s-%s-%04d.jsonl", a.name, now.Format("150405"), a.sequence))
+		if err := a.s3.PutObject(ctx, key, "application/x-ndjson", a.pending[0]); err != nil {
+			return err
+		}
+		a.pending = a.pending[1:]
+	}
+	return nil
+}
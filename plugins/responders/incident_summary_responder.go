@@ -0,0 +1,610 @@
+package responders
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+const (
+	// incidentSummaryQueueSize is how many analyses can wait to be summarized
+	incidentSummaryQueueSize = 16
+	// incidentSummaryEvents is how many recent framework events are kept for summaries
+	incidentSummaryEvents = 50
+	// incidentSummaryLines caps each section of the prompt
+	incidentSummaryLines = 20
+)
+
+// incidentSummarySettings are the settings a summary is written with
+type incidentSummarySettings struct {
+	enabled     bool
+	agent       string
+	responders  []string
+	minSeverity string
+	window      time.Duration
+}
+
+// IncidentSummaryResponder implements the DataResponder interface by having an agent write
+// up severe analyses. For each one it gathers the metrics around the analysis, the other
+// analyses and the framework events in the same window, asks an agent for an incident
+// summary, and posts the summary through the configured responders.
+type IncidentSummaryResponder struct {
+	name     string
+	version  string
+	status   core.PluginStatus
+	settings incidentSummarySettings
+	maxRate  int
+	per      time.Duration
+	limiter  core.RateLimiter
+	provider core.IncidentSummaryProvider
+	bus      core.EventBus
+	// subscription keeps recent events from the bus while the plugin runs
+	subscription core.SubscriptionID
+	queue        chan *core.Analysis
+	cancel       context.CancelFunc
+	done         chan struct{}
+
+	events     []core.Event
+	summarized int64
+	limited    int64
+	failed     int64
+	eventsMu   sync.Mutex
+	mu         sync.RWMutex
+}
+
+// NewIncidentSummaryResponder creates a new incident summary responder plugin
+func NewIncidentSummaryResponder(name string) *IncidentSummaryResponder {
+	return &IncidentSummaryResponder{
+		name:    name,
+		version: "1.0.0",
+		status:  core.PluginStatusStopped,
+		settings: incidentSummarySettings{
+			enabled:     true,
+			minSeverity: "high",
+			window:      30 * time.Minute,
+		},
+		maxRate: 6,
+		per:     time.Hour,
+	}
+}
+
+// Name returns the name of the plugin
+func (s *IncidentSummaryResponder) Name() string {
+	return s.name
+}
+
+// Type returns the type of plugin
+func (s *IncidentSummaryResponder) Type() core.PluginType {
+	return core.PluginTypeResponder
+}
+
+// Version returns the plugin version
+func (s *IncidentSummaryResponder) Version() string {
+	return s.version
+}
+
+// Configure initializes the plugin with configuration
+func (s *IncidentSummaryResponder) Configure(config map[string]interface{}) error {
+	settings := s.settings
+	list, ok := config["responders"].([]interface{})
+	if !ok || len(list) == 0 {
+		return fmt.Errorf("incident summary responders not specified")
+	}
+	settings.responders = nil
+	for _, item := range list {
+		name, ok := item.(string)
+		if !ok || name == "" {
+			return fmt.Errorf("incident summary responders must be non-empty strings")
+		}
+		if name == s.name {
+			return fmt.Errorf("incident summary responder cannot post through itself")
+		}
+		settings.responders = append(settings.responders, name)
+	}
+	if agent, ok := config["agent"].(string); ok {
+		settings.agent = agent
+	}
+	if err := applyIncidentSummarySettings(&settings, config); err != nil {
+		return err
+	}
+
+	maxRate, per := s.maxRate, s.per
+	if section, ok := config["rate_limit"].(map[string]interface{}); ok {
+		switch value := section["max"].(type) {
+		case nil:
+		case int:
+			maxRate = value
+		case float64:
+			maxRate = int(value)
+		default:
+			return fmt.Errorf("rate_limit.max must be a number")
+		}
+		if maxRate <= 0 {
+			return fmt.Errorf("rate_limit.max must be positive, got %d", maxRate)
+		}
+		if perStr, ok := section["per"].(string); ok {
+			parsed, err := time.ParseDuration(perStr)
+			if err != nil {
+				return fmt.Errorf("invalid rate_limit.per %q: %w", perStr, err)
+			}
+			if parsed <= 0 {
+				return fmt.Errorf("rate_limit.per must be positive, got %v", parsed)
+			}
+			per = parsed
+		}
+	} else if config["rate_limit"] != nil {
+		return fmt.Errorf("rate_limit must be a map")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = settings
+	s.maxRate, s.per = maxRate, per
+	s.limiter = core.NewTokenBucketRateLimiter(float64(maxRate)/per.Seconds(), maxRate)
+	return nil
+}
+
+// applyIncidentSummarySettings reads the settings that can also change while running
+func applyIncidentSummarySettings(settings *incidentSummarySettings, config map[string]interface{}) error {
+	if value, ok := config["enabled"]; ok {
+		enabled, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("enabled must be true or false, got %v", value)
+		}
+		settings.enabled = enabled
+	}
+	if minSeverity, ok := config["min_severity"].(string); ok {
+		if _, known := severityRank[minSeverity]; !known {
+			return fmt.Errorf("unknown min_severity %q", minSeverity)
+		}
+		settings.minSeverity = minSeverity
+	}
+	if windowStr, ok := config["window"].(string); ok {
+		window, err := time.ParseDuration(windowStr)
+		if err != nil {
+			return fmt.Errorf("invalid window %q: %w", windowStr, err)
+		}
+		if window <= 0 {
+			return fmt.Errorf("window must be positive, got %v", window)
+		}
+		settings.window = window
+	}
+	return nil
+}
+
+// Reconfigure turns summaries on or off and changes their minimum severity or window
+// without a restart
+func (s *IncidentSummaryResponder) Reconfigure(ctx context.Context, config map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings := s.settings
+	for key := range config {
+		switch key {
+		case "enabled", "min_severity", "window":
+		default:
+			return fmt.Errorf("%s cannot be changed without a restart", key)
+		}
+	}
+	if err := applyIncidentSummarySettings(&settings, config); err != nil {
+		return err
+	}
+	s.settings = settings
+	return nil
+}
+
+// SetIncidentSummaryProvider provides the history, agents and responders summaries use
+func (s *IncidentSummaryResponder) SetIncidentSummaryProvider(provider core.IncidentSummaryProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.provider = provider
+}
+
+// SetEventBus provides the framework events that go into summaries
+func (s *IncidentSummaryResponder) SetEventBus(bus core.EventBus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bus = bus
+}
+
+// Start begins the plugin's operation
+func (s *IncidentSummaryResponder) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status == core.PluginStatusRunning {
+		return fmt.Errorf("responder is already running")
+	}
+	if s.provider == nil {
+		return fmt.Errorf("no incident summary provider set")
+	}
+	if s.limiter == nil {
+		return fmt.Errorf("incident summary responder is not configured")
+	}
+
+	s.status = core.PluginStatusStarting
+	slog.Info("Starting incident summary responder", "plugin", s.name, "type", s.Type(), "responders", s.settings.responders)
+
+	if s.bus != nil {
+		subscription, err := s.bus.Subscribe(core.EventTypeAll, s.recordEvent)
+		if err != nil {
+			s.status = core.PluginStatusError
+			return fmt.Errorf("failed to subscribe to events: %w", err)
+		}
+		s.subscription = subscription
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+	s.queue = make(chan *core.Analysis, incidentSummaryQueueSize)
+	s.done = make(chan struct{})
+	go s.run(runCtx, s.queue, s.done)
+
+	s.status = core.PluginStatusRunning
+	slog.Info("Incident summary responder started", "plugin", s.name, "type", s.Type())
+	return nil
+}
+
+// Stop gracefully stops the plugin
+func (s *IncidentSummaryResponder) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status != core.PluginStatusRunning {
+		return fmt.Errorf("responder is not running")
+	}
+
+	s.status = core.PluginStatusStopping
+	slog.Info("Stopping incident summary responder", "plugin", s.name, "type", s.Type())
+
+	// The summary in progress needs the lock to finish
+	cancel, done := s.cancel, s.done
+	s.mu.Unlock()
+	cancel()
+	<-done
+	s.mu.Lock()
+
+	if s.bus != nil {
+		if err := s.bus.Unsubscribe(s.subscription); err != nil {
+			slog.Warn("Failed to unsubscribe from events", "plugin", s.name, "error", err)
+		}
+	}
+
+	s.status = core.PluginStatusStopped
+	slog.Info("Incident summary responder stopped", "plugin", s.name, "type", s.Type())
+	return nil
+}
+
+// Status returns the current status of the plugin
+func (s *IncidentSummaryResponder) Status() core.PluginStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// Health checks if the plugin is healthy
+func (s *IncidentSummaryResponder) Health(ctx context.Context) error {
+	if s.Status() == core.PluginStatusRunning {
+		return nil
+	}
+	return fmt.Errorf("responder is not running")
+}
+
+// GetCapabilities returns what this plugin can do
+func (s *IncidentSummaryResponder) GetCapabilities() []string {
+	return []string{
+		"incident_summary",
+		"llm",
+	}
+}
+
+// StatusDetails reports how many analyses were summarized, rate limited, or failed
+func (s *IncidentSummaryResponder) StatusDetails() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return map[string]interface{}{
+		"enabled":      s.settings.enabled,
+		"summarized":   s.summarized,
+		"rate_limited": s.limited,
+		"failed":       s.failed,
+	}
+}
+
+// Respond queues the analysis to be summarized, so a slow agent never holds up delivery.
+// Analyses arriving faster than the rate limit allows are not summarized.
+func (s *IncidentSummaryResponder) Respond(ctx context.Context, analysis *core.Analysis) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status != core.PluginStatusRunning {
+		return fmt.Errorf("responder is not running")
+	}
+	if !s.wants(analysis) {
+		return nil
+	}
+	if !s.limiter.Allow() {
+		s.limited++
+		slog.Warn("Incident summary rate limited", "plugin", s.name, "analysis", analysis.ID,
+			"limit", fmt.Sprintf("%d per %s", s.maxRate, s.per))
+		return nil
+	}
+
+	select {
+	case s.queue <- analysis:
+		return nil
+	default:
+		s.failed++
+		return fmt.Errorf("incident summary queue is full, dropping analysis %s", analysis.ID)
+	}
+}
+
+// CanHandle determines if this responder can handle the given analysis
+func (s *IncidentSummaryResponder) CanHandle(analysis *core.Analysis) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.wants(analysis)
+}
+
+// wants reports whether an analysis should be summarized. Summaries are never summarized
+// again, nor are resolve notifications. The caller holds the lock.
+func (s *IncidentSummaryResponder) wants(analysis *core.Analysis) bool {
+	if !s.settings.enabled || analysis.Resolved || analysis.Type == core.AnalysisTypeIncidentSummary {
+		return false
+	}
+	rank, known := severityRank[analysis.Severity]
+	return known && rank >= severityRank[s.settings.minSeverity]
+}
+
+// recordEvent keeps the most recent framework events for summaries. Created analyses are
+// left out since summaries read them from the history.
+func (s *IncidentSummaryResponder) recordEvent(event core.Event) error {
+	if event.Type == core.EventAnalysisCreated {
+		return nil
+	}
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	s.events = append(s.events, event)
+	if len(s.events) > incidentSummaryEvents {
+		s.events = s.events[len(s.events)-incidentSummaryEvents:]
+	}
+	return nil
+}
+
+// recentEvents returns the kept events between start and end
+func (s *IncidentSummaryResponder) recentEvents(start, end time.Time) []core.Event {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	var events []core.Event
+	for _, event := range s.events {
+		if !event.Timestamp.Before(start) && !event.Timestamp.After(end) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// run summarizes queued analyses one at a time until the context is done
+func (s *IncidentSummaryResponder) run(ctx context.Context, queue chan *core.Analysis, done chan struct{}) {
+	defer close(done)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case analysis := <-queue:
+			err := s.summarize(ctx, analysis)
+			s.mu.Lock()
+			if err != nil {
+				s.failed++
+			} else {
+				s.summarized++
+			}
+			s.mu.Unlock()
+			if err != nil {
+				slog.Error("Failed to summarize incident", "plugin", s.name, "analysis", analysis.ID, "error", err)
+			}
+		}
+	}
+}
+
+// summarize asks an agent to write up the analysis and posts the summary
+func (s *IncidentSummaryResponder) summarize(ctx context.Context, analysis *core.Analysis) error {
+	s.mu.RLock()
+	provider, settings := s.provider, s.settings
+	s.mu.RUnlock()
+
+	agent := settings.agent
+	if agent == "" {
+		agent = provider.DefaultAgent()
+	}
+	if agent == "" {
+		return fmt.Errorf("no agent configured and no default agent")
+	}
+
+	prompt := s.prompt(ctx, provider, analysis, settings.window)
+	response, err := provider.QueryAgent(ctx, agent, prompt)
+	if err != nil {
+		return fmt.Errorf("agent %s failed: %w", agent, err)
+	}
+	if exceeded, _ := response.Metadata["budget_exceeded"].(bool); exceeded {
+		return fmt.Errorf("agent %s is over its budget", agent)
+	}
+	text := strings.TrimSpace(response.Response)
+	if text == "" {
+		return fmt.Errorf("agent %s returned an empty summary", agent)
+	}
+
+	summary := &core.Analysis{
+		Type:       core.AnalysisTypeIncidentSummary,
+		Confidence: analysis.Confidence,
+		Severity:   analysis.Severity,
+		Summary:    text,
+		Details: map[string]interface{}{
+			"analysis_id":      analysis.ID,
+			"analysis_summary": analysis.Summary,
+			"agent":            agent,
+		},
+		DataPoints: analysis.DataPoints,
+		Timestamp:  time.Now(),
+		Source:     s.name,
+		Provenance: []core.AnalysisRef{{
+			ID:          analysis.ID,
+			Fingerprint: analysis.Fingerprint,
+			Source:      analysis.Source,
+			Type:        analysis.Type,
+			Severity:    analysis.Severity,
+			Summary:     analysis.Summary,
+		}},
+	}
+	return provider.PostAnalysis(ctx, summary, settings.responders)
+}
+
+// prompt describes the analysis and what surrounded it. Context that cannot be read is
+// left out rather than failing the summary.
+func (s *IncidentSummaryResponder) prompt(ctx context.Context, provider core.IncidentSummaryProvider, analysis *core.Analysis, window time.Duration) string {
+	// The window runs from before the analysis until now, which takes in what followed it
+	end := time.Now()
+	at := analysis.Timestamp
+	if at.IsZero() {
+		at = end
+	}
+	start := at.Add(-window)
+
+	var b strings.Builder
+	b.WriteString("Write a short incident summary for the on-call engineer: what is happening, the likely cause, ")
+	b.WriteString("what it affects, and what to check first. Use only the context below.\n\n")
+	fmt.Fprintf(&b, "Analysis: [%s] %s (from %s at %s)\n", analysis.Severity, analysis.Summary, analysis.Source, at.Format(time.RFC3339))
+
+	if lines := s.metricLines(ctx, provider, analysis, start, end); len(lines) > 0 {
+		fmt.Fprintf(&b, "\nMetrics from %s before the analysis until now:\n", window)
+		writeLines(&b, lines)
+	}
+
+	analyses, err := provider.QueryAnalyses(ctx, core.HistoryQuery{Start: start, End: end, Limit: incidentSummaryLines + 1})
+	if err != nil {
+		slog.Warn("Failed to read recent analyses for incident summary", "plugin", s.name, "error", err)
+	}
+	var lines []string
+	for _, other := range analyses {
+		if other.ID == analysis.ID || other.Type == core.AnalysisTypeIncidentSummary {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s [%s] %s: %s", other.Timestamp.Format(time.RFC3339), other.Severity, other.Source, other.Summary))
+	}
+	if len(lines) > 0 {
+		b.WriteString("\nOther analyses in the window:\n")
+		writeLines(&b, lines)
+	}
+
+	lines = nil
+	for _, event := range s.recentEvents(start, end) {
+		line := fmt.Sprintf("%s %s", event.Timestamp.Format(time.RFC3339), event.Type)
+		if len(event.Data) > 0 {
+			line += " " + formatEventData(event.Data)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) > 0 {
+		b.WriteString("\nFramework events in the window:\n")
+		writeLines(&b, lines)
+	}
+	return b.String()
+}
+
+// metricLines describes each series of the analysis' metrics over the window
+func (s *IncidentSummaryResponder) metricLines(ctx context.Context, provider core.IncidentSummaryProvider, analysis *core.Analysis, start, end time.Time) []string {
+	type seriesStats struct {
+		count            int
+		min, max, latest float64
+		latestAt         time.Time
+	}
+	series := make(map[string]*seriesStats)
+	queried := make(map[string]bool)
+	for _, point := range analysis.DataPoints {
+		if queried[point.Metric] {
+			continue
+		}
+		queried[point.Metric] = true
+
+		points, err := provider.QueryDataPoints(ctx, core.HistoryQuery{Start: start, End: end, Metric: point.Metric})
+		if err != nil {
+			slog.Warn("Failed to read metric history for incident summary", "plugin", s.name, "metric", point.Metric, "error", err)
+			continue
+		}
+		for _, p := range points {
+			key := seriesKey(p)
+			stats, ok := series[key]
+			if !ok {
+				stats = &seriesStats{min: math.Inf(1), max: math.Inf(-1)}
+				series[key] = stats
+			}
+			stats.count++
+			stats.min = math.Min(stats.min, p.Value)
+			stats.max = math.Max(stats.max, p.Value)
+			if !p.Timestamp.Before(stats.latestAt) {
+				stats.latest, stats.latestAt = p.Value, p.Timestamp
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		stats := series[key]
+		lines = append(lines, fmt.Sprintf("%s: %d points, min %g, max %g, latest %g", key, stats.count, stats.min, stats.max, stats.latest))
+	}
+	return lines
+}
+
+// seriesKey renders a series as metric{k="v",...} with sorted labels
+func seriesKey(point core.DataPoint) string {
+	if len(point.Labels) == 0 {
+		return point.Metric
+	}
+	keys := make([]string, 0, len(point.Labels))
+	for k := range point.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, point.Labels[k]))
+	}
+	return point.Metric + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatEventData renders event data as sorted key=value pairs
+func formatEventData(data map[string]interface{}) string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", k, data[k]))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// writeLines writes a prompt section as a list, keeping the most recent lines when there
+// are too many
+func writeLines(b *strings.Builder, lines []string) {
+	if len(lines) > incidentSummaryLines {
+		fmt.Fprintf(b, "- (%d earlier lines left out)\n", len(lines)-incidentSummaryLines)
+		lines = lines[len(lines)-incidentSummaryLines:]
+	}
+	for _, line := range lines {
+		fmt.Fprintf(b, "- %s\n", line)
+	}
+}
@@ -0,0 +1,188 @@
+package responders
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSummaryProvider answers with a fixed summary and records what was asked and posted
+type fakeSummaryProvider struct {
+	points   []core.DataPoint
+	analyses []core.Analysis
+	answer   string
+	agentErr error
+
+	prompts []string
+	agents  []string
+	posted  []*core.Analysis
+	targets [][]string
+	mu      sync.Mutex
+}
+
+func (f *fakeSummaryProvider) QueryDataPoints(ctx context.Context, query core.HistoryQuery) ([]core.DataPoint, error) {
+	var points []core.DataPoint
+	for _, point := range f.points {
+		if point.Metric == query.Metric && !point.Timestamp.Before(query.Start) && !point.Timestamp.After(query.End) {
+			points = append(points, point)
+		}
+	}
+	return points, nil
+}
+
+func (f *fakeSummaryProvider) QueryAnalyses(ctx context.Context, query core.HistoryQuery) ([]core.Analysis, error) {
+	return f.analyses, nil
+}
+
+func (f *fakeSummaryProvider) QueryCollector(ctx context.Context, collector, query string) ([]core.DataPoint, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (f *fakeSummaryProvider) PluginStates(ctx context.Context) []core.PluginState {
+	return nil
+}
+
+func (f *fakeSummaryProvider) QueryAgent(ctx context.Context, agentName, query string) (*core.AgentResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.prompts = append(f.prompts, query)
+	f.agents = append(f.agents, agentName)
+	if f.agentErr != nil {
+		return nil, f.agentErr
+	}
+	return &core.AgentResponse{Response: f.answer}, nil
+}
+
+func (f *fakeSummaryProvider) DefaultAgent() string {
+	return "default-ai"
+}
+
+func (f *fakeSummaryProvider) PostAnalysis(ctx context.Context, analysis *core.Analysis, responders []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.posted = append(f.posted, analysis)
+	f.targets = append(f.targets, responders)
+	return nil
+}
+
+func (f *fakeSummaryProvider) postedCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.posted)
+}
+
+func TestIncidentSummaryResponder_Summarizes(t *testing.T) {
+	now := time.Now()
+	provider := &fakeSummaryProvider{
+		answer: "  Checkout CPU is saturated after the 14:00 deploy.  ",
+		points: []core.DataPoint{
+			{Metric: "cpu_usage", Value: 40, Labels: map[string]string{"service": "checkout"}, Timestamp: now.Add(-20 * time.Minute)},
+			{Metric: "cpu_usage", Value: 97, Labels: map[string]string{"service": "checkout"}, Timestamp: now.Add(-time.Minute)},
+			{Metric: "cpu_usage", Value: 99, Labels: map[string]string{"service": "checkout"}, Timestamp: now.Add(-2 * time.Hour)},
+		},
+		analyses: []core.Analysis{
+			{ID: "an-2", Severity: "medium", Source: "trend", Summary: "latency rising", Timestamp: now.Add(-5 * time.Minute)},
+			{ID: "an-1", Severity: "critical", Source: "anomaly", Summary: "cpu spike", Timestamp: now},
+		},
+	}
+	bus := core.NewInProcessEventBus(10)
+	defer bus.Close()
+
+	responder := NewIncidentSummaryResponder("incident-summary")
+	require.NoError(t, responder.Configure(map[string]interface{}{"responders": []interface{}{"slack", "jira"}}))
+	responder.SetIncidentSummaryProvider(provider)
+	responder.SetEventBus(bus)
+	require.NoError(t, responder.Start(context.Background()))
+	defer responder.Stop()
+
+	require.NoError(t, bus.Publish(core.Event{Type: core.EventConfigReloaded, Data: map[string]interface{}{"changed": "thresholds"}}))
+	require.Eventually(t, func() bool { return len(responder.recentEvents(now.Add(-time.Minute), time.Now())) == 1 },
+		time.Second, 10*time.Millisecond)
+
+	analysis := &core.Analysis{
+		ID: "an-1", Fingerprint: "fp-1", Type: core.AnalysisTypeAnomaly, Severity: "critical", Source: "anomaly",
+		Summary: "cpu spike", Timestamp: now,
+		DataPoints: []core.DataPoint{{Metric: "cpu_usage", Value: 97, Labels: map[string]string{"service": "checkout"}}},
+	}
+	assert.False(t, responder.CanHandle(&core.Analysis{Severity: "medium"}), "Expected analyses below high not to be summarized")
+	require.True(t, responder.CanHandle(analysis))
+	require.NoError(t, responder.Respond(context.Background(), analysis))
+	require.Eventually(t, func() bool { return provider.postedCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	assert.Equal(t, []string{"default-ai"}, provider.agents, "Expected the default agent without an agent configured")
+	prompt := provider.prompts[0]
+	assert.Contains(t, prompt, "[critical] cpu spike")
+	assert.Contains(t, prompt, `cpu_usage{service="checkout"}: 2 points, min 40, max 97, latest 97`, "Expected only points in the window")
+	assert.Contains(t, prompt, "[medium] trend: latency rising")
+	assert.NotContains(t, prompt, "anomaly: cpu spike", "Expected the analysis itself not to be listed again")
+	assert.Contains(t, prompt, "config_reloaded changed=thresholds")
+
+	summary := provider.posted[0]
+	assert.Equal(t, []string{"slack", "jira"}, provider.targets[0])
+	assert.Equal(t, core.AnalysisTypeIncidentSummary, summary.Type)
+	assert.Equal(t, "Checkout CPU is saturated after the 14:00 deploy.", summary.Summary)
+	assert.Equal(t, "critical", summary.Severity)
+	assert.Equal(t, "an-1", summary.Details["analysis_id"])
+	require.Len(t, summary.Provenance, 1)
+	assert.Equal(t, "fp-1", summary.Provenance[0].Fingerprint)
+	assert.False(t, responder.CanHandle(summary), "Expected summaries not to be summarized again")
+}
+
+func TestIncidentSummaryResponder_RateLimitAndToggle(t *testing.T) {
+	provider := &fakeSummaryProvider{answer: "summary"}
+	responder := NewIncidentSummaryResponder("incident-summary")
+	require.NoError(t, responder.Configure(map[string]interface{}{
+		"responders": []interface{}{"slack"},
+		"agent":      "ops-ai",
+		"rate_limit": map[string]interface{}{"max": 1, "per": "1h"},
+	}))
+	responder.SetIncidentSummaryProvider(provider)
+	ctx := context.Background()
+	require.NoError(t, responder.Start(ctx))
+	defer responder.Stop()
+
+	high := &core.Analysis{ID: "an-1", Severity: "high", Summary: "disk full"}
+	require.NoError(t, responder.Respond(ctx, high))
+	require.NoError(t, responder.Respond(ctx, &core.Analysis{ID: "an-2", Severity: "high"}))
+	require.Eventually(t, func() bool { return provider.postedCount() == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"ops-ai"}, provider.agents)
+	assert.Equal(t, int64(1), responder.StatusDetails()["rate_limited"])
+
+	require.NoError(t, responder.Reconfigure(ctx, map[string]interface{}{"enabled": false}))
+	assert.False(t, responder.CanHandle(high), "Expected no summaries while disabled")
+	assert.Error(t, responder.Reconfigure(ctx, map[string]interface{}{"responders": []interface{}{"jira"}}))
+}
+
+func TestIncidentSummaryResponder_AgentFailure(t *testing.T) {
+	provider := &fakeSummaryProvider{agentErr: fmt.Errorf("model unavailable")}
+	responder := NewIncidentSummaryResponder("incident-summary")
+	require.NoError(t, responder.Configure(map[string]interface{}{"responders": []interface{}{"slack"}}))
+	responder.SetIncidentSummaryProvider(provider)
+	ctx := context.Background()
+	require.NoError(t, responder.Start(ctx))
+	defer responder.Stop()
+
+	require.NoError(t, responder.Respond(ctx, &core.Analysis{ID: "an-1", Severity: "critical"}))
+	require.Eventually(t, func() bool { return responder.StatusDetails()["failed"] == int64(1) }, time.Second, 10*time.Millisecond)
+	assert.Zero(t, provider.postedCount(), "Expected nothing posted when the agent fails")
+}
+
+func TestIncidentSummaryResponder_Configure(t *testing.T) {
+	responder := NewIncidentSummaryResponder("incident-summary")
+	assert.Error(t, responder.Configure(map[string]interface{}{}), "Expected responders to be required")
+	assert.Error(t, responder.Configure(map[string]interface{}{"responders": []interface{}{"incident-summary"}}),
+		"Expected the responder not to post through itself")
+	assert.Error(t, responder.Configure(map[string]interface{}{"responders": []interface{}{"slack"}, "min_severity": "urgent"}))
+	assert.Error(t, responder.Configure(map[string]interface{}{"responders": []interface{}{"slack"}, "window": "-5m"}))
+	assert.Error(t, responder.Configure(map[string]interface{}{"responders": []interface{}{"slack"}, "rate_limit": map[string]interface{}{"max": 0}}))
+	assert.Error(t, responder.Configure(map[string]interface{}{"responders": []interface{}{"slack"}, "enabled": "yes"}))
+	assert.Error(t, responder.Start(context.Background()), "Expected start to need a provider")
+}
@@ -0,0 +1,370 @@
+package responders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// jiraMaxSummary is the longest issue summary Jira accepts
+const jiraMaxSummary = 255
+
+// jiraFingerprintLabelPrefix prefixes the label that ties an issue to an analysis fingerprint
+const jiraFingerprintLabelPrefix = "agent-fp-"
+
+// defaultJiraPriorityMap maps analysis severities to the default Jira priority names
+var defaultJiraPriorityMap = map[string]string{
+	"critical": "Highest",
+	"high":     "High",
+	"medium":   "Medium",
+	"low":      "Low",
+}
+
+// JiraResponder implements the DataResponder interface for Jira issues. Issues are
+// labelled with the analysis fingerprint; while one is open, repeats comment on it instead
+// of opening another. Every analysis adds its data points as a comment.
+type JiraResponder struct {
+	name        string
+	version     string
+	status      core.PluginStatus
+	baseURL     string
+	email       string
+	apiToken    string
+	project     string
+	issueType   string
+	labels      []string
+	minSeverity string
+	priorityMap map[string]string
+	metadata    *core.MetricMetadataRegistry
+	httpClient  *http.Client
+	mu          sync.RWMutex
+}
+
+// NewJiraResponder creates a new Jira responder plugin
+func NewJiraResponder(name string) *JiraResponder {
+	priorityMap := make(map[string]string, len(defaultJiraPriorityMap))
+	for severity, priority := range defaultJiraPriorityMap {
+		priorityMap[severity] = priority
+	}
+	return &JiraResponder{
+		name:        name,
+		version:     "1.0.0",
+		status:      core.PluginStatusStopped,
+		issueType:   "Task",
+		minSeverity: "high",
+		priorityMap: priorityMap,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name returns the name of the plugin
+func (j *JiraResponder) Name() string {
+	return j.name
+}
+
+// Type returns the type of plugin
+func (j *JiraResponder) Type() core.PluginType {
+	return core.PluginTypeResponder
+}
+
+// Version returns the plugin version
+func (j *JiraResponder) Version() string {
+	return j.version
+}
+
+// Configure initializes the plugin with configuration. Jira Cloud authenticates with
+// email and api_token; Jira Data Center with a personal access token in api_token alone.
+func (j *JiraResponder) Configure(config map[string]interface{}) error {
+	baseURL, ok := config["url"].(string)
+	if !ok || baseURL == "" {
+		return fmt.Errorf("jira url not specified")
+	}
+	j.baseURL = strings.TrimSuffix(baseURL, "/")
+
+	project, ok := config["project"].(string)
+	if !ok || project == "" {
+		return fmt.Errorf("jira project not specified")
+	}
+	j.project = project
+
+	apiToken, ok := config["api_token"].(string)
+	if !ok || apiToken == "" {
+		return fmt.Errorf("jira api_token not specified")
+	}
+	j.apiToken = apiToken
+	if email, ok := config["email"].(string); ok {
+		j.email = email
+	}
+
+	if issueType, ok := config["issue_type"].(string); ok && issueType != "" {
+		j.issueType = issueType
+	}
+	if labels, ok := config["labels"].([]interface{}); ok {
+		for _, label := range labels {
+			text, ok := label.(string)
+			if !ok || text == "" || strings.ContainsAny(text, " \t") {
+				return fmt.Errorf("jira labels must be non-empty strings without spaces")
+			}
+			j.labels = append(j.labels, text)
+		}
+	}
+
+	if minSeverity, ok := config["min_severity"].(string); ok {
+		if _, known := severityRank[minSeverity]; !known {
+			return fmt.Errorf("unknown min_severity %q", minSeverity)
+		}
+		j.minSeverity = minSeverity
+	}
+
+	if priorityMap, ok := config["priority_map"].(map[string]interface{}); ok {
+		for severity, value := range priorityMap {
+			if _, known := severityRank[severity]; !known {
+				return fmt.Errorf("unknown severity %q in priority_map", severity)
+			}
+			priority, ok := value.(string)
+			if !ok || priority == "" {
+				return fmt.Errorf("priority_map %s must be a Jira priority name", severity)
+			}
+			j.priorityMap[severity] = priority
+		}
+	}
+
+	return nil
+}
+
+// Start begins the plugin's operation
+func (j *JiraResponder) Start(ctx context.Context) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.status == core.PluginStatusRunning {
+		return fmt.Errorf("responder is already running")
+	}
+
+	j.status = core.PluginStatusStarting
+	slog.Info("Starting Jira responder", "plugin", j.name, "type", j.Type())
+
+	j.status = core.PluginStatusRunning
+	slog.Info("Jira responder started", "plugin", j.name, "type", j.Type(), "project", j.project)
+	return nil
+}
+
+// Stop gracefully stops the plugin
+func (j *JiraResponder) Stop() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.status != core.PluginStatusRunning {
+		return fmt.Errorf("responder is not running")
+	}
+
+	j.status = core.PluginStatusStopping
+	slog.Info("Stopping Jira responder", "plugin", j.name, "type", j.Type())
+
+	j.status = core.PluginStatusStopped
+	slog.Info("Jira responder stopped", "plugin", j.name, "type", j.Type())
+	return nil
+}
+
+// Status returns the current status of the plugin
+func (j *JiraResponder) Status() core.PluginStatus {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.status
+}
+
+// Health checks if the plugin is healthy
+func (j *JiraResponder) Health(ctx context.Context) error {
+	if j.Status() != core.PluginStatusRunning {
+		return fmt.Errorf("responder is not running")
+	}
+	if j.apiToken == "" {
+		return fmt.Errorf("jira api token not configured")
+	}
+	return nil
+}
+
+// CheckDestination checks that the Jira server can be reached
+func (j *JiraResponder) CheckDestination(ctx context.Context) error {
+	return checkReachable(ctx, j.baseURL)
+}
+
+// GetCapabilities returns what this plugin can do
+func (j *JiraResponder) GetCapabilities() []string {
+	return []string{
+		"jira_issues",
+		"ticket_deduplication",
+		"severity_filtering",
+	}
+}
+
+// SetMetricMetadata provides the units used to format data point values
+func (j *JiraResponder) SetMetricMetadata(registry *core.MetricMetadataRegistry) {
+	j.metadata = registry
+}
+
+// CanHandle determines if this responder can handle the given analysis
+func (j *JiraResponder) CanHandle(analysis *core.Analysis) bool {
+	return severityRank[analysis.Severity] >= severityRank[j.minSeverity]
+}
+
+// Respond opens a Jira issue for the analysis, or comments on the open issue for its
+// fingerprint, and attaches the data points as a comment. A resolved condition is noted
+// on the open issue; closing it is left to the team's workflow.
+func (j *JiraResponder) Respond(ctx context.Context, analysis *core.Analysis) error {
+	key, err := j.findOpenIssue(ctx, analysis.Fingerprint)
+	if err != nil {
+		return err
+	}
+
+	if analysis.Resolved {
+		if key == "" {
+			return nil
+		}
+		return j.addComment(ctx, key, fmt.Sprintf("Condition resolved: %s", analysis.Summary))
+	}
+
+	comment := fmt.Sprintf("[%s] %s (analysis %s)", analysis.Severity, analysis.Summary, analysis.ID)
+	if key == "" {
+		if key, err = j.createIssue(ctx, analysis); err != nil {
+			return err
+		}
+		slog.Info("Jira issue created", "plugin", j.name, "issue", key, "fingerprint", analysis.Fingerprint)
+		comment = "Data points that triggered this issue"
+	}
+	if len(analysis.DataPoints) > 0 {
+		comment += ":\n{noformat}\n" + formatDataPointLines(j.metadata, analysis.DataPoints, 20) + "\n{noformat}"
+	}
+	return j.addComment(ctx, key, comment)
+}
+
+// Simulate returns the issue Respond would create for a new condition
+func (j *JiraResponder) Simulate(analysis *core.Analysis) (*core.SimulatedAction, error) {
+	if analysis.Resolved {
+		return &core.SimulatedAction{Action: "comment", Target: j.baseURL, Payload: map[string]interface{}{
+			"fingerprint": analysis.Fingerprint,
+			"comment":     fmt.Sprintf("Condition resolved: %s", analysis.Summary),
+		}}, nil
+	}
+	return &core.SimulatedAction{Action: "create_issue", Target: j.baseURL + "/rest/api/2/issue", Payload: j.buildIssue(analysis)}, nil
+}
+
+// fingerprintLabel is the label tying an issue to an analysis fingerprint
+func fingerprintLabel(fingerprint string) string {
+	return jiraFingerprintLabelPrefix + fingerprint
+}
+
+// findOpenIssue returns the key of the unresolved issue labelled with the fingerprint, or
+// an empty key when there is none
+func (j *JiraResponder) findOpenIssue(ctx context.Context, fingerprint string) (string, error) {
+	jql := fmt.Sprintf(`project = %q AND labels = %q AND statusCategory != Done ORDER BY created DESC`,
+		j.project, fingerprintLabel(fingerprint))
+	var result struct {
+		Issues []struct {
+			Key string `json:"key"`
+		} `json:"issues"`
+	}
+	request := map[string]interface{}{"jql": jql, "maxResults": 1, "fields": []string{"status"}}
+	if err := j.do(ctx, http.MethodPost, "/rest/api/2/search", request, &result); err != nil {
+		return "", err
+	}
+	if len(result.Issues) == 0 {
+		return "", nil
+	}
+	return result.Issues[0].Key, nil
+}
+
+// createIssue creates an issue for the analysis and returns its key
+func (j *JiraResponder) createIssue(ctx context.Context, analysis *core.Analysis) (string, error) {
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := j.do(ctx, http.MethodPost, "/rest/api/2/issue", j.buildIssue(analysis), &created); err != nil {
+		return "", err
+	}
+	return created.Key, nil
+}
+
+// addComment adds a comment to an issue
+func (j *JiraResponder) addComment(ctx context.Context, key, body string) error {
+	return j.do(ctx, http.MethodPost, fmt.Sprintf("/rest/api/2/issue/%s/comment", key), map[string]string{"body": body}, nil)
+}
+
+// buildIssue builds the create-issue request for an analysis
+func (j *JiraResponder) buildIssue(analysis *core.Analysis) map[string]interface{} {
+	summary := fmt.Sprintf("[%s] %s", analysis.Severity, analysis.Summary)
+	if len(summary) > jiraMaxSummary {
+		summary = summary[:jiraMaxSummary-3] + "..."
+	}
+
+	var description strings.Builder
+	fmt.Fprintf(&description, "%s\n\n", analysis.Summary)
+	fmt.Fprintf(&description, "*Source:* %s\n*Type:* %s\n*Confidence:* %.2f\n*Analysis:* %s\n*Fingerprint:* %s\n",
+		analysis.Source, analysis.Type, analysis.Confidence, analysis.ID, analysis.Fingerprint)
+	if incidentID, ok := analysis.Details["incident_id"].(string); ok {
+		fmt.Fprintf(&description, "*Incident:* %s\n", incidentID)
+	}
+
+	labels := append([]string{fingerprintLabel(analysis.Fingerprint)}, j.labels...)
+	fields := map[string]interface{}{
+		"project":     map[string]string{"key": j.project},
+		"summary":     summary,
+		"description": description.String(),
+		"issuetype":   map[string]string{"name": j.issueType},
+		"labels":      labels,
+	}
+	if priority, ok := j.priorityMap[analysis.Severity]; ok {
+		fields["priority"] = map[string]string{"name": priority}
+	}
+	return map[string]interface{}{"fields": fields}
+}
+
+// do makes a Jira REST API request, decoding the response into out when it is not nil
+func (j *JiraResponder) do(ctx context.Context, method, path string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode jira request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, j.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create jira request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if j.email != "" {
+		req.SetBasicAuth(j.email, j.apiToken)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+j.apiToken)
+	}
+
+	resp, err := j.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send jira request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var body struct {
+			ErrorMessages []string          `json:"errorMessages"`
+			Errors        map[string]string `json:"errors"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return fmt.Errorf("jira returned status %d: %v %v", resp.StatusCode, body.ErrorMessages, body.Errors)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid jira response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package responders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeJira keeps issues in memory and answers the searches, creates, and comments the
+// responder makes
+type fakeJira struct {
+	*httptest.Server
+	issues   map[string]map[string]interface{}
+	comments map[string][]string
+	done     map[string]bool
+	searches []string
+	mu       sync.Mutex
+}
+
+func newFakeJira(t *testing.T) *fakeJira {
+	jira := &fakeJira{issues: make(map[string]map[string]interface{}), comments: make(map[string][]string), done: make(map[string]bool)}
+	jira.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jira.mu.Lock()
+		defer jira.mu.Unlock()
+
+		if user, password, ok := r.BasicAuth(); !ok || user != "bot@example.com" || password != "T0KEN" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		switch {
+		case r.URL.Path == "/rest/api/2/search":
+			jql := body["jql"].(string)
+			jira.searches = append(jira.searches, jql)
+			issues := []map[string]string{}
+			for key, fields := range jira.issues {
+				for _, label := range fields["labels"].([]interface{}) {
+					if strings.Contains(jql, fmt.Sprintf("labels = %q", label)) && !jira.done[key] {
+						issues = append(issues, map[string]string{"key": key})
+					}
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"issues": issues})
+		case r.URL.Path == "/rest/api/2/issue":
+			key := fmt.Sprintf("OPS-%d", len(jira.issues)+1)
+			jira.issues[key] = body["fields"].(map[string]interface{})
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]string{"key": key})
+		case strings.HasSuffix(r.URL.Path, "/comment"):
+			key := strings.Split(r.URL.Path, "/")[5]
+			jira.comments[key] = append(jira.comments[key], body["body"].(string))
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(jira.Close)
+	return jira
+}
+
+func TestJiraResponder_Configure(t *testing.T) {
+	responder := NewJiraResponder("test-jira")
+
+	assert.Error(t, responder.Configure(map[string]interface{}{"project": "OPS", "api_token": "t"}), "Expected error without url")
+	assert.Error(t, responder.Configure(map[string]interface{}{"url": "https://x.atlassian.net", "api_token": "t"}), "Expected error without project")
+	assert.Error(t, responder.Configure(map[string]interface{}{"url": "https://x.atlassian.net", "project": "OPS"}), "Expected error without api_token")
+	assert.Error(t, responder.Configure(map[string]interface{}{
+		"url": "https://x.atlassian.net", "project": "OPS", "api_token": "t", "labels": []interface{}{"has space"},
+	}), "Expected error for a label Jira does not accept")
+
+	require.NoError(t, responder.Configure(map[string]interface{}{
+		"url":          "https://x.atlassian.net/",
+		"project":      "OPS",
+		"api_token":    "t",
+		"priority_map": map[string]interface{}{"critical": "Blocker"},
+	}))
+	issue := responder.buildIssue(&core.Analysis{Severity: "critical", Summary: "Disk full", Fingerprint: "fp-1"})
+	fields := issue["fields"].(map[string]interface{})
+	assert.Equal(t, map[string]string{"name": "Blocker"}, fields["priority"])
+	assert.Equal(t, map[string]string{"name": "Task"}, fields["issuetype"])
+	assert.Equal(t, "[critical] Disk full", fields["summary"])
+}
+
+func TestJiraResponder_DeduplicatesOnOpenIssue(t *testing.T) {
+	jira := newFakeJira(t)
+	responder := NewJiraResponder("test-jira")
+	require.NoError(t, responder.Configure(map[string]interface{}{
+		"url":       jira.URL,
+		"project":   "OPS",
+		"email":     "bot@example.com",
+		"api_token": "T0KEN",
+		"labels":    []interface{}{"observability"},
+	}))
+
+	analysis := &core.Analysis{
+		ID:          "a1",
+		Fingerprint: "fp-1",
+		Severity:    "high",
+		Summary:     "Error rate 12%",
+		Source:      "slo-analyzer",
+		DataPoints:  []core.DataPoint{{Metric: "error_rate", Value: 0.12}},
+	}
+	ctx := context.Background()
+	require.NoError(t, responder.Respond(ctx, analysis))
+
+	repeat := *analysis
+	repeat.ID = "a2"
+	repeat.DataPoints = []core.DataPoint{{Metric: "error_rate", Value: 0.15}}
+	require.NoError(t, responder.Respond(ctx, &repeat))
+
+	require.Len(t, jira.issues, 1, "Expected repeats to comment on the open issue")
+	fields := jira.issues["OPS-1"]
+	assert.Equal(t, []interface{}{"agent-fp-fp-1", "observability"}, fields["labels"])
+	assert.Equal(t, "High", fields["priority"].(map[string]interface{})["name"])
+	assert.Contains(t, jira.searches[0], `project = "OPS"`)
+	assert.Contains(t, jira.searches[0], "statusCategory != Done")
+
+	require.Len(t, jira.comments["OPS-1"], 2)
+	assert.Contains(t, jira.comments["OPS-1"][0], "error_rate = 0.12")
+	assert.Contains(t, jira.comments["OPS-1"][1], "(analysis a2)")
+	assert.Contains(t, jira.comments["OPS-1"][1], "error_rate = 0.15")
+
+	resolved := *analysis
+	resolved.Resolved = true
+	require.NoError(t, responder.Respond(ctx, &resolved))
+	assert.Contains(t, jira.comments["OPS-1"][2], "Condition resolved")
+
+	// Once the issue is done, the next occurrence opens a new one
+	jira.done["OPS-1"] = true
+	require.NoError(t, responder.Respond(ctx, analysis))
+	assert.Len(t, jira.issues, 2)
+	assert.Len(t, jira.comments["OPS-2"], 1)
+}
@@ -4,18 +4,22 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/habruzzo/agent/core"
 )
 
 // LoggerResponder implements the DataResponder interface for logging
 type LoggerResponder struct {
-	name    string
-	version string
-	status  core.PluginStatus
-	level   slog.Level
-	mu      sync.RWMutex
+	name     string
+	version  string
+	status   core.PluginStatus
+	level    slog.Level
+	metadata *core.MetricMetadataRegistry
+	mu       sync.RWMutex
 }
 
 // NewLoggerResponder creates a new logger responder plugin
@@ -126,6 +130,8 @@ func (l *LoggerResponder) GetCapabilities() []string {
 func (l *LoggerResponder) Respond(ctx context.Context, analysis *core.Analysis) error {
 	logger := slog.Default().With(
 		"plugin", l.name,
+		"analysis_id", analysis.ID,
+		"fingerprint", analysis.Fingerprint,
 		"analyzer", analysis.Source,
 		"type", analysis.Type,
 		"confidence", analysis.Confidence,
@@ -133,14 +139,24 @@ func (l *LoggerResponder) Respond(ctx context.Context, analysis *core.Analysis)
 		"data_points", len(analysis.DataPoints),
 	)
 
+	if onCall, ok := analysis.Details["on_call"].(string); ok {
+		logger = logger.With("on_call", onCall)
+	}
+
+	if values := formatDataPoints(l.metadata, analysis.DataPoints, 5); len(values) > 0 {
+		logger = logger.With("values", values)
+	}
+
 	message := fmt.Sprintf("[%s] %s", analysis.Type, analysis.Summary)
 
-	switch analysis.Severity {
-	case "critical":
+	switch {
+	case analysis.Resolved:
+		logger.Info(message, "resolved", true)
+	case analysis.Severity == "critical":
 		logger.Error(message)
-	case "high":
+	case analysis.Severity == "high":
 		logger.Warn(message)
-	case "medium":
+	case analysis.Severity == "medium":
 		logger.Info(message)
 	default:
 		logger.Debug(message)
@@ -149,8 +165,62 @@ func (l *LoggerResponder) Respond(ctx context.Context, analysis *core.Analysis)
 	return nil
 }
 
+// ReadOnly reports that logging has no side effects, so the logger keeps running in
+// read-only mode
+func (l *LoggerResponder) ReadOnly() bool {
+	return true
+}
+
+// SetMetricMetadata provides the units used to format data point values
+func (l *LoggerResponder) SetMetricMetadata(registry *core.MetricMetadataRegistry) {
+	l.metadata = registry
+}
+
 // CanHandle determines if this responder can handle the given analysis
 func (l *LoggerResponder) CanHandle(analysis *core.Analysis) bool {
 	// Logger can handle all analysis types
 	return true
 }
+
+// formatDataPoints renders up to limit data points as "metric=value" with human-readable units
+func formatDataPoints(metadata *core.MetricMetadataRegistry, points []core.DataPoint, limit int) []string {
+	if len(points) > limit {
+		points = points[:limit]
+	}
+	values := make([]string, 0, len(points))
+	for _, point := range points {
+		values = append(values, fmt.Sprintf("%s=%s", point.Metric, metadata.FormatValue(point.Metric, point.Value)))
+	}
+	return values
+}
+
+// formatDataPointLines renders up to limit data points one per line with their labels and
+// times, for attaching the offending values to a ticket
+func formatDataPointLines(metadata *core.MetricMetadataRegistry, points []core.DataPoint, limit int) string {
+	shown := points
+	if len(shown) > limit {
+		shown = shown[:limit]
+	}
+	lines := make([]string, 0, len(shown)+1)
+	for _, point := range shown {
+		series := point.Metric
+		if len(point.Labels) > 0 {
+			names := make([]string, 0, len(point.Labels))
+			for name := range point.Labels {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			pairs := make([]string, len(names))
+			for i, name := range names {
+				pairs[i] = fmt.Sprintf("%s=%q", name, point.Labels[name])
+			}
+			series += "{" + strings.Join(pairs, ", ") + "}"
+		}
+		lines = append(lines, fmt.Sprintf("%s = %s at %s", series, metadata.FormatValue(point.Metric, point.Value),
+			point.Timestamp.UTC().Format(time.RFC3339)))
+	}
+	if len(points) > limit {
+		lines = append(lines, fmt.Sprintf("... and %d more", len(points)-limit))
+	}
+	return strings.Join(lines, "\n")
+}
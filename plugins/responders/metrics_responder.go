@@ -0,0 +1,218 @@
+package responders
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// activeAnalysis is a firing condition exposed as a metric
+type activeAnalysis struct {
+	analysisType core.AnalysisType
+	severity     string
+	metrics      []string
+	lastSeen     time.Time
+}
+
+// activeSeries identifies one agent_analysis_active series
+type activeSeries struct {
+	analysisType core.AnalysisType
+	severity     string
+	metric       string
+}
+
+// MetricsResponder implements the DataResponder interface by exposing firing analyses as
+// Prometheus metrics on the framework's /metrics endpoint, so dashboards and alerting can
+// observe what the agent decided
+type MetricsResponder struct {
+	name    string
+	version string
+	status  core.PluginStatus
+	// ttl expires conditions not reported again in time; zero keeps them until resolved
+	ttl    time.Duration
+	active map[string]*activeAnalysis
+	now    func() time.Time
+	mu     sync.RWMutex
+}
+
+// NewMetricsResponder creates a new metrics responder plugin
+func NewMetricsResponder(name string) *MetricsResponder {
+	return &MetricsResponder{
+		name:    name,
+		version: "1.0.0",
+		status:  core.PluginStatusStopped,
+		active:  make(map[string]*activeAnalysis),
+		now:     time.Now,
+	}
+}
+
+// Name returns the name of the plugin
+func (m *MetricsResponder) Name() string {
+	return m.name
+}
+
+// Type returns the type of plugin
+func (m *MetricsResponder) Type() core.PluginType {
+	return core.PluginTypeResponder
+}
+
+// Version returns the plugin version
+func (m *MetricsResponder) Version() string {
+	return m.version
+}
+
+// Configure initializes the plugin with configuration
+func (m *MetricsResponder) Configure(config map[string]interface{}) error {
+	if ttl, ok := config["ttl"].(string); ok {
+		parsed, err := time.ParseDuration(ttl)
+		if err != nil || parsed < 0 {
+			return fmt.Errorf("invalid ttl %q", ttl)
+		}
+		m.ttl = parsed
+	}
+	return nil
+}
+
+// Start begins the plugin's operation
+func (m *MetricsResponder) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.status == core.PluginStatusRunning {
+		return fmt.Errorf("responder is already running")
+	}
+
+	m.status = core.PluginStatusStarting
+	slog.Info("Starting metrics responder", "plugin", m.name, "type", m.Type())
+
+	m.status = core.PluginStatusRunning
+	slog.Info("Metrics responder started", "plugin", m.name, "type", m.Type())
+	return nil
+}
+
+// Stop gracefully stops the plugin
+func (m *MetricsResponder) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.status != core.PluginStatusRunning {
+		return fmt.Errorf("responder is not running")
+	}
+
+	m.status = core.PluginStatusStopping
+	slog.Info("Stopping metrics responder", "plugin", m.name, "type", m.Type())
+
+	m.status = core.PluginStatusStopped
+	slog.Info("Metrics responder stopped", "plugin", m.name, "type", m.Type())
+	return nil
+}
+
+// Status returns the current status of the plugin
+func (m *MetricsResponder) Status() core.PluginStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// Health checks if the plugin is healthy
+func (m *MetricsResponder) Health(ctx context.Context) error {
+	if m.Status() == core.PluginStatusRunning {
+		return nil
+	}
+	return fmt.Errorf("responder is not running")
+}
+
+// GetCapabilities returns what this plugin can do
+func (m *MetricsResponder) GetCapabilities() []string {
+	return []string{
+		"analysis_metrics",
+		"prometheus_export",
+	}
+}
+
+// CanHandle determines if this responder can handle the given analysis
+func (m *MetricsResponder) CanHandle(analysis *core.Analysis) bool {
+	// Every analysis is counted
+	return true
+}
+
+// ReadOnly reports that exporting metrics has no side effects, so the responder keeps
+// running in read-only mode
+func (m *MetricsResponder) ReadOnly() bool {
+	return true
+}
+
+// Respond marks the analysis's condition active, or inactive once it resolves
+func (m *MetricsResponder) Respond(ctx context.Context, analysis *core.Analysis) error {
+	analysis.EnsureIdentity()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if analysis.Resolved {
+		delete(m.active, analysis.Fingerprint)
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var metrics []string
+	for _, point := range analysis.DataPoints {
+		if !seen[point.Metric] {
+			seen[point.Metric] = true
+			metrics = append(metrics, point.Metric)
+		}
+	}
+	if len(metrics) == 0 {
+		metrics = []string{""}
+	}
+	m.active[analysis.Fingerprint] = &activeAnalysis{
+		analysisType: analysis.Type,
+		severity:     analysis.Severity,
+		metrics:      metrics,
+		lastSeen:     m.now(),
+	}
+	return nil
+}
+
+// WriteMetrics writes the number of active analyses by type, severity, and metric
+func (m *MetricsResponder) WriteMetrics(w io.Writer) {
+	m.mu.Lock()
+	counts := make(map[activeSeries]int)
+	now := m.now()
+	for fingerprint, active := range m.active {
+		if m.ttl > 0 && now.Sub(active.lastSeen) > m.ttl {
+			delete(m.active, fingerprint)
+			continue
+		}
+		for _, metric := range active.metrics {
+			counts[activeSeries{analysisType: active.analysisType, severity: active.severity, metric: metric}]++
+		}
+	}
+	m.mu.Unlock()
+
+	series := make([]activeSeries, 0, len(counts))
+	for s := range counts {
+		series = append(series, s)
+	}
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].analysisType != series[j].analysisType {
+			return series[i].analysisType < series[j].analysisType
+		}
+		if series[i].severity != series[j].severity {
+			return series[i].severity < series[j].severity
+		}
+		return series[i].metric < series[j].metric
+	})
+
+	fmt.Fprintf(w, "# HELP agent_analysis_active Conditions the agent currently reports as firing\n")
+	fmt.Fprintf(w, "# TYPE agent_analysis_active gauge\n")
+	for _, s := range series {
+		fmt.Fprintf(w, "agent_analysis_active{type=%q,severity=%q,metric=%q} %d\n", s.analysisType, s.severity, s.metric, counts[s])
+	}
+}
@@ -0,0 +1,61 @@
+package responders
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsResponder_ActiveAnalyses(t *testing.T) {
+	responder := NewMetricsResponder("test-metrics")
+	require.NoError(t, responder.Configure(map[string]interface{}{"ttl": "10m"}))
+	now := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	responder.now = func() time.Time { return now }
+
+	cpu := &core.Analysis{Fingerprint: "fp-cpu", Type: core.AnalysisTypeAnomaly, Severity: "high",
+		DataPoints: []core.DataPoint{{Metric: "cpu", Labels: map[string]string{"instance": "a"}}, {Metric: "cpu", Labels: map[string]string{"instance": "b"}}}}
+	otherCPU := &core.Analysis{Fingerprint: "fp-cpu-2", Type: core.AnalysisTypeAnomaly, Severity: "high",
+		DataPoints: []core.DataPoint{{Metric: "cpu"}}}
+	errors := &core.Analysis{Fingerprint: "fp-errors", Type: core.AnalysisTypeTrend, Severity: "critical",
+		DataPoints: []core.DataPoint{{Metric: "error_rate"}, {Metric: "latency"}}}
+
+	ctx := context.Background()
+	for _, analysis := range []*core.Analysis{cpu, otherCPU, errors} {
+		require.NoError(t, responder.Respond(ctx, analysis))
+	}
+	// Repeats of a condition are counted once
+	require.NoError(t, responder.Respond(ctx, cpu))
+
+	var buf bytes.Buffer
+	responder.WriteMetrics(&buf)
+	assert.Equal(t, `# HELP agent_analysis_active Conditions the agent currently reports as firing
+# TYPE agent_analysis_active gauge
+agent_analysis_active{type="anomaly",severity="high",metric="cpu"} 2
+agent_analysis_active{type="trend",severity="critical",metric="error_rate"} 1
+agent_analysis_active{type="trend",severity="critical",metric="latency"} 1
+`, buf.String())
+
+	resolved := *errors
+	resolved.Resolved = true
+	require.NoError(t, responder.Respond(ctx, &resolved))
+	now = now.Add(5 * time.Minute)
+	require.NoError(t, responder.Respond(ctx, cpu))
+	now = now.Add(6 * time.Minute)
+
+	buf.Reset()
+	responder.WriteMetrics(&buf)
+	assert.Contains(t, buf.String(), `agent_analysis_active{type="anomaly",severity="high",metric="cpu"} 1`,
+		"Expected the condition not reported within the ttl to expire")
+	assert.NotContains(t, buf.String(), "error_rate", "Expected resolved conditions to be removed")
+}
+
+func TestMetricsResponder_Configure(t *testing.T) {
+	responder := NewMetricsResponder("test-metrics")
+	assert.Error(t, responder.Configure(map[string]interface{}{"ttl": "soon"}))
+	assert.True(t, responder.ReadOnly())
+}
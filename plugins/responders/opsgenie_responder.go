@@ -0,0 +1,304 @@
+package responders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// opsgenieAPIURL is the Opsgenie API for accounts in the US region
+const opsgenieAPIURL = "https://api.opsgenie.com"
+
+// opsgenieMaxMessage is the longest alert message Opsgenie accepts
+const opsgenieMaxMessage = 130
+
+// opsgeniePriorities are the priorities Opsgenie accepts
+var opsgeniePriorities = map[string]bool{"P1": true, "P2": true, "P3": true, "P4": true, "P5": true}
+
+// defaultOpsgeniePriorityMap maps analysis severities to Opsgenie priorities
+var defaultOpsgeniePriorityMap = map[string]string{
+	"critical": "P1",
+	"high":     "P2",
+	"medium":   "P3",
+	"low":      "P4",
+}
+
+// OpsgenieResponder implements the DataResponder interface for Opsgenie alerts. Alerts use
+// the analysis fingerprint as their alias, so Opsgenie folds repeats into the open alert;
+// the offending data points are added to it as a note each time.
+type OpsgenieResponder struct {
+	name        string
+	version     string
+	status      core.PluginStatus
+	apiKey      string
+	apiURL      string
+	team        string
+	minSeverity string
+	priorityMap map[string]string
+	metadata    *core.MetricMetadataRegistry
+	httpClient  *http.Client
+	mu          sync.RWMutex
+}
+
+// NewOpsgenieResponder creates a new Opsgenie responder plugin
+func NewOpsgenieResponder(name string) *OpsgenieResponder {
+	priorityMap := make(map[string]string, len(defaultOpsgeniePriorityMap))
+	for severity, priority := range defaultOpsgeniePriorityMap {
+		priorityMap[severity] = priority
+	}
+	return &OpsgenieResponder{
+		name:        name,
+		version:     "1.0.0",
+		status:      core.PluginStatusStopped,
+		apiURL:      opsgenieAPIURL,
+		minSeverity: "high",
+		priorityMap: priorityMap,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name returns the name of the plugin
+func (o *OpsgenieResponder) Name() string {
+	return o.name
+}
+
+// Type returns the type of plugin
+func (o *OpsgenieResponder) Type() core.PluginType {
+	return core.PluginTypeResponder
+}
+
+// Version returns the plugin version
+func (o *OpsgenieResponder) Version() string {
+	return o.version
+}
+
+// Configure initializes the plugin with configuration
+func (o *OpsgenieResponder) Configure(config map[string]interface{}) error {
+	apiKey, ok := config["api_key"].(string)
+	if !ok || apiKey == "" {
+		return fmt.Errorf("opsgenie api_key not specified")
+	}
+	o.apiKey = apiKey
+
+	if apiURL, ok := config["api_url"].(string); ok && apiURL != "" {
+		o.apiURL = apiURL
+	}
+	if team, ok := config["team"].(string); ok {
+		o.team = team
+	}
+
+	if minSeverity, ok := config["min_severity"].(string); ok {
+		if _, known := severityRank[minSeverity]; !known {
+			return fmt.Errorf("unknown min_severity %q", minSeverity)
+		}
+		o.minSeverity = minSeverity
+	}
+
+	if priorityMap, ok := config["priority_map"].(map[string]interface{}); ok {
+		for severity, value := range priorityMap {
+			if _, known := severityRank[severity]; !known {
+				return fmt.Errorf("unknown severity %q in priority_map", severity)
+			}
+			priority, ok := value.(string)
+			if !ok || !opsgeniePriorities[priority] {
+				return fmt.Errorf("priority_map %s must be one of P1 to P5", severity)
+			}
+			o.priorityMap[severity] = priority
+		}
+	}
+
+	return nil
+}
+
+// Start begins the plugin's operation
+func (o *OpsgenieResponder) Start(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.status == core.PluginStatusRunning {
+		return fmt.Errorf("responder is already running")
+	}
+
+	o.status = core.PluginStatusStarting
+	slog.Info("Starting Opsgenie responder", "plugin", o.name, "type", o.Type())
+
+	o.status = core.PluginStatusRunning
+	slog.Info("Opsgenie responder started", "plugin", o.name, "type", o.Type())
+	return nil
+}
+
+// Stop gracefully stops the plugin
+func (o *OpsgenieResponder) Stop() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.status != core.PluginStatusRunning {
+		return fmt.Errorf("responder is not running")
+	}
+
+	o.status = core.PluginStatusStopping
+	slog.Info("Stopping Opsgenie responder", "plugin", o.name, "type", o.Type())
+
+	o.status = core.PluginStatusStopped
+	slog.Info("Opsgenie responder stopped", "plugin", o.name, "type", o.Type())
+	return nil
+}
+
+// Status returns the current status of the plugin
+func (o *OpsgenieResponder) Status() core.PluginStatus {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.status
+}
+
+// Health checks if the plugin is healthy
+func (o *OpsgenieResponder) Health(ctx context.Context) error {
+	if o.Status() != core.PluginStatusRunning {
+		return fmt.Errorf("responder is not running")
+	}
+	if o.apiKey == "" {
+		return fmt.Errorf("opsgenie api key not configured")
+	}
+	return nil
+}
+
+// CheckDestination checks that the Opsgenie API can be reached
+func (o *OpsgenieResponder) CheckDestination(ctx context.Context) error {
+	return checkReachable(ctx, o.apiURL)
+}
+
+// GetCapabilities returns what this plugin can do
+func (o *OpsgenieResponder) GetCapabilities() []string {
+	return []string{
+		"opsgenie_alerts",
+		"incident_acknowledgement",
+		"auto_resolve",
+		"severity_filtering",
+	}
+}
+
+// SetMetricMetadata provides the units used to format data point values
+func (o *OpsgenieResponder) SetMetricMetadata(registry *core.MetricMetadataRegistry) {
+	o.metadata = registry
+}
+
+// CanHandle determines if this responder can handle the given analysis
+func (o *OpsgenieResponder) CanHandle(analysis *core.Analysis) bool {
+	return severityRank[analysis.Severity] >= severityRank[o.minSeverity]
+}
+
+// Respond creates or updates the Opsgenie alert for the analysis and notes its data
+// points, or closes the alert when the framework reports the condition resolved
+func (o *OpsgenieResponder) Respond(ctx context.Context, analysis *core.Analysis) error {
+	if analysis.Resolved {
+		return o.send(ctx, http.MethodPost, o.alertPath(analysis.Fingerprint, "close"),
+			map[string]interface{}{"source": analysis.Source, "note": "Condition resolved"})
+	}
+
+	if err := o.send(ctx, http.MethodPost, "/v2/alerts", o.buildAlert(analysis)); err != nil {
+		return err
+	}
+	if len(analysis.DataPoints) == 0 {
+		return nil
+	}
+	note := "Data points:\n" + formatDataPointLines(o.metadata, analysis.DataPoints, 20)
+	return o.send(ctx, http.MethodPost, o.alertPath(analysis.Fingerprint, "notes"),
+		map[string]interface{}{"source": analysis.Source, "note": note})
+}
+
+// Simulate returns the alert Respond would create
+func (o *OpsgenieResponder) Simulate(analysis *core.Analysis) (*core.SimulatedAction, error) {
+	if analysis.Resolved {
+		return &core.SimulatedAction{Action: "close", Target: o.apiURL + o.alertPath(analysis.Fingerprint, "close")}, nil
+	}
+	return &core.SimulatedAction{Action: "create", Target: o.apiURL + "/v2/alerts", Payload: o.buildAlert(analysis)}, nil
+}
+
+// Acknowledge acknowledges the Opsgenie alert opened for the incident's fingerprint
+func (o *OpsgenieResponder) Acknowledge(ctx context.Context, incident core.Incident, actor string) error {
+	return o.send(ctx, http.MethodPost, o.alertPath(incident.Fingerprint, "acknowledge"),
+		map[string]interface{}{"user": actor, "note": fmt.Sprintf("Acknowledged %s", incident.ID)})
+}
+
+// buildAlert builds the create-alert request for an analysis
+func (o *OpsgenieResponder) buildAlert(analysis *core.Analysis) map[string]interface{} {
+	message := analysis.Summary
+	if len(message) > opsgenieMaxMessage {
+		message = message[:opsgenieMaxMessage-3] + "..."
+	}
+
+	details := map[string]string{
+		"analysis_id": analysis.ID,
+		"severity":    analysis.Severity,
+		"type":        string(analysis.Type),
+		"confidence":  fmt.Sprintf("%.2f", analysis.Confidence),
+	}
+	for key, value := range analysis.Details {
+		details[key] = fmt.Sprint(value)
+	}
+
+	alert := map[string]interface{}{
+		"message":     message,
+		"alias":       analysis.Fingerprint,
+		"description": analysis.Summary,
+		"source":      analysis.Source,
+		"priority":    o.priority(analysis.Severity),
+		"tags":        []string{"severity:" + analysis.Severity, string(analysis.Type)},
+		"details":     details,
+	}
+	if o.team != "" {
+		alert["responders"] = []map[string]string{{"type": "team", "name": o.team}}
+	}
+	return alert
+}
+
+// priority maps an analysis severity to an Opsgenie priority
+func (o *OpsgenieResponder) priority(severity string) string {
+	if priority, ok := o.priorityMap[severity]; ok {
+		return priority
+	}
+	return "P3"
+}
+
+// alertPath is the path of an action on the alert with the given alias
+func (o *OpsgenieResponder) alertPath(alias, action string) string {
+	return fmt.Sprintf("/v2/alerts/%s/%s?identifierType=alias", url.PathEscape(alias), action)
+}
+
+// send makes an Opsgenie API request
+func (o *OpsgenieResponder) send(ctx context.Context, method, path string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode opsgenie request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, o.apiURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create opsgenie request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+o.apiKey)
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send opsgenie request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var body struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return fmt.Errorf("opsgenie returned status %d: %s", resp.StatusCode, body.Message)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package responders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpsgenieResponder_Configure(t *testing.T) {
+	responder := NewOpsgenieResponder("test-opsgenie")
+
+	assert.Error(t, responder.Configure(map[string]interface{}{}), "Expected error without api_key")
+	assert.Error(t, responder.Configure(map[string]interface{}{
+		"api_key":      "key",
+		"priority_map": map[string]interface{}{"high": "urgent"},
+	}), "Expected error for a priority Opsgenie does not accept")
+
+	require.NoError(t, responder.Configure(map[string]interface{}{
+		"api_key":      "key",
+		"min_severity": "medium",
+		"priority_map": map[string]interface{}{"high": "P1"},
+	}))
+	assert.Equal(t, "P1", responder.priority("high"))
+	assert.Equal(t, "P3", responder.priority("medium"))
+	assert.True(t, responder.CanHandle(&core.Analysis{Severity: "medium"}))
+	assert.False(t, responder.CanHandle(&core.Analysis{Severity: "low"}))
+}
+
+func TestOpsgenieResponder_Lifecycle(t *testing.T) {
+	type request struct {
+		path string
+		auth string
+		body map[string]interface{}
+	}
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, request{path: r.URL.RequestURI(), auth: r.Header.Get("Authorization"), body: body})
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	responder := NewOpsgenieResponder("test-opsgenie")
+	require.NoError(t, responder.Configure(map[string]interface{}{
+		"api_key": "G3N1E",
+		"api_url": server.URL,
+		"team":    "sre",
+	}))
+
+	analysis := &core.Analysis{
+		ID:          "a1",
+		Fingerprint: "fp-1",
+		Type:        core.AnalysisTypeAnomaly,
+		Severity:    "critical",
+		Summary:     "CPU at 99%",
+		Source:      "anomaly-analyzer",
+		DataPoints: []core.DataPoint{{Metric: "cpu", Value: 99, Labels: map[string]string{"instance": "web-1"},
+			Timestamp: time.Date(2026, 3, 2, 14, 3, 12, 0, time.UTC)}},
+		Timestamp: time.Now(),
+	}
+	ctx := context.Background()
+	require.NoError(t, responder.Respond(ctx, analysis))
+	require.NoError(t, responder.Acknowledge(ctx, core.Incident{ID: "INC-1", Fingerprint: "fp-1"}, "alice"))
+	resolved := *analysis
+	resolved.Resolved = true
+	require.NoError(t, responder.Respond(ctx, &resolved))
+
+	require.Len(t, requests, 4)
+	assert.Equal(t, "GenieKey G3N1E", requests[0].auth)
+	assert.Equal(t, "/v2/alerts", requests[0].path)
+	alert := requests[0].body
+	assert.Equal(t, "fp-1", alert["alias"], "Expected alerts to be deduplicated by fingerprint")
+	assert.Equal(t, "P1", alert["priority"])
+	assert.Equal(t, "sre", alert["responders"].([]interface{})[0].(map[string]interface{})["name"])
+
+	assert.Equal(t, "/v2/alerts/fp-1/notes?identifierType=alias", requests[1].path)
+	assert.Contains(t, requests[1].body["note"], `cpu{instance="web-1"} = 99 at 2026-03-02T14:03:12Z`)
+	assert.Equal(t, "/v2/alerts/fp-1/acknowledge?identifierType=alias", requests[2].path)
+	assert.Equal(t, "alice", requests[2].body["user"])
+	assert.Equal(t, "/v2/alerts/fp-1/close?identifierType=alias", requests[3].path)
+}
+
+func TestOpsgenieResponder_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message": "Key format is not valid!"}`))
+	}))
+	defer server.Close()
+
+	responder := NewOpsgenieResponder("test-opsgenie")
+	require.NoError(t, responder.Configure(map[string]interface{}{"api_key": "bad", "api_url": server.URL}))
+	err := responder.Respond(context.Background(), &core.Analysis{Fingerprint: "fp-1", Severity: "high"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Key format is not valid!")
+}
@@ -0,0 +1,310 @@
+package responders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// pagerDutyEventsURL is the PagerDuty Events API v2 endpoint
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// pagerDutyMaxSummary is the longest summary the Events API accepts
+const pagerDutyMaxSummary = 1024
+
+// pagerDutySeverities are the severities the Events API accepts
+var pagerDutySeverities = map[string]bool{
+	"critical": true,
+	"error":    true,
+	"warning":  true,
+	"info":     true,
+}
+
+// defaultPagerDutySeverityMap maps analysis severities to PagerDuty severities
+var defaultPagerDutySeverityMap = map[string]string{
+	"critical": "critical",
+	"high":     "error",
+	"medium":   "warning",
+	"low":      "info",
+}
+
+// PagerDutyResponder implements the DataResponder interface for the PagerDuty Events API
+// v2. Events are keyed by analysis fingerprint, so repeats update one PagerDuty incident
+// and the framework's resolve notification resolves it.
+type PagerDutyResponder struct {
+	name        string
+	version     string
+	status      core.PluginStatus
+	routingKey  string
+	eventsURL   string
+	minSeverity string
+	severityMap map[string]string
+	metadata    *core.MetricMetadataRegistry
+	httpClient  *http.Client
+	mu          sync.RWMutex
+}
+
+// NewPagerDutyResponder creates a new PagerDuty responder plugin
+func NewPagerDutyResponder(name string) *PagerDutyResponder {
+	severityMap := make(map[string]string, len(defaultPagerDutySeverityMap))
+	for severity, mapped := range defaultPagerDutySeverityMap {
+		severityMap[severity] = mapped
+	}
+	return &PagerDutyResponder{
+		name:        name,
+		version:     "1.0.0",
+		status:      core.PluginStatusStopped,
+		eventsURL:   pagerDutyEventsURL,
+		minSeverity: "high",
+		severityMap: severityMap,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name returns the name of the plugin
+func (p *PagerDutyResponder) Name() string {
+	return p.name
+}
+
+// Type returns the type of plugin
+func (p *PagerDutyResponder) Type() core.PluginType {
+	return core.PluginTypeResponder
+}
+
+// Version returns the plugin version
+func (p *PagerDutyResponder) Version() string {
+	return p.version
+}
+
+// Configure initializes the plugin with configuration
+func (p *PagerDutyResponder) Configure(config map[string]interface{}) error {
+	routingKey, ok := config["routing_key"].(string)
+	if !ok || routingKey == "" {
+		return fmt.Errorf("pagerduty routing_key not specified")
+	}
+	p.routingKey = routingKey
+
+	if eventsURL, ok := config["events_url"].(string); ok && eventsURL != "" {
+		p.eventsURL = eventsURL
+	}
+
+	if minSeverity, ok := config["min_severity"].(string); ok {
+		if _, known := severityRank[minSeverity]; !known {
+			return fmt.Errorf("unknown min_severity %q", minSeverity)
+		}
+		p.minSeverity = minSeverity
+	}
+
+	if severityMap, ok := config["severity_map"].(map[string]interface{}); ok {
+		for severity, value := range severityMap {
+			if _, known := severityRank[severity]; !known {
+				return fmt.Errorf("unknown severity %q in severity_map", severity)
+			}
+			mapped, ok := value.(string)
+			if !ok || !pagerDutySeverities[mapped] {
+				return fmt.Errorf("severity_map %s must be one of critical, error, warning, or info", severity)
+			}
+			p.severityMap[severity] = mapped
+		}
+	}
+
+	return nil
+}
+
+// Start begins the plugin's operation
+func (p *PagerDutyResponder) Start(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.status == core.PluginStatusRunning {
+		return fmt.Errorf("responder is already running")
+	}
+
+	p.status = core.PluginStatusStarting
+	slog.Info("Starting PagerDuty responder", "plugin", p.name, "type", p.Type())
+
+	p.status = core.PluginStatusRunning
+	slog.Info("PagerDuty responder started", "plugin", p.name, "type", p.Type())
+	return nil
+}
+
+// Stop gracefully stops the plugin
+func (p *PagerDutyResponder) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.status != core.PluginStatusRunning {
+		return fmt.Errorf("responder is not running")
+	}
+
+	p.status = core.PluginStatusStopping
+	slog.Info("Stopping PagerDuty responder", "plugin", p.name, "type", p.Type())
+
+	p.status = core.PluginStatusStopped
+	slog.Info("PagerDuty responder stopped", "plugin", p.name, "type", p.Type())
+	return nil
+}
+
+// Status returns the current status of the plugin
+func (p *PagerDutyResponder) Status() core.PluginStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.status
+}
+
+// Health checks if the plugin is healthy
+func (p *PagerDutyResponder) Health(ctx context.Context) error {
+	if p.Status() != core.PluginStatusRunning {
+		return fmt.Errorf("responder is not running")
+	}
+	if p.routingKey == "" {
+		return fmt.Errorf("pagerduty routing key not configured")
+	}
+	return nil
+}
+
+// CheckDestination checks that the PagerDuty Events API can be reached
+func (p *PagerDutyResponder) CheckDestination(ctx context.Context) error {
+	return checkReachable(ctx, p.eventsURL)
+}
+
+// GetCapabilities returns what this plugin can do
+func (p *PagerDutyResponder) GetCapabilities() []string {
+	return []string{
+		"pagerduty_events",
+		"incident_acknowledgement",
+		"auto_resolve",
+		"severity_filtering",
+	}
+}
+
+// SetMetricMetadata provides the units used to format data point values
+func (p *PagerDutyResponder) SetMetricMetadata(registry *core.MetricMetadataRegistry) {
+	p.metadata = registry
+}
+
+// CanHandle determines if this responder can handle the given analysis
+func (p *PagerDutyResponder) CanHandle(analysis *core.Analysis) bool {
+	return severityRank[analysis.Severity] >= severityRank[p.minSeverity]
+}
+
+// Respond triggers a PagerDuty alert for the analysis, or resolves it when the framework
+// reports the condition resolved
+func (p *PagerDutyResponder) Respond(ctx context.Context, analysis *core.Analysis) error {
+	return p.send(ctx, p.buildEvent(analysis))
+}
+
+// Simulate returns the event Respond would send
+func (p *PagerDutyResponder) Simulate(analysis *core.Analysis) (*core.SimulatedAction, error) {
+	event := p.buildEvent(analysis)
+	return &core.SimulatedAction{Action: event["event_action"].(string), Target: p.eventsURL, Payload: redactRoutingKey(event)}, nil
+}
+
+// Acknowledge acknowledges the PagerDuty alert opened for the incident's fingerprint
+func (p *PagerDutyResponder) Acknowledge(ctx context.Context, incident core.Incident, actor string) error {
+	return p.send(ctx, map[string]interface{}{
+		"routing_key":  p.routingKey,
+		"event_action": "acknowledge",
+		"dedup_key":    incident.Fingerprint,
+	})
+}
+
+// buildEvent builds the trigger or resolve event for an analysis
+func (p *PagerDutyResponder) buildEvent(analysis *core.Analysis) map[string]interface{} {
+	if analysis.Resolved {
+		return map[string]interface{}{
+			"routing_key":  p.routingKey,
+			"event_action": "resolve",
+			"dedup_key":    analysis.Fingerprint,
+		}
+	}
+
+	summary := fmt.Sprintf("[%s] %s", analysis.Severity, analysis.Summary)
+	if len(summary) > pagerDutyMaxSummary {
+		summary = summary[:pagerDutyMaxSummary-3] + "..."
+	}
+
+	details := make(map[string]interface{}, len(analysis.Details)+3)
+	for key, value := range analysis.Details {
+		details[key] = value
+	}
+	details["analysis_id"] = analysis.ID
+	details["confidence"] = analysis.Confidence
+	if values := formatDataPoints(p.metadata, analysis.DataPoints, 10); len(values) > 0 {
+		details["values"] = values
+	}
+
+	timestamp := analysis.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	return map[string]interface{}{
+		"routing_key":  p.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    analysis.Fingerprint,
+		"payload": map[string]interface{}{
+			"summary":        summary,
+			"source":         analysis.Source,
+			"severity":       p.severity(analysis.Severity),
+			"timestamp":      timestamp.Format(time.RFC3339),
+			"class":          string(analysis.Type),
+			"custom_details": details,
+		},
+	}
+}
+
+// severity maps an analysis severity to a PagerDuty severity
+func (p *PagerDutyResponder) severity(severity string) string {
+	if mapped, ok := p.severityMap[severity]; ok {
+		return mapped
+	}
+	return "warning"
+}
+
+// send posts an event to the Events API
+func (p *PagerDutyResponder) send(ctx context.Context, event map[string]interface{}) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode pagerduty event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.eventsURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create pagerduty request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send pagerduty event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		var body struct {
+			Message string   `json:"message"`
+			Errors  []string `json:"errors"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return fmt.Errorf("pagerduty returned status %d: %s %v", resp.StatusCode, body.Message, body.Errors)
+	}
+	return nil
+}
+
+// redactRoutingKey returns a copy of the event without its routing key, for reports
+func redactRoutingKey(event map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(event))
+	for key, value := range event {
+		redacted[key] = value
+	}
+	redacted["routing_key"] = "REDACTED"
+	return redacted
+}
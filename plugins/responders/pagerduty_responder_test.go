@@ -0,0 +1,106 @@
+package responders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPagerDutyResponder_Configure(t *testing.T) {
+	responder := NewPagerDutyResponder("test-pagerduty")
+
+	assert.Error(t, responder.Configure(map[string]interface{}{}), "Expected error without routing_key")
+	assert.Error(t, responder.Configure(map[string]interface{}{
+		"routing_key":  "key",
+		"severity_map": map[string]interface{}{"high": "sev1"},
+	}), "Expected error for a severity PagerDuty does not accept")
+
+	require.NoError(t, responder.Configure(map[string]interface{}{
+		"routing_key":  "key",
+		"min_severity": "medium",
+		"severity_map": map[string]interface{}{"high": "critical"},
+	}))
+	assert.Equal(t, "critical", responder.severity("high"))
+	assert.Equal(t, "warning", responder.severity("medium"))
+	assert.True(t, responder.CanHandle(&core.Analysis{Severity: "medium"}))
+	assert.False(t, responder.CanHandle(&core.Analysis{Severity: "low"}))
+}
+
+func TestPagerDutyResponder_Lifecycle(t *testing.T) {
+	var events []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&event)
+		events = append(events, event)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	responder := NewPagerDutyResponder("test-pagerduty")
+	require.NoError(t, responder.Configure(map[string]interface{}{
+		"routing_key": "R0UT1NG",
+		"events_url":  server.URL,
+	}))
+
+	analysis := &core.Analysis{
+		ID:          "a1",
+		Fingerprint: "fp-1",
+		Type:        core.AnalysisTypeAnomaly,
+		Severity:    "high",
+		Summary:     "CPU at 99%",
+		Source:      "anomaly-analyzer",
+		Details:     map[string]interface{}{"incident_id": "INC-1"},
+		DataPoints:  []core.DataPoint{{Metric: "cpu", Value: 99}},
+		Timestamp:   time.Now(),
+	}
+	ctx := context.Background()
+	require.NoError(t, responder.Respond(ctx, analysis))
+	require.NoError(t, responder.Acknowledge(ctx, core.Incident{ID: "INC-1", Fingerprint: "fp-1"}, "alice"))
+
+	resolved := *analysis
+	resolved.Resolved = true
+	require.NoError(t, responder.Respond(ctx, &resolved))
+
+	require.Len(t, events, 3)
+	for i, action := range []string{"trigger", "acknowledge", "resolve"} {
+		assert.Equal(t, action, events[i]["event_action"])
+		assert.Equal(t, "fp-1", events[i]["dedup_key"], "Expected events to be keyed by fingerprint")
+		assert.Equal(t, "R0UT1NG", events[i]["routing_key"])
+	}
+	payload := events[0]["payload"].(map[string]interface{})
+	assert.Equal(t, "error", payload["severity"])
+	assert.Equal(t, "[high] CPU at 99%", payload["summary"])
+	assert.Equal(t, "INC-1", payload["custom_details"].(map[string]interface{})["incident_id"])
+}
+
+func TestPagerDutyResponder_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":"invalid event","message":"Event object is invalid","errors":["'routing_key' is invalid"]}`))
+	}))
+	defer server.Close()
+
+	responder := NewPagerDutyResponder("test-pagerduty")
+	require.NoError(t, responder.Configure(map[string]interface{}{"routing_key": "bad", "events_url": server.URL}))
+
+	err := responder.Respond(context.Background(), &core.Analysis{Severity: "critical", Fingerprint: "fp"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "routing_key")
+}
+
+func TestPagerDutyResponder_Simulate(t *testing.T) {
+	responder := NewPagerDutyResponder("test-pagerduty")
+	require.NoError(t, responder.Configure(map[string]interface{}{"routing_key": "secret"}))
+
+	action, err := responder.Simulate(&core.Analysis{Severity: "critical", Summary: "disk full", Fingerprint: "fp"})
+	require.NoError(t, err)
+	assert.Equal(t, "trigger", action.Action)
+	assert.Equal(t, "REDACTED", action.Payload.(map[string]interface{})["routing_key"], "Expected the routing key to stay out of the report")
+}
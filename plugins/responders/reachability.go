@@ -0,0 +1,37 @@
+package responders
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
+// reachabilityTimeout bounds how long a destination check waits for a connection
+const reachabilityTimeout = 5 * time.Second
+
+// checkReachable opens a TCP connection to the host of a URL, so a destination that is
+// down or does not resolve is noticed without sending it anything
+func checkReachable(ctx context.Context, rawURL string) error {
+	destination, err := url.Parse(rawURL)
+	if err != nil || destination.Host == "" {
+		return fmt.Errorf("invalid destination url")
+	}
+
+	address := destination.Host
+	if destination.Port() == "" {
+		port := "443"
+		if destination.Scheme == "http" {
+			port = "80"
+		}
+		address = net.JoinHostPort(destination.Hostname(), port)
+	}
+
+	dialer := net.Dialer{Timeout: reachabilityTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("destination %s unreachable: %w", destination.Host, err)
+	}
+	return conn.Close()
+}
@@ -0,0 +1,24 @@
+package responders
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckReachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL + "/services/T000/B000/secret"
+
+	ctx := context.Background()
+	assert.NoError(t, checkReachable(ctx, url))
+	assert.Error(t, checkReachable(ctx, "not a url"))
+
+	server.Close()
+	err := checkReachable(ctx, url)
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret", "Expected the error not to leak the webhook path")
+}
@@ -0,0 +1,84 @@
+package responders
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// s3Client uploads objects to S3 or S3-compatible storage such as MinIO, signing requests
+// with AWS Signature Version 4
+type s3Client struct {
+	endpoint        string
+	bucket          string
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	// pathStyle addresses the bucket in the path rather than the host, as most
+	// S3-compatible stores expect
+	pathStyle  bool
+	httpClient *http.Client
+}
+
+// objectURL returns the URL of an object
+func (c *s3Client) objectURL(key string) (string, error) {
+	endpoint, err := url.Parse(c.endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	if c.pathStyle {
+		endpoint.Path = strings.TrimSuffix(endpoint.Path, "/") + "/" + url.PathEscape(c.bucket) + "/" + strings.Join(segments, "/")
+	} else {
+		endpoint.Host = c.bucket + "." + endpoint.Host
+		endpoint.Path = strings.TrimSuffix(endpoint.Path, "/") + "/" + strings.Join(segments, "/")
+	}
+	endpoint.RawPath = endpoint.Path
+	return endpoint.String(), nil
+}
+
+// PutObject uploads an object
+func (c *s3Client) PutObject(ctx context.Context, key, contentType string, body []byte) error {
+	objectURL, err := c.objectURL(key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create s3 request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	signS3Request(req, body, c.accessKeyID, c.secretAccessKey, c.sessionToken, c.region, time.Now())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 returned status %d for %s: %s", resp.StatusCode, key, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// signS3Request adds AWS Signature Version 4 headers for S3 to a request
+func signS3Request(req *http.Request, payload []byte, accessKeyID, secretAccessKey, sessionToken, region string, now time.Time) {
+	core.SignAWSRequest(req, payload, core.AWSCredentials{
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		SessionToken:    sessionToken,
+	}, region, "s3", now)
+}
@@ -0,0 +1,364 @@
+package responders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// severityRank orders severities so responders can filter by a minimum
+var severityRank = map[string]int{
+	"low":      0,
+	"medium":   1,
+	"high":     2,
+	"critical": 3,
+}
+
+// SlackResponder implements the DataResponder interface for Slack notifications
+// with interactive acknowledge, silence, and runbook buttons
+type SlackResponder struct {
+	name            string
+	version         string
+	status          core.PluginStatus
+	webhookURL      string
+	channel         string
+	minSeverity     string
+	runbookWorkflow string
+	metadata        *core.MetricMetadataRegistry
+	httpClient      *http.Client
+	mu              sync.RWMutex
+}
+
+// NewSlackResponder creates a new Slack responder plugin
+func NewSlackResponder(name string) *SlackResponder {
+	return &SlackResponder{
+		name:        name,
+		version:     "1.0.0",
+		status:      core.PluginStatusStopped,
+		minSeverity: "medium",
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name returns the name of the plugin
+func (s *SlackResponder) Name() string {
+	return s.name
+}
+
+// Type returns the type of plugin
+func (s *SlackResponder) Type() core.PluginType {
+	return core.PluginTypeResponder
+}
+
+// Version returns the plugin version
+func (s *SlackResponder) Version() string {
+	return s.version
+}
+
+// Configure initializes the plugin with configuration
+func (s *SlackResponder) Configure(config map[string]interface{}) error {
+	webhookURL, ok := config["webhook_url"].(string)
+	if !ok || webhookURL == "" {
+		return fmt.Errorf("slack webhook_url not specified")
+	}
+	s.webhookURL = webhookURL
+
+	if channel, ok := config["channel"].(string); ok {
+		s.channel = channel
+	}
+
+	if minSeverity, ok := config["min_severity"].(string); ok {
+		if _, known := severityRank[minSeverity]; !known {
+			return fmt.Errorf("unknown min_severity %q", minSeverity)
+		}
+		s.minSeverity = minSeverity
+	}
+
+	if workflow, ok := config["runbook_workflow"].(string); ok {
+		s.runbookWorkflow = workflow
+	}
+
+	return nil
+}
+
+// Start begins the plugin's operation
+func (s *SlackResponder) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status == core.PluginStatusRunning {
+		return fmt.Errorf("responder is already running")
+	}
+
+	s.status = core.PluginStatusStarting
+	slog.Info("Starting Slack responder", "plugin", s.name, "type", s.Type())
+
+	s.status = core.PluginStatusRunning
+	slog.Info("Slack responder started", "plugin", s.name, "type", s.Type())
+	return nil
+}
+
+// Stop gracefully stops the plugin
+func (s *SlackResponder) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status != core.PluginStatusRunning {
+		return fmt.Errorf("responder is not running")
+	}
+
+	s.status = core.PluginStatusStopping
+	slog.Info("Stopping Slack responder", "plugin", s.name, "type", s.Type())
+
+	s.status = core.PluginStatusStopped
+	slog.Info("Slack responder stopped", "plugin", s.name, "type", s.Type())
+	return nil
+}
+
+// Status returns the current status of the plugin
+func (s *SlackResponder) Status() core.PluginStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// Health checks if the plugin is healthy
+func (s *SlackResponder) Health(ctx context.Context) error {
+	if s.Status() != core.PluginStatusRunning {
+		return fmt.Errorf("responder is not running")
+	}
+	if s.webhookURL == "" {
+		return fmt.Errorf("slack webhook not configured")
+	}
+	return nil
+}
+
+// CheckDestination checks that the Slack webhook can be reached
+func (s *SlackResponder) CheckDestination(ctx context.Context) error {
+	return checkReachable(ctx, s.webhookURL)
+}
+
+// GetCapabilities returns what this plugin can do
+func (s *SlackResponder) GetCapabilities() []string {
+	return []string{
+		"slack_notification",
+		"interactive_acknowledgement",
+		"severity_filtering",
+		"grouped_notifications",
+	}
+}
+
+// Respond posts the analysis to Slack with interactive buttons
+func (s *SlackResponder) Respond(ctx context.Context, analysis *core.Analysis) error {
+	return s.post(ctx, s.buildMessage(analysis))
+}
+
+// Simulate returns the message Respond would post
+func (s *SlackResponder) Simulate(analysis *core.Analysis) (*core.SimulatedAction, error) {
+	target := s.channel
+	if target == "" {
+		target = "webhook default channel"
+	}
+	return &core.SimulatedAction{Action: "post_message", Target: target, Payload: s.buildMessage(analysis)}, nil
+}
+
+// RespondGroup posts the analyses of a group as one message
+func (s *SlackResponder) RespondGroup(ctx context.Context, group *core.AnalysisGroup) error {
+	return s.post(ctx, s.buildGroupMessage(group))
+}
+
+// post sends a message to the webhook
+func (s *SlackResponder) post(ctx context.Context, message map[string]interface{}) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode slack message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to slack: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SetMetricMetadata provides the units used to format data point values
+func (s *SlackResponder) SetMetricMetadata(registry *core.MetricMetadataRegistry) {
+	s.metadata = registry
+}
+
+// CanHandle determines if this responder can handle the given analysis
+func (s *SlackResponder) CanHandle(analysis *core.Analysis) bool {
+	return severityRank[analysis.Severity] >= severityRank[s.minSeverity]
+}
+
+// buildMessage builds a Block Kit message for the analysis
+func (s *SlackResponder) buildMessage(analysis *core.Analysis) map[string]interface{} {
+	text := fmt.Sprintf("*[%s] %s*\n%s\nSource: `%s` · Confidence: %.0f%%",
+		analysis.Severity, analysis.Type, analysis.Summary, analysis.Source, analysis.Confidence*100)
+
+	if values := formatDataPoints(s.metadata, analysis.DataPoints, 5); len(values) > 0 {
+		text += fmt.Sprintf("\nValues: `%s`", strings.Join(values, "`, `"))
+	}
+
+	if slackID, ok := analysis.Details["on_call_slack_id"].(string); ok && slackID != "" {
+		text += fmt.Sprintf("\nOn call: <@%s>", slackID)
+	} else if onCall, ok := analysis.Details["on_call"].(string); ok && onCall != "" {
+		text += fmt.Sprintf("\nOn call: %s", onCall)
+	}
+
+	blocks := []map[string]interface{}{
+		{
+			"type": "section",
+			"text": map[string]interface{}{"type": "mrkdwn", "text": text},
+		},
+	}
+
+	if analysis.ID != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "context",
+			"elements": []map[string]interface{}{{
+				"type": "mrkdwn",
+				"text": fmt.Sprintf("Analysis `%s` · Fingerprint `%s`", analysis.ID, analysis.Fingerprint),
+			}},
+		})
+	}
+
+	// Approval requests are answered with approve and reject buttons; resolved
+	// notifications have nothing left to acknowledge or silence
+	if remediationID, ok := analysis.Details["remediation_id"].(string); ok && analysis.Type == core.AnalysisTypeApprovalRequest {
+		blocks = append(blocks, map[string]interface{}{
+			"type":     "actions",
+			"block_id": "approval_actions",
+			"elements": s.buildApprovalActions(remediationID),
+		})
+	} else if incidentID, ok := analysis.Details["incident_id"].(string); ok && incidentID != "" && !analysis.Resolved {
+		blocks = append(blocks, map[string]interface{}{
+			"type":     "actions",
+			"block_id": "incident_actions",
+			"elements": s.buildActions(incidentID),
+		})
+	}
+
+	message := map[string]interface{}{
+		"text":   fmt.Sprintf("[%s] %s", analysis.Severity, analysis.Summary),
+		"blocks": blocks,
+	}
+	if s.channel != "" {
+		message["channel"] = s.channel
+	}
+	return message
+}
+
+// maxGroupAnalyses bounds how many analyses a group message lists
+const maxGroupAnalyses = 10
+
+// buildGroupMessage builds a Block Kit message listing the analyses of a group
+func (s *SlackResponder) buildGroupMessage(group *core.AnalysisGroup) map[string]interface{} {
+	highest := ""
+	for _, analysis := range group.Analyses {
+		if highest == "" || severityRank[analysis.Severity] > severityRank[highest] {
+			highest = analysis.Severity
+		}
+	}
+
+	names := make([]string, 0, len(group.Labels))
+	for name := range group.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	labels := make([]string, len(names))
+	for i, name := range names {
+		labels[i] = fmt.Sprintf("%s=%s", name, group.Labels[name])
+	}
+
+	title := fmt.Sprintf("%d analyses", len(group.Analyses))
+	if len(labels) > 0 {
+		title += fmt.Sprintf(" for %s", strings.Join(labels, ", "))
+	}
+
+	lines := []string{fmt.Sprintf("*[%s] %s*", highest, title)}
+	for i, analysis := range group.Analyses {
+		if i == maxGroupAnalyses {
+			lines = append(lines, fmt.Sprintf("…and %d more", len(group.Analyses)-maxGroupAnalyses))
+			break
+		}
+		line := fmt.Sprintf("• [%s] %s (`%s`)", analysis.Severity, analysis.Summary, analysis.Source)
+		if incidentID, ok := analysis.Details["incident_id"].(string); ok && incidentID != "" {
+			line += fmt.Sprintf(" · %s", incidentID)
+		}
+		lines = append(lines, line)
+	}
+
+	message := map[string]interface{}{
+		"text": fmt.Sprintf("[%s] %s", highest, title),
+		"blocks": []map[string]interface{}{{
+			"type": "section",
+			"text": map[string]interface{}{"type": "mrkdwn", "text": strings.Join(lines, "\n")},
+		}},
+	}
+	if s.channel != "" {
+		message["channel"] = s.channel
+	}
+	return message
+}
+
+// slackButton builds an interactive button carrying the value back to the framework
+func slackButton(actionID, label, style string, value core.SlackActionValue) map[string]interface{} {
+	encoded, _ := json.Marshal(value)
+	element := map[string]interface{}{
+		"type":      "button",
+		"action_id": actionID,
+		"text":      map[string]interface{}{"type": "plain_text", "text": label},
+		"value":     string(encoded),
+	}
+	if style != "" {
+		element["style"] = style
+	}
+	return element
+}
+
+// buildApprovalActions builds the buttons approving or rejecting a held remediation
+func (s *SlackResponder) buildApprovalActions(remediationID string) []map[string]interface{} {
+	value := core.SlackActionValue{RemediationID: remediationID}
+	return []map[string]interface{}{
+		slackButton(core.SlackActionApprove, "Approve", "primary", value),
+		slackButton(core.SlackActionReject, "Reject", "danger", value),
+	}
+}
+
+// buildActions builds the interactive buttons attached to an incident notification
+func (s *SlackResponder) buildActions(incidentID string) []map[string]interface{} {
+	value := core.SlackActionValue{IncidentID: incidentID}
+	actions := []map[string]interface{}{
+		slackButton(core.SlackActionAcknowledge, "Acknowledge", "primary", value),
+		slackButton(core.SlackActionSilence, "Silence 1h", "", value),
+	}
+
+	if s.runbookWorkflow != "" {
+		runbookValue := value
+		runbookValue.WorkflowID = s.runbookWorkflow
+		actions = append(actions, slackButton(core.SlackActionRunbook, "Run runbook", "danger", runbookValue))
+	}
+
+	return actions
+}
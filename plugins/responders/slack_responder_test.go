@@ -0,0 +1,126 @@
+package responders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackResponder_Configure(t *testing.T) {
+	responder := NewSlackResponder("test-slack")
+
+	err := responder.Configure(map[string]interface{}{})
+	assert.Error(t, err, "Expected error without webhook_url")
+
+	err = responder.Configure(map[string]interface{}{
+		"webhook_url":  "http://example.com/hook",
+		"min_severity": "bogus",
+	})
+	assert.Error(t, err, "Expected error for unknown severity")
+}
+
+func TestSlackResponder_RespondWithButtons(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	responder := NewSlackResponder("test-slack")
+	require.NoError(t, responder.Configure(map[string]interface{}{
+		"webhook_url":      server.URL,
+		"runbook_workflow": "restart-service",
+	}))
+
+	analysis := &core.Analysis{
+		Type:      core.AnalysisTypeAnomaly,
+		Severity:  "critical",
+		Summary:   "CPU at 99%",
+		Source:    "anomaly-analyzer",
+		Details:   map[string]interface{}{"incident_id": "INC-1"},
+		Timestamp: time.Now(),
+	}
+
+	assert.True(t, responder.CanHandle(analysis))
+	require.NoError(t, responder.Respond(context.Background(), analysis))
+
+	blocks := received["blocks"].([]interface{})
+	require.Len(t, blocks, 2, "Expected section and actions blocks")
+	elements := blocks[1].(map[string]interface{})["elements"].([]interface{})
+	assert.Len(t, elements, 3, "Expected ack, silence, and runbook buttons")
+
+	assert.False(t, responder.CanHandle(&core.Analysis{Severity: "low"}), "Expected low severity to be filtered")
+}
+
+func TestSlackResponder_ApprovalButtons(t *testing.T) {
+	responder := NewSlackResponder("test-slack")
+	require.NoError(t, responder.Configure(map[string]interface{}{"webhook_url": "http://example.com/hook"}))
+
+	message := responder.buildMessage(&core.Analysis{
+		Type:     core.AnalysisTypeApprovalRequest,
+		Severity: "high",
+		Summary:  "restart api needs approval (REM-3)",
+		Details:  map[string]interface{}{"remediation_id": "REM-3", "incident_id": "INC-1"},
+	})
+	blocks := message["blocks"].([]map[string]interface{})
+	require.Len(t, blocks, 2)
+	elements := blocks[1]["elements"].([]map[string]interface{})
+	require.Len(t, elements, 2, "Expected approve and reject buttons instead of the incident buttons")
+	assert.Equal(t, core.SlackActionApprove, elements[0]["action_id"])
+	assert.Equal(t, core.SlackActionReject, elements[1]["action_id"])
+
+	var value core.SlackActionValue
+	require.NoError(t, json.Unmarshal([]byte(elements[0]["value"].(string)), &value))
+	assert.Equal(t, "REM-3", value.RemediationID)
+}
+
+func TestSlackResponder_RespondGroup(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	responder := NewSlackResponder("test-slack")
+	require.NoError(t, responder.Configure(map[string]interface{}{"webhook_url": server.URL}))
+
+	group := &core.AnalysisGroup{
+		Key:    `root{service="api"}`,
+		Labels: map[string]string{"service": "api"},
+		Analyses: []*core.Analysis{
+			{Severity: "medium", Summary: "latency high", Source: "latency", Details: map[string]interface{}{"incident_id": "INC-1"}},
+			{Severity: "high", Summary: "errors high", Source: "errors", Details: map[string]interface{}{"incident_id": "INC-2"}},
+		},
+	}
+	require.NoError(t, responder.RespondGroup(context.Background(), group))
+
+	assert.Equal(t, "[high] 2 analyses for service=api", received["text"])
+	blocks := received["blocks"].([]interface{})
+	require.Len(t, blocks, 1)
+	text := blocks[0].(map[string]interface{})["text"].(map[string]interface{})["text"].(string)
+	assert.Contains(t, text, "latency high")
+	assert.Contains(t, text, "INC-2")
+}
+
+func TestSlackResponder_Simulate(t *testing.T) {
+	responder := NewSlackResponder("test-slack")
+	require.NoError(t, responder.Configure(map[string]interface{}{
+		"webhook_url": "http://example.invalid",
+		"channel":     "#ops",
+	}))
+
+	action, err := responder.Simulate(&core.Analysis{Severity: "high", Summary: "CPU at 99%", Source: "anomaly"})
+	require.NoError(t, err)
+	assert.Equal(t, "post_message", action.Action)
+	assert.Equal(t, "#ops", action.Target)
+	assert.Equal(t, "[high] CPU at 99%", action.Payload.(map[string]interface{})["text"])
+}
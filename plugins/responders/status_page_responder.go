@@ -0,0 +1,225 @@
+package responders
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+)
+
+// StatusPageResponder implements the DataResponder interface by keeping a static HTML
+// status page up to date on disk. The page is rewritten on an interval and whenever an
+// analysis arrives, so it can be synced to static hosting such as S3.
+type StatusPageResponder struct {
+	name     string
+	version  string
+	status   core.PluginStatus
+	path     string
+	interval time.Duration
+	provider core.StatusPageProvider
+	cancel   context.CancelFunc
+	done     chan struct{}
+	writeMu  sync.Mutex
+	mu       sync.RWMutex
+}
+
+// NewStatusPageResponder creates a new status page responder plugin
+func NewStatusPageResponder(name string) *StatusPageResponder {
+	return &StatusPageResponder{
+		name:     name,
+		version:  "1.0.0",
+		status:   core.PluginStatusStopped,
+		interval: time.Minute,
+	}
+}
+
+// Name returns the name of the plugin
+func (s *StatusPageResponder) Name() string {
+	return s.name
+}
+
+// Type returns the type of plugin
+func (s *StatusPageResponder) Type() core.PluginType {
+	return core.PluginTypeResponder
+}
+
+// Version returns the plugin version
+func (s *StatusPageResponder) Version() string {
+	return s.version
+}
+
+// Configure initializes the plugin with configuration
+func (s *StatusPageResponder) Configure(config map[string]interface{}) error {
+	path, ok := config["path"].(string)
+	if !ok || path == "" {
+		return fmt.Errorf("status page path not specified")
+	}
+	s.path = path
+
+	if intervalStr, ok := config["interval"].(string); ok {
+		interval, err := time.ParseDuration(intervalStr)
+		if err != nil {
+			return fmt.Errorf("invalid interval %q: %w", intervalStr, err)
+		}
+		if interval <= 0 {
+			return fmt.Errorf("interval must be positive, got %v", interval)
+		}
+		s.interval = interval
+	}
+
+	return nil
+}
+
+// SetStatusPageProvider provides the source of the status page
+func (s *StatusPageResponder) SetStatusPageProvider(provider core.StatusPageProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.provider = provider
+}
+
+// Start begins the plugin's operation
+func (s *StatusPageResponder) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status == core.PluginStatusRunning || s.status == core.PluginStatusStopping {
+		return fmt.Errorf("responder is already running")
+	}
+	if s.provider == nil {
+		return fmt.Errorf("no status page provider set")
+	}
+
+	s.status = core.PluginStatusStarting
+	slog.Info("Starting status page responder", "plugin", s.name, "type", s.Type(), "path", s.path)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	go s.run(runCtx, s.provider, s.done)
+
+	s.status = core.PluginStatusRunning
+	slog.Info("Status page responder started", "plugin", s.name, "type", s.Type())
+	return nil
+}
+
+// Stop gracefully stops the plugin. The lock is released while waiting for a write in
+// flight, since rendering the page asks every plugin for its health, this one included.
+func (s *StatusPageResponder) Stop() error {
+	s.mu.Lock()
+	if s.status != core.PluginStatusRunning {
+		s.mu.Unlock()
+		return fmt.Errorf("responder is not running")
+	}
+
+	s.status = core.PluginStatusStopping
+	cancel, done := s.cancel, s.done
+	s.mu.Unlock()
+	slog.Info("Stopping status page responder", "plugin", s.name, "type", s.Type())
+
+	cancel()
+	<-done
+
+	s.mu.Lock()
+	s.status = core.PluginStatusStopped
+	s.mu.Unlock()
+	slog.Info("Status page responder stopped", "plugin", s.name, "type", s.Type())
+	return nil
+}
+
+// Status returns the current status of the plugin
+func (s *StatusPageResponder) Status() core.PluginStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// Health checks if the plugin is healthy
+func (s *StatusPageResponder) Health(ctx context.Context) error {
+	if s.Status() == core.PluginStatusRunning {
+		return nil
+	}
+	return fmt.Errorf("responder is not running")
+}
+
+// GetCapabilities returns what this plugin can do
+func (s *StatusPageResponder) GetCapabilities() []string {
+	return []string{
+		"status_page",
+		"static_html",
+	}
+}
+
+// Respond refreshes the page so new incidents appear without waiting for the interval
+func (s *StatusPageResponder) Respond(ctx context.Context, analysis *core.Analysis) error {
+	s.mu.RLock()
+	provider := s.provider
+	s.mu.RUnlock()
+
+	if provider == nil {
+		return fmt.Errorf("no status page provider set")
+	}
+	return s.write(ctx, provider)
+}
+
+// CanHandle determines if this responder can handle the given analysis
+func (s *StatusPageResponder) CanHandle(analysis *core.Analysis) bool {
+	return true
+}
+
+// run writes the page immediately and then on every interval until the context is done
+func (s *StatusPageResponder) run(ctx context.Context, provider core.StatusPageProvider, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.write(ctx, provider); err != nil {
+			slog.Error("Failed to write status page", "plugin", s.name, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// write renders the page and replaces the file atomically so readers never see a partial page
+func (s *StatusPageResponder) write(ctx context.Context, provider core.StatusPageProvider) error {
+	var buf bytes.Buffer
+	if err := core.RenderStatusPage(&buf, provider.StatusPage(ctx)); err != nil {
+		return err
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".status-*.html")
+	if err != nil {
+		return fmt.Errorf("failed to create status page: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write status page: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write status page: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write status page: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace status page: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package responders
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/habruzzo/agent/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStatusPageProvider struct {
+	page core.StatusPage
+}
+
+func (f *fakeStatusPageProvider) StatusPage(ctx context.Context) core.StatusPage {
+	return f.page
+}
+
+// healthCheckingProvider renders the page like the framework does, asking the responder for
+// its health, once released
+type healthCheckingProvider struct {
+	responder *StatusPageResponder
+	rendering chan struct{}
+	release   chan struct{}
+}
+
+func (p *healthCheckingProvider) StatusPage(ctx context.Context) core.StatusPage {
+	p.rendering <- struct{}{}
+	<-p.release
+	p.responder.Health(ctx)
+	return core.StatusPage{Title: "Agent Status", GeneratedAt: time.Now()}
+}
+
+func TestStatusPageResponder_StopDuringWrite(t *testing.T) {
+	responder := NewStatusPageResponder("status-page")
+	require.NoError(t, responder.Configure(map[string]interface{}{"path": filepath.Join(t.TempDir(), "status.html"), "interval": "1h"}))
+	provider := &healthCheckingProvider{responder: responder, rendering: make(chan struct{}, 1), release: make(chan struct{})}
+	responder.SetStatusPageProvider(provider)
+	require.NoError(t, responder.Start(context.Background()))
+	<-provider.rendering
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- responder.Stop() }()
+	require.Eventually(t, func() bool { return responder.Status() == core.PluginStatusStopping }, time.Second, time.Millisecond)
+	close(provider.release)
+
+	select {
+	case err := <-stopped:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Stop to return while a write asks the responder for its health")
+	}
+	assert.Equal(t, core.PluginStatusStopped, responder.Status())
+}
+
+func TestStatusPageResponder_WritesPage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.html")
+
+	responder := NewStatusPageResponder("status-page")
+	assert.Error(t, responder.Configure(map[string]interface{}{}), "Expected path to be required")
+	require.NoError(t, responder.Configure(map[string]interface{}{"path": path, "interval": "1h"}))
+
+	provider := &fakeStatusPageProvider{page: core.StatusPage{Title: "Agent Status", Running: true, GeneratedAt: time.Now()}}
+	responder.SetStatusPageProvider(provider)
+	require.NoError(t, responder.Start(context.Background()))
+	defer responder.Stop()
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 10*time.Millisecond, "Expected the page to be written on start")
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "All systems operational")
+
+	// A new analysis refreshes the page without waiting for the interval
+	provider.page.Incidents = []core.Incident{{ID: "INC-1", Summary: "cpu spike", Severity: "high", Status: core.IncidentStatusOpen}}
+	require.NoError(t, responder.Respond(context.Background(), &core.Analysis{Severity: "high"}))
+
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "INC-1")
+	assert.Contains(t, string(content), "1 open incident(s)")
+}